@@ -74,12 +74,19 @@ func NewChannel(
 }
 
 // FromContext retrieves the Channel from the context.
-// Panics if no Channel is present -- this indicates a programming error
-// (the handler is being called outside the channel runtime).
-func FromContext(ctx context.Context) *Channel {
+// Returns (nil, false) if no Channel is present.
+func FromContext(ctx context.Context) (*Channel, bool) {
 	ch, ok := ctx.Value(contextKey{}).(*Channel)
-	if !ok || ch == nil {
-		panic("channel.FromContext: no Channel in context -- is this handler running inside the channel runtime?")
+	return ch, ok && ch != nil
+}
+
+// MustFromContext is like FromContext but panics when no Channel is present
+// -- this indicates a programming error (the handler is being called outside
+// the channel runtime).
+func MustFromContext(ctx context.Context) *Channel {
+	ch, ok := FromContext(ctx)
+	if !ok {
+		panic("channel.MustFromContext: no Channel in context -- is this handler running inside the channel runtime?")
 	}
 	return ch
 }
@@ -97,9 +104,18 @@ func WithDB(ctx context.Context, db *sql.DB) context.Context {
 }
 
 // DBFromContext retrieves the *sql.DB from the context.
-// Returns nil if no DB is present (e.g., in tests without a database).
-func DBFromContext(ctx context.Context) *sql.DB {
-	db, _ := ctx.Value(dbContextKey{}).(*sql.DB)
+// Returns (nil, false) if no DB is present (e.g., in tests without a database).
+func DBFromContext(ctx context.Context) (*sql.DB, bool) {
+	db, ok := ctx.Value(dbContextKey{}).(*sql.DB)
+	return db, ok && db != nil
+}
+
+// MustDBFromContext is like DBFromContext but panics when no DB is present.
+func MustDBFromContext(ctx context.Context) *sql.DB {
+	db, ok := DBFromContext(ctx)
+	if !ok {
+		panic("channel.MustDBFromContext: no *sql.DB in context -- is this code running under WrapDispatchHandler?")
+	}
 	return db
 }
 
@@ -109,9 +125,20 @@ func WithAccountID(ctx context.Context, id int64) context.Context {
 }
 
 // AccountIDFromContext retrieves the account ID from the context.
-// Returns 0 if not present (e.g., public channels).
-func AccountIDFromContext(ctx context.Context) int64 {
-	id, _ := ctx.Value(accountIDKey{}).(int64)
+// Returns (0, false) if not present (e.g., public channels).
+func AccountIDFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(accountIDKey{}).(int64)
+	return id, ok
+}
+
+// MustAccountIDFromContext is like AccountIDFromContext but panics when no
+// account ID is present. Public channels have none and should use
+// AccountIDFromContext instead.
+func MustAccountIDFromContext(ctx context.Context) int64 {
+	id, ok := AccountIDFromContext(ctx)
+	if !ok {
+		panic("channel.MustAccountIDFromContext: no account ID in context -- is the channel public, or was the job dispatched without WrapDispatchHandler?")
+	}
 	return id
 }
 
@@ -121,9 +148,19 @@ func WithOrgID(ctx context.Context, id int64) context.Context {
 }
 
 // OrgIDFromContext retrieves the org ID from the context.
-// Returns 0 if not present.
-func OrgIDFromContext(ctx context.Context) int64 {
-	id, _ := ctx.Value(orgIDKey{}).(int64)
+// Returns (0, false) if not present.
+func OrgIDFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(orgIDKey{}).(int64)
+	return id, ok
+}
+
+// MustOrgIDFromContext is like OrgIDFromContext but panics when no org ID is
+// present.
+func MustOrgIDFromContext(ctx context.Context) int64 {
+	id, ok := OrgIDFromContext(ctx)
+	if !ok {
+		panic("channel.MustOrgIDFromContext: no org ID in context -- was the job dispatched without WrapDispatchHandler?")
+	}
 	return id
 }
 
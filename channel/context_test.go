@@ -4,20 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
-func TestFromContext_Panics_WhenMissing(t *testing.T) {
+func TestFromContext_ReportsMissing(t *testing.T) {
+	if ch, ok := FromContext(context.Background()); ok || ch != nil {
+		t.Fatalf("FromContext on bare context = (%v, %v), want (nil, false)", ch, ok)
+	}
+}
+
+func TestMustFromContext_Panics_WhenMissing(t *testing.T) {
 	defer func() {
 		r := recover()
 		if r == nil {
-			t.Fatal("expected panic when FromContext called on bare context")
+			t.Fatal("expected panic when MustFromContext called on bare context")
 		}
 	}()
-	FromContext(context.Background())
+	MustFromContext(context.Background())
 }
 
 func TestWithChannel_RoundTrips(t *testing.T) {
@@ -28,8 +35,8 @@ func TestWithChannel_RoundTrips(t *testing.T) {
 	ch := NewChannel("test", "job-1", 100, 200, false, recorder, incoming, cleanup)
 	ctx := WithChannel(context.Background(), ch)
 
-	got := FromContext(ctx)
-	if got != ch {
+	got, ok := FromContext(ctx)
+	if !ok || got != ch {
 		t.Errorf("expected same pointer from FromContext, got different")
 	}
 }
@@ -37,7 +44,7 @@ func TestWithChannel_RoundTrips(t *testing.T) {
 func TestChannel_Send_PublishesEnvelope(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	data, _ := json.Marshal(map[string]string{"token": "abc123"})
 	if err := ch.Send(ctx, "Token", data); err != nil {
@@ -63,7 +70,7 @@ func TestChannel_Send_PublishesEnvelope(t *testing.T) {
 func TestChannel_Receive_ReturnsMatchingType(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Enqueue a message of the type we're waiting for.
 	recorder.EnqueueIncoming("Approval", map[string]bool{"approved": true})
@@ -85,7 +92,7 @@ func TestChannel_Receive_ReturnsMatchingType(t *testing.T) {
 func TestChannel_Receive_BuffersNonMatchingMessages(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Enqueue 3 different types. We want "type3".
 	recorder.EnqueueIncoming("type1", map[string]int{"v": 1})
@@ -169,7 +176,7 @@ func TestChannel_Receive_ChecksBufferFirst(t *testing.T) {
 func TestChannel_Receive_ContextCancellation(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Create a cancellable context.
 	cancelCtx, cancel := context.WithCancel(ctx)
@@ -189,7 +196,7 @@ func TestChannel_Receive_ContextCancellation(t *testing.T) {
 func TestChannel_Receive_ContextTimeout(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
 	defer cancel()
@@ -206,7 +213,7 @@ func TestChannel_Receive_ContextTimeout(t *testing.T) {
 func TestChannel_ReceiveAny_ReturnsFirstMessage(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	recorder.EnqueueIncoming("Greeting", map[string]string{"hello": "world"})
 
@@ -261,7 +268,7 @@ func TestChannel_ReceiveAny_ReturnsBufferedFirst(t *testing.T) {
 func TestChannel_ReceiveAny_ContextCancellation(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	cancelCtx, cancel := context.WithCancel(ctx)
 	cancel()
@@ -341,7 +348,7 @@ func TestChannel_JobID(t *testing.T) {
 func TestChannel_Send_MultipleTimes(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	for i := 0; i < 5; i++ {
 		data, _ := json.Marshal(map[string]int{"i": i})
@@ -403,53 +410,106 @@ func TestWithDB_RoundTrip(t *testing.T) {
 	defer db.Close()
 
 	ctx := WithDB(context.Background(), db)
-	got := DBFromContext(ctx)
-	if got != db {
+	got, ok := DBFromContext(ctx)
+	if !ok || got != db {
 		t.Error("expected same *sql.DB pointer from DBFromContext")
 	}
+	if MustDBFromContext(ctx) != db {
+		t.Error("expected same *sql.DB pointer from MustDBFromContext")
+	}
 }
 
 func TestDBFromContext_Missing(t *testing.T) {
-	got := DBFromContext(context.Background())
-	if got != nil {
-		t.Errorf("expected nil from DBFromContext on bare context, got %v", got)
+	got, ok := DBFromContext(context.Background())
+	if ok || got != nil {
+		t.Errorf("expected (nil, false) from DBFromContext on bare context, got (%v, %v)", got, ok)
 	}
 }
 
+func TestDBFromContext_NilDB(t *testing.T) {
+	if _, ok := DBFromContext(WithDB(context.Background(), nil)); ok {
+		t.Error("expected ok=false when a nil *sql.DB was injected")
+	}
+}
+
+func TestMustDBFromContext_Panics_WhenMissing(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expected panic when MustDBFromContext called on bare context")
+		}
+		if msg, _ := r.(string); !strings.Contains(msg, "WrapDispatchHandler") {
+			t.Errorf("panic message should name the missing middleware, got %v", r)
+		}
+	}()
+	MustDBFromContext(context.Background())
+}
+
 // ── WithAccountID / AccountIDFromContext tests ────────────────────────────────
 
 func TestWithAccountID_RoundTrip(t *testing.T) {
 	ctx := WithAccountID(context.Background(), 42)
-	got := AccountIDFromContext(ctx)
-	if got != 42 {
-		t.Errorf("AccountIDFromContext: got %d, want 42", got)
+	got, ok := AccountIDFromContext(ctx)
+	if !ok || got != 42 {
+		t.Errorf("AccountIDFromContext: got (%d, %v), want (42, true)", got, ok)
+	}
+	if got := MustAccountIDFromContext(ctx); got != 42 {
+		t.Errorf("MustAccountIDFromContext: got %d, want 42", got)
 	}
 }
 
 func TestAccountIDFromContext_Missing(t *testing.T) {
-	got := AccountIDFromContext(context.Background())
-	if got != 0 {
-		t.Errorf("expected 0 from AccountIDFromContext on bare context, got %d", got)
+	got, ok := AccountIDFromContext(context.Background())
+	if ok || got != 0 {
+		t.Errorf("expected (0, false) from AccountIDFromContext on bare context, got (%d, %v)", got, ok)
+	}
+}
+
+func TestAccountIDFromContext_ZeroIsPresent(t *testing.T) {
+	got, ok := AccountIDFromContext(WithAccountID(context.Background(), 0))
+	if !ok || got != 0 {
+		t.Errorf("expected (0, true) for an explicitly injected zero ID, got (%d, %v)", got, ok)
 	}
 }
 
+func TestMustAccountIDFromContext_Panics_WhenMissing(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic when MustAccountIDFromContext called on bare context")
+		}
+	}()
+	MustAccountIDFromContext(context.Background())
+}
+
 // ── WithOrgID / OrgIDFromContext tests ────────────────────────────────────────
 
 func TestWithOrgID_RoundTrip(t *testing.T) {
 	ctx := WithOrgID(context.Background(), 99)
-	got := OrgIDFromContext(ctx)
-	if got != 99 {
-		t.Errorf("OrgIDFromContext: got %d, want 99", got)
+	got, ok := OrgIDFromContext(ctx)
+	if !ok || got != 99 {
+		t.Errorf("OrgIDFromContext: got (%d, %v), want (99, true)", got, ok)
+	}
+	if got := MustOrgIDFromContext(ctx); got != 99 {
+		t.Errorf("MustOrgIDFromContext: got %d, want 99", got)
 	}
 }
 
 func TestOrgIDFromContext_Missing(t *testing.T) {
-	got := OrgIDFromContext(context.Background())
-	if got != 0 {
-		t.Errorf("expected 0 from OrgIDFromContext on bare context, got %d", got)
+	got, ok := OrgIDFromContext(context.Background())
+	if ok || got != 0 {
+		t.Errorf("expected (0, false) from OrgIDFromContext on bare context, got (%d, %v)", got, ok)
 	}
 }
 
+func TestMustOrgIDFromContext_Panics_WhenMissing(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic when MustOrgIDFromContext called on bare context")
+		}
+	}()
+	MustOrgIDFromContext(context.Background())
+}
+
 func TestChannel_Receive_ClosedIncoming(t *testing.T) {
 	recorder := NewTestRecorder()
 	// Create our own incoming channel so we can close it.
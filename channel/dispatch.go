@@ -64,7 +64,7 @@ func WithSetup(fn func(context.Context) context.Context) DispatchOption {
 // WithDispatchDB returns a DispatchOption that provides a *sql.DB to inject
 // into the handler context. This is primarily used with WrapDispatchHandlerWithUpdater,
 // which passes nil for the db parameter internally — without this option,
-// DBFromContext(ctx) would return nil inside Setup and handler functions.
+// DBFromContext(ctx) would report no DB inside Setup and handler functions.
 //
 // Usage in generated worker code:
 //
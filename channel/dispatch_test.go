@@ -91,7 +91,7 @@ func TestWrapDispatchHandler_CallsHandler(t *testing.T) {
 		receivedPrompt = req.Prompt
 
 		// Verify the channel is available in context
-		ch := FromContext(ctx)
+		ch := MustFromContext(ctx)
 		if ch == nil {
 			t.Error("expected Channel in context")
 		}
@@ -329,7 +329,7 @@ func TestWrapDispatchHandler_PublicChannel(t *testing.T) {
 	var capturedChannel *Channel
 
 	handler := func(ctx context.Context, req *testRequest) error {
-		capturedChannel = FromContext(ctx)
+		capturedChannel = MustFromContext(ctx)
 		return nil
 	}
 
@@ -532,7 +532,7 @@ func TestWrapDispatchHandler_WithSetup_ChannelAvailableInSetup(t *testing.T) {
 
 	setupFn := func(ctx context.Context) context.Context {
 		// The Channel should already be in the context when Setup is called
-		channelInSetup = FromContext(ctx)
+		channelInSetup = MustFromContext(ctx)
 		return ctx
 	}
 
@@ -614,7 +614,7 @@ func TestWrapDispatchHandler_InjectsDB(t *testing.T) {
 	}
 
 	setupFn := func(ctx context.Context) context.Context {
-		capturedDB = DBFromContext(ctx)
+		capturedDB, _ = DBFromContext(ctx)
 		return ctx
 	}
 
@@ -660,8 +660,8 @@ func TestWrapDispatchHandler_InjectsAccountID(t *testing.T) {
 	}
 
 	setupFn := func(ctx context.Context) context.Context {
-		capturedAccountID = AccountIDFromContext(ctx)
-		capturedOrgID = OrgIDFromContext(ctx)
+		capturedAccountID, _ = AccountIDFromContext(ctx)
+		capturedOrgID, _ = OrgIDFromContext(ctx)
 		return ctx
 	}
 
@@ -706,9 +706,9 @@ func TestWrapDispatchHandler_InjectsDBIntoHandler(t *testing.T) {
 	var handlerOrgID int64
 
 	handler := func(ctx context.Context, req *testRequest) error {
-		handlerDB = DBFromContext(ctx)
-		handlerAccountID = AccountIDFromContext(ctx)
-		handlerOrgID = OrgIDFromContext(ctx)
+		handlerDB, _ = DBFromContext(ctx)
+		handlerAccountID, _ = AccountIDFromContext(ctx)
+		handlerOrgID, _ = OrgIDFromContext(ctx)
 		return nil
 	}
 
@@ -762,7 +762,7 @@ func TestWrapDispatchHandler_BackwardsCompatible(t *testing.T) {
 		handlerCalled = true
 		receivedPrompt = req.Prompt
 		// Only access Channel (the pre-existing context value).
-		ch := FromContext(ctx)
+		ch := MustFromContext(ctx)
 		if ch.Name() != "chatbot" {
 			t.Errorf("expected channel name 'chatbot', got %q", ch.Name())
 		}
@@ -952,7 +952,7 @@ func TestWrapDispatchHandlerWithUpdater_WithDispatchDB_InjectsDBIntoSetup(t *tes
 	}
 
 	setupFn := func(ctx context.Context) context.Context {
-		capturedDB = DBFromContext(ctx)
+		capturedDB, _ = DBFromContext(ctx)
 		return ctx
 	}
 
@@ -1002,7 +1002,7 @@ func TestWrapDispatchHandlerWithUpdater_WithDispatchDB_InjectsDBIntoHandler(t *t
 	var handlerDB *sql.DB
 
 	handler := func(ctx context.Context, req *testRequest) error {
-		handlerDB = DBFromContext(ctx)
+		handlerDB, _ = DBFromContext(ctx)
 		return nil
 	}
 
@@ -1049,7 +1049,7 @@ func TestWrapDispatchHandlerWithUpdater_WithoutDispatchDB_DBIsNil(t *testing.T)
 	capturedDBSet := false
 
 	handler := func(ctx context.Context, req *testRequest) error {
-		capturedDB = DBFromContext(ctx)
+		capturedDB, _ = DBFromContext(ctx)
 		capturedDBSet = true
 		return nil
 	}
@@ -1097,7 +1097,7 @@ func TestWrapDispatchHandler_DBParamTakesPrecedence(t *testing.T) {
 	var capturedDB *sql.DB
 
 	handler := func(ctx context.Context, req *testRequest) error {
-		capturedDB = DBFromContext(ctx)
+		capturedDB, _ = DBFromContext(ctx)
 		return nil
 	}
 
@@ -1140,7 +1140,7 @@ func TestWithDispatchDB_OverridesNilDBParam(t *testing.T) {
 	var capturedDB *sql.DB
 
 	handler := func(ctx context.Context, req *testRequest) error {
-		capturedDB = DBFromContext(ctx)
+		capturedDB, _ = DBFromContext(ctx)
 		return nil
 	}
 
@@ -0,0 +1,168 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// OutboxEvent is a domain event recorded in the outbox table by the
+// transaction that changed the data it describes.
+type OutboxEvent struct {
+	ID          int64           // outbox row id; events are published in id order
+	PublicID    string          // stable event id, the same on every delivery attempt
+	Topic       string          // e.g. the table name, "posts"
+	EventType   string          // e.g. "post.created"
+	AggregateID string          // public id of the row the event is about
+	Payload     json.RawMessage // JSON body of the event
+	Attempts    int             // failed publish attempts so far
+}
+
+// OutboxSink publishes outbox events to a message broker (Kafka, NATS, SNS,
+// a webhook, ...). Publish must return only once the broker has accepted
+// the event; any error leaves the event in the outbox to be retried.
+//
+// Delivery is at-least-once: an event is published again if the relay
+// stops between Publish and marking the event published, so sinks should
+// pass PublicID to the broker as a deduplication key (e.g. the Nats-Msg-Id
+// header or the Kafka record key) and consumers should ignore repeats.
+type OutboxSink interface {
+	Publish(ctx context.Context, event OutboxEvent) error
+}
+
+// OutboxSinkFunc adapts a function to the OutboxSink interface.
+type OutboxSinkFunc func(ctx context.Context, event OutboxEvent) error
+
+// Publish calls f(ctx, event).
+func (f OutboxSinkFunc) Publish(ctx context.Context, event OutboxEvent) error {
+	return f(ctx, event)
+}
+
+// LogOutboxSink returns a sink that only logs each event. It is the
+// default sink until a broker is wired up, so events drain from the
+// outbox instead of accumulating.
+func LogOutboxSink(logger *slog.Logger) OutboxSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return OutboxSinkFunc(func(_ context.Context, e OutboxEvent) error {
+		logger.Info("outbox event", "id", e.PublicID, "topic", e.Topic, "type", e.EventType, "aggregate_id", e.AggregateID)
+		return nil
+	})
+}
+
+// OutboxStore reads and updates the outbox table. The generated worker
+// implements it with the outbox querydefs.
+type OutboxStore interface {
+	// Pending returns up to limit unpublished events that have failed
+	// fewer than maxAttempts times, ordered by id.
+	Pending(ctx context.Context, limit, maxAttempts int) ([]OutboxEvent, error)
+
+	// MarkPublished records that the event was accepted by the sink.
+	MarkPublished(ctx context.Context, id int64) error
+
+	// MarkFailed records a failed publish attempt.
+	MarkFailed(ctx context.Context, id int64, attempts int, errMsg string) error
+}
+
+// Default OutboxRelay settings.
+const (
+	DefaultOutboxBatchSize   = 100
+	DefaultOutboxMaxAttempts = 10
+)
+
+// OutboxRelay moves events from the outbox to a sink.
+type OutboxRelay struct {
+	Store       OutboxStore
+	Sink        OutboxSink
+	BatchSize   int // events read per run; 0 means DefaultOutboxBatchSize
+	MaxAttempts int // failures after which an event is left for inspection; 0 means DefaultOutboxMaxAttempts
+	Logger      *slog.Logger
+}
+
+// RunOnce publishes one batch of pending events in id order. The first
+// event the sink rejects is recorded as failed and ends the batch, so a
+// broker outage doesn't burn through the attempts of every pending event
+// and later events are not published ahead of it. Events that have failed
+// MaxAttempts times stay in the outbox, with their last error, and are no
+// longer retried.
+func (r *OutboxRelay) RunOnce(ctx context.Context) error {
+	_, err := r.runBatch(ctx)
+	return err
+}
+
+// Run publishes pending events every interval until ctx is cancelled.
+// After a full batch the next one starts right away, so a backlog drains
+// without waiting out the interval. Failures are logged and retried on
+// the next tick.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		full, err := r.runBatch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("outbox relay failed", "error", err.Error())
+		}
+		if full && err == nil {
+			timer.Reset(0)
+		} else {
+			timer.Reset(interval)
+		}
+	}
+}
+
+// runBatch implements RunOnce, also reporting whether the batch was full.
+func (r *OutboxRelay) runBatch(ctx context.Context) (full bool, err error) {
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultOutboxBatchSize
+	}
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultOutboxMaxAttempts
+	}
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	events, err := r.Store.Pending(ctx, batchSize, maxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("outbox: load pending events: %w", err)
+	}
+	for _, e := range events {
+		if err := r.Sink.Publish(ctx, e); err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			attempts := e.Attempts + 1
+			if markErr := r.Store.MarkFailed(ctx, e.ID, attempts, err.Error()); markErr != nil {
+				return false, fmt.Errorf("outbox: record failure of event %s: %w", e.PublicID, markErr)
+			}
+			if attempts >= maxAttempts {
+				logger.Error("outbox event abandoned", "id", e.PublicID, "type", e.EventType, "attempts", attempts, "error", err.Error())
+			}
+			return false, fmt.Errorf("outbox: publish event %s: %w", e.PublicID, err)
+		}
+		if err := r.Store.MarkPublished(ctx, e.ID); err != nil {
+			// The event was delivered; it will be delivered again with the
+			// same PublicID, which consumers deduplicate.
+			return false, fmt.Errorf("outbox: mark event %s published: %w", e.PublicID, err)
+		}
+	}
+	return len(events) == batchSize, nil
+}
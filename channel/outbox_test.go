@@ -0,0 +1,142 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memOutbox is an in-memory OutboxStore.
+type memOutbox struct {
+	events    []OutboxEvent
+	published map[int64]bool
+	lastError map[int64]string
+}
+
+func newMemOutbox(n int) *memOutbox {
+	m := &memOutbox{published: map[int64]bool{}, lastError: map[int64]string{}}
+	for i := 1; i <= n; i++ {
+		m.events = append(m.events, OutboxEvent{ID: int64(i), PublicID: string(rune('a' + i - 1)), EventType: "post.created"})
+	}
+	return m
+}
+
+func (m *memOutbox) Pending(_ context.Context, limit, maxAttempts int) ([]OutboxEvent, error) {
+	var out []OutboxEvent
+	for _, e := range m.events {
+		if !m.published[e.ID] && e.Attempts < maxAttempts && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *memOutbox) MarkPublished(_ context.Context, id int64) error {
+	m.published[id] = true
+	return nil
+}
+
+func (m *memOutbox) MarkFailed(_ context.Context, id int64, attempts int, errMsg string) error {
+	m.events[id-1].Attempts = attempts
+	m.lastError[id] = errMsg
+	return nil
+}
+
+func TestOutboxRelay_RunOnce(t *testing.T) {
+	store := newMemOutbox(3)
+	var sent []string
+	down := true
+	sink := OutboxSinkFunc(func(_ context.Context, e OutboxEvent) error {
+		if e.ID == 2 && down {
+			return errors.New("broker unavailable")
+		}
+		sent = append(sent, e.PublicID)
+		return nil
+	})
+	relay := &OutboxRelay{Store: store, Sink: sink, BatchSize: 10}
+
+	// The failing event ends the batch; the one after it waits.
+	if err := relay.RunOnce(context.Background()); err == nil {
+		t.Fatal("RunOnce with a failing sink returned nil error")
+	}
+	if want := []string{"a"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent = %q, want %q", sent, want)
+	}
+	if store.events[1].Attempts != 1 || store.lastError[2] != "broker unavailable" {
+		t.Errorf("failure not recorded: attempts = %d, last error = %q", store.events[1].Attempts, store.lastError[2])
+	}
+
+	down = false
+	if err := relay.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent = %q, want %q", sent, want)
+	}
+
+	// Nothing is left to publish.
+	if err := relay.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if len(sent) != 3 {
+		t.Errorf("published events were sent again: %q", sent)
+	}
+}
+
+func TestOutboxRelay_MaxAttempts(t *testing.T) {
+	store := newMemOutbox(2)
+	var sent []string
+	sink := OutboxSinkFunc(func(_ context.Context, e OutboxEvent) error {
+		if e.ID == 1 {
+			return errors.New("rejected")
+		}
+		sent = append(sent, e.PublicID)
+		return nil
+	})
+	relay := &OutboxRelay{Store: store, Sink: sink, MaxAttempts: 2}
+
+	for range 3 {
+		relay.RunOnce(context.Background())
+	}
+	if store.events[0].Attempts != 2 {
+		t.Errorf("attempts = %d, want 2 (retries stop at MaxAttempts)", store.events[0].Attempts)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent = %q, want %q", sent, want)
+	}
+	if store.published[1] {
+		t.Error("abandoned event was marked published")
+	}
+}
+
+func TestOutboxRelay_RunDrainsBacklog(t *testing.T) {
+	store := newMemOutbox(3)
+	var sent atomic.Int32
+	sink := OutboxSinkFunc(func(context.Context, OutboxEvent) error {
+		sent.Add(1)
+		return nil
+	})
+	relay := &OutboxRelay{Store: store, Sink: sink, BatchSize: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	// Full batches are followed immediately by the next, so all three
+	// events go out long before the hour-long interval.
+	deadline := time.Now().Add(5 * time.Second)
+	for sent.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if n := sent.Load(); n != 3 {
+		t.Errorf("sent %d events, want 3", n)
+	}
+}
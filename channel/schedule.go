@@ -0,0 +1,186 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a recurring job runs next.
+type Schedule interface {
+	// Next returns the first activation time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule parses a cron-style schedule: a standard 5-field cron
+// expression ("minute hour day-of-month month day-of-week", supporting *,
+// lists, ranges and */n steps) or one of the descriptors "@hourly",
+// "@daily", "@weekly" and "@every <duration>". Times are evaluated in UTC.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("schedule %q: interval must be at least 1s", spec)
+		}
+		return everySchedule(d), nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: expected 5 cron fields or an @descriptor", spec)
+	}
+	var cs cronSchedule
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	sets := [5]*uint64{&cs.minute, &cs.hour, &cs.dom, &cs.month, &cs.dow}
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		*sets[i] = set
+	}
+	cs.domStar = fields[2] == "*"
+	cs.dowStar = fields[4] == "*"
+	return cs, nil
+}
+
+// parseCronField parses one cron field into a bitset of allowed values.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// everySchedule runs at a fixed interval.
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// cronSchedule is a parsed 5-field cron expression.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+func (s cronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Every valid expression matches within a few years (Feb 29 included).
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); t = t.Add(time.Minute) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Minute)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC).Add(-time.Minute)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour - time.Minute)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) != 0 {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's day rule: when both day-of-month and
+// day-of-week are restricted, either may match.
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// StartSchedule parses spec and runs fn in a background goroutine at each
+// activation until ctx is cancelled. Runs never overlap: an activation that
+// falls while fn is still running is skipped. Errors from fn are logged and
+// do not stop the schedule. A malformed spec is returned immediately so
+// the worker fails at startup rather than silently never running the job.
+func StartSchedule(ctx context.Context, name, spec string, fn func(context.Context) error, logger *slog.Logger) error {
+	sched, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	go func() {
+		for {
+			next := sched.Next(time.Now())
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			start := time.Now()
+			if err := fn(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Error("scheduled job failed", "job", name, "error", err.Error())
+				continue
+			}
+			logger.Info("scheduled job finished", "job", name, "duration_ms", time.Since(start).Milliseconds())
+		}
+	}()
+	return nil
+}
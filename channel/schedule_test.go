@@ -0,0 +1,70 @@
+package channel
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	base := time.Date(2026, 3, 14, 10, 7, 30, 0, time.UTC) // a Saturday
+
+	for _, tc := range []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * 1-5", time.Date(2026, 3, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 9 1,15 * *", time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	} {
+		sched, err := ParseSchedule(tc.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) error = %v", tc.spec, err)
+			continue
+		}
+		if got := sched.Next(base); !got.Equal(tc.want) {
+			t.Errorf("ParseSchedule(%q).Next = %v, want %v", tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every", "@every 10ms", "@yearly-ish"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestStartSchedule(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	done := make(chan struct{})
+	err := StartSchedule(ctx, "refresh", "@every 1s", func(context.Context) error {
+		if runs.Add(1) == 1 {
+			close(done)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled job never ran")
+	}
+
+	if err := StartSchedule(ctx, "broken", "every hour", func(context.Context) error { return nil }, nil); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected parse error naming the job, got %v", err)
+	}
+}
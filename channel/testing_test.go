@@ -57,7 +57,7 @@ func TestTestRecorder_ImplementsRealtimeTransport(t *testing.T) {
 func TestTestRecorder_Send_CapturesMessages(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	data, _ := json.Marshal(map[string]string{"token": "xyz"})
 	if err := ch.Send(ctx, "Token", data); err != nil {
@@ -83,7 +83,7 @@ func TestTestRecorder_Send_CapturesMessages(t *testing.T) {
 func TestTestRecorder_Receive_ReturnsPreQueued(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Enqueue a message before Receive.
 	recorder.EnqueueIncoming("Approval", map[string]bool{"ok": true})
@@ -105,7 +105,7 @@ func TestTestRecorder_Receive_ReturnsPreQueued(t *testing.T) {
 func TestTestRecorder_HasSent(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Nothing sent yet.
 	if recorder.HasSent("Token") {
@@ -142,7 +142,7 @@ func TestTestRecorder_HasSent(t *testing.T) {
 func TestTestRecorder_SentOfType(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Send 3 Token messages and 1 Done message.
 	for i := 0; i < 3; i++ {
@@ -180,7 +180,7 @@ func TestTestRecorder_SentOfType(t *testing.T) {
 func TestTestRecorder_ReceiveAny_ReturnsAny(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Enqueue messages of different types.
 	recorder.EnqueueIncoming("TypeA", map[string]string{"a": "1"})
@@ -225,7 +225,7 @@ func TestTestRecorder_ReceiveAny_ReturnsAny(t *testing.T) {
 func TestWithTestChannel_CreatesWorkingChannel(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Verify the channel was created with sensible defaults.
 	if ch.Name() != "test" {
@@ -239,7 +239,7 @@ func TestWithTestChannel_CreatesWorkingChannel(t *testing.T) {
 func TestTestRecorder_EnqueueIncoming_MultipleTypes(t *testing.T) {
 	recorder := NewTestRecorder()
 	ctx := WithTestChannel(context.Background(), recorder)
-	ch := FromContext(ctx)
+	ch := MustFromContext(ctx)
 
 	// Enqueue several types and retrieve them in specific order via Receive.
 	recorder.EnqueueIncoming("A", "alpha")
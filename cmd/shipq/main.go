@@ -5,6 +5,7 @@ import (
 	"os"
 
 	authcmd "github.com/shipq/shipq/internal/commands/auth"
+	completioncmd "github.com/shipq/shipq/internal/commands/completion"
 	dbcmd "github.com/shipq/shipq/internal/commands/db"
 	dockercmd "github.com/shipq/shipq/internal/commands/docker"
 	emailcmd "github.com/shipq/shipq/internal/commands/email"
@@ -17,12 +18,19 @@ import (
 	"github.com/shipq/shipq/internal/commands/migrate/new"
 	"github.com/shipq/shipq/internal/commands/migrate/up"
 	nixcmd "github.com/shipq/shipq/internal/commands/nix"
+	outboxcmd "github.com/shipq/shipq/internal/commands/outbox"
+	quotascmd "github.com/shipq/shipq/internal/commands/quotas"
 	resourcecmd "github.com/shipq/shipq/internal/commands/resource"
+	routescmd "github.com/shipq/shipq/internal/commands/routes"
+	schemacmd "github.com/shipq/shipq/internal/commands/schema"
 	seedcmd "github.com/shipq/shipq/internal/commands/seed"
 	signupcmd "github.com/shipq/shipq/internal/commands/signup"
+	smokecmd "github.com/shipq/shipq/internal/commands/smoke"
 	startcmd "github.com/shipq/shipq/internal/commands/start"
 	statuscmd "github.com/shipq/shipq/internal/commands/status"
+	testcmd "github.com/shipq/shipq/internal/commands/test"
 	workerscmd "github.com/shipq/shipq/internal/commands/workers"
+	"github.com/shipq/shipq/internal/profiling"
 )
 
 const usage = `shipq - A database migration and code generation tool
@@ -36,12 +44,14 @@ Commands:
   docker            Generate production Dockerfiles (server + optional worker)
   health            Generate api/health/ healthcheck endpoint
   init              Initialize a new shipq project (creates go.mod and shipq.ini)
+                    --lite runs everything on an embedded SQLite file (no servers)
   auth              Generate authentication system (tables, handlers, tests)
   auth google       Add Google OAuth login to an existing auth system
   auth github       Add GitHub OAuth login to an existing auth system
   signup            Generate signup handler (run after auth)
   email             Add email verification and password reset (run after auth + workers)
-  seed              Run all seed files in seeds/ directory
+  seed [--env E]    Run the seed files in seeds/ (dev or test set)
+  seed new <name>   Scaffold a seed file in seeds/
   start <service>   Start a dev service (postgres|mysql|sqlite|redis|minio|centrifugo|server|worker)
                     For server/worker: hot reload is on by default; use --no-watch to disable
   kill-port <port>  Kill the process bound to <port>
@@ -49,17 +59,36 @@ Commands:
   db setup          Set up the database (create database and configure shipq.ini)
   db set <dialect>  Set the database dialect in shipq.ini (sqlite|postgres|mysql)
   db compile        Generate type-safe query runner code from user-defined queries
+  db promote <dialect>  Move a SQLite (lite) project to postgres or mysql and regenerate code
   db reset          Drop and recreate dev/test databases, re-run migrations (alias for migrate reset)
+  db refresh [view] Create and refresh materialized views (--recreate to rebuild)
+  db explain <query>  Print the query plan of a compiled query (--analyze runs EXPLAIN ANALYZE)
+  db seed --generate N  Insert N generated rows into every table (--seed S to reproduce)
+  db import <table> <file.csv>  Bulk-load a CSV file into a table (--dry-run only validates it)
+  db start <dialect> --docker  Run postgres or mysql in an ephemeral Docker container
+  db stop           Stop the database containers started with --docker
   migrate new <name>  Create a new migration
   migrate up        Run all pending migrations
   migrate reset     Drop and recreate dev/test databases, re-run migrations
+  migrate resolve   Renumber pending migrations that conflict with applied ones (after merging branches)
+  migrate status    List applied, pending and missing migrations (--json for CI)
   files             Generate S3-compatible file upload system (tables, handlers, helpers)
   workers           Bootstrap the workers system (channels, Centrifugo, task queue)
   workers compile   Recompile channel codegen without full bootstrap
-  resource <table> <op>  Generate CRUD handler(s) for a table (create|get_one|list|update|delete|all)
-  handler generate <table>  Generate CRUD handlers for a table
-  handler compile           Compile handler registry and run codegen
+  outbox            Add the transactional event outbox and its worker relay (run after workers)
+  quotas            Add per-scope row quotas for create handlers (max_rows_per_scope)
+  resource <table|@label> <op>  Generate CRUD handler(s) for a table or label group (create|get_one|list|update|delete|import|near|all)
+  handler generate <table>  Generate CRUD handlers for a table (--bulk adds POST /<table>/bulk; --action <verb> for a custom POST /<table>/:id/<verb>)
+  handler compile           Compile handler registry and run codegen (--grpc also serves the handlers over gRPC; --verbose prints phase timings; --cpuprofile/--memprofile <file>)
+  routes [--deprecated]     List compiled routes (or only deprecated ones with sunset dates)
+  schema changelog <from> [<to>]  Markdown (or --json) changelog of schema changes between releases
+  schema labels [--json]    List the tables carrying each label
+  schema graph [--migrations] [--format dot|mermaid] [--svg <file>]  Graph table or migration dependencies
+  schema import <openapi.json> [--write]  Propose migrations and handler scaffolds from an OpenAPI document
+  smoke                     Generate cmd/smoke, a post-deploy check of every GET endpoint
+  test concurrency          Generate and run -race concurrency tests for resource runner methods
   llm compile               Compile LLM tool registries, persister, migrations, and querydefs
+  completion <shell>        Print a bash, zsh or fish completion script
 
 Options:
   -h, --help    Show this help message
@@ -126,8 +155,13 @@ func main() {
 	case "files":
 		filescmd.FilesCmd()
 
+	case "outbox":
+		outboxcmd.OutboxCmd()
+	case "quotas":
+		quotascmd.QuotasCmd()
+
 	case "seed":
-		seedcmd.SeedCmd()
+		seedcmd.SeedCmd(os.Args[2:])
 
 	case "kill-port":
 		if len(os.Args) < 3 || os.Args[2] == "--help" || os.Args[2] == "-h" || os.Args[2] == "help" {
@@ -176,6 +210,15 @@ func main() {
 			fmt.Fprintln(os.Stderr, "  set <dialect>  Set the database dialect (sqlite|postgres|mysql)")
 			fmt.Fprintln(os.Stderr, "  compile        Generate type-safe query runner code")
 			fmt.Fprintln(os.Stderr, "  reset          Drop and recreate databases, re-run all migrations")
+			fmt.Fprintln(os.Stderr, "  refresh        Create and refresh materialized views")
+			fmt.Fprintln(os.Stderr, "  promote        Move a SQLite (lite) project to postgres or mysql")
+			fmt.Fprintln(os.Stderr, "  seed           Run seed files, or generate rows with --generate N")
+			fmt.Fprintln(os.Stderr, "  import <table> <file.csv>")
+			fmt.Fprintln(os.Stderr, "                 Bulk-load a CSV file into a table")
+			fmt.Fprintln(os.Stderr, "  console        Open an interactive SQL console on the dev database")
+			fmt.Fprintln(os.Stderr, "  start <dialect> [--docker]")
+			fmt.Fprintln(os.Stderr, "                 Start a postgres or mysql server (same as 'shipq start')")
+			fmt.Fprintln(os.Stderr, "  stop [dialect] Stop the database containers started with --docker")
 			os.Exit(1)
 		}
 
@@ -202,19 +245,99 @@ func main() {
 			dbcmd.DBSetCmd(dialect)
 
 		case "compile":
-			dbcmd.DBCompileCmd()
+			dbcmd.DBCompileArgsCmd(os.Args[3:])
 
 		case "reset":
 			up.MigrateResetCmd() // Alias for user convenience
 
+		case "refresh":
+			dbcmd.DBRefreshCmd(os.Args[3:])
+
+		case "explain":
+			if len(os.Args) < 4 {
+				dbcmd.DBExplainUsage()
+				os.Exit(1)
+			}
+			if arg := os.Args[3]; arg == "-h" || arg == "--help" || arg == "help" {
+				dbcmd.DBExplainUsage()
+				os.Exit(0)
+			}
+			dbcmd.DBExplainCmd(os.Args[3:])
+
+		case "seed":
+			seedcmd.DBSeedCmd(os.Args[3:])
+
+		case "import":
+			if len(os.Args) < 4 {
+				dbcmd.DBImportUsage()
+				os.Exit(1)
+			}
+			if arg := os.Args[3]; arg == "-h" || arg == "--help" || arg == "help" {
+				dbcmd.DBImportUsage()
+				os.Exit(0)
+			}
+			dbcmd.DBImportCmd(os.Args[3:])
+
+		case "console":
+			if len(os.Args) > 3 {
+				if arg := os.Args[3]; arg == "-h" || arg == "--help" || arg == "help" {
+					dbcmd.DBConsoleUsage()
+					os.Exit(0)
+				}
+			}
+			dbcmd.DBConsoleCmd(os.Args[3:])
+
+		case "start":
+			if len(os.Args) < 4 {
+				fmt.Fprintln(os.Stderr, "Usage: shipq db start <postgres|mysql> [--docker]")
+				os.Exit(1)
+			}
+			startcmd.StartCmd(os.Args[3], os.Args[4:]) // Alias for user convenience
+
+		case "stop":
+			startcmd.StopDocker(os.Args[3:])
+
+		case "promote":
+			if len(os.Args) < 4 {
+				dbcmd.DBPromoteUsage()
+				os.Exit(1)
+			}
+			target := os.Args[3]
+			if target == "-h" || target == "--help" || target == "help" {
+				dbcmd.DBPromoteUsage()
+				os.Exit(0)
+			}
+			dbcmd.DBPromoteCmd(target)
+
 		case "-h", "--help", "help":
 			fmt.Println("shipq db - Database management commands")
 			fmt.Println("")
 			fmt.Println("Subcommands:")
 			fmt.Println("  setup          Set up the database (create database and configure shipq.ini)")
 			fmt.Println("  set <dialect>  Set the database dialect in shipq.ini (sqlite|postgres|mysql)")
-			fmt.Println("  compile        Generate type-safe query runner code from user-defined queries")
+			fmt.Println("  compile [--only <table|query>]")
+			fmt.Println("                 Generate type-safe query runner code from user-defined queries")
+			fmt.Println("                 (--only recompiles just one table's or query's querydefs package)")
+			fmt.Println("                 (--cpuprofile/--memprofile <file> capture pprof profiles, --verbose prints phase timings)")
 			fmt.Println("  reset          Drop and recreate databases, re-run all migrations")
+			fmt.Println("  promote <dialect>")
+			fmt.Println("                 Move a SQLite (lite) project to postgres or mysql and regenerate code")
+			fmt.Println("  refresh [view...] [--recreate]")
+			fmt.Println("                 Create and refresh materialized views (all when none named)")
+			fmt.Println("  explain <query> [--analyze] [--param name=value]")
+			fmt.Println("                 Print the dev database's plan for a compiled query")
+			fmt.Println("  seed [--env E] [--generate N] [--seed S] [--exclude-label L]")
+			fmt.Println("                 Run seed files, or insert N generated rows into every table")
+			fmt.Println("  import <table> <file.csv> [--dry-run]")
+			fmt.Println("                 Validate a CSV file against schema.json and bulk-load it into the")
+			fmt.Println("                 table (COPY on Postgres, multi-row INSERTs on MySQL and SQLite)")
+			fmt.Println("  console        Open an interactive SQL console on the dev database, with")
+			fmt.Println("                 \\d table to describe a table and \\run <query> to run a querydef")
+			fmt.Println("  start <postgres|mysql> [--docker]")
+			fmt.Println("                 Start a database server (same as 'shipq start'); --docker runs it in")
+			fmt.Println("                 an ephemeral container and writes its database_url to shipq.ini")
+			fmt.Println("  stop [postgres|mysql]")
+			fmt.Println("                 Stop and remove the database containers started with --docker")
 			fmt.Println("")
 			fmt.Println("To start a database server use: shipq start <postgres|mysql|sqlite|redis|minio>")
 			os.Exit(0)
@@ -246,6 +369,12 @@ func main() {
 		case "reset":
 			up.MigrateResetCmd()
 
+		case "resolve":
+			up.MigrateResolveCmd(os.Args[3:])
+
+		case "status":
+			up.MigrateStatusCmd(os.Args[3:])
+
 		case "-h", "--help", "help":
 			fmt.Println("shipq migrate - Migration management commands")
 			fmt.Println("")
@@ -253,14 +382,18 @@ func main() {
 			fmt.Println("  new <name> [columns...]  Create a new migration")
 			fmt.Println("  up                       Run all pending migrations")
 			fmt.Println("  reset                    Drop and recreate databases, re-run all migrations")
+			fmt.Println("  resolve [--dry-run]      Renumber pending migrations that conflict with applied ones")
+			fmt.Println("  status [--json]          List applied, pending and missing migrations")
 			fmt.Println("")
 			fmt.Println("Examples:")
 			fmt.Println("  shipq migrate new users")
 			fmt.Println("  shipq migrate new users name:string email:string")
 			fmt.Println("  shipq migrate new posts title:string user_id:references:users")
 			fmt.Println("")
-			fmt.Println("Column types: string, text, int, bigint, bool, float, decimal, datetime, timestamp, binary, json")
+			fmt.Println("Column types: string, text, int, bigint, bool, float, decimal, datetime, timestamp, binary, json, point, polygon, enum")
 			fmt.Println("References: <column>:references:<table>")
+			fmt.Println("Decimals:   <column>:decimal[:<precision>:<scale>] (default 10, 2)")
+			fmt.Println("Enums:      <column>:enum:<value>,<value>,...")
 			os.Exit(0)
 
 		default:
@@ -285,7 +418,7 @@ func main() {
 			handlercmd.HandlerGenerateCmd(os.Args[3:])
 
 		case "compile":
-			handlercmd.HandlerCompileCmd()
+			handlercmd.HandlerCompileCmd(os.Args[3:])
 
 		case "-h", "--help", "help":
 			fmt.Println("shipq handler - Handler generation commands")
@@ -294,9 +427,14 @@ func main() {
 			fmt.Println("  generate <table>  Generate CRUD handlers for a table")
 			fmt.Println("  compile           Compile handler registry and run codegen")
 			fmt.Println("")
+			fmt.Println("Flags for compile:")
+			fmt.Println(profiling.Usage)
+			fmt.Println("")
 			fmt.Println("Examples:")
 			fmt.Println("  shipq handler generate posts")
 			fmt.Println("  shipq handler generate users")
+			fmt.Println("  shipq handler generate posts --action publish")
+			fmt.Println("  shipq handler generate posts --bulk")
 			fmt.Println("")
 			fmt.Println("This generates handler files in api/<table>/ including:")
 			fmt.Println("  - create.go      POST /<table>")
@@ -305,6 +443,13 @@ func main() {
 			fmt.Println("  - update.go      PATCH /<table>/:id")
 			fmt.Println("  - soft_delete.go DELETE /<table>/:id")
 			fmt.Println("  - register.go    Handler registration function")
+			fmt.Println("")
+			fmt.Println("With --bulk (or [crud.<table>] bulk = true), also:")
+			fmt.Println("  - bulk_create.go POST /<table>/bulk, creates a JSON array of items in one transaction")
+			fmt.Println("")
+			fmt.Println("With --action <verb>, only a custom action is scaffolded instead:")
+			fmt.Println("  - <verb>.go                 POST /<table>/:id/<verb> handler stub")
+			fmt.Println("  - querydefs/<table>/<verb>.go  the query it runs (edit the placeholder)")
 			os.Exit(0)
 
 		default:
@@ -313,6 +458,78 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "routes":
+		if len(os.Args) > 2 && (os.Args[2] == "-h" || os.Args[2] == "--help" || os.Args[2] == "help") {
+			fmt.Println("shipq routes - List routes from the last handler compile")
+			fmt.Println("")
+			fmt.Println("Usage: shipq routes [--deprecated]")
+			fmt.Println("")
+			fmt.Println("Flags:")
+			fmt.Println("  --deprecated  Only show deprecated routes, with their sunset dates")
+			fmt.Println("")
+			fmt.Println("Routes are marked deprecated with .Deprecated() or .Sunset(\"YYYY-MM-DD\")")
+			fmt.Println("in register.go, or for generated CRUD routes with deprecated/sunset keys")
+			fmt.Println("in a [crud.<table>] section of shipq.ini.")
+			os.Exit(0)
+		}
+		routescmd.RoutesCmd(os.Args[2:])
+
+	case "smoke":
+		if len(os.Args) > 2 && (os.Args[2] == "-h" || os.Args[2] == "--help" || os.Args[2] == "help") {
+			fmt.Println("shipq smoke - Generate a post-deploy smoke check binary")
+			fmt.Println("")
+			fmt.Println("Usage: shipq smoke")
+			fmt.Println("")
+			fmt.Println("Writes cmd/smoke/main.go from the routes and OpenAPI document recorded by")
+			fmt.Println("the last 'shipq handler compile'. The program calls every GET endpoint of a")
+			fmt.Println("running server, checks for 200 and a body matching the OpenAPI schema, and")
+			fmt.Println("exits 1 if any endpoint fails.")
+			fmt.Println("")
+			fmt.Println("Path parameters such as :id are taken from the first item returned by the")
+			fmt.Println("parent collection (so seed the database first) or from -param name=value.")
+			fmt.Println("")
+			fmt.Println("Smoke program flags:")
+			fmt.Println("  -base-url URL      Server to check (default $SMOKE_BASE_URL or http://localhost:8080)")
+			fmt.Println("  -cookie VALUE      Cookie header for routes that require auth (default $SMOKE_COOKIE)")
+			fmt.Println("  -header 'N: V'     Extra request header (repeatable)")
+			fmt.Println("  -param name=value  Path parameter value (repeatable)")
+			fmt.Println("  -timeout DURATION  Per-request timeout (default 10s)")
+			os.Exit(0)
+		}
+		smokecmd.SmokeCmd(os.Args[2:])
+
+	case "test":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "error: 'shipq test' requires a subcommand")
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, "Available subcommands:")
+			fmt.Fprintln(os.Stderr, "  concurrency  Generate and run concurrency tests for resource runner methods")
+			os.Exit(1)
+		}
+
+		subCmd := os.Args[2]
+		switch subCmd {
+		case "concurrency":
+			testcmd.ConcurrencyCmd(os.Args[3:])
+
+		case "-h", "--help", "help":
+			fmt.Println("shipq test - Generated test suites")
+			fmt.Println("")
+			fmt.Println("Subcommands:")
+			fmt.Println("  concurrency [go test flags]")
+			fmt.Println("                 Write api/<table>/spec/zz_generated_concurrency_test.go for each")
+			fmt.Println("                 resource and run them with -race. The tests hammer Create,")
+			fmt.Println("                 Update and SoftDelete from parallel goroutines and check unique")
+			fmt.Println("                 constraint races. They commit rows to the test database and")
+			fmt.Println("                 only build with -tags concurrency.")
+			os.Exit(0)
+
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown test subcommand: %s\n", subCmd)
+			fmt.Fprintln(os.Stderr, "Run 'shipq test --help' for usage.")
+			os.Exit(1)
+		}
+
 	case "workers":
 		if len(os.Args) < 3 {
 			workerscmd.WorkersCmd()
@@ -384,13 +601,59 @@ func main() {
 			os.Exit(1)
 		}
 
+	case "schema":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "error: 'shipq schema' requires a subcommand")
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, "Available subcommands:")
+			fmt.Fprintln(os.Stderr, "  changelog <from> [<to>]  Print schema changes between two releases")
+			fmt.Fprintln(os.Stderr, "  labels                   List the tables carrying each label")
+			fmt.Fprintln(os.Stderr, "  graph                    Graph table or migration dependencies")
+			fmt.Fprintln(os.Stderr, "  import <openapi.json>    Propose migrations from an OpenAPI document")
+			os.Exit(1)
+		}
+
+		subCmd := os.Args[2]
+		switch subCmd {
+		case "changelog":
+			schemacmd.ChangelogCmd(os.Args[3:])
+
+		case "labels":
+			schemacmd.LabelsCmd(os.Args[3:])
+
+		case "graph":
+			schemacmd.GraphCmd(os.Args[3:])
+
+		case "import":
+			schemacmd.ImportCmd(os.Args[3:])
+
+		case "-h", "--help", "help":
+			fmt.Println("shipq schema - Schema inspection commands")
+			fmt.Println("")
+			fmt.Println("Subcommands:")
+			fmt.Println("  changelog <from> [<to>] [--json]")
+			fmt.Println("                 Print schema changes between two git refs or schema.json files")
+			fmt.Println("  labels [--json]")
+			fmt.Println("                 List the tables carrying each label")
+			fmt.Println("  graph [--migrations] [--format dot|mermaid] [--svg <file>]")
+			fmt.Println("                 Graph table foreign keys or migration ordering constraints")
+			fmt.Println("  import <openapi.json> [--write]")
+			fmt.Println("                 Propose migrations and handler scaffolds from an OpenAPI document")
+			os.Exit(0)
+
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown schema subcommand: %s\n", subCmd)
+			fmt.Fprintln(os.Stderr, "Run 'shipq schema --help' for usage.")
+			os.Exit(1)
+		}
+
 	case "resource":
 		if len(os.Args) < 3 {
 			fmt.Fprintln(os.Stderr, "error: 'shipq resource' requires a table name and operation")
 			fmt.Fprintln(os.Stderr, "")
-			fmt.Fprintln(os.Stderr, "Usage: shipq resource <table> <operation> [--public]")
+			fmt.Fprintln(os.Stderr, "Usage: shipq resource <table|@label> <operation> [--public]")
 			fmt.Fprintln(os.Stderr, "")
-			fmt.Fprintln(os.Stderr, "Operations: create, get_one, list, update, delete, all")
+			fmt.Fprintln(os.Stderr, "Operations: create, get_one, list, update, delete, import, near, all")
 			fmt.Fprintln(os.Stderr, "")
 			fmt.Fprintln(os.Stderr, "Examples:")
 			fmt.Fprintln(os.Stderr, "  shipq resource books create")
@@ -404,7 +667,7 @@ func main() {
 		if tableName == "-h" || tableName == "--help" || tableName == "help" {
 			fmt.Println("shipq resource - Per-operation handler generation")
 			fmt.Println("")
-			fmt.Println("Usage: shipq resource <table> <operation> [--public]")
+			fmt.Println("Usage: shipq resource <table|@label> <operation> [--public] [--exclude-label <label>] [--jobs N]")
 			fmt.Println("")
 			fmt.Println("Operations:")
 			fmt.Println("  create    Generate create handler + test")
@@ -412,23 +675,32 @@ func main() {
 			fmt.Println("  list      Generate list handler + test (with pagination)")
 			fmt.Println("  update    Generate update handler + test")
 			fmt.Println("  delete    Generate soft-delete handler + test")
+			fmt.Println("  import    Generate bulk CSV/NDJSON import handler + test (POST /<table>/import)")
+			fmt.Println("  near      Generate proximity search handler + test (GET /<table>/near, needs [crud.<table>] near)")
 			fmt.Println("  all       Generate all 5 CRUD handlers + tests + register.go")
 			fmt.Println("")
 			fmt.Println("Flags:")
-			fmt.Println("  --public  Skip auth protection for generated routes")
+			fmt.Println("  --public                 Skip auth protection for generated routes")
+			fmt.Println("  --exclude-label <label>  With @label, skip tables that also carry <label> (repeatable)")
+			fmt.Println("  --jobs N                 Generate up to N tables in parallel (default: number of CPUs)")
+			fmt.Println("")
+			fmt.Println("@label generates the operation for every table labeled with tb.Label(...).")
 			fmt.Println("")
 			fmt.Println("Examples:")
 			fmt.Println("  shipq resource books create")
 			fmt.Println("  shipq resource books all")
 			fmt.Println("  shipq resource books all --public")
+			fmt.Println("  shipq resource books import")
+			fmt.Println("  shipq resource stores near")
+			fmt.Println("  shipq resource @billing all --exclude-label internal")
 			os.Exit(0)
 		}
 
 		if len(os.Args) < 4 {
 			fmt.Fprintln(os.Stderr, "error: 'shipq resource' requires an operation")
 			fmt.Fprintln(os.Stderr, "")
-			fmt.Fprintln(os.Stderr, "Usage: shipq resource <table> <operation>")
-			fmt.Fprintln(os.Stderr, "Operations: create, get_one, list, update, delete, all")
+			fmt.Fprintln(os.Stderr, "Usage: shipq resource <table|@label> <operation>")
+			fmt.Fprintln(os.Stderr, "Operations: create, get_one, list, update, delete, import, near, all")
 			os.Exit(1)
 		}
 
@@ -444,12 +716,19 @@ func main() {
 		}
 		if !validOp {
 			fmt.Fprintf(os.Stderr, "error: unknown operation %q\n", operation)
-			fmt.Fprintln(os.Stderr, "Valid operations: create, get_one, list, update, delete, all")
+			fmt.Fprintln(os.Stderr, "Valid operations: create, get_one, list, update, delete, import, near, all")
 			os.Exit(1)
 		}
 
 		resourcecmd.ResourceCmd(tableName, operation, os.Args[4:])
 
+	case "completion":
+		completioncmd.CompletionCmd(os.Args[2:])
+
+	case "__complete":
+		// Called by the completion scripts; not listed in the usage.
+		completioncmd.CompleteCmd(os.Args[2:])
+
 	default:
 		fmt.Fprintf(os.Stderr, "error: unknown command: %s\n", cmd)
 		fmt.Fprintln(os.Stderr, "Run 'shipq --help' for usage.")
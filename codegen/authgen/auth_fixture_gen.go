@@ -3,7 +3,8 @@ package authgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateAccountFixture generates api/accounts/fixture/fixture.go.
@@ -53,7 +54,7 @@ func GenerateAccountFixture(cfg AuthGenConfig) ([]byte, error) {
 	buf.WriteString("\treturn result\n")
 	buf.WriteString("}\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format account fixture: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -93,7 +94,7 @@ func GenerateOrganizationFixture(cfg AuthGenConfig) ([]byte, error) {
 	buf.WriteString("\treturn result\n")
 	buf.WriteString("}\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format organization fixture: %w\nunformatted:\n%s", err, buf.String())
 	}
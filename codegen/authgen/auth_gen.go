@@ -3,7 +3,8 @@ package authgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // generatedFileHeader is the standard header for generated auth handler files.
@@ -18,6 +19,7 @@ type AuthGenConfig struct {
 	OAuthProviders  []string // e.g., ["google", "github"] — OAuth providers enabled in shipq.ini
 	SignupEnabled   bool     // true when `shipq signup` has been run — controls whether OAuth auto-creates accounts
 	EmailEnabled    bool     // true when `shipq email` has been run — controls verified check + email routes
+	BearerAuth      bool     // [server] auth = bearer: login returns a token that Authorization: Bearer headers carry
 }
 
 // GenerateAuthHandlerFiles generates all auth handler files.
@@ -81,14 +83,21 @@ type LoginResponse struct {
 	Email     string ` + "`json:\"email\"`" + `
 	FirstName string ` + "`json:\"first_name\"`" + `
 	LastName  string ` + "`json:\"last_name\"`" + `
-}
+`)
+	if cfg.BearerAuth {
+		buf.WriteString(`	// Token authenticates later requests sent with an
+	// Authorization: Bearer header. It expires with the session.
+	Token string ` + "`json:\"token\"`" + `
+`)
+	}
+	buf.WriteString(`}
 
 `)
 
 	// Handler function
 	buf.WriteString(`// Login handles POST /login
 func Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	// Look up account by email
 	account, err := runner.FindAccountByEmail(ctx, queries.FindAccountByEmailParams{
@@ -145,7 +154,12 @@ func Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
 		Email:     account.Email,
 		FirstName: account.FirstName,
 		LastName:  account.LastName,
-	}, nil
+`)
+	if cfg.BearerAuth {
+		buf.WriteString(`		Token:     crypto.SignCookie(session.PublicId, []byte(config.Settings.COOKIE_SECRET)),
+`)
+	}
+	buf.WriteString(`	}, nil
 }
 
 // setSessionCookie sets the session cookie on the response.
@@ -204,7 +218,7 @@ type LogoutResponse struct {
 	// Handler function
 	buf.WriteString(`// Logout handles DELETE /logout
 func Logout(ctx context.Context, req *LogoutRequest) (*LogoutResponse, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	// Get current session
 	session, err := getCurrentSession(ctx, runner)
@@ -313,7 +327,7 @@ func writeMeRoleInfoStruct(buf *bytes.Buffer, scopeColumn string) {
 func writeMeHandlerFunc(buf *bytes.Buffer, scopeColumn string) {
 	buf.WriteString(`// Me handles GET /me
 func Me(ctx context.Context, req *MeRequest) (*MeResponse, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	// Get current session
 	session, err := getCurrentSession(ctx, runner)
@@ -429,7 +443,7 @@ type SignupResponse struct {
 	buf.WriteString(`// Signup handles POST /signup.
 // All database writes are wrapped in a transaction for atomicity.
 func Signup(ctx context.Context, req *SignupRequest) (*SignupResponse, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	// Check if email is already taken (read — outside transaction)
 	existing, err := runner.FindAccountByEmail(ctx, queries.FindAccountByEmailParams{
@@ -718,7 +732,29 @@ func GenerateHelpers(cfg AuthGenConfig) ([]byte, error) {
 	buf.WriteString("\t\"" + cfg.ModulePath + "/config\"\n")
 	buf.WriteString(")\n\n")
 
-	buf.WriteString(`// getCurrentSession gets the current session from the request cookie.
+	if cfg.BearerAuth {
+		buf.WriteString(`// getCurrentSession gets the current session from the request's bearer
+// token, or from the session cookie when the request sent no token. Both
+// carry the signed session ID: it verifies the signature, then looks up the
+// session via the query runner.
+func getCurrentSession(ctx context.Context, runner queries.Runner) (*queries.FindActiveSessionResult, error) {
+	token, ok := httputil.BearerTokenFromContext(ctx)
+	if !ok {
+		cookie, err := httpserver.GetCookie(ctx, "session")
+		if err != nil {
+			return nil, err
+		}
+		token = cookie.Value
+	}
+
+	secret := []byte(config.Settings.COOKIE_SECRET)
+	sessionID, err := crypto.VerifyCookie(token, secret)
+	if err != nil {
+		return nil, err
+	}
+`)
+	} else {
+		buf.WriteString(`// getCurrentSession gets the current session from the request cookie.
 // It verifies the cookie signature, then looks up the session via the query runner.
 func getCurrentSession(ctx context.Context, runner queries.Runner) (*queries.FindActiveSessionResult, error) {
 	cookie, err := httpserver.GetCookie(ctx, "session")
@@ -731,7 +767,10 @@ func getCurrentSession(ctx context.Context, runner queries.Runner) (*queries.Fin
 	if err != nil {
 		return nil, err
 	}
+`)
+	}
 
+	buf.WriteString(`
 	return runner.FindActiveSession(ctx, queries.FindActiveSessionParams{
 		PublicId: sessionID,
 	})
@@ -773,9 +812,85 @@ func TryGetCurrentSession(ctx context.Context, runner queries.Runner) (*queries.
 	// Generate CheckRBAC helper based on scope configuration
 	writeCheckRBACHelper(&buf, cfg.ScopeColumn)
 
+	if cfg.ScopeColumn != "" {
+		writeResolveOrganizationHelper(&buf)
+	}
+
+	if cfg.BearerAuth {
+		writePrincipalHelpers(&buf)
+	}
+
 	return formatSource(buf.Bytes())
 }
 
+// writePrincipalHelpers writes Principal and the helpers that read it from
+// the context the generated auth middleware fills in, whether the request
+// authenticated with a bearer token or the session cookie.
+func writePrincipalHelpers(buf *bytes.Buffer) {
+	buf.WriteString(`
+// Principal is the account an authenticated request acts as, and the
+// organization it acts in.
+type Principal struct {
+	AccountID      int64
+	OrganizationID int64
+}
+
+// WithPrincipal returns a new context carrying p, as the auth middleware
+// leaves it for handlers. Tests use it to call handlers directly.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	ctx = httputil.WithSessionAccountID(ctx, p.AccountID)
+	return httputil.WithOrganizationID(ctx, p.OrganizationID)
+}
+
+// PrincipalFromContext returns the principal of an authenticated request.
+// Returns (Principal{}, false) on routes without .Auth() and on
+// OptionalAuth routes called anonymously.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	accountID, ok := httputil.SessionAccountIDFromContext(ctx)
+	if !ok {
+		return Principal{}, false
+	}
+	orgID, _ := httputil.OrganizationIDFromContext(ctx)
+	return Principal{AccountID: accountID, OrganizationID: orgID}, true
+}
+
+// MustPrincipalFromContext is like PrincipalFromContext but panics when the
+// request is not authenticated. Use it in handlers registered with .Auth().
+func MustPrincipalFromContext(ctx context.Context) Principal {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		panic("auth.MustPrincipalFromContext: no principal in context -- is the route registered with .Auth()?")
+	}
+	return p
+}
+`)
+}
+
+// writeResolveOrganizationHelper writes ResolveOrganization, which the
+// generated HTTP server calls when [db] scope_header is set and a request
+// names the organization it acts in.
+func writeResolveOrganizationHelper(buf *bytes.Buffer) {
+	buf.WriteString(`
+// ResolveOrganization returns the internal ID of the organization with the
+// given public ID, which the request asked to act in. An account that does
+// not belong to it gets a ForbiddenError, so a request cannot reach another
+// tenant's rows by naming its organization.
+func ResolveOrganization(ctx context.Context, runner queries.Runner, accountID int64, organizationPublicID string) (int64, error) {
+	org, err := runner.FindOrganizationMembership(ctx, queries.FindOrganizationMembershipParams{
+		OrganizationPublicId: organizationPublicID,
+		AccountId:            accountID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if org == nil {
+		return 0, httputil.Forbidden("not a member of the requested organization")
+	}
+	return org.Id, nil
+}
+`)
+}
+
 // writeCheckRBACHelper writes the CheckRBAC helper function to the buffer.
 // The helper encapsulates the RBAC decision logic: global owner bypass,
 // unrestricted route pass-through, and permission check.
@@ -1004,6 +1119,12 @@ func GenerateAuthQueryDefs(cfg AuthGenConfig) ([]byte, error) {
 	// Variant depends on whether org scoping is enabled.
 	writeCheckRBACQuery(&buf, cfg.ScopeColumn)
 
+	// FindOrganizationMembership: the organization a request asks to act in
+	// through the scope header, if the account belongs to it.
+	if cfg.ScopeColumn != "" {
+		writeFindOrganizationMembershipQuery(&buf)
+	}
+
 	// OAuth queries (only when OAuth providers are configured)
 	if len(cfg.OAuthProviders) > 0 {
 		writeOAuthQueryDefs(&buf, cfg)
@@ -1019,6 +1140,22 @@ func GenerateAuthQueryDefs(cfg AuthGenConfig) ([]byte, error) {
 	return formatSource(buf.Bytes())
 }
 
+// writeFindOrganizationMembershipQuery writes the query ResolveOrganization
+// uses to look up an organization by public ID among the account's own.
+func writeFindOrganizationMembershipQuery(buf *bytes.Buffer) {
+	buf.WriteString("\tquery.MustDefineOne(\"FindOrganizationMembership\",\n")
+	buf.WriteString("\t\tquery.From(schema.OrganizationUsers).\n")
+	buf.WriteString("\t\t\tJoin(schema.Organizations).On(schema.Organizations.Id().Eq(schema.OrganizationUsers.OrganizationId())).\n")
+	buf.WriteString("\t\t\tSelect(schema.Organizations.Id()).\n")
+	buf.WriteString("\t\t\tWhere(query.And(\n")
+	buf.WriteString("\t\t\t\tschema.Organizations.PublicId().Eq(query.Param[string](\"organizationPublicId\")),\n")
+	buf.WriteString("\t\t\t\tschema.OrganizationUsers.AccountId().Eq(query.Param[int64](\"accountId\")),\n")
+	buf.WriteString("\t\t\t\tschema.OrganizationUsers.DeletedAt().IsNull(),\n")
+	buf.WriteString("\t\t\t\tschema.Organizations.DeletedAt().IsNull(),\n")
+	buf.WriteString("\t\t\t)).\n")
+	buf.WriteString("\t\t\tBuild())\n\n")
+}
+
 // writeEmailQueryDefs writes query definitions for the email system:
 // sent_emails, password_reset_tokens, email_verification_tokens, and
 // account verification helpers.
@@ -1431,7 +1568,7 @@ func writeFindAccountByInternalIDQuery(buf *bytes.Buffer, scopeColumn string) {
 
 // formatSource formats Go source code, returning the original if formatting fails.
 func formatSource(src []byte) ([]byte, error) {
-	formatted, err := format.Source(src)
+	formatted, err := phase.FormatSource(src)
 	if err != nil {
 		return src, fmt.Errorf("failed to format source: %w\n%s", err, src)
 	}
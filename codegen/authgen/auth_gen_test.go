@@ -3,8 +3,11 @@ package authgen
 import (
 	"go/parser"
 	"go/token"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 )
 
 // ---------------------------------------------------------------------------
@@ -576,6 +579,76 @@ func TestGenerateHelpers_ContainsExpectedFunctions(t *testing.T) {
 	}
 }
 
+func TestGenerateHelpers_ScopedIncludesResolveOrganization(t *testing.T) {
+	for _, scope := range []string{"", "organization_id"} {
+		cfg := AuthGenConfig{
+			ModulePath:  "example.com/myapp",
+			ScopeColumn: scope,
+		}
+
+		helpers, err := GenerateHelpers(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHelpers(scope=%q) error = %v", scope, err)
+		}
+		querydefs, err := GenerateAuthQueryDefs(cfg)
+		if err != nil {
+			t.Fatalf("GenerateAuthQueryDefs(scope=%q) error = %v", scope, err)
+		}
+
+		scoped := scope != ""
+		hf := gofile.Parse(t, "helpers.go", helpers)
+		if got := hf.HasFunc("ResolveOrganization"); got != scoped {
+			t.Errorf("scope=%q: ResolveOrganization present = %v, want %v", scope, got, scoped)
+		}
+		if got := slices.Contains(gofile.Parse(t, "queries.go", querydefs).Strings(""), "FindOrganizationMembership"); got != scoped {
+			t.Errorf("scope=%q: FindOrganizationMembership present = %v, want %v", scope, got, scoped)
+		}
+		if scoped && hf.Calls("ResolveOrganization", "httputil.Forbidden") == 0 {
+			t.Error("ResolveOrganization should refuse non-members with httputil.Forbidden")
+		}
+	}
+}
+
+func TestGenerateAuthHandlers_BearerAuth(t *testing.T) {
+	cfg := AuthGenConfig{ModulePath: "example.com/myapp", BearerAuth: true}
+
+	login, err := GenerateLoginHandler(cfg)
+	if err != nil {
+		t.Fatalf("GenerateLoginHandler() error = %v", err)
+	}
+	helpers, err := GenerateHelpers(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHelpers() error = %v", err)
+	}
+	lf := gofile.Parse(t, "login.go", login)
+	lf.AssertField("LoginResponse", "Token", "string", `json:"token"`)
+	lf.AssertExprs("Login", "Token: crypto.SignCookie(session.PublicId, []byte(config.Settings.COOKIE_SECRET))")
+
+	// A bearer token is preferred over the session cookie.
+	hf := gofile.Parse(t, "helpers.go", helpers)
+	hf.AssertStmts("getCurrentSession",
+		"token, ok := httputil.BearerTokenFromContext(ctx)",
+		"token = cookie.Value",
+		"sessionID, err := crypto.VerifyCookie(token, secret)",
+	)
+	if !hf.HasType("Principal") {
+		t.Error("helpers.go should declare Principal")
+	}
+	hf.AssertSignature("WithPrincipal", "func WithPrincipal(ctx context.Context, p Principal) context.Context")
+	hf.AssertSignature("PrincipalFromContext", "func PrincipalFromContext(ctx context.Context) (Principal, bool)")
+	hf.AssertSignature("MustPrincipalFromContext", "func MustPrincipalFromContext(ctx context.Context) Principal")
+
+	cfg.BearerAuth = false
+	login, _ = GenerateLoginHandler(cfg)
+	helpers, _ = GenerateHelpers(cfg)
+	if _, _, ok := gofile.Parse(t, "login.go", login).Field("LoginResponse", "Token"); ok {
+		t.Error("login should not return a token without bearer auth")
+	}
+	if hf := gofile.Parse(t, "helpers.go", helpers); hf.Calls("getCurrentSession", "httputil.BearerTokenFromContext") != 0 || hf.HasType("Principal") {
+		t.Error("helpers.go should read the session cookie alone without bearer auth")
+	}
+}
+
 func TestGenerateAuthTestFiles_ValidGo(t *testing.T) {
 	cfg := AuthGenConfig{
 		ModulePath:      "example.com/myapp",
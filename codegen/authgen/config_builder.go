@@ -17,6 +17,7 @@ type AuthGenConfigParams struct {
 	OAuthProviders  []string
 	SignupEnabled   bool
 	EmailEnabled    bool
+	BearerAuth      bool
 }
 
 // BuildAuthGenConfig constructs an AuthGenConfig from project configuration,
@@ -34,11 +35,12 @@ func BuildAuthGenConfig(params AuthGenConfigParams) AuthGenConfig {
 		OAuthProviders:  params.OAuthProviders,
 		SignupEnabled:   params.SignupEnabled,
 		EmailEnabled:    params.EmailEnabled,
+		BearerAuth:      params.BearerAuth,
 	}
 }
 
 // BuildAuthGenConfigFromIni is a convenience constructor that reads OAuth
-// providers and email-enabled state from the ini file. The caller must
+// providers, email-enabled state and bearer auth from the ini file. The caller must
 // supply signupEnabled and the project-level fields.
 func BuildAuthGenConfigFromIni(
 	ini *inifile.File,
@@ -56,9 +58,16 @@ func BuildAuthGenConfigFromIni(
 		OAuthProviders:  oauthProviders,
 		SignupEnabled:   signupEnabled,
 		EmailEnabled:    emailEnabled,
+		BearerAuth:      BearerAuthFromIni(ini),
 	}
 }
 
+// BearerAuthFromIni reports whether [server] auth = bearer, which makes the
+// generated auth package accept Authorization: Bearer tokens.
+func BearerAuthFromIni(ini *inifile.File) bool {
+	return strings.ToLower(strings.TrimSpace(ini.Get("server", "auth"))) == "bearer"
+}
+
 // EnabledOAuthProvidersFromIni reads [auth] oauth_<name> flags from the ini
 // file and returns the list of enabled provider names. This is the canonical
 // implementation — the private copies in signup and email packages should
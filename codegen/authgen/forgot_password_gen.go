@@ -50,7 +50,7 @@ type ForgotPasswordRequest struct {
 // Always returns 200 regardless of whether the email exists (timing-safe).
 // This prevents email enumeration attacks.
 func ForgotPassword(ctx context.Context, req *ForgotPasswordRequest) (*struct{}, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	// Look up account (don't reveal if it exists)
 	account, err := runner.FindAccountByEmail(ctx, queries.FindAccountByEmailParams{
@@ -3,9 +3,9 @@ package authgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // RBACTestGenConfig holds configuration for generating RBAC integration tests.
@@ -42,7 +42,7 @@ func GenerateRBACTests(cfg RBACTestGenConfig) ([]byte, error) {
 		writeTestOrgScopedRolesDoNotCrossOrgs(&buf, cfg)
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format RBAC test code: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -41,7 +41,7 @@ type ResetPasswordRequest struct {
 // It validates the token, updates the account's password, and invalidates
 // all outstanding reset tokens for the account.
 func ResetPassword(ctx context.Context, req *ResetPasswordRequest) (*struct{}, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	if req.Token == "" {
 		return nil, httperror.BadRequest("token is required")
@@ -37,7 +37,7 @@ type VerifyEmailRequest struct {
 	buf.WriteString(`// VerifyEmail handles GET /auth/verify-email.
 // It validates the token and marks the account as verified.
 func VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*struct{}, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	if req.Token == "" {
 		return nil, httperror.BadRequest("token is required")
@@ -122,7 +122,7 @@ type ResendVerificationRequest struct {
 // Always returns 200 regardless of whether the email exists or is already verified (timing-safe).
 // This prevents email enumeration attacks.
 func ResendVerification(ctx context.Context, req *ResendVerificationRequest) (*struct{}, error) {
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	// Look up account (don't reveal if it exists)
 	account, err := runner.FindAccountByEmail(ctx, queries.FindAccountByEmailParams{
@@ -547,7 +547,7 @@ func Register(app *channel.App) {
 }
 
 func HandleEchoRequest(ctx context.Context, req *EchoRequest) error {
-	tc := TypedChannelFromContext(ctx)
+	tc := MustTypedChannelFromContext(ctx)
 	return tc.SendEchoResponse(ctx, &EchoResponse{
 		Echo: "Echo: " + req.Message,
 	})
@@ -3,8 +3,9 @@ package channelcompile
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"sort"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // ChannelCompileProgramConfig holds configuration for generating the channel
@@ -239,7 +240,7 @@ func convertChannelStructInfo(info *channel.MessageStructInfo) *SerializedStruct
 
 `)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format channel compile program: %w", err)
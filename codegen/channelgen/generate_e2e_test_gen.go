@@ -3,11 +3,11 @@ package channelgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"path/filepath"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateE2ETest generates the Go source code for E2E plumbing tests
@@ -212,7 +212,7 @@ func GenerateE2ETestCode(channels []codegen.SerializedChannelInfo, modulePath st
 
 	buf.WriteString("}\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted for debugging
 		return buf.Bytes(), fmt.Errorf("format e2e test code: %w\nunformatted:\n%s", err, buf.String())
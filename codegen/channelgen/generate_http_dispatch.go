@@ -3,10 +3,10 @@ package channelgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // ChannelHTTPGenConfig holds configuration for generating channel HTTP route files.
@@ -66,7 +66,7 @@ func generateChannelRoutesFile(cfg ChannelHTTPGenConfig) ([]byte, error) {
 	generateJobStatusHandler(&buf, cfg)
 	generateChannelHelpers(&buf, cfg)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format channel HTTP routes: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -3,11 +3,11 @@ package channelgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"path/filepath"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateIntegrationTests generates the Go source code for integration tests
@@ -223,7 +223,7 @@ func GenerateIntegrationTestCode(channels []codegen.SerializedChannelInfo, modul
 		buf.WriteString("}\n\n")
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted for debugging
 		return buf.Bytes(), fmt.Errorf("format integration test code: %w\nunformatted:\n%s", err, buf.String())
@@ -0,0 +1,149 @@
+package channelgen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// OutboxTable is the table the generated write handlers record events in.
+const OutboxTable = "outbox_events"
+
+// GenerateOutboxMigration generates the migration that creates the
+// outbox_events table. It uses AddEmptyTable so the table gets no
+// author_account_id or soft-delete columns: rows are written by the API's
+// write transactions and only ever updated by the worker's relay.
+func GenerateOutboxMigration(timestamp, modulePath string) []byte {
+	return []byte(fmt.Sprintf(`package migrations
+
+import (
+	"%s/shipq/lib/db/portsql/ddl"
+	"%s/shipq/lib/db/portsql/migrate"
+)
+
+func Migrate_%s_outbox_events(plan *migrate.MigrationPlan) error {
+	_, err := plan.AddEmptyTable("outbox_events", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.String("public_id").Unique()
+		tb.String("topic")
+		tb.String("event_type")
+		tb.String("aggregate_id")
+		tb.JSON("payload")
+		tb.Integer("attempts").Default(0)
+		tb.Text("last_error").Nullable()
+		tb.Datetime("published_at").Nullable().Indexed()
+		tb.Datetime("created_at").Default("CURRENT_TIMESTAMP")
+		return nil
+	})
+	return err
+}
+`, modulePath, modulePath, timestamp))
+}
+
+// GenerateOutboxQuerydefs generates querydefs/outbox_events/queries.go:
+// InsertOutboxEvent, used by the generated write handlers inside their
+// transactions, and the three queries behind the worker's relay.
+func GenerateOutboxQuerydefs(modulePath string) []byte {
+	var buf bytes.Buffer
+
+	schemaPkg := modulePath + "/shipq/db/schema"
+	queryPkg := modulePath + "/shipq/lib/db/portsql/query"
+
+	buf.WriteString(generatedQuerydefHeader)
+	buf.WriteString("package outbox_events\n\n")
+	buf.WriteString("import (\n")
+	buf.WriteString(fmt.Sprintf("\t%q\n", schemaPkg))
+	buf.WriteString(fmt.Sprintf("\t%q\n", queryPkg))
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("func init() {\n")
+
+	buf.WriteString("\t// InsertOutboxEvent records an event in the transaction that changed the data it describes.\n")
+	buf.WriteString("\tquery.MustDefineExec(\"InsertOutboxEvent\",\n")
+	buf.WriteString("\t\tquery.InsertInto(schema.OutboxEvents).\n")
+	buf.WriteString("\t\t\tColumns(\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.PublicId(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.Topic(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.EventType(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.AggregateId(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.Payload(),\n")
+	buf.WriteString("\t\t\t).\n")
+	buf.WriteString("\t\t\tValues(\n")
+	buf.WriteString("\t\t\t\tquery.Param[string](\"publicId\"),\n")
+	buf.WriteString("\t\t\t\tquery.Param[string](\"topic\"),\n")
+	buf.WriteString("\t\t\t\tquery.Param[string](\"eventType\"),\n")
+	buf.WriteString("\t\t\t\tquery.Param[string](\"aggregateId\"),\n")
+	buf.WriteString("\t\t\t\tquery.Param[string](\"payload\"),\n")
+	buf.WriteString("\t\t\t).\n")
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("\t// ListPendingOutboxEvents returns the oldest unpublished events still being retried.\n")
+	buf.WriteString("\tquery.MustDefineMany(\"ListPendingOutboxEvents\",\n")
+	buf.WriteString("\t\tquery.From(schema.OutboxEvents).\n")
+	buf.WriteString("\t\t\tSelect(\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.Id(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.PublicId(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.Topic(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.EventType(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.AggregateId(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.Payload(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.Attempts(),\n")
+	buf.WriteString("\t\t\t).\n")
+	buf.WriteString("\t\t\tWhere(query.And(\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.PublishedAt().IsNull(),\n")
+	buf.WriteString("\t\t\t\tschema.OutboxEvents.Attempts().Lt(query.Param[int](\"maxAttempts\")),\n")
+	buf.WriteString("\t\t\t)).\n")
+	buf.WriteString("\t\t\tOrderBy(schema.OutboxEvents.Id().Asc()).\n")
+	buf.WriteString("\t\t\tLimit(query.Param[int64](\"limit\")).\n")
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("\t// MarkOutboxEventPublished records that the sink accepted an event.\n")
+	buf.WriteString("\tquery.MustDefineExec(\"MarkOutboxEventPublished\",\n")
+	buf.WriteString("\t\tquery.Update(schema.OutboxEvents).\n")
+	buf.WriteString("\t\t\tSet(schema.OutboxEvents.PublishedAt(), query.Now()).\n")
+	buf.WriteString("\t\t\tWhere(schema.OutboxEvents.Id().Eq(query.Param[int64](\"id\"))).\n")
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("\t// RecordOutboxEventFailure records a failed publish attempt.\n")
+	buf.WriteString("\tquery.MustDefineExec(\"RecordOutboxEventFailure\",\n")
+	buf.WriteString("\t\tquery.Update(schema.OutboxEvents).\n")
+	buf.WriteString("\t\t\tSet(schema.OutboxEvents.Attempts(), query.Param[int](\"attempts\")).\n")
+	buf.WriteString("\t\t\tSet(schema.OutboxEvents.LastError(), query.Param[*string](\"lastError\")).\n")
+	buf.WriteString("\t\t\tWhere(schema.OutboxEvents.Id().Eq(query.Param[int64](\"id\"))).\n")
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("}\n")
+
+	return formatQuerydefSource(buf.Bytes())
+}
+
+// GenerateOutboxSink generates outbox/sink.go, the user-owned file that
+// picks where the worker's relay publishes events. It starts out logging
+// events so the outbox drains until a broker is wired up.
+func GenerateOutboxSink(modulePath string) []byte {
+	return []byte(fmt.Sprintf(`// Package outbox configures where the worker publishes outbox events.
+// This file is yours: shipq creates it once and never overwrites it.
+package outbox
+
+import (
+	"%s/config"
+	"%s/shipq/lib/channel"
+)
+
+// Sink returns the sink the worker's outbox relay publishes events to.
+//
+// Replace the log sink with your broker, passing event.PublicID as the
+// deduplication key so redelivered events can be recognized, e.g. for NATS
+// JetStream:
+//
+//	return channel.OutboxSinkFunc(func(ctx context.Context, e channel.OutboxEvent) error {
+//		msg := nats.NewMsg(e.Topic + "." + e.EventType)
+//		msg.Header.Set(nats.MsgIdHdr, e.PublicID)
+//		msg.Data = e.Payload
+//		_, err := js.PublishMsg(ctx, msg)
+//		return err
+//	})
+func Sink() channel.OutboxSink {
+	return channel.LogOutboxSink(config.Logger)
+}
+`, modulePath, modulePath))
+}
@@ -0,0 +1,59 @@
+package channelgen
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+)
+
+func TestGenerateOutboxMigration(t *testing.T) {
+	f := gofile.Parse(t, "outbox_events.go", GenerateOutboxMigration("20260615120000", "example.com/myapp"))
+
+	fn := "Migrate_20260615120000_outbox_events"
+	f.AssertSignature(fn, "func Migrate_20260615120000_outbox_events(plan *migrate.MigrationPlan) error")
+	if args := f.Args(fn, "plan.AddEmptyTable"); len(args) != 1 || args[0][0] != `"outbox_events"` {
+		t.Errorf("expected one outbox_events table, got %q", args)
+	}
+	f.AssertStmts(fn,
+		`tb.String("public_id").Unique()`,
+		`tb.JSON("payload")`,
+		`tb.Integer("attempts").Default(0)`,
+		`tb.Datetime("published_at").Nullable().Indexed()`,
+	)
+}
+
+func TestGenerateOutboxQuerydefs(t *testing.T) {
+	f := gofile.Parse(t, "queries.go", GenerateOutboxQuerydefs("example.com/myapp"))
+	if f.AST.Name.Name != "outbox_events" {
+		t.Errorf("package = %s, want outbox_events", f.AST.Name.Name)
+	}
+
+	var names []string
+	for _, define := range []string{"query.MustDefineMany", "query.MustDefineExec"} {
+		for _, args := range f.Args("init", define) {
+			names = append(names, args[0])
+		}
+	}
+	if want := []string{`"ListPendingOutboxEvents"`, `"InsertOutboxEvent"`, `"MarkOutboxEventPublished"`, `"RecordOutboxEventFailure"`}; !slices.Equal(names, want) {
+		t.Errorf("queries = %q, want %q", names, want)
+	}
+
+	// Pending events are unpublished ones still under the retry limit,
+	// oldest first.
+	if got, want := f.Args("init", "query.And"), [][]string{{
+		"schema.OutboxEvents.PublishedAt().IsNull()",
+		`schema.OutboxEvents.Attempts().Lt(query.Param[int]("maxAttempts"))`,
+	}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("pending conditions = %q, want %q", got, want)
+	}
+	f.AssertExprs("init", "schema.OutboxEvents.Id().Asc()")
+}
+
+func TestGenerateOutboxSink(t *testing.T) {
+	f := gofile.Parse(t, "sink.go", GenerateOutboxSink("example.com/myapp"))
+
+	// The sink defaults to the log sink.
+	f.AssertSignature("Sink", "func Sink() channel.OutboxSink")
+	f.AssertStmts("Sink", "return channel.LogOutboxSink(config.Logger)")
+}
@@ -3,7 +3,8 @@ package channelgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 const generatedQuerydefHeader = "// Code generated by shipq. DO NOT EDIT.\n"
@@ -149,7 +150,7 @@ func GenerateJobResultsQuerydefs(modulePath string, hasTenancy bool, hasAuth boo
 // formatQuerydefSource attempts to gofmt the source. If formatting fails, the
 // raw source is returned so the caller can debug the output.
 func formatQuerydefSource(src []byte) []byte {
-	formatted, err := format.Source(src)
+	formatted, err := phase.FormatSource(src)
 	if err != nil {
 		return src
 	}
@@ -3,10 +3,10 @@ package channelgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateTypedChannel generates the Go source code for a typed channel wrapper.
@@ -19,7 +19,7 @@ import (
 //   - A sealed ClientMessage interface (only if mid-stream FromClient types exist)
 //   - ClientMessage implementations for each non-dispatch FromClient type
 //   - A TypedChannel struct wrapping *channel.Channel
-//   - TypedChannelFromContext(ctx) helper
+//   - TypedChannelFromContext(ctx) and MustTypedChannelFromContext(ctx) helpers
 //   - Send(ctx, ServerMessage) and convenience Send<Type> methods
 //   - Type-specific Receive<Type> methods for non-dispatch FromClient types
 //   - ClientMessageHandler struct (only if mid-stream types exist)
@@ -121,11 +121,26 @@ func GenerateTypedChannel(ch codegen.SerializedChannelInfo, modulePath string) (
 	fmt.Fprintf(&buf, "\traw *channel.Channel\n")
 	fmt.Fprintf(&buf, "}\n\n")
 
-	// --- TypedChannelFromContext ---
+	// --- TypedChannelFromContext / MustTypedChannelFromContext ---
 	fmt.Fprintf(&buf, "// TypedChannelFromContext extracts the raw channel from the context and wraps it\n")
 	fmt.Fprintf(&buf, "// in a TypedChannel for type-safe operations.\n")
-	fmt.Fprintf(&buf, "func TypedChannelFromContext(ctx context.Context) *TypedChannel {\n")
-	fmt.Fprintf(&buf, "\treturn &TypedChannel{raw: channel.FromContext(ctx)}\n")
+	fmt.Fprintf(&buf, "// Returns (nil, false) if no channel is present.\n")
+	fmt.Fprintf(&buf, "func TypedChannelFromContext(ctx context.Context) (*TypedChannel, bool) {\n")
+	fmt.Fprintf(&buf, "\traw, ok := channel.FromContext(ctx)\n")
+	fmt.Fprintf(&buf, "\tif !ok {\n")
+	fmt.Fprintf(&buf, "\t\treturn nil, false\n")
+	fmt.Fprintf(&buf, "\t}\n")
+	fmt.Fprintf(&buf, "\treturn &TypedChannel{raw: raw}, true\n")
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// MustTypedChannelFromContext is like TypedChannelFromContext but panics when\n")
+	fmt.Fprintf(&buf, "// no channel is present -- the handler is running outside the channel runtime.\n")
+	fmt.Fprintf(&buf, "func MustTypedChannelFromContext(ctx context.Context) *TypedChannel {\n")
+	fmt.Fprintf(&buf, "\ttc, ok := TypedChannelFromContext(ctx)\n")
+	fmt.Fprintf(&buf, "\tif !ok {\n")
+	fmt.Fprintf(&buf, "\t\tpanic(%q)\n", "MustTypedChannelFromContext: no "+ch.Name+" channel in context -- is this handler running under the generated worker?")
+	fmt.Fprintf(&buf, "\t}\n")
+	fmt.Fprintf(&buf, "\treturn tc\n")
 	fmt.Fprintf(&buf, "}\n\n")
 
 	// --- Send(ctx, msg ServerMessage) error (only if FromServer types exist) ---
@@ -209,7 +224,7 @@ func GenerateTypedChannel(ch codegen.SerializedChannelInfo, modulePath string) (
 		fmt.Fprintf(&buf, "}\n\n")
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format generated channel code for %s: %w\nunformatted:\n%s", ch.Name, err, buf.String())
@@ -222,9 +222,12 @@ func TestGenerateTypedChannel_Unidirectional(t *testing.T) {
 	}
 
 	// TypedChannelFromContext
-	if !strings.Contains(src, "func TypedChannelFromContext(ctx context.Context) *TypedChannel") {
+	if !strings.Contains(src, "func TypedChannelFromContext(ctx context.Context) (*TypedChannel, bool)") {
 		t.Error("expected TypedChannelFromContext function")
 	}
+	if !strings.Contains(src, "func MustTypedChannelFromContext(ctx context.Context) *TypedChannel") {
+		t.Error("expected MustTypedChannelFromContext function")
+	}
 }
 
 func TestGenerateTypedChannel_Bidirectional(t *testing.T) {
@@ -513,7 +516,7 @@ func TestGenerateTypedChannel_RawChannelUsed(t *testing.T) {
 	}
 
 	// FromContext delegates to channel.FromContext
-	if !strings.Contains(src, "channel.FromContext(ctx)") {
+	if !strings.Contains(src, "raw, ok := channel.FromContext(ctx)") {
 		t.Error("expected TypedChannelFromContext to delegate to channel.FromContext")
 	}
 
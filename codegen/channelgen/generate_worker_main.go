@@ -3,9 +3,9 @@ package channelgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // WorkerGenConfig holds all configuration needed to generate cmd/worker/main.go.
@@ -19,6 +19,33 @@ type WorkerGenConfig struct {
 	CentrifugoHMACSecret string
 	CentrifugoWSURL      string
 	AutoMigrate          bool // true when [db] auto_migrate = true and schema.json exists; emits migrate-on-boot block
+	ScheduledViews       []ScheduledView
+	RetentionPurges      []RetentionPurge
+	Outbox               *OutboxRelay // non-nil when [outbox] is configured; runs the outbox relay
+}
+
+// ScheduledView is a materialized view the worker refreshes on a schedule.
+type ScheduledView struct {
+	Name          string // view name, used as the job name in logs
+	RefreshMethod string // runner method, e.g. RefreshDailyOrderTotals
+	Schedule      string // cron expression or @descriptor
+}
+
+// RetentionPurge is a table whose expired rows the worker deletes on a
+// schedule, per its RetainFor policy.
+type RetentionPurge struct {
+	Table            string // table name, used as the job name in logs
+	PurgeMethod      string // runner method, e.g. PurgeAuditEvents
+	RetentionSeconds int64  // rows created earlier than this many seconds ago are purged
+	Schedule         string // cron expression or @descriptor
+}
+
+// OutboxRelay configures the worker's relay from the outbox_events table to
+// the sink returned by the project's outbox.Sink.
+type OutboxRelay struct {
+	PollIntervalMs int64 // pause between polls when the outbox is drained
+	BatchSize      int   // events read per poll
+	MaxAttempts    int   // failed publishes after which an event is no longer retried
 }
 
 // GenerateWorkerMain generates the Go source code for cmd/worker/main.go.
@@ -38,8 +65,11 @@ func GenerateWorkerMain(cfg WorkerGenConfig) ([]byte, error) {
 
 	generateWorkerImports(&buf, cfg)
 	generateWorkerMainFunc(&buf, cfg)
+	if cfg.Outbox != nil {
+		generateWorkerOutboxStore(&buf)
+	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format worker main.go: %w\nunformatted:\n%s", err, buf.String())
@@ -56,6 +86,9 @@ func generateWorkerImports(buf *bytes.Buffer, cfg WorkerGenConfig) {
 	buf.WriteString("\t\"os\"\n")
 	buf.WriteString("\t\"os/signal\"\n")
 	buf.WriteString("\t\"syscall\"\n")
+	if len(cfg.RetentionPurges) > 0 || cfg.Outbox != nil {
+		buf.WriteString("\t\"time\"\n")
+	}
 	buf.WriteString("\n")
 
 	// Config package
@@ -66,6 +99,10 @@ func generateWorkerImports(buf *bytes.Buffer, cfg WorkerGenConfig) {
 	channelPkg := cfg.ModulePath + "/shipq/lib/channel"
 	fmt.Fprintf(buf, "\t%q\n", channelPkg)
 
+	if cfg.Outbox != nil {
+		fmt.Fprintf(buf, "\tappoutbox %q\n", cfg.ModulePath+"/outbox")
+	}
+
 	// Auto-migrate import
 	if cfg.AutoMigrate {
 		migratePkg := cfg.ModulePath + "/shipq/db/migrate"
@@ -102,6 +139,12 @@ func generateWorkerImports(buf *bytes.Buffer, cfg WorkerGenConfig) {
 func generateWorkerMainFunc(buf *bytes.Buffer, cfg WorkerGenConfig) {
 	buf.WriteString("func main() {\n")
 
+	// TLS policy check (same as http_main_gen.go)
+	buf.WriteString("\tif err := config.CheckDatabaseTLS(); err != nil {\n")
+	buf.WriteString("\t\tconfig.Logger.Error(\"insecure database connection\", \"error\", err.Error())\n")
+	buf.WriteString("\t\tos.Exit(1)\n")
+	buf.WriteString("\t}\n\n")
+
 	// Database connection (same pattern as http_main_gen.go)
 	buf.WriteString("\t// Database connection\n")
 	buf.WriteString("\tdriver, dsn := config.ParseDatabaseURL(config.Settings.DATABASE_URL)\n")
@@ -112,6 +155,11 @@ func generateWorkerMainFunc(buf *bytes.Buffer, cfg WorkerGenConfig) {
 	buf.WriteString("\t}\n")
 	buf.WriteString("\tdefer db.Close()\n\n")
 
+	buf.WriteString("\tif err := config.ConfigureDatabasePool(db); err != nil {\n")
+	buf.WriteString("\t\tconfig.Logger.Error(\"invalid database pool settings\", \"error\", err.Error())\n")
+	buf.WriteString("\t\tos.Exit(1)\n")
+	buf.WriteString("\t}\n\n")
+
 	buf.WriteString("\tif err := db.Ping(); err != nil {\n")
 	buf.WriteString("\t\tconfig.Logger.Error(\"failed to connect to database\", \"error\", err.Error())\n")
 	buf.WriteString("\t\tos.Exit(1)\n")
@@ -210,6 +258,52 @@ func generateWorkerMainFunc(buf *bytes.Buffer, cfg WorkerGenConfig) {
 	buf.WriteString("\tctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)\n")
 	buf.WriteString("\tdefer cancel()\n\n")
 
+	if len(cfg.ScheduledViews) > 0 {
+		buf.WriteString("\t// Refresh materialized views on their schedules.\n")
+		for _, v := range cfg.ScheduledViews {
+			fmt.Fprintf(buf, "\tif err := channel.StartSchedule(ctx, %q, %q, runner.%s, config.Logger); err != nil {\n", v.Name, v.Schedule, v.RefreshMethod)
+			buf.WriteString("\t\tconfig.Logger.Error(\"invalid refresh schedule\", \"error\", err.Error())\n")
+			buf.WriteString("\t\tos.Exit(1)\n")
+			buf.WriteString("\t}\n")
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(cfg.RetentionPurges) > 0 {
+		buf.WriteString("\t// Purge rows past their tables' retention policies. rows_purged in\n")
+		buf.WriteString("\t// the \"retention purge\" log line records how many each run deleted.\n")
+		for _, p := range cfg.RetentionPurges {
+			job := "purge " + p.Table
+			fmt.Fprintf(buf, "\tif err := channel.StartSchedule(ctx, %q, %q, func(ctx context.Context) error {\n", job, p.Schedule)
+			fmt.Fprintf(buf, "\t\tresult, err := runner.%s(ctx, queries.%sParams{\n", p.PurgeMethod, p.PurgeMethod)
+			fmt.Fprintf(buf, "\t\t\tCutoff: time.Now().Add(-%d * time.Second),\n", p.RetentionSeconds)
+			buf.WriteString("\t\t})\n")
+			buf.WriteString("\t\tif err != nil {\n")
+			buf.WriteString("\t\t\treturn err\n")
+			buf.WriteString("\t\t}\n")
+			buf.WriteString("\t\tpurged, _ := result.RowsAffected()\n")
+			fmt.Fprintf(buf, "\t\tconfig.Logger.Info(\"retention purge\", \"table\", %q, \"rows_purged\", purged)\n", p.Table)
+			buf.WriteString("\t\treturn nil\n")
+			buf.WriteString("\t}, config.Logger); err != nil {\n")
+			buf.WriteString("\t\tconfig.Logger.Error(\"invalid retention schedule\", \"error\", err.Error())\n")
+			buf.WriteString("\t\tos.Exit(1)\n")
+			buf.WriteString("\t}\n")
+		}
+		buf.WriteString("\n")
+	}
+
+	if o := cfg.Outbox; o != nil {
+		buf.WriteString("\t// Publish the events the API's write transactions record in the outbox.\n")
+		buf.WriteString("\toutboxRelay := &channel.OutboxRelay{\n")
+		buf.WriteString("\t\tStore:       outboxStore{runner},\n")
+		buf.WriteString("\t\tSink:        appoutbox.Sink(),\n")
+		fmt.Fprintf(buf, "\t\tBatchSize:   %d,\n", o.BatchSize)
+		fmt.Fprintf(buf, "\t\tMaxAttempts: %d,\n", o.MaxAttempts)
+		buf.WriteString("\t\tLogger:      config.Logger,\n")
+		buf.WriteString("\t}\n")
+		fmt.Fprintf(buf, "\tgo outboxRelay.Run(ctx, %d*time.Millisecond)\n\n", o.PollIntervalMs)
+	}
+
 	buf.WriteString("\tconfig.Logger.Info(\"starting worker\", \"concurrency\", 10)\n")
 	buf.WriteString("\tif err := queue.StartWorker(ctx, \"shipq-worker\", 10); err != nil {\n")
 	buf.WriteString("\t\tif ctx.Err() != nil {\n")
@@ -223,6 +317,54 @@ func generateWorkerMainFunc(buf *bytes.Buffer, cfg WorkerGenConfig) {
 	buf.WriteString("}\n")
 }
 
+// generateWorkerOutboxStore writes the channel.OutboxStore implementation
+// the outbox relay reads and updates outbox_events through.
+func generateWorkerOutboxStore(buf *bytes.Buffer) {
+	buf.WriteString(`
+// outboxStore implements channel.OutboxStore with the outbox querydefs.
+type outboxStore struct {
+	runner *dbrunner.QueryRunner
+}
+
+func (s outboxStore) Pending(ctx context.Context, limit, maxAttempts int) ([]channel.OutboxEvent, error) {
+	rows, err := s.runner.ListPendingOutboxEvents(ctx, queries.ListPendingOutboxEventsParams{
+		MaxAttempts: maxAttempts,
+		Limit:       int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]channel.OutboxEvent, len(rows))
+	for i, r := range rows {
+		events[i] = channel.OutboxEvent{
+			ID:          r.Id,
+			PublicID:    r.PublicId,
+			Topic:       r.Topic,
+			EventType:   r.EventType,
+			AggregateID: r.AggregateId,
+			Payload:     r.Payload,
+			Attempts:    int(r.Attempts),
+		}
+	}
+	return events, nil
+}
+
+func (s outboxStore) MarkPublished(ctx context.Context, id int64) error {
+	_, err := s.runner.MarkOutboxEventPublished(ctx, queries.MarkOutboxEventPublishedParams{Id: id})
+	return err
+}
+
+func (s outboxStore) MarkFailed(ctx context.Context, id int64, attempts int, errMsg string) error {
+	_, err := s.runner.RecordOutboxEventFailure(ctx, queries.RecordOutboxEventFailureParams{
+		Attempts:  attempts,
+		LastError: &errMsg,
+		Id:        id,
+	})
+	return err
+}
+`)
+}
+
 // getWorkerDriverImport returns the import path for the database driver.
 func getWorkerDriverImport(dialect string) string {
 	switch dialect {
@@ -232,6 +374,8 @@ func getWorkerDriverImport(dialect string) string {
 		return "modernc.org/sqlite"
 	case "mysql":
 		return "github.com/go-sql-driver/mysql"
+	case "mssql":
+		return "github.com/microsoft/go-mssqldb"
 	default:
 		return "github.com/go-sql-driver/mysql"
 	}
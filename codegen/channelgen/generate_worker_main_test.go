@@ -3,10 +3,12 @@ package channelgen
 import (
 	"go/parser"
 	"go/token"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 )
 
 func TestGenerateWorkerMain_SingleChannel(t *testing.T) {
@@ -353,6 +355,12 @@ func TestGenerateWorkerMain_DBConnection(t *testing.T) {
 	if !strings.Contains(codeStr, "defer db.Close()") {
 		t.Error("expected defer db.Close()")
 	}
+
+	// Should enforce the TLS policy before connecting
+	tlsIdx := strings.Index(codeStr, "config.CheckDatabaseTLS()")
+	if tlsIdx < 0 || tlsIdx > strings.Index(codeStr, "sql.Open") {
+		t.Error("expected config.CheckDatabaseTLS() before sql.Open")
+	}
 }
 
 func TestGenerateWorkerMain_NoDispatchMessage_SkipsChannel(t *testing.T) {
@@ -848,3 +856,127 @@ func TestGenerateWorkerMain_DuplicatePackagePaths_ImportedOnce(t *testing.T) {
 		t.Errorf("expected exactly 1 import of chatbot package, got %d", count)
 	}
 }
+
+func TestGenerateWorkerMain_ScheduledViews(t *testing.T) {
+	cfg := WorkerGenConfig{
+		ModulePath: "example.com/myapp",
+		DBDialect:  "postgres",
+		ScheduledViews: []ScheduledView{
+			{Name: "daily_order_totals", RefreshMethod: "RefreshDailyOrderTotals", Schedule: "@hourly"},
+		},
+	}
+
+	code, err := GenerateWorkerMain(cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkerMain() error = %v", err)
+	}
+	codeStr := string(code)
+
+	want := `channel.StartSchedule(ctx, "daily_order_totals", "@hourly", runner.RefreshDailyOrderTotals, config.Logger)`
+	if !strings.Contains(codeStr, want) {
+		t.Errorf("expected %s in generated code", want)
+	}
+	if strings.Index(codeStr, "StartSchedule") > strings.Index(codeStr, "queue.StartWorker") {
+		t.Error("schedules should start before the worker blocks")
+	}
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "main.go", code, parser.AllErrors); err != nil {
+		t.Fatalf("generated code is not valid Go: %v", err)
+	}
+
+	cfg.ScheduledViews = nil
+	code, err = GenerateWorkerMain(cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkerMain() error = %v", err)
+	}
+	if strings.Contains(string(code), "StartSchedule") {
+		t.Error("no schedules should be started without scheduled views")
+	}
+}
+
+func TestGenerateWorkerMain_RetentionPurges(t *testing.T) {
+	cfg := WorkerGenConfig{
+		ModulePath: "example.com/myapp",
+		DBDialect:  "sqlite",
+		RetentionPurges: []RetentionPurge{
+			{Table: "audit_events", PurgeMethod: "PurgeAuditEvents", RetentionSeconds: 7776000, Schedule: "@daily"},
+		},
+	}
+
+	code, err := GenerateWorkerMain(cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkerMain() error = %v", err)
+	}
+	f := gofile.Parse(t, "main.go", code)
+
+	if args := f.Args("main", "channel.StartSchedule"); len(args) != 1 || !slices.Equal(args[0][:3], []string{"ctx", `"purge audit_events"`, `"@daily"`}) {
+		t.Errorf("expected one daily purge schedule, got %q", args)
+	}
+	f.AssertStmts("main",
+		`result, err := runner.PurgeAuditEvents(ctx, queries.PurgeAuditEventsParams{
+			Cutoff: time.Now().Add(-7776000 * time.Second),
+		})`,
+		"purged, _ := result.RowsAffected()",
+		`config.Logger.Info("retention purge", "table", "audit_events", "rows_purged", purged)`,
+	)
+
+	cfg.RetentionPurges = nil
+	code, err = GenerateWorkerMain(cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkerMain() error = %v", err)
+	}
+	if gofile.Parse(t, "main.go", code).HasImport("time") {
+		t.Error("time should only be imported for retention purges")
+	}
+}
+
+func TestGenerateWorkerMain_OutboxRelay(t *testing.T) {
+	cfg := WorkerGenConfig{
+		ModulePath: "example.com/myapp",
+		DBDialect:  "postgres",
+		Outbox:     &OutboxRelay{PollIntervalMs: 1500, BatchSize: 50, MaxAttempts: 5},
+	}
+
+	code, err := GenerateWorkerMain(cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkerMain() error = %v", err)
+	}
+	f := gofile.Parse(t, "main.go", code)
+	if !f.HasImport("example.com/myapp/outbox") {
+		t.Error("expected the app's outbox package to be imported")
+	}
+	f.AssertStmts("main",
+		`outboxRelay := &channel.OutboxRelay{
+			Store:       outboxStore{runner},
+			Sink:        appoutbox.Sink(),
+			BatchSize:   50,
+			MaxAttempts: 5,
+			Logger:      config.Logger,
+		}`,
+		"go outboxRelay.Run(ctx, 1500*time.Millisecond)",
+	)
+	if !f.Before("main", "go outboxRelay.Run(ctx, 1500*time.Millisecond)", "queue.StartWorker") {
+		t.Error("the relay must start before the blocking StartWorker call")
+	}
+
+	// outboxStore implements channel.OutboxStore with the outbox querydefs.
+	f.AssertSignature("outboxStore.Pending", "func (s outboxStore) Pending(ctx context.Context, limit, maxAttempts int) ([]channel.OutboxEvent, error)")
+	f.AssertSignature("outboxStore.MarkPublished", "func (s outboxStore) MarkPublished(ctx context.Context, id int64) error")
+	f.AssertSignature("outboxStore.MarkFailed", "func (s outboxStore) MarkFailed(ctx context.Context, id int64, attempts int, errMsg string) error")
+	f.AssertStmts("outboxStore.MarkPublished", "_, err := s.runner.MarkOutboxEventPublished(ctx, queries.MarkOutboxEventPublishedParams{Id: id})")
+	f.AssertStmts("outboxStore.MarkFailed", `_, err := s.runner.RecordOutboxEventFailure(ctx, queries.RecordOutboxEventFailureParams{
+		Attempts:  attempts,
+		LastError: &errMsg,
+		Id:        id,
+	})`)
+
+	cfg.Outbox = nil
+	code, err = GenerateWorkerMain(cfg)
+	if err != nil {
+		t.Fatalf("GenerateWorkerMain() error = %v", err)
+	}
+	if f := gofile.Parse(t, "main.go", code); f.HasType("outboxStore") || f.HasImport("example.com/myapp/outbox") {
+		t.Error("no outbox code should be generated without [outbox]")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // ModuleInfo contains module path and relative subpath information.
@@ -87,6 +89,10 @@ type SerializedHandlerInfo struct {
 	PackagePath  string                `json:"package_path"`
 	RequireAuth  bool                  `json:"require_auth"`
 	OptionalAuth bool                  `json:"optional_auth"`
+	Deprecated   bool                  `json:"deprecated,omitempty"`
+	Sunset       string                `json:"sunset,omitempty"`
+	Feature      string                `json:"feature,omitempty"`
+	RawBodyTypes []string              `json:"raw_body_types,omitempty"`
 	Request      *SerializedStructInfo `json:"request,omitempty"`
 	Response     *SerializedStructInfo `json:"response,omitempty"`
 }
@@ -128,6 +134,7 @@ func EnsureDir(path string) error {
 // WriteFileIfChanged writes content to a file only if it differs from existing content.
 // Returns true if the file was written, false if unchanged.
 func WriteFileIfChanged(path string, content []byte) (bool, error) {
+	defer phase.Start(phase.Writing)()
 	existing, err := os.ReadFile(path)
 	if err == nil && string(existing) == string(content) {
 		return false, nil
@@ -148,6 +155,7 @@ func WriteFileIfChanged(path string, content []byte) (bool, error) {
 //  2. File exists and starts with GeneratedHeader -> overwrite if content changed.
 //  3. File exists but does NOT start with GeneratedHeader -> return an error.
 func WriteGeneratedFile(path string, content []byte) (bool, error) {
+	defer phase.Start(phase.Writing)()
 	existing, err := os.ReadFile(path)
 	if err != nil {
 		// File doesn't exist (or can't be read) -- safe to write.
@@ -174,6 +182,26 @@ func WriteGeneratedFile(path string, content []byte) (bool, error) {
 	return true, nil
 }
 
+// RemoveGeneratedFile deletes a shipq-generated file that is no longer
+// needed. A missing file, or one without GeneratedHeader, is left alone and
+// reported as not removed.
+func RemoveGeneratedFile(path string) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !hasGeneratedHeader(existing) {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // hasGeneratedHeader reports whether data begins with the GeneratedHeader line.
 func hasGeneratedHeader(data []byte) bool {
 	s := string(data)
@@ -317,6 +345,8 @@ func DriverImportForDialect(dialect string) string {
 		return `_ "github.com/go-sql-driver/mysql"`
 	case "sqlite":
 		return `_ "modernc.org/sqlite"`
+	case "mssql":
+		return `_ "github.com/microsoft/go-mssqldb"`
 	default:
 		return `_ "modernc.org/sqlite"` // safe default
 	}
@@ -400,6 +430,11 @@ func parseDatabaseURL(rawURL string) (driver, dsn string) {
 	} else if len(dsn) >= 7 && dsn[:7] == "sqlite:" {
 		dsn = dsn[7:]
 	}
+	// Open an in-memory database as a URI so SQLite honours cache=shared
+	// and every pooled connection sees the same schema.
+	if strings.HasPrefix(dsn, ":memory:") {
+		dsn = "file:" + dsn
+	}
 	// Set a busy timeout so concurrent connections wait instead of
 	// returning SQLITE_BUSY immediately.
 	if !strings.Contains(dsn, "busy_timeout") {
@@ -489,6 +524,11 @@ func ParseDatabaseURL(rawURL string) (driver, dsn string) {
 	} else if len(dsn) >= 7 && dsn[:7] == "sqlite:" {
 		dsn = dsn[7:]
 	}
+	// Open an in-memory database as a URI so SQLite honours cache=shared
+	// and every pooled connection sees the same schema.
+	if strings.HasPrefix(dsn, ":memory:") {
+		dsn = "file:" + dsn
+	}
 	// Set a busy timeout so concurrent connections wait instead of
 	// returning SQLITE_BUSY immediately.
 	if !strings.Contains(dsn, "busy_timeout") {
@@ -368,6 +368,30 @@ func TestWriteGeneratedFile(t *testing.T) {
 	})
 }
 
+func TestRemoveGeneratedFile(t *testing.T) {
+	dir := t.TempDir()
+	generated := filepath.Join(dir, "generated.go")
+	user := filepath.Join(dir, "user.go")
+	os.WriteFile(generated, []byte(codegen.GeneratedHeader+"\npackage foo\n"), 0644)
+	os.WriteFile(user, []byte("package foo\n"), 0644)
+
+	if removed, err := codegen.RemoveGeneratedFile(generated); err != nil || !removed {
+		t.Errorf("generated file: removed=%v err=%v, want true, nil", removed, err)
+	}
+	if _, err := os.Stat(generated); !os.IsNotExist(err) {
+		t.Error("generated file should be gone")
+	}
+	if removed, err := codegen.RemoveGeneratedFile(user); err != nil || removed {
+		t.Errorf("user file: removed=%v err=%v, want false, nil", removed, err)
+	}
+	if _, err := os.Stat(user); err != nil {
+		t.Error("user file should be kept")
+	}
+	if removed, err := codegen.RemoveGeneratedFile(filepath.Join(dir, "missing.go")); err != nil || removed {
+		t.Errorf("missing file: removed=%v err=%v, want false, nil", removed, err)
+	}
+}
+
 func TestWriteFileIfChanged(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -3,8 +3,13 @@ package crud
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/shipq/shipq/codegen/quotagen"
+	"github.com/shipq/shipq/config"
 	"github.com/shipq/shipq/db/portsql/codegen"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/inifile"
@@ -22,12 +27,32 @@ type CRUDConfig struct {
 	// Default is false (DESC, newest first)
 	GlobalOrderAsc bool
 
+	// GlobalListCacheMs is the default list micro-cache TTL from
+	// [db] list_cache_ms (0 = disabled)
+	GlobalListCacheMs int
+
 	// TableOpts holds per-table CRUD options, keyed by table name
 	TableOpts map[string]codegen.CRUDOptions
 }
 
 // LoadCRUDConfig reads scope and order configuration from shipq.ini.
-// It merges global defaults from [db] with per-table overrides from [crud.<table>] sections.
+// It merges global defaults from [db] with per-table overrides from [crud.<table>] sections,
+// which may also mark a table's routes deprecated (deprecated = true, sunset = YYYY-MM-DD)
+// cap the rows accepted by its import endpoint (import_max_rows), run
+// imports of uploaded files as worker jobs (import_job = true), name the
+// point column searched by its proximity endpoint (near), give create
+// requests API-side defaults (default.<column> = value), and set or disable
+// the list micro-cache (list_cache_ms, defaulting to [db] list_cache_ms),
+// record its writes as outbox events (outbox = true, which requires an
+// [outbox] section), and cap the live rows per scope
+// (max_rows_per_scope, which requires a [quotas] section and a scope),
+// name the state columns that get check-and-set updates (state_columns),
+// add a bulk create endpoint (bulk = true), call lifecycle hooks from
+// its write handlers (hooks = true), rename the JSON field of a column
+// (json.<column> = name), delete its rows outright instead of
+// soft-deleting them (no_soft_delete = true), and add an endpoint that
+// restores soft-deleted rows (restore = true).
+// Route paths follow [naming] path_segments and JSON names [naming] json_case.
 // The tables parameter is used to determine which tables to generate options for.
 func LoadCRUDConfig(ini *inifile.File, tables []string) (*CRUDConfig, error) {
 	cfg := &CRUDConfig{
@@ -47,11 +72,27 @@ func LoadCRUDConfig(ini *inifile.File, tables []string) (*CRUDConfig, error) {
 	globalOrder := strings.ToLower(ini.Get("db", "order"))
 	cfg.GlobalOrderAsc = (globalOrder == "asc")
 
+	if v := ini.Get("db", "list_cache_ms"); v != "" {
+		n, err := parseListCacheMs(v)
+		if err != nil {
+			return nil, fmt.Errorf("[db] list_cache_ms: %w", err)
+		}
+		cfg.GlobalListCacheMs = n
+	}
+
+	naming, err := config.ParseNamingConfig(ini)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build options for each table
 	for _, tableName := range tables {
 		opts := codegen.CRUDOptions{
 			ScopeColumn: cfg.GlobalScope,
 			OrderAsc:    cfg.GlobalOrderAsc,
+			ListCacheMs: cfg.GlobalListCacheMs,
+			PathSegment: naming.PathSegment(tableName),
+			JSONCase:    naming.JSONCaseOrDefault(),
 		}
 
 		// Check for per-table override in [crud.<table>] section
@@ -68,6 +109,114 @@ func LoadCRUDConfig(ini *inifile.File, tables []string) (*CRUDConfig, error) {
 				tableOrder := strings.ToLower(section.Get("order"))
 				opts.OrderAsc = (tableOrder == "asc")
 			}
+
+			opts.Deprecated = strings.ToLower(section.Get("deprecated")) == "true"
+			if sunset := section.Get("sunset"); sunset != "" {
+				if _, err := time.Parse("2006-01-02", sunset); err != nil {
+					return nil, fmt.Errorf("[%s] sunset = %q must be a YYYY-MM-DD date", sectionName, sunset)
+				}
+				opts.Deprecated = true
+				opts.Sunset = sunset
+			}
+
+			if v := section.Get("import_max_rows"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					return nil, fmt.Errorf("[%s] import_max_rows = %q must be a positive integer", sectionName, v)
+				}
+				opts.ImportMaxRows = n
+			}
+
+			if strings.ToLower(section.Get("import_job")) == "true" {
+				if ini.Section("workers") == nil || ini.Section("files") == nil {
+					return nil, fmt.Errorf("[%s] import_job = true requires workers and file uploads; run `shipq workers` and `shipq files` first", sectionName)
+				}
+				opts.ImportJob = true
+			}
+
+			opts.NearColumn = section.Get("near")
+
+			if strings.ToLower(section.Get("outbox")) == "true" {
+				if ini.Section("outbox") == nil {
+					return nil, fmt.Errorf("[%s] outbox = true requires the outbox table; run `shipq outbox` first", sectionName)
+				}
+				opts.Outbox = true
+			}
+
+			if v := section.Get("max_rows_per_scope"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					return nil, fmt.Errorf("[%s] max_rows_per_scope = %q must be a positive integer", sectionName, v)
+				}
+				if ini.Section("quotas") == nil {
+					return nil, fmt.Errorf("[%s] max_rows_per_scope requires the scope_quotas table; run `shipq quotas` first", sectionName)
+				}
+				if opts.ScopeColumn == "" {
+					return nil, fmt.Errorf("[%s] max_rows_per_scope requires a scope column ([db] scope or scope = ...)", sectionName)
+				}
+				status, err := parseQuotaStatus(ini.Get("quotas", "status"))
+				if err != nil {
+					return nil, err
+				}
+				opts.MaxRowsPerScope = n
+				opts.QuotaStatus = status
+			}
+
+			opts.Bulk = strings.ToLower(section.Get("bulk")) == "true"
+			opts.Hooks = strings.ToLower(section.Get("hooks")) == "true"
+			opts.Filters = strings.ToLower(section.Get("filters")) == "true"
+			opts.NoSoftDelete = strings.ToLower(section.Get("no_soft_delete")) == "true"
+			opts.Restore = strings.ToLower(section.Get("restore")) == "true"
+			if opts.Restore && opts.NoSoftDelete {
+				return nil, fmt.Errorf("[%s] restore = true cannot be combined with no_soft_delete = true", sectionName)
+			}
+
+			for _, column := range strings.Split(section.Get("state_columns"), ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					opts.StateColumns = append(opts.StateColumns, column)
+				}
+			}
+
+			for _, column := range strings.Split(section.Get("sort"), ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					opts.Sorts = append(opts.Sorts, column)
+				}
+			}
+
+			if section.HasKey("list_cache_ms") {
+				n, err := parseListCacheMs(section.Get("list_cache_ms"))
+				if err != nil {
+					return nil, fmt.Errorf("[%s] list_cache_ms: %w", sectionName, err)
+				}
+				opts.ListCacheMs = n
+			}
+
+			for _, kv := range section.Values {
+				if column, ok := strings.CutPrefix(kv.Key, "json."); ok {
+					if column == "" {
+						return nil, fmt.Errorf("[%s] %s must name a column, e.g. json.sku", sectionName, kv.Key)
+					}
+					if !jsonNamePattern.MatchString(kv.Value) {
+						return nil, fmt.Errorf("[%s] %s = %q must be a JSON name of letters, digits and underscores", sectionName, kv.Key, kv.Value)
+					}
+					if opts.JSONNames == nil {
+						opts.JSONNames = make(map[string]string)
+					}
+					opts.JSONNames[column] = kv.Value
+					continue
+				}
+				column, ok := strings.CutPrefix(kv.Key, "default.")
+				if !ok {
+					continue
+				}
+				if column == "" {
+					return nil, fmt.Errorf("[%s] %s must name a column, e.g. default.status", sectionName, kv.Key)
+				}
+				if opts.Defaults == nil {
+					opts.Defaults = make(map[string]string)
+				}
+				opts.Defaults[column] = kv.Value
+			}
 		}
 
 		cfg.TableOpts[tableName] = opts
@@ -76,6 +225,34 @@ func LoadCRUDConfig(ini *inifile.File, tables []string) (*CRUDConfig, error) {
 	return cfg, nil
 }
 
+// jsonNamePattern matches the names a json.<column> override may give a
+// field: identifiers, so the TypeScript client can use them unquoted.
+var jsonNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseListCacheMs parses a list micro-cache TTL in milliseconds.
+func parseListCacheMs(v string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q must be a non-negative number of milliseconds", v)
+	}
+	return n, nil
+}
+
+// parseQuotaStatus parses [quotas] status, the HTTP status of quota
+// errors. Empty means quotagen.DefaultStatus.
+func parseQuotaStatus(v string) (int, error) {
+	if v == "" {
+		return quotagen.DefaultStatus, nil
+	}
+	switch v {
+	case "402":
+		return 402, nil
+	case "403":
+		return 403, nil
+	}
+	return 0, fmt.Errorf("[quotas] status = %q must be 402 or 403", v)
+}
+
 // InferScopeTable infers the referenced table name from a scope column name.
 // For example:
 //   - organization_id -> organizations
@@ -2,6 +2,7 @@ package crud
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -119,6 +120,314 @@ order = asc
 	}
 }
 
+func TestLoadCRUDConfig_Deprecation(t *testing.T) {
+	ini := parseINI(t, `
+[db]
+database_url = postgres://localhost:5432/myapp
+
+[crud.legacy_orders]
+deprecated = true
+
+[crud.old_invoices]
+sunset = 2027-01-31
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"users", "legacy_orders", "old_invoices"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.TableOpts["users"].Deprecated {
+		t.Error("users should not be deprecated")
+	}
+	if opts := cfg.TableOpts["legacy_orders"]; !opts.Deprecated || opts.Sunset != "" {
+		t.Errorf("legacy_orders = %+v, want Deprecated without Sunset", opts)
+	}
+	if opts := cfg.TableOpts["old_invoices"]; !opts.Deprecated || opts.Sunset != "2027-01-31" {
+		t.Errorf("old_invoices = %+v, want Deprecated with Sunset 2027-01-31", opts)
+	}
+}
+
+func TestLoadCRUDConfig_InvalidSunset(t *testing.T) {
+	ini := parseINI(t, `
+[crud.old_invoices]
+sunset = next year
+`)
+	_, err := LoadCRUDConfig(ini, []string{"old_invoices"})
+	if err == nil {
+		t.Fatal("expected error for invalid sunset date")
+	}
+	if !strings.Contains(err.Error(), "crud.old_invoices") {
+		t.Errorf("error should name the section, got: %v", err)
+	}
+}
+
+func TestLoadCRUDConfig_ImportMaxRows(t *testing.T) {
+	ini := parseINI(t, `
+[crud.contacts]
+import_max_rows = 50000
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"users", "contacts"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.TableOpts["contacts"].ImportMaxRows; got != 50000 {
+		t.Errorf("contacts ImportMaxRows = %d, want 50000", got)
+	}
+	if got := cfg.TableOpts["users"].ImportMaxRows; got != 0 {
+		t.Errorf("users ImportMaxRows = %d, want 0 (generator default)", got)
+	}
+
+	ini = parseINI(t, `
+[crud.contacts]
+import_max_rows = lots
+`)
+	if _, err := LoadCRUDConfig(ini, []string{"contacts"}); err == nil || !strings.Contains(err.Error(), "import_max_rows") {
+		t.Errorf("expected import_max_rows error, got: %v", err)
+	}
+}
+
+func TestLoadCRUDConfig_ListCacheMs(t *testing.T) {
+	ini := parseINI(t, `
+[db]
+list_cache_ms = 1000
+
+[crud.audit_logs]
+list_cache_ms = 0
+
+[crud.feeds]
+list_cache_ms = 250
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "audit_logs", "feeds"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for table, want := range map[string]int{"posts": 1000, "audit_logs": 0, "feeds": 250} {
+		if got := cfg.TableOpts[table].ListCacheMs; got != want {
+			t.Errorf("%s ListCacheMs = %d, want %d", table, got, want)
+		}
+	}
+
+	ini = parseINI(t, `
+[crud.feeds]
+list_cache_ms = -5
+`)
+	if _, err := LoadCRUDConfig(ini, []string{"feeds"}); err == nil || !strings.Contains(err.Error(), "[crud.feeds] list_cache_ms") {
+		t.Errorf("expected list_cache_ms error, got: %v", err)
+	}
+}
+
+func TestLoadCRUDConfig_Near(t *testing.T) {
+	ini := parseINI(t, `
+[crud.stores]
+near = location
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"stores", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.TableOpts["stores"].NearColumn; got != "location" {
+		t.Errorf("stores NearColumn = %q, want %q", got, "location")
+	}
+	if got := cfg.TableOpts["users"].NearColumn; got != "" {
+		t.Errorf("users NearColumn = %q, want empty", got)
+	}
+}
+
+func TestLoadCRUDConfig_StateColumns(t *testing.T) {
+	ini := parseINI(t, `
+[crud.orders]
+state_columns = status, payment_state
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"orders", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := cfg.TableOpts["orders"].StateColumns, []string{"status", "payment_state"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders StateColumns = %v, want %v", got, want)
+	}
+	if got := cfg.TableOpts["users"].StateColumns; got != nil {
+		t.Errorf("users StateColumns = %v, want none", got)
+	}
+}
+
+func TestLoadCRUDConfig_Sorts(t *testing.T) {
+	ini := parseINI(t, `
+[crud.posts]
+sort = title, -updated_at
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := cfg.TableOpts["posts"].Sorts, []string{"title", "-updated_at"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("posts Sorts = %v, want %v", got, want)
+	}
+	if got := cfg.TableOpts["users"].Sorts; got != nil {
+		t.Errorf("users Sorts = %v, want none", got)
+	}
+}
+
+func TestLoadCRUDConfig_Bulk(t *testing.T) {
+	ini := parseINI(t, `
+[crud.posts]
+bulk = true
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TableOpts["posts"].Bulk {
+		t.Error("posts Bulk = false, want true")
+	}
+	if cfg.TableOpts["users"].Bulk {
+		t.Error("users Bulk = true, want false")
+	}
+}
+
+func TestLoadCRUDConfig_Hooks(t *testing.T) {
+	ini := parseINI(t, `
+[crud.posts]
+hooks = true
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TableOpts["posts"].Hooks {
+		t.Error("posts Hooks = false, want true")
+	}
+	if cfg.TableOpts["users"].Hooks {
+		t.Error("users Hooks = true, want false")
+	}
+}
+
+func TestLoadCRUDConfig_Filters(t *testing.T) {
+	ini := parseINI(t, `
+[crud.posts]
+filters = true
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TableOpts["posts"].Filters {
+		t.Error("posts Filters = false, want true")
+	}
+	if cfg.TableOpts["users"].Filters {
+		t.Error("users Filters = true, want false")
+	}
+}
+
+func TestLoadCRUDConfig_NoSoftDelete(t *testing.T) {
+	ini := parseINI(t, `
+[db]
+scope = organization_id
+
+[crud.events]
+scope =
+order = asc
+no_soft_delete = true
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"events", "posts"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events := cfg.TableOpts["events"]
+	if !events.NoSoftDelete || !events.OrderAsc || events.ScopeColumn != "" {
+		t.Errorf("events opts = %+v, want unscoped, ascending and without soft delete", events)
+	}
+	if posts := cfg.TableOpts["posts"]; posts.NoSoftDelete || posts.ScopeColumn != "organization_id" {
+		t.Errorf("posts opts = %+v, want the [db] defaults", posts)
+	}
+}
+
+func TestLoadCRUDConfig_Restore(t *testing.T) {
+	ini := parseINI(t, `
+[crud.posts]
+restore = true
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TableOpts["posts"].Restore {
+		t.Error("posts Restore = false, want true")
+	}
+	if cfg.TableOpts["users"].Restore {
+		t.Error("users Restore = true, want false")
+	}
+
+	ini = parseINI(t, `
+[crud.posts]
+restore = true
+no_soft_delete = true
+`)
+	if _, err := LoadCRUDConfig(ini, []string{"posts"}); err == nil || !strings.Contains(err.Error(), "no_soft_delete") {
+		t.Errorf("expected restore with no_soft_delete to be refused, got %v", err)
+	}
+}
+
+func TestLoadCRUDConfig_JSONNames(t *testing.T) {
+	ini := parseINI(t, `
+[naming]
+json_case = camel
+
+[crud.products]
+json.sku = productCode
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"products", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.TableOpts["products"].JSONNames; !reflect.DeepEqual(got, map[string]string{"sku": "productCode"}) {
+		t.Errorf("products JSONNames = %v", got)
+	}
+	for _, table := range []string{"products", "users"} {
+		if got := cfg.TableOpts[table].JSONCase; got != "camel" {
+			t.Errorf("%s JSONCase = %q, want camel", table, got)
+		}
+	}
+
+	cfg, _ = LoadCRUDConfig(parseINI(t, "[db]\n"), []string{"users"})
+	if got := cfg.TableOpts["users"].JSONCase; got != "snake" {
+		t.Errorf("default JSONCase = %q, want snake", got)
+	}
+
+	for _, bad := range []string{"json. = x", "json.sku = product-code", "json.sku ="} {
+		if _, err := LoadCRUDConfig(parseINI(t, "[crud.products]\n"+bad+"\n"), []string{"products"}); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestLoadCRUDConfig_Defaults(t *testing.T) {
+	ini := parseINI(t, `
+[crud.posts]
+default.status = draft
+default.priority = 3
+order = asc
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"status": "draft", "priority": "3"}
+	if got := cfg.TableOpts["posts"].Defaults; !reflect.DeepEqual(got, want) {
+		t.Errorf("posts Defaults = %v, want %v", got, want)
+	}
+	if got := cfg.TableOpts["users"].Defaults; got != nil {
+		t.Errorf("users Defaults = %v, want nil", got)
+	}
+
+	ini = parseINI(t, `
+[crud.posts]
+default. = draft
+`)
+	if _, err := LoadCRUDConfig(ini, []string{"posts"}); err == nil || !strings.Contains(err.Error(), "crud.posts") {
+		t.Errorf("expected an error naming the section, got: %v", err)
+	}
+}
+
 func TestLoadCRUDConfig_ExplicitScopeTable(t *testing.T) {
 	ini := parseINI(t, `
 [db]
@@ -410,3 +719,116 @@ order = asc
 		t.Errorf("events.OrderAsc = %v, want true (overridden)", cfg.TableOpts["events"].OrderAsc)
 	}
 }
+
+func TestLoadCRUDConfig_PathSegments(t *testing.T) {
+	cfg, err := LoadCRUDConfig(parseINI(t, "[db]\n"), []string{"blog_posts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.TableOpts["blog_posts"].PathSegment; got != "blog_posts" {
+		t.Errorf("default PathSegment = %q, want blog_posts", got)
+	}
+
+	cfg, err = LoadCRUDConfig(parseINI(t, "[naming]\npath_segments = singular\n"), []string{"blog_posts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.TableOpts["blog_posts"].PathSegment; got != "blog_post" {
+		t.Errorf("singular PathSegment = %q, want blog_post", got)
+	}
+
+	if _, err := LoadCRUDConfig(parseINI(t, "[naming]\npath_segments = dual\n"), []string{"blog_posts"}); err == nil {
+		t.Error("expected an error for an unknown path_segments style")
+	}
+}
+
+func TestLoadCRUDConfig_Outbox(t *testing.T) {
+	ini := parseINI(t, `
+[outbox]
+
+[crud.posts]
+outbox = true
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TableOpts["posts"].Outbox {
+		t.Error("posts Outbox = false, want true")
+	}
+	if cfg.TableOpts["users"].Outbox {
+		t.Error("users Outbox = true, want false")
+	}
+
+	ini = parseINI(t, `
+[crud.posts]
+outbox = true
+`)
+	if _, err := LoadCRUDConfig(ini, []string{"posts"}); err == nil || !strings.Contains(err.Error(), "shipq outbox") {
+		t.Errorf("outbox without [outbox]: err = %v, want an error pointing to shipq outbox", err)
+	}
+}
+
+func TestLoadCRUDConfig_ImportJob(t *testing.T) {
+	ini := parseINI(t, `
+[workers]
+
+[files]
+
+[crud.posts]
+import_job = true
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.TableOpts["posts"].ImportJob || cfg.TableOpts["users"].ImportJob {
+		t.Errorf("ImportJob = %v for posts, %v for users", cfg.TableOpts["posts"].ImportJob, cfg.TableOpts["users"].ImportJob)
+	}
+
+	ini = parseINI(t, `
+[workers]
+
+[crud.posts]
+import_job = true
+`)
+	if _, err := LoadCRUDConfig(ini, []string{"posts"}); err == nil || !strings.Contains(err.Error(), "shipq files") {
+		t.Errorf("import_job without [files]: err = %v, want an error pointing to shipq files", err)
+	}
+}
+
+func TestLoadCRUDConfig_MaxRowsPerScope(t *testing.T) {
+	ini := parseINI(t, `
+[db]
+scope = organization_id
+
+[quotas]
+status = 402
+
+[crud.posts]
+max_rows_per_scope = 100
+`)
+	cfg, err := LoadCRUDConfig(ini, []string{"posts", "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.TableOpts["posts"]; got.MaxRowsPerScope != 100 || got.QuotaStatus != 402 {
+		t.Errorf("posts quota = %d (status %d), want 100 (status 402)", got.MaxRowsPerScope, got.QuotaStatus)
+	}
+	if cfg.TableOpts["users"].MaxRowsPerScope != 0 {
+		t.Error("users should have no quota")
+	}
+
+	for _, tc := range []struct {
+		name, ini, want string
+	}{
+		{"no [quotas]", "[db]\nscope = organization_id\n[crud.posts]\nmax_rows_per_scope = 100\n", "shipq quotas"},
+		{"unscoped", "[quotas]\n[crud.posts]\nmax_rows_per_scope = 100\n", "scope column"},
+		{"not positive", "[db]\nscope = organization_id\n[quotas]\n[crud.posts]\nmax_rows_per_scope = 0\n", "positive integer"},
+		{"bad status", "[db]\nscope = organization_id\n[quotas]\nstatus = 429\n[crud.posts]\nmax_rows_per_scope = 5\n", "402 or 403"},
+	} {
+		if _, err := LoadCRUDConfig(parseINI(t, tc.ini), []string{"posts"}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tc.name, err, tc.want)
+		}
+	}
+}
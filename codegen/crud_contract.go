@@ -5,6 +5,7 @@ package codegen
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/shipq/shipq/dbstrings"
 )
@@ -33,12 +34,33 @@ func (c CRUDContract) ListMethodName(tableName string) string {
 	return fmt.Sprintf("List%s", dbstrings.ToPascalCase(tableName))
 }
 
+// NearMethodName returns the method name for the proximity search over a
+// table's point column.
+// Example: "stores" -> "ListStoresNear"
+func (c CRUDContract) NearMethodName(tableName string) string {
+	return c.ListMethodName(tableName) + "Near"
+}
+
 // CreateMethodName returns the method name for creating a record.
 // Example: "accounts" -> "CreateAccount"
 func (c CRUDContract) CreateMethodName(tableName string) string {
 	return fmt.Sprintf("Create%s", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)))
 }
 
+// CreateBatchMethodName returns the method name for creating several
+// records in one multi-row INSERT.
+// Example: "accounts" -> "CreateAccountsBatch"
+func (c CRUDContract) CreateBatchMethodName(tableName string) string {
+	return fmt.Sprintf("Create%sBatch", dbstrings.ToPascalCase(tableName))
+}
+
+// UpsertMethodName returns the method name for inserting a record or, when
+// its public ID exists, updating it.
+// Example: "accounts" -> "UpsertAccount"
+func (c CRUDContract) UpsertMethodName(tableName string) string {
+	return fmt.Sprintf("Upsert%s", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)))
+}
+
 // UpdateMethodName returns the method name for updating a record by public ID.
 // Example: "accounts" -> "UpdateAccountByPublicID"
 func (c CRUDContract) UpdateMethodName(tableName string) string {
@@ -51,6 +73,13 @@ func (c CRUDContract) SoftDeleteMethodName(tableName string) string {
 	return fmt.Sprintf("SoftDelete%sByPublicID", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)))
 }
 
+// DeleteMethodName returns the method name for deleting a record by public
+// ID outright, for tables with no_soft_delete.
+// Example: "accounts" -> "DeleteAccountByPublicID"
+func (c CRUDContract) DeleteMethodName(tableName string) string {
+	return fmt.Sprintf("Delete%sByPublicID", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)))
+}
+
 // AdminListMethodName returns the method name for the admin list (includes soft-deleted).
 // Example: "accounts" -> "AdminListAccounts"
 func (c CRUDContract) AdminListMethodName(tableName string) string {
@@ -63,6 +92,87 @@ func (c CRUDContract) UndeleteMethodName(tableName string) string {
 	return fmt.Sprintf("Undelete%sByPublicID", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)))
 }
 
+// RestoreMethodName returns the method name for restoring a soft-deleted
+// record by public ID, which reports whether a deleted row was restored.
+// Example: "accounts" -> "RestoreAccountByPublicID"
+func (c CRUDContract) RestoreMethodName(tableName string) string {
+	return fmt.Sprintf("Restore%sByPublicID", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)))
+}
+
+// ActionMethodName returns the method name for a custom action on a record
+// by public ID, as scaffolded by `shipq handler generate <table> --action`.
+// Example: ("posts", "mark_paid") -> "MarkPaidPostByPublicID"
+func (c CRUDContract) ActionMethodName(tableName, action string) string {
+	return fmt.Sprintf("%s%sByPublicID", dbstrings.ToPascalCase(action), dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)))
+}
+
+// PurgeMethodName returns the method name for the retention purge of a table
+// declared with RetainFor.
+// Example: "audit_events" -> "PurgeAuditEvents"
+func (c CRUDContract) PurgeMethodName(tableName string) string {
+	return fmt.Sprintf("Purge%s", dbstrings.ToPascalCase(tableName))
+}
+
+// SoftUniqueMethodName returns the method name of the query that locks a
+// live (not soft-deleted) row holding the values of a soft-unique index.
+// Example: ("posts", ["author_id", "slug"]) -> "LockActivePostByAuthorIdAndSlug"
+func (c CRUDContract) SoftUniqueMethodName(tableName string, columns []string) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		parts[i] = dbstrings.ToPascalCase(col)
+	}
+	return fmt.Sprintf("LockActive%sBy%s", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)), strings.Join(parts, "And"))
+}
+
+// CountInScopeMethodName returns the method name of the query counting the
+// live rows of one scope, which enforces max_rows_per_scope.
+// Example: "posts" -> "CountPostsInScope"
+func (c CRUDContract) CountInScopeMethodName(tableName string) string {
+	return fmt.Sprintf("Count%sInScope", dbstrings.ToPascalCase(tableName))
+}
+
+// StateTransitionMethodName returns the method name of the check-and-set
+// update of a state column, which changes it only from an expected value.
+// Example: ("users", "status") -> "UpdateUserStatusIf"
+func (c CRUDContract) StateTransitionMethodName(tableName, column string) string {
+	return fmt.Sprintf("Update%s%sIf", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)), dbstrings.ToPascalCase(column))
+}
+
+// PreloadMethodName returns the method name that batch-loads the rows a
+// <name>_id References column points at for a slice of get results.
+// Example: ("posts", "author") -> "PreloadPostAuthors"
+func (c CRUDContract) PreloadMethodName(tableName, relation string) string {
+	return fmt.Sprintf("Preload%s%s", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)), dbstrings.ToPascalCase(dbstrings.ToPlural(relation)))
+}
+
+// ListPreloadMethodName is PreloadMethodName for a slice of list items.
+// Example: ("posts", "author") -> "PreloadListPostsAuthors"
+func (c CRUDContract) ListPreloadMethodName(tableName, relation string) string {
+	return fmt.Sprintf("Preload%s%s", c.ListMethodName(tableName), dbstrings.ToPascalCase(dbstrings.ToPlural(relation)))
+}
+
+// RelationListMethodName returns the method name of the query listing the
+// rows a record is linked to through a junction table, which the get-one
+// handler streams into its response.
+// Example: ("posts", "tags") -> "ListPostTags"
+func (c CRUDContract) RelationListMethodName(tableName, relation string) string {
+	return fmt.Sprintf("List%s%s", dbstrings.ToPascalCase(dbstrings.ToSingular(tableName)), dbstrings.ToPascalCase(relation))
+}
+
+// EachMethodName returns the name of the streaming variant of a ReturnMany
+// query, which calls a function per row instead of returning a slice.
+// Example: "ListPostComments" -> "EachListPostComments"
+func (c CRUDContract) EachMethodName(queryName string) string {
+	return "Each" + queryName
+}
+
+// IterMethodName returns the name of the iterator variant of a ReturnMany
+// query registered with query.Iter().
+// Example: "ListUsers" -> "ListUsersIter"
+func (c CRUDContract) IterMethodName(queryName string) string {
+	return queryName + "Iter"
+}
+
 // =============================================================================
 // Type Names (param and result structs in queries package)
 // =============================================================================
@@ -91,6 +201,12 @@ func (c CRUDContract) ListItemType(tableName string) string {
 	return fmt.Sprintf("List%sItem", dbstrings.ToPascalCase(tableName))
 }
 
+// PreloadedType returns the type name of a row attached by a Preload method.
+// Example: "users" -> "PreloadedUser"
+func (c CRUDContract) PreloadedType(tableName string) string {
+	return "Preloaded" + dbstrings.ToPascalCase(dbstrings.ToSingular(tableName))
+}
+
 // ListCursorType returns the type name for the pagination cursor.
 // Example: "accounts" -> "ListAccountsCursor"
 func (c CRUDContract) ListCursorType(tableName string) string {
@@ -177,8 +293,9 @@ func (c CRUDContract) DecodeCursorFunc(tableName string) string {
 // Context function names
 // =============================================================================
 
-// RunnerFromContextFunc is the function name for getting runner from context.
-const RunnerFromContextFunc = "RunnerFromContext"
+// MustRunnerFromContextFunc is the function name generated handlers call to
+// get the runner from context. It panics when the server wiring is missing.
+const MustRunnerFromContextFunc = "MustRunnerFromContext"
 
 // NewContextWithRunnerFunc is the function name for adding runner to context.
 const NewContextWithRunnerFunc = "NewContextWithRunner"
@@ -198,3 +315,14 @@ func (c CRUDContract) ResourceName(tableName string) string {
 func (c CRUDContract) PluralResourceName(tableName string) string {
 	return dbstrings.ToPascalCase(tableName)
 }
+
+// =============================================================================
+// Request validation (shared by handler and test generation)
+// =============================================================================
+
+// IsEmailColumn reports whether a column holds an email address, which
+// generated handlers validate as one: "email" or a name ending in "_email".
+// Generated tests must send a valid address for these columns.
+func (c CRUDContract) IsEmailColumn(columnName string) bool {
+	return columnName == "email" || strings.HasSuffix(columnName, "_email")
+}
@@ -25,6 +25,17 @@ func TestCRUDContract_MethodNames(t *testing.T) {
 		{"CreateMethodName users", "users", CRUD.CreateMethodName, "CreateUser"},
 		{"CreateMethodName user_profiles", "user_profiles", CRUD.CreateMethodName, "CreateUserProfile"},
 
+		// CountInScopeMethodName tests
+		{"CountInScopeMethodName posts", "posts", CRUD.CountInScopeMethodName, "CountPostsInScope"},
+
+		// CreateBatchMethodName tests
+		{"CreateBatchMethodName users", "users", CRUD.CreateBatchMethodName, "CreateUsersBatch"},
+		{"CreateBatchMethodName user_profiles", "user_profiles", CRUD.CreateBatchMethodName, "CreateUserProfilesBatch"},
+
+		// UpsertMethodName tests
+		{"UpsertMethodName users", "users", CRUD.UpsertMethodName, "UpsertUser"},
+		{"UpsertMethodName user_profiles", "user_profiles", CRUD.UpsertMethodName, "UpsertUserProfile"},
+
 		// UpdateMethodName tests
 		{"UpdateMethodName accounts", "accounts", CRUD.UpdateMethodName, "UpdateAccountByPublicID"},
 		{"UpdateMethodName users", "users", CRUD.UpdateMethodName, "UpdateUserByPublicID"},
@@ -34,6 +45,10 @@ func TestCRUDContract_MethodNames(t *testing.T) {
 		{"SoftDeleteMethodName accounts", "accounts", CRUD.SoftDeleteMethodName, "SoftDeleteAccountByPublicID"},
 		{"SoftDeleteMethodName users", "users", CRUD.SoftDeleteMethodName, "SoftDeleteUserByPublicID"},
 		{"SoftDeleteMethodName user_profiles", "user_profiles", CRUD.SoftDeleteMethodName, "SoftDeleteUserProfileByPublicID"},
+
+		// RestoreMethodName tests
+		{"RestoreMethodName users", "users", CRUD.RestoreMethodName, "RestoreUserByPublicID"},
+		{"RestoreMethodName user_profiles", "user_profiles", CRUD.RestoreMethodName, "RestoreUserProfileByPublicID"},
 	}
 
 	for _, tt := range tests {
@@ -44,6 +59,32 @@ func TestCRUDContract_MethodNames(t *testing.T) {
 			}
 		})
 	}
+
+	if got := CRUD.ActionMethodName("user_profiles", "mark_verified"); got != "MarkVerifiedUserProfileByPublicID" {
+		t.Errorf("ActionMethodName: got %q, want %q", got, "MarkVerifiedUserProfileByPublicID")
+	}
+	if got := CRUD.PurgeMethodName("audit_events"); got != "PurgeAuditEvents" {
+		t.Errorf("PurgeMethodName: got %q, want %q", got, "PurgeAuditEvents")
+	}
+	if got := CRUD.StateTransitionMethodName("users", "status"); got != "UpdateUserStatusIf" {
+		t.Errorf("StateTransitionMethodName: got %q, want %q", got, "UpdateUserStatusIf")
+	}
+	if got := CRUD.StateTransitionMethodName("user_profiles", "review_state"); got != "UpdateUserProfileReviewStateIf" {
+		t.Errorf("StateTransitionMethodName: got %q, want %q", got, "UpdateUserProfileReviewStateIf")
+	}
+
+	if got := CRUD.SoftUniqueMethodName("posts", []string{"author_id", "slug"}); got != "LockActivePostByAuthorIdAndSlug" {
+		t.Errorf("SoftUniqueMethodName: got %q, want %q", got, "LockActivePostByAuthorIdAndSlug")
+	}
+	if got := CRUD.PreloadMethodName("posts", "author"); got != "PreloadPostAuthors" {
+		t.Errorf("PreloadMethodName: got %q, want %q", got, "PreloadPostAuthors")
+	}
+	if got := CRUD.ListPreloadMethodName("posts", "author"); got != "PreloadListPostsAuthors" {
+		t.Errorf("ListPreloadMethodName: got %q, want %q", got, "PreloadListPostsAuthors")
+	}
+	if got := CRUD.PreloadedType("users"); got != "PreloadedUser" {
+		t.Errorf("PreloadedType: got %q, want %q", got, "PreloadedUser")
+	}
 }
 
 func TestCRUDContract_TypeNames(t *testing.T) {
@@ -149,8 +190,8 @@ func TestCRUDContract_ResourceNames(t *testing.T) {
 }
 
 func TestCRUDContract_Constants(t *testing.T) {
-	if RunnerFromContextFunc != "RunnerFromContext" {
-		t.Errorf("RunnerFromContextFunc = %q, want %q", RunnerFromContextFunc, "RunnerFromContext")
+	if MustRunnerFromContextFunc != "MustRunnerFromContext" {
+		t.Errorf("MustRunnerFromContextFunc = %q, want %q", MustRunnerFromContextFunc, "MustRunnerFromContext")
 	}
 	if NewContextWithRunnerFunc != "NewContextWithRunner" {
 		t.Errorf("NewContextWithRunnerFunc = %q, want %q", NewContextWithRunnerFunc, "NewContextWithRunner")
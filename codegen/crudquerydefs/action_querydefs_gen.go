@@ -0,0 +1,58 @@
+package crudquerydefs
+
+import (
+	"fmt"
+	"strings"
+
+	topcodegen "github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/codegen"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// GenerateActionQueryDef generates querydefs/<table>/<action>.go, the query
+// behind a custom action endpoint such as POST /posts/:id/publish. The file
+// is a starting point the user owns: it defines an UPDATE of one record by
+// public ID (and scope, when configured) whose SET clause is a placeholder
+// to replace with the columns the action changes.
+func GenerateActionQueryDef(cfg Config, action string) ([]byte, error) {
+	analysis := codegen.AnalyzeTable(cfg.Table)
+	if !analysis.HasPublicID {
+		return nil, fmt.Errorf("table %q has no public_id column; actions address records as /%s/:id", cfg.TableName, cfg.TableName)
+	}
+
+	schemaVar := dbstrings.ToPascalCase(cfg.TableName)
+	queryName := topcodegen.CRUD.ActionMethodName(cfg.TableName, action)
+
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("package %s\n\n", cfg.TableName))
+	buf.WriteString("import (\n")
+	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/shipq/db/schema"))
+	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/shipq/lib/db/portsql/query"))
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("func init() {\n")
+	buf.WriteString(fmt.Sprintf("\t// %s backs POST /%s/:id/%s.\n", queryName, cfg.TableName, action))
+	buf.WriteString("\t// Replace the placeholder Set with the columns this action changes,\n")
+	buf.WriteString("\t// then run `shipq db compile`.\n")
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineExec(%q,\n", queryName))
+	buf.WriteString(fmt.Sprintf("\t\tquery.Update(schema.%s).\n", schemaVar))
+	if analysis.HasUpdatedAt {
+		buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, query.Now()).\n", schemaCol(schemaVar, "updated_at")))
+	} else {
+		buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, %s).\n", schemaCol(schemaVar, "public_id"), paramExpr("string", "publicId")))
+	}
+
+	whereParts := []string{fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, "public_id"), paramExpr("string", "publicId"))}
+	if cfg.ScopeColumn != "" {
+		scopeMapping := codegen.MapColumnType(colByName(cfg.Table, cfg.ScopeColumn))
+		whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, cfg.ScopeColumn), paramExpr(scopeMapping.GoType, lowerCamel(cfg.ScopeColumn))))
+	}
+	if analysis.HasDeletedAt {
+		whereParts = append(whereParts, fmt.Sprintf("%s.IsNull()", schemaCol(schemaVar, "deleted_at")))
+	}
+	writeWhere(&buf, whereParts)
+	buf.WriteString("\t\t\tBuild())\n")
+	buf.WriteString("}\n")
+
+	return formatSource([]byte(buf.String()))
+}
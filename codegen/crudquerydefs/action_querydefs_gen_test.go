@@ -0,0 +1,51 @@
+package crudquerydefs
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestGenerateActionQueryDef(t *testing.T) {
+	code, err := GenerateActionQueryDef(Config{
+		ModulePath:  "example.com/myapp",
+		TableName:   "posts",
+		Table:       postsTable(),
+		ScopeColumn: "organization_id",
+	}, "publish")
+	if err != nil {
+		t.Fatalf("GenerateActionQueryDef() error = %v", err)
+	}
+	if bytes.Contains(code, []byte("DO NOT EDIT")) {
+		t.Error("action querydefs are user-owned and must not carry the generated header")
+	}
+	if f := gofile.Parse(t, "publish.go", code); f.AST.Name.Name != "posts" {
+		t.Errorf("package = %s, want posts", f.AST.Name.Name)
+	}
+	f := queryDefs(t, code)
+	f.AssertStmts("MustDefineExec.PublishPostByPublicID",
+		"query.Update(schema.Posts)",
+		"Set(schema.Posts.UpdatedAt(), query.Now())",
+	)
+	if got, want := conditions(f, "MustDefineExec.PublishPostByPublicID"), []string{
+		`schema.Posts.PublicId().Eq(query.Param[string]("publicId"))`,
+		`schema.Posts.OrganizationId().Eq(query.Param[int64]("organizationId"))`,
+		"schema.Posts.DeletedAt().IsNull()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("PublishPostByPublicID conditions = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateActionQueryDef_RequiresPublicID(t *testing.T) {
+	table := ddl.Table{
+		Name:    "settings",
+		Columns: []ddl.ColumnDefinition{{Name: "id", Type: ddl.BigintType, PrimaryKey: true}},
+	}
+	if _, err := GenerateActionQueryDef(Config{ModulePath: "m", TableName: "settings", Table: table}, "reset"); err == nil {
+		t.Error("expected error for a table without public_id")
+	}
+}
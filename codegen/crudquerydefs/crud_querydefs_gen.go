@@ -5,10 +5,11 @@ package crudquerydefs
 
 import (
 	"fmt"
-	"go/format"
+	"slices"
 	"strings"
 
 	topcodegen "github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/db/portsql/codegen"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/dbstrings"
@@ -22,6 +23,22 @@ type Config struct {
 	ScopeColumn string
 	Schema      map[string]ddl.Table // all tables (for FK resolution)
 	ExposeEmail bool
+	NearColumn  string // point column searched by List<Table>Near; empty to skip it
+	// MaxRowsPerScope, if positive, adds Count<Table>InScope, which the
+	// create handler uses to enforce the table's quota.
+	MaxRowsPerScope int
+	// StateColumns adds Update<Singular><Column>If, a check-and-set update
+	// of each listed column, for simple state machines.
+	StateColumns []string
+	// Filters adds the optional filters of codegen.ListFilters to the
+	// List<Table> query, when it is paginated.
+	Filters bool
+	// Sorts adds the sorts of codegen.ListSorts for these columns to the
+	// List<Table> query, which must be paginated.
+	Sorts []string
+	// NoSoftDelete ignores the table's deleted_at column: the queries
+	// don't filter on it and Delete<Singular>ByPublicID removes the row.
+	NoSoftDelete bool
 }
 
 // GenerateCRUDQueryDefs generates a Go source file containing query.MustDefine*
@@ -29,7 +46,32 @@ type Config struct {
 // references the schema package so it uses the same typed column helpers as
 // user-defined queries.
 func GenerateCRUDQueryDefs(cfg Config) ([]byte, error) {
+	if cfg.NearColumn != "" {
+		if err := validateNearColumn(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateSoftUniqueIndexes(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateStateColumns(cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Sorts) > 0 {
+		if err := validateListSorts(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MaxRowsPerScope > 0 {
+		if _, ok := findColumn(cfg.Table, cfg.ScopeColumn); !ok {
+			return nil, fmt.Errorf("max_rows_per_scope on %s requires its scope column %q", cfg.TableName, cfg.ScopeColumn)
+		}
+	}
+
 	analysis := codegen.AnalyzeTable(cfg.Table)
+	if cfg.NoSoftDelete {
+		analysis.HasDeletedAt = false
+	}
 
 	schemaPkg := cfg.ModulePath + "/shipq/db/schema"
 	queryPkg := cfg.ModulePath + "/shipq/lib/db/portsql/query"
@@ -58,9 +100,20 @@ func GenerateCRUDQueryDefs(cfg Config) ([]byte, error) {
 	schemaVar := dbstrings.ToPascalCase(cfg.TableName) // e.g. "Posts"
 
 	writeGetQuery(&buf, cfg, analysis, schemaVar)
+	writeRelationListQueries(&buf, cfg)
 	writeListQuery(&buf, cfg, analysis, schemaVar)
+	if cfg.NearColumn != "" {
+		writeNearQuery(&buf, cfg, analysis, schemaVar)
+	}
 	writeCreateQuery(&buf, cfg, analysis, schemaVar)
+	writeCreateBatchQuery(&buf, cfg, analysis, schemaVar)
+	writeUpsertQuery(&buf, cfg, analysis, schemaVar)
+	writeSoftUniqueQueries(&buf, cfg, schemaVar)
+	if cfg.MaxRowsPerScope > 0 {
+		writeCountInScopeQuery(&buf, cfg, analysis, schemaVar)
+	}
 	writeUpdateQuery(&buf, cfg, analysis, schemaVar)
+	writeStateTransitionQueries(&buf, cfg, analysis, schemaVar)
 	writeDeleteQuery(&buf, cfg, analysis, schemaVar)
 
 	buf.WriteString("}\n")
@@ -280,15 +333,7 @@ func writeGetQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnaly
 	}
 
 	// WHERE clause
-	whereCol := "public_id"
-	if !analysis.HasPublicID && analysis.PrimaryKey != nil {
-		whereCol = analysis.PrimaryKey.Name
-	}
-
-	mapping := codegen.MapColumnType(colByName(cfg.Table, whereCol))
-
-	var whereParts []string
-	whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, whereCol), paramExpr(mapping.GoType, lowerCamel(whereCol))))
+	whereParts := keyWhereParts(cfg, analysis, schemaVar)
 	if analysis.HasDeletedAt {
 		whereParts = append(whereParts, fmt.Sprintf("%s.IsNull()", schemaCol(schemaVar, "deleted_at")))
 	}
@@ -301,6 +346,46 @@ func writeGetQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnaly
 	buf.WriteString("\t\t\tBuild())\n\n")
 }
 
+// writeRelationListQueries emits one List<Singular><Relation> query per
+// many-to-many relation of the table, which the get-one handler streams
+// into its response through Each<Query>, e.g.:
+//
+//	query.MustDefineMany("ListPostTags",
+//	    query.From(schema.Tags).
+//	        Join(schema.PostTags).On(schema.PostTags.TagId().Eq(schema.Tags.Id())).
+//	        Select(schema.Tags.PublicId(), schema.Tags.Name()).
+//	        Where(schema.PostTags.PostId().Eq(<posts.id of publicId>)).
+//	        OrderBy(schema.Tags.Id().Asc()).
+//	        Build())
+func writeRelationListQueries(buf *strings.Builder, cfg Config) {
+	for _, rel := range codegen.EmbeddableManyToMany(cfg.TableName, cfg.Schema) {
+		target := cfg.Schema[rel.ToTable]
+		targetVar := dbstrings.ToPascalCase(rel.ToTable)
+		junctionVar := dbstrings.ToPascalCase(rel.JunctionTable)
+		queryName := topcodegen.CRUD.RelationListMethodName(cfg.TableName, rel.Name())
+
+		buf.WriteString(fmt.Sprintf("\tquery.MustDefineMany(%q,\n", queryName))
+		buf.WriteString(fmt.Sprintf("\t\tquery.From(schema.%s).\n", targetVar))
+		buf.WriteString(fmt.Sprintf("\t\t\tJoin(schema.%s).On(%s.Eq(%s)).\n",
+			junctionVar, schemaCol(junctionVar, rel.JunctionFKTo), schemaCol(targetVar, "id")))
+		buf.WriteString("\t\t\tSelect(\n")
+		for _, col := range codegen.EmbeddableColumns(target) {
+			buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", schemaCol(targetVar, col.Name)))
+		}
+		buf.WriteString("\t\t\t).\n")
+
+		whereParts := []string{fmt.Sprintf("%s.Eq(%s)", schemaCol(junctionVar, rel.JunctionFKFrom), fkSubquery(cfg.TableName, "publicId"))}
+		if codegen.AnalyzeTable(target).HasDeletedAt {
+			whereParts = append(whereParts, fmt.Sprintf("%s.IsNull()", schemaCol(targetVar, "deleted_at")))
+		}
+		writeWhere(buf, whereParts)
+		if _, ok := findColumn(target, "id"); ok {
+			buf.WriteString(fmt.Sprintf("\t\t\tOrderBy(%s.Asc()).\n", schemaCol(targetVar, "id")))
+		}
+		buf.WriteString("\t\t\tBuild())\n\n")
+	}
+}
+
 // ---------- LIST ----------
 
 func writeListQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
@@ -384,27 +469,160 @@ func writeListQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnal
 		buf.WriteString(fmt.Sprintf("\t\t%s.Desc(),\n", schemaCol(schemaVar, "created_at")))
 		buf.WriteString(fmt.Sprintf("\t\t%s.Desc(),\n", schemaCol(schemaVar, "public_id")))
 		buf.WriteString("\t)\n\n")
+		if cfg.Filters {
+			writeListFilters(buf, cfg, queryName, schemaVar)
+		}
+		if len(cfg.Sorts) > 0 {
+			writeListSorts(buf, cfg, queryName, schemaVar)
+		}
 	} else {
 		buf.WriteString("\t\t\tBuild())\n\n")
 	}
 }
 
-// ---------- CREATE ----------
+// writeListFilters emits the optional filters of the List query, e.g.:
+//
+//	query.MustDefineFilters("ListPosts",
+//	    query.FilterEq(schema.Posts.Status(), "status"),
+//	    query.FilterGe(schema.Posts.CreatedAt(), "created_after"),
+//	    query.FilterLt(schema.Posts.CreatedAt(), "created_before"),
+//	)
+func writeListFilters(buf *strings.Builder, cfg Config, queryName, schemaVar string) {
+	filters := codegen.ListFilters(cfg.Table, cfg.ScopeColumn)
+	if len(filters) == 0 {
+		return
+	}
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineFilters(%q,\n", queryName))
+	for _, f := range filters {
+		constructor := "FilterEq"
+		switch f.Range {
+		case "after":
+			constructor = "FilterGe"
+		case "before":
+			constructor = "FilterLt"
+		}
+		buf.WriteString(fmt.Sprintf("\t\tquery.%s(%s, %q),\n", constructor, schemaCol(schemaVar, f.Column.Name), f.Param))
+	}
+	buf.WriteString("\t)\n\n")
+}
 
-func writeCreateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
-	singular := dbstrings.ToPascalCase(dbstrings.ToSingular(cfg.TableName))
-	queryName := fmt.Sprintf("Create%s", singular)
+// validateListSorts checks the sort columns of cfg and that the List
+// query they order is paginated.
+func validateListSorts(cfg Config) error {
+	if _, err := codegen.ListSorts(cfg.Table, cfg.ScopeColumn, cfg.Sorts); err != nil {
+		return err
+	}
+	if analysis := codegen.AnalyzeTable(cfg.Table); !analysis.HasCreatedAt || !analysis.HasPublicID {
+		return fmt.Errorf("sort on %s requires a paginated list, which needs created_at and public_id", cfg.TableName)
+	}
+	return nil
+}
 
-	buf.WriteString(fmt.Sprintf("\tquery.MustDefineOne(%q,\n", queryName))
-	buf.WriteString(fmt.Sprintf("\t\tquery.InsertInto(schema.%s).\n", schemaVar))
+// writeListSorts emits the sorts of the List query, e.g.:
+//
+//	query.MustDefineSorts("ListPosts",
+//	    query.SortBy("title", schema.Posts.Title().Asc(), schema.Posts.PublicId().Asc()),
+//	    query.SortBy("updated_at", schema.Posts.UpdatedAt().Desc(), schema.Posts.PublicId().Desc()),
+//	)
+func writeListSorts(buf *strings.Builder, cfg Config, queryName, schemaVar string) {
+	sorts, _ := codegen.ListSorts(cfg.Table, cfg.ScopeColumn, cfg.Sorts)
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineSorts(%q,\n", queryName))
+	for _, s := range sorts {
+		dir := "Asc"
+		if s.Desc {
+			dir = "Desc"
+		}
+		buf.WriteString(fmt.Sprintf("\t\tquery.SortBy(%q, %s.%s(), %s.%s()),\n", s.Name(),
+			schemaCol(schemaVar, s.Column.Name), dir, schemaCol(schemaVar, "public_id"), dir))
+	}
+	buf.WriteString("\t)\n\n")
+}
 
-	// Columns
-	buf.WriteString("\t\t\tColumns(\n")
+// ---------- NEAR ----------
 
-	type insertCol struct {
-		colName string
-		value   string
+// validateNearColumn checks that cfg.NearColumn names a point column.
+func validateNearColumn(cfg Config) error {
+	for _, col := range cfg.Table.Columns {
+		if col.Name != cfg.NearColumn {
+			continue
+		}
+		if col.Type != ddl.PointType {
+			return fmt.Errorf("near column %s.%s must be a point column, got %s", cfg.TableName, col.Name, col.Type)
+		}
+		return nil
 	}
+	return fmt.Errorf("near column %q not found in table %q", cfg.NearColumn, cfg.TableName)
+}
+
+// writeNearQuery emits List<Table>Near, which returns the rows whose
+// NearColumn lies within radius meters of (lat, lng), nearest first, with the
+// distance selected as distance_meters.
+func writeNearQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
+	queryName := topcodegen.CRUD.NearMethodName(cfg.TableName)
+
+	var fkCols []ddl.ColumnDefinition
+	var fkRefTables []string
+	var plainCols []ddl.ColumnDefinition
+	for _, col := range cfg.Table.Columns {
+		if col.Name == "id" || col.Name == "author_account_id" {
+			continue
+		}
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			continue
+		}
+		if col.References != "" {
+			fkCols = append(fkCols, col)
+			fkRefTables = append(fkRefTables, col.References)
+			continue
+		}
+		plainCols = append(plainCols, col)
+	}
+	joinPlan := buildJoinPlan(fkCols, fkRefTables, analysis.HasAuthorAccountID, cfg.ExposeEmail)
+
+	point := schemaCol(schemaVar, cfg.NearColumn)
+	lat, lng := paramExpr("float64", "lat"), paramExpr("float64", "lng")
+
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineMany(%q,\n", queryName))
+	buf.WriteString(fmt.Sprintf("\t\tquery.From(schema.%s).\n", schemaVar))
+	for _, j := range joinPlan {
+		emitJoin(buf, schemaVar, j)
+	}
+	buf.WriteString("\t\t\tSelect(\n")
+	for _, col := range plainCols {
+		buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", schemaCol(schemaVar, col.Name)))
+	}
+	buf.WriteString("\t\t\t).\n")
+	for _, j := range joinPlan {
+		emitSelectAs(buf, j)
+	}
+	buf.WriteString(fmt.Sprintf("\t\t\tSelectExprAs(%s.DistanceFrom(%s, %s), \"distance_meters\").\n", point, lat, lng))
+
+	whereParts := []string{fmt.Sprintf("%s.WithinRadius(%s, %s, %s)", point, lat, lng, paramExpr("float64", "radius"))}
+	if analysis.HasDeletedAt {
+		whereParts = append(whereParts, fmt.Sprintf("%s.IsNull()", schemaCol(schemaVar, "deleted_at")))
+	}
+	if cfg.ScopeColumn != "" {
+		scopeMapping := codegen.MapColumnType(colByName(cfg.Table, cfg.ScopeColumn))
+		whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, cfg.ScopeColumn), paramExpr(scopeMapping.GoType, lowerCamel(cfg.ScopeColumn))))
+	}
+	writeWhere(buf, whereParts)
+
+	buf.WriteString(fmt.Sprintf("\t\t\tOrderBy(query.OrderByExpr{Expr: %s.DistanceFrom(%s, %s)}).\n", point, lat, lng))
+	buf.WriteString(fmt.Sprintf("\t\t\tLimit(%s).\n", paramExpr("int64", "limit")))
+	buf.WriteString("\t\t\tBuild())\n\n")
+}
+
+// ---------- CREATE ----------
+
+// insertCol is a column written by the create queries and the value
+// written to it.
+type insertCol struct {
+	colName string
+	value   string
+}
+
+// createInsertCols returns the columns the create queries insert.
+func createInsertCols(cfg Config, analysis codegen.TableAnalysis) []insertCol {
 	var insertCols []insertCol
 
 	// public_id first
@@ -446,25 +664,46 @@ func writeCreateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAn
 			value:   value,
 		})
 	}
+	return insertCols
+}
 
+// writeInsertColumnsAndValues writes the Columns(...) and Values(...)
+// calls of a create query.
+func writeInsertColumnsAndValues(buf *strings.Builder, insertCols []insertCol, schemaVar string) {
+	buf.WriteString("\t\t\tColumns(\n")
 	for _, ic := range insertCols {
 		buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", schemaCol(schemaVar, ic.colName)))
 	}
 	buf.WriteString("\t\t\t).\n")
 
-	// Values
 	buf.WriteString("\t\t\tValues(\n")
 	for _, ic := range insertCols {
 		buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", ic.value))
 	}
 	buf.WriteString("\t\t\t).\n")
+}
+
+func writeCreateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
+	singular := dbstrings.ToPascalCase(dbstrings.ToSingular(cfg.TableName))
+	queryName := fmt.Sprintf("Create%s", singular)
+
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineOne(%q,\n", queryName))
+	buf.WriteString(fmt.Sprintf("\t\tquery.InsertInto(schema.%s).\n", schemaVar))
+	writeInsertColumnsAndValues(buf, createInsertCols(cfg, analysis), schemaVar)
 
 	// Always return the internal id — fixtures and scope-column resolution
 	// need the raw integer PK from CreateResult types.
 	// This value is NEVER exposed in API responses. See isResponseExcluded()
 	// in handlergen and the SELECT exclusion in writeGetQuery/writeListQuery.
+	// Tables without an id column return their primary key instead.
 	buf.WriteString("\t\t\tReturning(\n")
-	buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", schemaCol(schemaVar, "id")))
+	if _, ok := findColumn(cfg.Table, "id"); ok || len(analysis.PrimaryKeyColumns) == 0 {
+		buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", schemaCol(schemaVar, "id")))
+	} else {
+		for _, col := range analysis.PrimaryKeyColumns {
+			buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", schemaCol(schemaVar, col.Name)))
+		}
+	}
 	if analysis.HasPublicID {
 		buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", schemaCol(schemaVar, "public_id")))
 	}
@@ -473,15 +712,153 @@ func writeCreateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAn
 	buf.WriteString("\t\t\tBuild())\n\n")
 }
 
+// writeCreateBatchQuery writes Create<Table>Batch, a multi-row INSERT of the
+// same columns as Create<Singular>. Its runner method takes a
+// []Create<Singular>Params and returns no ids, so callers generate each
+// row's public_id up front.
+func writeCreateBatchQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
+	queryName := topcodegen.CRUD.CreateBatchMethodName(cfg.TableName)
+
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineBulkExec(%q,\n", queryName))
+	buf.WriteString(fmt.Sprintf("\t\tquery.InsertInto(schema.%s).\n", schemaVar))
+	writeInsertColumnsAndValues(buf, createInsertCols(cfg, analysis), schemaVar)
+	buf.WriteString("\t\t\tBuild(),\n")
+	buf.WriteString(fmt.Sprintf("\t\tquery.RowParams(%q))\n\n", topcodegen.CRUD.CreateMethodName(cfg.TableName)))
+}
+
+// writeUpsertQuery writes Upsert<Singular>, which inserts the same columns
+// as Create<Singular> or, when a row with the public_id exists, updates the
+// columns Update<Singular>ByPublicID sets. On a scoped table a row of
+// another scope is left untouched. Tables without public_id get no upsert.
+func writeUpsertQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
+	if !analysis.HasPublicID {
+		return
+	}
+
+	var sets []string
+	for _, col := range cfg.Table.Columns {
+		if col.Name == "id" || col.Name == "public_id" || col.Name == "created_at" ||
+			col.Name == "updated_at" || col.Name == "deleted_at" || col.Name == "author_account_id" {
+			continue
+		}
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			continue
+		}
+		c := schemaCol(schemaVar, col.Name)
+		sets = append(sets, fmt.Sprintf("query.Assign(%s, query.Excluded(%s))", c, c))
+	}
+	if len(sets) == 0 {
+		return
+	}
+	if analysis.HasUpdatedAt {
+		sets = append(sets, fmt.Sprintf("query.Assign(%s, query.Now())", schemaCol(schemaVar, "updated_at")))
+	}
+
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineExec(%q,\n", topcodegen.CRUD.UpsertMethodName(cfg.TableName)))
+	buf.WriteString(fmt.Sprintf("\t\tquery.InsertInto(schema.%s).\n", schemaVar))
+	writeInsertColumnsAndValues(buf, createInsertCols(cfg, analysis), schemaVar)
+	buf.WriteString(fmt.Sprintf("\t\t\tOnConflict(%s).\n", schemaCol(schemaVar, "public_id")))
+	if cfg.ScopeColumn != "" {
+		scope := schemaCol(schemaVar, cfg.ScopeColumn)
+		buf.WriteString(fmt.Sprintf("\t\t\tDoUpdateWhere(%s.Eq(query.Excluded(%s)),\n", scope, scope))
+	} else {
+		buf.WriteString("\t\t\tDoUpdate(\n")
+	}
+	for _, set := range sets {
+		buf.WriteString(fmt.Sprintf("\t\t\t\t%s,\n", set))
+	}
+	buf.WriteString("\t\t\t).\n")
+	buf.WriteString("\t\t\tBuild())\n\n")
+}
+
+// ---------- SOFT-UNIQUE CHECKS ----------
+
+// validateSoftUniqueIndexes checks that a table with soft-unique indexes can
+// tell live rows apart (deleted_at) and exclude the row being written
+// (public_id), and that the indexed columns exist.
+func validateSoftUniqueIndexes(cfg Config) error {
+	indexes := cfg.Table.SoftUniqueIndexes()
+	if len(indexes) == 0 {
+		return nil
+	}
+	for _, required := range []string{"deleted_at", "public_id"} {
+		if _, ok := findColumn(cfg.Table, required); !ok {
+			return fmt.Errorf("soft-unique index %s requires a %s column on %s", indexes[0].Name, required, cfg.TableName)
+		}
+	}
+	for _, idx := range indexes {
+		for _, name := range idx.Columns {
+			if _, ok := findColumn(cfg.Table, name); !ok {
+				return fmt.Errorf("soft-unique index %s: column %q not found in table %q", idx.Name, name, cfg.TableName)
+			}
+		}
+	}
+	return nil
+}
+
+// writeSoftUniqueQueries emits, per soft-unique index, a query that locks
+// (FOR UPDATE) a live row other than publicId holding the given values. The
+// create and update handlers run it in their transaction before writing.
+func writeSoftUniqueQueries(buf *strings.Builder, cfg Config, schemaVar string) {
+	for _, idx := range cfg.Table.SoftUniqueIndexes() {
+		queryName := topcodegen.CRUD.SoftUniqueMethodName(cfg.TableName, idx.Columns)
+
+		var whereParts []string
+		for _, name := range idx.Columns {
+			col := colByName(cfg.Table, name)
+			paramName := lowerCamel(name)
+			var value string
+			if col.References != "" && name != cfg.ScopeColumn {
+				value = fkSubquery(col.References, paramName)
+			} else {
+				value = paramExpr(codegen.MapColumnType(col).GoType, paramName)
+			}
+			whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, name), value))
+		}
+		whereParts = append(whereParts,
+			fmt.Sprintf("%s.IsNull()", schemaCol(schemaVar, "deleted_at")),
+			fmt.Sprintf("%s.Ne(%s)", schemaCol(schemaVar, "public_id"), paramExpr("string", "publicId")))
+
+		buf.WriteString(fmt.Sprintf("\tquery.MustDefineOne(%q,\n", queryName))
+		buf.WriteString(fmt.Sprintf("\t\tquery.From(schema.%s).\n", schemaVar))
+		buf.WriteString(fmt.Sprintf("\t\t\tSelect(%s).\n", schemaCol(schemaVar, "public_id")))
+		writeWhere(buf, whereParts)
+		buf.WriteString("\t\t\tForUpdate().\n")
+		buf.WriteString("\t\t\tBuild())\n\n")
+	}
+}
+
+// ---------- QUOTA ----------
+
+// writeCountInScopeQuery emits Count<Table>InScope, the COUNT of a scope's
+// live rows that the create handler compares with the table's quota. It is
+// served by the index on the scope column.
+func writeCountInScopeQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
+	queryName := topcodegen.CRUD.CountInScopeMethodName(cfg.TableName)
+	scopeMapping := codegen.MapColumnType(colByName(cfg.Table, cfg.ScopeColumn))
+
+	whereParts := []string{fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, cfg.ScopeColumn), paramExpr(scopeMapping.GoType, lowerCamel(cfg.ScopeColumn)))}
+	if analysis.HasDeletedAt {
+		whereParts = append(whereParts, fmt.Sprintf("%s.IsNull()", schemaCol(schemaVar, "deleted_at")))
+	}
+
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineOne(%q,\n", queryName))
+	buf.WriteString(fmt.Sprintf("\t\tquery.From(schema.%s).\n", schemaVar))
+	buf.WriteString("\t\t\tSelectCountAs(\"count\").\n")
+	writeWhere(buf, whereParts)
+	buf.WriteString("\t\t\tBuild())\n\n")
+}
+
 // ---------- UPDATE ----------
 
 func writeUpdateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
 	queryName := topcodegen.CRUD.UpdateMethodName(cfg.TableName)
 
-	buf.WriteString(fmt.Sprintf("\tquery.MustDefineExec(%q,\n", queryName))
-	buf.WriteString(fmt.Sprintf("\t\tquery.Update(schema.%s).\n", schemaVar))
-
-	// SET clauses: user columns (excluding auto-filled, scope, author_account_id)
+	// SET clauses: user columns (excluding auto-filled, scope, author_account_id
+	// and the key columns the row is matched on). They are optional, so a
+	// partial update only writes the columns it was given.
+	keyCols := keyColumns(analysis)
+	var sets []string
 	for _, col := range cfg.Table.Columns {
 		if col.Name == "id" || col.Name == "public_id" || col.Name == "created_at" ||
 			col.Name == "updated_at" || col.Name == "deleted_at" || col.Name == "author_account_id" {
@@ -490,6 +867,9 @@ func writeUpdateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAn
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
 			continue
 		}
+		if slices.Contains(keyCols, col.Name) {
+			continue
+		}
 
 		mapping := codegen.MapColumnType(col)
 		paramName := lowerCamel(col.Name)
@@ -501,23 +881,28 @@ func writeUpdateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAn
 			value = paramExpr(mapping.GoType, paramName)
 		}
 
-		buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, %s).\n", schemaCol(schemaVar, col.Name), value))
+		sets = append(sets, fmt.Sprintf("SetOptional(%s, %s)", schemaCol(schemaVar, col.Name), value))
 	}
 
 	// Set updated_at = NOW() if present
 	if analysis.HasUpdatedAt {
-		buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, query.Now()).\n", schemaCol(schemaVar, "updated_at")))
+		sets = append(sets, fmt.Sprintf("Set(%s, query.Now())", schemaCol(schemaVar, "updated_at")))
 	}
 
-	// WHERE clause
-	whereCol := "public_id"
-	if !analysis.HasPublicID && analysis.PrimaryKey != nil {
-		whereCol = analysis.PrimaryKey.Name
+	// A table made only of its key columns (e.g. a junction table) has
+	// nothing to update.
+	if len(sets) == 0 {
+		return
 	}
-	whereMapping := codegen.MapColumnType(colByName(cfg.Table, whereCol))
 
-	var whereParts []string
-	whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, whereCol), paramExpr(whereMapping.GoType, lowerCamel(whereCol))))
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineExec(%q,\n", queryName))
+	buf.WriteString(fmt.Sprintf("\t\tquery.Update(schema.%s).\n", schemaVar))
+	for _, set := range sets {
+		buf.WriteString(fmt.Sprintf("\t\t\t%s.\n", set))
+	}
+
+	// WHERE clause
+	whereParts := keyWhereParts(cfg, analysis, schemaVar)
 	if cfg.ScopeColumn != "" {
 		scopeMapping := codegen.MapColumnType(colByName(cfg.Table, cfg.ScopeColumn))
 		whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, cfg.ScopeColumn), paramExpr(scopeMapping.GoType, lowerCamel(cfg.ScopeColumn))))
@@ -527,17 +912,67 @@ func writeUpdateQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAn
 	buf.WriteString("\t\t\tBuild())\n\n")
 }
 
-// ---------- DELETE ----------
+// ---------- STATE TRANSITIONS ----------
 
-func writeDeleteQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
-	whereCol := "public_id"
-	if !analysis.HasPublicID && analysis.PrimaryKey != nil {
-		whereCol = analysis.PrimaryKey.Name
+// validateStateColumns checks that every state column is an updatable
+// column of the table: not a key, the scope or a managed timestamp.
+func validateStateColumns(cfg Config) error {
+	analysis := codegen.AnalyzeTable(cfg.Table)
+	for _, name := range cfg.StateColumns {
+		col, ok := findColumn(cfg.Table, name)
+		if !ok {
+			return fmt.Errorf("state column %q not found in table %s", name, cfg.TableName)
+		}
+		switch {
+		case col.Name == "id" || col.Name == "public_id" || col.Name == "created_at" ||
+			col.Name == "updated_at" || col.Name == "deleted_at" || col.Name == "author_account_id" ||
+			col.Name == cfg.ScopeColumn || slices.Contains(keyColumns(analysis), col.Name):
+			return fmt.Errorf("state column %q of table %s is not an updatable column", name, cfg.TableName)
+		case col.References != "":
+			return fmt.Errorf("state column %q of table %s is a reference; use a plain column", name, cfg.TableName)
+		case col.Nullable:
+			// "= from" never matches NULL, so a nullable state could not
+			// leave its initial value.
+			return fmt.Errorf("state column %q of table %s must be NOT NULL", name, cfg.TableName)
+		}
 	}
-	whereMapping := codegen.MapColumnType(colByName(cfg.Table, whereCol))
+	return nil
+}
 
-	var whereParts []string
-	whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, whereCol), paramExpr(whereMapping.GoType, lowerCamel(whereCol))))
+// writeStateTransitionQueries emits Update<Singular><Column>If for each
+// state column: an UPDATE setting the column to "to" only where it still
+// holds "from", registered with MustDefineApplied so the runner reports
+// whether the transition happened. The condition and the write are one
+// statement, so concurrent transitions can't both succeed.
+func writeStateTransitionQueries(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
+	for _, name := range cfg.StateColumns {
+		mapping := codegen.MapColumnType(colByName(cfg.Table, name))
+
+		whereParts := keyWhereParts(cfg, analysis, schemaVar)
+		whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, name), paramExpr(mapping.GoType, "from")))
+		if cfg.ScopeColumn != "" {
+			scopeMapping := codegen.MapColumnType(colByName(cfg.Table, cfg.ScopeColumn))
+			whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, cfg.ScopeColumn), paramExpr(scopeMapping.GoType, lowerCamel(cfg.ScopeColumn))))
+		}
+		if analysis.HasDeletedAt {
+			whereParts = append(whereParts, fmt.Sprintf("%s.IsNull()", schemaCol(schemaVar, "deleted_at")))
+		}
+
+		buf.WriteString(fmt.Sprintf("\tquery.MustDefineApplied(%q,\n", topcodegen.CRUD.StateTransitionMethodName(cfg.TableName, name)))
+		buf.WriteString(fmt.Sprintf("\t\tquery.Update(schema.%s).\n", schemaVar))
+		buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, %s).\n", schemaCol(schemaVar, name), paramExpr(mapping.GoType, "to")))
+		if analysis.HasUpdatedAt {
+			buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, query.Now()).\n", schemaCol(schemaVar, "updated_at")))
+		}
+		writeWhere(buf, whereParts)
+		buf.WriteString("\t\t\tBuild())\n\n")
+	}
+}
+
+// ---------- DELETE ----------
+
+func writeDeleteQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAnalysis, schemaVar string) {
+	whereParts := keyWhereParts(cfg, analysis, schemaVar)
 	if cfg.ScopeColumn != "" {
 		scopeMapping := codegen.MapColumnType(colByName(cfg.Table, cfg.ScopeColumn))
 		whereParts = append(whereParts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, cfg.ScopeColumn), paramExpr(scopeMapping.GoType, lowerCamel(cfg.ScopeColumn))))
@@ -551,10 +986,24 @@ func writeDeleteQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAn
 		buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, query.Now()).\n", schemaCol(schemaVar, "deleted_at")))
 		writeWhere(buf, whereParts)
 		buf.WriteString("\t\t\tBuild())\n\n")
+
+		// Restore: UPDATE ... SET deleted_at = NULL, only matching a deleted
+		// row so the runner reports whether there was one to restore.
+		buf.WriteString(fmt.Sprintf("\tquery.MustDefineApplied(%q,\n", topcodegen.CRUD.RestoreMethodName(cfg.TableName)))
+		buf.WriteString(fmt.Sprintf("\t\tquery.Update(schema.%s).\n", schemaVar))
+		buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, query.Literal[any](nil)).\n", schemaCol(schemaVar, "deleted_at")))
+		if analysis.HasUpdatedAt {
+			buf.WriteString(fmt.Sprintf("\t\t\tSet(%s, query.Now()).\n", schemaCol(schemaVar, "updated_at")))
+		}
+		writeWhere(buf, append(whereParts, fmt.Sprintf("%s.IsNotNull()", schemaCol(schemaVar, "deleted_at"))))
+		buf.WriteString("\t\t\tBuild())\n\n")
 	} else {
 		// Hard delete: DELETE FROM ...
 		singular := dbstrings.ToPascalCase(dbstrings.ToSingular(cfg.TableName))
 		queryName := fmt.Sprintf("Delete%s", singular)
+		if cfg.NoSoftDelete {
+			queryName = topcodegen.CRUD.DeleteMethodName(cfg.TableName)
+		}
 		buf.WriteString(fmt.Sprintf("\tquery.MustDefineExec(%q,\n", queryName))
 		buf.WriteString(fmt.Sprintf("\t\tquery.Delete(schema.%s).\n", schemaVar))
 		writeWhere(buf, whereParts)
@@ -564,6 +1013,30 @@ func writeDeleteQuery(buf *strings.Builder, cfg Config, analysis codegen.TableAn
 
 // ---------- Helpers ----------
 
+// keyColumns returns the columns that identify a row in the Get, Update and
+// Delete queries: public_id, or else the table's primary key, which may span
+// several columns.
+func keyColumns(analysis codegen.TableAnalysis) []string {
+	if analysis.HasPublicID || len(analysis.PrimaryKeyColumns) == 0 {
+		return []string{"public_id"}
+	}
+	names := make([]string, len(analysis.PrimaryKeyColumns))
+	for i, col := range analysis.PrimaryKeyColumns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// keyWhereParts returns one equality condition per key column.
+func keyWhereParts(cfg Config, analysis codegen.TableAnalysis, schemaVar string) []string {
+	var parts []string
+	for _, col := range keyColumns(analysis) {
+		mapping := codegen.MapColumnType(colByName(cfg.Table, col))
+		parts = append(parts, fmt.Sprintf("%s.Eq(%s)", schemaCol(schemaVar, col), paramExpr(mapping.GoType, lowerCamel(col))))
+	}
+	return parts
+}
+
 func writeWhere(buf *strings.Builder, parts []string) {
 	if len(parts) == 0 {
 		return
@@ -579,6 +1052,15 @@ func writeWhere(buf *strings.Builder, parts []string) {
 	buf.WriteString("\t\t\t)).\n")
 }
 
+func findColumn(table ddl.Table, name string) (ddl.ColumnDefinition, bool) {
+	for _, col := range table.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return ddl.ColumnDefinition{}, false
+}
+
 func colByName(table ddl.Table, name string) ddl.ColumnDefinition {
 	for _, col := range table.Columns {
 		if col.Name == name {
@@ -601,11 +1083,9 @@ func collectParamImports(cfg Config, analysis codegen.TableAnalysis) map[string]
 	}
 
 	// WHERE clause columns (public_id or PK, scope column)
-	whereCol := "public_id"
-	if !analysis.HasPublicID && analysis.PrimaryKey != nil {
-		whereCol = analysis.PrimaryKey.Name
+	for _, col := range keyColumns(analysis) {
+		addIfNeeded(colByName(cfg.Table, col))
 	}
-	addIfNeeded(colByName(cfg.Table, whereCol))
 	if cfg.ScopeColumn != "" {
 		addIfNeeded(colByName(cfg.Table, cfg.ScopeColumn))
 	}
@@ -630,7 +1110,7 @@ func collectParamImports(cfg Config, analysis codegen.TableAnalysis) map[string]
 }
 
 func formatSource(src []byte) ([]byte, error) {
-	formatted, err := format.Source(src)
+	formatted, err := phase.FormatSource(src)
 	if err != nil {
 		return src, fmt.Errorf("failed to format generated code: %w (source:\n%s)", err, string(src))
 	}
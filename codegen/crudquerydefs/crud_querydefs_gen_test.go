@@ -1,11 +1,17 @@
 package crudquerydefs
 
 import (
+	"bytes"
+	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/token"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 	"github.com/shipq/shipq/db/portsql/ddl"
 )
 
@@ -66,6 +72,98 @@ func allTables() map[string]ddl.Table {
 	}
 }
 
+// queryDefs parses generated querydefs code into one method per query
+// definition, named after the query and with the query.MustDefine*
+// function that registers it as receiver type. Its body holds the steps of
+// the definition's builder chain as statements, from query.From (or
+// InsertInto, Update, Delete) through Build, followed by any further
+// arguments:
+//
+//	func (MustDefineOne) CountPostsInScope() {
+//		query.From(schema.Posts)
+//		SelectCountAs("count")
+//		Where(query.And(...))
+//		Build()
+//	}
+func queryDefs(t *testing.T, code []byte) *gofile.File {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "querydefs.go", code, 0)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, code)
+	}
+	text := func(from, to token.Pos) string {
+		return string(code[fset.Position(from).Offset:fset.Position(to).Offset])
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("package querydefs\n")
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		define, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !strings.HasPrefix(define.Sel.Name, "MustDefine") {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		name, _ := strconv.Unquote(lit.Value)
+		fmt.Fprintf(&buf, "\nfunc (%s) %s() {\n", define.Sel.Name, name)
+		for _, arg := range call.Args[1:] {
+			var steps []string
+			if isBuild(arg) {
+				// Unwind X.Method(args) until the chain's first call.
+				for {
+					sel, ok := arg.(*ast.CallExpr).Fun.(*ast.SelectorExpr)
+					if !ok {
+						break
+					}
+					inner, ok := sel.X.(*ast.CallExpr)
+					if !ok {
+						break
+					}
+					steps = append(steps, sel.Sel.Name+text(arg.(*ast.CallExpr).Lparen, arg.End()))
+					arg = inner
+				}
+			}
+			fmt.Fprintf(&buf, "\t%s\n", text(arg.Pos(), arg.End()))
+			for i := len(steps) - 1; i >= 0; i-- {
+				fmt.Fprintf(&buf, "\t%s\n", steps[i])
+			}
+		}
+		buf.WriteString("}\n")
+		return false
+	})
+	return gofile.Parse(t, "querydefs.go", buf.Bytes())
+}
+
+// conditions returns the conditions of the Where step of the query
+// definition fn, one per argument of a top-level query.And.
+func conditions(f *gofile.File, fn string) []string {
+	where := f.Args(fn, "Where")
+	if len(where) == 0 {
+		return nil
+	}
+	if strings.HasPrefix(where[0][0], "query.And(") {
+		return f.Args(fn, "query.And")[0]
+	}
+	return where[0]
+}
+
+// isBuild reports whether e is a builder chain ending in Build().
+func isBuild(e ast.Expr) bool {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Build"
+}
+
 func TestGenerateCRUDQueryDefs_ValidGo(t *testing.T) {
 	cfg := Config{
 		ModulePath:  "example.com/myapp",
@@ -178,6 +276,60 @@ func TestGenerateCRUDQueryDefs_ListQuery(t *testing.T) {
 	}
 }
 
+func TestGenerateCRUDQueryDefs_NearQuery(t *testing.T) {
+	stores := ddl.Table{
+		Name: "stores",
+		Columns: []ddl.ColumnDefinition{
+			{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "public_id", Type: ddl.StringType},
+			{Name: "name", Type: ddl.StringType},
+			{Name: "location", Type: ddl.PointType},
+			{Name: "created_at", Type: ddl.DatetimeType},
+			{Name: "deleted_at", Type: ddl.DatetimeType, Nullable: true},
+		},
+	}
+	cfg := Config{
+		ModulePath: "example.com/myapp",
+		TableName:  "stores",
+		Table:      stores,
+		Schema:     map[string]ddl.Table{"stores": stores},
+		NearColumn: "location",
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+	distance := `schema.Stores.Location().DistanceFrom(query.Param[float64]("lat"), query.Param[float64]("lng"))`
+	f.AssertStmts("MustDefineMany.ListStoresNear",
+		`SelectExprAs(`+distance+`, "distance_meters")`,
+		"OrderBy(query.OrderByExpr{Expr: "+distance+"})",
+		`Limit(query.Param[int64]("limit"))`,
+	)
+	if got, want := conditions(f, "MustDefineMany.ListStoresNear"), []string{
+		`schema.Stores.Location().WithinRadius(query.Param[float64]("lat"), query.Param[float64]("lng"), query.Param[float64]("radius"))`,
+		"schema.Stores.DeletedAt().IsNull()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("ListStoresNear conditions = %q, want %q", got, want)
+	}
+
+	// Without a near column the query is not generated.
+	cfg.NearColumn = ""
+	code, err = GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if queryDefs(t, code).HasFunc("MustDefineMany.ListStoresNear") {
+		t.Error("ListStoresNear generated without a near column")
+	}
+
+	cfg.NearColumn = "name"
+	if _, err := GenerateCRUDQueryDefs(cfg); err == nil || !strings.Contains(err.Error(), "must be a point column") {
+		t.Errorf("expected error for non-point near column, got %v", err)
+	}
+}
+
 func TestGenerateCRUDQueryDefs_ListQuery_NoCursorFallback(t *testing.T) {
 	// Table without created_at should fall back to MustDefineMany
 	table := ddl.Table{
@@ -251,6 +403,281 @@ func TestGenerateCRUDQueryDefs_CreateQuery(t *testing.T) {
 	}
 }
 
+func TestGenerateCRUDQueryDefs_CountInScopeQuery(t *testing.T) {
+	cfg := Config{
+		ModulePath:      "example.com/myapp",
+		TableName:       "posts",
+		Table:           postsTable(),
+		ScopeColumn:     "organization_id",
+		Schema:          allTables(),
+		MaxRowsPerScope: 100,
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+	if got, want := f.TopStmts("MustDefineOne.CountPostsInScope"), []string{
+		"query.From(schema.Posts)",
+		`SelectCountAs("count")`,
+		`Where(query.And( schema.Posts.OrganizationId().Eq(query.Param[int64]("organizationId")), schema.Posts.DeletedAt().IsNull(), ))`,
+		"Build()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("CountPostsInScope = %q, want %q", got, want)
+	}
+
+	cfg.MaxRowsPerScope = 0
+	code, _ = GenerateCRUDQueryDefs(cfg)
+	if queryDefs(t, code).HasFunc("MustDefineOne.CountPostsInScope") {
+		t.Error("CountPostsInScope should only be generated for tables with a quota")
+	}
+
+	cfg.MaxRowsPerScope, cfg.ScopeColumn = 100, ""
+	if _, err := GenerateCRUDQueryDefs(cfg); err == nil {
+		t.Error("expected an error for a quota without a scope column")
+	}
+}
+
+func TestGenerateCRUDQueryDefs_ListFilters(t *testing.T) {
+	table := postsTable()
+	table.Columns = append(table.Columns,
+		ddl.ColumnDefinition{Name: "status", Type: ddl.StringType, Index: true},
+		ddl.ColumnDefinition{Name: "slug", Type: ddl.StringType, Unique: true},
+	)
+	table.Indexes = []ddl.IndexDefinition{{Name: "idx_posts_org_title", Columns: []string{"organization_id", "title"}}}
+	cfg := Config{
+		ModulePath:  "example.com/myapp",
+		TableName:   "posts",
+		Table:       table,
+		ScopeColumn: "organization_id",
+		Schema:      allTables(),
+		Filters:     true,
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	// Only the leading column of an index is filtered on, and never the
+	// scope column or unindexed columns.
+	if got, want := queryDefs(t, code).TopStmts("MustDefineFilters.ListPosts"), []string{
+		`query.FilterGe(schema.Posts.CreatedAt(), "created_after")`,
+		`query.FilterLt(schema.Posts.CreatedAt(), "created_before")`,
+		`query.FilterEq(schema.Posts.Status(), "status")`,
+		`query.FilterEq(schema.Posts.Slug(), "slug")`,
+	}; !slices.Equal(got, want) {
+		t.Errorf("ListPosts filters = %q, want %q", got, want)
+	}
+
+	cfg.Filters = false
+	code, _ = GenerateCRUDQueryDefs(cfg)
+	if queryDefs(t, code).HasFunc("MustDefineFilters.ListPosts") {
+		t.Error("filters should only be generated with filters = true")
+	}
+}
+
+func TestGenerateCRUDQueryDefs_ListSorts(t *testing.T) {
+	cfg := Config{
+		ModulePath: "example.com/myapp",
+		TableName:  "posts",
+		Table:      postsTable(),
+		Schema:     allTables(),
+		Sorts:      []string{"title", "-updated_at"},
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if got, want := queryDefs(t, code).TopStmts("MustDefineSorts.ListPosts"), []string{
+		`query.SortBy("title", schema.Posts.Title().Asc(), schema.Posts.PublicId().Asc())`,
+		`query.SortBy("updated_at", schema.Posts.UpdatedAt().Desc(), schema.Posts.PublicId().Desc())`,
+	}; !slices.Equal(got, want) {
+		t.Errorf("ListPosts sorts = %q, want %q", got, want)
+	}
+
+	nullable := postsTable()
+	nullable.Columns = append(nullable.Columns, ddl.ColumnDefinition{Name: "published_at", Type: ddl.TimestampType, Nullable: true})
+	unpaginated := postsTable()
+	unpaginated.Columns = slices.DeleteFunc(unpaginated.Columns, func(c ddl.ColumnDefinition) bool { return c.Name == "created_at" })
+	for name, tc := range map[string]struct {
+		table ddl.Table
+		sort  string
+		want  string
+	}{
+		"missing":     {postsTable(), "rating", `sort column "rating" not found`},
+		"reference":   {postsTable(), "category_id", "not exposed by the list"},
+		"nullable":    {nullable, "published_at", "is nullable"},
+		"unpaginated": {unpaginated, "title", "requires a paginated list"},
+	} {
+		cfg.Table, cfg.Sorts = tc.table, []string{tc.sort}
+		if _, err := GenerateCRUDQueryDefs(cfg); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestGenerateCRUDQueryDefs_NoSoftDelete(t *testing.T) {
+	cfg := Config{
+		ModulePath:   "example.com/myapp",
+		TableName:    "posts",
+		Table:        postsTable(),
+		Schema:       allTables(),
+		NoSoftDelete: true,
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+	if got, want := f.TopStmts("MustDefineExec.DeletePostByPublicID"), []string{
+		"query.Delete(schema.Posts)",
+		`Where(schema.Posts.PublicId().Eq(query.Param[string]("publicId")))`,
+		"Build()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("DeletePostByPublicID = %q, want %q", got, want)
+	}
+	if f.HasFunc("MustDefineExec.SoftDeletePostByPublicID") || f.HasExpr("", "schema.Posts.DeletedAt().IsNull()") {
+		t.Errorf("deleted_at should be ignored with NoSoftDelete:\n%s", code)
+	}
+}
+
+func TestGenerateCRUDQueryDefs_RestoreQuery(t *testing.T) {
+	cfg := Config{
+		ModulePath:  "example.com/myapp",
+		TableName:   "posts",
+		Table:       postsTable(),
+		ScopeColumn: "organization_id",
+		Schema:      allTables(),
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+	f.AssertStmts("MustDefineApplied.RestorePostByPublicID",
+		"Set(schema.Posts.DeletedAt(), query.Literal[any](nil))",
+		"Set(schema.Posts.UpdatedAt(), query.Now())",
+	)
+	if got, want := conditions(f, "MustDefineApplied.RestorePostByPublicID"), []string{
+		`schema.Posts.PublicId().Eq(query.Param[string]("publicId"))`,
+		`schema.Posts.OrganizationId().Eq(query.Param[int64]("organizationId"))`,
+		"schema.Posts.DeletedAt().IsNotNull()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("RestorePostByPublicID conditions = %q, want %q", got, want)
+	}
+
+	cfg.NoSoftDelete = true
+	code, _ = GenerateCRUDQueryDefs(cfg)
+	if queryDefs(t, code).HasFunc("MustDefineApplied.RestorePostByPublicID") {
+		t.Error("no restore query should be generated with NoSoftDelete")
+	}
+}
+
+func TestGenerateCRUDQueryDefs_StateTransitionQuery(t *testing.T) {
+	table := postsTable()
+	table.Columns = append(table.Columns, ddl.ColumnDefinition{Name: "status", Type: ddl.StringType})
+	cfg := Config{
+		ModulePath:   "example.com/myapp",
+		TableName:    "posts",
+		Table:        table,
+		ScopeColumn:  "organization_id",
+		Schema:       allTables(),
+		StateColumns: []string{"status"},
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+	f.AssertStmts("MustDefineApplied.UpdatePostStatusIf",
+		"query.Update(schema.Posts)",
+		`Set(schema.Posts.Status(), query.Param[string]("to"))`,
+		"Set(schema.Posts.UpdatedAt(), query.Now())",
+	)
+	if got, want := conditions(f, "MustDefineApplied.UpdatePostStatusIf"), []string{
+		`schema.Posts.PublicId().Eq(query.Param[string]("publicId"))`,
+		`schema.Posts.Status().Eq(query.Param[string]("from"))`,
+		`schema.Posts.OrganizationId().Eq(query.Param[int64]("organizationId"))`,
+		"schema.Posts.DeletedAt().IsNull()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("UpdatePostStatusIf conditions = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"missing", "public_id", "organization_id", "category_id", "deleted_at"} {
+		cfg.StateColumns = []string{bad}
+		if _, err := GenerateCRUDQueryDefs(cfg); err == nil {
+			t.Errorf("expected an error for state column %q", bad)
+		}
+	}
+}
+
+func TestGenerateCRUDQueryDefs_UpsertQuery(t *testing.T) {
+	cfg := Config{
+		ModulePath:  "example.com/myapp",
+		TableName:   "posts",
+		Table:       postsTable(),
+		ScopeColumn: "organization_id",
+		Schema:      allTables(),
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+	f.AssertStmts("MustDefineExec.UpsertPost", "OnConflict(schema.Posts.PublicId())")
+	// A conflicting row in another organization is left alone, and only
+	// the columns a create sets from the request are overwritten.
+	if got, want := f.Args("MustDefineExec.UpsertPost", "DoUpdateWhere"), [][]string{{
+		"schema.Posts.OrganizationId().Eq(query.Excluded(schema.Posts.OrganizationId()))",
+		"query.Assign(schema.Posts.Title(), query.Excluded(schema.Posts.Title()))",
+		"query.Assign(schema.Posts.Body(), query.Excluded(schema.Posts.Body()))",
+		"query.Assign(schema.Posts.CategoryId(), query.Excluded(schema.Posts.CategoryId()))",
+		"query.Assign(schema.Posts.UpdatedAt(), query.Now())",
+	}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("UpsertPost DoUpdateWhere = %q, want %q", got, want)
+	}
+
+	cfg.ScopeColumn = ""
+	code, _ = GenerateCRUDQueryDefs(cfg)
+	if queryDefs(t, code).Calls("MustDefineExec.UpsertPost", "DoUpdate") != 1 {
+		t.Errorf("unscoped UpsertPost should use DoUpdate:\n%s", code)
+	}
+}
+
+func TestGenerateCRUDQueryDefs_CreateBatchQuery(t *testing.T) {
+	cfg := Config{
+		ModulePath:  "example.com/myapp",
+		TableName:   "posts",
+		Table:       postsTable(),
+		ScopeColumn: "organization_id",
+		Schema:      allTables(),
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+
+	// Same columns and FK subqueries as CreatePost, but no RETURNING:
+	// bulk inserts return no rows. The batch reuses CreatePost's params.
+	create := f.TopStmts("MustDefineOne.CreatePost")
+	batch := f.TopStmts("MustDefineBulkExec.CreatePostsBatch")
+	if len(create) < 2 || create[len(create)-2] != "Returning( schema.Posts.Id(), schema.Posts.PublicId(), )" {
+		t.Fatalf("CreatePost = %q", create)
+	}
+	want := append(slices.Clone(create[:len(create)-2]), "Build()", `query.RowParams("CreatePost")`)
+	if !slices.Equal(batch, want) {
+		t.Errorf("CreatePostsBatch = %q, want %q", batch, want)
+	}
+}
+
 func TestGenerateCRUDQueryDefs_UpdateQuery(t *testing.T) {
 	cfg := Config{
 		ModulePath:  "example.com/myapp",
@@ -273,17 +700,24 @@ func TestGenerateCRUDQueryDefs_UpdateQuery(t *testing.T) {
 	if !strings.Contains(codeStr, "query.Update(schema.Posts)") {
 		t.Error("missing Update call")
 	}
-	// updated_at = NOW()
-	if !strings.Contains(codeStr, "query.Now()") {
+	// User columns are optional so a partial update leaves the rest alone,
+	// while updated_at = NOW() is always set.
+	if !strings.Contains(codeStr, `SetOptional(schema.Posts.Title(), query.Param[string]("title"))`) {
+		t.Error("user columns should be optional SET clauses")
+	}
+	if !strings.Contains(codeStr, "SetOptional(schema.Posts.CategoryId(), query.Subquery(") {
+		t.Error("FK columns should be optional SET clauses resolving the public ID")
+	}
+	if !strings.Contains(codeStr, "Set(schema.Posts.UpdatedAt(), query.Now())") {
 		t.Error("missing NOW() for updated_at")
 	}
 	// Scope column should NOT be in SET, only in WHERE
 	// Count occurrences: should appear in WHERE but not in Set
-	if strings.Contains(codeStr, "Set(schema.Posts.OrganizationId()") {
+	if strings.Contains(codeStr, "SetOptional(schema.Posts.OrganizationId()") {
 		t.Error("scope column should not be in SET clause")
 	}
 	// author_account_id should NOT be updatable
-	if strings.Contains(codeStr, "Set(schema.Posts.AuthorAccountId()") {
+	if strings.Contains(codeStr, "SetOptional(schema.Posts.AuthorAccountId()") {
 		t.Error("author_account_id should not be updatable")
 	}
 }
@@ -1279,3 +1713,114 @@ func TestGenerateCRUDQueryDefs_GetQuery_SingleFKNoAlias(t *testing.T) {
 		t.Error("GET query missing unaliased SelectAs for category_id FK resolution")
 	}
 }
+
+func TestGenerateCRUDQueryDefs_SoftUniqueQuery(t *testing.T) {
+	table := postsTable()
+	table.Indexes = []ddl.IndexDefinition{
+		{Name: "idx_posts_category_id_title", Columns: []string{"category_id", "title"}, SoftUnique: true},
+	}
+	cfg := Config{
+		ModulePath: "example.com/myapp",
+		TableName:  "posts",
+		Table:      table,
+		Schema:     allTables(),
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+	f.AssertStmts("MustDefineOne.LockActivePostByCategoryIdAndTitle", "ForUpdate()")
+	if got, want := conditions(f, "MustDefineOne.LockActivePostByCategoryIdAndTitle"), []string{
+		`schema.Posts.CategoryId().Eq(query.Subquery( query.From(schema.Categories). Select(schema.Categories.Id()). Where(schema.Categories.PublicId().Eq(query.Param[string]("categoryId")))))`,
+		`schema.Posts.Title().Eq(query.Param[string]("title"))`,
+		"schema.Posts.DeletedAt().IsNull()",
+		`schema.Posts.PublicId().Ne(query.Param[string]("publicId"))`,
+	}; !slices.Equal(got, want) {
+		t.Errorf("soft-unique conditions = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateCRUDQueryDefs_SoftUniqueRequiresDeletedAt(t *testing.T) {
+	table := categoriesTable()
+	table.Indexes = []ddl.IndexDefinition{
+		{Name: "idx_categories_name", Columns: []string{"name"}, SoftUnique: true},
+	}
+	_, err := GenerateCRUDQueryDefs(Config{
+		ModulePath: "example.com/myapp",
+		TableName:  "categories",
+		Table:      table,
+		Schema:     allTables(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "deleted_at") {
+		t.Fatalf("err = %v, want a missing deleted_at error", err)
+	}
+}
+
+func TestGenerateCRUDQueryDefs_CompositePrimaryKey(t *testing.T) {
+	table := ddl.Table{
+		Name: "user_roles",
+		Columns: []ddl.ColumnDefinition{
+			{Name: "user_id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "role_id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "granted_by", Type: ddl.StringType},
+		},
+	}
+	cfg := Config{
+		ModulePath: "example.com/myapp",
+		TableName:  "user_roles",
+		Table:      table,
+		Schema:     map[string]ddl.Table{"user_roles": table},
+	}
+
+	code, err := GenerateCRUDQueryDefs(cfg)
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	f := queryDefs(t, code)
+
+	// Get, Update and Delete are all keyed by both columns.
+	key := []string{
+		`schema.UserRoles.UserId().Eq(query.Param[int64]("userId"))`,
+		`schema.UserRoles.RoleId().Eq(query.Param[int64]("roleId"))`,
+	}
+	for _, fn := range []string{"MustDefineOne.GetUserRoleByPublicID", "MustDefineExec.UpdateUserRoleByPublicID", "MustDefineExec.DeleteUserRole"} {
+		if got := conditions(f, fn); !slices.Equal(got, key) {
+			t.Errorf("%s conditions = %q, want %q", fn, got, key)
+		}
+	}
+	if slices.ContainsFunc(f.Strings(""), func(s string) bool { return s == "publicId" }) || f.HasExpr("", "schema.UserRoles.PublicId()") {
+		t.Error("table without public_id should not reference it")
+	}
+	// Key columns are matched on, not updated.
+	if got := f.Args("MustDefineExec.UpdateUserRoleByPublicID", "SetOptional"); !slices.EqualFunc(got, [][]string{
+		{"schema.UserRoles.GrantedBy()", `query.Param[string]("grantedBy")`},
+	}, slices.Equal) {
+		t.Errorf("update sets %q, want only the non-key columns", got)
+	}
+	// Without an id column, create returns the key.
+	f.AssertStmts("MustDefineOne.CreateUserRole", "Returning( schema.UserRoles.UserId(), schema.UserRoles.RoleId(), )")
+}
+
+func TestGenerateCRUDQueryDefs_CompositePrimaryKey_NothingToUpdate(t *testing.T) {
+	table := ddl.Table{
+		Name: "user_roles",
+		Columns: []ddl.ColumnDefinition{
+			{Name: "user_id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "role_id", Type: ddl.BigintType, PrimaryKey: true},
+		},
+	}
+	code, err := GenerateCRUDQueryDefs(Config{
+		ModulePath: "example.com/myapp",
+		TableName:  "user_roles",
+		Table:      table,
+		Schema:     map[string]ddl.Table{"user_roles": table},
+	})
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if queryDefs(t, code).HasFunc("MustDefineExec.UpdateUserRoleByPublicID") {
+		t.Error("a table of only key columns should get no update query")
+	}
+}
@@ -0,0 +1,49 @@
+package crudquerydefs
+
+import (
+	"fmt"
+	"strings"
+
+	topcodegen "github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/codegen"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// RetentionFileName is the querydefs file holding a table's purge query.
+// `shipq db compile` rewrites it on every run and removes it when the table
+// no longer declares RetainFor.
+const RetentionFileName = "retention.go"
+
+// GenerateRetentionQueryDef generates querydefs/<table>/retention.go for a
+// table declared with RetainFor. It defines Purge<Table>, which hard-deletes
+// every row, soft-deleted or not, created before the cutoff parameter.
+func GenerateRetentionQueryDef(cfg Config) ([]byte, error) {
+	if cfg.Table.RetentionSeconds <= 0 {
+		return nil, fmt.Errorf("table %q has no retention policy", cfg.TableName)
+	}
+	if !codegen.AnalyzeTable(cfg.Table).HasCreatedAt {
+		return nil, fmt.Errorf("table %q has no created_at column to purge by", cfg.TableName)
+	}
+
+	schemaVar := dbstrings.ToPascalCase(cfg.TableName)
+	queryName := topcodegen.CRUD.PurgeMethodName(cfg.TableName)
+
+	var buf strings.Builder
+	buf.WriteString(topcodegen.GeneratedHeader + "\n")
+	buf.WriteString(fmt.Sprintf("package %s\n\n", cfg.TableName))
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"time\"\n\n")
+	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/shipq/db/schema"))
+	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/shipq/lib/db/portsql/query"))
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("func init() {\n")
+	buf.WriteString(fmt.Sprintf("\t// %s enforces the table's RetainFor(%s) policy.\n", queryName, cfg.Table.Retention()))
+	buf.WriteString(fmt.Sprintf("\tquery.MustDefineExec(%q,\n", queryName))
+	buf.WriteString(fmt.Sprintf("\t\tquery.Delete(schema.%s).\n", schemaVar))
+	writeWhere(&buf, []string{fmt.Sprintf("%s.Lt(%s)", schemaCol(schemaVar, "created_at"), paramExpr("time.Time", "cutoff"))})
+	buf.WriteString("\t\t\tBuild())\n")
+	buf.WriteString("}\n")
+
+	return formatSource([]byte(buf.String()))
+}
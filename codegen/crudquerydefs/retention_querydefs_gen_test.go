@@ -0,0 +1,47 @@
+package crudquerydefs
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+)
+
+func TestGenerateRetentionQueryDef(t *testing.T) {
+	table := postsTable()
+	table.RetentionSeconds = int64(90 * 24 * time.Hour / time.Second)
+
+	code, err := GenerateRetentionQueryDef(Config{
+		ModulePath: "example.com/myapp",
+		TableName:  "posts",
+		Table:      table,
+	})
+	if err != nil {
+		t.Fatalf("GenerateRetentionQueryDef() error = %v", err)
+	}
+	if !bytes.HasPrefix(code, []byte("// Code generated by shipq. DO NOT EDIT.\n")) {
+		t.Error("retention querydefs are regenerated and must carry the generated header")
+	}
+	if f := gofile.Parse(t, "retention.go", code); f.AST.Name.Name != "posts" {
+		t.Errorf("package = %s, want posts", f.AST.Name.Name)
+	}
+	if !bytes.Contains(code, []byte("\t// PurgePosts enforces the table's RetainFor(2160h0m0s) policy.\n")) {
+		t.Errorf("PurgePosts should document the policy it enforces:\n%s", code)
+	}
+	// The purge hard-deletes soft-deleted rows too.
+	if got, want := queryDefs(t, code).TopStmts("MustDefineExec.PurgePosts"), []string{
+		"query.Delete(schema.Posts)",
+		`Where(schema.Posts.CreatedAt().Lt(query.Param[time.Time]("cutoff")))`,
+		"Build()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("PurgePosts = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateRetentionQueryDef_RequiresPolicy(t *testing.T) {
+	if _, err := GenerateRetentionQueryDef(Config{ModulePath: "m", TableName: "posts", Table: postsTable()}); err == nil {
+		t.Error("expected error for a table without RetainFor")
+	}
+}
@@ -3,12 +3,16 @@ package dbpkg
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/shipq/shipq/codegen"
 	"github.com/shipq/shipq/codegen/crud"
+	"github.com/shipq/shipq/codegen/phase"
+	"github.com/shipq/shipq/config"
 	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
 	"github.com/shipq/shipq/dburl"
 	"github.com/shipq/shipq/inifile"
@@ -20,8 +24,18 @@ type DBPackageConfig struct {
 	ShipqRoot   string           // Directory containing shipq.ini
 	ModulePath  string           // Module path from go.mod
 	DatabaseURL string           // From shipq.ini [db] database_url
-	Dialect     string           // postgres, mysql, or sqlite
+	Dialect     string           // postgres, mysql, sqlite, or mssql
 	CRUDConfig  *crud.CRUDConfig // Scope and order configuration for CRUD generation
+	MaxRows     int              // From shipq.ini [db] max_rows; 0 (unset) leaves ReturnMany uncapped
+	// PrepareStatements is [db] prepare_statements: the query runner
+	// prepares and caches the statement of each query.
+	PrepareStatements bool
+	// Telemetry is [server] telemetry = otel: the query runner package gets
+	// queries.Traced, which cmd/server wraps its runner in.
+	Telemetry bool
+	// QueryTimeout is [db] query_timeout: the default timeout of each query
+	// method of the runner (0 = none).
+	QueryTimeout time.Duration
 }
 
 // GetTableOpts returns the TableOpts map from CRUDConfig, or an empty map if not configured.
@@ -61,13 +75,40 @@ func LoadDBPackageConfig(goModRoot, shipqRoot string) (*DBPackageConfig, error)
 	// Tables will be loaded later and ApplyScopeFiltering will be called
 	crudCfg, _ := crud.LoadCRUDConfig(ini, nil) // Pass nil tables for now
 
+	var maxRows int
+	if v := ini.Get("db", "max_rows"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("[db] max_rows = %q must be a non-negative integer", v)
+		}
+		maxRows = n
+	}
+
+	var queryTimeout time.Duration
+	if v := ini.Get("db", "query_timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 || d%time.Millisecond != 0 {
+			return nil, fmt.Errorf("[db] query_timeout = %q must be a non-negative duration in milliseconds or coarser, e.g. 5s", v)
+		}
+		queryTimeout = d
+	}
+
+	telemetry, err := config.ParseTelemetry(ini)
+	if err != nil {
+		return nil, err
+	}
+
 	return &DBPackageConfig{
-		GoModRoot:   goModRoot,
-		ShipqRoot:   shipqRoot,
-		ModulePath:  moduleInfo.FullImportPath(""),
-		DatabaseURL: databaseURL,
-		Dialect:     dialect,
-		CRUDConfig:  crudCfg,
+		GoModRoot:         goModRoot,
+		ShipqRoot:         shipqRoot,
+		ModulePath:        moduleInfo.FullImportPath(""),
+		DatabaseURL:       databaseURL,
+		Dialect:           dialect,
+		CRUDConfig:        crudCfg,
+		MaxRows:           maxRows,
+		PrepareStatements: strings.ToLower(ini.Get("db", "prepare_statements")) == "true",
+		Telemetry:         telemetry,
+		QueryTimeout:      queryTimeout,
 	}, nil
 }
 
@@ -87,6 +128,9 @@ func GenerateDBFile(cfg *DBPackageConfig) ([]byte, error) {
 	case dburl.DialectSQLite:
 		driverImport = `_ "modernc.org/sqlite"`
 		driverName = "sqlite"
+	case dburl.DialectMSSQL:
+		driverImport = `_ "github.com/microsoft/go-mssqldb"`
+		driverName = "sqlserver"
 	default:
 		return nil, fmt.Errorf("unsupported dialect: %s", cfg.Dialect)
 	}
@@ -156,7 +200,18 @@ func DB() (*sql.DB, error) {
 			dbErr = fmt.Errorf("shipq: failed to open database: %w", dbErr)
 			return
 		}
-
+`)
+	if cfg.Dialect == dburl.DialectSQLite {
+		buf.WriteString(`
+		// An in-memory database lives only while a connection to it is
+		// open, so pin the pool to a single connection that is never closed.
+		if isMemoryDSN(dsn) {
+			db.SetMaxOpenConns(1)
+			db.SetMaxIdleConns(1)
+		}
+`)
+	}
+	buf.WriteString(`
 		// Verify connection
 		if err := db.Ping(); err != nil {
 			dbErr = fmt.Errorf("shipq: failed to ping database: %w", err)
@@ -225,21 +280,41 @@ func urlToDSN(dbURL string) (string, error) {
 }
 `)
 	case dburl.DialectSQLite:
-		buf.WriteString(`// urlToDSN converts a sqlite:// URL to a file path.
+		buf.WriteString(`// urlToDSN converts a sqlite:// URL to a file path. An in-memory database
+// (sqlite://:memory:?cache=shared) becomes a file: URI so that SQLite
+// honours cache=shared.
 func urlToDSN(dbURL string) (string, error) {
+	dsn := dbURL
 	// Strip sqlite:// or sqlite: prefix
-	if len(dbURL) > 9 && dbURL[:9] == "sqlite://" {
-		return dbURL[9:], nil
+	if len(dsn) > 9 && dsn[:9] == "sqlite://" {
+		dsn = dsn[9:]
+	} else if len(dsn) > 7 && dsn[:7] == "sqlite:" {
+		dsn = dsn[7:]
+	}
+	if len(dsn) >= 8 && dsn[:8] == ":memory:" {
+		dsn = "file:" + dsn
 	}
-	if len(dbURL) > 7 && dbURL[:7] == "sqlite:" {
-		return dbURL[7:], nil
+	return dsn, nil
+}
+
+// isMemoryDSN reports whether dsn opens an in-memory database.
+func isMemoryDSN(dsn string) bool {
+	return len(dsn) >= 13 && dsn[:13] == "file::memory:"
+}
+`)
+	case dburl.DialectMSSQL:
+		buf.WriteString(`// urlToDSN converts an mssql:// URL to the sqlserver:// form accepted by
+// go-mssqldb. sqlserver:// URLs are passed through unchanged.
+func urlToDSN(dbURL string) (string, error) {
+	if len(dbURL) > 8 && dbURL[:8] == "mssql://" {
+		return "sqlserver://" + dbURL[8:], nil
 	}
 	return dbURL, nil
 }
 `)
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format db.go: %w", err)
 	}
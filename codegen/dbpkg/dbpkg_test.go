@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shipq/shipq/codegen/dbpkg"
 )
@@ -48,6 +49,63 @@ database_url = postgres://user@localhost:5432/mydb
 		if cfg.GoModRoot != tmpDir {
 			t.Errorf("GoModRoot = %q, want %q", cfg.GoModRoot, tmpDir)
 		}
+		if cfg.MaxRows != 0 {
+			t.Errorf("MaxRows = %d, want 0 (no cap) when max_rows is unset", cfg.MaxRows)
+		}
+	})
+
+	t.Run("reads max_rows", func(t *testing.T) {
+		for value, want := range map[string]int{"500": 500, "0": 0, "-1": -1, "lots": -1} {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example.com/myapp\n\ngo 1.21\n"), 0644); err != nil {
+				t.Fatalf("failed to write go.mod: %v", err)
+			}
+			shipqIni := "[db]\ndatabase_url = postgres://user@localhost:5432/mydb\nmax_rows = " + value + "\n"
+			if err := os.WriteFile(filepath.Join(tmpDir, "shipq.ini"), []byte(shipqIni), 0644); err != nil {
+				t.Fatalf("failed to write shipq.ini: %v", err)
+			}
+
+			cfg, err := dbpkg.LoadDBPackageConfig(tmpDir, tmpDir)
+			if want < 0 {
+				if err == nil {
+					t.Errorf("max_rows = %s: expected error", value)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("max_rows = %s: LoadDBPackageConfig() error = %v", value, err)
+			}
+			if cfg.MaxRows != want {
+				t.Errorf("max_rows = %s: MaxRows = %d, want %d", value, cfg.MaxRows, want)
+			}
+		}
+	})
+
+	t.Run("reads query_timeout", func(t *testing.T) {
+		for value, want := range map[string]time.Duration{"": 0, "5s": 5 * time.Second, "250ms": 250 * time.Millisecond, "0": 0, "-1s": -1, "1us": -1, "soon": -1} {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example.com/myapp\n\ngo 1.21\n"), 0644); err != nil {
+				t.Fatalf("failed to write go.mod: %v", err)
+			}
+			shipqIni := "[db]\ndatabase_url = postgres://user@localhost:5432/mydb\nquery_timeout = " + value + "\n"
+			if err := os.WriteFile(filepath.Join(tmpDir, "shipq.ini"), []byte(shipqIni), 0644); err != nil {
+				t.Fatalf("failed to write shipq.ini: %v", err)
+			}
+
+			cfg, err := dbpkg.LoadDBPackageConfig(tmpDir, tmpDir)
+			if want < 0 {
+				if err == nil {
+					t.Errorf("query_timeout = %s: expected error", value)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("query_timeout = %s: LoadDBPackageConfig() error = %v", value, err)
+			}
+			if cfg.QueryTimeout != want {
+				t.Errorf("query_timeout = %s: QueryTimeout = %s, want %s", value, cfg.QueryTimeout, want)
+			}
+		}
 	})
 
 	t.Run("detects mysql dialect", func(t *testing.T) {
@@ -287,6 +345,41 @@ func TestGenerateDBFile(t *testing.T) {
 		if !strings.Contains(contentStr, `_ "modernc.org/sqlite"`) {
 			t.Error("generated code missing sqlite driver import")
 		}
+
+		// In-memory databases are pinned to one connection
+		if !strings.Contains(contentStr, "if isMemoryDSN(dsn) {") || !strings.Contains(contentStr, "db.SetMaxOpenConns(1)") {
+			t.Error("generated code missing in-memory connection pinning")
+		}
+		if !strings.Contains(contentStr, `dsn = "file:" + dsn`) {
+			t.Error("generated urlToDSN missing in-memory URI conversion")
+		}
+	})
+
+	t.Run("generates valid go code for mssql", func(t *testing.T) {
+		cfg := &dbpkg.DBPackageConfig{
+			GoModRoot:   "/fake/root",
+			ShipqRoot:   "/fake/root",
+			ModulePath:  "example.com/myapp",
+			DatabaseURL: "sqlserver://sa@localhost:1433?database=mydb",
+			Dialect:     "mssql",
+		}
+
+		content, err := dbpkg.GenerateDBFile(cfg)
+		if err != nil {
+			t.Fatalf("GenerateDBFile() error = %v", err)
+		}
+
+		contentStr := string(content)
+
+		if !strings.Contains(contentStr, `_ "github.com/microsoft/go-mssqldb"`) {
+			t.Error("generated code missing go-mssqldb import")
+		}
+		if !strings.Contains(contentStr, `sql.Open("sqlserver", dsn)`) {
+			t.Error("generated code should open the sqlserver driver")
+		}
+		if !strings.Contains(contentStr, `return "sqlserver://" + dbURL[8:], nil`) {
+			t.Error("generated urlToDSN missing mssql:// rewrite")
+		}
 	})
 
 	t.Run("error for unsupported dialect", func(t *testing.T) {
@@ -0,0 +1,171 @@
+package discovery
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NoRouteDirective marks an exported handler-shaped function under api/
+// that is deliberately not routed (e.g. a helper shared by two handlers),
+// so strict discovery does not report it.
+const NoRouteDirective = "//shipq:noroute"
+
+// UnregisteredHandler is an exported function under api/ that looks like
+// a handler but is not in the handler manifest.
+type UnregisteredHandler struct {
+	Package string // import path, e.g. "myapp/api/posts"
+	Func    string // e.g. "ArchivePost"
+	Pos     string // file:line relative to shipqRoot, e.g. "api/posts/archive.go:12"
+}
+
+// FindUnregisteredHandlers parses every package under api/ and returns the
+// exported functions with a handler signature that registered does not
+// contain. registered is keyed by "<import path>.<func name>".
+//
+// Two shapes count as handlers: shipq handlers,
+// func(context.Context, *Req) (Resp, error), which a Register function
+// forgot to route, and plain net/http handlers,
+// func(http.ResponseWriter, *http.Request), which the handler compiler
+// cannot route at all. Test files, generated files and functions whose doc
+// comment carries NoRouteDirective are skipped.
+func FindUnregisteredHandlers(goModRoot, shipqRoot, modulePath string, registered map[string]bool) ([]UnregisteredHandler, error) {
+	pkgs, err := DiscoverPackages(goModRoot, shipqRoot, "api", modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []UnregisteredHandler
+	fset := token.NewFileSet()
+	for _, pkg := range pkgs {
+		dir := filepath.Join(goModRoot, filepath.FromSlash(strings.TrimPrefix(pkg, modulePath+"/")))
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			if ast.IsGenerated(file) {
+				continue
+			}
+
+			imports := importNames(file)
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || !fn.Name.IsExported() || hasNoRoute(fn) {
+					continue
+				}
+				if !isShipqHandler(fn.Type, imports) && !isHTTPHandler(fn.Type, imports) {
+					continue
+				}
+				if registered[pkg+"."+fn.Name.Name] {
+					continue
+				}
+				p := fset.Position(fn.Pos())
+				rel, err := filepath.Rel(shipqRoot, p.Filename)
+				if err != nil {
+					rel = p.Filename
+				}
+				found = append(found, UnregisteredHandler{
+					Package: pkg,
+					Func:    fn.Name.Name,
+					Pos:     fmt.Sprintf("%s:%d", filepath.ToSlash(rel), p.Line),
+				})
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Pos < found[j].Pos })
+	return found, nil
+}
+
+// importNames maps the names a file refers to its imports by to their
+// paths, honouring aliases.
+func importNames(file *ast.File) map[string]string {
+	names := make(map[string]string, len(file.Imports))
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		names[name] = path
+	}
+	return names
+}
+
+// isSelector reports whether expr is pkg.name for the import path pkg.
+func isSelector(expr ast.Expr, imports map[string]string, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && imports[x.Name] == pkg
+}
+
+// paramTypes flattens a field list so "a, b int" yields two entries.
+func paramTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, f := range fields.List {
+		n := max(len(f.Names), 1)
+		for i := 0; i < n; i++ {
+			types = append(types, f.Type)
+		}
+	}
+	return types
+}
+
+func isShipqHandler(ft *ast.FuncType, imports map[string]string) bool {
+	params, results := paramTypes(ft.Params), paramTypes(ft.Results)
+	if len(params) != 2 || len(results) != 2 {
+		return false
+	}
+	if _, ok := params[1].(*ast.StarExpr); !ok {
+		return false
+	}
+	errIdent, ok := results[1].(*ast.Ident)
+	return ok && errIdent.Name == "error" && isSelector(params[0], imports, "context", "Context")
+}
+
+func isHTTPHandler(ft *ast.FuncType, imports map[string]string) bool {
+	params := paramTypes(ft.Params)
+	if len(params) != 2 || ft.Results != nil && len(ft.Results.List) > 0 {
+		return false
+	}
+	req, ok := params[1].(*ast.StarExpr)
+	return ok && isSelector(params[0], imports, "net/http", "ResponseWriter") &&
+		isSelector(req.X, imports, "net/http", "Request")
+}
+
+func hasNoRoute(fn *ast.FuncDecl) bool {
+	if fn.Doc == nil {
+		return false
+	}
+	for _, c := range fn.Doc.List {
+		if strings.HasPrefix(c.Text, NoRouteDirective) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestFindUnregisteredHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeFile(t, filepath.Join(tmpDir, "api", "posts", "handlers.go"), `package posts
+
+import (
+	"context"
+	stdhttp "net/http"
+)
+
+type CreatePostRequest struct{}
+type CreatePostResponse struct{}
+
+func CreatePost(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
+	return nil, nil
+}
+
+func ArchivePost(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
+	return nil, nil
+}
+
+func Webhook(w stdhttp.ResponseWriter, r *stdhttp.Request) {}
+
+//shipq:noroute
+func SharedHelper(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
+	return nil, nil
+}
+
+func unexported(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
+	return nil, nil
+}
+
+func NotAHandler(ctx context.Context, id string) error { return nil }
+`)
+	writeFile(t, filepath.Join(tmpDir, "api", "posts", "handlers_test.go"), `package posts
+
+import "context"
+
+func TestOnly(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
+	return nil, nil
+}
+`)
+	writeFile(t, filepath.Join(tmpDir, "api", "posts", "zz_generated.go"), `// Code generated by shipq. DO NOT EDIT.
+package posts
+
+import "context"
+
+func Generated(ctx context.Context, req *CreatePostRequest) (*CreatePostResponse, error) {
+	return nil, nil
+}
+`)
+
+	registered := map[string]bool{"example.com/myapp/api/posts.CreatePost": true}
+	found, err := FindUnregisteredHandlers(tmpDir, tmpDir, "example.com/myapp", registered)
+	if err != nil {
+		t.Fatalf("FindUnregisteredHandlers failed: %v", err)
+	}
+
+	want := []UnregisteredHandler{
+		{Package: "example.com/myapp/api/posts", Func: "ArchivePost", Pos: "api/posts/handlers.go:15"},
+		{Package: "example.com/myapp/api/posts", Func: "Webhook", Pos: "api/posts/handlers.go:19"},
+	}
+	if len(found) != len(want) {
+		t.Fatalf("expected %d unregistered handlers, got %d: %+v", len(want), len(found), found)
+	}
+	for i := range want {
+		if found[i] != want[i] {
+			t.Errorf("found[%d] = %+v, want %+v", i, found[i], want[i])
+		}
+	}
+}
+
+func TestFindUnregisteredHandlers_AllRegistered(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeFile(t, filepath.Join(tmpDir, "api", "health", "health.go"), `package health
+
+import "net/http"
+
+func Check(w http.ResponseWriter, r *http.Request) {}
+`)
+
+	registered := map[string]bool{"example.com/myapp/api/health.Check": true}
+	found, err := FindUnregisteredHandlers(tmpDir, tmpDir, "example.com/myapp", registered)
+	if err != nil {
+		t.Fatalf("FindUnregisteredHandlers failed: %v", err)
+	}
+	if len(found) != 0 {
+		t.Errorf("expected no unregistered handlers, got %+v", found)
+	}
+}
@@ -30,10 +30,12 @@ type embeddedPackage struct {
 // depends on the published shipq module.
 // EmbedOptions controls which optional packages are embedded.
 type EmbedOptions struct {
-	FilesEnabled   bool
-	WorkersEnabled bool
-	LLMEnabled     bool
-	DBDialect      string // "sqlite", "postgres", or "mysql"
+	FilesEnabled     bool
+	WorkersEnabled   bool
+	LLMEnabled       bool
+	GRPCEnabled      bool
+	TelemetryEnabled bool
+	DBDialect        string // "sqlite", "postgres", or "mysql"
 }
 
 func EmbedAllPackages(shipqRoot, modulePath string, opts EmbedOptions) error {
@@ -51,13 +53,17 @@ func EmbedAllPackages(shipqRoot, modulePath string, opts EmbedOptions) error {
 		{fs: shipqsrc.CryptoFS, srcDir: "crypto", destDir: filepath.Join("shipq", "lib", "crypto")},
 		{fs: shipqsrc.NanoidFS, srcDir: "nanoid", destDir: filepath.Join("shipq", "lib", "nanoid")},
 		{fs: shipqsrc.HttputilFS, srcDir: "httputil", destDir: filepath.Join("shipq", "lib", "httputil")},
+		{fs: shipqsrc.ValidateFS, srcDir: "validate", destDir: filepath.Join("shipq", "lib", "validate")},
 		{fs: shipqsrc.QueryFS, srcDir: filepath.Join("db", "portsql", "query"), destDir: filepath.Join("shipq", "lib", "db", "portsql", "query")},
 		{fs: shipqsrc.QueryCompileFS, srcDir: filepath.Join("db", "portsql", "query", "compile"), destDir: filepath.Join("shipq", "lib", "db", "portsql", "query", "compile")},
 		{fs: shipqsrc.MigrateFS, srcDir: filepath.Join("db", "portsql", "migrate"), destDir: filepath.Join("shipq", "lib", "db", "portsql", "migrate")},
 		{fs: shipqsrc.DdlFS, srcDir: filepath.Join("db", "portsql", "ddl"), destDir: filepath.Join("shipq", "lib", "db", "portsql", "ddl")},
 		{fs: shipqsrc.RefFS, srcDir: filepath.Join("db", "portsql", "ref"), destDir: filepath.Join("shipq", "lib", "db", "portsql", "ref")},
+		{fs: shipqsrc.QueryRowdiffFS, srcDir: filepath.Join("db", "portsql", "query", "rowdiff"), destDir: filepath.Join("shipq", "lib", "db", "portsql", "query", "rowdiff")},
+		{fs: shipqsrc.QueryMemdbFS, srcDir: filepath.Join("db", "portsql", "query", "memdb"), destDir: filepath.Join("shipq", "lib", "db", "portsql", "query", "memdb")},
 		{fs: shipqsrc.ProptestFS, srcDir: "proptest", destDir: filepath.Join("shipq", "lib", "proptest")},
 		{fs: shipqsrc.DagFS, srcDir: "dag", destDir: filepath.Join("shipq", "lib", "dag")},
+		{fs: shipqsrc.OpenapidiffFS, srcDir: "openapidiff", destDir: filepath.Join("shipq", "lib", "openapidiff")},
 	}
 
 	if opts.FilesEnabled {
@@ -74,6 +80,20 @@ func EmbedAllPackages(shipqRoot, modulePath string, opts EmbedOptions) error {
 		})
 	}
 
+	if opts.GRPCEnabled {
+		packages = append(packages, embeddedPackage{
+			fs: shipqsrc.GrpcbridgeFS, srcDir: "grpcbridge",
+			destDir: filepath.Join("shipq", "lib", "grpcbridge"),
+		})
+	}
+
+	if opts.TelemetryEnabled {
+		packages = append(packages, embeddedPackage{
+			fs: shipqsrc.TelemetryFS, srcDir: "telemetry",
+			destDir: filepath.Join("shipq", "lib", "telemetry"),
+		})
+	}
+
 	if opts.LLMEnabled {
 		packages = append(packages,
 			embeddedPackage{
@@ -238,6 +258,7 @@ var driverImports = map[string][]byte{
 	"sqlite":   []byte(`"modernc.org/sqlite"`),
 	"postgres": []byte(`"github.com/jackc/pgx/v5`),
 	"mysql":    []byte(`"github.com/go-sql-driver/mysql"`),
+	"mssql":    []byte(`"github.com/microsoft/go-mssqldb"`),
 }
 
 // importsWrongDriver returns true if the file content contains a
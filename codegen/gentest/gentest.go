@@ -0,0 +1,174 @@
+// Package gentest builds generated code into a throwaway shipq project so
+// generator tests can compile it and run tests against it, instead of only
+// searching the generated source for text.
+//
+// The project is laid out under the calling package's testdata directory,
+// inside the shipq module, so it builds against the module's own
+// dependencies: no go.mod of its own and no network access.
+package gentest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/embed"
+	"github.com/shipq/shipq/codegen/querycompile"
+	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
+	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dburl"
+)
+
+// shipqModulePath is the module the project is laid out in.
+const shipqModulePath = "github.com/shipq/shipq"
+
+// Project is a generated SQLite project with the shipq library packages
+// and the schema package of its migration plan.
+type Project struct {
+	t          testing.TB
+	plan       *migrate.MigrationPlan
+	Root       string // absolute path of the project
+	ModulePath string // import path of Root
+}
+
+// New creates a project for plan, removed again when the test ends. It
+// skips the test in -short mode, as building a project takes a while.
+func New(t testing.TB, plan *migrate.MigrationPlan) *Project {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds a generated project")
+	}
+
+	if err := os.MkdirAll("testdata", 0o755); err != nil {
+		t.Fatalf("gentest: %v", err)
+	}
+	dir, err := os.MkdirTemp("testdata", "gentest-")
+	if err != nil {
+		t.Fatalf("gentest: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatalf("gentest: %v", err)
+	}
+	modRoot, err := moduleRoot(root)
+	if err != nil {
+		t.Fatalf("gentest: %v", err)
+	}
+	rel, err := filepath.Rel(modRoot, root)
+	if err != nil {
+		t.Fatalf("gentest: %v", err)
+	}
+
+	p := &Project{
+		t:          t,
+		plan:       plan,
+		Root:       root,
+		ModulePath: shipqModulePath + "/" + filepath.ToSlash(rel),
+	}
+	if err := embed.EmbedAllPackages(root, p.ModulePath, embed.EmbedOptions{DBDialect: dburl.DialectSQLite}); err != nil {
+		t.Fatalf("gentest: embed library packages: %v", err)
+	}
+	schema, err := portsqlcodegen.GenerateSchemaPackage(plan, p.ModulePath+"/shipq/lib/db/portsql/query")
+	if err != nil {
+		t.Fatalf("gentest: generate schema package: %v", err)
+	}
+	p.WriteFile("shipq/db/schema/schema.go", schema)
+	return p
+}
+
+// moduleRoot returns the directory of the go.mod enclosing dir.
+func moduleRoot(dir string) (string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("no go.mod above %s", dir)
+		}
+	}
+}
+
+// WriteFile writes data to path, relative to the project root.
+func (p *Project) WriteFile(path string, data []byte) {
+	p.t.Helper()
+	full := filepath.Join(p.Root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		p.t.Fatalf("gentest: %v", err)
+	}
+	if err := os.WriteFile(full, data, 0o644); err != nil {
+		p.t.Fatalf("gentest: %v", err)
+	}
+}
+
+// CompileQueries compiles the querydefs packages written under
+// shipq/querydefs and generates the queries package, its SQLite runner and
+// the fake runner from them, as `shipq db compile` does.
+func (p *Project) CompileQueries() {
+	p.t.Helper()
+	entries, err := os.ReadDir(filepath.Join(p.Root, "shipq", "querydefs"))
+	if err != nil {
+		p.t.Fatalf("gentest: %v", err)
+	}
+	var pkgs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			pkgs = append(pkgs, p.ModulePath+"/shipq/querydefs/"+e.Name())
+		}
+	}
+	userQueries, err := querycompile.BuildAndRunCompileProgram(p.Root, querycompile.CompileProgramConfig{
+		ModulePath:    p.ModulePath,
+		QuerydefsPkgs: pkgs,
+	})
+	if err != nil {
+		p.t.Fatalf("gentest: compile queries: %v", err)
+	}
+
+	cfg := queryrunner.UnifiedRunnerConfig{
+		ModulePath:  p.ModulePath,
+		Dialect:     dburl.DialectSQLite,
+		UserQueries: userQueries,
+		Schema:      p.plan.Schema.Tables,
+	}
+	generators := []struct {
+		path string
+		gen  func(queryrunner.UnifiedRunnerConfig) ([]byte, error)
+	}{
+		{"shipq/queries/types.go", queryrunner.GenerateSharedTypes},
+		{"shipq/queries/sqlite/runner.go", queryrunner.GenerateUnifiedRunner},
+		{queryrunner.FakeRunnerPath, queryrunner.GenerateFakeRunner},
+	}
+	for _, g := range generators {
+		code, err := g.gen(cfg)
+		if err != nil {
+			p.t.Fatalf("gentest: generate %s: %v", g.path, err)
+		}
+		p.WriteFile(g.path, code)
+	}
+}
+
+// SchemaSQL returns the SQLite statements of the plan's migrations, which
+// create its tables in a test database.
+func (p *Project) SchemaSQL() string {
+	var stmts []string
+	for _, m := range p.plan.Migrations {
+		stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(m.Instructions.Sqlite), ";"))
+	}
+	return strings.Join(stmts, ";\n") + ";"
+}
+
+// GoTest runs the tests of the package at path, relative to the project
+// root, and fails the test with their output unless they pass.
+func (p *Project) GoTest(path string) {
+	p.t.Helper()
+	cmd := exec.Command("go", "test", "-count=1", "./"+filepath.ToSlash(path))
+	cmd.Dir = p.Root
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		p.t.Fatalf("go test %s: %v\n%s", path, err, out)
+	}
+}
@@ -0,0 +1,454 @@
+// Package gofile parses generated Go source for generator tests. It looks
+// up declarations, statements and expressions by their gofmt form, so
+// tests can assert on what a generated function does rather than on
+// fragments of its text. It has no dependencies on the generators, so
+// their own packages can use it in tests.
+package gofile
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// File is a parsed generated Go file.
+//
+// Statements and expressions are compared with runs of white space
+// collapsed, so "if req.X != nil" matches however the generator indents
+// it. Block statements (if, for, switch, case) match on their header
+// alone: "if err != nil", "for _, item := range items", "case 1, 2".
+type File struct {
+	t    testing.TB
+	name string
+	src  []byte
+	fset *token.FileSet
+	AST  *ast.File
+}
+
+// Parse parses src, the contents of the generated file name, and fails the
+// test if it is not valid Go.
+func Parse(t testing.TB, name string, src []byte) *File {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, src, parser.AllErrors)
+	if err != nil {
+		t.Fatalf("generated %s does not parse: %v\n%s", name, err, src)
+	}
+	return &File{t: t, name: name, src: src, fset: fset, AST: f}
+}
+
+// Source returns the file's contents.
+func (f *File) Source() string { return string(f.src) }
+
+// HasImport reports whether the file imports path.
+func (f *File) HasImport(path string) bool {
+	for _, imp := range f.AST.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Func returns the declaration of the function name, or of the method
+// "Type.Method" whatever its receiver's pointerness, or nil.
+func (f *File) Func(name string) *ast.FuncDecl {
+	for _, d := range f.AST.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if ok && funcName(fn) == name {
+			return fn
+		}
+	}
+	return nil
+}
+
+// Doc returns the text of the doc comment of the function or method name,
+// or "" if it has none. The file's AST is parsed without comments, so that
+// statements print without them; Doc parses the source again to find it.
+func (f *File) Doc(name string) string {
+	file, err := parser.ParseFile(token.NewFileSet(), f.name, f.src, parser.ParseComments)
+	if err != nil {
+		return ""
+	}
+	for _, d := range file.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && funcName(fn) == name {
+			return fn.Doc.Text()
+		}
+	}
+	return ""
+}
+
+// HasFunc reports whether the file declares the function or method name.
+func (f *File) HasFunc(name string) bool { return f.Func(name) != nil }
+
+// Signature returns the gofmt form of name's declaration without its
+// body, such as "func Get(ctx context.Context) (*Item, error)", or "" if
+// the file does not declare it.
+func (f *File) Signature(name string) string {
+	fn := f.Func(name)
+	if fn == nil {
+		return ""
+	}
+	decl := *fn
+	decl.Body = nil
+	decl.Doc = nil
+	return f.format(&decl)
+}
+
+// Value returns the gofmt form of the value of the package-level constant
+// or variable name, or "" if the file does not declare it with a value.
+func (f *File) Value(name string) string {
+	for _, d := range f.AST.Decls {
+		gen, ok := d.(*ast.GenDecl)
+		if !ok || (gen.Tok != token.CONST && gen.Tok != token.VAR) {
+			continue
+		}
+		for _, s := range gen.Specs {
+			vs := s.(*ast.ValueSpec)
+			for i, n := range vs.Names {
+				if n.Name == name && i < len(vs.Values) {
+					return f.format(vs.Values[i])
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// HasType reports whether the file declares the type name.
+func (f *File) HasType(name string) bool { return f.typeSpec(name) != nil }
+
+// Type returns the gofmt form of the type the file declares as name, such
+// as "interface {\n\tClose() error\n}", or "" if it does not declare it.
+func (f *File) Type(name string) string {
+	if spec := f.typeSpec(name); spec != nil {
+		return f.format(spec.Type)
+	}
+	return ""
+}
+
+// Field returns the type and the unquoted tag of field in the struct type
+// typeName, with ok false if there is no such field.
+func (f *File) Field(typeName, field string) (typ, tag string, ok bool) {
+	spec := f.typeSpec(typeName)
+	if spec == nil {
+		return "", "", false
+	}
+	st, isStruct := spec.Type.(*ast.StructType)
+	if !isStruct {
+		return "", "", false
+	}
+	for _, fld := range st.Fields.List {
+		for _, n := range fld.Names {
+			if n.Name != field {
+				continue
+			}
+			if fld.Tag != nil {
+				tag, _ = strconv.Unquote(fld.Tag.Value)
+			}
+			return f.format(fld.Type), tag, true
+		}
+	}
+	return "", "", false
+}
+
+// StructTag returns the value of key in the tag of field in the struct
+// type typeName, as reflect.StructTag.Get would.
+func (f *File) StructTag(typeName, field, key string) string {
+	_, tag, _ := f.Field(typeName, field)
+	return reflect.StructTag(tag).Get(key)
+}
+
+// HasStmt reports whether the function fn contains stmt, at any depth and
+// including function literals. An empty fn searches every declaration in
+// the file.
+func (f *File) HasStmt(fn, stmt string) bool {
+	return f.stmtPos(fn, stmt).IsValid()
+}
+
+// HasExpr reports whether the function fn contains the expression expr,
+// such as a call or a "Key: value" element of a composite literal. An
+// empty fn searches every declaration in the file.
+func (f *File) HasExpr(fn, expr string) bool {
+	return f.exprPos(fn, expr).IsValid()
+}
+
+// Calls returns how many times fn calls callee, given as it is written at
+// the call site, such as "runner.CreatePost" or "httperror.NotFoundf".
+func (f *File) Calls(fn, callee string) int {
+	count := 0
+	f.inspect(fn, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && f.format(call.Fun) == callee {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// Args returns the arguments of each call fn makes to callee, in order,
+// with runs of white space collapsed.
+func (f *File) Args(fn, callee string) [][]string {
+	var calls [][]string
+	f.inspect(fn, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && f.format(call.Fun) == callee {
+			args := make([]string, len(call.Args))
+			for i, a := range call.Args {
+				args[i] = normalize(f.format(a))
+			}
+			calls = append(calls, args)
+		}
+		return true
+	})
+	return calls
+}
+
+// Strings returns the values of the string literals in fn, in order. An
+// empty fn searches every declaration in the file.
+func (f *File) Strings(fn string) []string {
+	var values []string
+	f.inspect(fn, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if v, err := strconv.Unquote(lit.Value); err == nil {
+				values = append(values, v)
+			}
+		}
+		return true
+	})
+	return values
+}
+
+// TopStmts returns the statements directly in the body of fn, not nested
+// in blocks, each in the form HasStmt matches.
+func (f *File) TopStmts(fn string) []string {
+	f.t.Helper()
+	decl := f.Func(fn)
+	if decl == nil {
+		f.t.Fatalf("%s: no function %s\n%s", f.name, fn, f.src)
+	}
+	var stmts []string
+	if decl.Body != nil {
+		for _, s := range decl.Body.List {
+			stmts = append(stmts, normalize(f.stmtHead(s)))
+		}
+	}
+	return stmts
+}
+
+// Before reports whether fn contains both first and second and first
+// comes before second. Each is a statement or, failing that, an
+// expression, such as the callee "runner.GetPost".
+func (f *File) Before(fn, first, second string) bool {
+	a, b := f.pos(fn, first), f.pos(fn, second)
+	return a.IsValid() && b.IsValid() && a < b
+}
+
+// AssertStmts fails the test for each of stmts that fn does not contain.
+func (f *File) AssertStmts(fn string, stmts ...string) {
+	f.t.Helper()
+	for _, s := range stmts {
+		if !f.HasStmt(fn, s) {
+			f.t.Errorf("%s: %s does not contain statement %q\n%s", f.name, f.describe(fn), s, f.src)
+		}
+	}
+}
+
+// AssertExprs fails the test for each of exprs that fn does not contain.
+func (f *File) AssertExprs(fn string, exprs ...string) {
+	f.t.Helper()
+	for _, e := range exprs {
+		if !f.HasExpr(fn, e) {
+			f.t.Errorf("%s: %s does not contain expression %q\n%s", f.name, f.describe(fn), e, f.src)
+		}
+	}
+}
+
+// AssertField fails the test unless the struct type typeName has field
+// with the given type and tag.
+func (f *File) AssertField(typeName, field, typ, tag string) {
+	f.t.Helper()
+	gotType, gotTag, ok := f.Field(typeName, field)
+	switch {
+	case !ok:
+		f.t.Errorf("%s: %s has no field %s\n%s", f.name, typeName, field, f.src)
+	case gotType != typ || gotTag != tag:
+		f.t.Errorf("%s: %s.%s is %s `%s`, want %s `%s`", f.name, typeName, field, gotType, gotTag, typ, tag)
+	}
+}
+
+// AssertSignature fails the test unless the file declares name with the
+// signature sig.
+func (f *File) AssertSignature(name, sig string) {
+	f.t.Helper()
+	if got := f.Signature(name); normalize(got) != normalize(sig) {
+		f.t.Errorf("%s: signature of %s is %q, want %q", f.name, name, got, sig)
+	}
+}
+
+func (f *File) describe(fn string) string {
+	if fn == "" {
+		return "file"
+	}
+	return fn
+}
+
+func (f *File) typeSpec(name string) *ast.TypeSpec {
+	for _, d := range f.AST.Decls {
+		gen, ok := d.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range gen.Specs {
+			if ts := s.(*ast.TypeSpec); ts.Name.Name == name {
+				return ts
+			}
+		}
+	}
+	return nil
+}
+
+// inspect walks fn, or every declaration when fn is empty. A missing
+// function fails the test, as every lookup in it would.
+func (f *File) inspect(fn string, visit func(ast.Node) bool) {
+	f.t.Helper()
+	if fn == "" {
+		for _, d := range f.AST.Decls {
+			ast.Inspect(d, visit)
+		}
+		return
+	}
+	decl := f.Func(fn)
+	if decl == nil {
+		f.t.Fatalf("%s: no function %s\n%s", f.name, fn, f.src)
+	}
+	if decl.Body != nil {
+		ast.Inspect(decl.Body, visit)
+	}
+}
+
+func (f *File) pos(fn, s string) token.Pos {
+	if p := f.stmtPos(fn, s); p.IsValid() {
+		return p
+	}
+	return f.exprPos(fn, s)
+}
+
+func (f *File) exprPos(fn, expr string) token.Pos {
+	want := normalize(expr)
+	pos := token.NoPos
+	f.inspect(fn, func(n ast.Node) bool {
+		if pos.IsValid() {
+			return false
+		}
+		if e, ok := n.(ast.Expr); ok && normalize(f.format(e)) == want {
+			pos = e.Pos()
+		}
+		return !pos.IsValid()
+	})
+	return pos
+}
+
+func (f *File) stmtPos(fn, stmt string) token.Pos {
+	want := normalize(stmt)
+	pos := token.NoPos
+	f.inspect(fn, func(n ast.Node) bool {
+		if pos.IsValid() {
+			return false
+		}
+		if s, ok := n.(ast.Stmt); ok && normalize(f.stmtHead(s)) == want {
+			pos = s.Pos()
+		}
+		return !pos.IsValid()
+	})
+	return pos
+}
+
+// stmtHead returns the gofmt form of s, or of its header for block
+// statements.
+func (f *File) stmtHead(s ast.Stmt) string {
+	switch s := s.(type) {
+	case *ast.IfStmt:
+		if s.Init != nil {
+			return "if " + f.format(s.Init) + "; " + f.format(s.Cond)
+		}
+		return "if " + f.format(s.Cond)
+	case *ast.ForStmt:
+		cp := *s
+		cp.Body = &ast.BlockStmt{}
+		return strings.TrimSuffix(f.format(&cp), " {\n}")
+	case *ast.RangeStmt:
+		cp := *s
+		cp.Body = &ast.BlockStmt{}
+		return strings.TrimSuffix(f.format(&cp), " {\n}")
+	case *ast.SwitchStmt:
+		cp := *s
+		cp.Body = &ast.BlockStmt{}
+		return strings.TrimSuffix(f.format(&cp), " {\n}")
+	case *ast.TypeSwitchStmt:
+		cp := *s
+		cp.Body = &ast.BlockStmt{}
+		return strings.TrimSuffix(f.format(&cp), " {\n}")
+	case *ast.SelectStmt:
+		return "select"
+	case *ast.CaseClause:
+		if s.List == nil {
+			return "default:"
+		}
+		return "case " + f.formatList(s.List) + ":"
+	case *ast.CommClause:
+		if s.Comm == nil {
+			return "default:"
+		}
+		return "case " + f.format(s.Comm) + ":"
+	case *ast.BlockStmt:
+		return "{"
+	case *ast.LabeledStmt:
+		return s.Label.Name + ":"
+	}
+	return f.format(s)
+}
+
+func (f *File) formatList(exprs []ast.Expr) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = f.format(e)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (f *File) format(n ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f.fset, n); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	typ := fn.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if idx, ok := typ.(*ast.IndexExpr); ok {
+		typ = idx.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// normalize collapses runs of white space, so that statements compare
+// equal whatever their indentation and line breaks.
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
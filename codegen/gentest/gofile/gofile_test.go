@@ -0,0 +1,82 @@
+package gofile
+
+import "testing"
+
+const sample = `package items
+
+import (
+	"context"
+	"fmt"
+)
+
+const maxItems = 10
+
+type Item struct {
+	Name  string ` + "`json:\"name\" validate:\"required\"`" + `
+	Count *int32 ` + "`json:\"count,omitempty\"`" + `
+}
+
+type store struct{}
+
+// Get returns the item id.
+func (s *store) Get(ctx context.Context, id string) (*Item, error) {
+	if id == "" {
+		return nil, fmt.Errorf("no id")
+	}
+	item := &Item{
+		Name:  id,
+		Count: nil,
+	}
+	for _, r := range id {
+		_ = r
+	}
+	return item, nil
+}
+`
+
+func TestFile(t *testing.T) {
+	f := Parse(t, "items.go", []byte(sample))
+
+	if !f.HasImport("context") || f.HasImport("os") {
+		t.Error("HasImport")
+	}
+	if got := f.Value("maxItems"); got != "10" {
+		t.Errorf("Value = %q", got)
+	}
+	f.AssertSignature("store.Get", "func (s *store) Get(ctx context.Context, id string) (*Item, error)")
+	if got := f.Doc("store.Get"); got != "Get returns the item id.\n" {
+		t.Errorf("Doc = %q", got)
+	}
+	if got := f.Type("store"); got != "struct{}" {
+		t.Errorf("Type = %q", got)
+	}
+	f.AssertField("Item", "Count", "*int32", `json:"count,omitempty"`)
+	if got := f.StructTag("Item", "Name", "validate"); got != "required" {
+		t.Errorf("StructTag = %q", got)
+	}
+	f.AssertStmts("store.Get",
+		`if id == ""`,
+		"for _, r := range id",
+		"return item, nil",
+		"item := &Item{ Name: id, Count: nil, }",
+	)
+	f.AssertExprs("store.Get", "Name: id", `fmt.Errorf("no id")`)
+	if got := f.TopStmts("store.Get"); len(got) != 4 || got[0] != `if id == ""` || got[3] != "return item, nil" {
+		t.Errorf("TopStmts = %q", got)
+	}
+	if got := f.Args("store.Get", "fmt.Errorf"); len(got) != 1 || len(got[0]) != 1 || got[0][0] != `"no id"` {
+		t.Errorf("Args = %q", got)
+	}
+	if f.HasStmt("store.Get", "return nil, nil") {
+		t.Error("HasStmt matched a missing statement")
+	}
+	if got := f.Strings("store.Get"); len(got) != 2 || got[0] != "" || got[1] != "no id" {
+		t.Errorf("Strings = %q", got)
+	}
+	if got := f.Calls("", "fmt.Errorf"); got != 1 {
+		t.Errorf("Calls = %d, want 1", got)
+	}
+	if !f.Before("store.Get", `if id == ""`, "return item, nil") || f.Before("store.Get", "return item, nil", `if id == ""`) {
+		t.Error("Before")
+	}
+}
@@ -0,0 +1,442 @@
+// Package grpcgen generates the gRPC view of the handler registry: a .proto
+// file with one service per resource and one RPC per handler, and the Go
+// file that serves it with grpcbridge. Request and response messages are
+// derived from the handler structs, so they change whenever the handlers do
+// and gRPC stays in sync with HTTP.
+package grpcgen
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/httpserver/server"
+	"github.com/shipq/shipq/codegen/phase"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// GRPCGenConfig holds configuration for generating the gRPC service.
+type GRPCGenConfig struct {
+	ModulePath  string                          // e.g., "myapp"
+	OutputPkg   string                          // package of the generated HTTP server (e.g., "api"); also the proto package
+	Handlers    []codegen.SerializedHandlerInfo // handlers from registry
+	StripPrefix string                          // [server] strip_prefix; prepended to every route the RPCs call
+}
+
+// GeneratedGRPCFile represents a single generated file.
+type GeneratedGRPCFile struct {
+	RelPath string // relative path from shipq root, e.g., "api/api.proto"
+	Content []byte
+}
+
+// Well-known types standing in for Go types with no proto equivalent.
+const (
+	emptyType     = ".google.protobuf.Empty"
+	timestampType = ".google.protobuf.Timestamp"
+	valueType     = ".google.protobuf.Value"
+)
+
+// wellKnownImports maps a well-known type to the file declaring it.
+var wellKnownImports = map[string]string{
+	emptyType:     "google/protobuf/empty.proto",
+	timestampType: "google/protobuf/timestamp.proto",
+	valueType:     "google/protobuf/struct.proto",
+}
+
+// route is the HTTP route an RPC calls.
+type route struct {
+	service string // fully-qualified service name
+	rpc     string
+	method  string
+	path    string // with {param} placeholders and the strip prefix
+	query   []string
+}
+
+// GenerateGRPC generates <OutputPkg>/<OutputPkg>.proto and
+// <OutputPkg>/zz_generated_grpc.go, which defines NewGRPCServer.
+func GenerateGRPC(cfg GRPCGenConfig) ([]GeneratedGRPCFile, error) {
+	file, routes := buildFile(cfg)
+
+	desc, err := proto.MarshalOptions{Deterministic: true}.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", file.GetName(), err)
+	}
+	goCode, err := generateGoFile(cfg, desc, routes)
+	if err != nil {
+		return nil, err
+	}
+
+	return []GeneratedGRPCFile{
+		{RelPath: cfg.OutputPkg + "/" + file.GetName(), Content: renderProto(file, routes)},
+		{RelPath: cfg.OutputPkg + "/zz_generated_grpc.go", Content: goCode},
+	}, nil
+}
+
+// buildFile returns the proto file describing the handlers and
+// the route of each of its RPCs, in service and method order.
+func buildFile(cfg GRPCGenConfig) (*descriptorpb.FileDescriptorProto, []route) {
+	b := &builder{
+		file: &descriptorpb.FileDescriptorProto{
+			Name:    proto.String(cfg.OutputPkg + ".proto"),
+			Package: proto.String(cfg.OutputPkg),
+			Syntax:  proto.String("proto3"),
+		},
+		taken:   make(map[string]bool),
+		structs: make(map[string]string),
+		imports: make(map[string]bool),
+	}
+
+	var routes []route
+	takenServices := make(map[string]bool)
+	for _, group := range server.GroupHandlersByPackage(cfg.ModulePath, bridgeable(cfg.Handlers)) {
+		resource := protoIdent(dbstrings.ToPascalCase(group.ResourceName))
+		svcName := uniqueName(resource+"Service", takenServices)
+		svc := &descriptorpb.ServiceDescriptorProto{Name: proto.String(svcName)}
+		fullSvc := cfg.OutputPkg + "." + svcName
+
+		takenRPCs := make(map[string]bool)
+		for _, h := range group.Handlers {
+			rpc := uniqueName(protoIdent(h.FuncName), takenRPCs)
+			in, query := b.requestType(h, rpc, resource)
+			out := b.responseType(h, rpc, resource)
+			svc.Method = append(svc.Method, &descriptorpb.MethodDescriptorProto{
+				Name:       proto.String(rpc),
+				InputType:  proto.String(in),
+				OutputType: proto.String(out),
+			})
+			routes = append(routes, route{
+				service: fullSvc,
+				rpc:     rpc,
+				method:  h.Method,
+				path:    cfg.StripPrefix + codegen.ConvertPathSyntax(h.Path),
+				query:   query,
+			})
+		}
+		b.file.Service = append(b.file.Service, svc)
+	}
+
+	for _, wkt := range []string{emptyType, timestampType, valueType} {
+		if b.imports[wkt] {
+			b.file.Dependency = append(b.file.Dependency, wellKnownImports[wkt])
+		}
+	}
+	return b.file, routes
+}
+
+// bridgeable returns the handlers that take a JSON body or none. The bridge
+// re-encodes RPC input as JSON, so handlers reading a raw request body
+// (such as a CSV import) have no RPC.
+func bridgeable(handlers []codegen.SerializedHandlerInfo) []codegen.SerializedHandlerInfo {
+	var out []codegen.SerializedHandlerInfo
+	for _, h := range handlers {
+		if len(h.RawBodyTypes) == 0 {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// builder accumulates the messages of the proto file.
+type builder struct {
+	file    *descriptorpb.FileDescriptorProto
+	taken   map[string]bool   // message names in use
+	structs map[string]string // "<package>.<Name>" of a Go struct → its message name
+	imports map[string]bool   // well-known types referenced
+}
+
+// requestType returns the input type of the RPC for h and the JSON names
+// of its query fields. The message holds the request fields the route
+// binds: path parameters, query fields and, for POST, PUT and PATCH, the
+// body.
+func (b *builder) requestType(h codegen.SerializedHandlerInfo, rpc, resource string) (string, []string) {
+	if h.Request == nil && len(h.PathParams) == 0 {
+		b.imports[emptyType] = true
+		return emptyType, nil
+	}
+	msg := b.newMessage(rpc+"Request", resource)
+
+	var query []string
+	boundParams := make(map[string]bool)
+	if h.Request != nil {
+		for _, f := range h.Request.Fields {
+			key := ""
+			switch {
+			case pathParam(h, f) != "":
+				key = pathParam(h, f)
+				boundParams[key] = true
+			case f.Tags["query"] != "":
+				key, _, _ = strings.Cut(f.Tags["query"], ",")
+				query = append(query, key)
+			case codegen.MethodHasBody(h.Method):
+				key = f.JSONName
+			}
+			if key != "" {
+				b.addField(msg, f, key)
+			}
+		}
+	}
+	for _, pp := range h.PathParams {
+		if !boundParams[pp.Name] {
+			b.addField(msg, codegen.SerializedFieldInfo{Name: pp.Name, Type: "string"}, pp.Name)
+		}
+	}
+	return "." + b.file.GetPackage() + "." + msg.GetName(), query
+}
+
+// responseType returns the output type of the RPC for h.
+func (b *builder) responseType(h codegen.SerializedHandlerInfo, rpc, resource string) string {
+	if h.Response == nil {
+		b.imports[emptyType] = true
+		return emptyType
+	}
+	msg := b.newMessage(rpc+"Response", resource)
+	for _, f := range h.Response.Fields {
+		if f.JSONName != "" {
+			b.addField(msg, f, f.JSONName)
+		}
+	}
+	return "." + b.file.GetPackage() + "." + msg.GetName()
+}
+
+// pathParam returns the name of the path parameter f is bound to, if any,
+// matching fields the way codegen.FilterBodyFields does.
+func pathParam(h codegen.SerializedHandlerInfo, f codegen.SerializedFieldInfo) string {
+	for _, pp := range h.PathParams {
+		name := strings.ToLower(pp.Name)
+		if strings.ToLower(f.Tags["path"]) == name || strings.ToLower(f.JSONName) == name || strings.ToLower(f.Name) == name {
+			return pp.Name
+		}
+	}
+	return ""
+}
+
+// newMessage adds an empty message named name to the file, qualified by
+// qualifier if that name is already taken.
+func (b *builder) newMessage(name, qualifier string) *descriptorpb.DescriptorProto {
+	if b.taken[name] {
+		name = qualifier + name
+	}
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(uniqueName(name, b.taken))}
+	b.file.MessageType = append(b.file.MessageType, msg)
+	return msg
+}
+
+// structType returns the message type of a Go struct, adding the message
+// the first time the struct is seen. Named structs get one message each,
+// named after the struct; anonymous ones are named after the field.
+func (b *builder) structType(info *codegen.SerializedStructInfo, fallback string) string {
+	key := info.Package + "." + info.Name
+	if info.Name != "" {
+		if name, ok := b.structs[key]; ok {
+			return "." + b.file.GetPackage() + "." + name
+		}
+	}
+
+	name := protoIdent(info.Name)
+	if name == "" {
+		name = fallback
+	}
+	msg := b.newMessage(name, protoIdent(dbstrings.ToPascalCase(path.Base(info.Package))))
+	if info.Name != "" {
+		b.structs[key] = msg.GetName()
+	}
+	for _, f := range info.Fields {
+		if f.JSONName != "" {
+			b.addField(msg, f, f.JSONName)
+		}
+	}
+	return "." + b.file.GetPackage() + "." + msg.GetName()
+}
+
+// addField adds the field f, sent under the JSON key key, to msg. Fields
+// are numbered in declaration order.
+func (b *builder) addField(msg *descriptorpb.DescriptorProto, f codegen.SerializedFieldInfo, key string) {
+	names := make(map[string]bool, len(msg.Field))
+	for _, existing := range msg.Field {
+		names[existing.GetName()] = true
+	}
+	fd := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(uniqueName(protoFieldName(key), names)),
+		JsonName: proto.String(key),
+		Number:   proto.Int32(int32(len(msg.Field) + 1)),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+
+	goType := f.Type
+	optional := strings.HasPrefix(goType, "*")
+	goType = strings.TrimPrefix(goType, "*")
+	if goType != "[]byte" && strings.HasPrefix(goType, "[]") {
+		goType = strings.TrimPrefix(goType[2:], "*")
+		optional = false
+		if goType != "[]byte" && strings.HasPrefix(goType, "[]") {
+			// Nested lists have no proto equivalent: carry them as JSON.
+			goType = "any"
+		} else {
+			fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+	}
+
+	if f.StructFields != nil && len(f.StructFields.Fields) > 0 && !strings.HasPrefix(goType, "map[") {
+		fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		fd.TypeName = proto.String(b.structType(f.StructFields, msg.GetName()+protoIdent(f.Name)))
+	} else if typ, typeName := scalarType(goType); typeName != "" {
+		fd.Type = typ.Enum()
+		fd.TypeName = proto.String(typeName)
+		b.imports[typeName] = true
+	} else {
+		fd.Type = typ.Enum()
+		if optional {
+			// proto3 optional: a synthetic oneof gives the scalar presence.
+			fd.Proto3Optional = proto.Bool(true)
+			fd.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+			msg.OneofDecl = append(msg.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + fd.GetName())})
+		}
+	}
+	msg.Field = append(msg.Field, fd)
+}
+
+// scalarType returns the proto type of a Go type, or a well-known message
+// type for time.Time and for arbitrary JSON (json.RawMessage, any, maps).
+// Unknown types are strings, as in the OpenAPI spec.
+func scalarType(goType string) (descriptorpb.FieldDescriptorProto_Type, string) {
+	switch goType {
+	case "string":
+		return descriptorpb.FieldDescriptorProto_TYPE_STRING, ""
+	case "bool":
+		return descriptorpb.FieldDescriptorProto_TYPE_BOOL, ""
+	case "int", "int64":
+		return descriptorpb.FieldDescriptorProto_TYPE_INT64, ""
+	case "int32", "int16", "int8":
+		return descriptorpb.FieldDescriptorProto_TYPE_INT32, ""
+	case "uint", "uint64":
+		return descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""
+	case "uint32", "uint16", "uint8":
+		return descriptorpb.FieldDescriptorProto_TYPE_UINT32, ""
+	case "float64":
+		return descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""
+	case "float32":
+		return descriptorpb.FieldDescriptorProto_TYPE_FLOAT, ""
+	case "[]byte":
+		return descriptorpb.FieldDescriptorProto_TYPE_BYTES, ""
+	case "time.Time":
+		return descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, timestampType
+	case "json.RawMessage", "any", "interface{}":
+		return descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, valueType
+	}
+	if strings.HasPrefix(goType, "map[") {
+		return descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, valueType
+	}
+	return descriptorpb.FieldDescriptorProto_TYPE_STRING, ""
+}
+
+// identInvalid matches the characters a proto identifier may not contain.
+var identInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// protoIdent strips the characters of s that cannot appear in a proto
+// identifier, such as the brackets of a generic type's name.
+func protoIdent(s string) string {
+	s = identInvalid.ReplaceAllString(s, "")
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		s = "X" + s
+	}
+	return s
+}
+
+// protoFieldName converts a JSON key to a snake_case field name:
+// "createdAt" and "created_at" both become "created_at", "userID" becomes
+// "user_id".
+func protoFieldName(key string) string {
+	var out strings.Builder
+	for i, r := range key {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				prev := key[i-1]
+				if prev >= 'a' && prev <= 'z' || prev >= '0' && prev <= '9' {
+					out.WriteByte('_')
+				}
+			}
+			out.WriteRune(r + ('a' - 'A'))
+			continue
+		}
+		if r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			out.WriteRune(r)
+			continue
+		}
+		out.WriteByte('_')
+	}
+	name := out.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "f_" + name
+	}
+	return name
+}
+
+// uniqueName returns name, or name followed by the smallest number from 2
+// that makes it unique, and marks the result as taken.
+func uniqueName(name string, taken map[string]bool) string {
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+// generateGoFile generates zz_generated_grpc.go: the serialized descriptor,
+// the route of each RPC and NewGRPCServer.
+func generateGoFile(cfg GRPCGenConfig, desc []byte, routes []route) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(codegen.GeneratedHeader + "\n")
+	fmt.Fprintf(&buf, "package %s\n\n", cfg.OutputPkg)
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"net/http\"\n\n")
+	buf.WriteString("\t\"google.golang.org/grpc\"\n\n")
+	fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/lib/grpcbridge")
+	buf.WriteString(")\n\n")
+
+	fmt.Fprintf(&buf, "// grpcFileDescriptor is the serialized FileDescriptorProto of %s.proto.\n", cfg.OutputPkg)
+	fmt.Fprintf(&buf, "var grpcFileDescriptor = []byte(%q)\n\n", desc)
+
+	buf.WriteString("// grpcMethods binds each RPC to the HTTP route that serves it.\n")
+	buf.WriteString("var grpcMethods = []grpcbridge.Method{\n")
+	for _, r := range routes {
+		fmt.Fprintf(&buf, "\t{Service: %q, Name: %q, HTTPMethod: %q, Path: %q", r.service, r.rpc, r.method, r.path)
+		if len(r.query) > 0 {
+			fmt.Fprintf(&buf, ", Query: []string{%s}", quoteAll(r.query))
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(&buf, `// NewGRPCServer returns a gRPC server for the services in %s.proto. Each
+// RPC is sent to h as a request to its HTTP route, so h should be the
+// handler from NewMux: gRPC calls then run the same handlers, middleware,
+// auth checks and validation as HTTP calls.
+func NewGRPCServer(h http.Handler, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	return grpcbridge.NewServer(h, grpcFileDescriptor, grpcMethods, opts...)
+}
+`, cfg.OutputPkg)
+
+	formatted, err := phase.FormatSource(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("failed to format zz_generated_grpc.go: %w\nunformatted:\n%s", err, buf.String())
+	}
+	return formatted, nil
+}
+
+// quoteAll returns strs as a comma-separated list of Go string literals.
+func quoteAll(strs []string) string {
+	quoted := make([]string, len(strs))
+	for i, s := range strs {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,254 @@
+package grpcgen
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+)
+
+func postHandlers() []codegen.SerializedHandlerInfo {
+	author := &codegen.SerializedStructInfo{Name: "Author", Package: "myapp/api/posts", Fields: []codegen.SerializedFieldInfo{
+		{Name: "ID", Type: "string", JSONName: "id"},
+		{Name: "Name", Type: "string", JSONName: "name"},
+	}}
+	post := []codegen.SerializedFieldInfo{
+		{Name: "ID", Type: "string", JSONName: "id"},
+		{Name: "Title", Type: "string", JSONName: "title"},
+		{Name: "Views", Type: "int64", JSONName: "views"},
+		{Name: "Score", Type: "*float64", JSONName: "score"},
+		{Name: "Tags", Type: "[]string", JSONName: "tags"},
+		{Name: "Meta", Type: "json.RawMessage", JSONName: "meta"},
+		{Name: "CreatedAt", Type: "time.Time", JSONName: "createdAt"},
+		{Name: "Author", Type: "*Author", JSONName: "author", StructFields: author},
+		{Name: "Secret", Type: "string", JSONName: ""},
+	}
+	return []codegen.SerializedHandlerInfo{
+		{
+			Method: "GET", Path: "/posts/:id", FuncName: "GetPost", PackagePath: "myapp/api/posts",
+			PathParams: []codegen.SerializedPathParam{{Name: "id", Position: 0}},
+			Request: &codegen.SerializedStructInfo{Name: "GetPostRequest", Fields: []codegen.SerializedFieldInfo{
+				{Name: "ID", Type: "string", JSONName: "id", Tags: map[string]string{"path": "id"}},
+			}},
+			Response: &codegen.SerializedStructInfo{Name: "GetPostResponse", Fields: post},
+		},
+		{
+			Method: "GET", Path: "/posts", FuncName: "ListPosts", PackagePath: "myapp/api/posts",
+			Request: &codegen.SerializedStructInfo{Name: "ListPostsRequest", Fields: []codegen.SerializedFieldInfo{
+				{Name: "Limit", Type: "*int", JSONName: "limit", Tags: map[string]string{"query": "limit"}},
+				{Name: "Cursor", Type: "string", JSONName: "cursor", Tags: map[string]string{"query": "cursor,omitempty"}},
+				{Name: "Ignored", Type: "string", JSONName: "ignored"},
+			}},
+			Response: &codegen.SerializedStructInfo{Name: "ListPostsResponse", Fields: []codegen.SerializedFieldInfo{
+				{Name: "Items", Type: "[]Author", JSONName: "items", StructFields: author},
+			}},
+		},
+		{
+			Method: "PATCH", Path: "/posts/:id", FuncName: "UpdatePost", PackagePath: "myapp/api/posts",
+			PathParams: []codegen.SerializedPathParam{{Name: "id", Position: 0}},
+			Request: &codegen.SerializedStructInfo{Name: "UpdatePostRequest", Fields: []codegen.SerializedFieldInfo{
+				{Name: "ID", Type: "string", JSONName: "id", Tags: map[string]string{"path": "id"}},
+				{Name: "Title", Type: "*string", JSONName: "title"},
+				{Name: "AuthorID", Type: "int64", JSONName: "authorID"},
+			}},
+		},
+		{Method: "GET", Path: "/health", FuncName: "Healthcheck", PackagePath: "myapp/api/healthcheck"},
+	}
+}
+
+func generate(t *testing.T, cfg GRPCGenConfig) (proto, goCode string) {
+	t.Helper()
+	files, err := GenerateGRPC(cfg)
+	if err != nil {
+		t.Fatalf("GenerateGRPC() error = %v", err)
+	}
+	if len(files) != 2 || files[0].RelPath != "api/api.proto" || files[1].RelPath != "api/zz_generated_grpc.go" {
+		t.Fatalf("unexpected files %+v", files)
+	}
+	return string(files[0].Content), string(files[1].Content)
+}
+
+func TestGenerateGRPC_Proto(t *testing.T) {
+	proto, _ := generate(t, GRPCGenConfig{ModulePath: "myapp", OutputPkg: "api", Handlers: postHandlers()})
+
+	// One Author message is shared by both responses; json:"-" fields and
+	// unbound GET fields are left out.
+	want := codegen.GeneratedHeader + "\n" + `// Generated from the handler registry; regenerate with ` + "`shipq handler compile`" + `.
+
+syntax = "proto3";
+
+package api;
+
+import "google/protobuf/empty.proto";
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/struct.proto";
+
+service HealthcheckService {
+  // GET /health
+  rpc Healthcheck(google.protobuf.Empty) returns (google.protobuf.Empty);
+}
+
+service PostsService {
+  // GET /posts/{id}
+  rpc GetPost(GetPostRequest) returns (GetPostResponse);
+  // GET /posts
+  rpc ListPosts(ListPostsRequest) returns (ListPostsResponse);
+  // PATCH /posts/{id}
+  rpc UpdatePost(UpdatePostRequest) returns (google.protobuf.Empty);
+}
+
+message GetPostRequest {
+  string id = 1;
+}
+
+message GetPostResponse {
+  string id = 1;
+  string title = 2;
+  int64 views = 3;
+  optional double score = 4;
+  repeated string tags = 5;
+  google.protobuf.Value meta = 6;
+  google.protobuf.Timestamp created_at = 7;
+  Author author = 8;
+}
+
+message Author {
+  string id = 1;
+  string name = 2;
+}
+
+message ListPostsRequest {
+  optional int64 limit = 1;
+  string cursor = 2;
+}
+
+message ListPostsResponse {
+  repeated Author items = 1;
+}
+
+message UpdatePostRequest {
+  string id = 1;
+  optional string title = 2;
+  int64 author_id = 3 [json_name = "authorID"];
+}
+`
+	if proto != want {
+		t.Errorf("api.proto =\n%s\nwant:\n%s", proto, want)
+	}
+}
+
+func TestGenerateGRPC_DescriptorIsValid(t *testing.T) {
+	file, routes := buildFile(GRPCGenConfig{ModulePath: "myapp", OutputPkg: "api", Handlers: postHandlers(), StripPrefix: "/api"})
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("invalid descriptor: %v", err)
+	}
+	if got := fd.Services().Len(); got != 2 {
+		t.Errorf("services = %d, want 2", got)
+	}
+	score := fd.Messages().ByName("GetPostResponse").Fields().ByName("score")
+	if !score.HasPresence() || score.JSONName() != "score" {
+		t.Errorf("score should be a proto3 optional field: %v", score)
+	}
+	if routes[2].service != "api.PostsService" || routes[2].rpc != "ListPosts" || routes[2].path != "/api/posts" ||
+		strings.Join(routes[2].query, ",") != "limit,cursor" {
+		t.Errorf("unexpected route %+v", routes[2])
+	}
+}
+
+func TestGenerateGRPC_GoFile(t *testing.T) {
+	cfg := GRPCGenConfig{ModulePath: "myapp", OutputPkg: "api", Handlers: postHandlers(), StripPrefix: "/api"}
+	_, goCode := generate(t, cfg)
+	if !strings.HasPrefix(goCode, codegen.GeneratedHeader) {
+		t.Error("missing the generated header")
+	}
+	f := gofile.Parse(t, "zz_generated_grpc.go", []byte(goCode))
+	if !f.HasImport("myapp/shipq/lib/grpcbridge") {
+		t.Error("missing the grpcbridge import")
+	}
+	f.AssertSignature("NewGRPCServer", "func NewGRPCServer(h http.Handler, opts ...grpc.ServerOption) (*grpc.Server, error)")
+	f.AssertStmts("NewGRPCServer", "return grpcbridge.NewServer(h, grpcFileDescriptor, grpcMethods, opts...)")
+
+	// The embedded descriptor is the one the proto file is rendered from.
+	lit := strings.TrimSuffix(strings.TrimPrefix(f.Value("grpcFileDescriptor"), "[]byte("), ")")
+	raw, err := strconv.Unquote(lit)
+	if err != nil {
+		t.Fatalf("grpcFileDescriptor = %s: %v", f.Value("grpcFileDescriptor"), err)
+	}
+	var got descriptorpb.FileDescriptorProto
+	if err := proto.Unmarshal([]byte(raw), &got); err != nil {
+		t.Fatalf("grpcFileDescriptor does not unmarshal: %v", err)
+	}
+	if want, _ := buildFile(cfg); !proto.Equal(&got, want) {
+		t.Errorf("grpcFileDescriptor = %v, want %v", &got, want)
+	}
+
+	if got, want := strings.Fields(f.Value("grpcMethods")), strings.Fields(`[]grpcbridge.Method{
+	{Service: "api.HealthcheckService", Name: "Healthcheck", HTTPMethod: "GET", Path: "/api/health"},
+	{Service: "api.PostsService", Name: "GetPost", HTTPMethod: "GET", Path: "/api/posts/{id}"},
+	{Service: "api.PostsService", Name: "ListPosts", HTTPMethod: "GET", Path: "/api/posts", Query: []string{"limit", "cursor"}},
+	{Service: "api.PostsService", Name: "UpdatePost", HTTPMethod: "PATCH", Path: "/api/posts/{id}"},
+}`); !slices.Equal(got, want) {
+		t.Errorf("grpcMethods = %s", f.Value("grpcMethods"))
+	}
+}
+
+func TestGenerateGRPC_NameCollisions(t *testing.T) {
+	handlers := []codegen.SerializedHandlerInfo{
+		{Method: "GET", Path: "/a/items", FuncName: "List", PackagePath: "myapp/api/a", Response: &codegen.SerializedStructInfo{Name: "Item"}},
+		{Method: "GET", Path: "/b/items", FuncName: "List", PackagePath: "myapp/api/b", Response: &codegen.SerializedStructInfo{Name: "Item"}},
+	}
+	file, _ := buildFile(GRPCGenConfig{ModulePath: "myapp", OutputPkg: "api", Handlers: handlers})
+	if _, err := protodesc.NewFile(file, protoregistry.GlobalFiles); err != nil {
+		t.Fatalf("invalid descriptor: %v", err)
+	}
+	var names []string
+	for _, m := range file.MessageType {
+		names = append(names, m.GetName())
+	}
+	if got := strings.Join(names, ","); got != "ListResponse,BListResponse" {
+		t.Errorf("messages = %s", got)
+	}
+}
+
+func TestGenerateGRPC_SkipsRawBodyHandlers(t *testing.T) {
+	handlers := append(postHandlers(), codegen.SerializedHandlerInfo{
+		Method: "POST", Path: "/imports", FuncName: "ImportPosts", PackagePath: "myapp/api/imports",
+		RawBodyTypes: []string{"text/csv"},
+		Request:      &codegen.SerializedStructInfo{Name: "ImportPostsRequest"},
+	})
+	file, routes := buildFile(GRPCGenConfig{ModulePath: "myapp", OutputPkg: "api", Handlers: handlers})
+	if got := len(file.Service); got != 2 {
+		t.Errorf("services = %d, want 2: a package of raw body handlers gets no service", got)
+	}
+	for _, r := range routes {
+		if r.rpc == "ImportPosts" {
+			t.Errorf("unexpected route for a raw body handler: %+v", r)
+		}
+	}
+}
+
+func TestProtoFieldName(t *testing.T) {
+	for key, want := range map[string]string{
+		"created_at": "created_at",
+		"createdAt":  "created_at",
+		"userID":     "user_id",
+		"page-size":  "page_size",
+		"2fa":        "f_2fa",
+	} {
+		if got := protoFieldName(key); got != want {
+			t.Errorf("protoFieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package grpcgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// renderProto renders file as .proto source for clients to compile with
+// protoc. Each RPC is commented with the HTTP route it calls.
+func renderProto(file *descriptorpb.FileDescriptorProto, routes []route) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(codegen.GeneratedHeader + "\n")
+	buf.WriteString("// Generated from the handler registry; regenerate with `shipq handler compile`.\n\n")
+	buf.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&buf, "package %s;\n", file.GetPackage())
+	if len(file.Dependency) > 0 {
+		buf.WriteString("\n")
+		for _, dep := range file.Dependency {
+			fmt.Fprintf(&buf, "import %q;\n", dep)
+		}
+	}
+
+	byRPC := make(map[string]route, len(routes))
+	for _, r := range routes {
+		byRPC[r.service+"/"+r.rpc] = r
+	}
+	for _, svc := range file.Service {
+		fmt.Fprintf(&buf, "\nservice %s {\n", svc.GetName())
+		for _, m := range svc.Method {
+			r := byRPC[file.GetPackage()+"."+svc.GetName()+"/"+m.GetName()]
+			fmt.Fprintf(&buf, "  // %s %s\n", r.method, r.path)
+			fmt.Fprintf(&buf, "  rpc %s(%s) returns (%s);\n", m.GetName(), typeRef(file, m.GetInputType()), typeRef(file, m.GetOutputType()))
+		}
+		buf.WriteString("}\n")
+	}
+
+	for _, msg := range file.MessageType {
+		fmt.Fprintf(&buf, "\nmessage %s {\n", msg.GetName())
+		for _, f := range msg.Field {
+			buf.WriteString("  ")
+			switch {
+			case f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+				buf.WriteString("repeated ")
+			case f.GetProto3Optional():
+				buf.WriteString("optional ")
+			}
+			fmt.Fprintf(&buf, "%s %s = %d", fieldType(file, f), f.GetName(), f.GetNumber())
+			if f.GetJsonName() != defaultJSONName(f.GetName()) {
+				fmt.Fprintf(&buf, " [json_name = %q]", f.GetJsonName())
+			}
+			buf.WriteString(";\n")
+		}
+		buf.WriteString("}\n")
+	}
+
+	return buf.Bytes()
+}
+
+// typeRef returns how a fully-qualified message name is written in file:
+// relative for the file's own messages, qualified for well-known types.
+func typeRef(file *descriptorpb.FileDescriptorProto, name string) string {
+	if local, ok := strings.CutPrefix(name, "."+file.GetPackage()+"."); ok {
+		return local
+	}
+	return strings.TrimPrefix(name, ".")
+}
+
+// fieldType returns the type of f as written in a .proto file.
+func fieldType(file *descriptorpb.FileDescriptorProto, f *descriptorpb.FieldDescriptorProto) string {
+	if f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return typeRef(file, f.GetTypeName())
+	}
+	// TYPE_STRING → "string", TYPE_INT64 → "int64", ...
+	return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+}
+
+// defaultJSONName returns the json_name protoc gives a field named name,
+// which lowerCamelCases it: "created_at" becomes "createdAt".
+func defaultJSONName(name string) string {
+	var out strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		out.WriteRune(r)
+	}
+	return out.String()
+}
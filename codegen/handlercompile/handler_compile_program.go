@@ -3,8 +3,9 @@ package handlercompile
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"sort"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // HandlerCompileProgramConfig holds configuration for generating the handler
@@ -66,6 +67,10 @@ type SerializedHandlerInfo struct {
 	PackagePath string                  ` + "`json:\"package_path\"`" + `
 	RequireAuth  bool                    ` + "`json:\"require_auth\"`" + `
 	OptionalAuth bool                    ` + "`json:\"optional_auth\"`" + `
+	Deprecated   bool                    ` + "`json:\"deprecated,omitempty\"`" + `
+	Sunset       string                  ` + "`json:\"sunset,omitempty\"`" + `
+	Feature      string                  ` + "`json:\"feature,omitempty\"`" + `
+	RawBodyTypes []string                ` + "`json:\"raw_body_types,omitempty\"`" + `
 	Request      *SerializedStructInfo   ` + "`json:\"request,omitempty\"`" + `
 	Response     *SerializedStructInfo   ` + "`json:\"response,omitempty\"`" + `
 }
@@ -116,6 +121,10 @@ func main() {
 			PackagePath:  h.PackagePath,
 			RequireAuth:  h.RequireAuth,
 			OptionalAuth: h.OptionalAuth,
+			Deprecated:   h.Deprecated,
+			Sunset:       h.Sunset,
+			Feature:      h.Feature,
+			RawBodyTypes: h.RawBodyTypes,
 			Request:      convertStructInfo(h.Request),
 			Response:     convertStructInfo(h.Response),
 		}
@@ -176,7 +185,7 @@ func convertFields(fields []handler.FieldInfo) []SerializedFieldInfo {
 }
 `)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format handler compile program: %w", err)
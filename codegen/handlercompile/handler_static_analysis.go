@@ -78,35 +78,53 @@ func ParseRegisterFile(filePath string) ([]RegisterCall, error) {
 }
 
 // tryParseRegistration attempts to extract a RegisterCall from a call expression.
-// It handles three patterns:
+// It handles a base registration followed by any chain of route modifiers:
 //  1. app.Post("/path", Handler)                -> direct registration
 //  2. app.Post("/path", Handler).Auth()         -> chained registration with auth
 //  3. app.Post("/path", Handler).OptionalAuth() -> chained registration with optional auth
+//  4. app.Get("/path", Handler).Auth().Sunset("2026-01-31") -> any combination of modifiers
+//
+// Deprecation and feature modifiers are recorded at runtime by the
+// RouteBuilder; static analysis only needs to see through them to the base registration.
 func tryParseRegistration(fset *token.FileSet, filePath string, call *ast.CallExpr, parseErrors *[]string) *RegisterCall {
-	// Pattern 2/3: Check if this is a chained call like app.Post(...).Auth() or .OptionalAuth()
-	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
-		if (sel.Sel.Name == "Auth" || sel.Sel.Name == "OptionalAuth") && len(call.Args) == 0 {
-			chainName := sel.Sel.Name
-			// The receiver of .Auth()/.OptionalAuth() should be the base registration call
-			innerCall, ok := sel.X.(*ast.CallExpr)
-			if !ok {
-				return nil
-			}
-			reg := tryParseBaseRegistration(fset, filePath, innerCall, parseErrors)
-			if reg != nil {
-				switch chainName {
-				case "Auth":
-					reg.RequireAuth = true
-				case "OptionalAuth":
-					reg.OptionalAuth = true
-				}
-			}
-			return reg
+	var requireAuth, optionalAuth bool
+
+	// Peel chained modifiers off the outside in until we reach app.Method(...)
+	for {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !isRouteModifier(sel.Sel.Name) {
+			break
+		}
+		innerCall, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			return nil
 		}
+		switch sel.Sel.Name {
+		case "Auth":
+			requireAuth = true
+		case "OptionalAuth":
+			optionalAuth = true
+		}
+		call = innerCall
 	}
 
-	// Pattern 1: Direct call like app.Post("/path", Handler)
-	return tryParseBaseRegistration(fset, filePath, call, parseErrors)
+	reg := tryParseBaseRegistration(fset, filePath, call, parseErrors)
+	if reg != nil {
+		reg.RequireAuth = requireAuth
+		reg.OptionalAuth = optionalAuth
+	}
+	return reg
+}
+
+// isRouteModifier reports whether name is a RouteBuilder method that may be
+// chained after a registration call.
+func isRouteModifier(name string) bool {
+	switch name {
+	case "Auth", "OptionalAuth", "Deprecated", "Sunset", "Feature":
+		return true
+	default:
+		return false
+	}
 }
 
 // tryParseBaseRegistration parses a direct app.Method(path, handler) call.
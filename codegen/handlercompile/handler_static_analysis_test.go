@@ -206,6 +206,40 @@ func Register(app *handler.App) {
 			},
 			expectError: false,
 		},
+		{
+			name: "deprecation modifiers chained with auth",
+			content: `package pets
+
+import "github.com/shipq/shipq/handler"
+
+func Register(app *handler.App) {
+	app.Get("/pets", ListPets).Deprecated()
+	app.Get("/pets/:id", GetPet).Auth().Sunset("2027-01-31")
+	app.Delete("/pets/:id", SoftDeletePet).Sunset("2027-01-31").Auth()
+}
+`,
+			expectedCalls: []RegisterCall{
+				{Method: "Get", Path: "/pets", FuncName: "ListPets"},
+				{Method: "Get", Path: "/pets/:id", FuncName: "GetPet", RequireAuth: true},
+				{Method: "Delete", Path: "/pets/:id", FuncName: "SoftDeletePet", RequireAuth: true},
+			},
+			expectError: false,
+		},
+		{
+			name: "feature modifier chained with auth",
+			content: `package pets
+
+import "github.com/shipq/shipq/handler"
+
+func Register(app *handler.App) {
+	app.Post("/pets/:id/adopt", AdoptPet).Feature("adoptions").Auth()
+}
+`,
+			expectedCalls: []RegisterCall{
+				{Method: "Post", Path: "/pets/:id/adopt", FuncName: "AdoptPet", RequireAuth: true},
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
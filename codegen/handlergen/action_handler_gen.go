@@ -0,0 +1,124 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// reservedActions are verbs that collide with generated CRUD handlers or
+// their routes.
+var reservedActions = map[string]bool{
+	"create": true, "get": true, "get_one": true, "list": true, "update": true,
+	"delete": true, "soft_delete": true, "undelete": true, "import": true, "near": true,
+}
+
+// ValidateAction checks that action is usable as a custom verb: a lower
+// snake_case identifier that does not shadow a CRUD operation.
+func ValidateAction(action string) error {
+	if action == "" {
+		return fmt.Errorf("action name cannot be empty")
+	}
+	for i, r := range action {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return fmt.Errorf("action %q must be a lower snake_case identifier (e.g. publish, mark_paid)", action)
+		}
+	}
+	if reservedActions[action] {
+		return fmt.Errorf("action %q is reserved for CRUD handlers", action)
+	}
+	return nil
+}
+
+// ActionFuncName returns the handler function name for a custom action.
+// Example: ("posts", "mark_paid") -> "MarkPaidPost"
+func ActionFuncName(tableName, action string) string {
+	return dbstrings.ToPascalCase(action) + codegen.CRUD.ResourceName(tableName)
+}
+
+// RegistrationForAction returns the route registration for a custom action,
+// POST /<table>/:id/<action>, with the table's routes under pathSegment.
+func RegistrationForAction(tableName, pathSegment, action string, requireAuth bool) RouteRegistration {
+	return RouteRegistration{
+		Method:      "Post",
+		Path:        resourcePath(tableName, pathSegment) + "/:id/" + action,
+		FuncName:    ActionFuncName(tableName, action),
+		RequireAuth: requireAuth,
+	}
+}
+
+// GenerateActionHandler generates api/<table>/<action>.go, a handler stub
+// for a custom verb such as POST /posts/:id/publish. It calls the
+// <Action><Resource>ByPublicID query scaffolded alongside it and returns
+// 404 when no live record matched. The file is the user's to edit, so it
+// carries no generated-file header.
+func GenerateActionHandler(cfg HandlerGenConfig, action string) ([]byte, error) {
+	if err := ValidateAction(action); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	funcName := ActionFuncName(cfg.TableName, action)
+	method := codegen.CRUD.ActionMethodName(cfg.TableName, action)
+	singular := toSingular(cfg.TableName)
+	verb := strings.ReplaceAll(action, "_", " ")
+
+	buf.WriteString("package " + cfg.TableName + "\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"context\"\n\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
+	}
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
+	buf.WriteString(")\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %sRequest is the request for the %s action on a %s.\n", funcName, action, singular))
+	buf.WriteString(fmt.Sprintf("type %sRequest struct {\n", funcName))
+	buf.WriteString("\tID string `path:\"id\"` // This is the PUBLIC ID\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %sResponse is the response after the %s action.\n", funcName, action))
+	buf.WriteString(fmt.Sprintf("type %sResponse struct {\n", funcName))
+	buf.WriteString("\tSuccess bool `json:\"success\"`\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s handles POST %s/:id/%s\n", funcName, cfg.routePath(), action))
+	buf.WriteString("//\n")
+	buf.WriteString(fmt.Sprintf("// The %s query is defined in querydefs/%s/%s.go.\n", method, cfg.TableName, action))
+	buf.WriteString("// Edit it to change what the action writes.\n")
+	buf.WriteString(fmt.Sprintf("func %s(ctx context.Context, req *%sRequest) (*%sResponse, error) {\n", funcName, funcName, funcName))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
+
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
+		buf.WriteString("\tif !ok {\n")
+		buf.WriteString("\t\treturn nil, httperror.Wrap(403, \"organization context missing\", nil)\n")
+		buf.WriteString("\t}\n\n")
+	}
+
+	buf.WriteString(fmt.Sprintf("\tresult, err := runner.%s(ctx, queries.%sParams{\n", method, method))
+	buf.WriteString("\t\tPublicId: req.ID,\n")
+	if cfg.ScopeColumn != "" {
+		buf.WriteString(fmt.Sprintf("\t\t%s: orgID,\n", dbstrings.ToPascalCase(cfg.ScopeColumn)))
+	}
+	buf.WriteString("\t})\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, classifyDBError(err, %q)\n", verb+" "+singular))
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif n, err := result.RowsAffected(); err == nil && n == 0 {\n")
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, httperror.NotFoundf(\"%s %%q not found\", req.ID)\n", singular))
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString(fmt.Sprintf("\treturn &%sResponse{Success: true}, nil\n", funcName))
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
@@ -0,0 +1,84 @@
+package handlergen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+)
+
+func TestGenerateActionHandler(t *testing.T) {
+	cfg := testConfig("stores", pointColumns...)
+	cfg.ScopeColumn = "organization_id"
+	result, err := GenerateActionHandler(cfg, "mark_closed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.HasPrefix(string(result), generatedFileHeader) {
+		t.Error("action stubs are user-owned and must not carry the generated header")
+	}
+	f := gofile.Parse(t, "mark_closed.go", result)
+
+	f.AssertSignature("MarkClosedStore", "func MarkClosedStore(ctx context.Context, req *MarkClosedStoreRequest) (*MarkClosedStoreResponse, error)")
+	if f.Calls("MarkClosedStore", "runner.MarkClosedStoreByPublicID") != 1 {
+		t.Error("expected the action to run its query")
+	}
+	f.AssertExprs("MarkClosedStore", "OrganizationId: orgID")
+	f.AssertStmts("MarkClosedStore", `return nil, classifyDBError(err, "mark closed store")`)
+	// No affected row means there is no such store.
+	if !f.Before("MarkClosedStore", "result.RowsAffected", "httperror.NotFoundf") {
+		t.Error("expected a 404 when no row is affected")
+	}
+}
+
+func TestValidateAction(t *testing.T) {
+	for _, ok := range []string{"publish", "mark_paid", "v2_sync"} {
+		if err := ValidateAction(ok); err != nil {
+			t.Errorf("ValidateAction(%q) = %v, want nil", ok, err)
+		}
+	}
+	for _, bad := range []string{"", "Publish", "mark-paid", "2fa", "delete", "import"} {
+		if err := ValidateAction(bad); err == nil {
+			t.Errorf("ValidateAction(%q) = nil, want error", bad)
+		}
+	}
+}
+
+func TestAddRouteToRegister_KeepsExistingRoutes(t *testing.T) {
+	registerPath := filepath.Join(t.TempDir(), "register.go")
+	existing, err := GenerateIncrementalRegister(registerPath, "myapp", "posts", "", AllOperations(), true, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(registerPath, existing, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := AddRouteToRegister(registerPath, "myapp", "posts", "", RegistrationForAction("posts", "", "publish", true))
+	if err != nil {
+		t.Fatalf("AddRouteToRegister() error = %v", err)
+	}
+	f := gofile.Parse(t, "register.go", result)
+	f.AssertStmts("Register",
+		`app.Post("/posts/:id/publish", PublishPost).Auth()`,
+		`app.Post("/posts", CreatePost).Auth()`,
+		`app.Get("/posts", ListPosts).Auth()`,
+		`app.Get("/posts/:id", GetPost).Auth()`,
+		`app.Patch("/posts/:id", UpdatePost).Auth()`,
+		`app.Delete("/posts/:id", SoftDeletePost).Auth()`,
+	)
+
+	// Adding the same action again must not duplicate it.
+	if err := os.WriteFile(registerPath, result, 0644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := AddRouteToRegister(registerPath, "myapp", "posts", "", RegistrationForAction("posts", "", "publish", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := gofile.Parse(t, "register.go", again).Calls("Register", "app.Post"); n != 2 {
+		t.Errorf("Register has %d POST routes, want 2:\n%s", n, again)
+	}
+}
@@ -0,0 +1,140 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// DefaultBulkMaxItems is the item limit baked into generated bulk create
+// handlers.
+const DefaultBulkMaxItems = 1000
+
+// GenerateBulkCreateHandler generates api/<table>/bulk_create.go, a bulk
+// create endpoint (POST /<table>/bulk) that accepts a JSON array of create
+// requests and creates them all in one transaction, or none of them.
+//
+// Each item goes through the generated Create<Resource> handler on the
+// transaction's runner, so it gets exactly the defaults, validation, quota
+// and outbox handling of a single create. When items fail, the response is
+// an error naming every failed item by index.
+func GenerateBulkCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error) {
+	var buf bytes.Buffer
+	res := codegen.CRUD.ResourceName(cfg.TableName)
+	plural := codegen.CRUD.PluralResourceName(cfg.TableName)
+	pkgName := cfg.TableName
+
+	funcName := "BulkCreate" + plural
+	reqType := funcName + "Request"
+	respType := funcName + "Response"
+	maxConst := funcName + "MaxItems"
+
+	buf.WriteString(generatedFileHeader)
+	buf.WriteString("package " + pkgName + "\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"context\"\n")
+	buf.WriteString("\t\"errors\"\n")
+	buf.WriteString("\t\"fmt\"\n")
+	buf.WriteString("\t\"strings\"\n\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
+	buf.WriteString(")\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s is the maximum number of items accepted by a single bulk create.\n", maxConst))
+	buf.WriteString(fmt.Sprintf("const %s = %d\n\n", maxConst, DefaultBulkMaxItems))
+
+	buf.WriteString(fmt.Sprintf("// %s is the request body for creating %s in bulk. Each item\n", reqType, cfg.TableName))
+	buf.WriteString(fmt.Sprintf("// accepts the same fields as Create%sRequest.\n", res))
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", reqType))
+	buf.WriteString(fmt.Sprintf("\tItems []Create%sRequest `json:\"items\"`\n", res))
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s lists the created %s in request order.\n", respType, cfg.TableName))
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", respType))
+	buf.WriteString(fmt.Sprintf("\tItems []Create%sResponse `json:\"items\"`\n", res))
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s handles POST %s/bulk\n", funcName, cfg.routePath()))
+	buf.WriteString("//\n")
+	buf.WriteString(fmt.Sprintf("// Every item is created with Create%s in one transaction, which is only\n", res))
+	buf.WriteString("// committed if all of them succeed. After a failure the remaining items are\n")
+	buf.WriteString("// still tried, on a fresh transaction that is rolled back too, so that the\n")
+	buf.WriteString("// error reports every failing item: its message lists them as\n")
+	buf.WriteString("// \"items[i]: ...\" and its fields as \"items[i].<field>\". The status is that\n")
+	buf.WriteString("// of the first failure.\n")
+	buf.WriteString(fmt.Sprintf("func %s(ctx context.Context, req *%s) (*%s, error) {\n", funcName, reqType, respType))
+	buf.WriteString("\tif len(req.Items) == 0 {\n")
+	buf.WriteString("\t\treturn nil, httperror.BadRequest(\"items must not be empty\").WithFields(\"items\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\tif len(req.Items) > %s {\n", maxConst))
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, httperror.Newf(413, \"bulk create exceeds the maximum of %%d items\", %s)\n", maxConst))
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
+
+	buf.WriteString("\t// When runner is already a transaction, the items join it and the\n")
+	buf.WriteString("\t// caller decides whether to commit.\n")
+	buf.WriteString("\tvar tx *queries.TxRunner\n")
+	buf.WriteString("\ttxCtx := ctx\n")
+	writeBeginTx(&buf, "\t", "t", "nil, ")
+	buf.WriteString(fmt.Sprintf("\t\ttx, txCtx = t, queries.%s(ctx, t)\n", codegen.NewContextWithRunnerFunc))
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tdefer func() {\n")
+	buf.WriteString("\t\tif tx != nil {\n")
+	buf.WriteString("\t\t\ttx.Rollback() // no-op after commit\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}()\n\n")
+
+	buf.WriteString(fmt.Sprintf("\titems := make([]Create%sResponse, 0, len(req.Items))\n", res))
+	buf.WriteString("\tvar first *httperror.Error\n")
+	buf.WriteString("\tvar messages, fields []string\n")
+	buf.WriteString("\tfor i := range req.Items {\n")
+	buf.WriteString(fmt.Sprintf("\t\titem, err := Create%s(txCtx, &req.Items[i])\n", res))
+	buf.WriteString("\t\tif err == nil {\n")
+	buf.WriteString("\t\t\titems = append(items, *item)\n")
+	buf.WriteString("\t\t\tcontinue\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tvar httpErr *httperror.Error\n")
+	buf.WriteString("\t\tif !errors.As(err, &httpErr) {\n")
+	buf.WriteString("\t\t\treturn nil, err\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif first == nil {\n")
+	buf.WriteString("\t\t\tfirst = httpErr\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tmessages = append(messages, fmt.Sprintf(\"items[%d]: %s\", i, httpErr.Message()))\n")
+	buf.WriteString("\t\tif len(httpErr.Fields()) == 0 {\n")
+	buf.WriteString("\t\t\tfields = append(fields, fmt.Sprintf(\"items[%d]\", i))\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tfor _, field := range httpErr.Fields() {\n")
+	buf.WriteString("\t\t\tfields = append(fields, fmt.Sprintf(\"items[%d].%s\", i, field))\n")
+	buf.WriteString("\t\t}\n\n")
+	buf.WriteString("\t\t// A failed statement may abort the transaction, so the remaining\n")
+	buf.WriteString("\t\t// items are checked on a fresh one. The caller's transaction cannot\n")
+	buf.WriteString("\t\t// be restarted.\n")
+	buf.WriteString("\t\tif tx == nil {\n")
+	buf.WriteString("\t\t\tbreak\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\ttx.Rollback()\n")
+	buf.WriteString("\t\tt, err := runner.BeginTx(ctx)\n")
+	buf.WriteString("\t\tif err != nil {\n")
+	buf.WriteString("\t\t\ttx = nil\n")
+	buf.WriteString("\t\t\tbreak\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString(fmt.Sprintf("\t\ttx, txCtx = t, queries.%s(ctx, t)\n", codegen.NewContextWithRunnerFunc))
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\tif first != nil {\n")
+	buf.WriteString("\t\treturn nil, httperror.New(first.Code(), strings.Join(messages, \"; \")).WithFields(fields...)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif tx != nil {\n")
+	buf.WriteString("\t\tif err := tx.Commit(); err != nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\t\treturn nil, classifyDBError(err, \"bulk create %s\")\n", cfg.TableName))
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n\n")
+	buf.WriteString(fmt.Sprintf("\treturn &%s{Items: items}, nil\n", respType))
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
@@ -0,0 +1,214 @@
+package handlergen
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/crudquerydefs"
+	"github.com/shipq/shipq/codegen/gentest"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+)
+
+func TestGenerateBulkCreateHandler(t *testing.T) {
+	cfg := testConfig("posts", scopedPostColumns...)
+	cfg.ScopeColumn = "organization_id"
+	result, err := GenerateBulkCreateHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := gofile.Parse(t, "bulk_create.go", result)
+
+	f.AssertSignature("BulkCreatePosts", "func BulkCreatePosts(ctx context.Context, req *BulkCreatePostsRequest) (*BulkCreatePostsResponse, error)")
+	f.AssertField("BulkCreatePostsRequest", "Items", "[]CreatePostRequest", `json:"items"`)
+	f.AssertField("BulkCreatePostsResponse", "Items", "[]CreatePostResponse", `json:"items"`)
+	if got := f.Value("BulkCreatePostsMaxItems"); got != "1000" {
+		t.Errorf("BulkCreatePostsMaxItems = %s, want 1000", got)
+	}
+	// Each item goes through the create handler, on a transaction begun
+	// unless the runner already is one.
+	f.AssertStmts("BulkCreatePosts",
+		"if !queries.InTx(runner)",
+		"t, err := runner.BeginTx(ctx)",
+		`return nil, httperror.Wrap(500, "begin transaction", err)`,
+		"tx, txCtx = t, queries.NewContextWithRunner(ctx, t)",
+		"item, err := CreatePost(txCtx, &req.Items[i])",
+	)
+	if !f.Before("BulkCreatePosts", "if !queries.InTx(runner)", "t, err := runner.BeginTx(ctx)") {
+		t.Error("BulkCreatePosts should check for a transaction before beginning one")
+	}
+}
+
+// bulkCreateTest is the test run inside the generated posts package: it
+// checks that a bulk create is all or nothing, reports failing items by
+// index, joins the caller's transaction and fails when it cannot begin one.
+const bulkCreateTest = `package posts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"MODULE/shipq/lib/httperror"
+	"MODULE/shipq/queries"
+	"MODULE/shipq/queries/sqlite"
+)
+
+const schemaSQL = SCHEMA
+
+func TestBulkCreatePosts(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		t.Fatal(err)
+	}
+	ctx := queries.NewContextWithRunner(context.Background(), sqlite.NewQueryRunner(db))
+	count := func() int {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM posts").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	resp, err := BulkCreatePosts(ctx, &BulkCreatePostsRequest{Items: []CreatePostRequest{{Title: "a"}, {Title: "b"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Items) != 2 || resp.Items[0].Title != "a" || resp.Items[1].Title != "b" || count() != 2 {
+		t.Fatalf("resp = %+v, %d posts", resp, count())
+	}
+
+	_, err = BulkCreatePosts(ctx, &BulkCreatePostsRequest{Items: []CreatePostRequest{{Title: "c"}, {}, {Title: "d"}, {}}})
+	var httpErr *httperror.Error
+	if !errors.As(err, &httpErr) || httpErr.Code() != 422 {
+		t.Fatalf("err = %v, want a 422", err)
+	}
+	if want := []string{"items[1].title", "items[3].title"}; !slices.Equal(httpErr.Fields(), want) {
+		t.Errorf("fields = %v, want %v", httpErr.Fields(), want)
+	}
+	if n := count(); n != 2 {
+		t.Errorf("a failed bulk create left %d posts, want 2", n)
+	}
+
+	_, err = BulkCreatePosts(ctx, &BulkCreatePostsRequest{})
+	if !errors.As(err, &httpErr) || httpErr.Code() != 400 {
+		t.Errorf("empty items: err = %v, want a 400", err)
+	}
+
+	// Inside the caller's transaction the items join it.
+	tx, err := queries.MustRunnerFromContext(ctx).BeginTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BulkCreatePosts(queries.NewContextWithRunner(ctx, tx), &BulkCreatePostsRequest{Items: []CreatePostRequest{{Title: "e"}}}); err != nil {
+		t.Fatalf("in a transaction: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 2 {
+		t.Errorf("rolling back the caller's transaction left %d posts, want 2", n)
+	}
+
+	// Failing to begin the transaction is a server error.
+	failing := queries.NewContextWithRunner(ctx, noTxRunner{sqlite.NewQueryRunner(db)})
+	_, err = BulkCreatePosts(failing, &BulkCreatePostsRequest{Items: []CreatePostRequest{{Title: "f"}}})
+	if !errors.As(err, &httpErr) || httpErr.Code() != 500 {
+		t.Errorf("failed BeginTx: err = %v, want a 500", err)
+	}
+	if n := count(); n != 2 {
+		t.Errorf("a failed BeginTx left %d posts, want 2", n)
+	}
+}
+
+// noTxRunner is a runner that cannot begin transactions.
+type noTxRunner struct{ queries.Runner }
+
+func (noTxRunner) BeginTx(context.Context) (*queries.TxRunner, error) {
+	return nil, errors.New("connection refused")
+}
+`
+
+func TestBulkCreateHandler_AllOrNothing(t *testing.T) {
+	plan := migrate.NewPlan()
+	if _, err := plan.AddTable("posts", func(tb *ddl.TableBuilder) error {
+		tb.String("title")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := gentest.New(t, plan)
+	code, err := crudquerydefs.GenerateCRUDQueryDefs(crudquerydefs.Config{
+		ModulePath: p.ModulePath,
+		TableName:  "posts",
+		Table:      plan.Schema.Tables["posts"],
+		Schema:     plan.Schema.Tables,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WriteFile("shipq/querydefs/posts/queries.go", code)
+	p.CompileQueries()
+
+	files, err := GenerateHandlerFiles(HandlerGenConfig{
+		ModulePath: p.ModulePath,
+		TableName:  "posts",
+		Table:      plan.Schema.Tables["posts"],
+		Schema:     plan.Schema.Tables,
+		Bulk:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"create.go", "bulk_create.go", "helpers.go"} {
+		p.WriteFile("api/posts/"+name, files[name])
+	}
+	p.WriteFile("api/posts/bulk_create_test.go", []byte(strings.NewReplacer(
+		"MODULE", p.ModulePath,
+		"SCHEMA", strconv.Quote(p.SchemaSQL()),
+	).Replace(bulkCreateTest)))
+
+	p.GoTest("api/posts")
+}
+
+func TestGenerateHandlerFiles_Bulk(t *testing.T) {
+	cfg := testConfig("posts", scopedPostColumns...)
+
+	files, err := GenerateHandlerFiles(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := files["bulk_create.go"]; ok {
+		t.Error("bulk_create.go should only be generated with Bulk")
+	}
+	if gofile.Parse(t, "register.go", files["register.go"]).HasExpr("Register", "BulkCreatePosts") {
+		t.Error("register.go should not route /bulk without Bulk")
+	}
+
+	cfg.Bulk = true
+	files, err = GenerateHandlerFiles(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := files["bulk_create.go"]; !ok {
+		t.Error("expected bulk_create.go with Bulk")
+	}
+	gofile.Parse(t, "register.go", files["register.go"]).AssertStmts("Register", `app.Post("/posts/bulk", BulkCreatePosts)`)
+
+	reg := RegistrationForOp(OpBulkCreate, "posts", "", true)
+	if reg.Method != "Post" || reg.Path != "/posts/bulk" || reg.FuncName != "BulkCreatePosts" || !reg.RequireAuth {
+		t.Errorf("RegistrationForOp(OpBulkCreate) = %+v", reg)
+	}
+}
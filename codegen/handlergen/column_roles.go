@@ -0,0 +1,216 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// tableHasColumnRoles reports whether any column declares ReadRoles or
+// WriteRoles.
+func tableHasColumnRoles(table ddl.Table) bool {
+	for _, col := range table.Columns {
+		if len(col.ReadRoles) > 0 || len(col.WriteRoles) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateColumnRoles rejects role declarations the generated handlers
+// cannot honour. Roles are resolved from the caller's session, so the routes
+// must be authenticated, and FK columns are resolved through a join that the
+// read check does not cover.
+func validateColumnRoles(cfg HandlerGenConfig) error {
+	if !tableHasColumnRoles(cfg.Table) {
+		return nil
+	}
+	if !cfg.RequireAuth {
+		return fmt.Errorf("table %q declares column roles but its routes are public; column roles need authenticated routes", cfg.TableName)
+	}
+	for _, col := range cfg.Table.Columns {
+		if len(col.ReadRoles) > 0 && col.References != "" {
+			return fmt.Errorf("column %s.%s: ReadRoles is not supported on reference columns", cfg.TableName, col.Name)
+		}
+	}
+	return nil
+}
+
+// quotedRoles renders roles as a Go argument list: "admin", "hr".
+func quotedRoles(roles []string) string {
+	quoted := make([]string, len(roles))
+	for i, r := range roles {
+		quoted[i] = fmt.Sprintf("%q", r)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// requestFieldTag returns the struct tag for a request field, recording the
+// column's write roles so they reach the OpenAPI document.
+func requestFieldTag(col ddl.ColumnDefinition, jsonTag string) string {
+	tag := fmt.Sprintf("json:\"%s\"", jsonTag)
+	if len(col.WriteRoles) > 0 {
+		tag += fmt.Sprintf(" write_roles:\"%s\"", strings.Join(col.WriteRoles, ","))
+	}
+	return "`" + tag + "`"
+}
+
+// responseStructField returns the declaration of a response field. Columns
+// with read roles become optional (a pointer unless the type is already
+// nilable) so they can be omitted for callers without the role.
+func responseStructField(col ddl.ColumnDefinition, fieldName, jsonName string) string {
+	fieldType := responseFieldType(col)
+	if len(col.ReadRoles) == 0 {
+		return fmt.Sprintf("%s %s `json:\"%s\"`", fieldName, fieldType, jsonName)
+	}
+	if !isNilableType(fieldType) {
+		fieldType = "*" + fieldType
+	}
+	return fmt.Sprintf("%s %s `json:\"%s,omitempty\" read_roles:\"%s\"`",
+		fieldName, fieldType, jsonName, strings.Join(col.ReadRoles, ","))
+}
+
+// isNilableType reports whether a generated Go type can already be nil.
+func isNilableType(goType string) bool {
+	return strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || goType == "json.RawMessage"
+}
+
+// responseValueExpr returns the expression converting a query result field
+// (src is "result" or "item") into its response representation.
+func responseValueExpr(col ddl.ColumnDefinition, src string) string {
+	expr := src + "." + toPascalCase(col.Name)
+	if ddl.IsTimeType(col.Type) {
+		if col.Nullable {
+			return "formatTimePtr(" + expr + ")"
+		}
+		return expr + ".Format(time.RFC3339)"
+	}
+	return expr
+}
+
+// requestFieldSetExpr returns a boolean expression that is true when the
+// request field expr carries a value, i.e. the caller is writing it.
+func requestFieldSetExpr(goType, expr string) string {
+	switch {
+	case isNilableType(goType):
+		return expr + " != nil"
+	case goType == "bool":
+		return expr
+	case goType == "string":
+		return expr + ` != ""`
+	case goType == "time.Time":
+		return "!" + expr + ".IsZero()"
+	default:
+		return expr + " != 0"
+	}
+}
+
+// writeCallerRoles emits the lookup of the caller's roles into `roles`.
+func writeCallerRoles(buf *bytes.Buffer) {
+	buf.WriteString("\troles, rolesErr := callerRoles(ctx, runner)\n")
+	buf.WriteString("\tif rolesErr != nil {\n")
+	buf.WriteString("\t\treturn nil, httperror.Wrap(500, \"load caller roles\", rolesErr)\n")
+	buf.WriteString("\t}\n\n")
+}
+
+// writeWriteRoleChecks emits a 403 for every write-restricted column the
+// request sets without the caller holding one of its write roles. fieldType
+// returns the request field's Go type.
+func writeWriteRoleChecks(buf *bytes.Buffer, cfg HandlerGenConfig, cols []ddl.ColumnDefinition, fieldType func(ddl.ColumnDefinition) string) {
+	for _, col := range cols {
+		set := requestFieldSetExpr(fieldType(col), "req."+toPascalCase(col.Name))
+		buf.WriteString(fmt.Sprintf("\tif %s && !httputil.RolesAllow(roles, %s) {\n", set, quotedRoles(col.WriteRoles)))
+		buf.WriteString(fmt.Sprintf("\t\treturn nil, httperror.Wrap(403, \"not allowed to set %s\", nil)\n", columnJSONName(cfg, col.Name)))
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\n")
+}
+
+// writeReadRoleFlags emits one canRead<Field> flag per read-restricted
+// column, evaluated once per request.
+func writeReadRoleFlags(buf *bytes.Buffer, cols []ddl.ColumnDefinition) {
+	for _, col := range cols {
+		buf.WriteString(fmt.Sprintf("\tcanRead%s := httputil.RolesAllow(roles, %s)\n", toPascalCase(col.Name), quotedRoles(col.ReadRoles)))
+	}
+	buf.WriteString("\n")
+}
+
+// writeReadRoleAssignments fills the read-restricted fields of target from
+// src, guarded by the flags written by writeReadRoleFlags.
+func writeReadRoleAssignments(buf *bytes.Buffer, cols []ddl.ColumnDefinition, indent, target, src string) {
+	for _, col := range cols {
+		fieldName := toPascalCase(col.Name)
+		expr := responseValueExpr(col, src)
+		buf.WriteString(fmt.Sprintf("%sif canRead%s {\n", indent, fieldName))
+		if isNilableType(responseFieldType(col)) {
+			buf.WriteString(fmt.Sprintf("%s\t%s.%s = %s\n", indent, target, fieldName, expr))
+		} else {
+			buf.WriteString(fmt.Sprintf("%s\tv := %s\n", indent, expr))
+			buf.WriteString(fmt.Sprintf("%s\t%s.%s = &v\n", indent, target, fieldName))
+		}
+		buf.WriteString(fmt.Sprintf("%s}\n", indent))
+	}
+}
+
+// readRestrictedColumns returns the response columns of cfg.Table that
+// declare ReadRoles.
+func readRestrictedColumns(cfg HandlerGenConfig) []ddl.ColumnDefinition {
+	var cols []ddl.ColumnDefinition
+	for _, col := range cfg.Table.Columns {
+		if isResponseExcluded(col.Name) || (cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn) {
+			continue
+		}
+		if len(col.ReadRoles) > 0 {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// writeCallerRolesHelper emits callerRoles into helpers.go. Roles injected
+// with httputil.WithRoles take precedence; otherwise they are loaded from the
+// account's role assignments.
+func writeCallerRolesHelper(buf *bytes.Buffer) {
+	buf.WriteString(`
+// callerRoles returns the role names held by the authenticated caller, used
+// to enforce column-level read and write roles.
+func callerRoles(ctx context.Context, runner queries.Runner) ([]string, error) {
+	if roles, ok := httputil.RolesFromContext(ctx); ok {
+		return roles, nil
+	}
+	accountID, ok := httputil.SessionAccountIDFromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	account, err := runner.FindAccountByInternalID(ctx, queries.FindAccountByInternalIDParams{Id: accountID})
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, nil
+	}
+	roles := make([]string, 0, len(account.Roles))
+	for _, r := range account.Roles {
+		roles = append(roles, r.Name)
+	}
+	return roles, nil
+}
+`)
+}
+
+// writeRestrictedColumns returns the request columns of cfg.Table that
+// declare WriteRoles.
+func writeRestrictedColumns(cfg HandlerGenConfig) []ddl.ColumnDefinition {
+	var cols []ddl.ColumnDefinition
+	for _, col := range cfg.Table.Columns {
+		if isAutoColumn(col.Name) || col.Name == "public_id" || (cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn) {
+			continue
+		}
+		if len(col.WriteRoles) > 0 {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
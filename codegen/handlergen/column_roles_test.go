@@ -0,0 +1,197 @@
+package handlergen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// roleColumns are the columns of an "employees" table with column-level
+// read and write roles.
+var roleColumns = []ddl.ColumnDefinition{
+	{Name: "organization_id", Type: ddl.BigintType, References: "organizations"},
+	{Name: "name", Type: ddl.StringType},
+	{Name: "salary", Type: ddl.BigintType, ReadRoles: []string{"hr"}, WriteRoles: []string{"hr"}},
+	{Name: "review_notes", Type: ddl.TextType, Nullable: true, ReadRoles: []string{"hr", "manager"}},
+	{Name: "hired_at", Type: ddl.DatetimeType, ReadRoles: []string{"hr"}},
+	{Name: "level", Type: ddl.IntegerType, WriteRoles: []string{"admin"}},
+	deletedAt,
+}
+
+func TestValidateColumnRoles(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	if err := validateColumnRoles(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	public := cfg
+	public.RequireAuth = false
+	if err := validateColumnRoles(public); err == nil || !strings.Contains(err.Error(), "public") {
+		t.Errorf("expected error for column roles on public routes, got %v", err)
+	}
+
+	fk := testConfig("employees", append(roleColumns, ddl.ColumnDefinition{
+		Name: "manager_id", Type: ddl.BigintType, References: "employees", ReadRoles: []string{"hr"},
+	})...)
+	fk.RequireAuth = true
+	if err := validateColumnRoles(fk); err == nil || !strings.Contains(err.Error(), "manager_id") {
+		t.Errorf("expected error for ReadRoles on a reference column, got %v", err)
+	}
+}
+
+func TestRequestFieldSetExpr(t *testing.T) {
+	tests := []struct {
+		goType string
+		want   string
+	}{
+		{"*int64", "req.X != nil"},
+		{"json.RawMessage", "req.X != nil"},
+		{"bool", "req.X"},
+		{"string", `req.X != ""`},
+		{"time.Time", "!req.X.IsZero()"},
+		{"int64", "req.X != 0"},
+	}
+	for _, tt := range tests {
+		if got := requestFieldSetExpr(tt.goType, "req.X"); got != tt.want {
+			t.Errorf("requestFieldSetExpr(%q) = %q, want %q", tt.goType, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateCreateHandler_ColumnRoles(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	result, err := GenerateCreateHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("GenerateCreateHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "create.go", result)
+
+	f.AssertField("CreateEmployeeRequest", "Name", "string", `json:"name" validate:"required"`)
+	f.AssertField("CreateEmployeeRequest", "Salary", "int64", `json:"salary" write_roles:"hr"`)
+	f.AssertField("CreateEmployeeResponse", "Salary", "*int64", `json:"salary,omitempty" read_roles:"hr"`)
+	f.AssertStmts("CreateEmployee",
+		"roles, rolesErr := callerRoles(ctx, runner)",
+		`if req.Salary != 0 && !httputil.RolesAllow(roles, "hr")`,
+		`return nil, httperror.Wrap(403, "not allowed to set salary", nil)`,
+		`if req.Level != 0 && !httputil.RolesAllow(roles, "admin")`,
+		`canReadSalary := httputil.RolesAllow(roles, "hr")`,
+		`canReadReviewNotes := httputil.RolesAllow(roles, "hr", "manager")`,
+	)
+	if !f.Before("CreateEmployee", `if req.Salary != 0 && !httputil.RolesAllow(roles, "hr")`, "runner.CreateEmployee") {
+		t.Error("write checks should run before the insert")
+	}
+}
+
+func TestGenerateGetOneHandler_ColumnRoles(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	result, err := GenerateGetOneHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("GenerateGetOneHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "get_one.go", result)
+
+	f.AssertField("GetEmployeeResponse", "Salary", "*int64", `json:"salary,omitempty" read_roles:"hr"`)
+	f.AssertField("GetEmployeeResponse", "ReviewNotes", "*string", `json:"review_notes,omitempty" read_roles:"hr,manager"`)
+	f.AssertField("GetEmployeeResponse", "HiredAt", "*string", `json:"hired_at,omitempty" read_roles:"hr"`)
+	f.AssertStmts("GetEmployee",
+		"if canReadSalary",
+		"resp.Salary = &v",
+		"v := result.HiredAt.Format(time.RFC3339)",
+		"resp.ReviewNotes = result.ReviewNotes",
+	)
+	if f.HasExpr("GetEmployee", "Salary: result.Salary") {
+		t.Error("read-restricted fields must not be set unconditionally")
+	}
+}
+
+func TestGenerateListHandler_ColumnRoles(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	result, err := GenerateListHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("GenerateListHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "list.go", result)
+
+	// Roles are resolved once per request, not per item.
+	if n := f.Calls("ListEmployees", "callerRoles"); n != 1 {
+		t.Errorf("expected a single caller role lookup in the list handler, got %d", n)
+	}
+	if !f.Before("ListEmployees", `canReadSalary := httputil.RolesAllow(roles, "hr")`, "for i, item := range result.Items") {
+		t.Error("role checks should be hoisted out of the item loop")
+	}
+	f.AssertStmts("ListEmployees", "items[i].Salary = &v")
+}
+
+func TestGenerateListHandler_ColumnRolesListCacheIsPerCaller(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	cfg.ListCacheMs = 500
+	result, err := GenerateListHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("GenerateListHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "list.go", result)
+	f.AssertStmts("ListEmployees", "key := httputil.ListCacheKey(req.Limit, cursor, orgID, accountID, roles)")
+}
+
+func TestGenerateUpdateHandler_ColumnRoles(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	result, err := GenerateUpdateHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("GenerateUpdateHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "update.go", result)
+
+	check := `if req.Salary != nil && !httputil.RolesAllow(roles, "hr")`
+	f.AssertStmts("UpdateEmployee", check)
+	if !f.Before("UpdateEmployee", check, "runner.GetEmployeeByPublicID") {
+		t.Error("write checks should run before the existence lookup")
+	}
+}
+
+func TestGenerateImportHandler_ColumnRoles(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	result, err := GenerateImportHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("GenerateImportHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "import.go", result)
+
+	f.AssertStmts("ImportEmployeesFrom",
+		`case p.row.Salary != 0 && !httputil.RolesAllow(roles, "hr"):`,
+		`denied = "salary"`,
+		`resp.Errors = append(resp.Errors, ImportEmployeesRowError{Row: p.line, Field: denied, Error: "not allowed to set " + denied})`,
+	)
+}
+
+func TestGenerateHelpersFile_ColumnRoles(t *testing.T) {
+	cfg := testConfig("employees", roleColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.RequireAuth = true
+	result, err := GenerateHelpersFile(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHelpersFile failed: %v", err)
+	}
+	f := gofile.Parse(t, "helpers.go", result)
+
+	f.AssertSignature("callerRoles", "func callerRoles(ctx context.Context, runner queries.Runner) ([]string, error)")
+	// Roles injected into the context take precedence over the lookup.
+	if !f.Before("callerRoles", "if roles, ok := httputil.RolesFromContext(ctx); ok", "accountID, ok := httputil.SessionAccountIDFromContext(ctx)") {
+		t.Error("expected roles injected into the context to take precedence")
+	}
+}
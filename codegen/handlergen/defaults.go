@@ -0,0 +1,140 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// validateDefaults checks that every column in cfg.Defaults is a scalar
+// column the create request accepts and that its value parses as the
+// column's type.
+func validateDefaults(cfg HandlerGenConfig) error {
+	for _, name := range sortedDefaultColumns(cfg) {
+		col, ok := findColumn(cfg.Table, name)
+		if !ok {
+			return fmt.Errorf("default for column %q: column not found in table %q", name, cfg.TableName)
+		}
+		if isAutoColumn(col.Name) || col.Name == "public_id" || (cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn) {
+			return fmt.Errorf("default for column %q: the column is not accepted by create requests", name)
+		}
+		if col.References != "" {
+			return fmt.Errorf("default for column %q: reference columns cannot have API defaults", name)
+		}
+		if len(col.WriteRoles) > 0 {
+			return fmt.Errorf("default for column %q: columns with write roles cannot have API defaults", name)
+		}
+		if _, err := defaultLiteral(col, cfg.Defaults[name]); err != nil {
+			return fmt.Errorf("default for column %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// findColumn returns the column of table called name.
+func findColumn(table ddl.Table, name string) (ddl.ColumnDefinition, bool) {
+	for _, col := range table.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return ddl.ColumnDefinition{}, false
+}
+
+// sortedDefaultColumns returns the names of the columns with API defaults.
+func sortedDefaultColumns(cfg HandlerGenConfig) []string {
+	names := make([]string, 0, len(cfg.Defaults))
+	for name := range cfg.Defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultLiteral returns the Go expression for value as the base type of
+// col. Only scalar built-in types can have API defaults.
+func defaultLiteral(col ddl.ColumnDefinition, value string) (string, error) {
+	if col.Custom != nil {
+		return "", fmt.Errorf("custom type %q is not supported", col.Type)
+	}
+	switch col.Type {
+	case ddl.StringType, ddl.TextType, ddl.DecimalType:
+		return strconv.Quote(value), nil
+	case ddl.EnumType:
+		if !slices.Contains(col.EnumValues, value) {
+			return "", fmt.Errorf("%q is not one of %s", value, strings.Join(col.EnumValues, ", "))
+		}
+		return strconv.Quote(value), nil
+	case ddl.IntegerType, ddl.BigintType:
+		bits := 64
+		if col.Type == ddl.IntegerType {
+			bits = 32
+		}
+		n, err := strconv.ParseInt(value, 10, bits)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a valid %s", value, col.Type)
+		}
+		return fmt.Sprintf("%s(%d)", goBaseType(col.Type), n), nil
+	case ddl.FloatType:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a valid float", value)
+		}
+		return "float64(" + strconv.FormatFloat(f, 'g', -1, 64) + ")", nil
+	case ddl.BooleanType:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a valid boolean", value)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return "", fmt.Errorf("%s columns are not supported", col.Type)
+	}
+}
+
+// hasDefault reports whether col has an API default.
+func hasDefault(cfg HandlerGenConfig, col ddl.ColumnDefinition) bool {
+	_, ok := cfg.Defaults[col.Name]
+	return ok
+}
+
+// createRequestField returns the type and struct tag of col in the create
+// request. Columns with an API default are optional pointers, so that an
+// omitted field can be told apart from a zero value, and carry a default tag
+// for the OpenAPI schema.
+func createRequestField(cfg HandlerGenConfig, col ddl.ColumnDefinition) (fieldType, tag string) {
+	value, ok := cfg.Defaults[col.Name]
+	if !ok {
+		jsonTag := columnJSONName(cfg, col.Name)
+		if col.Nullable {
+			jsonTag += ",omitempty"
+		}
+		return goRequestTypeForColumn(col), withValidateTag(cfg, col, requestFieldTag(col, jsonTag))
+	}
+	return "*" + goBaseTypeForColumn(col), withValidateTag(cfg, col, fmt.Sprintf("`json:\"%s,omitempty\" default:%q`", columnJSONName(cfg, col.Name), value))
+}
+
+// writeDefaults emits the code filling omitted create request fields with
+// their API defaults.
+func writeDefaults(buf *bytes.Buffer, cfg HandlerGenConfig) {
+	names := sortedDefaultColumns(cfg)
+	if len(names) == 0 {
+		return
+	}
+	buf.WriteString("\t// Fill in API defaults for omitted fields\n")
+	for _, name := range names {
+		col, _ := findColumn(cfg.Table, name)
+		literal, _ := defaultLiteral(col, cfg.Defaults[name])
+		field := "req." + toPascalCase(col.Name)
+		buf.WriteString(fmt.Sprintf("\tif %s == nil {\n", field))
+		buf.WriteString(fmt.Sprintf("\t\tv := %s\n", literal))
+		buf.WriteString(fmt.Sprintf("\t\t%s = &v\n", field))
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\n")
+}
@@ -0,0 +1,101 @@
+package handlergen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// defaultColumns are the columns of a "posts" table whose optional fields
+// take API defaults.
+var defaultColumns = []ddl.ColumnDefinition{
+	{Name: "title", Type: ddl.StringType},
+	{Name: "status", Type: ddl.StringType},
+	{Name: "priority", Type: ddl.IntegerType},
+	{Name: "pinned", Type: ddl.BooleanType},
+	{Name: "summary", Type: ddl.TextType, Nullable: true},
+	deletedAt,
+}
+
+func TestGenerateCreateHandler_Defaults(t *testing.T) {
+	cfg := testConfig("posts", defaultColumns...)
+	cfg.Defaults = map[string]string{"status": "draft", "priority": "3", "pinned": "true", "summary": "No summary"}
+	code, err := GenerateCreateHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("GenerateCreateHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "create.go", code)
+
+	f.AssertField("CreatePostRequest", "Title", "string", `json:"title" validate:"required"`)
+	f.AssertField("CreatePostRequest", "Status", "*string", `json:"status,omitempty" default:"draft"`)
+	f.AssertField("CreatePostRequest", "Priority", "*int32", `json:"priority,omitempty" default:"3"`)
+	f.AssertField("CreatePostRequest", "Pinned", "*bool", `json:"pinned,omitempty" default:"true"`)
+	f.AssertField("CreatePostRequest", "Summary", "*string", `json:"summary,omitempty" default:"No summary"`)
+	f.AssertStmts("CreatePost",
+		"if req.Priority == nil",
+		"v := int32(3)",
+		"v := true",
+		`v := "draft"`,
+		"req.Priority = &v",
+	)
+	// Defaulted NOT NULL columns are dereferenced, nullable ones passed on.
+	f.AssertExprs("CreatePost", "Status: *req.Status", "Priority: *req.Priority", "Summary: req.Summary", "Title: req.Title")
+	if !f.Before("CreatePost", "if req.Status == nil", "runner.CreatePost") {
+		t.Error("defaults must be applied before the insert")
+	}
+}
+
+func TestGenerateCreateHandler_NoDefaults(t *testing.T) {
+	code, err := GenerateCreateHandler(testConfig("posts", defaultColumns...), nil)
+	if err != nil {
+		t.Fatalf("GenerateCreateHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "create.go", code)
+	f.AssertField("CreatePostRequest", "Status", "string", `json:"status" validate:"required"`)
+	if f.HasStmt("CreatePost", "if req.Status == nil") {
+		t.Error("no defaulting code expected without defaults")
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	cfg := testConfig("posts", defaultColumns...)
+	cfg.Defaults = map[string]string{"status": "draft", "priority": "3", "pinned": "true", "summary": "No summary"}
+	if err := validateDefaults(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		extra    ddl.ColumnDefinition
+		defaults map[string]string
+		wantErr  string
+	}{
+		{"unknown column", ddl.ColumnDefinition{}, map[string]string{"nope": "x"}, "not found"},
+		{"auto column", ddl.ColumnDefinition{}, map[string]string{"created_at": "x"}, "not accepted"},
+		{"bad integer", ddl.ColumnDefinition{}, map[string]string{"priority": "high"}, "not a valid integer"},
+		{"integer overflow", ddl.ColumnDefinition{}, map[string]string{"priority": "9999999999"}, "not a valid integer"},
+		{"bad enum value", ddl.ColumnDefinition{Name: "stage", Type: ddl.EnumType, EnumValues: []string{"draft", "live"}},
+			map[string]string{"stage": "archived"}, `"archived" is not one of draft, live`},
+		{"bad boolean", ddl.ColumnDefinition{}, map[string]string{"pinned": "maybe"}, "not a valid boolean"},
+		{"unsupported type", ddl.ColumnDefinition{Name: "published_at", Type: ddl.DatetimeType},
+			map[string]string{"published_at": "now"}, "not supported"},
+		{"write roles", ddl.ColumnDefinition{Name: "stage", Type: ddl.StringType, WriteRoles: []string{"editor"}},
+			map[string]string{"stage": "draft"}, "write roles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols := defaultColumns
+			if tt.extra.Name != "" {
+				cols = append(cols[:len(cols):len(cols)], tt.extra)
+			}
+			cfg := testConfig("posts", cols...)
+			cfg.Defaults = tt.defaults
+			err := validateDefaults(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package handlergen
+
+import "github.com/shipq/shipq/db/portsql/ddl"
+
+// testConfig returns the config of a resource in module "myapp" whose table
+// has the standard id, public_id and timestamp columns around cols. Pass a
+// nullable deleted_at column among cols for a soft-deleted table.
+func testConfig(table string, cols ...ddl.ColumnDefinition) HandlerGenConfig {
+	columns := []ddl.ColumnDefinition{
+		{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+		{Name: "public_id", Type: ddl.StringType},
+	}
+	columns = append(columns, cols...)
+	columns = append(columns,
+		ddl.ColumnDefinition{Name: "created_at", Type: ddl.DatetimeType},
+		ddl.ColumnDefinition{Name: "updated_at", Type: ddl.DatetimeType},
+	)
+	t := ddl.Table{Name: table, Columns: columns}
+	return HandlerGenConfig{
+		ModulePath: "myapp",
+		TableName:  table,
+		Table:      t,
+		Schema:     map[string]ddl.Table{table: t},
+	}
+}
+
+// deletedAt is the column that makes a table soft-deleted.
+var deletedAt = ddl.ColumnDefinition{Name: "deleted_at", Type: ddl.DatetimeType, Nullable: true}
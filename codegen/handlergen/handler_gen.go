@@ -3,12 +3,13 @@ package handlergen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
-	"github.com/shipq/shipq/codegen/crud"
+	"github.com/shipq/shipq/codegen/phase"
+	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/dbstrings"
 )
@@ -25,6 +26,43 @@ type HandlerGenConfig struct {
 	ScopeColumn string               // e.g., "organization_id" (empty if unscoped)
 	RequireAuth bool                 // true if handlers should require authentication
 	ExposeEmail bool                 // true if author email should be included in responses
+	Deprecated  bool                 // true if the CRUD routes should be marked deprecated
+	Sunset      string               // YYYY-MM-DD removal date for the CRUD routes (implies Deprecated)
+
+	ImportMaxRows int    // row limit for the import endpoint (0 = DefaultImportMaxRows)
+	NearColumn    string // point column searched by the near endpoint
+
+	Defaults map[string]string // API-side create defaults, keyed by column name
+
+	ListCacheMs int // list micro-cache TTL in milliseconds (0 = no cache)
+
+	PathSegment string // URL path segment of the routes, e.g. "post" (empty = TableName)
+
+	Outbox bool // record created/updated/deleted events in the outbox table
+
+	MaxRowsPerScope int // live rows a scope may hold before creates are refused (0 = unlimited)
+	QuotaStatus     int // HTTP status of the quota error, 402 or 403
+
+	Bulk bool // also generate the bulk create endpoint (POST /<table>/bulk)
+
+	Hooks bool // call the lifecycle hooks registered with RegisterHooks
+
+	JSONCase  string            // case of the fields' JSON names, "snake" (default) or "camel"
+	JSONNames map[string]string // JSON name overrides, keyed by column name
+
+	Filters bool // accept the filters of codegen.ListFilters on the list endpoint
+
+	Sorts []string // columns the list endpoint can be sorted by; see codegen.ListSorts
+
+	NoSoftDelete bool // DELETE removes the row instead of setting deleted_at
+
+	Restore bool // also generate the restore endpoint (POST /<table>/:id/restore)
+}
+
+// routePath returns the path the table's routes are registered under,
+// e.g. "/posts".
+func (cfg HandlerGenConfig) routePath() string {
+	return resourcePath(cfg.TableName, cfg.PathSegment)
 }
 
 // RelationshipInfo describes a relationship to embed in GET responses.
@@ -60,14 +98,18 @@ func AnalyzeRelationships(table ddl.Table, schema map[string]ddl.Table) []Relati
 	}
 
 	// 2. Find many-to-many via junction tables
-	for _, jt := range schema {
-		if !jt.IsJunctionTable {
-			continue
-		}
-		// Check if this junction table references our table
-		if refs := getJunctionReferences(jt, table.Name, schema); refs != nil {
-			relations = append(relations, *refs)
+	for _, rel := range portsqlcodegen.EmbeddableManyToMany(table.Name, schema) {
+		var cols []string
+		for _, col := range portsqlcodegen.EmbeddableColumns(schema[rel.ToTable]) {
+			cols = append(cols, col.Name)
 		}
+		relations = append(relations, RelationshipInfo{
+			FieldName:    rel.Name(),
+			TargetTable:  rel.ToTable,
+			IsMany:       true,
+			FKColumn:     rel.JunctionFKTo,
+			EmbedColumns: cols,
+		})
 	}
 
 	return relations
@@ -91,37 +133,6 @@ func toEmbedFieldName(fkColumn string) string {
 	return strings.TrimSuffix(fkColumn, "_id")
 }
 
-// getJunctionReferences checks if a junction table creates a many-to-many
-// relationship with the given table and returns the relationship info.
-func getJunctionReferences(junction ddl.Table, tableName string, schema map[string]ddl.Table) *RelationshipInfo {
-	var thisRef, otherRef string
-	var otherTable ddl.Table
-	var found bool
-
-	for _, col := range junction.Columns {
-		if col.References == tableName {
-			thisRef = col.Name
-		} else if col.References != "" {
-			otherRef = col.Name
-			otherTable, found = schema[col.References]
-		}
-	}
-
-	// Must have references to both our table and another table
-	if thisRef == "" || otherRef == "" || !found {
-		return nil
-	}
-
-	return &RelationshipInfo{
-		FieldName:    crud.ToPlural(toEmbedFieldName(otherRef)),
-		TargetTable:  otherTable.Name,
-		IsMany:       true,
-		IsNullable:   false, // Many-to-many is always an array
-		FKColumn:     otherRef,
-		EmbedColumns: getEmbeddableColumns(otherTable),
-	}
-}
-
 // toSingular converts a plural noun to singular (simple version).
 func toSingular(plural string) string {
 	if strings.HasSuffix(plural, "ies") {
@@ -165,7 +176,7 @@ func toCamelCase(s string) string {
 
 // goTypeForColumn returns the Go type for a column (raw DB type mapping).
 func goTypeForColumn(col ddl.ColumnDefinition) string {
-	baseType := goBaseType(col.Type)
+	baseType := goBaseTypeForColumn(col)
 	if col.Nullable {
 		return "*" + baseType
 	}
@@ -184,20 +195,32 @@ func goRequestTypeForColumn(col ddl.ColumnDefinition) string {
 	return goTypeForColumn(col)
 }
 
+// goBaseTypeForColumn returns the non-pointer Go type of a column, which for
+// custom types is the registered Go type.
+func goBaseTypeForColumn(col ddl.ColumnDefinition) string {
+	if col.Custom != nil {
+		return col.Custom.GoType
+	}
+	return goBaseType(col.Type)
+}
+
 // goBaseType returns the base Go type for a DDL type.
+// Decimals are carried as strings end-to-end (matching query.DecimalColumn)
+// so that values like "0.10" never pass through float64 and lose precision;
+// they marshal to JSON as strings.
 func goBaseType(colType string) string {
 	switch colType {
 	case ddl.IntegerType:
 		return "int32"
 	case ddl.BigintType:
 		return "int64"
-	case ddl.DecimalType, ddl.FloatType:
+	case ddl.FloatType:
 		return "float64"
 	case ddl.BooleanType:
 		return "bool"
-	case ddl.StringType, ddl.TextType:
+	case ddl.StringType, ddl.TextType, ddl.EnumType:
 		return "string"
-	case ddl.DatetimeType, ddl.TimestampType:
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
 		return "time.Time"
 	case ddl.BinaryType:
 		return "[]byte"
@@ -238,9 +261,6 @@ func GenerateHandlerFiles(cfg HandlerGenConfig) (map[string][]byte, error) {
 	files["helpers.go"] = helpersContent
 
 	// Generate each handler file.
-	// NOTE: get_one uses nil relations because the query runner does not yet
-	// support WithRelations methods. Once those are implemented, pass
-	// relations here to re-enable embedded relation data in get-one responses.
 	generators := map[string]func(HandlerGenConfig, []RelationshipInfo) ([]byte, error){
 		"create.go":      GenerateCreateHandler,
 		"get_one.go":     GenerateGetOneHandler,
@@ -249,11 +269,26 @@ func GenerateHandlerFiles(cfg HandlerGenConfig) (map[string][]byte, error) {
 		"soft_delete.go": GenerateSoftDeleteHandler,
 		"register.go":    GenerateRegister,
 	}
+	if cfg.Bulk {
+		generators["bulk_create.go"] = GenerateBulkCreateHandler
+	}
+	if cfg.Restore {
+		generators["restore.go"] = GenerateRestoreHandler
+	}
 
+	// get_one gets only the many-to-many relations, which it streams from
+	// their junction queries. The standard Get query does not return the
+	// referenced rows that belongs-to embeds are built from.
+	var streamed []RelationshipInfo
+	for _, rel := range relations {
+		if rel.IsMany {
+			streamed = append(streamed, rel)
+		}
+	}
 	for filename, generator := range generators {
 		rels := relations
 		if filename == "get_one.go" {
-			rels = nil // Standard get method; no relation data available yet
+			rels = streamed
 		}
 		content, err := generator(cfg, rels)
 		if err != nil {
@@ -267,19 +302,50 @@ func GenerateHandlerFiles(cfg HandlerGenConfig) (map[string][]byte, error) {
 
 // GenerateHelpersFile generates api/<table>/helpers.go containing the
 // classifyDBError helper which maps database errors to appropriate HTTP
-// status codes.
+// status codes. Unique violations become a 409 naming the fields of the
+// violated index.
 func GenerateHelpersFile(cfg HandlerGenConfig) ([]byte, error) {
 	var buf bytes.Buffer
 	pkgName := cfg.TableName
+	if err := validateColumnRoles(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateOutbox(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateJSONNames(cfg); err != nil {
+		return nil, err
+	}
+	hasRoles := tableHasColumnRoles(cfg.Table)
+	hasPoints := tableHasColumnType(cfg.Table, ddl.PointType)
+	hasPolygons := tableHasColumnType(cfg.Table, ddl.PolygonType)
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + pkgName + "\n\n")
 
 	buf.WriteString("import (\n")
+	if hasRoles || cfg.Outbox {
+		buf.WriteString("\t\"context\"\n")
+	}
 	buf.WriteString("\t\"database/sql\"\n")
+	if cfg.Outbox {
+		buf.WriteString("\t\"encoding/json\"\n")
+	}
 	buf.WriteString("\t\"errors\"\n")
 	buf.WriteString("\t\"strings\"\n\n")
+	if hasPoints || hasPolygons {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/db/portsql/query\"\n")
+	}
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
+	if hasRoles {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
+	}
+	if cfg.Outbox {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/nanoid\"\n")
+	}
+	if hasRoles || cfg.Outbox {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
+	}
 	buf.WriteString(")\n\n")
 
 	buf.WriteString(`// classifyDBError maps database errors to appropriate HTTP status codes.
@@ -293,7 +359,7 @@ func classifyDBError(err error, fallbackMsg string) *httperror.Error {
 		return httperror.NotFound("not found")
 	}
 	if isUniqueViolation(err) {
-		return httperror.Conflict("resource already exists")
+		return uniqueViolationError(err)
 	}
 	if isForeignKeyViolation(err) {
 		return httperror.UnprocessableEntity("referenced resource not found")
@@ -346,10 +412,98 @@ func isForeignKeyViolation(err error) bool {
 	return false
 }
 `)
+	writeUniqueViolationHelper(&buf, cfg)
+
+	if hasRoles {
+		writeCallerRolesHelper(&buf)
+	}
+	if hasPoints {
+		writePointHelper(&buf)
+	}
+	if hasPolygons {
+		writePolygonHelper(&buf)
+	}
+	if cfg.Outbox {
+		writeOutboxHelper(&buf, cfg)
+	}
+	if cfg.Hooks {
+		writeHooksRegistry(&buf, cfg)
+	}
 
 	return formatSource(buf.Bytes())
 }
 
+// writeUniqueViolationHelper emits uniqueViolationError, which identifies
+// the violated unique index of table from the driver's error message and
+// returns a 409 Conflict listing the JSON names of its columns as response
+// fields.
+func writeUniqueViolationHelper(buf *bytes.Buffer, cfg HandlerGenConfig) {
+	buf.WriteString(`
+// uniqueIndex is a unique index of the table, as declared in schema.json.
+// fields holds the JSON names of its columns.
+type uniqueIndex struct {
+	name    string
+	columns []string
+	fields  []string
+}
+
+`)
+	buf.WriteString("var uniqueIndexes = []uniqueIndex{\n")
+	for _, idx := range cfg.Table.Indexes {
+		if !idx.Unique {
+			continue
+		}
+		cols := make([]string, len(idx.Columns))
+		fields := make([]string, len(idx.Columns))
+		for i, c := range idx.Columns {
+			cols[i] = fmt.Sprintf("%q", c)
+			fields[i] = fmt.Sprintf("%q", columnJSONName(cfg, c))
+		}
+		fmt.Fprintf(buf, "\t{name: %q, columns: []string{%s}, fields: []string{%s}},\n",
+			idx.Name, strings.Join(cols, ", "), strings.Join(fields, ", "))
+	}
+	buf.WriteString("}\n")
+
+	fmt.Fprintf(buf, `
+// uniqueViolationError returns a 409 Conflict naming the fields of the
+// unique index err reports violated, or a generic one when the index cannot
+// be identified.
+func uniqueViolationError(err error) *httperror.Error {
+	idx := violatedUniqueIndex(err.Error())
+	if idx == nil {
+		return httperror.Conflict("resource already exists")
+	}
+	return httperror.Conflict(strings.Join(idx.fields, ", ") + " already exists").WithFields(idx.fields...)
+}
+
+// violatedUniqueIndex finds the unique index a constraint violation message
+// refers to. PostgreSQL and MySQL name the index; SQLite lists its columns
+// ("UNIQUE constraint failed: %[1]s.a, %[1]s.b").
+func violatedUniqueIndex(msg string) *uniqueIndex {
+	if _, rest, ok := strings.Cut(msg, "UNIQUE constraint failed: "); ok {
+		var cols []string
+		for _, col := range strings.Split(rest, ", ") {
+			col, _, _ = strings.Cut(col, " ")
+			cols = append(cols, strings.TrimPrefix(col, "%[1]s."))
+		}
+		for i := range uniqueIndexes {
+			if strings.Join(uniqueIndexes[i].columns, ",") == strings.Join(cols, ",") {
+				return &uniqueIndexes[i]
+			}
+		}
+		return nil
+	}
+	for i := range uniqueIndexes {
+		name := uniqueIndexes[i].name
+		if strings.Contains(msg, "\""+name+"\"") || strings.Contains(msg, "'"+name+"'") || strings.Contains(msg, "."+name+"'") {
+			return &uniqueIndexes[i]
+		}
+	}
+	return nil
+}
+`, cfg.Table.Name)
+}
+
 // GenerateTypesFile generates api/<table>/types.go containing shared type
 // declarations (e.g. AuthorEmbed) that are referenced by multiple handler files.
 func GenerateTypesFile(cfg HandlerGenConfig) ([]byte, error) {
@@ -365,8 +519,8 @@ func GenerateTypesFile(cfg HandlerGenConfig) ([]byte, error) {
 	if cfg.ExposeEmail {
 		buf.WriteString("\tEmail     string `json:\"email\"`\n")
 	}
-	buf.WriteString("\tFirstName string `json:\"first_name\"`\n")
-	buf.WriteString("\tLastName  string `json:\"last_name\"`\n")
+	fmt.Fprintf(&buf, "\tFirstName string `json:%q`\n", jsonFieldName(cfg, "first_name"))
+	fmt.Fprintf(&buf, "\tLastName  string `json:%q`\n", jsonFieldName(cfg, "last_name"))
 	buf.WriteString("}\n")
 
 	return formatSource(buf.Bytes())
@@ -378,6 +532,20 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 	res := codegen.CRUD.ResourceName(cfg.TableName)
 	pkgName := cfg.TableName
 	hasAuthor := TableHasAuthorAccountID(cfg.Table) && !AuthorJoinConflictsWithFK(cfg.Table)
+	if err := validateColumnRoles(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateDefaults(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateOutbox(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateQuota(cfg); err != nil {
+		return nil, err
+	}
+	readCols := readRestrictedColumns(cfg)
+	writeCols := writeRestrictedColumns(cfg)
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + pkgName + "\n\n")
@@ -400,13 +568,17 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("\t\"encoding/json\"\n")
 	}
 	buf.WriteString("\t\"time\"\n\n")
+	writeCustomTypeImports(&buf, cfg.Table)
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
-	if cfg.ScopeColumn != "" || hasAuthor {
+	if cfg.ScopeColumn != "" || hasAuthor || len(readCols) > 0 || len(writeCols) > 0 {
 		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
 	}
 	if hasPublicID {
 		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/nanoid\"\n")
 	}
+	if hasValidation(cfg) {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/validate\"\n")
+	}
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
 	buf.WriteString(")\n\n")
 
@@ -423,12 +595,8 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 			continue // Scope column is injected from context, not from request
 		}
 		fieldName := toPascalCase(col.Name)
-		fieldType := goRequestTypeForColumn(col)
-		jsonTag := col.Name
-		if col.Nullable {
-			jsonTag += ",omitempty"
-		}
-		buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonTag))
+		fieldType, tag := createRequestField(cfg, col)
+		buf.WriteString(fmt.Sprintf("\t%s %s %s\n", fieldName, fieldType, tag))
 	}
 	buf.WriteString("}\n\n")
 
@@ -444,22 +612,20 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 			continue // Scope column is an internal FK, never exposed in responses
 		}
 		fieldName := toPascalCase(col.Name)
-		jsonName := col.Name
-		if col.Name == "public_id" {
-			jsonName = "id"
-		}
-		fieldType := responseFieldType(col)
-		buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonName))
+		jsonName := columnJSONName(cfg, col.Name)
+		buf.WriteString("\t" + responseStructField(col, fieldName, jsonName) + "\n")
 	}
 	if hasAuthor {
 		buf.WriteString("\tAuthor *AuthorEmbed `json:\"author\"`\n")
 	}
 	buf.WriteString("}\n\n")
 
+	writeHooksInterface(&buf, cfg, hookCreate, "Create"+res+"Request", "resp *Create"+res+"Response")
+
 	// Handler function
-	buf.WriteString("// Create" + res + " handles POST /" + cfg.TableName + "\n")
+	buf.WriteString("// Create" + res + " handles POST " + cfg.routePath() + "\n")
 	buf.WriteString("func Create" + res + "(ctx context.Context, req *Create" + res + "Request) (*Create" + res + "Response, error) {\n")
-	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.RunnerFromContextFunc))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
 
 	// When scoped, extract org ID from context
 	if cfg.ScopeColumn != "" {
@@ -469,11 +635,37 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("\t}\n\n")
 	}
 
-	// When table has author_account_id, extract account ID from session context
-	if hasAuthor {
-		buf.WriteString("\taccountID, _ := httputil.SessionAccountIDFromContext(ctx)\n\n")
+	// When table has author_account_id, extract account ID from session context.
+	// Behind .Auth() a missing ID is a wiring bug, so panic; on public routes
+	// reject the request rather than recording account 0 as the author.
+	if hasAuthor && cfg.RequireAuth {
+		buf.WriteString("\taccountID := httputil.MustSessionAccountIDFromContext(ctx)\n\n")
+	} else if hasAuthor {
+		buf.WriteString("\taccountID, ok := httputil.SessionAccountIDFromContext(ctx)\n")
+		buf.WriteString("\tif !ok {\n")
+		buf.WriteString("\t\treturn nil, httperror.Wrap(401, \"authentication required\", nil)\n")
+		buf.WriteString("\t}\n\n")
 	}
 
+	// API defaults are filled in before any validation of the request
+	writeDefaults(&buf, cfg)
+
+	// Column-level roles: reject restricted writes before touching the database
+	if len(readCols) > 0 || len(writeCols) > 0 {
+		writeCallerRoles(&buf)
+	}
+	if len(writeCols) > 0 {
+		writeWriteRoleChecks(&buf, cfg, writeCols, goRequestTypeForColumn)
+	}
+	writeValidation(&buf, cfg, func(col ddl.ColumnDefinition) string {
+		fieldType, _ := createRequestField(cfg, col)
+		return fieldType
+	})
+	if pointCols := pointRequestColumns(cfg); len(pointCols) > 0 {
+		writePointChecks(&buf, cfg, pointCols, goRequestTypeForColumn)
+	}
+	writeHookCall(&buf, cfg, hookCreate, "BeforeCreate", "req")
+
 	// Build params - use contract for method and type names
 	createMethod := codegen.CRUD.CreateMethodName(cfg.TableName)
 	createParamsType := codegen.CRUD.CreateParamsType(cfg.TableName)
@@ -483,7 +675,24 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("\tpublicId := nanoid.New()\n\n")
 	}
 
-	buf.WriteString(fmt.Sprintf("\t_, err := runner.%s(ctx, queries.%s{\n", createMethod, createParamsType))
+	createAction := "create " + toSingular(cfg.TableName)
+	writer := writeWriteTx(&buf, cfg)
+	writeQuotaCheck(&buf, cfg, writer, createAction)
+	writeSoftUniqueChecks(&buf, cfg, "publicId", createAction, func(name string) string {
+		fieldName := toPascalCase(name)
+		switch {
+		case cfg.ScopeColumn != "" && name == cfg.ScopeColumn:
+			return "orgID"
+		case name == "author_account_id":
+			return "accountID"
+		}
+		if col, _ := findColumn(cfg.Table, name); hasDefault(cfg, col) && !col.Nullable {
+			return "*req." + fieldName
+		}
+		return "req." + fieldName
+	})
+
+	buf.WriteString(fmt.Sprintf("\t_, err := %s.%s(ctx, queries.%s{\n", writer, createMethod, createParamsType))
 	if hasPublicID {
 		buf.WriteString("\t\tPublicId: publicId,\n")
 	}
@@ -497,14 +706,18 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		fieldName := toPascalCase(col.Name)
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
 			buf.WriteString(fmt.Sprintf("\t\t%s: orgID,\n", fieldName))
+		} else if hasDefault(cfg, col) && !col.Nullable {
+			buf.WriteString(fmt.Sprintf("\t\t%s: *req.%s,\n", fieldName, fieldName))
 		} else {
 			buf.WriteString(fmt.Sprintf("\t\t%s: req.%s,\n", fieldName, fieldName))
 		}
 	}
 	buf.WriteString("\t})\n")
 	buf.WriteString("\tif err != nil {\n")
-	buf.WriteString("\t\treturn nil, classifyDBError(err, \"create " + toSingular(cfg.TableName) + "\")\n")
+	buf.WriteString("\t\treturn nil, classifyDBError(err, \"" + createAction + "\")\n")
 	buf.WriteString("\t}\n\n")
+	writeOutboxEvent(&buf, cfg, outboxCreated, "publicId", "req", createAction)
+	writeWriteCommit(&buf, cfg, createAction)
 
 	// Re-fetch the created record via GET to get resolved FK references and author
 	getMethod := codegen.CRUD.GetMethodName(cfg.TableName)
@@ -530,9 +743,12 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
 			continue
 		}
+		if len(col.ReadRoles) > 0 {
+			continue // assigned below when the caller may read it
+		}
 		fieldName := toPascalCase(col.Name)
 		resultField := "result." + fieldName
-		if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
+		if ddl.IsTimeType(col.Type) {
 			if col.Nullable {
 				resultField = "formatTimePtr(" + resultField + ")"
 			} else {
@@ -542,6 +758,11 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, resultField))
 	}
 	buf.WriteString("\t}\n")
+	if len(readCols) > 0 {
+		buf.WriteString("\n")
+		writeReadRoleFlags(&buf, readCols)
+		writeReadRoleAssignments(&buf, readCols, "\t", "resp", "result")
+	}
 
 	// Map author embed from flat fields
 	if hasAuthor {
@@ -557,7 +778,9 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("\t}\n")
 	}
 
-	buf.WriteString("\n\treturn resp, nil\n")
+	buf.WriteString("\n")
+	writeHookCall(&buf, cfg, hookCreate, "AfterCreate", "resp")
+	buf.WriteString("\treturn resp, nil\n")
 	buf.WriteString("}\n")
 
 	// Add helper if needed
@@ -571,20 +794,44 @@ func GenerateCreateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("}\n")
 	}
 
-	return formatSource(buf.Bytes())
+	return formatSource(dropUnusedImport(buf.Bytes(), cfg.ModulePath+"/shipq/lib/httperror"))
 }
 
-// GenerateGetOneHandler generates api/<table>/get_one.go
+// GenerateGetOneHandler generates api/<table>/get_one.go. Many-to-many
+// relations are embedded in the response, which then implements
+// httputil.JSONStreamer and streams them from their List<Singular><Relation>
+// queries through Each<Query>, so a record with many links never has them all
+// in memory. Belongs-to relations are embedded from the matching field of
+// the Get<Singular>ByPublicID result in place of their foreign key.
 func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) ([]byte, error) {
 	var buf bytes.Buffer
 	res := codegen.CRUD.ResourceName(cfg.TableName)
 	pkgName := cfg.TableName
 	hasAuthor := TableHasAuthorAccountID(cfg.Table) && !AuthorJoinConflictsWithFK(cfg.Table)
+	if err := validateColumnRoles(cfg); err != nil {
+		return nil, err
+	}
+	readCols := readRestrictedColumns(cfg)
+
+	var embedded, streamed []RelationshipInfo
+	importCols := slices.Clone(cfg.Table.Columns)
+	for _, rel := range relations {
+		if rel.IsMany {
+			streamed = append(streamed, rel)
+			importCols = append(importCols, portsqlcodegen.EmbeddableColumns(cfg.Schema[rel.TargetTable])...)
+		} else {
+			embedded = append(embedded, rel)
+		}
+	}
+	isEmbeddedFK := func(col string) bool {
+		return slices.ContainsFunc(embedded, func(rel RelationshipInfo) bool { return rel.FKColumn == col })
+	}
+	importTable := ddl.Table{Columns: importCols}
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + pkgName + "\n\n")
 
-	hasJSON := tableHasJSONColumn(cfg.Table)
+	hasJSON := tableHasJSONColumn(importTable)
 
 	// Imports
 	buf.WriteString("import (\n")
@@ -593,8 +840,9 @@ func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) (
 		buf.WriteString("\t\"encoding/json\"\n")
 	}
 	buf.WriteString("\t\"time\"\n\n")
+	writeCustomTypeImports(&buf, importTable)
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
-	if cfg.ScopeColumn != "" {
+	if cfg.ScopeColumn != "" || len(readCols) > 0 || len(streamed) > 0 {
 		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
 	}
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
@@ -607,22 +855,32 @@ func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) (
 	buf.WriteString("}\n\n")
 
 	// Embed structs for relations
-	for _, rel := range relations {
+	for _, rel := range embedded {
 		embedName := toPascalCase(rel.FieldName) + "Embed"
 		buf.WriteString("// " + embedName + " contains embedded " + rel.FieldName + " data.\n")
 		buf.WriteString("type " + embedName + " struct {\n")
-		targetTable := cfg.Schema[rel.TargetTable]
-		for _, col := range targetTable.Columns {
+		for _, col := range cfg.Schema[rel.TargetTable].Columns {
 			if isResponseExcluded(col.Name) {
 				continue
 			}
-			fieldName := toPascalCase(col.Name)
-			jsonName := col.Name
+			jsonName := jsonFieldName(cfg, col.Name)
+			if col.Name == "public_id" {
+				jsonName = "id"
+			}
+			buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", toPascalCase(col.Name), responseFieldType(col), jsonName))
+		}
+		buf.WriteString("}\n\n")
+	}
+	for _, rel := range streamed {
+		embedName := toPascalCase(rel.FieldName) + "Embed"
+		buf.WriteString("// " + embedName + " contains embedded " + rel.FieldName + " data.\n")
+		buf.WriteString("type " + embedName + " struct {\n")
+		for _, col := range portsqlcodegen.EmbeddableColumns(cfg.Schema[rel.TargetTable]) {
+			jsonName := jsonFieldName(cfg, col.Name)
 			if col.Name == "public_id" {
 				jsonName = "id"
 			}
-			fieldType := responseFieldType(col)
-			buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonName))
+			buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", toPascalCase(col.Name), responseFieldType(col), jsonName))
 		}
 		buf.WriteString("}\n\n")
 	}
@@ -638,46 +896,55 @@ func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) (
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
 			continue // Scope column is an internal FK, never exposed in responses
 		}
-		fieldName := toPascalCase(col.Name)
-		jsonName := col.Name
-		if col.Name == "public_id" {
-			jsonName = "id"
-		}
-		// Skip FK columns that will be embedded
-		skipForEmbed := false
-		for _, rel := range relations {
-			if col.Name == rel.FKColumn {
-				skipForEmbed = true
-				break
-			}
-		}
-		if skipForEmbed {
-			continue
+		if isEmbeddedFK(col.Name) {
+			continue // replaced by the embedded relation
 		}
-		fieldType := responseFieldType(col)
-		buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonName))
+		fieldName := toPascalCase(col.Name)
+		jsonName := columnJSONName(cfg, col.Name)
+		buf.WriteString("\t" + responseStructField(col, fieldName, jsonName) + "\n")
 	}
-	// Add embedded relations
-	for _, rel := range relations {
+	for _, rel := range embedded {
 		embedName := toPascalCase(rel.FieldName) + "Embed"
-		jsonName := rel.FieldName
-		if rel.IsMany {
-			buf.WriteString(fmt.Sprintf("\t%s []%s `json:\"%s\"`\n", toPascalCase(rel.FieldName), embedName, jsonName))
-		} else if rel.IsNullable {
-			buf.WriteString(fmt.Sprintf("\t%s *%s `json:\"%s,omitempty\"`\n", toPascalCase(rel.FieldName), embedName, jsonName))
-		} else {
-			buf.WriteString(fmt.Sprintf("\t%s *%s `json:\"%s\"`\n", toPascalCase(rel.FieldName), embedName, jsonName))
+		jsonName := jsonFieldName(cfg, rel.FieldName)
+		if rel.IsNullable {
+			jsonName += ",omitempty"
 		}
+		buf.WriteString(fmt.Sprintf("\t%s *%s `json:\"%s\"`\n", toPascalCase(rel.FieldName), embedName, jsonName))
 	}
 	if hasAuthor {
 		buf.WriteString("\tAuthor *AuthorEmbed `json:\"author\"`\n")
 	}
+	// Many-to-many relations document the response shape and are left nil;
+	// StreamJSON writes them from the unexported producers.
+	for _, rel := range streamed {
+		embedName := toPascalCase(rel.FieldName) + "Embed"
+		jsonName := jsonFieldName(cfg, rel.FieldName)
+		buf.WriteString(fmt.Sprintf("\t%s []%s `json:\"%s,omitempty\"`\n", toPascalCase(rel.FieldName), embedName, jsonName))
+	}
+	if len(streamed) > 0 {
+		buf.WriteString("\n")
+		for _, rel := range streamed {
+			buf.WriteString("\t" + streamedFieldName(rel) + " func(yield func(any) error) error\n")
+		}
+	}
 	buf.WriteString("}\n\n")
 
+	if len(streamed) > 0 {
+		buf.WriteString("// StreamJSON writes the response, reading the embedded relations from\n")
+		buf.WriteString("// the database as they are sent.\n")
+		buf.WriteString("func (r *Get" + res + "Response) StreamJSON(s *httputil.JSONStream) error {\n")
+		buf.WriteString("\treturn s.ObjectFields(*r,\n")
+		for _, rel := range streamed {
+			buf.WriteString(fmt.Sprintf("\t\thttputil.StreamedField{Name: %q, Items: r.%s},\n", jsonFieldName(cfg, rel.FieldName), streamedFieldName(rel)))
+		}
+		buf.WriteString("\t)\n")
+		buf.WriteString("}\n\n")
+	}
+
 	// Handler function
-	buf.WriteString("// Get" + res + " handles GET /" + cfg.TableName + "/:id\n")
+	buf.WriteString("// Get" + res + " handles GET " + cfg.routePath() + "/:id\n")
 	buf.WriteString("func Get" + res + "(ctx context.Context, req *Get" + res + "Request) (*Get" + res + "Response, error) {\n")
-	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.RunnerFromContextFunc))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
 
 	if cfg.ScopeColumn != "" {
 		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
@@ -702,6 +969,9 @@ func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) (
 	buf.WriteString("\t}\n\n")
 
 	// Build response
+	if len(readCols) > 0 {
+		writeCallerRoles(&buf)
+	}
 	buf.WriteString("\tresp := &Get" + res + "Response{\n")
 	for _, col := range cfg.Table.Columns {
 		if isResponseExcluded(col.Name) {
@@ -710,20 +980,15 @@ func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) (
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
 			continue
 		}
-		// Skip FK columns that will be embedded
-		skipForEmbed := false
-		for _, rel := range relations {
-			if col.Name == rel.FKColumn {
-				skipForEmbed = true
-				break
-			}
+		if len(col.ReadRoles) > 0 {
+			continue // assigned below when the caller may read it
 		}
-		if skipForEmbed {
+		if isEmbeddedFK(col.Name) {
 			continue
 		}
 		fieldName := toPascalCase(col.Name)
 		resultField := "result." + fieldName
-		if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
+		if ddl.IsTimeType(col.Type) {
 			if col.Nullable {
 				resultField = "formatTimePtr(" + resultField + ")"
 			} else {
@@ -733,55 +998,40 @@ func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) (
 		buf.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, resultField))
 	}
 	buf.WriteString("\t}\n")
+	if len(readCols) > 0 {
+		buf.WriteString("\n")
+		writeReadRoleFlags(&buf, readCols)
+		writeReadRoleAssignments(&buf, readCols, "\t", "resp", "result")
+	}
 
-	// Handle embedded relations
-	for _, rel := range relations {
+	// Map belongs-to relations from the Get result
+	for _, rel := range embedded {
 		embedName := toPascalCase(rel.FieldName) + "Embed"
 		fieldName := toPascalCase(rel.FieldName)
-		if rel.IsMany {
-			buf.WriteString("\n\tresp." + fieldName + " = make([]" + embedName + ", len(result." + fieldName + "))\n")
-			buf.WriteString("\tfor i, item := range result." + fieldName + " {\n")
-			buf.WriteString("\t\tresp." + fieldName + "[i] = " + embedName + "{\n")
-			targetTable := cfg.Schema[rel.TargetTable]
-			for _, col := range targetTable.Columns {
-				if isResponseExcluded(col.Name) {
-					continue
-				}
-				colFieldName := toPascalCase(col.Name)
-				itemField := "item." + colFieldName
-				if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
-					if col.Nullable {
-						itemField = "formatTimePtr(" + itemField + ")"
-					} else {
-						itemField = itemField + ".Format(time.RFC3339)"
-					}
-				}
-				buf.WriteString(fmt.Sprintf("\t\t\t%s: %s,\n", colFieldName, itemField))
+		buf.WriteString("\n\tif result." + fieldName + " != nil {\n")
+		buf.WriteString("\t\tresp." + fieldName + " = &" + embedName + "{\n")
+		for _, col := range cfg.Schema[rel.TargetTable].Columns {
+			if isResponseExcluded(col.Name) {
+				continue
 			}
-			buf.WriteString("\t\t}\n")
-			buf.WriteString("\t}\n")
-		} else {
-			buf.WriteString("\n\tif result." + fieldName + " != nil {\n")
-			buf.WriteString("\t\tresp." + fieldName + " = &" + embedName + "{\n")
-			targetTable := cfg.Schema[rel.TargetTable]
-			for _, col := range targetTable.Columns {
-				if isResponseExcluded(col.Name) {
-					continue
+			colFieldName := toPascalCase(col.Name)
+			itemField := "result." + fieldName + "." + colFieldName
+			if ddl.IsTimeType(col.Type) {
+				if col.Nullable {
+					itemField = "formatTimePtr(" + itemField + ")"
+				} else {
+					itemField = itemField + ".Format(time.RFC3339)"
 				}
-				colFieldName := toPascalCase(col.Name)
-				itemField := "result." + fieldName + "." + colFieldName
-				if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
-					if col.Nullable {
-						itemField = "formatTimePtr(" + itemField + ")"
-					} else {
-						itemField = itemField + ".Format(time.RFC3339)"
-					}
-				}
-				buf.WriteString(fmt.Sprintf("\t\t\t%s: %s,\n", colFieldName, itemField))
 			}
-			buf.WriteString("\t\t}\n")
-			buf.WriteString("\t}\n")
+			buf.WriteString(fmt.Sprintf("\t\t\t%s: %s,\n", colFieldName, itemField))
 		}
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+	}
+
+	// Stream embedded relations from their junction queries
+	for _, rel := range streamed {
+		writeStreamedRelation(&buf, cfg, rel)
 	}
 
 	// Map author embed from flat fields
@@ -804,6 +1054,54 @@ func GenerateGetOneHandler(cfg HandlerGenConfig, relations []RelationshipInfo) (
 	return formatSource(buf.Bytes())
 }
 
+// streamedFieldName returns the unexported response field holding the
+// producer of a streamed relation, e.g. "tags".
+func streamedFieldName(rel RelationshipInfo) string {
+	return dbstrings.ToLowerCamel(toPascalCase(rel.FieldName))
+}
+
+// writeStreamedRelation sets the producer of a many-to-many relation, which
+// StreamJSON calls once the response starts, e.g.:
+//
+//	resp.tags = func(yield func(any) error) error {
+//	    return runner.EachListPostTags(ctx, queries.ListPostTagsParams{PublicId: req.ID},
+//	        func(item queries.ListPostTagsResult) error {
+//	            return yield(TagsEmbed{PublicId: item.PublicId, Name: item.Name})
+//	        })
+//	}
+//
+// Nullable times are formatted after the literal, so the file does not
+// depend on formatTimePtr, which create.go defines only for this table's
+// own columns.
+func writeStreamedRelation(buf *bytes.Buffer, cfg HandlerGenConfig, rel RelationshipInfo) {
+	embedName := toPascalCase(rel.FieldName) + "Embed"
+	queryName := codegen.CRUD.RelationListMethodName(cfg.TableName, rel.FieldName)
+	cols := portsqlcodegen.EmbeddableColumns(cfg.Schema[rel.TargetTable])
+
+	buf.WriteString("\n\tresp." + streamedFieldName(rel) + " = func(yield func(any) error) error {\n")
+	buf.WriteString(fmt.Sprintf("\t\treturn runner.%s(ctx, queries.%sParams{PublicId: req.ID},\n", codegen.CRUD.EachMethodName(queryName), queryName))
+	buf.WriteString(fmt.Sprintf("\t\t\tfunc(item queries.%sResult) error {\n", queryName))
+	buf.WriteString("\t\t\t\tembed := " + embedName + "{\n")
+	var nullableTimes []ddl.ColumnDefinition
+	for _, col := range cols {
+		if ddl.IsTimeType(col.Type) && col.Nullable {
+			nullableTimes = append(nullableTimes, col)
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("\t\t\t\t\t%s: %s,\n", toPascalCase(col.Name), responseValueExpr(col, "item")))
+	}
+	buf.WriteString("\t\t\t\t}\n")
+	for _, col := range nullableTimes {
+		field := toPascalCase(col.Name)
+		buf.WriteString("\t\t\t\tif item." + field + " != nil {\n")
+		buf.WriteString("\t\t\t\t\tembed." + field + " = item." + field + ".Format(time.RFC3339)\n")
+		buf.WriteString("\t\t\t\t}\n")
+	}
+	buf.WriteString("\t\t\t\treturn yield(embed)\n")
+	buf.WriteString("\t\t\t})\n")
+	buf.WriteString("\t}\n")
+}
+
 // GenerateListHandler generates api/<table>/list.go
 func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error) {
 	var buf bytes.Buffer
@@ -811,6 +1109,10 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	plural := codegen.CRUD.PluralResourceName(cfg.TableName)
 	pkgName := cfg.TableName
 	hasAuthor := TableHasAuthorAccountID(cfg.Table) && !AuthorJoinConflictsWithFK(cfg.Table)
+	if err := validateColumnRoles(cfg); err != nil {
+		return nil, err
+	}
+	readCols := readRestrictedColumns(cfg)
 
 	// Contract-based type/method names
 	listMethod := codegen.CRUD.ListMethodName(cfg.TableName)
@@ -818,11 +1120,14 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	listCursorType := codegen.CRUD.ListCursorType(cfg.TableName)
 	decodeCursorFunc := codegen.CRUD.DecodeCursorFunc(cfg.TableName)
 	encodeCursorFunc := codegen.CRUD.EncodeCursorFunc(cfg.TableName)
+	endpoint := "GET /" + cfg.TableName
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + pkgName + "\n\n")
 
 	hasJSON := tableHasJSONColumn(cfg.Table)
+	filters := listFilters(cfg)
+	sorts := listSorts(cfg)
 
 	// Imports
 	buf.WriteString("import (\n")
@@ -830,11 +1135,13 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	if hasJSON {
 		buf.WriteString("\t\"encoding/json\"\n")
 	}
+	if listFiltersNeedStrconv(filters) {
+		buf.WriteString("\t\"strconv\"\n")
+	}
 	buf.WriteString("\t\"time\"\n\n")
+	writeCustomTypeImports(&buf, cfg.Table)
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
-	if cfg.ScopeColumn != "" {
-		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
-	}
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
 	buf.WriteString(")\n\n")
 
@@ -843,6 +1150,8 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	buf.WriteString("type List" + plural + "Request struct {\n")
 	buf.WriteString("\tLimit  int     `query:\"limit\"`  // Max items per page (default 20, max 100)\n")
 	buf.WriteString("\tCursor *string `query:\"cursor\"` // Base64-encoded pagination cursor\n")
+	writeListFilterFields(&buf, filters)
+	writeListSortField(&buf, sorts)
 	buf.WriteString("}\n\n")
 
 	// Item struct (flat, no embedding)
@@ -857,12 +1166,8 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 			continue
 		}
 		fieldName := toPascalCase(col.Name)
-		jsonName := col.Name
-		if col.Name == "public_id" {
-			jsonName = "id"
-		}
-		fieldType := responseFieldType(col)
-		buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonName))
+		jsonName := columnJSONName(cfg, col.Name)
+		buf.WriteString("\t" + responseStructField(col, fieldName, jsonName) + "\n")
 	}
 	if hasAuthor {
 		buf.WriteString("\tAuthor *AuthorEmbed `json:\"author\"`\n")
@@ -873,13 +1178,19 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	buf.WriteString("// List" + plural + "Response is the response for listing " + cfg.TableName + ".\n")
 	buf.WriteString("type List" + plural + "Response struct {\n")
 	buf.WriteString("\tItems      []" + res + "Item `json:\"items\"`\n")
-	buf.WriteString("\tNextCursor *string        `json:\"next_cursor,omitempty\"`\n")
+	fmt.Fprintf(&buf, "\tNextCursor *string        `json:\"%s,omitempty\"`\n", jsonFieldName(cfg, "next_cursor"))
 	buf.WriteString("}\n\n")
 
 	// Handler function
-	buf.WriteString("// List" + plural + " handles GET /" + cfg.TableName + "\n")
-	buf.WriteString("func List" + plural + "(ctx context.Context, req *List" + plural + "Request) (*List" + plural + "Response, error) {\n")
-	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.RunnerFromContextFunc))
+	buf.WriteString("// List" + plural + " handles GET " + cfg.routePath() + "\n")
+	if cfg.ListCacheMs > 0 {
+		writeListCacheWrapper(&buf, cfg, plural, endpoint, len(readCols) > 0, filters, sorts)
+		buf.WriteString("// list" + plural + "Uncached is List" + plural + " without the micro-cache.\n")
+		buf.WriteString("func list" + plural + "Uncached(ctx context.Context, req *List" + plural + "Request) (*List" + plural + "Response, error) {\n")
+	} else {
+		buf.WriteString("func List" + plural + "(ctx context.Context, req *List" + plural + "Request) (*List" + plural + "Response, error) {\n")
+	}
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
 
 	if cfg.ScopeColumn != "" {
 		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
@@ -895,27 +1206,44 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	buf.WriteString("\t\tlimit = 20\n")
 	buf.WriteString("\t}\n\n")
 
-	// Decode cursor
-	buf.WriteString("\t// Decode cursor\n")
-	buf.WriteString(fmt.Sprintf("\tvar cursor *queries.%s\n", listCursorType))
-	buf.WriteString("\tif req.Cursor != nil {\n")
-	buf.WriteString(fmt.Sprintf("\t\tcursor = queries.%s(*req.Cursor)\n", decodeCursorFunc))
-	buf.WriteString("\t}\n\n")
+	writeListCursorDecode(&buf, listCursorType, decodeCursorFunc, endpoint)
 
 	// Call query
-	buf.WriteString("\t// Query database\n")
-	buf.WriteString(fmt.Sprintf("\tresult, err := runner.%s(ctx, queries.%s{\n", listMethod, listParamsType))
+	hasParams := len(filters) > 0 || len(sorts) > 0
+	if hasParams {
+		buf.WriteString(fmt.Sprintf("\tparams := queries.%s{\n", listParamsType))
+	} else {
+		buf.WriteString("\t// Query database\n")
+		buf.WriteString(fmt.Sprintf("\tresult, err := runner.%s(ctx, queries.%s{\n", listMethod, listParamsType))
+	}
 	if cfg.ScopeColumn != "" {
 		buf.WriteString(fmt.Sprintf("\t\t%s: orgID,\n", dbstrings.ToPascalCase(cfg.ScopeColumn)))
 	}
 	buf.WriteString("\t\tLimit:  limit,\n")
 	buf.WriteString("\t\tCursor: cursor,\n")
-	buf.WriteString("\t})\n")
+	if hasParams {
+		buf.WriteString("\t}\n")
+		if len(filters) > 0 {
+			writeListFilterParams(&buf, filters)
+		} else {
+			buf.WriteString("\n")
+		}
+		writeListSortParam(&buf, sorts)
+		buf.WriteString("\t// Query database\n")
+		buf.WriteString(fmt.Sprintf("\tresult, err := runner.%s(ctx, params)\n", listMethod))
+	} else {
+		buf.WriteString("\t})\n")
+	}
 	buf.WriteString("\tif err != nil {\n")
 	buf.WriteString("\t\treturn nil, classifyDBError(err, \"list " + cfg.TableName + "\")\n")
 	buf.WriteString("\t}\n\n")
+	buf.WriteString(fmt.Sprintf("\thttputil.RecordListPage(ctx, %q, depth)\n\n", endpoint))
 
 	// Map items
+	if len(readCols) > 0 {
+		writeCallerRoles(&buf)
+		writeReadRoleFlags(&buf, readCols)
+	}
 	buf.WriteString("\t// Map items to response\n")
 	buf.WriteString("\titems := make([]" + res + "Item, len(result.Items))\n")
 	buf.WriteString("\tfor i, item := range result.Items {\n")
@@ -927,9 +1255,12 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
 			continue
 		}
+		if len(col.ReadRoles) > 0 {
+			continue // assigned below when the caller may read it
+		}
 		fieldName := toPascalCase(col.Name)
 		itemField := "item." + fieldName
-		if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
+		if ddl.IsTimeType(col.Type) {
 			if col.Nullable {
 				itemField = "formatTimePtr(" + itemField + ")"
 			} else {
@@ -939,6 +1270,7 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 		buf.WriteString(fmt.Sprintf("\t\t\t%s: %s,\n", fieldName, itemField))
 	}
 	buf.WriteString("\t\t}\n")
+	writeReadRoleAssignments(&buf, readCols, "\t\t", "items[i]", "item")
 	if hasAuthor {
 		buf.WriteString("\t\tif item.AuthorId != nil && *item.AuthorId != \"\" {\n")
 		buf.WriteString("\t\t\titems[i].Author = &AuthorEmbed{\n")
@@ -954,12 +1286,7 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	buf.WriteString("\t}\n\n")
 
 	// Encode next cursor
-	buf.WriteString("\t// Encode next cursor\n")
-	buf.WriteString("\tvar nextCursor *string\n")
-	buf.WriteString("\tif result.NextCursor != nil {\n")
-	buf.WriteString(fmt.Sprintf("\t\tencoded := queries.%s(result.NextCursor)\n", encodeCursorFunc))
-	buf.WriteString("\t\tnextCursor = &encoded\n")
-	buf.WriteString("\t}\n\n")
+	writeListCursorEncode(&buf, encodeCursorFunc)
 
 	buf.WriteString("\treturn &List" + plural + "Response{\n")
 	buf.WriteString("\t\tItems:      items,\n")
@@ -967,7 +1294,92 @@ func GenerateListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, er
 	buf.WriteString("\t}, nil\n")
 	buf.WriteString("}\n")
 
-	return formatSource(buf.Bytes())
+	return formatSource(dropUnusedImport(buf.Bytes(), cfg.ModulePath+"/shipq/lib/httperror"))
+}
+
+// writeListCacheWrapper writes the exported list handler of a table with a
+// micro-cache: it keys the request by its parameters and the caller's
+// scope (organization, and account and roles when columns are
+// read-restricted), its filters and its sort, and delegates to list<Plural>Uncached. The doc
+// comment is written by the caller.
+func writeListCacheWrapper(buf *bytes.Buffer, cfg HandlerGenConfig, plural, endpoint string, perCaller bool, filters []listFilter, sorts []string) {
+	handler := "List" + plural
+	cacheVar := "list" + plural + "Cache"
+
+	buf.WriteString("func " + handler + "(ctx context.Context, req *" + handler + "Request) (*" + handler + "Response, error) {\n")
+	buf.WriteString("\tcursor := \"\"\n")
+	buf.WriteString("\tif req.Cursor != nil {\n")
+	buf.WriteString("\t\tcursor = *req.Cursor\n")
+	buf.WriteString("\t}\n")
+	keyParts := []string{"req.Limit", "cursor"}
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\torgID, _ := httputil.OrganizationIDFromContext(ctx)\n")
+		keyParts = append(keyParts, "orgID")
+	}
+	if perCaller {
+		buf.WriteString("\taccountID, _ := httputil.SessionAccountIDFromContext(ctx)\n")
+		buf.WriteString("\troles, _ := httputil.RolesFromContext(ctx)\n")
+		keyParts = append(keyParts, "accountID", "roles")
+	}
+	if len(filters) > 0 {
+		// An unset filter and an empty one are different requests.
+		buf.WriteString("\tfilterKey := func(v *string) string {\n")
+		buf.WriteString("\t\tif v == nil {\n")
+		buf.WriteString("\t\t\treturn \"\"\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t\treturn \"=\" + *v\n")
+		buf.WriteString("\t}\n")
+		for _, f := range filters {
+			keyParts = append(keyParts, "filterKey(req."+f.Field+")")
+		}
+	}
+	if len(sorts) > 0 {
+		buf.WriteString("\tsortKey := \"\"\n")
+		buf.WriteString("\tif req.Sort != nil {\n")
+		buf.WriteString("\t\tsortKey = *req.Sort\n")
+		buf.WriteString("\t}\n")
+		keyParts = append(keyParts, "sortKey")
+	}
+	buf.WriteString(fmt.Sprintf("\tkey := httputil.ListCacheKey(%s)\n", strings.Join(keyParts, ", ")))
+	buf.WriteString(fmt.Sprintf("\treturn %s.Do(ctx, key, func() (*%sResponse, error) {\n", cacheVar, handler))
+	buf.WriteString("\t\treturn list" + plural + "Uncached(ctx, req)\n")
+	buf.WriteString("\t})\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s answers identical %s requests within %dms with one query.\n", cacheVar, handler, cfg.ListCacheMs))
+	buf.WriteString(fmt.Sprintf("var %s = httputil.NewListCache[%sResponse](%q, %d*time.Millisecond)\n\n", cacheVar, handler, endpoint, cfg.ListCacheMs))
+}
+
+// writeListCursorDecode writes the cursor decoding of a list handler. It
+// tracks the page depth carried in the cursor and records cursors that fail
+// to decode (typically issued before a schema change), which restart at the
+// first page.
+func writeListCursorDecode(buf *bytes.Buffer, cursorType, decodeFunc, endpoint string) {
+	buf.WriteString("\t// Decode cursor\n")
+	buf.WriteString(fmt.Sprintf("\tvar cursor *queries.%s\n", cursorType))
+	buf.WriteString("\tdepth := 1\n")
+	buf.WriteString("\tif req.Cursor != nil && *req.Cursor != \"\" {\n")
+	buf.WriteString(fmt.Sprintf("\t\tcursor = queries.%s(*req.Cursor)\n", decodeFunc))
+	buf.WriteString("\t\tif cursor == nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\t\thttputil.RecordCursorError(ctx, %q)\n", endpoint))
+	buf.WriteString("\t\t} else if cursor.PageDepth > 1 {\n")
+	buf.WriteString("\t\t\tdepth = cursor.PageDepth + 1\n")
+	buf.WriteString("\t\t} else {\n")
+	buf.WriteString("\t\t\tdepth = 2\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n\n")
+}
+
+// writeListCursorEncode writes the next-cursor encoding of a list handler,
+// stamping the cursor with the depth of the current page.
+func writeListCursorEncode(buf *bytes.Buffer, encodeFunc string) {
+	buf.WriteString("\t// Encode next cursor\n")
+	buf.WriteString("\tvar nextCursor *string\n")
+	buf.WriteString("\tif result.NextCursor != nil {\n")
+	buf.WriteString("\t\tresult.NextCursor.PageDepth = depth\n")
+	buf.WriteString(fmt.Sprintf("\t\tencoded := queries.%s(result.NextCursor)\n", encodeFunc))
+	buf.WriteString("\t\tnextCursor = &encoded\n")
+	buf.WriteString("\t}\n\n")
 }
 
 // GenerateUpdateHandler generates api/<table>/update.go
@@ -976,6 +1388,11 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 	res := codegen.CRUD.ResourceName(cfg.TableName)
 	pkgName := cfg.TableName
 	hasAuthor := TableHasAuthorAccountID(cfg.Table) && !AuthorJoinConflictsWithFK(cfg.Table)
+	if err := validateColumnRoles(cfg); err != nil {
+		return nil, err
+	}
+	readCols := readRestrictedColumns(cfg)
+	writeCols := writeRestrictedColumns(cfg)
 
 	// Contract-based type/method names
 	updateMethod := codegen.CRUD.UpdateMethodName(cfg.TableName)
@@ -993,10 +1410,14 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("\t\"encoding/json\"\n")
 	}
 	buf.WriteString("\t\"time\"\n\n")
+	writeCustomTypeImports(&buf, cfg.Table)
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
-	if cfg.ScopeColumn != "" {
+	if cfg.ScopeColumn != "" || len(readCols) > 0 || len(writeCols) > 0 {
 		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
 	}
+	if hasValidation(cfg) {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/validate\"\n")
+	}
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
 	buf.WriteString(")\n\n")
 
@@ -1013,16 +1434,9 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 			continue // Scope column is not updatable
 		}
 		fieldName := toPascalCase(col.Name)
-		// All fields are pointers for optional updates (PATCH semantics).
-		// FK columns use *string (public_id) regardless of nullable status.
-		var fieldType string
-		if col.References != "" {
-			fieldType = "*string"
-		} else {
-			fieldType = "*" + goTypeForColumn(col)
-		}
-		jsonTag := col.Name + ",omitempty"
-		buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonTag))
+		jsonTag := columnJSONName(cfg, col.Name) + ",omitempty"
+		tag := withValidateTag(cfg, col, requestFieldTag(col, jsonTag))
+		buf.WriteString(fmt.Sprintf("\t%s %s %s\n", fieldName, updateRequestFieldType(col), tag))
 	}
 	buf.WriteString("}\n\n")
 
@@ -1037,22 +1451,20 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 			continue
 		}
 		fieldName := toPascalCase(col.Name)
-		jsonName := col.Name
-		if col.Name == "public_id" {
-			jsonName = "id"
-		}
-		fieldType := responseFieldType(col)
-		buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", fieldName, fieldType, jsonName))
+		jsonName := columnJSONName(cfg, col.Name)
+		buf.WriteString("\t" + responseStructField(col, fieldName, jsonName) + "\n")
 	}
 	if hasAuthor {
 		buf.WriteString("\tAuthor *AuthorEmbed `json:\"author\"`\n")
 	}
 	buf.WriteString("}\n\n")
 
+	writeHooksInterface(&buf, cfg, hookUpdate, "Update"+res+"Request", "resp *Update"+res+"Response")
+
 	// Handler function
-	buf.WriteString("// Update" + res + " handles PATCH /" + cfg.TableName + "/:id\n")
+	buf.WriteString("// Update" + res + " handles PATCH " + cfg.routePath() + "/:id\n")
 	buf.WriteString("func Update" + res + "(ctx context.Context, req *Update" + res + "Request) (*Update" + res + "Response, error) {\n")
-	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.RunnerFromContextFunc))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
 
 	if cfg.ScopeColumn != "" {
 		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
@@ -1061,6 +1473,19 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("\t}\n\n")
 	}
 
+	// Column-level roles: reject restricted writes before touching the database
+	if len(readCols) > 0 || len(writeCols) > 0 {
+		writeCallerRoles(&buf)
+	}
+	if len(writeCols) > 0 {
+		writeWriteRoleChecks(&buf, cfg, writeCols, func(ddl.ColumnDefinition) string { return "*" })
+	}
+	writeValidation(&buf, cfg, updateRequestFieldType)
+	if pointCols := pointRequestColumns(cfg); len(pointCols) > 0 {
+		writePointChecks(&buf, cfg, pointCols, updateRequestFieldType)
+	}
+	writeHookCall(&buf, cfg, hookUpdate, "BeforeUpdate", "req")
+
 	// Verify the resource exists before attempting the update.
 	// This avoids nil-pointer dereferences on optional PATCH fields when
 	// the caller only supplies the ID (e.g., not-found tests).
@@ -1079,10 +1504,24 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 	buf.WriteString("\t\treturn nil, httperror.NotFoundf(\"" + toSingular(cfg.TableName) + " %q not found\", req.ID)\n")
 	buf.WriteString("\t}\n\n")
 
-	// Merge request fields with existing values — nil pointers in the
-	// request mean "keep the current value" (PATCH semantics).
-	// Execute update
-	buf.WriteString(fmt.Sprintf("\t_, err = runner.%s(ctx, queries.%s{\n", updateMethod, updateParamsType))
+	updateAction := "update " + toSingular(cfg.TableName)
+	writer := writeWriteTx(&buf, cfg)
+	writeSoftUniqueChecks(&buf, cfg, "req.ID", updateAction, func(name string) string {
+		fieldName := toPascalCase(name)
+		switch {
+		case cfg.ScopeColumn != "" && name == cfg.ScopeColumn:
+			return "orgID"
+		}
+		if col, _ := findColumn(cfg.Table, name); col.References != "" {
+			return fmt.Sprintf("derefOr(req.%s, \"\")", fieldName)
+		}
+		return fmt.Sprintf("derefOr(req.%s, existing.%s)", fieldName, fieldName)
+	})
+
+	// Execute update. The update's params are pointers like the request's
+	// fields (PATCH semantics): nil leaves the column unchanged, so the
+	// fields are passed through as they are.
+	buf.WriteString(fmt.Sprintf("\t_, err = %s.%s(ctx, queries.%s{\n", writer, updateMethod, updateParamsType))
 	buf.WriteString("\t\tPublicId: req.ID,\n")
 	for _, col := range cfg.Table.Columns {
 		if isAutoColumn(col.Name) || col.Name == "public_id" {
@@ -1092,25 +1531,17 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 			continue // Scope column is not updatable
 		}
 		fieldName := toPascalCase(col.Name)
-		// Request fields are pointers (PATCH semantics).
-		// When nil, fall back to the existing value so callers may omit unchanged fields.
-		if col.References != "" {
-			// FK columns: request uses *string (public_id) but existing record
-			// stores int64 (internal FK), so the types are incompatible for derefOr.
-			// Use "" as fallback; callers must always provide FK fields on update.
-			// The 404 check above ensures we never reach here for nonexistent resources.
-			buf.WriteString(fmt.Sprintf("\t\t%s: derefOr(req.%s, \"\"),\n", fieldName, fieldName))
-		} else {
-			buf.WriteString(fmt.Sprintf("\t\t%s: derefOr(req.%s, existing.%s),\n", fieldName, fieldName, fieldName))
-		}
+		buf.WriteString(fmt.Sprintf("\t\t%s: req.%s,\n", fieldName, fieldName))
 	}
 	if cfg.ScopeColumn != "" {
 		buf.WriteString(fmt.Sprintf("\t\t%s: orgID,\n", dbstrings.ToPascalCase(cfg.ScopeColumn)))
 	}
 	buf.WriteString("\t})\n")
 	buf.WriteString("\tif err != nil {\n")
-	buf.WriteString("\t\treturn nil, classifyDBError(err, \"update " + toSingular(cfg.TableName) + "\")\n")
+	buf.WriteString("\t\treturn nil, classifyDBError(err, \"" + updateAction + "\")\n")
 	buf.WriteString("\t}\n\n")
+	writeOutboxEvent(&buf, cfg, outboxUpdated, "req.ID", "req", updateAction)
+	writeWriteCommit(&buf, cfg, updateAction)
 
 	// Re-fetch the updated record
 	buf.WriteString(fmt.Sprintf("\tresult, err := runner.%s(ctx, queries.%s{\n", getMethod, getParamsType))
@@ -1135,9 +1566,12 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
 			continue
 		}
+		if len(col.ReadRoles) > 0 {
+			continue // assigned below when the caller may read it
+		}
 		fieldName := toPascalCase(col.Name)
 		resultField := "result." + fieldName
-		if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
+		if ddl.IsTimeType(col.Type) {
 			if col.Nullable {
 				resultField = "formatTimePtr(" + resultField + ")"
 			} else {
@@ -1147,6 +1581,11 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, resultField))
 	}
 	buf.WriteString("\t}\n")
+	if len(readCols) > 0 {
+		buf.WriteString("\n")
+		writeReadRoleFlags(&buf, readCols)
+		writeReadRoleAssignments(&buf, readCols, "\t", "resp", "result")
+	}
 
 	// Map author embed from flat fields
 	if hasAuthor {
@@ -1162,7 +1601,9 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 		buf.WriteString("\t}\n")
 	}
 
-	buf.WriteString("\n\treturn resp, nil\n")
+	buf.WriteString("\n")
+	writeHookCall(&buf, cfg, hookUpdate, "AfterUpdate", "resp")
+	buf.WriteString("\treturn resp, nil\n")
 	buf.WriteString("}\n\n")
 
 	// derefOr helper for PATCH semantics: use the pointer value if non-nil,
@@ -1178,6 +1619,16 @@ func GenerateUpdateHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte,
 	return formatSource(buf.Bytes())
 }
 
+// updateRequestFieldType returns the Go type of col in the update request.
+// All fields are pointers for optional updates (PATCH semantics); FK columns
+// use *string (public_id) regardless of nullable status.
+func updateRequestFieldType(col ddl.ColumnDefinition) string {
+	if col.References != "" {
+		return "*string"
+	}
+	return "*" + goTypeForColumn(col)
+}
+
 // GenerateSoftDeleteHandler generates api/<table>/soft_delete.go
 func GenerateSoftDeleteHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error) {
 	var buf bytes.Buffer
@@ -1186,6 +1637,9 @@ func GenerateSoftDeleteHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]by
 
 	// Contract-based method name
 	softDeleteMethod := codegen.CRUD.SoftDeleteMethodName(cfg.TableName)
+	if cfg.NoSoftDelete {
+		softDeleteMethod = codegen.CRUD.DeleteMethodName(cfg.TableName)
+	}
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + pkgName + "\n\n")
@@ -1212,10 +1666,12 @@ func GenerateSoftDeleteHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]by
 	buf.WriteString("\tSuccess bool `json:\"success\"`\n")
 	buf.WriteString("}\n\n")
 
+	writeHooksInterface(&buf, cfg, hookDelete, "SoftDelete"+res+"Request", "req *SoftDelete"+res+"Request")
+
 	// Handler function
-	buf.WriteString("// SoftDelete" + res + " handles DELETE /" + cfg.TableName + "/:id\n")
+	buf.WriteString("// SoftDelete" + res + " handles DELETE " + cfg.routePath() + "/:id\n")
 	buf.WriteString("func SoftDelete" + res + "(ctx context.Context, req *SoftDelete" + res + "Request) (*SoftDelete" + res + "Response, error) {\n")
-	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.RunnerFromContextFunc))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
 
 	if cfg.ScopeColumn != "" {
 		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
@@ -1224,23 +1680,42 @@ func GenerateSoftDeleteHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]by
 		buf.WriteString("\t}\n\n")
 	}
 
+	writeHookCall(&buf, cfg, hookDelete, "BeforeDelete", "req")
+
+	deleteAction := "delete " + toSingular(cfg.TableName)
 	softDeleteParamsType := softDeleteMethod + "Params"
-	buf.WriteString(fmt.Sprintf("\t_, err := runner.%s(ctx, queries.%s{\n", softDeleteMethod, softDeleteParamsType))
+	if cfg.Outbox {
+		buf.WriteString("\t// The outbox event is recorded in the transaction that deletes the row.\n")
+		writeTxRunner(&buf)
+		buf.WriteString(fmt.Sprintf("\tresult, err := tx.%s(ctx, queries.%s{\n", softDeleteMethod, softDeleteParamsType))
+	} else {
+		buf.WriteString(fmt.Sprintf("\t_, err := runner.%s(ctx, queries.%s{\n", softDeleteMethod, softDeleteParamsType))
+	}
 	buf.WriteString("\t\tPublicId: req.ID,\n")
 	if cfg.ScopeColumn != "" {
 		buf.WriteString(fmt.Sprintf("\t\t%s: orgID,\n", dbstrings.ToPascalCase(cfg.ScopeColumn)))
 	}
 	buf.WriteString("\t})\n")
 	buf.WriteString("\tif err != nil {\n")
-	buf.WriteString("\t\treturn nil, classifyDBError(err, \"delete " + toSingular(cfg.TableName) + "\")\n")
+	buf.WriteString("\t\treturn nil, classifyDBError(err, \"" + deleteAction + "\")\n")
 	buf.WriteString("\t}\n\n")
+	if cfg.Outbox {
+		// Only a row that was live gets a deleted event.
+		buf.WriteString("\tif n, _ := result.RowsAffected(); n > 0 {\n")
+		var event bytes.Buffer
+		writeOutboxEvent(&event, cfg, outboxDeleted, "req.ID", "req", deleteAction)
+		buf.Write(bytes.TrimSuffix(event.Bytes(), []byte("\n")))
+		buf.WriteString("\t}\n\n")
+		writeWriteCommit(&buf, cfg, deleteAction)
+	}
+	writeHookCall(&buf, cfg, hookDelete, "AfterDelete", "req")
 
 	buf.WriteString("\treturn &SoftDelete" + res + "Response{\n")
 	buf.WriteString("\t\tSuccess: true,\n")
 	buf.WriteString("\t}, nil\n")
 	buf.WriteString("}\n")
 
-	return formatSource(buf.Bytes())
+	return formatSource(dropUnusedImport(buf.Bytes(), cfg.ModulePath+"/shipq/lib/httperror"))
 }
 
 // tableHasDeletedAt returns true if the table has a deleted_at column.
@@ -1299,6 +1774,7 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 	listCursorType := codegen.CRUD.AdminListCursorType(cfg.TableName)
 	decodeCursorFunc := codegen.CRUD.AdminListDecodeCursorFunc(cfg.TableName)
 	encodeCursorFunc := codegen.CRUD.AdminListEncodeCursorFunc(cfg.TableName)
+	endpoint := "GET /admin/" + cfg.TableName
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + pkgName + "\n\n")
@@ -1312,10 +1788,9 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 		buf.WriteString("\t\"encoding/json\"\n")
 	}
 	buf.WriteString("\t\"time\"\n\n")
+	writeCustomTypeImports(&buf, cfg.Table)
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
-	if cfg.ScopeColumn != "" {
-		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
-	}
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
 	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
 	buf.WriteString(")\n\n")
 
@@ -1335,10 +1810,7 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 			continue
 		}
 		fieldName := toPascalCase(col.Name)
-		jsonName := col.Name
-		if col.Name == "public_id" {
-			jsonName = "id"
-		}
+		jsonName := columnJSONName(cfg, col.Name)
 		fieldType := responseFieldType(col)
 		// deleted_at is nullable timestamp → *string
 		if col.Name == "deleted_at" {
@@ -1352,13 +1824,13 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 	buf.WriteString("// AdminList" + plural + "Response is the response for admin-listing " + cfg.TableName + " (includes deleted).\n")
 	buf.WriteString("type AdminList" + plural + "Response struct {\n")
 	buf.WriteString("\tItems      []Admin" + res + "Item `json:\"items\"`\n")
-	buf.WriteString("\tNextCursor *string              `json:\"next_cursor,omitempty\"`\n")
+	fmt.Fprintf(&buf, "\tNextCursor *string              `json:\"%s,omitempty\"`\n", jsonFieldName(cfg, "next_cursor"))
 	buf.WriteString("}\n\n")
 
 	// Handler function
-	buf.WriteString("// AdminList" + plural + " handles GET /admin/" + cfg.TableName + " (admin-only, includes soft-deleted records)\n")
+	buf.WriteString("// AdminList" + plural + " handles GET /admin" + cfg.routePath() + " (admin-only, includes soft-deleted records)\n")
 	buf.WriteString("func AdminList" + plural + "(ctx context.Context, req *AdminList" + plural + "Request) (*AdminList" + plural + "Response, error) {\n")
-	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.RunnerFromContextFunc))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
 
 	if cfg.ScopeColumn != "" {
 		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
@@ -1374,12 +1846,7 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 	buf.WriteString("\t\tlimit = 20\n")
 	buf.WriteString("\t}\n\n")
 
-	// Decode cursor
-	buf.WriteString("\t// Decode cursor\n")
-	buf.WriteString(fmt.Sprintf("\tvar cursor *queries.%s\n", listCursorType))
-	buf.WriteString("\tif req.Cursor != nil {\n")
-	buf.WriteString(fmt.Sprintf("\t\tcursor = queries.%s(*req.Cursor)\n", decodeCursorFunc))
-	buf.WriteString("\t}\n\n")
+	writeListCursorDecode(&buf, listCursorType, decodeCursorFunc, endpoint)
 
 	// Call query
 	buf.WriteString("\t// Query database\n")
@@ -1393,6 +1860,7 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 	buf.WriteString("\tif err != nil {\n")
 	buf.WriteString("\t\treturn nil, classifyDBError(err, \"admin-list " + cfg.TableName + "\")\n")
 	buf.WriteString("\t}\n\n")
+	buf.WriteString(fmt.Sprintf("\thttputil.RecordListPage(ctx, %q, depth)\n\n", endpoint))
 
 	// Map items
 	buf.WriteString("\t// Map items to response\n")
@@ -1405,7 +1873,7 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 		}
 		fieldName := toPascalCase(col.Name)
 		itemField := "item." + fieldName
-		if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
+		if ddl.IsTimeType(col.Type) {
 			if col.Nullable {
 				itemField = "formatTimePtr(" + itemField + ")"
 			} else {
@@ -1418,12 +1886,7 @@ func GenerateAdminListHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byt
 	buf.WriteString("\t}\n\n")
 
 	// Encode next cursor
-	buf.WriteString("\t// Encode next cursor\n")
-	buf.WriteString("\tvar nextCursor *string\n")
-	buf.WriteString("\tif result.NextCursor != nil {\n")
-	buf.WriteString(fmt.Sprintf("\t\tencoded := queries.%s(result.NextCursor)\n", encodeCursorFunc))
-	buf.WriteString("\t\tnextCursor = &encoded\n")
-	buf.WriteString("\t}\n\n")
+	writeListCursorEncode(&buf, encodeCursorFunc)
 
 	buf.WriteString("\treturn &AdminList" + plural + "Response{\n")
 	buf.WriteString("\t\tItems:      items,\n")
@@ -1469,9 +1932,9 @@ func GenerateUndeleteHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte
 	buf.WriteString("}\n\n")
 
 	// Handler function
-	buf.WriteString("// Undelete" + res + " handles PATCH /admin/" + cfg.TableName + "/:id/restore\n")
+	buf.WriteString("// Undelete" + res + " handles PATCH /admin" + cfg.routePath() + "/:id/restore\n")
 	buf.WriteString("func Undelete" + res + "(ctx context.Context, req *Undelete" + res + "Request) (*Undelete" + res + "Response, error) {\n")
-	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.RunnerFromContextFunc))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
 
 	if cfg.ScopeColumn != "" {
 		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
@@ -1505,6 +1968,7 @@ func GenerateRegister(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error
 	res := codegen.CRUD.ResourceName(cfg.TableName)
 	plural := codegen.CRUD.PluralResourceName(cfg.TableName)
 	pkgName := cfg.TableName
+	base := cfg.routePath()
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + pkgName + "\n\n")
@@ -1518,29 +1982,32 @@ func GenerateRegister(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error
 	buf.WriteString("// To add a new route, define your handler function in this package and\n")
 	buf.WriteString("// register it here:\n")
 	buf.WriteString("//\n")
-	buf.WriteString("//   app.Get(\"/" + cfg.TableName + "/popular\", ListPopular" + plural + "\")         // public route\n")
-	buf.WriteString("//   app.Post(\"/" + cfg.TableName + "/import\", Import" + plural + "\").Auth()       // requires auth\n")
-	buf.WriteString("//   app.Put(\"/" + cfg.TableName + "/:id/publish\", Publish" + res + ").Auth()    // requires auth\n")
+	buf.WriteString("//   app.Get(\"" + base + "/popular\", ListPopular" + plural + "\")         // public route\n")
+	buf.WriteString("//   app.Post(\"" + base + "/import\", Import" + plural + "\").Auth()       // requires auth\n")
+	buf.WriteString("//   app.Put(\"" + base + "/:id/publish\", Publish" + res + ").Auth()    // requires auth\n")
 	buf.WriteString("//\n")
 	buf.WriteString("// Available methods: app.Get, app.Post, app.Put, app.Patch, app.Delete\n")
 	buf.WriteString("func Register(app *handler.App) {\n")
 
-	authSuffix := ""
-	if cfg.RequireAuth {
-		authSuffix = ".Auth()"
-	}
+	suffix := routeModifiers(cfg.RequireAuth, cfg.Deprecated, cfg.Sunset)
 
-	buf.WriteString("\tapp.Post(\"/" + cfg.TableName + "\", Create" + res + ")" + authSuffix + "\n")
-	buf.WriteString("\tapp.Get(\"/" + cfg.TableName + "\", List" + plural + ")" + authSuffix + "\n")
-	buf.WriteString("\tapp.Get(\"/" + cfg.TableName + "/:id\", Get" + res + ")" + authSuffix + "\n")
-	buf.WriteString("\tapp.Patch(\"/" + cfg.TableName + "/:id\", Update" + res + ")" + authSuffix + "\n")
-	buf.WriteString("\tapp.Delete(\"/" + cfg.TableName + "/:id\", SoftDelete" + res + ")" + authSuffix + "\n")
+	buf.WriteString("\tapp.Post(\"" + base + "\", Create" + res + ")" + suffix + "\n")
+	if cfg.Bulk {
+		buf.WriteString("\tapp.Post(\"" + base + "/bulk\", BulkCreate" + plural + ")" + suffix + "\n")
+	}
+	buf.WriteString("\tapp.Get(\"" + base + "\", List" + plural + ")" + suffix + "\n")
+	buf.WriteString("\tapp.Get(\"" + base + "/:id\", Get" + res + ")" + suffix + "\n")
+	buf.WriteString("\tapp.Patch(\"" + base + "/:id\", Update" + res + ")" + suffix + "\n")
+	buf.WriteString("\tapp.Delete(\"" + base + "/:id\", SoftDelete" + res + ")" + suffix + "\n")
+	if cfg.Restore {
+		buf.WriteString("\tapp.Post(\"" + base + "/:id/restore\", Restore" + res + ")" + suffix + "\n")
+	}
 
 	// Admin routes: list including deleted + undelete (always require auth)
-	if tableHasDeletedAt(cfg.Table) {
+	if tableHasDeletedAt(cfg.Table) && !cfg.NoSoftDelete {
 		buf.WriteString("\n\t// Admin routes (GLOBAL_OWNER only, includes soft-deleted records)\n")
-		buf.WriteString("\tapp.Get(\"/admin/" + cfg.TableName + "\", AdminList" + plural + ").Auth()\n")
-		buf.WriteString("\tapp.Patch(\"/admin/" + cfg.TableName + "/:id/restore\", Undelete" + res + ").Auth()\n")
+		buf.WriteString("\tapp.Get(\"/admin" + base + "\", AdminList" + plural + ").Auth()\n")
+		buf.WriteString("\tapp.Patch(\"/admin" + base + "/:id/restore\", Undelete" + res + ").Auth()\n")
 	}
 
 	buf.WriteString("}\n")
@@ -1575,7 +2042,7 @@ func isResponseExcluded(name string) bool {
 // Timestamps become strings (RFC3339 formatted).
 // FK reference columns are resolved to the referenced row's public_id (string).
 func responseFieldType(col ddl.ColumnDefinition) string {
-	if col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType {
+	if ddl.IsTimeType(col.Type) {
 		return "string"
 	}
 	// FK reference columns are resolved to the referenced row's public_id (string)
@@ -1598,19 +2065,49 @@ func tableHasJSONColumn(table ddl.Table) bool {
 	return false
 }
 
+// writeCustomTypeImports writes the import paths of the Go types of the
+// table's custom type columns.
+func writeCustomTypeImports(buf *bytes.Buffer, table ddl.Table) {
+	seen := make(map[string]bool)
+	for _, col := range table.Columns {
+		if col.Custom == nil || col.Custom.GoImport == "" || seen[col.Custom.GoImport] {
+			continue
+		}
+		seen[col.Custom.GoImport] = true
+		buf.WriteString(fmt.Sprintf("\t%q\n", col.Custom.GoImport))
+	}
+}
+
 // hasNullableTime checks if a table has nullable time columns.
 func hasNullableTime(table ddl.Table) bool {
 	for _, col := range table.Columns {
-		if (col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType) && col.Nullable {
+		if ddl.IsTimeType(col.Type) && col.Nullable {
 			return true
 		}
 	}
 	return false
 }
 
+// dropUnusedImport removes the import of path from src when nothing after
+// the import block refers to its package. Handlers whose error paths depend
+// on the table import httperror unconditionally and drop it here.
+func dropUnusedImport(src []byte, path string) []byte {
+	line := []byte("\t\"" + path + "\"\n")
+	start := bytes.Index(src, line)
+	if start < 0 {
+		return src
+	}
+	end := bytes.Index(src[start:], []byte("\n)\n"))
+	name := path[strings.LastIndex(path, "/")+1:]
+	if end < 0 || bytes.Contains(src[start+end:], []byte(name+".")) {
+		return src
+	}
+	return append(src[:start:start], src[start+len(line):]...)
+}
+
 // formatSource formats Go source code, returning the original if formatting fails.
 func formatSource(src []byte) ([]byte, error) {
-	formatted, err := format.Source(src)
+	formatted, err := phase.FormatSource(src)
 	if err != nil {
 		return src, fmt.Errorf("failed to format source: %w\n%s", err, src)
 	}
@@ -0,0 +1,77 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// Lifecycle hook events, each with a Before and an After method on the
+// event's hooks interface, e.g. PostCreateHooks.BeforeCreate.
+const (
+	hookCreate = "Create"
+	hookUpdate = "Update"
+	hookDelete = "Delete"
+)
+
+// hooksInterface returns the name of the interface of the event's hooks,
+// e.g. "PostCreateHooks".
+func hooksInterface(cfg HandlerGenConfig, event string) string {
+	return codegen.CRUD.ResourceName(cfg.TableName) + event + "Hooks"
+}
+
+// writeHooksInterface emits, for a table with hooks = true, the interface
+// of the event's hooks. before and after are the types of the values the
+// Before and After methods receive. Each handler file declares its own
+// interface so a resource generated with only some of the handlers still
+// compiles.
+func writeHooksInterface(buf *bytes.Buffer, cfg HandlerGenConfig, event, before, after string) {
+	if !cfg.Hooks {
+		return
+	}
+	name := hooksInterface(cfg, event)
+	participle := map[string]string{hookCreate: "created", hookUpdate: "updated", hookDelete: "deleted"}[event]
+	fmt.Fprintf(buf, `// %s are the hooks, registered with RegisterHooks, run when
+// a %s is %s. Before%s runs once the request is validated and
+// may change it; After%s runs once the write succeeded. An error from
+// either is returned to the client instead of the response.
+type %s interface {
+	Before%s(ctx context.Context, req *%s) error
+	After%s(ctx context.Context, %s) error
+}
+
+`, name, toSingular(cfg.TableName), participle, event, event, name, event, before, event, after)
+}
+
+// writeHookCall emits, for a table with hooks = true, the call of the
+// registered hooks' method (e.g. "BeforeCreate") with arg, returning its
+// error from the handler.
+func writeHookCall(buf *bytes.Buffer, cfg HandlerGenConfig, event, method, arg string) {
+	if !cfg.Hooks {
+		return
+	}
+	fmt.Fprintf(buf, "\tif h, ok := registeredHooks.(%s); ok {\n", hooksInterface(cfg, event))
+	fmt.Fprintf(buf, "\t\tif err := h.%s(ctx, %s); err != nil {\n", method, arg)
+	buf.WriteString("\t\t\treturn nil, err\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n\n")
+}
+
+// writeHooksRegistry emits registeredHooks and RegisterHooks, which the
+// application calls to attach business logic to the generated handlers.
+func writeHooksRegistry(buf *bytes.Buffer, cfg HandlerGenConfig) {
+	res := codegen.CRUD.ResourceName(cfg.TableName)
+	fmt.Fprintf(buf, `
+// registeredHooks holds the hooks attached with RegisterHooks.
+var registeredHooks any
+
+// RegisterHooks attaches h to the generated %s handlers, which call the
+// hooks of whichever of %[2]sCreateHooks, %[2]sUpdateHooks and
+// %[2]sDeleteHooks h implements. Call it once at startup, e.g. from an
+// init function in a file of this package that shipq does not generate.
+func RegisterHooks(h any) {
+	registeredHooks = h
+}
+`, toSingular(cfg.TableName), res)
+}
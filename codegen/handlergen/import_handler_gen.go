@@ -0,0 +1,603 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+const (
+	// DefaultImportMaxRows is the row limit baked into generated import
+	// handlers when [crud.<table>] import_max_rows is not set.
+	DefaultImportMaxRows = 10000
+
+	// importBatchSize is the number of rows inserted per transaction by
+	// generated import handlers.
+	importBatchSize = 500
+)
+
+// importColumns returns the columns accepted by the import endpoint: the same
+// set the create request accepts.
+func importColumns(cfg HandlerGenConfig) []ddl.ColumnDefinition {
+	var cols []ddl.ColumnDefinition
+	for _, col := range cfg.Table.Columns {
+		if isAutoColumn(col.Name) {
+			continue
+		}
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// GenerateImportHandler generates api/<table>/import.go, a bulk import
+// endpoint (POST /<table>/import) that reads a raw text/csv or
+// application/x-ndjson body row by row, validates every row, inserts the
+// valid ones in batches as they are read and reports per-row failures.
+//
+// The parsing and insert logic lives in Import<Plural>From, which takes an
+// io.Reader so that very large files can be imported from a worker instead
+// of a single HTTP request (see GenerateImportJob).
+func GenerateImportHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error) {
+	var buf bytes.Buffer
+	res := codegen.CRUD.ResourceName(cfg.TableName)
+	plural := codegen.CRUD.PluralResourceName(cfg.TableName)
+	singular := toSingular(cfg.TableName)
+	pkgName := cfg.TableName
+	hasAuthor := TableHasAuthorAccountID(cfg.Table) && !AuthorJoinConflictsWithFK(cfg.Table)
+	cols := importColumns(cfg)
+	if err := validateColumnRoles(cfg); err != nil {
+		return nil, err
+	}
+	writeCols := writeRestrictedColumns(cfg)
+
+	maxRows := cfg.ImportMaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultImportMaxRows
+	}
+
+	hasPublicID := false
+	for _, col := range cfg.Table.Columns {
+		if col.Name == "public_id" {
+			hasPublicID = true
+			break
+		}
+	}
+
+	needsStrconv, needsTime, needsBase64, needsSlices := false, false, false, false
+	for _, col := range cols {
+		if col.References != "" {
+			continue
+		}
+		switch col.Type {
+		case ddl.EnumType:
+			needsSlices = true
+		case ddl.IntegerType, ddl.BigintType, ddl.FloatType, ddl.BooleanType, ddl.DecimalType:
+			needsStrconv = true
+		case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
+			needsTime = true
+		case ddl.BinaryType:
+			needsBase64 = true
+		}
+	}
+
+	rowType := "Import" + plural + "Row"
+	rowErrType := "Import" + plural + "RowError"
+	respType := "Import" + plural + "Response"
+	pendingType := "import" + plural + "Pending"
+	maxConst := "Import" + plural + "MaxRows"
+	batchConst := "import" + plural + "BatchSize"
+
+	buf.WriteString(generatedFileHeader)
+	buf.WriteString("package " + pkgName + "\n\n")
+
+	// Imports
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"bufio\"\n")
+	buf.WriteString("\t\"bytes\"\n")
+	buf.WriteString("\t\"context\"\n")
+	if needsBase64 {
+		buf.WriteString("\t\"encoding/base64\"\n")
+	}
+	buf.WriteString("\t\"encoding/csv\"\n")
+	buf.WriteString("\t\"encoding/json\"\n")
+	buf.WriteString("\t\"errors\"\n")
+	buf.WriteString("\t\"io\"\n")
+	if needsSlices {
+		buf.WriteString("\t\"slices\"\n")
+	}
+	buf.WriteString("\t\"sort\"\n")
+	if needsStrconv {
+		buf.WriteString("\t\"strconv\"\n")
+	}
+	buf.WriteString("\t\"strings\"\n")
+	if needsTime {
+		buf.WriteString("\t\"time\"\n")
+	}
+	buf.WriteString("\n")
+	writeCustomTypeImports(&buf, cfg.Table)
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
+	if cfg.ScopeColumn != "" || hasAuthor || len(writeCols) > 0 {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
+	}
+	if hasPublicID {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/nanoid\"\n")
+	}
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
+	buf.WriteString(")\n\n")
+
+	// Limits
+	buf.WriteString("const (\n")
+	buf.WriteString(fmt.Sprintf("\t// %s is the maximum number of data rows accepted by a single\n", maxConst))
+	buf.WriteString("\t// import request.\n")
+	buf.WriteString(fmt.Sprintf("\t%s = %d\n\n", maxConst, maxRows))
+	buf.WriteString(fmt.Sprintf("\t// %s is the number of rows inserted per transaction.\n", batchConst))
+	buf.WriteString(fmt.Sprintf("\t%s = %d\n", batchConst, importBatchSize))
+	buf.WriteString(")\n\n")
+
+	// Request / response types
+	buf.WriteString(fmt.Sprintf("// Import%sRequest is a request to import %s in bulk. The rows are the\n", plural, cfg.TableName))
+	buf.WriteString("// raw request body: text/csv, whose first line is a header naming the\n")
+	buf.WriteString("// columns, or application/x-ndjson, one JSON object per line.\n")
+	buf.WriteString(fmt.Sprintf("type Import%sRequest struct {\n", plural))
+	buf.WriteString("\tDryRun bool `query:\"dry_run\"`\n\n")
+	buf.WriteString("\tmediaType string\n")
+	buf.WriteString("\tbody      io.Reader\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("// RawBodyTypes lists the media types whose bodies are handed to SetRawBody\n")
+	buf.WriteString("// unread instead of being decoded into the request.\n")
+	buf.WriteString(fmt.Sprintf("func (*Import%sRequest) RawBodyTypes() []string {\n", plural))
+	buf.WriteString("\treturn []string{\"text/csv\", \"application/x-ndjson\"}\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("// SetRawBody stores the request body, read while the rows are imported.\n")
+	buf.WriteString(fmt.Sprintf("func (req *Import%sRequest) SetRawBody(mediaType string, body io.Reader) {\n", plural))
+	buf.WriteString("\treq.mediaType, req.body = mediaType, body\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s describes why a row was not imported. Row is the 1-based\n", rowErrType))
+	buf.WriteString("// index of the data row (the CSV header is not counted).\n")
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", rowErrType))
+	buf.WriteString("\tRow   int    `json:\"row\"`\n")
+	buf.WriteString("\tField string `json:\"field,omitempty\"`\n")
+	buf.WriteString("\tError string `json:\"error\"`\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s summarises an import. In a dry run Imported counts the rows\n", respType))
+	buf.WriteString("// that passed validation; nothing is written.\n")
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", respType))
+	buf.WriteString("\tTotal    int  `json:\"total\"`\n")
+	buf.WriteString("\tImported int  `json:\"imported\"`\n")
+	buf.WriteString("\tFailed   int  `json:\"failed\"`\n")
+	fmt.Fprintf(&buf, "\tDryRun   bool `json:%q`\n", jsonFieldName(cfg, "dry_run"))
+	buf.WriteString(fmt.Sprintf("\tErrors   []%s `json:\"errors\"`\n", rowErrType))
+	buf.WriteString("}\n\n")
+
+	// Row type mirrors the create request
+	buf.WriteString(fmt.Sprintf("// %s is a single imported %s. It accepts the same fields as\n", rowType, singular))
+	buf.WriteString(fmt.Sprintf("// Create%sRequest.\n", res))
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", rowType))
+	for _, col := range cols {
+		jsonTag := columnJSONName(cfg, col.Name)
+		if col.Nullable {
+			jsonTag += ",omitempty"
+		}
+		buf.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"`\n", toPascalCase(col.Name), goRequestTypeForColumn(col), jsonTag))
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", pendingType))
+	buf.WriteString("\tline int\n")
+	buf.WriteString(fmt.Sprintf("\trow  %s\n", rowType))
+	buf.WriteString("}\n\n")
+
+	// Handler
+	buf.WriteString(fmt.Sprintf("// Import%s handles POST %s/import\n", plural, cfg.routePath()))
+	buf.WriteString(fmt.Sprintf("func Import%s(ctx context.Context, req *Import%sRequest) (*%s, error) {\n", plural, plural, respType))
+	buf.WriteString("\tif req.body == nil {\n")
+	buf.WriteString("\t\treturn nil, httperror.Newf(415, \"send the rows as text/csv or application/x-ndjson\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tformat := \"csv\"\n")
+	buf.WriteString("\tif req.mediaType == \"application/x-ndjson\" {\n")
+	buf.WriteString("\t\tformat = \"ndjson\"\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\treturn Import%sFrom(ctx, format, req.body, req.DryRun, %s)\n", plural, maxConst))
+	buf.WriteString("}\n\n")
+
+	// Core import function
+	buf.WriteString(fmt.Sprintf("// Import%sFrom reads r row by row, validates every row and inserts the\n", plural))
+	buf.WriteString(fmt.Sprintf("// valid ones in batches of %s as soon as a batch fills up, so\n", batchConst))
+	buf.WriteString("// only one batch is held in memory. Rows that fail validation or insertion\n")
+	buf.WriteString("// are reported in the response; they do not abort the import. Reading a row\n")
+	buf.WriteString("// past maxRows stops the import with a 413, leaving the batches inserted so\n")
+	buf.WriteString("// far in place; pass 0 to disable the limit when importing large files\n")
+	buf.WriteString("// from a worker. When a batch's transaction cannot begin, no further\n")
+	buf.WriteString("// batches are inserted and the import fails with a 500.\n")
+	buf.WriteString(fmt.Sprintf("func Import%sFrom(ctx context.Context, format string, r io.Reader, dryRun bool, maxRows int) (*%s, error) {\n", plural, respType))
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
+		buf.WriteString("\tif !ok {\n")
+		buf.WriteString("\t\treturn nil, httperror.Wrap(403, \"organization context missing\", nil)\n")
+		buf.WriteString("\t}\n\n")
+	}
+	if hasAuthor {
+		// Workers calling Import<Plural>From must inject the author with
+		// httputil.WithSessionAccountID, so report a missing ID instead of
+		// importing rows owned by account 0.
+		buf.WriteString("\taccountID, ok := httputil.SessionAccountIDFromContext(ctx)\n")
+		buf.WriteString("\tif !ok {\n")
+		buf.WriteString("\t\treturn nil, httperror.Wrap(401, \"no authenticated account in context\", nil)\n")
+		buf.WriteString("\t}\n\n")
+	}
+	if len(writeCols) > 0 {
+		writeCallerRoles(&buf)
+	}
+	buf.WriteString(fmt.Sprintf("\tresp := &%s{DryRun: dryRun, Errors: []%s{}}\n", respType, rowErrType))
+	buf.WriteString(fmt.Sprintf("\tcreate := func(r queries.Runner, row %s) error {\n", rowType))
+	createMethod := codegen.CRUD.CreateMethodName(cfg.TableName)
+	createParamsType := codegen.CRUD.CreateParamsType(cfg.TableName)
+	buf.WriteString(fmt.Sprintf("\t\t_, err := r.%s(ctx, queries.%s{\n", createMethod, createParamsType))
+	if hasPublicID {
+		buf.WriteString("\t\t\tPublicId: nanoid.New(),\n")
+	}
+	if hasAuthor {
+		buf.WriteString("\t\t\tAuthorAccountId: accountID,\n")
+	}
+	for _, col := range cfg.Table.Columns {
+		if isAutoColumn(col.Name) {
+			continue
+		}
+		fieldName := toPascalCase(col.Name)
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			buf.WriteString(fmt.Sprintf("\t\t\t%s: orgID,\n", fieldName))
+		} else {
+			buf.WriteString(fmt.Sprintf("\t\t\t%s: row.%s,\n", fieldName, fieldName))
+		}
+	}
+	buf.WriteString("\t\t})\n")
+	buf.WriteString("\t\treturn err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\tbatch := make([]%s, 0, %s)\n", pendingType, batchConst))
+	buf.WriteString("\tvar insertErr error\n")
+	buf.WriteString("\tflush := func() {\n")
+	buf.WriteString("\t\tif dryRun {\n")
+	buf.WriteString("\t\t\tresp.Imported += len(batch)\n")
+	buf.WriteString("\t\t} else if len(batch) > 0 && insertErr == nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\t\tinsertErr = insert%sBatch(ctx, runner, batch, create, resp)\n", plural))
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tbatch = batch[:0]\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\tadd := func(p %s) {\n", pendingType))
+	// Column-level write roles apply per row, like any other validation error
+	if len(writeCols) > 0 {
+		buf.WriteString("\t\tdenied := \"\"\n")
+		buf.WriteString("\t\tswitch {\n")
+		for _, col := range writeCols {
+			set := requestFieldSetExpr(goRequestTypeForColumn(col), "p.row."+toPascalCase(col.Name))
+			buf.WriteString(fmt.Sprintf("\t\tcase %s && !httputil.RolesAllow(roles, %s):\n", set, quotedRoles(col.WriteRoles)))
+			buf.WriteString(fmt.Sprintf("\t\t\tdenied = %q\n", columnJSONName(cfg, col.Name)))
+		}
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t\tif denied != \"\" {\n")
+		buf.WriteString(fmt.Sprintf("\t\t\tresp.Errors = append(resp.Errors, %s{Row: p.line, Field: denied, Error: \"not allowed to set \" + denied})\n", rowErrType))
+		buf.WriteString("\t\t\treturn\n")
+		buf.WriteString("\t\t}\n")
+	}
+	buf.WriteString("\t\tif batch = append(batch, p); len(batch) == cap(batch) {\n")
+	buf.WriteString("\t\t\tflush()\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\tvar err error\n")
+	buf.WriteString("\tswitch strings.ToLower(format) {\n")
+	buf.WriteString("\tcase \"\", \"csv\":\n")
+	buf.WriteString(fmt.Sprintf("\t\terr = parse%sCSV(r, maxRows, resp, add)\n", plural))
+	buf.WriteString("\tcase \"ndjson\":\n")
+	buf.WriteString(fmt.Sprintf("\t\terr = parse%sNDJSON(r, maxRows, resp, add)\n", plural))
+	buf.WriteString("\tdefault:\n")
+	buf.WriteString("\t\treturn nil, httperror.BadRequestf(\"unsupported import format %q (use csv or ndjson)\", format)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tflush()\n")
+	buf.WriteString("\tif insertErr != nil {\n")
+	buf.WriteString("\t\treturn nil, insertErr\n")
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\tsort.SliceStable(resp.Errors, func(i, j int) bool { return resp.Errors[i].Row < resp.Errors[j].Row })\n")
+	buf.WriteString("\tresp.Failed = len(resp.Errors)\n")
+	buf.WriteString("\treturn resp, nil\n")
+	buf.WriteString("}\n\n")
+
+	// Batch insert
+	buf.WriteString(fmt.Sprintf("// insert%sBatch inserts batch in one transaction. If the runner is already\n", plural))
+	buf.WriteString("// transactional, or any row in the batch fails, the rows are inserted one at\n")
+	buf.WriteString("// a time instead so that each failure is attributed to its row. It only\n")
+	buf.WriteString("// returns an error when it cannot begin the transaction.\n")
+	buf.WriteString(fmt.Sprintf("func insert%sBatch(ctx context.Context, runner queries.Runner, batch []%s, create func(queries.Runner, %s) error, resp *%s) error {\n", plural, pendingType, rowType, respType))
+	writeBeginTx(&buf, "\t", "txRunner", "")
+	buf.WriteString("\t\tfailed := false\n")
+	buf.WriteString("\t\tfor _, p := range batch {\n")
+	buf.WriteString("\t\t\tif err := create(txRunner, p.row); err != nil {\n")
+	buf.WriteString("\t\t\t\tfailed = true\n")
+	buf.WriteString("\t\t\t\tbreak\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif !failed && txRunner.Commit() == nil {\n")
+	buf.WriteString("\t\t\tresp.Imported += len(batch)\n")
+	buf.WriteString("\t\t\treturn nil\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\ttxRunner.Rollback()\n")
+	buf.WriteString("\t}\n\n")
+	buf.WriteString("\tfor _, p := range batch {\n")
+	buf.WriteString("\t\tif err := create(runner, p.row); err != nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\t\tresp.Errors = append(resp.Errors, %s{Row: p.line, Error: classifyDBError(err, \"import %s\").Message()})\n", rowErrType, singular))
+	buf.WriteString("\t\t\tcontinue\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tresp.Imported++\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn nil\n")
+	buf.WriteString("}\n\n")
+
+	// CSV parsing
+	buf.WriteString(fmt.Sprintf("// parse%sCSV reads a header row followed by data rows. Columns are matched\n", plural))
+	buf.WriteString("// by header name, so their order does not matter and nullable columns may be\n")
+	buf.WriteString("// omitted. Every valid row is passed to add as soon as it is read.\n")
+	buf.WriteString(fmt.Sprintf("func parse%sCSV(r io.Reader, maxRows int, resp *%s, add func(%s)) error {\n", plural, respType, pendingType))
+	buf.WriteString("\tcr := csv.NewReader(r)\n")
+	buf.WriteString("\tcr.ReuseRecord = true\n")
+	buf.WriteString("\theader, err := cr.Read()\n")
+	buf.WriteString("\tif errors.Is(err, io.EOF) {\n")
+	buf.WriteString("\t\treturn nil\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn httperror.BadRequestf(\"invalid CSV header: %v\", err)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tindex := make(map[string]int, len(header))\n")
+	buf.WriteString("\tfor i, name := range header {\n")
+	buf.WriteString("\t\tindex[strings.TrimSpace(name)] = i\n")
+	buf.WriteString("\t}\n")
+	var required []string
+	for _, col := range cols {
+		if !col.Nullable {
+			required = append(required, fmt.Sprintf("%q", col.Name))
+		}
+	}
+	if len(required) > 0 {
+		buf.WriteString("\tfor _, name := range []string{" + strings.Join(required, ", ") + "} {\n")
+		buf.WriteString("\t\tif _, ok := index[name]; !ok {\n")
+		buf.WriteString("\t\t\treturn httperror.BadRequestf(\"CSV header is missing column %q\", name)\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\n")
+	buf.WriteString("\tfor line := 1; ; line++ {\n")
+	buf.WriteString("\t\trecord, err := cr.Read()\n")
+	buf.WriteString("\t\tif errors.Is(err, io.EOF) {\n")
+	buf.WriteString("\t\t\tbreak\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tresp.Total++\n")
+	buf.WriteString("\t\tif maxRows > 0 && resp.Total > maxRows {\n")
+	buf.WriteString("\t\t\treturn httperror.Newf(413, \"import exceeds the maximum of %d rows\", maxRows)\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif err != nil {\n")
+	buf.WriteString("\t\t\tvar parseErr *csv.ParseError\n")
+	buf.WriteString("\t\t\tif !errors.As(err, &parseErr) {\n")
+	buf.WriteString("\t\t\t\treturn httperror.BadRequestf(\"failed to read CSV: %v\", err)\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString(fmt.Sprintf("\t\t\tresp.Errors = append(resp.Errors, %s{Row: line, Error: parseErr.Err.Error()})\n", rowErrType))
+	buf.WriteString("\t\t\tcontinue\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tfield := func(name string) (string, bool) {\n")
+	buf.WriteString("\t\t\ti, ok := index[name]\n")
+	buf.WriteString("\t\t\tif !ok || i >= len(record) {\n")
+	buf.WriteString("\t\t\t\treturn \"\", false\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t\treturn record[i], true\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString(fmt.Sprintf("\t\trow, rowErr := convert%sCSVRecord(field)\n", plural))
+	buf.WriteString("\t\tif rowErr != nil {\n")
+	buf.WriteString("\t\t\trowErr.Row = line\n")
+	buf.WriteString("\t\t\tresp.Errors = append(resp.Errors, *rowErr)\n")
+	buf.WriteString("\t\t\tcontinue\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString(fmt.Sprintf("\t\tadd(%s{line: line, row: row})\n", pendingType))
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn nil\n")
+	buf.WriteString("}\n\n")
+
+	// CSV record conversion
+	buf.WriteString(fmt.Sprintf("// convert%sCSVRecord converts the text fields of a CSV record to typed\n", plural))
+	buf.WriteString("// values. Empty fields are treated as absent.\n")
+	buf.WriteString(fmt.Sprintf("func convert%sCSVRecord(field func(string) (string, bool)) (%s, *%s) {\n", plural, rowType, rowErrType))
+	buf.WriteString(fmt.Sprintf("\tvar row %s\n", rowType))
+	for _, col := range cols {
+		writeImportFieldConversion(&buf, col, rowErrType)
+	}
+	buf.WriteString("\treturn row, nil\n")
+	buf.WriteString("}\n\n")
+
+	// NDJSON parsing
+	buf.WriteString(fmt.Sprintf("// parse%sNDJSON reads one JSON object per line, passing every valid row to\n", plural))
+	buf.WriteString("// add as soon as it is read. Blank lines are skipped.\n")
+	buf.WriteString(fmt.Sprintf("func parse%sNDJSON(r io.Reader, maxRows int, resp *%s, add func(%s)) error {\n", plural, respType, pendingType))
+	buf.WriteString("\tbr := bufio.NewReader(r)\n")
+	buf.WriteString("\tfor line := 0; ; {\n")
+	buf.WriteString("\t\traw, readErr := br.ReadBytes('\\n')\n")
+	buf.WriteString("\t\tif readErr != nil && !errors.Is(readErr, io.EOF) {\n")
+	buf.WriteString("\t\t\treturn httperror.BadRequestf(\"failed to read NDJSON: %v\", readErr)\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif raw = bytes.TrimSpace(raw); len(raw) > 0 {\n")
+	buf.WriteString("\t\t\tline++\n")
+	buf.WriteString("\t\t\tresp.Total++\n")
+	buf.WriteString("\t\t\tif maxRows > 0 && resp.Total > maxRows {\n")
+	buf.WriteString("\t\t\t\treturn httperror.Newf(413, \"import exceeds the maximum of %d rows\", maxRows)\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString(fmt.Sprintf("\t\t\tvar row %s\n", rowType))
+	buf.WriteString("\t\t\tdec := json.NewDecoder(bytes.NewReader(raw))\n")
+	buf.WriteString("\t\t\tdec.DisallowUnknownFields()\n")
+	buf.WriteString("\t\t\tif err := dec.Decode(&row); err != nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\t\t\tresp.Errors = append(resp.Errors, %s{Row: line, Error: err.Error()})\n", rowErrType))
+	for _, col := range cols {
+		if col.References == "" || col.Nullable {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("\t\t\t} else if row.%s == \"\" {\n", toPascalCase(col.Name)))
+		buf.WriteString(fmt.Sprintf("\t\t\t\tresp.Errors = append(resp.Errors, %s{Row: line, Field: %q, Error: \"is required\"})\n", rowErrType, col.Name))
+	}
+	for _, col := range cols {
+		if !ddl.IsSpatialType(col.Type) {
+			continue
+		}
+		arg, _ := pointArgExpr(goRequestTypeForColumn(col), "row."+toPascalCase(col.Name))
+		buf.WriteString(fmt.Sprintf("\t\t\t} else if %s(%q, %s) != nil {\n", spatialNormalizer(col), col.Name, arg))
+		buf.WriteString(fmt.Sprintf("\t\t\t\tresp.Errors = append(resp.Errors, %s{Row: line, Field: %q, Error: %q})\n", rowErrType, col.Name, spatialFormatError(col)))
+	}
+	for _, col := range cols {
+		if col.Type != ddl.EnumType || col.References != "" {
+			continue
+		}
+		value := "row." + toPascalCase(col.Name)
+		if col.Nullable {
+			buf.WriteString(fmt.Sprintf("\t\t\t} else if %s != nil && !slices.Contains(%s, *%s) {\n", value, enumValuesLiteral(col), value))
+		} else {
+			buf.WriteString(fmt.Sprintf("\t\t\t} else if !slices.Contains(%s, %s) {\n", enumValuesLiteral(col), value))
+		}
+		buf.WriteString(fmt.Sprintf("\t\t\t\tresp.Errors = append(resp.Errors, %s{Row: line, Field: %q, Error: %q})\n", rowErrType, col.Name, enumValuesError(col)))
+	}
+	buf.WriteString("\t\t\t} else {\n")
+	buf.WriteString(fmt.Sprintf("\t\t\t\tadd(%s{line: line, row: row})\n", pendingType))
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif readErr != nil {\n")
+	buf.WriteString("\t\t\treturn nil\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
+
+// enumValuesLiteral returns a []string literal of the values of the enum
+// column col.
+func enumValuesLiteral(col ddl.ColumnDefinition) string {
+	values := make([]string, len(col.EnumValues))
+	for i, value := range col.EnumValues {
+		values[i] = strconv.Quote(value)
+	}
+	return "[]string{" + strings.Join(values, ", ") + "}"
+}
+
+// enumValuesError is the row error of a value outside the enum column col.
+func enumValuesError(col ddl.ColumnDefinition) string {
+	return "must be one of " + strings.Join(col.EnumValues, ", ")
+}
+
+// writeImportFieldConversion emits the code that parses one CSV field into
+// the corresponding ImportRow field, returning a row error when the value is
+// missing or malformed.
+func writeImportFieldConversion(buf *bytes.Buffer, col ddl.ColumnDefinition, rowErrType string) {
+	fieldName := toPascalCase(col.Name)
+	fail := func(msg string) string {
+		return fmt.Sprintf("return row, &%s{Field: %q, Error: %q}", rowErrType, col.Name, msg)
+	}
+
+	// Non-nullable text columns accept empty strings, like the create endpoint.
+	isText := col.References == "" && goBaseTypeForColumn(col) == "string" && col.Type != ddl.DecimalType && !ddl.IsSpatialType(col.Type) && col.Type != ddl.EnumType
+	if isText && !col.Nullable {
+		buf.WriteString(fmt.Sprintf("\tif v, ok := field(%q); ok {\n", col.Name))
+		buf.WriteString(fmt.Sprintf("\t\trow.%s = v\n", fieldName))
+		buf.WriteString("\t}\n")
+		return
+	}
+
+	buf.WriteString(fmt.Sprintf("\tif v, ok := field(%q); ok && v != \"\" {\n", col.Name))
+	value := "v"
+	if col.References == "" && col.Custom != nil && col.Custom.GoType != "string" {
+		// Custom types decode the field as a JSON string, the same way
+		// the create endpoint receives them.
+		buf.WriteString(fmt.Sprintf("\t\tvar x %s\n", col.Custom.GoType))
+		buf.WriteString("\t\tquoted, _ := json.Marshal(v)\n")
+		buf.WriteString("\t\tif err := json.Unmarshal(quoted, &x); err != nil {\n")
+		buf.WriteString("\t\t\t" + fail("is not a valid "+col.Custom.Name) + "\n")
+		buf.WriteString("\t\t}\n")
+		value = "x"
+	} else if col.References == "" {
+		switch col.Type {
+		case ddl.IntegerType:
+			buf.WriteString("\t\tn, err := strconv.ParseInt(v, 10, 32)\n")
+			buf.WriteString("\t\tif err != nil {\n")
+			buf.WriteString("\t\t\t" + fail("must be an integer") + "\n")
+			buf.WriteString("\t\t}\n")
+			buf.WriteString("\t\tx := int32(n)\n")
+			value = "x"
+		case ddl.BigintType:
+			buf.WriteString("\t\tx, err := strconv.ParseInt(v, 10, 64)\n")
+			buf.WriteString("\t\tif err != nil {\n")
+			buf.WriteString("\t\t\t" + fail("must be an integer") + "\n")
+			buf.WriteString("\t\t}\n")
+			value = "x"
+		case ddl.FloatType:
+			buf.WriteString("\t\tx, err := strconv.ParseFloat(v, 64)\n")
+			buf.WriteString("\t\tif err != nil {\n")
+			buf.WriteString("\t\t\t" + fail("must be a number") + "\n")
+			buf.WriteString("\t\t}\n")
+			value = "x"
+		case ddl.DecimalType:
+			buf.WriteString("\t\tif _, err := strconv.ParseFloat(v, 64); err != nil {\n")
+			buf.WriteString("\t\t\t" + fail("must be a decimal number") + "\n")
+			buf.WriteString("\t\t}\n")
+		case ddl.BooleanType:
+			buf.WriteString("\t\tx, err := strconv.ParseBool(v)\n")
+			buf.WriteString("\t\tif err != nil {\n")
+			buf.WriteString("\t\t\t" + fail("must be true or false") + "\n")
+			buf.WriteString("\t\t}\n")
+			value = "x"
+		case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
+			buf.WriteString("\t\tx, err := time.Parse(time.RFC3339, v)\n")
+			buf.WriteString("\t\tif err != nil {\n")
+			buf.WriteString("\t\t\t" + fail("must be an RFC 3339 timestamp") + "\n")
+			buf.WriteString("\t\t}\n")
+			value = "x"
+		case ddl.BinaryType:
+			buf.WriteString("\t\tx, err := base64.StdEncoding.DecodeString(v)\n")
+			buf.WriteString("\t\tif err != nil {\n")
+			buf.WriteString("\t\t\t" + fail("must be base64-encoded") + "\n")
+			buf.WriteString("\t\t}\n")
+			value = "x"
+		case ddl.EnumType:
+			buf.WriteString(fmt.Sprintf("\t\tif !slices.Contains(%s, v) {\n", enumValuesLiteral(col)))
+			buf.WriteString("\t\t\t" + fail(enumValuesError(col)) + "\n")
+			buf.WriteString("\t\t}\n")
+		case ddl.PointType, ddl.PolygonType:
+			buf.WriteString(fmt.Sprintf("\t\tif %s(%q, &v) != nil {\n", spatialNormalizer(col), col.Name))
+			buf.WriteString("\t\t\t" + fail(spatialFormatError(col)) + "\n")
+			buf.WriteString("\t\t}\n")
+		case ddl.JSONType:
+			buf.WriteString("\t\tif !json.Valid([]byte(v)) {\n")
+			buf.WriteString("\t\t\t" + fail("must be valid JSON") + "\n")
+			buf.WriteString("\t\t}\n")
+			buf.WriteString("\t\tx := json.RawMessage(v)\n")
+			value = "x"
+		}
+	}
+	if col.Nullable {
+		buf.WriteString(fmt.Sprintf("\t\trow.%s = &%s\n", fieldName, value))
+	} else {
+		buf.WriteString(fmt.Sprintf("\t\trow.%s = %s\n", fieldName, value))
+	}
+	if col.Nullable {
+		buf.WriteString("\t}\n")
+		return
+	}
+	buf.WriteString("\t} else {\n")
+	buf.WriteString("\t\t" + fail("is required") + "\n")
+	buf.WriteString("\t}\n")
+}
@@ -0,0 +1,325 @@
+package handlergen
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/crudquerydefs"
+	"github.com/shipq/shipq/codegen/gentest"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+)
+
+// scopedPostColumns are the columns of an organization-scoped "posts" table
+// with one column of each type the import handler converts.
+var scopedPostColumns = []ddl.ColumnDefinition{
+	{Name: "organization_id", Type: ddl.BigintType, References: "organizations"},
+	{Name: "title", Type: ddl.StringType},
+	{Name: "summary", Type: ddl.TextType, Nullable: true},
+	{Name: "views", Type: ddl.IntegerType},
+	{Name: "price", Type: ddl.DecimalType},
+	{Name: "published_at", Type: ddl.TimestampType, Nullable: true},
+	{Name: "category_id", Type: ddl.BigintType, References: "categories"},
+}
+
+func TestGenerateImportHandler(t *testing.T) {
+	cfg := testConfig("posts", scopedPostColumns...)
+	cfg.ScopeColumn = "organization_id"
+	result, err := GenerateImportHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := gofile.Parse(t, "import.go", result)
+
+	f.AssertSignature("ImportPosts", "func ImportPosts(ctx context.Context, req *ImportPostsRequest) (*ImportPostsResponse, error)")
+	f.AssertSignature("ImportPostsFrom", "func ImportPostsFrom(ctx context.Context, format string, r io.Reader, dryRun bool, maxRows int) (*ImportPostsResponse, error)")
+	if got := f.Value("ImportPostsMaxRows"); got != "10000" {
+		t.Errorf("ImportPostsMaxRows = %s, want 10000", got)
+	}
+	if got := f.Value("importPostsBatchSize"); got != "500" {
+		t.Errorf("importPostsBatchSize = %s, want 500", got)
+	}
+
+	// Rows are inserted in a transaction per batch, scoped to the caller.
+	f.AssertStmts("insertPostsBatch",
+		"if !queries.InTx(runner)",
+		"txRunner, err := runner.BeginTx(ctx)",
+		`return httperror.Wrap(500, "begin transaction", err)`,
+	)
+	f.AssertStmts("ImportPostsFrom",
+		"insertErr = insertPostsBatch(ctx, runner, batch, create, resp)",
+		"return nil, insertErr",
+	)
+	f.AssertExprs("ImportPostsFrom", "OrganizationId: orgID", "CategoryId: row.CategoryId")
+	if f.Calls("ImportPostsFrom", "r.CreatePost") != 1 {
+		t.Error("expected rows to be inserted through the batch runner")
+	}
+
+	// CSV headers must name every required column, and values are converted
+	// per column type.
+	f.AssertStmts("parsePostsCSV",
+		`for _, name := range []string{"title", "views", "price", "category_id"}`,
+		`return httperror.Newf(413, "import exceeds the maximum of %d rows", maxRows)`,
+	)
+	f.AssertStmts("convertPostsCSVRecord",
+		"n, err := strconv.ParseInt(v, 10, 32)",
+		"x, err := time.Parse(time.RFC3339, v)",
+		`return row, &ImportPostsRowError{Field: "views", Error: "is required"}`,
+	)
+	if f.HasStmt("convertPostsCSVRecord", `return row, &ImportPostsRowError{Field: "summary", Error: "is required"}`) {
+		t.Error("nullable columns must not be required")
+	}
+	f.AssertStmts("parsePostsNDJSON", "dec.DisallowUnknownFields()")
+
+	// The scope column comes from the request context, never from the file.
+	if _, _, ok := f.Field("ImportPostsRow", "OrganizationId"); ok {
+		t.Error("scope column must not be importable")
+	}
+	if f.HasExpr("", `field("organization_id")`) {
+		t.Error("scope column must not be read from the CSV")
+	}
+}
+
+func TestGenerateImportHandler_Enum(t *testing.T) {
+	cfg := testConfig("posts", append(scopedPostColumns,
+		ddl.ColumnDefinition{Name: "status", Type: ddl.EnumType, EnumValues: []string{"draft", "live"}})...)
+	cfg.ScopeColumn = "organization_id"
+	result, err := GenerateImportHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := gofile.Parse(t, "import.go", result)
+
+	if !f.HasImport("slices") {
+		t.Error("expected the slices import")
+	}
+	f.AssertStmts("convertPostsCSVRecord",
+		`if !slices.Contains([]string{"draft", "live"}, v)`,
+		`return row, &ImportPostsRowError{Field: "status", Error: "must be one of draft, live"}`,
+	)
+	f.AssertStmts("parsePostsNDJSON", `if !slices.Contains([]string{"draft", "live"}, row.Status)`)
+}
+
+func TestGenerateImportHandler_MaxRowsFromConfig(t *testing.T) {
+	cfg := testConfig("posts", scopedPostColumns...)
+	cfg.ImportMaxRows = 250
+
+	result, err := GenerateImportHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := gofile.Parse(t, "import.go", result).Value("ImportPostsMaxRows"); got != "250" {
+		t.Errorf("ImportPostsMaxRows = %s, want the configured 250", got)
+	}
+}
+
+func TestRegistrationForOp_Import(t *testing.T) {
+	reg := RegistrationForOp(OpImport, "posts", "", true)
+	if reg.Method != "Post" || reg.Path != "/posts/import" || reg.FuncName != "ImportPosts" || !reg.RequireAuth {
+		t.Errorf("unexpected import registration: %+v", reg)
+	}
+	for _, op := range AllOperations() {
+		if op == OpImport {
+			t.Error("import is opt-in and must not be part of AllOperations")
+		}
+	}
+}
+
+// importStreamTest is the test run inside the generated posts package: it
+// feeds the import handler a body through a pipe and checks that full
+// batches reach the database before the body ends, and that an import
+// fails when it cannot begin a batch's transaction.
+const importStreamTest = `package posts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"MODULE/shipq/lib/httperror"
+	"MODULE/shipq/queries"
+	"MODULE/shipq/queries/sqlite"
+)
+
+const schemaSQL = SCHEMA
+
+func openDB(t *testing.T) (*sql.DB, context.Context) {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		t.Fatal(err)
+	}
+	return db, queries.NewContextWithRunner(context.Background(), sqlite.NewQueryRunner(db))
+}
+
+func countPosts(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM posts").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestImportPostsStreamsBatches(t *testing.T) {
+	db, ctx := openDB(t)
+
+	pr, pw := io.Pipe()
+	req := &ImportPostsRequest{}
+	req.SetRawBody("text/csv", pr)
+	done := make(chan *ImportPostsResponse)
+	go func() {
+		resp, err := ImportPosts(ctx, req)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- resp
+	}()
+
+	fmt.Fprintln(pw, "title,views")
+	for i := 0; i < importPostsBatchSize; i++ {
+		fmt.Fprintf(pw, "post %d,%d\n", i, i)
+	}
+	fmt.Fprintln(pw, "bad,not-a-number")
+	// The first batch is inserted while the rest of the body is pending.
+	deadline := time.Now().Add(10 * time.Second)
+	for countPosts(t, db) != importPostsBatchSize {
+		if time.Now().After(deadline) {
+			t.Fatal("first batch was not inserted before the body ended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	fmt.Fprintln(pw, "last,1")
+	pw.Close()
+
+	resp := <-done
+	if resp == nil {
+		t.FailNow()
+	}
+	if resp.Total != importPostsBatchSize+2 || resp.Imported != importPostsBatchSize+1 || resp.Failed != 1 {
+		t.Errorf("resp = total %d, imported %d, failed %d", resp.Total, resp.Imported, resp.Failed)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Row != importPostsBatchSize+1 || resp.Errors[0].Field != "views" {
+		t.Errorf("errors = %+v", resp.Errors)
+	}
+	if n := countPosts(t, db); n != importPostsBatchSize+1 {
+		t.Errorf("posts = %d", n)
+	}
+}
+
+func TestImportPostsNDJSONDryRun(t *testing.T) {
+	db, ctx := openDB(t)
+
+	req := &ImportPostsRequest{DryRun: true}
+	req.SetRawBody("application/x-ndjson", strings.NewReader("{\"title\":\"a\",\"views\":1}\n\n{\"title\":\"b\",\"nope\":2}\n"))
+	resp, err := ImportPosts(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.DryRun || resp.Total != 2 || resp.Imported != 1 || resp.Failed != 1 {
+		t.Errorf("resp = %+v", resp)
+	}
+	if n := countPosts(t, db); n != 0 {
+		t.Errorf("a dry run inserted %d posts", n)
+	}
+}
+
+func TestImportPostsMaxRows(t *testing.T) {
+	_, ctx := openDB(t)
+
+	_, err := ImportPostsFrom(ctx, "csv", strings.NewReader("title,views\na,1\nb,2\nc,3\n"), false, 2)
+	if httpErr, ok := err.(*httperror.Error); !ok || httpErr.Code() != 413 {
+		t.Errorf("err = %v, want a 413", err)
+	}
+}
+
+func TestImportPostsBeginTxFails(t *testing.T) {
+	db, ctx := openDB(t)
+	ctx = queries.NewContextWithRunner(ctx, noTxRunner{queries.MustRunnerFromContext(ctx)})
+
+	_, err := ImportPostsFrom(ctx, "csv", strings.NewReader("title,views\na,1\n"), false, 0)
+	if httpErr, ok := err.(*httperror.Error); !ok || httpErr.Code() != 500 {
+		t.Errorf("err = %v, want a 500", err)
+	}
+	if n := countPosts(t, db); n != 0 {
+		t.Errorf("a failed BeginTx inserted %d posts", n)
+	}
+}
+
+// noTxRunner is a runner that cannot begin transactions.
+type noTxRunner struct{ queries.Runner }
+
+func (noTxRunner) BeginTx(context.Context) (*queries.TxRunner, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestImportPostsRequiresRawBody(t *testing.T) {
+	_, ctx := openDB(t)
+
+	_, err := ImportPosts(ctx, &ImportPostsRequest{})
+	if httpErr, ok := err.(*httperror.Error); !ok || httpErr.Code() != 415 {
+		t.Errorf("err = %v, want a 415", err)
+	}
+}
+`
+
+func TestImportHandler_StreamsRawBody(t *testing.T) {
+	plan := migrate.NewPlan()
+	if _, err := plan.AddTable("posts", func(tb *ddl.TableBuilder) error {
+		tb.String("title")
+		tb.Integer("views")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := gentest.New(t, plan)
+	code, err := crudquerydefs.GenerateCRUDQueryDefs(crudquerydefs.Config{
+		ModulePath: p.ModulePath,
+		TableName:  "posts",
+		Table:      plan.Schema.Tables["posts"],
+		Schema:     plan.Schema.Tables,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WriteFile("shipq/querydefs/posts/queries.go", code)
+	p.CompileQueries()
+
+	cfg := HandlerGenConfig{
+		ModulePath: p.ModulePath,
+		TableName:  "posts",
+		Table:      plan.Schema.Tables["posts"],
+		Schema:     plan.Schema.Tables,
+	}
+	files, err := GenerateHandlerFiles(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp, err := GenerateImportHandler(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WriteFile("api/posts/helpers.go", files["helpers.go"])
+	p.WriteFile("api/posts/import.go", imp)
+	p.WriteFile("api/posts/import_test.go", []byte(strings.NewReplacer(
+		"MODULE", p.ModulePath,
+		"SCHEMA", strconv.Quote(p.SchemaSQL()),
+	).Replace(importStreamTest)))
+
+	p.GoTest("api/posts")
+}
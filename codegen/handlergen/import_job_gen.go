@@ -0,0 +1,137 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// ImportJobChannel returns the name of the worker channel, and of its
+// package under channels/, that runs the import job of tableName.
+func ImportJobChannel(tableName string) string {
+	return tableName + "_import"
+}
+
+// importJobTimeoutSeconds is how long an import job may run before the
+// worker gives up on it.
+const importJobTimeoutSeconds = 3600
+
+// GenerateImportJob generates channels/<table>_import/register.go, the
+// background job mode of the import endpoint. The client uploads the file
+// through the managed files API and dispatches Import<Plural>Job with its
+// id; a worker streams the file from S3 through Import<Plural>From, without
+// the endpoint's row limit, and sends the summary back as an
+// Import<Plural>Finished message. dialect selects the query runner package
+// the worker opens the database with.
+//
+// The job requires `shipq workers` and `shipq files`.
+func GenerateImportJob(cfg HandlerGenConfig, dialect string) ([]byte, error) {
+	var buf bytes.Buffer
+	plural := codegen.CRUD.PluralResourceName(cfg.TableName)
+	name := ImportJobChannel(cfg.TableName)
+	jobType := "Import" + plural + "Job"
+	finishedType := "Import" + plural + "Finished"
+
+	buf.WriteString(generatedFileHeader)
+	buf.WriteString("package " + name + "\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"context\"\n")
+	buf.WriteString("\t\"fmt\"\n\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/api/" + cfg.TableName + "\"\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/config\"\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/channel\"\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/filestorage\"\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
+	buf.WriteString("\tdbrunner \"" + cfg.ModulePath + "/shipq/queries/" + dialect + "\"\n")
+	buf.WriteString(")\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s starts the import of a file uploaded through the files API.\n", jobType))
+	buf.WriteString("// Format is \"csv\" (the default; the first line is a header naming the\n")
+	buf.WriteString("// columns) or \"ndjson\" (one JSON object per line).\n")
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", jobType))
+	buf.WriteString("\tFileID string `json:\"file_id\"`\n")
+	buf.WriteString("\tFormat string `json:\"format,omitempty\"`\n")
+	buf.WriteString("\tDryRun bool   `json:\"dry_run,omitempty\"`\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// %s summarises the import once the whole file has been read.\n", finishedType))
+	buf.WriteString(fmt.Sprintf("// Its fields are those of %s.Import%sResponse.\n", cfg.TableName, plural))
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", finishedType))
+	buf.WriteString("\tTotal    int  `json:\"total\"`\n")
+	buf.WriteString("\tImported int  `json:\"imported\"`\n")
+	buf.WriteString("\tFailed   int  `json:\"failed\"`\n")
+	fmt.Fprintf(&buf, "\tDryRun   bool `json:%q`\n", jsonFieldName(cfg, "dry_run"))
+	buf.WriteString(fmt.Sprintf("\tErrors   []%s.Import%sRowError `json:\"errors\"`\n", cfg.TableName, plural))
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// Register registers the %s import channel with the app.\n", cfg.TableName))
+	buf.WriteString("func Register(app *channel.App) {\n")
+	buf.WriteString("\tapp.DefineChannel(\n")
+	buf.WriteString(fmt.Sprintf("\t\t%q,\n", name))
+	buf.WriteString(fmt.Sprintf("\t\tchannel.FromClient(%s{}),\n", jobType))
+	buf.WriteString(fmt.Sprintf("\t\tchannel.FromServer(%s{}),\n", finishedType))
+	buf.WriteString(fmt.Sprintf("\t).TimeoutSeconds(%d)\n", importJobTimeoutSeconds))
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// Setup gives the import the query runner of the worker's database.\n")
+	buf.WriteString("func Setup(ctx context.Context) context.Context {\n")
+	buf.WriteString("\treturn queries.NewContextWithRunner(ctx, dbrunner.NewQueryRunner(channel.MustDBFromContext(ctx)))\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// Handle%s imports the rows of the uploaded file on behalf of the\n", jobType))
+	buf.WriteString("// account that dispatched the job, which must be the file's author.\n")
+	buf.WriteString(fmt.Sprintf("func Handle%s(ctx context.Context, job *%s) error {\n", jobType, jobType))
+	buf.WriteString("\taccountID := channel.MustAccountIDFromContext(ctx)\n")
+	buf.WriteString(fmt.Sprintf("\tfile, err := queries.%s(ctx).FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{\n", codegen.MustRunnerFromContextFunc))
+	buf.WriteString("\t\tPublicId: job.FileID,\n")
+	buf.WriteString("\t})\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn fmt.Errorf(\"find file %s: %w\", job.FileID, err)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif file.AuthorAccountId != accountID {\n")
+	buf.WriteString("\t\treturn fmt.Errorf(\"file %s does not belong to the account importing it\", job.FileID)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif file.Status != \"uploaded\" {\n")
+	buf.WriteString("\t\treturn fmt.Errorf(\"file %s has not finished uploading\", job.FileID)\n")
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\ts3Client, err := filestorage.NewS3Client(filestorage.S3Config{\n")
+	buf.WriteString("\t\tBucket:    config.Settings.S3_BUCKET,\n")
+	buf.WriteString("\t\tRegion:    config.Settings.S3_REGION,\n")
+	buf.WriteString("\t\tEndpoint:  config.Settings.S3_ENDPOINT,\n")
+	buf.WriteString("\t\tAccessKey: config.Settings.AWS_ACCESS_KEY_ID,\n")
+	buf.WriteString("\t\tSecretKey: config.Settings.AWS_SECRET_ACCESS_KEY,\n")
+	buf.WriteString("\t})\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tbody, err := s3Client.OpenObject(file.FileKey)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tdefer body.Close()\n\n")
+
+	buf.WriteString("\tctx = httputil.WithSessionAccountID(ctx, accountID)\n")
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\tif orgID, ok := channel.OrgIDFromContext(ctx); ok && orgID != 0 {\n")
+		buf.WriteString("\t\tctx = httputil.WithOrganizationID(ctx, orgID)\n")
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString(fmt.Sprintf("\tresp, err := %s.Import%sFrom(ctx, job.Format, body, job.DryRun, 0)\n", cfg.TableName, plural))
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\treturn MustTypedChannelFromContext(ctx).Send%s(ctx, &%s{\n", finishedType, finishedType))
+	buf.WriteString("\t\tTotal:    resp.Total,\n")
+	buf.WriteString("\t\tImported: resp.Imported,\n")
+	buf.WriteString("\t\tFailed:   resp.Failed,\n")
+	buf.WriteString("\t\tDryRun:   resp.DryRun,\n")
+	buf.WriteString("\t\tErrors:   resp.Errors,\n")
+	buf.WriteString("\t})\n")
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
@@ -0,0 +1,58 @@
+package handlergen
+
+import (
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestGenerateImportJob(t *testing.T) {
+	cfg := testConfig("posts",
+		ddl.ColumnDefinition{Name: "organization_id", Type: ddl.BigintType, References: "organizations"},
+		ddl.ColumnDefinition{Name: "title", Type: ddl.StringType},
+	)
+	cfg.ScopeColumn = "organization_id"
+	result, err := GenerateImportJob(cfg, "postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := gofile.Parse(t, "register.go", result)
+	if f.AST.Name.Name != "posts_import" {
+		t.Errorf("package = %s", f.AST.Name.Name)
+	}
+	var runnerName string
+	for _, imp := range f.AST.Imports {
+		if imp.Path.Value == `"myapp/shipq/queries/postgres"` && imp.Name != nil {
+			runnerName = imp.Name.Name
+		}
+	}
+	if runnerName != "dbrunner" {
+		t.Errorf("expected the dialect's runner imported as dbrunner, got %q", runnerName)
+	}
+
+	// The worker finds Setup and Handle<Dispatch> by name and signature.
+	f.AssertSignature("Setup", "func Setup(ctx context.Context) context.Context")
+	f.AssertSignature("HandleImportPostsJob", "func HandleImportPostsJob(ctx context.Context, job *ImportPostsJob) error")
+
+	// The handler imports without a row limit, as the dispatching account
+	// and in its organization.
+	for _, callee := range []string{"channel.MustAccountIDFromContext", "s3Client.OpenObject", "httputil.WithSessionAccountID", "httputil.WithOrganizationID"} {
+		if f.Calls("HandleImportPostsJob", callee) == 0 {
+			t.Errorf("expected the handler to call %s", callee)
+		}
+	}
+	if !f.HasExpr("HandleImportPostsJob", "posts.ImportPostsFrom(ctx, job.Format, body, job.DryRun, 0)") {
+		t.Errorf("expected the job to import without a row limit:\n%s", result)
+	}
+}
+
+func TestGenerateImportJob_Unscoped(t *testing.T) {
+	result, err := GenerateImportJob(testConfig("posts", ddl.ColumnDefinition{Name: "title", Type: ddl.StringType}), "sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gofile.Parse(t, "register.go", result).Calls("HandleImportPostsJob", "httputil.WithOrganizationID") != 0 {
+		t.Error("an unscoped import must not set an organization")
+	}
+}
@@ -0,0 +1,55 @@
+package handlergen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// jsonFieldName returns the JSON name of a generated field that is not a table
+// column, such as "next_cursor", in the project's [naming] json_case.
+func jsonFieldName(cfg HandlerGenConfig, name string) string {
+	if cfg.JSONCase == "camel" {
+		return dbstrings.ToLowerCamel(dbstrings.ToPascalCase(name))
+	}
+	return name
+}
+
+// columnJSONName returns the JSON name of the field of one of the table's
+// columns: its [crud.<table>] json.<column> override, "id" for public_id,
+// or the column name in the project's json_case. The request field,
+// response fields, validation errors and, through the handler registry, the
+// OpenAPI schemas and TypeScript client all use it.
+func columnJSONName(cfg HandlerGenConfig, column string) string {
+	if name, ok := cfg.JSONNames[column]; ok {
+		return name
+	}
+	if column == "public_id" {
+		return "id"
+	}
+	return jsonFieldName(cfg, column)
+}
+
+// validateJSONNames checks that every json.<column> override names a column
+// of the table and that no two fields of its responses share a JSON name.
+func validateJSONNames(cfg HandlerGenConfig) error {
+	for _, column := range slices.Sorted(maps.Keys(cfg.JSONNames)) {
+		if _, ok := findColumn(cfg.Table, column); !ok {
+			return fmt.Errorf("json name for column %q: column not found in table %q", column, cfg.TableName)
+		}
+	}
+	seen := make(map[string]string) // JSON name → column
+	for _, col := range cfg.Table.Columns {
+		if col.Name == "id" || col.Name == "author_account_id" {
+			continue
+		}
+		name := columnJSONName(cfg, col.Name)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("columns %q and %q of table %q both have the JSON name %q", other, col.Name, cfg.TableName, name)
+		}
+		seen[name] = col.Name
+	}
+	return nil
+}
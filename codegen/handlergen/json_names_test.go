@@ -0,0 +1,123 @@
+package handlergen
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// jsonNameColumns are the columns of a "posts" table with a multi-word
+// column and a length-limited one.
+var jsonNameColumns = []ddl.ColumnDefinition{
+	{Name: "title", Type: ddl.StringType, Length: &nameLength},
+	{Name: "reading_time", Type: ddl.IntegerType},
+	deletedAt,
+}
+
+func TestColumnJSONName(t *testing.T) {
+	cfg := testConfig("posts", jsonNameColumns...)
+	cfg.JSONCase = "camel"
+	cfg.JSONNames = map[string]string{"title": "headline"}
+	tests := map[string]string{
+		"public_id":    "id",
+		"title":        "headline",
+		"reading_time": "readingTime",
+		"created_at":   "createdAt",
+	}
+	for column, want := range tests {
+		if got := columnJSONName(cfg, column); got != want {
+			t.Errorf("columnJSONName(%q) = %q, want %q", column, got, want)
+		}
+	}
+
+	cfg.JSONCase = "snake"
+	if got := columnJSONName(cfg, "reading_time"); got != "reading_time" {
+		t.Errorf("snake case: columnJSONName(reading_time) = %q, want reading_time", got)
+	}
+}
+
+func TestGenerateHandlerFiles_JSONNames(t *testing.T) {
+	cfg := testConfig("posts", jsonNameColumns...)
+	cfg.Table.Indexes = []ddl.IndexDefinition{
+		{Name: "idx_posts_reading_time", Columns: []string{"reading_time"}, Unique: true},
+	}
+	cfg.JSONCase = "camel"
+	cfg.JSONNames = map[string]string{"title": "headline"}
+	files, err := GenerateHandlerFiles(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHandlerFiles failed: %v", err)
+	}
+
+	for name, code := range files {
+		for _, n := range jsonTagNames(gofile.Parse(t, name, code)) {
+			if strings.Contains(n, "_") || n == "title" {
+				t.Errorf("%s: unexpected JSON name %q", name, n)
+			}
+		}
+	}
+
+	create := gofile.Parse(t, "create.go", files["create.go"])
+	create.AssertField("CreatePostRequest", "Title", "string", `json:"headline" validate:"required,max=200"`)
+	create.AssertField("CreatePostRequest", "ReadingTime", "int32", `json:"readingTime"`)
+	create.AssertField("CreatePostResponse", "CreatedAt", "string", `json:"createdAt"`)
+	create.AssertStmts("CreatePost", `v.MaxLength("headline", req.Title, 200)`)
+
+	list := gofile.Parse(t, "list.go", files["list.go"])
+	list.AssertField("ListPostsResponse", "NextCursor", "*string", `json:"nextCursor,omitempty"`)
+	list.AssertField("PostItem", "ReadingTime", "int32", `json:"readingTime"`)
+
+	update := gofile.Parse(t, "update.go", files["update.go"])
+	update.AssertField("UpdatePostRequest", "Title", "*string", `json:"headline,omitempty" validate:"required,max=200"`)
+
+	// Unique violations report the field by its JSON name.
+	helpers := gofile.Parse(t, "helpers.go", files["helpers.go"])
+	helpers.AssertExprs("", `fields: []string{"readingTime"}`)
+}
+
+// jsonTagNames returns the JSON names in the tags of every struct field
+// declared in f.
+func jsonTagNames(f *gofile.File) []string {
+	var names []string
+	ast.Inspect(f.AST, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+		return true
+	})
+	return names
+}
+
+func TestValidateJSONNames(t *testing.T) {
+	cfg := testConfig("posts", jsonNameColumns...)
+	cfg.JSONCase = "camel"
+	cfg.JSONNames = map[string]string{"title": "headline"}
+	if err := validateJSONNames(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.JSONNames = map[string]string{"subtitle": "subhead"}
+	if err := validateJSONNames(cfg); err == nil || !strings.Contains(err.Error(), `column "subtitle"`) {
+		t.Errorf("expected unknown column error, got %v", err)
+	}
+
+	cfg.JSONNames = map[string]string{"title": "readingTime"}
+	if err := validateJSONNames(cfg); err == nil || !strings.Contains(err.Error(), `JSON name "readingTime"`) {
+		t.Errorf("expected duplicate name error, got %v", err)
+	}
+
+	cfg.JSONNames = map[string]string{"title": "id"}
+	if _, err := GenerateHandlerFiles(cfg); err == nil {
+		t.Error("expected GenerateHandlerFiles to reject a name taken by public_id")
+	}
+}
@@ -0,0 +1,122 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/db/portsql/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// listFilter is an optional filter of the list endpoint.
+type listFilter struct {
+	codegen.ListFilter
+	Field string // field of the request and of the List query's params
+	Query string // query parameter, in the project's json_case
+}
+
+// listFilters returns the filters of the list endpoint, or nil unless
+// [crud.<table>] filters = true.
+func listFilters(cfg HandlerGenConfig) []listFilter {
+	if !cfg.Filters {
+		return nil
+	}
+	var filters []listFilter
+	for _, f := range codegen.ListFilters(cfg.Table, cfg.ScopeColumn) {
+		query := columnJSONName(cfg, f.Column.Name)
+		if f.Range != "" {
+			query = jsonFieldName(cfg, f.Param)
+		}
+		filters = append(filters, listFilter{ListFilter: f, Field: toPascalCase(f.Param), Query: query})
+	}
+	return filters
+}
+
+// listFiltersNeedStrconv reports whether parsing the filters needs strconv.
+func listFiltersNeedStrconv(filters []listFilter) bool {
+	for _, f := range filters {
+		if f.Range == "" && f.Column.Type != ddl.StringType && f.Column.Type != ddl.TextType {
+			return true
+		}
+	}
+	return false
+}
+
+// writeListFilterFields writes the request fields of the filters. They are
+// strings, parsed by the handler so a malformed value is a 400 rather than
+// no filter; the format tag gives their type in the OpenAPI spec.
+func writeListFilterFields(buf *bytes.Buffer, filters []listFilter) {
+	for _, f := range filters {
+		tags := fmt.Sprintf("query:%q", f.Query)
+		if format := listFilterFormat(f); format != "" {
+			tags += fmt.Sprintf(" format:%q", format)
+		}
+		fmt.Fprintf(buf, "\t%s *string `%s` // %s\n", f.Field, tags, listFilterDoc(f))
+	}
+}
+
+// listFilterFormat returns the OpenAPI format of a filter's values, or ""
+// for strings.
+func listFilterFormat(f listFilter) string {
+	if f.Range != "" {
+		return "date-time"
+	}
+	switch f.Column.Type {
+	case ddl.IntegerType:
+		return "int32"
+	case ddl.BigintType:
+		return "int64"
+	case ddl.BooleanType:
+		return "boolean"
+	}
+	return ""
+}
+
+// listFilterDoc describes a filter for the request field's comment.
+func listFilterDoc(f listFilter) string {
+	switch f.Range {
+	case "after":
+		return "Only items with " + f.Column.Name + " at or after this RFC 3339 time"
+	case "before":
+		return "Only items with " + f.Column.Name + " before this RFC 3339 time"
+	}
+	return "Only items with this " + f.Column.Name
+}
+
+// writeListFilterParams writes the parsing of the set filters of req into
+// the List query's params, returning a 400 naming the query parameter of a
+// malformed value.
+func writeListFilterParams(buf *bytes.Buffer, filters []listFilter) {
+	for _, f := range filters {
+		req := "req." + f.Field
+		fmt.Fprintf(buf, "\tif %s != nil {\n", req)
+		parse, goType := "", ""
+		switch {
+		case f.Range != "":
+			parse, goType = "time.Parse(time.RFC3339, *"+req+")", "an RFC 3339 time"
+		case f.Column.Type == ddl.IntegerType:
+			parse, goType = "strconv.ParseInt(*"+req+", 10, 32)", "an integer"
+		case f.Column.Type == ddl.BigintType:
+			parse, goType = "strconv.ParseInt(*"+req+", 10, 64)", "an integer"
+		case f.Column.Type == ddl.BooleanType:
+			parse, goType = "strconv.ParseBool(*"+req+")", "true or false"
+		}
+		if parse == "" {
+			fmt.Fprintf(buf, "\t\tparams.%s = %s\n", f.Field, req)
+			buf.WriteString("\t}\n")
+			continue
+		}
+		fmt.Fprintf(buf, "\t\tv, err := %s\n", parse)
+		buf.WriteString("\t\tif err != nil {\n")
+		fmt.Fprintf(buf, "\t\t\treturn nil, httperror.BadRequest(%q).WithFields(%q)\n", f.Query+" must be "+goType, f.Query)
+		buf.WriteString("\t\t}\n")
+		if f.Column.Type == ddl.IntegerType {
+			buf.WriteString("\t\tn := int32(v)\n")
+			fmt.Fprintf(buf, "\t\tparams.%s = &n\n", f.Field)
+		} else {
+			fmt.Fprintf(buf, "\t\tparams.%s = &v\n", f.Field)
+		}
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\n")
+}
@@ -0,0 +1,49 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/codegen"
+)
+
+// listSorts returns the names of the sorts of the list endpoint, or nil
+// unless [crud.<table>] sort is set. The columns were checked when the
+// querydefs were generated.
+func listSorts(cfg HandlerGenConfig) []string {
+	sorts, _ := codegen.ListSorts(cfg.Table, cfg.ScopeColumn, cfg.Sorts)
+	names := make([]string, len(sorts))
+	for i, s := range sorts {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// writeListSortField writes the request field choosing the sort.
+func writeListSortField(buf *bytes.Buffer, sorts []string) {
+	if len(sorts) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "\tSort *string `query:\"sort\"` // Order by %s (default newest first)\n", strings.Join(sorts, ", "))
+}
+
+// writeListSortParam writes the check of req.Sort into the List query's
+// params, returning a 400 for a sort the list doesn't offer.
+func writeListSortParam(buf *bytes.Buffer, sorts []string) {
+	if len(sorts) == 0 {
+		return
+	}
+	quoted := make([]string, len(sorts))
+	for i, s := range sorts {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	buf.WriteString("\tif req.Sort != nil {\n")
+	buf.WriteString("\t\tswitch *req.Sort {\n")
+	fmt.Fprintf(buf, "\t\tcase \"\", %s:\n", strings.Join(quoted, ", "))
+	buf.WriteString("\t\t\tparams.Sort = *req.Sort\n")
+	buf.WriteString("\t\tdefault:\n")
+	fmt.Fprintf(buf, "\t\t\treturn nil, httperror.BadRequest(%q).WithFields(\"sort\")\n", "sort must be one of "+strings.Join(sorts, ", "))
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n\n")
+}
@@ -0,0 +1,192 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// NearMaxRadiusMeters is the largest search radius accepted by generated
+// near endpoints. Larger radii defeat the spatial index and are rejected
+// with a 400.
+const NearMaxRadiusMeters = 100000
+
+// validateNearColumn checks that cfg.NearColumn names a point column of
+// cfg.Table.
+func validateNearColumn(cfg HandlerGenConfig) error {
+	if cfg.NearColumn == "" {
+		return fmt.Errorf("table %q has no near column; set `near = <column>` in [crud.%s]", cfg.TableName, cfg.TableName)
+	}
+	for _, col := range cfg.Table.Columns {
+		if col.Name == cfg.NearColumn {
+			if col.Type != ddl.PointType {
+				return fmt.Errorf("near column %s.%s must be a point column, got %s", cfg.TableName, col.Name, col.Type)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("near column %q not found in table %q", cfg.NearColumn, cfg.TableName)
+}
+
+// GenerateNearHandler generates api/<table>/near.go, a proximity search
+// endpoint (GET /<table>/near?lat=&lng=&radius=) backed by the
+// List<Plural>Near query. Items carry the same fields as the list endpoint
+// plus distance_meters and are ordered nearest first.
+func GenerateNearHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error) {
+	if err := validateNearColumn(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateColumnRoles(cfg); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	res := codegen.CRUD.ResourceName(cfg.TableName)
+	plural := codegen.CRUD.PluralResourceName(cfg.TableName)
+	pkgName := cfg.TableName
+	hasAuthor := TableHasAuthorAccountID(cfg.Table) && !AuthorJoinConflictsWithFK(cfg.Table)
+	readCols := readRestrictedColumns(cfg)
+
+	nearMethod := codegen.CRUD.NearMethodName(cfg.TableName)
+	funcName := "List" + plural + "Near"
+	itemType := res + "NearItem"
+
+	buf.WriteString(generatedFileHeader)
+	buf.WriteString("package " + pkgName + "\n\n")
+
+	// Imports
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"context\"\n")
+	if tableHasJSONColumn(cfg.Table) {
+		buf.WriteString("\t\"encoding/json\"\n")
+	}
+	buf.WriteString("\t\"time\"\n\n")
+	writeCustomTypeImports(&buf, cfg.Table)
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
+	if cfg.ScopeColumn != "" || len(readCols) > 0 {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
+	}
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
+	buf.WriteString(")\n\n")
+
+	// Request struct
+	buf.WriteString("// " + funcName + "Request is the request for searching " + cfg.TableName + " by distance.\n")
+	buf.WriteString("type " + funcName + "Request struct {\n")
+	buf.WriteString("\tLat    float64 `query:\"lat\"`    // Latitude of the search center, -90..90\n")
+	buf.WriteString("\tLng    float64 `query:\"lng\"`    // Longitude of the search center, -180..180\n")
+	buf.WriteString(fmt.Sprintf("\tRadius float64 `query:\"radius\"` // Search radius in meters (max %d)\n", NearMaxRadiusMeters))
+	buf.WriteString("\tLimit  int     `query:\"limit\"`  // Max items (default 20, max 100)\n")
+	buf.WriteString("}\n\n")
+
+	// Item struct
+	buf.WriteString("// " + itemType + " is a " + toSingular(cfg.TableName) + " returned by " + funcName + ".\n")
+	buf.WriteString("type " + itemType + " struct {\n")
+	for _, col := range cfg.Table.Columns {
+		if isResponseExcluded(col.Name) {
+			continue
+		}
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			continue
+		}
+		fieldName := toPascalCase(col.Name)
+		jsonName := columnJSONName(cfg, col.Name)
+		buf.WriteString("\t" + responseStructField(col, fieldName, jsonName) + "\n")
+	}
+	if hasAuthor {
+		buf.WriteString("\tAuthor *AuthorEmbed `json:\"author\"`\n")
+	}
+	fmt.Fprintf(&buf, "\tDistanceMeters float64 `json:%q`\n", jsonFieldName(cfg, "distance_meters"))
+	buf.WriteString("}\n\n")
+
+	// Response struct
+	buf.WriteString("// " + funcName + "Response is the response for searching " + cfg.TableName + " by distance.\n")
+	buf.WriteString("type " + funcName + "Response struct {\n")
+	buf.WriteString("\tItems []" + itemType + " `json:\"items\"`\n")
+	buf.WriteString("}\n\n")
+
+	// Handler function
+	buf.WriteString("// " + funcName + " handles GET " + cfg.routePath() + "/near\n")
+	buf.WriteString("func " + funcName + "(ctx context.Context, req *" + funcName + "Request) (*" + funcName + "Response, error) {\n")
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
+
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
+		buf.WriteString("\tif !ok {\n")
+		buf.WriteString("\t\treturn nil, httperror.Wrap(403, \"organization context missing\", nil)\n")
+		buf.WriteString("\t}\n\n")
+	}
+
+	buf.WriteString("\t// Validate and set defaults\n")
+	buf.WriteString("\tif req.Lat < -90 || req.Lat > 90 {\n")
+	buf.WriteString("\t\treturn nil, httperror.BadRequest(\"lat must be between -90 and 90\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif req.Lng < -180 || req.Lng > 180 {\n")
+	buf.WriteString("\t\treturn nil, httperror.BadRequest(\"lng must be between -180 and 180\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\tif req.Radius <= 0 || req.Radius > %d {\n", NearMaxRadiusMeters))
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, httperror.BadRequest(\"radius must be greater than 0 and at most %d meters\")\n", NearMaxRadiusMeters))
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tlimit := req.Limit\n")
+	buf.WriteString("\tif limit <= 0 || limit > 100 {\n")
+	buf.WriteString("\t\tlimit = 20\n")
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\t// Query database\n")
+	buf.WriteString(fmt.Sprintf("\tresults, err := runner.%s(ctx, queries.%sParams{\n", nearMethod, nearMethod))
+	buf.WriteString("\t\tLat:    req.Lat,\n")
+	buf.WriteString("\t\tLng:    req.Lng,\n")
+	buf.WriteString("\t\tRadius: req.Radius,\n")
+	buf.WriteString("\t\tLimit:  int64(limit),\n")
+	if cfg.ScopeColumn != "" {
+		buf.WriteString(fmt.Sprintf("\t\t%s: orgID,\n", dbstrings.ToPascalCase(cfg.ScopeColumn)))
+	}
+	buf.WriteString("\t})\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn nil, classifyDBError(err, \"search " + cfg.TableName + " near point\")\n")
+	buf.WriteString("\t}\n\n")
+
+	if len(readCols) > 0 {
+		writeCallerRoles(&buf)
+		writeReadRoleFlags(&buf, readCols)
+	}
+	buf.WriteString("\t// Map items to response\n")
+	buf.WriteString("\titems := make([]" + itemType + ", len(results))\n")
+	buf.WriteString("\tfor i, item := range results {\n")
+	buf.WriteString("\t\titems[i] = " + itemType + "{\n")
+	for _, col := range cfg.Table.Columns {
+		if isResponseExcluded(col.Name) {
+			continue
+		}
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			continue
+		}
+		if len(col.ReadRoles) > 0 {
+			continue // assigned below when the caller may read it
+		}
+		buf.WriteString(fmt.Sprintf("\t\t\t%s: %s,\n", toPascalCase(col.Name), responseValueExpr(col, "item")))
+	}
+	buf.WriteString("\t\t\tDistanceMeters: item.DistanceMeters,\n")
+	buf.WriteString("\t\t}\n")
+	writeReadRoleAssignments(&buf, readCols, "\t\t", "items[i]", "item")
+	if hasAuthor {
+		buf.WriteString("\t\tif item.AuthorId != nil && *item.AuthorId != \"\" {\n")
+		buf.WriteString("\t\t\titems[i].Author = &AuthorEmbed{\n")
+		buf.WriteString("\t\t\t\tId:        *item.AuthorId,\n")
+		if cfg.ExposeEmail {
+			buf.WriteString("\t\t\t\tEmail:     *item.AuthorEmail,\n")
+		}
+		buf.WriteString("\t\t\t\tFirstName: *item.AuthorFirstName,\n")
+		buf.WriteString("\t\t\t\tLastName:  *item.AuthorLastName,\n")
+		buf.WriteString("\t\t\t}\n")
+		buf.WriteString("\t\t}\n")
+	}
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\treturn &" + funcName + "Response{Items: items}, nil\n")
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
@@ -0,0 +1,310 @@
+package handlergen
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/crudquerydefs"
+	"github.com/shipq/shipq/codegen/gentest"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+)
+
+// pointColumns are the columns of an organization-scoped "stores" table
+// with a required and an optional point.
+var pointColumns = []ddl.ColumnDefinition{
+	{Name: "organization_id", Type: ddl.BigintType, References: "organizations"},
+	{Name: "name", Type: ddl.StringType},
+	{Name: "location", Type: ddl.PointType},
+	{Name: "entrance", Type: ddl.PointType, Nullable: true},
+}
+
+func TestGenerateNearHandler(t *testing.T) {
+	cfg := testConfig("stores", pointColumns...)
+	cfg.ScopeColumn = "organization_id"
+	cfg.NearColumn = "location"
+	result, err := GenerateNearHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := gofile.Parse(t, "near.go", result)
+
+	f.AssertSignature("ListStoresNear", "func ListStoresNear(ctx context.Context, req *ListStoresNearRequest) (*ListStoresNearResponse, error)")
+	f.AssertField("ListStoresNearRequest", "Lat", "float64", `query:"lat"`)
+	f.AssertField("ListStoresNearRequest", "Radius", "float64", `query:"radius"`)
+	f.AssertField("StoreNearItem", "DistanceMeters", "float64", `json:"distance_meters"`)
+	if _, _, ok := f.Field("StoreNearItem", "OrganizationId"); ok {
+		t.Error("scope column must not be exposed")
+	}
+	f.AssertStmts("ListStoresNear", "if req.Radius <= 0 || req.Radius > 100000")
+	f.AssertExprs("ListStoresNear", "Limit: int64(limit)", "OrganizationId: orgID", "DistanceMeters: item.DistanceMeters")
+}
+
+func TestGenerateNearHandler_RequiresPointColumn(t *testing.T) {
+	cfg := testConfig("stores", pointColumns...)
+	if _, err := GenerateNearHandler(cfg, nil); err == nil || !strings.Contains(err.Error(), "near = <column>") {
+		t.Errorf("expected error pointing at the near setting, got %v", err)
+	}
+
+	cfg.NearColumn = "name"
+	if _, err := GenerateNearHandler(cfg, nil); err == nil || !strings.Contains(err.Error(), "must be a point column") {
+		t.Errorf("expected error for non-point near column, got %v", err)
+	}
+}
+
+func TestRegistrationForOp_Near(t *testing.T) {
+	reg := RegistrationForOp(OpNear, "stores", "", true)
+	if reg.Method != "Get" || reg.Path != "/stores/near" || reg.FuncName != "ListStoresNear" {
+		t.Errorf("unexpected registration: %+v", reg)
+	}
+	for _, op := range AllOperations() {
+		if op == OpNear {
+			t.Error("near must be opt-in, not part of AllOperations")
+		}
+	}
+}
+
+// nearTest is the test run inside the generated stores package: it
+// creates and updates stores with points in loose WKT and searches them by
+// distance.
+const nearTest = `package stores
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"MODULE/shipq/lib/httperror"
+	"MODULE/shipq/queries"
+	"MODULE/shipq/queries/sqlite"
+)
+
+const schemaSQL = SCHEMA
+
+func TestStoresNear(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		t.Fatal(err)
+	}
+	ctx := queries.NewContextWithRunner(context.Background(), sqlite.NewQueryRunner(db))
+
+	ferry, err := CreateStore(ctx, &CreateStoreRequest{Name: "ferry", Location: "point( -122.3937  37.7955 )"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ferry.Location != "POINT(-122.3937 37.7955)" {
+		t.Errorf("location = %q, want the canonical form", ferry.Location)
+	}
+	if _, err := CreateStore(ctx, &CreateStoreRequest{Name: "la", Location: "POINT(-118.2437 34.0522)"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = CreateStore(ctx, &CreateStoreRequest{Name: "bad", Location: "POINT(37.7955 -122.3937)"})
+	var httpErr *httperror.Error
+	if !errors.As(err, &httpErr) || httpErr.Code() != 422 {
+		t.Errorf("out of range point: err = %v, want a 422", err)
+	}
+
+	entrance := "POINT(-122.3940   37.7950)"
+	entrancePtr := &entrance
+	updated, err := UpdateStore(ctx, &UpdateStoreRequest{ID: ferry.PublicId, Entrance: &entrancePtr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Entrance == nil || *updated.Entrance != "POINT(-122.394 37.795)" {
+		t.Errorf("entrance = %v, want the canonical form", updated.Entrance)
+	}
+
+	resp, err := ListStoresNear(ctx, &ListStoresNearRequest{Lat: 37.7749, Lng: -122.4194, Radius: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Name != "ferry" {
+		t.Fatalf("items = %+v, want only the ferry store", resp.Items)
+	}
+	if d := resp.Items[0].DistanceMeters; d < 3000 || d > 4000 {
+		t.Errorf("distance = %v, want about 3.4km", d)
+	}
+
+	if _, err := ListStoresNear(ctx, &ListStoresNearRequest{Lat: 37.7749, Lng: -122.4194}); !errors.As(err, &httpErr) || httpErr.Code() != 400 {
+		t.Errorf("missing radius: err = %v, want a 400", err)
+	}
+}
+`
+
+func TestNearHandler_SearchesByDistance(t *testing.T) {
+	plan := migrate.NewPlan()
+	if _, err := plan.AddTable("stores", func(tb *ddl.TableBuilder) error {
+		tb.String("name")
+		tb.Point("location")
+		tb.Point("entrance").Nullable()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := gentest.New(t, plan)
+	code, err := crudquerydefs.GenerateCRUDQueryDefs(crudquerydefs.Config{
+		ModulePath: p.ModulePath,
+		TableName:  "stores",
+		Table:      plan.Schema.Tables["stores"],
+		Schema:     plan.Schema.Tables,
+		NearColumn: "location",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WriteFile("shipq/querydefs/stores/queries.go", code)
+	p.CompileQueries()
+
+	cfg := HandlerGenConfig{
+		ModulePath: p.ModulePath,
+		TableName:  "stores",
+		Table:      plan.Schema.Tables["stores"],
+		Schema:     plan.Schema.Tables,
+		NearColumn: "location",
+	}
+	files, err := GenerateHandlerFiles(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	near, err := GenerateNearHandler(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"create.go", "update.go", "helpers.go"} {
+		p.WriteFile("api/stores/"+name, files[name])
+	}
+	p.WriteFile("api/stores/near.go", near)
+	p.WriteFile("api/stores/near_test.go", []byte(strings.NewReplacer(
+		"MODULE", p.ModulePath,
+		"SCHEMA", strconv.Quote(p.SchemaSQL()),
+	).Replace(nearTest)))
+
+	p.GoTest("api/stores")
+}
+
+func TestGenerateImportHandler_NormalizesPoints(t *testing.T) {
+	imp, err := GenerateImportHandler(testConfig("stores", pointColumns...), nil)
+	if err != nil {
+		t.Fatalf("GenerateImportHandler: %v", err)
+	}
+	f := gofile.Parse(t, "import.go", imp)
+
+	// Both the CSV and the NDJSON path reject rows with a malformed point.
+	f.AssertStmts("convertStoresCSVRecord",
+		`if normalizePoint("location", &v) != nil`,
+		`return row, &ImportStoresRowError{Field: "location", Error: "must be a WKT POINT(lng lat)"}`,
+	)
+	f.AssertStmts("parseStoresNDJSON",
+		`if normalizePoint("location", &row.Location) != nil`,
+		`if normalizePoint("entrance", row.Entrance) != nil`,
+	)
+}
+
+const polygonCreateTest = `package zones
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"MODULE/shipq/lib/httperror"
+	"MODULE/shipq/queries"
+	"MODULE/shipq/queries/sqlite"
+)
+
+const schemaSQL = SCHEMA
+
+func TestCreateZoneNormalizesArea(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		t.Fatal(err)
+	}
+	ctx := queries.NewContextWithRunner(context.Background(), sqlite.NewQueryRunner(db))
+
+	// Clockwise, with irregular spacing
+	resp, err := CreateZone(ctx, &CreateZoneRequest{Name: "bay", Area: "polygon((-122.5 37.7,-122.5 37.9 , -122.3 37.9, -122.3 37.7, -122.5 37.7))"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "POLYGON((-122.5 37.7, -122.3 37.7, -122.3 37.9, -122.5 37.9, -122.5 37.7))"
+	if resp.Area != want {
+		t.Errorf("area = %q, want %q", resp.Area, want)
+	}
+	var minLng, maxLat float64
+	if err := db.QueryRow("SELECT min_lng, max_lat FROM zones_area_rtree").Scan(&minLng, &maxLat); err != nil {
+		t.Fatal(err)
+	}
+	if minLng > -122.5 || minLng < -122.51 || maxLat < 37.9 || maxLat > 37.91 {
+		t.Errorf("bounding box min_lng=%v max_lat=%v", minLng, maxLat)
+	}
+
+	_, err = CreateZone(ctx, &CreateZoneRequest{Name: "open", Area: "POLYGON((0 0, 1 0, 1 1))"})
+	var httpErr *httperror.Error
+	if !errors.As(err, &httpErr) || httpErr.Code() != 422 {
+		t.Fatalf("unclosed ring: got %v, want a 422", err)
+	}
+}
+`
+
+func TestCreateHandler_NormalizesPolygons(t *testing.T) {
+	plan := migrate.NewPlan()
+	if _, err := plan.AddTable("zones", func(tb *ddl.TableBuilder) error {
+		tb.String("name")
+		tb.Polygon("area")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := gentest.New(t, plan)
+	code, err := crudquerydefs.GenerateCRUDQueryDefs(crudquerydefs.Config{
+		ModulePath: p.ModulePath,
+		TableName:  "zones",
+		Table:      plan.Schema.Tables["zones"],
+		Schema:     plan.Schema.Tables,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WriteFile("shipq/querydefs/zones/queries.go", code)
+	p.CompileQueries()
+
+	files, err := GenerateHandlerFiles(HandlerGenConfig{
+		ModulePath: p.ModulePath,
+		TableName:  "zones",
+		Table:      plan.Schema.Tables["zones"],
+		Schema:     plan.Schema.Tables,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"create.go", "helpers.go"} {
+		p.WriteFile("api/zones/"+name, files[name])
+	}
+	p.WriteFile("api/zones/create_test.go", []byte(strings.NewReplacer(
+		"MODULE", p.ModulePath,
+		"SCHEMA", strconv.Quote(p.SchemaSQL()),
+	).Replace(polygonCreateTest)))
+
+	p.GoTest("api/zones")
+}
@@ -0,0 +1,69 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Outbox event kinds, suffixed to the singular table name to form an
+// event type, e.g. "post.created".
+const (
+	outboxCreated = "created"
+	outboxUpdated = "updated"
+	outboxDeleted = "deleted"
+)
+
+// outboxEventType returns the event type recorded for kind, e.g.
+// "post.created".
+func outboxEventType(cfg HandlerGenConfig, kind string) string {
+	return toSingular(cfg.TableName) + "." + kind
+}
+
+// validateOutbox reports whether the table can record outbox events: each
+// event is keyed by the public_id of the row it describes.
+func validateOutbox(cfg HandlerGenConfig) error {
+	if !cfg.Outbox {
+		return nil
+	}
+	if _, ok := findColumn(cfg.Table, "public_id"); !ok {
+		return fmt.Errorf("table %q: outbox events require a public_id column", cfg.TableName)
+	}
+	return nil
+}
+
+// writeOutboxEvent emits, for a table with outbox = true, the call that
+// records a kind event about the row aggregateID in the write transaction,
+// so the event is stored if and only if the write commits. payload is the
+// Go expression marshaled as the event body.
+func writeOutboxEvent(buf *bytes.Buffer, cfg HandlerGenConfig, kind, aggregateID, payload, action string) {
+	if !cfg.Outbox {
+		return
+	}
+	buf.WriteString(fmt.Sprintf("\tif err := recordOutboxEvent(ctx, tx, %q, %s, %s); err != nil {\n", outboxEventType(cfg, kind), aggregateID, payload))
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, classifyDBError(err, %q)\n", action))
+	buf.WriteString("\t}\n\n")
+}
+
+// writeOutboxHelper emits recordOutboxEvent, which inserts an event into
+// the outbox_events table for the worker's relay to publish.
+func writeOutboxHelper(buf *bytes.Buffer, cfg HandlerGenConfig) {
+	fmt.Fprintf(buf, `
+// recordOutboxEvent records an event about a %s in the outbox. Call it
+// with the runner of the transaction that writes the row: the worker's
+// relay publishes the event once that transaction commits.
+func recordOutboxEvent(ctx context.Context, runner queries.Runner, eventType, aggregateID string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = runner.InsertOutboxEvent(ctx, queries.InsertOutboxEventParams{
+		PublicId:    nanoid.New(),
+		Topic:       %q,
+		EventType:   eventType,
+		AggregateId: aggregateID,
+		Payload:     string(body),
+	})
+	return err
+}
+`, toSingular(cfg.TableName), cfg.TableName)
+}
@@ -0,0 +1,128 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// tableHasColumnType reports whether any column of table has type colType.
+func tableHasColumnType(table ddl.Table, colType string) bool {
+	for _, col := range table.Columns {
+		if col.Type == colType {
+			return true
+		}
+	}
+	return false
+}
+
+// spatialNormalizer returns the helpers.go function that checks and
+// canonicalizes values of the point or polygon column col.
+func spatialNormalizer(col ddl.ColumnDefinition) string {
+	if col.Type == ddl.PolygonType {
+		return "normalizePolygon"
+	}
+	return "normalizePoint"
+}
+
+// spatialFormatError is the import row error for a malformed value of the
+// point or polygon column col.
+func spatialFormatError(col ddl.ColumnDefinition) string {
+	if col.Type == ddl.PolygonType {
+		return "must be a single-ring WKT POLYGON((lng lat, ...))"
+	}
+	return "must be a WKT POINT(lng lat)"
+}
+
+// pointRequestColumns returns the point and polygon columns a create or
+// update request can set.
+func pointRequestColumns(cfg HandlerGenConfig) []ddl.ColumnDefinition {
+	var cols []ddl.ColumnDefinition
+	for _, col := range cfg.Table.Columns {
+		if isAutoColumn(col.Name) || col.Name == "public_id" || (cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn) {
+			continue
+		}
+		if ddl.IsSpatialType(col.Type) {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// pointArgExpr returns the *string argument passed to normalizePoint or
+// normalizePolygon for a request field expr of type goType. ok is false for
+// double pointers, which the caller must dereference behind a nil check.
+func pointArgExpr(goType, expr string) (arg string, ok bool) {
+	switch goType {
+	case "string":
+		return "&" + expr, true
+	case "*string":
+		return expr, true
+	default:
+		return "*" + expr, false
+	}
+}
+
+// writePointChecks emits a normalizePoint or normalizePolygon call for every
+// column in cols, rejecting malformed WKT with a 422 before the database sees
+// it. fieldType returns the request field's Go type.
+func writePointChecks(buf *bytes.Buffer, cfg HandlerGenConfig, cols []ddl.ColumnDefinition, fieldType func(ddl.ColumnDefinition) string) {
+	for _, col := range cols {
+		field := columnJSONName(cfg, col.Name)
+		expr := "req." + toPascalCase(col.Name)
+		arg, ok := pointArgExpr(fieldType(col), expr)
+		if ok {
+			buf.WriteString(fmt.Sprintf("\tif err := %s(%q, %s); err != nil {\n", spatialNormalizer(col), field, arg))
+			buf.WriteString("\t\treturn nil, err\n")
+			buf.WriteString("\t}\n")
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("\tif %s != nil {\n", expr))
+		buf.WriteString(fmt.Sprintf("\t\tif err := %s(%q, %s); err != nil {\n", spatialNormalizer(col), field, arg))
+		buf.WriteString("\t\t\treturn nil, err\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\n")
+}
+
+// writePointHelper emits normalizePoint into helpers.go.
+func writePointHelper(buf *bytes.Buffer) {
+	buf.WriteString(`
+// normalizePoint checks that *wkt is a WKT point with coordinates in range and
+// rewrites it in the canonical "POINT(lng lat)" form stored by the database.
+// A nil wkt is left alone.
+func normalizePoint(field string, wkt *string) error {
+	if wkt == nil {
+		return nil
+	}
+	lat, lng, err := query.ParsePointWKT(*wkt)
+	if err != nil {
+		return httperror.UnprocessableEntityf("%s: %v", field, err)
+	}
+	*wkt = query.PointWKT(lat, lng)
+	return nil
+}
+`)
+}
+
+// writePolygonHelper emits normalizePolygon into helpers.go.
+func writePolygonHelper(buf *bytes.Buffer) {
+	buf.WriteString(`
+// normalizePolygon checks that *wkt is a single-ring WKT polygon with
+// coordinates in range and rewrites it in the canonical form stored by the
+// database. A nil wkt is left alone.
+func normalizePolygon(field string, wkt *string) error {
+	if wkt == nil {
+		return nil
+	}
+	polygon, err := query.NormalizePolygonWKT(*wkt)
+	if err != nil {
+		return httperror.UnprocessableEntityf("%s: %v", field, err)
+	}
+	*wkt = polygon
+	return nil
+}
+`)
+}
@@ -0,0 +1,47 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// validateQuota reports whether the table can enforce max_rows_per_scope:
+// rows are counted per value of the scope column.
+func validateQuota(cfg HandlerGenConfig) error {
+	if cfg.MaxRowsPerScope <= 0 {
+		return nil
+	}
+	if cfg.ScopeColumn == "" {
+		return fmt.Errorf("table %q: max_rows_per_scope requires a scope column", cfg.TableName)
+	}
+	if cfg.QuotaStatus != 402 && cfg.QuotaStatus != 403 {
+		return fmt.Errorf("table %q: quota status must be 402 or 403, got %d", cfg.TableName, cfg.QuotaStatus)
+	}
+	return nil
+}
+
+// writeQuotaCheck emits, for a table with max_rows_per_scope, the check
+// that refuses a create once the caller's scope holds its quota of live
+// rows. The limit is the scope's scope_quotas row if it has one, so it can
+// be raised or lowered at runtime, and max_rows_per_scope otherwise.
+// writer is the runner the row is written with.
+func writeQuotaCheck(buf *bytes.Buffer, cfg HandlerGenConfig, writer, action string) {
+	if cfg.MaxRowsPerScope <= 0 {
+		return
+	}
+	countMethod := codegen.CRUD.CountInScopeMethodName(cfg.TableName)
+	buf.WriteString(fmt.Sprintf("\t// At most %d %s per scope, unless scope_quotas says otherwise.\n", cfg.MaxRowsPerScope, cfg.TableName))
+	buf.WriteString(fmt.Sprintf("\tquotaLimit := int64(%d)\n", cfg.MaxRowsPerScope))
+	buf.WriteString(fmt.Sprintf("\tif quota, err := %s.GetScopeQuota(ctx, queries.GetScopeQuotaParams{Resource: %q, ScopeId: orgID}); err != nil {\n", writer, cfg.TableName))
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, classifyDBError(err, %q)\n", action))
+	buf.WriteString("\t} else if quota != nil {\n")
+	buf.WriteString("\t\tquotaLimit = quota.MaxRows\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\tif existing, err := %s.%s(ctx, queries.%sParams{%s: orgID}); err != nil {\n", writer, countMethod, countMethod, toPascalCase(cfg.ScopeColumn)))
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, classifyDBError(err, %q)\n", action))
+	buf.WriteString("\t} else if existing != nil && existing.Count >= quotaLimit {\n")
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, httperror.Newf(%d, \"%s quota of %%d reached\", quotaLimit)\n", cfg.QuotaStatus, cfg.TableName))
+	buf.WriteString("\t}\n\n")
+}
@@ -3,9 +3,10 @@ package handlergen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"os"
 	"strings"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // Operation represents a CRUD operation type for per-operation generation.
@@ -17,6 +18,22 @@ const (
 	OpList   Operation = "list"
 	OpUpdate Operation = "update"
 	OpDelete Operation = "delete"
+
+	// OpImport generates the bulk import endpoint. It is opt-in and not part
+	// of AllOperations.
+	OpImport Operation = "import"
+
+	// OpNear generates the proximity search endpoint for the table's
+	// configured point column. It is opt-in and not part of AllOperations.
+	OpNear Operation = "near"
+
+	// OpBulkCreate generates the bulk create endpoint, which creates items
+	// through the create handler. It is opt-in and not part of AllOperations.
+	OpBulkCreate Operation = "bulk_create"
+
+	// OpRestore generates the restore endpoint, which undoes a soft delete.
+	// It is opt-in and not part of AllOperations.
+	OpRestore Operation = "restore"
 )
 
 // AllOperations returns all CRUD operations in the standard order.
@@ -30,49 +47,90 @@ type RouteRegistration struct {
 	Path        string // "/books" or "/books/:id"
 	FuncName    string // "CreateBook"
 	RequireAuth bool
+	Deprecated  bool   // true if .Deprecated() or .Sunset() is chained
+	Sunset      string // YYYY-MM-DD passed to .Sunset(), empty if none
 }
 
-// RegistrationForOp returns the route registration for a given operation and table.
-func RegistrationForOp(op Operation, tableName string, requireAuth bool) RouteRegistration {
+// resourcePath returns the path the routes of tableName are registered
+// under: "/" + pathSegment, or "/" + tableName when pathSegment is empty.
+func resourcePath(tableName, pathSegment string) string {
+	if pathSegment == "" {
+		return "/" + tableName
+	}
+	return "/" + pathSegment
+}
+
+// RegistrationForOp returns the route registration for a given operation and
+// table, whose routes are registered under pathSegment ("" for the table name).
+func RegistrationForOp(op Operation, tableName, pathSegment string, requireAuth bool) RouteRegistration {
 	res := resourceName(tableName)
 	plural := toPascalCase(tableName)
+	base := resourcePath(tableName, pathSegment)
 
 	switch op {
 	case OpCreate:
 		return RouteRegistration{
 			Method:      "Post",
-			Path:        "/" + tableName,
+			Path:        base,
 			FuncName:    "Create" + res,
 			RequireAuth: requireAuth,
 		}
 	case OpGetOne:
 		return RouteRegistration{
 			Method:      "Get",
-			Path:        "/" + tableName + "/:id",
+			Path:        base + "/:id",
 			FuncName:    "Get" + res,
 			RequireAuth: requireAuth,
 		}
 	case OpList:
 		return RouteRegistration{
 			Method:      "Get",
-			Path:        "/" + tableName,
+			Path:        base,
 			FuncName:    "List" + plural,
 			RequireAuth: requireAuth,
 		}
 	case OpUpdate:
 		return RouteRegistration{
 			Method:      "Patch",
-			Path:        "/" + tableName + "/:id",
+			Path:        base + "/:id",
 			FuncName:    "Update" + res,
 			RequireAuth: requireAuth,
 		}
 	case OpDelete:
 		return RouteRegistration{
 			Method:      "Delete",
-			Path:        "/" + tableName + "/:id",
+			Path:        base + "/:id",
 			FuncName:    "SoftDelete" + res,
 			RequireAuth: requireAuth,
 		}
+	case OpImport:
+		return RouteRegistration{
+			Method:      "Post",
+			Path:        base + "/import",
+			FuncName:    "Import" + plural,
+			RequireAuth: requireAuth,
+		}
+	case OpBulkCreate:
+		return RouteRegistration{
+			Method:      "Post",
+			Path:        base + "/bulk",
+			FuncName:    "BulkCreate" + plural,
+			RequireAuth: requireAuth,
+		}
+	case OpRestore:
+		return RouteRegistration{
+			Method:      "Post",
+			Path:        base + "/:id/restore",
+			FuncName:    "Restore" + res,
+			RequireAuth: requireAuth,
+		}
+	case OpNear:
+		return RouteRegistration{
+			Method:      "Get",
+			Path:        base + "/near",
+			FuncName:    "List" + plural + "Near",
+			RequireAuth: requireAuth,
+		}
 	default:
 		panic("unknown operation: " + string(op))
 	}
@@ -81,29 +139,42 @@ func RegistrationForOp(op Operation, tableName string, requireAuth bool) RouteRe
 // GenerateIncrementalRegister generates or updates a register.go file,
 // adding only the specified operations. If the file already exists, it
 // parses existing routes and merges the new ones.
-func GenerateIncrementalRegister(registerPath string, modulePath string, tableName string, ops []Operation, requireAuth bool) ([]byte, error) {
+//
+// Regenerated routes are marked deprecated when deprecated is true, with an
+// optional sunset date; untouched routes keep whatever modifiers they had.
+func GenerateIncrementalRegister(registerPath string, modulePath string, tableName string, pathSegment string, ops []Operation, requireAuth bool, deprecated bool, sunset string) ([]byte, error) {
 	// Collect desired registrations
 	existing := parseExistingRoutes(registerPath)
 	for _, op := range ops {
-		reg := RegistrationForOp(op, tableName, requireAuth)
-		// Replace existing route for the same func, or add new
-		found := false
-		for i, e := range existing {
-			if e.FuncName == reg.FuncName {
-				existing[i] = reg
-				found = true
-				break
-			}
-		}
-		if !found {
-			existing = append(existing, reg)
-		}
+		reg := RegistrationForOp(op, tableName, pathSegment, requireAuth)
+		reg.Deprecated = deprecated || sunset != ""
+		reg.Sunset = sunset
+		existing = mergeRoute(existing, reg)
 	}
 
 	// Sort routes in canonical order: Create, List, GetOne, Update, Delete
 	existing = sortRoutes(existing)
 
-	return renderRegisterFile(modulePath, tableName, existing)
+	return renderRegisterFile(modulePath, tableName, pathSegment, existing)
+}
+
+// AddRouteToRegister generates or updates a register.go file so it contains
+// reg, keeping every route already registered there.
+func AddRouteToRegister(registerPath string, modulePath string, tableName string, pathSegment string, reg RouteRegistration) ([]byte, error) {
+	routes := mergeRoute(parseExistingRoutes(registerPath), reg)
+	return renderRegisterFile(modulePath, tableName, pathSegment, sortRoutes(routes))
+}
+
+// mergeRoute replaces the route registered for the same handler function,
+// or appends reg if there is none.
+func mergeRoute(routes []RouteRegistration, reg RouteRegistration) []RouteRegistration {
+	for i, e := range routes {
+		if e.FuncName == reg.FuncName {
+			routes[i] = reg
+			return routes
+		}
+	}
+	return append(routes, reg)
 }
 
 // parseExistingRoutes reads an existing register.go and extracts the route registrations.
@@ -122,14 +193,15 @@ func parseExistingRoutes(registerPath string) []RouteRegistration {
 			continue
 		}
 
-		requireAuth := strings.HasSuffix(line, ".Auth()")
-		// Strip .Auth() suffix for parsing
-		parseLine := strings.TrimSuffix(line, ".Auth()")
+		// Strip chained modifiers (.Auth(), .Sunset("..."), ...) for parsing
+		parseLine, mods := splitRouteModifiers(line)
 
 		// Parse app.Method("path", FuncName)
 		reg := parseRouteLine(parseLine)
 		if reg != nil {
-			reg.RequireAuth = requireAuth
+			reg.RequireAuth = mods.RequireAuth
+			reg.Deprecated = mods.Deprecated
+			reg.Sunset = mods.Sunset
 			routes = append(routes, *reg)
 		}
 	}
@@ -137,6 +209,45 @@ func parseExistingRoutes(registerPath string) []RouteRegistration {
 	return routes
 }
 
+// splitRouteModifiers strips trailing RouteBuilder calls from a registration
+// line and records them on the returned RouteRegistration. Unknown trailing
+// calls are left in place.
+func splitRouteModifiers(line string) (string, RouteRegistration) {
+	var mods RouteRegistration
+	line = strings.TrimRight(line, " \t;")
+	for {
+		switch {
+		case strings.HasSuffix(line, ".Auth()"):
+			mods.RequireAuth = true
+			line = strings.TrimSuffix(line, ".Auth()")
+		case strings.HasSuffix(line, ".Deprecated()"):
+			mods.Deprecated = true
+			line = strings.TrimSuffix(line, ".Deprecated()")
+		case strings.HasSuffix(line, "\")") && strings.Contains(line, ".Sunset(\""):
+			idx := strings.LastIndex(line, ".Sunset(\"")
+			mods.Deprecated = true
+			mods.Sunset = strings.TrimSuffix(line[idx+len(".Sunset(\""):], "\")")
+			line = line[:idx]
+		default:
+			return line, mods
+		}
+	}
+}
+
+// routeModifiers renders the RouteBuilder calls chained after a registration.
+func routeModifiers(requireAuth, deprecated bool, sunset string) string {
+	var suffix string
+	if requireAuth {
+		suffix += ".Auth()"
+	}
+	if sunset != "" {
+		suffix += ".Sunset(\"" + sunset + "\")"
+	} else if deprecated {
+		suffix += ".Deprecated()"
+	}
+	return suffix
+}
+
 // parseRouteLine extracts a RouteRegistration from a line like:
 // app.Post("/books", CreateBook)
 func parseRouteLine(line string) *RouteRegistration {
@@ -207,10 +318,11 @@ func routeOrder(r RouteRegistration, order map[string]int) int {
 	return base
 }
 
-func renderRegisterFile(modulePath string, tableName string, routes []RouteRegistration) ([]byte, error) {
+func renderRegisterFile(modulePath string, tableName string, pathSegment string, routes []RouteRegistration) ([]byte, error) {
 	var buf bytes.Buffer
 	res := toPascalCase(toSingular(tableName))
 	plural := toPascalCase(tableName)
+	base := resourcePath(tableName, pathSegment)
 
 	buf.WriteString(generatedFileHeader)
 	buf.WriteString("package " + tableName + "\n\n")
@@ -220,22 +332,18 @@ func renderRegisterFile(modulePath string, tableName string, routes []RouteRegis
 	buf.WriteString("// To add a new route, define your handler function in this package and\n")
 	buf.WriteString("// register it here:\n")
 	buf.WriteString("//\n")
-	buf.WriteString("//   app.Get(\"/" + tableName + "/popular\", ListPopular" + plural + "\")         // public route\n")
-	buf.WriteString("//   app.Post(\"/" + tableName + "/import\", Import" + plural + "\").Auth()       // requires auth\n")
-	buf.WriteString("//   app.Put(\"/" + tableName + "/:id/publish\", Publish" + res + ").Auth()    // requires auth\n")
+	buf.WriteString("//   app.Get(\"" + base + "/popular\", ListPopular" + plural + "\")         // public route\n")
+	buf.WriteString("//   app.Post(\"" + base + "/import\", Import" + plural + "\").Auth()       // requires auth\n")
+	buf.WriteString("//   app.Put(\"" + base + "/:id/publish\", Publish" + res + ").Auth()    // requires auth\n")
 	buf.WriteString("//\n")
 	buf.WriteString("// Available methods: app.Get, app.Post, app.Put, app.Patch, app.Delete\n")
 	buf.WriteString("func Register(app *handler.App) {\n")
 
 	for _, r := range routes {
-		authSuffix := ""
-		if r.RequireAuth {
-			authSuffix = ".Auth()"
-		}
-		buf.WriteString(fmt.Sprintf("\tapp.%s(\"%s\", %s)%s\n", r.Method, r.Path, r.FuncName, authSuffix))
+		buf.WriteString(fmt.Sprintf("\tapp.%s(\"%s\", %s)%s\n", r.Method, r.Path, r.FuncName, routeModifiers(r.RequireAuth, r.Deprecated, r.Sunset)))
 	}
 
 	buf.WriteString("}\n")
 
-	return format.Source(buf.Bytes())
+	return phase.FormatSource(buf.Bytes())
 }
@@ -0,0 +1,84 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// GenerateRestoreHandler generates api/<table>/restore.go, an endpoint
+// (POST /<table>/:id/restore) that undoes a soft delete by clearing
+// deleted_at through Restore<Resource>ByPublicID. Restoring a row that is
+// not deleted, or does not exist, is a 404.
+//
+// Tables with soft-unique indexes are refused: a restored row could
+// duplicate a live one, and the restore query does not know the row's
+// values to check them.
+func GenerateRestoreHandler(cfg HandlerGenConfig, _ []RelationshipInfo) ([]byte, error) {
+	if !tableHasDeletedAt(cfg.Table) || cfg.NoSoftDelete {
+		return nil, fmt.Errorf("restore on %s requires soft delete (a deleted_at column and no no_soft_delete)", cfg.TableName)
+	}
+	if idx := cfg.Table.SoftUniqueIndexes(); len(idx) > 0 {
+		return nil, fmt.Errorf("restore is not supported on %s: it has the soft-unique index %s", cfg.TableName, idx[0].Name)
+	}
+
+	var buf bytes.Buffer
+	res := codegen.CRUD.ResourceName(cfg.TableName)
+	pkgName := cfg.TableName
+	restoreMethod := codegen.CRUD.RestoreMethodName(cfg.TableName)
+
+	buf.WriteString(generatedFileHeader)
+	buf.WriteString("package " + pkgName + "\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"context\"\n\n")
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httperror\"\n")
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/lib/httputil\"\n")
+	}
+	buf.WriteString("\t\"" + cfg.ModulePath + "/shipq/queries\"\n")
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("// Restore" + res + "Request is the request for restoring a soft-deleted " + toSingular(cfg.TableName) + ".\n")
+	buf.WriteString("type Restore" + res + "Request struct {\n")
+	buf.WriteString("\tID string `path:\"id\"` // This is the PUBLIC ID\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// Restore" + res + "Response is the response after restoring a " + toSingular(cfg.TableName) + ".\n")
+	buf.WriteString("type Restore" + res + "Response struct {\n")
+	buf.WriteString("\tSuccess bool `json:\"success\"`\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// Restore" + res + " handles POST " + cfg.routePath() + "/:id/restore\n")
+	buf.WriteString("func Restore" + res + "(ctx context.Context, req *Restore" + res + "Request) (*Restore" + res + "Response, error) {\n")
+	buf.WriteString(fmt.Sprintf("\trunner := queries.%s(ctx)\n\n", codegen.MustRunnerFromContextFunc))
+
+	if cfg.ScopeColumn != "" {
+		buf.WriteString("\torgID, ok := httputil.OrganizationIDFromContext(ctx)\n")
+		buf.WriteString("\tif !ok {\n")
+		buf.WriteString("\t\treturn nil, httperror.Wrap(403, \"organization context missing\", nil)\n")
+		buf.WriteString("\t}\n\n")
+	}
+
+	buf.WriteString(fmt.Sprintf("\trestored, err := runner.%s(ctx, queries.%sParams{\n", restoreMethod, restoreMethod))
+	buf.WriteString("\t\tPublicId: req.ID,\n")
+	if cfg.ScopeColumn != "" {
+		buf.WriteString(fmt.Sprintf("\t\t%s: orgID,\n", dbstrings.ToPascalCase(cfg.ScopeColumn)))
+	}
+	buf.WriteString("\t})\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn nil, classifyDBError(err, \"restore " + toSingular(cfg.TableName) + "\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif !restored {\n")
+	buf.WriteString("\t\treturn nil, httperror.NotFoundf(\"deleted " + toSingular(cfg.TableName) + " %q not found\", req.ID)\n")
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\treturn &Restore" + res + "Response{\n")
+	buf.WriteString("\t\tSuccess: true,\n")
+	buf.WriteString("\t}, nil\n")
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
@@ -0,0 +1,182 @@
+package handlergen
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/crudquerydefs"
+	"github.com/shipq/shipq/codegen/gentest"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+)
+
+func TestGenerateRestoreHandler(t *testing.T) {
+	cfg := testConfig("posts", append(scopedPostColumns, deletedAt)...)
+	cfg.ScopeColumn = "organization_id"
+	result, err := GenerateRestoreHandler(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := gofile.Parse(t, "restore.go", result)
+
+	f.AssertSignature("RestorePost", "func RestorePost(ctx context.Context, req *RestorePostRequest) (*RestorePostResponse, error)")
+	// The restore is scoped to the caller's organization.
+	f.AssertExprs("RestorePost", "OrganizationId: orgID")
+	f.AssertStmts("RestorePost",
+		"if !restored",
+		`return nil, httperror.NotFoundf("deleted post %q not found", req.ID)`,
+	)
+}
+
+// restoreTest is the test run inside the generated posts package: it
+// restores a soft-deleted post and checks that live posts are not found.
+const restoreTest = `package posts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"MODULE/shipq/lib/httperror"
+	"MODULE/shipq/queries"
+	"MODULE/shipq/queries/sqlite"
+)
+
+const schemaSQL = SCHEMA
+
+func TestRestorePost(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schemaSQL); err != nil {
+		t.Fatal(err)
+	}
+	ctx := queries.NewContextWithRunner(context.Background(), sqlite.NewQueryRunner(db))
+	isNotFound := func(err error) bool {
+		var httpErr *httperror.Error
+		return errors.As(err, &httpErr) && httpErr.Code() == 404
+	}
+
+	created, err := CreatePost(ctx, &CreatePostRequest{Title: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := created.PublicId
+
+	if _, err := RestorePost(ctx, &RestorePostRequest{ID: id}); !isNotFound(err) {
+		t.Errorf("restoring a live post: err = %v, want a 404", err)
+	}
+	if _, err := SoftDeletePost(ctx, &SoftDeletePostRequest{ID: id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetPost(ctx, &GetPostRequest{ID: id}); !isNotFound(err) {
+		t.Fatalf("deleted post: err = %v, want a 404", err)
+	}
+	resp, err := RestorePost(ctx, &RestorePostRequest{ID: id})
+	if err != nil || !resp.Success {
+		t.Fatalf("restore: %+v, %v", resp, err)
+	}
+	if got, err := GetPost(ctx, &GetPostRequest{ID: id}); err != nil || got.Title != "a" {
+		t.Errorf("restored post: %+v, %v", got, err)
+	}
+	if _, err := RestorePost(ctx, &RestorePostRequest{ID: "missing"}); !isNotFound(err) {
+		t.Errorf("unknown post: err = %v, want a 404", err)
+	}
+}
+`
+
+func TestRestoreHandler_RestoresDeletedRows(t *testing.T) {
+	plan := migrate.NewPlan()
+	if _, err := plan.AddTable("posts", func(tb *ddl.TableBuilder) error {
+		tb.String("title")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := gentest.New(t, plan)
+	code, err := crudquerydefs.GenerateCRUDQueryDefs(crudquerydefs.Config{
+		ModulePath: p.ModulePath,
+		TableName:  "posts",
+		Table:      plan.Schema.Tables["posts"],
+		Schema:     plan.Schema.Tables,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WriteFile("shipq/querydefs/posts/queries.go", code)
+	p.CompileQueries()
+
+	files, err := GenerateHandlerFiles(HandlerGenConfig{
+		ModulePath: p.ModulePath,
+		TableName:  "posts",
+		Table:      plan.Schema.Tables["posts"],
+		Schema:     plan.Schema.Tables,
+		Restore:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"create.go", "get_one.go", "soft_delete.go", "restore.go", "helpers.go"} {
+		p.WriteFile("api/posts/"+name, files[name])
+	}
+	p.WriteFile("api/posts/restore_test.go", []byte(strings.NewReplacer(
+		"MODULE", p.ModulePath,
+		"SCHEMA", strconv.Quote(p.SchemaSQL()),
+	).Replace(restoreTest)))
+
+	p.GoTest("api/posts")
+}
+
+func TestGenerateRestoreHandler_RequiresSoftDelete(t *testing.T) {
+	if _, err := GenerateRestoreHandler(testConfig("posts", scopedPostColumns...), nil); err == nil {
+		t.Error("expected an error for a table without deleted_at")
+	}
+
+	cfg := testConfig("posts", append(scopedPostColumns, deletedAt)...)
+	cfg.NoSoftDelete = true
+	if _, err := GenerateRestoreHandler(cfg, nil); err == nil {
+		t.Error("expected an error with NoSoftDelete")
+	}
+
+	cfg = testConfig("posts", append(scopedPostColumns, deletedAt)...)
+	cfg.Table.Indexes = []ddl.IndexDefinition{{Name: "posts_title_soft_unique", Columns: []string{"title"}, SoftUnique: true}}
+	if _, err := GenerateRestoreHandler(cfg, nil); err == nil || !strings.Contains(err.Error(), "posts_title_soft_unique") {
+		t.Errorf("expected an error naming the soft-unique index, got %v", err)
+	}
+}
+
+func TestGenerateHandlerFiles_Restore(t *testing.T) {
+	cfg := testConfig("posts", append(scopedPostColumns, deletedAt)...)
+
+	files, err := GenerateHandlerFiles(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := files["restore.go"]; ok {
+		t.Error("restore.go should only be generated with Restore")
+	}
+
+	cfg.Restore = true
+	files, err = GenerateHandlerFiles(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := files["restore.go"]; !ok {
+		t.Error("expected restore.go with Restore")
+	}
+	gofile.Parse(t, "register.go", files["register.go"]).AssertStmts("Register", `app.Post("/posts/:id/restore", RestorePost)`)
+
+	reg := RegistrationForOp(OpRestore, "posts", "post", true)
+	if reg.Method != "Post" || reg.Path != "/post/:id/restore" || reg.FuncName != "RestorePost" || !reg.RequireAuth {
+		t.Errorf("RegistrationForOp(OpRestore) = %+v", reg)
+	}
+}
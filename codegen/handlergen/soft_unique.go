@@ -0,0 +1,104 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// needsWriteTx reports whether the write handlers write the row in a
+// transaction: to check soft-unique indexes, or to record outbox events.
+func needsWriteTx(cfg HandlerGenConfig) bool {
+	return len(cfg.Table.SoftUniqueIndexes()) > 0 || cfg.Outbox
+}
+
+// writeWriteTx emits the transaction in which the write handlers check
+// soft-unique indexes, write the row and record its outbox event, so that
+// no live duplicate can be written in between and the event is stored
+// only if the write commits. A runner that is already a transaction is
+// used as is. It returns the name of the runner to write with: "tx" when
+// the table needs the transaction, otherwise "runner".
+func writeWriteTx(buf *bytes.Buffer, cfg HandlerGenConfig) string {
+	if !needsWriteTx(cfg) {
+		return "runner"
+	}
+	if len(cfg.Table.SoftUniqueIndexes()) > 0 {
+		buf.WriteString("\t// Soft-unique indexes are checked in the transaction that writes the row.\n")
+	} else {
+		buf.WriteString("\t// The outbox event is recorded in the transaction that writes the row.\n")
+	}
+	writeTxRunner(buf)
+	return "tx"
+}
+
+// writeTxRunner emits tx, a transaction on runner (or runner itself when
+// it already is one), and commit, which commits it.
+func writeTxRunner(buf *bytes.Buffer) {
+	buf.WriteString("\ttx, commit := runner, func() error { return nil }\n")
+	writeBeginTx(buf, "\t", "txRunner", "nil, ")
+	buf.WriteString("\t\tdefer txRunner.Rollback() // no-op after commit\n")
+	buf.WriteString("\t\ttx, commit = txRunner, txRunner.Commit\n")
+	buf.WriteString("\t}\n\n")
+}
+
+// writeBeginTx emits the opening of a block that starts a transaction on
+// runner as name, skipped when runner already is a transaction for the
+// caller to join instead. Any other BeginTx error returns a 500, after
+// results, the enclosing function's other results. The caller writes the
+// rest of the block and closes it.
+func writeBeginTx(buf *bytes.Buffer, indent, name, results string) {
+	fmt.Fprintf(buf, "%sif !queries.InTx(runner) {\n", indent)
+	fmt.Fprintf(buf, "%s\t%s, err := runner.BeginTx(ctx)\n", indent, name)
+	fmt.Fprintf(buf, "%s\tif err != nil {\n", indent)
+	fmt.Fprintf(buf, "%s\t\treturn %shttperror.Wrap(500, \"begin transaction\", err)\n", indent, results)
+	fmt.Fprintf(buf, "%s\t}\n", indent)
+}
+
+// writeSoftUniqueChecks emits, per soft-unique index, a call to its
+// LockActive query that returns a 409 naming the index's fields when a live
+// row other than publicIDExpr holds the values being written. value returns
+// the Go expression written to a column; action names the operation in
+// database error messages.
+func writeSoftUniqueChecks(buf *bytes.Buffer, cfg HandlerGenConfig, publicIDExpr, action string, value func(col string) string) {
+	indexes := cfg.Table.SoftUniqueIndexes()
+	if len(indexes) == 0 {
+		return
+	}
+	for _, idx := range indexes {
+		method := codegen.CRUD.SoftUniqueMethodName(cfg.TableName, idx.Columns)
+		buf.WriteString(fmt.Sprintf("\tif dup, err := tx.%s(ctx, queries.%sParams{\n", method, method))
+		for _, col := range idx.Columns {
+			buf.WriteString(fmt.Sprintf("\t\t%s: %s,\n", toPascalCase(col), value(col)))
+		}
+		buf.WriteString(fmt.Sprintf("\t\tPublicId: %s,\n", publicIDExpr))
+		buf.WriteString("\t}); err != nil {\n")
+		buf.WriteString(fmt.Sprintf("\t\treturn nil, classifyDBError(err, %q)\n", action))
+		buf.WriteString("\t} else if dup != nil {\n")
+		// The scope column comes from the session, not the request, so it
+		// isn't reported as a conflicting field.
+		var names, fields []string
+		for _, col := range idx.Columns {
+			if cfg.ScopeColumn != "" && col == cfg.ScopeColumn {
+				continue
+			}
+			names = append(names, columnJSONName(cfg, col))
+			fields = append(fields, fmt.Sprintf("%q", columnJSONName(cfg, col)))
+		}
+		buf.WriteString(fmt.Sprintf("\t\treturn nil, httperror.Conflict(%q).WithFields(%s)\n",
+			strings.Join(names, ", ")+" already exists", strings.Join(fields, ", ")))
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\n")
+}
+
+// writeWriteCommit commits the transaction opened by writeWriteTx.
+func writeWriteCommit(buf *bytes.Buffer, cfg HandlerGenConfig, action string) {
+	if !needsWriteTx(cfg) {
+		return
+	}
+	buf.WriteString("\tif err := commit(); err != nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\treturn nil, classifyDBError(err, %q)\n", action))
+	buf.WriteString("\t}\n\n")
+}
@@ -0,0 +1,172 @@
+package handlergen
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// columnRules are the request validation rules derived from a column's DDL
+// definition, checked by generated create and update handlers with
+// shipq/lib/validate.
+type columnRules struct {
+	required  bool // NOT NULL without a database or API default: must not be empty
+	maxLength int  // string(n): at most n characters, 0 for no limit
+	email     bool // named like an email column, see codegen.CRUD.IsEmailColumn
+	precision int  // decimal(p, s): at most p-s integer digits, 0 for no limit
+	scale     int
+	decimal   bool   // decimal column: must be a plain decimal number
+	oneOf     string // enum column: its space-separated values
+}
+
+// empty reports whether r has no rules.
+func (r columnRules) empty() bool {
+	return r == columnRules{}
+}
+
+// validationRules derives the validation rules of col's request field.
+// Columns of custom types, points and polygons (checked by normalizePoint
+// and normalizePolygon) and non-string types have none: JSON decoding
+// already enforces their type.
+func validationRules(cfg HandlerGenConfig, col ddl.ColumnDefinition) columnRules {
+	if col.Custom != nil || isAutoColumn(col.Name) || col.Name == "public_id" || (cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn) {
+		return columnRules{}
+	}
+	var r columnRules
+	required := !col.Nullable && col.Default == nil && !hasDefault(cfg, col)
+	switch {
+	case col.References != "":
+		r.required = required
+	case col.Type == ddl.StringType || col.Type == ddl.TextType:
+		r.required = required
+		if col.Length != nil && *col.Length > 0 {
+			r.maxLength = *col.Length
+		}
+		r.email = codegen.CRUD.IsEmailColumn(col.Name)
+	case col.Type == ddl.EnumType:
+		r.required = required
+		r.oneOf = strings.Join(col.EnumValues, " ")
+	case col.Type == ddl.DecimalType:
+		r.required = required
+		r.decimal = true
+		if col.Precision != nil && col.Scale != nil {
+			r.precision, r.scale = *col.Precision, *col.Scale
+		}
+	}
+	return r
+}
+
+// tag renders r as a validate struct tag value, e.g. "required,max=255,email",
+// "decimal=10:2" or "oneof=pending active". The tag documents the rules for the OpenAPI schema and
+// generated tests; the handler checks them explicitly.
+func (r columnRules) tag() string {
+	var rules []string
+	if r.required {
+		rules = append(rules, "required")
+	}
+	if r.maxLength > 0 {
+		rules = append(rules, fmt.Sprintf("max=%d", r.maxLength))
+	}
+	if r.email {
+		rules = append(rules, "email")
+	}
+	if r.decimal {
+		if r.precision > 0 {
+			rules = append(rules, fmt.Sprintf("decimal=%d:%d", r.precision, r.scale))
+		} else {
+			rules = append(rules, "decimal")
+		}
+	}
+	if r.oneOf != "" {
+		rules = append(rules, "oneof="+r.oneOf)
+	}
+	return strings.Join(rules, ",")
+}
+
+// withValidateTag adds the validate tag of col's rules to the struct tag
+// literal tag (including its backquotes).
+func withValidateTag(cfg HandlerGenConfig, col ddl.ColumnDefinition, tag string) string {
+	rules := validationRules(cfg, col)
+	if rules.empty() {
+		return tag
+	}
+	return strings.TrimSuffix(tag, "`") + fmt.Sprintf(" validate:%q`", rules.tag())
+}
+
+// hasValidation reports whether any request field of cfg's table has
+// validation rules, i.e. whether the handlers import shipq/lib/validate.
+func hasValidation(cfg HandlerGenConfig) bool {
+	for _, col := range cfg.Table.Columns {
+		if !validationRules(cfg, col).empty() {
+			return true
+		}
+	}
+	return false
+}
+
+// writeValidation emits the checks of every request field with validation
+// rules, returning a structured 422 listing all invalid fields before the
+// handler touches the database. fieldType returns the request field's Go
+// type; pointer levels are nil-checked, so omitted optional fields pass.
+func writeValidation(buf *bytes.Buffer, cfg HandlerGenConfig, fieldType func(ddl.ColumnDefinition) string) {
+	if !hasValidation(cfg) {
+		return
+	}
+	buf.WriteString("\t// Validate the request against the column definitions\n")
+	buf.WriteString("\tvar v validate.Errors\n")
+	for _, col := range cfg.Table.Columns {
+		rules := validationRules(cfg, col)
+		if rules.empty() {
+			continue
+		}
+		expr := "req." + toPascalCase(col.Name)
+		field := columnJSONName(cfg, col.Name)
+		goType := fieldType(col)
+		var guards []string
+		for strings.HasPrefix(goType, "*") {
+			guards = append(guards, expr+" != nil")
+			goType = goType[1:]
+			expr = "*" + expr
+		}
+
+		var checks []string
+		if rules.required {
+			checks = append(checks, fmt.Sprintf("v.Required(%q, %s)", field, expr))
+		}
+		if rules.maxLength > 0 {
+			checks = append(checks, fmt.Sprintf("v.MaxLength(%q, %s, %d)", field, expr, rules.maxLength))
+		}
+		if rules.email {
+			checks = append(checks, fmt.Sprintf("v.Email(%q, %s)", field, expr))
+		}
+		if rules.decimal {
+			checks = append(checks, fmt.Sprintf("v.Decimal(%q, %s, %d, %d)", field, expr, rules.precision, rules.scale))
+		}
+		if rules.oneOf != "" {
+			values := strings.Fields(rules.oneOf)
+			for i, value := range values {
+				values[i] = strconv.Quote(value)
+			}
+			checks = append(checks, fmt.Sprintf("v.OneOf(%q, %s, %s)", field, expr, strings.Join(values, ", ")))
+		}
+
+		indent := "\t"
+		if len(guards) > 0 {
+			buf.WriteString(fmt.Sprintf("\tif %s {\n", strings.Join(guards, " && ")))
+			indent = "\t\t"
+		}
+		for _, check := range checks {
+			buf.WriteString(indent + check + "\n")
+		}
+		if len(guards) > 0 {
+			buf.WriteString("\t}\n")
+		}
+	}
+	buf.WriteString("\tif err := v.Err(); err != nil {\n")
+	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t}\n\n")
+}
@@ -0,0 +1,133 @@
+package handlergen
+
+import (
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+var (
+	nameLength, pricePrecision, priceScale = 200, 10, 2
+	emptyDefault                           = ""
+)
+
+// validatedColumns are the columns of a "products" table that each take a
+// different validation rule.
+var validatedColumns = []ddl.ColumnDefinition{
+	{Name: "name", Type: ddl.StringType, Length: &nameLength},
+	{Name: "contact_email", Type: ddl.StringType, Nullable: true},
+	{Name: "price", Type: ddl.DecimalType, Precision: &pricePrecision, Scale: &priceScale},
+	{Name: "notes", Type: ddl.TextType, Default: &emptyDefault},
+	{Name: "stock", Type: ddl.IntegerType},
+	{Name: "status", Type: ddl.EnumType, EnumValues: []string{"draft", "live"}},
+	deletedAt,
+}
+
+func TestValidationRules(t *testing.T) {
+	cfg := testConfig("products", validatedColumns...)
+	want := map[string]string{
+		"name":          "required,max=200",
+		"contact_email": "email",
+		"price":         "required,decimal=10:2",
+		"notes":         "", // has a database default
+		"stock":         "", // JSON decoding enforces the type
+		"status":        "required,oneof=draft live",
+		"public_id":     "",
+		"created_at":    "",
+	}
+	for _, col := range cfg.Table.Columns {
+		w, ok := want[col.Name]
+		if !ok {
+			continue
+		}
+		if got := validationRules(cfg, col).tag(); got != w {
+			t.Errorf("validationRules(%s).tag() = %q, want %q", col.Name, got, w)
+		}
+	}
+
+	cfg.Defaults = map[string]string{"name": "Untitled"}
+	name, _ := findColumn(cfg.Table, "name")
+	if got := validationRules(cfg, name).tag(); got != "max=200" {
+		t.Errorf("a column with an API default is not required, got %q", got)
+	}
+}
+
+func TestGenerateCreateHandler_Validation(t *testing.T) {
+	code, err := GenerateCreateHandler(testConfig("products", validatedColumns...), nil)
+	if err != nil {
+		t.Fatalf("GenerateCreateHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "create.go", code)
+
+	if !f.HasImport("myapp/shipq/lib/validate") {
+		t.Error("expected the validate import")
+	}
+	f.AssertField("CreateProductRequest", "Name", "string", `json:"name" validate:"required,max=200"`)
+	f.AssertField("CreateProductRequest", "ContactEmail", "*string", `json:"contact_email,omitempty" validate:"email"`)
+	f.AssertField("CreateProductRequest", "Price", "string", `json:"price" validate:"required,decimal=10:2"`)
+	f.AssertField("CreateProductRequest", "Status", "string", `json:"status" validate:"required,oneof=draft live"`)
+	f.AssertStmts("CreateProduct",
+		"var v validate.Errors",
+		`v.Required("name", req.Name)`,
+		`v.MaxLength("name", req.Name, 200)`,
+		"if req.ContactEmail != nil",
+		`v.Email("contact_email", *req.ContactEmail)`,
+		`v.Decimal("price", req.Price, 10, 2)`,
+		`v.OneOf("status", req.Status, "draft", "live")`,
+		"return nil, err",
+	)
+	if f.HasExpr("CreateProduct", `v.Required("notes", req.Notes)`) {
+		t.Error("notes has a database default and must not be required")
+	}
+	if !f.Before("CreateProduct", "if err := v.Err(); err != nil", "runner.CreateProduct") {
+		t.Error("validation must happen before the insert")
+	}
+}
+
+func TestGenerateUpdateHandler_Validation(t *testing.T) {
+	code, err := GenerateUpdateHandler(testConfig("products", validatedColumns...), nil)
+	if err != nil {
+		t.Fatalf("GenerateUpdateHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "update.go", code)
+
+	if !f.HasImport("myapp/shipq/lib/validate") {
+		t.Error("expected the validate import")
+	}
+	f.AssertField("UpdateProductRequest", "Name", "*string", `json:"name,omitempty" validate:"required,max=200"`)
+	f.AssertStmts("UpdateProduct",
+		"if req.Name != nil",
+		`v.Required("name", *req.Name)`,
+		`v.MaxLength("name", *req.Name, 200)`,
+		"if req.ContactEmail != nil && *req.ContactEmail != nil",
+		`v.Email("contact_email", **req.ContactEmail)`,
+	)
+	if !f.Before("UpdateProduct", "if err := v.Err(); err != nil", "runner.GetProductByPublicID") {
+		t.Error("validation must happen before the lookup")
+	}
+}
+
+func TestGenerateCreateHandler_NoValidation(t *testing.T) {
+	table := ddl.Table{
+		Name: "counters",
+		Columns: []ddl.ColumnDefinition{
+			{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "public_id", Type: ddl.StringType},
+			{Name: "value", Type: ddl.IntegerType},
+		},
+	}
+	code, err := GenerateCreateHandler(HandlerGenConfig{
+		ModulePath: "myapp",
+		TableName:  "counters",
+		Table:      table,
+		Schema:     map[string]ddl.Table{"counters": table},
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCreateHandler failed: %v", err)
+	}
+	f := gofile.Parse(t, "create.go", code)
+	if f.HasImport("myapp/shipq/lib/validate") || f.HasStmt("CreateCounter", "var v validate.Errors") {
+		t.Error("a table without validated columns should not validate")
+	}
+}
@@ -3,9 +3,10 @@ package config
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
+	shipqconfig "github.com/shipq/shipq/config"
 )
 
 // CustomEnvVar represents a user-defined environment variable declared in the
@@ -25,6 +26,7 @@ type CustomEnvVar struct {
 type DevDefaults struct {
 	DatabaseURL  string
 	Port         string
+	GRPCPort     string
 	CookieSecret string
 	// Files
 	S3Bucket             string
@@ -62,6 +64,16 @@ type ConfigGenConfig struct {
 	EmailEnabled   bool           // true if [email] section exists in shipq.ini
 	DevDefaults    DevDefaults    // compile-time dev defaults from shipq.ini
 	CustomEnvVars  []CustomEnvVar // user-defined env vars from [env] section
+	// DatabaseTLSEnvs lists the GO_ENV values in which CheckDatabaseTLS
+	// demands verified TLS ([db] require_tls). nil means
+	// shipqconfig.DefaultDatabaseTLSEnvs; an empty slice disables the check.
+	DatabaseTLSEnvs []string
+	// GRPCEnabled adds GRPC_PORT, the port of the gRPC server started by
+	// cmd/server when [server] grpc = true.
+	GRPCEnabled bool
+	// DatabasePool is the [db] connection pool settings applied by
+	// ConfigureDatabasePool.
+	DatabasePool shipqconfig.DatabasePoolConfig
 }
 
 // GenerateConfig generates the config/config.go file.
@@ -96,7 +108,13 @@ func GenerateConfig(cfg ConfigGenConfig) ([]byte, error) {
 	generateParseDatabaseURL(&buf, cfg)
 	generateIsLocalhostURL(&buf)
 
-	formatted, err := format.Source(buf.Bytes())
+	// Generate CheckDatabaseTLS
+	generateCheckDatabaseTLS(&buf, cfg)
+
+	// Generate ConfigureDatabasePool
+	generateConfigureDatabasePool(&buf, cfg)
+
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format config.go: %w\nunformatted:\n%s", err, buf.String())
@@ -108,11 +126,14 @@ func GenerateConfig(cfg ConfigGenConfig) ([]byte, error) {
 // generateConfigImports writes the import block for config.go.
 func generateConfigImports(buf *bytes.Buffer, cfg ConfigGenConfig) {
 	buf.WriteString("import (\n")
+	buf.WriteString("\t\"database/sql\"\n")
 	buf.WriteString("\t\"fmt\"\n")
 	buf.WriteString("\t\"log/slog\"\n")
 	buf.WriteString("\t\"os\"\n")
 	buf.WriteString("\t\"reflect\"\n")
+	buf.WriteString("\t\"strconv\"\n")
 	buf.WriteString("\t\"strings\"\n")
+	buf.WriteString("\t\"time\"\n")
 	buf.WriteString("\n")
 	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/shipq/lib/logging"))
 	buf.WriteString(")\n\n")
@@ -164,6 +185,10 @@ func generateDevDefaults(buf *bytes.Buffer, cfg ConfigGenConfig) {
 	writeConst(buf, "devGO_ENV", "development")
 	writeConst(buf, "devCOOKIE_SECRET", d.CookieSecret)
 
+	if cfg.GRPCEnabled {
+		writeConst(buf, "devGRPC_PORT", d.GRPCPort)
+	}
+
 	if cfg.FilesEnabled {
 		writeConst(buf, "devS3_BUCKET", d.S3Bucket)
 		writeConst(buf, "devS3_REGION", d.S3Region)
@@ -218,6 +243,10 @@ func generateDevDefaults(buf *bytes.Buffer, cfg ConfigGenConfig) {
 	writeMapEntry(buf, "GO_ENV", "devGO_ENV")
 	writeMapEntry(buf, "COOKIE_SECRET", "devCOOKIE_SECRET")
 
+	if cfg.GRPCEnabled {
+		writeMapEntry(buf, "GRPC_PORT", "devGRPC_PORT")
+	}
+
 	if cfg.FilesEnabled {
 		writeMapEntry(buf, "S3_BUCKET", "devS3_BUCKET")
 		writeMapEntry(buf, "S3_REGION", "devS3_REGION")
@@ -309,6 +338,9 @@ type RequiredConfig struct {
 	GO_ENV        string
 	COOKIE_SECRET string
 `)
+	if cfg.GRPCEnabled {
+		buf.WriteString("\tGRPC_PORT     string\n")
+	}
 	if cfg.FilesEnabled {
 		buf.WriteString("\tS3_BUCKET             string\n")
 		buf.WriteString("\tS3_REGION             string\n")
@@ -442,3 +474,152 @@ func generateIsLocalhostURL(buf *bytes.Buffer) {
 	buf.WriteString(codegen.ExportedIsLocalhostFunc)
 	buf.WriteString("\n")
 }
+
+// generateCheckDatabaseTLS writes CheckDatabaseTLS, which cmd/server and
+// cmd/worker call before opening the database, and the dialect's rule for
+// what counts as a verified TLS connection.
+func generateCheckDatabaseTLS(buf *bytes.Buffer, cfg ConfigGenConfig) {
+	envs := cfg.DatabaseTLSEnvs
+	if envs == nil {
+		envs = shipqconfig.DefaultDatabaseTLSEnvs
+	}
+	buf.WriteString("// databaseTLSEnvs lists the GO_ENV values in which DATABASE_URL must use\n")
+	buf.WriteString("// verified TLS ([db] require_tls in shipq.ini).\n")
+	buf.WriteString("var databaseTLSEnvs = []string{")
+	for i, env := range envs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(buf, "%q", env)
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(`// CheckDatabaseTLS returns an error when GO_ENV is listed in databaseTLSEnvs
+// and DATABASE_URL does not require a verified TLS connection. Loopback hosts
+// (e.g. a local auth proxy) and SQLite files are exempt. Setting
+// DATABASE_ALLOW_INSECURE=true logs a warning instead of failing.
+func CheckDatabaseTLS() error {
+	required := false
+	for _, env := range databaseTLSEnvs {
+		if env == Settings.GO_ENV {
+			required = true
+		}
+	}
+	if !required || IsLocalhostURL(Settings.DATABASE_URL) {
+		return nil
+	}
+	err := requireDatabaseTLS(Settings.DATABASE_URL)
+	if err == nil {
+		return nil
+	}
+	if os.Getenv("DATABASE_ALLOW_INSECURE") == "true" {
+		Logger.Warn("database connection is not verified TLS; continuing because DATABASE_ALLOW_INSECURE=true", "reason", err.Error())
+		return nil
+	}
+	return fmt.Errorf("%w (required when GO_ENV=%s; set DATABASE_ALLOW_INSECURE=true to override)", err, Settings.GO_ENV)
+}
+
+// databaseURLParam returns the value of the query parameter key in rawURL.
+func databaseURLParam(rawURL, key string) string {
+	_, query, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return ""
+	}
+	value := ""
+	for _, kv := range strings.Split(query, "&") {
+		if v, ok := strings.CutPrefix(kv, key+"="); ok {
+			value = v
+		}
+	}
+	return value
+}
+
+`)
+	switch cfg.Dialect {
+	case "postgres":
+		buf.WriteString(`// requireDatabaseTLS accepts only sslmode=verify-full, which encrypts the
+// connection and checks the server certificate against its hostname.
+func requireDatabaseTLS(rawURL string) error {
+	if mode := databaseURLParam(rawURL, "sslmode"); mode != "verify-full" {
+		return fmt.Errorf("DATABASE_URL must set sslmode=verify-full, got sslmode=%q", mode)
+	}
+	return nil
+}
+`)
+	case "mysql":
+		buf.WriteString(`// requireDatabaseTLS accepts only tls=true, which makes go-sql-driver/mysql
+// encrypt the connection and verify the server certificate.
+func requireDatabaseTLS(rawURL string) error {
+	if mode := databaseURLParam(rawURL, "tls"); mode != "true" {
+		return fmt.Errorf("DATABASE_URL must set tls=true, got tls=%q", mode)
+	}
+	return nil
+}
+`)
+	default:
+		buf.WriteString(`// requireDatabaseTLS accepts every URL: SQLite databases are local files.
+func requireDatabaseTLS(rawURL string) error {
+	return nil
+}
+`)
+	}
+}
+
+// generateConfigureDatabasePool writes ConfigureDatabasePool, which cmd/server
+// and cmd/worker call right after sql.Open, with the [db] pool settings
+// baked in as its defaults.
+func generateConfigureDatabasePool(buf *bytes.Buffer, cfg ConfigGenConfig) {
+	p := cfg.DatabasePool
+	buf.WriteString("\n// databasePool holds the connection pool settings from [db] in shipq.ini,\n")
+	buf.WriteString("// keyed by the environment variable that overrides each. Empty leaves the\n")
+	buf.WriteString("// database/sql default.\n")
+	buf.WriteString("var databasePool = map[string]string{\n")
+	fmt.Fprintf(buf, "\t\"DB_MAX_OPEN_CONNS\":    %q,\n", p.MaxOpenConns)
+	fmt.Fprintf(buf, "\t\"DB_MAX_IDLE_CONNS\":    %q,\n", p.MaxIdleConns)
+	fmt.Fprintf(buf, "\t\"DB_CONN_MAX_LIFETIME\": %q,\n", p.ConnMaxLifetime)
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(`// ConfigureDatabasePool applies the connection pool settings to db:
+// DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME (a Go
+// duration such as "30m") when set, otherwise [db] max_open_conns,
+// max_idle_conns and conn_max_lifetime from shipq.ini.
+func ConfigureDatabasePool(db *sql.DB) error {
+	setting := func(name string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return databasePool[name]
+	}
+	count := func(name string) (int, bool, error) {
+		v := setting(name)
+		if v == "" {
+			return 0, false, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, false, fmt.Errorf("%s must be a non-negative integer, got %q", name, v)
+		}
+		return n, true, nil
+	}
+
+	if n, ok, err := count("DB_MAX_OPEN_CONNS"); err != nil {
+		return err
+	} else if ok {
+		db.SetMaxOpenConns(n)
+	}
+	if n, ok, err := count("DB_MAX_IDLE_CONNS"); err != nil {
+		return err
+	} else if ok {
+		db.SetMaxIdleConns(n)
+	}
+	if v := setting("DB_CONN_MAX_LIFETIME"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("DB_CONN_MAX_LIFETIME must be a duration such as 30m, got %q", v)
+		}
+		db.SetConnMaxLifetime(d)
+	}
+	return nil
+}
+`)
+}
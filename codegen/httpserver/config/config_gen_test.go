@@ -5,6 +5,9 @@ import (
 	"go/token"
 	"strings"
 	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	shipqconfig "github.com/shipq/shipq/config"
 )
 
 func TestGenerateConfig_ValidGo(t *testing.T) {
@@ -1239,3 +1242,115 @@ func TestGenerateConfigTest_ContainsExpectedElements(t *testing.T) {
 		t.Error("generated code must NOT import from github.com/shipq/shipq")
 	}
 }
+
+func TestGenerateConfig_CheckDatabaseTLS_PerDialect(t *testing.T) {
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{"postgres", `if mode := databaseURLParam(rawURL, "sslmode"); mode != "verify-full"`},
+		{"mysql", `if mode := databaseURLParam(rawURL, "tls"); mode != "true"`},
+		{"sqlite", "return nil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			code, err := GenerateConfig(ConfigGenConfig{
+				ModulePath:  "example.com/myapp",
+				Dialect:     tt.dialect,
+				DevDefaults: DevDefaults{Port: "8080", CookieSecret: "dev-secret"},
+			})
+			if err != nil {
+				t.Fatalf("GenerateConfig() error = %v", err)
+			}
+			f := gofile.Parse(t, "config.go", code)
+			f.AssertSignature("CheckDatabaseTLS", "func CheckDatabaseTLS() error")
+			f.AssertStmts("CheckDatabaseTLS",
+				"if !required || IsLocalhostURL(Settings.DATABASE_URL)",
+				"err := requireDatabaseTLS(Settings.DATABASE_URL)",
+				`if os.Getenv("DATABASE_ALLOW_INSECURE") == "true"`,
+			)
+			if got := f.TopStmts("requireDatabaseTLS"); len(got) == 0 || got[0] != tt.want {
+				t.Errorf("requireDatabaseTLS starts with %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateConfig_DatabaseTLSEnvs(t *testing.T) {
+	tests := []struct {
+		name string
+		envs []string
+		want string
+	}{
+		{"default", nil, `[]string{"production"}`},
+		{"custom", []string{"production", "staging"}, `[]string{"production", "staging"}`},
+		{"disabled", []string{}, `[]string{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := GenerateConfig(ConfigGenConfig{
+				ModulePath:      "example.com/myapp",
+				Dialect:         "postgres",
+				DevDefaults:     DevDefaults{Port: "8080", CookieSecret: "dev-secret"},
+				DatabaseTLSEnvs: tt.envs,
+			})
+			if err != nil {
+				t.Fatalf("GenerateConfig() error = %v", err)
+			}
+			if got := gofile.Parse(t, "config.go", code).Value("databaseTLSEnvs"); got != tt.want {
+				t.Errorf("databaseTLSEnvs = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateConfig_GRPCPort(t *testing.T) {
+	cfg := ConfigGenConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     "sqlite",
+		DevDefaults: DevDefaults{Port: "8080", GRPCPort: "9090"},
+	}
+	code, err := GenerateConfig(cfg)
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+	if _, _, ok := gofile.Parse(t, "config.go", code).Field("RequiredConfig", "GRPC_PORT"); ok {
+		t.Error("GRPC_PORT generated without [server] grpc")
+	}
+
+	cfg.GRPCEnabled = true
+	code, err = GenerateConfig(cfg)
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+	f := gofile.Parse(t, "config.go", code)
+	if typ, _, _ := f.Field("RequiredConfig", "GRPC_PORT"); typ != "string" {
+		t.Errorf("RequiredConfig.GRPC_PORT is %q, want string", typ)
+	}
+	if got := f.Value("devGRPC_PORT"); got != `"9090"` {
+		t.Errorf("devGRPC_PORT = %s", got)
+	}
+	if !f.HasExpr("", `"GRPC_PORT": devGRPC_PORT`) {
+		t.Errorf("GRPC_PORT missing from devDefaultMap:\n%s", code)
+	}
+}
+
+func TestGenerateConfig_DatabasePool(t *testing.T) {
+	code, err := GenerateConfig(ConfigGenConfig{
+		ModulePath:   "example.com/myapp",
+		Dialect:      "postgres",
+		DevDefaults:  DevDefaults{Port: "8080"},
+		DatabasePool: shipqconfig.DatabasePoolConfig{MaxOpenConns: "25", ConnMaxLifetime: "30m"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateConfig() error = %v", err)
+	}
+	f := gofile.Parse(t, "config.go", code)
+	f.AssertSignature("ConfigureDatabasePool", "func ConfigureDatabasePool(db *sql.DB) error")
+	for _, kv := range []string{`"DB_MAX_OPEN_CONNS": "25"`, `"DB_MAX_IDLE_CONNS": ""`, `"DB_CONN_MAX_LIFETIME": "30m"`} {
+		if !f.HasExpr("", kv) {
+			t.Errorf("databasePool is missing %s", kv)
+		}
+	}
+	f.AssertStmts("ConfigureDatabasePool", "db.SetMaxOpenConns(n)", "db.SetMaxIdleConns(n)", "db.SetConnMaxLifetime(d)")
+}
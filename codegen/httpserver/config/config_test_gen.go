@@ -3,7 +3,8 @@ package config
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateConfigTest generates the config/config_test.go file.
@@ -19,7 +20,7 @@ func GenerateConfigTest(cfg ConfigGenConfig) ([]byte, error) {
 	// Generate test functions
 	generateLoggerTests(&buf)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format config_test.go: %w\nunformatted:\n%s", err, buf.String())
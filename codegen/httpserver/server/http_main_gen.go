@@ -3,7 +3,9 @@ package server
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
+	"github.com/shipq/shipq/db/portsql/migrate"
 )
 
 // HTTPMainGenConfig holds configuration for generating the main.go entrypoint.
@@ -15,6 +17,11 @@ type HTTPMainGenConfig struct {
 	HasAuth     bool   // true when at least one channel requires auth (i.e., is not public)
 	AutoMigrate bool   // true when [db] auto_migrate = true and schema.json exists; emits migrate-on-boot block
 	StripPrefix string // URL prefix to strip from incoming requests (e.g., "/api"); mirrors HTTPServerGenConfig.StripPrefix
+	AccessLog   bool   // true when [logging] is configured; channel builds decorate with api.AccessLogOptions
+	MigrationUI bool   // true when schema.json exists; mounts the migration page outside production
+	NoRecover   bool   // [server] recover_panics = false; channel builds skip api.Recover
+	GRPC        bool   // [server] grpc = true; serves api.NewGRPCServer on GRPC_PORT alongside HTTP
+	Telemetry   bool   // [server] telemetry = otel; wraps the runner in queries.Traced (and, with channels, the mux in telemetry.Handler)
 }
 
 // GenerateHTTPMain generates the main.go entrypoint for the HTTP server.
@@ -30,7 +37,7 @@ func GenerateHTTPMain(cfg HTTPMainGenConfig) ([]byte, error) {
 	// Generate main function
 	generateMainFunc(&buf, cfg)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format main.go: %w\nunformatted:\n%s", err, buf.String())
@@ -46,6 +53,9 @@ func generateMainImports(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 		buf.WriteString("\t\"context\"\n")
 	}
 	buf.WriteString("\t\"database/sql\"\n")
+	if cfg.GRPC {
+		buf.WriteString("\t\"net\"\n")
+	}
 	buf.WriteString("\t\"net/http\"\n")
 	buf.WriteString("\t\"os\"\n")
 	buf.WriteString("\n")
@@ -76,15 +86,25 @@ func generateMainImports(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 		// Logging import (for manual Decorate call)
 		loggingPkg := cfg.ModulePath + "/shipq/lib/logging"
 		fmt.Fprintf(buf, "\t%q\n", loggingPkg)
-		if cfg.HasAuth {
-			// Queries import (for runner type in auth wrappers)
-			queriesPkg := cfg.ModulePath + "/shipq/queries"
-			fmt.Fprintf(buf, "\t%q\n", queriesPkg)
+		if cfg.Telemetry {
+			// Telemetry import (for the manual telemetry.Handler call)
+			telemetryPkg := cfg.ModulePath + "/shipq/lib/telemetry"
+			fmt.Fprintf(buf, "\t%q\n", telemetryPkg)
 		}
 	}
 
-	// Auto-migrate import
-	if cfg.AutoMigrate {
+	// Queries import (for runner type in auth wrappers, and queries.Traced)
+	if (cfg.HasChannels && cfg.HasAuth) || cfg.Telemetry {
+		queriesPkg := cfg.ModulePath + "/shipq/queries"
+		fmt.Fprintf(buf, "\t%q\n", queriesPkg)
+	}
+
+	// Health check handler
+	httputilPkg := cfg.ModulePath + "/shipq/lib/httputil"
+	fmt.Fprintf(buf, "\t%q\n", httputilPkg)
+
+	// Generated migrate package (auto-migrate, the migration UI and /healthz)
+	if cfg.hasMigrationPlan() {
 		migratePkg := cfg.ModulePath + "/shipq/db/migrate"
 		fmt.Fprintf(buf, "\tdbmigrate %q\n", migratePkg)
 	}
@@ -105,6 +125,12 @@ func generateMainImports(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 func generateMainFunc(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 	buf.WriteString("func main() {\n")
 
+	// Refuse a plaintext database connection where [db] require_tls applies
+	buf.WriteString("\tif err := config.CheckDatabaseTLS(); err != nil {\n")
+	buf.WriteString("\t\tconfig.Logger.Error(\"insecure database connection\", \"error\", err.Error())\n")
+	buf.WriteString("\t\tos.Exit(1)\n")
+	buf.WriteString("\t}\n\n")
+
 	// Database connection using config.ParseDatabaseURL
 	buf.WriteString("\tdriver, dsn := config.ParseDatabaseURL(config.Settings.DATABASE_URL)\n")
 	buf.WriteString("\tdb, err := sql.Open(driver, dsn)\n")
@@ -114,6 +140,11 @@ func generateMainFunc(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 	buf.WriteString("\t}\n")
 	buf.WriteString("\tdefer db.Close()\n\n")
 
+	buf.WriteString("\tif err := config.ConfigureDatabasePool(db); err != nil {\n")
+	buf.WriteString("\t\tconfig.Logger.Error(\"invalid database pool settings\", \"error\", err.Error())\n")
+	buf.WriteString("\t\tos.Exit(1)\n")
+	buf.WriteString("\t}\n\n")
+
 	// Verify connection
 	buf.WriteString("\tif err := db.Ping(); err != nil {\n")
 	buf.WriteString("\t\tconfig.Logger.Error(\"failed to connect to database\", \"error\", err.Error())\n")
@@ -132,7 +163,12 @@ func generateMainFunc(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 	}
 
 	// Create query runner
-	buf.WriteString("\trunner := dbrunner.NewQueryRunner(db)\n\n")
+	if cfg.Telemetry {
+		buf.WriteString("\t// Trace every query (configured via [server] telemetry = otel in shipq.ini)\n")
+		buf.WriteString("\trunner := queries.Traced(dbrunner.NewQueryRunner(db))\n\n")
+	} else {
+		buf.WriteString("\trunner := dbrunner.NewQueryRunner(db)\n\n")
+	}
 
 	if cfg.HasChannels {
 		generateMainFuncWithChannels(buf, cfg)
@@ -146,6 +182,13 @@ func generateMainFunc(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 // generateMainFuncWithoutChannels writes the simple handler + ListenAndServe path.
 func generateMainFuncWithoutChannels(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 	buf.WriteString("\thandler := api.NewMux(db, runner, config.Logger)\n\n")
+	if cfg.GRPC {
+		generateGRPCBlock(buf)
+	}
+	if cfg.MigrationUI {
+		generateMigrationUIBlock(buf)
+	}
+	generateHealthzBlock(buf, cfg)
 
 	buf.WriteString("\taddr := \":\" + config.Settings.PORT\n")
 	buf.WriteString("\tconfig.Logger.Info(\"starting server\", \"addr\", addr)\n")
@@ -201,19 +244,31 @@ func generateMainFuncWithChannels(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 	}
 
 	// Use SetupMux to get the raw mux, register channel routes, then wrap
-	buf.WriteString("\t// Build mux: handler routes + channel routes + logging middleware\n")
+	buf.WriteString("\t// Build mux: handler routes + channel routes + recovery and logging middleware\n")
 	buf.WriteString("\tmux := api.SetupMux(db, runner)\n")
 	if cfg.HasAuth {
 		buf.WriteString("\tapi.RegisterChannelRoutes(mux, queue, transport, db, runner, checkAuth, checkRBAC)\n")
 	} else {
 		buf.WriteString("\tapi.RegisterChannelRoutes(mux, queue, transport, db, runner)\n")
 	}
+	optsRef := ""
+	if cfg.AccessLog {
+		optsRef = "api.AccessLogOptions"
+	}
+	recovered := traceCall(cfg.Telemetry, recoverCall(!cfg.NoRecover, "api", "config.Logger", "mux"))
 	if cfg.StripPrefix != "" {
-		fmt.Fprintf(buf, "\tvar handler http.Handler = http.StripPrefix(%q, mux)\n", cfg.StripPrefix)
-		fmt.Fprintf(buf, "\thandler = logging.Decorate([]string{%q}, config.Logger, handler)\n\n", cfg.StripPrefix+"/health")
+		fmt.Fprintf(buf, "\tvar handler http.Handler = http.StripPrefix(%q, %s)\n", cfg.StripPrefix, recovered)
+		fmt.Fprintf(buf, "\thandler = %s\n\n", decorateCall(cfg.StripPrefix+"/health", "config.Logger", "handler", optsRef))
 	} else {
-		buf.WriteString("\thandler := logging.Decorate([]string{\"/health\"}, config.Logger, mux)\n\n")
+		fmt.Fprintf(buf, "\thandler := %s\n\n", decorateCall("/health", "config.Logger", recovered, optsRef))
 	}
+	if cfg.GRPC {
+		generateGRPCBlock(buf)
+	}
+	if cfg.MigrationUI {
+		generateMigrationUIBlock(buf)
+	}
+	generateHealthzBlock(buf, cfg)
 
 	buf.WriteString("\taddr := \":\" + config.Settings.PORT\n")
 	buf.WriteString("\tconfig.Logger.Info(\"starting server\", \"addr\", addr)\n")
@@ -223,6 +278,74 @@ func generateMainFuncWithChannels(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
 	buf.WriteString("\t}\n")
 }
 
+// generateGRPCBlock starts the gRPC server from api.NewGRPCServer on
+// GRPC_PORT. Its RPCs call handler, the API mux, so they go through the
+// same middleware and handlers as HTTP requests.
+func generateGRPCBlock(buf *bytes.Buffer) {
+	buf.WriteString("\t// gRPC server (configured via [server] grpc = true in shipq.ini)\n")
+	buf.WriteString("\tgrpcServer, err := api.NewGRPCServer(handler)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\tconfig.Logger.Error(\"failed to create gRPC server\", \"error\", err.Error())\n")
+	buf.WriteString("\t\tos.Exit(1)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tgrpcAddr := \":\" + config.Settings.GRPC_PORT\n")
+	buf.WriteString("\tgrpcListener, err := net.Listen(\"tcp\", grpcAddr)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\tconfig.Logger.Error(\"failed to listen for gRPC\", \"error\", err.Error())\n")
+	buf.WriteString("\t\tos.Exit(1)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tgo func() {\n")
+	buf.WriteString("\t\tconfig.Logger.Info(\"starting gRPC server\", \"addr\", grpcAddr)\n")
+	buf.WriteString("\t\tif err := grpcServer.Serve(grpcListener); err != nil {\n")
+	buf.WriteString("\t\t\tconfig.Logger.Error(\"gRPC server failed\", \"error\", err.Error())\n")
+	buf.WriteString("\t\t\tos.Exit(1)\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}()\n\n")
+}
+
+// generateMigrationUIBlock wraps handler so that the migration page from
+// the generated migrate package answers under migrate.UIPath. It sits
+// outside the API mux, and outside any StripPrefix, so the page is at the
+// same URL in every project. Like /docs it is never served in production.
+func generateMigrationUIBlock(buf *bytes.Buffer) {
+	buf.WriteString("\t// Migration UI (development and test modes only)\n")
+	buf.WriteString("\tif goEnv := os.Getenv(\"GO_ENV\"); goEnv == \"\" || goEnv == \"development\" || goEnv == \"test\" {\n")
+	buf.WriteString("\t\tmigrationUI, err := dbmigrate.UIHandler(db)\n")
+	buf.WriteString("\t\tif err != nil {\n")
+	buf.WriteString("\t\t\tconfig.Logger.Error(\"failed to load migration plan\", \"error\", err.Error())\n")
+	buf.WriteString("\t\t\tos.Exit(1)\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tdevMux := http.NewServeMux()\n")
+	fmt.Fprintf(buf, "\t\tdevMux.Handle(%q, migrationUI)\n", migrate.UIPath)
+	buf.WriteString("\t\tdevMux.Handle(\"/\", handler)\n")
+	buf.WriteString("\t\thandler = devMux\n")
+	fmt.Fprintf(buf, "\t\tconfig.Logger.Info(\"migration UI enabled\", \"path\", %q)\n", migrate.UIPath)
+	buf.WriteString("\t}\n\n")
+}
+
+// generateHealthzBlock wraps handler so that httputil.HealthzHandler answers
+// under httputil.HealthzPath: a database ping plus, when the project has a
+// migration plan, the migrations not yet applied. Like the migration page it
+// sits outside the API mux, StripPrefix and access logging, and it is served
+// in every environment.
+func generateHealthzBlock(buf *bytes.Buffer, cfg HTTPMainGenConfig) {
+	pending := "nil"
+	if cfg.hasMigrationPlan() {
+		pending = "dbmigrate.Pending"
+	}
+	buf.WriteString("\t// Health check: database ping and migration status\n")
+	buf.WriteString("\thealthMux := http.NewServeMux()\n")
+	fmt.Fprintf(buf, "\thealthMux.Handle(httputil.HealthzPath, httputil.HealthzHandler(db, %s))\n", pending)
+	buf.WriteString("\thealthMux.Handle(\"/\", handler)\n")
+	buf.WriteString("\thandler = healthMux\n\n")
+}
+
+// hasMigrationPlan reports whether the generated migrate package, which
+// embeds schema.json, exists for main.go to import.
+func (cfg HTTPMainGenConfig) hasMigrationPlan() bool {
+	return cfg.AutoMigrate || cfg.MigrationUI
+}
+
 // getDriverImport returns the import path for the database driver.
 func getDriverImport(dialect string) string {
 	switch dialect {
@@ -232,6 +355,8 @@ func getDriverImport(dialect string) string {
 		return "modernc.org/sqlite"
 	case "mysql":
 		return "github.com/go-sql-driver/mysql"
+	case "mssql":
+		return "github.com/microsoft/go-mssqldb"
 	default:
 		// Default to mysql
 		return "github.com/go-sql-driver/mysql"
@@ -1,10 +1,13 @@
 package server
 
 import (
+	"go/ast"
 	"go/parser"
 	"go/token"
 	"strings"
 	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 )
 
 // ── HasChannels + HasAuth tests ──────────────────────────────────────────────
@@ -1181,3 +1184,211 @@ func TestGetDriverImport(t *testing.T) {
 		})
 	}
 }
+
+// ── AccessLog ───────────────────────────────────────────────────────────────
+
+func TestGenerateHTTPMain_HasChannels_AccessLog_UsesOptions(t *testing.T) {
+	cfg := HTTPMainGenConfig{
+		ModulePath:  "example.com/myapp",
+		OutputPkg:   "api",
+		DBDialect:   "sqlite",
+		HasChannels: true,
+		AccessLog:   true,
+	}
+
+	code, err := GenerateHTTPMain(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPMain() error = %v", err)
+	}
+
+	codeStr := string(code)
+	want := `logging.DecorateWithOptions([]string{"/health"}, config.Logger, api.AccessLogOptions, api.Recover(config.Logger, mux))`
+	if !strings.Contains(codeStr, want) {
+		t.Errorf("expected %q in generated main.go\n%s", want, codeStr)
+	}
+
+	_, err = parser.ParseFile(token.NewFileSet(), "", code, parser.AllErrors)
+	if err != nil {
+		t.Errorf("generated code is not valid Go: %v\n%s", err, codeStr)
+	}
+}
+
+// ── Migration UI tests ───────────────────────────────────────────────────────
+
+func TestGenerateHTTPMain_MigrationUI(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		cfg := HTTPMainGenConfig{
+			ModulePath:  "example.com/myapp",
+			OutputPkg:   "api",
+			DBDialect:   "sqlite",
+			HasChannels: hasChannels,
+			MigrationUI: true,
+		}
+		code, err := GenerateHTTPMain(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPMain() error = %v", err)
+		}
+		f := gofile.Parse(t, "main.go", code)
+		if !f.HasImport("example.com/myapp/shipq/db/migrate") {
+			t.Errorf("channels=%v: missing the migrate package import", hasChannels)
+		}
+		f.AssertStmts("main",
+			`if goEnv := os.Getenv("GO_ENV"); goEnv == "" || goEnv == "development" || goEnv == "test"`,
+			"migrationUI, err := dbmigrate.UIHandler(db)",
+			`devMux.Handle("/__migrations/", migrationUI)`,
+			`devMux.Handle("/", handler)`,
+		)
+		if !f.Before("main", "handler = devMux", "http.ListenAndServe") {
+			t.Errorf("channels=%v: the UI must be mounted before the server starts", hasChannels)
+		}
+
+		cfg.MigrationUI = false
+		code, err = GenerateHTTPMain(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPMain() error = %v", err)
+		}
+		if gofile.Parse(t, "main.go", code).HasImport("example.com/myapp/shipq/db/migrate") {
+			t.Errorf("channels=%v: migrate package imported without a migration UI", hasChannels)
+		}
+	}
+}
+
+func TestGenerateHTTPMain_ChecksDatabaseTLSBeforeOpen(t *testing.T) {
+	code, err := GenerateHTTPMain(HTTPMainGenConfig{
+		ModulePath: "example.com/myapp",
+		OutputPkg:  "api",
+		DBDialect:  "postgres",
+	})
+	if err != nil {
+		t.Fatalf("GenerateHTTPMain() error = %v", err)
+	}
+	src := string(code)
+	checkIdx := strings.Index(src, "if err := config.CheckDatabaseTLS(); err != nil {")
+	if checkIdx < 0 {
+		t.Fatalf("missing CheckDatabaseTLS call in:\n%s", src)
+	}
+	if checkIdx > strings.Index(src, "sql.Open(driver, dsn)") {
+		t.Error("CheckDatabaseTLS must run before the database is opened")
+	}
+}
+
+func TestGenerateHTTPMain_GRPC(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		cfg := HTTPMainGenConfig{
+			ModulePath:  "example.com/myapp",
+			OutputPkg:   "api",
+			DBDialect:   "sqlite",
+			HasChannels: hasChannels,
+			MigrationUI: true,
+			GRPC:        true,
+		}
+		code, err := GenerateHTTPMain(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPMain() error = %v", err)
+		}
+		f := gofile.Parse(t, "main.go", code)
+		if !f.HasImport("net") {
+			t.Errorf("channels=%v: missing the net import", hasChannels)
+		}
+		f.AssertStmts("main",
+			"grpcServer, err := api.NewGRPCServer(handler)",
+			`grpcAddr := ":" + config.Settings.GRPC_PORT`,
+		)
+		f.AssertExprs("main", "grpcServer.Serve(grpcListener)")
+		if !f.Before("main", "grpcServer, err := api.NewGRPCServer(handler)", "handler = devMux") {
+			t.Errorf("channels=%v: gRPC must be served by the API handler, not the dev mux", hasChannels)
+		}
+	}
+
+	code, err := GenerateHTTPMain(HTTPMainGenConfig{ModulePath: "example.com/myapp", OutputPkg: "api", DBDialect: "sqlite"})
+	if err != nil {
+		t.Fatalf("GenerateHTTPMain() error = %v", err)
+	}
+	f := gofile.Parse(t, "main.go", code)
+	if f.HasImport("net") || f.Calls("main", "api.NewGRPCServer") != 0 {
+		t.Error("gRPC server generated without [server] grpc")
+	}
+}
+
+func TestGenerateHTTPMain_Telemetry(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		cfg := HTTPMainGenConfig{
+			ModulePath:  "example.com/myapp",
+			OutputPkg:   "api",
+			DBDialect:   "postgres",
+			HasChannels: hasChannels,
+			Telemetry:   true,
+		}
+		code, err := GenerateHTTPMain(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPMain() error = %v", err)
+		}
+		f := gofile.Parse(t, "main.go", code)
+		if !f.HasImport("example.com/myapp/shipq/queries") {
+			t.Errorf("channels=%v: missing queries import", hasChannels)
+		}
+		f.AssertStmts("main", "runner := queries.Traced(dbrunner.NewQueryRunner(db))")
+		// Without channels NewMux traces requests, so main.go only does it
+		// when it builds the mux itself.
+		traced := f.HasExpr("main", "telemetry.Handler(api.Recover(config.Logger, mux))")
+		if traced != hasChannels || f.HasImport("example.com/myapp/shipq/lib/telemetry") != hasChannels {
+			t.Errorf("channels=%v: main.go wraps the mux in telemetry.Handler = %v", hasChannels, traced)
+		}
+	}
+}
+
+func TestGenerateHTTPMain_DatabasePool(t *testing.T) {
+	code, err := GenerateHTTPMain(HTTPMainGenConfig{ModulePath: "example.com/myapp", OutputPkg: "api", DBDialect: "postgres"})
+	if err != nil {
+		t.Fatalf("GenerateHTTPMain() error = %v", err)
+	}
+	f := gofile.Parse(t, "main.go", code)
+	f.AssertStmts("main", "if err := config.ConfigureDatabasePool(db); err != nil")
+	if !f.Before("main", "if err := config.ConfigureDatabasePool(db); err != nil", "if err := db.Ping(); err != nil") {
+		t.Error("the pool must be configured before the first connection is made")
+	}
+}
+
+func TestGenerateHTTPMain_Healthz(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		for _, withPlan := range []bool{false, true} {
+			code, err := GenerateHTTPMain(HTTPMainGenConfig{
+				ModulePath:  "example.com/myapp",
+				OutputPkg:   "api",
+				DBDialect:   "sqlite",
+				HasChannels: hasChannels,
+				MigrationUI: withPlan,
+				StripPrefix: "/api",
+			})
+			if err != nil {
+				t.Fatalf("GenerateHTTPMain() error = %v", err)
+			}
+			f := gofile.Parse(t, "main.go", code)
+			if !f.HasImport("example.com/myapp/shipq/lib/httputil") {
+				t.Errorf("channels=%v plan=%v: missing httputil import", hasChannels, withPlan)
+			}
+
+			pending := "nil"
+			if withPlan {
+				pending = "dbmigrate.Pending"
+			}
+			f.AssertStmts("main",
+				"healthMux.Handle(httputil.HealthzPath, httputil.HealthzHandler(db, "+pending+"))",
+				`healthMux.Handle("/", handler)`,
+			)
+			// Mounted last, so /healthz is outside StripPrefix and the dev mux.
+			var last ast.Expr
+			ast.Inspect(f.Func("main"), func(n ast.Node) bool {
+				if as, ok := n.(*ast.AssignStmt); ok {
+					if id, ok := as.Lhs[0].(*ast.Ident); ok && id.Name == "handler" {
+						last = as.Rhs[0]
+					}
+				}
+				return true
+			})
+			if id, ok := last.(*ast.Ident); !ok || id.Name != "healthMux" {
+				t.Errorf("channels=%v plan=%v: /healthz must wrap the final handler", hasChannels, withPlan)
+			}
+		}
+	}
+}
@@ -3,12 +3,17 @@ package server
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+	"net/http"
 	"path"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
+	"github.com/shipq/shipq/config"
 )
 
 // HTTPServerGenConfig holds configuration for generating the HTTP server.
@@ -23,6 +28,16 @@ type HTTPServerGenConfig struct {
 	HasChannels     bool                            // true when [workers] channels exist; generates SetupMux
 	HasOAuth        bool                            // true when any OAuth provider is enabled; registers OAuth routes
 	StripPrefix     string                          // URL prefix to strip from incoming requests (e.g., "/api")
+	Serializers     []string                        // from [server] serializers: extra body formats negotiated via Accept/Content-Type (nil = JSON only)
+	AccessLog       *config.LoggingConfig           // from [logging] in shipq.ini (nil = log every request, no slow capture)
+	ShipqVersion    string                          // generator version reported by GET /__meta
+	SchemaHash      string                          // SHA-256 of schema.json ("" = no migrations)
+	Migrations      []string                        // migration names in schema.json, oldest first (nil = no migrations)
+	NoRecover       bool                            // [server] recover_panics = false: NewMux does not recover handler panics
+	TxPerRequest    bool                            // [server] tx_per_request = true: mutating handlers run in a request transaction
+	Telemetry       bool                            // [server] telemetry = otel: NewMux runs each request in an OpenTelemetry span
+	ScopeHeader     string                          // [db] scope_header: request header naming the organization to act in (requires ScopeColumn)
+	BearerAuth      bool                            // [server] auth = bearer: authenticated routes also accept Authorization: Bearer tokens
 }
 
 // GeneratedHTTPFile represents a single generated file.
@@ -46,6 +61,10 @@ type ResourceGroup struct {
 func GenerateHTTPServer(cfg HTTPServerGenConfig) ([]GeneratedHTTPFile, error) {
 	var files []GeneratedHTTPFile
 
+	if cfg.ScopeHeader != "" && cfg.ScopeColumn == "" {
+		return nil, fmt.Errorf("[db] scope_header = %s requires [db] scope", cfg.ScopeHeader)
+	}
+
 	// Group handlers by package
 	groups := GroupHandlersByPackage(cfg.ModulePath, cfg.Handlers)
 
@@ -54,7 +73,7 @@ func GenerateHTTPServer(cfg HTTPServerGenConfig) ([]GeneratedHTTPFile, error) {
 
 	// Generate per-resource http/ sub-packages
 	for _, group := range groups {
-		content, err := generateResourceHTTPFile(cfg.ModulePath, group, authPkgPath, cfg.ScopeColumn)
+		content, err := generateResourceHTTPFile(cfg.ModulePath, group, authPkgPath, cfg.ScopeColumn, cfg.ScopeHeader, len(cfg.Serializers) > 0, cfg.TxPerRequest, cfg.BearerAuth)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate %s: %w", group.RelDir, err)
 		}
@@ -121,24 +140,29 @@ func GroupHandlersByPackage(modulePath string, handlers []codegen.SerializedHand
 }
 
 // generateResourceHTTPFile generates a single per-resource http sub-package file.
-func generateResourceHTTPFile(modulePath string, group ResourceGroup, authPkgPath string, scopeColumn string) ([]byte, error) {
+// When negotiate is set, bodies are decoded and encoded through httputil's
+// codec negotiation instead of always as JSON. When txPerRequest is set,
+// mutating handlers run in a transaction per request. When bearerAuth is
+// set, authenticated routes pass Authorization: Bearer tokens to the auth
+// check.
+func generateResourceHTTPFile(modulePath string, group ResourceGroup, authPkgPath string, scopeColumn, scopeHeader string, negotiate, txPerRequest, bearerAuth bool) ([]byte, error) {
 	var buf bytes.Buffer
 
 	buf.WriteString("// Code generated by shipq.\n")
 	fmt.Fprintf(&buf, "package %s\n\n", group.HTTPPkgName)
 
 	// Generate imports
-	generateResourceImports(&buf, modulePath, group, authPkgPath)
+	generateResourceImports(&buf, modulePath, group, authPkgPath, negotiate)
 
 	// Generate RegisterRoutes function
-	generateRegisterRoutes(&buf, modulePath, group, authPkgPath, scopeColumn)
+	generateRegisterRoutes(&buf, modulePath, group, authPkgPath, scopeColumn, scopeHeader, txPerRequest, bearerAuth)
 
 	// Generate handler wrappers
 	for _, h := range group.Handlers {
-		generateResourceHandlerWrapper(&buf, h, group.ResourceName)
+		generateResourceHandlerWrapper(&buf, h, group.ResourceName, negotiate)
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format %s HTTP code: %w\nunformatted:\n%s", group.ResourceName, err, buf.String())
 	}
@@ -146,7 +170,7 @@ func generateResourceHTTPFile(modulePath string, group ResourceGroup, authPkgPat
 }
 
 // generateResourceImports writes the import block for a per-resource http file.
-func generateResourceImports(buf *bytes.Buffer, modulePath string, group ResourceGroup, authPkgPath string) {
+func generateResourceImports(buf *bytes.Buffer, modulePath string, group ResourceGroup, authPkgPath string, negotiate bool) {
 	needsAuth := false
 	for _, h := range group.Handlers {
 		if h.RequireAuth || h.OptionalAuth {
@@ -155,8 +179,9 @@ func generateResourceImports(buf *bytes.Buffer, modulePath string, group Resourc
 		}
 	}
 
-	jsonNeeded := needsJSONImport(group.Handlers)
-	httperrorNeeded := jsonNeeded || needsHTTPError(group.Handlers)
+	// With negotiation, httputil.DecodeBody owns JSON decoding and its errors.
+	jsonNeeded := !negotiate && needsJSONImport(group.Handlers)
+	httperrorNeeded := jsonNeeded || hasTypedPathParam(group.Handlers)
 
 	buf.WriteString("import (\n")
 	buf.WriteString("\t\"context\"\n")
@@ -206,7 +231,16 @@ func generateResourceImports(buf *bytes.Buffer, modulePath string, group Resourc
 }
 
 // generateRegisterRoutes generates the RegisterRoutes function for a resource.
-func generateRegisterRoutes(buf *bytes.Buffer, modulePath string, group ResourceGroup, authPkgPath string, scopeColumn string) {
+//
+// With a scope header, authenticated routes act in the organization the
+// header names, resolved by the auth package's ResolveOrganization, which
+// refuses organizations the account does not belong to; without the header
+// they act in the account's default organization.
+//
+// With bearerAuth, authenticated routes hand the token of an
+// Authorization: Bearer header to the auth package's GetCurrentSession,
+// which validates it in place of the session cookie.
+func generateRegisterRoutes(buf *bytes.Buffer, modulePath string, group ResourceGroup, authPkgPath string, scopeColumn, scopeHeader string, txPerRequest, bearerAuth bool) {
 	needsAuth := false
 	needsOptionalAuth := false
 	for _, h := range group.Handlers {
@@ -237,7 +271,7 @@ func RegisterRoutes(mux *http.ServeMux, q httpserver.PingableQuerier, runner que
 		if needsAuth {
 			buf.WriteString(`
 	checkAuth := func(ctx context.Context) (accountID int64, orgID int64, err error) {
-		qRunner := queries.RunnerFromContext(ctx)
+		qRunner := queries.MustRunnerFromContext(ctx)
 `)
 			fmt.Fprintf(buf, "\t\tsession, err := %s.GetCurrentSession(ctx, qRunner)\n", authAlias)
 			buf.WriteString(`		if err != nil {
@@ -247,7 +281,9 @@ func RegisterRoutes(mux *http.ServeMux, q httpserver.PingableQuerier, runner que
 		if session.DefaultOrganizationId != nil {
 			defaultOrgID = *session.DefaultOrganizationId
 		}
-		return session.AccountId, defaultOrgID, nil
+`)
+			writeScopeHeaderResolution(buf, authAlias, scopeHeader)
+			buf.WriteString(`		return session.AccountId, defaultOrgID, nil
 	}
 `)
 
@@ -255,14 +291,14 @@ func RegisterRoutes(mux *http.ServeMux, q httpserver.PingableQuerier, runner que
 			if scopeColumn != "" {
 				fmt.Fprintf(buf, `
 	checkRBAC := func(ctx context.Context, accountID int64, orgID int64, routePath, method string) error {
-		qRunner := queries.RunnerFromContext(ctx)
+		qRunner := queries.MustRunnerFromContext(ctx)
 		return %s.CheckRBAC(ctx, qRunner, accountID, orgID, routePath, method)
 	}
 `, authAlias)
 			} else {
 				fmt.Fprintf(buf, `
 	checkRBAC := func(ctx context.Context, accountID int64, _ int64, routePath, method string) error {
-		qRunner := queries.RunnerFromContext(ctx)
+		qRunner := queries.MustRunnerFromContext(ctx)
 		return %s.CheckRBAC(ctx, qRunner, accountID, 0, routePath, method)
 	}
 `, authAlias)
@@ -273,7 +309,7 @@ func RegisterRoutes(mux *http.ServeMux, q httpserver.PingableQuerier, runner que
 			// tryAuth uses TryGetCurrentSession which classifies errors properly
 			buf.WriteString(`
 	tryAuth := func(ctx context.Context) (accountID int64, orgID int64, err error) {
-		qRunner := queries.RunnerFromContext(ctx)
+		qRunner := queries.MustRunnerFromContext(ctx)
 `)
 			fmt.Fprintf(buf, "\t\tsession, err := %s.TryGetCurrentSession(ctx, qRunner)\n", authAlias)
 			buf.WriteString(`		if err != nil {
@@ -283,7 +319,9 @@ func RegisterRoutes(mux *http.ServeMux, q httpserver.PingableQuerier, runner que
 		if session.DefaultOrganizationId != nil {
 			defaultOrgID = *session.DefaultOrganizationId
 		}
-		return session.AccountId, defaultOrgID, nil
+`)
+			writeScopeHeaderResolution(buf, authAlias, scopeHeader)
+			buf.WriteString(`		return session.AccountId, defaultOrgID, nil
 	}
 `)
 			fmt.Fprintf(buf, `
@@ -294,36 +332,163 @@ func RegisterRoutes(mux *http.ServeMux, q httpserver.PingableQuerier, runner que
 		}
 	}
 
+	txRoutes := txPerRequest && group.PackagePath != authPkgPath && hasMutatingHandler(group.Handlers)
+	if txRoutes {
+		buf.WriteString(`
+	beginTx := func(ctx context.Context) (context.Context, func() error, func() error, error) {
+		tx, err := runner.BeginTx(ctx)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return queries.NewContextWithRunner(ctx, tx), tx.Commit, tx.Rollback, nil
+	}
+`)
+	}
+
 	buf.WriteString("\n")
 
 	for _, h := range group.Handlers {
 		convertedPath := codegen.ConvertPathSyntax(h.Path)
 		wrapperName := handlerWrapperName(h)
+		if txRoutes && isMutatingMethod(h.Method) {
+			// Inside the auth wrappers, which inject the base runner.
+			wrapperName = fmt.Sprintf("httputil.WithTx(beginTx, http.HandlerFunc(%s)).ServeHTTP", wrapperName)
+		}
+		var wrapped string
 		if h.RequireAuth {
 			// Use WrapRBACHandler for auth routes -- it enforces both auth and RBAC.
 			// The routePath uses the original :param syntax to match role_actions.route_path.
-			fmt.Fprintf(buf, "\tmux.Handle(\"%s %s\", httputil.WrapRBACHandler(q, injectCtx, checkAuth, checkRBAC, %q, %q, %s))\n", h.Method, convertedPath, h.Path, h.Method, wrapperName)
+			wrapped = fmt.Sprintf("httputil.WrapRBACHandler(q, injectCtx, checkAuth, checkRBAC, %q, %q, %s)", h.Path, h.Method, wrapperName)
 		} else if h.OptionalAuth {
 			// Use WrapOptionalAuthHandler -- attempts auth but proceeds unauthenticated if no session.
-			fmt.Fprintf(buf, "\tmux.Handle(\"%s %s\", httputil.WrapOptionalAuthHandler(q, injectCtx, tryAuth, isNoSession, %s))\n", h.Method, convertedPath, wrapperName)
+			wrapped = fmt.Sprintf("httputil.WrapOptionalAuthHandler(q, injectCtx, tryAuth, isNoSession, %s)", wrapperName)
 		} else {
-			fmt.Fprintf(buf, "\tmux.Handle(\"%s %s\", httputil.WrapHandler(q, injectCtx, %s))\n", h.Method, convertedPath, wrapperName)
+			wrapped = fmt.Sprintf("httputil.WrapHandler(q, injectCtx, %s)", wrapperName)
+		}
+		if scopeHeader != "" && (h.RequireAuth || h.OptionalAuth) {
+			wrapped = fmt.Sprintf("httputil.WithScopeHeader(%q, %s)", scopeHeader, wrapped)
+		}
+		if bearerAuth && (h.RequireAuth || h.OptionalAuth) {
+			wrapped = fmt.Sprintf("httputil.WithBearerAuth(%s)", wrapped)
+		}
+		if h.Deprecated {
+			// Deprecation headers go on the outside so 401/403 responses carry them too.
+			wrapped = fmt.Sprintf("httputil.WithDeprecation(%q, %s)", sunsetHTTPDate(h.Sunset), wrapped)
 		}
+		if h.Feature != "" {
+			// The feature gate goes outermost so a disabled route looks absent.
+			wrapped = fmt.Sprintf("httputil.WithFeature(%q, %s)", h.Feature, wrapped)
+		}
+		fmt.Fprintf(buf, "\tmux.Handle(\"%s %s\", %s)\n", h.Method, convertedPath, wrapped)
+	}
+
+	buf.WriteString("}\n\n")
+}
+
+// writeScopeHeaderResolution writes the part of the checkAuth and tryAuth
+// closures that switches defaultOrgID to the organization requested through
+// the scope header. Nothing is written without a scope header.
+func writeScopeHeaderResolution(buf *bytes.Buffer, authAlias, scopeHeader string) {
+	if scopeHeader == "" {
+		return
 	}
+	fmt.Fprintf(buf, "\t\t// %s names the organization to act in; the account must belong to it.\n", scopeHeader)
+	buf.WriteString("\t\tif requested, ok := httputil.RequestedOrganizationFromContext(ctx); ok {\n")
+	fmt.Fprintf(buf, "\t\t\torgID, err := %s.ResolveOrganization(ctx, qRunner, session.AccountId, requested)\n", authAlias)
+	buf.WriteString("\t\t\tif err != nil {\n")
+	buf.WriteString("\t\t\t\treturn 0, 0, err\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t\treturn session.AccountId, orgID, nil\n")
+	buf.WriteString("\t\t}\n")
+}
 
+// generateOptionsRoutes emits registerOptionsRoutes, which answers OPTIONS
+// on every handler path with the methods it serves. GET patterns already
+// answer HEAD, so HEAD needs no routes of its own. The routes are registered
+// here rather than per resource because two resources may share a path.
+func generateOptionsRoutes(buf *bytes.Buffer, routes []pathRoute) {
+	buf.WriteString(`// registerOptionsRoutes answers OPTIONS requests with each path's Allow header.
+func registerOptionsRoutes(mux *http.ServeMux) {
+`)
+	for _, rt := range routes {
+		quoted := make([]string, len(rt.methods))
+		for i, m := range rt.methods {
+			quoted[i] = strconv.Quote(m)
+		}
+		fmt.Fprintf(buf, "\tmux.Handle(\"OPTIONS %s\", httputil.Options(%s))\n", rt.pattern, strings.Join(quoted, ", "))
+	}
 	buf.WriteString("}\n\n")
 }
 
+// pathRoute is one path of a resource and the methods registered on it.
+type pathRoute struct {
+	pattern string // ServeMux path pattern, e.g. "/posts/{id}"
+	methods []string
+}
+
+// wildcardName matches a ServeMux wildcard so that paths differing only in
+// wildcard names ("/posts/{id}" and "/posts/{post_id}") share one route.
+var wildcardName = regexp.MustCompile(`\{[^}.]*(\.\.\.)?\}`)
+
+// groupRoutesByPath groups handlers by path for their OPTIONS routes, in
+// registration order. Paths with a handler of their own for OPTIONS are
+// left out.
+func groupRoutesByPath(handlers []codegen.SerializedHandlerInfo) []pathRoute {
+	var routes []pathRoute
+	index := make(map[string]int)
+	ownOptions := make(map[string]bool)
+	for _, h := range handlers {
+		pattern := codegen.ConvertPathSyntax(h.Path)
+		key := wildcardName.ReplaceAllString(pattern, "{$1}")
+		if strings.EqualFold(h.Method, http.MethodOptions) {
+			ownOptions[key] = true
+			continue
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(routes)
+			index[key] = i
+			routes = append(routes, pathRoute{pattern: pattern})
+		}
+		routes[i].methods = append(routes[i].methods, h.Method)
+	}
+
+	kept := routes[:0]
+	for _, rt := range routes {
+		if !ownOptions[wildcardName.ReplaceAllString(rt.pattern, "{$1}")] {
+			kept = append(kept, rt)
+		}
+	}
+	return kept
+}
+
+// hasMutatingHandler reports whether any handler serves POST, PUT, PATCH or
+// DELETE, the methods tx_per_request runs in a transaction.
+func hasMutatingHandler(handlers []codegen.SerializedHandlerInfo) bool {
+	for _, h := range handlers {
+		if isMutatingMethod(h.Method) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMutatingMethod reports whether method changes state.
+func isMutatingMethod(method string) bool {
+	return codegen.MethodHasBody(method) || method == http.MethodDelete
+}
+
 // generateResourceHandlerWrapper writes a handler wrapper for a per-resource file.
 // In the sub-package, the handler package is imported as the resource name.
-func generateResourceHandlerWrapper(buf *bytes.Buffer, h codegen.SerializedHandlerInfo, resourceAlias string) {
+func generateResourceHandlerWrapper(buf *bytes.Buffer, h codegen.SerializedHandlerInfo, resourceAlias string, negotiate bool) {
 	wrapperName := handlerWrapperName(h)
 	fmt.Fprintf(buf, "func %s(w http.ResponseWriter, r *http.Request) {\n", wrapperName)
 
 	pkgAlias := resourceAlias
 
 	// Determine if we need to declare a request variable
-	hasRequest := h.Request != nil && (len(h.Request.Fields) > 0 || len(h.PathParams) > 0)
+	rawBody := len(h.RawBodyTypes) > 0 && codegen.MethodHasBody(h.Method)
+	hasRequest := h.Request != nil && (len(h.Request.Fields) > 0 || len(h.PathParams) > 0 || rawBody)
 	queryFields := codegen.FilterQueryFields(h)
 	bodyFields := codegen.FilterBodyFields(h)
 	needsJSONBody := hasRequest && codegen.MethodHasBody(h.Method) && len(bodyFields) > 0
@@ -340,7 +505,11 @@ func generateResourceHandlerWrapper(buf *bytes.Buffer, h codegen.SerializedHandl
 			generateQueryParamBinding(buf, h, queryFields)
 		}
 
-		if needsJSONBody {
+		// Requests reading raw bodies are bound by httputil.DecodeBody,
+		// which hands those bodies over unread.
+		if (needsJSONBody && negotiate) || rawBody {
+			generateNegotiatedBodyBinding(buf)
+		} else if needsJSONBody {
 			generateJSONBodyBinding(buf, h)
 		}
 	}
@@ -360,13 +529,18 @@ func generateResourceHandlerWrapper(buf *bytes.Buffer, h codegen.SerializedHandl
 	}
 
 	buf.WriteString("\tif err != nil {\n")
-	fmt.Fprintf(buf, "\t\tconfig.Logger.Error(%q, \"error\", err.Error(), \"handler\", %q, \"request_id\", logging.RequestIDFromContext(r.Context()))\n", h.FuncName+" failed", h.FuncName)
+	buf.WriteString("\t\trequestID, _ := logging.RequestIDFromContext(r.Context())\n")
+	fmt.Fprintf(buf, "\t\tconfig.Logger.Error(%q, \"error\", err.Error(), \"handler\", %q, \"request_id\", requestID)\n", h.FuncName+" failed", h.FuncName)
 	buf.WriteString("\t\thttputil.WriteError(w, err)\n")
 	buf.WriteString("\t\treturn\n")
 	buf.WriteString("\t}\n\n")
 
 	statusCode := successStatusCode(h.Method)
-	fmt.Fprintf(buf, "\thttputil.WriteJSON(w, %s, resp)\n", statusCode)
+	if negotiate {
+		fmt.Fprintf(buf, "\thttputil.WriteResponse(w, r, %s, resp)\n", statusCode)
+	} else {
+		fmt.Fprintf(buf, "\thttputil.WriteJSON(w, %s, resp)\n", statusCode)
+	}
 
 	buf.WriteString("}\n\n")
 }
@@ -392,16 +566,26 @@ func generateTopLevelHTTP(cfg HTTPServerGenConfig, groups []ResourceGroup) ([]by
 	buf.WriteString("import (\n")
 	buf.WriteString("\t\"log/slog\"\n")
 	buf.WriteString("\t\"net/http\"\n")
-	if hasOpenAPI(cfg) {
+	if hasOpenAPI(cfg) || hasSlowRequestCapture(cfg) {
 		buf.WriteString("\t\"os\"\n")
 	}
+	if hasSlowRequestCapture(cfg) {
+		buf.WriteString("\t\"time\"\n")
+	}
 	buf.WriteString("\n")
 
 	if hasOpenAPI(cfg) || hasAdmin(cfg) {
 		fmt.Fprintf(&buf, "\tshipqassets %q\n", cfg.ModulePath+"/shipq/assets")
 	}
 	fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/lib/httpserver")
+	routes := groupRoutesByPath(cfg.Handlers)
+	if len(cfg.Serializers) > 0 || len(routes) > 0 {
+		fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/lib/httputil")
+	}
 	fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/lib/logging")
+	if cfg.Telemetry {
+		fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/lib/telemetry")
+	}
 	fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/queries")
 
 	// Import auth package when OAuth is enabled (for RegisterOAuthRoutes)
@@ -444,6 +628,27 @@ func generateTopLevelHTTP(cfg HTTPServerGenConfig, groups []ResourceGroup) ([]by
 		generateAdminConstants(&buf, cfg)
 	}
 
+	// Emit access-log options if [logging] is configured
+	if cfg.AccessLog != nil {
+		generateAccessLogOptions(&buf, cfg)
+	}
+
+	generateBuildMeta(&buf, cfg)
+
+	if !cfg.NoRecover {
+		generatePanicRecovery(&buf)
+	}
+
+	if len(cfg.Serializers) > 0 {
+		if err := generateCodecRegistration(&buf, cfg.Serializers); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(routes) > 0 {
+		generateOptionsRoutes(&buf, routes)
+	}
+
 	// When channels exist, generate SetupMux so that cmd/server/main.go can
 	// register channel routes on the raw *http.ServeMux before applying the
 	// logging middleware. NewMux delegates to SetupMux internally.
@@ -456,15 +661,15 @@ func generateTopLevelHTTP(cfg HTTPServerGenConfig, groups []ResourceGroup) ([]by
 		// Thin wrapper that delegates to SetupMux + logging.
 		buf.WriteString(`// NewMux creates an http.ServeMux with all registered handlers.
 // When channel routes need to be registered, use SetupMux directly to obtain the
-// raw *http.ServeMux, register channel routes, then wrap with logging.Decorate.
+// raw *http.ServeMux, register channel routes, then wrap it as NewMux does.
 func NewMux(q httpserver.PingableQuerier, runner queries.Runner, logger *slog.Logger) http.Handler {
 	mux := SetupMux(q, runner)
 `)
 		if cfg.StripPrefix != "" {
-			fmt.Fprintf(&buf, "\tvar handler http.Handler = http.StripPrefix(%q, mux)\n", cfg.StripPrefix)
-			fmt.Fprintf(&buf, "\treturn logging.Decorate([]string{%q}, logger, handler)\n", cfg.StripPrefix+"/health")
+			fmt.Fprintf(&buf, "\tvar handler http.Handler = http.StripPrefix(%q, %s)\n", cfg.StripPrefix, traceCall(cfg.Telemetry, recoverCall(!cfg.NoRecover, "", "logger", "mux")))
+			fmt.Fprintf(&buf, "\treturn %s\n", decorateCall(cfg.StripPrefix+"/health", "logger", "handler", accessLogOptionsRef(cfg)))
 		} else {
-			buf.WriteString("\treturn logging.Decorate([]string{\"/health\"}, logger, mux)\n")
+			fmt.Fprintf(&buf, "\treturn %s\n", decorateCall("/health", "logger", traceCall(cfg.Telemetry, recoverCall(!cfg.NoRecover, "", "logger", "mux")), accessLogOptionsRef(cfg)))
 		}
 		buf.WriteString("}\n")
 	} else {
@@ -482,6 +687,9 @@ func NewMux(q httpserver.PingableQuerier, runner queries.Runner, logger *slog.Lo
 		for _, g := range groups {
 			fmt.Fprintf(&buf, "\t%s.RegisterRoutes(mux, q, runner)\n", g.HTTPPkgName)
 		}
+		if len(routes) > 0 {
+			buf.WriteString("\tregisterOptionsRoutes(mux)\n")
+		}
 
 		// Dev/test-mode OpenAPI routes
 		if hasOpenAPI(cfg) {
@@ -493,6 +701,12 @@ func NewMux(q httpserver.PingableQuerier, runner queries.Runner, logger *slog.Lo
 `)
 		}
 
+		if hasSlowRequestCapture(cfg) {
+			buf.WriteString(slowRequestRouteBlock)
+		}
+
+		buf.WriteString(metaRouteBlock)
+
 		// Admin panel routes (available in all environments)
 		if hasAdmin(cfg) {
 			buf.WriteString(`
@@ -512,11 +726,11 @@ func NewMux(q httpserver.PingableQuerier, runner queries.Runner, logger *slog.Lo
 		buf.WriteString(`
 `)
 		if cfg.StripPrefix != "" {
-			fmt.Fprintf(&buf, "\tvar handler http.Handler = http.StripPrefix(%q, mux)\n", cfg.StripPrefix)
-			fmt.Fprintf(&buf, "\treturn logging.Decorate([]string{%q}, logger, handler)\n", cfg.StripPrefix+"/health")
+			fmt.Fprintf(&buf, "\tvar handler http.Handler = http.StripPrefix(%q, %s)\n", cfg.StripPrefix, traceCall(cfg.Telemetry, recoverCall(!cfg.NoRecover, "", "logger", "mux")))
+			fmt.Fprintf(&buf, "\treturn %s\n", decorateCall(cfg.StripPrefix+"/health", "logger", "handler", accessLogOptionsRef(cfg)))
 		} else {
 			buf.WriteString("\t// Wrap with logging middleware, excluding /health\n")
-			buf.WriteString("\treturn logging.Decorate([]string{\"/health\"}, logger, mux)\n")
+			fmt.Fprintf(&buf, "\treturn %s\n", decorateCall("/health", "logger", traceCall(cfg.Telemetry, recoverCall(!cfg.NoRecover, "", "logger", "mux")), accessLogOptionsRef(cfg)))
 		}
 		buf.WriteString("}\n")
 	}
@@ -531,7 +745,7 @@ func NewMux(q httpserver.PingableQuerier, runner queries.Runner, logger *slog.Lo
 		generateAdminRoutesFunc(&buf)
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format top-level HTTP code: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -554,6 +768,9 @@ func SetupMux(q httpserver.PingableQuerier, runner queries.Runner) *http.ServeMu
 	for _, g := range groups {
 		fmt.Fprintf(buf, "\t%s.RegisterRoutes(mux, q, runner)\n", g.HTTPPkgName)
 	}
+	if len(groupRoutesByPath(cfg.Handlers)) > 0 {
+		buf.WriteString("\tregisterOptionsRoutes(mux)\n")
+	}
 
 	// Dev/test-mode OpenAPI routes
 	if hasOpenAPI(cfg) {
@@ -565,6 +782,12 @@ func SetupMux(q httpserver.PingableQuerier, runner queries.Runner) *http.ServeMu
 `)
 	}
 
+	if hasSlowRequestCapture(cfg) {
+		buf.WriteString(slowRequestRouteBlock)
+	}
+
+	buf.WriteString(metaRouteBlock)
+
 	// Admin panel routes (available in all environments)
 	if hasAdmin(cfg) {
 		buf.WriteString(`
@@ -589,7 +812,7 @@ func SetupMux(q httpserver.PingableQuerier, runner queries.Runner) *http.ServeMu
 
 // generateOpenAPIConstants writes the OpenAPI spec and docs HTML as Go constants.
 func generateOpenAPIConstants(buf *bytes.Buffer, cfg HTTPServerGenConfig) {
-	buf.WriteString("// openAPISpec is the OpenAPI 3.1 JSON spec generated at compile time.\n")
+	buf.WriteString("// openAPISpec is the OpenAPI JSON spec generated at compile time.\n")
 	buf.WriteString("var openAPISpec = ")
 	// Use a raw string literal with backtick quoting
 	fmt.Fprintf(buf, "`%s`\n\n", cfg.OpenAPISpec)
@@ -599,6 +822,253 @@ func generateOpenAPIConstants(buf *bytes.Buffer, cfg HTTPServerGenConfig) {
 	fmt.Fprintf(buf, "`%s`\n\n", cfg.OpenAPIDocsHTML)
 }
 
+// hasSlowRequestCapture reports whether [logging] enables slow-request capture.
+func hasSlowRequestCapture(cfg HTTPServerGenConfig) bool {
+	return cfg.AccessLog != nil && cfg.AccessLog.SlowThresholdMs > 0
+}
+
+// slowRequestRouteBlock registers the slow-request debug endpoint. Captured
+// bodies may contain sensitive data, so it is never served in production.
+const slowRequestRouteBlock = `
+	// Slow-request capture (development and test modes only)
+	if goEnv := os.Getenv("GO_ENV"); goEnv == "" || goEnv == "development" || goEnv == "test" {
+		mux.Handle("GET /__debug/slow-requests", AccessLogOptions.SlowRequests)
+	}
+`
+
+// metaRouteBlock registers the build-info endpoint. It is served in every
+// environment so deploy tooling can check a running binary against the
+// schema it was built for.
+const metaRouteBlock = `
+	// Build and schema version info for deploy tooling
+	mux.Handle("GET /__meta", httpserver.MetaHandler(q, BuildMeta()))
+`
+
+// apiVersionPattern matches a leading "/v1"-style path segment.
+var apiVersionPattern = regexp.MustCompile(`^/(v[0-9]+)(/|$)`)
+
+// apiVersions returns the sorted, distinct leading version segments
+// ("v1", "v2", ...) of the handler paths.
+func apiVersions(handlers []codegen.SerializedHandlerInfo) []string {
+	seen := make(map[string]bool)
+	var versions []string
+	for _, h := range handlers {
+		m := apiVersionPattern.FindStringSubmatch(h.Path)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		versions = append(versions, m[1])
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		a, _ := strconv.Atoi(versions[i][1:])
+		b, _ := strconv.Atoi(versions[j][1:])
+		return a < b
+	})
+	return versions
+}
+
+// generateBuildMeta writes the ServiceVersion variable and the BuildMeta
+// function backing GET /__meta.
+func generateBuildMeta(buf *bytes.Buffer, cfg HTTPServerGenConfig) {
+	pkgPath := cfg.ModulePath + "/" + cfg.OutputPkg
+	buf.WriteString("// ServiceVersion identifies this build in GET /__meta. Set it at link time:\n")
+	buf.WriteString("//\n")
+	fmt.Fprintf(buf, "//\tgo build -ldflags \"-X %s.ServiceVersion=v1.2.3\" ./cmd/server\n", pkgPath)
+	buf.WriteString("var ServiceVersion = \"dev\"\n\n")
+
+	buf.WriteString("// BuildMeta returns the versions this binary was generated for. The applied\n")
+	buf.WriteString("// migration head is filled in per request by httpserver.MetaHandler.\n")
+	buf.WriteString("func BuildMeta() httpserver.Meta {\n")
+	buf.WriteString("\treturn httpserver.Meta{\n")
+	buf.WriteString("\t\tServiceVersion: ServiceVersion,\n")
+	fmt.Fprintf(buf, "\t\tShipqVersion: %q,\n", cfg.ShipqVersion)
+	fmt.Fprintf(buf, "\t\tSchemaHash: %q,\n", cfg.SchemaHash)
+	var head string
+	if n := len(cfg.Migrations); n > 0 {
+		head = cfg.Migrations[n-1]
+	}
+	fmt.Fprintf(buf, "\t\tMigrationHead: %q,\n", head)
+	if len(cfg.Migrations) > 0 {
+		buf.WriteString("\t\tMigrations: []string{\n")
+		for _, name := range cfg.Migrations {
+			fmt.Fprintf(buf, "\t\t\t%q,\n", name)
+		}
+		buf.WriteString("\t\t},\n")
+	}
+	buf.WriteString("\t\tAPIVersions: []string{")
+	for i, v := range apiVersions(cfg.Handlers) {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(buf, "%q", v)
+	}
+	buf.WriteString("},\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+}
+
+// generateAccessLogOptions writes the AccessLogOptions variable from the
+// [logging] section of shipq.ini.
+func generateAccessLogOptions(buf *bytes.Buffer, cfg HTTPServerGenConfig) {
+	buf.WriteString("// AccessLogOptions configures request logging ([logging] in shipq.ini).\n")
+	buf.WriteString("var AccessLogOptions = logging.Options{\n")
+	fmt.Fprintf(buf, "\tSampleRate: %s,\n", strconv.FormatFloat(cfg.AccessLog.SampleRate, 'g', -1, 64))
+	if hasSlowRequestCapture(cfg) {
+		fmt.Fprintf(buf, "\tSlowThreshold: %d * time.Millisecond,\n", cfg.AccessLog.SlowThresholdMs)
+		fmt.Fprintf(buf, "\tSlowRequests: logging.NewSlowRequestBuffer(%d),\n", cfg.AccessLog.SlowBufferSize)
+	}
+	buf.WriteString("}\n\n")
+}
+
+// serializerCodecs maps [server] serializers names to httputil codec types.
+var serializerCodecs = map[string]string{
+	"msgpack": "MsgpackCodec",
+	"cbor":    "CBORCodec",
+}
+
+// generateCodecRegistration writes an init function registering the codecs
+// the per-resource handlers negotiate.
+func generateCodecRegistration(buf *bytes.Buffer, serializers []string) error {
+	buf.WriteString("// Body formats negotiated besides JSON ([server] serializers in shipq.ini).\n")
+	buf.WriteString("func init() {\n")
+	for _, name := range serializers {
+		codec, ok := serializerCodecs[name]
+		if !ok {
+			return fmt.Errorf("unknown serializer %q", name)
+		}
+		fmt.Fprintf(buf, "\thttputil.RegisterCodec(httputil.%s{})\n", codec)
+	}
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+// accessLogOptionsRef returns "AccessLogOptions" when [logging] is
+// configured, or "" so that decorateCall falls back to logging.Decorate.
+func accessLogOptionsRef(cfg HTTPServerGenConfig) string {
+	if cfg.AccessLog == nil {
+		return ""
+	}
+	return "AccessLogOptions"
+}
+
+// decorateCall returns the logging middleware call wrapping handler. When
+// optsRef is empty the plain logging.Decorate form is used.
+func decorateCall(ignorePath, logger, handler, optsRef string) string {
+	if optsRef == "" {
+		return fmt.Sprintf("logging.Decorate([]string{%q}, %s, %s)", ignorePath, logger, handler)
+	}
+	return fmt.Sprintf("logging.DecorateWithOptions([]string{%q}, %s, %s, %s)", ignorePath, logger, optsRef, handler)
+}
+
+// recoverCall returns handler wrapped in the generated api package's Recover
+// middleware, qualified by pkg ("" inside the package itself), or handler
+// unchanged when recovery is disabled.
+func recoverCall(enabled bool, pkg, logger, handler string) string {
+	if !enabled {
+		return handler
+	}
+	if pkg != "" {
+		pkg += "."
+	}
+	return fmt.Sprintf("%sRecover(%s, %s)", pkg, logger, handler)
+}
+
+// traceCall returns handler wrapped in telemetry.Handler, which runs each
+// request in an OpenTelemetry span, or handler unchanged when tracing is
+// off. It wraps the mux, or Recover around it, so that the span sees the status
+// of a recovered panic and the route pattern the mux matched.
+func traceCall(enabled bool, handler string) string {
+	if !enabled {
+		return handler
+	}
+	return fmt.Sprintf("telemetry.Handler(%s)", handler)
+}
+
+// TelemetryHookFile is the user-owned file, relative to the api package
+// directory, where the OpenTelemetry exporter is installed.
+const TelemetryHookFile = "telemetry.go"
+
+// GenerateTelemetryHook returns the initial contents of the
+// TelemetryHookFile. shipq writes it only when it does not exist, so the
+// file belongs to the user once created.
+func GenerateTelemetryHook(outputPkg string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", outputPkg)
+	buf.WriteString(`// With [server] telemetry = otel, every request and query runs in an
+// OpenTelemetry span. The spans go to the global TracerProvider, which
+// drops them until one is installed. To export them, install one in an
+// init function. For example, over OTLP/HTTP to the collector named by
+// OTEL_EXPORTER_OTLP_ENDPOINT
+// (go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp):
+//
+//	func init() {
+//		exporter, err := otlptracehttp.New(context.Background())
+//		if err != nil {
+//			panic(err)
+//		}
+//		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter)))
+//		otel.SetTextMapPropagator(propagation.TraceContext{})
+//	}
+//
+// This file was created by shipq and is not regenerated.
+`)
+	return buf.Bytes()
+}
+
+// generatePanicRecovery writes the PanicReporter hook and the Recover
+// middleware that NewMux (or cmd/server/main.go, with channels) wraps the
+// mux in, inside the logging middleware so a recovered panic is logged as a
+// 500 with its request ID.
+func generatePanicRecovery(buf *bytes.Buffer) {
+	buf.WriteString(`// PanicReporter receives every panic recovered while serving a request,
+// after the 500 response has been written and the panic logged. Set it from
+// an init function in this package (see panic_reporter.go) to forward panics
+// to an error tracker. Nil only logs them.
+var PanicReporter httpserver.PanicReporter
+
+// Recover wraps next so that handler panics become 500
+// application/problem+json responses reported to PanicReporter.
+func Recover(logger *slog.Logger, next http.Handler) http.Handler {
+	return httpserver.Recover(logger, PanicReporter, next)
+}
+
+`)
+}
+
+// PanicReporterHookFile is the user-owned file, relative to the api package
+// directory, where PanicReporter is set.
+const PanicReporterHookFile = "panic_reporter.go"
+
+// GeneratePanicReporterHook returns the initial contents of the
+// PanicReporterHookFile. shipq writes it only when it does not exist, so
+// the file belongs to the user once created.
+func GeneratePanicReporterHook(outputPkg string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", outputPkg)
+	buf.WriteString(`// Panics in handlers are logged and answered with a 500
+// application/problem+json response. To also send them to an error tracker,
+// set PanicReporter in an init function. For example, with Sentry
+// (github.com/getsentry/sentry-go):
+//
+//	func init() {
+//		if err := sentry.Init(sentry.ClientOptions{Dsn: os.Getenv("SENTRY_DSN")}); err != nil {
+//			panic(err)
+//		}
+//		PanicReporter = httpserver.PanicReporterFunc(func(ctx context.Context, report httpserver.PanicReport) {
+//			hub := sentry.CurrentHub().Clone()
+//			hub.Scope().SetRequest(report.Request)
+//			hub.Scope().SetTag("request_id", report.RequestID)
+//			hub.RecoverWithContext(ctx, report.Value)
+//			hub.Flush(2 * time.Second)
+//		})
+//	}
+//
+// This file was created by shipq and is not regenerated.
+`)
+	return buf.Bytes()
+}
+
 // generateOpenAPIRoutesFunc writes the registerOpenAPIRoutes helper function.
 func generateOpenAPIRoutesFunc(buf *bytes.Buffer) {
 	buf.WriteString(`// registerOpenAPIRoutes adds OpenAPI documentation routes to the mux.
@@ -663,6 +1133,21 @@ func registerAdminRoutes(mux *http.ServeMux) {
 `)
 }
 
+// sunsetHTTPDate converts a YYYY-MM-DD sunset date into the HTTP-date format
+// required by the Sunset header. Returns "" when no sunset date is set.
+func sunsetHTTPDate(sunset string) string {
+	if sunset == "" {
+		return ""
+	}
+	t, err := time.Parse("2006-01-02", sunset)
+	if err != nil {
+		// RouteBuilder.Sunset validates the date at registration time, so this
+		// only happens for hand-edited registries; pass the value through.
+		return sunset
+	}
+	return t.UTC().Format(http.TimeFormat)
+}
+
 // hasAuthHandlers checks if any handler requires or optionally uses authentication.
 func hasAuthHandlers(handlers []codegen.SerializedHandlerInfo) bool {
 	for _, h := range handlers {
@@ -687,9 +1172,13 @@ func findAuthPackagePath(handlers []codegen.SerializedHandlerInfo) string {
 // needsStrconv checks if any handler needs strconv for type conversion.
 // needsJSONImport returns true if any handler has a method with a body (POST,
 // PUT, PATCH) AND a request type with body fields (excluding path and query params).
-// In that case the generated wrapper calls json.NewDecoder to bind the JSON body.
+// In that case the generated wrapper calls json.NewDecoder to bind the JSON body,
+// unless the request reads raw bodies and is bound by httputil.DecodeBody.
 func needsJSONImport(handlers []codegen.SerializedHandlerInfo) bool {
 	for _, h := range handlers {
+		if len(h.RawBodyTypes) > 0 {
+			continue
+		}
 		hasRequest := h.Request != nil && (len(h.Request.Fields) > 0 || len(h.PathParams) > 0)
 		bodyFields := codegen.FilterBodyFields(h)
 		if hasRequest && codegen.MethodHasBody(h.Method) && len(bodyFields) > 0 {
@@ -699,12 +1188,12 @@ func needsJSONImport(handlers []codegen.SerializedHandlerInfo) bool {
 	return false
 }
 
-// needsHTTPError returns true if any handler has a typed (non-string) path
+// hasTypedPathParam returns true if any handler has a typed (non-string) path
 // parameter. The generated path-param binding code calls
 // httperror.BadRequest when the conversion fails.
-// Note: httperror is also needed when needsJSONImport is true (the JSON body
-// binding uses httperror.BadRequest too), but the caller checks that separately.
-func needsHTTPError(handlers []codegen.SerializedHandlerInfo) bool {
+// Note: httperror is also needed when the wrappers bind JSON bodies directly
+// (the binding uses httperror.BadRequest too), but the caller checks that separately.
+func hasTypedPathParam(handlers []codegen.SerializedHandlerInfo) bool {
 	for _, h := range handlers {
 		if h.Request == nil {
 			continue
@@ -719,10 +1208,6 @@ func needsHTTPError(handlers []codegen.SerializedHandlerInfo) bool {
 			}
 		}
 	}
-	// Also needed when JSON body binding is present
-	if needsJSONImport(handlers) {
-		return true
-	}
 	return false
 }
 
@@ -836,6 +1321,16 @@ func generateJSONBodyBinding(buf *bytes.Buffer, h codegen.SerializedHandlerInfo)
 	buf.WriteString("\t}\n\n")
 }
 
+// generateNegotiatedBodyBinding generates code that decodes the request body
+// with the codec matching its Content-Type.
+func generateNegotiatedBodyBinding(buf *bytes.Buffer) {
+	buf.WriteString("\t// Bind request body (JSON or a negotiated codec)\n")
+	buf.WriteString("\tif err := httputil.DecodeBody(r, &req); err != nil {\n")
+	buf.WriteString("\t\thttputil.WriteError(w, err)\n")
+	buf.WriteString("\t\treturn\n")
+	buf.WriteString("\t}\n\n")
+}
+
 // generateQueryParamBinding generates code to bind query parameters to request fields.
 func generateQueryParamBinding(buf *bytes.Buffer, h codegen.SerializedHandlerInfo, queryFields []codegen.SerializedFieldInfo) {
 	buf.WriteString("\t// Bind query parameters\n")
@@ -883,6 +1378,18 @@ func generateQueryParamBinding(buf *bytes.Buffer, h codegen.SerializedHandlerInf
 			fmt.Fprintf(buf, "\t\t\treq.%s = parsed\n", field.Name)
 			buf.WriteString("\t\t}\n")
 			buf.WriteString("\t}\n")
+		case "float64":
+			fmt.Fprintf(buf, "\tif v := queryValues.Get(%q); v != \"\" {\n", queryKey)
+			fmt.Fprintf(buf, "\t\tif parsed, err := strconv.ParseFloat(v, 64); err == nil {\n")
+			fmt.Fprintf(buf, "\t\t\treq.%s = parsed\n", field.Name)
+			buf.WriteString("\t\t}\n")
+			buf.WriteString("\t}\n")
+		case "*float64":
+			fmt.Fprintf(buf, "\tif v := queryValues.Get(%q); v != \"\" {\n", queryKey)
+			fmt.Fprintf(buf, "\t\tif parsed, err := strconv.ParseFloat(v, 64); err == nil {\n")
+			fmt.Fprintf(buf, "\t\t\treq.%s = &parsed\n", field.Name)
+			buf.WriteString("\t\t}\n")
+			buf.WriteString("\t}\n")
 		case "bool":
 			fmt.Fprintf(buf, "\tif v := queryValues.Get(%q); v != \"\" {\n", queryKey)
 			fmt.Fprintf(buf, "\t\tif parsed, err := strconv.ParseBool(v); err == nil {\n")
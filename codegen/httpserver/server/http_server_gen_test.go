@@ -3,10 +3,14 @@ package server
 import (
 	"go/parser"
 	"go/token"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/config"
 )
 
 // findFile finds a generated file by relative path suffix.
@@ -29,6 +33,38 @@ func findResourceHTTP(files []GeneratedHTTPFile, resource string) *GeneratedHTTP
 	return findFile(files, resource+"/http/zz_generated_http.go")
 }
 
+// testHandler returns the handler funcName of the api/<pkg> package for
+// method and path, with empty request and response structs.
+func testHandler(pkg, method, path, funcName string) codegen.SerializedHandlerInfo {
+	pkgPath := "example.com/app/api/" + pkg
+	h := codegen.SerializedHandlerInfo{
+		Method:      method,
+		Path:        path,
+		FuncName:    funcName,
+		PackagePath: pkgPath,
+		PathParams:  []codegen.SerializedPathParam{},
+		Request:     &codegen.SerializedStructInfo{Name: funcName + "Request", Package: pkgPath, Fields: []codegen.SerializedFieldInfo{}},
+		Response:    &codegen.SerializedStructInfo{Name: funcName + "Response", Package: pkgPath, Fields: []codegen.SerializedFieldInfo{}},
+	}
+	for i, seg := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(seg, ":"); ok {
+			h.PathParams = append(h.PathParams, codegen.SerializedPathParam{Name: name, Position: i})
+		}
+	}
+	return h
+}
+
+// routeHandler returns the handler fn registers for pattern with
+// mux.Handle, as gofmt prints it on one line, or "" if it registers none.
+func routeHandler(f *gofile.File, fn, pattern string) string {
+	for _, args := range f.Args(fn, "mux.Handle") {
+		if len(args) == 2 && args[0] == strconv.Quote(pattern) {
+			return args[1]
+		}
+	}
+	return ""
+}
+
 func TestConvertPathSyntax(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -875,6 +911,150 @@ func TestGenerateHTTPServer_RegisterRoutesFunction(t *testing.T) {
 	}
 }
 
+func TestGenerateHTTPServer_DeprecatedRoutes(t *testing.T) {
+	listUsers := testHandler("users", "GET", "/users", "ListUsers")
+	listUsers.Deprecated = true
+	deleteUser := testHandler("users", "DELETE", "/users/:id", "DeleteUser")
+	deleteUser.Deprecated, deleteUser.Sunset = true, "2027-01-31"
+	cfg := HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   []codegen.SerializedHandlerInfo{listUsers, deleteUser, testHandler("users", "POST", "/users", "CreateUser")},
+		OutputPkg:  "api",
+	}
+
+	files, err := GenerateHTTPServer(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	resFile := findResourceHTTP(files, "users")
+	if resFile == nil {
+		t.Fatal("missing users resource file")
+	}
+	f := gofile.Parse(t, "users/http", resFile.Content)
+
+	if got := routeHandler(f, "RegisterRoutes", "GET /users"); got != `httputil.WithDeprecation("", httputil.WrapHandler(q, injectCtx, handleListUsers))` {
+		t.Errorf("deprecated route without sunset should be wrapped with WithDeprecation(\"\"), got %s", got)
+	}
+	if got := routeHandler(f, "RegisterRoutes", "DELETE /users/{id}"); !strings.HasPrefix(got, `httputil.WithDeprecation("Sun, 31 Jan 2027 00:00:00 GMT", `) {
+		t.Errorf("sunset date should be emitted as an HTTP-date, got %s", got)
+	}
+	if got := routeHandler(f, "RegisterRoutes", "POST /users"); got != "httputil.WrapHandler(q, injectCtx, handleCreateUser)" {
+		t.Errorf("non-deprecated route should not be wrapped, got %s", got)
+	}
+}
+
+func TestGenerateHTTPServer_FeatureGatedRoutes(t *testing.T) {
+	importUsers := testHandler("users", "POST", "/users/import", "ImportUsers")
+	importUsers.Feature, importUsers.Deprecated = "bulk-import", true
+	cfg := HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   []codegen.SerializedHandlerInfo{importUsers, testHandler("users", "GET", "/users", "ListUsers")},
+		OutputPkg:  "api",
+	}
+
+	files, err := GenerateHTTPServer(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	resFile := findResourceHTTP(files, "users")
+	if resFile == nil {
+		t.Fatal("missing users resource file")
+	}
+	f := gofile.Parse(t, "users/http", resFile.Content)
+
+	if got := routeHandler(f, "RegisterRoutes", "POST /users/import"); got != `httputil.WithFeature("bulk-import", httputil.WithDeprecation("", httputil.WrapHandler(q, injectCtx, handleImportUsers)))` {
+		t.Errorf("gated route should be wrapped with WithFeature outside WithDeprecation, got %s", got)
+	}
+	if got := routeHandler(f, "RegisterRoutes", "GET /users"); got != "httputil.WrapHandler(q, injectCtx, handleListUsers)" {
+		t.Errorf("ungated route should not be wrapped, got %s", got)
+	}
+}
+
+func TestGenerateHTTPServer_ScopeHeader(t *testing.T) {
+	me := testHandler("auth", "GET", "/me", "Me")
+	me.RequireAuth = true
+	listPosts := testHandler("posts", "GET", "/posts", "ListPosts")
+	listPosts.RequireAuth = true
+	cfg := HTTPServerGenConfig{
+		ModulePath:  "example.com/app",
+		Handlers:    []codegen.SerializedHandlerInfo{me, listPosts, testHandler("posts", "GET", "/posts/public", "ListPublicPosts")},
+		OutputPkg:   "api",
+		ScopeColumn: "organization_id",
+		ScopeHeader: "X-Organization-ID",
+	}
+
+	files, err := GenerateHTTPServer(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	resFile := findResourceHTTP(files, "posts")
+	if resFile == nil {
+		t.Fatal("missing posts resource file")
+	}
+	f := gofile.Parse(t, "posts/http", resFile.Content)
+	// A requested organization replaces the session's default.
+	f.AssertStmts("RegisterRoutes",
+		"if requested, ok := httputil.RequestedOrganizationFromContext(ctx); ok",
+		"orgID, err := auth.ResolveOrganization(ctx, qRunner, session.AccountId, requested)",
+	)
+	if got := routeHandler(f, "RegisterRoutes", "GET /posts"); !strings.HasPrefix(got, `httputil.WithScopeHeader("X-Organization-ID", httputil.WrapRBACHandler(`) {
+		t.Errorf("authenticated routes should read the scope header, got %s", got)
+	}
+	if got := routeHandler(f, "RegisterRoutes", "GET /posts/public"); got != "httputil.WrapHandler(q, injectCtx, handleListPublicPosts)" {
+		t.Errorf("public routes should not read the scope header, got %s", got)
+	}
+
+	cfg.ScopeHeader = ""
+	files, _ = GenerateHTTPServer(cfg)
+	if gofile.Parse(t, "posts/http", findResourceHTTP(files, "posts").Content).Calls("RegisterRoutes", "auth.ResolveOrganization") != 0 {
+		t.Error("the scope header should only be read when configured")
+	}
+
+	cfg.ScopeHeader = "X-Organization-ID"
+	cfg.ScopeColumn = ""
+	if _, err := GenerateHTTPServer(cfg); err == nil {
+		t.Error("expected an error for a scope header without a scope column")
+	}
+}
+
+func TestGenerateHTTPServer_BearerAuth(t *testing.T) {
+	me := testHandler("auth", "GET", "/me", "Me")
+	me.RequireAuth = true
+	listPosts := testHandler("posts", "GET", "/posts", "ListPosts")
+	listPosts.RequireAuth = true
+	feed := testHandler("posts", "GET", "/posts/feed", "Feed")
+	feed.OptionalAuth = true
+	cfg := HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   []codegen.SerializedHandlerInfo{me, listPosts, feed, testHandler("posts", "GET", "/posts/public", "ListPublicPosts")},
+		OutputPkg:  "api",
+		BearerAuth: true,
+	}
+
+	files, err := GenerateHTTPServer(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	f := gofile.Parse(t, "posts/http", findResourceHTTP(files, "posts").Content)
+	for pattern, wrapper := range map[string]string{
+		"GET /posts":      "httputil.WithBearerAuth(httputil.WrapRBACHandler(",
+		"GET /posts/feed": "httputil.WithBearerAuth(httputil.WrapOptionalAuthHandler(",
+	} {
+		if got := routeHandler(f, "RegisterRoutes", pattern); !strings.HasPrefix(got, wrapper) {
+			t.Errorf("%s should accept bearer tokens, got %s", pattern, got)
+		}
+	}
+	if got := routeHandler(f, "RegisterRoutes", "GET /posts/public"); got != "httputil.WrapHandler(q, injectCtx, handleListPublicPosts)" {
+		t.Errorf("public routes should not read bearer tokens, got %s", got)
+	}
+
+	cfg.BearerAuth = false
+	files, _ = GenerateHTTPServer(cfg)
+	if gofile.Parse(t, "posts/http", findResourceHTTP(files, "posts").Content).Calls("RegisterRoutes", "httputil.WithBearerAuth") != 0 {
+		t.Error("bearer tokens should only be read when configured")
+	}
+}
+
 func TestGenerateHTTPServer_ErrorLogging(t *testing.T) {
 	cfg := HTTPServerGenConfig{
 		ModulePath: "example.com/app",
@@ -1609,6 +1789,63 @@ func TestGenerateHTTPServer_QueryParamBinding_Int64Field(t *testing.T) {
 	}
 }
 
+func TestGenerateHTTPServer_QueryParamBinding_Float64Field(t *testing.T) {
+	cfg := HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:      "GET",
+				Path:        "/stores/near",
+				FuncName:    "ListStoresNear",
+				PackagePath: "example.com/app/api/stores",
+				PathParams:  []codegen.SerializedPathParam{},
+				Request: &codegen.SerializedStructInfo{
+					Name:    "ListStoresNearRequest",
+					Package: "example.com/app/api/stores",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Lat", Type: "float64", JSONName: "lat", Tags: map[string]string{"query": "lat"}},
+						{Name: "Radius", Type: "*float64", JSONName: "radius", Tags: map[string]string{"query": "radius"}},
+					},
+				},
+				Response: &codegen.SerializedStructInfo{
+					Name:    "ListStoresNearResponse",
+					Package: "example.com/app/api/stores",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Items", Type: "[]string", JSONName: "items", Required: true},
+					},
+				},
+			},
+		},
+		OutputPkg: "api",
+	}
+
+	files, err := GenerateHTTPServer(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+
+	resFile := findResourceHTTP(files, "stores")
+	if resFile == nil {
+		t.Fatal("missing stores resource file")
+	}
+	codeStr := string(resFile.Content)
+
+	if !strings.Contains(codeStr, "strconv.ParseFloat(v, 64)") {
+		t.Error("missing strconv.ParseFloat for float64 query param")
+	}
+	if !strings.Contains(codeStr, "req.Lat = parsed") {
+		t.Error("missing assignment for float64 query param")
+	}
+	if !strings.Contains(codeStr, "req.Radius = &parsed") {
+		t.Error("missing pointer assignment for *float64 query param")
+	}
+
+	_, err = parser.ParseFile(token.NewFileSet(), "", resFile.Content, parser.AllErrors)
+	if err != nil {
+		t.Errorf("generated code is not valid Go: %v\n%s", err, codeStr)
+	}
+}
+
 func TestGenerateHTTPServer_QueryParamBinding_PointerStringField(t *testing.T) {
 	// A *string query param should be set only when the query key is
 	// present, assigning a pointer to the retrieved value.
@@ -1843,6 +2080,74 @@ func TestGenerateHTTPServer_StripPrefix_ValidGoWithChannels(t *testing.T) {
 	}
 }
 
+// ─── AccessLog tests ───
+
+func TestGenerateHTTPServer_AccessLog_SamplingAndSlowCapture(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		files, err := GenerateHTTPServer(HTTPServerGenConfig{
+			ModulePath:  "example.com/app",
+			Handlers:    []codegen.SerializedHandlerInfo{testHandler("posts", "GET", "/posts", "ListPosts")},
+			OutputPkg:   "api",
+			HasChannels: hasChannels,
+			AccessLog:   &config.LoggingConfig{SampleRate: 0.01, SlowThresholdMs: 500, SlowBufferSize: 50},
+		})
+		if err != nil {
+			t.Fatalf("GenerateHTTPServer() error = %v", err)
+		}
+		f := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+
+		want := "logging.Options{ SampleRate: 0.01, SlowThreshold: 500 * time.Millisecond, SlowRequests: logging.NewSlowRequestBuffer(50), }"
+		if got := strings.Join(strings.Fields(f.Value("AccessLogOptions")), " "); got != want {
+			t.Errorf("hasChannels=%v: AccessLogOptions = %s, want %s", hasChannels, got, want)
+		}
+		setup := "NewMux"
+		if hasChannels {
+			setup = "SetupMux"
+		}
+		if got := routeHandler(f, setup, "GET /__debug/slow-requests"); got != "AccessLogOptions.SlowRequests" {
+			t.Errorf("hasChannels=%v: slow requests handler = %q", hasChannels, got)
+		}
+		f.AssertStmts("NewMux", `return logging.DecorateWithOptions([]string{"/health"}, logger, AccessLogOptions, Recover(logger, mux))`)
+	}
+}
+
+func TestGenerateHTTPServer_AccessLog_SamplingOnly(t *testing.T) {
+	files, err := GenerateHTTPServer(HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   []codegen.SerializedHandlerInfo{testHandler("posts", "GET", "/posts", "ListPosts")},
+		OutputPkg:  "api",
+		AccessLog:  &config.LoggingConfig{SampleRate: 0.5, SlowBufferSize: 100},
+	})
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	f := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+
+	if got := strings.Join(strings.Fields(f.Value("AccessLogOptions")), " "); got != "logging.Options{ SampleRate: 0.5, }" {
+		t.Errorf("AccessLogOptions = %s", got)
+	}
+	if routeHandler(f, "NewMux", "GET /__debug/slow-requests") != "" || f.HasImport("time") {
+		t.Error("slow-request capture should not be generated when slow_threshold_ms is unset")
+	}
+}
+
+func TestGenerateHTTPServer_AccessLog_AbsentWhenNil(t *testing.T) {
+	files, err := GenerateHTTPServer(HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   []codegen.SerializedHandlerInfo{testHandler("posts", "GET", "/posts", "ListPosts")},
+		OutputPkg:  "api",
+	})
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	f := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+
+	if f.Value("AccessLogOptions") != "" {
+		t.Error("AccessLogOptions should not be generated without a [logging] section")
+	}
+	f.AssertStmts("NewMux", `return logging.Decorate([]string{"/health"}, logger, Recover(logger, mux))`)
+}
+
 func TestGenerateHTTPServer_QueryParamBinding_PlainStringField(t *testing.T) {
 	// A non-pointer string query field should do simple req.Field = v assignment.
 	cfg := HTTPServerGenConfig{
@@ -2304,3 +2609,285 @@ func TestGenerateHTTPServer_QueryParamBinding_EmptyQueryTag(t *testing.T) {
 		t.Errorf("generated code is not valid Go: %v\n%s", err, codeStr)
 	}
 }
+
+func TestGenerateHTTPServer_BuildMeta(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		v1 := testHandler("posts", "GET", "/v1/posts", "ListPostsV1")
+		v2 := testHandler("posts", "GET", "/v2/posts", "ListPosts")
+		files, err := GenerateHTTPServer(HTTPServerGenConfig{
+			ModulePath:   "example.com/app",
+			Handlers:     []codegen.SerializedHandlerInfo{v2, v1},
+			OutputPkg:    "api",
+			HasChannels:  hasChannels,
+			ShipqVersion: "v0.9.0",
+			SchemaHash:   "deadbeef",
+			Migrations:   []string{"20260101000000_create_users", "20260102000000_add_posts"},
+		})
+		if err != nil {
+			t.Fatalf("GenerateHTTPServer() error = %v", err)
+		}
+		f := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+
+		if got := f.Value("ServiceVersion"); got != `"dev"` {
+			t.Errorf("ServiceVersion = %s", got)
+		}
+		if doc := f.Source(); !strings.Contains(doc, "-X example.com/app/api.ServiceVersion=") {
+			t.Error("ServiceVersion should document its -ldflags")
+		}
+		f.AssertStmts("BuildMeta", `return httpserver.Meta{
+			ServiceVersion: ServiceVersion,
+			ShipqVersion:   "v0.9.0",
+			SchemaHash:     "deadbeef",
+			MigrationHead:  "20260102000000_add_posts",
+			Migrations: []string{
+				"20260101000000_create_users",
+				"20260102000000_add_posts",
+			},
+			APIVersions: []string{"v1", "v2"},
+		}`)
+		// /__meta is served in every environment, not only in development.
+		setup := "NewMux"
+		if hasChannels {
+			setup = "SetupMux"
+		}
+		if !slices.Contains(f.TopStmts(setup), `mux.Handle("GET /__meta", httpserver.MetaHandler(q, BuildMeta()))`) {
+			t.Errorf("hasChannels=%v: /__meta must be served in every environment", hasChannels)
+		}
+	}
+}
+
+func TestAPIVersions(t *testing.T) {
+	handlers := []codegen.SerializedHandlerInfo{
+		{Path: "/v10/posts"}, {Path: "/v2/posts"}, {Path: "/v2"}, {Path: "/posts"}, {Path: "/version"},
+	}
+	got := apiVersions(handlers)
+	if strings.Join(got, ",") != "v2,v10" {
+		t.Errorf("apiVersions() = %v, want [v2 v10]", got)
+	}
+}
+
+func TestGenerateHTTPServer_Serializers_NegotiatesBodies(t *testing.T) {
+	createUser := testHandler("users", "POST", "/users", "CreateUser")
+	createUser.Request.Fields = []codegen.SerializedFieldInfo{
+		{Name: "Name", Type: "string", JSONName: "name", Tags: map[string]string{"json": "name"}},
+	}
+	files, err := GenerateHTTPServer(HTTPServerGenConfig{
+		ModulePath:  "example.com/app",
+		Handlers:    []codegen.SerializedHandlerInfo{createUser},
+		OutputPkg:   "api",
+		Serializers: []string{"msgpack", "cbor"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+
+	resFile := findResourceHTTP(files, "users")
+	if resFile == nil {
+		t.Fatal("missing users resource file")
+	}
+	f := gofile.Parse(t, "users/http", resFile.Content)
+	f.AssertStmts("handleCreateUser",
+		"if err := httputil.DecodeBody(r, &req); err != nil",
+		"httputil.WriteResponse(w, r, http.StatusCreated, resp)",
+	)
+	if f.HasImport("encoding/json") || f.HasImport("example.com/app/shipq/lib/httperror") {
+		t.Error("unused import in negotiated resource file")
+	}
+
+	top := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+	if got := top.TopStmts("init"); !slices.Equal(got, []string{
+		"httputil.RegisterCodec(httputil.MsgpackCodec{})",
+		"httputil.RegisterCodec(httputil.CBORCodec{})",
+	}) {
+		t.Errorf("init registers %q", got)
+	}
+}
+
+func TestGenerateHTTPServer_RawBodyUsesDecodeBody(t *testing.T) {
+	h := testHandler("users", "POST", "/users/import", "ImportUsers")
+	h.RawBodyTypes = []string{"text/csv"}
+	h.Request.Fields = []codegen.SerializedFieldInfo{
+		{Name: "DryRun", Type: "bool", Tags: map[string]string{"query": "dry_run"}},
+	}
+
+	files, err := GenerateHTTPServer(HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   []codegen.SerializedHandlerInfo{h},
+		OutputPkg:  "api",
+	})
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	f := gofile.Parse(t, "users/http", findResourceHTTP(files, "users").Content)
+	f.AssertStmts("handleImportUsers", "if err := httputil.DecodeBody(r, &req); err != nil")
+	if f.HasImport("encoding/json") {
+		t.Error("unused encoding/json import in raw body resource file")
+	}
+}
+
+func TestGenerateHTTPServer_Serializers_AbsentKeepsJSON(t *testing.T) {
+	createUser := testHandler("users", "POST", "/users", "CreateUser")
+	createUser.Request.Fields = []codegen.SerializedFieldInfo{
+		{Name: "Name", Type: "string", JSONName: "name", Tags: map[string]string{"json": "name"}},
+	}
+	files, err := GenerateHTTPServer(HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   []codegen.SerializedHandlerInfo{createUser},
+		OutputPkg:  "api",
+	})
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	f := gofile.Parse(t, "users/http", findResourceHTTP(files, "users").Content)
+	f.AssertStmts("handleCreateUser",
+		"if err := json.NewDecoder(r.Body).Decode(&req); err != nil",
+		"httputil.WriteJSON(w, http.StatusCreated, resp)",
+	)
+	if gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content).HasFunc("init") {
+		t.Error("no codecs should be registered without [server] serializers")
+	}
+}
+
+func TestGenerateHTTPServer_Serializers_Unknown(t *testing.T) {
+	_, err := GenerateHTTPServer(HTTPServerGenConfig{
+		ModulePath:  "example.com/app",
+		Handlers:    []codegen.SerializedHandlerInfo{testHandler("users", "POST", "/users", "CreateUser")},
+		OutputPkg:   "api",
+		Serializers: []string{"protobuf"},
+	})
+	if err == nil {
+		t.Error("expected an error for an unknown serializer")
+	}
+}
+
+func TestGenerateHTTPServer_PanicRecovery(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		cfg := HTTPServerGenConfig{
+			ModulePath:  "example.com/app",
+			Handlers:    []codegen.SerializedHandlerInfo{testHandler("posts", "GET", "/posts", "ListPosts")},
+			OutputPkg:   "api",
+			HasChannels: hasChannels,
+			StripPrefix: "/api",
+		}
+
+		files, err := GenerateHTTPServer(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPServer() error = %v", err)
+		}
+		f := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+		if got := f.Value("PanicReporter"); got != "" || !f.HasExpr("", "httpserver.PanicReporter") {
+			t.Errorf("hasChannels=%v: expected an unset PanicReporter variable", hasChannels)
+		}
+		f.AssertStmts("Recover", "return httpserver.Recover(logger, PanicReporter, next)")
+		f.AssertExprs("NewMux", `http.StripPrefix("/api", Recover(logger, mux))`)
+
+		cfg.NoRecover = true
+		files, err = GenerateHTTPServer(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPServer() error = %v", err)
+		}
+		f = gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+		if f.HasFunc("Recover") || f.HasExpr("", "httpserver.PanicReporter") {
+			t.Errorf("hasChannels=%v: recovery should not be generated with recover_panics = false", hasChannels)
+		}
+		f.AssertExprs("NewMux", `http.StripPrefix("/api", mux)`)
+	}
+}
+
+func TestGenerateHTTPServer_Telemetry(t *testing.T) {
+	for _, hasChannels := range []bool{false, true} {
+		cfg := HTTPServerGenConfig{
+			ModulePath:  "example.com/app",
+			Handlers:    []codegen.SerializedHandlerInfo{testHandler("posts", "GET", "/posts", "ListPosts")},
+			OutputPkg:   "api",
+			HasChannels: hasChannels,
+			StripPrefix: "/api",
+			Telemetry:   true,
+		}
+
+		files, err := GenerateHTTPServer(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPServer() error = %v", err)
+		}
+		f := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+		f.AssertExprs("NewMux", `http.StripPrefix("/api", telemetry.Handler(Recover(logger, mux)))`)
+
+		cfg.Telemetry = false
+		files, err = GenerateHTTPServer(cfg)
+		if err != nil {
+			t.Fatalf("GenerateHTTPServer() error = %v", err)
+		}
+		if gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content).HasImport("example.com/app/shipq/lib/telemetry") {
+			t.Errorf("hasChannels=%v: requests should not be traced without [server] telemetry", hasChannels)
+		}
+	}
+}
+
+func TestGenerateHTTPServer_OptionsRoutes(t *testing.T) {
+	cfg := HTTPServerGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			testHandler("posts", "GET", "/posts", "ListPosts"),
+			testHandler("posts", "POST", "/posts", "CreatePost"),
+			testHandler("posts", "GET", "/posts/:id", "GetPost"),
+			testHandler("posts", "DELETE", "/posts/:post_id", "DeletePost"),
+			// A second resource sharing a path still yields one OPTIONS route.
+			testHandler("drafts", "PUT", "/posts/:id", "ReplacePost"),
+			testHandler("ping", "GET", "/ping", "Ping"),
+			testHandler("ping", "OPTIONS", "/ping", "PingOptions"),
+		},
+		OutputPkg: "api",
+	}
+
+	files, err := GenerateHTTPServer(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	f := gofile.Parse(t, "zz_generated_http.go", findTopLevel(files).Content)
+
+	f.AssertStmts("NewMux", "registerOptionsRoutes(mux)")
+	// Paths differing only in wildcard names share one OPTIONS route, and a
+	// path with its own OPTIONS handler gets none.
+	if got := f.TopStmts("registerOptionsRoutes"); !slices.Equal(got, []string{
+		`mux.Handle("OPTIONS /posts", httputil.Options("GET", "POST"))`,
+		`mux.Handle("OPTIONS /posts/{id}", httputil.Options("GET", "DELETE", "PUT"))`,
+	}) {
+		t.Errorf("registerOptionsRoutes registers %q", got)
+	}
+}
+
+func TestGenerateHTTPServer_TxPerRequest(t *testing.T) {
+	deleteUser := testHandler("users", "DELETE", "/users/:id", "DeleteUser")
+	deleteUser.Deprecated = true
+	handlers := []codegen.SerializedHandlerInfo{
+		testHandler("users", "GET", "/users", "ListUsers"),
+		testHandler("users", "POST", "/users", "CreateUser"),
+		deleteUser,
+	}
+
+	files, err := GenerateHTTPServer(HTTPServerGenConfig{ModulePath: "example.com/app", Handlers: handlers, OutputPkg: "api", TxPerRequest: true})
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	f := gofile.Parse(t, "users/http", findResourceHTTP(files, "users").Content)
+
+	f.AssertStmts("RegisterRoutes", "return queries.NewContextWithRunner(ctx, tx), tx.Commit, tx.Rollback, nil")
+	// Writes run in a transaction inside any deprecation wrapper; reads do not.
+	for pattern, want := range map[string]string{
+		"POST /users":        "httputil.WrapHandler(q, injectCtx, httputil.WithTx(beginTx, http.HandlerFunc(handleCreateUser)).ServeHTTP)",
+		"DELETE /users/{id}": `httputil.WithDeprecation("", httputil.WrapHandler(q, injectCtx, httputil.WithTx(beginTx, http.HandlerFunc(handleDeleteUser)).ServeHTTP))`,
+		"GET /users":         "httputil.WrapHandler(q, injectCtx, handleListUsers)",
+	} {
+		if got := routeHandler(f, "RegisterRoutes", pattern); got != want {
+			t.Errorf("%s handler = %s, want %s", pattern, got, want)
+		}
+	}
+
+	files, err = GenerateHTTPServer(HTTPServerGenConfig{ModulePath: "example.com/app", Handlers: handlers, OutputPkg: "api"})
+	if err != nil {
+		t.Fatalf("GenerateHTTPServer() error = %v", err)
+	}
+	if gofile.Parse(t, "users/http", findResourceHTTP(files, "users").Content).Calls("RegisterRoutes", "httputil.WithTx") != 0 {
+		t.Error("routes should not run in a transaction unless tx_per_request is set")
+	}
+}
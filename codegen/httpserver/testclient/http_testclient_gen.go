@@ -3,7 +3,6 @@ package testclient
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"regexp"
 	"sort"
 	"strings"
@@ -11,6 +10,7 @@ import (
 
 	"github.com/shipq/shipq/codegen"
 	"github.com/shipq/shipq/codegen/httpserver/server"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // testClientTypeName returns a unique type name for a resource's test client.
@@ -96,7 +96,7 @@ func generateResourceTestClient(modulePath string, group server.ResourceGroup, s
 			needsStrings = true
 		}
 		bodyFields := codegen.FilterBodyFields(h)
-		if codegen.MethodHasBody(h.Method) && len(bodyFields) > 0 {
+		if codegen.MethodHasBody(h.Method) && len(bodyFields) > 0 && !isRawBody(h) {
 			needsBytes = true
 		}
 		queryFields := codegen.FilterQueryFields(h)
@@ -175,7 +175,7 @@ func New%s(ts *httptest.Server, httpClient *http.Client, sessionCookie string) *
 		generateTestClientMethod(&buf, h, handlerPkgs, typeName, stripPrefix)
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format %s test client: %w\nunformatted:\n%s", group.ResourceName, err, buf.String())
 	}
@@ -238,7 +238,7 @@ func NewAuthenticatedTestClient(ts *httptest.Server, sessionCookie string) *Clie
 	}
 	buf.WriteString("\t}\n}\n\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format top-level test client: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -278,7 +278,7 @@ func NewAuthenticatedTestClient(ts *httptest.Server, sessionCookie string) *Clie
 	}
 }
 `)
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format empty test client: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -313,6 +313,11 @@ func generateTestClientMethod(buf *bytes.Buffer, h codegen.SerializedHandlerInfo
 	convertedPath := codegen.ConvertPathSyntax(h.Path)
 	fmt.Fprintf(buf, "// %s calls %s %s\n", h.FuncName, h.Method, h.Path)
 
+	// Raw request bodies are passed alongside the request and sent as they are
+	if isRawBody(h) {
+		reqType += ", contentType string, body io.Reader"
+	}
+
 	if isDeleteNoBody {
 		fmt.Fprintf(buf, "func (c *%s) %s(ctx context.Context, req %s) error {\n", typeName, h.FuncName, reqType)
 	} else {
@@ -422,7 +427,13 @@ func generateRequestCreationWithCookies(buf *bytes.Buffer, h codegen.SerializedH
 	hasBody := codegen.MethodHasBody(h.Method)
 	bodyFields := codegen.FilterBodyFields(h)
 
-	if hasBody && len(bodyFields) > 0 {
+	if isRawBody(h) {
+		fmt.Fprintf(buf, "\thttpReq, err := http.NewRequestWithContext(ctx, %q, reqURL, body)\n", h.Method)
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn resp, nil, fmt.Errorf(\"failed to create request: %w\", err)\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\thttpReq.Header.Set(\"Content-Type\", contentType)\n\n")
+	} else if hasBody && len(bodyFields) > 0 {
 		buf.WriteString("\tbody, err := json.Marshal(req)\n")
 		buf.WriteString("\tif err != nil {\n")
 		if isDeleteNoBody {
@@ -522,6 +533,14 @@ func generateQueryParamURLAppend(buf *bytes.Buffer, queryFields []codegen.Serial
 			fmt.Fprintf(buf, "\tif req.%s != 0 {\n", field.Name)
 			fmt.Fprintf(buf, "\t\tqv.Set(%q, strconv.FormatUint(req.%s, 10))\n", queryKey, field.Name)
 			buf.WriteString("\t}\n")
+		case "float64":
+			fmt.Fprintf(buf, "\tif req.%s != 0 {\n", field.Name)
+			fmt.Fprintf(buf, "\t\tqv.Set(%q, strconv.FormatFloat(req.%s, 'f', -1, 64))\n", queryKey, field.Name)
+			buf.WriteString("\t}\n")
+		case "*float64":
+			fmt.Fprintf(buf, "\tif req.%s != nil {\n", field.Name)
+			fmt.Fprintf(buf, "\t\tqv.Set(%q, strconv.FormatFloat(*req.%s, 'f', -1, 64))\n", queryKey, field.Name)
+			buf.WriteString("\t}\n")
 		case "bool":
 			fmt.Fprintf(buf, "\tif req.%s {\n", field.Name)
 			fmt.Fprintf(buf, "\t\tqv.Set(%q, strconv.FormatBool(req.%s))\n", queryKey, field.Name)
@@ -590,12 +609,24 @@ func findRequestFieldForParam(h codegen.SerializedHandlerInfo, paramName string)
 	return paramName
 }
 
+// isRawBody reports whether the handler's request body is sent raw, with one
+// of the media types listed in RawBodyTypes, instead of as JSON.
+func isRawBody(h codegen.SerializedHandlerInfo) bool {
+	return codegen.MethodHasBody(h.Method) && len(h.RawBodyTypes) > 0
+}
+
 // generateRequestCreation generates code to create the HTTP request.
 func generateRequestCreation(buf *bytes.Buffer, h codegen.SerializedHandlerInfo) {
 	hasBody := codegen.MethodHasBody(h.Method)
 	bodyFields := codegen.FilterBodyFields(h)
 
-	if hasBody && len(bodyFields) > 0 {
+	if isRawBody(h) {
+		fmt.Fprintf(buf, "\thttpReq, err := http.NewRequestWithContext(ctx, %q, reqURL, body)\n", h.Method)
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn resp, fmt.Errorf(\"failed to create request: %w\", err)\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\thttpReq.Header.Set(\"Content-Type\", contentType)\n\n")
+	} else if hasBody && len(bodyFields) > 0 {
 		buf.WriteString("\tbody, err := json.Marshal(req)\n")
 		buf.WriteString("\tif err != nil {\n")
 		buf.WriteString("\t\treturn resp, fmt.Errorf(\"failed to marshal request: %w\", err)\n")
@@ -1,6 +1,7 @@
 package testclient
 
 import (
+	"go/ast"
 	"go/parser"
 	"go/token"
 	"strings"
@@ -1053,6 +1054,126 @@ func TestGenerateHTTPTestClient_QueryParams_BoolFieldConversion(t *testing.T) {
 	}
 }
 
+func TestGenerateHTTPTestClient_RawBody(t *testing.T) {
+	cfg := HTTPTestClientGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:       "POST",
+				Path:         "/posts/import",
+				FuncName:     "ImportPosts",
+				PackagePath:  "example.com/app/api/posts",
+				RawBodyTypes: []string{"text/csv"},
+				Request: &codegen.SerializedStructInfo{
+					Name:    "ImportPostsRequest",
+					Package: "example.com/app/api/posts",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "DryRun", Type: "bool", JSONName: "DryRun", Tags: map[string]string{"query": "dry_run"}},
+					},
+				},
+				Response: &codegen.SerializedStructInfo{
+					Name:    "ImportPostsResponse",
+					Package: "example.com/app/api/posts",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Total", Type: "int", JSONName: "total", Required: true},
+					},
+				},
+			},
+		},
+		OutputPkg: "api",
+	}
+
+	files, err := GenerateHTTPTestClient(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPTestClient() error = %v", err)
+	}
+	resFile := findResourceTestClient(files, "posts")
+	if resFile == nil {
+		t.Fatal("missing posts resource test client file")
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "", resFile.Content, parser.AllErrors)
+	if err != nil {
+		t.Fatalf("generated code is not valid Go: %v\n%s", err, resFile.Content)
+	}
+	for _, imp := range f.Imports {
+		if imp.Path.Value == `"bytes"` {
+			t.Error("unused bytes import for a raw body handler")
+		}
+	}
+
+	// Both variants take the content type and the body after the request.
+	for _, name := range []string{"ImportPosts", "ImportPostsWithCookies"} {
+		var params []string
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Name.Name != name {
+				continue
+			}
+			for _, field := range fn.Type.Params.List {
+				for _, n := range field.Names {
+					params = append(params, n.Name)
+				}
+			}
+		}
+		if got := strings.Join(params, ","); got != "ctx,req,contentType,body" {
+			t.Errorf("%s parameters = %s, want ctx,req,contentType,body", name, got)
+		}
+	}
+	if !strings.Contains(string(resFile.Content), `httpReq.Header.Set("Content-Type", contentType)`) {
+		t.Error("expected the request to carry the given content type")
+	}
+}
+
+func TestGenerateHTTPTestClient_QueryParams_Float64FieldConversion(t *testing.T) {
+	cfg := HTTPTestClientGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:      "GET",
+				Path:        "/stores/near",
+				FuncName:    "ListStoresNear",
+				PackagePath: "example.com/app/api/stores",
+				PathParams:  []codegen.SerializedPathParam{},
+				Request: &codegen.SerializedStructInfo{
+					Name:    "ListStoresNearRequest",
+					Package: "example.com/app/api/stores",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Radius", Type: "float64", JSONName: "radius", Required: false, Tags: map[string]string{"query": "radius"}},
+					},
+				},
+				Response: &codegen.SerializedStructInfo{
+					Name:    "ListStoresNearResponse",
+					Package: "example.com/app/api/stores",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Items", Type: "[]string", JSONName: "items", Required: true},
+					},
+				},
+			},
+		},
+		OutputPkg: "api",
+	}
+
+	files, err := GenerateHTTPTestClient(cfg)
+	if err != nil {
+		t.Fatalf("GenerateHTTPTestClient() error = %v", err)
+	}
+
+	resFile := findResourceTestClient(files, "stores")
+	if resFile == nil {
+		t.Fatal("missing stores resource test client file")
+	}
+	codeStr := string(resFile.Content)
+
+	if !strings.Contains(codeStr, "strconv.FormatFloat(req.Radius, 'f', -1, 64)") {
+		t.Error("missing strconv.FormatFloat for float64 query param conversion")
+	}
+
+	_, err = parser.ParseFile(token.NewFileSet(), "", resFile.Content, parser.AllErrors)
+	if err != nil {
+		t.Errorf("generated code is not valid Go: %v\n%s", err, codeStr)
+	}
+}
+
 func TestGenerateHTTPTestClient_QueryParams_WithCookiesVariant(t *testing.T) {
 	// The WithCookies variant of a POST method with query params should also
 	// correctly construct the URL with query params.
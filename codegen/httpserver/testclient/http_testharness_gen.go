@@ -3,7 +3,8 @@ package testclient
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // HTTPTestHarnessGenConfig holds configuration for generating the HTTP test harness.
@@ -30,7 +31,7 @@ func GenerateHTTPTestHarness(cfg HTTPTestHarnessGenConfig) ([]byte, error) {
 	// Generate NewUnauthenticatedTestServer function
 	generateNewTestServer(&buf)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format HTTP test harness code: %w\nunformatted:\n%s", err, buf.String())
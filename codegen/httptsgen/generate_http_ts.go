@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
@@ -197,7 +198,9 @@ func writeQueryHelper(buf *bytes.Buffer) {
 	buf.WriteString("}\n")
 }
 
-// writeFetchWrapper writes the shared request<T> function.
+// writeFetchWrapper writes the shared request<T> function. Bodies are sent as
+// JSON unless a contentType is given, in which case they are sent as they are
+// (handlers with raw request bodies, such as CSV imports).
 func writeFetchWrapper(buf *bytes.Buffer) {
 	buf.WriteString("\n// ─── Shared fetch wrapper ───\n\n")
 
@@ -205,16 +208,17 @@ func writeFetchWrapper(buf *bytes.Buffer) {
 	buf.WriteString("  method: string,\n")
 	buf.WriteString("  path: string,\n")
 	buf.WriteString("  body?: unknown,\n")
+	buf.WriteString("  contentType?: string,\n")
 	buf.WriteString("): Promise<T> {\n")
 	buf.WriteString("  const cfg = getConfig();\n")
-	buf.WriteString("  const headers: Record<string, string> = { \"Content-Type\": \"application/json\" };\n")
+	buf.WriteString("  const headers: Record<string, string> = { \"Content-Type\": contentType ?? \"application/json\" };\n")
 	buf.WriteString("  if (cfg.getHeaders) {\n")
 	buf.WriteString("    Object.assign(headers, await cfg.getHeaders());\n")
 	buf.WriteString("  }\n")
 	buf.WriteString("  const res = await fetch(`${cfg.baseURL}${path}`, {\n")
 	buf.WriteString("    method,\n")
 	buf.WriteString("    headers,\n")
-	buf.WriteString("    body: body ? JSON.stringify(body) : undefined,\n")
+	buf.WriteString("    body: contentType ? (body as BodyInit) : body ? JSON.stringify(body) : undefined,\n")
 	buf.WriteString("    credentials: \"include\",\n")
 	buf.WriteString("  });\n")
 	buf.WriteString("  if (res.status === 401 && cfg.onUnauthorized) {\n")
@@ -244,7 +248,7 @@ func writeHandlerTypes(buf *bytes.Buffer, h codegen.SerializedHandlerInfo) {
 	// Request type (if present and has body fields)
 	if h.Request != nil && len(h.Request.Fields) > 0 {
 		bodyFields := filterBodyFields(h)
-		if len(bodyFields) > 0 && codegen.MethodHasBody(h.Method) {
+		if len(bodyFields) > 0 && codegen.MethodHasBody(h.Method) && !isRawBody(h) {
 			typeName := h.FuncName + "Request"
 			tsutil.GenerateTSInterface(buf, typeName, bodyFields)
 		}
@@ -293,7 +297,8 @@ func writeHandlerFunction(buf *bytes.Buffer, h codegen.SerializedHandlerInfo) {
 	pathExpr = buildPathExpression(h.Path, h.PathParams)
 
 	// Determine request/response types
-	hasBody := codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
+	rawBody := isRawBody(h)
+	hasBody := !rawBody && codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
 	hasResponse := h.Response != nil && len(h.Response.Fields) > 0
 
 	reqTypeName := h.FuncName + "Request"
@@ -304,6 +309,11 @@ func writeHandlerFunction(buf *bytes.Buffer, h codegen.SerializedHandlerInfo) {
 	hasQueryParams := len(queryFields) > 0
 	paramsTypeName := h.FuncName + "Params"
 
+	// Raw bodies come before the optional query params
+	if rawBody {
+		params = append(params, "body: BodyInit", "contentType: "+rawContentTypeUnion(h))
+	}
+
 	if hasQueryParams {
 		params = append(params, "params?: "+paramsTypeName)
 	}
@@ -336,12 +346,52 @@ func writeHandlerFunction(buf *bytes.Buffer, h codegen.SerializedHandlerInfo) {
 
 	if hasBody {
 		buf.WriteString(", req")
+	} else if rawBody {
+		buf.WriteString(", body, contentType")
 	}
 
 	buf.WriteString(");\n")
 	buf.WriteString("}\n")
 }
 
+// isRawBody reports whether the handler's request body is sent raw, with one
+// of the media types listed in RawBodyTypes, instead of as JSON.
+func isRawBody(h codegen.SerializedHandlerInfo) bool {
+	return codegen.MethodHasBody(h.Method) && len(h.RawBodyTypes) > 0
+}
+
+// rawContentTypeUnion returns the TypeScript union of the handler's raw body
+// media types, e.g. "text/csv" | "application/x-ndjson".
+func rawContentTypeUnion(h codegen.SerializedHandlerInfo) string {
+	quoted := make([]string, len(h.RawBodyTypes))
+	for i, t := range h.RawBodyTypes {
+		quoted[i] = strconv.Quote(t)
+	}
+	return strings.Join(quoted, " | ")
+}
+
+// rawBodyMutation returns the variables type and the mutationFn of a
+// mutation hook for a handler with a raw request body. The variables are an
+// object holding the path params, the body, its content type and the
+// optional query params, passed on to funcRef in its argument order.
+func rawBodyMutation(h codegen.SerializedHandlerInfo, funcRef string) (varsType, mutationFn string) {
+	var fields, names []string
+	for _, pp := range h.PathParams {
+		fields = append(fields, pp.Name+": string")
+		names = append(names, pp.Name)
+	}
+	fields = append(fields, "body: BodyInit", "contentType: "+rawContentTypeUnion(h))
+	names = append(names, "body", "contentType")
+	if hasQueryParams(h) {
+		fields = append(fields, "params?: "+h.FuncName+"Params")
+		names = append(names, "params")
+	}
+	varsType = "{ " + strings.Join(fields, "; ") + " }"
+	args := strings.Join(names, ", ")
+	mutationFn = fmt.Sprintf("({ %s }: %s) => %s(%s)", args, varsType, funcRef, args)
+	return varsType, mutationFn
+}
+
 // buildPathExpression converts a route path with :param placeholders into a
 // TypeScript template literal expression with encodeURIComponent calls.
 // e.g., "/posts/:id" -> "/posts/${encodeURIComponent(id)}"
@@ -44,7 +44,7 @@ func GenerateReactHooks(handlers []codegen.SerializedHandlerInfo) ([]byte, error
 		for _, h := range pkgHandlers {
 			funcImports = append(funcImports, tsutil.ToCamelCase(h.FuncName))
 
-			hasBody := codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
+			hasBody := !isRawBody(h) && codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
 			if hasBody {
 				typeImports = append(typeImports, h.FuncName+"Request")
 			}
@@ -207,7 +207,7 @@ func writeReactMutationHook(buf *bytes.Buffer, pkgName string, h codegen.Seriali
 	role := DetectCRUDRole(h)
 	isCRUD := role != CRUDRoleNone
 
-	hasBody := codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
+	hasBody := !isRawBody(h) && codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
 	hasResponse := h.Response != nil && len(h.Response.Fields) > 0
 	hasPathParams := len(h.PathParams) > 0
 
@@ -217,8 +217,10 @@ func writeReactMutationHook(buf *bytes.Buffer, pkgName string, h codegen.Seriali
 	}
 
 	// Determine the mutation variable type
-	var varsType string
-	if hasPathParams && hasBody {
+	var varsType, rawMutationFn string
+	if isRawBody(h) {
+		varsType, rawMutationFn = rawBodyMutation(h, tsutil.ToCamelCase(h.FuncName))
+	} else if hasPathParams && hasBody {
 		// Combine path params and body into one object
 		var parts []string
 		for _, pp := range h.PathParams {
@@ -254,7 +256,9 @@ func writeReactMutationHook(buf *bytes.Buffer, pkgName string, h codegen.Seriali
 	buf.WriteString("  return useMutation({\n")
 
 	// mutationFn
-	if hasPathParams && hasBody {
+	if rawMutationFn != "" {
+		fmt.Fprintf(buf, "    mutationFn: %s,\n", rawMutationFn)
+	} else if hasPathParams && hasBody {
 		var destructure []string
 		for _, pp := range h.PathParams {
 			destructure = append(destructure, pp.Name)
@@ -68,7 +68,7 @@ func GenerateSvelteHooks(handlers []codegen.SerializedHandlerInfo) ([]byte, erro
 				funcImports = append(funcImports, camelName)
 			}
 
-			hasBody := codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
+			hasBody := !isRawBody(h) && codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
 			if hasBody {
 				typeImports = append(typeImports, h.FuncName+"Request")
 			}
@@ -258,7 +258,7 @@ func writeSvelteMutationHook(buf *bytes.Buffer, pkgName string, h codegen.Serial
 	role := DetectCRUDRole(h)
 	isCRUD := role != CRUDRoleNone
 
-	hasBody := codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
+	hasBody := !isRawBody(h) && codegen.MethodHasBody(h.Method) && h.Request != nil && len(filterBodyFields(h)) > 0
 	hasResponse := h.Response != nil && len(h.Response.Fields) > 0
 	hasPathParams := len(h.PathParams) > 0
 
@@ -268,8 +268,10 @@ func writeSvelteMutationHook(buf *bytes.Buffer, pkgName string, h codegen.Serial
 	}
 
 	// Determine the mutation variable type
-	var varsType string
-	if hasPathParams && hasBody {
+	var varsType, rawMutationFn string
+	if isRawBody(h) {
+		varsType, rawMutationFn = rawBodyMutation(h, baseFuncRef)
+	} else if hasPathParams && hasBody {
 		var parts []string
 		for _, pp := range h.PathParams {
 			parts = append(parts, pp.Name+": string")
@@ -304,7 +306,9 @@ func writeSvelteMutationHook(buf *bytes.Buffer, pkgName string, h codegen.Serial
 	buf.WriteString("  return createMutation(() => ({\n")
 
 	// mutationFn
-	if hasPathParams && hasBody {
+	if rawMutationFn != "" {
+		fmt.Fprintf(buf, "    mutationFn: %s,\n", rawMutationFn)
+	} else if hasPathParams && hasBody {
 		var destructure []string
 		for _, pp := range h.PathParams {
 			destructure = append(destructure, pp.Name)
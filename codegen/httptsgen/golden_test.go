@@ -159,6 +159,27 @@ func makeMultiResourceHandlers() []codegen.SerializedHandlerInfo {
 				},
 			},
 		},
+		{
+			Method:       "POST",
+			Path:         "/posts/import",
+			FuncName:     "ImportPosts",
+			PackagePath:  "myapp/api/posts",
+			RequireAuth:  true,
+			RawBodyTypes: []string{"text/csv", "application/x-ndjson"},
+			Request: &codegen.SerializedStructInfo{
+				Name: "ImportPostsRequest",
+				Fields: []codegen.SerializedFieldInfo{
+					{Name: "DryRun", Type: "bool", JSONName: "DryRun", Tags: map[string]string{"query": "dry_run"}},
+				},
+			},
+			Response: &codegen.SerializedStructInfo{
+				Name: "ImportPostsResponse",
+				Fields: []codegen.SerializedFieldInfo{
+					{Name: "Total", Type: "int", JSONName: "total", Required: true},
+					{Name: "Imported", Type: "int", JSONName: "imported", Required: true},
+				},
+			},
+		},
 	}
 
 	comments := []codegen.SerializedHandlerInfo{
@@ -18,6 +18,8 @@ import {
   type CreatePostRequest,
   type CreatePostResponse,
   type GetPostResponse,
+  type ImportPostsParams,
+  type ImportPostsResponse,
   type ListPostsParams,
   type ListPostsResponse,
   type PublishPostResponse,
@@ -29,6 +31,7 @@ import {
   adminListPosts,
   createPost,
   getPost,
+  importPosts,
   listPosts,
   publishPost,
   softDeletePost,
@@ -88,6 +91,7 @@ export const postsKeys = {
   adminListPosts: () => [...postsKeys.all, "adminListPosts"] as const,
   createPost: () => [...postsKeys.all, "createPost"] as const,
   getPost: (id: string) => [...postsKeys.all, "getPost", id] as const,
+  importPosts: () => [...postsKeys.all, "importPosts"] as const,
   listPosts: () => [...postsKeys.all, "listPosts"] as const,
   publishPost: (id: string) => [...postsKeys.all, "publishPost", id] as const,
   softDeletePost: (id: string) => [...postsKeys.all, "softDeletePost", id] as const,
@@ -129,6 +133,15 @@ export function useGetPost(
   });
 }
 
+export function useImportPosts(
+  options?: UseMutationOptions<ImportPostsResponse, Error, { body: BodyInit; contentType: "text/csv" | "application/x-ndjson"; params?: ImportPostsParams }>,
+) {
+  return useMutation({
+    mutationFn: ({ body, contentType, params }: { body: BodyInit; contentType: "text/csv" | "application/x-ndjson"; params?: ImportPostsParams }) => importPosts(body, contentType, params),
+    ...options,
+  });
+}
+
 export function useListPosts(
   params?: ListPostsParams,
   options?: Partial<UseQueryOptions<ListPostsResponse>>,
@@ -40,16 +40,17 @@ async function request<T>(
   method: string,
   path: string,
   body?: unknown,
+  contentType?: string,
 ): Promise<T> {
   const cfg = getConfig();
-  const headers: Record<string, string> = { "Content-Type": "application/json" };
+  const headers: Record<string, string> = { "Content-Type": contentType ?? "application/json" };
   if (cfg.getHeaders) {
     Object.assign(headers, await cfg.getHeaders());
   }
   const res = await fetch(`${cfg.baseURL}${path}`, {
     method,
     headers,
-    body: body ? JSON.stringify(body) : undefined,
+    body: contentType ? (body as BodyInit) : body ? JSON.stringify(body) : undefined,
     credentials: "include",
   });
   if (res.status === 401 && cfg.onUnauthorized) {
@@ -144,6 +145,15 @@ export interface GetPostResponse {
   created_at: string;
 }
 
+export interface ImportPostsParams {
+  dry_run?: boolean;
+}
+
+export interface ImportPostsResponse {
+  total: number;
+  imported: number;
+}
+
 export interface ListPostsParams {
   cursor?: string;
   limit?: number;
@@ -186,6 +196,12 @@ export async function getPost(id: string): Promise<GetPostResponse> {
   return request<GetPostResponse>("GET", `/posts/${encodeURIComponent(id)}`);
 }
 
+/** POST /posts/import */
+export async function importPosts(body: BodyInit, contentType: "text/csv" | "application/x-ndjson", params?: ImportPostsParams): Promise<ImportPostsResponse> {
+  const query = buildQuery(params as Record<string, unknown>);
+  return request<ImportPostsResponse>("POST", `/posts/import${query}`, body, contentType);
+}
+
 /** GET /posts */
 export async function listPosts(params?: ListPostsParams): Promise<ListPostsResponse> {
   const query = buildQuery(params as Record<string, unknown>);
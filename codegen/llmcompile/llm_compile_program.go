@@ -3,8 +3,9 @@ package llmcompile
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"sort"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // LLMCompileProgramConfig holds configuration for generating the LLM
@@ -107,7 +108,7 @@ func main() {
 }
 `)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging.
 		return buf.Bytes(), fmt.Errorf("failed to format llm compile program: %w", err)
@@ -3,7 +3,8 @@ package llmgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GeneratePersisterAdapter generates the llmpersist adapter source code that
@@ -159,7 +160,7 @@ func GeneratePersisterAdapter(modulePath string, hasAuth bool) ([]byte, error) {
 	buf.WriteString("\treturn names, nil\n")
 	buf.WriteString("}\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging.
 		return buf.Bytes(), fmt.Errorf("failed to format generated persister adapter: %w", err)
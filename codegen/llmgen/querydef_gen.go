@@ -3,7 +3,8 @@ package llmgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateLLMQuerydefs generates querydefs/llm/queries.go with typed query
@@ -205,7 +206,7 @@ func GenerateLLMQuerydefs(modulePath string, hasTenancy bool, hasAuth bool) []by
 // formatQuerydefSourceLLM attempts to gofmt the source. If formatting fails,
 // the raw source is returned so the caller can debug the output.
 func formatQuerydefSourceLLM(src []byte) []byte {
-	formatted, err := format.Source(src)
+	formatted, err := phase.FormatSource(src)
 	if err != nil {
 		return src
 	}
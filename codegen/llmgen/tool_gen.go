@@ -3,11 +3,11 @@ package llmgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"strings"
 	"unicode"
 
 	"github.com/shipq/shipq/codegen/llmcompile"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateToolRegistry generates a zz_generated_registry.go file for a single
@@ -98,7 +98,7 @@ func GenerateToolRegistry(pkg llmcompile.SerializedToolPackage, modulePath strin
 	buf.WriteString("\t}\n")
 	buf.WriteString("}\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging.
 		return buf.Bytes(), fmt.Errorf("failed to format generated tool registry: %w", err)
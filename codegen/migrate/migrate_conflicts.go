@@ -0,0 +1,159 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MigrationConflicts describes pending migrations that will not run in the
+// order their files suggest, typically after merging two branches that each
+// added migrations.
+type MigrationConflicts struct {
+	// AppliedHead is the latest migration applied to the database.
+	AppliedHead string
+	// OutOfOrder lists pending migrations that sort before AppliedHead. They
+	// will run after migrations that were written later, while schema.json
+	// is built in file order.
+	OutOfOrder []MigrationFile
+	// Collisions lists pending migrations that share their timestamp with
+	// another migration, so their relative order is decided by name alone.
+	Collisions []MigrationFile
+}
+
+// Empty reports whether no conflicts were found.
+func (c MigrationConflicts) Empty() bool {
+	return len(c.OutOfOrder) == 0 && len(c.Collisions) == 0
+}
+
+// Conflicting returns every conflicting migration once, in file order.
+func (c MigrationConflicts) Conflicting(migrations []MigrationFile) []MigrationFile {
+	flagged := make(map[string]bool)
+	for _, m := range c.OutOfOrder {
+		flagged[m.Path] = true
+	}
+	for _, m := range c.Collisions {
+		flagged[m.Path] = true
+	}
+	var result []MigrationFile
+	for _, m := range migrations {
+		if flagged[m.Path] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// MigrationName returns the name a migration is tracked under, e.g.
+// "20260115120000_users".
+func (m MigrationFile) MigrationName() string {
+	return m.Timestamp + "_" + m.Name
+}
+
+// DetectConflicts compares the discovered migration files against the names
+// already applied to a database. migrations must be sorted as returned by
+// DiscoverMigrations.
+func DetectConflicts(migrations []MigrationFile, applied []string) MigrationConflicts {
+	var conflicts MigrationConflicts
+	appliedSet := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		appliedSet[name] = true
+		if name > conflicts.AppliedHead {
+			conflicts.AppliedHead = name
+		}
+	}
+
+	byTimestamp := make(map[string]int)
+	for _, m := range migrations {
+		byTimestamp[m.Timestamp]++
+	}
+
+	for _, m := range migrations {
+		if appliedSet[m.MigrationName()] {
+			continue
+		}
+		if m.MigrationName() < conflicts.AppliedHead {
+			conflicts.OutOfOrder = append(conflicts.OutOfOrder, m)
+		} else if byTimestamp[m.Timestamp] > 1 {
+			conflicts.Collisions = append(conflicts.Collisions, m)
+		}
+	}
+
+	return conflicts
+}
+
+// MigrationRename records a migration file moved to a new timestamp.
+type MigrationRename struct {
+	From MigrationFile
+	To   MigrationFile
+}
+
+// PlanRenumber assigns fresh timestamps, after every existing migration in
+// migrationsPath, to the given migrations while keeping their relative order.
+func PlanRenumber(migrationsPath string, migrations []MigrationFile) []MigrationRename {
+	base := NextMigrationBaseTime(migrationsPath)
+	renames := make([]MigrationRename, len(migrations))
+	for i, m := range migrations {
+		ts := base.Add(time.Duration(i) * time.Second).Format("20060102150405")
+		to := m
+		to.Timestamp = ts
+		to.Path = filepath.Join(filepath.Dir(m.Path), ts+"_"+m.Name+".go")
+		to.FuncName = fmt.Sprintf("Migrate_%s_%s", ts, m.Name)
+		renames[i] = MigrationRename{From: m, To: to}
+	}
+	return renames
+}
+
+// ApplyRenumber renames the planned migration files and rewrites references
+// to their old names (function names, tracked names) in every .go file in
+// migrationsPath, including tests.
+func ApplyRenumber(migrationsPath string, renames []MigrationRename) error {
+	for _, r := range renames {
+		if _, err := os.Stat(r.To.Path); err == nil {
+			return fmt.Errorf("cannot rename %s: %s already exists", filepath.Base(r.From.Path), filepath.Base(r.To.Path))
+		}
+	}
+
+	entries, err := os.ReadDir(migrationsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	patterns := make([]*regexp.Regexp, len(renames))
+	for i, r := range renames {
+		// The old name may follow "Migrate_" or a quote, but must not be
+		// part of a longer timestamp or migration name.
+		patterns[i] = regexp.MustCompile(`(^|[^0-9])` + regexp.QuoteMeta(r.From.MigrationName()) + `([^0-9A-Za-z_]|$)`)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		path := filepath.Join(migrationsPath, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		content := string(data)
+		for i, r := range renames {
+			content = patterns[i].ReplaceAllString(content, "${1}"+r.To.MigrationName()+"${2}")
+		}
+		if content != string(data) {
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", entry.Name(), err)
+			}
+		}
+	}
+
+	for _, r := range renames {
+		if err := os.Rename(r.From.Path, r.To.Path); err != nil {
+			return fmt.Errorf("failed to rename %s: %w", filepath.Base(r.From.Path), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package migrate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/migrate"
+)
+
+func writeMigrationFile(t *testing.T, dir, timestamp, name string) {
+	t.Helper()
+	content := "package migrations\n\n" +
+		"import \"github.com/shipq/shipq/db/portsql/migrate\"\n\n" +
+		"func Migrate_" + timestamp + "_" + name + "(plan *migrate.MigrationPlan) error {\n" +
+		"\treturn nil\n" +
+		"}\n"
+	if err := os.WriteFile(filepath.Join(dir, timestamp+"_"+name+".go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func migrationNames(files []migrate.MigrationFile) []string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.MigrationName()
+	}
+	return names
+}
+
+func TestDetectConflicts(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrationFile(t, dir, "20260101000000", "users")
+	writeMigrationFile(t, dir, "20260102000000", "comments") // other branch, not yet applied
+	writeMigrationFile(t, dir, "20260103000000", "posts")
+	writeMigrationFile(t, dir, "20260104000000", "tags")
+	writeMigrationFile(t, dir, "20260104000000", "labels")
+
+	migrations, err := migrate.DiscoverMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("out of order and collisions", func(t *testing.T) {
+		conflicts := migrate.DetectConflicts(migrations, []string{"20260101000000_users", "20260103000000_posts"})
+
+		if conflicts.AppliedHead != "20260103000000_posts" {
+			t.Errorf("AppliedHead = %q", conflicts.AppliedHead)
+		}
+		if got := strings.Join(migrationNames(conflicts.OutOfOrder), ","); got != "20260102000000_comments" {
+			t.Errorf("OutOfOrder = %s", got)
+		}
+		if got := strings.Join(migrationNames(conflicts.Collisions), ","); got != "20260104000000_labels,20260104000000_tags" {
+			t.Errorf("Collisions = %s", got)
+		}
+		if got := strings.Join(migrationNames(conflicts.Conflicting(migrations)), ","); got != "20260102000000_comments,20260104000000_labels,20260104000000_tags" {
+			t.Errorf("Conflicting = %s", got)
+		}
+	})
+
+	t.Run("fresh database has no out of order migrations", func(t *testing.T) {
+		conflicts := migrate.DetectConflicts(migrations[:4], nil)
+		if !conflicts.Empty() {
+			t.Errorf("expected no conflicts, got %+v", conflicts)
+		}
+	})
+}
+
+func TestDiscoverMigrations_SameTimestampSortedByName(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrationFile(t, dir, "20260104000000", "tags")
+	writeMigrationFile(t, dir, "20260104000000", "labels")
+	writeMigrationFile(t, dir, "20260104000000", "authors")
+
+	migrations, err := migrate.DiscoverMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(migrationNames(migrations), ","); got != "20260104000000_authors,20260104000000_labels,20260104000000_tags" {
+		t.Errorf("order = %s", got)
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrationFile(t, dir, "20260101000000", "users")
+	writeMigrationFile(t, dir, "20260102000000", "comments")
+	writeMigrationFile(t, dir, "20260102000000", "comments_index")
+	writeMigrationFile(t, dir, "20260103000000", "posts")
+	testContent := "package migrations\n\n" +
+		"var names = []string{\"20260102000000_comments\", \"20260102000000_comments_index\"}\n" +
+		"var fn = Migrate_20260102000000_comments\n"
+	if err := os.WriteFile(filepath.Join(dir, "migrations_test.go"), []byte(testContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := migrate.DiscoverMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicts := migrate.DetectConflicts(migrations, []string{"20260101000000_users", "20260103000000_posts"})
+	renames := migrate.PlanRenumber(dir, conflicts.Conflicting(migrations))
+	if len(renames) != 2 {
+		t.Fatalf("expected 2 renames, got %d", len(renames))
+	}
+	first, second := renames[0].To, renames[1].To
+	if first.Name != "comments" || second.Name != "comments_index" {
+		t.Errorf("renames lost their order: %s, %s", first.Name, second.Name)
+	}
+	if first.Timestamp <= "20260103000000" || second.Timestamp <= first.Timestamp {
+		t.Errorf("new timestamps must follow the head in order: %s, %s", first.Timestamp, second.Timestamp)
+	}
+
+	if err := migrate.ApplyRenumber(dir, renames); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := migrate.DiscoverMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"20260101000000_users", "20260103000000_posts", first.MigrationName(), second.MigrationName()}
+	if got := strings.Join(migrationNames(after), ","); got != strings.Join(want, ",") {
+		t.Errorf("migrations after renumber = %s, want %s", got, strings.Join(want, ","))
+	}
+	if !migrate.DetectConflicts(after, []string{"20260101000000_users", "20260103000000_posts"}).Empty() {
+		t.Error("expected no conflicts after renumber")
+	}
+
+	moved, err := os.ReadFile(first.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(moved), "func "+first.FuncName+"(") {
+		t.Errorf("function not renamed:\n%s", moved)
+	}
+
+	refs, err := os.ReadFile(filepath.Join(dir, "migrations_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRefs := "package migrations\n\n" +
+		"var names = []string{\"" + first.MigrationName() + "\", \"" + second.MigrationName() + "\"}\n" +
+		"var fn = " + first.FuncName + "\n"
+	if string(refs) != wantRefs {
+		t.Errorf("references not updated:\n%s\nwant:\n%s", refs, wantRefs)
+	}
+}
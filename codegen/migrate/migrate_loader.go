@@ -138,9 +138,13 @@ func DiscoverMigrations(migrationsPath string) ([]MigrationFile, error) {
 		})
 	}
 
-	// Sort by timestamp (lexicographic = chronological for this format)
+	// Sort by timestamp (lexicographic = chronological for this format), then
+	// by name so migrations sharing a timestamp keep the order migrate.Run expects
 	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].Timestamp < migrations[j].Timestamp
+		if migrations[i].Timestamp != migrations[j].Timestamp {
+			return migrations[i].Timestamp < migrations[j].Timestamp
+		}
+		return migrations[i].Name < migrations[j].Name
 	})
 
 	return migrations, nil
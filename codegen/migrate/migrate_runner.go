@@ -3,7 +3,8 @@ package migrate
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateMigrateRunner generates the runner.go file for the migrate subpackage.
@@ -17,6 +18,7 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"net/http"
 
 	"`)
 	buf.WriteString(modulePath)
@@ -53,9 +55,29 @@ func RunWithDB(ctx context.Context, dbConn *sql.DB) error {
 	}
 	return migrate.Run(ctx, dbConn, plan, db.Dialect)
 }
+
+// Pending returns the names of the embedded plan's migrations that have not
+// been applied to dbConn. cmd/server reports them on /healthz.
+func Pending(ctx context.Context, dbConn *sql.DB) ([]string, error) {
+	plan, err := Plan()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.PendingMigrations(ctx, dbConn, plan)
+}
+
+// UIHandler returns the development migration page for the embedded plan.
+// Mount it at migrate.UIPath; cmd/server does so outside production.
+func UIHandler(dbConn *sql.DB) (http.Handler, error) {
+	plan, err := Plan()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.UIHandler(dbConn, plan, db.Dialect), nil
+}
 `)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format runner.go: %w", err)
 	}
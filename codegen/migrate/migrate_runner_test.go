@@ -53,6 +53,11 @@ func TestGenerateMigrateRunner(t *testing.T) {
 			t.Error("generated code missing RunWithDB() function")
 		}
 
+		// Check UIHandler function
+		if !strings.Contains(contentStr, "func UIHandler(dbConn *sql.DB) (http.Handler, error)") {
+			t.Error("generated code missing UIHandler() function")
+		}
+
 		// Check imports
 		if !strings.Contains(contentStr, `"context"`) {
 			t.Error("generated code missing context import")
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"sort"
+	"time"
+)
+
+// MigrationState is where a migration stands relative to a database.
+type MigrationState string
+
+const (
+	// MigrationApplied is a migration file recorded in the tracking table.
+	MigrationApplied MigrationState = "applied"
+	// MigrationPending is a migration file not yet applied.
+	MigrationPending MigrationState = "pending"
+	// MigrationMissing is an applied migration with no file in the
+	// migrations directory, usually a deleted or renamed file.
+	MigrationMissing MigrationState = "missing"
+)
+
+// MigrationStatus is one line of `shipq migrate status`.
+type MigrationStatus struct {
+	Name  string         `json:"name"`
+	State MigrationState `json:"state"`
+	// AppliedAt is when the migration was applied; nil when pending.
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// MigrationStatuses compares the discovered migration files against the
+// migrations applied to a database, given as name → applied_at. The result
+// holds every migration of either side once, sorted by name, which puts
+// missing migrations where they were applied in the history.
+func MigrationStatuses(migrations []MigrationFile, applied map[string]time.Time) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(migrations)+len(applied))
+	seen := make(map[string]bool, len(migrations))
+	for _, m := range migrations {
+		name := m.MigrationName()
+		seen[name] = true
+		status := MigrationStatus{Name: name, State: MigrationPending}
+		if at, ok := applied[name]; ok {
+			status.State = MigrationApplied
+			status.AppliedAt = appliedAtPtr(at)
+		}
+		statuses = append(statuses, status)
+	}
+	for name, at := range applied {
+		if !seen[name] {
+			statuses = append(statuses, MigrationStatus{Name: name, State: MigrationMissing, AppliedAt: appliedAtPtr(at)})
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// appliedAtPtr returns &at, or nil for a zero time (an applied_at the
+// driver returned in an unrecognized form).
+func appliedAtPtr(at time.Time) *time.Time {
+	if at.IsZero() {
+		return nil
+	}
+	return &at
+}
+
+// CountStates returns how many statuses are in each state.
+func CountStates(statuses []MigrationStatus) map[MigrationState]int {
+	counts := map[MigrationState]int{MigrationApplied: 0, MigrationPending: 0, MigrationMissing: 0}
+	for _, s := range statuses {
+		counts[s.State]++
+	}
+	return counts
+}
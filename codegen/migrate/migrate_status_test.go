@@ -0,0 +1,55 @@
+package migrate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/codegen/migrate"
+)
+
+func TestMigrationStatuses(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrationFile(t, dir, "20260101000000", "users")
+	writeMigrationFile(t, dir, "20260103000000", "posts")
+	writeMigrationFile(t, dir, "20260104000000", "tags")
+
+	migrations, err := migrate.DiscoverMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usersAt := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	statuses := migrate.MigrationStatuses(migrations, map[string]time.Time{
+		"20260101000000_users":    usersAt,
+		"20260102000000_comments": usersAt.Add(time.Hour), // file deleted
+		"20260103000000_posts":    {},                     // unreadable applied_at
+	})
+
+	want := []struct {
+		name    string
+		state   migrate.MigrationState
+		applied bool
+	}{
+		{"20260101000000_users", migrate.MigrationApplied, true},
+		{"20260102000000_comments", migrate.MigrationMissing, true},
+		{"20260103000000_posts", migrate.MigrationApplied, false},
+		{"20260104000000_tags", migrate.MigrationPending, false},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("got %d statuses, want %d: %+v", len(statuses), len(want), statuses)
+	}
+	for i, w := range want {
+		s := statuses[i]
+		if s.Name != w.name || s.State != w.state || (s.AppliedAt != nil) != w.applied {
+			t.Errorf("statuses[%d] = %+v, want %s %s (applied_at set: %v)", i, s, w.name, w.state, w.applied)
+		}
+	}
+	if !statuses[0].AppliedAt.Equal(usersAt) {
+		t.Errorf("users AppliedAt = %v, want %v", statuses[0].AppliedAt, usersAt)
+	}
+
+	counts := migrate.CountStates(statuses)
+	if counts[migrate.MigrationApplied] != 2 || counts[migrate.MigrationPending] != 1 || counts[migrate.MigrationMissing] != 1 {
+		t.Errorf("counts = %v", counts)
+	}
+}
@@ -0,0 +1,115 @@
+package openapigen
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// schemaSet builds the schemas of one document. For OpenAPI 3.1 it moves
+// the schemas of named structs (handler requests and responses and the
+// structs nested in them) into components.schemas and refers to them with
+// $ref; for 3.0 every schema is inlined.
+type schemaSet struct {
+	custom     typeSchemas
+	components map[string]any    // nil inlines every schema
+	names      map[string]string // "<package>.<Name>" → component name
+}
+
+// newSchemaSet returns the schemaSet for handlers. extract enables
+// components: each struct is named after its Go type, qualified by its
+// package's name when two packages declare a struct of that name.
+func newSchemaSet(handlers []codegen.SerializedHandlerInfo, custom typeSchemas, extract bool) *schemaSet {
+	s := &schemaSet{custom: custom}
+	if !extract {
+		return s
+	}
+	s.components = make(map[string]any)
+
+	packages := make(map[string]map[string]bool) // Name → packages declaring it
+	var collect func(info *codegen.SerializedStructInfo)
+	collect = func(info *codegen.SerializedStructInfo) {
+		if info == nil {
+			return
+		}
+		if info.Name != "" {
+			if packages[info.Name] == nil {
+				packages[info.Name] = make(map[string]bool)
+			}
+			packages[info.Name][info.Package] = true
+		}
+		for _, f := range info.Fields {
+			collect(f.StructFields)
+		}
+	}
+	for _, h := range handlers {
+		collect(h.Request)
+		collect(h.Response)
+	}
+
+	s.names = make(map[string]string)
+	for name, pkgs := range packages {
+		for pkg := range pkgs {
+			component := name
+			if len(pkgs) > 1 {
+				component = path.Base(pkg) + "." + name
+			}
+			s.names[pkg+"."+name] = componentNameInvalid.ReplaceAllString(component, "_")
+		}
+	}
+	return s
+}
+
+// componentNameInvalid matches the characters a components.schemas key may
+// not contain, such as the brackets of an instantiated generic type.
+var componentNameInvalid = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// ref registers schema, built from info, as a component and returns a
+// $ref to it. The schema itself is returned when components are disabled,
+// the struct is anonymous, or the component already holds a different
+// schema, as when a request struct's body differs between two routes
+// with different path parameters.
+func (s *schemaSet) ref(info *codegen.SerializedStructInfo, schema map[string]any) map[string]any {
+	if s.components == nil || info == nil || info.Name == "" {
+		return schema
+	}
+	name := s.names[info.Package+"."+info.Name]
+	if existing, ok := s.components[name]; ok && !reflect.DeepEqual(existing, schema) {
+		return schema
+	}
+	s.components[name] = schema
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// convertNullable rewrites the OpenAPI 3.0 "nullable" keyword in v, a
+// document or any part of it, into its OpenAPI 3.1 form: "null" joins the
+// schema's type, and a nullable $ref becomes an anyOf with the null type.
+// A schema without a type already accepts null.
+func convertNullable(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, child := range v {
+			convertNullable(child)
+		}
+		if v["nullable"] != true {
+			return
+		}
+		delete(v, "nullable")
+		if ref, ok := v["$ref"]; ok {
+			delete(v, "$ref")
+			v["anyOf"] = []any{map[string]any{"$ref": ref}, map[string]any{"type": "null"}}
+		} else if typ, ok := v["type"].(string); ok {
+			v["type"] = []any{typ, "null"}
+		}
+	case []any:
+		for _, child := range v {
+			convertNullable(child)
+		}
+	case []map[string]any:
+		for _, child := range v {
+			convertNullable(child)
+		}
+	}
+}
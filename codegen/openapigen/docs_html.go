@@ -1,11 +1,31 @@
 package openapigen
 
+import (
+	"encoding/json"
+	"slices"
+)
+
+// DocsTokenFields are the JSON fields treated as auth tokens when the docs
+// page masks "try it" responses.
+var DocsTokenFields = []string{"access_token", "api_key", "id_token", "refresh_token", "session_token", "token"}
+
+// DocsOptions configures the docs page's "try it" mode.
+type DocsOptions struct {
+	// MaskFields lists JSON fields whose values are replaced with "*****"
+	// in displayed responses, at any depth. Empty disables masking.
+	MaskFields []string
+	// ReadOnly refuses to send requests other than GET, HEAD and OPTIONS.
+	ReadOnly bool
+}
+
 // GenerateDocsHTML returns an HTML page that renders an OpenAPI spec using
 // Stoplight Elements. The page loads the Elements web component JS and CSS
 // from /openapi/assets/ and points the <elements-api> component at /openapi
 // for the spec JSON. When prefix is non-empty (e.g., "/api"), all absolute
 // paths are prepended with it so the page works behind http.StripPrefix.
-func GenerateDocsHTML(title string, prefix string) string {
+// Masking and read-only mode wrap window.fetch, which Elements sends "try
+// it" requests through; they only change what the page shows and sends.
+func GenerateDocsHTML(title string, prefix string, opts DocsOptions) string {
 	if title == "" {
 		title = "API Documentation"
 	}
@@ -15,7 +35,7 @@ func GenerateDocsHTML(title string, prefix string) string {
     <meta charset="utf-8">
     <meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
     <title>` + title + `</title>
-    <script src="` + prefix + `/openapi/assets/web-components.min.js"></script>
+` + docsFetchScript(prefix, opts) + `    <script src="` + prefix + `/openapi/assets/web-components.min.js"></script>
     <link rel="stylesheet" href="` + prefix + `/openapi/assets/styles.min.css">
   </head>
   <body>
@@ -27,3 +47,72 @@ func GenerateDocsHTML(title string, prefix string) string {
   </body>
 </html>`
 }
+
+// docsFetchScript returns the <script> wrapping window.fetch for opts, or ""
+// when neither masking nor read-only mode is on. The spec itself is fetched
+// unmasked: its schemas are keyed by the very field names being masked.
+func docsFetchScript(prefix string, opts DocsOptions) string {
+	if len(opts.MaskFields) == 0 && !opts.ReadOnly {
+		return ""
+	}
+	fields := slices.Clone(opts.MaskFields)
+	slices.Sort(fields)
+	fields = slices.Compact(fields)
+	if fields == nil {
+		fields = []string{}
+	}
+	maskJSON, _ := json.Marshal(fields)
+	specJSON, _ := json.Marshal(prefix + "/openapi")
+	readOnly := "false"
+	if opts.ReadOnly {
+		readOnly = "true"
+	}
+	return `    <script>
+      (function () {
+        var masked = new Set(` + string(maskJSON) + `);
+        var readOnly = ` + readOnly + `;
+        var specPath = ` + string(specJSON) + `;
+        var safeMethods = ["GET", "HEAD", "OPTIONS"];
+        var fetch = window.fetch.bind(window);
+
+        function mask(v) {
+          if (Array.isArray(v)) return v.map(mask);
+          if (v && typeof v === "object") {
+            var out = {};
+            Object.keys(v).forEach(function (k) {
+              out[k] = masked.has(k) && v[k] !== null ? "*****" : mask(v[k]);
+            });
+            return out;
+          }
+          return v;
+        }
+
+        window.fetch = function (input, init) {
+          var req = new Request(input, init);
+          if (new URL(req.url, location.href).pathname === specPath) return fetch(input, init);
+          if (readOnly && safeMethods.indexOf(req.method.toUpperCase()) < 0) {
+            return Promise.resolve(new Response(JSON.stringify({ error: "these docs are read-only: " + req.method + " requests are disabled" }), {
+              status: 403,
+              headers: { "Content-Type": "application/json" }
+            }));
+          }
+          return fetch(req).then(function (resp) {
+            if (masked.size === 0 || resp.body === null) return resp;
+            var headers = new Headers(resp.headers);
+            headers.delete("Authorization");
+            headers.delete("Set-Cookie");
+            if ((headers.get("Content-Type") || "").indexOf("json") < 0) {
+              return new Response(resp.body, { status: resp.status, statusText: resp.statusText, headers: headers });
+            }
+            return resp.text().then(function (text) {
+              var body = text;
+              try { body = JSON.stringify(mask(JSON.parse(text))); } catch (e) {}
+              headers.delete("Content-Length");
+              return new Response(body, { status: resp.status, statusText: resp.statusText, headers: headers });
+            });
+          });
+        };
+      })();
+    </script>
+`
+}
@@ -0,0 +1,47 @@
+package openapigen
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDocsHTML(t *testing.T) {
+	plain := GenerateDocsHTML("", "/api", DocsOptions{})
+	if !strings.Contains(plain, `apiDescriptionUrl="/api/openapi"`) {
+		t.Errorf("missing prefixed spec URL:\n%s", plain)
+	}
+	if strings.Contains(plain, "window.fetch") {
+		t.Error("fetch should not be wrapped without masking or read-only mode")
+	}
+
+	html := GenerateDocsHTML("Docs", "/api", DocsOptions{MaskFields: []string{"ssn", "token", "ssn"}, ReadOnly: true})
+	lines := htmlLines(html)
+	for _, want := range []string{
+		`var masked = new Set(["ssn","token"]);`,
+		"var readOnly = true;",
+		`var specPath = "/api/openapi";`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing line %q:\n%s", want, html)
+		}
+	}
+	// The wrapper must be installed before Elements loads.
+	if strings.Index(html, "window.fetch =") > strings.Index(html, "web-components.min.js") {
+		t.Error("fetch wrapper should come before the Elements script")
+	}
+
+	readOnly := GenerateDocsHTML("Docs", "", DocsOptions{ReadOnly: true})
+	if !slices.Contains(htmlLines(readOnly), "var masked = new Set([]);") {
+		t.Errorf("read-only page should mask nothing:\n%s", readOnly)
+	}
+}
+
+// htmlLines returns the lines of page with surrounding whitespace removed.
+func htmlLines(page string) []string {
+	lines := strings.Split(page, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
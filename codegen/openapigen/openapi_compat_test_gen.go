@@ -0,0 +1,83 @@
+package openapigen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
+)
+
+// OpenAPICompatTestGenConfig holds configuration for generating the OpenAPI
+// backward-compatibility test.
+type OpenAPICompatTestGenConfig struct {
+	ModulePath   string // e.g., "myapp"
+	OutputPkg    string // package name for generated code (e.g., "api")
+	BaselinePath string // released OpenAPI document, relative to the output package directory
+}
+
+// GenerateOpenAPICompatTest generates a test that compares the compiled
+// OpenAPI document (the openAPISpec variable of the generated server)
+// against the released baseline with shipq/lib/openapidiff. Each breaking
+// change fails the test; compatible changes are logged. When
+// OPENAPI_COMPAT_REPORT is set, the full report is written there as JSON
+// for CI to archive or post.
+func GenerateOpenAPICompatTest(cfg OpenAPICompatTestGenConfig) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(codegen.GeneratedHeader + "\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", cfg.OutputPkg)
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"os\"\n")
+	buf.WriteString("\t\"testing\"\n")
+	buf.WriteString("\n")
+	fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/lib/openapidiff")
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("// openAPIBaseline is the last released OpenAPI document ([openapi] baseline\n")
+	buf.WriteString("// in shipq.ini), relative to this package.\n")
+	fmt.Fprintf(&buf, "const openAPIBaseline = %q\n\n", cfg.BaselinePath)
+
+	buf.WriteString(`// TestOpenAPIBackwardCompatible fails when the current OpenAPI document would
+// break clients of the released one: removed paths, operations or response
+// fields, changed types, or newly required parameters and request fields.
+// After a release, copy .shipq/openapi.json over the baseline.
+func TestOpenAPIBackwardCompatible(t *testing.T) {
+	baseline, err := os.ReadFile(openAPIBaseline)
+	if err != nil {
+		t.Fatalf("failed to read OpenAPI baseline: %v (copy .shipq/openapi.json there after a release)", err)
+	}
+
+	report, err := openapidiff.Compare(baseline, []byte(openAPISpec))
+	if err != nil {
+		t.Fatalf("failed to compare OpenAPI documents: %v", err)
+	}
+
+	if path := os.Getenv("OPENAPI_COMPAT_REPORT"); path != "" {
+		data, err := report.JSON()
+		if err != nil {
+			t.Fatalf("failed to encode compatibility report: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to write compatibility report: %v", err)
+		}
+	}
+
+	for _, change := range report.Changes {
+		if change.Breaking {
+			t.Errorf("breaking change: %s", change)
+		} else {
+			t.Logf("compatible change: %s", change)
+		}
+	}
+}
+`)
+
+	formatted, err := phase.FormatSource(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("failed to format OpenAPI compat test code: %w\nunformatted:\n%s", err, buf.String())
+	}
+
+	return formatted, nil
+}
@@ -0,0 +1,47 @@
+package openapigen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+)
+
+func TestGenerateOpenAPICompatTest(t *testing.T) {
+	code, err := GenerateOpenAPICompatTest(OpenAPICompatTestGenConfig{
+		ModulePath:   "example.com/app",
+		OutputPkg:    "api",
+		BaselinePath: "../openapi/released.json",
+	})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPICompatTest() error = %v", err)
+	}
+
+	if !bytes.HasPrefix(code, []byte("// Code generated by shipq. DO NOT EDIT.\n")) {
+		t.Error("missing generated-code header")
+	}
+	f := gofile.Parse(t, "openapi_compat_test.go", code)
+	if f.AST.Name.Name != "api" {
+		t.Errorf("package = %s, want api", f.AST.Name.Name)
+	}
+	if !f.HasImport("example.com/app/shipq/lib/openapidiff") {
+		t.Error("missing openapidiff import")
+	}
+	if got := f.Value("openAPIBaseline"); got != `"../openapi/released.json"` {
+		t.Errorf("openAPIBaseline = %s", got)
+	}
+
+	fn := "TestOpenAPIBackwardCompatible"
+	f.AssertSignature(fn, "func TestOpenAPIBackwardCompatible(t *testing.T)")
+	f.AssertStmts(fn,
+		"baseline, err := os.ReadFile(openAPIBaseline)",
+		"report, err := openapidiff.Compare(baseline, []byte(openAPISpec))",
+		`if path := os.Getenv("OPENAPI_COMPAT_REPORT"); path != ""`,
+		"if change.Breaking",
+		`t.Errorf("breaking change: %s", change)`,
+		`t.Logf("compatible change: %s", change)`,
+	)
+	if !f.Before(fn, "report, err := openapidiff.Compare(baseline, []byte(openAPISpec))", "for _, change := range report.Changes") {
+		t.Error("changes should be reported after the comparison")
+	}
+}
@@ -2,11 +2,16 @@ package openapigen
 
 import (
 	"encoding/json"
+	"fmt"
+	"maps"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/config"
+	"github.com/shipq/shipq/db/portsql/ddl"
 )
 
 // OpenAPIGenConfig holds configuration for generating the OpenAPI spec.
@@ -16,9 +21,58 @@ type OpenAPIGenConfig struct {
 	Title       string                          // defaults to module path base name
 	Version     string                          // defaults to "1.0.0"
 	StripPrefix string                          // URL prefix for the servers block (e.g., "/api")
+	OpenAPI     *config.OpenAPIConfig           // [openapi] servers and security schemes; nil uses cookie auth only
+	CustomTypes map[string]ddl.CustomType       // custom column types from schema.json; fields of their GoType use their OpenAPI schema
+	Naming      *config.NamingConfig            // [naming] operationId template and case; nil uses the handler function name
 }
 
-// GenerateOpenAPISpec generates an OpenAPI 3.1.0 JSON document from the handler registry.
+// typeSchemas maps Go types, as they appear in handler fields, to the
+// OpenAPI schemas of the custom column types they represent.
+type typeSchemas map[string]map[string]any
+
+// customTypeSchemas builds the typeSchemas for types, defaulting a type
+// without an OpenAPI schema to a string. Types represented by a predeclared
+// Go type such as string are indistinguishable from other fields of that
+// type, so they keep its schema.
+func customTypeSchemas(types map[string]ddl.CustomType) typeSchemas {
+	schemas := make(typeSchemas)
+	for _, t := range types {
+		if !strings.Contains(t.GoType, ".") {
+			continue
+		}
+		schema := map[string]any{"type": "string"}
+		if len(t.OpenAPI) > 0 {
+			schema = t.OpenAPI
+		}
+		schemas[t.GoType] = schema
+	}
+	return schemas
+}
+
+// lookup returns a copy of the schema for goType, which callers may then
+// annotate without affecting other fields.
+func (s typeSchemas) lookup(goType string) (map[string]any, bool) {
+	schema, ok := s[goType]
+	if !ok {
+		return nil, false
+	}
+	copied := make(map[string]any, len(schema))
+	for k, v := range schema {
+		copied[k] = v
+	}
+	return copied, true
+}
+
+// securitySchemeNames maps [openapi] security entries to the names of their
+// components.securitySchemes entries.
+var securitySchemeNames = map[string]string{
+	"cookie": "cookieAuth",
+	"bearer": "bearerAuth",
+	"apikey": "apiKeyAuth",
+}
+
+// GenerateOpenAPISpec generates an OpenAPI JSON document from the handler
+// registry: 3.0.3 by default, or 3.1.0 when [openapi] spec_version = 3.1.
 // The spec is built as nested maps and marshalled to indented JSON.
 func GenerateOpenAPISpec(cfg OpenAPIGenConfig) ([]byte, error) {
 	title := cfg.Title
@@ -30,35 +84,81 @@ func GenerateOpenAPISpec(cfg OpenAPIGenConfig) ([]byte, error) {
 		version = "1.0.0"
 	}
 
+	v31 := cfg.OpenAPI != nil && cfg.OpenAPI.SpecVersion == "3.1"
+	openapiVersion := "3.0.3"
+	if v31 {
+		openapiVersion = "3.1.0"
+	}
+
 	spec := map[string]any{
-		"openapi": "3.1.0",
+		"openapi": openapiVersion,
 		"info": map[string]any{
 			"title":   title,
 			"version": version,
 		},
 	}
 
-	// When a strip prefix is configured (e.g., "/api"), add a servers block
-	// so that OpenAPI clients know to prepend the prefix to all paths.
-	if cfg.StripPrefix != "" {
+	security := []string{"cookie"}
+	apiKeyHeader := "X-API-Key"
+	if cfg.OpenAPI != nil {
+		security = cfg.OpenAPI.Security
+		apiKeyHeader = cfg.OpenAPI.APIKeyHeader
+	}
+
+	// Per-environment servers come from [openapi.servers]; the strip prefix
+	// is part of every server's base URL. Without them, a configured strip
+	// prefix (e.g., "/api") becomes a relative server so that OpenAPI
+	// clients know to prepend it to all paths.
+	if cfg.OpenAPI != nil && len(cfg.OpenAPI.Servers) > 0 {
+		servers := make([]map[string]any, len(cfg.OpenAPI.Servers))
+		for i, srv := range cfg.OpenAPI.Servers {
+			servers[i] = map[string]any{
+				"url":         srv.URL + cfg.StripPrefix,
+				"description": srv.Env,
+			}
+		}
+		spec["servers"] = servers
+	} else if cfg.StripPrefix != "" {
 		spec["servers"] = []map[string]any{
 			{"url": cfg.StripPrefix},
 		}
 	}
 
-	// Build paths
-	paths := buildPaths(cfg.Handlers)
+	// operationIds must be unique within the document, which a configured
+	// template without {func} may not guarantee.
+	if cfg.Naming != nil {
+		seen := make(map[string]codegen.SerializedHandlerInfo)
+		for _, h := range cfg.Handlers {
+			id := operationID(h, cfg.Naming)
+			if prev, ok := seen[id]; ok {
+				return nil, fmt.Errorf("operationId %q is shared by %s %s and %s %s; make [naming] operation_id more specific (e.g. include {func})",
+					id, prev.Method, prev.Path, h.Method, h.Path)
+			}
+			seen[id] = h
+		}
+	}
+
+	// Build paths; a 3.1 document collects the struct schemas as components
+	schemas := newSchemaSet(cfg.Handlers, customTypeSchemas(cfg.CustomTypes), v31)
+	paths := buildPaths(cfg.Handlers, security, schemas, cfg.Naming)
 	spec["paths"] = paths
 
 	// Build components (schemas + security schemes)
-	components := buildComponents(cfg.Handlers)
+	components := buildComponents(cfg.Handlers, security, apiKeyHeader)
+	if len(schemas.components) > 0 {
+		components["schemas"] = schemas.components
+	}
 	spec["components"] = components
 
+	if v31 {
+		convertNullable(spec)
+	}
+
 	return json.MarshalIndent(spec, "", "  ")
 }
 
 // buildPaths converts handler info into the OpenAPI paths object.
-func buildPaths(handlers []codegen.SerializedHandlerInfo) map[string]any {
+func buildPaths(handlers []codegen.SerializedHandlerInfo, security []string, schemas *schemaSet, naming *config.NamingConfig) map[string]any {
 	paths := make(map[string]any)
 
 	// Group by path for deterministic output
@@ -78,7 +178,7 @@ func buildPaths(handlers []codegen.SerializedHandlerInfo) map[string]any {
 	for _, p := range pathOrder {
 		pathItem := make(map[string]any)
 		for _, h := range pathHandlers[p] {
-			operation := buildOperation(h)
+			operation := buildOperation(h, security, schemas, naming)
 			method := strings.ToLower(h.Method)
 			pathItem[method] = operation
 		}
@@ -88,22 +188,28 @@ func buildPaths(handlers []codegen.SerializedHandlerInfo) map[string]any {
 	return paths
 }
 
+// operationID renders the operationId of h following [naming].
+func operationID(h codegen.SerializedHandlerInfo, naming *config.NamingConfig) string {
+	return naming.OperationID(h.FuncName, path.Base(h.PackagePath), h.Method)
+}
+
 // buildOperation creates an OpenAPI operation object from a handler.
-func buildOperation(h codegen.SerializedHandlerInfo) map[string]any {
+// security lists the [openapi] schemes that authenticated routes accept.
+func buildOperation(h codegen.SerializedHandlerInfo, security []string, schemas *schemaSet, naming *config.NamingConfig) map[string]any {
 	op := make(map[string]any)
 
-	// Operation ID from function name
-	op["operationId"] = h.FuncName
+	// Operation ID from the function name, shaped by [naming]
+	op["operationId"] = operationID(h, naming)
 
 	// Tags from resource name
 	resourceName := path.Base(h.PackagePath)
 	op["tags"] = []string{resourceName}
 
 	// Path parameters
-	params := buildPathParameters(h)
+	params := buildPathParameters(h, schemas.custom)
 
 	// Query parameters
-	queryParams := buildQueryParameters(h)
+	queryParams := buildQueryParameters(h, schemas.custom)
 	params = append(params, queryParams...)
 
 	if len(params) > 0 {
@@ -115,7 +221,7 @@ func buildOperation(h codegen.SerializedHandlerInfo) map[string]any {
 		// Filter out path param and query param fields from the request body
 		bodyFields := filterBodyFields(h)
 		if len(bodyFields) > 0 {
-			schema := buildSchemaFromFields(bodyFields)
+			schema := schemas.ref(h.Request, buildSchemaFromFields(bodyFields, schemas))
 			op["requestBody"] = map[string]any{
 				"required": true,
 				"content": map[string]any{
@@ -127,21 +233,53 @@ func buildOperation(h codegen.SerializedHandlerInfo) map[string]any {
 		}
 	}
 
+	// Raw request bodies, such as CSV uploads, are documented as strings
+	if codegen.MethodHasBody(h.Method) && len(h.RawBodyTypes) > 0 {
+		reqBody, _ := op["requestBody"].(map[string]any)
+		if reqBody == nil {
+			reqBody = map[string]any{"required": true, "content": map[string]any{}}
+			op["requestBody"] = reqBody
+		}
+		content := reqBody["content"].(map[string]any)
+		for _, mediaType := range h.RawBodyTypes {
+			content[mediaType] = map[string]any{"schema": map[string]any{"type": "string"}}
+		}
+	}
+
 	// Responses
-	op["responses"] = buildResponses(h)
+	op["responses"] = buildResponses(h, schemas)
 
-	// Security
-	if h.RequireAuth {
-		op["security"] = []map[string]any{
-			{"cookieAuth": []string{}},
+	// Deprecation
+	if h.Deprecated {
+		op["deprecated"] = true
+		if h.Sunset != "" {
+			op["x-sunset"] = h.Sunset
 		}
 	}
 
+	// Feature gating
+	if h.Feature != "" {
+		op["x-feature"] = h.Feature
+	}
+
+	// Security: any one of the configured schemes satisfies an auth route;
+	// an optional-auth route may also be called anonymously ({}).
+	if (h.RequireAuth || h.OptionalAuth) && len(security) > 0 {
+		var requirements []map[string]any
+		for _, scheme := range security {
+			requirements = append(requirements, map[string]any{securitySchemeNames[scheme]: []string{}})
+		}
+		if h.OptionalAuth {
+			requirements = append(requirements, map[string]any{})
+		}
+		op["security"] = requirements
+	}
+
 	return op
 }
 
 // buildPathParameters creates OpenAPI parameter objects from path params.
-func buildPathParameters(h codegen.SerializedHandlerInfo) []map[string]any {
+func buildPathParameters(h codegen.SerializedHandlerInfo, custom typeSchemas) []map[string]any {
 	var params []map[string]any
 
 	for _, pp := range h.PathParams {
@@ -159,7 +297,7 @@ func buildPathParameters(h codegen.SerializedHandlerInfo) []map[string]any {
 				if f.Tags["path"] == pp.Name ||
 					strings.EqualFold(f.JSONName, pp.Name) ||
 					strings.EqualFold(f.Name, pp.Name) {
-					schema = goTypeToOpenAPISchema(f.Type)
+					schema = goTypeToOpenAPISchema(f.Type, custom)
 					break
 				}
 			}
@@ -173,7 +311,7 @@ func buildPathParameters(h codegen.SerializedHandlerInfo) []map[string]any {
 }
 
 // buildQueryParameters creates OpenAPI parameter objects from query-tagged fields.
-func buildQueryParameters(h codegen.SerializedHandlerInfo) []map[string]any {
+func buildQueryParameters(h codegen.SerializedHandlerInfo, custom typeSchemas) []map[string]any {
 	queryFields := codegen.FilterQueryFields(h)
 	if len(queryFields) == 0 {
 		return nil
@@ -182,11 +320,18 @@ func buildQueryParameters(h codegen.SerializedHandlerInfo) []map[string]any {
 	var params []map[string]any
 	for _, f := range queryFields {
 		queryName := f.Tags["query"]
+		schema := goTypeToOpenAPISchema(f.Type, custom)
+		if format, ok := queryFormatSchemas[f.Tags["format"]]; ok {
+			schema = maps.Clone(format)
+			if strings.HasPrefix(f.Type, "*") {
+				schema["nullable"] = true
+			}
+		}
 		param := map[string]any{
 			"name":     queryName,
 			"in":       "query",
 			"required": f.Required,
-			"schema":   goTypeToOpenAPISchema(f.Type),
+			"schema":   schema,
 		}
 		params = append(params, param)
 	}
@@ -194,6 +339,16 @@ func buildQueryParameters(h codegen.SerializedHandlerInfo) []map[string]any {
 	return params
 }
 
+// queryFormatSchemas are the schemas of query parameters whose field has a
+// format tag. Generated list filters are strings parsed by the handler;
+// the tag documents the type of their values.
+var queryFormatSchemas = map[string]map[string]any{
+	"date-time": {"type": "string", "format": "date-time"},
+	"int32":     {"type": "integer", "format": "int32"},
+	"int64":     {"type": "integer", "format": "int64"},
+	"boolean":   {"type": "boolean"},
+}
+
 // filterBodyFields returns request fields that are NOT path parameters.
 func filterBodyFields(h codegen.SerializedHandlerInfo) []codegen.SerializedFieldInfo {
 	if h.Request == nil {
@@ -225,7 +380,7 @@ func filterBodyFields(h codegen.SerializedHandlerInfo) []codegen.SerializedField
 }
 
 // buildResponses creates the OpenAPI responses object for a handler.
-func buildResponses(h codegen.SerializedHandlerInfo) map[string]any {
+func buildResponses(h codegen.SerializedHandlerInfo, schemas *schemaSet) map[string]any {
 	responses := make(map[string]any)
 
 	successCode := "200"
@@ -238,7 +393,7 @@ func buildResponses(h codegen.SerializedHandlerInfo) map[string]any {
 	}
 
 	if h.Response != nil && len(h.Response.Fields) > 0 {
-		schema := buildSchemaFromFields(h.Response.Fields)
+		schema := schemas.ref(h.Response, buildSchemaFromFields(h.Response.Fields, schemas))
 		successResp["content"] = map[string]any{
 			"application/json": map[string]any{
 				"schema": schema,
@@ -269,7 +424,7 @@ func buildResponses(h codegen.SerializedHandlerInfo) map[string]any {
 }
 
 // buildSchemaFromFields creates an OpenAPI schema object from struct fields.
-func buildSchemaFromFields(fields []codegen.SerializedFieldInfo) map[string]any {
+func buildSchemaFromFields(fields []codegen.SerializedFieldInfo, schemas *schemaSet) map[string]any {
 	schema := map[string]any{
 		"type": "object",
 	}
@@ -290,7 +445,26 @@ func buildSchemaFromFields(fields []codegen.SerializedFieldInfo) map[string]any
 			jsonName = f.Name
 		}
 
-		properties[jsonName] = fieldToOpenAPISchema(f)
+		prop := fieldToOpenAPISchema(f, schemas)
+		if f.Tags["deprecated"] == "true" {
+			prop["deprecated"] = true
+		}
+		// Column-level role restrictions from generated CRUD handlers
+		if roles := f.Tags["read_roles"]; roles != "" {
+			prop["x-read-roles"] = strings.Split(roles, ",")
+		}
+		if roles := f.Tags["write_roles"]; roles != "" {
+			prop["x-write-roles"] = strings.Split(roles, ",")
+		}
+		// API-side defaults from generated create handlers
+		if def, ok := f.Tags["default"]; ok {
+			prop["default"] = defaultValue(prop, def)
+		}
+		// Column-derived validation rules from generated CRUD handlers
+		if rules := f.Tags["validate"]; rules != "" {
+			applyValidateRules(prop, rules)
+		}
+		properties[jsonName] = prop
 
 		if f.Required {
 			required = append(required, jsonName)
@@ -306,27 +480,96 @@ func buildSchemaFromFields(fields []codegen.SerializedFieldInfo) map[string]any
 	return schema
 }
 
+// applyValidateRules adds the string constraints of a validate tag value
+// ("required,max=255,email" or "oneof=draft live") to the string schema
+// prop. Decimal rules have no JSON Schema counterpart and are left to the
+// handler's 422.
+func applyValidateRules(prop map[string]any, rules string) {
+	if prop["type"] != "string" {
+		return
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			prop["minLength"] = 1
+		case "max":
+			if n, err := strconv.Atoi(arg); err == nil {
+				prop["maxLength"] = n
+			}
+		case "email":
+			prop["format"] = "email"
+		case "oneof":
+			// A nullable enum lists null among its values
+			var values []any
+			for _, v := range strings.Fields(arg) {
+				values = append(values, v)
+			}
+			if prop["nullable"] == true {
+				values = append(values, nil)
+			}
+			prop["enum"] = values
+		}
+	}
+}
+
+// defaultValue converts a default tag value to the JSON type of prop, falling
+// back to the string as written.
+func defaultValue(prop map[string]any, value string) any {
+	switch prop["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
 // buildComponents creates the OpenAPI components object.
-func buildComponents(handlers []codegen.SerializedHandlerInfo) map[string]any {
+func buildComponents(handlers []codegen.SerializedHandlerInfo, security []string, apiKeyHeader string) map[string]any {
 	components := make(map[string]any)
 
-	// Add security schemes if any handler requires auth
+	// Add security schemes if any handler goes through the auth middleware
 	hasAuth := false
 	for _, h := range handlers {
-		if h.RequireAuth {
+		if h.RequireAuth || h.OptionalAuth {
 			hasAuth = true
 			break
 		}
 	}
 
-	if hasAuth {
-		components["securitySchemes"] = map[string]any{
-			"cookieAuth": map[string]any{
-				"type": "apiKey",
-				"in":   "cookie",
-				"name": "session",
-			},
+	if hasAuth && len(security) > 0 {
+		schemes := make(map[string]any)
+		for _, scheme := range security {
+			switch scheme {
+			case "cookie":
+				schemes["cookieAuth"] = map[string]any{
+					"type": "apiKey",
+					"in":   "cookie",
+					"name": "session",
+				}
+			case "bearer":
+				schemes["bearerAuth"] = map[string]any{
+					"type":   "http",
+					"scheme": "bearer",
+				}
+			case "apikey":
+				schemes["apiKeyAuth"] = map[string]any{
+					"type": "apiKey",
+					"in":   "header",
+					"name": apiKeyHeader,
+				}
+			}
 		}
+		components["securitySchemes"] = schemes
 	}
 
 	return components
@@ -335,9 +578,9 @@ func buildComponents(handlers []codegen.SerializedHandlerInfo) map[string]any {
 // fieldToOpenAPISchema converts a SerializedFieldInfo to an OpenAPI schema.
 // If the field has StructFields (i.e., it's a nested struct), it produces a
 // proper object schema (or array of objects) instead of falling back to string.
-func fieldToOpenAPISchema(f codegen.SerializedFieldInfo) map[string]any {
+func fieldToOpenAPISchema(f codegen.SerializedFieldInfo, schemas *schemaSet) map[string]any {
 	if f.StructFields != nil && len(f.StructFields.Fields) > 0 {
-		objSchema := buildSchemaFromFields(f.StructFields.Fields)
+		objSchema := schemas.ref(f.StructFields, buildSchemaFromFields(f.StructFields.Fields, schemas))
 
 		goType := f.Type
 		// Peel pointer wrapper
@@ -366,14 +609,15 @@ func fieldToOpenAPISchema(f codegen.SerializedFieldInfo) map[string]any {
 		return objSchema
 	}
 
-	return goTypeToOpenAPISchema(f.Type)
+	return goTypeToOpenAPISchema(f.Type, schemas.custom)
 }
 
 // goTypeToOpenAPISchema converts a Go type string to an OpenAPI schema map.
-func goTypeToOpenAPISchema(goType string) map[string]any {
+// Custom column types take their schema from custom.
+func goTypeToOpenAPISchema(goType string, custom typeSchemas) map[string]any {
 	// Handle pointer types by stripping the *
 	if strings.HasPrefix(goType, "*") {
-		inner := goTypeToOpenAPISchema(goType[1:])
+		inner := goTypeToOpenAPISchema(goType[1:], custom)
 		inner["nullable"] = true
 		return inner
 	}
@@ -383,10 +627,14 @@ func goTypeToOpenAPISchema(goType string) map[string]any {
 		elementType := goType[2:]
 		return map[string]any{
 			"type":  "array",
-			"items": goTypeToOpenAPISchema(elementType),
+			"items": goTypeToOpenAPISchema(elementType, custom),
 		}
 	}
 
+	if schema, ok := custom.lookup(goType); ok {
+		return schema
+	}
+
 	switch goType {
 	case "string":
 		return map[string]any{"type": "string"}
@@ -406,7 +654,13 @@ func goTypeToOpenAPISchema(goType string) map[string]any {
 		return map[string]any{"type": "boolean"}
 	case "time.Time":
 		return map[string]any{"type": "string", "format": "date-time"}
+	case "json.RawMessage", "any", "interface{}":
+		// Arbitrary JSON: an empty schema accepts any value
+		return map[string]any{}
 	default:
+		if strings.HasPrefix(goType, "map[") {
+			return map[string]any{"type": "object"}
+		}
 		// Unknown types default to string
 		return map[string]any{"type": "string"}
 	}
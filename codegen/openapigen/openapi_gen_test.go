@@ -2,9 +2,13 @@ package openapigen
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/config"
+	"github.com/shipq/shipq/db/portsql/ddl"
 )
 
 // parseSpec is a test helper that generates the spec and unmarshals it.
@@ -29,8 +33,8 @@ func TestGenerateOpenAPISpec_BasicStructure(t *testing.T) {
 
 	spec := parseSpec(t, cfg)
 
-	if spec["openapi"] != "3.1.0" {
-		t.Errorf("expected openapi 3.1.0, got %v", spec["openapi"])
+	if spec["openapi"] != "3.0.3" {
+		t.Errorf("expected openapi 3.0.3, got %v", spec["openapi"])
 	}
 
 	info, ok := spec["info"].(map[string]any)
@@ -340,6 +344,127 @@ func TestGenerateOpenAPISpec_NoAuthNoCookieScheme(t *testing.T) {
 	}
 }
 
+func TestGenerateOpenAPISpec_ConfiguredServersAndSecurity(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath:  "example.com/app",
+		StripPrefix: "/api",
+		OpenAPI: &config.OpenAPIConfig{
+			Servers: []config.OpenAPIServer{
+				{Env: "development", URL: "http://localhost:8080"},
+				{Env: "production", URL: "https://api.example.com"},
+			},
+			Security:     []string{"cookie", "bearer", "apikey"},
+			APIKeyHeader: "X-Token",
+		},
+		Handlers: []codegen.SerializedHandlerInfo{
+			{Method: "GET", Path: "/me", FuncName: "Me", PackagePath: "example.com/app/api/auth", RequireAuth: true},
+			{Method: "GET", Path: "/feed", FuncName: "Feed", PackagePath: "example.com/app/api/feed", OptionalAuth: true},
+			{Method: "GET", Path: "/health", FuncName: "Health", PackagePath: "example.com/app/api/health"},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+
+	servers := spec["servers"].([]any)
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 servers, got %d", len(servers))
+	}
+	prod := servers[1].(map[string]any)
+	if prod["url"] != "https://api.example.com/api" || prod["description"] != "production" {
+		t.Errorf("unexpected production server: %v", prod)
+	}
+
+	paths := spec["paths"].(map[string]any)
+	operation := func(p string) map[string]any {
+		return paths[p].(map[string]any)["get"].(map[string]any)
+	}
+
+	meSecurity := operation("/me")["security"].([]any)
+	if len(meSecurity) != 3 {
+		t.Fatalf("expected one requirement per scheme on auth route, got %v", meSecurity)
+	}
+	for i, name := range []string{"cookieAuth", "bearerAuth", "apiKeyAuth"} {
+		if _, ok := meSecurity[i].(map[string]any)[name]; !ok {
+			t.Errorf("requirement %d: expected %s, got %v", i, name, meSecurity[i])
+		}
+	}
+
+	feedSecurity := operation("/feed")["security"].([]any)
+	if len(feedSecurity) != 4 || len(feedSecurity[3].(map[string]any)) != 0 {
+		t.Errorf("optional-auth route should also allow anonymous access, got %v", feedSecurity)
+	}
+
+	if _, ok := operation("/health")["security"]; ok {
+		t.Error("public route should not have security requirement")
+	}
+
+	schemes := spec["components"].(map[string]any)["securitySchemes"].(map[string]any)
+	bearer := schemes["bearerAuth"].(map[string]any)
+	if bearer["type"] != "http" || bearer["scheme"] != "bearer" {
+		t.Errorf("unexpected bearerAuth scheme: %v", bearer)
+	}
+	apiKey := schemes["apiKeyAuth"].(map[string]any)
+	if apiKey["in"] != "header" || apiKey["name"] != "X-Token" {
+		t.Errorf("unexpected apiKeyAuth scheme: %v", apiKey)
+	}
+	if _, ok := schemes["cookieAuth"]; !ok {
+		t.Error("expected cookieAuth scheme")
+	}
+}
+
+func TestGenerateOpenAPISpec_NoSecuritySchemesConfigured(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		OpenAPI:    &config.OpenAPIConfig{},
+		Handlers: []codegen.SerializedHandlerInfo{
+			{Method: "GET", Path: "/me", FuncName: "Me", PackagePath: "example.com/app/api/auth", RequireAuth: true},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+
+	me := spec["paths"].(map[string]any)["/me"].(map[string]any)["get"].(map[string]any)
+	if _, ok := me["security"]; ok {
+		t.Error("expected no security requirement when [openapi] security is empty")
+	}
+	if _, ok := spec["components"].(map[string]any)["securitySchemes"]; ok {
+		t.Error("expected no securitySchemes when [openapi] security is empty")
+	}
+}
+
+func TestGoTypeToOpenAPISchema_ArbitraryJSON(t *testing.T) {
+	for _, goType := range []string{"json.RawMessage", "any", "interface{}"} {
+		if schema := goTypeToOpenAPISchema(goType, nil); len(schema) != 0 {
+			t.Errorf("%s: expected empty (any-value) schema, got %v", goType, schema)
+		}
+	}
+}
+
+func TestGoTypeToOpenAPISchema_CustomTypes(t *testing.T) {
+	custom := customTypeSchemas(map[string]ddl.CustomType{
+		"money":  {Name: "money", GoType: "decimal.Decimal", OpenAPI: map[string]any{"type": "string", "format": "decimal"}},
+		"ulid":   {Name: "ulid", GoType: "ulid.ULID"},
+		"citext": {Name: "citext", GoType: "string"},
+	})
+
+	if schema := goTypeToOpenAPISchema("decimal.Decimal", custom); schema["format"] != "decimal" {
+		t.Errorf("decimal.Decimal: expected the custom schema, got %v", schema)
+	}
+	nullable := goTypeToOpenAPISchema("*decimal.Decimal", custom)
+	if nullable["format"] != "decimal" || nullable["nullable"] != true {
+		t.Errorf("*decimal.Decimal: expected a nullable custom schema, got %v", nullable)
+	}
+	if _, ok := custom["decimal.Decimal"]["nullable"]; ok {
+		t.Error("annotating a field's schema must not modify the shared custom schema")
+	}
+	if schema := goTypeToOpenAPISchema("ulid.ULID", custom); schema["type"] != "string" {
+		t.Errorf("ulid.ULID: expected the default string schema, got %v", schema)
+	}
+	if _, ok := custom["string"]; ok {
+		t.Error("custom types represented by predeclared Go types should not override them")
+	}
+}
+
 func TestGoTypeToOpenAPISchema(t *testing.T) {
 	tests := []struct {
 		goType       string
@@ -359,11 +484,12 @@ func TestGoTypeToOpenAPISchema(t *testing.T) {
 		{"*string", "string", "", true, false},
 		{"*int64", "integer", "int64", true, false},
 		{"[]string", "string", "", false, true},
+		{"map[string]string", "object", "", false, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.goType, func(t *testing.T) {
-			schema := goTypeToOpenAPISchema(tt.goType)
+			schema := goTypeToOpenAPISchema(tt.goType, nil)
 
 			if tt.isArray {
 				if schema["type"] != "array" {
@@ -511,6 +637,207 @@ func TestGenerateOpenAPISpec_OmittedFields(t *testing.T) {
 	}
 }
 
+func TestGenerateOpenAPISpec_Deprecation(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:      "GET",
+				Path:        "/users",
+				FuncName:    "ListUsers",
+				PackagePath: "example.com/app/api/users",
+				Deprecated:  true,
+				Sunset:      "2027-01-31",
+				Response: &codegen.SerializedStructInfo{
+					Name:    "ListUsersResponse",
+					Package: "example.com/app/api/users",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "ID", Type: "int64", JSONName: "id", Required: true},
+						{Name: "Nick", Type: "string", JSONName: "nick", Required: true, Tags: map[string]string{"deprecated": "true"}},
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/users",
+				FuncName:    "CreateUser",
+				PackagePath: "example.com/app/api/users",
+				Feature:     "user-signup",
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+
+	pathItem := spec["paths"].(map[string]any)["/users"].(map[string]any)
+	get := pathItem["get"].(map[string]any)
+	if _, ok := get["x-feature"]; ok {
+		t.Error("ungated GET /users should not have x-feature")
+	}
+	if got := pathItem["post"].(map[string]any)["x-feature"]; got != "user-signup" {
+		t.Errorf("expected x-feature user-signup on POST /users, got %v", got)
+	}
+	if get["deprecated"] != true {
+		t.Errorf("expected deprecated: true on GET /users, got %v", get["deprecated"])
+	}
+	if get["x-sunset"] != "2027-01-31" {
+		t.Errorf("expected x-sunset 2027-01-31, got %v", get["x-sunset"])
+	}
+	post := pathItem["post"].(map[string]any)
+	if _, ok := post["deprecated"]; ok {
+		t.Error("POST /users should not be marked deprecated")
+	}
+
+	schema := get["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	props := schema["properties"].(map[string]any)
+	if props["nick"].(map[string]any)["deprecated"] != true {
+		t.Error("field tagged deprecated:\"true\" should be marked deprecated in the schema")
+	}
+	if _, ok := props["id"].(map[string]any)["deprecated"]; ok {
+		t.Error("untagged field should not be marked deprecated")
+	}
+}
+
+func TestGenerateOpenAPISpec_ColumnRoles(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:      "POST",
+				Path:        "/employees",
+				FuncName:    "CreateEmployee",
+				PackagePath: "example.com/app/api/employees",
+				RequireAuth: true,
+				Request: &codegen.SerializedStructInfo{
+					Name:    "CreateEmployeeRequest",
+					Package: "example.com/app/api/employees",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Name", Type: "string", JSONName: "name", Required: true},
+						{Name: "Salary", Type: "int64", JSONName: "salary", Required: true, Tags: map[string]string{"json": "salary", "write_roles": "admin,hr"}},
+					},
+				},
+				Response: &codegen.SerializedStructInfo{
+					Name:    "CreateEmployeeResponse",
+					Package: "example.com/app/api/employees",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Name", Type: "string", JSONName: "name", Required: true},
+						{Name: "Salary", Type: "*int64", JSONName: "salary", JSONOmit: true, Tags: map[string]string{"json": "salary,omitempty", "read_roles": "hr"}},
+					},
+				},
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+	post := spec["paths"].(map[string]any)["/employees"].(map[string]any)["post"].(map[string]any)
+
+	reqSchema := post["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	reqProps := reqSchema["properties"].(map[string]any)
+	writeRoles, ok := reqProps["salary"].(map[string]any)["x-write-roles"].([]any)
+	if !ok || len(writeRoles) != 2 || writeRoles[0] != "admin" || writeRoles[1] != "hr" {
+		t.Errorf("expected x-write-roles [admin hr] on request salary, got %v", reqProps["salary"])
+	}
+	if _, ok := reqProps["name"].(map[string]any)["x-write-roles"]; ok {
+		t.Error("unrestricted field should not carry x-write-roles")
+	}
+
+	respSchema := post["responses"].(map[string]any)["201"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	respProps := respSchema["properties"].(map[string]any)
+	readRoles, ok := respProps["salary"].(map[string]any)["x-read-roles"].([]any)
+	if !ok || len(readRoles) != 1 || readRoles[0] != "hr" {
+		t.Errorf("expected x-read-roles [hr] on response salary, got %v", respProps["salary"])
+	}
+}
+
+func TestGenerateOpenAPISpec_FieldDefaults(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:      "POST",
+				Path:        "/posts",
+				FuncName:    "CreatePost",
+				PackagePath: "example.com/app/api/posts",
+				Request: &codegen.SerializedStructInfo{
+					Name:    "CreatePostRequest",
+					Package: "example.com/app/api/posts",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Title", Type: "string", JSONName: "title", Required: true},
+						{Name: "Status", Type: "*string", JSONName: "status", JSONOmit: true, Tags: map[string]string{"json": "status,omitempty", "default": "draft"}},
+						{Name: "Priority", Type: "*int32", JSONName: "priority", JSONOmit: true, Tags: map[string]string{"json": "priority,omitempty", "default": "3"}},
+						{Name: "Pinned", Type: "*bool", JSONName: "pinned", JSONOmit: true, Tags: map[string]string{"json": "pinned,omitempty", "default": "true"}},
+						{Name: "Score", Type: "*float64", JSONName: "score", JSONOmit: true, Tags: map[string]string{"json": "score,omitempty", "default": "0.5"}},
+					},
+				},
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+	post := spec["paths"].(map[string]any)["/posts"].(map[string]any)["post"].(map[string]any)
+	reqSchema := post["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	props := reqSchema["properties"].(map[string]any)
+
+	want := map[string]any{"status": "draft", "priority": float64(3), "pinned": true, "score": 0.5}
+	for name, def := range want {
+		if got := props[name].(map[string]any)["default"]; got != def {
+			t.Errorf("%s default = %#v, want %#v", name, got, def)
+		}
+	}
+	if _, ok := props["title"].(map[string]any)["default"]; ok {
+		t.Error("field without a default tag should not carry a default")
+	}
+	if required := reqSchema["required"].([]any); len(required) != 1 || required[0] != "title" {
+		t.Errorf("defaulted fields should be optional, required = %v", required)
+	}
+}
+
+func TestGenerateOpenAPISpec_FieldValidation(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:      "POST",
+				Path:        "/products",
+				FuncName:    "CreateProduct",
+				PackagePath: "example.com/app/api/products",
+				Request: &codegen.SerializedStructInfo{
+					Name:    "CreateProductRequest",
+					Package: "example.com/app/api/products",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Name", Type: "string", JSONName: "name", Required: true, Tags: map[string]string{"json": "name", "validate": "required,max=200"}},
+						{Name: "ContactEmail", Type: "*string", JSONName: "contact_email", JSONOmit: true, Tags: map[string]string{"json": "contact_email,omitempty", "validate": "email"}},
+						{Name: "Price", Type: "string", JSONName: "price", Required: true, Tags: map[string]string{"json": "price", "validate": "required,decimal=10:2"}},
+						{Name: "Status", Type: "string", JSONName: "status", Required: true, Tags: map[string]string{"json": "status", "validate": "required,oneof=draft live"}},
+						{Name: "Channel", Type: "*string", JSONName: "channel", JSONOmit: true, Tags: map[string]string{"json": "channel,omitempty", "validate": "oneof=web store"}},
+					},
+				},
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+	post := spec["paths"].(map[string]any)["/products"].(map[string]any)["post"].(map[string]any)
+	props := post["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)["properties"].(map[string]any)
+
+	name := props["name"].(map[string]any)
+	if name["minLength"] != float64(1) || name["maxLength"] != float64(200) {
+		t.Errorf("name schema = %v, want minLength 1 and maxLength 200", name)
+	}
+	if got := props["contact_email"].(map[string]any)["format"]; got != "email" {
+		t.Errorf("contact_email format = %v, want email", got)
+	}
+	if _, ok := props["price"].(map[string]any)["maxLength"]; ok {
+		t.Error("decimal rules should not add a maxLength")
+	}
+	if got := fmt.Sprint(props["status"].(map[string]any)["enum"]); got != "[draft live]" {
+		t.Errorf("status enum = %s, want [draft live]", got)
+	}
+	if got := fmt.Sprint(props["channel"].(map[string]any)["enum"]); got != "[web store <nil>]" {
+		t.Errorf("channel enum = %s, want null among its values", got)
+	}
+}
+
 func TestGenerateOpenAPISpec_NestedStructSlice(t *testing.T) {
 	// Simulates ListFilesResponse.Items []FileListItem — the field should produce
 	// {type: "array", items: {type: "object", properties: {id: ..., name: ..., size: ...}}}
@@ -863,6 +1190,52 @@ func TestGenerateOpenAPISpec_QueryParams(t *testing.T) {
 	}
 }
 
+func TestGenerateOpenAPISpec_RawBodyTypes(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:       "POST",
+				Path:         "/posts/import",
+				FuncName:     "ImportPosts",
+				PackagePath:  "example.com/app/api/posts",
+				RawBodyTypes: []string{"text/csv", "application/x-ndjson"},
+				Request: &codegen.SerializedStructInfo{
+					Name:    "ImportPostsRequest",
+					Package: "example.com/app/api/posts",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "DryRun", Type: "bool", Tags: map[string]string{"query": "dry_run"}},
+					},
+				},
+				Response: &codegen.SerializedStructInfo{
+					Name:    "ImportPostsResponse",
+					Package: "example.com/app/api/posts",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "Total", Type: "int", JSONName: "total", Required: true},
+					},
+				},
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+	paths, _ := spec["paths"].(map[string]any)
+	importPath, _ := paths["/posts/import"].(map[string]any)
+	postOp, _ := importPath["post"].(map[string]any)
+	reqBody, _ := postOp["requestBody"].(map[string]any)
+	content, _ := reqBody["content"].(map[string]any)
+	if len(content) != 2 {
+		t.Fatalf("expected CSV and NDJSON request bodies only, got %v", content)
+	}
+	for _, mediaType := range []string{"text/csv", "application/x-ndjson"} {
+		media, _ := content[mediaType].(map[string]any)
+		schema, _ := media["schema"].(map[string]any)
+		if schema["type"] != "string" {
+			t.Errorf("%s: schema = %v, want a string", mediaType, media["schema"])
+		}
+	}
+}
+
 func TestGenerateOpenAPISpec_QueryParamsNotInRequestBody(t *testing.T) {
 	cfg := OpenAPIGenConfig{
 		ModulePath: "example.com/app",
@@ -986,6 +1359,50 @@ func TestGenerateOpenAPISpec_QueryParamsBoolType(t *testing.T) {
 	}
 }
 
+func TestGenerateOpenAPISpec_QueryParamsFormatTag(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method:      "GET",
+				Path:        "/posts",
+				FuncName:    "ListPosts",
+				PackagePath: "example.com/app/api/posts",
+				Request: &codegen.SerializedStructInfo{
+					Name:    "ListPostsRequest",
+					Package: "example.com/app/api/posts",
+					Fields: []codegen.SerializedFieldInfo{
+						{Name: "CreatedAfter", Type: "*string", JSONName: "CreatedAfter", Tags: map[string]string{"query": "created_after", "format": "date-time"}},
+						{Name: "Views", Type: "*string", JSONName: "Views", Tags: map[string]string{"query": "views", "format": "int64"}},
+						{Name: "Status", Type: "*string", JSONName: "Status", Tags: map[string]string{"query": "status"}},
+					},
+				},
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+	paths, _ := spec["paths"].(map[string]any)
+	getOp, _ := paths["/posts"].(map[string]any)["get"].(map[string]any)
+	params, _ := getOp["parameters"].([]any)
+	if len(params) != 3 {
+		t.Fatalf("expected 3 parameters, got %d", len(params))
+	}
+	want := map[string][2]any{
+		"created_after": {"string", "date-time"},
+		"views":         {"integer", "int64"},
+		"status":        {"string", nil},
+	}
+	for _, p := range params {
+		param, _ := p.(map[string]any)
+		schema, _ := param["schema"].(map[string]any)
+		w := want[param["name"].(string)]
+		if schema["type"] != w[0] || schema["format"] != w[1] || schema["nullable"] != true {
+			t.Errorf("parameter %v has schema %v, want type %v format %v", param["name"], schema, w[0], w[1])
+		}
+	}
+}
+
 func TestGenerateOpenAPISpec_NoQueryParamsNoParameters(t *testing.T) {
 	cfg := OpenAPIGenConfig{
 		ModulePath: "example.com/app",
@@ -1091,3 +1508,137 @@ func TestGenerateOpenAPISpec_MixedPathAndQueryParams(t *testing.T) {
 		t.Error("missing query parameter 'cursor' with in=query")
 	}
 }
+
+func TestGenerateOpenAPISpec_OperationIDNaming(t *testing.T) {
+	handlers := []codegen.SerializedHandlerInfo{
+		{Method: "GET", Path: "/posts/:id", FuncName: "GetPost", PackagePath: "example.com/app/api/posts"},
+		{Method: "POST", Path: "/posts", FuncName: "CreatePost", PackagePath: "example.com/app/api/posts"},
+	}
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		Handlers:   handlers,
+		Naming:     &config.NamingConfig{OperationIDTemplate: "{resource}_{func}", OperationIDCase: "snake"},
+	}
+
+	spec := parseSpec(t, cfg)
+	paths := spec["paths"].(map[string]any)
+	if got := paths["/posts/{id}"].(map[string]any)["get"].(map[string]any)["operationId"]; got != "posts_get_post" {
+		t.Errorf("GET /posts/{id} operationId = %v, want posts_get_post", got)
+	}
+	if got := paths["/posts"].(map[string]any)["post"].(map[string]any)["operationId"]; got != "posts_create_post" {
+		t.Errorf("POST /posts operationId = %v, want posts_create_post", got)
+	}
+
+	// A template that maps two handlers to the same id is rejected.
+	cfg.Naming = &config.NamingConfig{OperationIDTemplate: "{resource}"}
+	if _, err := GenerateOpenAPISpec(cfg); err == nil || !strings.Contains(err.Error(), `operationId "posts" is shared`) {
+		t.Errorf("expected a duplicate operationId error, got %v", err)
+	}
+}
+
+func TestGenerateOpenAPISpec_Version31Components(t *testing.T) {
+	author := &codegen.SerializedStructInfo{
+		Name:    "AuthorEmbed",
+		Package: "example.com/app/api/posts",
+		Fields: []codegen.SerializedFieldInfo{
+			{Name: "Id", Type: "string", JSONName: "id", Required: true},
+		},
+	}
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		OpenAPI:    &config.OpenAPIConfig{SpecVersion: "3.1"},
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method: "POST", Path: "/posts", FuncName: "CreatePost", PackagePath: "example.com/app/api/posts",
+				Request: &codegen.SerializedStructInfo{Name: "CreatePostRequest", Package: "example.com/app/api/posts", Fields: []codegen.SerializedFieldInfo{
+					{Name: "Title", Type: "string", JSONName: "title", Required: true},
+					{Name: "Body", Type: "*string", JSONName: "body"},
+				}},
+				Response: &codegen.SerializedStructInfo{Name: "CreatePostResponse", Package: "example.com/app/api/posts", Fields: []codegen.SerializedFieldInfo{
+					{Name: "Title", Type: "string", JSONName: "title", Required: true},
+					{Name: "Author", Type: "*posts.AuthorEmbed", JSONName: "author", StructFields: author},
+				}},
+			},
+			{
+				Method: "GET", Path: "/posts", FuncName: "ListPosts", PackagePath: "example.com/app/api/posts",
+				Response: &codegen.SerializedStructInfo{Name: "ListResponse", Package: "example.com/app/api/posts", Fields: []codegen.SerializedFieldInfo{
+					{Name: "Authors", Type: "[]posts.AuthorEmbed", JSONName: "authors", StructFields: author},
+				}},
+			},
+			{
+				Method: "GET", Path: "/tags", FuncName: "ListTags", PackagePath: "example.com/app/api/tags",
+				Response: &codegen.SerializedStructInfo{Name: "ListResponse", Package: "example.com/app/api/tags", Fields: []codegen.SerializedFieldInfo{
+					{Name: "Names", Type: "[]string", JSONName: "names"},
+				}},
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+	if spec["openapi"] != "3.1.0" {
+		t.Errorf("expected openapi 3.1.0, got %v", spec["openapi"])
+	}
+
+	schemas, ok := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	if !ok {
+		t.Fatal("missing components.schemas")
+	}
+	for _, name := range []string{"CreatePostRequest", "CreatePostResponse", "AuthorEmbed", "posts.ListResponse", "tags.ListResponse"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("components.schemas missing %q", name)
+		}
+	}
+
+	post := spec["paths"].(map[string]any)["/posts"].(map[string]any)["post"].(map[string]any)
+	reqSchema := post["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	if reqSchema["$ref"] != "#/components/schemas/CreatePostRequest" {
+		t.Errorf("request body schema = %v, want a $ref to CreatePostRequest", reqSchema)
+	}
+	respSchema := post["responses"].(map[string]any)["201"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	if respSchema["$ref"] != "#/components/schemas/CreatePostResponse" {
+		t.Errorf("response schema = %v, want a $ref to CreatePostResponse", respSchema)
+	}
+
+	// Nullable types use 3.1 type arrays; a nullable $ref becomes an anyOf.
+	body := schemas["CreatePostRequest"].(map[string]any)["properties"].(map[string]any)["body"].(map[string]any)
+	if got, _ := json.Marshal(body["type"]); string(got) != `["string","null"]` || body["nullable"] != nil {
+		t.Errorf("body schema = %v, want type [string null] without nullable", body)
+	}
+	authorProp, _ := json.Marshal(schemas["CreatePostResponse"].(map[string]any)["properties"].(map[string]any)["author"])
+	if want := `{"anyOf":[{"$ref":"#/components/schemas/AuthorEmbed"},{"type":"null"}]}`; string(authorProp) != want {
+		t.Errorf("author schema = %s, want %s", authorProp, want)
+	}
+	authors, _ := json.Marshal(schemas["posts.ListResponse"].(map[string]any)["properties"].(map[string]any)["authors"])
+	if want := `{"items":{"$ref":"#/components/schemas/AuthorEmbed"},"type":"array"}`; string(authors) != want {
+		t.Errorf("authors schema = %s, want %s", authors, want)
+	}
+}
+
+func TestGenerateOpenAPISpec_Version30InlinesSchemas(t *testing.T) {
+	cfg := OpenAPIGenConfig{
+		ModulePath: "example.com/app",
+		OpenAPI:    &config.OpenAPIConfig{SpecVersion: "3.0"},
+		Handlers: []codegen.SerializedHandlerInfo{
+			{
+				Method: "GET", Path: "/posts", FuncName: "ListPosts", PackagePath: "example.com/app/api/posts",
+				Response: &codegen.SerializedStructInfo{Name: "ListPostsResponse", Package: "example.com/app/api/posts", Fields: []codegen.SerializedFieldInfo{
+					{Name: "Cursor", Type: "*string", JSONName: "cursor"},
+				}},
+			},
+		},
+	}
+
+	spec := parseSpec(t, cfg)
+	if spec["openapi"] != "3.0.3" {
+		t.Errorf("expected openapi 3.0.3, got %v", spec["openapi"])
+	}
+	if _, ok := spec["components"].(map[string]any)["schemas"]; ok {
+		t.Error("a 3.0 document should inline its schemas")
+	}
+	get := spec["paths"].(map[string]any)["/posts"].(map[string]any)["get"].(map[string]any)
+	schema := get["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+	cursor := schema["properties"].(map[string]any)["cursor"].(map[string]any)
+	if cursor["type"] != "string" || cursor["nullable"] != true {
+		t.Errorf("cursor schema = %v, want a nullable string", cursor)
+	}
+}
@@ -3,9 +3,9 @@ package openapigen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // OpenAPITestGenConfig holds configuration for generating the OpenAPI endpoint test.
@@ -33,7 +33,7 @@ func GenerateOpenAPITest(cfg OpenAPITestGenConfig) ([]byte, error) {
 	generateTestDocsEndpoint(&buf, cfg.StripPrefix)
 	generateTestAssetsEndpoint(&buf, cfg.StripPrefix)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format OpenAPI test code: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -126,10 +126,10 @@ func generateTestOpenAPISpecEndpoint(buf *bytes.Buffer, prefix string) {
 		t.Fatalf("response is not valid JSON: %v", err)
 	}
 
-	// Should be OpenAPI 3.1
+	// Should be OpenAPI 3.0 or 3.1 ([openapi] spec_version)
 	version, ok := spec["openapi"].(string)
-	if !ok || !strings.HasPrefix(version, "3.1") {
-		t.Errorf("expected openapi version starting with 3.1, got %v", spec["openapi"])
+	if !ok || !strings.HasPrefix(version, "3.") {
+		t.Errorf("expected openapi version starting with 3., got %v", spec["openapi"])
 	}
 
 	// Should have paths
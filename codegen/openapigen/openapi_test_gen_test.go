@@ -91,7 +91,7 @@ func TestGenerateOpenAPITest_ContainsAssertions(t *testing.T) {
 	codeStr := string(code)
 
 	// Should check for OpenAPI version
-	if !strings.Contains(codeStr, `"3.1"`) {
+	if !strings.Contains(codeStr, `strings.HasPrefix(version, "3.")`) {
 		t.Error("missing OpenAPI version check")
 	}
 
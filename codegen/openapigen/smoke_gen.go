@@ -0,0 +1,517 @@
+package openapigen
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
+)
+
+// SmokeGenConfig holds configuration for generating the smoke check binary.
+type SmokeGenConfig struct {
+	Handlers []codegen.SerializedHandlerInfo // handlers from the routes manifest
+	SpecJSON string                          // OpenAPI document the responses are validated against
+}
+
+// GenerateSmokeMain generates cmd/smoke/main.go: a dependency-free program
+// that calls every GET endpoint of a running server, expects 200, and
+// validates each JSON body against the response schema in the embedded
+// OpenAPI document. Path parameters are filled from the first item returned
+// by the parent collection endpoint (so the database must be seeded) or from
+// -param flags. It exits 1 if any endpoint fails.
+func GenerateSmokeMain(cfg SmokeGenConfig) ([]byte, error) {
+	if strings.Contains(cfg.SpecJSON, "`") {
+		return nil, fmt.Errorf("OpenAPI spec contains a backtick and cannot be embedded")
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("// Code generated by shipq. DO NOT EDIT.\n\n")
+	buf.WriteString("// Command smoke is a post-deploy smoke check. It calls every GET endpoint of\n")
+	buf.WriteString("// a running server and verifies that each returns 200 with a body matching\n")
+	buf.WriteString("// the OpenAPI document. Regenerate it with `shipq smoke`.\n")
+	buf.WriteString("//\n")
+	buf.WriteString("// Usage:\n")
+	buf.WriteString("//\n")
+	buf.WriteString("//\tgo run ./cmd/smoke -base-url https://api.example.com -cookie \"session=...\"\n")
+	buf.WriteString("package main\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"encoding/json\"\n")
+	buf.WriteString("\t\"flag\"\n")
+	buf.WriteString("\t\"fmt\"\n")
+	buf.WriteString("\t\"io\"\n")
+	buf.WriteString("\t\"net/http\"\n")
+	buf.WriteString("\t\"os\"\n")
+	buf.WriteString("\t\"sort\"\n")
+	buf.WriteString("\t\"strings\"\n")
+	buf.WriteString("\t\"time\"\n")
+	buf.WriteString(")\n\n")
+
+	generateSmokeRoutes(&buf, cfg.Handlers)
+
+	buf.WriteString("// openAPISpec is the OpenAPI document recorded by the last handler compile.\n")
+	buf.WriteString("const openAPISpec = ")
+	fmt.Fprintf(&buf, "`%s`\n\n", cfg.SpecJSON)
+
+	buf.WriteString(smokeRuntime)
+
+	formatted, err := phase.FormatSource(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("failed to format smoke program: %w\nunformatted:\n%s", err, buf.String())
+	}
+
+	return formatted, nil
+}
+
+// generateSmokeRoutes writes the smokeRoutes table: every GET handler,
+// sorted by path so parent collections are checked before their items.
+func generateSmokeRoutes(buf *bytes.Buffer, handlers []codegen.SerializedHandlerInfo) {
+	var gets []codegen.SerializedHandlerInfo
+	for _, h := range handlers {
+		if h.Method == "GET" {
+			gets = append(gets, h)
+		}
+	}
+	sort.SliceStable(gets, func(i, j int) bool { return gets[i].Path < gets[j].Path })
+
+	buf.WriteString("// smokeRoute is a GET endpoint to check.\n")
+	buf.WriteString("type smokeRoute struct {\n")
+	buf.WriteString("\tPath        string // route path with :param placeholders\n")
+	buf.WriteString("\tSpecPath    string // OpenAPI path with {param} placeholders\n")
+	buf.WriteString("\tRequireAuth bool\n")
+	buf.WriteString("\tSkipReason  string // non-empty when the route cannot be called blind\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("var smokeRoutes = []smokeRoute{\n")
+	for _, h := range gets {
+		skip := ""
+		var required []string
+		for _, f := range codegen.FilterQueryFields(h) {
+			if f.Required {
+				required = append(required, f.Tags["query"])
+			}
+		}
+		if len(required) > 0 {
+			skip = "requires query parameters: " + strings.Join(required, ", ")
+		}
+		fmt.Fprintf(buf, "\t{Path: %q, SpecPath: %q, RequireAuth: %t, SkipReason: %q},\n",
+			h.Path, codegen.ConvertPathSyntax(h.Path), h.RequireAuth, skip)
+	}
+	buf.WriteString("}\n\n")
+}
+
+// smokeRuntime is the static part of the smoke program: flag handling, path
+// parameter resolution and a minimal JSON Schema validator covering the
+// subset of OpenAPI that shipq emits (type, nullable, properties, required,
+// items, $ref).
+const smokeRuntime = `// headerFlags collects a repeatable string flag.
+type headerFlags []string
+
+func (f *headerFlags) String() string { return strings.Join(*f, ", ") }
+
+func (f *headerFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func main() {
+	baseURL := flag.String("base-url", envOr("SMOKE_BASE_URL", "http://localhost:8080"), "server base URL, including any strip prefix")
+	cookie := flag.String("cookie", os.Getenv("SMOKE_COOKIE"), "Cookie header sent with every request; routes requiring auth are skipped without it")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-request timeout")
+	var headers, params headerFlags
+	flag.Var(&headers, "header", "extra request header as 'Name: value' (repeatable)")
+	flag.Var(&params, "param", "path parameter as name=value instead of looking it up (repeatable)")
+	flag.Parse()
+
+	s := &smoker{
+		baseURL:  strings.TrimRight(*baseURL, "/"),
+		client:   &http.Client{Timeout: *timeout},
+		cookie:   *cookie,
+		headers:  http.Header{},
+		params:   map[string]string{},
+		fixtures: map[string]string{},
+	}
+	if err := json.Unmarshal([]byte(openAPISpec), &s.spec); err != nil {
+		fmt.Fprintf(os.Stderr, "smoke: invalid embedded OpenAPI document: %v\n", err)
+		os.Exit(2)
+	}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			fmt.Fprintf(os.Stderr, "smoke: invalid -header %q, want 'Name: value'\n", h)
+			os.Exit(2)
+		}
+		s.headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for _, p := range params {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok || name == "" {
+			fmt.Fprintf(os.Stderr, "smoke: invalid -param %q, want name=value\n", p)
+			os.Exit(2)
+		}
+		s.params[name] = value
+	}
+
+	s.run()
+
+	fmt.Printf("\n%d passed, %d failed, %d skipped\n", s.passed, s.failed, s.skipped)
+	if s.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+type smoker struct {
+	baseURL  string
+	client   *http.Client
+	cookie   string
+	headers  http.Header
+	params   map[string]string
+	fixtures map[string]string // "<collection path> <param>" -> looked-up value
+	spec     map[string]any
+
+	passed, failed, skipped int
+}
+
+func (s *smoker) run() {
+	for _, r := range smokeRoutes {
+		if r.SkipReason != "" {
+			s.report("SKIP", r.Path, r.SkipReason)
+			continue
+		}
+		if r.RequireAuth && s.cookie == "" && s.headers.Get("Authorization") == "" {
+			s.report("SKIP", r.Path, "requires auth; pass -cookie or -header")
+			continue
+		}
+		path, err := s.resolvePath(r.Path)
+		if err != nil {
+			s.report("SKIP", r.Path, err.Error())
+			continue
+		}
+		status, body, err := s.get(path)
+		if err != nil {
+			s.report("FAIL", path, err.Error())
+			continue
+		}
+		if status != http.StatusOK {
+			s.report("FAIL", path, fmt.Sprintf("status %d, want 200", status))
+			continue
+		}
+		if errs := s.validateResponse(r.SpecPath, body); len(errs) > 0 {
+			if len(errs) > 5 {
+				errs = append(errs[:5], fmt.Sprintf("... and %d more", len(errs)-5))
+			}
+			s.report("FAIL", path, "response does not match the OpenAPI schema:\n    "+strings.Join(errs, "\n    "))
+			continue
+		}
+		s.report("PASS", path, "")
+	}
+}
+
+func (s *smoker) report(result, path, detail string) {
+	switch result {
+	case "PASS":
+		s.passed++
+	case "FAIL":
+		s.failed++
+	default:
+		s.skipped++
+	}
+	if detail == "" {
+		fmt.Printf("%s GET %s\n", result, path)
+		return
+	}
+	fmt.Printf("%s GET %s: %s\n", result, path, detail)
+}
+
+func (s *smoker) get(path string) (int, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	for name, values := range s.headers {
+		req.Header[name] = values
+	}
+	if s.cookie != "" {
+		req.Header.Set("Cookie", s.cookie)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+// resolvePath substitutes each :param segment with a -param value or, failing
+// that, with an identifier taken from the first item listed by the path up to
+// that segment (e.g. GET /posts for /posts/:id).
+func (s *smoker) resolvePath(routePath string) (string, error) {
+	segments := strings.Split(routePath, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		name := seg[1:]
+		if v, ok := s.params[name]; ok {
+			segments[i] = v
+			continue
+		}
+		collection := strings.Join(segments[:i], "/")
+		key := collection + " " + name
+		v, ok := s.fixtures[key]
+		if !ok {
+			var err error
+			v, err = s.lookupFixture(collection, name)
+			if err != nil {
+				return "", err
+			}
+			s.fixtures[key] = v
+		}
+		segments[i] = v
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+func (s *smoker) lookupFixture(collection, param string) (string, error) {
+	hint := fmt.Sprintf("; seed the database or pass -param %s=...", param)
+	if collection == "" {
+		return "", fmt.Errorf("no collection to look up :%s%s", param, hint)
+	}
+	status, body, err := s.get(collection)
+	if err != nil || status != http.StatusOK {
+		return "", fmt.Errorf("cannot look up :%s from GET %s%s", param, collection, hint)
+	}
+	value, ok := firstItemID(body, param)
+	if !ok {
+		return "", fmt.Errorf("GET %s returned no items with an ID for :%s%s", collection, param, hint)
+	}
+	return value, nil
+}
+
+// firstItemID finds the first array of objects in a list response (either
+// the body itself or its first array-valued field) and returns the param,
+// id or public_id field of its first item.
+func firstItemID(body []byte, param string) (string, bool) {
+	v, err := decodeJSON(body)
+	if err != nil {
+		return "", false
+	}
+	var items []any
+	switch val := v.(type) {
+	case []any:
+		items = val
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if arr, ok := val[k].([]any); ok && len(arr) > 0 {
+				items = arr
+				break
+			}
+		}
+	}
+	if len(items) == 0 {
+		return "", false
+	}
+	item, ok := items[0].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	for _, key := range []string{param, "id", "public_id"} {
+		switch id := item[key].(type) {
+		case string:
+			if id != "" {
+				return id, true
+			}
+		case json.Number:
+			return id.String(), true
+		}
+	}
+	return "", false
+}
+
+func decodeJSON(body []byte) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// validateResponse checks body against the 200 response schema of the GET
+// operation at specPath. Operations without a JSON schema only need a 200.
+func (s *smoker) validateResponse(specPath string, body []byte) []string {
+	schema, ok := lookup(s.spec, "paths", specPath, "get", "responses", "200", "content", "application/json", "schema").(map[string]any)
+	if !ok {
+		return nil
+	}
+	v, err := decodeJSON(body)
+	if err != nil {
+		return []string{"body is not valid JSON: " + err.Error()}
+	}
+	var errs []string
+	s.validate(schema, v, "$", &errs)
+	return errs
+}
+
+func lookup(v any, keys ...string) any {
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+func (s *smoker) validate(schema map[string]any, v any, at string, errs *[]string) {
+	for depth := 0; ; depth++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			break
+		}
+		target, ok := lookup(s.spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...).(map[string]any)
+		if !ok || !strings.HasPrefix(ref, "#/") || depth > 32 {
+			*errs = append(*errs, fmt.Sprintf("%s: cannot resolve %s", at, ref))
+			return
+		}
+		schema = target
+	}
+
+	types := schemaTypes(schema)
+	if v == nil {
+		if len(types) > 0 && schema["nullable"] != true && !contains(types, "null") {
+			*errs = append(*errs, fmt.Sprintf("%s: null is not allowed", at))
+		}
+		return
+	}
+	if len(types) > 0 && !typeMatches(types, v) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", at, strings.Join(types, " or "), jsonKind(v)))
+		return
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := val[name]; !present {
+					*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", at, name))
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := props[name].(map[string]any)
+			if value, present := val[name]; ok && present {
+				s.validate(sub, value, at+"."+name, errs)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				s.validate(items, item, fmt.Sprintf("%s[%d]", at, i), errs)
+			}
+		}
+	}
+}
+
+// schemaTypes returns the schema's "type" as a list, accepting both the
+// single-string form and the OpenAPI 3.1 array form.
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func typeMatches(types []string, v any) bool {
+	for _, t := range types {
+		switch val := v.(type) {
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case json.Number:
+			if t == "number" || (t == "integer" && !strings.ContainsAny(val.String(), ".eE")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	}
+	return "null"
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+`
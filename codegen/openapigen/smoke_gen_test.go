@@ -0,0 +1,74 @@
+package openapigen
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+)
+
+func smokeTestHandlers() []codegen.SerializedHandlerInfo {
+	return []codegen.SerializedHandlerInfo{
+		{Method: "POST", Path: "/posts", FuncName: "CreatePost", PackagePath: "myapp/api/posts"},
+		{Method: "GET", Path: "/posts/:id", FuncName: "GetPost", PackagePath: "myapp/api/posts",
+			PathParams: []codegen.SerializedPathParam{{Name: "id", Position: 1}}},
+		{Method: "GET", Path: "/posts", FuncName: "ListPosts", PackagePath: "myapp/api/posts", RequireAuth: true},
+		{Method: "GET", Path: "/search", FuncName: "Search", PackagePath: "myapp/api/search",
+			Request: &codegen.SerializedStructInfo{Name: "SearchRequest", Fields: []codegen.SerializedFieldInfo{
+				{Name: "Q", Type: "string", JSONName: "q", Required: true, Tags: map[string]string{"query": "q"}},
+			}}},
+	}
+}
+
+func TestGenerateSmokeMain_ValidGo(t *testing.T) {
+	handlers := smokeTestHandlers()
+	spec, err := GenerateOpenAPISpec(OpenAPIGenConfig{ModulePath: "myapp", Handlers: handlers})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPISpec() error = %v", err)
+	}
+
+	code, err := GenerateSmokeMain(SmokeGenConfig{Handlers: handlers, SpecJSON: string(spec)})
+	if err != nil {
+		t.Fatalf("GenerateSmokeMain() error = %v", err)
+	}
+
+	f := gofile.Parse(t, "main.go", code)
+	if f.AST.Name.Name != "main" {
+		t.Errorf("package = %s, want main", f.AST.Name.Name)
+	}
+	// The spec is embedded verbatim so the binary needs nothing at runtime.
+	if got, err := strconv.Unquote(f.Value("openAPISpec")); err != nil || got != string(spec) {
+		t.Errorf("openAPISpec = %s, want the generated spec (%v)", f.Value("openAPISpec"), err)
+	}
+	f.AssertStmts("main",
+		`baseURL := flag.String("base-url", envOr("SMOKE_BASE_URL", "http://localhost:8080"), "server base URL, including any strip prefix")`,
+		"if s.failed > 0",
+		"os.Exit(1)",
+	)
+}
+
+func TestGenerateSmokeMain_RoutesTable(t *testing.T) {
+	code, err := GenerateSmokeMain(SmokeGenConfig{Handlers: smokeTestHandlers(), SpecJSON: "{}"})
+	if err != nil {
+		t.Fatalf("GenerateSmokeMain() error = %v", err)
+	}
+	f := gofile.Parse(t, "main.go", code)
+
+	// Only GET handlers are checked, collection routes before their item
+	// routes, and routes with required query parameters carry a skip reason.
+	want := `[]smokeRoute{ {Path: "/posts", SpecPath: "/posts", RequireAuth: true, SkipReason: ""}, ` +
+		`{Path: "/posts/:id", SpecPath: "/posts/{id}", RequireAuth: false, SkipReason: ""}, ` +
+		`{Path: "/search", SpecPath: "/search", RequireAuth: false, SkipReason: "requires query parameters: q"}, }`
+	if got := strings.Join(strings.Fields(f.Value("smokeRoutes")), " "); got != want {
+		t.Errorf("smokeRoutes = %s\nwant %s", got, want)
+	}
+}
+
+func TestGenerateSmokeMain_RejectsBacktickInSpec(t *testing.T) {
+	_, err := GenerateSmokeMain(SmokeGenConfig{SpecJSON: "{\"description\": \"`x`\"}"})
+	if err == nil {
+		t.Fatal("expected error for a spec that cannot be embedded in a raw string")
+	}
+}
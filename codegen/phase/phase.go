@@ -0,0 +1,129 @@
+// Package phase times the phases of code generation (discovery, SQL
+// generation, formatting, writing, ...) so that a slow compile can be
+// attributed to one of them. Timing is off until Enable is called, and
+// Start is then cheap enough to wrap every generated file.
+//
+// Phases nest: formatting and writing happen inside the phase that
+// generates the file, so their time is also counted there.
+package phase
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The phases timed by shipq's own commands. Start accepts any name.
+const (
+	Discovery  = "discovery"       // finding packages and running the compile programs
+	SQLGen     = "sql generation"  // compiling queries into the dialect's runner
+	Codegen    = "code generation" // generating handlers, server, clients, tests
+	Formatting = "formatting"      // gofmt of generated Go source
+	Writing    = "writing"         // writing generated files to disk
+)
+
+// order lists the built-in phases in the order they are reported.
+var order = []string{Discovery, SQLGen, Codegen, Formatting, Writing}
+
+var (
+	enabled atomic.Bool
+
+	mu     sync.Mutex
+	totals = map[string]*Timing{}
+)
+
+// Timing is the accumulated time of one phase.
+type Timing struct {
+	Phase    string
+	Duration time.Duration
+	Count    int // how many times the phase was entered
+}
+
+// Enable turns timing on and clears what was recorded so far.
+func Enable() {
+	mu.Lock()
+	totals = map[string]*Timing{}
+	mu.Unlock()
+	enabled.Store(true)
+}
+
+// Enabled reports whether timing is on.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Start begins timing an occurrence of phase and returns the function that
+// ends it, meant to be deferred:
+//
+//	defer phase.Start(phase.Writing)()
+//
+// When timing is off it does nothing.
+func Start(name string) (stop func()) {
+	if !enabled.Load() {
+		return func() {}
+	}
+	begin := time.Now()
+	return func() {
+		elapsed := time.Since(begin)
+		mu.Lock()
+		defer mu.Unlock()
+		t := totals[name]
+		if t == nil {
+			t = &Timing{Phase: name}
+			totals[name] = t
+		}
+		t.Duration += elapsed
+		t.Count++
+	}
+}
+
+// FormatSource is go/format.Source, timed as the Formatting phase.
+// Generators use it for all generated Go source.
+func FormatSource(src []byte) ([]byte, error) {
+	defer Start(Formatting)()
+	return format.Source(src)
+}
+
+// Summary returns the recorded timings: the built-in phases in pipeline
+// order, then any others by name. Phases never entered are left out.
+func Summary() []Timing {
+	mu.Lock()
+	defer mu.Unlock()
+
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i
+	}
+	out := make([]Timing, 0, len(totals))
+	for _, t := range totals {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ri, iBuiltin := rank[out[i].Phase]
+		rj, jBuiltin := rank[out[j].Phase]
+		switch {
+		case iBuiltin && jBuiltin:
+			return ri < rj
+		case iBuiltin != jBuiltin:
+			return iBuiltin
+		default:
+			return out[i].Phase < out[j].Phase
+		}
+	})
+	return out
+}
+
+// WriteSummary writes the recorded timings as a table, followed by total,
+// the wall time of the whole command.
+func WriteSummary(w io.Writer, total time.Duration) {
+	fmt.Fprintln(w, "Phase timings:")
+	for _, t := range Summary() {
+		fmt.Fprintf(w, "  %-16s %10s  (%d×)\n", t.Phase, t.Duration.Round(time.Millisecond), t.Count)
+	}
+	fmt.Fprintf(w, "  %-16s %10s\n", "total", total.Round(time.Millisecond))
+	fmt.Fprintln(w, "  (formatting and writing are also counted in the phase that generated the file)")
+}
@@ -0,0 +1,73 @@
+package phase
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStart_Disabled(t *testing.T) {
+	enabled.Store(false)
+	t.Cleanup(func() { enabled.Store(false) })
+	totals = map[string]*Timing{}
+
+	Start(Writing)()
+	if got := Summary(); len(got) != 0 {
+		t.Errorf("Summary() = %v, want nothing recorded while disabled", got)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	Enable()
+	t.Cleanup(func() { enabled.Store(false) })
+
+	stop := Start(Writing)
+	time.Sleep(time.Millisecond)
+	stop()
+	Start(Writing)()
+	Start("custom")()
+	Start(Discovery)()
+	if _, err := FormatSource([]byte("package p\nvar x=1\n")); err != nil {
+		t.Fatalf("FormatSource() error = %v", err)
+	}
+
+	got := Summary()
+	var phases []string
+	for _, timing := range got {
+		phases = append(phases, timing.Phase)
+	}
+	if want := "discovery,formatting,writing,custom"; strings.Join(phases, ",") != want {
+		t.Errorf("Summary() phases = %v, want %s", phases, want)
+	}
+	if w := got[2]; w.Count != 2 || w.Duration < time.Millisecond {
+		t.Errorf("writing = %+v, want 2 entries of at least 1ms", w)
+	}
+
+	var buf bytes.Buffer
+	WriteSummary(&buf, 5*time.Second)
+	// One line per phase in Summary order between the header and the total.
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(got)+3 {
+		t.Fatalf("WriteSummary() wrote %d lines, want %d:\n%s", len(lines), len(got)+3, buf.String())
+	}
+	if lines[0] != "Phase timings:" {
+		t.Errorf("WriteSummary() header = %q", lines[0])
+	}
+	for i, timing := range got {
+		want := []string{timing.Phase, timing.Duration.Round(time.Millisecond).String(), fmt.Sprintf("(%d×)", timing.Count)}
+		if fields := strings.Fields(lines[i+1]); !slices.Equal(fields, want) {
+			t.Errorf("WriteSummary() line %d = %q, want fields %q", i+1, lines[i+1], want)
+		}
+	}
+	if fields := strings.Fields(lines[len(got)+1]); !slices.Equal(fields, []string{"total", "5s"}) {
+		t.Errorf("WriteSummary() total line = %q", lines[len(got)+1])
+	}
+
+	Enable()
+	if got := Summary(); len(got) != 0 {
+		t.Errorf("Enable() should clear previous timings, got %v", got)
+	}
+}
@@ -676,26 +676,26 @@ func TestGeneratedCodeCompiles(t *testing.T) {
 	// Check that handlers call the right query methods
 	handlerExpectations := map[string][]string{
 		"create": {
-			"queries.RunnerFromContext(ctx)",
+			"queries.MustRunnerFromContext(ctx)",
 			"runner.CreateAccount(ctx, queries.CreateAccountParams{",
 		},
 		"get_one": {
-			"queries.RunnerFromContext(ctx)",
+			"queries.MustRunnerFromContext(ctx)",
 			"runner.GetAccountByPublicID(ctx, queries.GetAccountByPublicIDParams{",
 		},
 		"list": {
-			"queries.RunnerFromContext(ctx)",
+			"queries.MustRunnerFromContext(ctx)",
 			"queries.ListAccountsCursor",
 			"queries.DecodeListAccountsCursor",
 			"runner.ListAccounts(ctx, queries.ListAccountsParams{",
 			"queries.EncodeListAccountsCursor",
 		},
 		"update": {
-			"queries.RunnerFromContext(ctx)",
+			"queries.MustRunnerFromContext(ctx)",
 			"runner.UpdateAccountByPublicID(ctx, queries.UpdateAccountByPublicIDParams{",
 		},
 		"soft_delete": {
-			"queries.RunnerFromContext(ctx)",
+			"queries.MustRunnerFromContext(ctx)",
 			"runner.SoftDeleteAccountByPublicID(ctx, queries.SoftDeleteAccountByPublicIDParams{",
 		},
 	}
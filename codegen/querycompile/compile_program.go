@@ -3,8 +3,9 @@ package querycompile
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"sort"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // CompileProgramConfig holds configuration for generating the compile program.
@@ -75,7 +76,7 @@ func main() {
 }
 `)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format compile program: %w", err)
@@ -0,0 +1,91 @@
+package querycompile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// QueryCacheFile is the file in CompileProgramDir holding every query of the
+// last compile. `shipq db compile --only` recompiles one package and takes
+// the queries of all other packages from it.
+const QueryCacheFile = "queries.json"
+
+// WriteQueryCache records the compiled queries in .shipq/compile/queries.json.
+func WriteQueryCache(projectRoot string, queries []query.SerializedQuery) error {
+	compileDir := filepath.Join(projectRoot, CompileProgramDir)
+	if err := codegen.EnsureDir(compileDir); err != nil {
+		return fmt.Errorf("failed to create compile directory: %w", err)
+	}
+	if queries == nil {
+		queries = []query.SerializedQuery{}
+	}
+	data, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode query cache: %w", err)
+	}
+	if _, err := codegen.WriteFileIfChanged(filepath.Join(compileDir, QueryCacheFile), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write query cache: %w", err)
+	}
+	return nil
+}
+
+// ReadQueryCache returns the queries recorded by the last compile. It
+// returns ok=false when there is no cache, or when the cache predates
+// package tracking and so cannot be merged with a partial compile.
+func ReadQueryCache(projectRoot string) (queries []query.SerializedQuery, ok bool, err error) {
+	data, err := os.ReadFile(filepath.Join(projectRoot, CompileProgramDir, QueryCacheFile))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read query cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, false, fmt.Errorf("failed to parse query cache: %w", err)
+	}
+	for _, q := range queries {
+		if q.Package == "" {
+			return nil, false, nil
+		}
+	}
+	return queries, true, nil
+}
+
+// FindQueryPackage returns the package that defined the named query.
+func FindQueryPackage(queries []query.SerializedQuery, name string) (string, bool) {
+	for _, q := range queries {
+		if q.Name == name {
+			return q.Package, true
+		}
+	}
+	return "", false
+}
+
+// MergeQueries replaces the queries cached for pkg with the freshly compiled
+// ones and returns the result sorted by name, as a full compile would. It
+// fails if a fresh query has the name of a query from another package.
+func MergeQueries(cached, fresh []query.SerializedQuery, pkg string) ([]query.SerializedQuery, error) {
+	merged := make([]query.SerializedQuery, 0, len(cached)+len(fresh))
+	owners := make(map[string]string)
+	for _, q := range cached {
+		if q.Package == pkg {
+			continue
+		}
+		merged = append(merged, q)
+		owners[q.Name] = q.Package
+	}
+	for _, q := range fresh {
+		if other, ok := owners[q.Name]; ok {
+			return nil, fmt.Errorf("duplicate query name: %s (defined in %s and %s)", q.Name, other, q.Package)
+		}
+		merged = append(merged, q)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	return merged, nil
+}
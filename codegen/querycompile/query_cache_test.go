@@ -0,0 +1,92 @@
+package querycompile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+func TestQueryCache_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+
+	if _, ok, err := ReadQueryCache(root); err != nil || ok {
+		t.Fatalf("ReadQueryCache without a cache = ok %v, err %v; want false, nil", ok, err)
+	}
+
+	queries := []query.SerializedQuery{
+		{Name: "GetPost", ReturnType: query.ReturnOne, Package: "myapp/querydefs/posts"},
+		{Name: "ListUsers", ReturnType: query.ReturnMany, Package: "myapp/querydefs/users"},
+	}
+	if err := WriteQueryCache(root, queries); err != nil {
+		t.Fatalf("WriteQueryCache failed: %v", err)
+	}
+	got, ok, err := ReadQueryCache(root)
+	if err != nil || !ok {
+		t.Fatalf("ReadQueryCache = ok %v, err %v", ok, err)
+	}
+	if len(got) != 2 || got[1].Package != "myapp/querydefs/users" {
+		t.Errorf("ReadQueryCache = %+v", got)
+	}
+}
+
+func TestReadQueryCache_WithoutPackages(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, CompileProgramDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, QueryCacheFile), []byte(`[{"name":"GetPost","return_type":"one"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := ReadQueryCache(root); err != nil || ok {
+		t.Errorf("a cache without packages cannot be merged; got ok %v, err %v", ok, err)
+	}
+}
+
+func TestFindQueryPackage(t *testing.T) {
+	queries := []query.SerializedQuery{{Name: "GetPost", Package: "myapp/querydefs/posts"}}
+	if pkg, ok := FindQueryPackage(queries, "GetPost"); !ok || pkg != "myapp/querydefs/posts" {
+		t.Errorf("FindQueryPackage = %q, %v", pkg, ok)
+	}
+	if _, ok := FindQueryPackage(queries, "Missing"); ok {
+		t.Error("FindQueryPackage should not find an unknown query")
+	}
+}
+
+func TestMergeQueries(t *testing.T) {
+	cached := []query.SerializedQuery{
+		{Name: "GetPost", ReturnType: query.ReturnOne, Package: "myapp/querydefs/posts"},
+		{Name: "ListPosts", ReturnType: query.ReturnMany, Package: "myapp/querydefs/posts"},
+		{Name: "ListUsers", ReturnType: query.ReturnMany, Package: "myapp/querydefs/users"},
+	}
+	fresh := []query.SerializedQuery{
+		{Name: "GetPost", ReturnType: query.ReturnOne, Package: "myapp/querydefs/posts"},
+		{Name: "CountPosts", ReturnType: query.ReturnOne, Package: "myapp/querydefs/posts"},
+	}
+
+	merged, err := MergeQueries(cached, fresh, "myapp/querydefs/posts")
+	if err != nil {
+		t.Fatalf("MergeQueries failed: %v", err)
+	}
+	var names []string
+	for _, q := range merged {
+		names = append(names, q.Name)
+	}
+	// ListPosts was removed from the package; the result is sorted by name.
+	if got := strings.Join(names, ","); got != "CountPosts,GetPost,ListUsers" {
+		t.Errorf("merged names = %s, want CountPosts,GetPost,ListUsers", got)
+	}
+}
+
+func TestMergeQueries_DuplicateName(t *testing.T) {
+	cached := []query.SerializedQuery{{Name: "ListUsers", Package: "myapp/querydefs/users"}}
+	fresh := []query.SerializedQuery{{Name: "ListUsers", Package: "myapp/querydefs/posts"}}
+
+	_, err := MergeQueries(cached, fresh, "myapp/querydefs/posts")
+	if err == nil || !strings.Contains(err.Error(), "duplicate query name: ListUsers") {
+		t.Errorf("expected a duplicate name error, got %v", err)
+	}
+}
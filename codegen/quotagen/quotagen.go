@@ -0,0 +1,120 @@
+// Package quotagen generates the scope_quotas settings table and its
+// queries. Tables with max_rows_per_scope in their [crud.<table>] section
+// get a create handler that refuses new rows once a scope (e.g. an
+// organization) holds that many; a scope_quotas row overrides the limit for
+// one scope at runtime, without regenerating anything.
+package quotagen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
+)
+
+// QuotaTable is the settings table holding per-scope row limits.
+const QuotaTable = "scope_quotas"
+
+// DefaultStatus is the HTTP status of the error returned when a scope's
+// quota is exhausted, unless [quotas] status says otherwise.
+const DefaultStatus = 403
+
+// GenerateQuotaMigration generates the migration that creates the
+// scope_quotas table. It uses AddEmptyTable so the table gets no public_id,
+// author_account_id or soft-delete columns: it is read by the generated
+// create handlers and written by the application's own admin code.
+func GenerateQuotaMigration(timestamp, modulePath string) []byte {
+	return []byte(fmt.Sprintf(`package migrations
+
+import (
+	"%s/shipq/lib/db/portsql/ddl"
+	"%s/shipq/lib/db/portsql/migrate"
+)
+
+func Migrate_%s_scope_quotas(plan *migrate.MigrationPlan) error {
+	_, err := plan.AddEmptyTable("scope_quotas", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		resourceCol := tb.String("resource").Col()
+		scopeIDCol := tb.Bigint("scope_id").Col()
+		tb.Bigint("max_rows")
+		tb.Datetime("created_at").Default("CURRENT_TIMESTAMP")
+		tb.Datetime("updated_at").Default("CURRENT_TIMESTAMP")
+		tb.AddUniqueIndex(resourceCol, scopeIDCol)
+		return nil
+	})
+	return err
+}
+`, modulePath, modulePath, timestamp))
+}
+
+// GenerateQuotaQuerydefs generates querydefs/scope_quotas/queries.go:
+// GetScopeQuota, which the generated create handlers call, and the queries
+// the application uses to set and clear a scope's limit at runtime.
+func GenerateQuotaQuerydefs(modulePath string) []byte {
+	var buf bytes.Buffer
+
+	schemaPkg := modulePath + "/shipq/db/schema"
+	queryPkg := modulePath + "/shipq/lib/db/portsql/query"
+
+	buf.WriteString(codegen.GeneratedHeader + "\n")
+	buf.WriteString("package scope_quotas\n\n")
+	buf.WriteString("import (\n")
+	buf.WriteString(fmt.Sprintf("\t%q\n", schemaPkg))
+	buf.WriteString(fmt.Sprintf("\t%q\n", queryPkg))
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("func init() {\n")
+
+	buf.WriteString("\t// GetScopeQuota returns the limit overriding max_rows_per_scope for one scope of a table.\n")
+	buf.WriteString("\tquery.MustDefineOne(\"GetScopeQuota\",\n")
+	buf.WriteString("\t\tquery.From(schema.ScopeQuotas).\n")
+	buf.WriteString("\t\t\tSelect(schema.ScopeQuotas.MaxRows()).\n")
+	writeKeyWhere(&buf)
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("\t// InsertScopeQuota sets the limit of a scope that has none yet.\n")
+	buf.WriteString("\tquery.MustDefineExec(\"InsertScopeQuota\",\n")
+	buf.WriteString("\t\tquery.InsertInto(schema.ScopeQuotas).\n")
+	buf.WriteString("\t\t\tColumns(\n")
+	buf.WriteString("\t\t\t\tschema.ScopeQuotas.Resource(),\n")
+	buf.WriteString("\t\t\t\tschema.ScopeQuotas.ScopeId(),\n")
+	buf.WriteString("\t\t\t\tschema.ScopeQuotas.MaxRows(),\n")
+	buf.WriteString("\t\t\t).\n")
+	buf.WriteString("\t\t\tValues(\n")
+	buf.WriteString("\t\t\t\tquery.Param[string](\"resource\"),\n")
+	buf.WriteString("\t\t\t\tquery.Param[int64](\"scopeId\"),\n")
+	buf.WriteString("\t\t\t\tquery.Param[int64](\"maxRows\"),\n")
+	buf.WriteString("\t\t\t).\n")
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("\t// UpdateScopeQuota changes the limit of a scope.\n")
+	buf.WriteString("\tquery.MustDefineExec(\"UpdateScopeQuota\",\n")
+	buf.WriteString("\t\tquery.Update(schema.ScopeQuotas).\n")
+	buf.WriteString("\t\t\tSet(schema.ScopeQuotas.MaxRows(), query.Param[int64](\"maxRows\")).\n")
+	buf.WriteString("\t\t\tSet(schema.ScopeQuotas.UpdatedAt(), query.Now()).\n")
+	writeKeyWhere(&buf)
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("\t// DeleteScopeQuota reverts a scope to the table's max_rows_per_scope.\n")
+	buf.WriteString("\tquery.MustDefineExec(\"DeleteScopeQuota\",\n")
+	buf.WriteString("\t\tquery.Delete(schema.ScopeQuotas).\n")
+	writeKeyWhere(&buf)
+	buf.WriteString("\t\t\tBuild())\n\n")
+
+	buf.WriteString("}\n")
+
+	formatted, err := phase.FormatSource(buf.Bytes())
+	if err != nil {
+		return buf.Bytes()
+	}
+	return formatted
+}
+
+// writeKeyWhere writes the WHERE clause matching one (resource, scope_id) row.
+func writeKeyWhere(buf *bytes.Buffer) {
+	buf.WriteString("\t\t\tWhere(query.And(\n")
+	buf.WriteString("\t\t\t\tschema.ScopeQuotas.Resource().Eq(query.Param[string](\"resource\")),\n")
+	buf.WriteString("\t\t\t\tschema.ScopeQuotas.ScopeId().Eq(query.Param[int64](\"scopeId\")),\n")
+	buf.WriteString("\t\t\t)).\n")
+}
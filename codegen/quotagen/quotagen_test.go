@@ -0,0 +1,58 @@
+package quotagen
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+)
+
+func TestGenerateQuotaMigration(t *testing.T) {
+	f := gofile.Parse(t, "scope_quotas.go", GenerateQuotaMigration("20260615120000", "example.com/myapp"))
+
+	fn := "Migrate_20260615120000_scope_quotas"
+	f.AssertSignature(fn, "func Migrate_20260615120000_scope_quotas(plan *migrate.MigrationPlan) error")
+	if args := f.Args(fn, "plan.AddEmptyTable"); len(args) != 1 || args[0][0] != `"scope_quotas"` {
+		t.Errorf("expected one scope_quotas table, got %q", args)
+	}
+	f.AssertStmts(fn,
+		`tb.Bigint("id").PrimaryKey()`,
+		`resourceCol := tb.String("resource").Col()`,
+		`scopeIDCol := tb.Bigint("scope_id").Col()`,
+		`tb.Bigint("max_rows")`,
+		"tb.AddUniqueIndex(resourceCol, scopeIDCol)",
+	)
+}
+
+func TestGenerateQuotaQuerydefs(t *testing.T) {
+	f := gofile.Parse(t, "queries.go", GenerateQuotaQuerydefs("example.com/myapp"))
+	if f.AST.Name.Name != "scope_quotas" {
+		t.Errorf("package = %s, want scope_quotas", f.AST.Name.Name)
+	}
+
+	var names []string
+	for _, define := range []string{"query.MustDefineOne", "query.MustDefineExec"} {
+		for _, args := range f.Args("init", define) {
+			names = append(names, args[0])
+		}
+	}
+	if want := []string{`"GetScopeQuota"`, `"InsertScopeQuota"`, `"UpdateScopeQuota"`, `"DeleteScopeQuota"`}; !slices.Equal(names, want) {
+		t.Errorf("queries = %q, want %q", names, want)
+	}
+	f.AssertExprs("init", "query.From(schema.ScopeQuotas). Select(schema.ScopeQuotas.MaxRows())")
+
+	// Get, Update and Delete all match one scope of one table.
+	key := []string{
+		`schema.ScopeQuotas.Resource().Eq(query.Param[string]("resource"))`,
+		`schema.ScopeQuotas.ScopeId().Eq(query.Param[int64]("scopeId"))`,
+	}
+	ands := f.Args("init", "query.And")
+	if len(ands) != 3 {
+		t.Fatalf("expected 3 keyed queries, got %d", len(ands))
+	}
+	for _, got := range ands {
+		if !slices.Equal(got, key) {
+			t.Errorf("conditions = %q, want %q", got, key)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package resourcegen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// ConcurrencyTestGenConfig holds configuration for generating the runner
+// concurrency test of a resource.
+type ConcurrencyTestGenConfig struct {
+	ModulePath  string    // e.g., "myapp"
+	TableName   string    // e.g., "books"
+	Table       ddl.Table // The table definition
+	Dialect     string    // "postgres", "mysql", or "sqlite"
+	ScopeColumn string    // e.g., "organization_id" (empty if unscoped)
+}
+
+// ConcurrencyTestFile is the name of the generated concurrency test in a
+// resource's spec directory.
+const ConcurrencyTestFile = "zz_generated_concurrency_test.go"
+
+// ConcurrencyBuildTag keeps the generated concurrency tests out of plain
+// `go test` runs: they commit rows to the test database instead of rolling
+// back a transaction, because concurrent callers need separate connections.
+const ConcurrencyBuildTag = "concurrency"
+
+// GenerateConcurrencyTest generates a table-driven test that releases
+// several goroutines at once against the resource's Create, Update and
+// SoftDelete (or Delete) runner methods. It is meant to run with -race and
+// asserts that:
+//
+//   - concurrent creates of distinct rows all succeed and are readable;
+//   - concurrent creates with the same public_id let exactly one through;
+//   - concurrent updates of one row all succeed and leave exactly one
+//     writer's value behind, never a mix or a stale value;
+//   - concurrent deletes of one row all succeed and leave it unreadable.
+//
+// Generated CRUD updates are last-writer-wins (there is no version column),
+// so "no lost updates" means every update is applied and the final row is
+// one complete write.
+func GenerateConcurrencyTest(cfg ConcurrencyTestGenConfig) ([]byte, error) {
+	analysis := analyzeConcurrencyTable(cfg)
+	if !analysis.hasPublicID {
+		return nil, fmt.Errorf("table %q has no public_id column", cfg.TableName)
+	}
+
+	pascal := dbstrings.ToPascalCase(dbstrings.ToSingular(cfg.TableName))
+	createMethod := codegen.CRUD.CreateMethodName(cfg.TableName)
+	getMethod := codegen.CRUD.GetMethodName(cfg.TableName)
+	updateMethod := codegen.CRUD.UpdateMethodName(cfg.TableName)
+	deleteMethod := codegen.CRUD.SoftDeleteMethodName(cfg.TableName)
+	deleteName := "soft delete"
+	if !analysis.hasDeletedAt {
+		deleteMethod = "Delete" + pascal
+		deleteName = "delete"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "//go:build %s\n\n", ConcurrencyBuildTag)
+	buf.WriteString("// Code generated by shipq. DO NOT EDIT.\n")
+	buf.WriteString("package spec\n\n")
+
+	writeConcurrencyTestImports(&buf, cfg, analysis)
+
+	buf.WriteString("// concurrencyWorkers is how many goroutines each case releases at once.\n")
+	buf.WriteString("const concurrencyWorkers = 8\n\n")
+
+	fmt.Fprintf(&buf, "func TestConcurrency_%s(t *testing.T) {\n", dbstrings.ToPascalCase(cfg.TableName))
+	buf.WriteString("\tctx := context.Background()\n")
+	if cfg.Dialect == "sqlite" {
+		buf.WriteString("\t// SQLite allows one writer at a time; a single connection serializes\n")
+		buf.WriteString("\t// the writes while the goroutines still race in the runner.\n")
+		buf.WriteString("\ttestDB.SetMaxOpenConns(1)\n")
+	}
+	buf.WriteString("\trunner := dbrunner.NewQueryRunner(testDB)\n")
+	buf.WriteString("\t// suffix keeps string values unique across runs, since rows are committed.\n")
+	buf.WriteString("\tsuffix := nanoid.New()\n\n")
+
+	// Dependencies are committed so that every pooled connection sees them.
+	if len(analysis.deps) > 0 {
+		buf.WriteString("\t// Parent rows are committed so every pooled connection can see them.\n")
+		buf.WriteString("\ttx, err := testDB.BeginTx(ctx, nil)\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\tt.Fatalf(\"failed to begin setup transaction: %v\", err)\n")
+		buf.WriteString("\t}\n")
+		for _, dep := range analysis.deps {
+			fmt.Fprintf(&buf, "\t%s := %s.Create(t, ctx, tx)\n", dep.varName, dep.alias)
+		}
+		buf.WriteString("\tif err := tx.Commit(); err != nil {\n")
+		buf.WriteString("\t\tt.Fatalf(\"failed to commit setup transaction: %v\", err)\n")
+		buf.WriteString("\t}\n\n")
+	}
+
+	// createParams
+	fmt.Fprintf(&buf, "\tcreateParams := func(publicID string, worker int) queries.%s {\n", codegen.CRUD.CreateParamsType(cfg.TableName))
+	fmt.Fprintf(&buf, "\t\treturn queries.%s{\n", codegen.CRUD.CreateParamsType(cfg.TableName))
+	buf.WriteString("\t\t\tPublicId: publicID,\n")
+	if analysis.hasAuthor {
+		buf.WriteString("\t\t\tAuthorAccountId: account.Id,\n")
+	}
+	for _, col := range cfg.Table.Columns {
+		if isFixtureAutoColumn(col.Name) || col.Nullable {
+			continue
+		}
+		fmt.Fprintf(&buf, "\t\t\t%s: %s,\n", dbstrings.ToPascalCase(col.Name), concurrencyColumnValue(cfg, col, `"_"`))
+	}
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n\n")
+
+	// updateParams
+	fmt.Fprintf(&buf, "\tupdateParams := func(publicID string, worker int) queries.%s {\n", codegen.CRUD.UpdateParamsType(cfg.TableName))
+	fmt.Fprintf(&buf, "\t\treturn queries.%s{\n", codegen.CRUD.UpdateParamsType(cfg.TableName))
+	buf.WriteString("\t\t\tPublicId: publicID,\n")
+	for _, col := range cfg.Table.Columns {
+		if isFixtureAutoColumn(col.Name) || col.Nullable {
+			continue
+		}
+		fmt.Fprintf(&buf, "\t\t\t%s: %s,\n", dbstrings.ToPascalCase(col.Name), concurrencyColumnValue(cfg, col, `"_updated_"`))
+	}
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n\n")
+
+	// scope holds the WHERE-clause scope field shared by get/update/delete.
+	scope := ""
+	if analysis.scope != nil {
+		scope = fmt.Sprintf(", %s: %s.Id", dbstrings.ToPascalCase(cfg.ScopeColumn), analysis.scope.varName)
+	}
+
+	fmt.Fprintf(&buf, "\tget := func(publicID string) (*queries.%s, error) {\n", codegen.CRUD.GetResultType(cfg.TableName))
+	fmt.Fprintf(&buf, "\t\treturn runner.%s(ctx, queries.%sParams{PublicId: publicID%s})\n", getMethod, getMethod, scope)
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tcreate := func(t *testing.T) string {\n")
+	buf.WriteString("\t\tt.Helper()\n")
+	buf.WriteString("\t\tpublicID := nanoid.New()\n")
+	fmt.Fprintf(&buf, "\t\tif _, err := runner.%s(ctx, createParams(publicID, concurrencyWorkers)); err != nil {\n", createMethod)
+	fmt.Fprintf(&buf, "\t\t\tt.Fatalf(\"setup: %s failed: %%v\", err)\n", createMethod)
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\treturn publicID\n")
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\ttests := []struct {\n")
+	buf.WriteString("\t\tname  string\n")
+	buf.WriteString("\t\tsetup func(t *testing.T) string\n")
+	buf.WriteString("\t\top    func(publicID string, worker int) error\n")
+	buf.WriteString("\t\tcheck func(t *testing.T, publicID string, errs []error)\n")
+	buf.WriteString("\t}{\n")
+
+	// Case: distinct creates
+	buf.WriteString("\t\t{\n")
+	buf.WriteString("\t\t\tname:  \"create distinct rows\",\n")
+	buf.WriteString("\t\t\tsetup: func(t *testing.T) string { return \"\" },\n")
+	buf.WriteString("\t\t\top: func(_ string, worker int) error {\n")
+	buf.WriteString("\t\t\t\tpublicID := nanoid.New()\n")
+	fmt.Fprintf(&buf, "\t\t\t\tif _, err := runner.%s(ctx, createParams(publicID, worker)); err != nil {\n", createMethod)
+	buf.WriteString("\t\t\t\t\treturn err\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t\tgot, err := get(publicID)\n")
+	buf.WriteString("\t\t\t\tif err != nil {\n")
+	buf.WriteString("\t\t\t\t\treturn err\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t\tif got == nil {\n")
+	buf.WriteString("\t\t\t\t\treturn fmt.Errorf(\"created row %s is not readable\", publicID)\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t\treturn nil\n")
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t\tcheck: func(t *testing.T, _ string, errs []error) {\n")
+	buf.WriteString("\t\t\t\trequireNoErrors(t, errs)\n")
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t},\n")
+
+	// Case: unique constraint race on public_id
+	buf.WriteString("\t\t{\n")
+	buf.WriteString("\t\t\tname:  \"create same public_id\",\n")
+	buf.WriteString("\t\t\tsetup: func(t *testing.T) string { return nanoid.New() },\n")
+	buf.WriteString("\t\t\top: func(publicID string, worker int) error {\n")
+	fmt.Fprintf(&buf, "\t\t\t\t_, err := runner.%s(ctx, createParams(publicID, worker))\n", createMethod)
+	buf.WriteString("\t\t\t\treturn err\n")
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t\tcheck: func(t *testing.T, publicID string, errs []error) {\n")
+	buf.WriteString("\t\t\t\tsucceeded := 0\n")
+	buf.WriteString("\t\t\t\tfor _, err := range errs {\n")
+	buf.WriteString("\t\t\t\t\tif err == nil {\n")
+	buf.WriteString("\t\t\t\t\t\tsucceeded++\n")
+	buf.WriteString("\t\t\t\t\t}\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t\tif succeeded != 1 {\n")
+	buf.WriteString("\t\t\t\t\tt.Fatalf(\"expected exactly 1 create to win the unique race, got %d (errors: %v)\", succeeded, errs)\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t\tgot, err := get(publicID)\n")
+	buf.WriteString("\t\t\t\tif err != nil || got == nil {\n")
+	buf.WriteString("\t\t\t\t\tt.Fatalf(\"winning row is not readable: %v\", err)\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t},\n")
+
+	// Case: concurrent updates of one row
+	buf.WriteString("\t\t{\n")
+	buf.WriteString("\t\t\tname:  \"update same row\",\n")
+	buf.WriteString("\t\t\tsetup: create,\n")
+	buf.WriteString("\t\t\top: func(publicID string, worker int) error {\n")
+	fmt.Fprintf(&buf, "\t\t\t\t_, err := runner.%s(ctx, updateParams(publicID, worker))\n", updateMethod)
+	buf.WriteString("\t\t\t\treturn err\n")
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t\tcheck: func(t *testing.T, publicID string, errs []error) {\n")
+	buf.WriteString("\t\t\t\trequireNoErrors(t, errs)\n")
+	buf.WriteString("\t\t\t\tgot, err := get(publicID)\n")
+	buf.WriteString("\t\t\t\tif err != nil || got == nil {\n")
+	buf.WriteString("\t\t\t\t\tt.Fatalf(\"updated row is not readable: %v\", err)\n")
+	buf.WriteString("\t\t\t\t}\n")
+	if analysis.witness != nil {
+		field := dbstrings.ToPascalCase(analysis.witness.Name)
+		buf.WriteString("\t\t\t\twritten := make(map[string]bool, concurrencyWorkers)\n")
+		buf.WriteString("\t\t\t\tfor worker := 0; worker < concurrencyWorkers; worker++ {\n")
+		fmt.Fprintf(&buf, "\t\t\t\t\twritten[updateParams(publicID, worker).%s] = true\n", field)
+		buf.WriteString("\t\t\t\t}\n")
+		fmt.Fprintf(&buf, "\t\t\t\tif !written[got.%s] {\n", field)
+		fmt.Fprintf(&buf, "\t\t\t\t\tt.Errorf(\"%s = %%q after concurrent updates, want one of the written values\", got.%s)\n", field, field)
+		buf.WriteString("\t\t\t\t}\n")
+	} else {
+		buf.WriteString("\t\t\t\t_ = got\n")
+	}
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t},\n")
+
+	// Case: concurrent deletes of one row
+	buf.WriteString("\t\t{\n")
+	fmt.Fprintf(&buf, "\t\t\tname:  \"%s same row\",\n", deleteName)
+	buf.WriteString("\t\t\tsetup: create,\n")
+	buf.WriteString("\t\t\top: func(publicID string, worker int) error {\n")
+	fmt.Fprintf(&buf, "\t\t\t\t_, err := runner.%s(ctx, queries.%sParams{PublicId: publicID%s})\n", deleteMethod, deleteMethod, scope)
+	buf.WriteString("\t\t\t\treturn err\n")
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t\tcheck: func(t *testing.T, publicID string, errs []error) {\n")
+	buf.WriteString("\t\t\t\trequireNoErrors(t, errs)\n")
+	buf.WriteString("\t\t\t\tgot, err := get(publicID)\n")
+	buf.WriteString("\t\t\t\tif err != nil {\n")
+	buf.WriteString("\t\t\t\t\tt.Fatalf(\"get after delete failed: %v\", err)\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t\tif got != nil {\n")
+	buf.WriteString("\t\t\t\t\tt.Error(\"row is still readable after concurrent deletes\")\n")
+	buf.WriteString("\t\t\t\t}\n")
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t},\n")
+
+	buf.WriteString("\t}\n\n")
+
+	buf.WriteString("\tfor _, tt := range tests {\n")
+	buf.WriteString("\t\tt.Run(tt.name, func(t *testing.T) {\n")
+	buf.WriteString("\t\t\tpublicID := tt.setup(t)\n")
+	buf.WriteString("\t\t\terrs := runConcurrently(concurrencyWorkers, func(worker int) error {\n")
+	buf.WriteString("\t\t\t\treturn tt.op(publicID, worker)\n")
+	buf.WriteString("\t\t\t})\n")
+	buf.WriteString("\t\t\ttt.check(t, publicID, errs)\n")
+	buf.WriteString("\t\t})\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// runConcurrently calls fn from n goroutines released at the same moment\n")
+	buf.WriteString("// and returns each call's error, indexed by worker.\n")
+	buf.WriteString("func runConcurrently(n int, fn func(worker int) error) []error {\n")
+	buf.WriteString("\terrs := make([]error, n)\n")
+	buf.WriteString("\tstart := make(chan struct{})\n")
+	buf.WriteString("\tvar wg sync.WaitGroup\n")
+	buf.WriteString("\tfor i := 0; i < n; i++ {\n")
+	buf.WriteString("\t\twg.Add(1)\n")
+	buf.WriteString("\t\tgo func(worker int) {\n")
+	buf.WriteString("\t\t\tdefer wg.Done()\n")
+	buf.WriteString("\t\t\t<-start\n")
+	buf.WriteString("\t\t\terrs[worker] = fn(worker)\n")
+	buf.WriteString("\t\t}(i)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tclose(start)\n")
+	buf.WriteString("\twg.Wait()\n")
+	buf.WriteString("\treturn errs\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("func requireNoErrors(t *testing.T, errs []error) {\n")
+	buf.WriteString("\tt.Helper()\n")
+	buf.WriteString("\tfor worker, err := range errs {\n")
+	buf.WriteString("\t\tif err != nil {\n")
+	buf.WriteString("\t\t\tt.Errorf(\"worker %d: %v\", worker, err)\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
+
+// concurrencyDep is a parent row the concurrency test creates up front.
+type concurrencyDep struct {
+	table   string // e.g., "authors"
+	varName string // e.g., "author"
+	alias   string // e.g., "authorfixture"
+}
+
+type concurrencyTableAnalysis struct {
+	hasPublicID  bool
+	hasDeletedAt bool
+	hasAuthor    bool
+	needsTime    bool
+	needsJSON    bool
+	needsStrconv bool
+	deps         []concurrencyDep      // one per referenced table, accounts last
+	scope        *concurrencyDep       // dep backing the scope column, if any
+	witness      *ddl.ColumnDefinition // string column checked after the update race
+}
+
+// analyzeConcurrencyTable collects what the generated test needs to know
+// about the table. Dependencies mirror GenerateFixture: every non-nullable
+// References column gets a parent row from that table's fixture, and
+// author_account_id shares a single accounts fixture with any account FK.
+func analyzeConcurrencyTable(cfg ConcurrencyTestGenConfig) concurrencyTableAnalysis {
+	var a concurrencyTableAnalysis
+	seen := make(map[string]bool)
+	addDep := func(table string) {
+		if seen[table] {
+			return
+		}
+		seen[table] = true
+		singular := dbstrings.ToSingular(table)
+		a.deps = append(a.deps, concurrencyDep{table: table, varName: singular, alias: singular + "fixture"})
+	}
+
+	needsAccount := false
+	for _, col := range cfg.Table.Columns {
+		switch col.Name {
+		case "public_id":
+			a.hasPublicID = true
+		case "deleted_at":
+			a.hasDeletedAt = true
+		case "author_account_id":
+			a.hasAuthor = true
+			needsAccount = true
+		}
+		if isFixtureAutoColumn(col.Name) || col.Nullable {
+			continue
+		}
+		switch {
+		case col.References == "accounts":
+			needsAccount = true
+		case col.References != "":
+			addDep(col.References)
+		case col.Type == ddl.DatetimeType || col.Type == ddl.TimestampType || col.Type == ddl.TimestamptzType:
+			a.needsTime = true
+		case col.Type == ddl.JSONType:
+			a.needsJSON = true
+		case col.Type == ddl.StringType || col.Type == ddl.TextType:
+			a.needsStrconv = true
+			if a.witness == nil {
+				c := col
+				a.witness = &c
+			}
+		case isCustomStringColumn(col):
+			a.needsStrconv = true
+		}
+	}
+	if needsAccount {
+		addDep("accounts")
+	}
+
+	for _, col := range cfg.Table.Columns {
+		if cfg.ScopeColumn == "" || col.Name != cfg.ScopeColumn {
+			continue
+		}
+		for i := range a.deps {
+			if a.deps[i].table == col.References {
+				a.scope = &a.deps[i]
+			}
+		}
+	}
+	return a
+}
+
+// concurrencyColumnValue returns the Go expression for a non-nullable column
+// in the generated create/update params. String columns embed the run suffix
+// and the worker number (joined by sep) so concurrent rows never collide on
+// unique columns and each update writes a distinct value.
+func concurrencyColumnValue(cfg ConcurrencyTestGenConfig, col ddl.ColumnDefinition, sep string) string {
+	if col.References != "" {
+		dep := dbstrings.ToSingular(col.References)
+		// Scope columns take the internal ID, like the fixture.
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			return dep + ".Id"
+		}
+		return dep + ".PublicId"
+	}
+	switch {
+	case col.Type == ddl.StringType, col.Type == ddl.TextType, isCustomStringColumn(col):
+		return fmt.Sprintf("\"test_%s_\" + suffix + %s + strconv.Itoa(worker)", col.Name, sep)
+	case ddl.IsTimeType(col.Type):
+		return "time.Now().UTC().Truncate(time.Second)"
+	case col.Type == ddl.BinaryType:
+		return `[]byte("test")`
+	}
+	return sampleValueForColumn(col)
+}
+
+// isCustomStringColumn reports whether col has a custom type represented as a
+// Go string without a sample value, which gets a unique value like string
+// columns.
+func isCustomStringColumn(col ddl.ColumnDefinition) bool {
+	return col.Custom != nil && col.Custom.GoType == "string" && col.Custom.Sample == ""
+}
+
+// writeConcurrencyTestImports writes the import block for the concurrency test.
+func writeConcurrencyTestImports(buf *bytes.Buffer, cfg ConcurrencyTestGenConfig, a concurrencyTableAnalysis) {
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"context\"\n")
+	if a.needsJSON {
+		buf.WriteString("\t\"encoding/json\"\n")
+	}
+	buf.WriteString("\t\"fmt\"\n")
+	if a.needsStrconv {
+		buf.WriteString("\t\"strconv\"\n")
+	}
+	buf.WriteString("\t\"sync\"\n")
+	buf.WriteString("\t\"testing\"\n")
+	if a.needsTime {
+		buf.WriteString("\t\"time\"\n")
+	}
+	buf.WriteString("\n")
+	writeCustomSampleImports(buf, cfg.Table)
+	fmt.Fprintf(buf, "\t%q\n", cfg.ModulePath+"/shipq/lib/nanoid")
+	fmt.Fprintf(buf, "\t%q\n", cfg.ModulePath+"/shipq/queries")
+	fmt.Fprintf(buf, "\tdbrunner %q\n", cfg.ModulePath+"/shipq/queries/"+cfg.Dialect)
+	for _, dep := range a.deps {
+		fmt.Fprintf(buf, "\t%s %q\n", dep.alias, cfg.ModulePath+"/api/"+dep.table+"/fixture")
+	}
+	buf.WriteString(")\n\n")
+}
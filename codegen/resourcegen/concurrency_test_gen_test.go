@@ -0,0 +1,157 @@
+package resourcegen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func concurrencyTestBooksTable() ddl.Table {
+	return ddl.Table{
+		Name: "books",
+		Columns: []ddl.ColumnDefinition{
+			{Name: "id", Type: ddl.BigintType},
+			{Name: "public_id", Type: ddl.StringType},
+			{Name: "organization_id", Type: ddl.BigintType, References: "organizations"},
+			{Name: "author_account_id", Type: ddl.BigintType, References: "accounts"},
+			{Name: "title", Type: ddl.StringType, Unique: true},
+			{Name: "shelf_id", Type: ddl.BigintType, References: "shelves"},
+			{Name: "pages", Type: ddl.IntegerType},
+			{Name: "subtitle", Type: ddl.StringType, Nullable: true},
+			{Name: "created_at", Type: ddl.DatetimeType},
+			{Name: "updated_at", Type: ddl.DatetimeType},
+			{Name: "deleted_at", Type: ddl.DatetimeType, Nullable: true},
+		},
+	}
+}
+
+func TestGenerateConcurrencyTest(t *testing.T) {
+	result, err := GenerateConcurrencyTest(ConcurrencyTestGenConfig{
+		ModulePath:  "myapp",
+		TableName:   "books",
+		Table:       concurrencyTestBooksTable(),
+		Dialect:     "postgres",
+		ScopeColumn: "organization_id",
+	})
+	if err != nil {
+		t.Fatalf("GenerateConcurrencyTest failed: %v", err)
+	}
+	if !bytes.HasPrefix(result, []byte("//go:build concurrency\n")) {
+		t.Error("expected a //go:build concurrency constraint")
+	}
+	f := gofile.Parse(t, ConcurrencyTestFile, result)
+	for _, path := range []string{"myapp/api/organizations/fixture", "myapp/api/shelves/fixture", "myapp/api/accounts/fixture"} {
+		if !f.HasImport(path) {
+			t.Errorf("missing import %s", path)
+		}
+	}
+
+	// Parent rows are created once, with one accounts fixture even though
+	// two columns need an account, and committed before the workers run.
+	fn := "TestConcurrency_Books"
+	f.AssertStmts(fn,
+		"organization := organizationfixture.Create(t, ctx, tx)",
+		"shelve := shelvefixture.Create(t, ctx, tx)",
+		"account := accountfixture.Create(t, ctx, tx)",
+	)
+	if n := f.Calls(fn, "accountfixture.Create"); n != 1 {
+		t.Errorf("expected 1 accounts fixture call, got %d", n)
+	}
+	if !f.Before(fn, "account := accountfixture.Create(t, ctx, tx)", "if err := tx.Commit(); err != nil") {
+		t.Error("parent rows must be committed before the workers run")
+	}
+
+	// Unique string columns differ per worker; nullable columns are left
+	// unset.
+	f.AssertStmts(fn,
+		`return queries.CreateBookParams{
+			PublicId:        publicID,
+			AuthorAccountId: account.Id,
+			OrganizationId:  organization.Id,
+			Title:           "test_title_" + suffix + "_" + strconv.Itoa(worker),
+			ShelfId:         shelve.PublicId,
+			Pages:           int32(1),
+		}`,
+		`return queries.UpdateBookByPublicIDParams{
+			PublicId:       publicID,
+			OrganizationId: organization.Id,
+			Title:          "test_title_" + suffix + "_updated_" + strconv.Itoa(worker),
+			ShelfId:        shelve.PublicId,
+			Pages:          int32(1),
+		}`,
+		"return runner.GetBookByPublicID(ctx, queries.GetBookByPublicIDParams{PublicId: publicID, OrganizationId: organization.Id})",
+		"_, err := runner.UpdateBookByPublicID(ctx, updateParams(publicID, worker))",
+		"_, err := runner.SoftDeleteBookByPublicID(ctx, queries.SoftDeleteBookByPublicIDParams{PublicId: publicID, OrganizationId: organization.Id})",
+		"if !written[got.Title]",
+	)
+	f.AssertExprs(fn,
+		`name: "create distinct rows"`,
+		`name: "create same public_id"`,
+		`name: "update same row"`,
+		`name: "soft delete same row"`,
+	)
+	if f.Calls(fn, "testDB.SetMaxOpenConns") != 0 {
+		t.Error("only sqlite should limit the pool to one connection")
+	}
+}
+
+func TestGenerateConcurrencyTest_HardDeleteSQLite(t *testing.T) {
+	result, err := GenerateConcurrencyTest(ConcurrencyTestGenConfig{
+		ModulePath: "myapp",
+		TableName:  "tags",
+		Table: ddl.Table{
+			Name: "tags",
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType},
+				{Name: "public_id", Type: ddl.StringType},
+				{Name: "weight", Type: ddl.IntegerType},
+				{Name: "starts_at", Type: ddl.DatetimeType},
+			},
+		},
+		Dialect: "sqlite",
+	})
+	if err != nil {
+		t.Fatalf("GenerateConcurrencyTest failed: %v", err)
+	}
+	f := gofile.Parse(t, ConcurrencyTestFile, result)
+
+	fn := "TestConcurrency_Tags"
+	f.AssertStmts(fn,
+		"testDB.SetMaxOpenConns(1)",
+		"_, err := runner.DeleteTag(ctx, queries.DeleteTagParams{PublicId: publicID})",
+		`return queries.CreateTagParams{
+			PublicId: publicID,
+			Weight:   int32(1),
+			StartsAt: time.Now().UTC().Truncate(time.Second),
+		}`,
+	)
+	f.AssertExprs(fn, `name: "delete same row"`)
+	// Without unique strings or parents there is nothing to vary per
+	// worker and nothing to commit first.
+	if f.HasImport("strconv") || f.Calls(fn, "testDB.BeginTx") != 0 || f.HasStmt(fn, "written := make(map[string]bool, concurrencyWorkers)") {
+		t.Errorf("unexpected per-worker values or setup transaction:\n%s", result)
+	}
+	for _, imp := range f.AST.Imports {
+		if strings.HasSuffix(imp.Path.Value, `/fixture"`) {
+			t.Errorf("unexpected fixture import %s", imp.Path.Value)
+		}
+	}
+}
+
+func TestGenerateConcurrencyTest_RequiresPublicID(t *testing.T) {
+	_, err := GenerateConcurrencyTest(ConcurrencyTestGenConfig{
+		ModulePath: "myapp",
+		TableName:  "counters",
+		Table: ddl.Table{
+			Name:    "counters",
+			Columns: []ddl.ColumnDefinition{{Name: "id", Type: ddl.BigintType}},
+		},
+		Dialect: "postgres",
+	})
+	if err == nil {
+		t.Fatal("expected an error for a table without public_id")
+	}
+}
@@ -3,8 +3,11 @@ package resourcegen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+	"strconv"
+	"strings"
 
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/dbstrings"
 )
@@ -81,6 +84,8 @@ func GenerateFixture(cfg FixtureGenConfig) ([]byte, error) {
 		fmt.Fprintf(&buf, "\t%q\n\n", cfg.ModulePath+"/shipq/lib/nanoid")
 	}
 
+	writeCustomSampleImports(&buf, cfg.Table)
+
 	// Query runner packages
 	fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/queries")
 	fmt.Fprintf(&buf, "\tdbrunner %q\n", cfg.ModulePath+"/shipq/queries/"+cfg.Dialect)
@@ -171,7 +176,7 @@ func GenerateFixture(cfg FixtureGenConfig) ([]byte, error) {
 				fmt.Fprintf(&buf, "\t\t%s: %s.PublicId,\n", fieldName, depSingular)
 			}
 		} else {
-			sampleVal := getSampleValue(goBaseTypeForFixture(col.Type), col.Name)
+			sampleVal := sampleValueForColumn(col)
 			fmt.Fprintf(&buf, "\t\t%s: %s,\n", fieldName, sampleVal)
 		}
 	}
@@ -183,7 +188,7 @@ func GenerateFixture(cfg FixtureGenConfig) ([]byte, error) {
 	buf.WriteString("\treturn result\n")
 	buf.WriteString("}\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format fixture code: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -208,13 +213,111 @@ func fixtureTableHasJSONColumn(table ddl.Table) bool {
 	return false
 }
 
+// sampleValueForColumn returns a Go literal to use for col in generated
+// fixtures and tests. Decimals and points are strings, but "test_<name>"
+// would be rejected by their columns, so they get a numeric string and a WKT
+// point instead.
+func sampleValueForColumn(col ddl.ColumnDefinition) string {
+	if t := col.Custom; t != nil {
+		switch {
+		case t.Sample != "":
+			return t.GoType + "(" + t.Sample + ")"
+		case t.GoType == "string":
+			return getSampleValue("string", col.Name)
+		default:
+			return "*new(" + t.GoType + ")"
+		}
+	}
+	switch col.Type {
+	case ddl.DecimalType:
+		return `"1"`
+	case ddl.PointType:
+		return `"POINT(-122.4194 37.7749)"`
+	case ddl.PolygonType:
+		return `"POLYGON((-122.5 37.7, -122.3 37.7, -122.3 37.9, -122.5 37.7))"`
+	case ddl.EnumType:
+		if len(col.EnumValues) > 0 {
+			return strconv.Quote(col.EnumValues[0])
+		}
+	case ddl.StringType, ddl.TextType:
+		maxLength := 0
+		if col.Length != nil {
+			maxLength = *col.Length
+		}
+		return validSampleString(col.Name, codegen.CRUD.IsEmailColumn(col.Name), maxLength)
+	}
+	return getSampleValue(goBaseTypeForFixture(col.Type), col.Name)
+}
+
+// validSampleString returns a string literal for the field or column name
+// that passes the validation of generated handlers: an email address when
+// email is set, and at most maxLength characters (0 for no limit).
+func validSampleString(name string, email bool, maxLength int) string {
+	s := "test_" + strings.ToLower(name)
+	if email {
+		s += "@example.com"
+	}
+	if maxLength > 0 && len(s) > maxLength {
+		if email {
+			s = "t@example.com"
+		}
+		if len(s) > maxLength {
+			s = s[:maxLength]
+		}
+	}
+	return strconv.Quote(s)
+}
+
+// sampleValueForField returns a Go literal for a request field, honoring the
+// validate tag that generated create handlers put on column fields.
+func sampleValueForField(field codegen.SerializedFieldInfo) string {
+	rules := field.Tags["validate"]
+	if rules == "" || field.Type != "string" {
+		return getSampleValue(field.Type, field.Name)
+	}
+	email, maxLength := false, 0
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "decimal":
+			return `"1"`
+		case "oneof":
+			return strconv.Quote(strings.Fields(arg)[0])
+		case "email":
+			email = true
+		case "max":
+			maxLength, _ = strconv.Atoi(arg)
+		}
+	}
+	return validSampleString(field.Name, email, maxLength)
+}
+
+// writeCustomSampleImports writes the import paths of the custom types
+// whose sample values are set in generated fixtures and tests, i.e. those of
+// non-nullable columns.
+func writeCustomSampleImports(buf *bytes.Buffer, table ddl.Table) {
+	seen := make(map[string]bool)
+	for _, col := range table.Columns {
+		if col.Custom == nil || col.Custom.GoImport == "" || col.Nullable || isFixtureAutoColumn(col.Name) {
+			continue
+		}
+		if seen[col.Custom.GoImport] {
+			continue
+		}
+		seen[col.Custom.GoImport] = true
+		fmt.Fprintf(buf, "\t%q\n", col.Custom.GoImport)
+	}
+}
+
+// goBaseTypeForFixture returns the Go type used for a column in fixture and
+// test code. It mirrors handlergen's goBaseType.
 func goBaseTypeForFixture(colType string) string {
 	switch colType {
 	case ddl.IntegerType:
 		return "int32"
 	case ddl.BigintType:
 		return "int64"
-	case ddl.DecimalType, ddl.FloatType:
+	case ddl.FloatType:
 		return "float64"
 	case ddl.BooleanType:
 		return "bool"
@@ -3,9 +3,12 @@ package resourcegen
 import (
 	"go/parser"
 	"go/token"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 	"github.com/shipq/shipq/db/portsql/ddl"
 )
 
@@ -24,6 +27,44 @@ func TestGetSampleValue_JSONRawMessage(t *testing.T) {
 	}
 }
 
+func TestSampleValueForColumn_Validation(t *testing.T) {
+	short, long := 4, 255
+	tests := []struct {
+		col  ddl.ColumnDefinition
+		want string
+	}{
+		{ddl.ColumnDefinition{Name: "title", Type: ddl.StringType, Length: &long}, `"test_title"`},
+		{ddl.ColumnDefinition{Name: "code", Type: ddl.StringType, Length: &short}, `"test"`},
+		{ddl.ColumnDefinition{Name: "email", Type: ddl.StringType}, `"test_email@example.com"`},
+		{ddl.ColumnDefinition{Name: "billing_email", Type: ddl.TextType}, `"test_billing_email@example.com"`},
+		{ddl.ColumnDefinition{Name: "status", Type: ddl.EnumType, EnumValues: []string{"draft", "live"}}, `"draft"`},
+	}
+	for _, tt := range tests {
+		if got := sampleValueForColumn(tt.col); got != tt.want {
+			t.Errorf("sampleValueForColumn(%s) = %s, want %s", tt.col.Name, got, tt.want)
+		}
+	}
+}
+
+func TestSampleValueForField_Validation(t *testing.T) {
+	tests := []struct {
+		field codegen.SerializedFieldInfo
+		want  string
+	}{
+		{codegen.SerializedFieldInfo{Name: "Title", Type: "string"}, `"test_title"`},
+		{codegen.SerializedFieldInfo{Name: "Code", Type: "string", Tags: map[string]string{"validate": "required,max=3"}}, `"tes"`},
+		{codegen.SerializedFieldInfo{Name: "ContactEmail", Type: "string", Tags: map[string]string{"validate": "required,max=20,email"}}, `"t@example.com"`},
+		{codegen.SerializedFieldInfo{Name: "Price", Type: "string", Tags: map[string]string{"validate": "required,decimal=10:2"}}, `"1"`},
+		{codegen.SerializedFieldInfo{Name: "Status", Type: "string", Tags: map[string]string{"validate": "required,oneof=draft live"}}, `"draft"`},
+		{codegen.SerializedFieldInfo{Name: "Count", Type: "int32", Tags: map[string]string{"validate": "required"}}, "int32(1)"},
+	}
+	for _, tt := range tests {
+		if got := sampleValueForField(tt.field); got != tt.want {
+			t.Errorf("sampleValueForField(%s) = %s, want %s", tt.field.Name, got, tt.want)
+		}
+	}
+}
+
 func TestGenerateFixture_JSONColumn_ImportsEncodingJSON(t *testing.T) {
 	cfg := FixtureGenConfig{
 		ModulePath: "myapp",
@@ -542,3 +583,44 @@ func TestGenerateFixture_NoDuplicateAccountVar_ChannelMembers(t *testing.T) {
 		t.Errorf("expected exactly 1 account := accountfixture.Create(...) declaration, got %d\n%s", occurrences, code)
 	}
 }
+
+func TestGenerateFixture_CustomTypeColumns(t *testing.T) {
+	money := &ddl.CustomType{Name: "money", GoType: "decimal.Decimal", GoImport: "github.com/shopspring/decimal", Sample: `decimal.RequireFromString("9.99")`}
+	cents := &ddl.CustomType{Name: "cents", GoType: "money.Cents", GoImport: "example.com/money"}
+	citext := &ddl.CustomType{Name: "citext", GoType: "string"}
+	cfg := FixtureGenConfig{
+		ModulePath: "myapp",
+		TableName:  "orders",
+		Table: ddl.Table{
+			Name: "orders",
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType},
+				{Name: "public_id", Type: ddl.StringType},
+				{Name: "price", Type: "money", Custom: money},
+				{Name: "total", Type: "cents", Custom: cents},
+				{Name: "email", Type: "citext", Custom: citext},
+				{Name: "refund", Type: "cents", Nullable: true, Custom: cents},
+			},
+		},
+		Schema:  map[string]ddl.Table{},
+		Dialect: "sqlite",
+	}
+
+	result, err := GenerateFixture(cfg)
+	if err != nil {
+		t.Fatalf("GenerateFixture failed: %v", err)
+	}
+	f := gofile.Parse(t, "fixture.go", result)
+	for _, path := range []string{"github.com/shopspring/decimal", "example.com/money"} {
+		if !f.HasImport(path) {
+			t.Errorf("fixture should import %s", path)
+		}
+	}
+	// Nullable custom columns are left unset.
+	if got, want := f.Args("Create", "runner.CreateOrder"), [][]string{{
+		"ctx",
+		`queries.CreateOrderParams{ PublicId: nanoid.New(), Price: decimal.Decimal(decimal.RequireFromString("9.99")), Total: *new(money.Cents), Email: "test_email", }`,
+	}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("CreateOrder args = %q, want %q", got, want)
+	}
+}
@@ -3,9 +3,10 @@ package resourcegen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+	"strings"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/dbstrings"
 )
@@ -29,6 +30,14 @@ type PerOpTestGenConfig struct {
 	Dialect         string               // "postgres", "mysql", or "sqlite"
 	TestDatabaseURL string               // test database URL
 	ScopeColumn     string               // e.g., "organization_id" (empty if unscoped)
+	Defaults        map[string]string    // API-side create defaults, keyed by column name
+}
+
+// omittedFromCreate reports whether generated create requests leave col out:
+// nullable columns, and columns the create handler fills with an API default.
+func omittedFromCreate(cfg PerOpTestGenConfig, col ddl.ColumnDefinition) bool {
+	_, hasDefault := cfg.Defaults[col.Name]
+	return col.Nullable || hasDefault
 }
 
 // GenerateCreateTest generates create_test.go for a resource.
@@ -66,7 +75,7 @@ func GenerateCreateTest(cfg PerOpTestGenConfig) ([]byte, error) {
 	// Build the create request inline with sample values
 	buf.WriteString(fmt.Sprintf("\tresp, err := client.Create%s(ctx, %s.Create%sRequest{\n", res, pkgName, res))
 	for _, col := range cfg.Table.Columns {
-		if isFixtureAutoColumn(col.Name) || col.Nullable {
+		if isFixtureAutoColumn(col.Name) || omittedFromCreate(cfg, col) {
 			continue
 		}
 		// Scope column is injected by the handler from auth context, not from the request
@@ -78,7 +87,7 @@ func GenerateCreateTest(cfg PerOpTestGenConfig) ([]byte, error) {
 			depSingular := dbstrings.ToSingular(col.References)
 			buf.WriteString(fmt.Sprintf("\t\t%s: %s.PublicId,\n", fieldName, depSingular))
 		} else {
-			sampleVal := getSampleValue(goBaseTypeForFixture(col.Type), col.Name)
+			sampleVal := sampleValueForColumn(col)
 			buf.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, sampleVal))
 		}
 	}
@@ -326,6 +335,7 @@ func GenerateUpdateTest(cfg PerOpTestGenConfig) ([]byte, error) {
 		Name    string
 		Pascal  string
 		GoType  string
+		Sample  string // Go literal used when the column is not the update target
 		IsFK    bool
 		FKTable string // e.g., "authors"
 	}
@@ -344,8 +354,16 @@ func GenerateUpdateTest(cfg PerOpTestGenConfig) ([]byte, error) {
 			updCols = append(updCols, updCol{Name: col.Name, Pascal: pascal, GoType: "string", IsFK: true, FKTable: col.References})
 		} else {
 			goType := goBaseTypeForFixture(col.Type)
-			updCols = append(updCols, updCol{Name: col.Name, Pascal: pascal, GoType: goType})
-			if updateField == "" && goType == "string" {
+			if col.Custom != nil {
+				goType = col.Custom.GoType
+			}
+			updCols = append(updCols, updCol{Name: col.Name, Pascal: pascal, GoType: goType, Sample: sampleValueForColumn(col)})
+			// Decimal columns are strings too, but the database normalizes
+			// them (e.g. "1" -> "1.00"), so they cannot be round-tripped.
+			// Point columns only accept WKT, and custom types may do either.
+			// Email, enum and short columns would reject "updated_<name>".
+			if updateField == "" && goType == "string" && col.Type != ddl.DecimalType && col.Type != ddl.PointType && col.Type != ddl.EnumType && col.Custom == nil &&
+				!codegen.CRUD.IsEmailColumn(col.Name) && (col.Length == nil || *col.Length >= len("updated_"+col.Name)) {
 				updateField = col.Name
 			}
 		}
@@ -379,9 +397,8 @@ func GenerateUpdateTest(cfg PerOpTestGenConfig) ([]byte, error) {
 			depAlias := depSingular + "fixture"
 			buf.WriteString(fmt.Sprintf("\t%sForUpdate := %s.Create(t, ctx, tx)\n", depSingular, depAlias))
 		} else if uc.Name != updateField {
-			sampleVal := getSampleValue(uc.GoType, uc.Name)
 			varName := "keep" + uc.Pascal
-			buf.WriteString(fmt.Sprintf("\t%s := %s\n", varName, sampleVal))
+			buf.WriteString(fmt.Sprintf("\t%s := %s\n", varName, uc.Sample))
 		}
 	}
 
@@ -509,6 +526,231 @@ func GenerateSoftDeleteTest(cfg PerOpTestGenConfig) ([]byte, error) {
 	return formatSource(buf.Bytes())
 }
 
+// GenerateRestoreTest generates restore_test.go for a resource: a deleted
+// resource comes back after a restore, and restoring one that is not
+// deleted is a 404.
+func GenerateRestoreTest(cfg PerOpTestGenConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	res := dbstrings.ToPascalCase(dbstrings.ToSingular(cfg.TableName))
+	pkgName := cfg.TableName
+
+	buf.WriteString("// Code generated by shipq.\n")
+	buf.WriteString("package spec\n\n")
+
+	writeSimpleTestImports(&buf, cfg, true)
+
+	// TestRestore_Success
+	buf.WriteString(fmt.Sprintf("func TestRestore%s_Success(t *testing.T) {\n", res))
+	writeTestSetup(&buf, cfg)
+	buf.WriteString("\n")
+	if cfg.ScopeColumn != "" {
+		writeCreateDeps(&buf, cfg)
+		writeScopedCreateHelper(&buf, cfg)
+		buf.WriteString("\tcreated := createResource()\n\n")
+	} else {
+		buf.WriteString("\tcreated := fixture.Create(t, ctx, tx)\n\n")
+	}
+	buf.WriteString(fmt.Sprintf("\tif _, err := client.SoftDelete%s(ctx, %s.SoftDelete%sRequest{ID: created.PublicId}); err != nil {\n", res, pkgName, res))
+	buf.WriteString(fmt.Sprintf("\t\tt.Fatalf(\"SoftDelete%s failed: %%v\", err)\n", res))
+	buf.WriteString("\t}\n")
+	buf.WriteString(fmt.Sprintf("\tif _, err := client.Restore%s(ctx, %s.Restore%sRequest{ID: created.PublicId}); err != nil {\n", res, pkgName, res))
+	buf.WriteString(fmt.Sprintf("\t\tt.Fatalf(\"Restore%s failed: %%v\", err)\n", res))
+	buf.WriteString("\t}\n\n")
+	buf.WriteString(fmt.Sprintf("\tif _, err := client.Get%s(ctx, %s.Get%sRequest{ID: created.PublicId}); err != nil {\n", res, pkgName, res))
+	buf.WriteString("\t\tt.Errorf(\"expected the restored resource to be found: %v\", err)\n")
+	buf.WriteString("\t}\n\n")
+	// A second restore finds nothing deleted
+	buf.WriteString(fmt.Sprintf("\tif _, err := client.Restore%s(ctx, %s.Restore%sRequest{ID: created.PublicId}); err == nil {\n", res, pkgName, res))
+	buf.WriteString("\t\tt.Error(\"expected 404 restoring a resource that is not deleted\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	// TestRestore_NotFound
+	buf.WriteString(fmt.Sprintf("func TestRestore%s_NotFound(t *testing.T) {\n", res))
+	writeTestSetup(&buf, cfg)
+	buf.WriteString("\n")
+	buf.WriteString(fmt.Sprintf("\t_, restoreErr := client.Restore%s(ctx, %s.Restore%sRequest{ID: \"nonexistent\"})\n", res, pkgName, res))
+	buf.WriteString("\tif restoreErr == nil {\n")
+	buf.WriteString("\t\tt.Error(\"expected 404 for nonexistent resource\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	// TestRestore_Unauthenticated (if auth required)
+	if cfg.RequireAuth {
+		buf.WriteString(fmt.Sprintf("func TestRestore%s_Unauthenticated(t *testing.T) {\n", res))
+		writeUnauthTestSetup(&buf, cfg)
+		buf.WriteString(fmt.Sprintf("\t_, restoreErr := unauthClient.Restore%s(ctx, %s.Restore%sRequest{ID: \"any\"})\n", res, pkgName, res))
+		buf.WriteString("\tif restoreErr == nil {\n")
+		buf.WriteString("\t\tt.Error(\"expected error for unauthenticated request\")\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("}\n\n")
+	}
+
+	return formatSource(buf.Bytes())
+}
+
+// GenerateImportTest generates import_test.go for a resource. It only
+// exercises validation (in dry-run mode), so it needs no fixtures.
+func GenerateImportTest(cfg PerOpTestGenConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	plural := dbstrings.ToPascalCase(cfg.TableName)
+	pkgName := cfg.TableName
+
+	buf.WriteString("// Code generated by shipq.\n")
+	buf.WriteString("package spec\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"strings\"\n")
+	buf.WriteString("\t\"testing\"\n\n")
+	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/api/"+cfg.TableName))
+	buf.WriteString(")\n\n")
+
+	// TestImport_DryRunReportsRowErrors -- a row with the wrong number of
+	// fields is reported, and nothing is written in a dry run.
+	var header []string
+	for _, col := range cfg.Table.Columns {
+		if isFixtureAutoColumn(col.Name) {
+			continue
+		}
+		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
+			continue
+		}
+		header = append(header, col.Name)
+	}
+	data := strings.Join(header, ",") + "\n" + strings.Repeat(",", len(header)) + "\n"
+
+	buf.WriteString(fmt.Sprintf("func TestImport%s_DryRunReportsRowErrors(t *testing.T) {\n", plural))
+	writeTestSetup(&buf, cfg)
+	buf.WriteString("\n")
+	buf.WriteString(fmt.Sprintf("\tresp, err := client.Import%s(ctx, %s.Import%sRequest{DryRun: true}, \"text/csv\", strings.NewReader(%q))\n", plural, pkgName, plural, data))
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\tt.Fatalf(\"Import%s failed: %%v\", err)\n", plural))
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif resp.Total != 1 || resp.Failed != 1 || resp.Imported != 0 {\n")
+	buf.WriteString("\t\tt.Fatalf(\"expected 1 failed row, got total=%d failed=%d imported=%d\", resp.Total, resp.Failed, resp.Imported)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif resp.Errors[0].Row != 1 {\n")
+	buf.WriteString("\t\tt.Errorf(\"expected error for row 1, got %+v\", resp.Errors[0])\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	// TestImport_UnsupportedFormat -- only CSV and NDJSON bodies are read.
+	buf.WriteString(fmt.Sprintf("func TestImport%s_UnsupportedFormat(t *testing.T) {\n", plural))
+	writeTestSetup(&buf, cfg)
+	buf.WriteString("\n")
+	buf.WriteString(fmt.Sprintf("\t_, err := client.Import%s(ctx, %s.Import%sRequest{}, \"application/xml\", strings.NewReader(\"<rows/>\"))\n", plural, pkgName, plural))
+	buf.WriteString("\tif err == nil {\n")
+	buf.WriteString("\t\tt.Error(\"expected error for unsupported format\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	// TestImport_Unauthenticated (if auth required)
+	if cfg.RequireAuth {
+		buf.WriteString(fmt.Sprintf("func TestImport%s_Unauthenticated(t *testing.T) {\n", plural))
+		writeUnauthTestSetup(&buf, cfg)
+		buf.WriteString(fmt.Sprintf("\t_, importErr := unauthClient.Import%s(ctx, %s.Import%sRequest{}, \"text/csv\", strings.NewReader(\"\"))\n", plural, pkgName, plural))
+		buf.WriteString("\tif importErr == nil {\n")
+		buf.WriteString("\t\tt.Error(\"expected error for unauthenticated request\")\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("}\n\n")
+	}
+
+	return formatSource(buf.Bytes())
+}
+
+// GenerateBulkCreateTest generates spec/bulk_create_test.go for the bulk
+// create endpoint. It checks that an empty batch is rejected; items are
+// validated by the create handler, which create_test.go covers.
+func GenerateBulkCreateTest(cfg PerOpTestGenConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	plural := dbstrings.ToPascalCase(cfg.TableName)
+	pkgName := cfg.TableName
+	funcName := "BulkCreate" + plural
+
+	buf.WriteString("// Code generated by shipq.\n")
+	buf.WriteString("package spec\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"testing\"\n\n")
+	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/api/"+cfg.TableName))
+	buf.WriteString(")\n\n")
+
+	buf.WriteString(fmt.Sprintf("func Test%s_EmptyItems(t *testing.T) {\n", funcName))
+	writeTestSetup(&buf, cfg)
+	buf.WriteString("\n")
+	buf.WriteString(fmt.Sprintf("\t_, err := client.%s(ctx, %s.%sRequest{})\n", funcName, pkgName, funcName))
+	buf.WriteString("\tif err == nil {\n")
+	buf.WriteString("\t\tt.Error(\"expected error for an empty bulk create\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	if cfg.RequireAuth {
+		buf.WriteString(fmt.Sprintf("func Test%s_Unauthenticated(t *testing.T) {\n", funcName))
+		writeUnauthTestSetup(&buf, cfg)
+		buf.WriteString(fmt.Sprintf("\t_, bulkErr := unauthClient.%s(ctx, %s.%sRequest{})\n", funcName, pkgName, funcName))
+		buf.WriteString("\tif bulkErr == nil {\n")
+		buf.WriteString("\t\tt.Error(\"expected error for unauthenticated request\")\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("}\n\n")
+	}
+
+	return formatSource(buf.Bytes())
+}
+
+// GenerateNearTest generates spec/near_test.go for the proximity search
+// endpoint. It checks input validation and that a search far from any
+// fixture returns no items.
+func GenerateNearTest(cfg PerOpTestGenConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	plural := dbstrings.ToPascalCase(cfg.TableName)
+	pkgName := cfg.TableName
+	funcName := "List" + plural + "Near"
+
+	buf.WriteString("// Code generated by shipq.\n")
+	buf.WriteString("package spec\n\n")
+
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"testing\"\n\n")
+	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/api/"+cfg.TableName))
+	buf.WriteString(")\n\n")
+
+	// TestNear_EmptyArea -- nothing is stored near Null Island in a fresh test
+	buf.WriteString(fmt.Sprintf("func Test%s_EmptyArea(t *testing.T) {\n", funcName))
+	writeTestSetup(&buf, cfg)
+	buf.WriteString("\n")
+	buf.WriteString(fmt.Sprintf("\tresp, err := client.%s(ctx, %s.%sRequest{Lat: 0, Lng: 0, Radius: 1000})\n", funcName, pkgName, funcName))
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString(fmt.Sprintf("\t\tt.Fatalf(\"%s failed: %%v\", err)\n", funcName))
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif len(resp.Items) != 0 {\n")
+	buf.WriteString("\t\tt.Errorf(\"expected no items, got %d\", len(resp.Items))\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	// TestNear_InvalidRadius
+	buf.WriteString(fmt.Sprintf("func Test%s_InvalidRadius(t *testing.T) {\n", funcName))
+	writeTestSetup(&buf, cfg)
+	buf.WriteString("\n")
+	buf.WriteString(fmt.Sprintf("\t_, err := client.%s(ctx, %s.%sRequest{Lat: 37.7749, Lng: -122.4194, Radius: -1})\n", funcName, pkgName, funcName))
+	buf.WriteString("\tif err == nil {\n")
+	buf.WriteString("\t\tt.Error(\"expected error for negative radius\")\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n\n")
+
+	// TestNear_Unauthenticated (if auth required)
+	if cfg.RequireAuth {
+		buf.WriteString(fmt.Sprintf("func Test%s_Unauthenticated(t *testing.T) {\n", funcName))
+		writeUnauthTestSetup(&buf, cfg)
+		buf.WriteString(fmt.Sprintf("\t_, nearErr := unauthClient.%s(ctx, %s.%sRequest{Lat: 0, Lng: 0, Radius: 1000})\n", funcName, pkgName, funcName))
+		buf.WriteString("\tif nearErr == nil {\n")
+		buf.WriteString("\t\tt.Error(\"expected error for unauthenticated request\")\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("}\n\n")
+	}
+
+	return formatSource(buf.Bytes())
+}
+
 // ---- Shared helpers ----
 
 // GenerateTestHelpers generates helpers_test.go with TestMain, DB setup, and
@@ -517,6 +759,7 @@ func GenerateSoftDeleteTest(cfg PerOpTestGenConfig) ([]byte, error) {
 // test package.
 func GenerateTestHelpers(cfg PerOpTestGenConfig) ([]byte, error) {
 	var buf bytes.Buffer
+	isSQLite := cfg.Dialect == "sqlite"
 
 	buf.WriteString("// Code generated by shipq.\n")
 	buf.WriteString("package spec\n\n")
@@ -526,6 +769,9 @@ func GenerateTestHelpers(cfg PerOpTestGenConfig) ([]byte, error) {
 	buf.WriteString("\t\"context\"\n")
 	buf.WriteString("\t\"database/sql\"\n")
 	buf.WriteString("\t\"os\"\n")
+	if isSQLite {
+		buf.WriteString("\t\"strings\"\n")
+	}
 	buf.WriteString("\t\"testing\"\n")
 	if cfg.RequireAuth {
 		buf.WriteString("\t\"time\"\n")
@@ -535,6 +781,9 @@ func GenerateTestHelpers(cfg PerOpTestGenConfig) ([]byte, error) {
 	buf.WriteString("\n")
 	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/api"))
 	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/config"))
+	if isSQLite {
+		buf.WriteString(fmt.Sprintf("\tdbmigrate %q\n", cfg.ModulePath+"/shipq/db/migrate"))
+	}
 	if cfg.RequireAuth {
 		buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/shipq/lib/crypto"))
 		buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/shipq/lib/nanoid"))
@@ -568,6 +817,20 @@ func GenerateTestHelpers(cfg PerOpTestGenConfig) ([]byte, error) {
 	buf.WriteString("\t\tpanic(\"failed to connect to test database: \" + err.Error())\n")
 	buf.WriteString("\t}\n")
 	buf.WriteString("\tdefer testDB.Close()\n\n")
+	if isSQLite {
+		// TEST_DATABASE_URL=sqlite://:memory:?cache=shared gives each test
+		// binary a fresh database with no files to clean up.
+		buf.WriteString("\t// An in-memory database (sqlite://:memory:?cache=shared) starts empty and\n")
+		buf.WriteString("\t// lives only while a connection is open: keep one pinned connection and\n")
+		buf.WriteString("\t// apply the migrations before running the tests.\n")
+		buf.WriteString("\tif strings.HasPrefix(dsn, \"file::memory:\") {\n")
+		buf.WriteString("\t\ttestDB.SetMaxOpenConns(1)\n")
+		buf.WriteString("\t\ttestDB.SetMaxIdleConns(1)\n")
+		buf.WriteString("\t\tif err := dbmigrate.RunWithDB(context.Background(), testDB); err != nil {\n")
+		buf.WriteString("\t\t\tpanic(\"failed to migrate in-memory test database: \" + err.Error())\n")
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n\n")
+	}
 	buf.WriteString("\tos.Exit(m.Run())\n")
 	buf.WriteString("}\n\n")
 
@@ -673,6 +936,10 @@ func writeSimpleTestImports(buf *bytes.Buffer, cfg PerOpTestGenConfig, needsFixt
 		// Scoped resources create via the HTTP client instead.
 		buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/api/"+cfg.TableName+"/fixture"))
 	}
+	if needsFixture && cfg.ScopeColumn != "" {
+		// Scoped resources set sample values in writeScopedCreateHelper.
+		writeCustomSampleImports(buf, cfg.Table)
+	}
 	// FK dep fixtures (both nullable deps and non-nullable deps for scoped resources)
 	for _, col := range cfg.Table.Columns {
 		if col.References == "" || isFixtureAutoColumn(col.Name) {
@@ -703,6 +970,7 @@ func writeCreateTestImports(buf *bytes.Buffer, cfg PerOpTestGenConfig) {
 	}
 	buf.WriteString("\t\"testing\"\n\n")
 	buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/api/"+cfg.TableName))
+	writeCustomSampleImports(buf, cfg.Table)
 	// All FK dep fixtures (create needs them to set up dependencies)
 	// Scope column deps are excluded -- the auth middleware provides the org ID
 	for _, col := range cfg.Table.Columns {
@@ -730,6 +998,7 @@ func writeUpdateTestImports(buf *bytes.Buffer, cfg PerOpTestGenConfig) {
 	if cfg.ScopeColumn == "" {
 		buf.WriteString(fmt.Sprintf("\t%q\n", cfg.ModulePath+"/api/"+cfg.TableName+"/fixture"))
 	}
+	writeCustomSampleImports(buf, cfg.Table)
 	// FK dep fixtures (both nullable and non-nullable needed for update)
 	// Scope column deps are excluded -- the auth middleware provides the org ID
 	for _, col := range cfg.Table.Columns {
@@ -795,14 +1064,14 @@ func writeCreateRequestWithOptionalFK(buf *bytes.Buffer, cfg PerOpTestGenConfig,
 			}
 			continue
 		}
-		if col.Nullable {
+		if omittedFromCreate(cfg, col) {
 			continue
 		}
 		if col.References != "" {
 			depSingular := dbstrings.ToSingular(col.References)
 			buf.WriteString(fmt.Sprintf("\t\t%s: %sDep.PublicId,\n", fieldName, depSingular))
 		} else {
-			sampleVal := getSampleValue(goBaseTypeForFixture(col.Type), col.Name)
+			sampleVal := sampleValueForColumn(col)
 			buf.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fieldName, sampleVal))
 		}
 	}
@@ -822,7 +1091,7 @@ func writeScopedCreateHelper(buf *bytes.Buffer, cfg PerOpTestGenConfig) {
 	buf.WriteString("\t\tt.Helper()\n")
 	buf.WriteString(fmt.Sprintf("\t\tresp, err := client.Create%s(ctx, %s.Create%sRequest{\n", res, pkgName, res))
 	for _, col := range cfg.Table.Columns {
-		if isFixtureAutoColumn(col.Name) || col.Nullable {
+		if isFixtureAutoColumn(col.Name) || omittedFromCreate(cfg, col) {
 			continue
 		}
 		if cfg.ScopeColumn != "" && col.Name == cfg.ScopeColumn {
@@ -833,7 +1102,7 @@ func writeScopedCreateHelper(buf *bytes.Buffer, cfg PerOpTestGenConfig) {
 			depSingular := dbstrings.ToSingular(col.References)
 			buf.WriteString(fmt.Sprintf("\t\t\t%s: %sDep.PublicId,\n", fieldName, depSingular))
 		} else {
-			sampleVal := getSampleValue(goBaseTypeForFixture(col.Type), col.Name)
+			sampleVal := sampleValueForColumn(col)
 			buf.WriteString(fmt.Sprintf("\t\t\t%s: %s,\n", fieldName, sampleVal))
 		}
 	}
@@ -863,7 +1132,7 @@ func writeCreateDeps(buf *bytes.Buffer, cfg PerOpTestGenConfig) {
 }
 
 func formatSource(src []byte) ([]byte, error) {
-	formatted, err := format.Source(src)
+	formatted, err := phase.FormatSource(src)
 	if err != nil {
 		return src, fmt.Errorf("failed to format test code: %w\nunformatted:\n%s", err, string(src))
 	}
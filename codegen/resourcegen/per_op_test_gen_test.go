@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 	"github.com/shipq/shipq/db/portsql/ddl"
 )
 
@@ -184,6 +185,41 @@ func TestGenerateCreateTest_NoJSONColumn_OmitsEncodingJSON(t *testing.T) {
 	}
 }
 
+func TestGenerateCreateTest_DefaultedColumnsOmitted(t *testing.T) {
+	cfg := PerOpTestGenConfig{
+		ModulePath: "myapp",
+		TableName:  "pets",
+		Table: ddl.Table{
+			Name: "pets",
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+				{Name: "public_id", Type: ddl.StringType},
+				{Name: "name", Type: ddl.StringType},
+				{Name: "species", Type: ddl.StringType},
+				{Name: "created_at", Type: ddl.DatetimeType},
+				{Name: "updated_at", Type: ddl.DatetimeType},
+				{Name: "deleted_at", Type: ddl.DatetimeType, Nullable: true},
+			},
+		},
+		Schema:          map[string]ddl.Table{},
+		Dialect:         "sqlite",
+		TestDatabaseURL: "file::memory:?cache=shared",
+		Defaults:        map[string]string{"species": "cat"},
+	}
+
+	result, err := GenerateCreateTest(cfg)
+	if err != nil {
+		t.Fatalf("GenerateCreateTest failed: %v", err)
+	}
+	code := string(result)
+	if !strings.Contains(code, "Name: ") {
+		t.Errorf("expected the name field in the create request:\n%s", code)
+	}
+	if strings.Contains(code, "Species:") {
+		t.Errorf("a defaulted column should be left to the handler's default:\n%s", code)
+	}
+}
+
 func TestGenerateUpdateTest_JSONColumn_ImportsEncodingJSON(t *testing.T) {
 	cfg := PerOpTestGenConfig{
 		ModulePath: "myapp",
@@ -392,3 +428,191 @@ func TestGenerateTestHelpers_UniqueEmail(t *testing.T) {
 		t.Error("organization name should include a unique suffix via nanoid")
 	}
 }
+
+func TestGenerateImportTest(t *testing.T) {
+	cfg := PerOpTestGenConfig{
+		ModulePath: "myapp",
+		TableName:  "posts",
+		Table: ddl.Table{
+			Name: "posts",
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+				{Name: "public_id", Type: ddl.StringType},
+				{Name: "organization_id", Type: ddl.BigintType, References: "organizations"},
+				{Name: "title", Type: ddl.StringType},
+				{Name: "views", Type: ddl.IntegerType},
+				{Name: "created_at", Type: ddl.DatetimeType},
+			},
+		},
+		Schema:      map[string]ddl.Table{},
+		RequireAuth: true,
+		Dialect:     "sqlite",
+		ScopeColumn: "organization_id",
+	}
+
+	result, err := GenerateImportTest(cfg)
+	if err != nil {
+		t.Fatalf("GenerateImportTest failed: %v", err)
+	}
+	code := string(result)
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "", result, parser.AllErrors); err != nil {
+		t.Fatalf("generated code is not valid Go: %v\n%s", err, code)
+	}
+	// The header lists the importable columns only; the data row has one
+	// field too many so that it is rejected.
+	if !strings.Contains(code, `"title,views\n,,\n"`) {
+		t.Errorf("expected CSV header without auto/scope columns and a malformed row, got:\n%s", code)
+	}
+	if !strings.Contains(code, `client.ImportPosts(ctx, posts.ImportPostsRequest{DryRun: true}, "text/csv", strings.NewReader(`) {
+		t.Errorf("expected the generated test to send a raw CSV body in a dry run, got:\n%s", code)
+	}
+	if !strings.Contains(code, "func TestImportPosts_Unauthenticated") {
+		t.Error("expected unauthenticated test when auth is required")
+	}
+}
+
+func TestGenerateBulkCreateTest(t *testing.T) {
+	cfg := PerOpTestGenConfig{
+		ModulePath: "myapp",
+		TableName:  "posts",
+		Table: ddl.Table{
+			Name: "posts",
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+				{Name: "public_id", Type: ddl.StringType},
+				{Name: "title", Type: ddl.StringType},
+			},
+		},
+		Schema:      map[string]ddl.Table{},
+		RequireAuth: true,
+		Dialect:     "sqlite",
+	}
+
+	result, err := GenerateBulkCreateTest(cfg)
+	if err != nil {
+		t.Fatalf("GenerateBulkCreateTest failed: %v", err)
+	}
+	code := string(result)
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "", result, parser.AllErrors); err != nil {
+		t.Fatalf("generated code is not valid Go: %v\n%s", err, code)
+	}
+	if !strings.Contains(code, "client.BulkCreatePosts(ctx, posts.BulkCreatePostsRequest{})") {
+		t.Errorf("expected an empty bulk create call, got:\n%s", code)
+	}
+	if !strings.Contains(code, "func TestBulkCreatePosts_Unauthenticated") {
+		t.Error("expected unauthenticated test when auth is required")
+	}
+}
+
+func TestGenerateRestoreTest(t *testing.T) {
+	cfg := PerOpTestGenConfig{
+		ModulePath: "myapp",
+		TableName:  "posts",
+		Table: ddl.Table{
+			Name: "posts",
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+				{Name: "public_id", Type: ddl.StringType},
+				{Name: "title", Type: ddl.StringType},
+				{Name: "deleted_at", Type: ddl.DatetimeType, Nullable: true},
+			},
+		},
+		Schema:      map[string]ddl.Table{},
+		RequireAuth: true,
+		Dialect:     "sqlite",
+	}
+
+	result, err := GenerateRestoreTest(cfg)
+	if err != nil {
+		t.Fatalf("GenerateRestoreTest failed: %v", err)
+	}
+	f := gofile.Parse(t, "restore_test.go", result)
+
+	// The success test deletes, restores and reads back, in that order,
+	// then expects a second restore to fail.
+	fn := "TestRestorePost_Success"
+	deleteStmt := "if _, err := client.SoftDeletePost(ctx, posts.SoftDeletePostRequest{ID: created.PublicId}); err != nil"
+	restoreStmt := "if _, err := client.RestorePost(ctx, posts.RestorePostRequest{ID: created.PublicId}); err != nil"
+	getStmt := "if _, err := client.GetPost(ctx, posts.GetPostRequest{ID: created.PublicId}); err != nil"
+	if !f.Before(fn, deleteStmt, restoreStmt) || !f.Before(fn, restoreStmt, getStmt) {
+		t.Errorf("%s should delete, restore, then get:\n%s", fn, result)
+	}
+	f.AssertStmts(fn, "if _, err := client.RestorePost(ctx, posts.RestorePostRequest{ID: created.PublicId}); err == nil")
+	f.AssertStmts("TestRestorePost_NotFound", `_, restoreErr := client.RestorePost(ctx, posts.RestorePostRequest{ID: "nonexistent"})`)
+	f.AssertStmts("TestRestorePost_Unauthenticated", `_, restoreErr := unauthClient.RestorePost(ctx, posts.RestorePostRequest{ID: "any"})`)
+}
+
+func TestGenerateNearTest(t *testing.T) {
+	cfg := PerOpTestGenConfig{
+		ModulePath: "myapp",
+		TableName:  "stores",
+		Table: ddl.Table{
+			Name: "stores",
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+				{Name: "public_id", Type: ddl.StringType},
+				{Name: "name", Type: ddl.StringType},
+				{Name: "location", Type: ddl.PointType},
+				{Name: "created_at", Type: ddl.DatetimeType},
+			},
+		},
+		Schema:      map[string]ddl.Table{},
+		RequireAuth: false,
+		Dialect:     "sqlite",
+	}
+
+	result, err := GenerateNearTest(cfg)
+	if err != nil {
+		t.Fatalf("GenerateNearTest failed: %v", err)
+	}
+	f := gofile.Parse(t, "near_test.go", result)
+	f.AssertStmts("TestListStoresNear_EmptyArea",
+		"resp, err := client.ListStoresNear(ctx, stores.ListStoresNearRequest{Lat: 0, Lng: 0, Radius: 1000})",
+		"if len(resp.Items) != 0",
+	)
+	f.AssertStmts("TestListStoresNear_InvalidRadius",
+		"_, err := client.ListStoresNear(ctx, stores.ListStoresNearRequest{Lat: 37.7749, Lng: -122.4194, Radius: -1})",
+		"if err == nil",
+	)
+	if f.HasFunc("TestListStoresNear_Unauthenticated") {
+		t.Error("public routes must not get an unauthenticated test")
+	}
+}
+
+func TestGenerateTestHelpers_SQLiteInMemory(t *testing.T) {
+	cfg := PerOpTestGenConfig{
+		ModulePath:      "myapp",
+		TableName:       "posts",
+		Table:           ddl.Table{Name: "posts"},
+		Schema:          map[string]ddl.Table{},
+		Dialect:         "sqlite",
+		TestDatabaseURL: "sqlite://:memory:?cache=shared",
+	}
+
+	result, err := GenerateTestHelpers(cfg)
+	if err != nil {
+		t.Fatalf("GenerateTestHelpers failed: %v", err)
+	}
+	f := gofile.Parse(t, "helpers_test.go", result)
+	if !f.HasImport("myapp/shipq/db/migrate") {
+		t.Error("expected the migrate package to be imported")
+	}
+	// An in-memory database needs one connection and is migrated in place.
+	f.AssertStmts("TestMain",
+		`if strings.HasPrefix(dsn, "file::memory:")`,
+		"testDB.SetMaxOpenConns(1)",
+		"if err := dbmigrate.RunWithDB(context.Background(), testDB); err != nil",
+	)
+
+	cfg.Dialect = "postgres"
+	cfg.TestDatabaseURL = "postgres://localhost:5432/myapp_test"
+	result, err = GenerateTestHelpers(cfg)
+	if err != nil {
+		t.Fatalf("GenerateTestHelpers failed: %v", err)
+	}
+	if gofile.Parse(t, "helpers_test.go", result).HasImport("myapp/shipq/db/migrate") {
+		t.Error("postgres helpers should not migrate in TestMain")
+	}
+}
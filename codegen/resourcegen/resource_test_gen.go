@@ -3,10 +3,10 @@ package resourcegen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/dbstrings"
 )
 
@@ -43,7 +43,7 @@ func GenerateResourceTest(cfg ResourceTestGenConfig, resource ResourceInfo) ([]b
 		generateUnauthenticatedTest(&buf, cfg, resource)
 	}
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted source for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format resource test code: %w\nunformatted:\n%s", err, buf.String())
@@ -169,7 +169,7 @@ func generateCreateSection(buf *bytes.Buffer, resource ResourceInfo) {
 		// Generate sample values for required fields
 		for _, field := range resource.CreateHandler.Request.Fields {
 			if field.Required && !isIDField(field.JSONName) {
-				sampleValue := getSampleValue(field.Type, field.Name)
+				sampleValue := sampleValueForField(field)
 				fmt.Fprintf(buf, "\t\t%s: %s,\n", field.Name, sampleValue)
 			}
 		}
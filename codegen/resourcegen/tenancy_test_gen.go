@@ -3,8 +3,8 @@ package resourcegen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/dbstrings"
 )
 
@@ -62,7 +62,7 @@ func GenerateTenancyTest(cfg TenancyTestGenConfig, resource ResourceInfo) ([]byt
 	if resource.CreateHandler.Request != nil {
 		for _, field := range resource.CreateHandler.Request.Fields {
 			if field.Required && !isIDField(field.JSONName) {
-				sampleValue := getSampleValue(field.Type, field.Name)
+				sampleValue := sampleValueForField(field)
 				fmt.Fprintf(&buf, "\t\t%s: %s,\n", field.Name, sampleValue)
 			}
 		}
@@ -141,7 +141,7 @@ func GenerateTenancyTest(cfg TenancyTestGenConfig, resource ResourceInfo) ([]byt
 
 	buf.WriteString("}\n")
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format tenancy test code: %w\nunformatted:\n%s", err, buf.String())
 	}
@@ -3,7 +3,9 @@ package seedgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
+	"github.com/shipq/shipq/dbstrings"
 )
 
 // SeedGenConfig holds configuration for generating seed files.
@@ -137,7 +139,56 @@ func strPtr(s string) *string {
 }
 `)
 
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("failed to format seed code: %w\n%s", err, buf.String())
+	}
+	return formatted, nil
+}
+
+// GenerateSeedScaffold generates an empty seed file defining Seed_<name>,
+// for "shipq seed new". The rows are inserted by a helper that takes the
+// queries.Runner interface, so it works against any dialect's runner.
+func GenerateSeedScaffold(cfg SeedGenConfig, name string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	funcName := "Seed_" + name
+	helper := "seed" + dbstrings.ToPascalCase(name)
+
+	buf.WriteString("package seeds\n\n")
+	buf.WriteString("import (\n")
+	buf.WriteString("\t\"context\"\n")
+	buf.WriteString("\t\"database/sql\"\n")
+	buf.WriteString("\t\"fmt\"\n\n")
+	fmt.Fprintf(&buf, "\t%q\n", cfg.ModulePath+"/shipq/queries")
+	fmt.Fprintf(&buf, "\tdbrunner %q\n", cfg.ModulePath+"/shipq/queries/"+cfg.Dialect)
+	buf.WriteString(")\n\n")
+
+	fmt.Fprintf(&buf, "// %s runs %s in a transaction. `shipq seed` runs every seed on each\n", funcName, helper)
+	buf.WriteString("// invocation, so keep it idempotent: look rows up before inserting them.\n")
+	fmt.Fprintf(&buf, "func %s(db *sql.DB) error {\n", funcName)
+	buf.WriteString("\ttx, err := db.Begin()\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn fmt.Errorf(\"failed to begin transaction: %w\", err)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tdefer tx.Rollback()\n\n")
+	fmt.Fprintf(&buf, "\tif err := %s(context.Background(), dbrunner.NewQueryRunner(tx)); err != nil {\n", helper)
+	buf.WriteString("\t\treturn err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn tx.Commit()\n")
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(&buf, "// %s inserts the seed rows with the generated query methods, e.g.\n", helper)
+	buf.WriteString("//\n")
+	buf.WriteString("//\t_, err := runner.CreatePost(ctx, queries.CreatePostParams{Title: \"Hello\"})\n")
+	buf.WriteString("//\tif err != nil {\n")
+	buf.WriteString("//\t\treturn fmt.Errorf(\"failed to create post: %w\", err)\n")
+	buf.WriteString("//\t}\n")
+	fmt.Fprintf(&buf, "func %s(ctx context.Context, runner queries.Runner) error {\n", helper)
+	buf.WriteString("\treturn nil\n")
+	buf.WriteString("}\n")
+
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format seed code: %w\n%s", err, buf.String())
 	}
@@ -0,0 +1,20 @@
+package codegen
+
+import "runtime/debug"
+
+// shipqVersion is set at release time with
+// -ldflags "-X github.com/shipq/shipq/codegen.shipqVersion=v1.2.3".
+var shipqVersion = ""
+
+// ShipqVersion returns the version of the running shipq generator. Release
+// builds use the ldflags value; `go install ...@version` builds fall back to
+// the module version recorded in the binary, and local builds to "(devel)".
+func ShipqVersion() string {
+	if shipqVersion != "" {
+		return shipqVersion
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
@@ -1,8 +1,15 @@
 package config
 
 import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
+	"github.com/shipq/shipq/dbstrings"
 	"github.com/shipq/shipq/inifile"
 )
 
@@ -34,6 +41,491 @@ func ParseServerConfig(ini *inifile.File) *ServerConfig {
 	}
 }
 
+// LoggingConfig holds the [logging] section from shipq.ini, which controls
+// access-log sampling and slow-request capture in the generated server.
+type LoggingConfig struct {
+	// SampleRate is the fraction of successful requests that are logged.
+	// Failed requests are always logged. Defaults to 1 (log everything).
+	SampleRate float64
+	// SlowThresholdMs marks requests taking at least this many milliseconds
+	// as slow; their bodies are kept in an in-memory ring buffer exposed at
+	// GET /__debug/slow-requests. Zero disables capture.
+	SlowThresholdMs int
+	// SlowBufferSize is the number of slow requests retained. Defaults to 100.
+	SlowBufferSize int
+}
+
+// ParseLoggingConfig extracts the [logging] section from a parsed INI file.
+// Returns nil (not an error) when the [logging] section is absent.
+func ParseLoggingConfig(ini *inifile.File) (*LoggingConfig, error) {
+	section := ini.Section("logging")
+	if section == nil {
+		return nil, nil
+	}
+
+	cfg := &LoggingConfig{SampleRate: 1, SlowBufferSize: 100}
+
+	if raw := strings.TrimSpace(section.Get("sample_rate")); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate <= 0 || rate > 1 {
+			return nil, fmt.Errorf("[logging] sample_rate must be greater than 0 and at most 1, got %q", raw)
+		}
+		cfg.SampleRate = rate
+	}
+
+	if raw := strings.TrimSpace(section.Get("slow_threshold_ms")); raw != "" {
+		ms, err := strconv.Atoi(raw)
+		if err != nil || ms < 0 {
+			return nil, fmt.Errorf("[logging] slow_threshold_ms must be a non-negative integer, got %q", raw)
+		}
+		cfg.SlowThresholdMs = ms
+	}
+
+	if raw := strings.TrimSpace(section.Get("slow_buffer_size")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("[logging] slow_buffer_size must be a positive integer, got %q", raw)
+		}
+		cfg.SlowBufferSize = n
+	}
+
+	return cfg, nil
+}
+
+// DefaultDatabaseTLSEnvs are the GO_ENV values in which the generated server
+// and worker refuse to connect to a database without verified TLS, unless
+// [db] require_tls says otherwise.
+var DefaultDatabaseTLSEnvs = []string{"production"}
+
+// ParseDatabaseTLSEnvs returns the GO_ENV values listed in [db] require_tls
+// (e.g. "production, staging"). An absent key yields DefaultDatabaseTLSEnvs;
+// "none" or an empty value yields an empty, non-nil slice, which turns the
+// check off.
+func ParseDatabaseTLSEnvs(ini *inifile.File) []string {
+	section := ini.Section("db")
+	if section == nil || !section.HasKey("require_tls") {
+		return DefaultDatabaseTLSEnvs
+	}
+	raw := strings.TrimSpace(section.Get("require_tls"))
+	if strings.EqualFold(raw, "none") {
+		return []string{}
+	}
+	envs := parseCommaSeparatedList(raw)
+	if envs == nil {
+		return []string{}
+	}
+	return envs
+}
+
+// DatabasePoolConfig holds the sql.DB connection pool settings from [db] in
+// shipq.ini, as written there. An empty field leaves the database/sql
+// default in place.
+type DatabasePoolConfig struct {
+	MaxOpenConns    string // [db] max_open_conns
+	MaxIdleConns    string // [db] max_idle_conns
+	ConnMaxLifetime string // [db] conn_max_lifetime, a Go duration such as "30m"
+}
+
+// ParseDatabasePoolConfig reads and validates the [db] pool settings. The
+// connection counts must be non-negative integers and the lifetime a
+// non-negative Go duration.
+func ParseDatabasePoolConfig(ini *inifile.File) (DatabasePoolConfig, error) {
+	cfg := DatabasePoolConfig{
+		MaxOpenConns:    strings.TrimSpace(ini.Get("db", "max_open_conns")),
+		MaxIdleConns:    strings.TrimSpace(ini.Get("db", "max_idle_conns")),
+		ConnMaxLifetime: strings.TrimSpace(ini.Get("db", "conn_max_lifetime")),
+	}
+	for key, raw := range map[string]string{"max_open_conns": cfg.MaxOpenConns, "max_idle_conns": cfg.MaxIdleConns} {
+		if raw == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(raw); err != nil || n < 0 {
+			return DatabasePoolConfig{}, fmt.Errorf("[db] %s must be a non-negative integer, got %q", key, raw)
+		}
+	}
+	if raw := cfg.ConnMaxLifetime; raw != "" {
+		if d, err := time.ParseDuration(raw); err != nil || d < 0 {
+			return DatabasePoolConfig{}, fmt.Errorf("[db] conn_max_lifetime must be a duration such as 30m, got %q", raw)
+		}
+	}
+	return cfg, nil
+}
+
+// Serializers are the body formats [server] serializers may list, besides
+// JSON which is always supported.
+var Serializers = []string{"msgpack", "cbor"}
+
+// ParseSerializers returns the formats listed in [server] serializers
+// (e.g. "msgpack, cbor"), lowercased and deduplicated. The generated
+// handlers negotiate them through Accept and Content-Type. Returns nil when
+// the key is absent, so handlers speak only JSON.
+func ParseSerializers(ini *inifile.File) ([]string, error) {
+	var out []string
+	for _, name := range parseCommaSeparatedList(ini.Get("server", "serializers")) {
+		name = strings.ToLower(name)
+		if !slices.Contains(Serializers, name) {
+			return nil, fmt.Errorf("[server] serializers: unknown format %q (supported: %s)", name, strings.Join(Serializers, ", "))
+		}
+		if !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
+
+// ParseTelemetry reports whether [server] telemetry = otel, which makes the
+// generated server and query runner trace requests and queries with
+// OpenTelemetry. An absent key or "none" turns tracing off.
+func ParseTelemetry(ini *inifile.File) (bool, error) {
+	switch raw := strings.ToLower(strings.TrimSpace(ini.Get("server", "telemetry"))); raw {
+	case "", "none":
+		return false, nil
+	case "otel":
+		return true, nil
+	default:
+		return false, fmt.Errorf("[server] telemetry must be otel or none, got %q", raw)
+	}
+}
+
+// AuthSchemes are the values [server] auth accepts: "cookie" (the default)
+// authenticates requests by the session cookie alone, "bearer" also by an
+// Authorization: Bearer header carrying the token login returns.
+var AuthSchemes = []string{"cookie", "bearer"}
+
+// ParseAuthScheme returns [server] auth, lowercased, or "cookie" when the
+// key is absent.
+func ParseAuthScheme(ini *inifile.File) (string, error) {
+	raw := strings.ToLower(strings.TrimSpace(ini.Get("server", "auth")))
+	if raw == "" {
+		return "cookie", nil
+	}
+	if !slices.Contains(AuthSchemes, raw) {
+		return "", fmt.Errorf("[server] auth: unknown scheme %q (supported: %s)", raw, strings.Join(AuthSchemes, ", "))
+	}
+	return raw, nil
+}
+
+// OpenAPISecuritySchemes are the schemes [openapi] security may list. They
+// describe the credentials the auth middleware accepts: "cookie" is the
+// session cookie set by shipq auth, "bearer" an Authorization: Bearer token
+// and "apikey" a key sent in a request header.
+var OpenAPISecuritySchemes = []string{"cookie", "bearer", "apikey"}
+
+// OpenAPIServer is one base URL listed in the [openapi.servers] section.
+type OpenAPIServer struct {
+	// Env is the environment the server belongs to, e.g. "production".
+	Env string
+	// URL is the server's base URL, e.g. "https://api.example.com".
+	URL string
+}
+
+// OpenAPIConfig holds the [openapi] and [openapi.servers] sections from
+// shipq.ini, which shape the servers and securitySchemes of the generated
+// OpenAPI document.
+type OpenAPIConfig struct {
+	// Servers lists the per-environment base URLs, in file order.
+	Servers []OpenAPIServer
+	// Security lists the schemes applied to authenticated operations.
+	// Defaults to ["cookie"].
+	Security []string
+	// APIKeyHeader is the header carrying the "apikey" scheme's key.
+	// Defaults to "X-API-Key".
+	APIKeyHeader string
+	// Baseline is the path, relative to the project root, of the last
+	// released OpenAPI document. When set, compile generates a test that
+	// fails if the current document breaks clients of the baseline.
+	Baseline string
+	// DocsMask masks the values of Sensitive columns, DocsMaskFields and
+	// auth tokens in responses shown by the docs page's "try it" mode.
+	DocsMask bool
+	// DocsMaskFields lists extra JSON fields to mask, e.g. "phone".
+	DocsMaskFields []string
+	// DocsReadOnly makes the docs page refuse to send requests other than
+	// GET, HEAD and OPTIONS, for docs served in shared environments.
+	DocsReadOnly bool
+	// SpecVersion is the OpenAPI version of the document, one of
+	// OpenAPIVersions. Defaults to "3.0".
+	SpecVersion string
+}
+
+// OpenAPIVersions are the values [openapi] spec_version accepts. A 3.1
+// document moves handler request and response structs into
+// components.schemas and writes nullable types as JSON Schema type arrays;
+// a 3.0 document inlines every schema and marks them nullable.
+var OpenAPIVersions = []string{"3.0", "3.1"}
+
+// ParseOpenAPIConfig extracts the [openapi] and [openapi.servers] sections
+// from a parsed INI file. Returns nil (not an error) when both are absent,
+// unless [server] auth = bearer, which adds "bearer" to Security so the
+// document describes the tokens the server accepts.
+//
+// Example shipq.ini:
+//
+//	[openapi]
+//	security = cookie, bearer
+//	baseline = openapi/released.json
+//	docs_mask = true
+//	docs_mask_fields = phone, date_of_birth
+//	docs_read_only = true
+//	spec_version = 3.1
+//
+//	[openapi.servers]
+//	development = http://localhost:8080
+//	production = https://api.example.com
+func ParseOpenAPIConfig(ini *inifile.File) (*OpenAPIConfig, error) {
+	section := ini.Section("openapi")
+	serversSection := ini.Section("openapi.servers")
+	authScheme, err := ParseAuthScheme(ini)
+	if err != nil {
+		return nil, err
+	}
+	if section == nil && serversSection == nil && authScheme != "bearer" {
+		return nil, nil
+	}
+
+	cfg := &OpenAPIConfig{Security: []string{"cookie"}, APIKeyHeader: "X-API-Key", SpecVersion: "3.0"}
+
+	if serversSection != nil {
+		for _, kv := range serversSection.Values {
+			url := strings.TrimRight(strings.TrimSpace(kv.Value), "/")
+			if url == "" {
+				return nil, fmt.Errorf("[openapi.servers] %s: URL must not be empty", kv.Key)
+			}
+			cfg.Servers = append(cfg.Servers, OpenAPIServer{Env: kv.Key, URL: url})
+		}
+	}
+
+	if section != nil {
+		if section.HasKey("security") {
+			cfg.Security = nil
+			for _, name := range parseCommaSeparatedList(section.Get("security")) {
+				name = strings.ToLower(name)
+				if !slices.Contains(OpenAPISecuritySchemes, name) {
+					return nil, fmt.Errorf("[openapi] security: unknown scheme %q (supported: %s)", name, strings.Join(OpenAPISecuritySchemes, ", "))
+				}
+				if !slices.Contains(cfg.Security, name) {
+					cfg.Security = append(cfg.Security, name)
+				}
+			}
+		}
+		if header := strings.TrimSpace(section.Get("api_key_header")); header != "" {
+			cfg.APIKeyHeader = header
+		}
+		if baseline := strings.TrimSpace(section.Get("baseline")); baseline != "" {
+			if filepath.IsAbs(baseline) {
+				return nil, fmt.Errorf("[openapi] baseline: %q must be relative to the project root", baseline)
+			}
+			cfg.Baseline = filepath.Clean(baseline)
+		}
+		cfg.DocsMask = strings.ToLower(strings.TrimSpace(section.Get("docs_mask"))) == "true"
+		cfg.DocsMaskFields = parseCommaSeparatedList(section.Get("docs_mask_fields"))
+		cfg.DocsReadOnly = strings.ToLower(strings.TrimSpace(section.Get("docs_read_only"))) == "true"
+		if version := strings.TrimSpace(section.Get("spec_version")); version != "" {
+			if !slices.Contains(OpenAPIVersions, version) {
+				return nil, fmt.Errorf("[openapi] spec_version: unsupported version %q (supported: %s)", version, strings.Join(OpenAPIVersions, ", "))
+			}
+			cfg.SpecVersion = version
+		}
+	}
+	if authScheme == "bearer" && !slices.Contains(cfg.Security, "bearer") {
+		cfg.Security = append(cfg.Security, "bearer")
+	}
+
+	return cfg, nil
+}
+
+// PathSegmentStyles are the values [naming] path_segments accepts: "plural"
+// routes a table's resources at its name (/posts/:id), "singular" at the
+// singular of it (/post/:id).
+var PathSegmentStyles = []string{"plural", "singular"}
+
+// OperationIDCases are the values [naming] operation_id_case accepts.
+var OperationIDCases = []string{"pascal", "camel", "snake", "kebab"}
+
+// JSONCases are the values [naming] json_case accepts: "snake" keeps the
+// JSON names of generated request and response fields as the column names
+// (first_name), "camel" camel-cases them (firstName).
+var JSONCases = []string{"snake", "camel"}
+
+// OperationIDPlaceholders are the placeholders an [naming] operation_id
+// template may use: the handler function name ("CreatePost"), the handler's
+// resource, i.e. its package name ("posts"), and the lowercase HTTP method
+// ("post").
+var OperationIDPlaceholders = []string{"func", "resource", "method"}
+
+// NamingConfig holds the [naming] section from shipq.ini: the conventions
+// generated routes and OpenAPI operations are named by. A nil *NamingConfig
+// applies the defaults.
+type NamingConfig struct {
+	// PathSegments is "plural" (the default) or "singular".
+	PathSegments string
+	// OperationIDTemplate is the template OpenAPI operationIds are rendered
+	// from, e.g. "{resource}_{func}". Defaults to "{func}".
+	OperationIDTemplate string
+	// OperationIDCase re-cases the rendered operationId. Empty keeps it as
+	// rendered.
+	OperationIDCase string
+	// JSONCase is the case of the JSON names of generated CRUD request and
+	// response fields: "snake" (the default) or "camel".
+	JSONCase string
+}
+
+// ParseNamingConfig extracts the [naming] section from a parsed INI file.
+// Returns nil (not an error) when the section is absent.
+//
+// Example shipq.ini:
+//
+//	[naming]
+//	path_segments = singular
+//	operation_id = {method}_{resource}_{func}
+//	operation_id_case = snake
+//	json_case = camel
+func ParseNamingConfig(ini *inifile.File) (*NamingConfig, error) {
+	section := ini.Section("naming")
+	if section == nil {
+		return nil, nil
+	}
+
+	cfg := &NamingConfig{PathSegments: "plural", OperationIDTemplate: "{func}", JSONCase: "snake"}
+	if v := strings.ToLower(strings.TrimSpace(section.Get("path_segments"))); v != "" {
+		if !slices.Contains(PathSegmentStyles, v) {
+			return nil, fmt.Errorf("[naming] path_segments: unknown style %q (supported: %s)", v, strings.Join(PathSegmentStyles, ", "))
+		}
+		cfg.PathSegments = v
+	}
+	if v := strings.TrimSpace(section.Get("operation_id")); v != "" {
+		if err := checkOperationIDTemplate(v); err != nil {
+			return nil, fmt.Errorf("[naming] operation_id: %w", err)
+		}
+		cfg.OperationIDTemplate = v
+	}
+	if v := strings.ToLower(strings.TrimSpace(section.Get("operation_id_case"))); v != "" {
+		if !slices.Contains(OperationIDCases, v) {
+			return nil, fmt.Errorf("[naming] operation_id_case: unknown case %q (supported: %s)", v, strings.Join(OperationIDCases, ", "))
+		}
+		cfg.OperationIDCase = v
+	}
+	if v := strings.ToLower(strings.TrimSpace(section.Get("json_case"))); v != "" {
+		if !slices.Contains(JSONCases, v) {
+			return nil, fmt.Errorf("[naming] json_case: unknown case %q (supported: %s)", v, strings.Join(JSONCases, ", "))
+		}
+		cfg.JSONCase = v
+	}
+	return cfg, nil
+}
+
+// checkOperationIDTemplate reports an unbalanced brace or an unknown
+// placeholder in an operation_id template.
+func checkOperationIDTemplate(tmpl string) error {
+	rest := tmpl
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			return nil
+		}
+		if rest[open] == '}' {
+			return fmt.Errorf("%q has a '}' without a matching '{'", tmpl)
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return fmt.Errorf("%q has an unclosed '{'", tmpl)
+		}
+		name := rest[open+1 : open+end]
+		if !slices.Contains(OperationIDPlaceholders, name) {
+			return fmt.Errorf("unknown placeholder {%s} in %q (supported: {%s})", name, tmpl, strings.Join(OperationIDPlaceholders, "}, {"))
+		}
+		rest = rest[open+end+1:]
+	}
+}
+
+// PathSegment returns the URL path segment the generated routes of table
+// are registered under, e.g. "posts" or, with singular path segments, "post".
+func (n *NamingConfig) PathSegment(table string) string {
+	if n == nil || n.PathSegments != "singular" {
+		return table
+	}
+	return dbstrings.ToSingular(table)
+}
+
+// JSONCaseOrDefault returns the case of the JSON names of generated CRUD
+// fields, "snake" or "camel".
+func (n *NamingConfig) JSONCaseOrDefault() string {
+	if n == nil || n.JSONCase == "" {
+		return "snake"
+	}
+	return n.JSONCase
+}
+
+// OperationID renders the OpenAPI operationId of the handler funcName in the
+// resource package, served for the HTTP method.
+func (n *NamingConfig) OperationID(funcName, resource, method string) string {
+	if n == nil || n.OperationIDTemplate == "" {
+		return funcName
+	}
+	id := strings.NewReplacer(
+		"{func}", funcName,
+		"{resource}", resource,
+		"{method}", strings.ToLower(method),
+	).Replace(n.OperationIDTemplate)
+
+	words := splitWords(id)
+	for i, w := range words {
+		switch n.OperationIDCase {
+		case "pascal":
+			words[i] = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+		case "camel":
+			if i == 0 {
+				words[i] = strings.ToLower(w)
+			} else {
+				words[i] = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+			}
+		case "snake", "kebab":
+			words[i] = strings.ToLower(w)
+		default:
+			return id
+		}
+	}
+	switch n.OperationIDCase {
+	case "snake":
+		return strings.Join(words, "_")
+	case "kebab":
+		return strings.Join(words, "-")
+	default:
+		return strings.Join(words, "")
+	}
+}
+
+// splitWords splits an identifier into words at non-alphanumeric
+// characters and case changes: "posts_GetPostByID" -> posts, Get, Post,
+// By, ID.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
 // LLMConfig holds the [llm] section from shipq.ini.
 type LLMConfig struct {
 	// ToolPkgs is the list of Go import paths for packages that export
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"slices"
 	"strings"
 	"testing"
 
@@ -126,6 +127,84 @@ tool_pkgs = myapp/tools/weather
 	}
 }
 
+// ─── ParseLoggingConfig tests ───
+
+func TestParseLoggingConfig_MissingSection(t *testing.T) {
+	ini := parseINI(t, `
+[db]
+database_url = sqlite:test.db
+`)
+
+	cfg, err := ParseLoggingConfig(ini)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg != nil {
+		t.Errorf("expected nil LoggingConfig, got %+v", cfg)
+	}
+}
+
+func TestParseLoggingConfig_Defaults(t *testing.T) {
+	ini := parseINI(t, `
+[logging]
+`)
+
+	cfg, err := ParseLoggingConfig(ini)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SampleRate != 1 {
+		t.Errorf("expected SampleRate 1, got %v", cfg.SampleRate)
+	}
+	if cfg.SlowThresholdMs != 0 {
+		t.Errorf("expected SlowThresholdMs 0, got %d", cfg.SlowThresholdMs)
+	}
+	if cfg.SlowBufferSize != 100 {
+		t.Errorf("expected SlowBufferSize 100, got %d", cfg.SlowBufferSize)
+	}
+}
+
+func TestParseLoggingConfig_AllKeys(t *testing.T) {
+	ini := parseINI(t, `
+[logging]
+sample_rate = 0.01
+slow_threshold_ms = 500
+slow_buffer_size = 25
+`)
+
+	cfg, err := ParseLoggingConfig(ini)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SampleRate != 0.01 {
+		t.Errorf("expected SampleRate 0.01, got %v", cfg.SampleRate)
+	}
+	if cfg.SlowThresholdMs != 500 {
+		t.Errorf("expected SlowThresholdMs 500, got %d", cfg.SlowThresholdMs)
+	}
+	if cfg.SlowBufferSize != 25 {
+		t.Errorf("expected SlowBufferSize 25, got %d", cfg.SlowBufferSize)
+	}
+}
+
+func TestParseLoggingConfig_InvalidValues(t *testing.T) {
+	cases := map[string]string{
+		"sample_rate not a number": "sample_rate = often",
+		"sample_rate above one":    "sample_rate = 1.5",
+		"sample_rate zero":         "sample_rate = 0",
+		"negative threshold":       "slow_threshold_ms = -1",
+		"zero buffer size":         "slow_buffer_size = 0",
+	}
+	for name, line := range cases {
+		t.Run(name, func(t *testing.T) {
+			ini := parseINI(t, "[logging]\n"+line+"\n")
+			if _, err := ParseLoggingConfig(ini); err == nil {
+				t.Errorf("expected error for %q", line)
+			}
+		})
+	}
+}
+
 func TestParseLLMConfig_SinglePackage(t *testing.T) {
 	ini := parseINI(t, `
 [llm]
@@ -305,3 +384,339 @@ framework = react
 		t.Errorf("expected 'myapp/tools/search', got %q", cfg.ToolPkgs[0])
 	}
 }
+
+// ─── ParseDatabaseTLSEnvs tests ───
+
+func TestParseDatabaseTLSEnvs(t *testing.T) {
+	tests := []struct {
+		name string
+		ini  string
+		want []string
+	}{
+		{"no db section", "[server]\n", []string{"production"}},
+		{"key absent", "[db]\ndatabase_url = sqlite:test.db\n", []string{"production"}},
+		{"list", "[db]\nrequire_tls = production, staging\n", []string{"production", "staging"}},
+		{"none", "[db]\nrequire_tls = none\n", []string{}},
+		{"empty", "[db]\nrequire_tls =\n", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseDatabaseTLSEnvs(parseINI(t, tt.ini))
+			if got == nil || len(got) != len(tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %#v, want %#v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDatabasePoolConfig(t *testing.T) {
+	got, err := ParseDatabasePoolConfig(parseINI(t, "[db]\nmax_open_conns = 25\nmax_idle_conns = 0\nconn_max_lifetime = 30m\n"))
+	if err != nil {
+		t.Fatalf("ParseDatabasePoolConfig() error = %v", err)
+	}
+	want := DatabasePoolConfig{MaxOpenConns: "25", MaxIdleConns: "0", ConnMaxLifetime: "30m"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, err = ParseDatabasePoolConfig(parseINI(t, "[server]\n"))
+	if err != nil || got != (DatabasePoolConfig{}) {
+		t.Errorf("absent keys: got %+v, %v; want zero value", got, err)
+	}
+
+	for _, ini := range []string{
+		"[db]\nmax_open_conns = many\n",
+		"[db]\nmax_idle_conns = -1\n",
+		"[db]\nconn_max_lifetime = 30\n",
+		"[db]\nconn_max_lifetime = -1m\n",
+	} {
+		if _, err := ParseDatabasePoolConfig(parseINI(t, ini)); err == nil {
+			t.Errorf("expected an error for %q", ini)
+		}
+	}
+}
+
+func TestParseSerializers(t *testing.T) {
+	tests := []struct {
+		name    string
+		ini     string
+		want    []string
+		wantErr bool
+	}{
+		{"no server section", "[db]\n", nil, false},
+		{"key absent", "[server]\nstrip_prefix = /api\n", nil, false},
+		{"list", "[server]\nserializers = msgpack, CBOR\n", []string{"msgpack", "cbor"}, false},
+		{"duplicates", "[server]\nserializers = cbor,cbor\n", []string{"cbor"}, false},
+		{"unknown", "[server]\nserializers = msgpack, protobuf\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSerializers(parseINI(t, tt.ini))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTelemetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		ini     string
+		want    bool
+		wantErr bool
+	}{
+		{"no server section", "[db]\n", false, false},
+		{"none", "[server]\ntelemetry = none\n", false, false},
+		{"otel", "[server]\ntelemetry = OTel\n", true, false},
+		{"unknown", "[server]\ntelemetry = datadog\n", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTelemetry(parseINI(t, tt.ini))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAuthScheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		ini     string
+		want    string
+		wantErr bool
+	}{
+		{"no server section", "[db]\n", "cookie", false},
+		{"cookie", "[server]\nauth = cookie\n", "cookie", false},
+		{"bearer", "[server]\nauth = Bearer\n", "bearer", false},
+		{"unknown", "[server]\nauth = basic\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuthScheme(parseINI(t, tt.ini))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOpenAPIConfig(t *testing.T) {
+	t.Run("sections absent", func(t *testing.T) {
+		cfg, err := ParseOpenAPIConfig(parseINI(t, "[server]\nstrip_prefix = /api\n"))
+		if err != nil || cfg != nil {
+			t.Fatalf("got %+v, %v; want nil, nil", cfg, err)
+		}
+	})
+
+	t.Run("servers only keeps cookie default", func(t *testing.T) {
+		cfg, err := ParseOpenAPIConfig(parseINI(t, `
+[openapi.servers]
+development = http://localhost:8080
+production = https://api.example.com/
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []OpenAPIServer{
+			{Env: "development", URL: "http://localhost:8080"},
+			{Env: "production", URL: "https://api.example.com"},
+		}
+		if !slices.Equal(cfg.Servers, want) {
+			t.Errorf("Servers = %+v, want %+v", cfg.Servers, want)
+		}
+		if !slices.Equal(cfg.Security, []string{"cookie"}) {
+			t.Errorf("Security = %v, want [cookie]", cfg.Security)
+		}
+		if cfg.APIKeyHeader != "X-API-Key" {
+			t.Errorf("APIKeyHeader = %q", cfg.APIKeyHeader)
+		}
+		if cfg.SpecVersion != "3.0" {
+			t.Errorf("SpecVersion = %q, want 3.0", cfg.SpecVersion)
+		}
+	})
+
+	t.Run("security schemes", func(t *testing.T) {
+		cfg, err := ParseOpenAPIConfig(parseINI(t, `
+[openapi]
+security = Bearer, apikey, bearer
+api_key_header = X-Token
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(cfg.Security, []string{"bearer", "apikey"}) {
+			t.Errorf("Security = %v", cfg.Security)
+		}
+		if cfg.APIKeyHeader != "X-Token" {
+			t.Errorf("APIKeyHeader = %q", cfg.APIKeyHeader)
+		}
+		if cfg.Baseline != "" {
+			t.Errorf("Baseline = %q, want empty", cfg.Baseline)
+		}
+	})
+
+	t.Run("bearer auth adds the bearer scheme", func(t *testing.T) {
+		cfg, err := ParseOpenAPIConfig(parseINI(t, "[server]\nauth = bearer\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(cfg.Security, []string{"cookie", "bearer"}) {
+			t.Errorf("Security = %v, want [cookie bearer]", cfg.Security)
+		}
+
+		cfg, err = ParseOpenAPIConfig(parseINI(t, "[server]\nauth = bearer\n\n[openapi]\nsecurity = bearer\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(cfg.Security, []string{"bearer"}) {
+			t.Errorf("Security = %v, want [bearer]", cfg.Security)
+		}
+	})
+
+	t.Run("baseline", func(t *testing.T) {
+		cfg, err := ParseOpenAPIConfig(parseINI(t, "[openapi]\nbaseline = ./openapi/released.json\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Baseline != "openapi/released.json" {
+			t.Errorf("Baseline = %q", cfg.Baseline)
+		}
+	})
+
+	t.Run("docs masking and read-only", func(t *testing.T) {
+		cfg, err := ParseOpenAPIConfig(parseINI(t, "[openapi]\ndocs_mask = True\ndocs_mask_fields = phone, date_of_birth\ndocs_read_only = true\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cfg.DocsMask || !cfg.DocsReadOnly {
+			t.Errorf("DocsMask = %v, DocsReadOnly = %v; want both true", cfg.DocsMask, cfg.DocsReadOnly)
+		}
+		if !slices.Equal(cfg.DocsMaskFields, []string{"phone", "date_of_birth"}) {
+			t.Errorf("DocsMaskFields = %v", cfg.DocsMaskFields)
+		}
+	})
+
+	t.Run("spec version", func(t *testing.T) {
+		cfg, err := ParseOpenAPIConfig(parseINI(t, "[openapi]\nspec_version = 3.1\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.SpecVersion != "3.1" {
+			t.Errorf("SpecVersion = %q, want 3.1", cfg.SpecVersion)
+		}
+	})
+
+	for name, ini := range map[string]string{
+		"unknown scheme":       "[openapi]\nsecurity = cookie, oauth2\n",
+		"empty server":         "[openapi.servers]\nstaging =\n",
+		"absolute baseline":    "[openapi]\nbaseline = /tmp/openapi.json\n",
+		"unknown spec version": "[openapi]\nspec_version = 2.0\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseOpenAPIConfig(parseINI(t, ini)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseNamingConfig(t *testing.T) {
+	cfg, err := ParseNamingConfig(parseINI(t, "[server]\nstrip_prefix = /api\n"))
+	if err != nil || cfg != nil {
+		t.Fatalf("got %+v, %v; want nil, nil", cfg, err)
+	}
+
+	cfg, err = ParseNamingConfig(parseINI(t, `
+[naming]
+path_segments = Singular
+operation_id = {method}_{resource}.{func}
+operation_id_case = snake
+json_case = Camel
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NamingConfig{PathSegments: "singular", OperationIDTemplate: "{method}_{resource}.{func}", OperationIDCase: "snake", JSONCase: "camel"}
+	if *cfg != want {
+		t.Errorf("got %+v, want %+v", *cfg, want)
+	}
+
+	for name, ini := range map[string]string{
+		"unknown style":       "[naming]\npath_segments = dual\n",
+		"unknown placeholder": "[naming]\noperation_id = {table}_{func}\n",
+		"unclosed brace":      "[naming]\noperation_id = {func\n",
+		"stray brace":         "[naming]\noperation_id = func}\n",
+		"unknown case":        "[naming]\noperation_id_case = screaming\n",
+		"unknown json case":   "[naming]\njson_case = kebab\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseNamingConfig(parseINI(t, ini)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestNamingConfig_JSONCaseOrDefault(t *testing.T) {
+	var defaults *NamingConfig
+	if got := defaults.JSONCaseOrDefault(); got != "snake" {
+		t.Errorf("nil config: JSONCaseOrDefault = %q, want snake", got)
+	}
+	if got := (&NamingConfig{JSONCase: "camel"}).JSONCaseOrDefault(); got != "camel" {
+		t.Errorf("JSONCaseOrDefault = %q, want camel", got)
+	}
+}
+
+func TestNamingConfig_PathSegment(t *testing.T) {
+	var defaults *NamingConfig
+	if got := defaults.PathSegment("blog_posts"); got != "blog_posts" {
+		t.Errorf("nil config: PathSegment = %q", got)
+	}
+	singular := &NamingConfig{PathSegments: "singular"}
+	for table, want := range map[string]string{"blog_posts": "blog_post", "categories": "category", "people": "person"} {
+		if got := singular.PathSegment(table); got != want {
+			t.Errorf("PathSegment(%q) = %q, want %q", table, got, want)
+		}
+	}
+}
+
+func TestNamingConfig_OperationID(t *testing.T) {
+	var defaults *NamingConfig
+	if got := defaults.OperationID("GetPostByID", "posts", "GET"); got != "GetPostByID" {
+		t.Errorf("nil config: OperationID = %q", got)
+	}
+
+	tests := []struct {
+		tmpl, idCase, want string
+	}{
+		{"{func}", "", "GetPostByID"},
+		{"{resource}.{func}", "", "posts.GetPostByID"},
+		{"{func}", "camel", "getPostById"},
+		{"{method}_{func}", "pascal", "GetGetPostById"},
+		{"{resource}_{func}", "snake", "posts_get_post_by_id"},
+		{"{func}", "kebab", "get-post-by-id"},
+	}
+	for _, tt := range tests {
+		n := &NamingConfig{OperationIDTemplate: tt.tmpl, OperationIDCase: tt.idCase}
+		if got := n.OperationID("GetPostByID", "posts", "GET"); got != tt.want {
+			t.Errorf("%s (%s): got %q, want %q", tt.tmpl, tt.idCase, got, tt.want)
+		}
+	}
+}
@@ -15,6 +15,101 @@ type CRUDOptions struct {
 	// OrderAsc, if true, orders by created_at ASC (oldest first).
 	// Default is false (newest first, DESC).
 	OrderAsc bool
+
+	// Deprecated, if true, marks the generated CRUD routes as deprecated.
+	Deprecated bool
+
+	// Sunset is the YYYY-MM-DD date after which the generated CRUD routes
+	// may be removed. Setting it implies Deprecated.
+	Sunset string
+
+	// ImportMaxRows caps the number of rows accepted by the generated
+	// import endpoint. Zero means the generator's default.
+	ImportMaxRows int
+
+	// ImportJob adds a worker channel that imports a file uploaded through
+	// the managed files API in the background, for imports too large for a
+	// request. It requires `shipq workers` and `shipq files`.
+	ImportJob bool
+
+	// NearColumn is the point column searched by the generated
+	// List<Table>Near query and proximity endpoint. Empty disables both.
+	NearColumn string
+
+	// Defaults maps column names to values the generated create handler
+	// fills in when the request omits the field, e.g. {"status": "draft"}.
+	// They are applied by the API, independently of any database default.
+	Defaults map[string]string
+
+	// ListCacheMs, if positive, wraps the generated list handler in a
+	// micro-cache answering identical requests within this many
+	// milliseconds with one query. Zero disables it.
+	ListCacheMs int
+
+	// PathSegment is the URL path segment the generated routes are
+	// registered under, e.g. "posts" in /posts/:id. Empty means the table
+	// name.
+	PathSegment string
+
+	// Outbox makes the generated create, update and delete handlers record
+	// an event in the outbox_events table, in the transaction that writes
+	// the row. It requires `shipq outbox`.
+	Outbox bool
+
+	// MaxRowsPerScope, if positive, makes the generated create handler
+	// refuse new rows once the caller's scope holds this many live ones.
+	// A scope_quotas row overrides it per scope. It requires ScopeColumn
+	// and `shipq quotas`.
+	MaxRowsPerScope int
+
+	// QuotaStatus is the HTTP status returned when the quota is exhausted
+	// ([quotas] status, 402 or 403).
+	QuotaStatus int
+
+	// StateColumns lists the columns that get a check-and-set update,
+	// Update<Singular><Column>If, which changes the column only while it
+	// still holds the expected value and reports whether it did.
+	StateColumns []string
+
+	// Bulk adds a bulk create endpoint, POST /<table>/bulk, that creates
+	// every item of a JSON array in one transaction.
+	Bulk bool
+
+	// Hooks makes the generated create, update and delete handlers call
+	// the lifecycle hooks registered with the table package's
+	// RegisterHooks.
+	Hooks bool
+
+	// JSONCase is the case of the JSON names of the generated request and
+	// response fields: "snake" (the column name, the default) or "camel".
+	JSONCase string
+
+	// JSONNames maps column names to the JSON name of their field in the
+	// generated requests and responses, overriding JSONCase, e.g.
+	// {"sku": "productCode"}.
+	JSONNames map[string]string
+
+	// Filters makes the generated List query and list endpoint accept
+	// optional filters on the table's indexed columns, e.g.
+	// ?email=ann@example.com&created_after=2026-01-01T00:00:00Z. See
+	// ListFilters.
+	Filters bool
+
+	// Sorts lists the columns the generated List query and list endpoint
+	// can also be ordered by, e.g. []string{"title", "-updated_at"} for
+	// ?sort=title (ascending) and ?sort=updated_at (descending). See
+	// ListSorts.
+	Sorts []string
+
+	// NoSoftDelete makes the generated delete remove the row rather than
+	// set deleted_at, and leaves deleted_at out of the generated queries'
+	// WHERE clauses and the admin routes. The column itself is kept.
+	NoSoftDelete bool
+
+	// Restore adds a restore endpoint, POST /<table>/:id/restore, that
+	// undoes a soft delete with Restore<Singular>ByPublicID. It requires
+	// soft delete.
+	Restore bool
 }
 
 // SQLDialect represents a database dialect for SQL generation.
@@ -24,6 +119,7 @@ const (
 	SQLDialectPostgres SQLDialect = "postgres"
 	SQLDialectMySQL    SQLDialect = "mysql"
 	SQLDialectSQLite   SQLDialect = "sqlite"
+	SQLDialectMSSQL    SQLDialect = "mssql"
 )
 
 // QuoteIdentifier quotes an identifier based on dialect.
@@ -31,6 +127,8 @@ func QuoteIdentifier(name string, dialect SQLDialect) string {
 	switch dialect {
 	case SQLDialectMySQL:
 		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case SQLDialectMSSQL:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
 	default: // Postgres, SQLite use double quotes
 		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
 	}
@@ -41,6 +139,8 @@ func Placeholder(index int, dialect SQLDialect) string {
 	switch dialect {
 	case SQLDialectPostgres:
 		return fmt.Sprintf("$%d", index)
+	case SQLDialectMSSQL:
+		return fmt.Sprintf("@p%d", index)
 	default: // MySQL, SQLite use ?
 		return "?"
 	}
@@ -51,6 +151,8 @@ func NowFunc(dialect SQLDialect) string {
 	switch dialect {
 	case SQLDialectSQLite:
 		return "datetime('now')"
+	case SQLDialectMSSQL:
+		return "SYSUTCDATETIME()"
 	default: // Postgres, MySQL
 		return "NOW()"
 	}
@@ -0,0 +1,64 @@
+package codegen
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// ListFilter is an optional filter of a generated List query and the query
+// parameter of the list endpoint setting it.
+type ListFilter struct {
+	Column ddl.ColumnDefinition
+	// Param is the filter's param: the column name for an equality
+	// filter, or <column>_after and <column>_before for a time range,
+	// with a trailing _at dropped (created_at → created_after).
+	Param string
+	// Range is "" for an equality filter, "after" for column >= param and
+	// "before" for column < param.
+	Range string
+}
+
+// ListFilters returns the filters generated for the List query of table
+// with [crud.<table>] filters = true: an equality filter on each indexed
+// string, integer or boolean column, and a time range on each indexed time
+// column and on created_at, the order of the list. Columns the list never
+// exposes (id, public_id, deleted_at, author_account_id, references and the
+// scope column) and columns restricted to read roles are not filtered on.
+func ListFilters(table ddl.Table, scopeColumn string) []ListFilter {
+	indexed := make(map[string]bool)
+	for _, idx := range table.Indexes {
+		if len(idx.Columns) > 0 {
+			indexed[idx.Columns[0]] = true
+		}
+	}
+
+	var filters []ListFilter
+	used := map[string]bool{"limit": true, "cursor": true}
+	add := func(f ListFilter) {
+		if !used[f.Param] {
+			used[f.Param] = true
+			filters = append(filters, f)
+		}
+	}
+	for _, col := range table.Columns {
+		switch {
+		case col.Name == "id" || col.Name == "public_id" || col.Name == "deleted_at" || col.Name == "author_account_id":
+			continue
+		case col.Name == scopeColumn || col.References != "" || len(col.ReadRoles) > 0 || col.Custom != nil:
+			continue
+		case !col.Index && !col.Unique && !indexed[col.Name] && col.Name != "created_at":
+			continue
+		}
+		switch {
+		case ddl.IsTimeType(col.Type):
+			base := strings.TrimSuffix(col.Name, "_at")
+			add(ListFilter{Column: col, Param: base + "_after", Range: "after"})
+			add(ListFilter{Column: col, Param: base + "_before", Range: "before"})
+		case slices.Contains([]string{ddl.StringType, ddl.TextType, ddl.IntegerType, ddl.BigintType, ddl.BooleanType}, col.Type):
+			add(ListFilter{Column: col, Param: col.Name})
+		}
+	}
+	return filters
+}
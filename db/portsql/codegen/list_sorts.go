@@ -0,0 +1,66 @@
+package codegen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// ListSort is a sort order of a generated List query, chosen by the sort
+// query parameter of the list endpoint.
+type ListSort struct {
+	Column ddl.ColumnDefinition
+	// Desc orders the column descending; the list orders ascending unless
+	// the column is written -<column> in [crud.<table>] sort.
+	Desc bool
+}
+
+// Name returns the sort's name: the value of the sort query parameter and
+// of the Sort field of the List query's params.
+func (s ListSort) Name() string {
+	return s.Column.Name
+}
+
+// ListSorts returns the sorts of the List query of table configured with
+// [crud.<table>] sort = <column>, -<column>, ... Each orders by its column
+// and then public_id, in the same direction, so that cursors are unique.
+// A sort column must be a non-nullable string, integer or time column the
+// list exposes (not id, public_id, deleted_at, author_account_id, a
+// reference, the scope column or a read-restricted column).
+func ListSorts(table ddl.Table, scopeColumn string, columns []string) ([]ListSort, error) {
+	var sorts []ListSort
+	seen := make(map[string]bool)
+	for _, spec := range columns {
+		name, desc := strings.CutPrefix(spec, "-")
+		col, ok := findTableColumn(table, name)
+		if !ok {
+			return nil, fmt.Errorf("sort column %q not found in table %q", name, table.Name)
+		}
+		switch {
+		case name == "id" || name == "public_id" || name == "deleted_at" || name == "author_account_id",
+			name == scopeColumn || col.References != "" || len(col.ReadRoles) > 0 || col.Custom != nil:
+			return nil, fmt.Errorf("sort column %s.%s is not exposed by the list", table.Name, name)
+		case col.Nullable:
+			return nil, fmt.Errorf("sort column %s.%s is nullable; cursors can't page past NULLs", table.Name, name)
+		case !ddl.IsTimeType(col.Type) && !slices.Contains([]string{ddl.StringType, ddl.TextType, ddl.IntegerType, ddl.BigintType}, col.Type):
+			return nil, fmt.Errorf("sort column %s.%s must be a string, integer or time column, got %s", table.Name, name, col.Type)
+		case seen[name]:
+			return nil, fmt.Errorf("sort column %s.%s is listed twice", table.Name, name)
+		}
+		seen[name] = true
+		sorts = append(sorts, ListSort{Column: col, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// findTableColumn returns the column of table named name.
+func findTableColumn(table ddl.Table, name string) (ddl.ColumnDefinition, bool) {
+	for _, col := range table.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return ddl.ColumnDefinition{}, false
+}
@@ -0,0 +1,119 @@
+package queryrunner
+
+import "github.com/shipq/shipq/db/portsql/query"
+
+// columnParamNames returns the parameters compared with or written to a
+// column anywhere in ast for which tag returns a non-empty string, mapped to
+// that string.
+func columnParamNames(ast *query.SerializedAST, tag func(*query.SerializedColumn) string) map[string]string {
+	names := make(map[string]string)
+	collectColumnParams(ast, tag, names)
+	return names
+}
+
+func collectColumnParams(ast *query.SerializedAST, tag func(*query.SerializedColumn) string, names map[string]string) {
+	if ast == nil {
+		return
+	}
+	for _, row := range ast.InsertRows {
+		for i, v := range row {
+			if i < len(ast.InsertCols) {
+				markColumnParam(&v, tag(&ast.InsertCols[i]), names)
+			}
+			collectColumnParamsExpr(&v, tag, names)
+		}
+	}
+	for _, sc := range ast.SetClauses {
+		markColumnParam(&sc.Value, tag(&sc.Column), names)
+		collectColumnParamsExpr(&sc.Value, tag, names)
+	}
+	if oc := ast.OnConflict; oc != nil {
+		for _, sc := range oc.Updates {
+			markColumnParam(&sc.Value, tag(&sc.Column), names)
+			collectColumnParamsExpr(&sc.Value, tag, names)
+		}
+		collectColumnParamsExpr(oc.Where, tag, names)
+	}
+	for i := range ast.SelectCols {
+		collectColumnParamsExpr(&ast.SelectCols[i].Expr, tag, names)
+	}
+	for i := range ast.Joins {
+		collectColumnParamsExpr(&ast.Joins[i].Condition, tag, names)
+	}
+	collectColumnParamsExpr(ast.Where, tag, names)
+	collectColumnParamsExpr(ast.Having, tag, names)
+	for _, cte := range ast.CTEs {
+		collectColumnParams(cte.Query, tag, names)
+	}
+	collectColumnParams(ast.InsertSource, tag, names)
+	if ast.SetOp != nil {
+		collectColumnParams(ast.SetOp.Left, tag, names)
+		collectColumnParams(ast.SetOp.Right, tag, names)
+	}
+}
+
+func collectColumnParamsExpr(expr *query.SerializedExpr, tag func(*query.SerializedColumn) string, names map[string]string) {
+	if expr == nil {
+		return
+	}
+	switch expr.Type {
+	case "binary":
+		if expr.Binary == nil {
+			return
+		}
+		left, right := &expr.Binary.Left, &expr.Binary.Right
+		if t := columnExprTag(left, tag); t != "" {
+			markColumnParam(right, t, names)
+		}
+		if t := columnExprTag(right, tag); t != "" {
+			markColumnParam(left, t, names)
+		}
+		collectColumnParamsExpr(left, tag, names)
+		collectColumnParamsExpr(right, tag, names)
+	case "unary":
+		if expr.Unary != nil {
+			collectColumnParamsExpr(&expr.Unary.Expr, tag, names)
+		}
+	case "func":
+		if expr.Func != nil {
+			for i := range expr.Func.Args {
+				collectColumnParamsExpr(&expr.Func.Args[i], tag, names)
+			}
+		}
+	case "list":
+		for i := range expr.List {
+			collectColumnParamsExpr(&expr.List[i], tag, names)
+		}
+	case "subquery":
+		collectColumnParams(expr.Subquery, tag, names)
+	case "exists":
+		if expr.Exists != nil {
+			collectColumnParams(expr.Exists.Subquery, tag, names)
+		}
+	}
+}
+
+func columnExprTag(expr *query.SerializedExpr, tag func(*query.SerializedColumn) string) string {
+	if expr.Type != "column" || expr.Column == nil {
+		return ""
+	}
+	return tag(expr.Column)
+}
+
+// markColumnParam records expr, or each element of an IN list, under t when
+// it is a parameter and t is non-empty.
+func markColumnParam(expr *query.SerializedExpr, t string, names map[string]string) {
+	if t == "" {
+		return
+	}
+	switch expr.Type {
+	case "param":
+		if expr.Param != nil {
+			names[expr.Param.Name] = t
+		}
+	case "list":
+		for i := range expr.List {
+			markColumnParam(&expr.List[i], t, names)
+		}
+	}
+}
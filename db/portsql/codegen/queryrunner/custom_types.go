@@ -0,0 +1,204 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// customParamNames returns the parameters compared with or written to a
+// custom type column anywhere in ast, mapped to the type name.
+func customParamNames(ast *query.SerializedAST) map[string]string {
+	return columnParamNames(ast, func(col *query.SerializedColumn) string {
+		return col.Custom
+	})
+}
+
+// customHelperPrefix returns the prefix of the generated helpers for the
+// custom type name, e.g. "customMoney".
+func customHelperPrefix(name string) string {
+	return "custom" + dbstrings.ToPascalCase(name)
+}
+
+// resolveCustomTypes checks that every custom type referenced by queries is
+// defined in cfg.Schema and returns those with Scan/Value conversions, keyed
+// by name. References to types without conversions are cleared so their
+// values are bound and scanned as-is.
+func resolveCustomTypes(cfg UnifiedRunnerConfig, queries []userQueryInfo) (map[string]ddl.CustomType, error) {
+	defined := ddl.CustomTypes(cfg.Schema)
+	used := make(map[string]ddl.CustomType)
+
+	resolve := func(queryName, typeName string) (bool, error) {
+		t, ok := defined[typeName]
+		if !ok {
+			return false, fmt.Errorf("query %s: custom type %q is not defined by any column in the schema", queryName, typeName)
+		}
+		if !t.HasConversions() {
+			return false, nil
+		}
+		used[typeName] = t
+		return true, nil
+	}
+
+	for i := range queries {
+		qi := &queries[i]
+		for name, typeName := range qi.CustomParams {
+			keep, err := resolve(qi.Name, typeName)
+			if err != nil {
+				return nil, err
+			}
+			if !keep || !paramHasGoType(*qi, name, defined[typeName].GoType) {
+				delete(qi.CustomParams, name)
+			}
+		}
+		for j := range qi.Results {
+			r := &qi.Results[j]
+			if r.Custom == "" {
+				continue
+			}
+			keep, err := resolve(qi.Name, r.Custom)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				r.Custom = ""
+			}
+		}
+	}
+	return used, nil
+}
+
+// paramHasGoType reports whether the named parameter of qi is goType or a
+// pointer to it. Parameters of other types, e.g. a string compared with a
+// citext column, are bound unchanged.
+func paramHasGoType(qi userQueryInfo, paramName, goType string) bool {
+	for _, p := range qi.Params {
+		if p.Name == paramName {
+			return p.GoType == goType || p.GoType == "*"+goType
+		}
+	}
+	return false
+}
+
+// customArgExpr wraps field, a parameter bound to a column of the custom
+// type typeName, in the type's Value conversion.
+func customArgExpr(qi userQueryInfo, paramName, typeName, field string) string {
+	for _, p := range qi.Params {
+		if p.Name == paramName && strings.HasPrefix(p.GoType, "*") {
+			return customHelperPrefix(typeName) + "NullArg(" + field + ")"
+		}
+	}
+	return customHelperPrefix(typeName) + "Arg(" + field + ")"
+}
+
+// scanTarget returns the Scan destination for the field of r in target,
+// wrapping custom type fields and decimal aggregates in their scanner.
+func scanTarget(r resultInfo, target string) string {
+	field := "&" + target + "." + r.Name
+	if r.Decimal {
+		if strings.HasPrefix(r.GoType, "*") {
+			return "decimalNullScanner{" + field + "}"
+		}
+		return "decimalScanner{" + field + "}"
+	}
+	if r.Custom == "" {
+		return field
+	}
+	if strings.HasPrefix(r.GoType, "*") {
+		return customHelperPrefix(r.Custom) + "NullScanner{" + field + "}"
+	}
+	return customHelperPrefix(r.Custom) + "Scanner{" + field + "}"
+}
+
+// customTypeImports adds the import paths of custom Go types appearing in
+// the params or results of queries.
+func customTypeImports(cfg UnifiedRunnerConfig, queries []userQueryInfo, imports map[string]bool) {
+	for _, t := range ddl.CustomTypes(cfg.Schema) {
+		if t.GoImport == "" {
+			continue
+		}
+		for _, qi := range queries {
+			for _, p := range qi.Params {
+				if strings.Contains(p.GoType, t.GoType) {
+					imports[t.GoImport] = true
+				}
+			}
+			for _, r := range qi.Results {
+				if strings.Contains(r.GoType, t.GoType) {
+					imports[t.GoImport] = true
+				}
+			}
+		}
+	}
+}
+
+// writeCustomTypeHelpers emits, for each custom type with conversions, the
+// user's Scan and Value snippets wrapped as functions, plus the argument
+// wrappers and sql.Scanner implementations the query methods use.
+func writeCustomTypeHelpers(buf *bytes.Buffer, types map[string]ddl.CustomType) {
+	if len(types) == 0 {
+		return
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteString("// customValuer defers a custom type's Value conversion to the driver, so\n")
+	buf.WriteString("// a conversion error is returned by the query.\n")
+	buf.WriteString("type customValuer func() (driver.Value, error)\n\n")
+	buf.WriteString("func (f customValuer) Value() (driver.Value, error) { return f() }\n\n")
+
+	for _, name := range names {
+		t := types[name]
+		prefix := customHelperPrefix(name)
+
+		fmt.Fprintf(buf, "// %sScan converts a %s value read from the database.\n", prefix, name)
+		fmt.Fprintf(buf, "func %sScan(src any) (%s, error) {\n", prefix, t.GoType)
+		fmt.Fprintf(buf, "%s\n}\n\n", t.Scan)
+		fmt.Fprintf(buf, "// %sValue converts a %s value for the database.\n", prefix, name)
+		fmt.Fprintf(buf, "func %sValue(v %s) (driver.Value, error) {\n", prefix, t.GoType)
+		fmt.Fprintf(buf, "%s\n}\n\n", t.Value)
+
+		fmt.Fprintf(buf, "func %sArg(v %s) any {\n", prefix, t.GoType)
+		fmt.Fprintf(buf, "\treturn customValuer(func() (driver.Value, error) { return %sValue(v) })\n", prefix)
+		buf.WriteString("}\n\n")
+		fmt.Fprintf(buf, "func %sNullArg(v *%s) any {\n", prefix, t.GoType)
+		buf.WriteString("\tif v == nil {\n\t\treturn nil\n\t}\n")
+		fmt.Fprintf(buf, "\treturn %sArg(*v)\n", prefix)
+		buf.WriteString("}\n\n")
+
+		fmt.Fprintf(buf, "// %sScanner scans a %s column into dst.\n", prefix, name)
+		fmt.Fprintf(buf, "type %sScanner struct{ dst *%s }\n\n", prefix, t.GoType)
+		fmt.Fprintf(buf, "func (s %sScanner) Scan(src any) error {\n", prefix)
+		fmt.Fprintf(buf, "\tv, err := %sScan(src)\n", prefix)
+		buf.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+		buf.WriteString("\t*s.dst = v\n")
+		buf.WriteString("\treturn nil\n")
+		buf.WriteString("}\n\n")
+		fmt.Fprintf(buf, "// %sNullScanner scans a nullable %s column into dst.\n", prefix, name)
+		fmt.Fprintf(buf, "type %sNullScanner struct{ dst **%s }\n\n", prefix, t.GoType)
+		fmt.Fprintf(buf, "func (s %sNullScanner) Scan(src any) error {\n", prefix)
+		buf.WriteString("\tif src == nil {\n\t\t*s.dst = nil\n\t\treturn nil\n\t}\n")
+		fmt.Fprintf(buf, "\tv, err := %sScan(src)\n", prefix)
+		buf.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+		buf.WriteString("\t*s.dst = &v\n")
+		buf.WriteString("\treturn nil\n")
+		buf.WriteString("}\n\n")
+	}
+}
+
+// selectColumnCustomType returns the custom type name of a selected column
+// expression, or "" when it is not a plain custom type column.
+func selectColumnCustomType(expr query.SerializedExpr) string {
+	if expr.Type != "column" || expr.Column == nil {
+		return ""
+	}
+	return expr.Column.Custom
+}
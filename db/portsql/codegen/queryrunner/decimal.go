@@ -0,0 +1,120 @@
+package queryrunner
+
+import (
+	"bytes"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// decimalAggregateArg returns the decimal column summed or averaged by expr,
+// looking through COALESCE(SUM(...), ...) and scalar subqueries, or nil when
+// expr is not such an aggregate. Drivers return these aggregates as text
+// (Postgres), []byte (MySQL) or float64/int64 (SQLite), so the runner scans
+// them through decimalScanner to hand every dialect's callers the same string.
+func decimalAggregateArg(expr *query.SerializedExpr) *query.SerializedColumn {
+	if expr == nil {
+		return nil
+	}
+	switch expr.Type {
+	case "aggregate":
+		agg := expr.Aggregate
+		if agg == nil || (agg.Func != "SUM" && agg.Func != "AVG") || agg.Arg == nil {
+			return nil
+		}
+		if agg.Arg.Type == "column" && agg.Arg.Column != nil && agg.Arg.Column.Decimal {
+			return agg.Arg.Column
+		}
+	case "func":
+		if expr.Func != nil && expr.Func.Name == "COALESCE" && len(expr.Func.Args) > 0 {
+			return decimalAggregateArg(&expr.Func.Args[0])
+		}
+	case "subquery":
+		if expr.Subquery != nil && len(expr.Subquery.SelectCols) == 1 {
+			return decimalAggregateArg(&expr.Subquery.SelectCols[0].Expr)
+		}
+	}
+	return nil
+}
+
+// hasDecimalResults reports whether any query returns a decimal aggregate,
+// in which case the runner needs the decimal scanners.
+func hasDecimalResults(queries []userQueryInfo) bool {
+	for _, qi := range queries {
+		for _, r := range qi.Results {
+			if r.Decimal {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeDecimalHelpers emits the scanners for decimal aggregates. Values are
+// normalized to a plain decimal string without trailing fractional zeros,
+// so SUM(price) of 10.50 and 20.00 is "30.5" on every dialect rather than
+// "30.50", "30.500000" or 30.5. SQLite computes these aggregates in floating
+// point; its results are rounded to the 15 significant digits a float64
+// represents exactly.
+func writeDecimalHelpers(buf *bytes.Buffer) {
+	buf.WriteString(`// decimalString normalizes a DECIMAL/NUMERIC aggregate as returned by the
+// driver to a plain decimal string, e.g. "30.5".
+func decimalString(src any) (string, error) {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+		if err != nil {
+			return "", err
+		}
+		s = strconv.FormatFloat(rounded, 'f', -1, 64)
+	default:
+		return "", fmt.Errorf("unsupported decimal value of type %T", src)
+	}
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s, nil
+}
+
+// decimalScanner scans a decimal aggregate into dst.
+type decimalScanner struct{ dst *string }
+
+func (s decimalScanner) Scan(src any) error {
+	if src == nil {
+		return fmt.Errorf("converting NULL to string is unsupported")
+	}
+	v, err := decimalString(src)
+	if err != nil {
+		return err
+	}
+	*s.dst = v
+	return nil
+}
+
+// decimalNullScanner scans a nullable decimal aggregate into dst.
+type decimalNullScanner struct{ dst **string }
+
+func (s decimalNullScanner) Scan(src any) error {
+	if src == nil {
+		*s.dst = nil
+		return nil
+	}
+	v, err := decimalString(src)
+	if err != nil {
+		return err
+	}
+	*s.dst = &v
+	return nil
+}
+
+`)
+}
@@ -0,0 +1,104 @@
+package queryrunner
+
+import (
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+)
+
+func TestGenerateUnifiedRunner_DecimalAggregates(t *testing.T) {
+	price := query.DecimalColumn{Table: "orders", Name: "price"}
+	discount := query.NullDecimalColumn{Table: "orders", Name: "discount"}
+	weight := query.Float64Column{Table: "orders", Name: "weight"}
+
+	totals := query.SerializedQuery{
+		Name:       "OrderTotals",
+		ReturnType: query.ReturnOne,
+		AST: query.SerializeAST(query.From(viewTestTable{}).
+			SelectSumAs(price, "total_price").
+			SelectAvgAs(discount, "avg_discount").
+			SelectExprAs(query.Coalesce(query.Sum(price), query.Literal("0")), "price_or_zero").
+			SelectSumAs(weight, "total_weight").
+			SelectMaxAs(price, "max_price").
+			Build()),
+	}
+
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
+		t.Run(dialect, func(t *testing.T) {
+			cfg := UnifiedRunnerConfig{
+				ModulePath:  "myapp",
+				Dialect:     dialect,
+				UserQueries: []query.SerializedQuery{totals},
+			}
+			types, err := GenerateSharedTypes(cfg)
+			if err != nil {
+				t.Fatalf("GenerateSharedTypes() error = %v", err)
+			}
+			runner, err := GenerateUnifiedRunner(cfg)
+			if err != nil {
+				t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+			}
+			tf := gofile.Parse(t, "types.go", types)
+			for field, want := range map[string]string{
+				"TotalPrice":  "string",
+				"AvgDiscount": "*string",
+				"PriceOrZero": "string",
+				"TotalWeight": "float64",
+			} {
+				if typ, _, _ := tf.Field("OrderTotalsResult", field); typ != want {
+					t.Errorf("OrderTotalsResult.%s is %q, want %s", field, typ, want)
+				}
+			}
+
+			// Decimal sums and averages scan through decimalString; the
+			// float sum and the decimal max scan as-is.
+			f := gofile.Parse(t, "runner.go", runner)
+			f.AssertSignature("decimalString", "func decimalString(src any) (string, error)")
+			f.AssertExprs("QueryRunner.OrderTotals",
+				"decimalScanner{&result.TotalPrice}",
+				"decimalNullScanner{&result.AvgDiscount}",
+				"decimalScanner{&result.PriceOrZero}",
+			)
+			for _, field := range []string{"TotalWeight", "MaxPrice"} {
+				if f.HasExpr("QueryRunner.OrderTotals", "decimalScanner{&result."+field+"}") || !f.HasExpr("QueryRunner.OrderTotals", "&result."+field) {
+					t.Errorf("%s should scan as-is", field)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateUnifiedRunner_NoDecimalAggregates(t *testing.T) {
+	price := query.DecimalColumn{Table: "orders", Name: "price"}
+	listPrices := query.SerializedQuery{
+		Name:       "ListPrices",
+		ReturnType: query.ReturnMany,
+		AST:        query.SerializeAST(query.From(viewTestTable{}).Select(price).Build()),
+	}
+	runner, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+		ModulePath:  "myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{listPrices},
+	})
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+	}
+	if gofile.Parse(t, "runner.go", runner).HasFunc("decimalString") {
+		t.Error("plain decimal columns scan as-is and need no decimal helpers")
+	}
+}
+
+func TestDecimalAggregateArg_Subquery(t *testing.T) {
+	price := query.DecimalColumn{Table: "orders", Name: "price"}
+	ast := query.SerializeAST(query.From(viewTestTable{}).SelectSum(price).Build())
+	expr := &query.SerializedExpr{Type: "subquery", Subquery: ast}
+
+	if col := decimalAggregateArg(expr); col == nil || col.Name != "price" {
+		t.Errorf("decimalAggregateArg(subquery) = %v, want the price column", col)
+	}
+	if got := inferGoType(expr); got != "string" {
+		t.Errorf("inferGoType(subquery SUM(decimal)) = %q, want string", got)
+	}
+}
@@ -0,0 +1,55 @@
+package queryrunner
+
+import (
+	"fmt"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// ExplainQuery is a query compiled the way the generated runner sends it,
+// for `shipq db explain`.
+type ExplainQuery struct {
+	SQL string
+	// ParamOrder names the parameter of each placeholder, with duplicates.
+	ParamOrder []string
+	// ParamTypes maps each parameter name to its Go type. Paginated
+	// queries have the "__limit" parameter of their LIMIT.
+	ParamTypes map[string]string
+}
+
+// CompileForExplain compiles sq for dialect. A paginated query gets the
+// ORDER BY and LIMIT of its first page, without cursor or filters.
+// Materialized views and bulk inserts are rejected: their SQL is only
+// assembled at run time.
+func CompileForExplain(sq query.SerializedQuery, dialect string) (ExplainQuery, error) {
+	switch sq.ReturnType {
+	case query.ReturnMaterializedView:
+		return ExplainQuery{}, fmt.Errorf("%s is a materialized view; explain a query that reads it instead", sq.Name)
+	case query.ReturnBulkExec:
+		return ExplainQuery{}, fmt.Errorf("%s is a bulk insert, whose SQL depends on the number of rows", sq.Name)
+	}
+
+	compiler, err := getCompiler(dialect)
+	if err != nil {
+		return ExplainQuery{}, err
+	}
+	ast := query.DeserializeAST(sq.AST)
+	if ast == nil {
+		return ExplainQuery{}, fmt.Errorf("failed to deserialize AST for query %s", sq.Name)
+	}
+
+	types := make(map[string]string)
+	for _, p := range extractParams(sq.AST) {
+		types[p.Name] = p.GoType
+	}
+	if sq.ReturnType == query.ReturnPaginated && len(sq.CursorColumns) > 0 {
+		addPaginationToAST(ast, sq.CursorColumns)
+		types["__limit"] = "int"
+	}
+
+	sql, paramOrder, err := compiler.Compile(ast)
+	if err != nil {
+		return ExplainQuery{}, fmt.Errorf("failed to compile query %s: %w", sq.Name, err)
+	}
+	return ExplainQuery{SQL: sql, ParamOrder: paramOrder, ParamTypes: types}, nil
+}
@@ -0,0 +1,607 @@
+package queryrunner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
+	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// FakeRunnerPath is where GenerateFakeRunner's output is written, relative
+// to the shipq root.
+const FakeRunnerPath = "shipq/queries/fake/runner.go"
+
+// GenerateFakeRunner generates shipq/queries/fake/runner.go: a
+// queries.Runner that evaluates the query definitions against in-memory
+// tables (the memdb library package) instead of a database, so handler unit
+// tests can run without SQLite. Its tables are declared from cfg.Schema,
+// giving integer primary keys auto-increment and CURRENT_TIMESTAMP columns
+// the current time. Queries memdb cannot evaluate return an error wrapping
+// memdb.ErrUnsupported when they are called.
+func GenerateFakeRunner(cfg UnifiedRunnerConfig) ([]byte, error) {
+	var buf bytes.Buffer
+
+	compiler, err := getCompiler(cfg.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	userQueryInfo, err := compileUserQueries(cfg.UserQueries, compiler)
+	if err != nil {
+		return nil, err
+	}
+	preloads, preloadTables := collectPreloads(cfg, userQueryInfo)
+
+	defs, err := json.Marshal(cfg.UserQueries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize queries: %w", err)
+	}
+	if cfg.UserQueries == nil {
+		defs = []byte("[]")
+	}
+
+	imports := map[string]bool{
+		"context":       true,
+		"database/sql":  true,
+		"encoding/json": true,
+		"fmt":           true,
+		cfg.ModulePath + "/shipq/lib/db/portsql/query":       true,
+		cfg.ModulePath + "/shipq/lib/db/portsql/query/memdb": true,
+		cfg.ModulePath + "/shipq/queries":                    true,
+	}
+	for _, qi := range userQueryInfo {
+		if qi.Iter {
+			imports["iter"] = true
+		}
+		for _, col := range cursorFields(qi) {
+			if col.GoType == "time.Time" {
+				imports["time"] = true
+			}
+		}
+	}
+
+	buf.WriteString("// Code generated by shipq. DO NOT EDIT.\n\n")
+	buf.WriteString("// Package fake provides an in-memory queries.Runner for handler unit\n")
+	buf.WriteString("// tests. It runs the same query definitions as the database runners\n")
+	buf.WriteString("// against map-backed tables; see the memdb package for what it supports.\n")
+	buf.WriteString("package fake\n\n")
+	writeImports(&buf, imports)
+
+	writeFakeRunnerStruct(&buf)
+	writeFakeTables(&buf, cfg.Schema)
+
+	buf.WriteString("// queryDefsJSON holds the serialized query definitions.\n")
+	fmt.Fprintf(&buf, "const queryDefsJSON = %s\n\n", strconv.Quote(string(defs)))
+	writeFakeQueryDefs(&buf)
+
+	for _, qi := range userQueryInfo {
+		writeFakeQueryMethod(&buf, qi, cfg)
+	}
+	writeFakePreloadMethods(&buf, preloads, preloadTables)
+
+	formatted, err := phase.FormatSource(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("failed to format fake runner.go: %w (unformatted output returned)", err)
+	}
+	return formatted, nil
+}
+
+// writeFakeRunnerStruct writes the Runner type, its constructor, BeginTx
+// and the helpers every query method uses.
+func writeFakeRunnerStruct(buf *bytes.Buffer) {
+	buf.WriteString(`// Runner implements queries.Runner over in-memory tables, which start
+// empty. Seed them through the runner's own Create methods or with
+// DB().Insert. It is safe for concurrent use.
+type Runner struct {
+	db *memdb.DB
+}
+
+var _ queries.Runner = (*Runner)(nil)
+
+// NewRunner returns a Runner over empty tables.
+func NewRunner() *Runner {
+	return &Runner{db: memdb.New(tables...)}
+}
+
+// DB returns the tables the runner reads and writes, for seeding rows,
+// inspecting them after a call or pinning DB().Now.
+func (r *Runner) DB() *memdb.DB {
+	return r.db
+}
+
+// BeginTx starts a transaction. Its writes are visible to every caller
+// right away; Rollback puts the tables back as they were when it started.
+func (r *Runner) BeginTx(ctx context.Context) (*queries.TxRunner, error) {
+	snapshot := r.db.Snapshot()
+	done := false
+	return &queries.TxRunner{
+		Runner: r,
+		End: func(commit bool) error {
+			if done {
+				return sql.ErrTxDone
+			}
+			done = true
+			if !commit {
+				r.db.Restore(snapshot)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// query runs the named query definition and returns its rows.
+func (r *Runner) query(name string, args map[string]any) ([][]any, error) {
+	rows, err := r.db.Query(queryDefs[name].ast, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return rows, nil
+}
+
+// exec runs the named query definition without returning rows.
+func (r *Runner) exec(name string, args map[string]any) (sql.Result, error) {
+	res, err := r.db.Exec(queryDefs[name].ast, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return res, nil
+}
+
+`)
+}
+
+// writeFakeTables writes the memdb table declarations of the schema.
+func writeFakeTables(buf *bytes.Buffer, schema map[string]ddl.Table) {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteString("// tables declares the migrated tables and their column defaults.\n")
+	buf.WriteString("var tables = []memdb.Table{\n")
+	for _, name := range names {
+		var cols []string
+		for _, col := range schema[name].Columns {
+			if c := fakeColumn(col); c != "" {
+				cols = append(cols, c)
+			}
+		}
+		if len(cols) == 0 {
+			fmt.Fprintf(buf, "\t{Name: %q},\n", name)
+			continue
+		}
+		fmt.Fprintf(buf, "\t{Name: %q, Columns: []memdb.Column{\n", name)
+		for _, c := range cols {
+			fmt.Fprintf(buf, "\t\t%s,\n", c)
+		}
+		buf.WriteString("\t}},\n")
+	}
+	buf.WriteString("}\n\n")
+}
+
+// fakeColumn returns the memdb.Column literal of a column that gets a value
+// when an INSERT leaves it out, or "" for one that stays NULL.
+func fakeColumn(col ddl.ColumnDefinition) string {
+	if col.PrimaryKey && (col.Type == ddl.IntegerType || col.Type == ddl.BigintType) {
+		return fmt.Sprintf("{Name: %q, AutoIncrement: true}", col.Name)
+	}
+	if col.Default == nil {
+		return ""
+	}
+	def := *col.Default
+	switch strings.ToUpper(def) {
+	case "CURRENT_TIMESTAMP", "NOW()":
+		return fmt.Sprintf("{Name: %q, DefaultNow: true}", col.Name)
+	}
+
+	var value string
+	switch portsqlcodegen.MapColumnType(col).GoType {
+	case "int64", "*int64", "int32", "*int32", "int", "*int":
+		if _, err := strconv.ParseInt(def, 10, 64); err == nil {
+			value = "int64(" + def + ")"
+		}
+	case "float64", "*float64":
+		if _, err := strconv.ParseFloat(def, 64); err == nil {
+			value = "float64(" + def + ")"
+		}
+	case "bool", "*bool":
+		if b, err := strconv.ParseBool(def); err == nil {
+			value = strconv.FormatBool(b)
+		}
+	case "string", "*string":
+		value = strconv.Quote(def)
+	}
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf("{Name: %q, Default: %s}", col.Name, value)
+}
+
+// writeFakeQueryDefs writes the loader that deserializes queryDefsJSON.
+func writeFakeQueryDefs(buf *bytes.Buffer) {
+	buf.WriteString(`// queryDef is a deserialized query definition.
+type queryDef struct {
+	ast     *query.AST
+	cursor  []query.SerializedColumn
+	filters []query.SerializedFilter
+	sorts   map[string][]query.SerializedColumn
+}
+
+// queryDefs holds the query definitions by name.
+var queryDefs = loadQueryDefs()
+
+func loadQueryDefs() map[string]queryDef {
+	var serialized []query.SerializedQuery
+	if err := json.Unmarshal([]byte(queryDefsJSON), &serialized); err != nil {
+		panic("fake: failed to decode query definitions: " + err.Error())
+	}
+	defs := make(map[string]queryDef, len(serialized))
+	for _, sq := range serialized {
+		ast := query.DeserializeAST(sq.AST)
+		// A bulk insert runs its first row once per element of params.
+		if sq.ReturnType == query.ReturnBulkExec && len(ast.InsertRows) > 1 {
+			ast.InsertRows = ast.InsertRows[:1]
+		}
+		def := queryDef{ast: ast, cursor: sq.CursorColumns, filters: sq.Filters}
+		for _, s := range sq.Sorts {
+			if def.sorts == nil {
+				def.sorts = make(map[string][]query.SerializedColumn)
+			}
+			def.sorts[s.Name] = s.Columns
+		}
+		defs[sq.Name] = def
+	}
+	return defs
+}
+
+`)
+}
+
+// fakeDefName returns the name of the definition qi evaluates: its own, or
+// for the List method of a materialized view, the view's.
+func fakeDefName(qi userQueryInfo, cfg UnifiedRunnerConfig) string {
+	for _, sq := range cfg.UserQueries {
+		if sq.Name == qi.Name {
+			return sq.Name
+		}
+	}
+	for _, sq := range cfg.UserQueries {
+		if sq.ReturnType == query.ReturnMaterializedView && "List"+dbstrings.ToPascalCase(sq.Name) == qi.Name {
+			return sq.Name
+		}
+	}
+	return qi.Name
+}
+
+// writeFakeArgs writes args, the params of qi by name. Optional params are
+// left out when nil, which skips their SET clause.
+func writeFakeArgs(buf *bytes.Buffer, qi userQueryInfo, recv, indent string) {
+	var optional []paramInfo
+	fmt.Fprintf(buf, "%sargs := map[string]any{\n", indent)
+	for _, p := range qi.Params {
+		if p.Optional {
+			optional = append(optional, p)
+			continue
+		}
+		fmt.Fprintf(buf, "%s\t%q: %s.%s,\n", indent, p.Name, recv, dbstrings.ToPascalCase(p.Name))
+	}
+	fmt.Fprintf(buf, "%s}\n", indent)
+	for _, p := range optional {
+		field := recv + "." + dbstrings.ToPascalCase(p.Name)
+		fmt.Fprintf(buf, "%sif %s != nil {\n", indent, field)
+		fmt.Fprintf(buf, "%s\targs[%q] = *%s\n", indent, p.Name, field)
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+}
+
+// writeFakeScan writes scan<Name>, which assigns one row of qi to a value
+// of typeName.
+func writeFakeScan(buf *bytes.Buffer, qi userQueryInfo, typeName string) {
+	fmt.Fprintf(buf, "func scan%s(row []any) (%s, error) {\n", qi.Name, typeName)
+	fmt.Fprintf(buf, "\tvar item %s\n", typeName)
+	targets := make([]string, len(qi.Results))
+	for i, r := range qi.Results {
+		targets[i] = "&item." + r.Name
+	}
+	fmt.Fprintf(buf, "\terr := memdb.Scan(row, %s)\n", strings.Join(targets, ", "))
+	buf.WriteString("\treturn item, err\n")
+	buf.WriteString("}\n\n")
+}
+
+// writeFakeQueryMethod writes the methods of one query, with the same
+// signatures as the database runner's.
+func writeFakeQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig) {
+	name := qi.Name
+	def := strconv.Quote(fakeDefName(qi, cfg))
+	paramType := "queries." + name + "Params"
+	resultType := "queries." + name + "Result"
+
+	switch qi.ReturnType {
+	case query.ReturnOne:
+		writeFakeScan(buf, qi, resultType)
+		fmt.Fprintf(buf, "// %s runs the query and returns at most one result.\n", name)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params %s) (*%s, error) {\n", name, paramType, resultType)
+		writeFakeArgs(buf, qi, "params", "\t")
+		fmt.Fprintf(buf, "\trows, err := r.query(%s, args)\n", def)
+		buf.WriteString("\tif err != nil || len(rows) == 0 {\n\t\treturn nil, err\n\t}\n")
+		fmt.Fprintf(buf, "\tresult, err := scan%s(rows[0])\n", name)
+		fmt.Fprintf(buf, "\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n\t}\n", name)
+		buf.WriteString("\treturn &result, nil\n")
+		buf.WriteString("}\n\n")
+
+	case query.ReturnMany:
+		writeFakeScan(buf, qi, resultType)
+		fmt.Fprintf(buf, "// %s runs the query and returns all results.\n", name)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params %s) ([]%s, error) {\n", name, paramType, resultType)
+		writeFakeArgs(buf, qi, "params", "\t")
+		fmt.Fprintf(buf, "\trows, err := r.query(%s, args)\n", def)
+		buf.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+		if cfg.MaxRows > 0 {
+			buf.WriteString("\tif len(rows) > queries.MaxRows {\n")
+			fmt.Fprintf(buf, "\t\treturn nil, &queries.RowLimitError{Query: %q, Limit: queries.MaxRows}\n", name)
+			buf.WriteString("\t}\n")
+		}
+		fmt.Fprintf(buf, "\tvar results []%s\n", resultType)
+		buf.WriteString("\tfor _, row := range rows {\n")
+		fmt.Fprintf(buf, "\t\titem, err := scan%s(row)\n", name)
+		fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n\t\t}\n", name)
+		buf.WriteString("\t\tresults = append(results, item)\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn results, nil\n")
+		buf.WriteString("}\n\n")
+
+		each := codegen.CRUD.EachMethodName(name)
+		fmt.Fprintf(buf, "// %s runs the query and calls fn for each result in turn.\n", each)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params %s, fn func(%s) error) error {\n", each, paramType, resultType)
+		writeFakeArgs(buf, qi, "params", "\t")
+		fmt.Fprintf(buf, "\trows, err := r.query(%s, args)\n", def)
+		buf.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+		buf.WriteString("\tfor _, row := range rows {\n")
+		buf.WriteString("\t\tif err := ctx.Err(); err != nil {\n\t\t\treturn err\n\t\t}\n")
+		fmt.Fprintf(buf, "\t\titem, err := scan%s(row)\n", name)
+		fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn fmt.Errorf(\"%s: %%w\", err)\n\t\t}\n", name)
+		buf.WriteString("\t\tif err := fn(item); err != nil {\n\t\t\treturn err\n\t\t}\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn nil\n")
+		buf.WriteString("}\n\n")
+
+		if qi.Iter {
+			it := codegen.CRUD.IterMethodName(name)
+			fmt.Fprintf(buf, "// %s runs the query and returns an iterator over its results.\n", it)
+			fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params %s) (iter.Seq2[%s, error], error) {\n", it, paramType, resultType)
+			writeFakeArgs(buf, qi, "params", "\t")
+			fmt.Fprintf(buf, "\trows, err := r.query(%s, args)\n", def)
+			buf.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+			fmt.Fprintf(buf, "\treturn func(yield func(%s, error) bool) {\n", resultType)
+			buf.WriteString("\t\tfor _, row := range rows {\n")
+			buf.WriteString("\t\t\tif err := ctx.Err(); err != nil {\n")
+			fmt.Fprintf(buf, "\t\t\t\tyield(%s{}, err)\n", resultType)
+			buf.WriteString("\t\t\t\treturn\n\t\t\t}\n")
+			fmt.Fprintf(buf, "\t\t\titem, err := scan%s(row)\n", name)
+			buf.WriteString("\t\t\tif !yield(item, err) || err != nil {\n\t\t\t\treturn\n\t\t\t}\n")
+			buf.WriteString("\t\t}\n")
+			buf.WriteString("\t}, nil\n")
+			buf.WriteString("}\n\n")
+		}
+
+	case query.ReturnExec:
+		fmt.Fprintf(buf, "// %s runs the query without returning rows.\n", name)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params %s) (sql.Result, error) {\n", name, paramType)
+		writeFakeArgs(buf, qi, "params", "\t")
+		fmt.Fprintf(buf, "\treturn r.exec(%s, args)\n", def)
+		buf.WriteString("}\n\n")
+
+	case query.ReturnApplied:
+		fmt.Fprintf(buf, "// %s runs the query and reports whether it changed a row.\n", name)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params %s) (bool, error) {\n", name, paramType)
+		writeFakeArgs(buf, qi, "params", "\t")
+		fmt.Fprintf(buf, "\tres, err := r.exec(%s, args)\n", def)
+		buf.WriteString("\tif err != nil {\n\t\treturn false, err\n\t}\n")
+		buf.WriteString("\tn, err := res.RowsAffected()\n")
+		buf.WriteString("\treturn n > 0, err\n")
+		buf.WriteString("}\n\n")
+
+	case query.ReturnBulkExec:
+		fmt.Fprintf(buf, "// %s inserts one row per element of params.\n", name)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params []queries.%sParams) (sql.Result, error) {\n", name, bulkParamsName(qi))
+		buf.WriteString("\trows := make([]map[string]any, len(params))\n")
+		buf.WriteString("\tfor i, p := range params {\n")
+		buf.WriteString("\t\trows[i] = map[string]any{\n")
+		seen := make(map[string]bool)
+		for _, p := range qi.BulkParamNames {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			fmt.Fprintf(buf, "\t\t\t%q: p.%s,\n", p, dbstrings.ToPascalCase(p))
+		}
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+		fmt.Fprintf(buf, "\tres, err := r.db.ExecBatch(queryDefs[%s].ast, rows)\n", def)
+		fmt.Fprintf(buf, "\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n\t}\n", name)
+		buf.WriteString("\treturn res, nil\n")
+		buf.WriteString("}\n\n")
+
+	case query.ReturnMaterializedView:
+		fmt.Fprintf(buf, "// %s does nothing: the fake evaluates the view's query on every read.\n", name)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context) error {\n", name)
+		buf.WriteString("\treturn nil\n")
+		buf.WriteString("}\n\n")
+
+	case query.ReturnPaginated:
+		writeFakePaginatedMethod(buf, qi, def)
+	}
+}
+
+// writeFakePaginatedMethod writes a cursor-paginated method. Like the
+// database runner's, it fetches one row past the limit to tell whether
+// there is a next page, and encodes the cursor from the page's last item.
+func writeFakePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, def string) {
+	name := qi.Name
+	itemType := "queries." + name + "Item"
+
+	writeFakeScan(buf, qi, itemType)
+	fmt.Fprintf(buf, "// %s runs the query for one page of results.\n", name)
+	fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, params queries.%sParams) (*queries.%sResult, error) {\n", name, name, name)
+	buf.WriteString("\tlimit := params.Limit\n")
+	buf.WriteString("\tif limit <= 0 {\n\t\tlimit = 20\n\t}\n")
+	fmt.Fprintf(buf, "\tdef := queryDefs[%s]\n", def)
+	writeFakeArgs(buf, qi, "params", "\t")
+	if len(qi.Filters) > 0 {
+		buf.WriteString("\tvar conds []query.Expr\n")
+		for i, f := range qi.Filters {
+			field := "params." + dbstrings.ToPascalCase(f.Param)
+			fmt.Fprintf(buf, "\tif %s != nil {\n", field)
+			fmt.Fprintf(buf, "\t\tconds = append(conds, memdb.Filter(def.filters[%d]))\n", i)
+			fmt.Fprintf(buf, "\t\targs[%q] = *%s\n", f.Param, field)
+			buf.WriteString("\t}\n")
+		}
+	}
+	if len(qi.Sorts) == 0 {
+		buf.WriteString("\tpage := memdb.Page{Cursor: def.cursor, Limit: limit + 1}\n")
+		buf.WriteString("\tif params.Cursor != nil {\n")
+		buf.WriteString("\t\tpage.After = " + fakeCursorValues(qi.CursorColumns) + "\n")
+		buf.WriteString("\t}\n")
+	} else {
+		buf.WriteString("\tpage := memdb.Page{Cursor: def.cursor, Limit: limit + 1}\n")
+		buf.WriteString("\tif params.Sort != \"\" {\n")
+		buf.WriteString("\t\tcursor, ok := def.sorts[params.Sort]\n")
+		buf.WriteString("\t\tif !ok {\n")
+		fmt.Fprintf(buf, "\t\t\treturn nil, fmt.Errorf(\"%s: unknown sort %%q\", params.Sort)\n", name)
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t\tpage.Cursor = cursor\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tif params.Cursor != nil && params.Cursor.Sort == params.Sort {\n")
+		buf.WriteString("\t\tswitch params.Sort {\n")
+		buf.WriteString("\t\tcase \"\":\n")
+		buf.WriteString("\t\t\tpage.After = " + fakeCursorValues(qi.CursorColumns) + "\n")
+		for _, so := range qi.Sorts {
+			fmt.Fprintf(buf, "\t\tcase %q:\n", so.Name)
+			buf.WriteString("\t\t\tpage.After = " + fakeCursorValues(so.CursorColumns) + "\n")
+		}
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t}\n")
+	}
+	if len(qi.Filters) > 0 {
+		buf.WriteString("\trows, err := r.db.QueryPage(memdb.And(def.ast, conds...), args, page)\n")
+	} else {
+		buf.WriteString("\trows, err := r.db.QueryPage(def.ast, args, page)\n")
+	}
+	fmt.Fprintf(buf, "\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n\t}\n", name)
+	fmt.Fprintf(buf, "\titems := make([]%s, 0, len(rows))\n", itemType)
+	buf.WriteString("\tfor _, row := range rows {\n")
+	fmt.Fprintf(buf, "\t\titem, err := scan%s(row)\n", name)
+	fmt.Fprintf(buf, "\t\tif err != nil {\n\t\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n\t\t}\n", name)
+	buf.WriteString("\t\titems = append(items, item)\n")
+	buf.WriteString("\t}\n\n")
+
+	fmt.Fprintf(buf, "\tresult := &queries.%sResult{}\n", name)
+	buf.WriteString("\tif len(items) > limit {\n")
+	buf.WriteString("\t\tresult.Items = items[:limit]\n")
+	buf.WriteString("\t\tlastItem := items[limit-1]\n")
+	if len(qi.Sorts) == 0 {
+		fmt.Fprintf(buf, "\t\tresult.NextCursor = &queries.%sCursor{\n", name)
+		for _, col := range qi.CursorColumns {
+			field := dbstrings.ToPascalCase(col.Name)
+			fmt.Fprintf(buf, "\t\t\t%s: %s,\n", field, fakeCursorField(col))
+		}
+		buf.WriteString("\t\t}\n")
+	} else {
+		fmt.Fprintf(buf, "\t\tresult.NextCursor = &queries.%sCursor{Sort: params.Sort}\n", name)
+		buf.WriteString("\t\tswitch params.Sort {\n")
+		for _, so := range append([]sortInfo{{CursorColumns: qi.CursorColumns}}, qi.Sorts...) {
+			fmt.Fprintf(buf, "\t\tcase %q:\n", so.Name)
+			for _, col := range so.CursorColumns {
+				field := dbstrings.ToPascalCase(col.Name)
+				fmt.Fprintf(buf, "\t\t\tresult.NextCursor.%s = %s\n", field, fakeCursorField(col))
+			}
+		}
+		buf.WriteString("\t\t}\n")
+	}
+	buf.WriteString("\t} else {\n")
+	buf.WriteString("\t\tresult.Items = items\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn result, nil\n")
+	buf.WriteString("}\n\n")
+}
+
+// fakeCursorValues returns the []any of the cursor fields of cols, in
+// order, for memdb.Page.After.
+func fakeCursorValues(cols []query.SerializedColumn) string {
+	values := make([]string, len(cols))
+	for i, col := range cols {
+		values[i] = "params.Cursor." + dbstrings.ToPascalCase(col.Name)
+	}
+	return "[]any{" + strings.Join(values, ", ") + "}"
+}
+
+// fakeCursorField returns the cursor field value of col from lastItem.
+func fakeCursorField(col query.SerializedColumn) string {
+	field := dbstrings.ToPascalCase(col.Name)
+	if col.GoType == "time.Time" {
+		return fmt.Sprintf("lastItem.%s.UTC().Format(time.RFC3339Nano)", field)
+	}
+	return fmt.Sprintf("fmt.Sprint(lastItem.%s)", field)
+}
+
+// writeFakePreloadMethods writes the Preload methods, which look the
+// referenced rows up in the fake's tables.
+func writeFakePreloadMethods(buf *bytes.Buffer, preloads []preloadInfo, tables []preloadTable) {
+	if len(preloads) == 0 {
+		return
+	}
+	loaders := make(map[string]preloadTable, len(tables))
+	for _, t := range tables {
+		loaders[t.Name] = t
+		fmt.Fprintf(buf, "// %s returns the live %s rows by public_id.\n", t.Loader, t.Name)
+		fmt.Fprintf(buf, "func (r *Runner) %s() (map[string]*queries.%s, error) {\n", t.Loader, t.Type)
+		fmt.Fprintf(buf, "\tfound := make(map[string]*queries.%s)\n", t.Type)
+		fmt.Fprintf(buf, "\tfor _, row := range r.db.Rows(%q) {\n", t.Name)
+		if t.SoftDel {
+			buf.WriteString("\t\tif row[\"deleted_at\"] != nil {\n\t\t\tcontinue\n\t\t}\n")
+		}
+		fmt.Fprintf(buf, "\t\tvar item queries.%s\n", t.Type)
+		values := make([]string, len(t.Columns))
+		targets := make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			values[i] = fmt.Sprintf("row[%q]", col.Name)
+			targets[i] = "&item." + dbstrings.ToPascalCase(col.Name)
+		}
+		fmt.Fprintf(buf, "\t\tif err := memdb.Scan([]any{%s}, %s); err != nil {\n", strings.Join(values, ", "), strings.Join(targets, ", "))
+		fmt.Fprintf(buf, "\t\t\treturn nil, fmt.Errorf(\"%s: %%w\", err)\n", t.Loader)
+		buf.WriteString("\t\t}\n")
+		buf.WriteString("\t\tfound[item.PublicId] = &item\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn found, nil\n")
+		buf.WriteString("}\n\n")
+	}
+
+	for _, p := range preloads {
+		key := "items[i]." + p.KeyField
+		fmt.Fprintf(buf, "// %s sets each item's %s to the %s row referenced by\n", p.Method, p.Field, dbstrings.ToSingular(p.RefTable))
+		fmt.Fprintf(buf, "// %s.%s. Items whose row is missing or soft-deleted keep a nil field.\n", p.Table, p.Column)
+		fmt.Fprintf(buf, "func (r *Runner) %s(ctx context.Context, items []queries.%s) error {\n", p.Method, p.TargetType)
+		fmt.Fprintf(buf, "\tfound, err := r.%s()\n", loaders[p.RefTable].Loader)
+		buf.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+		buf.WriteString("\tfor i := range items {\n")
+		if p.KeyPtr {
+			fmt.Fprintf(buf, "\t\tif %s != nil {\n", key)
+			fmt.Fprintf(buf, "\t\t\titems[i].%s = found[*%s]\n", p.Field, key)
+			buf.WriteString("\t\t}\n")
+		} else {
+			fmt.Fprintf(buf, "\t\titems[i].%s = found[%s]\n", p.Field, key)
+		}
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn nil\n")
+		buf.WriteString("}\n\n")
+	}
+}
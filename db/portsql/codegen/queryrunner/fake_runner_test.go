@@ -0,0 +1,96 @@
+package queryrunner
+
+import (
+	"go/ast"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+)
+
+func TestGenerateFakeRunner(t *testing.T) {
+	list := preloadTestQuery("ListPosts", query.ReturnPaginated, "string")
+	list.CursorColumns = []query.SerializedColumn{
+		{Table: "posts", Name: "created_at", GoType: "time.Time"},
+		{Table: "posts", Name: "public_id", GoType: "string"},
+	}
+	cfg := UnifiedRunnerConfig{
+		ModulePath: "example.com/myapp",
+		Dialect:    dburl.DialectSQLite,
+		Schema:     preloadTestSchema(t, false),
+		MaxRows:    10000,
+		UserQueries: []query.SerializedQuery{
+			preloadTestQuery("GetPostByPublicID", query.ReturnOne, "string"),
+			preloadTestQuery("ListRecentPosts", query.ReturnMany, "string"),
+			list,
+		},
+	}
+
+	src, err := GenerateFakeRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateFakeRunner failed: %v\n%s", err, src)
+	}
+	f := gofile.Parse(t, "runner.go", src)
+	if f.AST.Name.Name != "fake" {
+		t.Errorf("package = %s, want fake", f.AST.Name.Name)
+	}
+	if !f.HasImport("example.com/myapp/shipq/lib/db/portsql/query/memdb") {
+		t.Error("the fake should run queries with memdb")
+	}
+	f.AssertExprs("",
+		"(*Runner)(nil)",
+		`{Name: "id", AutoIncrement: true}`,
+		`{Name: "created_at", DefaultNow: true}`,
+	)
+	f.AssertSignature("Runner.GetPostByPublicID", "func (r *Runner) GetPostByPublicID(ctx context.Context, params queries.GetPostByPublicIDParams) (*queries.GetPostByPublicIDResult, error)")
+	f.AssertSignature("Runner.ListRecentPosts", "func (r *Runner) ListRecentPosts(ctx context.Context, params queries.ListRecentPostsParams) ([]queries.ListRecentPostsResult, error)")
+	f.AssertSignature("Runner.EachListRecentPosts", "func (r *Runner) EachListRecentPosts(ctx context.Context, params queries.ListRecentPostsParams, fn func(queries.ListRecentPostsResult) error) error")
+	f.AssertSignature("Runner.ListPosts", "func (r *Runner) ListPosts(ctx context.Context, params queries.ListPostsParams) (*queries.ListPostsResult, error)")
+	f.AssertSignature("Runner.PreloadPostAuthors", "func (r *Runner) PreloadPostAuthors(ctx context.Context, items []queries.GetPostByPublicIDResult) error")
+	f.AssertSignature("Runner.BeginTx", "func (r *Runner) BeginTx(ctx context.Context) (*queries.TxRunner, error)")
+	if f.Calls("Runner.ListRecentPosts", "len") == 0 || !hasRowLimitError(f, "Runner.ListRecentPosts") {
+		t.Error("ListRecentPosts should enforce the row limit")
+	}
+	f.AssertStmts("Runner.ListPosts",
+		"page.After = []any{params.Cursor.CreatedAt, params.Cursor.PublicId}",
+	)
+	f.AssertExprs("Runner.ListPosts", "lastItem.CreatedAt.UTC().Format(time.RFC3339Nano)")
+}
+
+// hasRowLimitError reports whether fn returns a *queries.RowLimitError.
+func hasRowLimitError(f *gofile.File, fn string) bool {
+	found := false
+	ast.Inspect(f.Func(fn), func(n ast.Node) bool {
+		if lit, ok := n.(*ast.CompositeLit); ok {
+			if sel, ok := lit.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "RowLimitError" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func TestGenerateFakeRunner_NoQueries(t *testing.T) {
+	src, err := GenerateFakeRunner(UnifiedRunnerConfig{ModulePath: "example.com/myapp", Dialect: dburl.DialectPostgres})
+	if err != nil {
+		t.Fatalf("GenerateFakeRunner failed: %v\n%s", err, src)
+	}
+	if got := gofile.Parse(t, "runner.go", src).Value("queryDefsJSON"); got != `"[]"` {
+		t.Errorf("expected an empty query list, got:\n%s", src)
+	}
+}
+
+func TestSharedTypes_TxRunnerEnd(t *testing.T) {
+	src, err := GenerateSharedTypes(UnifiedRunnerConfig{ModulePath: "example.com/myapp", Dialect: dburl.DialectSQLite})
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	f := gofile.Parse(t, "types.go", src)
+	if typ, _, _ := f.Field("TxRunner", "End"); typ != "func(commit bool) error" {
+		t.Errorf("TxRunner.End is %q, want func(commit bool) error", typ)
+	}
+	f.AssertStmts("TxRunner.Commit", "return t.End(true)")
+	f.AssertStmts("TxRunner.Rollback", "return t.End(false)")
+}
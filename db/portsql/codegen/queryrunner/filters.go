@@ -0,0 +1,280 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
+	"github.com/shipq/shipq/dbstrings"
+	"github.com/shipq/shipq/dburl"
+)
+
+// filterInfo is an optional condition of a paginated query.
+type filterInfo struct {
+	Param  string
+	GoType string // type of the params field, always a pointer
+	Cond   string // compiled condition up to its placeholder, e.g. `"posts"."status" = `
+	UTC    bool   // compared with a timestamptz column
+}
+
+// filterSplit is a compiled paginated query split where the conditions of
+// its set filters go.
+type filterSplit struct {
+	Head   string // SQL before the conditions
+	Tail   string // SQL after the conditions
+	Where  bool   // Head ends inside a WHERE clause, so conditions are joined with AND
+	ArgsAt int    // number of args bound before the conditions
+}
+
+// filterMarker marks the position of the filter conditions in a compiled
+// paginated query.
+const filterMarker = "\x00"
+
+// filterMarkerParam is the param standing in for the filter conditions.
+const filterMarkerParam = "__filters"
+
+// filterMarkDialect writes filterMarker for the at-th placeholder and
+// numbers the placeholders after it as if it weren't there.
+type filterMarkDialect struct {
+	compile.Dialect
+	at int
+}
+
+func (d filterMarkDialect) Placeholder(index int) string {
+	switch {
+	case index == d.at:
+		return filterMarker
+	case index > d.at:
+		return d.Dialect.Placeholder(index - 1)
+	}
+	return d.Dialect.Placeholder(index)
+}
+
+// compileFilters compiles the filters of the paginated query sq, whose base
+// and cursor variants are already compiled into qi.
+func compileFilters(qi *userQueryInfo, sq query.SerializedQuery, ast *query.AST, compiler *compile.Compiler, dialectName string) error {
+	if len(ast.GroupBy) > 0 || ast.Having != nil || ast.SetOp != nil {
+		return fmt.Errorf("query %s: filters are not supported on queries with GROUP BY, HAVING or set operations", sq.Name)
+	}
+	dialect, err := getDialect(dialectName)
+	if err != nil {
+		return err
+	}
+
+	params := make(map[string]bool)
+	for _, p := range qi.Params {
+		params[p.Name] = true
+	}
+	for _, f := range sq.Filters {
+		if params[f.Param] || f.Param == "limit" || f.Param == "cursor" {
+			return fmt.Errorf("query %s: filter param %q is already a param of the query", sq.Name, f.Param)
+		}
+		goType := strings.TrimPrefix(f.Column.GoType, "*")
+		cond := query.BinaryExpr{
+			Left: query.ColumnExpr{Column: query.SimpleColumn{
+				Table_: f.Column.Table, Name_: f.Column.Name, GoType_: f.Column.GoType, UTC_: f.Column.UTC,
+			}},
+			Op:    query.BinaryOp(f.Op),
+			Right: query.ParamExpr{Name: f.Param, GoType: goType},
+		}
+		condSQL, _, err := compile.NewCompiler(filterMarkDialect{dialect, 1}).Compile(&query.AST{
+			Kind:      query.SelectQuery,
+			FromTable: query.TableRef{Name: f.Column.Table},
+			Where:     cond,
+		})
+		if err != nil {
+			return fmt.Errorf("query %s: compiling filter %q: %w", sq.Name, f.Param, err)
+		}
+		_, where, _ := strings.Cut(condSQL, " WHERE ")
+		qi.Filters = append(qi.Filters, filterInfo{
+			Param:  f.Param,
+			GoType: "*" + goType,
+			Cond:   strings.TrimSuffix(strings.TrimPrefix(where, "("), filterMarker+")"),
+			UTC:    f.Column.UTC,
+		})
+	}
+
+	baseAST := query.DeserializeAST(query.SerializeAST(ast))
+	addPaginationToAST(baseAST, sq.CursorColumns)
+	if qi.FilterSplit, err = splitAtFilters(baseAST, compiler, dialect); err != nil {
+		return fmt.Errorf("query %s: %w", sq.Name, err)
+	}
+	if qi.CursorFilterSplit, err = splitAtFilters(buildCursorAST(ast, sq.CursorColumns), compiler, dialect); err != nil {
+		return fmt.Errorf("query %s: %w", sq.Name, err)
+	}
+	return nil
+}
+
+// splitAtFilters compiles ast with a marker ANDed into its WHERE clause and
+// splits the SQL at the marker.
+func splitAtFilters(ast *query.AST, compiler *compile.Compiler, dialect compile.Dialect) (filterSplit, error) {
+	marker := query.ParamExpr{Name: filterMarkerParam, GoType: "bool"}
+	split := filterSplit{Where: ast.Where != nil}
+	if split.Where {
+		ast.Where = query.BinaryExpr{Left: ast.Where, Op: query.OpAnd, Right: marker}
+	} else {
+		ast.Where = marker
+	}
+
+	_, paramOrder, err := compiler.Compile(ast)
+	if err != nil {
+		return filterSplit{}, fmt.Errorf("compiling filter position: %w", err)
+	}
+	at := slices.Index(paramOrder, filterMarkerParam) + 1
+	sql, _, err := compile.NewCompiler(filterMarkDialect{dialect, at}).Compile(ast)
+	if err != nil {
+		return filterSplit{}, fmt.Errorf("compiling filter position: %w", err)
+	}
+
+	head, tail, _ := strings.Cut(sql, filterMarker)
+	sep := " WHERE "
+	if split.Where {
+		sep = " AND "
+	}
+	if !strings.HasSuffix(head, sep) {
+		return filterSplit{}, fmt.Errorf("could not find the WHERE clause for filters in %s", sql)
+	}
+	split.Head = strings.TrimSuffix(head, sep)
+	split.Tail = tail
+	split.ArgsAt = at - 1
+	return split, nil
+}
+
+// hasFilters reports whether any query has filters, in which case the
+// runner needs the filter types.
+func hasFilters(queries []userQueryInfo) bool {
+	for _, qi := range queries {
+		if len(qi.Filters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFilterTypes emits queryFilters, which adds the conditions of the set
+// filters to a paginated query at runtime. Conditions are joined with AND
+// and bound to fresh placeholders: numbered ones follow the query's own,
+// while ? placeholders take their args at the conditions' position.
+func writeFilterTypes(buf *bytes.Buffer, dialect string) {
+	buf.WriteString(`// filterSplit is a paginated query split where the conditions of its
+// filters go.
+type filterSplit struct {
+	head, tail string
+	where      bool // head ends inside the WHERE clause
+	argsAt     int  // args bound before the conditions
+}
+
+// queryFilters holds the optional filters of a paginated query.
+type queryFilters struct {
+	base, cursor filterSplit
+	conds        []string // condition of each filter, up to its placeholder
+}
+
+// apply returns the query of split with the conditions of the set filters
+// (indexes into conds) ANDed into its WHERE clause, and args with their
+// values bound.
+func (f queryFilters) apply(split filterSplit, args []any, set []int, values []any) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString(split.head)
+	for i, c := range set {
+		if i == 0 && !split.where {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		sb.WriteString(f.conds[c])
+`)
+	switch dialect {
+	case dburl.DialectPostgres:
+		buf.WriteString("\t\tfmt.Fprintf(&sb, \"$%d\", len(args)+i+1)\n")
+	case dburl.DialectMSSQL:
+		buf.WriteString("\t\tfmt.Fprintf(&sb, \"@p%d\", len(args)+i+1)\n")
+	default:
+		buf.WriteString("\t\tsb.WriteString(\"?\")\n")
+	}
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tsb.WriteString(split.tail)\n")
+	switch dialect {
+	case dburl.DialectPostgres, dburl.DialectMSSQL:
+		buf.WriteString("\treturn sb.String(), append(args, values...)\n")
+	default:
+		buf.WriteString("\tbound := make([]any, 0, len(args)+len(values))\n")
+		buf.WriteString("\tbound = append(bound, args[:split.argsAt]...)\n")
+		buf.WriteString("\tbound = append(bound, values...)\n")
+		buf.WriteString("\treturn sb.String(), append(bound, args[split.argsAt:]...)\n")
+	}
+	buf.WriteString("}\n\n")
+}
+
+// filtersField returns the QueryRunner field holding qi's filters.
+func filtersField(qi userQueryInfo) string {
+	return dbstrings.ToLowerCamel(qi.Name) + "Filters"
+}
+
+// writeFiltersValue writes the queryFilters literal of the QueryRunner
+// field named field, for filters applied at the base and cursor splits.
+func writeFiltersValue(buf *bytes.Buffer, field string, filters []filterInfo, base, cursor filterSplit) {
+	split := func(s filterSplit) string {
+		return fmt.Sprintf("filterSplit{head: %q, tail: %q, where: %t, argsAt: %d}", s.Head, s.Tail, s.Where, s.ArgsAt)
+	}
+	fmt.Fprintf(buf, "\t\t%s: queryFilters{\n", field)
+	fmt.Fprintf(buf, "\t\t\tbase:   %s,\n", split(base))
+	fmt.Fprintf(buf, "\t\t\tcursor: %s,\n", split(cursor))
+	buf.WriteString("\t\t\tconds: []string{\n")
+	for _, f := range filters {
+		fmt.Fprintf(buf, "\t\t\t\t%q,\n", f.Cond)
+	}
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t},\n")
+}
+
+// writeFilterApply writes the part of a paginated method that adds the
+// set filters of params to sqlStr and args.
+func writeFilterApply(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig) {
+	buf.WriteString("\tvar filters []int\n")
+	buf.WriteString("\tvar filterArgs []any\n")
+	for i, f := range qi.Filters {
+		field := "params." + dbstrings.ToPascalCase(f.Param)
+		fmt.Fprintf(buf, "\tif %s != nil {\n", field)
+		fmt.Fprintf(buf, "\t\tfilters = append(filters, %d)\n", i)
+		fmt.Fprintf(buf, "\t\tfilterArgs = append(filterArgs, %s)\n", filterArgExpr(f, "*"+field, cfg.Dialect))
+		buf.WriteString("\t}\n")
+	}
+	// With sorts, the method has picked the filters of its order.
+	source := "r." + filtersField(qi)
+	if len(qi.Sorts) > 0 {
+		source = "sortFilters"
+	}
+	buf.WriteString("\tif len(filters) > 0 {\n")
+	fmt.Fprintf(buf, "\t\tsplit := %s.base\n", source)
+	buf.WriteString("\t\tif params.Cursor != nil {\n")
+	fmt.Fprintf(buf, "\t\t\tsplit = %s.cursor\n", source)
+	buf.WriteString("\t\t}\n")
+	fmt.Fprintf(buf, "\t\tsqlStr, args = %s.apply(split, args, filters, filterArgs)\n", source)
+	if cfg.PrepareStatements {
+		// Each combination of filters is its own statement.
+		buf.WriteString("\t\tsqlName = fmt.Sprint(sqlName, filters)\n")
+	}
+	buf.WriteString("\t}\n\n")
+}
+
+// filterArgExpr returns the arg binding the value expr of filter f. Times
+// are bound the way cursors compare them: as UTC text on SQLite and for
+// timestamptz columns on MySQL.
+func filterArgExpr(f filterInfo, expr, dialect string) string {
+	if f.GoType != "*time.Time" {
+		return expr
+	}
+	switch {
+	case dialect == dburl.DialectSQLite:
+		return fmt.Sprintf("(%s).UTC().Format(%q)", expr, sqliteUTCLayout)
+	case f.UTC && dialect == dburl.DialectMySQL:
+		return fmt.Sprintf("(%s).UTC().Format(%q)", expr, mysqlUTCLayout)
+	case f.UTC:
+		return fmt.Sprintf("(%s).UTC()", expr)
+	}
+	return expr
+}
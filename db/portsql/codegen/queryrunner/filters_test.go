@@ -0,0 +1,213 @@
+package queryrunner
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+
+	_ "modernc.org/sqlite"
+)
+
+type filterTestTable struct{}
+
+func (filterTestTable) TableName() string { return "posts" }
+
+var (
+	postsID     = query.Int64Column{Table: "posts", Name: "id"}
+	postsAuthor = query.StringColumn{Table: "posts", Name: "author"}
+	postsStatus = query.StringColumn{Table: "posts", Name: "status"}
+)
+
+// listPostsQuery returns a paginated query of posts with filters on status
+// and created_at, scoped to an author when scoped is set.
+func listPostsQuery(scoped bool) query.SerializedQuery {
+	b := query.From(filterTestTable{}).Select(postsID, postsStatus)
+	if scoped {
+		b = b.Where(postsAuthor.Eq(query.Param[string]("author")))
+	}
+	return query.SerializedQuery{
+		Name:       "ListPosts",
+		ReturnType: query.ReturnPaginated,
+		AST:        query.SerializeAST(b.Build()),
+		CursorColumns: []query.SerializedColumn{
+			{Table: "posts", Name: "created_at", GoType: "time.Time"},
+			{Table: "posts", Name: "id", GoType: "int64"},
+		},
+		Filters: []query.SerializedFilter{
+			{Column: query.SerializedColumn{Table: "posts", Name: "status", GoType: "string"}, Op: string(query.OpEq), Param: "status"},
+			{Column: query.SerializedColumn{Table: "posts", Name: "created_at", GoType: "time.Time"}, Op: string(query.OpGe), Param: "created_after"},
+		},
+	}
+}
+
+func TestGenerateUnifiedRunner_Filters(t *testing.T) {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
+		cfg := UnifiedRunnerConfig{
+			ModulePath:  "myapp",
+			Dialect:     dialect,
+			UserQueries: []query.SerializedQuery{listPostsQuery(true)},
+		}
+		runner, err := GenerateUnifiedRunner(cfg)
+		if err != nil {
+			t.Fatalf("%s: GenerateUnifiedRunner() error = %v", dialect, err)
+		}
+		f := gofile.Parse(t, dialect+"/runner.go", runner)
+		if !f.HasFunc("queryFilters.apply") {
+			t.Errorf("%s: expected the queryFilters helper", dialect)
+		}
+		f.AssertStmts("QueryRunner.ListPosts",
+			"if params.Status != nil",
+			"sqlStr, args = r.listPostsFilters.apply(split, args, filters, filterArgs)",
+		)
+
+		types, err := GenerateSharedTypes(cfg)
+		if err != nil {
+			t.Fatalf("%s: GenerateSharedTypes() error = %v", dialect, err)
+		}
+		tf := gofile.Parse(t, dialect+"/types.go", types)
+		for field, want := range map[string]string{"Status": "*string", "CreatedAfter": "*time.Time"} {
+			if typ, _, _ := tf.Field("ListPostsParams", field); typ != want {
+				t.Errorf("%s: ListPostsParams.%s is %q, want %s", dialect, field, typ, want)
+			}
+		}
+	}
+}
+
+func TestGenerateUnifiedRunner_FiltersPrepared(t *testing.T) {
+	runner, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+		ModulePath:        "myapp",
+		Dialect:           dburl.DialectPostgres,
+		PrepareStatements: true,
+		UserQueries:       []query.SerializedQuery{listPostsQuery(false)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+	}
+	if !strings.Contains(string(runner), "sqlName = fmt.Sprint(sqlName, filters)") {
+		t.Error("each combination of filters should be prepared under its own name")
+	}
+}
+
+func TestCompileFilters_Splits(t *testing.T) {
+	compiler, err := getCompiler(dburl.DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := compileUserQueries([]query.SerializedQuery{listPostsQuery(true), listPostsQuery(false)}, compiler)
+	if err != nil {
+		t.Fatalf("compileUserQueries() error = %v", err)
+	}
+	scoped := infos[0]
+	if got := scoped.Filters[0].Cond; got != `"posts"."status" = ` {
+		t.Errorf("status condition = %q", got)
+	}
+	if got := scoped.Filters[1].Cond; got != `"posts"."created_at" >= ` {
+		t.Errorf("created_after condition = %q", got)
+	}
+	if s := scoped.FilterSplit; !s.Where || s.ArgsAt != 1 || !strings.HasSuffix(s.Head, `WHERE (("posts"."author" = $1)`) || !strings.HasPrefix(s.Tail, ") ORDER BY") {
+		t.Errorf("unexpected base split %+v", s)
+	}
+	if s := scoped.CursorFilterSplit; !s.Where || s.ArgsAt != 4 || !strings.Contains(s.Tail, "LIMIT $5") {
+		t.Errorf("unexpected cursor split %+v", s)
+	}
+	// The filter placeholders follow the query's own, so those after the
+	// conditions keep their numbers.
+	if s := infos[1].FilterSplit; s.Where || strings.Contains(s.Head, "WHERE") || !strings.Contains(s.Tail, "LIMIT $1") {
+		t.Errorf("unexpected split without WHERE %+v", s)
+	}
+}
+
+func TestCompileFilters_Rejects(t *testing.T) {
+	clash := listPostsQuery(true)
+	clash.Filters = append(clash.Filters, query.SerializedFilter{
+		Column: query.SerializedColumn{Table: "posts", Name: "author", GoType: "string"}, Op: string(query.OpEq), Param: "author",
+	})
+	grouped := listPostsQuery(false)
+	grouped.AST = query.SerializeAST(query.From(filterTestTable{}).Select(postsStatus).GroupBy(postsStatus).Build())
+
+	for name, tc := range map[string]struct {
+		sq   query.SerializedQuery
+		want string
+	}{
+		"param clash": {clash, `filter param "author" is already a param`},
+		"group by":    {grouped, "not supported on queries with GROUP BY"},
+	} {
+		_, err := GenerateUnifiedRunner(UnifiedRunnerConfig{ModulePath: "myapp", Dialect: dburl.DialectSQLite, UserQueries: []query.SerializedQuery{tc.sq}})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+// TestCompileFilters_SQLiteRoundTrip runs the split SQL with filters added
+// the way the generated apply does for ? placeholders.
+func TestCompileFilters_SQLiteRoundTrip(t *testing.T) {
+	compiler, err := getCompiler(dburl.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := compileUserQueries([]query.SerializedQuery{listPostsQuery(true)}, compiler)
+	if err != nil {
+		t.Fatalf("compileUserQueries() error = %v", err)
+	}
+	qi := infos[0]
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, author TEXT, status TEXT, created_at TEXT);
+		INSERT INTO posts VALUES
+			(1, 'ann', 'draft', '2026-01-01T00:00:00.000Z'),
+			(2, 'ann', 'published', '2026-02-01T00:00:00.000Z'),
+			(3, 'ann', 'published', '2026-03-01T00:00:00.000Z'),
+			(4, 'bob', 'published', '2026-03-01T00:00:00.000Z')`); err != nil {
+		t.Fatal(err)
+	}
+
+	apply := func(split filterSplit, args []any, set []int, values []any) (string, []any) {
+		sqlStr := split.Head
+		for i, c := range set {
+			if i == 0 && !split.Where {
+				sqlStr += " WHERE "
+			} else {
+				sqlStr += " AND "
+			}
+			sqlStr += qi.Filters[c].Cond + "?"
+		}
+		bound := append(append(append([]any{}, args[:split.ArgsAt]...), values...), args[split.ArgsAt:]...)
+		return sqlStr + split.Tail, bound
+	}
+	ids := func(sqlStr string, args []any) []int64 {
+		rows, err := db.Query(sqlStr, args...)
+		if err != nil {
+			t.Fatalf("query %s: %v", sqlStr, err)
+		}
+		defer rows.Close()
+		var got []int64
+		for rows.Next() {
+			var id int64
+			var status string
+			if err := rows.Scan(&id, &status); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, id)
+		}
+		return got
+	}
+
+	sqlStr, args := apply(qi.FilterSplit, []any{"ann", 10}, []int{0, 1}, []any{"published", "2026-02-15T00:00:00.000Z"})
+	if got := ids(sqlStr, args); len(got) != 1 || got[0] != 3 {
+		t.Errorf("filtered page = %v, want [3]", got)
+	}
+
+	sqlStr, args = apply(qi.CursorFilterSplit, []any{"ann", "2026-03-01T00:00:00.000Z", "2026-03-01T00:00:00.000Z", int64(3), 10}, []int{0}, []any{"published"})
+	if got := ids(sqlStr, args); len(got) != 1 || got[0] != 2 {
+		t.Errorf("filtered next page = %v, want [2]", got)
+	}
+}
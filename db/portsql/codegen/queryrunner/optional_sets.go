@@ -0,0 +1,238 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
+	"github.com/shipq/shipq/dbstrings"
+	"github.com/shipq/shipq/dburl"
+)
+
+// optionalSetInfo is an optional SET clause of an UPDATE query.
+type optionalSetInfo struct {
+	Param string
+	Head  string // compiled clause up to its placeholder, e.g. `"title" = `
+	Tail  string // compiled clause after its placeholder, e.g. `)` for a subquery
+}
+
+// setSplit is a compiled UPDATE query split where its optional SET clauses
+// go. The clauses come first in the SET list, followed by the fixed ones.
+type setSplit struct {
+	Head       string   // SQL up to and including "SET "
+	Tail       string   // SQL after the clauses, starting with ", " when there are fixed clauses
+	Keep       string   // no-op clause used when no clause is set and there are no fixed ones
+	ArgsAt     int      // number of args bound before the clauses
+	ParamOrder []string // params of Head and Tail in SQL order
+}
+
+// optionalSetsMarkerParam is the param standing in for the optional SET
+// clauses.
+const optionalSetsMarkerParam = "__sets"
+
+// hasOptionalSetClauses reports whether ast has a SET clause added with
+// SetOptional.
+func hasOptionalSetClauses(ast *query.AST) bool {
+	return slices.ContainsFunc(ast.SetClauses, func(s query.SetClause) bool { return s.Optional })
+}
+
+// compileOptionalSets compiles the optional SET clauses of the UPDATE query
+// sq into qi, and marks their params optional.
+func compileOptionalSets(qi *userQueryInfo, sq query.SerializedQuery, ast *query.AST, compiler *compile.Compiler, dialectName string) error {
+	if ast.Kind != query.UpdateQuery || (sq.ReturnType != query.ReturnExec && sq.ReturnType != query.ReturnApplied) {
+		return fmt.Errorf("query %s: optional SET clauses are only supported on UPDATE queries defined with MustDefineExec or MustDefineApplied", sq.Name)
+	}
+	dialect, err := getDialect(dialectName)
+	if err != nil {
+		return err
+	}
+
+	stmt := query.DeserializeAST(query.SerializeAST(ast))
+	stmt.SetClauses = nil
+	var first query.Column
+	for _, set := range ast.SetClauses {
+		if !set.Optional {
+			stmt.SetClauses = append(stmt.SetClauses, set)
+			continue
+		}
+		if first == nil {
+			first = set.Column
+		}
+		clauseSQL, params, err := compile.NewCompiler(filterMarkDialect{dialect, 1}).Compile(&query.AST{
+			Kind:       query.UpdateQuery,
+			FromTable:  ast.FromTable,
+			SetClauses: []query.SetClause{{Column: set.Column, Value: set.Value}},
+		})
+		if err != nil {
+			return fmt.Errorf("query %s: compiling optional SET clause for %q: %w", sq.Name, set.Column.ColumnName(), err)
+		}
+		if len(params) != 1 {
+			return fmt.Errorf("query %s: optional SET clause for %q must reference exactly one param", sq.Name, set.Column.ColumnName())
+		}
+		if slices.ContainsFunc(qi.OptionalSets, func(o optionalSetInfo) bool { return o.Param == params[0] }) {
+			return fmt.Errorf("query %s: param %q is used by more than one optional SET clause", sq.Name, params[0])
+		}
+		_, clause, _ := strings.Cut(clauseSQL, " SET ")
+		head, tail, _ := strings.Cut(clause, filterMarker)
+		qi.OptionalSets = append(qi.OptionalSets, optionalSetInfo{Param: params[0], Head: head, Tail: tail})
+	}
+
+	// The clauses are replaced by a marker ahead of the fixed clauses.
+	marker := query.SetClause{
+		Column: query.SimpleColumn{Table_: ast.FromTable.Name, Name_: first.ColumnName(), GoType_: "bool"},
+		Value:  query.ParamExpr{Name: optionalSetsMarkerParam, GoType: "bool"},
+	}
+	stmt.SetClauses = append([]query.SetClause{marker}, stmt.SetClauses...)
+	_, paramOrder, err := compiler.Compile(stmt)
+	if err != nil {
+		return fmt.Errorf("query %s: compiling optional SET clauses: %w", sq.Name, err)
+	}
+	for _, o := range qi.OptionalSets {
+		if slices.Contains(paramOrder, o.Param) {
+			return fmt.Errorf("query %s: param %q of an optional SET clause is also used elsewhere in the query", sq.Name, o.Param)
+		}
+	}
+	at := slices.Index(paramOrder, optionalSetsMarkerParam) + 1
+	sql, _, err := compile.NewCompiler(filterMarkDialect{dialect, at}).Compile(stmt)
+	if err != nil {
+		return fmt.Errorf("query %s: compiling optional SET clauses: %w", sq.Name, err)
+	}
+
+	head, tail, _ := strings.Cut(sql, filterMarker)
+	set := strings.LastIndex(head, " SET ")
+	if set < 0 {
+		return fmt.Errorf("query %s: could not find the SET clause in %s", sq.Name, sql)
+	}
+	qi.SetSplit = setSplit{
+		Head:       head[:set+len(" SET ")],
+		Tail:       tail,
+		ArgsAt:     at - 1,
+		ParamOrder: slices.DeleteFunc(paramOrder, func(p string) bool { return p == optionalSetsMarkerParam }),
+	}
+	if !strings.HasPrefix(tail, ", ") {
+		// Without fixed clauses an empty update sets a column to itself,
+		// so the statement stays valid and still matches its rows.
+		col, _, _ := strings.Cut(qi.OptionalSets[0].Head, " = ")
+		qi.SetSplit.Keep = col + " = " + col
+	}
+
+	for i, p := range qi.Params {
+		if slices.ContainsFunc(qi.OptionalSets, func(o optionalSetInfo) bool { return o.Param == p.Name }) {
+			qi.Params[i].Optional = true
+		}
+	}
+	return nil
+}
+
+// hasOptionalSets reports whether any query has optional SET clauses, in
+// which case the runner needs the optionalSets type.
+func hasOptionalSets(queries []userQueryInfo) bool {
+	for _, qi := range queries {
+		if len(qi.OptionalSets) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOptionalSetsType emits optionalSets, which adds the clauses of the
+// provided params to an UPDATE query at runtime. Their placeholders are
+// numbered after the query's own, or take their args at the clauses'
+// position for ? placeholders.
+func writeOptionalSetsType(buf *bytes.Buffer, dialect string) {
+	buf.WriteString(`// optionalSets is an UPDATE query split where its optional SET clauses go.
+type optionalSets struct {
+	head, tail string
+	keep       string      // no-op clause for an update setting nothing
+	argsAt     int         // args bound before the clauses
+	clauses    [][2]string // each clause split at its placeholder
+}
+
+// apply returns the query with the clauses of the provided params (indexes
+// into clauses) and args with their values bound.
+func (s optionalSets) apply(args []any, set []int, values []any) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString(s.head)
+	for i, c := range set {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(s.clauses[c][0])
+`)
+	switch dialect {
+	case dburl.DialectPostgres:
+		buf.WriteString("\t\tfmt.Fprintf(&sb, \"$%d\", len(args)+i+1)\n")
+	case dburl.DialectMSSQL:
+		buf.WriteString("\t\tfmt.Fprintf(&sb, \"@p%d\", len(args)+i+1)\n")
+	default:
+		buf.WriteString("\t\tsb.WriteString(\"?\")\n")
+	}
+	buf.WriteString(`		sb.WriteString(s.clauses[c][1])
+	}
+	if len(set) == 0 {
+		sb.WriteString(s.keep)
+		sb.WriteString(strings.TrimPrefix(s.tail, ", "))
+	} else {
+		sb.WriteString(s.tail)
+	}
+`)
+	switch dialect {
+	case dburl.DialectPostgres, dburl.DialectMSSQL:
+		buf.WriteString("\treturn sb.String(), append(args, values...)\n")
+	default:
+		buf.WriteString("\tbound := make([]any, 0, len(args)+len(values))\n")
+		buf.WriteString("\tbound = append(bound, args[:s.argsAt]...)\n")
+		buf.WriteString("\tbound = append(bound, values...)\n")
+		buf.WriteString("\treturn sb.String(), append(bound, args[s.argsAt:]...)\n")
+	}
+	buf.WriteString("}\n\n")
+}
+
+// setsField returns the QueryRunner field holding qi's optional SET clauses.
+func setsField(qi userQueryInfo) string {
+	return dbstrings.ToLowerCamel(qi.Name) + "Sets"
+}
+
+// writeOptionalSetsValue writes the optionalSets literal of qi.
+func writeOptionalSetsValue(buf *bytes.Buffer, qi userQueryInfo) {
+	s := qi.SetSplit
+	fmt.Fprintf(buf, "\t\t%s: optionalSets{\n", setsField(qi))
+	fmt.Fprintf(buf, "\t\t\thead: %q,\n", s.Head)
+	fmt.Fprintf(buf, "\t\t\ttail: %q,\n", s.Tail)
+	fmt.Fprintf(buf, "\t\t\tkeep: %q,\n", s.Keep)
+	fmt.Fprintf(buf, "\t\t\targsAt: %d,\n", s.ArgsAt)
+	buf.WriteString("\t\t\tclauses: [][2]string{\n")
+	for _, o := range qi.OptionalSets {
+		fmt.Fprintf(buf, "\t\t\t\t{%q, %q},\n", o.Head, o.Tail)
+	}
+	buf.WriteString("\t\t\t},\n")
+	buf.WriteString("\t\t},\n")
+}
+
+// writeOptionalSetsApply writes the args of an UPDATE method with optional
+// SET clauses and the SQL with the clauses of the provided params. It
+// returns the expressions naming and holding that SQL.
+func writeOptionalSetsApply(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig) (nameExpr, sqlExpr string) {
+	fixed := qi
+	fixed.ParamOrder = qi.SetSplit.ParamOrder
+	writeArgsSlice(buf, fixed)
+	buf.WriteString("\tvar sets []int\n")
+	buf.WriteString("\tvar setArgs []any\n")
+	for i, o := range qi.OptionalSets {
+		field := "params." + dbstrings.ToPascalCase(o.Param)
+		fmt.Fprintf(buf, "\tif %s != nil {\n", field)
+		fmt.Fprintf(buf, "\t\tsets = append(sets, %d)\n", i)
+		fmt.Fprintf(buf, "\t\tsetArgs = append(setArgs, %s)\n", fieldArgExpr(qi, o.Param, "*"+field))
+		buf.WriteString("\t}\n")
+	}
+	fmt.Fprintf(buf, "\tsqlStr, args := r.%s.apply(args, sets, setArgs)\n", setsField(qi))
+	if !cfg.PrepareStatements {
+		return "", "sqlStr"
+	}
+	// Each combination of clauses is its own statement.
+	fmt.Fprintf(buf, "\tsqlName := fmt.Sprint(%q, sets)\n", qi.Name)
+	return "sqlName", "sqlStr"
+}
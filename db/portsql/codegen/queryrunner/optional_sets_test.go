@@ -0,0 +1,237 @@
+package queryrunner
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+)
+
+type authorsTestTable struct{}
+
+func (authorsTestTable) TableName() string { return "authors" }
+
+var (
+	postsPublicID  = query.StringColumn{Table: "posts", Name: "public_id"}
+	postsTitle     = query.StringColumn{Table: "posts", Name: "title"}
+	postsSummary   = query.NullStringColumn{Table: "posts", Name: "summary"}
+	postsAuthorID  = query.Int64Column{Table: "posts", Name: "author_id"}
+	postsUpdatedAt = query.TimeColumn{Table: "posts", Name: "updated_at"}
+	authorsID      = query.Int64Column{Table: "authors", Name: "id"}
+	authorsPublic  = query.StringColumn{Table: "authors", Name: "public_id"}
+)
+
+// updatePostQuery returns an update of a post by public ID whose title,
+// summary and author are optional, also stamping updated_at when stamped
+// is set.
+func updatePostQuery(stamped bool) query.SerializedQuery {
+	b := query.Update(filterTestTable{}).
+		SetOptional(postsTitle, query.Param[string]("title")).
+		SetOptional(postsSummary, query.Param[*string]("summary")).
+		SetOptional(postsAuthorID, query.Subquery(
+			query.From(authorsTestTable{}).
+				Select(authorsID).
+				Where(authorsPublic.Eq(query.Param[string]("authorId")))))
+	if stamped {
+		b = b.Set(postsUpdatedAt, query.Now())
+	}
+	return query.SerializedQuery{
+		Name:       "UpdatePost",
+		ReturnType: query.ReturnExec,
+		AST:        query.SerializeAST(b.Where(postsPublicID.Eq(query.Param[string]("publicId"))).Build()),
+	}
+}
+
+func TestGenerateUnifiedRunner_OptionalSets(t *testing.T) {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
+		cfg := UnifiedRunnerConfig{
+			ModulePath:  "myapp",
+			Dialect:     dialect,
+			UserQueries: []query.SerializedQuery{updatePostQuery(true)},
+		}
+		runner, err := GenerateUnifiedRunner(cfg)
+		if err != nil {
+			t.Fatalf("%s: GenerateUnifiedRunner() error = %v", dialect, err)
+		}
+		f := gofile.Parse(t, dialect+"/runner.go", runner)
+		if !f.HasFunc("optionalSets.apply") {
+			t.Errorf("%s: expected the optionalSets helper", dialect)
+		}
+		f.AssertStmts("QueryRunner.UpdatePost",
+			"if params.Summary != nil",
+			"setArgs = append(setArgs, *params.Summary)",
+			"sqlStr, args := r.updatePostSets.apply(args, sets, setArgs)",
+			"return r.db.ExecContext(ctx, sqlStr, args...)",
+		)
+
+		types, err := GenerateSharedTypes(cfg)
+		if err != nil {
+			t.Fatalf("%s: GenerateSharedTypes() error = %v", dialect, err)
+		}
+		tf := gofile.Parse(t, dialect+"/types.go", types)
+		for field, want := range map[string]string{"Title": "*string", "Summary": "**string", "AuthorId": "*string", "PublicId": "string"} {
+			if typ, _, _ := tf.Field("UpdatePostParams", field); typ != want {
+				t.Errorf("%s: UpdatePostParams.%s is %q, want %s", dialect, field, typ, want)
+			}
+		}
+	}
+}
+
+func TestGenerateUnifiedRunner_OptionalSetsPrepared(t *testing.T) {
+	runner, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+		ModulePath:        "myapp",
+		Dialect:           dburl.DialectPostgres,
+		PrepareStatements: true,
+		UserQueries:       []query.SerializedQuery{updatePostQuery(true)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+	}
+	if !strings.Contains(string(runner), `sqlName := fmt.Sprint("UpdatePost", sets)`) {
+		t.Error("each combination of SET clauses should be prepared under its own name")
+	}
+}
+
+func TestCompileOptionalSets_Splits(t *testing.T) {
+	compiler, err := getCompiler(dburl.DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamped := updatePostQuery(true)
+	plain := updatePostQuery(false)
+	plain.Name = "UpdatePostPlain"
+	infos, err := compileUserQueries([]query.SerializedQuery{stamped, plain}, compiler)
+	if err != nil {
+		t.Fatalf("compileUserQueries() error = %v", err)
+	}
+
+	qi := infos[0]
+	if got := qi.OptionalSets[0]; got.Param != "title" || got.Head != `"title" = ` || got.Tail != "" {
+		t.Errorf("title clause = %+v", got)
+	}
+	if got := qi.OptionalSets[2]; got.Param != "authorId" || !strings.HasPrefix(got.Head, `"author_id" = (SELECT`) || got.Tail != "))" {
+		t.Errorf("author clause = %+v", got)
+	}
+	// The clause placeholders are numbered after the query's own.
+	if s := qi.SetSplit; s.Head != `UPDATE "posts" SET ` || s.Tail != `, "updated_at" = NOW() WHERE ("posts"."public_id" = $1)` || s.Keep != "" || s.ArgsAt != 0 {
+		t.Errorf("unexpected split %+v", s)
+	}
+	if len(qi.SetSplit.ParamOrder) != 1 || qi.SetSplit.ParamOrder[0] != "publicId" {
+		t.Errorf("split params = %v", qi.SetSplit.ParamOrder)
+	}
+
+	if s := infos[1].SetSplit; s.Keep != `"title" = "title"` || !strings.HasPrefix(s.Tail, " WHERE") {
+		t.Errorf("update without fixed clauses should keep a no-op clause: %+v", s)
+	}
+}
+
+func TestCompileOptionalSets_Rejects(t *testing.T) {
+	one := updatePostQuery(false)
+	one.ReturnType = query.ReturnOne
+	shared := query.SerializedQuery{
+		Name:       "RenamePost",
+		ReturnType: query.ReturnExec,
+		AST: query.SerializeAST(query.Update(filterTestTable{}).
+			SetOptional(postsTitle, query.Param[string]("title")).
+			Where(postsTitle.Ne(query.Param[string]("title"))).
+			Build()),
+	}
+	constant := query.SerializedQuery{
+		Name:       "ClearPost",
+		ReturnType: query.ReturnExec,
+		AST: query.SerializeAST(query.Update(filterTestTable{}).
+			SetOptional(postsTitle, query.Literal("")).
+			Build()),
+	}
+
+	for name, tc := range map[string]struct {
+		sq   query.SerializedQuery
+		want string
+	}{
+		"return one": {one, "only supported on UPDATE queries defined with MustDefineExec"},
+		"shared":     {shared, `param "title" of an optional SET clause is also used elsewhere`},
+		"no param":   {constant, "must reference exactly one param"},
+	} {
+		_, err := GenerateUnifiedRunner(UnifiedRunnerConfig{ModulePath: "myapp", Dialect: dburl.DialectSQLite, UserQueries: []query.SerializedQuery{tc.sq}})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+// TestCompileOptionalSets_SQLiteRoundTrip runs the split SQL with clauses
+// added the way the generated apply does for ? placeholders.
+func TestCompileOptionalSets_SQLiteRoundTrip(t *testing.T) {
+	compiler, err := getCompiler(dburl.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := compileUserQueries([]query.SerializedQuery{updatePostQuery(false)}, compiler)
+	if err != nil {
+		t.Fatalf("compileUserQueries() error = %v", err)
+	}
+	qi := infos[0]
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE authors (id INTEGER PRIMARY KEY, public_id TEXT);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, public_id TEXT, title TEXT, summary TEXT, author_id INTEGER);
+		INSERT INTO authors VALUES (1, 'ann'), (2, 'bob');
+		INSERT INTO posts VALUES (1, 'p1', 'Hello', 'First post', 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	apply := func(set []int, values []any) {
+		t.Helper()
+		s := qi.SetSplit
+		sqlStr := s.Head
+		for i, c := range set {
+			if i > 0 {
+				sqlStr += ", "
+			}
+			sqlStr += qi.OptionalSets[c].Head + "?" + qi.OptionalSets[c].Tail
+		}
+		if len(set) == 0 {
+			sqlStr += s.Keep + strings.TrimPrefix(s.Tail, ", ")
+		} else {
+			sqlStr += s.Tail
+		}
+		args := []any{"p1"}
+		bound := append(append(append([]any{}, args[:s.ArgsAt]...), values...), args[s.ArgsAt:]...)
+		res, err := db.Exec(sqlStr, bound...)
+		if err != nil {
+			t.Fatalf("exec %s: %v", sqlStr, err)
+		}
+		if n, _ := res.RowsAffected(); n != 1 {
+			t.Errorf("%s: rows affected = %d, want 1", sqlStr, n)
+		}
+	}
+	row := func() (title string, summary sql.NullString, author int64) {
+		t.Helper()
+		if err := db.QueryRow(`SELECT title, summary, author_id FROM posts WHERE public_id = 'p1'`).Scan(&title, &summary, &author); err != nil {
+			t.Fatal(err)
+		}
+		return title, summary, author
+	}
+
+	apply([]int{2}, []any{"bob"})
+	if title, summary, author := row(); title != "Hello" || summary.String != "First post" || author != 2 {
+		t.Errorf("after setting the author: %q %v %d", title, summary, author)
+	}
+
+	apply([]int{0, 1}, []any{"Hi", nil})
+	if title, summary, author := row(); title != "Hi" || summary.Valid || author != 2 {
+		t.Errorf("after setting title and clearing summary: %q %v %d", title, summary, author)
+	}
+
+	apply(nil, nil)
+	if title, _, _ := row(); title != "Hi" {
+		t.Errorf("an empty update changed the title to %q", title)
+	}
+}
@@ -0,0 +1,382 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
+	"github.com/shipq/shipq/dbstrings"
+	"github.com/shipq/shipq/dburl"
+)
+
+// preloadBatchSize caps the IN list of one preload query, keeping it under
+// SQLite's bind-parameter limit.
+const preloadBatchSize = 500
+
+// preloadInfo describes one generated Preload method: it reads KeyField
+// (the referenced row's public_id) from each element of a TargetType slice
+// and sets Field to the matching RefTable row.
+type preloadInfo struct {
+	Method     string // e.g. "PreloadPostAuthors"
+	TargetType string // e.g. "GetPostByPublicIDResult"
+	Table      string // e.g. "posts"
+	Column     string // e.g. "author_id"
+	KeyField   string // e.g. "AuthorId"
+	KeyPtr     bool   // KeyField is *string (nullable reference)
+	Field      string // e.g. "Author"
+	RefTable   string // e.g. "users"
+}
+
+// preloadTable describes a referenced table: the Preloaded<Table> struct
+// and the loader shared by every Preload method that targets it.
+type preloadTable struct {
+	Name    string
+	Type    string // e.g. "PreloadedUser"
+	Loader  string // e.g. "preloadUsers"
+	Columns []ddl.ColumnDefinition
+	SoftDel bool
+}
+
+// collectPreloads finds the References columns of CRUD tables whose Get
+// result and List item expose the referenced row's public_id, and returns
+// one preloadInfo per (query, column) together with the referenced tables.
+// Nothing is generated without a schema.
+func collectPreloads(cfg UnifiedRunnerConfig, queries []userQueryInfo) ([]preloadInfo, []preloadTable) {
+	if len(cfg.Schema) == 0 {
+		return nil, nil
+	}
+
+	byName := make(map[string]userQueryInfo, len(queries))
+	for _, qi := range queries {
+		byName[qi.Name] = qi
+	}
+
+	var preloads []preloadInfo
+	refTables := make(map[string]preloadTable)
+
+	tableNames := make([]string, 0, len(cfg.Schema))
+	for name := range cfg.Schema {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+
+	for _, tableName := range tableNames {
+		table := cfg.Schema[tableName]
+		for _, col := range table.Columns {
+			relation := strings.TrimSuffix(col.Name, "_id")
+			if col.References == "" || relation == col.Name {
+				continue
+			}
+			ref, ok := preloadableTable(cfg.Schema, col.References)
+			if !ok {
+				continue
+			}
+
+			targets := []struct{ query, method string }{
+				{codegen.CRUD.GetMethodName(tableName), codegen.CRUD.PreloadMethodName(tableName, relation)},
+				{codegen.CRUD.ListMethodName(tableName), codegen.CRUD.ListPreloadMethodName(tableName, relation)},
+			}
+			for _, t := range targets {
+				qi, ok := byName[t.query]
+				if !ok {
+					continue
+				}
+				p, ok := preloadFor(qi, col, relation)
+				if !ok {
+					continue
+				}
+				p.Method = t.method
+				p.Table = tableName
+				p.RefTable = ref.Name
+				preloads = append(preloads, p)
+				refTables[ref.Name] = ref
+			}
+		}
+	}
+
+	var tables []preloadTable
+	for _, name := range sortedKeys(refTables) {
+		tables = append(tables, refTables[name])
+	}
+	return preloads, tables
+}
+
+// preloadFor checks that qi returns col as the referenced public_id and that
+// the attached field name is free, and fills in the target type and fields.
+func preloadFor(qi userQueryInfo, col ddl.ColumnDefinition, relation string) (preloadInfo, bool) {
+	var target string
+	switch qi.ReturnType {
+	case query.ReturnOne, query.ReturnMany:
+		target = qi.Name + "Result"
+	case query.ReturnPaginated:
+		target = qi.Name + "Item"
+	default:
+		return preloadInfo{}, false
+	}
+
+	p := preloadInfo{
+		TargetType: target,
+		Column:     col.Name,
+		KeyField:   dbstrings.ToPascalCase(col.Name),
+		Field:      dbstrings.ToPascalCase(relation),
+	}
+	found := false
+	for _, r := range qi.Results {
+		switch r.Name {
+		case p.Field:
+			return preloadInfo{}, false
+		case p.KeyField:
+			if r.GoType != "string" && r.GoType != "*string" {
+				return preloadInfo{}, false
+			}
+			p.KeyPtr = r.GoType == "*string"
+			found = true
+		}
+	}
+	return p, found
+}
+
+// preloadableTable returns the columns loaded for a referenced table: its
+// public_id and plain columns. Internal ids, further references and
+// columns that need dialect-specific conversion (points, JSON,
+// timestamptz) are left out. Tables without public_id cannot be preloaded.
+func preloadableTable(schema map[string]ddl.Table, name string) (preloadTable, bool) {
+	table, ok := schema[name]
+	if !ok {
+		return preloadTable{}, false
+	}
+	analysis := portsqlcodegen.AnalyzeTable(table)
+	if !analysis.HasPublicID {
+		return preloadTable{}, false
+	}
+
+	pt := preloadTable{
+		Name:    name,
+		Type:    codegen.CRUD.PreloadedType(name),
+		Loader:  "preload" + dbstrings.ToPascalCase(name),
+		SoftDel: analysis.HasDeletedAt,
+	}
+	for _, col := range table.Columns {
+		if col.Name == "public_id" {
+			pt.Columns = append([]ddl.ColumnDefinition{col}, pt.Columns...)
+			continue
+		}
+		switch {
+		case col.Name == "id", col.Name == "deleted_at", col.Name == "author_account_id":
+			continue
+		case col.References != "":
+			continue
+		case ddl.IsSpatialType(col.Type), col.Type == ddl.JSONType, col.Type == ddl.TimestamptzType, col.Custom != nil:
+			continue
+		}
+		pt.Columns = append(pt.Columns, col)
+	}
+	return pt, true
+}
+
+func sortedKeys(m map[string]preloadTable) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// preloadFields returns the extra fields Preload methods set on typeName.
+func preloadFields(preloads []preloadInfo, typeName string) []preloadInfo {
+	var out []preloadInfo
+	for _, p := range preloads {
+		if p.TargetType == typeName {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// writePreloadFields writes the fields Preload methods fill in. They stay
+// nil until the matching Preload method is called.
+func writePreloadFields(buf *bytes.Buffer, fields []preloadInfo) {
+	for _, p := range fields {
+		fmt.Fprintf(buf, "\t// Set by %s.\n", p.Method)
+		fmt.Fprintf(buf, "\t%s *%s\n", p.Field, codegen.CRUD.PreloadedType(p.RefTable))
+	}
+}
+
+// writePreloadedTypes writes one Preloaded<Table> struct per referenced table.
+func writePreloadedTypes(buf *bytes.Buffer, tables []preloadTable) {
+	for _, t := range tables {
+		fmt.Fprintf(buf, "// %s is a %s row attached to a result by a Preload method.\n", t.Type, dbstrings.ToSingular(t.Name))
+		fmt.Fprintf(buf, "type %s struct {\n", t.Type)
+		for _, col := range t.Columns {
+			fmt.Fprintf(buf, "\t%s %s\n", dbstrings.ToPascalCase(col.Name), portsqlcodegen.MapColumnType(col).GoType)
+		}
+		buf.WriteString("}\n\n")
+	}
+}
+
+// writePreloadInterfaceMethods adds the Preload methods to the Runner interface.
+func writePreloadInterfaceMethods(buf *bytes.Buffer, preloads []preloadInfo) {
+	for _, p := range preloads {
+		fmt.Fprintf(buf, "\t%s(ctx context.Context, items []%s) error\n", p.Method, p.TargetType)
+	}
+}
+
+// writePreloadMethods writes the Preload methods, the per-table loaders and
+// the placeholder helper they share.
+func writePreloadMethods(buf *bytes.Buffer, preloads []preloadInfo, tables []preloadTable, dialect string) error {
+	if len(preloads) == 0 {
+		return nil
+	}
+	d, err := getDialect(dialect)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(buf, "// preloadBatchSize caps the IN list of one preload query.\nconst preloadBatchSize = %d\n\n", preloadBatchSize)
+
+	buf.WriteString("// preloadPlaceholders returns n comma-separated bind placeholders.\n")
+	buf.WriteString("func preloadPlaceholders(n int) string {\n")
+	buf.WriteString("\tvar b strings.Builder\n")
+	buf.WriteString("\tfor i := 0; i < n; i++ {\n")
+	buf.WriteString("\t\tif i > 0 {\n\t\t\tb.WriteString(\", \")\n\t\t}\n")
+	if dialect == dburl.DialectPostgres {
+		buf.WriteString("\t\tfmt.Fprintf(&b, \"$%d\", i+1)\n")
+	} else if dialect == dburl.DialectMSSQL {
+		buf.WriteString("\t\tfmt.Fprintf(&b, \"@p%d\", i+1)\n")
+	} else {
+		buf.WriteString("\t\tb.WriteString(\"?\")\n")
+	}
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn b.String()\n")
+	buf.WriteString("}\n\n")
+
+	loaders := make(map[string]preloadTable, len(tables))
+	for _, t := range tables {
+		loaders[t.Name] = t
+		writePreloadLoader(buf, t, d, dialect == dburl.DialectSQLite)
+	}
+
+	for _, p := range preloads {
+		ref := loaders[p.RefTable]
+		key := "items[i]." + p.KeyField
+		fmt.Fprintf(buf, "// %s loads the %s referenced by %s.%s with one query per\n", p.Method, p.RefTable, p.Table, p.Column)
+		fmt.Fprintf(buf, "// %d distinct keys and sets each item's %s. Items whose %s is\n", preloadBatchSize, p.Field, dbstrings.ToSingular(p.RefTable))
+		buf.WriteString("// missing or soft-deleted keep a nil field.\n")
+		fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, items []queries.%s) error {\n", p.Method, p.TargetType)
+		buf.WriteString("\tkeys := make([]any, 0, len(items))\n")
+		buf.WriteString("\tseen := make(map[string]bool, len(items))\n")
+		buf.WriteString("\tfor i := range items {\n")
+		if p.KeyPtr {
+			fmt.Fprintf(buf, "\t\tif %s == nil {\n\t\t\tcontinue\n\t\t}\n", key)
+			fmt.Fprintf(buf, "\t\tkey := *%s\n", key)
+		} else {
+			fmt.Fprintf(buf, "\t\tkey := %s\n", key)
+		}
+		buf.WriteString("\t\tif key == \"\" || seen[key] {\n\t\t\tcontinue\n\t\t}\n")
+		buf.WriteString("\t\tseen[key] = true\n")
+		buf.WriteString("\t\tkeys = append(keys, key)\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tif len(keys) == 0 {\n\t\treturn nil\n\t}\n\n")
+		fmt.Fprintf(buf, "\tfound, err := r.%s(ctx, keys)\n", ref.Loader)
+		buf.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n")
+		buf.WriteString("\tfor i := range items {\n")
+		if p.KeyPtr {
+			fmt.Fprintf(buf, "\t\tif %s != nil {\n", key)
+			fmt.Fprintf(buf, "\t\t\titems[i].%s = found[*%s]\n", p.Field, key)
+			buf.WriteString("\t\t}\n")
+		} else {
+			fmt.Fprintf(buf, "\t\titems[i].%s = found[%s]\n", p.Field, key)
+		}
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn nil\n")
+		buf.WriteString("}\n\n")
+	}
+	return nil
+}
+
+// writePreloadLoader writes the loader that fetches rows of t by public_id
+// in batches of preloadBatchSize.
+func writePreloadLoader(buf *bytes.Buffer, t preloadTable, d compile.Dialect, isSQLite bool) {
+	cols := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		cols[i] = quoteIdentifier(col.Name, d)
+	}
+	prefix := "SELECT " + strings.Join(cols, ", ") + " FROM " + quoteIdentifier(t.Name, d) + " WHERE "
+	if t.SoftDel {
+		prefix += quoteIdentifier("deleted_at", d) + " IS NULL AND "
+	}
+	prefix += quoteIdentifier("public_id", d) + " IN ("
+
+	fmt.Fprintf(buf, "// %s fetches live %s rows by public_id.\n", t.Loader, t.Name)
+	fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, keys []any) (map[string]*queries.%s, error) {\n", t.Loader, t.Type)
+	fmt.Fprintf(buf, "\tfound := make(map[string]*queries.%s, len(keys))\n", t.Type)
+	buf.WriteString("\tfor start := 0; start < len(keys); start += preloadBatchSize {\n")
+	buf.WriteString("\t\tbatch := keys[start:]\n")
+	buf.WriteString("\t\tif len(batch) > preloadBatchSize {\n\t\t\tbatch = batch[:preloadBatchSize]\n\t\t}\n")
+	fmt.Fprintf(buf, "\t\trows, err := r.db.QueryContext(ctx, %q+preloadPlaceholders(len(batch))+\")\", batch...)\n", prefix)
+	buf.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+	buf.WriteString("\t\tfor rows.Next() {\n")
+	fmt.Fprintf(buf, "\t\t\tvar item queries.%s\n", t.Type)
+
+	var scanTargets []string
+	var conversions []string
+	for _, col := range t.Columns {
+		field := dbstrings.ToPascalCase(col.Name)
+		goType := portsqlcodegen.MapColumnType(col).GoType
+		if isSQLite && (goType == "time.Time" || goType == "*time.Time") {
+			tmp := dbstrings.ToLowerCamel(field) + "Raw"
+			if goType == "time.Time" {
+				fmt.Fprintf(buf, "\t\t\tvar %s string\n", tmp)
+				conversions = append(conversions, fmt.Sprintf("item.%s, err = parseSQLiteTime(%s)", field, tmp))
+			} else {
+				fmt.Fprintf(buf, "\t\t\tvar %s sql.NullString\n", tmp)
+				conversions = append(conversions, fmt.Sprintf("item.%s, err = parseSQLiteNullTime(%s)", field, tmp))
+			}
+			scanTargets = append(scanTargets, "&"+tmp)
+			continue
+		}
+		scanTargets = append(scanTargets, "&item."+field)
+	}
+
+	buf.WriteString("\t\t\tif err := rows.Scan(\n")
+	for _, target := range scanTargets {
+		fmt.Fprintf(buf, "\t\t\t\t%s,\n", target)
+	}
+	buf.WriteString("\t\t\t); err != nil {\n")
+	buf.WriteString("\t\t\t\trows.Close()\n")
+	buf.WriteString("\t\t\t\treturn nil, err\n")
+	buf.WriteString("\t\t\t}\n")
+	for _, conv := range conversions {
+		fmt.Fprintf(buf, "\t\t\tif %s; err != nil {\n", conv)
+		buf.WriteString("\t\t\t\trows.Close()\n")
+		buf.WriteString("\t\t\t\treturn nil, err\n")
+		buf.WriteString("\t\t\t}\n")
+	}
+	buf.WriteString("\t\t\tfound[item.PublicId] = &item\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\terr = rows.Err()\n")
+	buf.WriteString("\t\trows.Close()\n")
+	buf.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn found, nil\n")
+	buf.WriteString("}\n\n")
+}
+
+// preloadTypesNeedTime reports whether a Preloaded struct has a time field.
+func preloadTypesNeedTime(tables []preloadTable) bool {
+	for _, t := range tables {
+		for _, col := range t.Columns {
+			if needsTimeImport(portsqlcodegen.MapColumnType(col).GoType) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package queryrunner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/ref"
+	"github.com/shipq/shipq/dburl"
+)
+
+// preloadTestSchema returns users and posts tables where posts.author_id
+// references users.
+func preloadTestSchema(t *testing.T, nullableAuthor bool) map[string]ddl.Table {
+	t.Helper()
+	plan := migrate.NewPlan()
+	plan.SetCurrentMigration("20260101120000_create_users")
+	if _, err := plan.AddTable("users", func(tb *ddl.TableBuilder) error {
+		tb.String("name")
+		tb.JSON("settings")
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	plan.SetCurrentMigration("20260101120001_create_posts")
+	if _, err := plan.AddTable("posts", func(tb *ddl.TableBuilder) error {
+		tb.String("title")
+		author := tb.Bigint("author_id").References(&ref.TableRef{Name: "users"})
+		if nullableAuthor {
+			author.Nullable()
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to create posts: %v", err)
+	}
+	return plan.Schema.Tables
+}
+
+// preloadTestQuery builds a CRUD-shaped query on posts returning the
+// author's public_id as author_id.
+func preloadTestQuery(name string, rt query.QueryReturnType, authorType string) query.SerializedQuery {
+	col := func(table, name, goType, alias string) query.SerializedSelectExpr {
+		return query.SerializedSelectExpr{
+			Alias: alias,
+			Expr: query.SerializedExpr{
+				Type:   "column",
+				Column: &query.SerializedColumn{Table: table, Name: name, GoType: goType},
+			},
+		}
+	}
+	return query.SerializedQuery{
+		Name:       name,
+		ReturnType: rt,
+		AST: &query.SerializedAST{
+			Kind:      "select",
+			FromTable: query.SerializedTableRef{Name: "posts"},
+			SelectCols: []query.SerializedSelectExpr{
+				col("posts", "public_id", "string", ""),
+				col("posts", "title", "string", ""),
+				col("users", "public_id", authorType, "author_id"),
+			},
+		},
+	}
+}
+
+func TestPreloads_GeneratedForReferenceColumns(t *testing.T) {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
+		t.Run(dialect, func(t *testing.T) {
+			cfg := UnifiedRunnerConfig{
+				ModulePath: "example.com/myapp",
+				Dialect:    dialect,
+				Schema:     preloadTestSchema(t, false),
+				UserQueries: []query.SerializedQuery{
+					preloadTestQuery("GetPostByPublicID", query.ReturnOne, "string"),
+					preloadTestQuery("ListPosts", query.ReturnMany, "string"),
+				},
+			}
+
+			types, err := GenerateSharedTypes(cfg)
+			if err != nil {
+				t.Fatalf("GenerateSharedTypes failed: %v", err)
+			}
+			runner, err := GenerateUnifiedRunner(cfg)
+			if err != nil {
+				t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+			}
+			tf := gofile.Parse(t, "types.go", types)
+			if !tf.HasType("PreloadedUser") {
+				t.Fatal("types.go should declare PreloadedUser")
+			}
+			if _, _, ok := tf.Field("PreloadedUser", "Settings"); ok {
+				t.Error("PreloadedUser should not include JSON columns")
+			}
+			if typ, _, _ := tf.Field("GetPostByPublicIDResult", "Author"); typ != "*PreloadedUser" {
+				t.Errorf("GetPostByPublicIDResult.Author is %q, want *PreloadedUser", typ)
+			}
+			runnerIface := tf.Type("Runner")
+			for _, want := range []string{
+				"PreloadPostAuthors(ctx context.Context, items []GetPostByPublicIDResult) error",
+				"PreloadListPostsAuthors(ctx context.Context, items []ListPostsResult) error",
+			} {
+				if !strings.Contains(runnerIface, want) {
+					t.Errorf("Runner does not declare %s", want)
+				}
+			}
+
+			// One loader per referenced table, shared by both methods.
+			f := gofile.Parse(t, "runner.go", runner)
+			f.AssertSignature("QueryRunner.PreloadPostAuthors", "func (r *QueryRunner) PreloadPostAuthors(ctx context.Context, items []queries.GetPostByPublicIDResult) error")
+			f.AssertSignature("QueryRunner.PreloadListPostsAuthors", "func (r *QueryRunner) PreloadListPostsAuthors(ctx context.Context, items []queries.ListPostsResult) error")
+			for _, method := range []string{"QueryRunner.PreloadPostAuthors", "QueryRunner.PreloadListPostsAuthors"} {
+				if f.Calls(method, "r.preloadUsers") != 1 {
+					t.Errorf("%s should load the authors with preloadUsers", method)
+				}
+			}
+			f.AssertStmts("QueryRunner.preloadUsers", "found[item.PublicId] = &item")
+			// Soft-deleted authors are not preloaded.
+			if !slices.ContainsFunc(f.Strings("QueryRunner.preloadUsers"), func(s string) bool { return strings.Contains(s, "IS NULL AND") }) {
+				t.Error("preloadUsers should skip soft-deleted rows")
+			}
+		})
+	}
+}
+
+func TestPreloads_DialectPlaceholders(t *testing.T) {
+	tests := []struct {
+		dialect string
+		sql     string
+		bind    string
+	}{
+		{dburl.DialectPostgres, `"deleted_at\" IS NULL AND \"public_id\" IN (`, `"$%d", i+1`},
+		{dburl.DialectMySQL, "`deleted_at` IS NULL AND `public_id` IN (", `b.WriteString("?")`},
+		{dburl.DialectSQLite, `"deleted_at\" IS NULL AND \"public_id\" IN (`, `b.WriteString("?")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			runner, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+				ModulePath:  "example.com/myapp",
+				Dialect:     tt.dialect,
+				Schema:      preloadTestSchema(t, false),
+				UserQueries: []query.SerializedQuery{preloadTestQuery("GetPostByPublicID", query.ReturnOne, "string")},
+			})
+			if err != nil {
+				t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+			}
+			code := string(runner)
+			if !strings.Contains(code, tt.sql) {
+				t.Errorf("expected loader SQL to contain %s", tt.sql)
+			}
+			if !strings.Contains(code, tt.bind) {
+				t.Errorf("expected placeholder helper to contain %s", tt.bind)
+			}
+			if tt.dialect == dburl.DialectSQLite && !strings.Contains(code, "parseSQLiteTime(createdAtRaw)") {
+				t.Error("sqlite loader should parse time columns from text")
+			}
+		})
+	}
+}
+
+func TestPreloads_NullableReference(t *testing.T) {
+	runner, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectPostgres,
+		Schema:      preloadTestSchema(t, true),
+		UserQueries: []query.SerializedQuery{preloadTestQuery("GetPostByPublicID", query.ReturnOne, "*string")},
+	})
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	code := string(runner)
+	if !strings.Contains(code, "if items[i].AuthorId == nil {") {
+		t.Error("nullable keys should be skipped when nil")
+	}
+	if !strings.Contains(code, "items[i].Author = found[*items[i].AuthorId]") {
+		t.Error("nullable keys should be dereferenced when attaching")
+	}
+}
+
+func TestPreloads_NotGeneratedWithoutSchema(t *testing.T) {
+	cfg := UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{preloadTestQuery("GetPostByPublicID", query.ReturnOne, "string")},
+	}
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	runner, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	if strings.Contains(string(types), "Preload") || strings.Contains(string(runner), "Preload") {
+		t.Error("no preload code should be generated without a schema")
+	}
+}
+
+func TestPreloads_SkipsFieldCollision(t *testing.T) {
+	q := preloadTestQuery("GetPostByPublicID", query.ReturnOne, "string")
+	q.AST.SelectCols = append(q.AST.SelectCols, query.SerializedSelectExpr{
+		Alias: "author",
+		Expr: query.SerializedExpr{
+			Type:   "column",
+			Column: &query.SerializedColumn{Table: "users", Name: "name", GoType: "string"},
+		},
+	})
+	types, err := GenerateSharedTypes(UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectPostgres,
+		Schema:      preloadTestSchema(t, false),
+		UserQueries: []query.SerializedQuery{q},
+	})
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	if strings.Contains(string(types), "PreloadPostAuthors") {
+		t.Error("preload should be skipped when the result already has an Author field")
+	}
+}
@@ -0,0 +1,156 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// preparedMethods maps a Querier method to the QueryRunner method that runs
+// the same query through the runner's statement cache.
+var preparedMethods = map[string]string{
+	"ExecContext":     "execPrepared",
+	"QueryContext":    "queryPrepared",
+	"QueryRowContext": "queryRowPrepared",
+}
+
+// dbCall returns the expression running the query sqlExpr with args via the
+// Querier method ("ExecContext", "QueryContext" or "QueryRowContext"). With
+// PrepareStatements the query goes through the runner's statement cache
+// under nameExpr, a Go expression of the query's name.
+func dbCall(cfg UnifiedRunnerConfig, method, nameExpr, sqlExpr string) string {
+	if !cfg.PrepareStatements {
+		return fmt.Sprintf("r.db.%s(ctx, %s, args...)", method, sqlExpr)
+	}
+	return fmt.Sprintf("r.%s(ctx, %s, %s, args...)", preparedMethods[method], nameExpr, sqlExpr)
+}
+
+// writeStmtCache writes stmtCache, which prepares each query the first time
+// it runs and keeps the statement for later calls, and the QueryRunner
+// methods running queries through it. Statements are prepared on the
+// *sql.DB the runner was created with and bound to the runner's
+// transaction with Tx.StmtContext; a runner over any other Querier runs its
+// queries unprepared.
+func writeStmtCache(buf *bytes.Buffer) {
+	buf.WriteString(`// stmtCache holds the statements prepared on a *sql.DB, keyed by query
+// name. Runners copied with WithTx share their runner's cache.
+type stmtCache struct {
+	db    *sql.DB
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+}
+
+// newStmtCache returns a cache preparing statements on db, or nil when db
+// is not a *sql.DB.
+func newStmtCache(db Querier) *stmtCache {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return nil
+	}
+	return &stmtCache{db: sqlDB, stmts: make(map[string]*sql.Stmt)}
+}
+
+// forDB returns c when it prepares on db, or a new cache for db.
+func (c *stmtCache) forDB(db Querier) *stmtCache {
+	if c != nil && Querier(c.db) == db {
+		return c
+	}
+	return newStmtCache(db)
+}
+
+// get returns the statement of the named query, preparing it on first use.
+func (c *stmtCache) get(ctx context.Context, name, query string) (*sql.Stmt, error) {
+	c.mu.RLock()
+	stmt, ok := c.stmts[name]
+	c.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[name]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[name] = stmt
+	return stmt, nil
+}
+
+// close closes and forgets every statement of the cache.
+func (c *stmtCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for name, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(c.stmts, name)
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the statements the runner prepared. It does not close the
+// database; runners sharing the statements prepare them again when they
+// next run a query.
+func (r *QueryRunner) Close() error {
+	if r.stmts == nil {
+		return nil
+	}
+	return r.stmts.close()
+}
+
+// stmt returns the prepared statement of the named query, bound to the
+// runner's transaction if it has one, or nil when the runner does not
+// prepare statements.
+func (r *QueryRunner) stmt(ctx context.Context, name, query string) (*sql.Stmt, error) {
+	if r.stmts == nil {
+		return nil, nil
+	}
+	stmt, err := r.stmts.get(ctx, name, query)
+	if err != nil {
+		return nil, err
+	}
+	if tx, ok := r.db.(*sql.Tx); ok {
+		return tx.StmtContext(ctx, stmt), nil
+	}
+	return stmt, nil
+}
+
+func (r *QueryRunner) execPrepared(ctx context.Context, name, query string, args ...any) (sql.Result, error) {
+	stmt, err := r.stmt(ctx, name, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return r.db.ExecContext(ctx, query, args...)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (r *QueryRunner) queryPrepared(ctx context.Context, name, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := r.stmt(ctx, name, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return r.db.QueryContext(ctx, query, args...)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+// queryRowPrepared runs the query unprepared when preparing it fails: a
+// *sql.Row can't be built with an error, so the query reports it instead.
+func (r *QueryRunner) queryRowPrepared(ctx context.Context, name, query string, args ...any) *sql.Row {
+	stmt, err := r.stmt(ctx, name, query)
+	if err != nil || stmt == nil {
+		return r.db.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+`)
+}
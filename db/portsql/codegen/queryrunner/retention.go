@@ -0,0 +1,78 @@
+package queryrunner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// RetentionSchedule is how often the worker purges expired rows.
+const RetentionSchedule = "@daily"
+
+// RetentionManifestEntry describes one table's retention policy in
+// shipq/queries/retention.json. The manifest lists every policy in the
+// project so it can be reviewed in one place; the worker generator reads
+// it to schedule the purges.
+type RetentionManifestEntry struct {
+	Table            string `json:"table"`
+	RetainFor        string `json:"retain_for"`
+	RetentionSeconds int64  `json:"retention_seconds"`
+	Column           string `json:"column"`
+	PurgeMethod      string `json:"purge_method"`
+	Schedule         string `json:"schedule"`
+}
+
+// GenerateRetentionManifest returns the retention manifest for tables, or
+// nil when no table declares RetainFor.
+func GenerateRetentionManifest(tables map[string]ddl.Table) ([]byte, error) {
+	var entries []RetentionManifestEntry
+	for name, table := range tables {
+		if table.RetentionSeconds <= 0 {
+			continue
+		}
+		entries = append(entries, RetentionManifestEntry{
+			Table:            name,
+			RetainFor:        table.Retention().String(),
+			RetentionSeconds: table.RetentionSeconds,
+			Column:           "created_at",
+			PurgeMethod:      codegen.CRUD.PurgeMethodName(name),
+			Schedule:         RetentionSchedule,
+		})
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Table < entries[j].Table })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// RetentionManifestPath is where `shipq db compile` writes the retention
+// manifest, relative to the shipq root.
+var RetentionManifestPath = filepath.Join("shipq", "queries", "retention.json")
+
+// ReadRetentionManifest reads the retention manifest under shipqRoot. It
+// returns nil and no error when no table has a retention policy.
+func ReadRetentionManifest(shipqRoot string) ([]RetentionManifestEntry, error) {
+	data, err := os.ReadFile(filepath.Join(shipqRoot, RetentionManifestPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []RetentionManifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RetentionManifestPath, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,62 @@
+package queryrunner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestGenerateRetentionManifest(t *testing.T) {
+	tables := map[string]ddl.Table{
+		"posts":        {Name: "posts"},
+		"sessions":     {Name: "sessions", RetentionSeconds: 30 * 24 * 3600},
+		"audit_events": {Name: "audit_events", RetentionSeconds: 90 * 24 * 3600},
+	}
+	data, err := GenerateRetentionManifest(tables)
+	if err != nil {
+		t.Fatalf("GenerateRetentionManifest() error = %v", err)
+	}
+	var entries []RetentionManifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	want := []RetentionManifestEntry{
+		{Table: "audit_events", RetainFor: "2160h0m0s", RetentionSeconds: 7776000, Column: "created_at", PurgeMethod: "PurgeAuditEvents", Schedule: "@daily"},
+		{Table: "sessions", RetainFor: "720h0m0s", RetentionSeconds: 2592000, Column: "created_at", PurgeMethod: "PurgeSessions", Schedule: "@daily"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	root := t.TempDir()
+	path := filepath.Join(root, RetentionManifestPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadRetentionManifest(root)
+	if err != nil || len(read) != 2 {
+		t.Fatalf("ReadRetentionManifest() = %+v, %v", read, err)
+	}
+}
+
+func TestGenerateRetentionManifest_NoPolicies(t *testing.T) {
+	data, err := GenerateRetentionManifest(map[string]ddl.Table{"posts": {Name: "posts"}})
+	if err != nil || data != nil {
+		t.Errorf("GenerateRetentionManifest() = %q, %v; want nil, nil", data, err)
+	}
+	entries, err := ReadRetentionManifest(t.TempDir())
+	if err != nil || entries != nil {
+		t.Errorf("ReadRetentionManifest() on a project without a manifest = %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,99 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// hasScannedResults reports whether any query scans result rows, in which
+// case the types need ScanError and the runner its scanError helper.
+func hasScannedResults(queries []userQueryInfo) bool {
+	for _, qi := range queries {
+		switch qi.ReturnType {
+		case query.ReturnOne, query.ReturnMany, query.ReturnPaginated:
+			if len(qi.Results) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeScanErrorType writes ScanError, which runners return when a result
+// row can't be scanned or decoded into its Go type, e.g. a NULL read into a
+// string field after the schema and generated code drifted apart.
+func writeScanErrorType(buf *bytes.Buffer) {
+	buf.WriteString("// =============================================================================\n")
+	buf.WriteString("// Scan Errors\n")
+	buf.WriteString("// =============================================================================\n\n")
+
+	buf.WriteString("// ScanError is returned when a result column of a query can't be scanned\n")
+	buf.WriteString("// or decoded into the Go type generated for it. Err is the driver or\n")
+	buf.WriteString("// decoding error.\n")
+	buf.WriteString("type ScanError struct {\n")
+	buf.WriteString("\tQuery  string\n")
+	buf.WriteString("\tColumn string\n")
+	buf.WriteString("\tGoType string\n")
+	buf.WriteString("\tErr    error\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("func (e *ScanError) Error() string {\n")
+	buf.WriteString("\treturn fmt.Sprintf(\"queries: %s: column %q (%s): %v\", e.Query, e.Column, e.GoType, e.Err)\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("func (e *ScanError) Unwrap() error { return e.Err }\n\n")
+}
+
+// writeScanErrorHelper writes scanError, which generated methods call with
+// the error from Scan. database/sql reports the index of the column a scan
+// failed on; scanError looks it up in the query's scan columns. Errors that
+// don't name a column (connection errors surfacing from QueryRow's Scan,
+// constraint violations of INSERT ... RETURNING) are returned unchanged.
+func writeScanErrorHelper(buf *bytes.Buffer) {
+	buf.WriteString("// scanColumn is a result column and the Go type it is scanned into.\n")
+	buf.WriteString("type scanColumn struct {\n")
+	buf.WriteString("\tname   string\n")
+	buf.WriteString("\tgoType string\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// scanError wraps a Scan error naming a column of query in a *queries.ScanError.\n")
+	buf.WriteString("// columns lists the query's result columns in scan order.\n")
+	buf.WriteString("func scanError(query string, columns []scanColumn, err error) error {\n")
+	buf.WriteString("\tvar i int\n")
+	buf.WriteString("\tif _, perr := fmt.Sscanf(err.Error(), \"sql: Scan error on column index %d,\", &i); perr != nil || i < 0 || i >= len(columns) {\n")
+	buf.WriteString("\t\treturn err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn &queries.ScanError{Query: query, Column: columns[i].name, GoType: columns[i].goType, Err: err}\n")
+	buf.WriteString("}\n\n")
+}
+
+// scanColumnsVar returns the name of the runner variable listing qi's scan
+// columns.
+func scanColumnsVar(qi userQueryInfo) string {
+	return dbstrings.ToLowerCamel(qi.Name) + "ScanColumns"
+}
+
+// writeScanColumns writes the variable passed to scanError by qi's method.
+func writeScanColumns(buf *bytes.Buffer, qi userQueryInfo) {
+	fmt.Fprintf(buf, "var %s = []scanColumn{\n", scanColumnsVar(qi))
+	for _, r := range qi.Results {
+		fmt.Fprintf(buf, "\t{%q, %q},\n", r.Column, r.GoType)
+	}
+	buf.WriteString("}\n\n")
+}
+
+// scanErrorReturn returns the statement returning a Scan error of qi, where
+// errReturn is the method's plain error return, e.g. "nil, err".
+func scanErrorReturn(qi userQueryInfo, errReturn string) string {
+	return fmt.Sprintf("return %sscanError(%q, %s, err)", strings.TrimSuffix(errReturn, "err"), qi.Name, scanColumnsVar(qi))
+}
+
+// decodeErrorReturn returns the statement returning an error from decoding
+// the scanned value of r (json_agg unmarshal, SQLite text parsing).
+func decodeErrorReturn(qi userQueryInfo, r resultInfo, errReturn string) string {
+	return fmt.Sprintf("return %s&queries.ScanError{Query: %q, Column: %q, GoType: %q, Err: err}", strings.TrimSuffix(errReturn, "err"), qi.Name, r.Column, r.GoType)
+}
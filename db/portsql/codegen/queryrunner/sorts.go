@@ -0,0 +1,155 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
+	"github.com/shipq/shipq/dbstrings"
+)
+
+// sortInfo is an alternate order of a paginated query, compiled like its
+// default order into a base and a cursor variant.
+type sortInfo struct {
+	Name             string
+	CursorColumns    []query.SerializedColumn
+	SQL              string
+	ParamOrder       []string
+	CursorSQL        string
+	CursorParamOrder []string
+
+	// Only set when the query has filters.
+	FilterSplit       filterSplit
+	CursorFilterSplit filterSplit
+}
+
+// compileSorts compiles the sorts of the paginated query sq, whose default
+// order and filters are already compiled into qi.
+func compileSorts(qi *userQueryInfo, sq query.SerializedQuery, ast *query.AST, compiler *compile.Compiler, dialectName string) error {
+	dialect, err := getDialect(dialectName)
+	if err != nil {
+		return err
+	}
+	for _, p := range qi.Params {
+		if p.Name == "sort" {
+			return fmt.Errorf("query %s: param \"sort\" is reserved for choosing one of its sorts", sq.Name)
+		}
+	}
+	for _, f := range qi.Filters {
+		if f.Param == "sort" {
+			return fmt.Errorf("query %s: filter param \"sort\" is reserved for choosing one of its sorts", sq.Name)
+		}
+	}
+
+	results := make(map[string]string, len(qi.Results))
+	for _, r := range qi.Results {
+		results[r.Column] = r.GoType
+	}
+	for _, s := range sq.Sorts {
+		for _, col := range s.Columns {
+			goType, ok := results[col.Name]
+			if !ok {
+				return fmt.Errorf("query %s: sort %q orders by %s, which the query doesn't select", sq.Name, s.Name, col.Name)
+			}
+			// NULLs don't compare, so a cursor could never get past them.
+			if strings.HasPrefix(goType, "*") {
+				return fmt.Errorf("query %s: sort %q orders by nullable column %s", sq.Name, s.Name, col.Name)
+			}
+		}
+
+		si := sortInfo{Name: s.Name, CursorColumns: s.Columns}
+		baseAST := query.DeserializeAST(query.SerializeAST(ast))
+		addPaginationToAST(baseAST, s.Columns)
+		if si.SQL, si.ParamOrder, err = compiler.Compile(baseAST); err != nil {
+			return fmt.Errorf("failed to compile sort %q of query %s: %w", s.Name, sq.Name, err)
+		}
+		if si.CursorSQL, si.CursorParamOrder, err = compiler.Compile(buildCursorAST(ast, s.Columns)); err != nil {
+			return fmt.Errorf("failed to compile cursor SQL of sort %q of query %s: %w", s.Name, sq.Name, err)
+		}
+
+		if len(qi.Filters) > 0 {
+			baseAST := query.DeserializeAST(query.SerializeAST(ast))
+			addPaginationToAST(baseAST, s.Columns)
+			if si.FilterSplit, err = splitAtFilters(baseAST, compiler, dialect); err != nil {
+				return fmt.Errorf("query %s: %w", sq.Name, err)
+			}
+			if si.CursorFilterSplit, err = splitAtFilters(buildCursorAST(ast, s.Columns), compiler, dialect); err != nil {
+				return fmt.Errorf("query %s: %w", sq.Name, err)
+			}
+		}
+		qi.Sorts = append(qi.Sorts, si)
+	}
+	return nil
+}
+
+// cursorFields returns the fields of qi's cursor type: its default cursor
+// columns followed by the other columns of its sorts.
+func cursorFields(qi userQueryInfo) []query.SerializedColumn {
+	fields := append([]query.SerializedColumn(nil), qi.CursorColumns...)
+	seen := make(map[string]bool, len(fields))
+	for _, col := range fields {
+		seen[col.Name] = true
+	}
+	for _, s := range qi.Sorts {
+		for _, col := range s.CursorColumns {
+			if !seen[col.Name] {
+				seen[col.Name] = true
+				fields = append(fields, col)
+			}
+		}
+	}
+	return fields
+}
+
+// sortPrefix returns the prefix of the QueryRunner fields and statement
+// names of a sort, e.g. ListPostsByTitle.
+func sortPrefix(qi userQueryInfo, s sortInfo) string {
+	return qi.Name + "By" + dbstrings.ToPascalCase(s.Name)
+}
+
+// sortFields returns the QueryRunner fields holding the SQL, cursor SQL
+// and filters of the sort s of qi.
+func sortFields(qi userQueryInfo, s sortInfo) (sqlField, cursorSQLField, filtersField string) {
+	prefix := dbstrings.ToLowerCamel(sortPrefix(qi, s))
+	return prefix + "SQL", prefix + "CursorSQL", prefix + "Filters"
+}
+
+// writeSortFieldDecls writes the QueryRunner fields of qi's sorts.
+func writeSortFieldDecls(buf *bytes.Buffer, qi userQueryInfo) {
+	for _, s := range qi.Sorts {
+		sqlField, cursorSQLField, filtersField := sortFields(qi, s)
+		fmt.Fprintf(buf, "\t%s string\n", sqlField)
+		fmt.Fprintf(buf, "\t%s string\n", cursorSQLField)
+		if len(qi.Filters) > 0 {
+			fmt.Fprintf(buf, "\t%s queryFilters\n", filtersField)
+		}
+	}
+}
+
+// writeSortFieldValues writes the values of the QueryRunner fields of qi's
+// sorts.
+func writeSortFieldValues(buf *bytes.Buffer, qi userQueryInfo) {
+	for _, s := range qi.Sorts {
+		sqlField, cursorSQLField, filtersField := sortFields(qi, s)
+		fmt.Fprintf(buf, "\t\t%s: %q,\n", sqlField, s.SQL)
+		fmt.Fprintf(buf, "\t\t%s: %q,\n", cursorSQLField, s.CursorSQL)
+		if len(qi.Filters) > 0 {
+			writeFiltersValue(buf, filtersField, qi.Filters, s.FilterSplit, s.CursorFilterSplit)
+		}
+	}
+}
+
+// writeSortFieldCopies writes the copies of the QueryRunner fields of qi's
+// sorts from r.
+func writeSortFieldCopies(buf *bytes.Buffer, qi userQueryInfo) {
+	for _, s := range qi.Sorts {
+		sqlField, cursorSQLField, filtersField := sortFields(qi, s)
+		fmt.Fprintf(buf, "\t\t%s: r.%s,\n", sqlField, sqlField)
+		fmt.Fprintf(buf, "\t\t%s: r.%s,\n", cursorSQLField, cursorSQLField)
+		if len(qi.Filters) > 0 {
+			fmt.Fprintf(buf, "\t\t%s: r.%s,\n", filtersField, filtersField)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package queryrunner
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+
+	_ "modernc.org/sqlite"
+)
+
+// sortedPostsQuery returns listPostsQuery with a sort by status, with or
+// without its filters.
+func sortedPostsQuery(filters bool) query.SerializedQuery {
+	sq := listPostsQuery(false)
+	if !filters {
+		sq.Filters = nil
+	}
+	sq.Sorts = []query.SerializedSort{{
+		Name: "status",
+		Columns: []query.SerializedColumn{
+			{Table: "posts", Name: "status", GoType: "string", Ascending: true},
+			{Table: "posts", Name: "id", GoType: "int64", Ascending: true},
+		},
+	}}
+	return sq
+}
+
+func TestGenerateUnifiedRunner_Sorts(t *testing.T) {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
+		for _, filters := range []bool{false, true} {
+			cfg := UnifiedRunnerConfig{
+				ModulePath:        "myapp",
+				Dialect:           dialect,
+				PrepareStatements: filters,
+				UserQueries:       []query.SerializedQuery{sortedPostsQuery(filters)},
+			}
+			runner, err := GenerateUnifiedRunner(cfg)
+			if err != nil {
+				t.Fatalf("%s: GenerateUnifiedRunner() error = %v", dialect, err)
+			}
+			f := gofile.Parse(t, dialect+"/runner.go", runner)
+			for _, field := range []string{"listPostsByStatusSQL", "listPostsByStatusCursorSQL"} {
+				if _, _, ok := f.Field("QueryRunner", field); !ok {
+					t.Errorf("%s: QueryRunner has no %s", dialect, field)
+				}
+			}
+			f.AssertStmts("QueryRunner.ListPosts",
+				"if params.Cursor != nil && params.Cursor.Sort != params.Sort",
+				`case "status":`,
+				"sqlStr = r.listPostsByStatusCursorSQL",
+				`return nil, fmt.Errorf("ListPosts: unknown sort %q", params.Sort)`,
+				"result.NextCursor = &queries.ListPostsCursor{Sort: params.Sort}",
+				"result.NextCursor.Status = fmt.Sprint(lastItem.Status)",
+			)
+			if filters {
+				f.AssertStmts("QueryRunner.ListPosts",
+					"sortFilters = r.listPostsByStatusFilters",
+					"sqlStr, args = sortFilters.apply(split, args, filters, filterArgs)",
+					`sqlName = "ListPostsByStatusCursor"`,
+				)
+			}
+
+			types, err := GenerateSharedTypes(cfg)
+			if err != nil {
+				t.Fatalf("%s: GenerateSharedTypes() error = %v", dialect, err)
+			}
+			tf := gofile.Parse(t, dialect+"/types.go", types)
+			tf.AssertField("ListPostsCursor", "Status", "string", `json:"status"`)
+			tf.AssertField("ListPostsCursor", "Sort", "string", `json:"_sort,omitempty"`)
+			if typ, _, _ := tf.Field("ListPostsParams", "Sort"); typ != "string" {
+				t.Errorf("%s: ListPostsParams.Sort is %q, want string", dialect, typ)
+			}
+		}
+	}
+}
+
+func TestGenerateFakeRunner_Sorts(t *testing.T) {
+	src, err := GenerateFakeRunner(UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectSQLite,
+		MaxRows:     10000,
+		UserQueries: []query.SerializedQuery{sortedPostsQuery(true)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateFakeRunner failed: %v", err)
+	}
+	gofile.Parse(t, "runner.go", src).AssertStmts("Runner.ListPosts",
+		"cursor, ok := def.sorts[params.Sort]",
+		"page.After = []any{params.Cursor.Status, params.Cursor.Id}",
+		"result.NextCursor.Status = fmt.Sprint(lastItem.Status)",
+	)
+}
+
+func TestCompileSorts_Rejects(t *testing.T) {
+	nullable := sortedPostsQuery(false)
+	nullable.AST = query.SerializeAST(query.From(filterTestTable{}).
+		Select(postsID, query.NullStringColumn{Table: "posts", Name: "status"}).Build())
+	unselected := sortedPostsQuery(false)
+	unselected.Sorts[0].Columns[0].Name = "author"
+	reserved := sortedPostsQuery(false)
+	reserved.AST = query.SerializeAST(query.From(filterTestTable{}).Select(postsID, postsStatus).
+		Where(postsAuthor.Eq(query.Param[string]("sort"))).Build())
+
+	for name, tc := range map[string]struct {
+		sq   query.SerializedQuery
+		want string
+	}{
+		"nullable":   {nullable, "orders by nullable column status"},
+		"unselected": {unselected, "orders by author, which the query doesn't select"},
+		"reserved":   {reserved, `param "sort" is reserved`},
+	} {
+		_, err := GenerateUnifiedRunner(UnifiedRunnerConfig{ModulePath: "myapp", Dialect: dburl.DialectSQLite, UserQueries: []query.SerializedQuery{tc.sq}})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+// TestCompileSorts_SQLiteRoundTrip pages through SQLite with the SQL of a
+// sort, binding cursor args in the order the generated method does.
+func TestCompileSorts_SQLiteRoundTrip(t *testing.T) {
+	compiler, err := getCompiler(dburl.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := compileUserQueries([]query.SerializedQuery{sortedPostsQuery(false)}, compiler)
+	if err != nil {
+		t.Fatalf("compileUserQueries() error = %v", err)
+	}
+	so := infos[0].Sorts[0]
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, author TEXT, status TEXT, created_at TEXT);
+		INSERT INTO posts VALUES
+			(1, 'ann', 'published', '2026-01-01T00:00:00.000Z'),
+			(2, 'ann', 'draft', '2026-02-01T00:00:00.000Z'),
+			(3, 'bob', 'published', '2026-03-01T00:00:00.000Z'),
+			(4, 'bob', 'archived', '2026-04-01T00:00:00.000Z')`); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int64
+	var cursor map[string]any
+	for page := 0; page < 4; page++ {
+		sqlStr, order := so.SQL, so.ParamOrder
+		if cursor != nil {
+			sqlStr, order = so.CursorSQL, so.CursorParamOrder
+		}
+		var args []any
+		for _, name := range order {
+			if name == "__limit" {
+				args = append(args, 2)
+			} else {
+				args = append(args, cursor[strings.TrimPrefix(name, "__cursor_")])
+			}
+		}
+		rows, err := db.Query(sqlStr, args...)
+		if err != nil {
+			t.Fatalf("query %s: %v", sqlStr, err)
+		}
+		var id int64
+		var status string
+		n := 0
+		for rows.Next() {
+			if err := rows.Scan(&id, &status); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, id)
+			n++
+		}
+		rows.Close()
+		if n < 2 {
+			break
+		}
+		cursor = map[string]any{"status": status, "id": id}
+	}
+	if want := []int64{4, 2, 1, 3}; !slices.Equal(got, want) {
+		t.Errorf("paged by status = %v, want %v", got, want)
+	}
+}
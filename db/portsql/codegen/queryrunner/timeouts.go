@@ -0,0 +1,114 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// queryTimeout returns the timeout of qi's generated method: its
+// MustDefineTimeout override, else the [db] query_timeout default. 0 means
+// the method runs under the caller's context alone, as materialized view
+// refreshes always do.
+func queryTimeout(qi userQueryInfo, cfg UnifiedRunnerConfig) time.Duration {
+	if qi.ReturnType == query.ReturnMaterializedView {
+		return 0
+	}
+	if qi.Timeout > 0 {
+		return qi.Timeout
+	}
+	return cfg.QueryTimeout
+}
+
+// hasTimeouts reports whether any generated method runs under a timeout.
+func hasTimeouts(cfg UnifiedRunnerConfig, userQueries []userQueryInfo) bool {
+	for _, qi := range userQueries {
+		if queryTimeout(qi, cfg) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutResults returns the result list of a method signature. A method
+// with a timeout names its results so that its deferred done can replace
+// the error it returns.
+func timeoutResults(d time.Duration, result string) string {
+	if d <= 0 {
+		return "(" + result + ", error)"
+	}
+	return "(_ " + result + ", err error)"
+}
+
+// writeTimeoutStart writes the first statements of a method with a
+// timeout: ctx is bounded by d, and an error caused by that deadline is
+// returned as a *queries.TimeoutError.
+func writeTimeoutStart(buf *bytes.Buffer, name string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	fmt.Fprintf(buf, "\tctx, done := withTimeout(ctx, %q, %s)\n", name, durationExpr(d))
+	buf.WriteString("\tdefer func() { err = done(err) }()\n\n")
+}
+
+// durationExpr returns d as a Go expression, e.g. 5*time.Second.
+func durationExpr(d time.Duration) string {
+	switch {
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d*time.Minute", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%d*time.Second", d/time.Second)
+	default:
+		return fmt.Sprintf("%d*time.Millisecond", d/time.Millisecond)
+	}
+}
+
+// writeTimeoutHelper writes withTimeout, which runner methods with a
+// timeout call first.
+func writeTimeoutHelper(buf *bytes.Buffer) {
+	buf.WriteString(`// withTimeout bounds ctx by d for the query name. done cancels the context
+// and turns an error caused by its deadline, rather than by the caller's
+// context, into a *queries.TimeoutError.
+func withTimeout(ctx context.Context, name string, d time.Duration) (context.Context, func(error) error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, d)
+	return timeoutCtx, func(err error) error {
+		defer cancel()
+		if err != nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return &queries.TimeoutError{Query: name, Timeout: d, Err: err}
+		}
+		return err
+	}
+}
+
+`)
+}
+
+// writeTimeoutTypes writes ErrTimeout and the TimeoutError that runner
+// methods return when their query runs past its timeout.
+func writeTimeoutTypes(buf *bytes.Buffer) {
+	buf.WriteString("// =============================================================================\n")
+	buf.WriteString("// Timeouts\n")
+	buf.WriteString("// =============================================================================\n\n")
+
+	buf.WriteString("// ErrTimeout matches, with errors.Is, the error of a query that ran past its\n")
+	buf.WriteString("// timeout ([db] query_timeout in shipq.ini, or query.MustDefineTimeout).\n")
+	buf.WriteString("var ErrTimeout = errors.New(\"queries: query timed out\")\n\n")
+
+	buf.WriteString("// TimeoutError is returned by a query that ran past its timeout. Err is the\n")
+	buf.WriteString("// error the driver returned when the deadline passed.\n")
+	buf.WriteString("type TimeoutError struct {\n")
+	buf.WriteString("\tQuery   string\n")
+	buf.WriteString("\tTimeout time.Duration\n")
+	buf.WriteString("\tErr     error\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("func (e *TimeoutError) Error() string {\n")
+	buf.WriteString("\treturn fmt.Sprintf(\"queries: %s timed out after %s\", e.Query, e.Timeout)\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("func (e *TimeoutError) Is(target error) bool { return target == ErrTimeout }\n\n")
+
+	buf.WriteString("func (e *TimeoutError) Unwrap() error { return e.Err }\n\n")
+}
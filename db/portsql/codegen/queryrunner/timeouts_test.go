@@ -0,0 +1,106 @@
+package queryrunner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+)
+
+func TestUnifiedRunner_QueryTimeouts(t *testing.T) {
+	search := preloadTestQuery("SearchPosts", query.ReturnMany, "string")
+	search.TimeoutMs = 1500
+	list := preloadTestQuery("ListPosts", query.ReturnPaginated, "string")
+	list.CursorColumns = []query.SerializedColumn{
+		{Table: "posts", Name: "public_id", GoType: "string"},
+	}
+	cfg := UnifiedRunnerConfig{
+		ModulePath:   "example.com/myapp",
+		Dialect:      dburl.DialectPostgres,
+		QueryTimeout: 5 * time.Second,
+		UserQueries: []query.SerializedQuery{
+			preloadTestQuery("GetPost", query.ReturnOne, "string"),
+			search,
+			preloadTestQuery("TouchPosts", query.ReturnExec, "string"),
+			preloadTestQuery("ClaimPost", query.ReturnApplied, "string"),
+			list,
+		},
+	}
+
+	runner, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v\n%s", err, runner)
+	}
+	f := gofile.Parse(t, "runner.go", runner)
+	f.AssertSignature("withTimeout", "func withTimeout(ctx context.Context, name string, d time.Duration) (context.Context, func(error) error)")
+	f.AssertExprs("withTimeout", "&queries.TimeoutError{Query: name, Timeout: d, Err: err}")
+	// Named results let the deferred done wrap the returned error.
+	for method, sig := range map[string]string{
+		"GetPost":     "func (r *QueryRunner) GetPost(ctx context.Context, params queries.GetPostParams) (_ *queries.GetPostResult, err error)",
+		"SearchPosts": "func (r *QueryRunner) SearchPosts(ctx context.Context, params queries.SearchPostsParams) (_ []queries.SearchPostsResult, err error)",
+		"TouchPosts":  "func (r *QueryRunner) TouchPosts(ctx context.Context, params queries.TouchPostsParams) (_ sql.Result, err error)",
+		"ClaimPost":   "func (r *QueryRunner) ClaimPost(ctx context.Context, params queries.ClaimPostParams) (_ bool, err error)",
+	} {
+		f.AssertSignature("QueryRunner."+method, sig)
+		f.AssertStmts("QueryRunner."+method, "defer func() { err = done(err) }()")
+	}
+	f.AssertStmts("QueryRunner.GetPost", `ctx, done := withTimeout(ctx, "GetPost", 5*time.Second)`)
+	f.AssertStmts("QueryRunner.SearchPosts", `ctx, done := withTimeout(ctx, "SearchPosts", 1500*time.Millisecond)`)
+	f.AssertStmts("QueryRunner.ListPosts", `ctx, done := withTimeout(ctx, "ListPosts", 5*time.Second)`)
+	// Each reads rows at the caller's pace, so it has no timeout.
+	if f.Calls("QueryRunner.EachSearchPosts", "withTimeout") != 0 {
+		t.Error("EachSearchPosts should not time out")
+	}
+
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v\n%s", err, types)
+	}
+	tf := gofile.Parse(t, "types.go", types)
+	if got := tf.Value("ErrTimeout"); got != `errors.New("queries: query timed out")` {
+		t.Errorf("ErrTimeout = %s", got)
+	}
+	tf.AssertStmts("TimeoutError.Is", "return target == ErrTimeout")
+	tf.AssertStmts("TimeoutError.Unwrap", "return e.Err")
+}
+
+func TestUnifiedRunner_NoQueryTimeout(t *testing.T) {
+	cfg := UnifiedRunnerConfig{
+		ModulePath: "example.com/myapp",
+		Dialect:    dburl.DialectSQLite,
+		UserQueries: []query.SerializedQuery{
+			preloadTestQuery("GetPost", query.ReturnOne, "string"),
+		},
+	}
+
+	runner, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	if strings.Contains(string(runner), "withTimeout") {
+		t.Error("runner.go should not bound queries without a timeout")
+	}
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	if strings.Contains(string(types), "TimeoutError") {
+		t.Error("types.go should not define TimeoutError without a timeout")
+	}
+}
+
+func TestDurationExpr(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		2 * time.Minute:         "2*time.Minute",
+		90 * time.Second:        "90*time.Second",
+		250 * time.Millisecond:  "250*time.Millisecond",
+		1500 * time.Millisecond: "1500*time.Millisecond",
+	} {
+		if got := durationExpr(d); got != want {
+			t.Errorf("durationExpr(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
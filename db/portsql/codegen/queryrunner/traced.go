@@ -0,0 +1,157 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// writeTracedRunner writes Traced, the Runner decorator that runs every
+// call in an OpenTelemetry span named after the query ([server] telemetry
+// = otel). The span records the dialect and the number of rows the call
+// returned or changed; cmd/server wraps its runner in it.
+func writeTracedRunner(buf *bytes.Buffer, cfg UnifiedRunnerConfig, userQueries []userQueryInfo, preloads []preloadInfo) {
+	buf.WriteString("// =============================================================================\n")
+	buf.WriteString("// Tracing\n")
+	buf.WriteString("// =============================================================================\n\n")
+
+	buf.WriteString("// Traced returns r with every call traced by an OpenTelemetry span, as are\n")
+	buf.WriteString("// the runners of the transactions it begins.\n")
+	buf.WriteString("func Traced(r Runner) Runner {\n")
+	buf.WriteString("\treturn tracedRunner{next: r}\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("type tracedRunner struct {\n")
+	buf.WriteString("\tnext Runner\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// tracedRowsAffected returns the rows changed by an exec, or -1 when the\n")
+	buf.WriteString("// driver can't tell.\n")
+	buf.WriteString("func tracedRowsAffected(res sql.Result) int {\n")
+	buf.WriteString("\tif res == nil {\n\t\treturn -1\n\t}\n")
+	buf.WriteString("\tn, err := res.RowsAffected()\n")
+	buf.WriteString("\tif err != nil {\n\t\treturn -1\n\t}\n")
+	buf.WriteString("\treturn int(n)\n")
+	buf.WriteString("}\n\n")
+
+	start := func(name string) {
+		fmt.Fprintf(buf, "\tctx, span := telemetry.StartQuery(ctx, %q, %q)\n", name, cfg.Dialect)
+	}
+
+	for _, qi := range userQueries {
+		switch qi.ReturnType {
+		case query.ReturnOne:
+			fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, params %sParams) (*%sResult, error) {\n", qi.Name, qi.Name, qi.Name)
+			start(qi.Name)
+			fmt.Fprintf(buf, "\tresult, err := t.next.%s(ctx, params)\n", qi.Name)
+			buf.WriteString("\trows := 0\n")
+			buf.WriteString("\tif result != nil {\n\t\trows = 1\n\t}\n")
+			buf.WriteString("\tspan.End(rows, err)\n")
+			buf.WriteString("\treturn result, err\n")
+			buf.WriteString("}\n\n")
+
+		case query.ReturnMany:
+			fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, params %sParams) ([]%sResult, error) {\n", qi.Name, qi.Name, qi.Name)
+			start(qi.Name)
+			fmt.Fprintf(buf, "\tresults, err := t.next.%s(ctx, params)\n", qi.Name)
+			buf.WriteString("\tspan.End(len(results), err)\n")
+			buf.WriteString("\treturn results, err\n")
+			buf.WriteString("}\n\n")
+
+			each := codegen.CRUD.EachMethodName(qi.Name)
+			fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, params %sParams, fn func(%sResult) error) error {\n", each, qi.Name, qi.Name)
+			start(each)
+			buf.WriteString("\trows := 0\n")
+			fmt.Fprintf(buf, "\terr := t.next.%s(ctx, params, func(item %sResult) error {\n", each, qi.Name)
+			buf.WriteString("\t\trows++\n")
+			buf.WriteString("\t\treturn fn(item)\n")
+			buf.WriteString("\t})\n")
+			buf.WriteString("\tspan.End(rows, err)\n")
+			buf.WriteString("\treturn err\n")
+			buf.WriteString("}\n\n")
+
+			if qi.Iter {
+				// The rows are read as the caller ranges over the sequence,
+				// so the span ends with the iteration.
+				it := codegen.CRUD.IterMethodName(qi.Name)
+				fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, params %sParams) (iter.Seq2[%sResult, error], error) {\n", it, qi.Name, qi.Name)
+				start(it)
+				fmt.Fprintf(buf, "\tseq, err := t.next.%s(ctx, params)\n", it)
+				buf.WriteString("\tif err != nil {\n")
+				buf.WriteString("\t\tspan.End(0, err)\n")
+				buf.WriteString("\t\treturn nil, err\n")
+				buf.WriteString("\t}\n")
+				fmt.Fprintf(buf, "\treturn func(yield func(%sResult, error) bool) {\n", qi.Name)
+				buf.WriteString("\t\trows := 0\n")
+				buf.WriteString("\t\tvar iterErr error\n")
+				buf.WriteString("\t\tdefer func() { span.End(rows, iterErr) }()\n")
+				buf.WriteString("\t\tfor item, err := range seq {\n")
+				buf.WriteString("\t\t\tif err != nil {\n\t\t\t\titerErr = err\n\t\t\t} else {\n\t\t\t\trows++\n\t\t\t}\n")
+				buf.WriteString("\t\t\tif !yield(item, err) {\n\t\t\t\treturn\n\t\t\t}\n")
+				buf.WriteString("\t\t}\n")
+				buf.WriteString("\t}, nil\n")
+				buf.WriteString("}\n\n")
+			}
+
+		case query.ReturnExec, query.ReturnBulkExec:
+			paramType := qi.Name + "Params"
+			if qi.ReturnType == query.ReturnBulkExec {
+				paramType = "[]" + bulkParamsName(qi) + "Params"
+			}
+			fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, params %s) (sql.Result, error) {\n", qi.Name, paramType)
+			start(qi.Name)
+			fmt.Fprintf(buf, "\tres, err := t.next.%s(ctx, params)\n", qi.Name)
+			buf.WriteString("\tspan.End(tracedRowsAffected(res), err)\n")
+			buf.WriteString("\treturn res, err\n")
+			buf.WriteString("}\n\n")
+
+		case query.ReturnApplied:
+			fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, params %sParams) (bool, error) {\n", qi.Name, qi.Name)
+			start(qi.Name)
+			fmt.Fprintf(buf, "\tapplied, err := t.next.%s(ctx, params)\n", qi.Name)
+			buf.WriteString("\trows := 0\n")
+			buf.WriteString("\tif applied {\n\t\trows = 1\n\t}\n")
+			buf.WriteString("\tspan.End(rows, err)\n")
+			buf.WriteString("\treturn applied, err\n")
+			buf.WriteString("}\n\n")
+
+		case query.ReturnPaginated:
+			fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, params %sParams) (*%sResult, error) {\n", qi.Name, qi.Name, qi.Name)
+			start(qi.Name)
+			fmt.Fprintf(buf, "\tresult, err := t.next.%s(ctx, params)\n", qi.Name)
+			buf.WriteString("\trows := 0\n")
+			buf.WriteString("\tif result != nil {\n\t\trows = len(result.Items)\n\t}\n")
+			buf.WriteString("\tspan.End(rows, err)\n")
+			buf.WriteString("\treturn result, err\n")
+			buf.WriteString("}\n\n")
+
+		case query.ReturnMaterializedView:
+			fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context) error {\n", qi.Name)
+			start(qi.Name)
+			fmt.Fprintf(buf, "\terr := t.next.%s(ctx)\n", qi.Name)
+			buf.WriteString("\tspan.End(-1, err)\n")
+			buf.WriteString("\treturn err\n")
+			buf.WriteString("}\n\n")
+		}
+	}
+
+	for _, p := range preloads {
+		fmt.Fprintf(buf, "func (t tracedRunner) %s(ctx context.Context, items []%s) error {\n", p.Method, p.TargetType)
+		start(p.Method)
+		fmt.Fprintf(buf, "\terr := t.next.%s(ctx, items)\n", p.Method)
+		buf.WriteString("\tspan.End(len(items), err)\n")
+		buf.WriteString("\treturn err\n")
+		buf.WriteString("}\n\n")
+	}
+
+	buf.WriteString("func (t tracedRunner) BeginTx(ctx context.Context) (*TxRunner, error) {\n")
+	buf.WriteString("\ttx, err := t.next.BeginTx(ctx)\n")
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\ttx.Runner = tracedRunner{next: tx.Runner}\n")
+	buf.WriteString("\treturn tx, nil\n")
+	buf.WriteString("}\n\n")
+}
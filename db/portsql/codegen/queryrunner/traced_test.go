@@ -0,0 +1,64 @@
+package queryrunner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+)
+
+func TestSharedTypes_Traced(t *testing.T) {
+	many := preloadTestQuery("ListRecentPosts", query.ReturnMany, "string")
+	many.Iter = true
+	list := preloadTestQuery("ListPosts", query.ReturnPaginated, "string")
+	list.CursorColumns = []query.SerializedColumn{
+		{Table: "posts", Name: "public_id", GoType: "string"},
+	}
+	cfg := UnifiedRunnerConfig{
+		ModulePath: "example.com/myapp",
+		Dialect:    dburl.DialectPostgres,
+		Schema:     preloadTestSchema(t, false),
+		Telemetry:  true,
+		UserQueries: []query.SerializedQuery{
+			preloadTestQuery("GetPostByPublicID", query.ReturnOne, "string"),
+			many,
+			preloadTestQuery("TouchPosts", query.ReturnExec, "string"),
+			preloadTestQuery("ClaimPost", query.ReturnApplied, "string"),
+			list,
+		},
+	}
+
+	src, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v\n%s", err, src)
+	}
+	f := gofile.Parse(t, "types.go", src)
+	f.AssertSignature("Traced", "func Traced(r Runner) Runner")
+	f.AssertStmts("tracedRunner.GetPostByPublicID",
+		`ctx, span := telemetry.StartQuery(ctx, "GetPostByPublicID", "postgres")`,
+		"rows = 1",
+	)
+	f.AssertStmts("tracedRunner.ListRecentPosts", "span.End(len(results), err)")
+	f.AssertStmts("tracedRunner.EachListRecentPosts",
+		`ctx, span := telemetry.StartQuery(ctx, "EachListRecentPosts", "postgres")`,
+		"return fn(item)",
+	)
+	f.AssertSignature("tracedRunner.ListRecentPostsIter", "func (t tracedRunner) ListRecentPostsIter(ctx context.Context, params ListRecentPostsParams) (iter.Seq2[ListRecentPostsResult, error], error)")
+	f.AssertStmts("tracedRunner.ListRecentPostsIter", "defer func() { span.End(rows, iterErr) }()")
+	f.AssertStmts("tracedRunner.TouchPosts", "span.End(tracedRowsAffected(res), err)")
+	f.AssertStmts("tracedRunner.ClaimPost", "if applied")
+	f.AssertStmts("tracedRunner.ListPosts", "rows = len(result.Items)")
+	f.AssertSignature("tracedRunner.PreloadPostAuthors", "func (t tracedRunner) PreloadPostAuthors(ctx context.Context, items []GetPostByPublicIDResult) error")
+	f.AssertStmts("tracedRunner.BeginTx", "tx.Runner = tracedRunner{next: tx.Runner}")
+
+	cfg.Telemetry = false
+	src, err = GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	if strings.Contains(string(src), "telemetry") {
+		t.Error("types.go should not trace without Telemetry")
+	}
+}
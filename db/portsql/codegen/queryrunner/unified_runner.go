@@ -3,10 +3,14 @@ package queryrunner
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/phase"
+	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/db/portsql/query"
 	"github.com/shipq/shipq/db/portsql/query/compile"
 	"github.com/shipq/shipq/dbstrings"
@@ -18,6 +22,22 @@ type UnifiedRunnerConfig struct {
 	ModulePath  string
 	Dialect     string // "postgres", "mysql", "sqlite"
 	UserQueries []query.SerializedQuery
+	MaxRows     int // hard cap on rows loaded by ReturnMany queries; 0 disables it
+	// Schema holds the migrated tables. When set, CRUD tables with
+	// <name>_id References columns get Preload methods; nil skips them.
+	// Custom column types used by queries are also resolved from it.
+	Schema map[string]ddl.Table
+	// PrepareStatements makes the runner prepare each query on first use
+	// and reuse the statement, closing them in QueryRunner.Close ([db]
+	// prepare_statements in shipq.ini).
+	PrepareStatements bool
+	// Telemetry adds queries.Traced, which wraps a Runner so that each call
+	// runs in an OpenTelemetry span ([server] telemetry = otel).
+	Telemetry bool
+	// QueryTimeout bounds each query method by context.WithTimeout ([db]
+	// query_timeout in shipq.ini); 0 leaves them to the caller's context.
+	// query.MustDefineTimeout overrides it per query.
+	QueryTimeout time.Duration
 }
 
 // GenerateUnifiedRunner generates the complete runner for a dialect.
@@ -36,8 +56,25 @@ func GenerateUnifiedRunner(cfg UnifiedRunnerConfig) ([]byte, error) {
 		return nil, err
 	}
 
+	customTypes, err := resolveCustomTypes(cfg, userQueryInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	preloads, preloadTables := collectPreloads(cfg, userQueryInfo)
+
 	// Collect imports
 	imports := collectRunnerImports(cfg, userQueryInfo)
+	if len(preloads) > 0 {
+		imports["strings"] = true
+	}
+	// Custom type conversion helpers name the Go type and driver.Value.
+	for _, t := range customTypes {
+		imports["database/sql/driver"] = true
+		if t.GoImport != "" {
+			imports[t.GoImport] = true
+		}
+	}
 
 	// Write header
 	buf.WriteString("// Code generated by shipq. DO NOT EDIT.\n")
@@ -51,6 +88,24 @@ func GenerateUnifiedRunner(cfg UnifiedRunnerConfig) ([]byte, error) {
 		writeSQLiteScanHelpers(&buf)
 	}
 
+	// timestamptz columns are converted to and from UTC by small helpers.
+	if hasUTCColumns(userQueryInfo) {
+		writeUTCHelpers(&buf, cfg.Dialect)
+	}
+
+	// Custom types convert values with the snippets registered for them.
+	writeCustomTypeHelpers(&buf, customTypes)
+
+	// SUM/AVG of decimal columns are normalized to the same string on every dialect.
+	if hasDecimalResults(userQueryInfo) {
+		writeDecimalHelpers(&buf)
+	}
+
+	// Scan errors are wrapped with the query, column and Go type.
+	if hasScannedResults(userQueryInfo) {
+		writeScanErrorHelper(&buf)
+	}
+
 	// MySQL and SQLite JSON_OBJECT outputs TINYINT(1) bools as 0/1 instead of
 	// true/false. Emit a small helper to patch the raw JSON before unmarshal.
 	if jsonAggNeedsBoolFix(cfg.Dialect, userQueryInfo) {
@@ -66,6 +121,22 @@ func GenerateUnifiedRunner(cfg UnifiedRunnerConfig) ([]byte, error) {
 	// Write Querier interface
 	writeQuerierInterface(&buf)
 
+	if cfg.PrepareStatements {
+		writeStmtCache(&buf)
+	}
+
+	if hasTimeouts(cfg, userQueryInfo) {
+		writeTimeoutHelper(&buf)
+	}
+
+	if hasFilters(userQueryInfo) {
+		writeFilterTypes(&buf, cfg.Dialect)
+	}
+
+	if hasOptionalSets(userQueryInfo) {
+		writeOptionalSetsType(&buf, cfg.Dialect)
+	}
+
 	// Write QueryRunner struct
 	writeQueryRunnerStruct(&buf, userQueryInfo, cfg)
 
@@ -85,8 +156,13 @@ func GenerateUnifiedRunner(cfg UnifiedRunnerConfig) ([]byte, error) {
 		}
 	}
 
+	// Write batch loaders for References columns of CRUD results
+	if err := writePreloadMethods(&buf, preloads, preloadTables, cfg.Dialect); err != nil {
+		return nil, err
+	}
+
 	// Format the code
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format runner.go: %w (unformatted output returned)", err)
@@ -111,8 +187,14 @@ func GenerateSharedTypes(cfg UnifiedRunnerConfig) ([]byte, error) {
 		return nil, err
 	}
 
+	preloads, preloadTables := collectPreloads(cfg, userQueryInfo)
+
 	// Collect imports needed for types
 	imports := collectTypesImports(userQueryInfo, cfg)
+	customTypeImports(cfg, userQueryInfo, imports)
+	if preloadTypesNeedTime(preloadTables) {
+		imports["time"] = true
+	}
 
 	// Always need context for RunnerFromContext
 	imports["context"] = true
@@ -120,6 +202,10 @@ func GenerateSharedTypes(cfg UnifiedRunnerConfig) ([]byte, error) {
 	// Always need database/sql for TxRunner
 	imports["database/sql"] = true
 
+	if cfg.Telemetry {
+		imports[cfg.ModulePath+"/shipq/lib/telemetry"] = true
+	}
+
 	// Need encoding/base64 and encoding/json for cursor helpers (paginated queries)
 	for _, qi := range userQueryInfo {
 		if qi.ReturnType == query.ReturnPaginated {
@@ -142,15 +228,32 @@ func GenerateSharedTypes(cfg UnifiedRunnerConfig) ([]byte, error) {
 	writeTxRunner(&buf)
 
 	// Write context helpers for runner access
-	writeContextHelpers(&buf, cfg, userQueryInfo)
+	writeContextHelpers(&buf, cfg, userQueryInfo, preloads)
+
+	if hasRowLimit(cfg, userQueryInfo) {
+		writeRowLimitTypes(&buf, cfg.MaxRows)
+	}
+
+	if hasTimeouts(cfg, userQueryInfo) {
+		writeTimeoutTypes(&buf)
+	}
+
+	if hasScannedResults(userQueryInfo) {
+		writeScanErrorType(&buf)
+	}
 
 	// Write user query types
 	for _, qi := range userQueryInfo {
-		writeUserQueryTypes(&buf, qi)
+		writeUserQueryTypes(&buf, qi, preloads)
+	}
+	writePreloadedTypes(&buf, preloadTables)
+
+	if cfg.Telemetry {
+		writeTracedRunner(&buf, cfg, userQueryInfo, preloads)
 	}
 
 	// Format the code
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format types.go: %w (unformatted output returned)", err)
@@ -170,18 +273,42 @@ func writeTxRunner(buf *bytes.Buffer) {
 	buf.WriteString("type TxRunner struct {\n")
 	buf.WriteString("\tRunner\n")
 	buf.WriteString("\tTx *sql.Tx\n")
+	buf.WriteString("\t// End finishes a transaction not backed by Tx, such as one of the\n")
+	buf.WriteString("\t// in-memory fake runner; commit tells it to keep or drop the writes.\n")
+	buf.WriteString("\tEnd func(commit bool) error\n")
 	buf.WriteString("}\n\n")
 
 	buf.WriteString("// Commit commits the underlying transaction.\n")
-	buf.WriteString("func (t *TxRunner) Commit() error { return t.Tx.Commit() }\n\n")
+	buf.WriteString("func (t *TxRunner) Commit() error {\n")
+	buf.WriteString("\tif t.Tx == nil && t.End != nil {\n")
+	buf.WriteString("\t\treturn t.End(true)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn t.Tx.Commit()\n")
+	buf.WriteString("}\n\n")
 
 	buf.WriteString("// Rollback aborts the underlying transaction.\n")
 	buf.WriteString("// It is safe to call after Commit — the driver returns sql.ErrTxDone.\n")
-	buf.WriteString("func (t *TxRunner) Rollback() error { return t.Tx.Rollback() }\n\n")
+	buf.WriteString("func (t *TxRunner) Rollback() error {\n")
+	buf.WriteString("\tif t.Tx == nil && t.End != nil {\n")
+	buf.WriteString("\t\treturn t.End(false)\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn t.Tx.Rollback()\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// InTx reports whether runner already is a transaction, on which BeginTx\n")
+	buf.WriteString("// fails. Code that needs a transaction joins runner's instead.\n")
+	buf.WriteString("func InTx(runner Runner) bool {\n")
+	buf.WriteString("\tif _, ok := runner.(*TxRunner); ok {\n")
+	buf.WriteString("\t\treturn true\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\ttx, ok := runner.(interface{ InTx() bool })\n")
+	buf.WriteString("\treturn ok && tx.InTx()\n")
+	buf.WriteString("}\n\n")
 }
 
-// writeContextHelpers writes the RunnerFromContext and NewContextWithRunner functions.
-func writeContextHelpers(buf *bytes.Buffer, cfg UnifiedRunnerConfig, userQueries []userQueryInfo) {
+// writeContextHelpers writes the RunnerFromContext, MustRunnerFromContext and
+// NewContextWithRunner functions.
+func writeContextHelpers(buf *bytes.Buffer, cfg UnifiedRunnerConfig, userQueries []userQueryInfo, preloads []preloadInfo) {
 	buf.WriteString("// =============================================================================\n")
 	buf.WriteString("// Context Helpers\n")
 	buf.WriteString("// =============================================================================\n\n")
@@ -200,13 +327,25 @@ func writeContextHelpers(buf *bytes.Buffer, cfg UnifiedRunnerConfig, userQueries
 			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params %sParams) (*%sResult, error)\n", qi.Name, qi.Name, qi.Name))
 		case query.ReturnMany:
 			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params %sParams) ([]%sResult, error)\n", qi.Name, qi.Name, qi.Name))
+			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params %sParams, fn func(%sResult) error) error\n", codegen.CRUD.EachMethodName(qi.Name), qi.Name, qi.Name))
+			if qi.Iter {
+				buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params %sParams) (iter.Seq2[%sResult, error], error)\n", codegen.CRUD.IterMethodName(qi.Name), qi.Name, qi.Name))
+			}
 		case query.ReturnExec:
 			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params %sParams) (sql.Result, error)\n", qi.Name, qi.Name))
+		case query.ReturnBulkExec:
+			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params []%sParams) (sql.Result, error)\n", qi.Name, bulkParamsName(qi)))
+		case query.ReturnApplied:
+			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params %sParams) (bool, error)\n", qi.Name, qi.Name))
 		case query.ReturnPaginated:
 			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context, params %sParams) (*%sResult, error)\n", qi.Name, qi.Name, qi.Name))
+		case query.ReturnMaterializedView:
+			buf.WriteString(fmt.Sprintf("\t%s(ctx context.Context) error\n", qi.Name))
 		}
 	}
 
+	writePreloadInterfaceMethods(buf, preloads)
+
 	// BeginTx method on the Runner interface
 	buf.WriteString("\tBeginTx(ctx context.Context) (*TxRunner, error)\n")
 
@@ -218,13 +357,55 @@ func writeContextHelpers(buf *bytes.Buffer, cfg UnifiedRunnerConfig, userQueries
 	buf.WriteString("}\n\n")
 
 	buf.WriteString("// RunnerFromContext extracts the QueryRunner from the context.\n")
-	buf.WriteString("// Panics if no runner is present (indicates middleware misconfiguration).\n")
-	buf.WriteString("func RunnerFromContext(ctx context.Context) Runner {\n")
-	buf.WriteString("\trunner := ctx.Value(contextKey{})\n")
-	buf.WriteString("\tif runner == nil {\n")
-	buf.WriteString("\t\tpanic(\"queries: no runner in context - ensure middleware calls NewContextWithRunner\")\n")
+	buf.WriteString("// Returns (nil, false) if no runner is present.\n")
+	buf.WriteString("func RunnerFromContext(ctx context.Context) (Runner, bool) {\n")
+	buf.WriteString("\trunner, ok := ctx.Value(contextKey{}).(Runner)\n")
+	buf.WriteString("\treturn runner, ok && runner != nil\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// MustRunnerFromContext is like RunnerFromContext but panics when no runner\n")
+	buf.WriteString("// is present (indicates middleware misconfiguration).\n")
+	buf.WriteString("func MustRunnerFromContext(ctx context.Context) Runner {\n")
+	buf.WriteString("\trunner, ok := RunnerFromContext(ctx)\n")
+	buf.WriteString("\tif !ok {\n")
+	buf.WriteString("\t\tpanic(\"queries.MustRunnerFromContext: no runner in context - ensure middleware calls NewContextWithRunner\")\n")
 	buf.WriteString("\t}\n")
-	buf.WriteString("\treturn runner.(Runner)\n")
+	buf.WriteString("\treturn runner\n")
+	buf.WriteString("}\n\n")
+}
+
+// hasRowLimit reports whether the runner caps any ReturnMany query.
+func hasRowLimit(cfg UnifiedRunnerConfig, userQueries []userQueryInfo) bool {
+	if cfg.MaxRows <= 0 {
+		return false
+	}
+	for _, qi := range userQueries {
+		if qi.ReturnType == query.ReturnMany {
+			return true
+		}
+	}
+	return false
+}
+
+// writeRowLimitTypes writes the MaxRows constant and the RowLimitError that
+// ReturnMany methods return instead of loading an unbounded result set.
+func writeRowLimitTypes(buf *bytes.Buffer, maxRows int) {
+	buf.WriteString("// =============================================================================\n")
+	buf.WriteString("// Row Limits\n")
+	buf.WriteString("// =============================================================================\n\n")
+
+	buf.WriteString("// MaxRows is the most rows a ReturnMany query may load ([db] max_rows in shipq.ini).\n")
+	fmt.Fprintf(buf, "const MaxRows = %d\n\n", maxRows)
+
+	buf.WriteString("// RowLimitError is returned by a ReturnMany query whose result set exceeds\n")
+	buf.WriteString("// MaxRows. Use a paginated query for large result sets.\n")
+	buf.WriteString("type RowLimitError struct {\n")
+	buf.WriteString("\tQuery string\n")
+	buf.WriteString("\tLimit int\n")
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("func (e *RowLimitError) Error() string {\n")
+	buf.WriteString("\treturn fmt.Sprintf(\"queries: %s returned more than %d rows\", e.Query, e.Limit)\n")
 	buf.WriteString("}\n\n")
 }
 
@@ -243,23 +424,46 @@ type userQueryInfo struct {
 	ParamOrder   []string // Parameter names in SQL order (may have duplicates)
 	Params       []paramInfo
 	Results      []resultInfo
+	UTCParams    map[string]bool   // params bound to timestamptz columns
+	CustomParams map[string]string // params bound to custom type columns, by type name
+	Iter         bool              // ReturnMany query that also gets a {Name}Iter method
+	Timeout      time.Duration     // MustDefineTimeout override; 0 = [db] query_timeout
 
 	// Paginated query fields (only set when ReturnType == ReturnPaginated)
 	CursorSQL        string                   // SQL with cursor WHERE clause injected
 	CursorParamOrder []string                 // Parameter names in SQL order for cursor SQL
 	CursorColumns    []query.SerializedColumn // Cursor column metadata
 
+	// Filter fields (only set for paginated queries with filters)
+	Filters           []filterInfo
+	FilterSplit       filterSplit // base SQL split at the filter conditions
+	CursorFilterSplit filterSplit // cursor SQL split at the filter conditions
+
+	// Sort fields (only set for paginated queries with sorts)
+	Sorts []sortInfo
+
+	// Optional SET clause fields (only set for UPDATE queries using SetOptional)
+	OptionalSets []optionalSetInfo
+	SetSplit     setSplit // SQL split at the optional SET clauses
+
 	// Bulk insert fields (only set when ReturnType == ReturnBulkExec)
 	BulkPrefix       string   // e.g. `INSERT INTO "t" ("a", "b") VALUES `
 	BulkParamsPerRow int      // number of params per row
 	BulkParamNames   []string // param names per row (template order)
 	BulkSuffix       string   // e.g. ` RETURNING "public_id"` or ""
 	BulkDialect      string   // "postgres", "mysql", "sqlite"
+	BulkRow          []string // row tuple split at its placeholders, e.g. ["(", ", NOW())"]
+	BulkRowParams    string   // query whose params type each row uses; "" = this query's
+
+	// Materialized view refresh fields (only set when ReturnType ==
+	// ReturnMaterializedView). SQL holds the CREATE ... IF NOT EXISTS.
+	RefreshSQL []string
 }
 
 type paramInfo struct {
-	Name   string
-	GoType string
+	Name     string
+	GoType   string
+	Optional bool // param of an optional SET clause; its field is a pointer to GoType
 }
 
 type resultInfo struct {
@@ -267,6 +471,9 @@ type resultInfo struct {
 	GoType      string
 	Column      string           // Original column name
 	JSONAggCols []jsonAggColInfo // non-nil when this is a json_agg field
+	UTC         bool             // timestamptz column, normalized to UTC after scanning
+	Custom      string           // custom type name; scanned through its conversion
+	Decimal     bool             // SUM/AVG of a decimal column, scanned through decimalScanner
 }
 
 // jsonAggColInfo describes a single column inside a json_agg aggregate.
@@ -290,6 +497,8 @@ func getCompiler(dialect string) (*compile.Compiler, error) {
 		return compile.NewCompiler(compile.MySQL), nil
 	case dburl.DialectSQLite:
 		return compile.NewCompiler(compile.SQLite), nil
+	case dburl.DialectMSSQL:
+		return compile.NewCompiler(compile.MSSQL), nil
 	default:
 		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
 	}
@@ -303,6 +512,8 @@ func getDialect(dialect string) (compile.Dialect, error) {
 		return compile.MySQL, nil
 	case dburl.DialectSQLite:
 		return compile.SQLite, nil
+	case dburl.DialectMSSQL:
+		return compile.MSSQL, nil
 	default:
 		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
 	}
@@ -320,6 +531,15 @@ func compileUserQueries(queries []query.SerializedQuery, compiler *compile.Compi
 			return nil, fmt.Errorf("failed to deserialize AST for query %s", sq.Name)
 		}
 
+		if sq.ReturnType == query.ReturnMaterializedView {
+			infos, err := compileMaterializedView(sq, ast, compiler)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, infos...)
+			continue
+		}
+
 		// Reject unsupported INSERT ... SELECT combinations
 		if ast.InsertSource != nil {
 			if sq.ReturnType == query.ReturnBulkExec {
@@ -367,6 +587,10 @@ func compileUserQueries(queries []query.SerializedQuery, compiler *compile.Compi
 			ParamOrder:   paramOrder,
 			Params:       params,
 			Results:      results,
+			UTCParams:    utcParamNames(sq.AST),
+			CustomParams: customParamNames(sq.AST),
+			Iter:         sq.Iter && sq.ReturnType == query.ReturnMany,
+			Timeout:      time.Duration(sq.TimeoutMs) * time.Millisecond,
 		}
 
 		// For bulk exec queries, compute the prefix/suffix/template parts
@@ -374,6 +598,7 @@ func compileUserQueries(queries []query.SerializedQuery, compiler *compile.Compi
 			if err := compileBulkInsertParts(&qi, ast, compiler, dialectName); err != nil {
 				return nil, fmt.Errorf("failed to compile bulk insert parts for query %s: %w", sq.Name, err)
 			}
+			qi.BulkRowParams = sq.RowParams
 		}
 
 		// For paginated queries, recompile the base SQL with ORDER BY + LIMIT,
@@ -399,11 +624,32 @@ func compileUserQueries(queries []query.SerializedQuery, compiler *compile.Compi
 			}
 			qi.CursorSQL = cursorSQL
 			qi.CursorParamOrder = cursorParamOrder
+
+			if len(sq.Filters) > 0 {
+				if err := compileFilters(&qi, sq, ast, compiler, dialectName); err != nil {
+					return nil, err
+				}
+			}
+			if len(sq.Sorts) > 0 {
+				if err := compileSorts(&qi, sq, ast, compiler, dialectName); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if hasOptionalSetClauses(ast) {
+			if err := compileOptionalSets(&qi, sq, ast, compiler, dialectName); err != nil {
+				return nil, err
+			}
 		}
 
 		result = append(result, qi)
 	}
 
+	if err := checkBulkRowParams(result); err != nil {
+		return nil, err
+	}
+
 	// Sort by name for deterministic output
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Name < result[j].Name
@@ -412,6 +658,47 @@ func compileUserQueries(queries []query.SerializedQuery, compiler *compile.Compi
 	return result, nil
 }
 
+// checkBulkRowParams checks that each bulk query registered with
+// query.RowParams names a query whose params type has every param of the
+// bulk query's template row, with the same Go type.
+func checkBulkRowParams(infos []userQueryInfo) error {
+	byName := make(map[string]userQueryInfo, len(infos))
+	for _, qi := range infos {
+		byName[qi.Name] = qi
+	}
+	for _, qi := range infos {
+		if qi.BulkRowParams == "" {
+			continue
+		}
+		ref, ok := byName[qi.BulkRowParams]
+		if !ok || ref.ReturnType == query.ReturnPaginated || ref.ReturnType == query.ReturnMaterializedView {
+			return fmt.Errorf("query %s: RowParams(%q) must name a one, many, exec or bulk query", qi.Name, qi.BulkRowParams)
+		}
+		if ref.BulkRowParams != "" {
+			return fmt.Errorf("query %s: RowParams(%q) names a query that itself uses RowParams", qi.Name, qi.BulkRowParams)
+		}
+		refTypes := make(map[string]string, len(ref.Params))
+		for _, p := range ref.Params {
+			refTypes[p.Name] = p.GoType
+		}
+		for _, p := range qi.Params {
+			if goType, ok := refTypes[p.Name]; !ok || goType != p.GoType {
+				return fmt.Errorf("query %s: param %q (%s) is not a param of %s with the same type", qi.Name, p.Name, p.GoType, qi.BulkRowParams)
+			}
+		}
+	}
+	return nil
+}
+
+// bulkParamsName returns the name of the query whose params type each row
+// of the bulk query qi is passed as.
+func bulkParamsName(qi userQueryInfo) string {
+	if qi.BulkRowParams != "" {
+		return qi.BulkRowParams
+	}
+	return qi.Name
+}
+
 // addPaginationToAST adds ORDER BY (cursor cols DESC) and LIMIT to an AST.
 // This modifies the AST in place and returns it for convenience.
 func addPaginationToAST(ast *query.AST, cursorCols []query.SerializedColumn) *query.AST {
@@ -567,9 +854,38 @@ func compileBulkInsertParts(qi *userQueryInfo, ast *query.AST, compiler *compile
 		qi.BulkSuffix = afterValues[suffixStart:]
 	}
 
+	// Recompile with a sentinel placeholder to split the row tuple into the
+	// SQL around its placeholders, keeping non-param values such as NOW()
+	// or foreign-key subqueries in every row.
+	dialect, err := getDialect(dialectName)
+	if err != nil {
+		return err
+	}
+	sentinelSQL, _, err := compile.NewCompiler(bulkRowDialect{dialect}).Compile(&singleRowAST)
+	if err != nil {
+		return fmt.Errorf("compiling single-row template: %w", err)
+	}
+	row := sentinelSQL[strings.Index(sentinelSQL, " VALUES ")+len(" VALUES "):]
+	row = strings.TrimSuffix(row, qi.BulkSuffix)
+	qi.BulkRow = strings.Split(row, bulkRowPlaceholder)
+	if len(qi.BulkRow) != qi.BulkParamsPerRow+1 {
+		return fmt.Errorf("template row has %d placeholders, want %d (params outside the VALUES row are not supported)", len(qi.BulkRow)-1, qi.BulkParamsPerRow)
+	}
+
 	return nil
 }
 
+// bulkRowPlaceholder marks placeholders in a compiled bulk insert row.
+const bulkRowPlaceholder = "\x00"
+
+// bulkRowDialect is a dialect that writes bulkRowPlaceholder for every
+// parameter, so compileBulkInsertParts can find them in the row tuple.
+type bulkRowDialect struct {
+	compile.Dialect
+}
+
+func (bulkRowDialect) Placeholder(int) string { return bulkRowPlaceholder }
+
 func extractParams(ast *query.SerializedAST) []paramInfo {
 	if ast == nil {
 		return nil
@@ -760,6 +1076,9 @@ func extractResults(ast *query.SerializedAST, queryName string) []resultInfo {
 				GoType:      goType,
 				Column:      colName,
 				JSONAggCols: jsonAggCols,
+				UTC:         col.Expr.Type == "column" && col.Expr.Column != nil && col.Expr.Column.UTC,
+				Custom:      selectColumnCustomType(col.Expr),
+				Decimal:     decimalAggregateArg(&col.Expr) != nil,
 			})
 		}
 
@@ -780,6 +1099,8 @@ func extractResults(ast *query.SerializedAST, queryName string) []resultInfo {
 				Name:   dbstrings.ToPascalCase(col.Name),
 				GoType: goType,
 				Column: col.Name,
+				UTC:    col.UTC,
+				Custom: col.Custom,
 			})
 		}
 
@@ -877,6 +1198,11 @@ func inferGoType(expr *query.SerializedExpr) string {
 			case "COUNT":
 				return "int64"
 			case "SUM", "AVG":
+				// Decimal aggregates keep the column's string type; the
+				// runner normalizes what each driver returns.
+				if col := decimalAggregateArg(expr); col != nil {
+					return col.GoType
+				}
 				return "float64"
 			case "MIN", "MAX":
 				if expr.Aggregate.Arg != nil {
@@ -892,6 +1218,12 @@ func inferGoType(expr *query.SerializedExpr) string {
 				return "time.Time"
 			case "LOWER", "UPPER", "TRIM", "CONCAT":
 				return "string"
+			case "GEO_DISTANCE":
+				return "float64"
+			case "JSON_EXTRACT_TEXT":
+				return "*string"
+			case "WITHIN_RADIUS", "GEO_CONTAINS", "ILIKE", "LIKE_CONTAINS", "LIKE_PREFIX", "LIKE_SUFFIX", "ILIKE_CONTAINS", "ILIKE_PREFIX", "ILIKE_SUFFIX", "JSON_CONTAINS":
+				return "bool"
 			case "COALESCE":
 				if len(expr.Func.Args) > 0 {
 					return inferGoType(&expr.Func.Args[0])
@@ -980,6 +1312,16 @@ func walkSerializedAST(ast *query.SerializedAST, fn func(*query.SerializedExpr))
 		walkSerializedAST(ast.InsertSource, fn)
 	}
 
+	// Walk upsert assignments
+	if oc := ast.OnConflict; oc != nil {
+		for i := range oc.Updates {
+			walkSerializedExpr(&oc.Updates[i].Value, fn)
+		}
+		if oc.Where != nil {
+			walkSerializedExpr(oc.Where, fn)
+		}
+	}
+
 	// Walk set clauses
 	for i := range ast.SetClauses {
 		walkSerializedExpr(&ast.SetClauses[i].Value, fn)
@@ -1052,6 +1394,18 @@ func collectRunnerImports(cfg UnifiedRunnerConfig, queries []userQueryInfo) map[
 	// Types package import
 	imports[cfg.ModulePath+"/shipq/queries"] = true
 
+	// The statement cache guards its map and joins Close errors
+	if cfg.PrepareStatements {
+		imports["errors"] = true
+		imports["sync"] = true
+	}
+
+	// withTimeout bounds query contexts and checks their errors
+	if hasTimeouts(cfg, queries) {
+		imports["errors"] = true
+		imports["time"] = true
+	}
+
 	// Bulk exec queries need strings and fmt for runtime SQL building
 	for _, qi := range queries {
 		if qi.ReturnType == query.ReturnBulkExec {
@@ -1083,17 +1437,41 @@ func collectRunnerImports(cfg UnifiedRunnerConfig, queries []userQueryInfo) map[
 		}
 	}
 
+	// UTC helpers for timestamptz columns take and return time.Time.
+	if hasUTCColumns(queries) {
+		imports["time"] = true
+	}
+
+	// Iterator variants of ReturnMany queries return iter.Seq2.
+	for _, qi := range queries {
+		if qi.Iter {
+			imports["iter"] = true
+		}
+	}
+
+	// Decimal aggregate scanners parse and trim the driver's value.
+	if hasDecimalResults(queries) {
+		imports["strconv"] = true
+		imports["strings"] = true
+	}
+
 	// MySQL/SQLite bool-fix helper uses strings.ReplaceAll.
 	if jsonAggNeedsBoolFix(cfg.Dialect, queries) {
 		imports["strings"] = true
 	}
 
+	// Filters of paginated queries are added to the SQL at runtime, as are
+	// the optional SET clauses of updates.
+	if hasFilters(queries) || hasOptionalSets(queries) {
+		imports["strings"] = true
+	}
+
 	// Paginated queries need fmt for fmt.Sprint when building cursors,
 	// and time for time.RFC3339Nano when formatting time.Time cursor fields.
 	for _, qi := range queries {
 		if qi.ReturnType == query.ReturnPaginated {
 			imports["fmt"] = true
-			for _, col := range qi.CursorColumns {
+			for _, col := range cursorFields(qi) {
 				if col.GoType == "time.Time" {
 					imports["time"] = true
 				}
@@ -1113,6 +1491,10 @@ func collectTypesImports(queries []userQueryInfo, cfg UnifiedRunnerConfig) map[s
 		if qi.ReturnType == query.ReturnExec || qi.ReturnType == query.ReturnBulkExec {
 			imports["database/sql"] = true
 		}
+		// and iter for iterator variants of ReturnMany queries.
+		if qi.Iter {
+			imports["iter"] = true
+		}
 		for _, p := range qi.Params {
 			if needsTimeImport(p.GoType) {
 				imports["time"] = true
@@ -1121,6 +1503,11 @@ func collectTypesImports(queries []userQueryInfo, cfg UnifiedRunnerConfig) map[s
 				imports["encoding/json"] = true
 			}
 		}
+		for _, f := range qi.Filters {
+			if needsTimeImport(f.GoType) {
+				imports["time"] = true
+			}
+		}
 		for _, r := range qi.Results {
 			if needsTimeImport(r.GoType) {
 				imports["time"] = true
@@ -1131,6 +1518,18 @@ func collectTypesImports(queries []userQueryInfo, cfg UnifiedRunnerConfig) map[s
 		}
 	}
 
+	// RowLimitError and ScanError format their messages
+	if hasRowLimit(cfg, queries) || hasScannedResults(queries) {
+		imports["fmt"] = true
+	}
+
+	// ErrTimeout is a sentinel error, and TimeoutError holds a duration
+	if hasTimeouts(cfg, queries) {
+		imports["errors"] = true
+		imports["fmt"] = true
+		imports["time"] = true
+	}
+
 	return imports
 }
 
@@ -1209,6 +1608,9 @@ type QueryRunner struct {
 	db Querier
 
 `)
+	if cfg.PrepareStatements {
+		buf.WriteString("\tstmts *stmtCache\n\n")
+	}
 
 	// User query SQL fields
 	if len(queries) > 0 {
@@ -1218,9 +1620,11 @@ type QueryRunner struct {
 				prefix := dbstrings.ToLowerCamel(qi.Name) + "BulkPrefix"
 				suffix := dbstrings.ToLowerCamel(qi.Name) + "BulkSuffix"
 				ppr := dbstrings.ToLowerCamel(qi.Name) + "BulkParamsPerRow"
+				row := dbstrings.ToLowerCamel(qi.Name) + "BulkRow"
 				buf.WriteString(fmt.Sprintf("\t%s string\n", prefix))
 				buf.WriteString(fmt.Sprintf("\t%s string\n", suffix))
 				buf.WriteString(fmt.Sprintf("\t%s int\n", ppr))
+				buf.WriteString(fmt.Sprintf("\t%s []string\n", row))
 			} else {
 				fieldName := dbstrings.ToLowerCamel(qi.Name) + "SQL"
 				buf.WriteString(fmt.Sprintf("\t%s string\n", fieldName))
@@ -1228,6 +1632,13 @@ type QueryRunner struct {
 					cursorFieldName := dbstrings.ToLowerCamel(qi.Name) + "CursorSQL"
 					buf.WriteString(fmt.Sprintf("\t%s string\n", cursorFieldName))
 				}
+				if len(qi.Filters) > 0 {
+					buf.WriteString(fmt.Sprintf("\t%s queryFilters\n", filtersField(qi)))
+				}
+				writeSortFieldDecls(buf, qi)
+				if len(qi.OptionalSets) > 0 {
+					buf.WriteString(fmt.Sprintf("\t%s optionalSets\n", setsField(qi)))
+				}
 			}
 		}
 	}
@@ -1243,6 +1654,9 @@ func NewQueryRunner(db Querier) *QueryRunner {
 		db: db,
 
 `)
+	if cfg.PrepareStatements {
+		buf.WriteString("\t\tstmts: newStmtCache(db),\n\n")
+	}
 
 	// User query SQL values
 	if len(queries) > 0 {
@@ -1252,9 +1666,11 @@ func NewQueryRunner(db Querier) *QueryRunner {
 				prefix := dbstrings.ToLowerCamel(qi.Name) + "BulkPrefix"
 				suffix := dbstrings.ToLowerCamel(qi.Name) + "BulkSuffix"
 				ppr := dbstrings.ToLowerCamel(qi.Name) + "BulkParamsPerRow"
+				row := dbstrings.ToLowerCamel(qi.Name) + "BulkRow"
 				buf.WriteString(fmt.Sprintf("\t\t%s: %q,\n", prefix, qi.BulkPrefix))
 				buf.WriteString(fmt.Sprintf("\t\t%s: %q,\n", suffix, qi.BulkSuffix))
 				buf.WriteString(fmt.Sprintf("\t\t%s: %d,\n", ppr, qi.BulkParamsPerRow))
+				buf.WriteString(fmt.Sprintf("\t\t%s: %#v,\n", row, qi.BulkRow))
 			} else {
 				fieldName := dbstrings.ToLowerCamel(qi.Name) + "SQL"
 				buf.WriteString(fmt.Sprintf("\t\t%s: %q,\n", fieldName, qi.SQL))
@@ -1262,6 +1678,13 @@ func NewQueryRunner(db Querier) *QueryRunner {
 					cursorFieldName := dbstrings.ToLowerCamel(qi.Name) + "CursorSQL"
 					buf.WriteString(fmt.Sprintf("\t\t%s: %q,\n", cursorFieldName, qi.CursorSQL))
 				}
+				if len(qi.Filters) > 0 {
+					writeFiltersValue(buf, filtersField(qi), qi.Filters, qi.FilterSplit, qi.CursorFilterSplit)
+				}
+				writeSortFieldValues(buf, qi)
+				if len(qi.OptionalSets) > 0 {
+					writeOptionalSetsValue(buf, qi)
+				}
 			}
 		}
 	}
@@ -1277,6 +1700,9 @@ func (r *QueryRunner) WithTx(tx *sql.Tx) *QueryRunner {
 		db: tx,
 
 `)
+	if cfg.PrepareStatements {
+		buf.WriteString("\t\tstmts: r.stmts,\n\n")
+	}
 
 	// Copy all query SQL (user-defined and CRUD queries are unified)
 	if len(queries) > 0 {
@@ -1285,9 +1711,11 @@ func (r *QueryRunner) WithTx(tx *sql.Tx) *QueryRunner {
 				prefix := dbstrings.ToLowerCamel(qi.Name) + "BulkPrefix"
 				suffix := dbstrings.ToLowerCamel(qi.Name) + "BulkSuffix"
 				ppr := dbstrings.ToLowerCamel(qi.Name) + "BulkParamsPerRow"
+				row := dbstrings.ToLowerCamel(qi.Name) + "BulkRow"
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", prefix, prefix))
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", suffix, suffix))
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", ppr, ppr))
+				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", row, row))
 			} else {
 				fieldName := dbstrings.ToLowerCamel(qi.Name) + "SQL"
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", fieldName, fieldName))
@@ -1295,6 +1723,13 @@ func (r *QueryRunner) WithTx(tx *sql.Tx) *QueryRunner {
 					cursorFieldName := dbstrings.ToLowerCamel(qi.Name) + "CursorSQL"
 					buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", cursorFieldName, cursorFieldName))
 				}
+				if len(qi.Filters) > 0 {
+					buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", filtersField(qi), filtersField(qi)))
+				}
+				writeSortFieldCopies(buf, qi)
+				if len(qi.OptionalSets) > 0 {
+					buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", setsField(qi), setsField(qi)))
+				}
 			}
 		}
 	}
@@ -1307,7 +1742,14 @@ func (r *QueryRunner) WithTx(tx *sql.Tx) *QueryRunner {
 
 // writeBeginTx emits the BeginTx method on QueryRunner.
 func writeBeginTx(buf *bytes.Buffer) {
-	buf.WriteString(`// BeginTx starts a new database transaction and returns a TxRunner
+	buf.WriteString(`// InTx reports whether r runs on a transaction, such as the one WithTx
+// binds it to, rather than on a *sql.DB it could begin one on.
+func (r *QueryRunner) InTx() bool {
+	_, ok := r.db.(*sql.DB)
+	return !ok
+}
+
+// BeginTx starts a new database transaction and returns a TxRunner
 // that wraps a transactional copy of this QueryRunner.
 // If the underlying db is already a *sql.Tx, BeginTx returns an error.
 func (r *QueryRunner) BeginTx(ctx context.Context) (*queries.TxRunner, error) {
@@ -1335,6 +1777,9 @@ func (r *QueryRunner) WithDB(db Querier) *QueryRunner {
 		db: db,
 
 `)
+	if cfg.PrepareStatements {
+		buf.WriteString("\t\tstmts: r.stmts.forDB(db),\n\n")
+	}
 
 	// Copy all query SQL (user-defined and CRUD queries are unified)
 	if len(queries) > 0 {
@@ -1343,9 +1788,11 @@ func (r *QueryRunner) WithDB(db Querier) *QueryRunner {
 				prefix := dbstrings.ToLowerCamel(qi.Name) + "BulkPrefix"
 				suffix := dbstrings.ToLowerCamel(qi.Name) + "BulkSuffix"
 				ppr := dbstrings.ToLowerCamel(qi.Name) + "BulkParamsPerRow"
+				row := dbstrings.ToLowerCamel(qi.Name) + "BulkRow"
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", prefix, prefix))
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", suffix, suffix))
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", ppr, ppr))
+				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", row, row))
 			} else {
 				fieldName := dbstrings.ToLowerCamel(qi.Name) + "SQL"
 				buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", fieldName, fieldName))
@@ -1353,6 +1800,13 @@ func (r *QueryRunner) WithDB(db Querier) *QueryRunner {
 					cursorFieldName := dbstrings.ToLowerCamel(qi.Name) + "CursorSQL"
 					buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", cursorFieldName, cursorFieldName))
 				}
+				if len(qi.Filters) > 0 {
+					buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", filtersField(qi), filtersField(qi)))
+				}
+				writeSortFieldCopies(buf, qi)
+				if len(qi.OptionalSets) > 0 {
+					buf.WriteString(fmt.Sprintf("\t\t%s: r.%s,\n", setsField(qi), setsField(qi)))
+				}
 			}
 		}
 	}
@@ -1363,18 +1817,28 @@ func (r *QueryRunner) WithDB(db Querier) *QueryRunner {
 func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig) error {
 	typesPackage := "queries"
 
+	if hasScannedResults([]userQueryInfo{qi}) {
+		writeScanColumns(buf, qi)
+	}
+
 	switch qi.ReturnType {
 	case query.ReturnBulkExec:
 		writeBulkExecMethod(buf, qi, cfg)
 		return nil
 
+	case query.ReturnMaterializedView:
+		writeRefreshViewMethod(buf, qi)
+		return nil
+
 	case query.ReturnOne:
 		// Returns (*Result, error)
 		paramType := fmt.Sprintf("%s.%sParams", typesPackage, qi.Name)
 		resultType := fmt.Sprintf("%s.%sResult", typesPackage, qi.Name)
 
 		buf.WriteString(fmt.Sprintf("// %s executes the user-defined query and returns at most one result.\n", qi.Name))
-		buf.WriteString(fmt.Sprintf("func (r *QueryRunner) %s(ctx context.Context, params %s) (*%s, error) {\n", qi.Name, paramType, resultType))
+		timeout := queryTimeout(qi, cfg)
+		fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params %s) %s {\n", qi.Name, paramType, timeoutResults(timeout, "*"+resultType))
+		writeTimeoutStart(buf, qi.Name, timeout)
 
 		// Build args slice
 		writeArgsSlice(buf, qi)
@@ -1389,7 +1853,7 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 			writeMySQLInsertReturningOne(buf, qi, sqlField, resultType, cfg)
 		} else {
 			// Postgres, SQLite, or non-INSERT: use QueryRowContext with RETURNING
-			buf.WriteString(fmt.Sprintf("\trow := r.db.QueryRowContext(ctx, r.%s, args...)\n\n", sqlField))
+			fmt.Fprintf(buf, "\trow := %s\n\n", dbCall(cfg, "QueryRowContext", strconv.Quote(qi.Name), "r."+sqlField))
 
 			// Scan result
 			buf.WriteString(fmt.Sprintf("\tvar result %s\n", resultType))
@@ -1429,16 +1893,16 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 					buf.WriteString(fmt.Sprintf("\t\t&%s,\n", tmp))
 					continue
 				}
-				buf.WriteString(fmt.Sprintf("\t\t&result.%s,\n", r.Name))
+				buf.WriteString(fmt.Sprintf("\t\t%s,\n", scanTarget(r, "result")))
 			}
 			buf.WriteString("\t); err != nil {\n")
 			buf.WriteString("\t\tif err == sql.ErrNoRows {\n")
 			buf.WriteString("\t\t\treturn nil, nil\n")
 			buf.WriteString("\t\t}\n")
-			buf.WriteString("\t\treturn nil, err\n")
+			buf.WriteString("\t\t" + scanErrorReturn(qi, "nil, err") + "\n")
 			buf.WriteString("\t}\n")
 			// Unmarshal json_agg fields (all dialects)
-			needsBoolFix := cfg.Dialect == dburl.DialectMySQL || cfg.Dialect == dburl.DialectSQLite
+			needsBoolFix := jsonAggBoolsAreNumeric(cfg.Dialect)
 			needsNullStrip := cfg.Dialect == dburl.DialectMySQL || cfg.Dialect == dburl.DialectSQLite
 			for _, r := range qi.Results {
 				if len(r.JSONAggCols) == 0 {
@@ -1455,7 +1919,7 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 					buf.WriteString(fmt.Sprintf("\t%s = stripJSONNulls(%s)\n", tmp, tmp))
 				}
 				buf.WriteString(fmt.Sprintf("\tif err := json.Unmarshal([]byte(%s), &result.%s); err != nil {\n", tmp, r.Name))
-				buf.WriteString("\t\treturn nil, err\n")
+				buf.WriteString("\t\t" + decodeErrorReturn(qi, r, "nil, err") + "\n")
 				buf.WriteString("\t}\n")
 			}
 			if isSQLite {
@@ -1470,11 +1934,11 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 					switch r.GoType {
 					case "time.Time":
 						buf.WriteString(fmt.Sprintf("\tparsed%s, err := parseSQLiteTime(%s)\n", r.Name, tmp))
-						buf.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+						buf.WriteString("\tif err != nil {\n\t\t" + decodeErrorReturn(qi, r, "nil, err") + "\n\t}\n")
 						buf.WriteString(fmt.Sprintf("\tresult.%s = parsed%s\n", r.Name, r.Name))
 					case "*time.Time":
 						buf.WriteString(fmt.Sprintf("\tparsed%s, err := parseSQLiteNullTime(%s)\n", r.Name, tmp))
-						buf.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+						buf.WriteString("\tif err != nil {\n\t\t" + decodeErrorReturn(qi, r, "nil, err") + "\n\t}\n")
 						buf.WriteString(fmt.Sprintf("\tresult.%s = parsed%s\n", r.Name, r.Name))
 					case "json.RawMessage":
 						buf.WriteString(fmt.Sprintf("\tif %s.Valid {\n\t\tresult.%s = []byte(%s.String)\n\t}\n", tmp, r.Name, tmp))
@@ -1483,6 +1947,7 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 					}
 				}
 			}
+			writeUTCResults(buf, qi.Results, "result", "\t")
 			buf.WriteString("\treturn &result, nil\n")
 		}
 		buf.WriteString("}\n\n")
@@ -1493,111 +1958,30 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 		resultType := fmt.Sprintf("%s.%sResult", typesPackage, qi.Name)
 
 		buf.WriteString(fmt.Sprintf("// %s executes the user-defined query and returns all results.\n", qi.Name))
-		buf.WriteString(fmt.Sprintf("func (r *QueryRunner) %s(ctx context.Context, params %s) ([]%s, error) {\n", qi.Name, paramType, resultType))
+		timeout := queryTimeout(qi, cfg)
+		fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params %s) %s {\n", qi.Name, paramType, timeoutResults(timeout, "[]"+resultType))
+		writeTimeoutStart(buf, qi.Name, timeout)
 
 		// Build args slice
 		writeArgsSlice(buf, qi)
 
 		// Execute query
 		sqlField := dbstrings.ToLowerCamel(qi.Name) + "SQL"
-		buf.WriteString(fmt.Sprintf("\trows, err := r.db.QueryContext(ctx, r.%s, args...)\n", sqlField))
+		fmt.Fprintf(buf, "\trows, err := %s\n", dbCall(cfg, "QueryContext", strconv.Quote(qi.Name), "r."+sqlField))
 		buf.WriteString("\tif err != nil {\n")
 		buf.WriteString("\t\treturn nil, err\n")
 		buf.WriteString("\t}\n")
 		buf.WriteString("\tdefer rows.Close()\n\n")
 
-		isSQLite := cfg.Dialect == dburl.DialectSQLite
 		// Scan results
 		buf.WriteString(fmt.Sprintf("\tvar results []%s\n", resultType))
 		buf.WriteString("\tfor rows.Next() {\n")
-		buf.WriteString(fmt.Sprintf("\t\tvar item %s\n", resultType))
-		// Declare temp vars for json_agg fields (all dialects)
-		for _, r := range qi.Results {
-			if len(r.JSONAggCols) > 0 {
-				tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
-				buf.WriteString(fmt.Sprintf("\t\tvar %s string\n", tmp))
-			}
-		}
-		if isSQLite {
-			for _, r := range qi.Results {
-				if len(r.JSONAggCols) > 0 {
-					continue // already handled above
-				}
-				if !isSQLiteSpecialResultGoType(r.GoType) {
-					continue
-				}
-				tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
-				switch r.GoType {
-				case "time.Time":
-					buf.WriteString(fmt.Sprintf("\t\tvar %s string\n", tmp))
-				case "*time.Time", "json.RawMessage", "*json.RawMessage":
-					buf.WriteString(fmt.Sprintf("\t\tvar %s sql.NullString\n", tmp))
-				}
-			}
-		}
-		buf.WriteString("\t\tif err := rows.Scan(\n")
-		for _, r := range qi.Results {
-			if len(r.JSONAggCols) > 0 {
-				tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
-				buf.WriteString(fmt.Sprintf("\t\t\t&%s,\n", tmp))
-				continue
-			}
-			if isSQLite && isSQLiteSpecialResultGoType(r.GoType) {
-				tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
-				buf.WriteString(fmt.Sprintf("\t\t\t&%s,\n", tmp))
-				continue
-			}
-			buf.WriteString(fmt.Sprintf("\t\t\t&item.%s,\n", r.Name))
-		}
-		buf.WriteString("\t\t); err != nil {\n")
-		buf.WriteString("\t\t\treturn nil, err\n")
-		buf.WriteString("\t\t}\n")
-		// Unmarshal json_agg fields (all dialects)
-		needsBoolFix := cfg.Dialect == dburl.DialectMySQL || cfg.Dialect == dburl.DialectSQLite
-		needsNullStrip := cfg.Dialect == dburl.DialectMySQL || cfg.Dialect == dburl.DialectSQLite
-		for _, r := range qi.Results {
-			if len(r.JSONAggCols) == 0 {
-				continue
-			}
-			tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
-			// Fix MySQL/SQLite numeric bools (0/1) before unmarshal
-			if needsBoolFix && jsonAggColsHaveBool(r.JSONAggCols) {
-				boolFields := jsonAggBoolFieldNames(r.JSONAggCols)
-				buf.WriteString(fmt.Sprintf("\t\t%s = fixJSONBoolFields(%s, %s)\n", tmp, tmp, boolFields))
-			}
-			// Strip null entries from JSON array (MySQL/SQLite LEFT JOIN produces [null])
-			if needsNullStrip {
-				buf.WriteString(fmt.Sprintf("\t\t%s = stripJSONNulls(%s)\n", tmp, tmp))
-			}
-			buf.WriteString(fmt.Sprintf("\t\tif err := json.Unmarshal([]byte(%s), &item.%s); err != nil {\n", tmp, r.Name))
-			buf.WriteString("\t\t\treturn nil, err\n")
+		if cfg.MaxRows > 0 {
+			buf.WriteString("\t\tif len(results) == queries.MaxRows {\n")
+			fmt.Fprintf(buf, "\t\t\treturn nil, &queries.RowLimitError{Query: %q, Limit: queries.MaxRows}\n", qi.Name)
 			buf.WriteString("\t\t}\n")
 		}
-		if isSQLite {
-			for _, r := range qi.Results {
-				if len(r.JSONAggCols) > 0 {
-					continue // already handled above
-				}
-				if !isSQLiteSpecialResultGoType(r.GoType) {
-					continue
-				}
-				tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
-				switch r.GoType {
-				case "time.Time":
-					buf.WriteString(fmt.Sprintf("\t\tparsed%s, err := parseSQLiteTime(%s)\n", r.Name, tmp))
-					buf.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
-					buf.WriteString(fmt.Sprintf("\t\titem.%s = parsed%s\n", r.Name, r.Name))
-				case "*time.Time":
-					buf.WriteString(fmt.Sprintf("\t\tparsed%s, err := parseSQLiteNullTime(%s)\n", r.Name, tmp))
-					buf.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
-					buf.WriteString(fmt.Sprintf("\t\titem.%s = parsed%s\n", r.Name, r.Name))
-				case "json.RawMessage":
-					buf.WriteString(fmt.Sprintf("\t\tif %s.Valid {\n\t\t\titem.%s = []byte(%s.String)\n\t\t}\n", tmp, r.Name, tmp))
-				case "*json.RawMessage":
-					buf.WriteString(fmt.Sprintf("\t\tif %s.Valid {\n\t\t\tv := json.RawMessage(%s.String)\n\t\t\titem.%s = &v\n\t\t}\n", tmp, tmp, r.Name))
-				}
-			}
-		}
+		writeManyRowScan(buf, qi, cfg, resultType, "nil, err")
 		buf.WriteString("\t\tresults = append(results, item)\n")
 		buf.WriteString("\t}\n\n")
 
@@ -1607,19 +1991,47 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 		buf.WriteString("\treturn results, nil\n")
 		buf.WriteString("}\n\n")
 
+		writeEachMethod(buf, qi, cfg, paramType, resultType)
+		if qi.Iter {
+			writeIterMethod(buf, qi, cfg, paramType, resultType)
+		}
+
 	case query.ReturnExec:
 		// Returns (sql.Result, error)
 		paramType := fmt.Sprintf("%s.%sParams", typesPackage, qi.Name)
 
 		buf.WriteString(fmt.Sprintf("// %s executes the user-defined query without returning rows.\n", qi.Name))
-		buf.WriteString(fmt.Sprintf("func (r *QueryRunner) %s(ctx context.Context, params %s) (sql.Result, error) {\n", qi.Name, paramType))
+		timeout := queryTimeout(qi, cfg)
+		fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params %s) %s {\n", qi.Name, paramType, timeoutResults(timeout, "sql.Result"))
+		writeTimeoutStart(buf, qi.Name, timeout)
 
 		// Build args slice
-		writeArgsSlice(buf, qi)
+		nameExpr, sqlExpr := writeExecArgs(buf, qi, cfg)
 
 		// Execute query
-		sqlField := dbstrings.ToLowerCamel(qi.Name) + "SQL"
-		buf.WriteString(fmt.Sprintf("\treturn r.db.ExecContext(ctx, r.%s, args...)\n", sqlField))
+		fmt.Fprintf(buf, "\treturn %s\n", dbCall(cfg, "ExecContext", nameExpr, sqlExpr))
+		buf.WriteString("}\n\n")
+
+	case query.ReturnApplied:
+		// Returns (bool, error): whether the conditional statement matched a row
+		paramType := fmt.Sprintf("%s.%sParams", typesPackage, qi.Name)
+
+		buf.WriteString(fmt.Sprintf("// %s executes the user-defined query and reports whether it changed a row.\n", qi.Name))
+		timeout := queryTimeout(qi, cfg)
+		fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params %s) %s {\n", qi.Name, paramType, timeoutResults(timeout, "bool"))
+		writeTimeoutStart(buf, qi.Name, timeout)
+
+		nameExpr, sqlExpr := writeExecArgs(buf, qi, cfg)
+
+		fmt.Fprintf(buf, "\tres, err := %s\n", dbCall(cfg, "ExecContext", nameExpr, sqlExpr))
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn false, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\tn, err := res.RowsAffected()\n")
+		buf.WriteString("\tif err != nil {\n")
+		buf.WriteString("\t\treturn false, err\n")
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn n > 0, nil\n")
 		buf.WriteString("}\n\n")
 
 	case query.ReturnPaginated:
@@ -1629,6 +2041,179 @@ func writeUserQueryMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 	return nil
 }
 
+// writeEachMethod writes Each<Name>, the streaming form of a ReturnMany
+// query: it hands rows to fn one at a time instead of collecting them, so
+// callers such as streamed JSON responses hold one row in memory. It is not
+// subject to MaxRows. Returning an error from fn stops the iteration and is
+// returned as is. The context is checked before every row, so a caller that
+// goes away (a disconnected HTTP client) stops the scan at the next row even
+// when the driver has rows buffered.
+func writeEachMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig, paramType, resultType string) {
+	name := codegen.CRUD.EachMethodName(qi.Name)
+	fmt.Fprintf(buf, "// %s executes the user-defined query and calls fn for each result in turn.\n", name)
+	fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params %s, fn func(%s) error) error {\n", name, paramType, resultType)
+
+	writeArgsSlice(buf, qi)
+
+	sqlField := dbstrings.ToLowerCamel(qi.Name) + "SQL"
+	fmt.Fprintf(buf, "\trows, err := %s\n", dbCall(cfg, "QueryContext", strconv.Quote(qi.Name), "r."+sqlField))
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn err\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tdefer rows.Close()\n\n")
+
+	buf.WriteString("\tfor rows.Next() {\n")
+	buf.WriteString("\t\tif err := ctx.Err(); err != nil {\n")
+	buf.WriteString("\t\t\treturn err\n")
+	buf.WriteString("\t\t}\n")
+	writeManyRowScan(buf, qi, cfg, resultType, "err")
+	buf.WriteString("\t\tif err := fn(item); err != nil {\n")
+	buf.WriteString("\t\t\treturn err\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn rows.Err()\n")
+	buf.WriteString("}\n\n")
+}
+
+// writeIterMethod writes the {Name}Iter variant of a ReturnMany query. The
+// query runs when the method is called, so its error is returned directly;
+// rows are then scanned as the caller ranges over the sequence, and closed
+// when the loop ends. Like Each{Name}, it has no row limit.
+func writeIterMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig, paramType, resultType string) {
+	name := codegen.CRUD.IterMethodName(qi.Name)
+	fmt.Fprintf(buf, "// %s executes the user-defined query and returns an iterator over\n", name)
+	buf.WriteString("// its results. The rows stay open until a range loop over it ends, so\n")
+	buf.WriteString("// range over it exactly once.\n")
+	fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params %s) (iter.Seq2[%s, error], error) {\n", name, paramType, resultType)
+
+	writeArgsSlice(buf, qi)
+
+	sqlField := dbstrings.ToLowerCamel(qi.Name) + "SQL"
+	fmt.Fprintf(buf, "\trows, err := %s\n", dbCall(cfg, "QueryContext", strconv.Quote(qi.Name), "r."+sqlField))
+	buf.WriteString("\tif err != nil {\n")
+	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t}\n\n")
+
+	fmt.Fprintf(buf, "\treturn func(yield func(%s, error) bool) {\n", resultType)
+	buf.WriteString("\t\tdefer rows.Close()\n")
+	buf.WriteString("\t\tfor rows.Next() {\n")
+	buf.WriteString("\t\t\tif err := ctx.Err(); err != nil {\n")
+	fmt.Fprintf(buf, "\t\t\t\tyield(%s{}, err)\n", resultType)
+	buf.WriteString("\t\t\t\treturn\n")
+	buf.WriteString("\t\t\t}\n")
+	// The row scan returns its errors, so it runs in a function literal.
+	fmt.Fprintf(buf, "\t\t\titem, err := func() (%s, error) {\n", resultType)
+	writeManyRowScan(buf, qi, cfg, resultType, resultType+"{}, err")
+	buf.WriteString("\t\t\t\treturn item, nil\n")
+	buf.WriteString("\t\t\t}()\n")
+	buf.WriteString("\t\t\tif !yield(item, err) || err != nil {\n")
+	buf.WriteString("\t\t\t\treturn\n")
+	buf.WriteString("\t\t\t}\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tif err := rows.Err(); err != nil {\n")
+	fmt.Fprintf(buf, "\t\t\tyield(%s{}, err)\n", resultType)
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}, nil\n")
+	buf.WriteString("}\n\n")
+}
+
+// writeManyRowScan writes the body of a rows.Next() loop that scans one row
+// of a ReturnMany query into item, decoding json_agg columns and SQLite
+// text-encoded values. errReturn is what the enclosing method returns
+// alongside a scan error, e.g. "nil, err".
+func writeManyRowScan(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig, resultType, errReturn string) {
+	isSQLite := cfg.Dialect == dburl.DialectSQLite
+	buf.WriteString(fmt.Sprintf("\t\tvar item %s\n", resultType))
+	// Declare temp vars for json_agg fields (all dialects)
+	for _, r := range qi.Results {
+		if len(r.JSONAggCols) > 0 {
+			tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
+			buf.WriteString(fmt.Sprintf("\t\tvar %s string\n", tmp))
+		}
+	}
+	if isSQLite {
+		for _, r := range qi.Results {
+			if len(r.JSONAggCols) > 0 {
+				continue // already handled above
+			}
+			if !isSQLiteSpecialResultGoType(r.GoType) {
+				continue
+			}
+			tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
+			switch r.GoType {
+			case "time.Time":
+				buf.WriteString(fmt.Sprintf("\t\tvar %s string\n", tmp))
+			case "*time.Time", "json.RawMessage", "*json.RawMessage":
+				buf.WriteString(fmt.Sprintf("\t\tvar %s sql.NullString\n", tmp))
+			}
+		}
+	}
+	buf.WriteString("\t\tif err := rows.Scan(\n")
+	for _, r := range qi.Results {
+		if len(r.JSONAggCols) > 0 {
+			tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
+			buf.WriteString(fmt.Sprintf("\t\t\t&%s,\n", tmp))
+			continue
+		}
+		if isSQLite && isSQLiteSpecialResultGoType(r.GoType) {
+			tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
+			buf.WriteString(fmt.Sprintf("\t\t\t&%s,\n", tmp))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("\t\t\t%s,\n", scanTarget(r, "item")))
+	}
+	buf.WriteString("\t\t); err != nil {\n")
+	buf.WriteString("\t\t\t" + scanErrorReturn(qi, errReturn) + "\n")
+	buf.WriteString("\t\t}\n")
+	// Unmarshal json_agg fields (all dialects)
+	needsBoolFix := jsonAggBoolsAreNumeric(cfg.Dialect)
+	needsNullStrip := cfg.Dialect == dburl.DialectMySQL || cfg.Dialect == dburl.DialectSQLite
+	for _, r := range qi.Results {
+		if len(r.JSONAggCols) == 0 {
+			continue
+		}
+		tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
+		// Fix MySQL/SQLite numeric bools (0/1) before unmarshal
+		if needsBoolFix && jsonAggColsHaveBool(r.JSONAggCols) {
+			boolFields := jsonAggBoolFieldNames(r.JSONAggCols)
+			buf.WriteString(fmt.Sprintf("\t\t%s = fixJSONBoolFields(%s, %s)\n", tmp, tmp, boolFields))
+		}
+		// Strip null entries from JSON array (MySQL/SQLite LEFT JOIN produces [null])
+		if needsNullStrip {
+			buf.WriteString(fmt.Sprintf("\t\t%s = stripJSONNulls(%s)\n", tmp, tmp))
+		}
+		buf.WriteString(fmt.Sprintf("\t\tif err := json.Unmarshal([]byte(%s), &item.%s); err != nil {\n", tmp, r.Name))
+		buf.WriteString("\t\t\t" + decodeErrorReturn(qi, r, errReturn) + "\n")
+		buf.WriteString("\t\t}\n")
+	}
+	if isSQLite {
+		for _, r := range qi.Results {
+			if len(r.JSONAggCols) > 0 {
+				continue // already handled above
+			}
+			if !isSQLiteSpecialResultGoType(r.GoType) {
+				continue
+			}
+			tmp := dbstrings.ToLowerCamel(r.Name) + "Raw"
+			switch r.GoType {
+			case "time.Time":
+				buf.WriteString(fmt.Sprintf("\t\tparsed%s, err := parseSQLiteTime(%s)\n", r.Name, tmp))
+				buf.WriteString("\t\tif err != nil {\n\t\t\t" + decodeErrorReturn(qi, r, errReturn) + "\n\t\t}\n")
+				buf.WriteString(fmt.Sprintf("\t\titem.%s = parsed%s\n", r.Name, r.Name))
+			case "*time.Time":
+				buf.WriteString(fmt.Sprintf("\t\tparsed%s, err := parseSQLiteNullTime(%s)\n", r.Name, tmp))
+				buf.WriteString("\t\tif err != nil {\n\t\t\t" + decodeErrorReturn(qi, r, errReturn) + "\n\t\t}\n")
+				buf.WriteString(fmt.Sprintf("\t\titem.%s = parsed%s\n", r.Name, r.Name))
+			case "json.RawMessage":
+				buf.WriteString(fmt.Sprintf("\t\tif %s.Valid {\n\t\t\titem.%s = []byte(%s.String)\n\t\t}\n", tmp, r.Name, tmp))
+			case "*json.RawMessage":
+				buf.WriteString(fmt.Sprintf("\t\tif %s.Valid {\n\t\t\tv := json.RawMessage(%s.String)\n\t\t\titem.%s = &v\n\t\t}\n", tmp, tmp, r.Name))
+			}
+		}
+	}
+	writeUTCResults(buf, qi.Results, "item", "\t\t")
+}
+
 // writeMySQLInsertReturningOne generates the MySQL-specific pattern for INSERT
 // queries that want to return columns (which other dialects handle via RETURNING).
 // MySQL doesn't support RETURNING, so we:
@@ -1640,7 +2225,7 @@ func writeMySQLInsertReturningOne(buf *bytes.Buffer, qi userQueryInfo, sqlField,
 	quotedTable := quoteIdentifier(qi.TableName, mysqlDialect)
 
 	// Step 1: ExecContext
-	buf.WriteString(fmt.Sprintf("\texecResult, err := r.db.ExecContext(ctx, r.%s, args...)\n", sqlField))
+	fmt.Fprintf(buf, "\texecResult, err := %s\n", dbCall(cfg, "ExecContext", strconv.Quote(qi.Name), "r."+sqlField))
 	buf.WriteString("\tif err != nil {\n")
 	buf.WriteString("\t\treturn nil, err\n")
 	buf.WriteString("\t}\n\n")
@@ -1667,11 +2252,12 @@ func writeMySQLInsertReturningOne(buf *bytes.Buffer, qi userQueryInfo, sqlField,
 	buf.WriteString(fmt.Sprintf("\tvar result %s\n", resultType))
 	buf.WriteString("\tif err := row.Scan(\n")
 	for _, r := range qi.Results {
-		buf.WriteString(fmt.Sprintf("\t\t&result.%s,\n", r.Name))
+		buf.WriteString(fmt.Sprintf("\t\t%s,\n", scanTarget(r, "result")))
 	}
 	buf.WriteString("\t); err != nil {\n")
-	buf.WriteString("\t\treturn nil, err\n")
+	buf.WriteString("\t\t" + scanErrorReturn(qi, "nil, err") + "\n")
 	buf.WriteString("\t}\n")
+	writeUTCResults(buf, qi.Results, "result", "\t")
 	buf.WriteString("\treturn &result, nil\n")
 }
 
@@ -1683,11 +2269,21 @@ func writeArgsSlice(buf *bytes.Buffer, qi userQueryInfo) {
 
 	buf.WriteString("\targs := []any{\n")
 	for _, paramName := range qi.ParamOrder {
-		buf.WriteString(fmt.Sprintf("\t\tparams.%s,\n", dbstrings.ToPascalCase(paramName)))
+		buf.WriteString(fmt.Sprintf("\t\t%s,\n", argExpr(qi, "params", paramName)))
 	}
 	buf.WriteString("\t}\n")
 }
 
+// writeExecArgs writes the args of the exec query qi and returns the
+// expressions naming and holding the SQL to run with them.
+func writeExecArgs(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig) (nameExpr, sqlExpr string) {
+	if len(qi.OptionalSets) > 0 {
+		return writeOptionalSetsApply(buf, qi, cfg)
+	}
+	writeArgsSlice(buf, qi)
+	return strconv.Quote(qi.Name), "r." + dbstrings.ToLowerCamel(qi.Name) + "SQL"
+}
+
 // writePaginatedMethod generates the cursor-paginated method for a query.
 func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig) {
 	typesPackage := "queries"
@@ -1702,7 +2298,9 @@ func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 	isSQLite := cfg.Dialect == dburl.DialectSQLite
 
 	buf.WriteString(fmt.Sprintf("// %s fetches paginated results with cursor support.\n", name))
-	buf.WriteString(fmt.Sprintf("func (r *QueryRunner) %s(ctx context.Context, params %s) (*%s, error) {\n", name, paramType, resultType))
+	timeout := queryTimeout(qi, cfg)
+	fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params %s) %s {\n", name, paramType, timeoutResults(timeout, "*"+resultType))
+	writeTimeoutStart(buf, name, timeout)
 
 	// Handle pagination params
 	buf.WriteString("\tlimit := params.Limit\n")
@@ -1711,45 +2309,65 @@ func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 	buf.WriteString("\t}\n")
 	buf.WriteString("\tfetchLimit := limit + 1\n\n")
 
+	if len(qi.Sorts) > 0 {
+		buf.WriteString("\tif params.Cursor != nil && params.Cursor.Sort != params.Sort {\n")
+		buf.WriteString("\t\t// A cursor issued for another sort starts over at the first page\n")
+		buf.WriteString("\t\tparams.Cursor = nil\n")
+		buf.WriteString("\t}\n\n")
+	}
+
 	// Build args and pick SQL based on cursor presence
 	buf.WriteString("\tvar sqlStr string\n")
-	buf.WriteString("\tvar args []any\n\n")
-
-	buf.WriteString("\tif params.Cursor != nil {\n")
-	buf.WriteString(fmt.Sprintf("\t\tsqlStr = r.%s\n", cursorSQLField))
-
-	// Build cursor args: user params first, then cursor params, then __limit
-	buf.WriteString("\t\targs = []any{\n")
-	for _, paramName := range qi.CursorParamOrder {
-		if strings.HasPrefix(paramName, "__cursor_") {
-			colName := strings.TrimPrefix(paramName, "__cursor_")
-			fieldName := dbstrings.ToPascalCase(colName)
-			buf.WriteString(fmt.Sprintf("\t\t\tparams.Cursor.%s,\n", fieldName))
-		} else if paramName == "__limit" {
-			buf.WriteString("\t\t\tfetchLimit,\n")
-		} else {
-			buf.WriteString(fmt.Sprintf("\t\t\tparams.%s,\n", dbstrings.ToPascalCase(paramName)))
+	if cfg.PrepareStatements {
+		buf.WriteString("\tvar sqlName string\n")
+	}
+	buf.WriteString("\tvar args []any\n")
+	defaultVariant := paginatedVariant{
+		stmtName:         name,
+		sqlField:         sqlField,
+		cursorSQLField:   cursorSQLField,
+		paramOrder:       qi.ParamOrder,
+		cursorParamOrder: qi.CursorParamOrder,
+	}
+	if len(qi.Sorts) == 0 {
+		buf.WriteString("\n")
+		writePaginatedVariant(buf, qi, defaultVariant, cfg, "\t")
+		buf.WriteString("\n")
+	} else {
+		if len(qi.Filters) > 0 {
+			buf.WriteString("\tvar sortFilters queryFilters\n")
+		}
+		buf.WriteString("\tswitch params.Sort {\n")
+		buf.WriteString("\tcase \"\":\n")
+		writePaginatedVariant(buf, qi, defaultVariant, cfg, "\t\t")
+		if len(qi.Filters) > 0 {
+			fmt.Fprintf(buf, "\t\tsortFilters = r.%s\n", filtersField(qi))
+		}
+		for _, so := range qi.Sorts {
+			sortSQLField, sortCursorSQLField, sortFiltersField := sortFields(qi, so)
+			fmt.Fprintf(buf, "\tcase %q:\n", so.Name)
+			writePaginatedVariant(buf, qi, paginatedVariant{
+				stmtName:         sortPrefix(qi, so),
+				sqlField:         sortSQLField,
+				cursorSQLField:   sortCursorSQLField,
+				paramOrder:       so.ParamOrder,
+				cursorParamOrder: so.CursorParamOrder,
+			}, cfg, "\t\t")
+			if len(qi.Filters) > 0 {
+				fmt.Fprintf(buf, "\t\tsortFilters = r.%s\n", sortFiltersField)
+			}
 		}
+		buf.WriteString("\tdefault:\n")
+		fmt.Fprintf(buf, "\t\treturn nil, fmt.Errorf(\"%s: unknown sort %%q\", params.Sort)\n", name)
+		buf.WriteString("\t}\n\n")
 	}
-	buf.WriteString("\t\t}\n")
-
-	buf.WriteString("\t} else {\n")
-	buf.WriteString(fmt.Sprintf("\t\tsqlStr = r.%s\n", sqlField))
 
-	// Build base args: user params, then __limit
-	buf.WriteString("\t\targs = []any{\n")
-	for _, paramName := range qi.ParamOrder {
-		if paramName == "__limit" {
-			buf.WriteString("\t\t\tfetchLimit,\n")
-		} else {
-			buf.WriteString(fmt.Sprintf("\t\t\tparams.%s,\n", dbstrings.ToPascalCase(paramName)))
-		}
+	if len(qi.Filters) > 0 {
+		writeFilterApply(buf, qi, cfg)
 	}
-	buf.WriteString("\t\t}\n")
-	buf.WriteString("\t}\n\n")
 
 	// Execute query
-	buf.WriteString("\trows, err := r.db.QueryContext(ctx, sqlStr, args...)\n")
+	fmt.Fprintf(buf, "\trows, err := %s\n", dbCall(cfg, "QueryContext", "sqlName", "sqlStr"))
 	buf.WriteString("\tif err != nil {\n")
 	buf.WriteString("\t\treturn nil, err\n")
 	buf.WriteString("\t}\n")
@@ -1781,10 +2399,10 @@ func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 				continue
 			}
 		}
-		buf.WriteString(fmt.Sprintf("\t\t\t&item.%s,\n", r.Name))
+		buf.WriteString(fmt.Sprintf("\t\t\t%s,\n", scanTarget(r, "item")))
 	}
 	buf.WriteString("\t\t); err != nil {\n")
-	buf.WriteString("\t\t\treturn nil, err\n")
+	buf.WriteString("\t\t\t" + scanErrorReturn(qi, "nil, err") + "\n")
 	buf.WriteString("\t\t}\n")
 
 	// SQLite type conversions
@@ -1798,11 +2416,11 @@ func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 			switch r.GoType {
 			case "time.Time":
 				buf.WriteString(fmt.Sprintf("\t\tparsed%s, err := parseSQLiteTime(%s)\n", r.Name, tmp))
-				buf.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+				buf.WriteString("\t\tif err != nil {\n\t\t\t" + decodeErrorReturn(qi, r, "nil, err") + "\n\t\t}\n")
 				buf.WriteString(fmt.Sprintf("\t\titem.%s = parsed%s\n", r.Name, r.Name))
 			case "*time.Time":
 				buf.WriteString(fmt.Sprintf("\t\tparsed%s, err := parseSQLiteNullTime(%s)\n", r.Name, tmp))
-				buf.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+				buf.WriteString("\t\tif err != nil {\n\t\t\t" + decodeErrorReturn(qi, r, "nil, err") + "\n\t\t}\n")
 				buf.WriteString(fmt.Sprintf("\t\titem.%s = parsed%s\n", r.Name, r.Name))
 			case "json.RawMessage":
 				buf.WriteString(fmt.Sprintf("\t\tif %s.Valid {\n\t\t\titem.%s = []byte(%s.String)\n\t\t}\n", tmp, r.Name, tmp))
@@ -1812,6 +2430,7 @@ func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 		}
 	}
 
+	writeUTCResults(buf, qi.Results, "item", "\t\t")
 	buf.WriteString("\t\titems = append(items, item)\n")
 	buf.WriteString("\t}\n\n")
 
@@ -1825,24 +2444,24 @@ func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 	buf.WriteString("\t\t// We fetched one extra to detect next page\n")
 	buf.WriteString("\t\tresult.Items = items[:limit]\n")
 	buf.WriteString("\t\tlastItem := items[limit-1]\n")
-	buf.WriteString(fmt.Sprintf("\t\tresult.NextCursor = &%s{\n", cursorType))
-	for _, col := range qi.CursorColumns {
-		fieldName := dbstrings.ToPascalCase(col.Name)
-		if col.GoType == "time.Time" {
-			if isSQLite {
-				// SQLite stores timestamps via strftime('%Y-%m-%dT%H:%M:%fZ','now')
-				// which always produces exactly 3 fractional digits (e.g. ".000").
-				// time.RFC3339Nano trims trailing zeros, producing a different
-				// string that breaks lexicographic cursor comparisons in SQLite.
-				buf.WriteString(fmt.Sprintf("\t\t\t%s: lastItem.%s.UTC().Format(\"2006-01-02T15:04:05.000Z\"),\n", fieldName, fieldName))
-			} else {
-				buf.WriteString(fmt.Sprintf("\t\t\t%s: lastItem.%s.UTC().Format(time.RFC3339Nano),\n", fieldName, fieldName))
-			}
-		} else {
-			buf.WriteString(fmt.Sprintf("\t\t\t%s: fmt.Sprint(lastItem.%s),\n", fieldName, fieldName))
+	if len(qi.Sorts) == 0 {
+		buf.WriteString(fmt.Sprintf("\t\tresult.NextCursor = &%s{\n", cursorType))
+		for _, col := range qi.CursorColumns {
+			fieldName := dbstrings.ToPascalCase(col.Name)
+			fmt.Fprintf(buf, "\t\t\t%s: %s,\n", fieldName, cursorFieldValue(col, "lastItem."+fieldName, cfg))
+		}
+		buf.WriteString("\t\t}\n")
+	} else {
+		buf.WriteString(fmt.Sprintf("\t\tresult.NextCursor = &%s{Sort: params.Sort}\n", cursorType))
+		buf.WriteString("\t\tswitch params.Sort {\n")
+		buf.WriteString("\t\tcase \"\":\n")
+		writeNextCursorFields(buf, qi.CursorColumns, cfg)
+		for _, so := range qi.Sorts {
+			fmt.Fprintf(buf, "\t\tcase %q:\n", so.Name)
+			writeNextCursorFields(buf, so.CursorColumns, cfg)
 		}
+		buf.WriteString("\t\t}\n")
 	}
-	buf.WriteString("\t\t}\n")
 	buf.WriteString("\t} else {\n")
 	buf.WriteString("\t\tresult.Items = items\n")
 	buf.WriteString("\t}\n\n")
@@ -1851,6 +2470,89 @@ func writePaginatedMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunner
 	buf.WriteString("}\n\n")
 }
 
+// paginatedVariant is one order of a paginated query: the default order of
+// its cursor columns or one of its sorts.
+type paginatedVariant struct {
+	stmtName         string // name of the base statement when prepared
+	sqlField         string // QueryRunner field holding the base SQL
+	cursorSQLField   string // QueryRunner field holding the cursor SQL
+	paramOrder       []string
+	cursorParamOrder []string
+}
+
+// writePaginatedVariant writes the choice of SQL and args of a paginated
+// method for the order v, depending on whether params has a cursor.
+func writePaginatedVariant(buf *bytes.Buffer, qi userQueryInfo, v paginatedVariant, cfg UnifiedRunnerConfig, indent string) {
+	buf.WriteString(indent + "if params.Cursor != nil {\n")
+	fmt.Fprintf(buf, "%s\tsqlStr = r.%s\n", indent, v.cursorSQLField)
+	if cfg.PrepareStatements {
+		fmt.Fprintf(buf, "%s\tsqlName = %q\n", indent, v.stmtName+"Cursor")
+	}
+
+	// Build cursor args: user params first, then cursor params, then __limit
+	buf.WriteString(indent + "\targs = []any{\n")
+	for _, paramName := range v.cursorParamOrder {
+		if strings.HasPrefix(paramName, "__cursor_") {
+			colName := strings.TrimPrefix(paramName, "__cursor_")
+			fieldName := dbstrings.ToPascalCase(colName)
+			fmt.Fprintf(buf, "%s\t\tparams.Cursor.%s,\n", indent, fieldName)
+		} else if paramName == "__limit" {
+			buf.WriteString(indent + "\t\tfetchLimit,\n")
+		} else {
+			fmt.Fprintf(buf, "%s\t\t%s,\n", indent, argExpr(qi, "params", paramName))
+		}
+	}
+	buf.WriteString(indent + "\t}\n")
+
+	buf.WriteString(indent + "} else {\n")
+	fmt.Fprintf(buf, "%s\tsqlStr = r.%s\n", indent, v.sqlField)
+	if cfg.PrepareStatements {
+		fmt.Fprintf(buf, "%s\tsqlName = %q\n", indent, v.stmtName)
+	}
+
+	// Build base args: user params, then __limit
+	buf.WriteString(indent + "\targs = []any{\n")
+	for _, paramName := range v.paramOrder {
+		if paramName == "__limit" {
+			buf.WriteString(indent + "\t\tfetchLimit,\n")
+		} else {
+			fmt.Fprintf(buf, "%s\t\t%s,\n", indent, argExpr(qi, "params", paramName))
+		}
+	}
+	buf.WriteString(indent + "\t}\n")
+	buf.WriteString(indent + "}\n")
+}
+
+// writeNextCursorFields writes the assignments of the cursor fields of
+// cols from lastItem, for a query with sorts.
+func writeNextCursorFields(buf *bytes.Buffer, cols []query.SerializedColumn, cfg UnifiedRunnerConfig) {
+	for _, col := range cols {
+		fieldName := dbstrings.ToPascalCase(col.Name)
+		fmt.Fprintf(buf, "\t\t\tresult.NextCursor.%s = %s\n", fieldName, cursorFieldValue(col, "lastItem."+fieldName, cfg))
+	}
+}
+
+// cursorFieldValue returns the string a cursor stores for the value expr of
+// the cursor column col.
+func cursorFieldValue(col query.SerializedColumn, expr string, cfg UnifiedRunnerConfig) string {
+	if col.GoType != "time.Time" {
+		return fmt.Sprintf("fmt.Sprint(%s)", expr)
+	}
+	if col.UTC && cfg.Dialect == dburl.DialectMySQL {
+		// Compare against the UTC wall-clock time stored in DATETIME(6);
+		// a zone suffix would be converted to the session time zone.
+		return fmt.Sprintf("%s.UTC().Format(%q)", expr, mysqlUTCLayout)
+	}
+	if cfg.Dialect == dburl.DialectSQLite {
+		// SQLite stores timestamps via strftime('%Y-%m-%dT%H:%M:%fZ','now')
+		// which always produces exactly 3 fractional digits (e.g. ".000").
+		// time.RFC3339Nano trims trailing zeros, producing a different
+		// string that breaks lexicographic cursor comparisons in SQLite.
+		return fmt.Sprintf("%s.UTC().Format(\"2006-01-02T15:04:05.000Z\")", expr)
+	}
+	return fmt.Sprintf("%s.UTC().Format(time.RFC3339Nano)", expr)
+}
+
 // sqliteScanType returns the intermediate scan type for SQLite, or "" if direct scan is fine.
 func sqliteScanType(goType string) string {
 	switch goType {
@@ -1868,14 +2570,17 @@ func sqliteScanType(goType string) string {
 // writeBulkExecMethod generates the bulk insert method that builds SQL at runtime.
 func writeBulkExecMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerConfig) {
 	typesPackage := "queries"
-	paramType := fmt.Sprintf("%s.%sParams", typesPackage, qi.Name)
+	paramType := fmt.Sprintf("%s.%sParams", typesPackage, bulkParamsName(qi))
 	prefixField := dbstrings.ToLowerCamel(qi.Name) + "BulkPrefix"
 	suffixField := dbstrings.ToLowerCamel(qi.Name) + "BulkSuffix"
 	pprField := dbstrings.ToLowerCamel(qi.Name) + "BulkParamsPerRow"
+	rowField := dbstrings.ToLowerCamel(qi.Name) + "BulkRow"
 
 	buf.WriteString(fmt.Sprintf("// %s executes a multi-row INSERT.\n", qi.Name))
 	buf.WriteString(fmt.Sprintf("// Pass an empty slice for a no-op (returns driver.RowsAffected(0)).\n"))
-	buf.WriteString(fmt.Sprintf("func (r *QueryRunner) %s(ctx context.Context, params []%s) (sql.Result, error) {\n", qi.Name, paramType))
+	timeout := queryTimeout(qi, cfg)
+	fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context, params []%s) %s {\n", qi.Name, paramType, timeoutResults(timeout, "sql.Result"))
+	writeTimeoutStart(buf, qi.Name, timeout)
 	buf.WriteString("\tif len(params) == 0 {\n")
 	buf.WriteString("\t\treturn driver.RowsAffected(0), nil\n")
 	buf.WriteString("\t}\n\n")
@@ -1889,34 +2594,33 @@ func writeBulkExecMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerC
 	buf.WriteString("\t\t\tsb.WriteString(\", \")\n")
 	buf.WriteString("\t\t}\n")
 
-	isPostgres := cfg.Dialect == "postgres"
-
-	if isPostgres {
-		// Postgres needs $N placeholders renumbered per row
+	// The row tuple is stored split at its placeholders, so values that
+	// aren't params (NOW(), foreign-key subqueries) repeat in every row.
+	switch cfg.Dialect {
+	case dburl.DialectPostgres, dburl.DialectMSSQL:
+		// Postgres ($N) and SQL Server (@pN) need placeholders renumbered per row
+		placeholder := "$%d"
+		if cfg.Dialect == dburl.DialectMSSQL {
+			placeholder = "@p%d"
+		}
 		buf.WriteString(fmt.Sprintf("\t\tbase := i * r.%s\n", pprField))
-		buf.WriteString("\t\tsb.WriteString(\"(\")\n")
-		buf.WriteString(fmt.Sprintf("\t\tfor j := 0; j < r.%s; j++ {\n", pprField))
-		buf.WriteString("\t\t\tif j > 0 {\n")
-		buf.WriteString("\t\t\t\tsb.WriteString(\", \")\n")
-		buf.WriteString("\t\t\t}\n")
-		buf.WriteString("\t\t\tfmt.Fprintf(&sb, \"$%d\", base+j+1)\n")
+		buf.WriteString(fmt.Sprintf("\t\tsb.WriteString(r.%s[0])\n", rowField))
+		buf.WriteString(fmt.Sprintf("\t\tfor j, part := range r.%s[1:] {\n", rowField))
+		buf.WriteString(fmt.Sprintf("\t\t\tfmt.Fprintf(&sb, %q, base+j+1)\n", placeholder))
+		buf.WriteString("\t\t\tsb.WriteString(part)\n")
 		buf.WriteString("\t\t}\n")
-		buf.WriteString("\t\tsb.WriteString(\")\")\n")
-	} else {
-		// MySQL/SQLite: all placeholders are ?, so the row template is static
-		buf.WriteString("\t\tsb.WriteString(\"(\")\n")
-		buf.WriteString(fmt.Sprintf("\t\tfor j := 0; j < r.%s; j++ {\n", pprField))
-		buf.WriteString("\t\t\tif j > 0 {\n")
-		buf.WriteString("\t\t\t\tsb.WriteString(\", \")\n")
-		buf.WriteString("\t\t\t}\n")
+	default:
+		// MySQL/SQLite: all placeholders are ?
+		buf.WriteString(fmt.Sprintf("\t\tsb.WriteString(r.%s[0])\n", rowField))
+		buf.WriteString(fmt.Sprintf("\t\tfor _, part := range r.%s[1:] {\n", rowField))
 		buf.WriteString("\t\t\tsb.WriteString(\"?\")\n")
+		buf.WriteString("\t\t\tsb.WriteString(part)\n")
 		buf.WriteString("\t\t}\n")
-		buf.WriteString("\t\tsb.WriteString(\")\")\n")
 	}
 
 	// Append args from the params struct in the template order
 	for _, paramName := range qi.BulkParamNames {
-		buf.WriteString(fmt.Sprintf("\t\targs = append(args, p.%s)\n", dbstrings.ToPascalCase(paramName)))
+		buf.WriteString(fmt.Sprintf("\t\targs = append(args, %s)\n", argExpr(qi, "p", paramName)))
 	}
 
 	buf.WriteString("\t}\n")
@@ -1925,11 +2629,18 @@ func writeBulkExecMethod(buf *bytes.Buffer, qi userQueryInfo, cfg UnifiedRunnerC
 	buf.WriteString("}\n\n")
 }
 
-func writeUserQueryTypes(buf *bytes.Buffer, qi userQueryInfo) {
+func writeUserQueryTypes(buf *bytes.Buffer, qi userQueryInfo, preloads []preloadInfo) {
 	if qi.ReturnType == query.ReturnPaginated {
-		writePaginatedTypes(buf, qi)
+		writePaginatedTypes(buf, qi, preloads)
 		return
 	}
+	if qi.ReturnType == query.ReturnMaterializedView {
+		return // Refresh<View> takes no params and returns only an error
+	}
+
+	if qi.BulkRowParams != "" {
+		return // rows are passed as []<BulkRowParams>Params
+	}
 
 	// Write params struct
 	buf.WriteString(fmt.Sprintf("// %sParams are the parameters for the %s query.\n", qi.Name, qi.Name))
@@ -1938,7 +2649,11 @@ func writeUserQueryTypes(buf *bytes.Buffer, qi userQueryInfo) {
 	}
 	buf.WriteString(fmt.Sprintf("type %sParams struct {\n", qi.Name))
 	for _, p := range qi.Params {
-		buf.WriteString(fmt.Sprintf("\t%s %s\n", dbstrings.ToPascalCase(p.Name), p.GoType))
+		goType := p.GoType
+		if p.Optional {
+			goType = "*" + goType // nil leaves the column unchanged
+		}
+		buf.WriteString(fmt.Sprintf("\t%s %s\n", dbstrings.ToPascalCase(p.Name), goType))
 	}
 	buf.WriteString("}\n\n")
 
@@ -1949,6 +2664,7 @@ func writeUserQueryTypes(buf *bytes.Buffer, qi userQueryInfo) {
 		for _, r := range qi.Results {
 			buf.WriteString(fmt.Sprintf("\t%s %s\n", r.Name, r.GoType))
 		}
+		writePreloadFields(buf, preloadFields(preloads, qi.Name+"Result"))
 		buf.WriteString("}\n\n")
 
 		// Write nested struct types for json_agg fields (recurse for nested aggs)
@@ -1962,18 +2678,24 @@ func writeUserQueryTypes(buf *bytes.Buffer, qi userQueryInfo) {
 }
 
 // writePaginatedTypes generates cursor, params, item, and result types for a paginated query.
-func writePaginatedTypes(buf *bytes.Buffer, qi userQueryInfo) {
+func writePaginatedTypes(buf *bytes.Buffer, qi userQueryInfo, preloads []preloadInfo) {
 	name := qi.Name // e.g. "ListPosts"
 
 	// Cursor type
 	cursorType := name + "Cursor"
 	buf.WriteString(fmt.Sprintf("// %s holds pagination state for %s.\n", cursorType, name))
 	buf.WriteString(fmt.Sprintf("type %s struct {\n", cursorType))
-	for _, col := range qi.CursorColumns {
+	for _, col := range cursorFields(qi) {
 		fieldName := dbstrings.ToPascalCase(col.Name)
 		// Cursor fields are always stored as strings for JSON serialization
 		buf.WriteString(fmt.Sprintf("\t%s string `json:%q`\n", fieldName, col.Name))
 	}
+	if len(qi.Sorts) > 0 {
+		buf.WriteString("\tSort string `json:\"_sort,omitempty\"` // sort the cursor was issued for\n")
+	}
+	// PageDepth is set by generated list handlers for pagination metrics;
+	// cursors issued before it existed decode with PageDepth 0.
+	buf.WriteString("\tPageDepth int `json:\"_page,omitempty\"` // page the cursor was issued on\n")
 	buf.WriteString("}\n\n")
 
 	// Encode cursor function
@@ -2012,6 +2734,19 @@ func writePaginatedTypes(buf *bytes.Buffer, qi userQueryInfo) {
 	for _, p := range qi.Params {
 		buf.WriteString(fmt.Sprintf("\t%s %s\n", dbstrings.ToPascalCase(p.Name), p.GoType))
 	}
+	if len(qi.Filters) > 0 {
+		buf.WriteString("\t// Filters; nil fields are not filtered on\n")
+		for _, f := range qi.Filters {
+			buf.WriteString(fmt.Sprintf("\t%s %s\n", dbstrings.ToPascalCase(f.Param), f.GoType))
+		}
+	}
+	if len(qi.Sorts) > 0 {
+		names := make([]string, len(qi.Sorts))
+		for i, so := range qi.Sorts {
+			names[i] = strconv.Quote(so.Name)
+		}
+		fmt.Fprintf(buf, "\tSort string // \"\" for the default order, or %s\n", strings.Join(names, ", "))
+	}
 	buf.WriteString("\tLimit  int\n")
 	buf.WriteString(fmt.Sprintf("\tCursor *%s\n", cursorType))
 	buf.WriteString("}\n\n")
@@ -2023,6 +2758,7 @@ func writePaginatedTypes(buf *bytes.Buffer, qi userQueryInfo) {
 	for _, r := range qi.Results {
 		buf.WriteString(fmt.Sprintf("\t%s %s\n", r.Name, r.GoType))
 	}
+	writePreloadFields(buf, preloadFields(preloads, itemType))
 	buf.WriteString("}\n\n")
 
 	// Result type (wrapper with Items + NextCursor)
@@ -2077,10 +2813,17 @@ func jsonAggNeedsNullStrip(dialect string, queries []userQueryInfo) bool {
 	return false
 }
 
-// jsonAggNeedsBoolFix returns true if the dialect is MySQL or SQLite and any
-// query has json_agg columns with bool fields.
+// jsonAggBoolsAreNumeric reports whether the dialect's JSON functions write
+// bool columns as 0/1: MySQL and SQLite store them as integers, SQL Server
+// as BIT.
+func jsonAggBoolsAreNumeric(dialect string) bool {
+	return dialect == dburl.DialectMySQL || dialect == dburl.DialectSQLite || dialect == dburl.DialectMSSQL
+}
+
+// jsonAggNeedsBoolFix returns true if the dialect writes numeric bools and
+// any query has json_agg columns with bool fields.
 func jsonAggNeedsBoolFix(dialect string, queries []userQueryInfo) bool {
-	if dialect != dburl.DialectMySQL && dialect != dburl.DialectSQLite {
+	if !jsonAggBoolsAreNumeric(dialect) {
 		return false
 	}
 	for _, qi := range queries {
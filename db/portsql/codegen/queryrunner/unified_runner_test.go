@@ -1,13 +1,18 @@
 package queryrunner
 
 import (
+	"fmt"
+	"go/ast"
 	"go/format"
 	"go/parser"
 	"go/token"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/db/portsql/migrate"
 	"github.com/shipq/shipq/db/portsql/query"
@@ -321,6 +326,32 @@ func TestGenerateSharedTypes_RunnerInterfaceHasBeginTx(t *testing.T) {
 	}
 }
 
+func TestGenerateSharedTypes_InTx(t *testing.T) {
+	cfg := UnifiedRunnerConfig{
+		ModulePath: "example.com/myapp",
+		Dialect:    dburl.DialectPostgres,
+	}
+
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	f := gofile.Parse(t, "types.go", types)
+	f.AssertSignature("InTx", "func InTx(runner Runner) bool")
+	f.AssertStmts("InTx",
+		"if _, ok := runner.(*TxRunner); ok",
+		"tx, ok := runner.(interface{ InTx() bool })",
+		"return ok && tx.InTx()",
+	)
+
+	runner, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	r := gofile.Parse(t, "runner.go", runner)
+	r.AssertStmts("QueryRunner.InTx", "_, ok := r.db.(*sql.DB)", "return !ok")
+}
+
 func TestGenerateUnifiedRunner_HasBeginTxMethod(t *testing.T) {
 	dialects := []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite}
 
@@ -1449,7 +1480,7 @@ func TestGenerateSharedTypes_WithJSONAgg(t *testing.T) {
 // TestGenerateUnifiedRunner_WithJSONAgg_ScanCodegen verifies the generated runner
 // scans json_agg into a temp string and unmarshals into the typed slice.
 func TestGenerateUnifiedRunner_WithJSONAgg_ScanCodegen(t *testing.T) {
-	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite} {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
 		t.Run(dialect, func(t *testing.T) {
 			sq := makeJSONAggQuery("FindAccountByInternalID", []query.SerializedColumn{
 				{Table: "roles", Name: "name", GoType: "string"},
@@ -1542,7 +1573,7 @@ func TestGenerateUnifiedRunner_WithJSONAgg_BoolFix(t *testing.T) {
 		{Table: "forum_signups", Name: "is_active", GoType: "*bool"},
 	})
 
-	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite} {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
 		t.Run(dialect, func(t *testing.T) {
 			cfg := UnifiedRunnerConfig{
 				ModulePath:  "example.com/myapp",
@@ -1557,7 +1588,7 @@ func TestGenerateUnifiedRunner_WithJSONAgg_BoolFix(t *testing.T) {
 
 			codeStr := string(code)
 
-			wantFix := dialect == dburl.DialectMySQL || dialect == dburl.DialectSQLite
+			wantFix := dialect != dburl.DialectPostgres
 
 			if wantFix {
 				// Should contain the fixJSONBoolFields helper function
@@ -2478,6 +2509,55 @@ func TestGenerateUnifiedRunner_BulkInsert_SQLite(t *testing.T) {
 	}
 }
 
+func TestGenerateUnifiedRunner_BulkInsert_MSSQL(t *testing.T) {
+	q := makeBulkInsertQuery("BulkInsertAuthors")
+	code, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectMSSQL,
+		UserQueries: []query.SerializedQuery{q},
+	})
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	f := gofile.Parse(t, "runner.go", code)
+	f.AssertStmts("QueryRunner.BulkInsertAuthors",
+		"base := i * r.bulkInsertAuthorsBulkParamsPerRow",
+		`fmt.Fprintf(&sb, "@p%d", base+j+1)`,
+	)
+	if f.HasStmt("QueryRunner.BulkInsertAuthors", `sb.WriteString("?")`) {
+		t.Error("SQL Server should NOT use ? placeholders")
+	}
+
+	// Render two rows the way the generated method does.
+	compiler, err := getCompiler(dburl.DialectMSSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := compileUserQueries([]query.SerializedQuery{q}, compiler)
+	if err != nil {
+		t.Fatalf("compileUserQueries() error = %v", err)
+	}
+	qi := infos[0]
+	var sb strings.Builder
+	sb.WriteString(qi.BulkPrefix)
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		base := i * qi.BulkParamsPerRow
+		sb.WriteString(qi.BulkRow[0])
+		for j, part := range qi.BulkRow[1:] {
+			fmt.Fprintf(&sb, "@p%d", base+j+1)
+			sb.WriteString(part)
+		}
+	}
+	sb.WriteString(qi.BulkSuffix)
+	want := "INSERT INTO [authors] ([name], [email]) VALUES (@p1, @p2), (@p3, @p4)"
+	if got := sb.String(); got != want {
+		t.Errorf("bulk SQL = %q, want %q", got, want)
+	}
+}
+
 func TestGenerateSharedTypes_BulkInsert(t *testing.T) {
 	cfg := UnifiedRunnerConfig{
 		ModulePath:  "example.com/myapp",
@@ -2620,6 +2700,91 @@ func TestGenerateUnifiedRunner_BulkInsert_MixedWithRegularQueries(t *testing.T)
 	}
 }
 
+func TestGenerateUnifiedRunner_BulkInsert_NonParamValues(t *testing.T) {
+	// Values that aren't params (here NOW()) must repeat in every row.
+	q := makeBulkInsertQuery("BulkInsertAuthors")
+	q.AST.InsertCols = append(q.AST.InsertCols, query.SerializedColumn{Table: "authors", Name: "created_at", GoType: "time.Time"})
+	q.AST.InsertRows[0] = append(q.AST.InsertRows[0], query.SerializedExpr{Type: "func", Func: &query.SerializedFunc{Name: "NOW"}})
+
+	for _, tc := range []struct {
+		dialect string
+		want    string
+	}{
+		{dburl.DialectPostgres, `[]string{"(", ", ", ", NOW())"}`},
+		{dburl.DialectMySQL, `[]string{"(", ", ", ", NOW(3))"}`},
+	} {
+		t.Run(tc.dialect, func(t *testing.T) {
+			code, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+				ModulePath:  "example.com/myapp",
+				Dialect:     tc.dialect,
+				UserQueries: []query.SerializedQuery{q},
+			})
+			if err != nil {
+				t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+			}
+			if !strings.Contains(string(code), tc.want) {
+				t.Errorf("expected row template %s in generated code", tc.want)
+			}
+			if !regexp.MustCompile(`bulkInsertAuthorsBulkParamsPerRow: +2,`).Match(code) {
+				t.Error("expected 2 params per row")
+			}
+		})
+	}
+}
+
+func TestGenerateUnifiedRunner_BulkInsert_RowParams(t *testing.T) {
+	single := makeBulkInsertQuery("CreateAuthor")
+	single.ReturnType = query.ReturnExec
+	batch := makeBulkInsertQuery("CreateAuthorsBatch")
+	batch.RowParams = "CreateAuthor"
+	cfg := UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{single, batch},
+	}
+
+	code, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	if !strings.Contains(string(code), "func (r *QueryRunner) CreateAuthorsBatch(ctx context.Context, params []queries.CreateAuthorParams) (sql.Result, error)") {
+		t.Error("expected CreateAuthorsBatch to take []queries.CreateAuthorParams")
+	}
+
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	if strings.Contains(string(types), "CreateAuthorsBatchParams") {
+		t.Error("RowParams bulk query should not declare its own params type")
+	}
+	if !strings.Contains(string(types), "CreateAuthorsBatch(ctx context.Context, params []CreateAuthorParams) (sql.Result, error)") {
+		t.Error("expected CreateAuthorsBatch in the Runner interface")
+	}
+}
+
+func TestGenerateUnifiedRunner_BulkInsert_RowParamsMismatch(t *testing.T) {
+	single := makeBulkInsertQuery("CreateAuthor")
+	single.ReturnType = query.ReturnExec
+	single.AST.Params[1].GoType = "*string"
+	single.AST.InsertRows[0][1].Param.GoType = "*string"
+	batch := makeBulkInsertQuery("CreateAuthorsBatch")
+	batch.RowParams = "CreateAuthor"
+	missing := makeBulkInsertQuery("OtherBatch")
+	missing.RowParams = "NoSuchQuery"
+
+	for _, queries := range [][]query.SerializedQuery{{single, batch}, {missing}} {
+		_, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+			ModulePath:  "example.com/myapp",
+			Dialect:     dburl.DialectPostgres,
+			UserQueries: queries,
+		})
+		if err == nil || !strings.Contains(err.Error(), "RowParams") && !strings.Contains(err.Error(), "same type") {
+			t.Errorf("expected RowParams error for %s, got %v", queries[len(queries)-1].Name, err)
+		}
+	}
+}
+
 func TestGenerateUnifiedRunner_Postgres_NonNullableJSONColumn_RemainsRawMessage(t *testing.T) {
 	// Build a query with an explicitly non-nullable JSON column
 	// (GoType "json.RawMessage", no pointer).
@@ -3344,7 +3509,7 @@ func TestGenerateUnifiedRunner_JSONAgg_TimeColumn_MySQL_ValidGo(t *testing.T) {
 // the generated code is valid Go for all three dialects when json_agg includes
 // time columns.
 func TestGenerateUnifiedRunner_JSONAgg_TimeColumn_AllDialects_FormatsOK(t *testing.T) {
-	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite} {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
 		t.Run(dialect, func(t *testing.T) {
 			sq := makeJSONAggQuery("GetDraftWithEntries", []query.SerializedColumn{
 				{Table: "entries", Name: "title", GoType: "string"},
@@ -3369,3 +3534,446 @@ func TestGenerateUnifiedRunner_JSONAgg_TimeColumn_AllDialects_FormatsOK(t *testi
 		})
 	}
 }
+
+func TestGenerateUnifiedRunner_RowLimit(t *testing.T) {
+	listOrders := query.SerializedQuery{
+		Name:       "ListOrders",
+		ReturnType: query.ReturnMany,
+		AST:        query.SerializeAST(query.From(viewTestTable{}).Select(ordersCustomer).Build()),
+	}
+
+	cfg := UnifiedRunnerConfig{
+		ModulePath:  "myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{listOrders},
+		MaxRows:     500,
+	}
+	runner, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+	}
+	gofile.Parse(t, "runner.go", runner).AssertStmts("QueryRunner.ListOrders",
+		`return nil, &queries.RowLimitError{Query: "ListOrders", Limit: queries.MaxRows}`)
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes() error = %v", err)
+	}
+	tf := gofile.Parse(t, "types.go", types)
+	if got := tf.Value("MaxRows"); got != "500" {
+		t.Errorf("MaxRows = %q, want 500", got)
+	}
+	tf.AssertSignature("RowLimitError.Error", "func (e *RowLimitError) Error() string")
+
+	cfg.MaxRows = 0
+	runner, _ = GenerateUnifiedRunner(cfg)
+	types, _ = GenerateSharedTypes(cfg)
+	if gofile.Parse(t, "runner.go", runner).HasExpr("QueryRunner.ListOrders", "queries.MaxRows") || gofile.Parse(t, "types.go", types).HasType("RowLimitError") {
+		t.Error("MaxRows = 0 should disable the row limit")
+	}
+}
+
+func TestGenerateUnifiedRunner_ScanErrors(t *testing.T) {
+	createdAt := query.TimeColumn{Table: "orders", Name: "created_at"}
+	getEvent := makeNullableJSONSelectQuery()
+	listOrders := query.SerializedQuery{
+		Name:       "ListOrders",
+		ReturnType: query.ReturnMany,
+		AST:        query.SerializeAST(query.From(viewTestTable{}).Select(ordersCustomer, createdAt).Build()),
+	}
+
+	cfg := UnifiedRunnerConfig{
+		ModulePath:  "myapp",
+		Dialect:     dburl.DialectSQLite,
+		UserQueries: []query.SerializedQuery{getEvent, listOrders},
+	}
+	runner, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+	}
+	f := gofile.Parse(t, "runner.go", runner)
+	f.AssertSignature("scanError", "func scanError(query string, columns []scanColumn, err error) error")
+	want := `[]scanColumn{
+	{"id", "int64"},
+	{"name", "string"},
+	{"metadata", "*json.RawMessage"},
+}`
+	if got := f.Value("getEventByIDScanColumns"); got != want {
+		t.Errorf("getEventByIDScanColumns = %s, want %s", got, want)
+	}
+	f.AssertStmts("QueryRunner.GetEventByID", `return nil, scanError("GetEventByID", getEventByIDScanColumns, err)`)
+	f.AssertStmts("QueryRunner.ListOrders",
+		`return nil, scanError("ListOrders", listOrdersScanColumns, err)`,
+		// SQLite text timestamps are parsed after the scan.
+		`return nil, &queries.ScanError{Query: "ListOrders", Column: "created_at", GoType: "time.Time", Err: err}`,
+	)
+	f.AssertStmts("QueryRunner.EachListOrders", `return scanError("ListOrders", listOrdersScanColumns, err)`)
+
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes() error = %v", err)
+	}
+	gofile.Parse(t, "types.go", types).AssertStmts("ScanError.Unwrap", "return e.Err")
+
+	// Exec-only runners scan nothing.
+	cfg.UserQueries = []query.SerializedQuery{makeBulkInsertQuery("BulkInsertAuthors")}
+	runner, _ = GenerateUnifiedRunner(cfg)
+	types, _ = GenerateSharedTypes(cfg)
+	if gofile.Parse(t, "runner.go", runner).HasFunc("scanError") || gofile.Parse(t, "types.go", types).HasType("ScanError") {
+		t.Error("runners without result rows should not declare scan errors")
+	}
+}
+
+func TestGenerateUnifiedRunner_Timestamptz(t *testing.T) {
+	occurredAt := query.TimestamptzColumn{Table: "orders", Name: "occurred_at"}
+	createdAt := query.TimeColumn{Table: "orders", Name: "created_at"}
+	since := query.Param[time.Time]("since")
+
+	listOrders := query.SerializedQuery{
+		Name:       "ListOrdersSince",
+		ReturnType: query.ReturnMany,
+		AST: query.SerializeAST(query.From(viewTestTable{}).
+			Select(occurredAt, createdAt).
+			Where(query.And(occurredAt.Gt(since), createdAt.Gt(query.Param[time.Time]("created")))).
+			Build()),
+	}
+	insertOrder := query.SerializedQuery{
+		Name:       "InsertOrder",
+		ReturnType: query.ReturnExec,
+		AST: query.SerializeAST(query.InsertInto(viewTestTable{}).
+			Columns(occurredAt).
+			Values(query.Param[time.Time]("occurredAt")).
+			Build()),
+	}
+
+	tests := []struct {
+		dialect string
+		helper  string
+	}{
+		{dburl.DialectPostgres, "return t.UTC()"},
+		{dburl.DialectMySQL, `return t.UTC().Format("2006-01-02 15:04:05.999999")`},
+		{dburl.DialectSQLite, `return t.UTC().Format("2006-01-02T15:04:05.000Z")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			runner, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+				ModulePath:  "myapp",
+				Dialect:     tt.dialect,
+				UserQueries: []query.SerializedQuery{listOrders, insertOrder},
+			})
+			if err != nil {
+				t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+			}
+			f := gofile.Parse(t, "runner.go", runner)
+			f.AssertStmts("utcArg", tt.helper)
+			f.AssertExprs("QueryRunner.ListOrdersSince", "utcArg(params.Since)")
+			f.AssertStmts("QueryRunner.ListOrdersSince", "item.OccurredAt = utcTime(item.OccurredAt)")
+			f.AssertExprs("QueryRunner.InsertOrder", "utcArg(params.OccurredAt)")
+			if f.HasExpr("QueryRunner.ListOrdersSince", "utcArg(params.Created)") || f.HasExpr("QueryRunner.ListOrdersSince", "utcTime(item.CreatedAt)") {
+				t.Error("datetime columns should not be converted to UTC")
+			}
+		})
+	}
+}
+
+// TestGenerateUnifiedRunner_EachMethod verifies that every ReturnMany query
+// also gets a streaming Each<Name> method that hands rows to a callback,
+// stops once its context is cancelled, is exempt from the row limit and is
+// part of the Runner interface.
+func TestGenerateUnifiedRunner_EachMethod(t *testing.T) {
+	sq := makeJSONAggQuery("ListAccountsWithRoles", []query.SerializedColumn{
+		{Table: "roles", Name: "name", GoType: "string"},
+	})
+	sq.ReturnType = query.ReturnMany
+
+	cfg := UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{sq},
+		MaxRows:     100,
+	}
+
+	code, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	f := gofile.Parse(t, "runner.go", code)
+	const each = "QueryRunner.EachListAccountsWithRoles"
+	f.AssertSignature(each, "func (r *QueryRunner) EachListAccountsWithRoles(ctx context.Context, params queries.ListAccountsWithRolesParams, fn func(queries.ListAccountsWithRolesResult) error) error")
+	f.AssertStmts(each,
+		"for rows.Next()",
+		"if err := ctx.Err(); err != nil",
+		"if err := fn(item); err != nil",
+		"return rows.Err()",
+	)
+	if !f.Before(each, "if err := ctx.Err(); err != nil", "if err := fn(item); err != nil") {
+		t.Error("each row should check the context before calling fn")
+	}
+	if !hasScanError(f, each, "ListAccountsWithRoles", "roles") {
+		t.Error("a bad roles aggregate should return a ScanError")
+	}
+	if f.HasExpr(each, "queries.MaxRows") {
+		t.Error("Each methods stream rows and should not enforce MaxRows")
+	}
+	if f.HasExpr(each, "results") {
+		t.Error("Each methods should not collect rows into a slice")
+	}
+
+	shared, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	if !strings.Contains(gofile.Parse(t, "types.go", shared).Type("Runner"), "EachListAccountsWithRoles(ctx context.Context, params ListAccountsWithRolesParams, fn func(ListAccountsWithRolesResult) error) error") {
+		t.Error("expected EachListAccountsWithRoles on the Runner interface")
+	}
+}
+
+// hasScanError reports whether fn, or any function when fn is empty,
+// returns a *queries.ScanError for the column of query.
+func hasScanError(f *gofile.File, fn, query, column string) bool {
+	var root ast.Node = f.AST
+	if fn != "" {
+		root = f.Func(fn)
+	}
+	found := false
+	ast.Inspect(root, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || found {
+			return !found
+		}
+		if sel, ok := lit.Type.(*ast.SelectorExpr); !ok || sel.Sel.Name != "ScanError" {
+			return true
+		}
+		fields := map[string]string{}
+		for _, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if v, ok := kv.Value.(*ast.BasicLit); ok {
+					fields[kv.Key.(*ast.Ident).Name], _ = strconv.Unquote(v.Value)
+				}
+			}
+		}
+		found = fields["Query"] == query && fields["Column"] == column
+		return !found
+	})
+	return found
+}
+
+// TestGenerateUnifiedRunner_IterMethod verifies that a ReturnMany query
+// registered with query.Iter() gets a <Name>Iter method returning an
+// iter.Seq2 that yields scan errors and closes its rows, and that other
+// queries do not.
+func TestGenerateUnifiedRunner_IterMethod(t *testing.T) {
+	sq := makeJSONAggQuery("ListAccountsWithRoles", []query.SerializedColumn{
+		{Table: "roles", Name: "name", GoType: "string"},
+	})
+	sq.ReturnType = query.ReturnMany
+	sq.Iter = true
+	plain := makeJSONAggQuery("ListAccounts", []query.SerializedColumn{
+		{Table: "roles", Name: "name", GoType: "string"},
+	})
+	plain.ReturnType = query.ReturnMany
+
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
+		t.Run(dialect, func(t *testing.T) {
+			cfg := UnifiedRunnerConfig{
+				ModulePath:  "example.com/myapp",
+				Dialect:     dialect,
+				UserQueries: []query.SerializedQuery{sq, plain},
+				MaxRows:     100,
+			}
+
+			code, err := GenerateUnifiedRunner(cfg)
+			if err != nil {
+				t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+			}
+			f := gofile.Parse(t, "runner.go", code)
+			const it = "QueryRunner.ListAccountsWithRolesIter"
+			f.AssertSignature(it, "func (r *QueryRunner) ListAccountsWithRolesIter(ctx context.Context, params queries.ListAccountsWithRolesParams) (iter.Seq2[queries.ListAccountsWithRolesResult, error], error)")
+			// The query runs before the sequence is returned; the sequence
+			// closes the rows and stops at the first scan error.
+			f.AssertStmts(it,
+				"return nil, err",
+				"defer rows.Close()",
+				"yield(queries.ListAccountsWithRolesResult{}, err)",
+				"if !yield(item, err) || err != nil",
+				"if err := rows.Err(); err != nil",
+			)
+			if !f.Before(it, "return nil, err", "defer rows.Close()") {
+				t.Error("the query should run before the sequence is returned")
+			}
+			if !hasScanError(f, "", "ListAccountsWithRoles", "roles") {
+				t.Error("a bad roles aggregate should yield a ScanError")
+			}
+			if f.HasExpr(it, "queries.MaxRows") {
+				t.Error("Iter methods stream rows and should not enforce MaxRows")
+			}
+			if f.HasFunc("QueryRunner.ListAccountsIter") {
+				t.Error("queries registered without Iter() should not get an Iter method")
+			}
+
+			shared, err := GenerateSharedTypes(cfg)
+			if err != nil {
+				t.Fatalf("GenerateSharedTypes failed: %v", err)
+			}
+			tf := gofile.Parse(t, "types.go", shared)
+			if !strings.Contains(tf.Type("Runner"), "ListAccountsWithRolesIter(ctx context.Context, params ListAccountsWithRolesParams) (iter.Seq2[ListAccountsWithRolesResult, error], error)") {
+				t.Error("expected ListAccountsWithRolesIter on the Runner interface")
+			}
+		})
+	}
+}
+
+func TestGenerateUnifiedRunner_CustomType(t *testing.T) {
+	cents := ddl.CustomType{
+		Name:     "cents",
+		Postgres: "BIGINT",
+		MySQL:    "BIGINT",
+		SQLite:   "INTEGER",
+		GoType:   "money.Cents",
+		GoImport: "example.com/money",
+		Scan:     "n, ok := src.(int64)\nif !ok {\nreturn 0, fmt.Errorf(\"cents: unexpected %T\", src)\n}\nreturn money.Cents(n), nil",
+		Value:    "return int64(v), nil",
+	}
+	schema := map[string]ddl.Table{
+		"orders": {Name: "orders", Columns: []ddl.ColumnDefinition{
+			{Name: "total", Type: "cents", Custom: &cents},
+			{Name: "refund", Type: "cents", Nullable: true, Custom: &cents},
+		}},
+	}
+	total := query.CustomColumn{Table: "orders", Name: "total", Type: "cents", Go: "money.Cents"}
+	refund := query.NullCustomColumn{Table: "orders", Name: "refund", Type: "cents", Go: "money.Cents"}
+
+	listOrders := query.SerializedQuery{
+		Name:       "ListOrdersOver",
+		ReturnType: query.ReturnMany,
+		AST: query.SerializeAST(query.From(viewTestTable{}).
+			Select(total, refund).
+			Where(total.Gt(query.ParamExpr{Name: "min", GoType: "money.Cents"})).
+			Build()),
+	}
+
+	runner, err := GenerateUnifiedRunner(UnifiedRunnerConfig{
+		ModulePath:  "myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{listOrders},
+		Schema:      schema,
+	})
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner() error = %v", err)
+	}
+	// The type's Scan and Value bodies become the helpers' bodies.
+	f := gofile.Parse(t, "runner.go", runner)
+	f.AssertSignature("customCentsScan", "func customCentsScan(src any) (money.Cents, error)")
+	f.AssertStmts("customCentsScan", "return money.Cents(n), nil")
+	f.AssertStmts("customCentsValue", "return int64(v), nil")
+	f.AssertExprs("QueryRunner.ListOrdersOver",
+		"customCentsArg(params.Min)",
+		"customCentsScanner{&item.Total}",
+		"customCentsNullScanner{&item.Refund}",
+	)
+
+	types, err := GenerateSharedTypes(UnifiedRunnerConfig{
+		ModulePath:  "myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{listOrders},
+		Schema:      schema,
+	})
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes() error = %v", err)
+	}
+	if typ, _, _ := gofile.Parse(t, "types.go", types).Field("ListOrdersOverResult", "Refund"); typ != "*money.Cents" {
+		t.Errorf("ListOrdersOverResult.Refund is %q, want *money.Cents", typ)
+	}
+
+	_, err = GenerateUnifiedRunner(UnifiedRunnerConfig{
+		ModulePath:  "myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{listOrders},
+	})
+	if err == nil || !strings.Contains(err.Error(), `custom type "cents" is not defined`) {
+		t.Errorf("expected undefined custom type error, got %v", err)
+	}
+}
+
+// TestGenerateUnifiedRunner_AppliedMethod verifies that a ReturnApplied
+// query becomes a method reporting whether the statement changed a row.
+func TestGenerateUnifiedRunner_AppliedMethod(t *testing.T) {
+	sq := query.SerializedQuery{
+		Name:       "UpdateOrderCustomerIf",
+		ReturnType: query.ReturnApplied,
+		AST: query.SerializeAST(query.Update(viewTestTable{}).
+			Set(ordersCustomer, query.Param[string]("to")).
+			Where(ordersCustomer.Eq(query.Param[string]("from"))).
+			Build()),
+	}
+	cfg := UnifiedRunnerConfig{
+		ModulePath:  "example.com/myapp",
+		Dialect:     dburl.DialectPostgres,
+		UserQueries: []query.SerializedQuery{sq},
+	}
+
+	code, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	f := gofile.Parse(t, "runner.go", code)
+	f.AssertSignature("QueryRunner.UpdateOrderCustomerIf", "func (r *QueryRunner) UpdateOrderCustomerIf(ctx context.Context, params queries.UpdateOrderCustomerIfParams) (bool, error)")
+	f.AssertStmts("QueryRunner.UpdateOrderCustomerIf",
+		"n, err := res.RowsAffected()",
+		"return n > 0, nil",
+	)
+
+	types, err := GenerateSharedTypes(cfg)
+	if err != nil {
+		t.Fatalf("GenerateSharedTypes failed: %v", err)
+	}
+	if want := "UpdateOrderCustomerIf(ctx context.Context, params UpdateOrderCustomerIfParams) (bool, error)"; !strings.Contains(gofile.Parse(t, "types.go", types).Type("Runner"), want) {
+		t.Errorf("Runner interface missing %q:\n%s", want, types)
+	}
+}
+
+func TestGenerateUnifiedRunner_PrepareStatements(t *testing.T) {
+	listOrders := query.SerializedQuery{
+		Name:       "ListOrders",
+		ReturnType: query.ReturnMany,
+		AST:        query.SerializeAST(query.From(viewTestTable{}).Select(ordersCustomer).Build()),
+	}
+	updateIf := query.SerializedQuery{
+		Name:       "UpdateOrderCustomerIf",
+		ReturnType: query.ReturnApplied,
+		AST: query.SerializeAST(query.Update(viewTestTable{}).
+			Set(ordersCustomer, query.Param[string]("to")).
+			Where(ordersCustomer.Eq(query.Param[string]("from"))).
+			Build()),
+	}
+	cfg := UnifiedRunnerConfig{
+		ModulePath:        "example.com/myapp",
+		Dialect:           dburl.DialectPostgres,
+		UserQueries:       []query.SerializedQuery{listOrders, updateIf},
+		PrepareStatements: true,
+	}
+
+	code, err := GenerateUnifiedRunner(cfg)
+	if err != nil {
+		t.Fatalf("GenerateUnifiedRunner failed: %v", err)
+	}
+	f := gofile.Parse(t, "runner.go", code)
+	if typ, _, _ := f.Field("QueryRunner", "stmts"); typ != "*stmtCache" {
+		t.Errorf("QueryRunner.stmts is %q, want *stmtCache", typ)
+	}
+	// Transactions and WithDB runners share the cache.
+	f.AssertExprs("NewQueryRunner", "stmts: newStmtCache(db)")
+	f.AssertExprs("QueryRunner.WithTx", "stmts: r.stmts")
+	f.AssertExprs("QueryRunner.WithDB", "stmts: r.stmts.forDB(db)")
+	f.AssertSignature("QueryRunner.Close", "func (r *QueryRunner) Close() error")
+	f.AssertStmts("QueryRunner.ListOrders", `rows, err := r.queryPrepared(ctx, "ListOrders", r.listOrdersSQL, args...)`)
+	f.AssertStmts("QueryRunner.UpdateOrderCustomerIf", `res, err := r.execPrepared(ctx, "UpdateOrderCustomerIf", r.updateOrderCustomerIfSQL, args...)`)
+	if f.Calls("QueryRunner.ListOrders", "r.db.QueryContext") != 0 {
+		t.Error("ListOrders should run through the statement cache")
+	}
+
+	cfg.PrepareStatements = false
+	code, _ = GenerateUnifiedRunner(cfg)
+	if f := gofile.Parse(t, "runner.go", code); f.HasType("stmtCache") || f.HasFunc("QueryRunner.Close") {
+		t.Error("PrepareStatements = false should not generate the statement cache")
+	}
+}
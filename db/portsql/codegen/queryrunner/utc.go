@@ -0,0 +1,133 @@
+package queryrunner
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dbstrings"
+	"github.com/shipq/shipq/dburl"
+)
+
+// mysqlUTCLayout formats timestamptz values for MySQL DATETIME(6). Passing
+// a string keeps go-sql-driver/mysql from converting the value into the
+// connection's loc first.
+const mysqlUTCLayout = "2006-01-02 15:04:05.999999"
+
+// sqliteUTCLayout matches the strftime('%Y-%m-%dT%H:%M:%fZ','now') default
+// so stored values compare correctly as text.
+const sqliteUTCLayout = "2006-01-02T15:04:05.000Z"
+
+// utcParamNames returns the parameters compared with or written to a
+// timestamptz column anywhere in ast.
+func utcParamNames(ast *query.SerializedAST) map[string]bool {
+	names := make(map[string]bool)
+	for name := range columnParamNames(ast, func(col *query.SerializedColumn) string {
+		if col.UTC {
+			return "utc"
+		}
+		return ""
+	}) {
+		names[name] = true
+	}
+	return names
+}
+
+// hasUTCColumns reports whether any query binds or returns a timestamptz
+// value, in which case the runner needs the UTC conversion helpers.
+func hasUTCColumns(queries []userQueryInfo) bool {
+	for _, qi := range queries {
+		if len(qi.UTCParams) > 0 {
+			return true
+		}
+		for _, r := range qi.Results {
+			if r.UTC {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// argExpr returns the expression binding the named parameter of qi from
+// the params struct recv, routing timestamptz parameters through utcArg and
+// custom type parameters through their Value conversion.
+func argExpr(qi userQueryInfo, recv, paramName string) string {
+	return fieldArgExpr(qi, paramName, recv+"."+dbstrings.ToPascalCase(paramName))
+}
+
+// fieldArgExpr is argExpr for field, an expression holding the value of
+// the named parameter.
+func fieldArgExpr(qi userQueryInfo, paramName, field string) string {
+	if typeName := qi.CustomParams[paramName]; typeName != "" {
+		return customArgExpr(qi, paramName, typeName, field)
+	}
+	if !qi.UTCParams[paramName] {
+		return field
+	}
+	for _, p := range qi.Params {
+		if p.Name != paramName {
+			continue
+		}
+		switch p.GoType {
+		case "time.Time":
+			return "utcArg(" + field + ")"
+		case "*time.Time":
+			return "utcNullArg(" + field + ")"
+		}
+	}
+	return field
+}
+
+// writeUTCResults normalizes timestamptz fields of target after a scan.
+func writeUTCResults(buf *bytes.Buffer, results []resultInfo, target, indent string) {
+	for _, r := range results {
+		if !r.UTC {
+			continue
+		}
+		switch r.GoType {
+		case "time.Time":
+			buf.WriteString(fmt.Sprintf("%s%s.%s = utcTime(%s.%s)\n", indent, target, r.Name, target, r.Name))
+		case "*time.Time":
+			buf.WriteString(fmt.Sprintf("%s%s.%s = utcNullTime(%s.%s)\n", indent, target, r.Name, target, r.Name))
+		}
+	}
+}
+
+// writeUTCHelpers emits the conversions used for timestamptz columns.
+// Values are always handed to callers in UTC. MySQL and SQLite have no
+// zone-aware type, so the stored wall-clock time is UTC as well.
+func writeUTCHelpers(buf *bytes.Buffer, dialect string) {
+	var arg, read string
+	switch dialect {
+	case dburl.DialectMySQL:
+		arg = fmt.Sprintf("t.UTC().Format(%q)", mysqlUTCLayout)
+		// The driver parses DATETIME in the connection's loc; the stored
+		// wall-clock time is UTC, so reinterpret it rather than convert.
+		read = "time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)"
+	case dburl.DialectSQLite:
+		arg = fmt.Sprintf("t.UTC().Format(%q)", sqliteUTCLayout)
+		read = "t.UTC()"
+	default:
+		arg = "t.UTC()"
+		read = "t.UTC()"
+	}
+
+	buf.WriteString("// utcArg binds a timestamptz parameter as UTC.\n")
+	buf.WriteString("func utcArg(t time.Time) any {\n")
+	buf.WriteString("\treturn " + arg + "\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("func utcNullArg(t *time.Time) any {\n")
+	buf.WriteString("\tif t == nil {\n\t\treturn nil\n\t}\n")
+	buf.WriteString("\treturn utcArg(*t)\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("// utcTime converts a scanned timestamptz value to UTC.\n")
+	buf.WriteString("func utcTime(t time.Time) time.Time {\n")
+	buf.WriteString("\treturn " + read + "\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("func utcNullTime(t *time.Time) *time.Time {\n")
+	buf.WriteString("\tif t == nil {\n\t\treturn nil\n\t}\n")
+	buf.WriteString("\tu := utcTime(*t)\n")
+	buf.WriteString("\treturn &u\n")
+	buf.WriteString("}\n\n")
+}
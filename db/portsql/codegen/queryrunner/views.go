@@ -0,0 +1,231 @@
+package queryrunner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
+	"github.com/shipq/shipq/dbstrings"
+	"github.com/shipq/shipq/dburl"
+)
+
+// ViewManifestEntry describes one materialized view in
+// shipq/queries/views.json, which `shipq db refresh` and the worker
+// generator read. All SQL is for the project's dialect.
+type ViewManifestEntry struct {
+	Name            string   `json:"name"`
+	RefreshMethod   string   `json:"refresh_method"`
+	RefreshSchedule string   `json:"refresh_schedule,omitempty"`
+	Create          string   `json:"create"`
+	Refresh         []string `json:"refresh"`
+	Drop            string   `json:"drop"`
+}
+
+// viewStatements returns the SQL that creates view name from selectSQL if
+// it does not exist, repopulates it, and drops it. Postgres gets a real
+// materialized view; MySQL, SQLite and SQL Server get a table that is
+// emptied and refilled, which the caller runs in one transaction.
+func viewStatements(dialect string, d compile.Dialect, name, selectSQL string) (create string, refresh []string, drop string) {
+	view := quoteIdentifier(name, d)
+	if dialect == dburl.DialectPostgres {
+		return fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS %s WITH NO DATA", view, selectSQL),
+			[]string{fmt.Sprintf("REFRESH MATERIALIZED VIEW %s", view)},
+			fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", view)
+	}
+	create = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s AS %s", view, selectSQL)
+	if dialect == dburl.DialectMSSQL {
+		// SQL Server has no CREATE TABLE ... AS; SELECT INTO creates the table
+		create = fmt.Sprintf("IF OBJECT_ID('%s', 'U') IS NULL SELECT * INTO %s FROM (%s) AS [src]",
+			strings.ReplaceAll(name, "'", "''"), view, selectSQL)
+	}
+	return create,
+		[]string{
+			fmt.Sprintf("DELETE FROM %s", view),
+			fmt.Sprintf("INSERT INTO %s %s", view, selectSQL),
+		},
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", view)
+}
+
+// compileMaterializedView turns a registered view into two runner methods:
+// List<View>, a parameterless ReturnMany read of the view's rows, and
+// Refresh<View>, which creates the view on first use and recomputes it.
+func compileMaterializedView(sq query.SerializedQuery, ast *query.AST, compiler *compile.Compiler) ([]userQueryInfo, error) {
+	if sq.AST.Kind != string(query.SelectQuery) {
+		return nil, fmt.Errorf("view %s must be a SELECT query", sq.Name)
+	}
+	if params := extractParams(sq.AST); len(params) > 0 {
+		return nil, fmt.Errorf("view %s cannot use parameters (got %q)", sq.Name, params[0].Name)
+	}
+
+	results := extractResults(sq.AST, sq.Name)
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if len(r.JSONAggCols) > 0 {
+			return nil, fmt.Errorf("view %s: json_agg column %s cannot be materialized", sq.Name, r.Column)
+		}
+		if r.Column == r.Name && strings.HasPrefix(r.Column, "Col") && strings.Trim(r.Column[3:], "0123456789") == "" {
+			return nil, fmt.Errorf("view %s: expression %s needs an alias (use SelectExprAs)", sq.Name, r.Column)
+		}
+		if seen[r.Column] {
+			return nil, fmt.Errorf("view %s: duplicate column %q; alias one of them", sq.Name, r.Column)
+		}
+		seen[r.Column] = true
+	}
+
+	selectSQL, _, err := compiler.Compile(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile view %s: %w", sq.Name, err)
+	}
+
+	dialect, err := getDialect(compiler.DialectName())
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]string, len(results))
+	for i, r := range results {
+		cols[i] = quoteIdentifier(r.Column, dialect)
+	}
+	readSQL := fmt.Sprintf("SELECT %s FROM %s", strings.Join(cols, ", "), quoteIdentifier(sq.Name, dialect))
+
+	create, refresh, _ := viewStatements(compiler.DialectName(), dialect, sq.Name, selectSQL)
+	pascal := dbstrings.ToPascalCase(sq.Name)
+
+	return []userQueryInfo{
+		{
+			Name:       "List" + pascal,
+			ReturnType: query.ReturnMany,
+			QueryKind:  string(query.SelectQuery),
+			TableName:  sq.Name,
+			SQL:        readSQL,
+			Results:    results,
+		},
+		{
+			Name:       "Refresh" + pascal,
+			ReturnType: query.ReturnMaterializedView,
+			TableName:  sq.Name,
+			SQL:        create,
+			RefreshSQL: refresh,
+		},
+	}, nil
+}
+
+// writeRefreshViewMethod writes Refresh<View>. The create statement runs
+// first and is a no-op once the view exists; multi-statement refreshes run
+// in a transaction unless the runner is already inside one.
+func writeRefreshViewMethod(buf *bytes.Buffer, qi userQueryInfo) {
+	sqlField := dbstrings.ToLowerCamel(qi.Name) + "SQL"
+
+	fmt.Fprintf(buf, "// %s creates the %s materialized view if needed and recomputes its rows.\n", qi.Name, qi.TableName)
+	fmt.Fprintf(buf, "func (r *QueryRunner) %s(ctx context.Context) error {\n", qi.Name)
+	fmt.Fprintf(buf, "\tif _, err := r.db.ExecContext(ctx, r.%s); err != nil {\n", sqlField)
+	fmt.Fprintf(buf, "\t\treturn fmt.Errorf(\"create view %s: %%w\", err)\n", qi.TableName)
+	buf.WriteString("\t}\n")
+
+	if len(qi.RefreshSQL) == 1 {
+		fmt.Fprintf(buf, "\tif _, err := r.db.ExecContext(ctx, %q); err != nil {\n", qi.RefreshSQL[0])
+		fmt.Fprintf(buf, "\t\treturn fmt.Errorf(\"refresh view %s: %%w\", err)\n", qi.TableName)
+		buf.WriteString("\t}\n")
+		buf.WriteString("\treturn nil\n")
+		buf.WriteString("}\n\n")
+		return
+	}
+
+	buf.WriteString("\tdb := r.db\n")
+	buf.WriteString("\tvar tx *sql.Tx\n")
+	buf.WriteString("\tif sqlDB, ok := r.db.(*sql.DB); ok {\n")
+	buf.WriteString("\t\tvar err error\n")
+	buf.WriteString("\t\tif tx, err = sqlDB.BeginTx(ctx, nil); err != nil {\n")
+	buf.WriteString("\t\t\treturn err\n")
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t\tdefer tx.Rollback()\n")
+	buf.WriteString("\t\tdb = tx\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tfor _, stmt := range []string{\n")
+	for _, stmt := range qi.RefreshSQL {
+		fmt.Fprintf(buf, "\t\t%q,\n", stmt)
+	}
+	buf.WriteString("\t} {\n")
+	buf.WriteString("\t\tif _, err := db.ExecContext(ctx, stmt); err != nil {\n")
+	fmt.Fprintf(buf, "\t\t\treturn fmt.Errorf(\"refresh view %s: %%w\", err)\n", qi.TableName)
+	buf.WriteString("\t\t}\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\tif tx != nil {\n")
+	buf.WriteString("\t\treturn tx.Commit()\n")
+	buf.WriteString("\t}\n")
+	buf.WriteString("\treturn nil\n")
+	buf.WriteString("}\n\n")
+}
+
+// GenerateViewManifest generates shipq/queries/views.json for the
+// materialized views among cfg.UserQueries, or nil when there are none.
+func GenerateViewManifest(cfg UnifiedRunnerConfig) ([]byte, error) {
+	compiler, err := getCompiler(cfg.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	dialect, err := getDialect(cfg.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ViewManifestEntry
+	for _, sq := range cfg.UserQueries {
+		if sq.ReturnType != query.ReturnMaterializedView {
+			continue
+		}
+		ast := query.DeserializeAST(sq.AST)
+		if ast == nil {
+			return nil, fmt.Errorf("failed to deserialize AST for view %s", sq.Name)
+		}
+		selectSQL, _, err := compiler.Compile(ast)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile view %s: %w", sq.Name, err)
+		}
+		create, refresh, drop := viewStatements(cfg.Dialect, dialect, sq.Name, selectSQL)
+		entries = append(entries, ViewManifestEntry{
+			Name:            sq.Name,
+			RefreshMethod:   "Refresh" + dbstrings.ToPascalCase(sq.Name),
+			RefreshSchedule: sq.RefreshSchedule,
+			Create:          create,
+			Refresh:         refresh,
+			Drop:            drop,
+		})
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ViewManifestPath is where `shipq db compile` writes the view manifest,
+// relative to the shipq root.
+var ViewManifestPath = filepath.Join("shipq", "queries", "views.json")
+
+// ReadViewManifest reads the view manifest under shipqRoot. It returns nil
+// and no error when the project has no materialized views.
+func ReadViewManifest(shipqRoot string) ([]ViewManifestEntry, error) {
+	data, err := os.ReadFile(filepath.Join(shipqRoot, ViewManifestPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []ViewManifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ViewManifestPath, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,205 @@
+package queryrunner
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+
+	_ "modernc.org/sqlite"
+)
+
+type viewTestTable struct{}
+
+func (viewTestTable) TableName() string { return "orders" }
+
+var (
+	ordersCustomer = query.StringColumn{Table: "orders", Name: "customer"}
+	ordersTotal    = query.Int64Column{Table: "orders", Name: "total"}
+)
+
+func customerTotalsView(schedule string) query.SerializedQuery {
+	ast := query.From(viewTestTable{}).
+		Select(ordersCustomer).
+		SelectExprAs(query.Count(), "order_count").
+		GroupBy(ordersCustomer).
+		Build()
+	return query.SerializedQuery{
+		Name:            "customer_totals",
+		ReturnType:      query.ReturnMaterializedView,
+		AST:             query.SerializeAST(ast),
+		RefreshSchedule: schedule,
+	}
+}
+
+func TestGenerateUnifiedRunner_MaterializedView(t *testing.T) {
+	for _, dialect := range []string{dburl.DialectPostgres, dburl.DialectMySQL, dburl.DialectSQLite, dburl.DialectMSSQL} {
+		cfg := UnifiedRunnerConfig{
+			ModulePath:  "myapp",
+			Dialect:     dialect,
+			UserQueries: []query.SerializedQuery{customerTotalsView("@hourly")},
+		}
+
+		runner, err := GenerateUnifiedRunner(cfg)
+		if err != nil {
+			t.Fatalf("%s: GenerateUnifiedRunner() error = %v", dialect, err)
+		}
+		f := gofile.Parse(t, dialect+"/runner.go", runner)
+		f.AssertSignature("QueryRunner.ListCustomerTotals", "func (r *QueryRunner) ListCustomerTotals(ctx context.Context, params queries.ListCustomerTotalsParams) ([]queries.ListCustomerTotalsResult, error)")
+		f.AssertSignature("QueryRunner.RefreshCustomerTotals", "func (r *QueryRunner) RefreshCustomerTotals(ctx context.Context) error")
+		sqls := strings.Join(f.Strings(""), "\n")
+		if dialect == dburl.DialectPostgres {
+			if !strings.Contains(sqls, `CREATE MATERIALIZED VIEW IF NOT EXISTS "customer_totals"`) ||
+				!strings.Contains(sqls, `REFRESH MATERIALIZED VIEW "customer_totals"`) {
+				t.Errorf("postgres: expected materialized view DDL\n%s", sqls)
+			}
+		} else if dialect == dburl.DialectMSSQL && !strings.Contains(sqls, `SELECT * INTO [customer_totals] FROM (`) {
+			t.Errorf("mssql: expected SELECT INTO to create the view table\n%s", sqls)
+		} else if f.Calls("QueryRunner.RefreshCustomerTotals", "sqlDB.BeginTx") != 1 {
+			t.Errorf("%s: table-backed refresh should run in a transaction", dialect)
+		}
+
+		types, err := GenerateSharedTypes(cfg)
+		if err != nil {
+			t.Fatalf("%s: GenerateSharedTypes() error = %v", dialect, err)
+		}
+		tf := gofile.Parse(t, dialect+"/types.go", types)
+		if !strings.Contains(tf.Type("Runner"), "RefreshCustomerTotals(ctx context.Context) error") {
+			t.Errorf("%s: Runner should declare RefreshCustomerTotals without params", dialect)
+		}
+		if typ, _, _ := tf.Field("ListCustomerTotalsResult", "OrderCount"); typ != "int64" {
+			t.Errorf("%s: ListCustomerTotalsResult.OrderCount is %q, want int64", dialect, typ)
+		}
+		if tf.HasType("RefreshCustomerTotalsParams") {
+			t.Errorf("%s: refresh method should not have a params type", dialect)
+		}
+	}
+}
+
+func TestCompileMaterializedView_Rejects(t *testing.T) {
+	withParam := customerTotalsView("")
+	withParam.AST = query.SerializeAST(query.From(viewTestTable{}).
+		Select(ordersCustomer).
+		Where(ordersTotal.Gt(query.Param[int64]("min"))).
+		Build())
+
+	unaliased := customerTotalsView("")
+	unaliased.AST = query.SerializeAST(query.From(viewTestTable{}).
+		Select(ordersCustomer).
+		SelectExpr(query.Count()).
+		GroupBy(ordersCustomer).
+		Build())
+
+	for name, tc := range map[string]struct {
+		sq   query.SerializedQuery
+		want string
+	}{
+		"param":     {withParam, "cannot use parameters"},
+		"unaliased": {unaliased, "needs an alias"},
+	} {
+		_, err := GenerateUnifiedRunner(UnifiedRunnerConfig{ModulePath: "myapp", Dialect: dburl.DialectSQLite, UserQueries: []query.SerializedQuery{tc.sq}})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestGenerateViewManifest_SQLiteRoundTrip(t *testing.T) {
+	data, err := GenerateViewManifest(UnifiedRunnerConfig{
+		Dialect:     dburl.DialectSQLite,
+		UserQueries: []query.SerializedQuery{customerTotalsView("*/15 * * * *")},
+	})
+	if err != nil {
+		t.Fatalf("GenerateViewManifest() error = %v", err)
+	}
+	var entries []ViewManifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if len(entries) != 1 || entries[0].RefreshMethod != "RefreshCustomerTotals" || entries[0].RefreshSchedule != "*/15 * * * *" {
+		t.Fatalf("unexpected manifest %+v", entries)
+	}
+	view := entries[0]
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	mustExec := func(stmt string) {
+		t.Helper()
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	count := func() int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(`SELECT order_count FROM "customer_totals" WHERE customer = 'ada'`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	refresh := func() {
+		t.Helper()
+		mustExec(view.Create)
+		for _, stmt := range view.Refresh {
+			mustExec(stmt)
+		}
+	}
+
+	mustExec(`CREATE TABLE orders (customer TEXT NOT NULL, total INTEGER NOT NULL)`)
+	mustExec(`INSERT INTO orders VALUES ('ada', 10), ('ada', 20), ('bob', 5)`)
+	refresh()
+	if got := count(); got != 2 {
+		t.Errorf("order_count = %d after first refresh, want 2", got)
+	}
+
+	mustExec(`INSERT INTO orders VALUES ('ada', 30)`)
+	if got := count(); got != 2 {
+		t.Errorf("view should not change before refresh, got %d", got)
+	}
+	refresh()
+	if got := count(); got != 3 {
+		t.Errorf("order_count = %d after second refresh, want 3", got)
+	}
+
+	mustExec(view.Drop)
+	if _, err := db.Exec(`SELECT 1 FROM "customer_totals"`); err == nil {
+		t.Error("expected view to be dropped")
+	}
+}
+
+func TestCompileForExplain(t *testing.T) {
+	ast := query.From(viewTestTable{}).
+		Select(ordersCustomer, ordersTotal).
+		Where(ordersCustomer.Eq(query.Param[string]("customer"))).
+		Build()
+	sq := query.SerializedQuery{
+		Name:          "ListOrders",
+		ReturnType:    query.ReturnPaginated,
+		AST:           query.SerializeAST(ast),
+		CursorColumns: []query.SerializedColumn{{Table: "orders", Name: "total", GoType: "int64"}},
+	}
+
+	got, err := CompileForExplain(sq, dburl.DialectPostgres)
+	if err != nil {
+		t.Fatalf("CompileForExplain() error = %v", err)
+	}
+	if !strings.Contains(got.SQL, `ORDER BY "orders"."total" DESC LIMIT $2`) {
+		t.Errorf("expected the first page's ORDER BY and LIMIT, got %s", got.SQL)
+	}
+	if strings.Join(got.ParamOrder, ",") != "customer,__limit" || got.ParamTypes["customer"] != "string" || got.ParamTypes["__limit"] != "int" {
+		t.Errorf("params = %v %v", got.ParamOrder, got.ParamTypes)
+	}
+
+	if _, err := CompileForExplain(customerTotalsView(""), dburl.DialectPostgres); err == nil {
+		t.Error("expected materialized views to be rejected")
+	}
+}
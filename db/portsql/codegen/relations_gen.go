@@ -3,12 +3,13 @@ package codegen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"sort"
 	"strings"
 
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dbstrings"
 )
 
 // RelationType represents the type of relationship between tables.
@@ -122,6 +123,58 @@ func scanManyToMany(table ddl.Table) []Relation {
 	}
 }
 
+// EmbeddableManyToMany returns the many-to-many relations from tableName
+// through the junction tables of schema that can be embedded in its get-one
+// response, ordered by junction table name so that generated code is
+// stable. Embeds are keyed by public_id, so both tables must have one.
+func EmbeddableManyToMany(tableName string, schema map[string]ddl.Table) []Relation {
+	if !AnalyzeTable(schema[tableName]).HasPublicID {
+		return nil
+	}
+	names := make([]string, 0, len(schema))
+	for name, table := range schema {
+		if table.IsJunctionTable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var relations []Relation
+	for _, name := range names {
+		for _, rel := range scanManyToMany(schema[name]) {
+			if rel.FromTable == tableName && AnalyzeTable(schema[rel.ToTable]).HasPublicID {
+				relations = append(relations, rel)
+			}
+		}
+	}
+	return relations
+}
+
+// Name returns the name of a many-to-many relation: the plural of the
+// junction column pointing at ToTable, without its _id suffix.
+// Example: JunctionFKTo "tag_id" -> "tags"
+func (r Relation) Name() string {
+	return dbstrings.ToPlural(strings.TrimSuffix(r.JunctionFKTo, "_id"))
+}
+
+// EmbeddableColumns returns the columns of a related table that are embedded
+// in another table's responses: all but the internal id, deleted_at,
+// author_account_id, further references, which hold internal ids too, and
+// columns restricted to read roles.
+func EmbeddableColumns(table ddl.Table) []ddl.ColumnDefinition {
+	var cols []ddl.ColumnDefinition
+	for _, col := range table.Columns {
+		switch {
+		case col.Name == "id", col.Name == "deleted_at", col.Name == "author_account_id":
+			continue
+		case col.References != "", len(col.ReadRoles) > 0:
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
 // GenerateRelationTypes generates Go structs for all relation types.
 // These structs represent the result types for relation queries.
 func GenerateRelationTypes(plan *migrate.MigrationPlan, relations []Relation) ([]byte, error) {
@@ -132,7 +185,7 @@ func GenerateRelationTypes(plan *migrate.MigrationPlan, relations []Relation) ([
 	buf.WriteString("package queries\n\n")
 
 	if len(relations) == 0 {
-		return format.Source(buf.Bytes())
+		return phase.FormatSource(buf.Bytes())
 	}
 
 	// Collect imports
@@ -191,7 +244,7 @@ func GenerateRelationTypes(plan *migrate.MigrationPlan, relations []Relation) ([
 		}
 	}
 
-	return format.Source(buf.Bytes())
+	return phase.FormatSource(buf.Bytes())
 }
 
 // generateRelationTypeName creates a type name like "CategoryWithPets" or "PetWithCategory"
@@ -366,10 +419,7 @@ func generateHasManySQL(plan *migrate.MigrationPlan, rel Relation, dialect SQLDi
 	b.WriteString(Placeholder(1, dialect))
 
 	// GROUP BY to aggregate related items
-	b.WriteString(" GROUP BY ")
-	b.WriteString(QuoteIdentifier(rel.FromTable, dialect))
-	b.WriteString(".")
-	b.WriteString(QuoteIdentifier("id", dialect))
+	writeRelationGroupBy(&b, rel.FromTable, fromAnalysis.ResultColumns, dialect)
 
 	return b.String()
 }
@@ -504,14 +554,32 @@ func generateManyToManySQL(plan *migrate.MigrationPlan, rel Relation, dialect SQ
 	b.WriteString(Placeholder(1, dialect))
 
 	// GROUP BY to aggregate related items
-	b.WriteString(" GROUP BY ")
-	b.WriteString(QuoteIdentifier(rel.FromTable, dialect))
-	b.WriteString(".")
-	b.WriteString(QuoteIdentifier("id", dialect))
+	writeRelationGroupBy(&b, rel.FromTable, fromAnalysis.ResultColumns, dialect)
 
 	return b.String()
 }
 
+// writeRelationGroupBy writes the GROUP BY of a relation query. Grouping by
+// the primary key is enough for the other dialects; SQL Server requires
+// every selected column to be grouped.
+func writeRelationGroupBy(b *strings.Builder, tableName string, cols []ddl.ColumnDefinition, dialect SQLDialect) {
+	b.WriteString(" GROUP BY ")
+	if dialect != SQLDialectMSSQL {
+		b.WriteString(QuoteIdentifier(tableName, dialect))
+		b.WriteString(".")
+		b.WriteString(QuoteIdentifier("id", dialect))
+		return
+	}
+	for i, col := range cols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(QuoteIdentifier(tableName, dialect))
+		b.WriteString(".")
+		b.WriteString(QuoteIdentifier(col.Name, dialect))
+	}
+}
+
 // writeJSONAgg writes the JSON aggregation expression for a list of columns.
 func writeJSONAgg(b *strings.Builder, tableName string, cols []ddl.ColumnDefinition, dialect SQLDialect) {
 	switch dialect {
@@ -521,6 +589,8 @@ func writeJSONAgg(b *strings.Builder, tableName string, cols []ddl.ColumnDefinit
 		writeJSONAggMySQL(b, tableName, cols)
 	case SQLDialectSQLite:
 		writeJSONAggSQLite(b, tableName, cols)
+	case SQLDialectMSSQL:
+		writeJSONAggMSSQL(b, tableName, cols)
 	}
 }
 
@@ -597,6 +667,18 @@ func writeJSONAggSQLite(b *strings.Builder, tableName string, cols []ddl.ColumnD
 	b.WriteString(" IS NOT NULL), '[]')")
 }
 
+// writeJSONAggMSSQL writes SQL Server JSON aggregation (Azure SQL, SQL Server 2025).
+// COALESCE(JSON_ARRAYAGG(CASE WHEN col IS NOT NULL THEN JSON_OBJECT(...) END), '[]')
+func writeJSONAggMSSQL(b *strings.Builder, tableName string, cols []ddl.ColumnDefinition) {
+	b.WriteString("COALESCE(JSON_ARRAYAGG(CASE WHEN ")
+	b.WriteString(QuoteIdentifier(tableName, SQLDialectMSSQL))
+	b.WriteString(".")
+	b.WriteString(QuoteIdentifier(cols[0].Name, SQLDialectMSSQL))
+	b.WriteString(" IS NOT NULL THEN ")
+	writeJSONObject(b, tableName, cols, SQLDialectMSSQL)
+	b.WriteString(" END), '[]')")
+}
+
 // writeJSONObject writes a JSON object expression for a single related item.
 func writeJSONObject(b *strings.Builder, tableName string, cols []ddl.ColumnDefinition, dialect SQLDialect) {
 	switch dialect {
@@ -642,5 +724,18 @@ func writeJSONObject(b *strings.Builder, tableName string, cols []ddl.ColumnDefi
 			b.WriteString(`"`)
 		}
 		b.WriteString(")")
+	case SQLDialectMSSQL:
+		// SQL Server separates keys from values with a colon
+		b.WriteString("JSON_OBJECT(")
+		for i, col := range cols {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(b, "'%s': ", col.Name)
+			b.WriteString(QuoteIdentifier(tableName, SQLDialectMSSQL))
+			b.WriteString(".")
+			b.WriteString(QuoteIdentifier(col.Name, SQLDialectMSSQL))
+		}
+		b.WriteString(")")
 	}
 }
@@ -164,6 +164,77 @@ func TestScanRelations_MultipleFK(t *testing.T) {
 	}
 }
 
+func TestEmbeddableManyToMany(t *testing.T) {
+	publicTable := func(name string) ddl.Table {
+		return ddl.Table{
+			Name: name,
+			Columns: []ddl.ColumnDefinition{
+				{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+				{Name: "public_id", Type: ddl.StringType},
+			},
+		}
+	}
+	schema := map[string]ddl.Table{
+		"posts": publicTable("posts"),
+		"tags":  publicTable("tags"),
+		"notes": {
+			Name:    "notes",
+			Columns: []ddl.ColumnDefinition{{Name: "id", Type: ddl.BigintType, PrimaryKey: true}},
+		},
+		"post_tags": {
+			Name:            "post_tags",
+			IsJunctionTable: true,
+			Columns: []ddl.ColumnDefinition{
+				{Name: "post_id", Type: ddl.BigintType, References: "posts"},
+				{Name: "tag_id", Type: ddl.BigintType, References: "tags"},
+			},
+		},
+		"post_notes": {
+			Name:            "post_notes",
+			IsJunctionTable: true,
+			Columns: []ddl.ColumnDefinition{
+				{Name: "post_id", Type: ddl.BigintType, References: "posts"},
+				{Name: "note_id", Type: ddl.BigintType, References: "notes"},
+			},
+		},
+	}
+
+	relations := EmbeddableManyToMany("posts", schema)
+	if len(relations) != 1 {
+		t.Fatalf("expected 1 relation (notes have no public_id), got %d", len(relations))
+	}
+	if rel := relations[0]; rel.ToTable != "tags" || rel.JunctionTable != "post_tags" || rel.Name() != "tags" {
+		t.Errorf("got relation to %q through %q named %q", rel.ToTable, rel.JunctionTable, rel.Name())
+	}
+
+	if got := EmbeddableManyToMany("notes", schema); got != nil {
+		t.Errorf("expected no relations for a table without public_id, got %d", len(got))
+	}
+}
+
+func TestEmbeddableColumns(t *testing.T) {
+	table := ddl.Table{
+		Name: "tags",
+		Columns: []ddl.ColumnDefinition{
+			{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "public_id", Type: ddl.StringType},
+			{Name: "deleted_at", Type: ddl.DatetimeType, Nullable: true},
+			{Name: "author_account_id", Type: ddl.BigintType},
+			{Name: "owner_id", Type: ddl.BigintType, References: "users"},
+			{Name: "secret", Type: ddl.StringType, ReadRoles: []string{"admin"}},
+			{Name: "name", Type: ddl.StringType},
+		},
+	}
+
+	var names []string
+	for _, col := range EmbeddableColumns(table) {
+		names = append(names, col.Name)
+	}
+	if got := strings.Join(names, ","); got != "public_id,name" {
+		t.Errorf("embeddable columns = %s, want public_id,name", got)
+	}
+}
+
 // Helper to find a specific relation
 func findRelation(relations []Relation, from, to string, relType RelationType) *Relation {
 	for i := range relations {
@@ -391,6 +462,31 @@ func TestGenerateRelationSQL_HasMany_SQLite(t *testing.T) {
 	}
 }
 
+func TestGenerateRelationSQL_HasMany_MSSQL(t *testing.T) {
+	plan := createCategoryPetsPlan()
+	relations := ScanRelations(plan)
+	hasManyRel := findRelation(relations, "categories", "pets", RelationHasMany)
+	if hasManyRel == nil {
+		t.Fatal("expected HasMany relation")
+	}
+
+	sql := GenerateRelationSQL(plan, *hasManyRel, SQLDialectMSSQL)
+
+	// Should have JSON_ARRAYAGG with colon-separated JSON_OBJECT keys
+	if !strings.Contains(sql, "COALESCE(JSON_ARRAYAGG(CASE WHEN [pets].[public_id] IS NOT NULL THEN JSON_OBJECT('public_id': [pets].[public_id]") {
+		t.Errorf("SQL should contain JSON_ARRAYAGG of JSON_OBJECT for SQL Server: %s", sql)
+	}
+
+	// SQL Server groups by every selected column
+	if !strings.Contains(sql, "GROUP BY [categories].[public_id], [categories].[name]") {
+		t.Errorf("SQL should group by every selected column: %s", sql)
+	}
+
+	if !strings.Contains(sql, "= @p1") {
+		t.Errorf("SQL should use @p1 placeholders: %s", sql)
+	}
+}
+
 func TestGenerateRelationSQL_BelongsTo_Postgres(t *testing.T) {
 	plan := createCategoryPetsPlan()
 	relations := ScanRelations(plan)
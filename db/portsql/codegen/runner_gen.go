@@ -2,7 +2,8 @@ package codegen
 
 import (
 	"bytes"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 // GenerateRunner generates the runner.go file content.
@@ -43,7 +44,7 @@ func Run(ctx context.Context, db *sql.DB, dialect string) error {
 `)
 
 	// Format the code
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), err
 	}
@@ -3,11 +3,11 @@ package codegen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"io"
 	"sort"
 	"strings"
 
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/db/portsql/migrate"
 )
@@ -20,9 +20,10 @@ type TableAnalysis struct {
 	HasCreatedAt       bool
 	HasUpdatedAt       bool
 	HasDeletedAt       bool
-	HasAuthorAccountID bool // True if table has author_account_id (auto-populated from session)
-	HasAutoincrementPK bool // True if table has autoincrement-eligible PK
-	PrimaryKey         *ddl.ColumnDefinition
+	HasAuthorAccountID bool                   // True if table has author_account_id (auto-populated from session)
+	HasAutoincrementPK bool                   // True if table has autoincrement-eligible PK
+	PrimaryKey         *ddl.ColumnDefinition  // Single-column primary key; nil for composite keys
+	PrimaryKeyColumns  []ddl.ColumnDefinition // All primary key columns, in column order
 	UserColumns        []ddl.ColumnDefinition // Columns for params (not auto-filled)
 	ResultColumns      []ddl.ColumnDefinition // Columns for results (not internal id, deleted_at)
 }
@@ -49,10 +50,12 @@ func AnalyzeTable(table ddl.Table) TableAnalysis {
 		}
 
 		if col.PrimaryKey {
-			colCopy := col
-			analysis.PrimaryKey = &colCopy
+			analysis.PrimaryKeyColumns = append(analysis.PrimaryKeyColumns, col)
 		}
 	}
+	if len(analysis.PrimaryKeyColumns) == 1 {
+		analysis.PrimaryKey = &analysis.PrimaryKeyColumns[0]
+	}
 
 	// UserColumns: exclude auto-filled columns
 	// These are columns that users provide values for in Insert/Update params.
@@ -139,6 +142,13 @@ type TypeMapping struct {
 
 // MapColumnType returns the Go type and column type for a DDL column.
 func MapColumnType(col ddl.ColumnDefinition) TypeMapping {
+	if col.Custom != nil {
+		if col.Nullable {
+			return TypeMapping{GoType: "*" + col.Custom.GoType, ColumnType: "NullCustomColumn", NeedsImport: col.Custom.GoImport}
+		}
+		return TypeMapping{GoType: col.Custom.GoType, ColumnType: "CustomColumn", NeedsImport: col.Custom.GoImport}
+	}
+
 	switch col.Type {
 	case ddl.IntegerType:
 		if col.Nullable {
@@ -170,7 +180,7 @@ func MapColumnType(col ddl.ColumnDefinition) TypeMapping {
 		}
 		return TypeMapping{GoType: "bool", ColumnType: "BoolColumn"}
 
-	case ddl.StringType, ddl.TextType:
+	case ddl.StringType, ddl.TextType, ddl.EnumType:
 		if col.Nullable {
 			return TypeMapping{GoType: "*string", ColumnType: "NullStringColumn"}
 		}
@@ -192,6 +202,22 @@ func MapColumnType(col ddl.ColumnDefinition) TypeMapping {
 			SQLiteScanType: "string",
 		}
 
+	case ddl.TimestamptzType:
+		if col.Nullable {
+			return TypeMapping{
+				GoType:         "*time.Time",
+				ColumnType:     "NullTimestamptzColumn",
+				NeedsImport:    "time",
+				SQLiteScanType: "sql.NullString",
+			}
+		}
+		return TypeMapping{
+			GoType:         "time.Time",
+			ColumnType:     "TimestamptzColumn",
+			NeedsImport:    "time",
+			SQLiteScanType: "string",
+		}
+
 	case ddl.BinaryType:
 		return TypeMapping{GoType: "[]byte", ColumnType: "BytesColumn"}
 
@@ -211,6 +237,20 @@ func MapColumnType(col ddl.ColumnDefinition) TypeMapping {
 			SQLiteScanType: "string",
 		}
 
+	case ddl.PointType:
+		// WKT text; the compiler converts to and from the native type.
+		if col.Nullable {
+			return TypeMapping{GoType: "*string", ColumnType: "NullPointColumn"}
+		}
+		return TypeMapping{GoType: "string", ColumnType: "PointColumn"}
+
+	case ddl.PolygonType:
+		// WKT text, as for points.
+		if col.Nullable {
+			return TypeMapping{GoType: "*string", ColumnType: "NullPolygonColumn"}
+		}
+		return TypeMapping{GoType: "string", ColumnType: "PolygonColumn"}
+
 	default:
 		// Default to string for unknown types
 		if col.Nullable {
@@ -220,6 +260,42 @@ func MapColumnType(col ddl.ColumnDefinition) TypeMapping {
 	}
 }
 
+// columnFields returns the field list of a generated column value, which
+// for custom types also names the type and its Go type.
+func columnFields(tableName string, col ddl.ColumnDefinition) string {
+	if col.Custom != nil {
+		return fmt.Sprintf("Table: %q, Name: %q, Type: %q, Go: %q", tableName, col.Name, col.Custom.Name, col.Custom.GoType)
+	}
+	return fmt.Sprintf("Table: %q, Name: %q", tableName, col.Name)
+}
+
+// writeEnumConstants writes a constant for each value of table's enum
+// columns, e.g. PostsStatusPending for "pending", and a slice of the values.
+func writeEnumConstants(buf *bytes.Buffer, table ddl.Table) {
+	for _, col := range table.Columns {
+		if col.Type != ddl.EnumType {
+			continue
+		}
+		prefix := toPascalCase(table.Name) + toPascalCase(col.Name)
+		names := make([]string, len(col.EnumValues))
+		fmt.Fprintf(buf, "// Values of the %s.%s enum column.\n", table.Name, col.Name)
+		buf.WriteString("const (\n")
+		for i, v := range col.EnumValues {
+			names[i] = prefix + enumValueIdent(v)
+			fmt.Fprintf(buf, "\t%s = %q\n", names[i], v)
+		}
+		buf.WriteString(")\n\n")
+		fmt.Fprintf(buf, "// %sValues lists the values of the %s.%s enum column in declaration order.\n", prefix, table.Name, col.Name)
+		fmt.Fprintf(buf, "var %sValues = []string{%s}\n\n", prefix, strings.Join(names, ", "))
+	}
+}
+
+// enumValueIdent converts an enum value to the PascalCase suffix of its
+// constant, treating '.' and '-' like '_' ("in-store" → InStore).
+func enumValueIdent(v string) string {
+	return toPascalCase(strings.NewReplacer(".", "_", "-", "_").Replace(v))
+}
+
 // NeedsSQLiteScanIntermediate reports whether a column should scan into an
 // intermediate SQLite type before conversion to its final Go type.
 func NeedsSQLiteScanIntermediate(col ddl.ColumnDefinition) bool {
@@ -313,12 +389,13 @@ func GenerateTableStruct(table ddl.Table, queryPkgPath string) ([]byte, error) {
 
 		buf.WriteString(fmt.Sprintf("// %s returns the %s column.\n", methodName, col.Name))
 		buf.WriteString(fmt.Sprintf("func (%s) %s() query.%s {\n", structName, methodName, mapping.ColumnType))
-		buf.WriteString(fmt.Sprintf("\treturn query.%s{Table: %q, Name: %q}\n", mapping.ColumnType, tableName, col.Name))
+		buf.WriteString(fmt.Sprintf("\treturn query.%s{%s}\n", mapping.ColumnType, columnFields(tableName, col)))
 		buf.WriteString("}\n\n")
 	}
+	writeEnumConstants(&buf, table)
 
 	// Format the code
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format generated code: %w", err)
 	}
@@ -378,13 +455,14 @@ func GenerateSchemaPackage(plan *migrate.MigrationPlan, queryPkgPath string) ([]
 			methodName := toPascalCase(col.Name)
 
 			buf.WriteString(fmt.Sprintf("func (%s) %s() query.%s {\n", structName, methodName, mapping.ColumnType))
-			buf.WriteString(fmt.Sprintf("\treturn query.%s{Table: %q, Name: %q}\n", mapping.ColumnType, tableName, col.Name))
+			buf.WriteString(fmt.Sprintf("\treturn query.%s{%s}\n", mapping.ColumnType, columnFields(tableName, col)))
 			buf.WriteString("}\n\n")
 		}
+		writeEnumConstants(&buf, table)
 	}
 
 	// Format the code
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		return buf.Bytes(), fmt.Errorf("failed to format generated code: %w", err)
 	}
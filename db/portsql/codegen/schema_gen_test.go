@@ -290,6 +290,20 @@ func TestMapColumnType(t *testing.T) {
 			wantColumn:     "NullTimeColumn",
 			wantSQLiteScan: "sql.NullString",
 		},
+		{
+			name:           "timestamptz non-nullable",
+			col:            ddl.ColumnDefinition{Type: ddl.TimestamptzType, Nullable: false},
+			wantGo:         "time.Time",
+			wantColumn:     "TimestamptzColumn",
+			wantSQLiteScan: "string",
+		},
+		{
+			name:           "timestamptz nullable",
+			col:            ddl.ColumnDefinition{Type: ddl.TimestamptzType, Nullable: true},
+			wantGo:         "*time.Time",
+			wantColumn:     "NullTimestamptzColumn",
+			wantSQLiteScan: "sql.NullString",
+		},
 		{
 			name:       "binary",
 			col:        ddl.ColumnDefinition{Type: ddl.BinaryType, Nullable: false},
@@ -310,6 +324,30 @@ func TestMapColumnType(t *testing.T) {
 			wantColumn:     "NullJSONColumn",
 			wantSQLiteScan: "sql.NullString",
 		},
+		{
+			name:       "point non-nullable",
+			col:        ddl.ColumnDefinition{Type: ddl.PointType, Nullable: false},
+			wantGo:     "string",
+			wantColumn: "PointColumn",
+		},
+		{
+			name:       "point nullable",
+			col:        ddl.ColumnDefinition{Type: ddl.PointType, Nullable: true},
+			wantGo:     "*string",
+			wantColumn: "NullPointColumn",
+		},
+		{
+			name:       "polygon non-nullable",
+			col:        ddl.ColumnDefinition{Type: ddl.PolygonType, Nullable: false},
+			wantGo:     "string",
+			wantColumn: "PolygonColumn",
+		},
+		{
+			name:       "polygon nullable",
+			col:        ddl.ColumnDefinition{Type: ddl.PolygonType, Nullable: true},
+			wantGo:     "*string",
+			wantColumn: "NullPolygonColumn",
+		},
 	}
 
 	for _, tt := range tests {
@@ -477,6 +515,77 @@ func TestGenerateSchemaPackage(t *testing.T) {
 	}
 }
 
+func TestGenerateSchemaPackage_CustomType(t *testing.T) {
+	cents := &ddl.CustomType{Name: "cents", Postgres: "BIGINT", MySQL: "BIGINT", SQLite: "INTEGER", GoType: "money.Cents"}
+	plan := &migrate.MigrationPlan{
+		Schema: migrate.Schema{
+			Name: "test",
+			Tables: map[string]ddl.Table{
+				"orders": {
+					Name: "orders",
+					Columns: []ddl.ColumnDefinition{
+						{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+						{Name: "total", Type: "cents", Custom: cents},
+						{Name: "refund", Type: "cents", Nullable: true, Custom: cents},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateSchemaPackage(plan, "myapp/src/query")
+	if err != nil {
+		t.Fatalf("GenerateSchemaPackage failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"func (OrdersTable) Total() query.CustomColumn",
+		`query.CustomColumn{Table: "orders", Name: "total", Type: "cents", Go: "money.Cents"}`,
+		"func (OrdersTable) Refund() query.NullCustomColumn",
+	} {
+		if !strings.Contains(string(code), expected) {
+			t.Errorf("generated code should contain %q\n%s", expected, code)
+		}
+	}
+}
+
+func TestGenerateSchemaPackage_Enum(t *testing.T) {
+	plan := &migrate.MigrationPlan{
+		Schema: migrate.Schema{
+			Name: "test",
+			Tables: map[string]ddl.Table{
+				"orders": {
+					Name: "orders",
+					Columns: []ddl.ColumnDefinition{
+						{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+						{Name: "status", Type: ddl.EnumType, EnumValues: []string{"pending", "in-store", "v1.2"}},
+						{Name: "channel", Type: ddl.EnumType, Nullable: true, EnumValues: []string{"web"}},
+					},
+				},
+			},
+		},
+	}
+
+	code, err := GenerateSchemaPackage(plan, "myapp/src/query")
+	if err != nil {
+		t.Fatalf("GenerateSchemaPackage failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"func (OrdersTable) Status() query.StringColumn",
+		"func (OrdersTable) Channel() query.NullStringColumn",
+		`OrdersStatusPending = "pending"`,
+		`OrdersStatusInStore = "in-store"`,
+		`OrdersStatusV12     = "v1.2"`,
+		"var OrdersStatusValues = []string{OrdersStatusPending, OrdersStatusInStore, OrdersStatusV12}",
+		`OrdersChannelWeb = "web"`,
+	} {
+		if !strings.Contains(string(code), expected) {
+			t.Errorf("generated code should contain %q\n%s", expected, code)
+		}
+	}
+}
+
 func TestGeneratedCodeCompiles(t *testing.T) {
 	plan := &migrate.MigrationPlan{
 		Schema: migrate.Schema{
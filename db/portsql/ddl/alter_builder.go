@@ -223,6 +223,24 @@ func (ab *AlterTableBuilder) AddUniqueIndex(cols ...ColumnRef) {
 	})
 }
 
+// AddSoftUniqueIndex adds an index on the specified columns whose values
+// must be unique among rows that are not soft-deleted. See
+// TableBuilder.AddSoftUniqueIndex.
+func (ab *AlterTableBuilder) AddSoftUniqueIndex(cols ...ColumnRef) {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.name
+	}
+	ab.operations = append(ab.operations, TableOperation{
+		Type: OpAddIndex,
+		IndexDef: &IndexDefinition{
+			Name:       GenerateIndexName(ab.tableName, names),
+			Columns:    names,
+			SoftUnique: true,
+		},
+	})
+}
+
 // --- Column Creation Methods (Add Column) ---
 
 // Alter*ColumnBuilder types for adding columns during alterations.
@@ -268,6 +286,16 @@ type AlterJSONColumnBuilder struct {
 	op           *TableOperation
 }
 
+type AlterPointColumnBuilder struct {
+	alterBuilder *AlterTableBuilder
+	op           *TableOperation
+}
+
+type AlterPolygonColumnBuilder struct {
+	alterBuilder *AlterTableBuilder
+	op           *TableOperation
+}
+
 type AlterTextColumnBuilder struct {
 	alterBuilder *AlterTableBuilder
 	op           *TableOperation
@@ -488,6 +516,27 @@ func (ab *AlterTableBuilder) Timestamp(name string) *AlterTimeColumnBuilder {
 	}
 }
 
+// Timestamptz adds a time-zone-aware timestamp column stored in UTC.
+func (ab *AlterTableBuilder) Timestamptz(name string) *AlterTimeColumnBuilder {
+	col := ColumnDefinition{
+		Name:       name,
+		Type:       TimestamptzType,
+		Nullable:   false,
+		Unique:     false,
+		PrimaryKey: false,
+		Index:      false,
+	}
+	op := TableOperation{
+		Type:      OpAddColumn,
+		ColumnDef: &col,
+	}
+	ab.operations = append(ab.operations, op)
+	return &AlterTimeColumnBuilder{
+		alterBuilder: ab,
+		op:           &ab.operations[len(ab.operations)-1],
+	}
+}
+
 // Binary adds a binary/blob column.
 func (ab *AlterTableBuilder) Binary(name string) *AlterBinaryColumnBuilder {
 	col := ColumnDefinition{
@@ -530,6 +579,48 @@ func (ab *AlterTableBuilder) JSON(name string) *AlterJSONColumnBuilder {
 	}
 }
 
+// Point adds a geographic point column. See TableBuilder.Point.
+func (ab *AlterTableBuilder) Point(name string) *AlterPointColumnBuilder {
+	col := ColumnDefinition{
+		Name:       name,
+		Type:       PointType,
+		Nullable:   false,
+		Unique:     false,
+		PrimaryKey: false,
+		Index:      false,
+	}
+	op := TableOperation{
+		Type:      OpAddColumn,
+		ColumnDef: &col,
+	}
+	ab.operations = append(ab.operations, op)
+	return &AlterPointColumnBuilder{
+		alterBuilder: ab,
+		op:           &ab.operations[len(ab.operations)-1],
+	}
+}
+
+// Polygon adds a geographic polygon column. See TableBuilder.Polygon.
+func (ab *AlterTableBuilder) Polygon(name string) *AlterPolygonColumnBuilder {
+	col := ColumnDefinition{
+		Name:       name,
+		Type:       PolygonType,
+		Nullable:   false,
+		Unique:     false,
+		PrimaryKey: false,
+		Index:      false,
+	}
+	op := TableOperation{
+		Type:      OpAddColumn,
+		ColumnDef: &col,
+	}
+	ab.operations = append(ab.operations, op)
+	return &AlterPolygonColumnBuilder{
+		alterBuilder: ab,
+		op:           &ab.operations[len(ab.operations)-1],
+	}
+}
+
 // --- AlterIntColumnBuilder Methods ---
 
 // Col returns a type-safe column reference for use in index definitions.
@@ -797,6 +888,32 @@ func (b *AlterJSONColumnBuilder) Nullable() *AlterJSONColumnBuilder {
 // Note: JSON columns cannot have DEFAULT values in MySQL.
 // For cross-database compatibility, Default() is intentionally not provided.
 
+// --- AlterPointColumnBuilder Methods ---
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *AlterPointColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.op.ColumnDef.Name}
+}
+
+// Nullable marks the column as nullable.
+func (b *AlterPointColumnBuilder) Nullable() *AlterPointColumnBuilder {
+	b.op.ColumnDef.Nullable = true
+	return b
+}
+
+// --- AlterPolygonColumnBuilder Methods ---
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *AlterPolygonColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.op.ColumnDef.Name}
+}
+
+// Nullable marks the column as nullable.
+func (b *AlterPolygonColumnBuilder) Nullable() *AlterPolygonColumnBuilder {
+	b.op.ColumnDef.Nullable = true
+	return b
+}
+
 // --- AlterTextColumnBuilder Methods ---
 
 // Col returns a type-safe column reference for use in index definitions.
@@ -2,6 +2,7 @@ package ddl
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -601,3 +602,25 @@ func TestRefMethodsWithCompositeIndex(t *testing.T) {
 		t.Errorf("unexpected index columns: %v", ops[1].IndexDef.Columns)
 	}
 }
+
+func TestAlterTableAddColumn_Roles(t *testing.T) {
+	alt := AlterTable("employees")
+	alt.String("review_notes").Nullable().ReadRoles("hr", "manager").WriteRoles("manager")
+	ops := alt.Build()
+
+	if len(ops) != 1 || ops[0].ColumnDef == nil {
+		t.Fatalf("expected 1 add-column operation, got %+v", ops)
+	}
+	col := ops[0].ColumnDef
+	if len(col.ReadRoles) != 2 || col.ReadRoles[1] != "manager" {
+		t.Errorf("ReadRoles = %v, want [hr manager]", col.ReadRoles)
+	}
+
+	data, err := json.Marshal(col)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"write_roles":["manager"]`) {
+		t.Errorf("expected write_roles in serialized column, got %s", data)
+	}
+}
@@ -0,0 +1,184 @@
+package ddl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A CHECK constraint restricts the values a row may hold. Column checks
+// (Check on a column builder) are emitted with the column, so dropping the
+// column drops them too; table checks (AddCheck) are named and may compare
+// several columns. Expressions are raw SQL copied into every dialect's DDL,
+// so they should stick to portable comparisons of unquoted column names.
+// CHECK constraints are enforced by MySQL 8.0.16 and later.
+
+// AddCheck adds a named CHECK constraint on the table, e.g.
+// tb.AddCheck("chk_orders_dates", "ends_at > starts_at").
+func (tb *TableBuilder) AddCheck(name, expr string) *TableBuilder {
+	tb.table.Checks = append(tb.table.Checks, CheckDefinition{Name: name, Expression: expr})
+	return tb
+}
+
+// AddCheck adds an operation to add a named CHECK constraint. On SQLite the
+// table is rebuilt, as it can't add constraints to an existing table.
+func (ab *AlterTableBuilder) AddCheck(name, expr string) {
+	ab.operations = append(ab.operations, TableOperation{
+		Type:     OpAddCheck,
+		CheckDef: &CheckDefinition{Name: name, Expression: expr},
+	})
+}
+
+// DropCheck adds an operation to drop a CHECK constraint added with
+// AddCheck.
+func (ab *AlterTableBuilder) DropCheck(name string) {
+	ab.operations = append(ab.operations, TableOperation{
+		Type:      OpDropCheck,
+		CheckName: name,
+	})
+}
+
+// ValidateChecks returns an error for a table check without a name or
+// expression, a duplicate check name, or an empty column check.
+func ValidateChecks(table *Table) error {
+	for i, c := range table.Checks {
+		if err := validateCheck(table.Name, &c); err != nil {
+			return err
+		}
+		if slices.ContainsFunc(table.Checks[:i], func(o CheckDefinition) bool { return o.Name == c.Name }) {
+			return fmt.Errorf("table %q: duplicate check %q", table.Name, c.Name)
+		}
+	}
+	for _, col := range table.Columns {
+		if err := ValidateColumnCheck(table.Name, &col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateColumnCheck returns an error when col was given an empty check.
+func ValidateColumnCheck(tableName string, col *ColumnDefinition) error {
+	if col.Check != "" && strings.TrimSpace(col.Check) == "" {
+		return fmt.Errorf("table %q: column %q: check expression is empty", tableName, col.Name)
+	}
+	return nil
+}
+
+func validateCheck(tableName string, c *CheckDefinition) error {
+	if c.Name == "" {
+		return fmt.Errorf("table %q: check %q needs a name", tableName, c.Expression)
+	}
+	if strings.TrimSpace(c.Expression) == "" {
+		return fmt.Errorf("table %q: check %q has an empty expression", tableName, c.Name)
+	}
+	return nil
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *IntColumnBuilder) Check(expr string) *IntColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *BoolColumnBuilder) Check(expr string) *BoolColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *StringColumnBuilder) Check(expr string) *StringColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *FloatColumnBuilder) Check(expr string) *FloatColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *DecimalColumnBuilder) Check(expr string) *DecimalColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *TimeColumnBuilder) Check(expr string) *TimeColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *TextColumnBuilder) Check(expr string) *TextColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *CustomColumnBuilder) Check(expr string) *CustomColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *EnumColumnBuilder) Check(expr string) *EnumColumnBuilder {
+	b.col.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterIntColumnBuilder) Check(expr string) *AlterIntColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterBoolColumnBuilder) Check(expr string) *AlterBoolColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterStringColumnBuilder) Check(expr string) *AlterStringColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterFloatColumnBuilder) Check(expr string) *AlterFloatColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterDecimalColumnBuilder) Check(expr string) *AlterDecimalColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterTimeColumnBuilder) Check(expr string) *AlterTimeColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterTextColumnBuilder) Check(expr string) *AlterTextColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterCustomColumnBuilder) Check(expr string) *AlterCustomColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
+
+// Check restricts the column to values satisfying expr, e.g. "age >= 0".
+func (b *AlterEnumColumnBuilder) Check(expr string) *AlterEnumColumnBuilder {
+	b.op.ColumnDef.Check = expr
+	return b
+}
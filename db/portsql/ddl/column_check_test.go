@@ -0,0 +1,91 @@
+package ddl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTableBuilder_Checks(t *testing.T) {
+	tb := MakeEmptyTable("bookings")
+	tb.Bigint("id").PrimaryKey()
+	tb.Integer("guests").Check("guests > 0")
+	tb.Datetime("starts_at")
+	tb.Datetime("ends_at")
+	tb.AddCheck("chk_bookings_dates", "ends_at > starts_at")
+	table := tb.Build()
+
+	if got := table.Columns[1].Check; got != "guests > 0" {
+		t.Errorf("column check = %q", got)
+	}
+	if len(table.Checks) != 1 || table.Checks[0] != (CheckDefinition{Name: "chk_bookings_dates", Expression: "ends_at > starts_at"}) {
+		t.Errorf("table checks = %+v", table.Checks)
+	}
+	if err := ValidateChecks(table); err != nil {
+		t.Errorf("ValidateChecks() error = %v", err)
+	}
+
+	// Checks round-trip through schema.json
+	data, err := json.Marshal(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Table
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Columns[1].Check != "guests > 0" || len(decoded.Checks) != 1 || decoded.Checks[0].Expression != "ends_at > starts_at" {
+		t.Errorf("checks lost in JSON round trip: %s", data)
+	}
+}
+
+func TestValidateChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  []CheckDefinition
+		column  string
+		wantErr string
+	}{
+		{"valid", []CheckDefinition{{Name: "a", Expression: "x > 0"}, {Name: "b", Expression: "x < 9"}}, "", ""},
+		{"no name", []CheckDefinition{{Expression: "x > 0"}}, "", "needs a name"},
+		{"no expression", []CheckDefinition{{Name: "a", Expression: " "}}, "", "empty expression"},
+		{"duplicate", []CheckDefinition{{Name: "a", Expression: "x > 0"}, {Name: "a", Expression: "x < 9"}}, "", `duplicate check "a"`},
+		{"blank column check", nil, "  ", "check expression is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &Table{Name: "t", Columns: []ColumnDefinition{{Name: "x", Type: IntegerType, Check: tt.column}}, Checks: tt.checks}
+			err := ValidateChecks(table)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAlterTableBuilder_Checks(t *testing.T) {
+	ab := AlterTable("bookings")
+	ab.Integer("nights").Check("nights > 0")
+	ab.AddCheck("chk_bookings_nights", "nights < 30")
+	ab.DropCheck("chk_bookings_dates")
+	ops := ab.Build()
+
+	if len(ops) != 3 {
+		t.Fatalf("expected 3 operations, got %d", len(ops))
+	}
+	if ops[0].ColumnDef.Check != "nights > 0" {
+		t.Errorf("column check = %q", ops[0].ColumnDef.Check)
+	}
+	if ops[1].Type != OpAddCheck || ops[1].CheckDef.Name != "chk_bookings_nights" {
+		t.Errorf("unexpected add check operation %+v", ops[1])
+	}
+	if ops[2].Type != OpDropCheck || ops[2].CheckName != "chk_bookings_dates" {
+		t.Errorf("unexpected drop check operation %+v", ops[2])
+	}
+}
@@ -0,0 +1,318 @@
+package ddl
+
+// Column-level role restrictions. ReadRoles and WriteRoles are metadata for
+// code generation: generated Get/List handlers omit a column the caller's
+// roles cannot read, and Create/Update handlers reject writes to a column the
+// caller's roles cannot write. Calling either again replaces the list.
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *IntColumnBuilder) ReadRoles(roles ...string) *IntColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *IntColumnBuilder) WriteRoles(roles ...string) *IntColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *BoolColumnBuilder) ReadRoles(roles ...string) *BoolColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *BoolColumnBuilder) WriteRoles(roles ...string) *BoolColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *StringColumnBuilder) ReadRoles(roles ...string) *StringColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *StringColumnBuilder) WriteRoles(roles ...string) *StringColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *FloatColumnBuilder) ReadRoles(roles ...string) *FloatColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *FloatColumnBuilder) WriteRoles(roles ...string) *FloatColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *DecimalColumnBuilder) ReadRoles(roles ...string) *DecimalColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *DecimalColumnBuilder) WriteRoles(roles ...string) *DecimalColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *TimeColumnBuilder) ReadRoles(roles ...string) *TimeColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *TimeColumnBuilder) WriteRoles(roles ...string) *TimeColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *BinaryColumnBuilder) ReadRoles(roles ...string) *BinaryColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *BinaryColumnBuilder) WriteRoles(roles ...string) *BinaryColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *JSONColumnBuilder) ReadRoles(roles ...string) *JSONColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *JSONColumnBuilder) WriteRoles(roles ...string) *JSONColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *TextColumnBuilder) ReadRoles(roles ...string) *TextColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *TextColumnBuilder) WriteRoles(roles ...string) *TextColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *PointColumnBuilder) ReadRoles(roles ...string) *PointColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *PointColumnBuilder) WriteRoles(roles ...string) *PointColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *PolygonColumnBuilder) ReadRoles(roles ...string) *PolygonColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *PolygonColumnBuilder) WriteRoles(roles ...string) *PolygonColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *CustomColumnBuilder) ReadRoles(roles ...string) *CustomColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *CustomColumnBuilder) WriteRoles(roles ...string) *CustomColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the column to callers holding one of roles.
+func (b *EnumColumnBuilder) ReadRoles(roles ...string) *EnumColumnBuilder {
+	b.col.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the column to callers holding one of roles.
+func (b *EnumColumnBuilder) WriteRoles(roles ...string) *EnumColumnBuilder {
+	b.col.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterIntColumnBuilder) ReadRoles(roles ...string) *AlterIntColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterIntColumnBuilder) WriteRoles(roles ...string) *AlterIntColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterBoolColumnBuilder) ReadRoles(roles ...string) *AlterBoolColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterBoolColumnBuilder) WriteRoles(roles ...string) *AlterBoolColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterStringColumnBuilder) ReadRoles(roles ...string) *AlterStringColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterStringColumnBuilder) WriteRoles(roles ...string) *AlterStringColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterFloatColumnBuilder) ReadRoles(roles ...string) *AlterFloatColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterFloatColumnBuilder) WriteRoles(roles ...string) *AlterFloatColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterDecimalColumnBuilder) ReadRoles(roles ...string) *AlterDecimalColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterDecimalColumnBuilder) WriteRoles(roles ...string) *AlterDecimalColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterTimeColumnBuilder) ReadRoles(roles ...string) *AlterTimeColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterTimeColumnBuilder) WriteRoles(roles ...string) *AlterTimeColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterBinaryColumnBuilder) ReadRoles(roles ...string) *AlterBinaryColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterBinaryColumnBuilder) WriteRoles(roles ...string) *AlterBinaryColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterJSONColumnBuilder) ReadRoles(roles ...string) *AlterJSONColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterJSONColumnBuilder) WriteRoles(roles ...string) *AlterJSONColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterTextColumnBuilder) ReadRoles(roles ...string) *AlterTextColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterTextColumnBuilder) WriteRoles(roles ...string) *AlterTextColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterPointColumnBuilder) ReadRoles(roles ...string) *AlterPointColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterPointColumnBuilder) WriteRoles(roles ...string) *AlterPointColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterPolygonColumnBuilder) ReadRoles(roles ...string) *AlterPolygonColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterPolygonColumnBuilder) WriteRoles(roles ...string) *AlterPolygonColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterCustomColumnBuilder) ReadRoles(roles ...string) *AlterCustomColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterCustomColumnBuilder) WriteRoles(roles ...string) *AlterCustomColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
+
+// ReadRoles restricts reads of the added column to callers holding one of roles.
+func (b *AlterEnumColumnBuilder) ReadRoles(roles ...string) *AlterEnumColumnBuilder {
+	b.op.ColumnDef.ReadRoles = append([]string(nil), roles...)
+	return b
+}
+
+// WriteRoles restricts writes of the added column to callers holding one of roles.
+func (b *AlterEnumColumnBuilder) WriteRoles(roles ...string) *AlterEnumColumnBuilder {
+	b.op.ColumnDef.WriteRoles = append([]string(nil), roles...)
+	return b
+}
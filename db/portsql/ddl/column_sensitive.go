@@ -0,0 +1,162 @@
+package ddl
+
+// Sensitive marks a column whose values the generated API docs mask. The
+// docs page's "try it" mode replaces the column's values in displayed
+// responses; the API itself still returns them. It has no effect on the
+// database schema.
+
+// Sensitive marks the column as sensitive.
+func (b *IntColumnBuilder) Sensitive() *IntColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *BoolColumnBuilder) Sensitive() *BoolColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *StringColumnBuilder) Sensitive() *StringColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *FloatColumnBuilder) Sensitive() *FloatColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *DecimalColumnBuilder) Sensitive() *DecimalColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *TimeColumnBuilder) Sensitive() *TimeColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *BinaryColumnBuilder) Sensitive() *BinaryColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *JSONColumnBuilder) Sensitive() *JSONColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *PointColumnBuilder) Sensitive() *PointColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *PolygonColumnBuilder) Sensitive() *PolygonColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *TextColumnBuilder) Sensitive() *TextColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *CustomColumnBuilder) Sensitive() *CustomColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *EnumColumnBuilder) Sensitive() *EnumColumnBuilder {
+	b.col.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterIntColumnBuilder) Sensitive() *AlterIntColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterBoolColumnBuilder) Sensitive() *AlterBoolColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterStringColumnBuilder) Sensitive() *AlterStringColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterFloatColumnBuilder) Sensitive() *AlterFloatColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterDecimalColumnBuilder) Sensitive() *AlterDecimalColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterTimeColumnBuilder) Sensitive() *AlterTimeColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterBinaryColumnBuilder) Sensitive() *AlterBinaryColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterJSONColumnBuilder) Sensitive() *AlterJSONColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterPointColumnBuilder) Sensitive() *AlterPointColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterPolygonColumnBuilder) Sensitive() *AlterPolygonColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterTextColumnBuilder) Sensitive() *AlterTextColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterCustomColumnBuilder) Sensitive() *AlterCustomColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
+
+// Sensitive marks the column as sensitive.
+func (b *AlterEnumColumnBuilder) Sensitive() *AlterEnumColumnBuilder {
+	b.op.ColumnDef.Sensitive = true
+	return b
+}
@@ -0,0 +1,286 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// CustomType describes a column type shipq has no built-in support for, such
+// as Postgres citext or money. Register it with RegisterType before the
+// migrations that use it run (an init function in the migrations package
+// works), then add columns with TableBuilder.Custom.
+//
+// The whole definition is copied into each column, so schema.json, and the
+// code generated from it, does not depend on the registry.
+type CustomType struct {
+	// Name identifies the type in migrations and becomes the column's Type.
+	Name string `json:"name"`
+
+	// Postgres, MySQL and SQLite are the SQL types used in DDL for each
+	// dialect, e.g. "CITEXT", "VARCHAR(255)" and "TEXT COLLATE NOCASE".
+	Postgres string `json:"postgres"`
+	MySQL    string `json:"mysql"`
+	SQLite   string `json:"sqlite"`
+
+	// MSSQL is the SQL Server type. It is optional; columns fall back to
+	// NVARCHAR(MAX).
+	MSSQL string `json:"mssql,omitempty"`
+
+	// GoType is the Go type of non-null values, qualified by its package
+	// name, e.g. "decimal.Decimal". GoImport is the import path providing it;
+	// leave it empty for predeclared types.
+	GoType   string `json:"go_type"`
+	GoImport string `json:"go_import,omitempty"`
+
+	// Scan is the body of func(src any) (GoType, error), converting a value
+	// read from the driver. Value is the body of
+	// func(v GoType) (driver.Value, error), converting a value before it is
+	// bound. When both are empty, values are scanned and bound as-is, which
+	// suits predeclared types and types implementing sql.Scanner and
+	// driver.Valuer.
+	Scan  string `json:"scan,omitempty"`
+	Value string `json:"value,omitempty"`
+
+	// OpenAPI is the JSON schema describing values in the generated OpenAPI
+	// document. Empty means {"type": "string"}.
+	OpenAPI map[string]any `json:"openapi,omitempty"`
+
+	// Sample is a Go expression for a valid value, used by generated fixtures
+	// and tests, e.g. `decimal.RequireFromString("9.99")`. Empty means a
+	// "test_<column>" string for string types and the zero value otherwise.
+	Sample string `json:"sample,omitempty"`
+}
+
+// HasConversions reports whether values pass through the Scan and Value
+// snippets rather than being handed to the driver directly.
+func (t CustomType) HasConversions() bool {
+	return t.Scan != "" || t.Value != ""
+}
+
+var (
+	customTypesMu sync.RWMutex
+	customTypes   = map[string]CustomType{}
+)
+
+var customTypeNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// builtinTypes cannot be redefined by RegisterType.
+var builtinTypes = map[string]bool{
+	IntegerType: true, BigintType: true, DecimalType: true, FloatType: true,
+	BooleanType: true, StringType: true, TextType: true, DatetimeType: true,
+	TimestampType: true, TimestamptzType: true, BinaryType: true,
+	JSONType: true, PointType: true, PolygonType: true, EnumType: true,
+}
+
+// RegisterType makes t available to TableBuilder.Custom and
+// AlterTableBuilder.Custom. Registering a name again replaces the previous
+// definition.
+func RegisterType(t CustomType) error {
+	if !customTypeNameRe.MatchString(t.Name) {
+		return fmt.Errorf("custom type name %q must be lower snake_case", t.Name)
+	}
+	if builtinTypes[t.Name] {
+		return fmt.Errorf("custom type name %q is a built-in type", t.Name)
+	}
+	if t.Postgres == "" || t.MySQL == "" || t.SQLite == "" {
+		return fmt.Errorf("custom type %q must set SQL types for postgres, mysql and sqlite", t.Name)
+	}
+	if t.GoType == "" {
+		return fmt.Errorf("custom type %q must set GoType", t.Name)
+	}
+	if (t.Scan == "") != (t.Value == "") {
+		return fmt.Errorf("custom type %q must set both Scan and Value, or neither", t.Name)
+	}
+
+	customTypesMu.Lock()
+	defer customTypesMu.Unlock()
+	customTypes[t.Name] = t
+	return nil
+}
+
+// MustRegisterType is like RegisterType but panics on an invalid definition,
+// for use in init functions.
+func MustRegisterType(t CustomType) {
+	if err := RegisterType(t); err != nil {
+		panic(err)
+	}
+}
+
+// LookupType returns the registered custom type called name.
+func LookupType(name string) (CustomType, bool) {
+	customTypesMu.RLock()
+	defer customTypesMu.RUnlock()
+	t, ok := customTypes[name]
+	return t, ok
+}
+
+// newCustomColumn builds a column of the registered type typeName. An
+// unregistered name leaves Custom nil; the migration plan rejects it.
+func newCustomColumn(name, typeName string) ColumnDefinition {
+	col := ColumnDefinition{Name: name, Type: typeName}
+	if t, ok := LookupType(typeName); ok {
+		col.Custom = &t
+	}
+	return col
+}
+
+// ValidateCustomColumns returns an error for any column of table whose type
+// is neither built in nor registered.
+func ValidateCustomColumns(table *Table) error {
+	for _, col := range table.Columns {
+		if err := ValidateCustomColumn(table.Name, &col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateCustomColumn returns an error when col's type is neither built in
+// nor registered.
+func ValidateCustomColumn(tableName string, col *ColumnDefinition) error {
+	if col.Custom == nil && !builtinTypes[col.Type] {
+		return fmt.Errorf("table %q: column %q has unregistered type %q (see ddl.RegisterType)", tableName, col.Name, col.Type)
+	}
+	return nil
+}
+
+// CustomTypes returns the custom types used by the columns of tables,
+// keyed by name.
+func CustomTypes(tables map[string]Table) map[string]CustomType {
+	result := make(map[string]CustomType)
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, col := range tables[name].Columns {
+			if col.Custom != nil {
+				result[col.Custom.Name] = *col.Custom
+			}
+		}
+	}
+	return result
+}
+
+// CustomColumnBuilder configures a column of a registered custom type.
+type CustomColumnBuilder struct {
+	tableBuilder *TableBuilder
+	col          *ColumnDefinition
+}
+
+// Custom adds a column of the custom type typeName, which must have been
+// registered with RegisterType.
+func (tb *TableBuilder) Custom(name, typeName string) *CustomColumnBuilder {
+	tb.table.Columns = append(tb.table.Columns, newCustomColumn(name, typeName))
+	return &CustomColumnBuilder{
+		tableBuilder: tb,
+		col:          &tb.table.Columns[len(tb.table.Columns)-1],
+	}
+}
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *CustomColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.col.Name}
+}
+
+// Nullable marks the column as nullable.
+func (b *CustomColumnBuilder) Nullable() *CustomColumnBuilder {
+	b.col.Nullable = true
+	return b
+}
+
+// Unique adds a unique constraint to the column.
+func (b *CustomColumnBuilder) Unique() *CustomColumnBuilder {
+	b.col.Unique = true
+	b.tableBuilder.table.Indexes = append(b.tableBuilder.table.Indexes, IndexDefinition{
+		Name:    GenerateIndexName(b.tableBuilder.table.Name, []string{b.col.Name}),
+		Columns: []string{b.col.Name},
+		Unique:  true,
+	})
+	return b
+}
+
+// Indexed adds a non-unique index on this column.
+func (b *CustomColumnBuilder) Indexed() *CustomColumnBuilder {
+	b.col.Index = true
+	b.tableBuilder.table.Indexes = append(b.tableBuilder.table.Indexes, IndexDefinition{
+		Name:    GenerateIndexName(b.tableBuilder.table.Name, []string{b.col.Name}),
+		Columns: []string{b.col.Name},
+		Unique:  false,
+	})
+	return b
+}
+
+// Default sets the default value, written as a quoted SQL string literal.
+func (b *CustomColumnBuilder) Default(v string) *CustomColumnBuilder {
+	b.col.Default = &v
+	return b
+}
+
+// AlterCustomColumnBuilder configures a custom type column being added to an
+// existing table.
+type AlterCustomColumnBuilder struct {
+	alterBuilder *AlterTableBuilder
+	op           *TableOperation
+}
+
+// Custom adds a column of a registered custom type. See TableBuilder.Custom.
+func (ab *AlterTableBuilder) Custom(name, typeName string) *AlterCustomColumnBuilder {
+	col := newCustomColumn(name, typeName)
+	ab.operations = append(ab.operations, TableOperation{
+		Type:      OpAddColumn,
+		ColumnDef: &col,
+	})
+	return &AlterCustomColumnBuilder{
+		alterBuilder: ab,
+		op:           &ab.operations[len(ab.operations)-1],
+	}
+}
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *AlterCustomColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.op.ColumnDef.Name}
+}
+
+// Nullable marks the column as nullable.
+func (b *AlterCustomColumnBuilder) Nullable() *AlterCustomColumnBuilder {
+	b.op.ColumnDef.Nullable = true
+	return b
+}
+
+// Unique marks the column as unique and adds a unique index operation.
+func (b *AlterCustomColumnBuilder) Unique() *AlterCustomColumnBuilder {
+	b.op.ColumnDef.Unique = true
+	b.alterBuilder.operations = append(b.alterBuilder.operations, TableOperation{
+		Type: OpAddIndex,
+		IndexDef: &IndexDefinition{
+			Name:    GenerateIndexName(b.alterBuilder.tableName, []string{b.op.ColumnDef.Name}),
+			Columns: []string{b.op.ColumnDef.Name},
+			Unique:  true,
+		},
+	})
+	return b
+}
+
+// Indexed adds a non-unique index operation on this column.
+func (b *AlterCustomColumnBuilder) Indexed() *AlterCustomColumnBuilder {
+	b.op.ColumnDef.Index = true
+	b.alterBuilder.operations = append(b.alterBuilder.operations, TableOperation{
+		Type: OpAddIndex,
+		IndexDef: &IndexDefinition{
+			Name:    GenerateIndexName(b.alterBuilder.tableName, []string{b.op.ColumnDef.Name}),
+			Columns: []string{b.op.ColumnDef.Name},
+			Unique:  false,
+		},
+	})
+	return b
+}
+
+// Default sets the default value, written as a quoted SQL string literal.
+func (b *AlterCustomColumnBuilder) Default(v string) *AlterCustomColumnBuilder {
+	b.op.ColumnDef.Default = &v
+	return b
+}
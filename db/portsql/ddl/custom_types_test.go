@@ -0,0 +1,93 @@
+package ddl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func citextType() CustomType {
+	return CustomType{
+		Name:     "citext",
+		Postgres: "CITEXT",
+		MySQL:    "VARCHAR(255)",
+		SQLite:   "TEXT COLLATE NOCASE",
+		GoType:   "string",
+	}
+}
+
+func TestRegisterType_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*CustomType)
+		wantErr string
+	}{
+		{"valid", func(*CustomType) {}, ""},
+		{"bad name", func(c *CustomType) { c.Name = "CiText" }, "lower snake_case"},
+		{"builtin name", func(c *CustomType) { c.Name = StringType }, "built-in type"},
+		{"missing dialect", func(c *CustomType) { c.SQLite = "" }, "postgres, mysql and sqlite"},
+		{"missing go type", func(c *CustomType) { c.GoType = "" }, "must set GoType"},
+		{"scan without value", func(c *CustomType) { c.Scan = "return src.(string), nil" }, "both Scan and Value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := citextType()
+			tt.modify(&ct)
+			err := RegisterType(ct)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("RegisterType() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RegisterType() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTableBuilder_Custom(t *testing.T) {
+	MustRegisterType(citextType())
+
+	tb := MakeEmptyTable("users")
+	tb.Custom("email", "citext").Unique()
+	tb.Custom("nickname", "citext").Nullable()
+	table := tb.Build()
+
+	email := table.Columns[0]
+	if email.Type != "citext" || email.Custom == nil || email.Custom.Postgres != "CITEXT" {
+		t.Fatalf("email column = %+v, want a citext custom column", email)
+	}
+	if !email.Unique || len(table.Indexes) != 1 {
+		t.Error("Unique() should mark the column and add an index")
+	}
+	if !table.Columns[1].Nullable {
+		t.Error("Nullable() should mark the column nullable")
+	}
+	if err := ValidateCustomColumns(table); err != nil {
+		t.Errorf("ValidateCustomColumns() error = %v", err)
+	}
+
+	// The definition travels with the column through schema.json.
+	data, err := json.Marshal(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed Table
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if got := CustomTypes(map[string]Table{"users": parsed}); got["citext"].SQLite != "TEXT COLLATE NOCASE" {
+		t.Errorf("CustomTypes() = %+v", got)
+	}
+}
+
+func TestValidateCustomColumns_Unregistered(t *testing.T) {
+	tb := MakeEmptyTable("items")
+	tb.Custom("price", "no_such_type")
+	err := ValidateCustomColumns(tb.Build())
+	if err == nil || !strings.Contains(err.Error(), `unregistered type "no_such_type"`) {
+		t.Errorf("ValidateCustomColumns() error = %v", err)
+	}
+}
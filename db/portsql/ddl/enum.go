@@ -0,0 +1,251 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// An enum column holds one of a fixed list of strings. It maps to a native
+// ENUM on MySQL, to a type created with CREATE TYPE on Postgres (see
+// EnumTypeName), and to a string column with a CHECK constraint on SQLite
+// and SQL Server. Generated code sees a plain string.
+
+// maxEnumValueLength is Postgres's limit on the length of an enum label.
+const maxEnumValueLength = 63
+
+// enumValueRe restricts values to characters that need no quoting in SQL,
+// struct tags or generated identifiers.
+var enumValueRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// EnumTypeName returns the name of the type created for an enum column on
+// Postgres.
+func EnumTypeName(tableName, column string) string {
+	return tableName + "_" + column
+}
+
+// newEnumColumn builds an enum column accepting values.
+func newEnumColumn(name string, values []string) ColumnDefinition {
+	return ColumnDefinition{
+		Name:       name,
+		Type:       EnumType,
+		EnumValues: append([]string(nil), values...),
+	}
+}
+
+// ValidateEnumColumns returns an error for any enum column of table whose
+// values or default are invalid.
+func ValidateEnumColumns(table *Table) error {
+	for _, col := range table.Columns {
+		if err := ValidateEnumColumn(table.Name, &col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateEnumColumn returns an error when col is an enum column without
+// values, with a duplicate or malformed value, or with a default that is not
+// one of its values. Other columns pass.
+func ValidateEnumColumn(tableName string, col *ColumnDefinition) error {
+	if col.Type != EnumType {
+		return nil
+	}
+	if len(col.EnumValues) == 0 {
+		return fmt.Errorf("table %q: enum column %q has no values", tableName, col.Name)
+	}
+	for i, v := range col.EnumValues {
+		if !enumValueRe.MatchString(v) || len(v) > maxEnumValueLength {
+			return fmt.Errorf("table %q: enum column %q: value %q must be 1-%d letters, digits, '_', '.' or '-'", tableName, col.Name, v, maxEnumValueLength)
+		}
+		if slices.Contains(col.EnumValues[:i], v) {
+			return fmt.Errorf("table %q: enum column %q: duplicate value %q", tableName, col.Name, v)
+		}
+	}
+	if col.Default != nil && !slices.Contains(col.EnumValues, *col.Default) {
+		return fmt.Errorf("table %q: enum column %q: default %q is not one of its values", tableName, col.Name, *col.Default)
+	}
+	return nil
+}
+
+// EnumColumnBuilder configures an enum column.
+type EnumColumnBuilder struct {
+	tableBuilder *TableBuilder
+	col          *ColumnDefinition
+}
+
+// Enum adds a column holding one of values, e.g.
+// tb.Enum("status", "pending", "active", "archived").
+func (tb *TableBuilder) Enum(name string, values ...string) *EnumColumnBuilder {
+	tb.table.Columns = append(tb.table.Columns, newEnumColumn(name, values))
+	return &EnumColumnBuilder{
+		tableBuilder: tb,
+		col:          &tb.table.Columns[len(tb.table.Columns)-1],
+	}
+}
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *EnumColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.col.Name}
+}
+
+// Nullable marks the column as nullable.
+func (b *EnumColumnBuilder) Nullable() *EnumColumnBuilder {
+	b.col.Nullable = true
+	return b
+}
+
+// Unique adds a unique constraint to the column.
+func (b *EnumColumnBuilder) Unique() *EnumColumnBuilder {
+	b.col.Unique = true
+	b.tableBuilder.table.Indexes = append(b.tableBuilder.table.Indexes, IndexDefinition{
+		Name:    GenerateIndexName(b.tableBuilder.table.Name, []string{b.col.Name}),
+		Columns: []string{b.col.Name},
+		Unique:  true,
+	})
+	return b
+}
+
+// Indexed adds a non-unique index on this column.
+func (b *EnumColumnBuilder) Indexed() *EnumColumnBuilder {
+	b.col.Index = true
+	b.tableBuilder.table.Indexes = append(b.tableBuilder.table.Indexes, IndexDefinition{
+		Name:    GenerateIndexName(b.tableBuilder.table.Name, []string{b.col.Name}),
+		Columns: []string{b.col.Name},
+		Unique:  false,
+	})
+	return b
+}
+
+// Default sets the default value, which must be one of the column's values.
+func (b *EnumColumnBuilder) Default(v string) *EnumColumnBuilder {
+	b.col.Default = &v
+	return b
+}
+
+// AlterEnumColumnBuilder configures an enum column being added to an
+// existing table.
+type AlterEnumColumnBuilder struct {
+	alterBuilder *AlterTableBuilder
+	op           *TableOperation
+}
+
+// Enum adds an enum column. See TableBuilder.Enum.
+func (ab *AlterTableBuilder) Enum(name string, values ...string) *AlterEnumColumnBuilder {
+	col := newEnumColumn(name, values)
+	ab.operations = append(ab.operations, TableOperation{
+		Type:      OpAddColumn,
+		ColumnDef: &col,
+	})
+	return &AlterEnumColumnBuilder{
+		alterBuilder: ab,
+		op:           &ab.operations[len(ab.operations)-1],
+	}
+}
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *AlterEnumColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.op.ColumnDef.Name}
+}
+
+// Nullable marks the column as nullable.
+func (b *AlterEnumColumnBuilder) Nullable() *AlterEnumColumnBuilder {
+	b.op.ColumnDef.Nullable = true
+	return b
+}
+
+// Unique marks the column as unique and adds a unique index operation.
+func (b *AlterEnumColumnBuilder) Unique() *AlterEnumColumnBuilder {
+	b.op.ColumnDef.Unique = true
+	b.alterBuilder.operations = append(b.alterBuilder.operations, TableOperation{
+		Type: OpAddIndex,
+		IndexDef: &IndexDefinition{
+			Name:    GenerateIndexName(b.alterBuilder.tableName, []string{b.op.ColumnDef.Name}),
+			Columns: []string{b.op.ColumnDef.Name},
+			Unique:  true,
+		},
+	})
+	return b
+}
+
+// Indexed adds a non-unique index operation on this column.
+func (b *AlterEnumColumnBuilder) Indexed() *AlterEnumColumnBuilder {
+	b.op.ColumnDef.Index = true
+	b.alterBuilder.operations = append(b.alterBuilder.operations, TableOperation{
+		Type: OpAddIndex,
+		IndexDef: &IndexDefinition{
+			Name:    GenerateIndexName(b.alterBuilder.tableName, []string{b.op.ColumnDef.Name}),
+			Columns: []string{b.op.ColumnDef.Name},
+			Unique:  false,
+		},
+	})
+	return b
+}
+
+// Default sets the default value, which must be one of the column's values.
+func (b *AlterEnumColumnBuilder) Default(v string) *AlterEnumColumnBuilder {
+	b.op.ColumnDef.Default = &v
+	return b
+}
+
+// AddEnumValues adds an operation appending values to an enum column's list.
+func (ab *AlterTableBuilder) AddEnumValues(column string, values ...string) {
+	ab.operations = append(ab.operations, TableOperation{
+		Type:       OpAddEnumValues,
+		Column:     column,
+		EnumValues: append([]string(nil), values...),
+	})
+}
+
+// DropEnumValues adds an operation removing values from an enum column's
+// list. The migration is not applied while a row still holds one of them:
+// update or delete those rows in an earlier migration.
+func (ab *AlterTableBuilder) DropEnumValues(column string, values ...string) {
+	ab.operations = append(ab.operations, TableOperation{
+		Type:       OpDropEnumValues,
+		Column:     column,
+		EnumValues: append([]string(nil), values...),
+	})
+}
+
+// AddEnumValuesRef is AddEnumValues with a type-safe column reference.
+func (ab *AlterTableBuilder) AddEnumValuesRef(col ColumnRef, values ...string) {
+	ab.AddEnumValues(col.name, values...)
+}
+
+// DropEnumValuesRef is DropEnumValues with a type-safe column reference.
+func (ab *AlterTableBuilder) DropEnumValuesRef(col ColumnRef, values ...string) {
+	ab.DropEnumValues(col.name, values...)
+}
+
+// ApplyEnumValues returns col with op, an OpAddEnumValues or
+// OpDropEnumValues operation, applied. It returns an error when col is not
+// an enum column, when an added value is already present or a dropped one
+// is missing, or when the result fails ValidateEnumColumn.
+func ApplyEnumValues(tableName string, col ColumnDefinition, op *TableOperation) (ColumnDefinition, error) {
+	if col.Type != EnumType {
+		return col, fmt.Errorf("table %q: column %q is not an enum column", tableName, col.Name)
+	}
+	if len(op.EnumValues) == 0 {
+		return col, fmt.Errorf("table %q: enum column %q: no values given", tableName, col.Name)
+	}
+	values := slices.Clone(col.EnumValues)
+	for _, v := range op.EnumValues {
+		i := slices.Index(values, v)
+		switch {
+		case op.Type == OpAddEnumValues && i >= 0:
+			return col, fmt.Errorf("table %q: enum column %q already has value %q", tableName, col.Name, v)
+		case op.Type == OpAddEnumValues:
+			values = append(values, v)
+		case i < 0:
+			return col, fmt.Errorf("table %q: enum column %q has no value %q", tableName, col.Name, v)
+		default:
+			values = slices.Delete(values, i, i+1)
+		}
+	}
+	col.EnumValues = values
+	if err := ValidateEnumColumn(tableName, &col); err != nil {
+		return col, err
+	}
+	return col, nil
+}
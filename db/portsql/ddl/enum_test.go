@@ -0,0 +1,99 @@
+package ddl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTableBuilder_Enum(t *testing.T) {
+	tb := MakeEmptyTable("posts")
+	tb.Bigint("id").PrimaryKey()
+	tb.Enum("status", "pending", "active", "archived").Default("pending").Indexed()
+	table := tb.Build()
+
+	col := table.Columns[1]
+	if col.Type != EnumType || strings.Join(col.EnumValues, ",") != "pending,active,archived" {
+		t.Fatalf("unexpected column %+v", col)
+	}
+	if col.Default == nil || *col.Default != "pending" || !col.Index {
+		t.Errorf("default and index not set: %+v", col)
+	}
+	if err := ValidateEnumColumns(table); err != nil {
+		t.Errorf("ValidateEnumColumns() error = %v", err)
+	}
+	if got := EnumTypeName("posts", "status"); got != "posts_status" {
+		t.Errorf("EnumTypeName() = %q", got)
+	}
+}
+
+func TestValidateEnumColumn(t *testing.T) {
+	def := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		col     ColumnDefinition
+		wantErr string
+	}{
+		{"valid", newEnumColumn("status", []string{"in-progress", "done"}), ""},
+		{"not an enum", ColumnDefinition{Name: "title", Type: StringType}, ""},
+		{"no values", newEnumColumn("status", nil), "has no values"},
+		{"duplicate", newEnumColumn("status", []string{"a", "b", "a"}), `duplicate value "a"`},
+		{"quote", newEnumColumn("status", []string{"it's"}), "must be 1-63 letters"},
+		{"empty", newEnumColumn("status", []string{""}), "must be 1-63 letters"},
+		{"too long", newEnumColumn("status", []string{strings.Repeat("x", 64)}), "must be 1-63 letters"},
+		{"bad default", ColumnDefinition{Name: "status", Type: EnumType, EnumValues: []string{"a"}, Default: def("b")}, `default "b" is not one of its values`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEnumColumn("posts", &tt.col)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateEnumColumn() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateEnumColumn() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAlterTableBuilder_Enum(t *testing.T) {
+	ab := AlterTable("posts")
+	ab.Enum("visibility", "public", "private").Nullable().Unique()
+	ops := ab.Build()
+	if len(ops) != 2 || ops[0].Type != OpAddColumn || ops[1].Type != OpAddIndex {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+	if col := ops[0].ColumnDef; col.Type != EnumType || !col.Nullable || len(col.EnumValues) != 2 {
+		t.Errorf("unexpected column %+v", col)
+	}
+}
+
+func TestApplyEnumValues(t *testing.T) {
+	col := newEnumColumn("status", []string{"pending", "active"})
+
+	ab := AlterTable("posts")
+	ab.AddEnumValues("status", "archived")
+	ab.DropEnumValues("status", "pending")
+	ops := ab.Build()
+
+	added, err := ApplyEnumValues("posts", col, &ops[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(added.EnumValues, []string{"pending", "active", "archived"}) {
+		t.Errorf("after add: %v", added.EnumValues)
+	}
+	dropped, err := ApplyEnumValues("posts", added, &ops[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(dropped.EnumValues, []string{"active", "archived"}) {
+		t.Errorf("after drop: %v", dropped.EnumValues)
+	}
+	if !slices.Equal(col.EnumValues, []string{"pending", "active"}) {
+		t.Errorf("ApplyEnumValues modified its argument: %v", col.EnumValues)
+	}
+}
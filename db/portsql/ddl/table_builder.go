@@ -2,6 +2,7 @@ package ddl
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/shipq/shipq/db/portsql/ref"
 )
@@ -57,6 +58,16 @@ type JSONColumnBuilder struct {
 	col          *ColumnDefinition
 }
 
+type PointColumnBuilder struct {
+	tableBuilder *TableBuilder
+	col          *ColumnDefinition
+}
+
+type PolygonColumnBuilder struct {
+	tableBuilder *TableBuilder
+	col          *ColumnDefinition
+}
+
 type TextColumnBuilder struct {
 	tableBuilder *TableBuilder
 	col          *ColumnDefinition
@@ -159,6 +170,41 @@ func (tb *TableBuilder) JunctionTable() *TableBuilder {
 	return tb
 }
 
+// RetainFor sets the table's retention policy: rows whose created_at is
+// older than d are deleted by the generated Purge<Table> query, which the
+// worker runs daily. d is truncated to whole seconds.
+func (tb *TableBuilder) RetainFor(d time.Duration) *TableBuilder {
+	tb.table.RetentionSeconds = int64(d / time.Second)
+	return tb
+}
+
+// Label tags the table with one or more labels (e.g. "billing"), which
+// `shipq resource @<label>` and `shipq schema labels` use to select groups of
+// tables. Labels carry no SQL.
+func (tb *TableBuilder) Label(labels ...string) *TableBuilder {
+	tb.table.Labels = append(tb.table.Labels, labels...)
+	return tb
+}
+
+// PrimaryKey makes the specified columns the table's primary key, replacing
+// any column marked with .PrimaryKey(). Several columns form a composite key
+// (e.g. the two references of a junction table), which is emitted as a
+// table-level PRIMARY KEY constraint and keys the generated Get, Update and
+// Delete queries of tables without a public_id.
+func (tb *TableBuilder) PrimaryKey(cols ...ColumnRef) *TableBuilder {
+	for i := range tb.table.Columns {
+		tb.table.Columns[i].PrimaryKey = false
+	}
+	for _, c := range cols {
+		for i := range tb.table.Columns {
+			if tb.table.Columns[i].Name == c.name {
+				tb.table.Columns[i].PrimaryKey = true
+			}
+		}
+	}
+	return tb
+}
+
 // AddIndex adds a composite index on the specified columns.
 func (tb *TableBuilder) AddIndex(cols ...ColumnRef) *TableBuilder {
 	names := make([]string, len(cols))
@@ -187,6 +233,25 @@ func (tb *TableBuilder) AddUniqueIndex(cols ...ColumnRef) *TableBuilder {
 	return tb
 }
 
+// AddSoftUniqueIndex adds an index on the specified columns whose values must
+// be unique among rows that are not soft-deleted (deleted_at IS NULL), so a
+// deleted row doesn't block re-creating its values. MySQL has no partial
+// unique indexes, so on every dialect the index itself is a plain one and
+// the generated create and update handlers check for a live duplicate
+// (SELECT ... FOR UPDATE) in the same transaction as their write.
+func (tb *TableBuilder) AddSoftUniqueIndex(cols ...ColumnRef) *TableBuilder {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.name
+	}
+	tb.table.Indexes = append(tb.table.Indexes, IndexDefinition{
+		Name:       GenerateIndexName(tb.table.Name, names),
+		Columns:    names,
+		SoftUnique: true,
+	})
+	return tb
+}
+
 // --- Column Type Methods on TableBuilder ---
 
 // Integer adds an integer column.
@@ -364,6 +429,24 @@ func (tb *TableBuilder) Timestamp(name string) *TimeColumnBuilder {
 	}
 }
 
+// Timestamptz adds a time-zone-aware timestamp column. Values are
+// normalized to UTC on write and read back as UTC time.Time values.
+func (tb *TableBuilder) Timestamptz(name string) *TimeColumnBuilder {
+	col := ColumnDefinition{
+		Name:       name,
+		Type:       TimestamptzType,
+		Nullable:   false,
+		Unique:     false,
+		PrimaryKey: false,
+		Index:      false,
+	}
+	tb.table.Columns = append(tb.table.Columns, col)
+	return &TimeColumnBuilder{
+		tableBuilder: tb,
+		col:          &tb.table.Columns[len(tb.table.Columns)-1],
+	}
+}
+
 // Binary adds a binary/blob column.
 func (tb *TableBuilder) Binary(name string) *BinaryColumnBuilder {
 	col := ColumnDefinition{
@@ -398,6 +481,46 @@ func (tb *TableBuilder) JSON(name string) *JSONColumnBuilder {
 	}
 }
 
+// Point adds a geographic point column (WGS 84 longitude/latitude). It maps to
+// a PostGIS geography, a MySQL POINT with SRID 4326, and TEXT backed by an
+// R*Tree index on SQLite. Values are WKT strings such as "POINT(-122.4 37.8)".
+func (tb *TableBuilder) Point(name string) *PointColumnBuilder {
+	col := ColumnDefinition{
+		Name:       name,
+		Type:       PointType,
+		Nullable:   false,
+		Unique:     false,
+		PrimaryKey: false,
+		Index:      false,
+	}
+	tb.table.Columns = append(tb.table.Columns, col)
+	return &PointColumnBuilder{
+		tableBuilder: tb,
+		col:          &tb.table.Columns[len(tb.table.Columns)-1],
+	}
+}
+
+// Polygon adds a geographic polygon column (WGS 84 longitude/latitude), for
+// areas such as delivery zones. It maps like Point: a PostGIS geography, a
+// MySQL POLYGON with SRID 4326, and TEXT backed by an R*Tree index of its
+// bounding box on SQLite. Values are single-ring WKT strings such as
+// "POLYGON((-122.5 37.7, -122.3 37.7, -122.3 37.9, -122.5 37.7))".
+func (tb *TableBuilder) Polygon(name string) *PolygonColumnBuilder {
+	col := ColumnDefinition{
+		Name:       name,
+		Type:       PolygonType,
+		Nullable:   false,
+		Unique:     false,
+		PrimaryKey: false,
+		Index:      false,
+	}
+	tb.table.Columns = append(tb.table.Columns, col)
+	return &PolygonColumnBuilder{
+		tableBuilder: tb,
+		col:          &tb.table.Columns[len(tb.table.Columns)-1],
+	}
+}
+
 // --- IntColumnBuilder Methods ---
 
 // Col returns a type-safe column reference for use in index definitions.
@@ -654,6 +777,38 @@ func (b *JSONColumnBuilder) Nullable() *JSONColumnBuilder {
 // Note: JSON columns cannot have DEFAULT values in MySQL.
 // For cross-database compatibility, Default() is intentionally not provided.
 
+// --- PointColumnBuilder Methods ---
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *PointColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.col.Name}
+}
+
+// Nullable marks the column as nullable.
+// Note: MySQL only supports spatial indexes on NOT NULL columns, so nullable
+// point columns are not indexed there.
+func (b *PointColumnBuilder) Nullable() *PointColumnBuilder {
+	b.col.Nullable = true
+	return b
+}
+
+// Note: point columns always get a spatial index, so Indexed() is not
+// provided, and Unique()/Default() have no portable meaning for geometries.
+
+// --- PolygonColumnBuilder Methods ---
+
+// Col returns a type-safe column reference for use in index definitions.
+func (b *PolygonColumnBuilder) Col() ColumnRef {
+	return ColumnRef{name: b.col.Name}
+}
+
+// Nullable marks the column as nullable. As with points, MySQL leaves
+// nullable polygon columns unindexed.
+func (b *PolygonColumnBuilder) Nullable() *PolygonColumnBuilder {
+	b.col.Nullable = true
+	return b
+}
+
 // --- TextColumnBuilder Methods ---
 
 // Col returns a type-safe column reference for use in index definitions.
@@ -55,6 +55,32 @@ func TestColumnTypes(t *testing.T) {
 			wantPrec:    nil,
 			wantScale:   nil,
 		},
+		{
+			name: "Point sets point type",
+			buildTable: func() *Table {
+				tb := MakeEmptyTable("test")
+				tb.Point("location")
+				return tb.Build()
+			},
+			wantType:    PointType,
+			wantColName: "location",
+			wantLength:  nil,
+			wantPrec:    nil,
+			wantScale:   nil,
+		},
+		{
+			name: "Polygon sets polygon type",
+			buildTable: func() *Table {
+				tb := MakeEmptyTable("test")
+				tb.Polygon("zone")
+				return tb.Build()
+			},
+			wantType:    PolygonType,
+			wantColName: "zone",
+			wantLength:  nil,
+			wantPrec:    nil,
+			wantScale:   nil,
+		},
 		{
 			name: "Bool sets boolean type",
 			buildTable: func() *Table {
@@ -638,6 +664,23 @@ func TestMakeEmptyTable(t *testing.T) {
 	}
 }
 
+func TestTableBuilderPrimaryKey(t *testing.T) {
+	tb := MakeEmptyTable("user_roles")
+	tb.Bigint("id").PrimaryKey()
+	userID := tb.Bigint("user_id").Col()
+	roleID := tb.Bigint("role_id").Col()
+	tb.PrimaryKey(userID, roleID)
+	table := tb.Build()
+
+	got := table.PrimaryKeyColumns()
+	if len(got) != 2 || got[0] != "user_id" || got[1] != "role_id" {
+		t.Errorf("PrimaryKeyColumns = %v, want [user_id role_id]", got)
+	}
+	if table.Columns[0].PrimaryKey {
+		t.Error("PrimaryKey should replace the previously marked key column")
+	}
+}
+
 // --- Index Tests ---
 
 func TestSingleColumnIndex(t *testing.T) {
@@ -953,3 +996,45 @@ func TestTableBuilder_JunctionTable_Chaining(t *testing.T) {
 		t.Errorf("expected 2 columns, got %d", len(table.Columns))
 	}
 }
+
+func TestColumnBuilder_Roles(t *testing.T) {
+	tb := MakeEmptyTable("employees")
+	roles := []string{"hr", "admin"}
+	tb.Bigint("salary").ReadRoles(roles...).WriteRoles("hr")
+	tb.String("name")
+	table := tb.Build()
+
+	// The builder must not alias the caller's slice.
+	roles[0] = "changed"
+
+	salary := table.Columns[0]
+	if len(salary.ReadRoles) != 2 || salary.ReadRoles[0] != "hr" || salary.ReadRoles[1] != "admin" {
+		t.Errorf("ReadRoles = %v, want [hr admin]", salary.ReadRoles)
+	}
+	if len(salary.WriteRoles) != 1 || salary.WriteRoles[0] != "hr" {
+		t.Errorf("WriteRoles = %v, want [hr]", salary.WriteRoles)
+	}
+	if name := table.Columns[1]; name.ReadRoles != nil || name.WriteRoles != nil {
+		t.Errorf("unrestricted column should have no roles, got %v / %v", name.ReadRoles, name.WriteRoles)
+	}
+}
+
+func TestColumnBuilder_Sensitive(t *testing.T) {
+	tb := MakeEmptyTable("patients")
+	tb.String("ssn").Sensitive()
+	tb.String("name")
+	table := tb.Build()
+
+	if !table.Columns[0].Sensitive {
+		t.Error("ssn should be sensitive")
+	}
+	if table.Columns[1].Sensitive {
+		t.Error("name should not be sensitive")
+	}
+
+	alt := AlterTable("patients")
+	alt.Text("notes").Nullable().Sensitive()
+	if ops := alt.Build(); !ops[0].ColumnDef.Sensitive {
+		t.Error("altered notes column should be sensitive")
+	}
+}
@@ -2,7 +2,10 @@ package ddl
 
 import (
 	"encoding/json"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 )
 
 // Type constants for ActiveRecord-style column types
@@ -16,10 +19,29 @@ const (
 	TextType      = "text"
 	DatetimeType  = "datetime"
 	TimestampType = "timestamp"
-	BinaryType    = "binary"
-	JSONType      = "json"
+	// TimestamptzType is an instant stored and returned in UTC on every
+	// dialect, unlike datetime and timestamp whose MySQL/SQLite storage
+	// follows the connection's time zone.
+	TimestamptzType = "timestamptz"
+	BinaryType      = "binary"
+	JSONType        = "json"
+	PointType       = "point"
+	PolygonType     = "polygon"
+	// EnumType is a string restricted to the column's EnumValues.
+	EnumType = "enum"
 )
 
+// IsTimeType reports whether colType holds a date and time.
+func IsTimeType(colType string) bool {
+	return colType == DatetimeType || colType == TimestampType || colType == TimestamptzType
+}
+
+// IsSpatialType reports whether colType holds geography (points or
+// polygons), which is read and written as WKT text.
+func IsSpatialType(colType string) bool {
+	return colType == PointType || colType == PolygonType
+}
+
 // ColumnDefinition represents a column in a database table.
 type ColumnDefinition struct {
 	Name       string  `json:"name"`
@@ -34,6 +56,28 @@ type ColumnDefinition struct {
 	Index      bool    `json:"index"`
 	ForeignKey string  `json:"foreign_key"`
 	References string  `json:"references,omitempty"` // Target table name for automatic relations (no actual FK)
+
+	// ReadRoles and WriteRoles restrict the column in generated CRUD handlers
+	// to callers holding at least one of the listed role names. Empty means
+	// unrestricted. They have no effect on the database schema.
+	ReadRoles  []string `json:"read_roles,omitempty"`
+	WriteRoles []string `json:"write_roles,omitempty"`
+
+	// Sensitive marks a column whose values the generated API docs mask in
+	// their "try it" responses. It has no effect on the database schema.
+	Sensitive bool `json:"sensitive,omitempty"`
+
+	// Custom is set for columns of a type registered with RegisterType, whose
+	// name is then the column's Type.
+	Custom *CustomType `json:"custom,omitempty"`
+
+	// EnumValues are the values an enum column accepts, in declaration
+	// order.
+	EnumValues []string `json:"enum_values,omitempty"`
+
+	// Check is a SQL boolean expression every value of the column must
+	// satisfy, emitted as a CHECK constraint on the column.
+	Check string `json:"check,omitempty"`
 }
 
 // IndexDefinition represents an index on a database table.
@@ -41,6 +85,17 @@ type IndexDefinition struct {
 	Name    string   `json:"name"`
 	Columns []string `json:"columns"`
 	Unique  bool     `json:"unique"`
+	// SoftUnique marks a plain index whose columns must be unique among rows
+	// that are not soft-deleted. The database does not enforce it; the
+	// generated create and update handlers check it in their transaction.
+	SoftUnique bool `json:"soft_unique,omitempty"`
+}
+
+// CheckDefinition represents a named table-level CHECK constraint.
+// Expression is raw SQL and must be valid on every dialect the app targets.
+type CheckDefinition struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
 }
 
 // Table represents a database table with its columns and indexes.
@@ -49,6 +104,49 @@ type Table struct {
 	Columns         []ColumnDefinition `json:"columns"`
 	Indexes         []IndexDefinition  `json:"indexes"`
 	IsJunctionTable bool               `json:"is_junction_table,omitempty"` // True for many-to-many junction tables
+	// RetentionSeconds is how long rows are kept, measured from created_at.
+	// Zero means rows are kept forever.
+	RetentionSeconds int64 `json:"retention_seconds,omitempty"`
+	// Labels group tables for code generation and governance tooling
+	// (e.g. "billing", "internal"). They are sorted and never emit SQL.
+	Labels []string `json:"labels,omitempty"`
+	// Checks are the table's CHECK constraints declared with AddCheck.
+	// Column checks live on their ColumnDefinition.
+	Checks []CheckDefinition `json:"checks,omitempty"`
+}
+
+// Retention returns how long rows of the table are kept, or zero when the
+// table has no retention policy.
+func (t *Table) Retention() time.Duration {
+	return time.Duration(t.RetentionSeconds) * time.Second
+}
+
+// HasLabel reports whether the table carries label.
+func (t *Table) HasLabel(label string) bool {
+	return slices.Contains(t.Labels, label)
+}
+
+// PrimaryKeyColumns returns the names of the table's primary key columns in
+// column order. More than one means a composite key.
+func (t *Table) PrimaryKeyColumns() []string {
+	var names []string
+	for _, col := range t.Columns {
+		if col.PrimaryKey {
+			names = append(names, col.Name)
+		}
+	}
+	return names
+}
+
+// SoftUniqueIndexes returns the indexes declared with AddSoftUniqueIndex.
+func (t *Table) SoftUniqueIndexes() []IndexDefinition {
+	var indexes []IndexDefinition
+	for _, idx := range t.Indexes {
+		if idx.SoftUnique {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
 }
 
 // Serialize serializes the table to a JSON string.
@@ -70,6 +168,51 @@ func GenerateIndexName(tableName string, columns []string) string {
 	return "idx_" + tableName + "_" + strings.Join(columns, "_")
 }
 
+// SpatialIndexName returns the name of the spatial index created for a point
+// or polygon column (a GiST index on Postgres, a SPATIAL index on MySQL).
+func SpatialIndexName(tableName, column string) string {
+	return "idx_" + tableName + "_" + column + "_spatial"
+}
+
+// RTreeTableName returns the name of the R*Tree virtual table that indexes a
+// point or polygon column on SQLite.
+func RTreeTableName(tableName, column string) string {
+	return tableName + "_" + column + "_rtree"
+}
+
+// SQLitePointLng and SQLitePointLat return SQL expressions extracting the
+// coordinates of a WKT "POINT(lng lat)" value, which is how SQLite stores
+// point columns.
+func SQLitePointLng(expr string) string {
+	return fmt.Sprintf("CAST(substr(%[1]s, 7, instr(%[1]s, ' ') - 7) AS REAL)", expr)
+}
+
+func SQLitePointLat(expr string) string {
+	return fmt.Sprintf("CAST(substr(%[1]s, instr(%[1]s, ' ') + 1, length(%[1]s) - instr(%[1]s, ' ') - 1) AS REAL)", expr)
+}
+
+// SQLitePolygonVertices returns a SQL expression turning a WKT
+// "POLYGON((lng lat, ...))" value into a JSON array of [lng, lat] pairs, so
+// that json_each can walk its ring. Only the outer ring is supported.
+func SQLitePolygonVertices(expr string) string {
+	return fmt.Sprintf("('[[' || replace(replace(replace(substr(%[1]s, 10, length(%[1]s) - 11), ', ', ','), ',', '],['), ' ', ',') || ']]')", expr)
+}
+
+// SQLiteSpatialBounds returns SQL expressions for the bounding box of a
+// spatial column value stored as WKT text, in the column order of its
+// R*Tree: min_lng, max_lng, min_lat, max_lat.
+func SQLiteSpatialBounds(colType, expr string) [4]string {
+	if colType != PolygonType {
+		lng, lat := SQLitePointLng(expr), SQLitePointLat(expr)
+		return [4]string{lng, lng, lat, lat}
+	}
+	vertices := SQLitePolygonVertices(expr)
+	bound := func(agg, axis string) string {
+		return fmt.Sprintf("(SELECT %s(json_extract(value, '$[%s]')) FROM json_each(%s))", agg, axis, vertices)
+	}
+	return [4]string{bound("min", "0"), bound("max", "0"), bound("min", "1"), bound("max", "1")}
+}
+
 // --- Operation Types for ALTER TABLE ---
 
 // OperationType represents the type of table alteration operation.
@@ -85,6 +228,10 @@ const (
 	OpAddIndex       OperationType = "add_index"
 	OpDropIndex      OperationType = "drop_index"
 	OpRenameIndex    OperationType = "rename_index"
+	OpAddCheck       OperationType = "add_check"
+	OpDropCheck      OperationType = "drop_check"
+	OpAddEnumValues  OperationType = "add_enum_values"
+	OpDropEnumValues OperationType = "drop_enum_values"
 )
 
 // TableOperation represents a single alteration operation on a table.
@@ -98,4 +245,10 @@ type TableOperation struct {
 	NewType   string            `json:"new_type,omitempty"`
 	Nullable  *bool             `json:"nullable,omitempty"`
 	Default   *string           `json:"default,omitempty"`
+	CheckDef  *CheckDefinition  `json:"check_def,omitempty"`
+	CheckName string            `json:"check_name,omitempty"`
+	// EnumValues are the values added or dropped by OpAddEnumValues and
+	// OpDropEnumValues. The migration planner sets ColumnDef to the column
+	// as it is after the operation.
+	EnumValues []string `json:"enum_values,omitempty"`
 }
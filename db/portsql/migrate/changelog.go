@@ -0,0 +1,268 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// SchemaDiff is the difference between two migration plans, as reported by
+// `shipq schema changelog`. Its JSON form is the machine-readable changelog.
+type SchemaDiff struct {
+	Migrations    []string    `json:"migrations"`
+	TablesAdded   []string    `json:"tables_added"`
+	TablesDropped []string    `json:"tables_dropped"`
+	TablesChanged []TableDiff `json:"tables_changed"`
+}
+
+// TableDiff lists the changes to a table present in both plans.
+type TableDiff struct {
+	Table          string         `json:"table"`
+	ColumnsAdded   []string       `json:"columns_added,omitempty"`
+	ColumnsDropped []string       `json:"columns_dropped,omitempty"`
+	ColumnsChanged []ColumnChange `json:"columns_changed,omitempty"`
+	IndexesAdded   []string       `json:"indexes_added,omitempty"`
+	IndexesDropped []string       `json:"indexes_dropped,omitempty"`
+	LabelsAdded    []string       `json:"labels_added,omitempty"`
+	LabelsRemoved  []string       `json:"labels_removed,omitempty"`
+}
+
+// ColumnChange describes how one attribute of a column changed, e.g.
+// {Column: "title", Attribute: "type", From: "string", To: "text"}.
+type ColumnChange struct {
+	Column    string `json:"column"`
+	Attribute string `json:"attribute"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// DiffPlans compares the schemas of two migration plans. Migrations lists
+// the migrations in to that from does not contain, in plan order. Either
+// plan may be nil, which is treated as an empty plan.
+func DiffPlans(from, to *MigrationPlan) *SchemaDiff {
+	if from == nil {
+		from = &MigrationPlan{}
+	}
+	if to == nil {
+		to = &MigrationPlan{}
+	}
+
+	diff := &SchemaDiff{
+		Migrations:    []string{},
+		TablesAdded:   []string{},
+		TablesDropped: []string{},
+		TablesChanged: []TableDiff{},
+	}
+
+	seen := make(map[string]bool, len(from.Migrations))
+	for _, m := range from.Migrations {
+		seen[m.Name] = true
+	}
+	for _, m := range to.Migrations {
+		if !seen[m.Name] {
+			diff.Migrations = append(diff.Migrations, m.Name)
+		}
+	}
+
+	for _, name := range sortedTableNames(to.Schema.Tables) {
+		oldTable, ok := from.Schema.Tables[name]
+		if !ok {
+			diff.TablesAdded = append(diff.TablesAdded, name)
+			continue
+		}
+		if td := diffTable(name, oldTable, to.Schema.Tables[name]); td != nil {
+			diff.TablesChanged = append(diff.TablesChanged, *td)
+		}
+	}
+	for _, name := range sortedTableNames(from.Schema.Tables) {
+		if _, ok := to.Schema.Tables[name]; !ok {
+			diff.TablesDropped = append(diff.TablesDropped, name)
+		}
+	}
+
+	return diff
+}
+
+// Empty reports whether the diff contains no schema changes and no new
+// migrations.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Migrations) == 0 && len(d.TablesAdded) == 0 &&
+		len(d.TablesDropped) == 0 && len(d.TablesChanged) == 0
+}
+
+// Markdown renders the diff as release-note Markdown.
+func (d *SchemaDiff) Markdown() string {
+	var b strings.Builder
+	b.WriteString("## Schema changes\n\n")
+	if d.Empty() {
+		b.WriteString("No schema changes.\n")
+		return b.String()
+	}
+
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "### %s\n\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&b, "- `%s`\n", item)
+		}
+		b.WriteString("\n")
+	}
+	writeList("Tables added", d.TablesAdded)
+	writeList("Tables dropped", d.TablesDropped)
+
+	if len(d.TablesChanged) > 0 {
+		b.WriteString("### Tables changed\n\n")
+		for _, td := range d.TablesChanged {
+			fmt.Fprintf(&b, "#### `%s`\n\n", td.Table)
+			for _, c := range td.ColumnsAdded {
+				fmt.Fprintf(&b, "- Added column `%s`\n", c)
+			}
+			for _, c := range td.ColumnsDropped {
+				fmt.Fprintf(&b, "- Dropped column `%s`\n", c)
+			}
+			for _, c := range td.ColumnsChanged {
+				fmt.Fprintf(&b, "- Column `%s`: %s changed from %s to %s\n", c.Column, c.Attribute, c.From, c.To)
+			}
+			for _, idx := range td.IndexesAdded {
+				fmt.Fprintf(&b, "- Added index `%s`\n", idx)
+			}
+			for _, idx := range td.IndexesDropped {
+				fmt.Fprintf(&b, "- Dropped index `%s`\n", idx)
+			}
+			for _, l := range td.LabelsAdded {
+				fmt.Fprintf(&b, "- Added label `%s`\n", l)
+			}
+			for _, l := range td.LabelsRemoved {
+				fmt.Fprintf(&b, "- Removed label `%s`\n", l)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	writeList("Migrations", d.Migrations)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// diffTable returns the changes between two versions of a table, or nil
+// when there are none.
+func diffTable(name string, from, to ddl.Table) *TableDiff {
+	td := TableDiff{Table: name}
+
+	oldCols := make(map[string]ddl.ColumnDefinition, len(from.Columns))
+	for _, c := range from.Columns {
+		oldCols[c.Name] = c
+	}
+	newCols := make(map[string]bool, len(to.Columns))
+	for _, c := range to.Columns {
+		newCols[c.Name] = true
+		old, ok := oldCols[c.Name]
+		if !ok {
+			td.ColumnsAdded = append(td.ColumnsAdded, c.Name)
+			continue
+		}
+		td.ColumnsChanged = append(td.ColumnsChanged, diffColumn(old, c)...)
+	}
+	for _, c := range from.Columns {
+		if !newCols[c.Name] {
+			td.ColumnsDropped = append(td.ColumnsDropped, c.Name)
+		}
+	}
+
+	oldIdx := make(map[string]bool, len(from.Indexes))
+	for _, idx := range from.Indexes {
+		oldIdx[idx.Name] = true
+	}
+	newIdx := make(map[string]bool, len(to.Indexes))
+	for _, idx := range to.Indexes {
+		newIdx[idx.Name] = true
+		if !oldIdx[idx.Name] {
+			td.IndexesAdded = append(td.IndexesAdded, idx.Name)
+		}
+	}
+	for _, idx := range from.Indexes {
+		if !newIdx[idx.Name] {
+			td.IndexesDropped = append(td.IndexesDropped, idx.Name)
+		}
+	}
+
+	for _, l := range to.Labels {
+		if !from.HasLabel(l) {
+			td.LabelsAdded = append(td.LabelsAdded, l)
+		}
+	}
+	for _, l := range from.Labels {
+		if !to.HasLabel(l) {
+			td.LabelsRemoved = append(td.LabelsRemoved, l)
+		}
+	}
+
+	if len(td.ColumnsAdded) == 0 && len(td.ColumnsDropped) == 0 && len(td.ColumnsChanged) == 0 &&
+		len(td.IndexesAdded) == 0 && len(td.IndexesDropped) == 0 &&
+		len(td.LabelsAdded) == 0 && len(td.LabelsRemoved) == 0 {
+		return nil
+	}
+	return &td
+}
+
+// diffColumn compares the schema-affecting attributes of a column.
+// ReadRoles and WriteRoles are left out: they only shape generated handlers.
+func diffColumn(from, to ddl.ColumnDefinition) []ColumnChange {
+	var changes []ColumnChange
+	add := func(attr, a, b string) {
+		if a != b {
+			changes = append(changes, ColumnChange{Column: to.Name, Attribute: attr, From: a, To: b})
+		}
+	}
+	add("type", columnTypeString(from), columnTypeString(to))
+	add("nullable", fmt.Sprint(from.Nullable), fmt.Sprint(to.Nullable))
+	add("default", defaultString(from.Default), defaultString(to.Default))
+	add("unique", fmt.Sprint(from.Unique), fmt.Sprint(to.Unique))
+	add("primary_key", fmt.Sprint(from.PrimaryKey), fmt.Sprint(to.PrimaryKey))
+	add("index", fmt.Sprint(from.Index), fmt.Sprint(to.Index))
+	add("references", refString(from), refString(to))
+	return changes
+}
+
+// columnTypeString renders a column type with its length or precision,
+// e.g. "string(255)" or "decimal(10,2)".
+func columnTypeString(c ddl.ColumnDefinition) string {
+	switch {
+	case c.Precision != nil && c.Scale != nil:
+		return fmt.Sprintf("%s(%d,%d)", c.Type, *c.Precision, *c.Scale)
+	case c.Precision != nil:
+		return fmt.Sprintf("%s(%d)", c.Type, *c.Precision)
+	case c.Length != nil:
+		return fmt.Sprintf("%s(%d)", c.Type, *c.Length)
+	}
+	return c.Type
+}
+
+func defaultString(d *string) string {
+	if d == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%q", *d)
+}
+
+func refString(c ddl.ColumnDefinition) string {
+	switch {
+	case c.References != "":
+		return c.References
+	case c.ForeignKey != "":
+		return c.ForeignKey
+	}
+	return "none"
+}
+
+func sortedTableNames(tables map[string]ddl.Table) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,129 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func changelogPlan(tables ...ddl.Table) *MigrationPlan {
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{}}}
+	for _, t := range tables {
+		plan.Schema.Tables[t.Name] = t
+		plan.Migrations = append(plan.Migrations, Migration{Name: "create_" + t.Name})
+	}
+	return plan
+}
+
+func TestDiffPlans(t *testing.T) {
+	length := 255
+	from := changelogPlan(
+		ddl.Table{Name: "posts", Columns: []ddl.ColumnDefinition{
+			{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "title", Type: ddl.StringType, Length: &length},
+			{Name: "legacy", Type: ddl.BooleanType},
+		}},
+		ddl.Table{Name: "sessions", Columns: []ddl.ColumnDefinition{{Name: "id", Type: ddl.BigintType}}},
+	)
+	to := changelogPlan(
+		ddl.Table{Name: "posts", Columns: []ddl.ColumnDefinition{
+			{Name: "id", Type: ddl.BigintType, PrimaryKey: true},
+			{Name: "title", Type: ddl.TextType, Nullable: true},
+			{Name: "author_id", Type: ddl.BigintType, References: "users"},
+		}, Indexes: []ddl.IndexDefinition{{Name: "idx_posts_author_id", Columns: []string{"author_id"}}}},
+		ddl.Table{Name: "users", Columns: []ddl.ColumnDefinition{{Name: "id", Type: ddl.BigintType}}},
+	)
+	to.Migrations = append(from.Migrations[:1:1], Migration{Name: "alter_posts"}, Migration{Name: "create_users"})
+
+	diff := DiffPlans(from, to)
+
+	if got := strings.Join(diff.TablesAdded, ","); got != "users" {
+		t.Errorf("TablesAdded = %q", got)
+	}
+	if got := strings.Join(diff.TablesDropped, ","); got != "sessions" {
+		t.Errorf("TablesDropped = %q", got)
+	}
+	if got := strings.Join(diff.Migrations, ","); got != "alter_posts,create_users" {
+		t.Errorf("Migrations = %q", got)
+	}
+	if len(diff.TablesChanged) != 1 {
+		t.Fatalf("TablesChanged = %+v", diff.TablesChanged)
+	}
+	td := diff.TablesChanged[0]
+	if strings.Join(td.ColumnsAdded, ",") != "author_id" || strings.Join(td.ColumnsDropped, ",") != "legacy" {
+		t.Errorf("columns added/dropped = %v / %v", td.ColumnsAdded, td.ColumnsDropped)
+	}
+	if strings.Join(td.IndexesAdded, ",") != "idx_posts_author_id" {
+		t.Errorf("IndexesAdded = %v", td.IndexesAdded)
+	}
+	want := []ColumnChange{
+		{Column: "title", Attribute: "type", From: "string(255)", To: "text"},
+		{Column: "title", Attribute: "nullable", From: "false", To: "true"},
+	}
+	if len(td.ColumnsChanged) != len(want) {
+		t.Fatalf("ColumnsChanged = %+v", td.ColumnsChanged)
+	}
+	for i := range want {
+		if td.ColumnsChanged[i] != want[i] {
+			t.Errorf("ColumnsChanged[%d] = %+v, want %+v", i, td.ColumnsChanged[i], want[i])
+		}
+	}
+
+	md := diff.Markdown()
+	for _, s := range []string{
+		"### Tables added\n\n- `users`",
+		"### Tables dropped\n\n- `sessions`",
+		"#### `posts`",
+		"- Added column `author_id`",
+		"- Dropped column `legacy`",
+		"- Column `title`: type changed from string(255) to text",
+		"- Added index `idx_posts_author_id`",
+		"- `alter_posts`",
+	} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Markdown missing %q:\n%s", s, md)
+		}
+	}
+}
+
+func TestDiffPlans_NoChanges(t *testing.T) {
+	plan := changelogPlan(ddl.Table{Name: "users", Columns: []ddl.ColumnDefinition{{Name: "id", Type: ddl.BigintType}}})
+
+	diff := DiffPlans(plan, plan)
+	if !diff.Empty() {
+		t.Errorf("expected empty diff, got %+v", diff)
+	}
+	if got := diff.Markdown(); got != "## Schema changes\n\nNo schema changes.\n" {
+		t.Errorf("Markdown() = %q", got)
+	}
+}
+
+func TestDiffPlans_NilFromListsEveryTable(t *testing.T) {
+	to := changelogPlan(
+		ddl.Table{Name: "b"},
+		ddl.Table{Name: "a"},
+	)
+	diff := DiffPlans(nil, to)
+	if got := strings.Join(diff.TablesAdded, ","); got != "a,b" {
+		t.Errorf("TablesAdded = %q", got)
+	}
+}
+
+func TestDiffPlans_Labels(t *testing.T) {
+	from := changelogPlan(ddl.Table{Name: "invoices", Labels: []string{"internal"}})
+	to := changelogPlan(ddl.Table{Name: "invoices", Labels: []string{"billing"}})
+
+	diff := DiffPlans(from, to)
+	if len(diff.TablesChanged) != 1 {
+		t.Fatalf("TablesChanged = %+v, want invoices", diff.TablesChanged)
+	}
+	td := diff.TablesChanged[0]
+	if strings.Join(td.LabelsAdded, ",") != "billing" || strings.Join(td.LabelsRemoved, ",") != "internal" {
+		t.Errorf("labels added %v, removed %v", td.LabelsAdded, td.LabelsRemoved)
+	}
+	want := "## Schema changes\n\n### Tables changed\n\n#### `invoices`\n\n- Added label `billing`\n- Removed label `internal`\n"
+	if md := diff.Markdown(); md != want {
+		t.Errorf("Markdown() =\n%s\nwant:\n%s", md, want)
+	}
+}
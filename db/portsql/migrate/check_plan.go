@@ -0,0 +1,60 @@
+package migrate
+
+// check_plan.go - CHECK Constraint SQL
+//
+// A column's check is emitted inline with the column, unnamed except on SQL
+// Server, which must drop it by name before it can drop or alter the
+// column. Table checks are named table constraints, added and dropped with
+// ALTER TABLE; SQLite can do neither, so it rebuilds the table.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// columnCheck returns the inline CHECK constraint of a column.
+func columnCheck(col *ddl.ColumnDefinition) string {
+	return fmt.Sprintf("CHECK (%s)", col.Check)
+}
+
+// tableChecks returns the table constraints of checks, each preceded by a
+// comma, with names quoted between open and close.
+func tableChecks(checks []ddl.CheckDefinition, open, close string) string {
+	var sb strings.Builder
+	for _, c := range checks {
+		sb.WriteString(fmt.Sprintf(", CONSTRAINT %s%s%s CHECK (%s)", open, c.Name, close, c.Expression))
+	}
+	return sb.String()
+}
+
+// mssqlColumnCheckConstraint names the CHECK constraint of a column's
+// check. CK_<table>_<column> is taken by enum columns.
+func mssqlColumnCheckConstraint(tableName, column string) string {
+	return fmt.Sprintf("CHK_%s_%s", tableName, column)
+}
+
+// mssqlColumnCheck returns the named CHECK constraint of a column's check.
+func mssqlColumnCheck(tableName string, col *ddl.ColumnDefinition) string {
+	return fmt.Sprintf("CONSTRAINT [%s] CHECK (%s)", mssqlColumnCheckConstraint(tableName, col.Name), col.Check)
+}
+
+// dropMSSQLColumnCheck drops a column's check constraint if it has one.
+func dropMSSQLColumnCheck(tableName, column string) string {
+	name := mssqlColumnCheckConstraint(tableName, column)
+	return fmt.Sprintf("IF OBJECT_ID('%s', 'C') IS NOT NULL ALTER TABLE [%s] DROP CONSTRAINT [%s]",
+		escapeMSSQLString(name), tableName, name)
+}
+
+// alterMSSQLColumnWithCheck wraps alterMSSQLColumn, which SQL Server
+// refuses while a CHECK constraint uses the column, in dropping and
+// re-adding the column's check.
+func alterMSSQLColumnWithCheck(tableName string, col *ddl.ColumnDefinition) string {
+	stmt := alterMSSQLColumn(tableName, col)
+	if col.Check == "" {
+		return stmt
+	}
+	return dropMSSQLColumnCheck(tableName, col.Name) + ";\n" + stmt + ";\n" +
+		fmt.Sprintf("ALTER TABLE [%s] ADD %s", tableName, mssqlColumnCheck(tableName, col))
+}
@@ -0,0 +1,185 @@
+package migrate
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func bookingsTable() *ddl.Table {
+	tb := ddl.MakeEmptyTable("bookings")
+	tb.Bigint("id").PrimaryKey()
+	tb.Integer("guests").Check("guests > 0")
+	tb.Integer("nights")
+	tb.AddCheck("chk_bookings_nights", "nights BETWEEN 1 AND 30")
+	return tb.Build()
+}
+
+func TestCreateTable_Checks(t *testing.T) {
+	table := bookingsTable()
+	for dialect, tc := range map[string]struct {
+		sql  string
+		want []string
+	}{
+		"postgres": {generatePostgresCreateTable(table), []string{
+			`"guests" INTEGER NOT NULL CHECK (guests > 0)`,
+			`, CONSTRAINT "chk_bookings_nights" CHECK (nights BETWEEN 1 AND 30))`,
+		}},
+		"mysql": {generateMySQLCreateTable(table), []string{
+			"`guests` INT NOT NULL CHECK (guests > 0)",
+			", CONSTRAINT `chk_bookings_nights` CHECK (nights BETWEEN 1 AND 30))",
+		}},
+		"sqlite": {generateSQLiteCreateTable(table), []string{
+			`"guests" INTEGER NOT NULL CHECK (guests > 0)`,
+			`, CONSTRAINT "chk_bookings_nights" CHECK (nights BETWEEN 1 AND 30))`,
+		}},
+		"mssql": {generateMSSQLCreateTable(table), []string{
+			"[guests] INT NOT NULL CONSTRAINT [CHK_bookings_guests] CHECK (guests > 0)",
+			", CONSTRAINT [chk_bookings_nights] CHECK (nights BETWEEN 1 AND 30))",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.sql, want) {
+				t.Errorf("%s: expected %q in:\n%s", dialect, want, tc.sql)
+			}
+		}
+	}
+}
+
+func TestUpdateTable_Checks(t *testing.T) {
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{"bookings": *bookingsTable()}}}
+	if err := plan.UpdateTable("bookings", func(alt *ddl.AlterTableBuilder) error {
+		alt.DropCheck("chk_bookings_nights")
+		alt.AddCheck("chk_bookings_stay", "nights <= 14")
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateTable failed: %v", err)
+	}
+
+	checks := plan.Schema.Tables["bookings"].Checks
+	if len(checks) != 1 || checks[0].Name != "chk_bookings_stay" {
+		t.Errorf("unexpected checks in schema: %+v", checks)
+	}
+	ins := plan.Migrations[0].Instructions
+	for dialect, tc := range map[string]struct {
+		sql  string
+		want []string
+	}{
+		"postgres": {ins.Postgres, []string{
+			`ALTER TABLE "bookings" DROP CONSTRAINT "chk_bookings_nights"`,
+			`ALTER TABLE "bookings" ADD CONSTRAINT "chk_bookings_stay" CHECK (nights <= 14)`,
+		}},
+		"mysql": {ins.MySQL, []string{
+			"ALTER TABLE `bookings` DROP CHECK `chk_bookings_nights`",
+			"ALTER TABLE `bookings` ADD CONSTRAINT `chk_bookings_stay` CHECK (nights <= 14)",
+		}},
+		"sqlite": {ins.Sqlite, []string{
+			`CONSTRAINT "chk_bookings_stay" CHECK (nights <= 14)`,
+			`ALTER TABLE "bookings_new" RENAME TO "bookings"`,
+		}},
+		"mssql": {ins.MSSQL, []string{
+			"ALTER TABLE [bookings] DROP CONSTRAINT [chk_bookings_nights]",
+			"ALTER TABLE [bookings] ADD CONSTRAINT [chk_bookings_stay] CHECK (nights <= 14)",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.sql, want) {
+				t.Errorf("%s: expected %q in:\n%s", dialect, want, tc.sql)
+			}
+		}
+	}
+	if strings.Contains(ins.Sqlite, "chk_bookings_nights") {
+		t.Errorf("sqlite rebuild kept the dropped check:\n%s", ins.Sqlite)
+	}
+}
+
+func TestUpdateTable_Checks_Errors(t *testing.T) {
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{"bookings": *bookingsTable()}}}
+	if err := plan.UpdateTable("bookings", func(alt *ddl.AlterTableBuilder) error {
+		alt.AddCheck("chk_bookings_nights", "nights > 0")
+		return nil
+	}); err == nil || !strings.Contains(err.Error(), "duplicate check") {
+		t.Errorf("expected duplicate check error, got %v", err)
+	}
+	if err := plan.UpdateTable("bookings", func(alt *ddl.AlterTableBuilder) error {
+		alt.RenameColumn("guests", "party_size")
+		return nil
+	}); err == nil || !strings.Contains(err.Error(), "has a check") {
+		t.Errorf("expected renaming a checked column to fail, got %v", err)
+	}
+}
+
+func TestDropColumn_MSSQL_DropsColumnCheck(t *testing.T) {
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{"bookings": *bookingsTable()}}}
+	if err := plan.UpdateTable("bookings", func(alt *ddl.AlterTableBuilder) error {
+		alt.DropColumn("guests")
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateTable failed: %v", err)
+	}
+	mssql := plan.Migrations[0].Instructions.MSSQL
+	drop := "IF OBJECT_ID('CHK_bookings_guests', 'C') IS NOT NULL ALTER TABLE [bookings] DROP CONSTRAINT [CHK_bookings_guests]"
+	if !strings.Contains(mssql, drop) || strings.Index(mssql, drop) > strings.Index(mssql, "DROP COLUMN") {
+		t.Errorf("expected the check to be dropped before the column:\n%s", mssql)
+	}
+}
+
+// TestSQLiteChecks_Run runs the generated SQL against SQLite and checks
+// that column and table checks reject bad rows, including after a rebuild.
+func TestSQLiteChecks_Run(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	exec := func(sqlStr string) {
+		t.Helper()
+		for _, stmt := range splitSQLStatements(sqlStr) {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("exec failed: %v\nSQL: %s", err, stmt)
+			}
+		}
+	}
+
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{}}}
+	if _, err := plan.AddEmptyTable("bookings", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.Integer("guests").Check("guests > 0")
+		tb.Integer("nights")
+		tb.AddCheck("chk_bookings_nights", "nights BETWEEN 1 AND 30")
+		return nil
+	}); err != nil {
+		t.Fatalf("AddEmptyTable failed: %v", err)
+	}
+	exec(plan.Migrations[0].Instructions.Sqlite)
+
+	exec(`INSERT INTO "bookings" (id, guests, nights) VALUES (1, 2, 20)`)
+	if _, err := db.Exec(`INSERT INTO "bookings" (id, guests, nights) VALUES (2, 0, 3)`); err == nil {
+		t.Error("expected the column check to reject guests = 0")
+	}
+	if _, err := db.Exec(`INSERT INTO "bookings" (id, guests, nights) VALUES (3, 1, 45)`); err == nil {
+		t.Error("expected the table check to reject nights = 45")
+	}
+
+	if err := plan.UpdateTable("bookings", func(alt *ddl.AlterTableBuilder) error {
+		alt.DropCheck("chk_bookings_nights")
+		alt.AddCheck("chk_bookings_stay", "nights <= 60")
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateTable failed: %v", err)
+	}
+	exec(plan.Migrations[1].Instructions.Sqlite)
+
+	exec(`INSERT INTO "bookings" (id, guests, nights) VALUES (4, 1, 45)`)
+	if _, err := db.Exec(`INSERT INTO "bookings" (id, guests, nights) VALUES (5, 1, 90)`); err == nil {
+		t.Error("expected the added check to reject nights = 90")
+	}
+	if _, err := db.Exec(`INSERT INTO "bookings" (id, guests, nights) VALUES (6, -1, 2)`); err == nil {
+		t.Error("expected the column check to survive the rebuild")
+	}
+}
@@ -0,0 +1,255 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const progressTableName = "_portsql_migration_progress"
+
+// SupportsTransactionalDDL reports whether dialect can roll back schema
+// changes. Postgres, SQLite and SQL Server can, so Run applies each
+// migration in one transaction. MySQL commits implicitly after every DDL
+// statement, so Run applies its migrations statement by statement, recording
+// a checkpoint after each one.
+func SupportsTransactionalDDL(dialect string) bool {
+	return dialect != MySQL
+}
+
+// PartialMigration describes a migration whose statements were only partly
+// applied by a previous run.
+type PartialMigration struct {
+	Name string
+	// Completed is the number of statements that were applied.
+	Completed int
+	// LastError is the error of the statement that failed, if recorded.
+	LastError string
+}
+
+// ensureProgressTable creates the table recording the progress of
+// checkpointed migrations if it doesn't exist.
+func ensureProgressTable(ctx context.Context, db *sql.DB, dialect string) error {
+	var createSQL string
+
+	switch dialect {
+	case Postgres, MySQL:
+		createSQL = `
+			CREATE TABLE IF NOT EXISTS _portsql_migration_progress (
+				name       VARCHAR(255) PRIMARY KEY,
+				completed  INT NOT NULL,
+				checksum   VARCHAR(64) NOT NULL,
+				last_error TEXT,
+				updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`
+	case Sqlite:
+		createSQL = `
+			CREATE TABLE IF NOT EXISTS _portsql_migration_progress (
+				name       TEXT PRIMARY KEY,
+				completed  INTEGER NOT NULL,
+				checksum   TEXT NOT NULL,
+				last_error TEXT,
+				updated_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`
+	default:
+		return fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+
+	_, err := db.ExecContext(ctx, createSQL)
+	return err
+}
+
+// GetPartialMigrations returns the migrations a previous run left partly
+// applied, sorted by name. It returns nil when the dialect supports
+// transactional DDL, since such migrations are never partly applied.
+func GetPartialMigrations(ctx context.Context, db *sql.DB, dialect string) ([]PartialMigration, error) {
+	if SupportsTransactionalDDL(dialect) {
+		return nil, nil
+	}
+	if err := ensureProgressTable(ctx, db, dialect); err != nil {
+		return nil, fmt.Errorf("failed to create migration progress table: %w", err)
+	}
+	return readPartialMigrations(ctx, db)
+}
+
+// readPartialMigrations returns the rows of the progress table.
+func readPartialMigrations(ctx context.Context, db *sql.DB) ([]PartialMigration, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT name, completed, last_error FROM _portsql_migration_progress ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migration progress: %w", err)
+	}
+	defer rows.Close()
+
+	var partial []PartialMigration
+	for rows.Next() {
+		var p PartialMigration
+		var lastError sql.NullString
+		if err := rows.Scan(&p.Name, &p.Completed, &lastError); err != nil {
+			return nil, fmt.Errorf("failed to scan migration progress: %w", err)
+		}
+		p.LastError = lastError.String
+		partial = append(partial, p)
+	}
+	return partial, rows.Err()
+}
+
+// statementsChecksum identifies the statements a checkpoint covers, so that
+// a resumed run can tell whether they were edited since.
+func statementsChecksum(statements []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(statements, ";\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// isDataStatement reports whether stmt only changes rows. Such statements
+// are applied in a transaction together with their checkpoint even when the
+// dialect cannot roll back DDL.
+func isDataStatement(stmt string) bool {
+	upper := strings.ToUpper(stmt)
+	for _, prefix := range []string{"INSERT ", "UPDATE ", "DELETE ", "REPLACE "} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// saveProgress records that the first len(done) statements of migration name
+// have been applied, along with the error of the next one, if any.
+func saveProgress(ctx context.Context, ex execer, dialect, name string, done []string, lastErr error) error {
+	var lastError any
+	if lastErr != nil {
+		lastError = lastErr.Error()
+	}
+	nowUTC := time.Now().UTC()
+
+	var upsertSQL string
+	args := []any{name, len(done), statementsChecksum(done), lastError, nowUTC}
+	switch dialect {
+	case Postgres:
+		upsertSQL = `INSERT INTO _portsql_migration_progress (name, completed, checksum, last_error, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (name) DO UPDATE SET completed = EXCLUDED.completed, checksum = EXCLUDED.checksum,
+				last_error = EXCLUDED.last_error, updated_at = EXCLUDED.updated_at`
+	case MySQL:
+		upsertSQL = `INSERT INTO _portsql_migration_progress (name, completed, checksum, last_error, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE completed = VALUES(completed), checksum = VALUES(checksum),
+				last_error = VALUES(last_error), updated_at = VALUES(updated_at)`
+	case Sqlite:
+		upsertSQL = `INSERT INTO _portsql_migration_progress (name, completed, checksum, last_error, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (name) DO UPDATE SET completed = excluded.completed, checksum = excluded.checksum,
+				last_error = excluded.last_error, updated_at = excluded.updated_at`
+		args[4] = nowUTC.Format(time.RFC3339)
+	default:
+		return fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+
+	if _, err := ex.ExecContext(ctx, upsertSQL, args...); err != nil {
+		return fmt.Errorf("failed to record progress of migration %s: %w", name, err)
+	}
+	return nil
+}
+
+// loadProgress returns the number of statements of migration name applied
+// by a previous run and the checksum of those statements.
+func loadProgress(ctx context.Context, db *sql.DB, dialect, name string) (completed int, checksum string, err error) {
+	query := "SELECT completed, checksum FROM _portsql_migration_progress WHERE name = ?"
+	if dialect == Postgres {
+		query = "SELECT completed, checksum FROM _portsql_migration_progress WHERE name = $1"
+	}
+	err = db.QueryRowContext(ctx, query, name).Scan(&completed, &checksum)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read progress of migration %s: %w", name, err)
+	}
+	return completed, checksum, nil
+}
+
+// runMigrationWithCheckpoints executes a migration one statement at a time,
+// recording a checkpoint after each, for dialects that cannot roll back DDL.
+// If a statement fails, the statements before it stay applied and a later
+// run resumes from the failed statement, provided the applied statements
+// are unchanged. The tracking record is written once every statement has
+// succeeded.
+func runMigrationWithCheckpoints(ctx context.Context, db *sql.DB, dialect, name, sqlStmt string) error {
+	statements := splitSQLStatements(sqlStmt)
+
+	start, checksum, err := loadProgress(ctx, db, dialect, name)
+	if err != nil {
+		return err
+	}
+	if start > 0 && (start > len(statements) || statementsChecksum(statements[:start]) != checksum) {
+		return fmt.Errorf("migration %s was partly applied (%d statements) but those statements have changed since; "+
+			"restore them, or repair the schema by hand and delete its row from %s", name, start, progressTableName)
+	}
+
+	for i := start; i < len(statements); i++ {
+		if err := execCheckpointed(ctx, db, dialect, name, statements, i); err != nil {
+			if saveErr := saveProgress(ctx, db, dialect, name, statements[:i], err); saveErr != nil {
+				return errors.Join(err, saveErr)
+			}
+			return fmt.Errorf("failed to execute migration %s at statement %d of %d (%d applied; fix it and run again to resume): %w",
+				name, i+1, len(statements), i, err)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction for migration %s: %w", name, err)
+	}
+	defer tx.Rollback() // no-op if committed
+
+	if err := RecordMigrationTx(ctx, tx, dialect, name[:14], name); err != nil {
+		return err
+	}
+	deleteSQL := "DELETE FROM _portsql_migration_progress WHERE name = ?"
+	if dialect == Postgres {
+		deleteSQL = "DELETE FROM _portsql_migration_progress WHERE name = $1"
+	}
+	if _, err := tx.ExecContext(ctx, deleteSQL, name); err != nil {
+		return fmt.Errorf("failed to clear progress of migration %s: %w", name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", name, err)
+	}
+	return nil
+}
+
+// execCheckpointed executes statements[i] and records the checkpoint after
+// it. Data statements share a transaction with their checkpoint; DDL cannot,
+// so a crash between the two leaves the checkpoint one statement behind.
+func execCheckpointed(ctx context.Context, db *sql.DB, dialect, name string, statements []string, i int) error {
+	if !isDataStatement(statements[i]) {
+		if _, err := db.ExecContext(ctx, statements[i]); err != nil {
+			return err
+		}
+		return saveProgress(ctx, db, dialect, name, statements[:i+1], nil)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op if committed
+	if _, err := tx.ExecContext(ctx, statements[i]); err != nil {
+		return err
+	}
+	if err := saveProgress(ctx, tx, dialect, name, statements[:i+1], nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
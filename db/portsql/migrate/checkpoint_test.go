@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// openCheckpointDB opens an in-memory SQLite database with the tracking and
+// progress tables. SQLite stands in for MySQL: runMigrationWithCheckpoints is
+// called directly, so the statement-by-statement path is exercised even
+// though SQLite could roll back the DDL.
+func openCheckpointDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	if err := EnsureTrackingTable(ctx, db, Sqlite); err != nil {
+		t.Fatalf("EnsureTrackingTable: %v", err)
+	}
+	if err := ensureProgressTable(ctx, db, Sqlite); err != nil {
+		t.Fatalf("ensureProgressTable: %v", err)
+	}
+	return db
+}
+
+func TestSupportsTransactionalDDL(t *testing.T) {
+	if !SupportsTransactionalDDL(Postgres) || !SupportsTransactionalDDL(Sqlite) {
+		t.Error("Postgres and SQLite support transactional DDL")
+	}
+	if SupportsTransactionalDDL(MySQL) {
+		t.Error("MySQL does not support transactional DDL")
+	}
+}
+
+func TestRunMigrationWithCheckpoints_ResumesFromFailedStatement(t *testing.T) {
+	db := openCheckpointDB(t)
+	ctx := context.Background()
+	const name = "20260301120000_create_orders"
+
+	broken := `CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER);
+INSERT INTO orders (id, total) VALUES (1, 10);
+CREATE INDEX idx_orders_total ON missing_table (total);
+CREATE INDEX idx_orders_id ON orders (id)`
+
+	err := runMigrationWithCheckpoints(ctx, db, Sqlite, name, broken)
+	if err == nil {
+		t.Fatal("expected the third statement to fail")
+	}
+	if !strings.Contains(err.Error(), "statement 3 of 4") || !strings.Contains(err.Error(), "2 applied") {
+		t.Errorf("error should locate the failed statement, got: %v", err)
+	}
+
+	partial, err := readPartialMigrations(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partial) != 1 || partial[0].Name != name || partial[0].Completed != 2 || !strings.Contains(partial[0].LastError, "missing_table") {
+		t.Fatalf("partial = %+v, want %s with 2 statements done and the failure recorded", partial, name)
+	}
+	if applied, _ := GetAppliedMigrations(ctx, db); len(applied) != 0 {
+		t.Fatalf("a partly applied migration must not be recorded, got %v", applied)
+	}
+
+	// Fix the failing statement; the first two must not run again (the
+	// INSERT would violate the primary key).
+	fixed := strings.Replace(broken, "missing_table", "orders", 1)
+	if err := runMigrationWithCheckpoints(ctx, db, Sqlite, name, fixed); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&count); err != nil || count != 1 {
+		t.Errorf("orders rows = %d (err %v), want 1", count, err)
+	}
+	if applied, _ := GetAppliedMigrations(ctx, db); len(applied) != 1 || applied[0] != name {
+		t.Errorf("applied = %v, want [%s]", applied, name)
+	}
+	if partial, _ := readPartialMigrations(ctx, db); len(partial) != 0 {
+		t.Errorf("progress should be cleared once the migration completes, got %+v", partial)
+	}
+}
+
+func TestRunMigrationWithCheckpoints_RejectsEditedAppliedStatements(t *testing.T) {
+	db := openCheckpointDB(t)
+	ctx := context.Background()
+	const name = "20260301120000_create_orders"
+
+	broken := "CREATE TABLE orders (id INTEGER PRIMARY KEY); CREATE INDEX idx ON missing_table (id)"
+	if err := runMigrationWithCheckpoints(ctx, db, Sqlite, name, broken); err == nil {
+		t.Fatal("expected the second statement to fail")
+	}
+
+	edited := "CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER); CREATE INDEX idx ON orders (id)"
+	err := runMigrationWithCheckpoints(ctx, db, Sqlite, name, edited)
+	if err == nil || !strings.Contains(err.Error(), "changed since") {
+		t.Fatalf("expected an error about edited statements, got: %v", err)
+	}
+}
+
+func TestRunMigrationWithCheckpoints_DataStatementRollsBackWithCheckpoint(t *testing.T) {
+	db := openCheckpointDB(t)
+	ctx := context.Background()
+	const name = "20260301120000_seed_orders"
+
+	stmts := "CREATE TABLE orders (id INTEGER PRIMARY KEY); INSERT INTO orders (id) VALUES (1), (1)"
+	if err := runMigrationWithCheckpoints(ctx, db, Sqlite, name, stmts); err == nil {
+		t.Fatal("expected the duplicate insert to fail")
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM orders").Scan(&count); err != nil || count != 0 {
+		t.Errorf("orders rows = %d (err %v), want 0", count, err)
+	}
+	partial, _ := readPartialMigrations(ctx, db)
+	if len(partial) != 1 || partial[0].Completed != 1 {
+		t.Errorf("partial = %+v, want 1 statement done", partial)
+	}
+}
+
+func TestGetPartialMigrations_TransactionalDialect(t *testing.T) {
+	db := openCheckpointDB(t)
+	partial, err := GetPartialMigrations(context.Background(), db, Sqlite)
+	if err != nil || partial != nil {
+		t.Errorf("GetPartialMigrations(sqlite) = %v, %v; want nil, nil", partial, err)
+	}
+}
+
+func TestIsDataStatement(t *testing.T) {
+	for stmt, want := range map[string]bool{
+		"INSERT INTO t VALUES (1)":         true,
+		"update t set x = 1":               true,
+		"DELETE FROM t":                    true,
+		"CREATE TABLE t (id INT)":          false,
+		"ALTER TABLE t ADD COLUMN x INT":   false,
+		"CREATE TRIGGER trg AFTER INSERT":  false,
+		"INSERTED_AT_IS_NOT_A_STATEMENT x": false,
+	} {
+		if got := isDataStatement(stmt); got != want {
+			t.Errorf("isDataStatement(%q) = %v, want %v", stmt, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func init() {
+	ddl.MustRegisterType(ddl.CustomType{
+		Name:     "citext",
+		Postgres: "CITEXT",
+		MySQL:    "VARCHAR(255)",
+		SQLite:   "TEXT COLLATE NOCASE",
+		GoType:   "string",
+	})
+}
+
+func customTypeTable() *ddl.Table {
+	tb := ddl.MakeEmptyTable("users")
+	tb.Bigint("id").PrimaryKey()
+	tb.Custom("email", "citext").Unique()
+	tb.Custom("nickname", "citext").Nullable()
+	return tb.Build()
+}
+
+func TestCreateTable_CustomType(t *testing.T) {
+	table := customTypeTable()
+	tests := []struct {
+		dialect string
+		sql     string
+		want    string
+	}{
+		{"postgres", generatePostgresCreateTable(table), `"email" CITEXT NOT NULL`},
+		{"mysql", generateMySQLCreateTable(table), "`email` VARCHAR(255) NOT NULL"},
+		{"sqlite", generateSQLiteCreateTable(table), `"email" TEXT COLLATE NOCASE NOT NULL`},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.sql, tt.want) {
+			t.Errorf("%s: expected %q in:\n%s", tt.dialect, tt.want, tt.sql)
+		}
+	}
+}
+
+func TestAlterTable_AddCustomColumn(t *testing.T) {
+	alt := ddl.AlterTable("users")
+	alt.Custom("handle", "citext").Nullable()
+	sql := generatePostgresAlterTable("users", alt.Build())
+
+	want := `ALTER TABLE "users" ADD COLUMN "handle" CITEXT`
+	if !strings.Contains(sql, want) {
+		t.Errorf("expected %q in:\n%s", want, sql)
+	}
+}
+
+func TestAddEmptyTable_UnregisteredCustomType(t *testing.T) {
+	plan := NewPlan()
+	_, err := plan.AddEmptyTable("items", func(tb *ddl.TableBuilder) error {
+		tb.Custom("price", "no_such_type")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), `unregistered type "no_such_type"`) {
+		t.Errorf("expected unregistered type error, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package migrate
 
 import (
+	"strings"
+
 	"github.com/shipq/shipq/db/portsql/ddl"
 )
 
@@ -44,6 +46,26 @@ func GetAutoincrementPK(table *ddl.Table) (AutoincrementPKInfo, bool) {
 	}, true
 }
 
+// compositePrimaryKey returns the table's primary key columns when the key
+// spans more than one column, and nil otherwise. A composite key can't be
+// declared inline on a column, so CREATE TABLE emits it as a table-level
+// PRIMARY KEY constraint and the columns themselves as plain NOT NULL ones.
+func compositePrimaryKey(table *ddl.Table) []string {
+	if pk := table.PrimaryKeyColumns(); len(pk) > 1 {
+		return pk
+	}
+	return nil
+}
+
+// quotedColumns joins column names quoted with q (" or `).
+func quotedColumns(names []string, q string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = q + n + q
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // IsAutoincrementEligible is a convenience function that returns true if the table
 // has an autoincrement-eligible primary key.
 func IsAutoincrementEligible(table *ddl.Table) bool {
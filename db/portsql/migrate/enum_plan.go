@@ -0,0 +1,187 @@
+package migrate
+
+// enum_plan.go - SQL for Enum Columns
+//
+// Enum columns are a native ENUM on MySQL and a string column with a CHECK
+// constraint on SQLite and SQL Server. On Postgres each column gets its own
+// type, named by ddl.EnumTypeName, which is created before the column,
+// renamed with it and dropped after it.
+//
+// Values are added to and dropped from an existing column with
+// AddEnumValues and DropEnumValues: ALTER TYPE on Postgres, MODIFY COLUMN
+// on MySQL, a replaced CHECK constraint on SQL Server and a table rebuild
+// on SQLite. A migration dropping values carries a DataCheck, so it is not
+// applied while a row still holds one of them.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// enumColumns returns the enum columns among cols.
+func enumColumns(cols []ddl.ColumnDefinition) []ddl.ColumnDefinition {
+	var enums []ddl.ColumnDefinition
+	for _, col := range cols {
+		if col.Type == ddl.EnumType {
+			enums = append(enums, col)
+		}
+	}
+	return enums
+}
+
+// enumValueList renders values as a list of string literals, each prefixed
+// with prefix (N for SQL Server's Unicode literals).
+func enumValueList(values []string, prefix string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = prefix + "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// enumMaxLength returns the length of the longest of values.
+func enumMaxLength(values []string) int {
+	longest := 1
+	for _, v := range values {
+		longest = max(longest, len(v))
+	}
+	return longest
+}
+
+// generatePostgresCreateEnumType creates the type of an enum column.
+func generatePostgresCreateEnumType(tableName string, col *ddl.ColumnDefinition) string {
+	return fmt.Sprintf(`CREATE TYPE "%s" AS ENUM (%s)`,
+		ddl.EnumTypeName(tableName, col.Name), enumValueList(col.EnumValues, ""))
+}
+
+// generatePostgresDropEnumType drops the type of an enum column.
+func generatePostgresDropEnumType(tableName, column string) string {
+	return fmt.Sprintf(`DROP TYPE "%s"`, ddl.EnumTypeName(tableName, column))
+}
+
+// generatePostgresUpdateTable wraps generatePostgresAlterTable, dropping
+// the type of every enum column in enumsBefore that ops drop and renaming
+// the type of those they rename.
+func generatePostgresUpdateTable(tableName string, ops []ddl.TableOperation, enumsBefore []ddl.ColumnDefinition) string {
+	statements := []string{generatePostgresAlterTable(tableName, ops)}
+	for _, op := range ops {
+		for _, col := range enumsBefore {
+			if col.Name != op.Column {
+				continue
+			}
+			switch op.Type {
+			case ddl.OpDropColumn:
+				statements = append(statements, generatePostgresDropEnumType(tableName, col.Name))
+			case ddl.OpRenameColumn:
+				statements = append(statements, fmt.Sprintf(`ALTER TYPE "%s" RENAME TO "%s"`,
+					ddl.EnumTypeName(tableName, col.Name), ddl.EnumTypeName(tableName, op.NewName)))
+			}
+		}
+	}
+	return strings.Join(statements, ";\n")
+}
+
+// sqliteEnumCheck returns the CHECK constraint of an enum column.
+func sqliteEnumCheck(col *ddl.ColumnDefinition) string {
+	return fmt.Sprintf(`CHECK ("%s" IN (%s))`, col.Name, enumValueList(col.EnumValues, ""))
+}
+
+// mssqlCheckConstraint names the CHECK constraint of an enum column, which
+// like its default is named so later migrations can drop it.
+func mssqlCheckConstraint(tableName, column string) string {
+	return fmt.Sprintf("CK_%s_%s", tableName, column)
+}
+
+// mssqlEnumCheck returns the named CHECK constraint of an enum column.
+func mssqlEnumCheck(tableName string, col *ddl.ColumnDefinition) string {
+	return fmt.Sprintf("CONSTRAINT [%s] CHECK ([%s] IN (%s))",
+		mssqlCheckConstraint(tableName, col.Name), col.Name, enumValueList(col.EnumValues, "N"))
+}
+
+// dropMSSQLCheck drops a column's CHECK constraint if it has one.
+func dropMSSQLCheck(tableName, column string) string {
+	name := mssqlCheckConstraint(tableName, column)
+	return fmt.Sprintf("IF OBJECT_ID('%s', 'C') IS NOT NULL ALTER TABLE [%s] DROP CONSTRAINT [%s]",
+		escapeMSSQLString(name), tableName, name)
+}
+
+// enumRemovalCheck returns the data check of a migration dropping values
+// from an enum column: a query finding a row that still holds one.
+func enumRemovalCheck(tableName, column string, values []string) DataCheck {
+	list := enumValueList(values, "")
+	return DataCheck{
+		Query: MigrationInstructions{
+			Postgres: fmt.Sprintf(`SELECT 1 FROM "%s" WHERE "%s" IN (%s)`, tableName, column, list),
+			MySQL:    fmt.Sprintf("SELECT 1 FROM `%s` WHERE `%s` IN (%s)", tableName, column, list),
+			Sqlite:   fmt.Sprintf(`SELECT 1 FROM "%s" WHERE "%s" IN (%s)`, tableName, column, list),
+			MSSQL:    fmt.Sprintf("SELECT 1 FROM [%s] WHERE [%s] IN (%s)", tableName, column, enumValueList(values, "N")),
+		},
+		Message: fmt.Sprintf("table %q: column %q still holds %s, which the migration drops from the enum; update or delete those rows in an earlier migration",
+			tableName, column, list),
+	}
+}
+
+// generatePostgresEnumValues changes the values of an enum column's type.
+// ADD VALUE runs inside the migration's transaction from Postgres 12, but
+// the new values can't be used until it commits. Postgres can't drop a
+// value, so a drop replaces the type: the old one is renamed aside, the new
+// one created, the column converted through text and the old type dropped.
+// The column's default has the old type, so it is dropped and restored
+// around the conversion.
+func generatePostgresEnumValues(tableName string, op *ddl.TableOperation) string {
+	col := op.ColumnDef
+	if col == nil {
+		return ""
+	}
+	typeName := ddl.EnumTypeName(tableName, col.Name)
+
+	var statements []string
+	if op.Type == ddl.OpAddEnumValues {
+		for _, v := range op.EnumValues {
+			statements = append(statements, fmt.Sprintf(`ALTER TYPE "%s" ADD VALUE '%s'`, typeName, escapePostgresString(v)))
+		}
+		return strings.Join(statements, ";\n")
+	}
+
+	oldType := typeName + "__old"
+	statements = append(statements,
+		fmt.Sprintf(`ALTER TYPE "%s" RENAME TO "%s"`, typeName, oldType),
+		generatePostgresCreateEnumType(tableName, col))
+	if col.Default != nil {
+		statements = append(statements, fmt.Sprintf(`ALTER TABLE "%s" ALTER COLUMN "%s" DROP DEFAULT`, tableName, col.Name))
+	}
+	statements = append(statements, fmt.Sprintf(`ALTER TABLE "%s" ALTER COLUMN "%s" TYPE "%s" USING "%s"::text::"%s"`,
+		tableName, col.Name, typeName, col.Name, typeName))
+	if col.Default != nil {
+		statements = append(statements, fmt.Sprintf(`ALTER TABLE "%s" ALTER COLUMN "%s" SET DEFAULT %s`,
+			tableName, col.Name, formatPostgresDefault(col)))
+	}
+	statements = append(statements, fmt.Sprintf(`DROP TYPE "%s"`, oldType))
+	return strings.Join(statements, ";\n")
+}
+
+// generateMSSQLEnumValues replaces the CHECK constraint of an enum column
+// whose values changed. When an added value is longer than the column
+// allows, the column is widened first, with its default constraint, which
+// blocks ALTER COLUMN, dropped and restored around it.
+func generateMSSQLEnumValues(tableName string, op *ddl.TableOperation) string {
+	col := op.ColumnDef
+	if col == nil {
+		return ""
+	}
+	statements := []string{dropMSSQLCheck(tableName, col.Name)}
+	if op.Type == ddl.OpAddEnumValues {
+		before := col.EnumValues[:len(col.EnumValues)-len(op.EnumValues)]
+		if enumMaxLength(col.EnumValues) > enumMaxLength(before) {
+			statements = append(statements, dropMSSQLDefault(tableName, col.Name), alterMSSQLColumn(tableName, col))
+			if col.Default != nil {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE [%s] ADD CONSTRAINT [%s] DEFAULT %s FOR [%s]",
+					tableName, mssqlDefaultConstraint(tableName, col.Name), formatMSSQLDefault(col), col.Name))
+			}
+		}
+	}
+	statements = append(statements, fmt.Sprintf("ALTER TABLE [%s] ADD %s", tableName, mssqlEnumCheck(tableName, col)))
+	return strings.Join(statements, ";\n")
+}
@@ -0,0 +1,299 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func ordersTable() *ddl.Table {
+	tb := ddl.MakeEmptyTable("orders")
+	tb.Bigint("id").PrimaryKey()
+	tb.Enum("status", "pending", "active", "archived").Default("pending")
+	tb.Enum("channel", "web", "in-store").Nullable()
+	return tb.Build()
+}
+
+func TestPostgres_CreateTable_Enum(t *testing.T) {
+	sql := generatePostgresCreateTable(ordersTable())
+
+	want := `CREATE TYPE "orders_status" AS ENUM ('pending', 'active', 'archived');` + "\n" +
+		`CREATE TYPE "orders_channel" AS ENUM ('web', 'in-store');` + "\n" +
+		`CREATE TABLE "orders" (`
+	if !strings.HasPrefix(sql, want) {
+		t.Errorf("expected enum types before the table, got:\n%s", sql)
+	}
+	if !strings.Contains(sql, `"status" "orders_status" NOT NULL DEFAULT 'pending'`) {
+		t.Errorf("expected column of the enum type, got:\n%s", sql)
+	}
+}
+
+func TestMySQL_CreateTable_Enum(t *testing.T) {
+	sql := generateMySQLCreateTable(ordersTable())
+
+	if !strings.Contains(sql, "`status` ENUM('pending', 'active', 'archived') NOT NULL DEFAULT 'pending'") {
+		t.Errorf("expected native ENUM column, got:\n%s", sql)
+	}
+}
+
+func TestSQLite_CreateTable_Enum(t *testing.T) {
+	sql := generateSQLiteCreateTable(ordersTable())
+
+	if !strings.Contains(sql, `"status" TEXT NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'active', 'archived'))`) {
+		t.Errorf("expected CHECK constraint, got:\n%s", sql)
+	}
+}
+
+func TestMSSQL_CreateTable_Enum(t *testing.T) {
+	sql := generateMSSQLCreateTable(ordersTable())
+
+	if !strings.Contains(sql, "[channel] NVARCHAR(8) "+mssqlCollation+" NULL CONSTRAINT [CK_orders_channel] CHECK ([channel] IN (N'web', N'in-store'))") {
+		t.Errorf("expected named CHECK constraint, got:\n%s", sql)
+	}
+}
+
+func TestPostgres_UpdateTable_Enum(t *testing.T) {
+	before := enumColumns(ordersTable().Columns)
+
+	alt := ddl.AlterTable("orders")
+	alt.Enum("priority", "low", "high").Default("low")
+	alt.RenameColumn("status", "state")
+	alt.DropColumn("channel")
+	sql := generatePostgresUpdateTable("orders", alt.Build(), before)
+
+	// The status type is renamed with its column and the channel type is
+	// dropped after the column that used it.
+	want := `CREATE TYPE "orders_priority" AS ENUM ('low', 'high');` + "\n" +
+		`ALTER TABLE "orders" ADD COLUMN "priority" "orders_priority" NOT NULL DEFAULT 'low';` + "\n" +
+		`ALTER TABLE "orders" RENAME COLUMN "status" TO "state";` + "\n" +
+		`ALTER TABLE "orders" DROP COLUMN "channel";` + "\n" +
+		`ALTER TYPE "orders_status" RENAME TO "orders_state";` + "\n" +
+		`DROP TYPE "orders_channel"`
+	if sql != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sql, want)
+	}
+}
+
+func TestPlan_DropTable_Enum(t *testing.T) {
+	plan := NewPlan()
+	if _, err := plan.AddEmptyTable("orders", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.Enum("status", "pending", "active")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.DropTable("orders"); err != nil {
+		t.Fatal(err)
+	}
+	got := plan.Migrations[len(plan.Migrations)-1].Instructions.Postgres
+	if got != `DROP TABLE "orders";`+"\n"+`DROP TYPE "orders_status"` {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestPlan_RejectsInvalidEnum(t *testing.T) {
+	plan := NewPlan()
+	_, err := plan.AddEmptyTable("orders", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.Enum("status", "pending", "active").Default("done")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), `default "done" is not one of its values`) {
+		t.Errorf("expected invalid default to be rejected, got %v", err)
+	}
+}
+
+func TestSQLite_Enum_RejectsOtherValues(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range strings.Split(generateSQLiteCreateTable(ordersTable()), ";\n") {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	if _, err := db.Exec(`INSERT INTO "orders" ("id", "channel") VALUES (1, 'in-store')`); err != nil {
+		t.Fatalf("valid value rejected: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO "orders" ("id", "status") VALUES (2, 'deleted')`); err == nil {
+		t.Error("expected the CHECK constraint to reject a value outside the enum")
+	}
+}
+
+// ordersPlan returns a plan creating ordersTable.
+func ordersPlan(t *testing.T) *MigrationPlan {
+	t.Helper()
+	plan := NewPlan()
+	plan.SetCurrentMigration("20260101000000_create_orders")
+	if _, err := plan.AddEmptyTable("orders", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.Enum("status", "pending", "active", "archived").Default("pending")
+		tb.Enum("channel", "web", "in-store").Nullable()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestPlan_AddEnumValues(t *testing.T) {
+	plan := ordersPlan(t)
+	plan.SetCurrentMigration("20260102000000_add_order_statuses")
+	if err := plan.UpdateTable("orders", func(alt *ddl.AlterTableBuilder) error {
+		alt.AddEnumValues("status", "shipped", "partially_refunded")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	table := plan.Schema.Tables["orders"]
+	col := findTableColumn(&table, "status")
+	if want := []string{"pending", "active", "archived", "shipped", "partially_refunded"}; !slices.Equal(col.EnumValues, want) {
+		t.Errorf("schema values = %v, want %v", col.EnumValues, want)
+	}
+
+	m := plan.Migrations[len(plan.Migrations)-1]
+	if len(m.DataChecks) != 0 {
+		t.Errorf("adding values needs no data check, got %+v", m.DataChecks)
+	}
+	if want := `ALTER TYPE "orders_status" ADD VALUE 'shipped';` + "\n" +
+		`ALTER TYPE "orders_status" ADD VALUE 'partially_refunded'`; m.Instructions.Postgres != want {
+		t.Errorf("postgres:\n%s\nwant:\n%s", m.Instructions.Postgres, want)
+	}
+	if want := "ALTER TABLE `orders` MODIFY COLUMN `status` ENUM('pending', 'active', 'archived', 'shipped', 'partially_refunded') NOT NULL DEFAULT 'pending'"; m.Instructions.MySQL != want {
+		t.Errorf("mysql:\n%s\nwant:\n%s", m.Instructions.MySQL, want)
+	}
+	if !strings.Contains(m.Instructions.Sqlite, `"status" TEXT NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'active', 'archived', 'shipped', 'partially_refunded'))`) {
+		t.Errorf("expected the SQLite rebuild to carry the new CHECK, got:\n%s", m.Instructions.Sqlite)
+	}
+	// partially_refunded is longer than NVARCHAR(8), so the column widens
+	if want := "IF OBJECT_ID('CK_orders_status', 'C') IS NOT NULL ALTER TABLE [orders] DROP CONSTRAINT [CK_orders_status];\n" +
+		"IF OBJECT_ID('DF_orders_status', 'D') IS NOT NULL ALTER TABLE [orders] DROP CONSTRAINT [DF_orders_status];\n" +
+		"ALTER TABLE [orders] ALTER COLUMN [status] NVARCHAR(18) " + mssqlCollation + " NOT NULL;\n" +
+		"ALTER TABLE [orders] ADD CONSTRAINT [DF_orders_status] DEFAULT 'pending' FOR [status];\n" +
+		"ALTER TABLE [orders] ADD CONSTRAINT [CK_orders_status] CHECK ([status] IN (N'pending', N'active', N'archived', N'shipped', N'partially_refunded'))"; m.Instructions.MSSQL != want {
+		t.Errorf("mssql:\n%s\nwant:\n%s", m.Instructions.MSSQL, want)
+	}
+}
+
+func TestPlan_DropEnumValues(t *testing.T) {
+	plan := ordersPlan(t)
+	plan.SetCurrentMigration("20260102000000_drop_archived")
+	if err := plan.UpdateTable("orders", func(alt *ddl.AlterTableBuilder) error {
+		alt.RenameColumn("status", "state")
+		alt.DropEnumValues("state", "archived")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := plan.Migrations[len(plan.Migrations)-1]
+	if want := `ALTER TABLE "orders" RENAME COLUMN "status" TO "state";` + "\n" +
+		`ALTER TYPE "orders_state" RENAME TO "orders_state__old";` + "\n" +
+		`CREATE TYPE "orders_state" AS ENUM ('pending', 'active');` + "\n" +
+		`ALTER TABLE "orders" ALTER COLUMN "state" DROP DEFAULT;` + "\n" +
+		`ALTER TABLE "orders" ALTER COLUMN "state" TYPE "orders_state" USING "state"::text::"orders_state";` + "\n" +
+		`ALTER TABLE "orders" ALTER COLUMN "state" SET DEFAULT 'pending';` + "\n" +
+		`DROP TYPE "orders_state__old";` + "\n" +
+		`ALTER TYPE "orders_status" RENAME TO "orders_state"`; m.Instructions.Postgres != want {
+		t.Errorf("postgres:\n%s\nwant:\n%s", m.Instructions.Postgres, want)
+	}
+
+	// The check runs before the rename, so it uses the old name
+	if len(m.DataChecks) != 1 {
+		t.Fatalf("expected one data check, got %+v", m.DataChecks)
+	}
+	if got, want := m.DataChecks[0].Query.MySQL, "SELECT 1 FROM `orders` WHERE `status` IN ('archived')"; got != want {
+		t.Errorf("mysql data check = %q, want %q", got, want)
+	}
+	if got, want := m.DataChecks[0].Query.MSSQL, "SELECT 1 FROM [orders] WHERE [status] IN (N'archived')"; got != want {
+		t.Errorf("mssql data check = %q, want %q", got, want)
+	}
+}
+
+func TestPlan_EnumValues_Rejected(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(*ddl.AlterTableBuilder)
+		want string
+	}{
+		{"existing value", func(alt *ddl.AlterTableBuilder) { alt.AddEnumValues("status", "active") }, `already has value "active"`},
+		{"missing value", func(alt *ddl.AlterTableBuilder) { alt.DropEnumValues("status", "shipped") }, `has no value "shipped"`},
+		{"default", func(alt *ddl.AlterTableBuilder) { alt.DropEnumValues("status", "pending") }, `default "pending" is not one of its values`},
+		{"every value", func(alt *ddl.AlterTableBuilder) { alt.DropEnumValues("channel", "web", "in-store") }, "has no values"},
+		{"not an enum", func(alt *ddl.AlterTableBuilder) { alt.AddEnumValues("id", "x") }, "is not an enum column"},
+		{"no column", func(alt *ddl.AlterTableBuilder) { alt.AddEnumValues("kind", "x") }, `column "kind" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ordersPlan(t).UpdateTable("orders", func(alt *ddl.AlterTableBuilder) error {
+				tt.fn(alt)
+				return nil
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRun_EnumValues_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	plan := ordersPlan(t)
+	plan.SetCurrentMigration("20260102000000_add_shipped")
+	if err := plan.UpdateTable("orders", func(alt *ddl.AlterTableBuilder) error {
+		alt.AddEnumValues("status", "shipped")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx, db, plan, Sqlite); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO "orders" ("id", "status") VALUES (1, 'shipped'), (2, 'archived')`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	plan.SetCurrentMigration("20260103000000_drop_archived")
+	if err := plan.UpdateTable("orders", func(alt *ddl.AlterTableBuilder) error {
+		alt.DropEnumValues("status", "archived")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err = Run(ctx, db, plan, Sqlite)
+	if err == nil || !strings.Contains(err.Error(), `column "status" still holds 'archived'`) {
+		t.Fatalf("expected the data check to stop the migration, got %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE "orders" SET "status" = 'active' WHERE "status" = 'archived'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx, db, plan, Sqlite); err != nil {
+		t.Fatalf("migration after the data was fixed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO "orders" ("id", "status") VALUES (3, 'archived')`); err == nil {
+		t.Error("expected the rebuilt CHECK to reject the dropped value")
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM "orders"`).Scan(&n); err != nil || n != 2 {
+		t.Errorf("rows after rebuild = %d (%v), want 2", n, err)
+	}
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// validateLabels checks that every label of table is a lowercase
+// identifier (letters, digits, '_' and '-', starting with a letter) and
+// leaves the labels sorted and deduplicated.
+func validateLabels(table *ddl.Table) error {
+	for _, label := range table.Labels {
+		if !isValidLabel(label) {
+			return fmt.Errorf("table %q: invalid label %q (use lowercase letters, digits, '_' and '-', starting with a letter)", table.Name, label)
+		}
+	}
+	if len(table.Labels) == 0 {
+		table.Labels = nil
+		return nil
+	}
+	sort.Strings(table.Labels)
+	table.Labels = slices.Compact(table.Labels)
+	return nil
+}
+
+func isValidLabel(label string) bool {
+	if label == "" || label[0] < 'a' || label[0] > 'z' {
+		return false
+	}
+	for _, c := range label {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// LabelTable adds labels to an existing table. Labels only affect code
+// generation, so no migration SQL is recorded.
+func (m *MigrationPlan) LabelTable(name string, labels ...string) error {
+	table, ok := m.Schema.Tables[name]
+	if !ok {
+		return fmt.Errorf("table %q not found in schema", name)
+	}
+	table.Labels = append(slices.Clone(table.Labels), labels...)
+	if err := validateLabels(&table); err != nil {
+		return err
+	}
+	m.Schema.Tables[name] = table
+	return nil
+}
+
+// UnlabelTable removes labels from an existing table. Labels the table does
+// not carry are ignored.
+func (m *MigrationPlan) UnlabelTable(name string, labels ...string) error {
+	table, ok := m.Schema.Tables[name]
+	if !ok {
+		return fmt.Errorf("table %q not found in schema", name)
+	}
+	table.Labels = slices.DeleteFunc(slices.Clone(table.Labels), func(l string) bool {
+		return slices.Contains(labels, l)
+	})
+	if len(table.Labels) == 0 {
+		table.Labels = nil
+	}
+	m.Schema.Tables[name] = table
+	return nil
+}
+
+// SelectTables returns the sorted names of the tables carrying at least one
+// of include (every table when include is empty) and none of exclude.
+func SelectTables(tables map[string]ddl.Table, include, exclude []string) []string {
+	var names []string
+	for _, name := range sortedTableNames(tables) {
+		table := tables[name]
+		if len(include) > 0 && !slices.ContainsFunc(include, table.HasLabel) {
+			continue
+		}
+		if slices.ContainsFunc(exclude, table.HasLabel) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// TablesByLabel maps every label used in tables to the sorted names of the
+// tables carrying it.
+func TablesByLabel(tables map[string]ddl.Table) map[string][]string {
+	byLabel := make(map[string][]string)
+	for _, name := range sortedTableNames(tables) {
+		for _, label := range tables[name].Labels {
+			byLabel[label] = append(byLabel[label], name)
+		}
+	}
+	return byLabel
+}
@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestAddTable_Labels(t *testing.T) {
+	plan := NewPlan()
+	if _, err := plan.AddTable("invoices", func(tb *ddl.TableBuilder) error {
+		tb.String("number")
+		tb.Label("billing", "internal", "billing")
+		return nil
+	}); err != nil {
+		t.Fatalf("AddTable failed: %v", err)
+	}
+	migrations := len(plan.Migrations)
+
+	table := plan.Schema.Tables["invoices"]
+	if got := strings.Join(table.Labels, ","); got != "billing,internal" {
+		t.Errorf("Labels = %q, want sorted and deduplicated", got)
+	}
+	if !table.HasLabel("billing") || table.HasLabel("admin") {
+		t.Error("HasLabel disagrees with Labels")
+	}
+
+	if err := plan.LabelTable("invoices", "pii"); err != nil {
+		t.Fatalf("LabelTable failed: %v", err)
+	}
+	if err := plan.UnlabelTable("invoices", "internal", "unknown"); err != nil {
+		t.Fatalf("UnlabelTable failed: %v", err)
+	}
+	if got := strings.Join(plan.Schema.Tables["invoices"].Labels, ","); got != "billing,pii" {
+		t.Errorf("Labels = %q, want billing,pii", got)
+	}
+	if len(plan.Migrations) != migrations {
+		t.Error("labeling a table should not record a migration")
+	}
+
+	data, err := plan.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := PlanFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(loaded.Schema.Tables["invoices"].Labels, ","); got != "billing,pii" {
+		t.Errorf("labels did not survive schema.json: %q", got)
+	}
+}
+
+func TestLabels_Invalid(t *testing.T) {
+	plan := NewPlan()
+	for _, label := range []string{"", "Billing", "1st", "has space"} {
+		_, err := plan.AddTable("t_"+strings.ReplaceAll(label, " ", "_"), func(tb *ddl.TableBuilder) error {
+			tb.Label(label)
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), "invalid label") {
+			t.Errorf("label %q: expected invalid label error, got %v", label, err)
+		}
+	}
+	if err := plan.LabelTable("missing", "billing"); err == nil {
+		t.Error("expected error for unknown table")
+	}
+}
+
+func TestSelectTables(t *testing.T) {
+	tables := map[string]ddl.Table{
+		"invoices": {Name: "invoices", Labels: []string{"billing"}},
+		"ledger":   {Name: "ledger", Labels: []string{"billing", "internal"}},
+		"posts":    {Name: "posts"},
+		"audit":    {Name: "audit", Labels: []string{"internal"}},
+	}
+	for _, tt := range []struct {
+		include, exclude []string
+		want             string
+	}{
+		{nil, nil, "audit,invoices,ledger,posts"},
+		{[]string{"billing"}, nil, "invoices,ledger"},
+		{[]string{"billing"}, []string{"internal"}, "invoices"},
+		{nil, []string{"internal"}, "invoices,posts"},
+		{[]string{"missing"}, nil, ""},
+	} {
+		if got := strings.Join(SelectTables(tables, tt.include, tt.exclude), ","); got != tt.want {
+			t.Errorf("SelectTables(%v, %v) = %q, want %q", tt.include, tt.exclude, got, tt.want)
+		}
+	}
+
+	byLabel, _ := json.Marshal(TablesByLabel(tables))
+	if string(byLabel) != `{"billing":["invoices","ledger"],"internal":["audit","ledger"]}` {
+		t.Errorf("TablesByLabel = %s", byLabel)
+	}
+}
@@ -0,0 +1,410 @@
+package migrate
+
+// mssql_plan.go - SQL Server SQL Generation
+//
+// This file contains all SQL Server-specific SQL generation functions.
+// SQL Server stores defaults as constraints, so they are named
+// DF_<table>_<column> to let later migrations drop and replace them, and
+// ALTER COLUMN restates the column's type and nullability, so alterations
+// look the column up in the table as it stands after the migration.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// mssqlCollation compares strings by code point, matching SQLite's binary
+// default, Postgres COLLATE "C" and MySQL utf8mb4_bin.
+const mssqlCollation = "COLLATE Latin1_General_100_BIN2"
+
+// mssqlMaxNVarChar is the longest NVARCHAR(n); longer strings use
+// NVARCHAR(MAX).
+const mssqlMaxNVarChar = 4000
+
+// mssqlType maps DDL types to SQL Server types
+func mssqlType(col *ddl.ColumnDefinition) string {
+	if col.Custom != nil {
+		if col.Custom.MSSQL != "" {
+			return col.Custom.MSSQL
+		}
+		return "NVARCHAR(MAX)"
+	}
+	switch col.Type {
+	case ddl.StringType:
+		length := 255
+		if col.Length != nil {
+			length = *col.Length
+		}
+		if length > mssqlMaxNVarChar {
+			return "NVARCHAR(MAX) " + mssqlCollation
+		}
+		return fmt.Sprintf("NVARCHAR(%d) %s", length, mssqlCollation)
+	case ddl.EnumType:
+		return fmt.Sprintf("NVARCHAR(%d) %s", enumMaxLength(col.EnumValues), mssqlCollation)
+	case ddl.DecimalType:
+		precision := 10
+		scale := 0
+		if col.Precision != nil {
+			precision = *col.Precision
+		}
+		if col.Scale != nil {
+			scale = *col.Scale
+		}
+		return fmt.Sprintf("DECIMAL(%d, %d)", precision, scale)
+	default:
+		return mssqlTypeFromString(col.Type)
+	}
+}
+
+// escapeMSSQLString escapes single quotes in a string for SQL Server
+func escapeMSSQLString(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
+
+// mssqlDefaultConstraint names the default constraint of a column.
+func mssqlDefaultConstraint(tableName, column string) string {
+	return fmt.Sprintf("DF_%s_%s", tableName, column)
+}
+
+// formatMSSQLDefault formats a default value for SQL Server
+func formatMSSQLDefault(col *ddl.ColumnDefinition) string {
+	if col.Default == nil {
+		return ""
+	}
+
+	defaultVal := *col.Default
+
+	// CURRENT_TIMESTAMP is a special sentinel for auto-managed timestamp columns.
+	// DATETIME2 carries no zone, so store UTC like the runner does.
+	if defaultVal == "CURRENT_TIMESTAMP" {
+		return "SYSUTCDATETIME()"
+	}
+
+	switch col.Type {
+	case ddl.BooleanType:
+		// BIT defaults to 1 or 0
+		if defaultVal == "true" {
+			return "1"
+		}
+		return "0"
+	case ddl.IntegerType, ddl.BigintType, ddl.FloatType, ddl.DecimalType:
+		// Numeric defaults are unquoted
+		return defaultVal
+	default:
+		// String defaults are single-quoted
+		return fmt.Sprintf("'%s'", escapeMSSQLString(defaultVal))
+	}
+}
+
+// generateMSSQLColumnDef generates a column definition for CREATE TABLE and
+// ALTER TABLE ADD.
+// isAutoincrementPK should be true if this column is the autoincrement-eligible primary key.
+func generateMSSQLColumnDef(tableName string, col *ddl.ColumnDefinition, isAutoincrementPK bool) string {
+	var parts []string
+
+	// Column name (bracket-quoted)
+	parts = append(parts, fmt.Sprintf("[%s]", col.Name))
+
+	// Type (with identity for autoincrement PK)
+	parts = append(parts, mssqlType(col))
+	if isAutoincrementPK {
+		parts = append(parts, "IDENTITY(1,1)")
+	}
+
+	// Nullability is spelled out: the implicit default depends on session
+	// settings
+	if col.Nullable {
+		parts = append(parts, "NULL")
+	} else if !col.PrimaryKey {
+		parts = append(parts, "NOT NULL")
+	}
+
+	// PRIMARY KEY
+	if col.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	}
+
+	// DEFAULT (skip for autoincrement PK - IDENTITY is the source of truth)
+	if col.Default != nil && !isAutoincrementPK {
+		parts = append(parts, fmt.Sprintf("CONSTRAINT [%s] DEFAULT %s",
+			mssqlDefaultConstraint(tableName, col.Name), formatMSSQLDefault(col)))
+	}
+
+	// Enum columns are strings restricted to their values
+	if col.Type == ddl.EnumType {
+		parts = append(parts, mssqlEnumCheck(tableName, col))
+	}
+
+	if col.Check != "" {
+		parts = append(parts, mssqlColumnCheck(tableName, col))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// generateMSSQLCreateTable generates a CREATE TABLE statement for SQL Server.
+func generateMSSQLCreateTable(table *ddl.Table) string {
+	var sb strings.Builder
+
+	// Check for autoincrement-eligible PK
+	pkInfo, hasAutoincrementPK := GetAutoincrementPK(table)
+
+	// CREATE TABLE statement
+	sb.WriteString(fmt.Sprintf("CREATE TABLE [%s] (", table.Name))
+
+	// Columns
+	compositePK := compositePrimaryKey(table)
+	for i, col := range table.Columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if compositePK != nil {
+			col.PrimaryKey = false
+		}
+		// Determine if this column is the autoincrement PK
+		isAutoincrementPK := hasAutoincrementPK && col.Name == pkInfo.ColumnName
+		sb.WriteString(generateMSSQLColumnDef(table.Name, &col, isAutoincrementPK))
+	}
+	if compositePK != nil {
+		sb.WriteString(fmt.Sprintf(", PRIMARY KEY (%s)", mssqlQuotedColumns(compositePK)))
+	}
+	sb.WriteString(tableChecks(table.Checks, "[", "]"))
+
+	sb.WriteString(")")
+
+	// Generate index statements separately
+	var indexStatements []string
+	for _, idx := range table.Indexes {
+		indexStatements = append(indexStatements, generateMSSQLIndexStatement(table, &idx))
+	}
+	for _, col := range spatialColumns(table.Columns) {
+		indexStatements = append(indexStatements, generateMSSQLSpatialIndex(table.Name, col.Name))
+	}
+
+	// Combine CREATE TABLE with index statements
+	result := sb.String()
+	if len(indexStatements) > 0 {
+		result += ";\n" + strings.Join(indexStatements, ";\n")
+	}
+
+	return result
+}
+
+// mssqlQuotedColumns joins bracket-quoted column names.
+func mssqlQuotedColumns(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = "[" + n + "]"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// generateMSSQLIndexStatement generates a CREATE INDEX statement for SQL
+// Server. A unique index on nullable columns is filtered to non-null rows:
+// SQL Server otherwise admits a single NULL, where the other dialects admit
+// any number.
+func generateMSSQLIndexStatement(table *ddl.Table, idx *ddl.IndexDefinition) string {
+	var sb strings.Builder
+
+	if idx.Unique {
+		sb.WriteString("CREATE UNIQUE INDEX ")
+	} else {
+		sb.WriteString("CREATE INDEX ")
+	}
+
+	sb.WriteString(fmt.Sprintf("[%s] ON [%s] (%s)", idx.Name, table.Name, mssqlQuotedColumns(idx.Columns)))
+
+	if idx.Unique {
+		var filters []string
+		for _, name := range idx.Columns {
+			if col := findTableColumn(table, name); col != nil && col.Nullable {
+				filters = append(filters, fmt.Sprintf("[%s] IS NOT NULL", name))
+			}
+		}
+		if len(filters) > 0 {
+			sb.WriteString(" WHERE " + strings.Join(filters, " AND "))
+		}
+	}
+
+	return sb.String()
+}
+
+// findTableColumn returns the column of table called name, or nil.
+func findTableColumn(table *ddl.Table, name string) *ddl.ColumnDefinition {
+	for i := range table.Columns {
+		if table.Columns[i].Name == name {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
+
+// generateMSSQLAlterTable generates ALTER TABLE statements for SQL Server.
+// table is the table after ops; spatialBefore are its spatial columns before.
+func generateMSSQLAlterTable(tableName string, ops []ddl.TableOperation, table *ddl.Table, spatialBefore []ddl.ColumnDefinition) string {
+	var statements []string
+
+	for _, op := range ops {
+		stmt := generateMSSQLOperation(tableName, &op, table, spatialBefore)
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	return strings.Join(statements, ";\n")
+}
+
+// dropMSSQLDefault drops a column's default constraint if it has one.
+func dropMSSQLDefault(tableName, column string) string {
+	name := mssqlDefaultConstraint(tableName, column)
+	return fmt.Sprintf("IF OBJECT_ID('%s', 'D') IS NOT NULL ALTER TABLE [%s] DROP CONSTRAINT [%s]",
+		escapeMSSQLString(name), tableName, name)
+}
+
+// alterMSSQLColumn restates a column's type and nullability, as
+// ALTER COLUMN requires.
+func alterMSSQLColumn(tableName string, col *ddl.ColumnDefinition) string {
+	nullability := "NOT NULL"
+	if col.Nullable {
+		nullability = "NULL"
+	}
+	return fmt.Sprintf("ALTER TABLE [%s] ALTER COLUMN [%s] %s %s",
+		tableName, col.Name, mssqlType(col), nullability)
+}
+
+// generateMSSQLOperation generates a single ALTER TABLE operation
+func generateMSSQLOperation(tableName string, op *ddl.TableOperation, table *ddl.Table, spatialBefore []ddl.ColumnDefinition) string {
+	switch op.Type {
+	case ddl.OpAddColumn:
+		if op.ColumnDef == nil {
+			return ""
+		}
+		stmt := fmt.Sprintf("ALTER TABLE [%s] ADD %s",
+			tableName, generateMSSQLColumnDef(tableName, op.ColumnDef, false))
+		if ddl.IsSpatialType(op.ColumnDef.Type) {
+			stmt += ";\n" + generateMSSQLSpatialIndex(tableName, op.ColumnDef.Name)
+		}
+		return stmt
+
+	case ddl.OpDropColumn:
+		// The default and check constraints and spatial index depend on the
+		// column
+		stmts := []string{dropMSSQLDefault(tableName, op.Column), dropMSSQLCheck(tableName, op.Column), dropMSSQLColumnCheck(tableName, op.Column)}
+		for _, col := range spatialBefore {
+			if col.Name == op.Column {
+				stmts = append(stmts, fmt.Sprintf("DROP INDEX [%s] ON [%s]",
+					ddl.SpatialIndexName(tableName, col.Name), tableName))
+			}
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE [%s] DROP COLUMN [%s]", tableName, op.Column))
+		return strings.Join(stmts, ";\n")
+
+	case ddl.OpRenameColumn:
+		// Keep the default and check constraints named after the column
+		oldDefault := mssqlDefaultConstraint(tableName, op.Column)
+		oldCheck := mssqlCheckConstraint(tableName, op.Column)
+		return fmt.Sprintf("EXEC sp_rename '%s', '%s', 'COLUMN';\n"+
+			"IF OBJECT_ID('%s', 'D') IS NOT NULL EXEC sp_rename '%s', '%s', 'OBJECT';\n"+
+			"IF OBJECT_ID('%s', 'C') IS NOT NULL EXEC sp_rename '%s', '%s', 'OBJECT'",
+			escapeMSSQLString(tableName+"."+op.Column), escapeMSSQLString(op.NewName),
+			escapeMSSQLString(oldDefault), escapeMSSQLString(oldDefault),
+			escapeMSSQLString(mssqlDefaultConstraint(tableName, op.NewName)),
+			escapeMSSQLString(oldCheck), escapeMSSQLString(oldCheck),
+			escapeMSSQLString(mssqlCheckConstraint(tableName, op.NewName)))
+
+	case ddl.OpChangeType:
+		// An enum's values no longer apply to the new type
+		col := findTableColumn(table, op.Column)
+		if col == nil {
+			return ""
+		}
+		return dropMSSQLCheck(tableName, op.Column) + ";\n" + alterMSSQLColumnWithCheck(tableName, col)
+
+	case ddl.OpChangeNullable:
+		col := findTableColumn(table, op.Column)
+		if col == nil {
+			return ""
+		}
+		return alterMSSQLColumnWithCheck(tableName, col)
+
+	case ddl.OpChangeDefault:
+		stmt := dropMSSQLDefault(tableName, op.Column)
+		if op.Default == nil {
+			return stmt
+		}
+		// For simplicity, quote all defaults as strings (works for most cases),
+		// as Postgres and MySQL do
+		return stmt + fmt.Sprintf(";\nALTER TABLE [%s] ADD CONSTRAINT [%s] DEFAULT '%s' FOR [%s]",
+			tableName, mssqlDefaultConstraint(tableName, op.Column), escapeMSSQLString(*op.Default), op.Column)
+
+	case ddl.OpAddIndex:
+		if op.IndexDef == nil {
+			return ""
+		}
+		return generateMSSQLIndexStatement(table, op.IndexDef)
+
+	case ddl.OpDropIndex:
+		return fmt.Sprintf("DROP INDEX [%s] ON [%s]", op.IndexName, tableName)
+
+	case ddl.OpRenameIndex:
+		return fmt.Sprintf("EXEC sp_rename '%s', '%s', 'INDEX'",
+			escapeMSSQLString(tableName+"."+op.IndexName), escapeMSSQLString(op.NewName))
+
+	case ddl.OpAddCheck:
+		if op.CheckDef == nil {
+			return ""
+		}
+		return fmt.Sprintf("ALTER TABLE [%s] ADD CONSTRAINT [%s] CHECK (%s)",
+			tableName, op.CheckDef.Name, op.CheckDef.Expression)
+
+	case ddl.OpDropCheck:
+		return fmt.Sprintf("ALTER TABLE [%s] DROP CONSTRAINT [%s]", tableName, op.CheckName)
+
+	case ddl.OpAddEnumValues, ddl.OpDropEnumValues:
+		return generateMSSQLEnumValues(tableName, op)
+
+	default:
+		return ""
+	}
+}
+
+// mssqlTypeFromString converts a DDL type string to SQL Server type
+func mssqlTypeFromString(ddlType string) string {
+	switch ddlType {
+	case ddl.IntegerType:
+		return "INT"
+	case ddl.BigintType:
+		return "BIGINT"
+	case ddl.StringType:
+		return "NVARCHAR(255) " + mssqlCollation
+	case ddl.TextType:
+		return "NVARCHAR(MAX) " + mssqlCollation
+	case ddl.BooleanType:
+		return "BIT"
+	case ddl.DecimalType:
+		return "DECIMAL(10, 0)"
+	case ddl.FloatType:
+		return "FLOAT"
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
+		// Stored as UTC wall-clock time, like MySQL
+		return "DATETIME2(6)"
+	case ddl.BinaryType:
+		return "VARBINARY(MAX)"
+	case ddl.JSONType:
+		return "NVARCHAR(MAX)"
+	case ddl.PointType, ddl.PolygonType:
+		return "GEOGRAPHY"
+	default:
+		if t, ok := ddl.LookupType(ddlType); ok && t.MSSQL != "" {
+			return t.MSSQL
+		}
+		return "NVARCHAR(MAX)"
+	}
+}
+
+// generateMSSQLDropTable generates a DROP TABLE statement for SQL Server.
+func generateMSSQLDropTable(tableName string) string {
+	return fmt.Sprintf("DROP TABLE [%s]", tableName)
+}
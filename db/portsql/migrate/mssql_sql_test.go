@@ -0,0 +1,161 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestMSSQL_CreateTable(t *testing.T) {
+	tb := ddl.MakeEmptyTable("users")
+	tb.Bigint("id").PrimaryKey()
+	tb.String("email").Unique()
+	tb.String("nickname").Nullable().Unique()
+	tb.Text("bio").Nullable()
+	tb.Bool("active").Default(true)
+	tb.Decimal("balance", 12, 2)
+	tb.Datetime("joined_at")
+	tb.Binary("avatar").Nullable()
+	tb.JSON("settings")
+	table := tb.Build()
+
+	sql := generateMSSQLCreateTable(table)
+
+	// SQL Server admits one NULL in a unique index unless it is filtered.
+	want := "CREATE TABLE [users] (" +
+		"[id] BIGINT IDENTITY(1,1) PRIMARY KEY, " +
+		"[email] NVARCHAR(255) COLLATE Latin1_General_100_BIN2 NOT NULL, " +
+		"[nickname] NVARCHAR(255) COLLATE Latin1_General_100_BIN2 NULL, " +
+		"[bio] NVARCHAR(MAX) COLLATE Latin1_General_100_BIN2 NULL, " +
+		"[active] BIT NOT NULL CONSTRAINT [DF_users_active] DEFAULT 1, " +
+		"[balance] DECIMAL(12, 2) NOT NULL, " +
+		"[joined_at] DATETIME2(6) NOT NULL, " +
+		"[avatar] VARBINARY(MAX) NULL, " +
+		"[settings] NVARCHAR(MAX) NOT NULL);\n" +
+		"CREATE UNIQUE INDEX [idx_users_email] ON [users] ([email]);\n" +
+		"CREATE UNIQUE INDEX [idx_users_nickname] ON [users] ([nickname]) WHERE [nickname] IS NOT NULL"
+	if sql != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sql, want)
+	}
+}
+
+func TestMSSQL_CreateTable_CompositePK(t *testing.T) {
+	tb := ddl.MakeEmptyTable("user_roles")
+	tb.Bigint("user_id").PrimaryKey()
+	tb.Bigint("role_id").PrimaryKey()
+	table := tb.Build()
+
+	sql := generateMSSQLCreateTable(table)
+
+	if strings.Contains(sql, "IDENTITY") {
+		t.Errorf("composite PK should not have IDENTITY, got:\n%s", sql)
+	}
+	if !strings.Contains(sql, ", PRIMARY KEY ([user_id], [role_id]))") {
+		t.Errorf("expected table-level PRIMARY KEY, got:\n%s", sql)
+	}
+}
+
+func TestMSSQL_CreateTable_Point(t *testing.T) {
+	tb := ddl.MakeEmptyTable("stores")
+	tb.Bigint("id").PrimaryKey()
+	tb.Point("location")
+	table := tb.Build()
+
+	sql := generateMSSQLCreateTable(table)
+
+	if !strings.Contains(sql, "[location] GEOGRAPHY NOT NULL") {
+		t.Errorf("expected GEOGRAPHY column, got:\n%s", sql)
+	}
+	want := "CREATE SPATIAL INDEX [" + ddl.SpatialIndexName("stores", "location") + "] ON [stores] ([location])"
+	if !strings.Contains(sql, want) {
+		t.Errorf("expected %q, got:\n%s", want, sql)
+	}
+}
+
+func TestMSSQL_AlterTable(t *testing.T) {
+	table := &ddl.Table{
+		Name: "users",
+		Columns: []ddl.ColumnDefinition{
+			{Name: "count", Type: ddl.BigintType},
+			{Name: "bio", Type: ddl.TextType, Nullable: true},
+		},
+	}
+	nullable := true
+
+	tests := []struct {
+		name string
+		op   ddl.TableOperation
+		want []string
+	}{
+		{
+			name: "add column",
+			op:   ddl.TableOperation{Type: ddl.OpAddColumn, ColumnDef: &ddl.ColumnDefinition{Name: "email", Type: ddl.StringType, Length: intPtr(100)}},
+			want: []string{"ALTER TABLE [users] ADD [email] NVARCHAR(100) COLLATE Latin1_General_100_BIN2 NOT NULL"},
+		},
+		{
+			name: "drop column drops its default first",
+			op:   ddl.TableOperation{Type: ddl.OpDropColumn, Column: "legacy"},
+			want: []string{
+				"IF OBJECT_ID('DF_users_legacy', 'D') IS NOT NULL ALTER TABLE [users] DROP CONSTRAINT [DF_users_legacy];\n",
+				"ALTER TABLE [users] DROP COLUMN [legacy]",
+			},
+		},
+		{
+			name: "rename column",
+			op:   ddl.TableOperation{Type: ddl.OpRenameColumn, Column: "name", NewName: "full_name"},
+			want: []string{
+				"EXEC sp_rename 'users.name', 'full_name', 'COLUMN'",
+				"EXEC sp_rename 'DF_users_name', 'DF_users_full_name', 'OBJECT'",
+			},
+		},
+		{
+			name: "change type restates nullability",
+			op:   ddl.TableOperation{Type: ddl.OpChangeType, Column: "count", NewType: ddl.BigintType},
+			want: []string{"ALTER TABLE [users] ALTER COLUMN [count] BIGINT NOT NULL"},
+		},
+		{
+			name: "change nullable restates type",
+			op:   ddl.TableOperation{Type: ddl.OpChangeNullable, Column: "bio", Nullable: &nullable},
+			want: []string{"ALTER TABLE [users] ALTER COLUMN [bio] NVARCHAR(MAX) COLLATE Latin1_General_100_BIN2 NULL"},
+		},
+		{
+			name: "set default",
+			op:   ddl.TableOperation{Type: ddl.OpChangeDefault, Column: "status", Default: strPtr("it's")},
+			want: []string{"ALTER TABLE [users] ADD CONSTRAINT [DF_users_status] DEFAULT 'it''s' FOR [status]"},
+		},
+		{
+			name: "drop index",
+			op:   ddl.TableOperation{Type: ddl.OpDropIndex, IndexName: "idx_users_email"},
+			want: []string{"DROP INDEX [idx_users_email] ON [users]"},
+		},
+		{
+			name: "rename index",
+			op:   ddl.TableOperation{Type: ddl.OpRenameIndex, IndexName: "idx_old", NewName: "idx_new"},
+			want: []string{"EXEC sp_rename 'users.idx_old', 'idx_new', 'INDEX'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql := generateMSSQLAlterTable("users", []ddl.TableOperation{tt.op}, table, nil)
+			for _, want := range tt.want {
+				if !strings.Contains(sql, want) {
+					t.Errorf("expected %q, got:\n%s", want, sql)
+				}
+			}
+		})
+	}
+}
+
+func TestMSSQL_AlterTable_DropPointColumn(t *testing.T) {
+	points := []ddl.ColumnDefinition{{Name: "location", Type: ddl.PointType}}
+	ops := []ddl.TableOperation{{Type: ddl.OpDropColumn, Column: "location"}}
+
+	sql := generateMSSQLAlterTable("stores", ops, &ddl.Table{Name: "stores"}, points)
+
+	dropIndex := "DROP INDEX [" + ddl.SpatialIndexName("stores", "location") + "] ON [stores]"
+	dropColumn := "ALTER TABLE [stores] DROP COLUMN [location]"
+	if i, j := strings.Index(sql, dropIndex), strings.Index(sql, dropColumn); i < 0 || j < i {
+		t.Errorf("expected the spatial index dropped before the column, got:\n%s", sql)
+	}
+}
@@ -14,6 +14,9 @@ import (
 
 // mysqlType maps DDL types to MySQL types
 func mysqlType(col *ddl.ColumnDefinition) string {
+	if col.Custom != nil {
+		return col.Custom.MySQL
+	}
 	switch col.Type {
 	case ddl.IntegerType:
 		return "INT"
@@ -46,10 +49,20 @@ func mysqlType(col *ddl.ColumnDefinition) string {
 		return "DATETIME(3)"
 	case ddl.TimestampType:
 		return "TIMESTAMP"
+	case ddl.TimestamptzType:
+		// Stored as UTC wall-clock time; the generated runner converts on
+		// the way in and out, independent of the connection's loc.
+		return "DATETIME(6)"
 	case ddl.BinaryType:
 		return "BLOB"
 	case ddl.JSONType:
 		return "JSON"
+	case ddl.PointType:
+		return "POINT SRID 4326"
+	case ddl.PolygonType:
+		return "POLYGON SRID 4326"
+	case ddl.EnumType:
+		return fmt.Sprintf("ENUM(%s)", enumValueList(col.EnumValues, ""))
 	default:
 		return "TEXT"
 	}
@@ -71,6 +84,10 @@ func formatMySQLDefault(col *ddl.ColumnDefinition) string {
 	// CURRENT_TIMESTAMP is a special sentinel for auto-managed timestamp columns.
 	// MySQL supports CURRENT_TIMESTAMP as an unquoted keyword for DATETIME/TIMESTAMP defaults.
 	if defaultVal == "CURRENT_TIMESTAMP" {
+		if col.Type == ddl.TimestamptzType {
+			// CURRENT_TIMESTAMP follows the session time zone
+			return "(UTC_TIMESTAMP(6))"
+		}
 		return "CURRENT_TIMESTAMP(3)"
 	}
 
@@ -124,6 +141,11 @@ func generateMySQLColumnDef(col *ddl.ColumnDefinition, isAutoincrementPK bool) s
 		parts = append(parts, "DEFAULT", formatMySQLDefault(col))
 	}
 
+	// CHECK constraints are enforced from MySQL 8.0.16
+	if col.Check != "" {
+		parts = append(parts, columnCheck(col))
+	}
+
 	return strings.Join(parts, " ")
 }
 
@@ -138,14 +160,22 @@ func generateMySQLCreateTable(table *ddl.Table) string {
 	sb.WriteString(fmt.Sprintf("CREATE TABLE `%s` (", table.Name))
 
 	// Columns
+	compositePK := compositePrimaryKey(table)
 	for i, col := range table.Columns {
 		if i > 0 {
 			sb.WriteString(", ")
 		}
+		if compositePK != nil {
+			col.PrimaryKey = false
+		}
 		// Determine if this column is the autoincrement PK
 		isAutoincrementPK := hasAutoincrementPK && col.Name == pkInfo.ColumnName
 		sb.WriteString(generateMySQLColumnDef(&col, isAutoincrementPK))
 	}
+	if compositePK != nil {
+		sb.WriteString(fmt.Sprintf(", PRIMARY KEY (%s)", quotedColumns(compositePK, "`")))
+	}
+	sb.WriteString(tableChecks(table.Checks, "`", "`"))
 
 	sb.WriteString(") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin")
 
@@ -154,6 +184,11 @@ func generateMySQLCreateTable(table *ddl.Table) string {
 	for _, idx := range table.Indexes {
 		indexStatements = append(indexStatements, generateMySQLIndexStatement(table.Name, &idx))
 	}
+	for _, col := range spatialColumns(table.Columns) {
+		if stmt := generateMySQLSpatialIndex(table.Name, &col); stmt != "" {
+			indexStatements = append(indexStatements, stmt)
+		}
+	}
 
 	// Combine CREATE TABLE with index statements
 	result := sb.String()
@@ -213,8 +248,14 @@ func generateMySQLOperation(tableName string, op *ddl.TableOperation) string {
 		}
 		// ALTER TABLE ADD COLUMN does not support autoincrement
 		// (that would require altering to AUTO_INCREMENT column separately)
-		return fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s",
+		stmt := fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s",
 			tableName, generateMySQLColumnDef(op.ColumnDef, false))
+		if ddl.IsSpatialType(op.ColumnDef.Type) {
+			if idx := generateMySQLSpatialIndex(tableName, op.ColumnDef); idx != "" {
+				stmt += ";\n" + idx
+			}
+		}
+		return stmt
 
 	case ddl.OpDropColumn:
 		return fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`",
@@ -268,6 +309,25 @@ func generateMySQLOperation(tableName string, op *ddl.TableOperation) string {
 		return fmt.Sprintf("ALTER TABLE `%s` RENAME INDEX `%s` TO `%s`",
 			tableName, op.IndexName, op.NewName)
 
+	case ddl.OpAddCheck:
+		if op.CheckDef == nil {
+			return ""
+		}
+		return fmt.Sprintf("ALTER TABLE `%s` ADD CONSTRAINT `%s` CHECK (%s)",
+			tableName, op.CheckDef.Name, op.CheckDef.Expression)
+
+	case ddl.OpDropCheck:
+		// MySQL 8.0.19+ syntax
+		return fmt.Sprintf("ALTER TABLE `%s` DROP CHECK `%s`", tableName, op.CheckName)
+
+	case ddl.OpAddEnumValues, ddl.OpDropEnumValues:
+		// MODIFY COLUMN restates the whole column with the new ENUM list
+		if op.ColumnDef == nil {
+			return ""
+		}
+		return fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN %s",
+			tableName, generateMySQLColumnDef(op.ColumnDef, false))
+
 	default:
 		return ""
 	}
@@ -294,11 +354,20 @@ func mysqlTypeFromString(ddlType string) string {
 		return "DATETIME(3)"
 	case ddl.TimestampType:
 		return "TIMESTAMP"
+	case ddl.TimestamptzType:
+		return "DATETIME(6)"
 	case ddl.BinaryType:
 		return "BLOB"
 	case ddl.JSONType:
 		return "JSON"
+	case ddl.PointType:
+		return "POINT SRID 4326"
+	case ddl.PolygonType:
+		return "POLYGON SRID 4326"
 	default:
+		if t, ok := ddl.LookupType(ddlType); ok {
+			return t.MySQL
+		}
 		return "TEXT"
 	}
 }
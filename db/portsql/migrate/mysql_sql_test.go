@@ -227,6 +227,23 @@ func TestMySQL_CreateTable_Timestamp(t *testing.T) {
 	}
 }
 
+func TestMySQL_CreateTable_Timestamptz(t *testing.T) {
+	tb := ddl.MakeEmptyTable("test_table")
+	tb.Timestamptz("occurred_at")
+	tb.Timestamptz("recorded_at").Default("CURRENT_TIMESTAMP")
+	table := tb.Build()
+
+	sql := generateMySQLCreateTable(table)
+
+	if !strings.Contains(sql, "`occurred_at` DATETIME(6) NOT NULL") {
+		t.Errorf("expected DATETIME(6) column, got:\n%s", sql)
+	}
+	// CURRENT_TIMESTAMP would use the session time zone
+	if !strings.Contains(sql, "`recorded_at` DATETIME(6) NOT NULL DEFAULT (UTC_TIMESTAMP(6))") {
+		t.Errorf("expected UTC_TIMESTAMP default, got:\n%s", sql)
+	}
+}
+
 func TestMySQL_CreateTable_Binary(t *testing.T) {
 	tb := ddl.MakeEmptyTable("test_table")
 	tb.Binary("data")
@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AddNamespace declares a Postgres schema or MySQL database, outside the
+// migrated schema, whose tables queries may read through
+// query.ExternalTable. shipq neither creates nor migrates it, so no
+// migration SQL is recorded; `shipq db compile` only checks that every
+// schema-qualified table a query names is in a declared namespace.
+func (m *MigrationPlan) AddNamespace(name string) error {
+	if !isValidNamespace(name) {
+		return fmt.Errorf("invalid namespace %q (use letters, digits and '_', not starting with a digit)", name)
+	}
+	if slices.Contains(m.Schema.Namespaces, name) {
+		return fmt.Errorf("namespace %q already declared", name)
+	}
+	m.Schema.Namespaces = append(m.Schema.Namespaces, name)
+	slices.Sort(m.Schema.Namespaces)
+	return nil
+}
+
+// DropNamespace removes a namespace declared by AddNamespace.
+func (m *MigrationPlan) DropNamespace(name string) error {
+	i := slices.Index(m.Schema.Namespaces, name)
+	if i < 0 {
+		return fmt.Errorf("namespace %q not declared", name)
+	}
+	m.Schema.Namespaces = slices.Delete(m.Schema.Namespaces, i, i+1)
+	if len(m.Schema.Namespaces) == 0 {
+		m.Schema.Namespaces = nil
+	}
+	return nil
+}
+
+func isValidNamespace(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddNamespace(t *testing.T) {
+	plan := NewPlan()
+	for _, name := range []string{"billing", "audit"} {
+		if err := plan.AddNamespace(name); err != nil {
+			t.Fatalf("AddNamespace(%q) failed: %v", name, err)
+		}
+	}
+	if got := strings.Join(plan.Schema.Namespaces, ","); got != "audit,billing" {
+		t.Errorf("Namespaces = %q, want sorted", got)
+	}
+	if len(plan.Migrations) != 0 {
+		t.Error("declaring a namespace should not record a migration")
+	}
+	for _, name := range []string{"billing", "", "1st", "bil-ling", "a.b"} {
+		if err := plan.AddNamespace(name); err == nil {
+			t.Errorf("AddNamespace(%q) should fail", name)
+		}
+	}
+
+	data, err := plan.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := PlanFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.DropNamespace("audit"); err != nil {
+		t.Fatalf("DropNamespace failed: %v", err)
+	}
+	if got := strings.Join(loaded.Schema.Namespaces, ","); got != "billing" {
+		t.Errorf("Namespaces after drop = %q, want billing", got)
+	}
+	if err := loaded.DropNamespace("audit"); err == nil {
+		t.Error("dropping an undeclared namespace should fail")
+	}
+}
@@ -2,6 +2,8 @@ package migrate
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -88,6 +90,9 @@ func ValidateMigrationName(name string) error {
 type Schema struct {
 	Name   string               `json:"name"`
 	Tables map[string]ddl.Table `json:"tables"`
+	// Namespaces are the schemas (Postgres) or databases (MySQL) declared
+	// with AddNamespace, sorted.
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 // currentMigrationName holds the name set by SetCurrentMigration.
@@ -98,17 +103,29 @@ const (
 	Sqlite   = "sqlite"
 	Postgres = "postgres"
 	MySQL    = "mysql"
+	MSSQL    = "mssql"
 )
 
 type MigrationInstructions struct {
 	Sqlite   string `json:"sqlite"`
 	Postgres string `json:"postgres"`
 	MySQL    string `json:"mysql"`
+	MSSQL    string `json:"mssql"`
 }
 
 type Migration struct {
 	Instructions MigrationInstructions `json:"instructions"`
 	Name         string                `json:"name"`
+	// DataChecks run before Instructions; see DataCheck.
+	DataChecks []DataCheck `json:"data_checks,omitempty"`
+}
+
+// DataCheck is a query Run executes before applying a migration. When it
+// returns a row the migration is not applied and Run fails with Message,
+// e.g. while rows still hold an enum value the migration removes.
+type DataCheck struct {
+	Query   MigrationInstructions `json:"query"`
+	Message string                `json:"message"`
 }
 
 type MigrationPlan struct {
@@ -180,6 +197,24 @@ func (m *MigrationPlan) AddEmptyTable(name string, fn func(*ddl.TableBuilder) er
 		}
 	}
 
+	if err := validateRetention(table); err != nil {
+		return nil, err
+	}
+	if err := validateLabels(table); err != nil {
+		return nil, err
+	}
+	if err := validatePrimaryKey(table); err != nil {
+		return nil, err
+	}
+	if err := ddl.ValidateCustomColumns(table); err != nil {
+		return nil, err
+	}
+	if err := ddl.ValidateEnumColumns(table); err != nil {
+		return nil, err
+	}
+	if err := ddl.ValidateChecks(table); err != nil {
+		return nil, err
+	}
 	m.Schema.Tables[name] = *table
 
 	// Generate SQL for each database with properly timestamped migration name
@@ -189,12 +224,40 @@ func (m *MigrationPlan) AddEmptyTable(name string, fn func(*ddl.TableBuilder) er
 			Postgres: generatePostgresCreateTable(table),
 			MySQL:    generateMySQLCreateTable(table),
 			Sqlite:   generateSQLiteCreateTable(table),
+			MSSQL:    generateMSSQLCreateTable(table),
 		},
 	})
 
 	return m, nil
 }
 
+// validatePrimaryKey checks that no primary key column is nullable.
+func validatePrimaryKey(table *ddl.Table) error {
+	for _, col := range table.Columns {
+		if col.PrimaryKey && col.Nullable {
+			return fmt.Errorf("table %q: primary key column %q cannot be nullable", table.Name, col.Name)
+		}
+	}
+	return nil
+}
+
+// validateRetention checks that a table with a RetainFor policy has the
+// created_at column its purge query filters on.
+func validateRetention(table *ddl.Table) error {
+	if table.RetentionSeconds < 0 {
+		return fmt.Errorf("table %q: retention must be positive", table.Name)
+	}
+	if table.RetentionSeconds == 0 {
+		return nil
+	}
+	for _, col := range table.Columns {
+		if col.Name == "created_at" && ddl.IsTimeType(col.Type) {
+			return nil
+		}
+	}
+	return fmt.Errorf("table %q: RetainFor requires a created_at time column", table.Name)
+}
+
 // authTableNames contains tables that should NOT receive the automatic
 // author_account_id column. This includes:
 //   - Tables generated by `shipq auth` (accounts, organizations, etc.)
@@ -243,6 +306,24 @@ func (m *MigrationPlan) AddTable(name string, fn func(*ddl.TableBuilder) error)
 
 	// Add the built table to the schema
 	table := tb.Build()
+	if pk := table.PrimaryKeyColumns(); len(pk) != 1 || pk[0] != "id" {
+		return nil, fmt.Errorf("table %q: AddTable tables are keyed by id; declare other primary keys with AddEmptyTable", name)
+	}
+	if err := validateRetention(table); err != nil {
+		return nil, err
+	}
+	if err := validateLabels(table); err != nil {
+		return nil, err
+	}
+	if err := ddl.ValidateCustomColumns(table); err != nil {
+		return nil, err
+	}
+	if err := ddl.ValidateEnumColumns(table); err != nil {
+		return nil, err
+	}
+	if err := ddl.ValidateChecks(table); err != nil {
+		return nil, err
+	}
 	m.Schema.Tables[name] = *table
 
 	// Generate SQL for each database with properly timestamped migration name
@@ -252,6 +333,7 @@ func (m *MigrationPlan) AddTable(name string, fn func(*ddl.TableBuilder) error)
 			Postgres: generatePostgresCreateTable(table),
 			MySQL:    generateMySQLCreateTable(table),
 			Sqlite:   generateSQLiteCreateTable(table),
+			MSSQL:    generateMSSQLCreateTable(table),
 		},
 	})
 
@@ -269,10 +351,36 @@ func (m *MigrationPlan) UpdateTable(tableName string, fn func(*ddl.AlterTableBui
 	if err := fn(alt); err != nil {
 		return err
 	}
+	spatialBefore := spatialColumns(table.Columns)
+	enumsBefore := enumColumns(table.Columns)
 
 	// Apply operations to the schema
 	operations := alt.Build()
 	for _, op := range operations {
+		if op.Type == ddl.OpAddColumn && op.ColumnDef != nil {
+			if err := ddl.ValidateCustomColumn(tableName, op.ColumnDef); err != nil {
+				return err
+			}
+			if err := ddl.ValidateEnumColumn(tableName, op.ColumnDef); err != nil {
+				return err
+			}
+			if err := ddl.ValidateColumnCheck(tableName, op.ColumnDef); err != nil {
+				return err
+			}
+		}
+		if op.Type == ddl.OpRenameColumn {
+			// The check's expression names the column, and MySQL refuses
+			// to rename a column a CHECK constraint uses
+			if col := findTableColumn(&table, op.Column); col != nil && col.Check != "" {
+				return fmt.Errorf("table %q: column %q has a check and can't be renamed", tableName, op.Column)
+			}
+		}
+	}
+	// Columns renamed so far, by new name, for data checks that run
+	// before the migration and so use the old name
+	renamedFrom := map[string]string{}
+	var dataChecks []DataCheck
+	for i, op := range operations {
 		switch op.Type {
 		case ddl.OpAddColumn:
 			if op.ColumnDef != nil {
@@ -293,6 +401,31 @@ func (m *MigrationPlan) UpdateTable(tableName string, fn func(*ddl.AlterTableBui
 					break
 				}
 			}
+			renamedFrom[op.NewName] = op.Column
+			if orig, ok := renamedFrom[op.Column]; ok {
+				renamedFrom[op.NewName] = orig
+			}
+		case ddl.OpAddEnumValues, ddl.OpDropEnumValues:
+			col := findTableColumn(&table, op.Column)
+			if col == nil {
+				return fmt.Errorf("table %q: column %q not found", tableName, op.Column)
+			}
+			updated, err := ddl.ApplyEnumValues(tableName, *col, &op)
+			if err != nil {
+				return err
+			}
+			*col = updated
+			operations[i].ColumnDef = &updated
+			// Only a column that existed before the migration can hold
+			// the values being dropped
+			column := op.Column
+			if orig, ok := renamedFrom[column]; ok {
+				column = orig
+			}
+			existed := slices.ContainsFunc(enumsBefore, func(c ddl.ColumnDefinition) bool { return c.Name == column })
+			if op.Type == ddl.OpDropEnumValues && existed {
+				dataChecks = append(dataChecks, enumRemovalCheck(tableName, column, op.EnumValues))
+			}
 		case ddl.OpAddIndex:
 			if op.IndexDef != nil {
 				table.Indexes = append(table.Indexes, *op.IndexDef)
@@ -316,6 +449,11 @@ func (m *MigrationPlan) UpdateTable(tableName string, fn func(*ddl.AlterTableBui
 			for i, col := range table.Columns {
 				if col.Name == op.Column {
 					table.Columns[i].Type = op.NewType
+					table.Columns[i].Custom = nil
+					table.Columns[i].EnumValues = nil
+					if t, ok := ddl.LookupType(op.NewType); ok {
+						table.Columns[i].Custom = &t
+					}
 					break
 				}
 			}
@@ -335,8 +473,19 @@ func (m *MigrationPlan) UpdateTable(tableName string, fn func(*ddl.AlterTableBui
 					break
 				}
 			}
+		case ddl.OpAddCheck:
+			if op.CheckDef != nil {
+				table.Checks = append(slices.Clone(table.Checks), *op.CheckDef)
+			}
+		case ddl.OpDropCheck:
+			table.Checks = slices.DeleteFunc(slices.Clone(table.Checks), func(c ddl.CheckDefinition) bool {
+				return c.Name == op.CheckName
+			})
 		}
 	}
+	if err := ddl.ValidateChecks(&table); err != nil {
+		return err
+	}
 
 	// Update the table in the schema
 	m.Schema.Tables[tableName] = table
@@ -346,32 +495,111 @@ func (m *MigrationPlan) UpdateTable(tableName string, fn func(*ddl.AlterTableBui
 	m.Migrations = append(m.Migrations, Migration{
 		Name: consumeCurrentMigrationName("alter", tableName),
 		Instructions: MigrationInstructions{
-			Postgres: generatePostgresAlterTable(tableName, operations),
+			Postgres: generatePostgresUpdateTable(tableName, operations, enumsBefore),
 			MySQL:    generateMySQLAlterTable(tableName, operations),
-			Sqlite:   generateSQLiteAlterTable(tableName, operations, &table),
+			Sqlite:   generateSQLiteUpdateTable(tableName, operations, &table, spatialBefore),
+			MSSQL:    generateMSSQLAlterTable(tableName, operations, &table, spatialBefore),
 		},
+		DataChecks: dataChecks,
 	})
 
 	return nil
 }
 
+// RenameTable renames a table in the schema. References columns of other
+// tables are pointed at the new name, and the objects named after the table
+// (its idx_<table>_ indexes, spatial indexes and R*Trees, Postgres enum
+// types and SQL Server constraints) are renamed with it, so later
+// migrations and generated code find them under the new name.
+func (m *MigrationPlan) RenameTable(oldName, newName string) (*MigrationPlan, error) {
+	table, ok := m.Schema.Tables[oldName]
+	if !ok {
+		return nil, fmt.Errorf("table %q not found in schema", oldName)
+	}
+	if newName == "" {
+		return nil, fmt.Errorf("new name for table %q is empty", oldName)
+	}
+	if _, exists := m.Schema.Tables[newName]; exists {
+		return nil, fmt.Errorf("table %q already exists in schema", newName)
+	}
+
+	renamed := table
+	renamed.Name = newName
+	renamed.Columns = slices.Clone(table.Columns)
+	renamed.Indexes = slices.Clone(table.Indexes)
+	var indexes []indexRename
+	for i, idx := range renamed.Indexes {
+		if rest, ok := strings.CutPrefix(idx.Name, "idx_"+oldName+"_"); ok {
+			renamed.Indexes[i].Name = "idx_" + newName + "_" + rest
+			indexes = append(indexes, indexRename{Old: idx.Name, Index: renamed.Indexes[i]})
+		}
+	}
+
+	delete(m.Schema.Tables, oldName)
+	m.Schema.Tables[newName] = renamed
+	for name, t := range m.Schema.Tables {
+		if !slices.ContainsFunc(t.Columns, func(c ddl.ColumnDefinition) bool { return c.References == oldName }) {
+			continue
+		}
+		t.Columns = slices.Clone(t.Columns)
+		for i := range t.Columns {
+			if t.Columns[i].References == oldName {
+				t.Columns[i].References = newName
+			}
+		}
+		m.Schema.Tables[name] = t
+	}
+
+	m.Migrations = append(m.Migrations, Migration{
+		Name: consumeCurrentMigrationName("rename", oldName),
+		Instructions: MigrationInstructions{
+			Postgres: generatePostgresRenameTable(oldName, &renamed, indexes),
+			MySQL:    generateMySQLRenameTable(oldName, newName, indexes),
+			Sqlite:   generateSQLiteRenameTable(oldName, &renamed, indexes),
+			MSSQL:    generateMSSQLRenameTable(oldName, &renamed, indexes),
+		},
+	})
+
+	return m, nil
+}
+
+// indexRename is an index of a renamed table whose name embeds the table's.
+type indexRename struct {
+	Old   string              // name before the rename
+	Index ddl.IndexDefinition // the index under its new name
+}
+
 // DropTable removes a table from the schema and returns a new plan with the table removed.
 func (m *MigrationPlan) DropTable(name string) (*MigrationPlan, error) {
 	// Verify table exists
-	if _, ok := m.Schema.Tables[name]; !ok {
+	table, ok := m.Schema.Tables[name]
+	if !ok {
 		return nil, fmt.Errorf("table %q not found in schema", name)
 	}
 
 	// Delete from schema
 	delete(m.Schema.Tables, name)
 
+	// SQLite R*Trees are separate tables and outlive the table they index
+	sqliteSQL := generateSQLiteDropTable(name)
+	for _, col := range spatialColumns(table.Columns) {
+		sqliteSQL += ";\n" + strings.Join(generateSQLiteRTreeDrop(name, col.Name), ";\n")
+	}
+
+	// Postgres enum types outlive the table whose columns use them
+	postgresSQL := generatePostgresDropTable(name)
+	for _, col := range enumColumns(table.Columns) {
+		postgresSQL += ";\n" + generatePostgresDropEnumType(name, col.Name)
+	}
+
 	// Generate SQL for each database
 	m.Migrations = append(m.Migrations, Migration{
 		Name: fmt.Sprintf("drop_%s_table", name),
 		Instructions: MigrationInstructions{
-			Postgres: generatePostgresDropTable(name),
+			Postgres: postgresSQL,
 			MySQL:    generateMySQLDropTable(name),
-			Sqlite:   generateSQLiteDropTable(name),
+			Sqlite:   sqliteSQL,
+			MSSQL:    generateMSSQLDropTable(name),
 		},
 	})
 
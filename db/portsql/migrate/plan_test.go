@@ -1776,3 +1776,41 @@ func TestAddTable_DoesNotAddAuthorAccountID_ToJunctionTables(t *testing.T) {
 		t.Errorf("expected junction table to have exactly 2 References columns, got %d", refCount)
 	}
 }
+
+func TestAddTable_RetainFor(t *testing.T) {
+	plan := NewPlan()
+	if _, err := plan.AddTable("audit_events", func(tb *ddl.TableBuilder) error {
+		tb.String("action")
+		tb.RetainFor(90 * 24 * time.Hour)
+		return nil
+	}); err != nil {
+		t.Fatalf("AddTable failed: %v", err)
+	}
+	table := plan.Schema.Tables["audit_events"]
+	if got := table.Retention(); got != 90*24*time.Hour {
+		t.Errorf("Retention() = %v, want 2160h", got)
+	}
+	if table.RetentionSeconds != 7776000 {
+		t.Errorf("RetentionSeconds = %d, want 7776000", table.RetentionSeconds)
+	}
+}
+
+func TestAddEmptyTable_RetainForRequiresCreatedAt(t *testing.T) {
+	plan := NewPlan()
+	_, err := plan.AddEmptyTable("events", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.RetainFor(time.Hour)
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "created_at") {
+		t.Errorf("expected created_at error, got %v", err)
+	}
+
+	_, err = plan.AddTable("bad_retention", func(tb *ddl.TableBuilder) error {
+		tb.RetainFor(-time.Hour)
+		return nil
+	})
+	if err == nil {
+		t.Error("expected error for negative retention")
+	}
+}
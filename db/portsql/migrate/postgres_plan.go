@@ -13,8 +13,13 @@ import (
 )
 
 // postgresTypeMap maps DDL types to PostgreSQL types
-func postgresType(col *ddl.ColumnDefinition) string {
+func postgresType(tableName string, col *ddl.ColumnDefinition) string {
+	if col.Custom != nil {
+		return col.Custom.Postgres
+	}
 	switch col.Type {
+	case ddl.EnumType:
+		return fmt.Sprintf(`"%s"`, ddl.EnumTypeName(tableName, col.Name))
 	case ddl.IntegerType:
 		return "INTEGER"
 	case ddl.BigintType:
@@ -44,12 +49,16 @@ func postgresType(col *ddl.ColumnDefinition) string {
 		return fmt.Sprintf("DECIMAL(%d, %d)", precision, scale)
 	case ddl.FloatType:
 		return "DOUBLE PRECISION"
-	case ddl.DatetimeType, ddl.TimestampType:
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
 		return "TIMESTAMP WITH TIME ZONE"
 	case ddl.BinaryType:
 		return "BYTEA"
 	case ddl.JSONType:
 		return "JSONB"
+	case ddl.PointType:
+		return "GEOGRAPHY(Point, 4326)"
+	case ddl.PolygonType:
+		return "GEOGRAPHY(Polygon, 4326)"
 	default:
 		return "TEXT"
 	}
@@ -92,7 +101,7 @@ func formatPostgresDefault(col *ddl.ColumnDefinition) string {
 
 // generatePostgresColumnDef generates a column definition for CREATE TABLE.
 // isAutoincrementPK should be true if this column is the autoincrement-eligible primary key.
-func generatePostgresColumnDef(col *ddl.ColumnDefinition, isAutoincrementPK bool) string {
+func generatePostgresColumnDef(tableName string, col *ddl.ColumnDefinition, isAutoincrementPK bool) string {
 	var parts []string
 
 	// Column name (double-quoted)
@@ -102,9 +111,9 @@ func generatePostgresColumnDef(col *ddl.ColumnDefinition, isAutoincrementPK bool
 	if isAutoincrementPK {
 		// Use SQL-standard identity columns for autoincrement PKs
 		// GENERATED BY DEFAULT AS IDENTITY allows explicit inserts while providing auto-generation
-		parts = append(parts, postgresType(col), "GENERATED BY DEFAULT AS IDENTITY")
+		parts = append(parts, postgresType(tableName, col), "GENERATED BY DEFAULT AS IDENTITY")
 	} else {
-		parts = append(parts, postgresType(col))
+		parts = append(parts, postgresType(tableName, col))
 	}
 
 	// NOT NULL (only if not nullable and not primary key - PK implies NOT NULL)
@@ -122,6 +131,10 @@ func generatePostgresColumnDef(col *ddl.ColumnDefinition, isAutoincrementPK bool
 		parts = append(parts, "DEFAULT", formatPostgresDefault(col))
 	}
 
+	if col.Check != "" {
+		parts = append(parts, columnCheck(col))
+	}
+
 	return strings.Join(parts, " ")
 }
 
@@ -136,14 +149,22 @@ func generatePostgresCreateTable(table *ddl.Table) string {
 	sb.WriteString(fmt.Sprintf(`CREATE TABLE "%s" (`, table.Name))
 
 	// Columns
+	compositePK := compositePrimaryKey(table)
 	for i, col := range table.Columns {
 		if i > 0 {
 			sb.WriteString(", ")
 		}
+		if compositePK != nil {
+			col.PrimaryKey = false
+		}
 		// Determine if this column is the autoincrement PK
 		isAutoincrementPK := hasAutoincrementPK && col.Name == pkInfo.ColumnName
-		sb.WriteString(generatePostgresColumnDef(&col, isAutoincrementPK))
+		sb.WriteString(generatePostgresColumnDef(table.Name, &col, isAutoincrementPK))
 	}
+	if compositePK != nil {
+		sb.WriteString(fmt.Sprintf(", PRIMARY KEY (%s)", quotedColumns(compositePK, `"`)))
+	}
+	sb.WriteString(tableChecks(table.Checks, `"`, `"`))
 
 	sb.WriteString(")")
 
@@ -152,9 +173,20 @@ func generatePostgresCreateTable(table *ddl.Table) string {
 	for _, idx := range table.Indexes {
 		indexStatements = append(indexStatements, generatePostgresIndexStatement(table.Name, &idx))
 	}
+	spatial := spatialColumns(table.Columns)
+	for _, col := range spatial {
+		indexStatements = append(indexStatements, generatePostgresSpatialIndex(table.Name, col.Name))
+	}
 
 	// Combine CREATE TABLE with index statements
 	result := sb.String()
+	enums := enumColumns(table.Columns)
+	for i := len(enums) - 1; i >= 0; i-- {
+		result = generatePostgresCreateEnumType(table.Name, &enums[i]) + ";\n" + result
+	}
+	if len(spatial) > 0 {
+		result = postgisExtension + ";\n" + result
+	}
 	if len(indexStatements) > 0 {
 		result += ";\n" + strings.Join(indexStatements, ";\n")
 	}
@@ -211,8 +243,15 @@ func generatePostgresOperation(tableName string, op *ddl.TableOperation) string
 		}
 		// ALTER TABLE ADD COLUMN does not support autoincrement identity
 		// (that would require altering to identity column separately)
-		return fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN %s`,
-			tableName, generatePostgresColumnDef(op.ColumnDef, false))
+		stmt := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN %s`,
+			tableName, generatePostgresColumnDef(tableName, op.ColumnDef, false))
+		if op.ColumnDef.Type == ddl.EnumType {
+			stmt = generatePostgresCreateEnumType(tableName, op.ColumnDef) + ";\n" + stmt
+		}
+		if ddl.IsSpatialType(op.ColumnDef.Type) {
+			stmt = postgisExtension + ";\n" + stmt + ";\n" + generatePostgresSpatialIndex(tableName, op.ColumnDef.Name)
+		}
+		return stmt
 
 	case ddl.OpDropColumn:
 		return fmt.Sprintf(`ALTER TABLE "%s" DROP COLUMN "%s"`,
@@ -260,6 +299,19 @@ func generatePostgresOperation(tableName string, op *ddl.TableOperation) string
 		return fmt.Sprintf(`ALTER INDEX "%s" RENAME TO "%s"`,
 			op.IndexName, op.NewName)
 
+	case ddl.OpAddCheck:
+		if op.CheckDef == nil {
+			return ""
+		}
+		return fmt.Sprintf(`ALTER TABLE "%s" ADD CONSTRAINT "%s" CHECK (%s)`,
+			tableName, op.CheckDef.Name, op.CheckDef.Expression)
+
+	case ddl.OpDropCheck:
+		return fmt.Sprintf(`ALTER TABLE "%s" DROP CONSTRAINT "%s"`, tableName, op.CheckName)
+
+	case ddl.OpAddEnumValues, ddl.OpDropEnumValues:
+		return generatePostgresEnumValues(tableName, op)
+
 	default:
 		return ""
 	}
@@ -282,13 +334,20 @@ func postgresTypeFromString(ddlType string) string {
 		return "DECIMAL"
 	case ddl.FloatType:
 		return "DOUBLE PRECISION"
-	case ddl.DatetimeType, ddl.TimestampType:
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
 		return "TIMESTAMP WITH TIME ZONE"
 	case ddl.BinaryType:
 		return "BYTEA"
 	case ddl.JSONType:
 		return "JSONB"
+	case ddl.PointType:
+		return "GEOGRAPHY(Point, 4326)"
+	case ddl.PolygonType:
+		return "GEOGRAPHY(Polygon, 4326)"
 	default:
+		if t, ok := ddl.LookupType(ddlType); ok {
+			return t.Postgres
+		}
 		return "TEXT"
 	}
 }
@@ -226,6 +226,18 @@ func TestPostgres_CreateTable_Timestamp(t *testing.T) {
 	}
 }
 
+func TestPostgres_CreateTable_Timestamptz(t *testing.T) {
+	tb := ddl.MakeEmptyTable("test_table")
+	tb.Timestamptz("occurred_at")
+	table := tb.Build()
+
+	sql := generatePostgresCreateTable(table)
+
+	if !strings.Contains(sql, `"occurred_at" TIMESTAMP WITH TIME ZONE NOT NULL`) {
+		t.Errorf("expected TIMESTAMP WITH TIME ZONE column, got:\n%s", sql)
+	}
+}
+
 func TestPostgres_CreateTable_Binary(t *testing.T) {
 	tb := ddl.MakeEmptyTable("test_table")
 	tb.Binary("data")
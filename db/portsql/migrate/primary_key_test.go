@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestAddEmptyTable_CompositePrimaryKey(t *testing.T) {
+	plan := NewPlan()
+	if _, err := plan.AddEmptyTable("user_roles", func(tb *ddl.TableBuilder) error {
+		userID := tb.Bigint("user_id").Col()
+		roleID := tb.Bigint("role_id").Col()
+		tb.String("granted_by").Nullable()
+		tb.PrimaryKey(userID, roleID)
+		return nil
+	}); err != nil {
+		t.Fatalf("AddEmptyTable failed: %v", err)
+	}
+
+	table := plan.Schema.Tables["user_roles"]
+	if got := strings.Join(table.PrimaryKeyColumns(), ","); got != "user_id,role_id" {
+		t.Errorf("PrimaryKeyColumns = %q, want user_id,role_id", got)
+	}
+
+	m := plan.Migrations[0].Instructions
+	tests := []struct {
+		dialect, sql, column, constraint string
+	}{
+		{Sqlite, m.Sqlite, `"user_id" INTEGER NOT NULL,`, `, PRIMARY KEY ("user_id", "role_id"))`},
+		{Postgres, m.Postgres, `"user_id" BIGINT NOT NULL,`, `, PRIMARY KEY ("user_id", "role_id"))`},
+		{MySQL, m.MySQL, "`user_id` BIGINT NOT NULL,", ", PRIMARY KEY (`user_id`, `role_id`))"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.sql, tt.column) {
+			t.Errorf("%s: key column should be NOT NULL without an inline key, got:\n%s", tt.dialect, tt.sql)
+		}
+		if !strings.Contains(tt.sql, tt.constraint) {
+			t.Errorf("%s: missing table-level constraint %q, got:\n%s", tt.dialect, tt.constraint, tt.sql)
+		}
+		if strings.Count(tt.sql, "PRIMARY KEY") != 1 {
+			t.Errorf("%s: want exactly one PRIMARY KEY, got:\n%s", tt.dialect, tt.sql)
+		}
+	}
+}
+
+func TestPrimaryKey_Validation(t *testing.T) {
+	plan := NewPlan()
+	_, err := plan.AddEmptyTable("user_roles", func(tb *ddl.TableBuilder) error {
+		tb.PrimaryKey(tb.Bigint("user_id").Col(), tb.Bigint("role_id").Nullable().Col())
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), `"role_id" cannot be nullable`) {
+		t.Errorf("nullable key column: err = %v", err)
+	}
+
+	_, err = plan.AddTable("posts", func(tb *ddl.TableBuilder) error {
+		tb.PrimaryKey(tb.String("slug").Col())
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "AddEmptyTable") {
+		t.Errorf("AddTable with another key: err = %v", err)
+	}
+}
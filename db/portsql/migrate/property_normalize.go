@@ -357,7 +357,7 @@ func NormalizeDDLType(ddlType string) string {
 		return BaseTypeFloat
 	case "decimal":
 		return BaseTypeDecimal
-	case "datetime", "timestamp":
+	case "datetime", "timestamp", "timestamptz":
 		return BaseTypeDatetime
 	case "binary":
 		return BaseTypeBinary
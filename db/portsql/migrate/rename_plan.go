@@ -0,0 +1,96 @@
+package migrate
+
+// rename_plan.go - Table Rename SQL
+//
+// Renaming a table also renames the objects named after it: indexes named
+// idx_<table>_..., spatial indexes of point and polygon columns, Postgres
+// enum types, SQL Server default and CHECK constraints, and SQLite R*Trees.
+// Later migrations derive those names from the table name, so they must
+// follow.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// generatePostgresRenameTable renames a table, its indexes and enum types.
+func generatePostgresRenameTable(oldName string, table *ddl.Table, indexes []indexRename) string {
+	statements := []string{fmt.Sprintf(`ALTER TABLE "%s" RENAME TO "%s"`, oldName, table.Name)}
+	for _, r := range indexes {
+		statements = append(statements, fmt.Sprintf(`ALTER INDEX "%s" RENAME TO "%s"`, r.Old, r.Index.Name))
+	}
+	for _, col := range spatialColumns(table.Columns) {
+		statements = append(statements, fmt.Sprintf(`ALTER INDEX "%s" RENAME TO "%s"`,
+			ddl.SpatialIndexName(oldName, col.Name), ddl.SpatialIndexName(table.Name, col.Name)))
+	}
+	for _, col := range enumColumns(table.Columns) {
+		statements = append(statements, fmt.Sprintf(`ALTER TYPE "%s" RENAME TO "%s"`,
+			ddl.EnumTypeName(oldName, col.Name), ddl.EnumTypeName(table.Name, col.Name)))
+	}
+	return strings.Join(statements, ";\n")
+}
+
+// generateMySQLRenameTable renames a table and its indexes. MySQL scopes
+// index names to their table, so spatial indexes keep their old names.
+func generateMySQLRenameTable(oldName, newName string, indexes []indexRename) string {
+	statements := []string{fmt.Sprintf("RENAME TABLE `%s` TO `%s`", oldName, newName)}
+	for _, r := range indexes {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE `%s` RENAME INDEX `%s` TO `%s`",
+			newName, r.Old, r.Index.Name))
+	}
+	return strings.Join(statements, ";\n")
+}
+
+// generateSQLiteRenameTable renames a table. SQLite cannot rename indexes
+// or triggers, so renamed indexes are recreated, and each spatial column's
+// R*Tree is dropped before the rename and rebuilt from the table after it.
+func generateSQLiteRenameTable(oldName string, table *ddl.Table, indexes []indexRename) string {
+	spatial := spatialColumns(table.Columns)
+	var statements []string
+	for _, col := range spatial {
+		statements = append(statements, generateSQLiteRTreeDrop(oldName, col.Name)...)
+	}
+	statements = append(statements, fmt.Sprintf(`ALTER TABLE "%s" RENAME TO "%s"`, oldName, table.Name))
+	for _, r := range indexes {
+		statements = append(statements,
+			fmt.Sprintf(`DROP INDEX IF EXISTS "%s"`, r.Old),
+			generateSQLiteIndexStatement(table.Name, &r.Index))
+	}
+	for _, col := range spatial {
+		statements = append(statements, generateSQLiteRTree(table.Name, col)...)
+		column := fmt.Sprintf(`"%s"`, col.Name)
+		statements = append(statements, fmt.Sprintf(`INSERT INTO "%s" SELECT rowid, %s FROM "%s" WHERE %s IS NOT NULL`,
+			ddl.RTreeTableName(table.Name, col.Name), sqliteRTreeRow(col, column), table.Name, column))
+	}
+	return strings.Join(statements, ";\n")
+}
+
+// generateMSSQLRenameTable renames a table, its indexes and the default and
+// CHECK constraints of its columns. Table checks keep their names.
+func generateMSSQLRenameTable(oldName string, table *ddl.Table, indexes []indexRename) string {
+	newName := table.Name
+	statements := []string{fmt.Sprintf("EXEC sp_rename '%s', '%s'", escapeMSSQLString(oldName), escapeMSSQLString(newName))}
+	for _, col := range table.Columns {
+		for _, c := range []struct{ old, new, kind string }{
+			{mssqlDefaultConstraint(oldName, col.Name), mssqlDefaultConstraint(newName, col.Name), "D"},
+			{mssqlCheckConstraint(oldName, col.Name), mssqlCheckConstraint(newName, col.Name), "C"},
+			{mssqlColumnCheckConstraint(oldName, col.Name), mssqlColumnCheckConstraint(newName, col.Name), "C"},
+		} {
+			statements = append(statements, fmt.Sprintf("IF OBJECT_ID('%s', '%s') IS NOT NULL EXEC sp_rename '%s', '%s', 'OBJECT'",
+				escapeMSSQLString(c.old), c.kind, escapeMSSQLString(c.old), escapeMSSQLString(c.new)))
+		}
+	}
+	renameIndex := func(oldIndex, newIndex string) {
+		statements = append(statements, fmt.Sprintf("EXEC sp_rename '%s.%s', '%s', 'INDEX'",
+			escapeMSSQLString(newName), escapeMSSQLString(oldIndex), escapeMSSQLString(newIndex)))
+	}
+	for _, r := range indexes {
+		renameIndex(r.Old, r.Index.Name)
+	}
+	for _, col := range spatialColumns(table.Columns) {
+		renameIndex(ddl.SpatialIndexName(oldName, col.Name), ddl.SpatialIndexName(newName, col.Name))
+	}
+	return strings.Join(statements, ";\n")
+}
@@ -0,0 +1,166 @@
+package migrate
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// renamePlan builds a plan with an indexed, spatial, enum-typed "shops"
+// table and an "orders" table referencing it.
+func renamePlan(t *testing.T) *MigrationPlan {
+	t.Helper()
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{}}}
+	if _, err := plan.AddEmptyTable("shops", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		name := tb.String("name")
+		tb.Enum("status", "open", "closed").Default("open")
+		tb.Point("location")
+		tb.AddUniqueIndex(name.Col())
+		return nil
+	}); err != nil {
+		t.Fatalf("AddEmptyTable failed: %v", err)
+	}
+	shops, _ := plan.Table("shops")
+	if _, err := plan.AddEmptyTable("orders", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.Bigint("shop_id").References(shops)
+		return nil
+	}); err != nil {
+		t.Fatalf("AddEmptyTable failed: %v", err)
+	}
+	return plan
+}
+
+func TestRenameTable_UpdatesSchema(t *testing.T) {
+	plan := renamePlan(t)
+	if _, err := plan.RenameTable("shops", "stores"); err != nil {
+		t.Fatalf("RenameTable failed: %v", err)
+	}
+
+	if _, ok := plan.Schema.Tables["shops"]; ok {
+		t.Error("expected shops to be removed from Schema.Tables")
+	}
+	stores, ok := plan.Schema.Tables["stores"]
+	if !ok {
+		t.Fatal("expected stores in Schema.Tables")
+	}
+	if stores.Name != "stores" {
+		t.Errorf("expected table name stores, got %q", stores.Name)
+	}
+	if got := stores.Indexes[0].Name; got != "idx_stores_name" {
+		t.Errorf("expected index to be renamed to idx_stores_name, got %q", got)
+	}
+	if got := plan.Schema.Tables["orders"].Columns[1].References; got != "stores" {
+		t.Errorf("expected orders.shop_id to reference stores, got %q", got)
+	}
+	if got := plan.Migrations[len(plan.Migrations)-1].Name; !strings.HasSuffix(got, "_rename_shops") {
+		t.Errorf("expected migration name ending in _rename_shops, got %q", got)
+	}
+}
+
+func TestRenameTable_Errors(t *testing.T) {
+	plan := renamePlan(t)
+	if _, err := plan.RenameTable("missing", "other"); err == nil {
+		t.Error("expected error when renaming nonexistent table")
+	}
+	if _, err := plan.RenameTable("shops", "orders"); err == nil {
+		t.Error("expected error when renaming onto an existing table")
+	}
+	if _, err := plan.RenameTable("shops", ""); err == nil {
+		t.Error("expected error when renaming to an empty name")
+	}
+}
+
+func TestRenameTable_SQL(t *testing.T) {
+	plan := renamePlan(t)
+	if _, err := plan.RenameTable("shops", "stores"); err != nil {
+		t.Fatalf("RenameTable failed: %v", err)
+	}
+	ins := plan.Migrations[len(plan.Migrations)-1].Instructions
+
+	for dialect, tc := range map[string]struct {
+		sql  string
+		want []string
+	}{
+		"postgres": {ins.Postgres, []string{
+			`ALTER TABLE "shops" RENAME TO "stores"`,
+			`ALTER INDEX "idx_shops_name" RENAME TO "idx_stores_name"`,
+			`ALTER INDEX "idx_shops_location_spatial" RENAME TO "idx_stores_location_spatial"`,
+			`ALTER TYPE "shops_status" RENAME TO "stores_status"`,
+		}},
+		"mysql": {ins.MySQL, []string{
+			"RENAME TABLE `shops` TO `stores`",
+			"ALTER TABLE `stores` RENAME INDEX `idx_shops_name` TO `idx_stores_name`",
+		}},
+		"sqlite": {ins.Sqlite, []string{
+			`DROP TABLE IF EXISTS "shops_location_rtree"`,
+			`ALTER TABLE "shops" RENAME TO "stores"`,
+			`DROP INDEX IF EXISTS "idx_shops_name"`,
+			`CREATE VIRTUAL TABLE "stores_location_rtree"`,
+		}},
+		"mssql": {ins.MSSQL, []string{
+			"EXEC sp_rename 'shops', 'stores'",
+			"IF OBJECT_ID('DF_shops_status', 'D') IS NOT NULL EXEC sp_rename 'DF_shops_status', 'DF_stores_status', 'OBJECT'",
+			"IF OBJECT_ID('CK_shops_status', 'C') IS NOT NULL EXEC sp_rename 'CK_shops_status', 'CK_stores_status', 'OBJECT'",
+			"EXEC sp_rename 'stores.idx_shops_name', 'idx_stores_name', 'INDEX'",
+			"EXEC sp_rename 'stores.idx_shops_location_spatial', 'idx_stores_location_spatial', 'INDEX'",
+		}},
+	} {
+		for _, want := range tc.want {
+			if !strings.Contains(tc.sql, want) {
+				t.Errorf("%s: expected %q in:\n%s", dialect, want, tc.sql)
+			}
+		}
+	}
+}
+
+// TestSQLiteRenameTable_Run runs a rename against SQLite and checks that
+// existing points are reindexed and new writes reach the renamed R*Tree.
+func TestSQLiteRenameTable_Run(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	exec := func(sqlStr string) {
+		t.Helper()
+		for _, stmt := range splitSQLStatements(sqlStr) {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("exec failed: %v\nSQL: %s", err, stmt)
+			}
+		}
+	}
+
+	plan := renamePlan(t)
+	exec(plan.Migrations[0].Instructions.Sqlite)
+	exec(`INSERT INTO "shops" (id, name, location) VALUES (1, 'north', 'POINT(2.3522 48.8566)')`)
+
+	if _, err := plan.RenameTable("shops", "stores"); err != nil {
+		t.Fatalf("RenameTable failed: %v", err)
+	}
+	exec(plan.Migrations[len(plan.Migrations)-1].Instructions.Sqlite)
+	exec(`INSERT INTO "stores" (id, name, location) VALUES (2, 'south', 'POINT(-0.1276 51.5072)')`)
+
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM "stores_location_rtree"`).Scan(&n); err != nil {
+		t.Fatalf("query rtree: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected both rows in the renamed R*Tree, got %d", n)
+	}
+	if _, err := db.Exec(`INSERT INTO "stores" (id, name, location) VALUES (3, 'north', 'POINT(0 0)')`); err == nil {
+		t.Error("expected the recreated unique index to reject a duplicate name")
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name LIKE '%shops%'`).Scan(&n); err != nil {
+		t.Fatalf("query sqlite_master: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected no objects named after shops, %d remain", n)
+	}
+}
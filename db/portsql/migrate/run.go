@@ -9,6 +9,9 @@ import (
 
 // Run executes all pending migrations from the plan.
 // It is safe to call on every application startup - it only runs unapplied migrations.
+// On MySQL, which cannot roll back DDL, a migration that fails part-way keeps
+// the statements before the failure and the next Run resumes from it (see
+// SupportsTransactionalDDL).
 //
 // Migration names must follow the TIMESTAMP_name format (e.g., "20260111170656_create_users")
 // and must be in strictly ascending lexicographic order (which equals timestamp order).
@@ -32,6 +35,11 @@ func Run(ctx context.Context, db *sql.DB, plan *MigrationPlan, dialect string) e
 	if err := EnsureTrackingTable(ctx, db, dialect); err != nil {
 		return fmt.Errorf("failed to create tracking table: %w", err)
 	}
+	if !SupportsTransactionalDDL(dialect) {
+		if err := ensureProgressTable(ctx, db, dialect); err != nil {
+			return fmt.Errorf("failed to create migration progress table: %w", err)
+		}
+	}
 
 	// Get already applied migrations (returns full names)
 	applied, err := GetAppliedMigrations(ctx, db)
@@ -52,20 +60,23 @@ func Run(ctx context.Context, db *sql.DB, plan *MigrationPlan, dialect string) e
 		}
 
 		// Get the SQL for this dialect
-		var sqlStmt string
-		switch dialect {
-		case Postgres:
-			sqlStmt = migration.Instructions.Postgres
-		case MySQL:
-			sqlStmt = migration.Instructions.MySQL
-		case Sqlite:
-			sqlStmt = migration.Instructions.Sqlite
-		default:
+		sqlStmt, ok := instructionsFor(migration.Instructions, dialect)
+		if !ok {
 			return fmt.Errorf("unsupported dialect: %s", dialect)
 		}
 
-		// Execute migration in a transaction
-		if err := runMigrationInTransaction(ctx, db, dialect, migration.Name, sqlStmt); err != nil {
+		if err := runDataChecks(ctx, db, dialect, migration); err != nil {
+			return err
+		}
+
+		// Execute migration in a transaction, or statement by statement with
+		// checkpoints where DDL cannot be rolled back
+		if SupportsTransactionalDDL(dialect) {
+			err = runMigrationInTransaction(ctx, db, dialect, migration.Name, sqlStmt)
+		} else {
+			err = runMigrationWithCheckpoints(ctx, db, dialect, migration.Name, sqlStmt)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -73,6 +84,47 @@ func Run(ctx context.Context, db *sql.DB, plan *MigrationPlan, dialect string) e
 	return nil
 }
 
+// instructionsFor returns the SQL of in for dialect.
+func instructionsFor(in MigrationInstructions, dialect string) (string, bool) {
+	switch dialect {
+	case Postgres:
+		return in.Postgres, true
+	case MySQL:
+		return in.MySQL, true
+	case Sqlite:
+		return in.Sqlite, true
+	case MSSQL:
+		return in.MSSQL, true
+	default:
+		return "", false
+	}
+}
+
+// runDataChecks runs the data checks of a migration, returning an error
+// naming the migration for the first check that finds a row.
+func runDataChecks(ctx context.Context, db *sql.DB, dialect string, migration Migration) error {
+	for _, check := range migration.DataChecks {
+		query, _ := instructionsFor(check.Query, dialect)
+		if query == "" {
+			continue
+		}
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to run data check of migration %s: %w", migration.Name, err)
+		}
+		found := rows.Next()
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to run data check of migration %s: %w", migration.Name, err)
+		}
+		if found {
+			return fmt.Errorf("migration %s: %s", migration.Name, check.Message)
+		}
+	}
+	return nil
+}
+
 // runMigrationInTransaction executes a single migration within a transaction.
 // Both the SQL execution and the tracking record are within the same transaction.
 func runMigrationInTransaction(ctx context.Context, db *sql.DB, dialect, name, sqlStmt string) error {
@@ -128,6 +180,12 @@ func DetectDialect(db *sql.DB) (string, error) {
 		}
 	}
 
+	// Try sqlserver-specific query (MySQL has @@VERSION too, without the vendor)
+	err = db.QueryRow("SELECT @@VERSION").Scan(&version)
+	if err == nil && strings.Contains(version, "Microsoft SQL") {
+		return MSSQL, nil
+	}
+
 	// Try mysql-specific query
 	err = db.QueryRow("SELECT VERSION()").Scan(&version)
 	if err == nil {
@@ -146,18 +204,35 @@ func DetectDialect(db *sql.DB) (string, error) {
 
 // splitSQLStatements splits a SQL string containing multiple statements into individual statements.
 // It handles semicolons as statement separators and trims whitespace.
-// Empty statements are filtered out.
+// Empty statements are filtered out. The semicolons inside a trigger body
+// (CREATE TRIGGER ... BEGIN ...; END) do not end the statement.
 func splitSQLStatements(sql string) []string {
 	// Split on semicolons
 	parts := strings.Split(sql, ";")
 
 	var statements []string
+	var trigger string
 	for _, part := range parts {
+		if trigger != "" {
+			trigger += ";" + part
+			if strings.HasSuffix(strings.ToUpper(strings.TrimSpace(part)), "END") {
+				statements = append(statements, strings.TrimSpace(trigger))
+				trigger = ""
+			}
+			continue
+		}
 		stmt := strings.TrimSpace(part)
+		if strings.HasPrefix(strings.ToUpper(stmt), "CREATE TRIGGER") {
+			trigger = stmt
+			continue
+		}
 		if stmt != "" {
 			statements = append(statements, stmt)
 		}
 	}
+	if trigger != "" {
+		statements = append(statements, strings.TrimSpace(trigger))
+	}
 
 	return statements
 }
@@ -66,6 +66,15 @@ func TestSplitSQLStatements(t *testing.T) {
 				"CREATE UNIQUE INDEX `idx_accounts_email` ON `accounts` (`email`)",
 			},
 		},
+		{
+			name:  "trigger body keeps its semicolons",
+			input: "CREATE TABLE t (id INT);\nCREATE TRIGGER tr AFTER DELETE ON t BEGIN DELETE FROM r WHERE id = OLD.rowid; DELETE FROM s WHERE id = OLD.rowid; END;\nDROP TABLE u",
+			want: []string{
+				"CREATE TABLE t (id INT)",
+				"CREATE TRIGGER tr AFTER DELETE ON t BEGIN DELETE FROM r WHERE id = OLD.rowid; DELETE FROM s WHERE id = OLD.rowid; END",
+				"DROP TABLE u",
+			},
+		},
 	}
 
 	for _, tt := range tests {
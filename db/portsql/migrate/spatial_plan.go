@@ -0,0 +1,122 @@
+package migrate
+
+// spatial_plan.go - Spatial Index SQL for Point and Polygon Columns
+//
+// Every point and polygon column gets a spatial index: a GiST index on
+// Postgres (which also needs the PostGIS extension), a SPATIAL index on MySQL
+// (NOT NULL columns only) and SQL Server, and on SQLite an R*Tree virtual
+// table that triggers keep in sync with the bounding box of the WKT text
+// stored in the column.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// postgisExtension is emitted before the first statement that needs PostGIS.
+const postgisExtension = "CREATE EXTENSION IF NOT EXISTS postgis"
+
+// spatialColumns returns the point and polygon columns among cols.
+func spatialColumns(cols []ddl.ColumnDefinition) []ddl.ColumnDefinition {
+	var spatial []ddl.ColumnDefinition
+	for _, col := range cols {
+		if ddl.IsSpatialType(col.Type) {
+			spatial = append(spatial, col)
+		}
+	}
+	return spatial
+}
+
+// generatePostgresSpatialIndex generates the GiST index for a spatial column.
+func generatePostgresSpatialIndex(tableName, column string) string {
+	return fmt.Sprintf(`CREATE INDEX "%s" ON "%s" USING GIST ("%s")`,
+		ddl.SpatialIndexName(tableName, column), tableName, column)
+}
+
+// generateMySQLSpatialIndex generates the SPATIAL index for a spatial column,
+// or "" for nullable columns, which MySQL cannot index spatially.
+func generateMySQLSpatialIndex(tableName string, col *ddl.ColumnDefinition) string {
+	if col.Nullable {
+		return ""
+	}
+	return fmt.Sprintf("CREATE SPATIAL INDEX `%s` ON `%s` (`%s`)",
+		ddl.SpatialIndexName(tableName, col.Name), tableName, col.Name)
+}
+
+// generateMSSQLSpatialIndex generates the spatial index for a geography
+// column. SQL Server requires the table to have a clustered primary key,
+// which PRIMARY KEY creates by default.
+func generateMSSQLSpatialIndex(tableName, column string) string {
+	return fmt.Sprintf("CREATE SPATIAL INDEX [%s] ON [%s] ([%s])",
+		ddl.SpatialIndexName(tableName, column), tableName, column)
+}
+
+// sqliteRTreeRow returns the R*Tree columns after the id for the value of
+// col in expr: its bounding box, which for a point is the point itself.
+func sqliteRTreeRow(col ddl.ColumnDefinition, expr string) string {
+	bounds := ddl.SQLiteSpatialBounds(col.Type, expr)
+	return strings.Join(bounds[:], ", ")
+}
+
+// generateSQLiteRTreeTriggers generates the triggers that mirror a spatial
+// column into its R*Tree. The R*Tree id is the row's rowid.
+func generateSQLiteRTreeTriggers(tableName string, col ddl.ColumnDefinition) []string {
+	column := col.Name
+	rtree := ddl.RTreeTableName(tableName, column)
+	newCol := fmt.Sprintf(`NEW."%s"`, column)
+	insertNew := fmt.Sprintf(`INSERT INTO "%s" SELECT NEW.rowid, %s WHERE %s IS NOT NULL;`,
+		rtree, sqliteRTreeRow(col, newCol), newCol)
+	deleteOld := fmt.Sprintf(`DELETE FROM "%s" WHERE id = OLD.rowid;`, rtree)
+
+	return []string{
+		fmt.Sprintf(`CREATE TRIGGER "%s_ai" AFTER INSERT ON "%s" BEGIN %s END`, rtree, tableName, insertNew),
+		fmt.Sprintf(`CREATE TRIGGER "%s_au" AFTER UPDATE OF "%s" ON "%s" BEGIN %s %s END`, rtree, column, tableName, deleteOld, insertNew),
+		fmt.Sprintf(`CREATE TRIGGER "%s_ad" AFTER DELETE ON "%s" BEGIN %s END`, rtree, tableName, deleteOld),
+	}
+}
+
+// generateSQLiteRTree generates the R*Tree for a spatial column and its
+// triggers. The column is new, so there are no existing values to index.
+func generateSQLiteRTree(tableName string, col ddl.ColumnDefinition) []string {
+	rtree := ddl.RTreeTableName(tableName, col.Name)
+	statements := []string{
+		fmt.Sprintf(`CREATE VIRTUAL TABLE "%s" USING rtree(id, min_lng, max_lng, min_lat, max_lat)`, rtree),
+	}
+	return append(statements, generateSQLiteRTreeTriggers(tableName, col)...)
+}
+
+// generateSQLiteRTreeDrop drops the triggers and R*Tree of a spatial column.
+func generateSQLiteRTreeDrop(tableName, column string) []string {
+	rtree := ddl.RTreeTableName(tableName, column)
+	return []string{
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS "%s_ai"`, rtree),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS "%s_au"`, rtree),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS "%s_ad"`, rtree),
+		fmt.Sprintf(`DROP TABLE IF EXISTS "%s"`, rtree),
+	}
+}
+
+// generateSQLiteUpdateTable wraps generateSQLiteAlterTable, first dropping the
+// R*Tree of every spatial column in spatialBefore that ops drop: SQLite refuses
+// to drop a column that a trigger still references.
+func generateSQLiteUpdateTable(tableName string, ops []ddl.TableOperation, currentTable *ddl.Table, spatialBefore []ddl.ColumnDefinition) string {
+	var drops []string
+	for _, op := range ops {
+		if op.Type != ddl.OpDropColumn {
+			continue
+		}
+		for _, col := range spatialBefore {
+			if col.Name == op.Column {
+				drops = append(drops, generateSQLiteRTreeDrop(tableName, col.Name)...)
+			}
+		}
+	}
+
+	stmt := generateSQLiteAlterTable(tableName, ops, currentTable)
+	if len(drops) == 0 {
+		return stmt
+	}
+	return strings.Join(drops, ";\n") + ";\n" + stmt
+}
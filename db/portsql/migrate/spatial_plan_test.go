@@ -0,0 +1,292 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
+)
+
+func storesTable() *ddl.Table {
+	tb := ddl.MakeEmptyTable("stores")
+	tb.Bigint("id").PrimaryKey()
+	tb.String("name")
+	tb.Point("location")
+	tb.Point("delivery_hub").Nullable()
+	return tb.Build()
+}
+
+func TestPostgres_CreateTable_Point(t *testing.T) {
+	sql := generatePostgresCreateTable(storesTable())
+
+	if !strings.HasPrefix(sql, "CREATE EXTENSION IF NOT EXISTS postgis;\n") {
+		t.Errorf("expected PostGIS extension first, got:\n%s", sql)
+	}
+	if !strings.Contains(sql, `"location" GEOGRAPHY(Point, 4326) NOT NULL`) {
+		t.Errorf("expected geography column, got:\n%s", sql)
+	}
+	if !strings.Contains(sql, `CREATE INDEX "idx_stores_delivery_hub_spatial" ON "stores" USING GIST ("delivery_hub")`) {
+		t.Errorf("expected GiST index on nullable point column, got:\n%s", sql)
+	}
+}
+
+func TestMySQL_CreateTable_Point(t *testing.T) {
+	sql := generateMySQLCreateTable(storesTable())
+
+	if !strings.Contains(sql, "`location` POINT SRID 4326 NOT NULL") {
+		t.Errorf("expected POINT SRID 4326 column, got:\n%s", sql)
+	}
+	if !strings.Contains(sql, "CREATE SPATIAL INDEX `idx_stores_location_spatial` ON `stores` (`location`)") {
+		t.Errorf("expected spatial index, got:\n%s", sql)
+	}
+	if strings.Contains(sql, "idx_stores_delivery_hub_spatial") {
+		t.Error("MySQL cannot spatially index a nullable column")
+	}
+}
+
+func TestSQLite_CreateTable_Point(t *testing.T) {
+	sql := generateSQLiteCreateTable(storesTable())
+
+	// Each point column gets an R*Tree and three triggers keeping it in sync;
+	// TestSQLitePointRTree_Sync runs them. Trigger bodies are cut off here.
+	var got []string
+	for _, stmt := range splitSQLStatements(sql) {
+		header, _, _ := strings.Cut(stmt, " BEGIN ")
+		got = append(got, header)
+	}
+	want := []string{
+		`CREATE TABLE "stores" ("id" INTEGER PRIMARY KEY, "name" TEXT NOT NULL, "location" TEXT NOT NULL, "delivery_hub" TEXT)`,
+		`CREATE VIRTUAL TABLE "stores_location_rtree" USING rtree(id, min_lng, max_lng, min_lat, max_lat)`,
+		`CREATE TRIGGER "stores_location_rtree_ai" AFTER INSERT ON "stores"`,
+		`CREATE TRIGGER "stores_location_rtree_au" AFTER UPDATE OF "location" ON "stores"`,
+		`CREATE TRIGGER "stores_location_rtree_ad" AFTER DELETE ON "stores"`,
+		`CREATE VIRTUAL TABLE "stores_delivery_hub_rtree" USING rtree(id, min_lng, max_lng, min_lat, max_lat)`,
+		`CREATE TRIGGER "stores_delivery_hub_rtree_ai" AFTER INSERT ON "stores"`,
+		`CREATE TRIGGER "stores_delivery_hub_rtree_au" AFTER UPDATE OF "delivery_hub" ON "stores"`,
+		`CREATE TRIGGER "stores_delivery_hub_rtree_ad" AFTER DELETE ON "stores"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("statements:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPostgres_AlterTable_AddPoint(t *testing.T) {
+	alt := ddl.AlterTable("stores")
+	alt.Point("location")
+	sql := generatePostgresAlterTable("stores", alt.Build())
+
+	want := "CREATE EXTENSION IF NOT EXISTS postgis;\n" +
+		`ALTER TABLE "stores" ADD COLUMN "location" GEOGRAPHY(Point, 4326) NOT NULL;` + "\n" +
+		`CREATE INDEX "idx_stores_location_spatial" ON "stores" USING GIST ("location")`
+	if sql != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sql, want)
+	}
+}
+
+func TestDropTable_Point_DropsSQLiteRTree(t *testing.T) {
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{"stores": *storesTable()}}}
+	if _, err := plan.DropTable("stores"); err != nil {
+		t.Fatalf("DropTable failed: %v", err)
+	}
+
+	ins := plan.Migrations[0].Instructions
+	if !strings.Contains(ins.Sqlite, `DROP TABLE IF EXISTS "stores_location_rtree"`) {
+		t.Errorf("expected SQLite R*Tree drop, got:\n%s", ins.Sqlite)
+	}
+	if ins.Postgres != `DROP TABLE "stores"` {
+		t.Errorf("Postgres drop should be unchanged, got %q", ins.Postgres)
+	}
+}
+
+// TestSQLitePointRTree_Sync runs the generated SQL against SQLite and checks
+// that the R*Tree follows inserts, updates and deletes, and that a point
+// column can be dropped and its table dropped.
+func TestSQLitePointRTree_Sync(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	exec := func(sqlStr string) {
+		t.Helper()
+		for _, stmt := range splitSQLStatements(sqlStr) {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("exec failed: %v\nSQL: %s", err, stmt)
+			}
+		}
+	}
+	box := func(id int) (lng, lat float64, found bool) {
+		t.Helper()
+		err := db.QueryRow(`SELECT min_lng, min_lat FROM "stores_location_rtree" WHERE id = ?`, id).Scan(&lng, &lat)
+		if err == sql.ErrNoRows {
+			return 0, 0, false
+		}
+		if err != nil {
+			t.Fatalf("query rtree: %v", err)
+		}
+		return lng, lat, true
+	}
+
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{}}}
+	if _, err := plan.AddEmptyTable("stores", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.Point("location")
+		return nil
+	}); err != nil {
+		t.Fatalf("AddEmptyTable failed: %v", err)
+	}
+	exec(plan.Migrations[0].Instructions.Sqlite)
+
+	exec(`INSERT INTO "stores" (id, location) VALUES (1, 'POINT(-122.4194 37.7749)'), (2, 'POINT(2.3522 48.8566)')`)
+	if lng, lat, ok := box(1); !ok || lng > -122.41 || lng < -122.43 || lat < 37.77 || lat > 37.78 {
+		t.Errorf("row 1 indexed at (%v, %v), found=%v", lng, lat, ok)
+	}
+
+	exec(`UPDATE "stores" SET location = 'POINT(-0.1276 51.5072)' WHERE id = 2`)
+	if _, lat, ok := box(2); !ok || lat < 51.5 || lat > 51.51 {
+		t.Errorf("row 2 not moved after update: lat=%v found=%v", lat, ok)
+	}
+
+	exec(`DELETE FROM "stores" WHERE id = 1`)
+	if _, _, ok := box(1); ok {
+		t.Error("row 1 still indexed after delete")
+	}
+
+	if err := plan.UpdateTable("stores", func(alt *ddl.AlterTableBuilder) error {
+		alt.DropColumn("location")
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateTable failed: %v", err)
+	}
+	exec(plan.Migrations[1].Instructions.Sqlite)
+	var n int
+	if err := db.QueryRowContext(context.Background(), `SELECT count(*) FROM sqlite_master WHERE name LIKE 'stores_location_rtree%'`).Scan(&n); err != nil {
+		t.Fatalf("query sqlite_master: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected R*Tree objects to be dropped with the column, %d remain", n)
+	}
+
+	if _, err := plan.DropTable("stores"); err != nil {
+		t.Fatalf("DropTable failed: %v", err)
+	}
+	exec(plan.Migrations[2].Instructions.Sqlite)
+}
+
+type areasTable struct{}
+
+func (areasTable) TableName() string { return "areas" }
+
+func zonesTable() *ddl.Table {
+	tb := ddl.MakeEmptyTable("zones")
+	tb.Bigint("id").PrimaryKey()
+	tb.Polygon("area")
+	return tb.Build()
+}
+
+func TestCreateTable_Polygon(t *testing.T) {
+	tests := []struct {
+		dialect, sql, column, index string
+	}{
+		{"postgres", generatePostgresCreateTable(zonesTable()),
+			`"area" GEOGRAPHY(Polygon, 4326) NOT NULL`,
+			`CREATE INDEX "idx_zones_area_spatial" ON "zones" USING GIST ("area")`},
+		{"mysql", generateMySQLCreateTable(zonesTable()),
+			"`area` POLYGON SRID 4326 NOT NULL",
+			"CREATE SPATIAL INDEX `idx_zones_area_spatial` ON `zones` (`area`)"},
+		{"mssql", generateMSSQLCreateTable(zonesTable()),
+			"[area] GEOGRAPHY NOT NULL",
+			"CREATE SPATIAL INDEX [idx_zones_area_spatial] ON [zones] ([area])"},
+		{"sqlite", generateSQLiteCreateTable(zonesTable()),
+			`"area" TEXT NOT NULL`,
+			`CREATE VIRTUAL TABLE "zones_area_rtree" USING rtree(id, min_lng, max_lng, min_lat, max_lat)`},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.sql, tt.column) {
+			t.Errorf("%s: expected column %q in:\n%s", tt.dialect, tt.column, tt.sql)
+		}
+		if !strings.Contains(tt.sql, tt.index) {
+			t.Errorf("%s: expected index %q in:\n%s", tt.dialect, tt.index, tt.sql)
+		}
+	}
+}
+
+// TestSQLitePolygonRTree_Sync runs the generated SQL against SQLite and
+// checks that the R*Tree holds each polygon's bounding box, that a table
+// rename rebuilds it, and that the compiled Contains query uses it.
+func TestSQLitePolygonRTree_Sync(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	exec := func(sqlStr string) {
+		t.Helper()
+		for _, stmt := range splitSQLStatements(sqlStr) {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("exec failed: %v\nSQL: %s", err, stmt)
+			}
+		}
+	}
+	box := func(table string, id int) [4]float64 {
+		t.Helper()
+		var b [4]float64
+		err := db.QueryRow(`SELECT min_lng, max_lng, min_lat, max_lat FROM "`+table+`_area_rtree" WHERE id = ?`, id).Scan(&b[0], &b[1], &b[2], &b[3])
+		if err != nil {
+			t.Fatalf("query rtree: %v", err)
+		}
+		return b
+	}
+
+	plan := &MigrationPlan{Schema: Schema{Tables: map[string]ddl.Table{}}}
+	if _, err := plan.AddEmptyTable("zones", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("id").PrimaryKey()
+		tb.Polygon("area")
+		return nil
+	}); err != nil {
+		t.Fatalf("AddEmptyTable failed: %v", err)
+	}
+	exec(plan.Migrations[0].Instructions.Sqlite)
+
+	exec(`INSERT INTO "zones" (id, area) VALUES (1, 'POLYGON((-122.5 37.7, -122.3 37.7, -122.3 37.9, -122.5 37.7))')`)
+	// R*Tree coordinates are 32-bit floats, rounded outwards
+	if b := box("zones", 1); b[0] > -122.5 || b[0] < -122.51 || b[1] < -122.3 || b[1] > -122.29 || b[2] > 37.7 || b[2] < 37.69 || b[3] < 37.9 || b[3] > 37.91 {
+		t.Errorf("unexpected bounding box %v", b)
+	}
+
+	exec(`UPDATE "zones" SET area = 'POLYGON((2 48, 3 48, 3 49, 2 48))' WHERE id = 1`)
+	if b := box("zones", 1); b[0] < 1.99 || b[3] > 49.01 {
+		t.Errorf("bounding box not moved after update: %v", b)
+	}
+
+	if _, err := plan.RenameTable("zones", "areas"); err != nil {
+		t.Fatalf("RenameTable failed: %v", err)
+	}
+	exec(plan.Migrations[1].Instructions.Sqlite)
+	if b := box("areas", 1); b[0] < 1.99 || b[1] > 3.01 {
+		t.Errorf("bounding box not rebuilt after rename: %v", b)
+	}
+
+	area := query.PolygonColumn{Table: "areas", Name: "area"}
+	id := query.Int64Column{Table: "areas", Name: "id"}
+	sqlStr, params, err := compile.NewCompiler(compile.SQLite).Compile(
+		query.From(areasTable{}).Select(id).Where(area.Contains(48.2, 2.5)).Build())
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	var found int64
+	if err := db.QueryRow(sqlStr).Scan(&found); err != nil || found != 1 || len(params) != 0 {
+		t.Errorf("Contains did not find the polygon: id=%d err=%v\nSQL: %s", found, err, sqlStr)
+	}
+}
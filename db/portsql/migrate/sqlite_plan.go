@@ -7,6 +7,7 @@ package migrate
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/shipq/shipq/db/portsql/ddl"
@@ -14,6 +15,9 @@ import (
 
 // sqliteType maps DDL types to SQLite types
 func sqliteType(col *ddl.ColumnDefinition) string {
+	if col.Custom != nil {
+		return col.Custom.SQLite
+	}
 	switch col.Type {
 	case ddl.IntegerType, ddl.BigintType:
 		// SQLite INTEGER is always 64-bit
@@ -31,7 +35,7 @@ func sqliteType(col *ddl.ColumnDefinition) string {
 		return "REAL"
 	case ddl.FloatType:
 		return "REAL"
-	case ddl.DatetimeType, ddl.TimestampType:
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
 		// SQLite stores datetime as TEXT (ISO8601 format)
 		return "TEXT"
 	case ddl.BinaryType:
@@ -39,6 +43,9 @@ func sqliteType(col *ddl.ColumnDefinition) string {
 	case ddl.JSONType:
 		// SQLite stores JSON as TEXT
 		return "TEXT"
+	case ddl.PointType, ddl.PolygonType:
+		// WKT text; spatial queries use the R*Tree kept in sync by triggers
+		return "TEXT"
 	default:
 		return "TEXT"
 	}
@@ -111,6 +118,15 @@ func generateSQLiteColumnDef(col *ddl.ColumnDefinition, isAutoincrementPK bool)
 		parts = append(parts, "DEFAULT", formatSQLiteDefault(col))
 	}
 
+	// Enum columns are TEXT restricted to their values
+	if col.Type == ddl.EnumType {
+		parts = append(parts, sqliteEnumCheck(col))
+	}
+
+	if col.Check != "" {
+		parts = append(parts, columnCheck(col))
+	}
+
 	return strings.Join(parts, " ")
 }
 
@@ -125,14 +141,22 @@ func generateSQLiteCreateTable(table *ddl.Table) string {
 	sb.WriteString(fmt.Sprintf(`CREATE TABLE "%s" (`, table.Name))
 
 	// Columns
+	compositePK := compositePrimaryKey(table)
 	for i, col := range table.Columns {
 		if i > 0 {
 			sb.WriteString(", ")
 		}
+		if compositePK != nil {
+			col.PrimaryKey = false
+		}
 		// Determine if this column is the autoincrement PK
 		isAutoincrementPK := hasAutoincrementPK && col.Name == pkInfo.ColumnName
 		sb.WriteString(generateSQLiteColumnDef(&col, isAutoincrementPK))
 	}
+	if compositePK != nil {
+		sb.WriteString(fmt.Sprintf(", PRIMARY KEY (%s)", quotedColumns(compositePK, `"`)))
+	}
+	sb.WriteString(tableChecks(table.Checks, `"`, `"`))
 
 	sb.WriteString(")")
 
@@ -141,6 +165,9 @@ func generateSQLiteCreateTable(table *ddl.Table) string {
 	for _, idx := range table.Indexes {
 		indexStatements = append(indexStatements, generateSQLiteIndexStatement(table.Name, &idx))
 	}
+	for _, col := range spatialColumns(table.Columns) {
+		indexStatements = append(indexStatements, generateSQLiteRTree(table.Name, col)...)
+	}
 
 	// Combine CREATE TABLE with index statements
 	result := sb.String()
@@ -209,8 +236,12 @@ func generateSQLiteOperation(tableName string, op *ddl.TableOperation) string {
 		}
 		// ALTER TABLE ADD COLUMN does not support autoincrement
 		// (that would require table rebuild)
-		return fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN %s`,
+		stmt := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN %s`,
 			tableName, generateSQLiteColumnDef(op.ColumnDef, false))
+		if ddl.IsSpatialType(op.ColumnDef.Type) {
+			stmt += ";\n" + strings.Join(generateSQLiteRTree(tableName, *op.ColumnDef), ";\n")
+		}
+		return stmt
 
 	case ddl.OpDropColumn:
 		// SQLite 3.35.0+ supports DROP COLUMN
@@ -269,13 +300,16 @@ func sqliteTypeFromString(ddlType string) string {
 		return "INTEGER"
 	case ddl.DecimalType, ddl.FloatType:
 		return "REAL"
-	case ddl.DatetimeType, ddl.TimestampType:
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
 		return "TEXT"
 	case ddl.BinaryType:
 		return "BLOB"
 	case ddl.JSONType:
 		return "TEXT"
 	default:
+		if t, ok := ddl.LookupType(ddlType); ok {
+			return t.SQLite
+		}
 		return "TEXT"
 	}
 }
@@ -286,11 +320,14 @@ func generateSQLiteDropTable(tableName string) string {
 }
 
 // requiresTableRebuild checks if any operation requires a SQLite table rebuild.
-// Returns true for: OpChangeType, OpChangeNullable, OpChangeDefault (on existing columns)
+// Returns true for: OpChangeType, OpChangeNullable, OpChangeDefault (on existing columns),
+// OpAddCheck, OpDropCheck, and OpAddEnumValues and OpDropEnumValues, which
+// change an enum column's CHECK constraint
 func requiresTableRebuild(ops []ddl.TableOperation) bool {
 	for _, op := range ops {
 		switch op.Type {
-		case ddl.OpChangeType, ddl.OpChangeNullable, ddl.OpChangeDefault:
+		case ddl.OpChangeType, ddl.OpChangeNullable, ddl.OpChangeDefault, ddl.OpAddCheck, ddl.OpDropCheck,
+			ddl.OpAddEnumValues, ddl.OpDropEnumValues:
 			return true
 		}
 	}
@@ -327,14 +364,22 @@ func generateSQLiteTableRebuild(tableName string, currentTable *ddl.Table, ops [
 
 	// 1. Create new table with desired schema
 	sb.WriteString(fmt.Sprintf(`CREATE TABLE "%s" (`, newTableName))
+	compositePK := compositePrimaryKey(newTable)
 	for i, col := range newTable.Columns {
 		if i > 0 {
 			sb.WriteString(", ")
 		}
+		if compositePK != nil {
+			col.PrimaryKey = false
+		}
 		// Determine if this column is the autoincrement PK
 		isAutoincrementPK := hasAutoincrementPK && col.Name == pkInfo.ColumnName
 		sb.WriteString(generateSQLiteColumnDef(&col, isAutoincrementPK))
 	}
+	if compositePK != nil {
+		sb.WriteString(fmt.Sprintf(", PRIMARY KEY (%s)", quotedColumns(compositePK, `"`)))
+	}
+	sb.WriteString(tableChecks(newTable.Checks, `"`, `"`))
 	sb.WriteString(");\n")
 
 	// 2. Copy data from old table
@@ -368,11 +413,18 @@ func generateSQLiteTableRebuild(tableName string, currentTable *ddl.Table, ops [
 	sb.WriteString(fmt.Sprintf(`ALTER TABLE "%s" RENAME TO "%s"`, newTableName, tableName))
 	sb.WriteString(";\n")
 
-	// 5. Recreate indexes
+	// 5. Recreate indexes, and the R*Tree triggers dropped with the old table
+	// (the R*Tree itself survives because rowids are copied)
 	for _, idx := range newTable.Indexes {
 		sb.WriteString(generateSQLiteIndexStatement(tableName, &idx))
 		sb.WriteString(";\n")
 	}
+	for _, col := range spatialColumns(newTable.Columns) {
+		for _, stmt := range generateSQLiteRTreeTriggers(tableName, col) {
+			sb.WriteString(stmt)
+			sb.WriteString(";\n")
+		}
+	}
 
 	// Re-enable foreign keys
 	sb.WriteString("PRAGMA foreign_keys=ON;")
@@ -387,6 +439,7 @@ func applyOperationsToTable(table *ddl.Table, ops []ddl.TableOperation) *ddl.Tab
 		Name:    table.Name,
 		Columns: make([]ddl.ColumnDefinition, len(table.Columns)),
 		Indexes: make([]ddl.IndexDefinition, len(table.Indexes)),
+		Checks:  slices.Clone(table.Checks),
 	}
 	copy(newTable.Columns, table.Columns)
 	copy(newTable.Indexes, table.Indexes)
@@ -455,6 +508,23 @@ func applyOperationsToTable(table *ddl.Table, ops []ddl.TableOperation) *ddl.Tab
 					break
 				}
 			}
+		case ddl.OpAddCheck:
+			// The table passed in may already carry the check
+			if op.CheckDef != nil && !slices.ContainsFunc(newTable.Checks, func(c ddl.CheckDefinition) bool { return c.Name == op.CheckDef.Name }) {
+				newTable.Checks = append(newTable.Checks, *op.CheckDef)
+			}
+		case ddl.OpDropCheck:
+			newTable.Checks = slices.DeleteFunc(newTable.Checks, func(c ddl.CheckDefinition) bool { return c.Name == op.CheckName })
+		case ddl.OpAddEnumValues, ddl.OpDropEnumValues:
+			// ColumnDef is the column after the operation, set by the planner
+			if op.ColumnDef != nil {
+				for i, col := range newTable.Columns {
+					if col.Name == op.ColumnDef.Name {
+						newTable.Columns[i] = *op.ColumnDef
+						break
+					}
+				}
+			}
 		}
 	}
 
@@ -238,6 +238,18 @@ func TestSQLite_CreateTable_Timestamp(t *testing.T) {
 	}
 }
 
+func TestSQLite_CreateTable_Timestamptz(t *testing.T) {
+	tb := ddl.MakeEmptyTable("test_table")
+	tb.Timestamptz("occurred_at")
+	table := tb.Build()
+
+	sql := generateSQLiteCreateTable(table)
+
+	if !strings.Contains(sql, `"occurred_at" TEXT NOT NULL`) {
+		t.Errorf("expected TEXT column for timestamptz, got:\n%s", sql)
+	}
+}
+
 func TestSQLite_CreateTable_Binary(t *testing.T) {
 	tb := ddl.MakeEmptyTable("test_table")
 	tb.Binary("data")
@@ -38,6 +38,15 @@ func EnsureTrackingTable(ctx context.Context, db *sql.DB, dialect string) error
 				version    TEXT NOT NULL,
 				applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
 			)`
+	case MSSQL:
+		// SQL Server has no CREATE TABLE IF NOT EXISTS
+		createSQL = `
+			IF OBJECT_ID('_portsql_migrations', 'U') IS NULL
+			CREATE TABLE _portsql_migrations (
+				name       NVARCHAR(255) PRIMARY KEY,
+				version    NVARCHAR(14) NOT NULL,
+				applied_at DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+			)`
 	default:
 		return fmt.Errorf("unsupported dialect: %s", dialect)
 	}
@@ -46,9 +55,16 @@ func EnsureTrackingTable(ctx context.Context, db *sql.DB, dialect string) error
 	return err
 }
 
+// Queryer is satisfied by *sql.DB and *sql.Tx. The tracking-table readers
+// take it so callers holding only a query interface, such as the generated
+// server's /__meta handler, share them.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
 // GetAppliedMigrations returns the list of applied migration names, sorted by version then name.
 // The name is the full migration identifier like "20260111170700_create_users".
-func GetAppliedMigrations(ctx context.Context, db *sql.DB) ([]string, error) {
+func GetAppliedMigrations(ctx context.Context, db Queryer) ([]string, error) {
 	rows, err := db.QueryContext(ctx,
 		"SELECT name FROM _portsql_migrations ORDER BY version, name")
 	if err != nil {
@@ -72,6 +88,108 @@ func GetAppliedMigrations(ctx context.Context, db *sql.DB) ([]string, error) {
 	return names, nil
 }
 
+// AppliedMigrationHead returns the name of the most recently applied
+// migration, or "" when none has been applied. It reads the whole tracking
+// table rather than using a row-limit clause, which SQL Server spells
+// differently.
+func AppliedMigrationHead(ctx context.Context, db Queryer) (string, error) {
+	applied, err := GetAppliedMigrations(ctx, db)
+	if err != nil || len(applied) == 0 {
+		return "", err
+	}
+	return applied[len(applied)-1], nil
+}
+
+// PendingMigrations returns the names of the migrations in plan that the
+// tracking table does not list, in plan order. It fails when the tracking
+// table cannot be read, e.g. because no migration has ever run.
+func PendingMigrations(ctx context.Context, db Queryer, plan *MigrationPlan) ([]string, error) {
+	names := make([]string, len(plan.Migrations))
+	for i, m := range plan.Migrations {
+		names[i] = m.Name
+	}
+	return PendingMigrationNames(ctx, db, names)
+}
+
+// PendingMigrationNames is PendingMigrations for a list of migration names,
+// for callers that carry the names without the plan.
+func PendingMigrationNames(ctx context.Context, db Queryer, names []string) ([]string, error) {
+	applied, err := GetAppliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	appliedSet := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		appliedSet[name] = true
+	}
+	pending := []string{}
+	for _, name := range names {
+		if !appliedSet[name] {
+			pending = append(pending, name)
+		}
+	}
+	return pending, nil
+}
+
+// AppliedMigration is a row of the tracking table.
+type AppliedMigration struct {
+	Name      string
+	Version   string
+	AppliedAt time.Time
+}
+
+// GetAppliedMigrationRecords returns the rows of the tracking table, sorted
+// by version then name. An applied_at the driver returns in an unrecognized
+// form is left zero.
+func GetAppliedMigrationRecords(ctx context.Context, db *sql.DB) ([]AppliedMigration, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT name, version, applied_at FROM _portsql_migrations ORDER BY version, name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var records []AppliedMigration
+	for rows.Next() {
+		var r AppliedMigration
+		var appliedAt any
+		if err := rows.Scan(&r.Name, &r.Version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		r.AppliedAt = parseAppliedAt(appliedAt)
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating migrations: %w", err)
+	}
+
+	return records, nil
+}
+
+// parseAppliedAt converts an applied_at value as scanned from any dialect:
+// a time.Time (Postgres, SQL Server), or text (SQLite stores RFC 3339, MySQL
+// returns "2006-01-02 15:04:05" without parseTime). Times are in UTC.
+func parseAppliedAt(v any) time.Time {
+	var text string
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC()
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
 // RecordMigration inserts a migration into the tracking table.
 // The name is the full migration identifier like "20260111170700_create_users".
 // The version is just the timestamp portion for ordering.
@@ -92,6 +210,9 @@ func RecordMigration(ctx context.Context, db *sql.DB, dialect, version, name str
 	case Sqlite:
 		insertSQL = `INSERT INTO _portsql_migrations (name, version, applied_at) VALUES (?, ?, ?)`
 		args = []interface{}{name, version, nowUTC.Format(time.RFC3339)}
+	case MSSQL:
+		insertSQL = `INSERT INTO _portsql_migrations (name, version, applied_at) VALUES (@p1, @p2, @p3)`
+		args = []interface{}{name, version, nowUTC}
 	default:
 		return fmt.Errorf("unsupported dialect: %s", dialect)
 	}
@@ -123,6 +244,9 @@ func RecordMigrationTx(ctx context.Context, tx *sql.Tx, dialect, version, name s
 	case Sqlite:
 		insertSQL = `INSERT INTO _portsql_migrations (name, version, applied_at) VALUES (?, ?, ?)`
 		args = []interface{}{name, version, nowUTC.Format(time.RFC3339)}
+	case MSSQL:
+		insertSQL = `INSERT INTO _portsql_migrations (name, version, applied_at) VALUES (@p1, @p2, @p3)`
+		args = []interface{}{name, version, nowUTC}
 	default:
 		return fmt.Errorf("unsupported dialect: %s", dialect)
 	}
@@ -152,6 +276,11 @@ func GetAllTables(ctx context.Context, db *sql.DB, dialect string) ([]string, er
 			SELECT name FROM sqlite_master 
 			WHERE type='table' AND name NOT LIKE 'sqlite_%'
 			ORDER BY name`
+	case MSSQL:
+		querySQL = `
+			SELECT name FROM sys.tables
+			WHERE schema_id = SCHEMA_ID('dbo')
+			ORDER BY name`
 	default:
 		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
 	}
@@ -185,6 +314,9 @@ func escapeIdentifier(name, dialect string) string {
 	case MySQL:
 		// MySQL uses backticks; escape embedded backticks by doubling
 		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case MSSQL:
+		// SQL Server uses brackets; escape embedded closing brackets by doubling
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
 	default:
 		// Postgres and SQLite use double quotes; escape embedded quotes by doubling
 		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
@@ -206,7 +338,7 @@ func DropAllTables(ctx context.Context, db *sql.DB, dialect string) error {
 			dropSQL = fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", quotedTable)
 		case MySQL:
 			dropSQL = fmt.Sprintf("DROP TABLE IF EXISTS %s", quotedTable)
-		case Sqlite:
+		case Sqlite, MSSQL:
 			dropSQL = fmt.Sprintf("DROP TABLE IF EXISTS %s", quotedTable)
 		default:
 			return fmt.Errorf("unsupported dialect: %s", dialect)
@@ -73,6 +73,73 @@ func TestTrackingTable(t *testing.T) {
 	}
 }
 
+func TestGetAppliedMigrationRecords(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := EnsureTrackingTable(ctx, db, Sqlite); err != nil {
+		t.Fatalf("EnsureTrackingTable failed: %v", err)
+	}
+
+	before := time.Now().UTC().Truncate(time.Second)
+	if err := RecordMigration(ctx, db, Sqlite, "20260111153000", "20260111153000_create_users"); err != nil {
+		t.Fatalf("RecordMigration failed: %v", err)
+	}
+	// A row written by the column default rather than RecordMigration
+	if _, err := db.ExecContext(ctx, `INSERT INTO _portsql_migrations (name, version) VALUES ('20260111160000_create_posts', '20260111160000')`); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	records, err := GetAppliedMigrationRecords(ctx, db)
+	if err != nil {
+		t.Fatalf("GetAppliedMigrationRecords failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Name != "20260111153000_create_users" || records[1].Version != "20260111160000" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	for _, r := range records {
+		if r.AppliedAt.Before(before) || r.AppliedAt.Location() != time.UTC {
+			t.Errorf("%s: AppliedAt = %v, want a UTC time after %v", r.Name, r.AppliedAt, before)
+		}
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	plan := &MigrationPlan{Migrations: []Migration{
+		{Name: "20260111153000_create_users"},
+		{Name: "20260111160000_create_posts"},
+	}}
+	if _, err := PendingMigrations(ctx, db, plan); err == nil {
+		t.Error("expected an error before the tracking table exists")
+	}
+
+	if err := EnsureTrackingTable(ctx, db, Sqlite); err != nil {
+		t.Fatalf("EnsureTrackingTable failed: %v", err)
+	}
+	if err := RecordMigration(ctx, db, Sqlite, "20260111153000", "20260111153000_create_users"); err != nil {
+		t.Fatalf("RecordMigration failed: %v", err)
+	}
+	pending, err := PendingMigrations(ctx, db, plan)
+	if err != nil {
+		t.Fatalf("PendingMigrations failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0] != "20260111160000_create_posts" {
+		t.Errorf("pending = %v, want [20260111160000_create_posts]", pending)
+	}
+}
+
 func TestGetAllTables(t *testing.T) {
 	db, err := sql.Open("sqlite", ":memory:")
 	if err != nil {
@@ -0,0 +1,210 @@
+package migrate
+
+import (
+	"database/sql"
+	"html/template"
+	"net/http"
+	"sort"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+// UIPath is the URL prefix the migration UI is served under.
+const UIPath = "/__migrations/"
+
+// uiActionHeader must be present on the UI's POST requests. Browsers only
+// send custom headers from same-origin scripts (or after a CORS preflight
+// the server never approves), so another site cannot submit a form that
+// resets the developer's database.
+const uiActionHeader = "X-Shipq-Migrations"
+
+// UIHandler serves a small development page under UIPath listing the
+// plan's migrations as applied or pending, the tables of the resulting
+// schema, and buttons that run pending migrations or reset the database.
+//
+// The handler performs no environment check of its own: the generated
+// server mounts it only when GO_ENV is development or test.
+func UIHandler(db *sql.DB, plan *MigrationPlan, dialect string) http.Handler {
+	ui := &migrationUI{db: db, plan: plan, dialect: dialect}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+UIPath+"{$}", ui.page)
+	mux.HandleFunc("POST "+UIPath+"up", ui.up)
+	mux.HandleFunc("POST "+UIPath+"reset", ui.reset)
+	return mux
+}
+
+type migrationUI struct {
+	db      *sql.DB
+	plan    *MigrationPlan
+	dialect string
+}
+
+type uiMigration struct {
+	Name    string
+	Applied bool
+}
+
+type uiColumn struct {
+	Name       string
+	Type       string
+	Nullable   bool
+	PrimaryKey bool
+	References string
+}
+
+type uiTable struct {
+	Name    string
+	Columns []uiColumn
+}
+
+type uiPage struct {
+	Dialect    string
+	Migrations []uiMigration
+	Pending    int
+	Tables     []uiTable
+	Error      string
+}
+
+func (ui *migrationUI) page(w http.ResponseWriter, r *http.Request) {
+	data := uiPage{Dialect: ui.dialect, Tables: uiTables(ui.plan.Schema.Tables)}
+
+	applied, err := GetAppliedMigrations(r.Context(), ui.db)
+	if err != nil {
+		// Most often the tracking table does not exist yet because no
+		// migration has run; everything is then pending.
+		data.Error = err.Error()
+	}
+	appliedSet := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		appliedSet[name] = true
+	}
+	for _, m := range ui.plan.Migrations {
+		data.Migrations = append(data.Migrations, uiMigration{Name: m.Name, Applied: appliedSet[m.Name]})
+		if !appliedSet[m.Name] {
+			data.Pending++
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := uiTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ui *migrationUI) up(w http.ResponseWriter, r *http.Request) {
+	if !uiActionAllowed(w, r) {
+		return
+	}
+	if err := Run(r.Context(), ui.db, ui.plan, ui.dialect); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ui *migrationUI) reset(w http.ResponseWriter, r *http.Request) {
+	if !uiActionAllowed(w, r) {
+		return
+	}
+	if err := DropAllTables(r.Context(), ui.db, ui.dialect); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := Run(r.Context(), ui.db, ui.plan, ui.dialect); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// uiActionAllowed rejects POSTs that did not come from the UI's own script.
+func uiActionAllowed(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get(uiActionHeader) == "" {
+		http.Error(w, "missing "+uiActionHeader+" header", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// uiTables returns the schema's tables sorted by name.
+func uiTables(tables map[string]ddl.Table) []uiTable {
+	out := make([]uiTable, 0, len(tables))
+	for name, t := range tables {
+		table := uiTable{Name: name}
+		for _, c := range t.Columns {
+			table.Columns = append(table.Columns, uiColumn{
+				Name:       c.Name,
+				Type:       c.Type,
+				Nullable:   c.Nullable,
+				PrimaryKey: c.PrimaryKey,
+				References: c.References,
+			})
+		}
+		out = append(out, table)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+var uiTemplate = template.Must(template.New("migrations").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Migrations</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2937; }
+h1 { margin-bottom: 0.25rem; }
+.muted { color: #6b7280; }
+.error { background: #fef2f2; color: #991b1b; padding: 0.5rem 0.75rem; border-radius: 4px; }
+table.list { border-collapse: collapse; margin: 1rem 0; }
+table.list td { padding: 0.25rem 0.75rem; border-bottom: 1px solid #e5e7eb; font-family: ui-monospace, monospace; }
+.applied { color: #047857; }
+.pending { color: #b45309; font-weight: 600; }
+button { padding: 0.5rem 1rem; margin-right: 0.5rem; cursor: pointer; }
+button.danger { background: #b91c1c; color: white; border: none; border-radius: 4px; }
+.schema { display: flex; flex-wrap: wrap; gap: 1rem; }
+.entity { border: 1px solid #d1d5db; border-radius: 6px; min-width: 14rem; }
+.entity:target { outline: 3px solid #2563eb; }
+.entity h3 { margin: 0; padding: 0.4rem 0.75rem; background: #f3f4f6; border-bottom: 1px solid #d1d5db; font-size: 1rem; }
+.entity ul { list-style: none; margin: 0; padding: 0.4rem 0.75rem; font-family: ui-monospace, monospace; font-size: 0.85rem; }
+.entity li { padding: 0.1rem 0; }
+.pk { font-weight: 700; }
+</style>
+</head>
+<body>
+<h1>Migrations</h1>
+<p class="muted">{{.Dialect}} &middot; {{len .Migrations}} migration(s), {{.Pending}} pending</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<p>
+<button onclick="act('up', false)"{{if not .Pending}} disabled{{end}}>Run pending migrations</button>
+<button class="danger" onclick="act('reset', true)">Reset database</button>
+</p>
+<p id="status" class="error" hidden></p>
+<table class="list">
+{{range .Migrations}}<tr><td>{{.Name}}</td><td class="{{if .Applied}}applied{{else}}pending{{end}}">{{if .Applied}}applied{{else}}pending{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Schema</h2>
+<div class="schema">
+{{range .Tables}}<div class="entity" id="table-{{.Name}}">
+<h3>{{.Name}}</h3>
+<ul>
+{{range .Columns}}<li{{if .PrimaryKey}} class="pk"{{end}}>{{.Name}} <span class="muted">{{.Type}}{{if .Nullable}}?{{end}}</span>{{if .References}} &rarr; <a href="#table-{{.References}}">{{.References}}</a>{{end}}</li>
+{{end}}</ul>
+</div>
+{{end}}</div>
+
+<script>
+async function act(action, destructive) {
+  if (destructive && !confirm("Drop every table and re-run all migrations?")) return;
+  const res = await fetch(action, { method: "POST", headers: { "` + uiActionHeader + `": "1" } });
+  if (res.ok) { location.reload(); return; }
+  const status = document.getElementById("status");
+  status.textContent = await res.text();
+  status.hidden = false;
+}
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,93 @@
+package migrate
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func uiTestPlan(t *testing.T) *MigrationPlan {
+	t.Helper()
+	plan := NewPlan()
+	plan.SetCurrentMigration("20260101000000_create_authors")
+	if _, err := plan.AddTable("authors", func(tb *ddl.TableBuilder) error {
+		tb.String("name")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	authors, _ := plan.Table("authors")
+	plan.SetCurrentMigration("20260102000000_create_books")
+	if _, err := plan.AddTable("books", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("author_id").References(authors)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestUIHandler(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	h := UIHandler(db, uiTestPlan(t), Sqlite)
+	serve := func(method, path string, header bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if header {
+			req.Header.Set(uiActionHeader, "1")
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("GET", UIPath, false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d: %s", UIPath, rec.Code, rec.Body)
+	}
+	lines := strings.Split(rec.Body.String(), "\n")
+	for _, want := range []string{
+		`<p class="muted">sqlite &middot; 2 migration(s), 2 pending</p>`,
+		`<tr><td>20260101000000_create_authors</td><td class="pending">pending</td></tr>`,
+		`<tr><td>20260102000000_create_books</td><td class="pending">pending</td></tr>`,
+		`<div class="entity" id="table-books">`,
+		`<li>author_id <span class="muted">bigint</span> &rarr; <a href="#table-authors">authors</a></li>`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("page missing line %q:\n%s", want, rec.Body)
+		}
+	}
+
+	if rec := serve("POST", UIPath+"up", false); rec.Code != http.StatusForbidden {
+		t.Errorf("POST up without header = %d, want 403", rec.Code)
+	}
+	if rec := serve("POST", UIPath+"up", true); rec.Code != http.StatusNoContent {
+		t.Fatalf("POST up = %d: %s", rec.Code, rec.Body)
+	}
+	if body := serve("GET", UIPath, false).Body.String(); !strings.Contains(body, "2 migration(s), 0 pending") {
+		t.Error("migrations should be applied after POST up")
+	}
+
+	if _, err := db.Exec(`INSERT INTO authors (public_id, name) VALUES ('a1', 'Ann')`); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve("POST", UIPath+"reset", true); rec.Code != http.StatusNoContent {
+		t.Fatalf("POST reset = %d: %s", rec.Code, rec.Body)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM authors`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("after reset authors has %d rows (err %v), want 0", n, err)
+	}
+}
@@ -23,11 +23,13 @@ type AST struct {
 	OrderBy    []OrderByExpr
 	Limit      Expr
 	Offset     Expr
+	ForUpdate  bool // SELECT ... FOR UPDATE (dropped on SQLite)
 
 	// For INSERT
 	InsertCols   []Column
 	InsertRows   [][]Expr // For VALUES-based inserts
 	InsertSource *AST     // For INSERT ... SELECT (mutually exclusive with InsertRows)
+	OnConflict   *OnConflict
 	Returning    []Column
 
 	// For UPDATE
@@ -39,6 +41,9 @@ type AST struct {
 	// For CTEs (WITH clause)
 	CTEs []CTE
 
+	// Dialect-specific optimizer hints (SELECT only)
+	Hints []Hint
+
 	// Collected parameters (for validation and codegen)
 	Params []ParamInfo
 }
@@ -79,9 +84,10 @@ type SetOperation struct {
 
 // CTE represents a Common Table Expression (WITH clause).
 type CTE struct {
-	Name    string   // The CTE alias
-	Columns []string // Optional column list
-	Query   *AST     // The CTE query
+	Name      string   // The CTE alias
+	Columns   []string // Optional column list
+	Query     *AST     // The CTE query
+	Recursive bool     // WITH RECURSIVE; Query is a UNION whose right side reads the CTE
 }
 
 // TableRef references a table, optionally with an alias.
@@ -119,10 +125,25 @@ type OrderByExpr struct {
 	Desc bool
 }
 
+// OnConflict is the conflict clause of an upsert. It compiles to
+// ON CONFLICT (Columns) DO UPDATE SET ... / DO NOTHING on Postgres and
+// SQLite, and to ON DUPLICATE KEY UPDATE on MySQL, which resolves conflicts
+// on any unique key and ignores Columns.
+type OnConflict struct {
+	Columns   []Column    // Conflict target: the columns of a unique index
+	DoNothing bool        // Keep the existing row
+	Updates   []SetClause // DO UPDATE SET assignments
+	Where     Expr        // Optional condition for applying Updates
+}
+
 // SetClause represents column = value in UPDATE.
 type SetClause struct {
 	Column Column
 	Value  Expr
+
+	// Optional clauses are only applied when their param is provided; see
+	// UpdateBuilder.SetOptional.
+	Optional bool
 }
 
 // ParamInfo tracks parameters for codegen.
@@ -9,6 +9,21 @@ type Table interface {
 	TableName() string
 }
 
+// ExternalTable is a table outside the migrated schema, named by its
+// schema (a Postgres schema or a MySQL database) and table name, e.g.
+// ExternalTable{Schema: "billing", Name: "invoices"}. Its columns use the
+// qualified name: StringColumn{Table: "billing.invoices", Name: "status"}.
+// SQLite cannot compile queries that use one.
+type ExternalTable struct {
+	Schema string
+	Name   string
+}
+
+// TableName returns the qualified name, "schema.name".
+func (t ExternalTable) TableName() string {
+	return t.Schema + "." + t.Name
+}
+
 // From starts building a SELECT query from the given table.
 func From(table Table) *SelectBuilder {
 	return &SelectBuilder{
@@ -198,6 +213,14 @@ func (b *SelectBuilder) Offset(expr Expr) *SelectBuilder {
 	return b
 }
 
+// ForUpdate locks the selected rows until the end of the transaction
+// (SELECT ... FOR UPDATE). SQLite has no row locks and serializes writers
+// instead, so the clause is omitted there.
+func (b *SelectBuilder) ForUpdate() *SelectBuilder {
+	b.ast.ForUpdate = true
+	return b
+}
+
 // Build returns the completed AST.
 func (b *SelectBuilder) Build() *AST {
 	return b.ast
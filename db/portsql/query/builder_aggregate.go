@@ -64,6 +64,40 @@ func MaxExpr(expr Expr) AggregateExpr {
 	return AggregateExpr{Func: AggMax, Arg: expr}
 }
 
+// =============================================================================
+// Aggregate Comparisons (for HAVING)
+// =============================================================================
+
+// Eq compares the aggregate with other, e.g. Having(Count().Eq(Param[int64]("n"))).
+func (a AggregateExpr) Eq(other any) Expr {
+	return BinaryExpr{Left: a, Op: OpEq, Right: toExpr(other)}
+}
+
+// Ne compares the aggregate with other using <>.
+func (a AggregateExpr) Ne(other any) Expr {
+	return BinaryExpr{Left: a, Op: OpNe, Right: toExpr(other)}
+}
+
+// Lt compares the aggregate with other using <.
+func (a AggregateExpr) Lt(other any) Expr {
+	return BinaryExpr{Left: a, Op: OpLt, Right: toExpr(other)}
+}
+
+// Le compares the aggregate with other using <=.
+func (a AggregateExpr) Le(other any) Expr {
+	return BinaryExpr{Left: a, Op: OpLe, Right: toExpr(other)}
+}
+
+// Gt compares the aggregate with other using >.
+func (a AggregateExpr) Gt(other any) Expr {
+	return BinaryExpr{Left: a, Op: OpGt, Right: toExpr(other)}
+}
+
+// Ge compares the aggregate with other using >=.
+func (a AggregateExpr) Ge(other any) Expr {
+	return BinaryExpr{Left: a, Op: OpGe, Right: toExpr(other)}
+}
+
 // =============================================================================
 // Aggregate SelectBuilder Methods
 // =============================================================================
@@ -88,6 +88,44 @@ func TestMax(t *testing.T) {
 	}
 }
 
+func TestAggregateComparisons(t *testing.T) {
+	tests := []struct {
+		name string
+		expr Expr
+		op   BinaryOp
+	}{
+		{"Eq", Count().Eq(1), OpEq},
+		{"Ne", Count().Ne(1), OpNe},
+		{"Lt", Count().Lt(1), OpLt},
+		{"Le", Count().Le(1), OpLe},
+		{"Gt", Count().Gt(1), OpGt},
+		{"Ge", Count().Ge(1), OpGe},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin, ok := tt.expr.(BinaryExpr)
+			if !ok {
+				t.Fatalf("expected BinaryExpr, got %T", tt.expr)
+			}
+			if _, ok := bin.Left.(AggregateExpr); !ok {
+				t.Errorf("expected Left to be AggregateExpr, got %T", bin.Left)
+			}
+			if bin.Op != tt.op {
+				t.Errorf("expected Op = %v, got %v", tt.op, bin.Op)
+			}
+			if _, ok := bin.Right.(LiteralExpr); !ok {
+				t.Errorf("expected Right to be LiteralExpr, got %T", bin.Right)
+			}
+		})
+	}
+
+	param := Param[float64]("min")
+	bin := Sum(Float64Column{Table: "orders", Name: "amount"}).Ge(param).(BinaryExpr)
+	if bin.Right != param {
+		t.Errorf("expected Right to be the param, got %v", bin.Right)
+	}
+}
+
 func TestSelectCount(t *testing.T) {
 	users := mockTable{name: "users"}
 
@@ -32,6 +32,25 @@ func WithColumns(name string, columns []string, builder *SelectBuilder) *CTEBuil
 	}
 }
 
+// WithRecursive starts a recursive CTE definition. base selects the first
+// rows; recursive reads CTERef(name) and is run against the rows of the
+// previous round until it returns none. The rounds are combined with UNION ALL.
+// Usage: WithRecursive("tree", roots, children).Select(query.CTERef("tree"))...
+func WithRecursive(name string, base, recursive *SelectBuilder) *CTEBuilder {
+	return &CTEBuilder{
+		ctes: []CTE{recursiveCTE(name, base, recursive)},
+	}
+}
+
+// recursiveCTE builds the CTE for WithRecursive and AndRecursive.
+func recursiveCTE(name string, base, recursive *SelectBuilder) CTE {
+	return CTE{
+		Name:      name,
+		Recursive: true,
+		Query:     base.UnionAll(recursive).Build(),
+	}
+}
+
 // And adds another CTE to the WITH clause.
 func (b *CTEBuilder) And(name string, builder *SelectBuilder) *CTEBuilder {
 	b.ctes = append(b.ctes, CTE{Name: name, Query: builder.Build()})
@@ -50,6 +69,12 @@ func (b *CTEBuilder) AndColumns(name string, columns []string, builder *SelectBu
 	return b
 }
 
+// AndRecursive adds a recursive CTE. See WithRecursive.
+func (b *CTEBuilder) AndRecursive(name string, base, recursive *SelectBuilder) *CTEBuilder {
+	b.ctes = append(b.ctes, recursiveCTE(name, base, recursive))
+	return b
+}
+
 // Select starts the main query that uses the CTEs.
 func (b *CTEBuilder) Select(table Table) *CTESelectBuilder {
 	return &CTESelectBuilder{
@@ -69,6 +69,36 @@ func TestWithColumns(t *testing.T) {
 	}
 }
 
+func TestWithRecursive(t *testing.T) {
+	employees := mockTable{name: "employees"}
+	idCol := Int64Column{Table: "employees", Name: "id"}
+	managerCol := Int64Column{Table: "employees", Name: "manager_id"}
+
+	base := From(employees).Select(idCol).Where(idCol.Eq(Param[int64]("id")))
+	reports := From(employees).Select(idCol).
+		Join(CTERef("chain")).On(managerCol.Eq(Int64Column{Table: "chain", Name: "id"}))
+
+	cteBuilder := With("managers", From(employees).Select(managerCol)).
+		AndRecursive("chain", base, reports)
+
+	if len(cteBuilder.ctes) != 2 {
+		t.Fatalf("expected 2 CTEs, got %d", len(cteBuilder.ctes))
+	}
+	if cteBuilder.ctes[0].Recursive {
+		t.Error("expected the plain CTE not to be recursive")
+	}
+	cte := cteBuilder.ctes[1]
+	if !cte.Recursive || cte.Name != "chain" {
+		t.Fatalf("expected recursive CTE %q, got %+v", "chain", cte)
+	}
+	if cte.Query.SetOp == nil || cte.Query.SetOp.Op != SetOpUnionAll {
+		t.Fatalf("expected a UNION ALL of the base and recursive queries, got %+v", cte.Query)
+	}
+	if cte.Query.SetOp.Right.Joins[0].Table.Name != "chain" {
+		t.Errorf("expected the recursive query to join the CTE, got %+v", cte.Query.SetOp.Right.Joins)
+	}
+}
+
 func TestCTESelect(t *testing.T) {
 	orders := mockTable{name: "orders"}
 	idCol := Int64Column{Table: "orders", Name: "id"}
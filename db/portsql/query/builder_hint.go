@@ -0,0 +1,62 @@
+package query
+
+// =============================================================================
+// Optimizer Hints
+// =============================================================================
+
+// HintKind identifies the kind of optimizer hint attached to a query.
+type HintKind string
+
+const (
+	// HintOptimizer is a /*+ ... */ hint comment: a MySQL optimizer hint, or
+	// a pg_hint_plan directive on Postgres.
+	HintOptimizer HintKind = "optimizer"
+
+	// HintUseIndex, HintForceIndex and HintIgnoreIndex are index hints on the
+	// FROM table. MySQL supports all three; SQLite supports use and force
+	// (both compile to INDEXED BY) with a single index, as does SQL Server
+	// (WITH (INDEX(...))) with any number.
+	HintUseIndex    HintKind = "use_index"
+	HintForceIndex  HintKind = "force_index"
+	HintIgnoreIndex HintKind = "ignore_index"
+)
+
+// Hint is an optimizer hint that is compiled only for Dialect and ignored
+// for every other dialect, so a plan fix for one database never changes
+// the SQL sent to the others.
+type Hint struct {
+	Dialect Dialect
+	Kind    HintKind
+	Text    string   // Hint body for HintOptimizer, without the /*+ */ delimiters
+	Indexes []string // Index names for index hints
+}
+
+// OptimizerHint attaches a /*+ hint */ comment that is emitted only when
+// compiling for dialect. On MySQL it is placed after SELECT; on Postgres it
+// leads the statement, where pg_hint_plan looks for it.
+// Usage: From(posts).OptimizerHint(Postgres, "IndexScan(posts posts_created_at_idx)")
+func (b *SelectBuilder) OptimizerHint(dialect Dialect, hint string) *SelectBuilder {
+	b.ast.Hints = append(b.ast.Hints, Hint{Dialect: dialect, Kind: HintOptimizer, Text: hint})
+	return b
+}
+
+// UseIndex suggests indexes for the FROM table when compiling for dialect
+// (MySQL USE INDEX, SQLite INDEXED BY).
+func (b *SelectBuilder) UseIndex(dialect Dialect, indexes ...string) *SelectBuilder {
+	b.ast.Hints = append(b.ast.Hints, Hint{Dialect: dialect, Kind: HintUseIndex, Indexes: indexes})
+	return b
+}
+
+// ForceIndex forces indexes for the FROM table when compiling for dialect
+// (MySQL FORCE INDEX, SQLite INDEXED BY).
+func (b *SelectBuilder) ForceIndex(dialect Dialect, indexes ...string) *SelectBuilder {
+	b.ast.Hints = append(b.ast.Hints, Hint{Dialect: dialect, Kind: HintForceIndex, Indexes: indexes})
+	return b
+}
+
+// IgnoreIndex excludes indexes for the FROM table when compiling for
+// dialect (MySQL IGNORE INDEX).
+func (b *SelectBuilder) IgnoreIndex(dialect Dialect, indexes ...string) *SelectBuilder {
+	b.ast.Hints = append(b.ast.Hints, Hint{Dialect: dialect, Kind: HintIgnoreIndex, Indexes: indexes})
+	return b
+}
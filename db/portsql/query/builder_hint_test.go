@@ -0,0 +1,58 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSelectBuilder_Hints(t *testing.T) {
+	posts := mockTable{name: "posts"}
+	idCol := Int64Column{Table: "posts", Name: "id"}
+
+	ast := From(posts).
+		Select(idCol).
+		OptimizerHint(Postgres, "IndexScan(posts posts_created_at_idx)").
+		UseIndex(MySQL, "posts_created_at_idx").
+		ForceIndex(SQLite, "posts_created_at_idx").
+		IgnoreIndex(MySQL, "posts_title_idx").
+		Build()
+
+	want := []Hint{
+		{Dialect: Postgres, Kind: HintOptimizer, Text: "IndexScan(posts posts_created_at_idx)"},
+		{Dialect: MySQL, Kind: HintUseIndex, Indexes: []string{"posts_created_at_idx"}},
+		{Dialect: SQLite, Kind: HintForceIndex, Indexes: []string{"posts_created_at_idx"}},
+		{Dialect: MySQL, Kind: HintIgnoreIndex, Indexes: []string{"posts_title_idx"}},
+	}
+	if !reflect.DeepEqual(ast.Hints, want) {
+		t.Errorf("Hints = %+v, want %+v", ast.Hints, want)
+	}
+}
+
+func TestSerializeAST_HintsRoundTrip(t *testing.T) {
+	posts := mockTable{name: "posts"}
+	idCol := Int64Column{Table: "posts", Name: "id"}
+
+	ast := From(posts).
+		Select(idCol).
+		OptimizerHint(MySQL, "MAX_EXECUTION_TIME(1000)").
+		UseIndex(SQLite, "posts_created_at_idx").
+		Build()
+
+	data, err := json.Marshal(SerializeAST(ast))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var s SerializedAST
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if s.Hints[0].Dialect != "mysql" {
+		t.Errorf("serialized dialect = %q, want %q", s.Hints[0].Dialect, "mysql")
+	}
+
+	got := DeserializeAST(&s)
+	if !reflect.DeepEqual(got.Hints, ast.Hints) {
+		t.Errorf("round-tripped Hints = %+v, want %+v", got.Hints, ast.Hints)
+	}
+}
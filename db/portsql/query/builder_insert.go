@@ -50,10 +50,20 @@ func (b *InsertBuilder) BulkRows(rows [][]Expr) *InsertBuilder {
 	return b
 }
 
+// ValuesBatch sets several rows of values at once, the multi-row form of
+// Values: each element of rows is one row, in Columns order, and all rows
+// compile into a single INSERT ... VALUES (...), (...). It replaces any
+// previously added rows.
+// ValuesBatch is mutually exclusive with FromSelect/FromSelectAST.
+// Calling ValuesBatch clears any previously set InsertSource.
+func (b *InsertBuilder) ValuesBatch(rows [][]Expr) *InsertBuilder {
+	return b.BulkRows(rows)
+}
+
 // FromSelect sets the source of the INSERT to a SELECT query.
 // This produces INSERT INTO t (cols) SELECT ... FROM ...
 //
-// FromSelect is mutually exclusive with Values/AddRow/BulkRows.
+// FromSelect is mutually exclusive with Values/AddRow/BulkRows/ValuesBatch.
 // Calling FromSelect clears any previously set InsertRows.
 func (b *InsertBuilder) FromSelect(source *SelectBuilder) *InsertBuilder {
 	b.ast.InsertRows = nil
@@ -65,7 +75,7 @@ func (b *InsertBuilder) FromSelect(source *SelectBuilder) *InsertBuilder {
 // This is the escape hatch for when the source query is built
 // programmatically or comes from a CTE select builder.
 //
-// FromSelectAST is mutually exclusive with Values/AddRow/BulkRows.
+// FromSelectAST is mutually exclusive with Values/AddRow/BulkRows/ValuesBatch.
 // Calling FromSelectAST clears any previously set InsertRows.
 func (b *InsertBuilder) FromSelectAST(source *AST) *InsertBuilder {
 	b.ast.InsertRows = nil
@@ -80,6 +90,50 @@ func (b *InsertBuilder) WithCTEs(ctes ...CTE) *InsertBuilder {
 	return b
 }
 
+// OnConflict turns the INSERT into an upsert resolving conflicts on the
+// unique index over cols. Finish the clause with DoUpdate, DoUpdateWhere or
+// DoNothing. MySQL resolves conflicts on any unique key and ignores cols.
+//
+//	query.InsertInto(schema.Users).
+//	    Columns(schema.Users.Email(), schema.Users.Name()).
+//	    Values(query.Param[string]("email"), query.Param[string]("name")).
+//	    OnConflict(schema.Users.Email()).
+//	    DoUpdate(query.SetExcluded(schema.Users.Name())...).
+//	    Build()
+func (b *InsertBuilder) OnConflict(cols ...Column) *OnConflictBuilder {
+	return &OnConflictBuilder{insert: b, clause: &OnConflict{Columns: cols}}
+}
+
+// OnConflictBuilder builds the conflict clause of an upsert.
+type OnConflictBuilder struct {
+	insert *InsertBuilder
+	clause *OnConflict
+}
+
+// DoUpdate updates the conflicting row with sets. Use Excluded (or
+// SetExcluded) for the values the INSERT proposed.
+func (o *OnConflictBuilder) DoUpdate(sets ...SetClause) *InsertBuilder {
+	o.clause.Updates = sets
+	o.insert.ast.OnConflict = o.clause
+	return o.insert
+}
+
+// DoUpdateWhere updates the conflicting row with sets only if cond holds
+// for it; otherwise the row is left as is. MySQL has no conditional
+// upsert, so there each assignment becomes col = IF(cond, value, col):
+// cond must not read columns that sets assign.
+func (o *OnConflictBuilder) DoUpdateWhere(cond Expr, sets ...SetClause) *InsertBuilder {
+	o.clause.Where = cond
+	return o.DoUpdate(sets...)
+}
+
+// DoNothing keeps the conflicting row and skips the insert.
+func (o *OnConflictBuilder) DoNothing() *InsertBuilder {
+	o.clause.DoNothing = true
+	o.insert.ast.OnConflict = o.clause
+	return o.insert
+}
+
 // Returning sets the columns to return after insert.
 func (b *InsertBuilder) Returning(cols ...Column) *InsertBuilder {
 	b.ast.Returning = cols
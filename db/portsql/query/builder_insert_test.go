@@ -317,6 +317,68 @@ func TestInsertInto_BulkRows_ReplacesExisting(t *testing.T) {
 	}
 }
 
+func TestInsertInto_ValuesBatch(t *testing.T) {
+	authors := mockTable{name: "authors"}
+	nameCol := StringColumn{Table: "authors", Name: "name"}
+	emailCol := StringColumn{Table: "authors", Name: "email"}
+
+	ast := InsertInto(authors).
+		Columns(nameCol, emailCol).
+		Values(Param[string]("old_name"), Param[string]("old_email")).
+		ValuesBatch([][]Expr{
+			{Param[string]("name_0"), Param[string]("email_0")},
+			{Param[string]("name_1"), Param[string]("email_1")},
+		}).
+		Build()
+
+	if len(ast.InsertRows) != 2 {
+		t.Fatalf("expected 2 InsertRows after ValuesBatch, got %d", len(ast.InsertRows))
+	}
+	p, ok := ast.InsertRows[1][1].(ParamExpr)
+	if !ok {
+		t.Fatalf("expected ParamExpr, got %T", ast.InsertRows[1][1])
+	}
+	if p.Name != "email_1" {
+		t.Errorf("expected param name %q, got %q", "email_1", p.Name)
+	}
+}
+
+func TestInsertInto_OnConflict(t *testing.T) {
+	authors := mockTable{name: "authors"}
+	nameCol := StringColumn{Table: "authors", Name: "name"}
+	emailCol := StringColumn{Table: "authors", Name: "email"}
+
+	ast := InsertInto(authors).
+		Columns(emailCol, nameCol).
+		Values(Param[string]("email"), Param[string]("name")).
+		OnConflict(emailCol).
+		DoUpdate(SetExcluded(nameCol)...).
+		Build()
+
+	if ast.OnConflict == nil {
+		t.Fatal("expected OnConflict to be set")
+	}
+	if len(ast.OnConflict.Columns) != 1 || ast.OnConflict.Columns[0] != emailCol {
+		t.Errorf("expected conflict target [email], got %v", ast.OnConflict.Columns)
+	}
+	if len(ast.OnConflict.Updates) != 1 {
+		t.Fatalf("expected 1 assignment, got %d", len(ast.OnConflict.Updates))
+	}
+	if ex, ok := ast.OnConflict.Updates[0].Value.(ExcludedExpr); !ok || ex.Column != nameCol {
+		t.Errorf("expected name = EXCLUDED.name, got %#v", ast.OnConflict.Updates[0])
+	}
+
+	ast = InsertInto(authors).
+		Columns(emailCol, nameCol).
+		Values(Param[string]("email"), Param[string]("name")).
+		OnConflict(emailCol).
+		DoNothing().
+		Build()
+	if ast.OnConflict == nil || !ast.OnConflict.DoNothing || len(ast.OnConflict.Updates) != 0 {
+		t.Errorf("expected DO NOTHING clause, got %#v", ast.OnConflict)
+	}
+}
+
 func TestInsertInto_AddRow_SingleRow(t *testing.T) {
 	// AddRow with a single row should work identically to Values.
 	authors := mockTable{name: "authors"}
@@ -24,6 +24,20 @@ func (b *UpdateBuilder) Set(col Column, value Expr) *UpdateBuilder {
 	return b
 }
 
+// SetOptional adds a column = value clause that is only applied when its
+// param is provided, for partial updates. value must reference exactly one
+// param; the generated params field for it is a pointer, and leaving it nil
+// leaves the column as it is. For a nullable column the field is a pointer
+// to a pointer, so "set to NULL" and "not provided" stay distinct.
+func (b *UpdateBuilder) SetOptional(col Column, value Expr) *UpdateBuilder {
+	b.ast.SetClauses = append(b.ast.SetClauses, SetClause{
+		Column:   col,
+		Value:    value,
+		Optional: true,
+	})
+	return b
+}
+
 // Where sets the WHERE clause.
 func (b *UpdateBuilder) Where(expr Expr) *UpdateBuilder {
 	b.ast.Where = expr
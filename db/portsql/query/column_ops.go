@@ -1,8 +1,8 @@
 package query
 
 // This file contains comparison and ordering methods for all column types.
-// Each column type supports: Eq, Ne, Lt, Le, Gt, Ge, In, IsNull, IsNotNull, Asc, Desc
-// String columns additionally support: Like, ILike
+// Each column type supports: Eq, Ne, IsNotDistinctFrom, Lt, Le, Gt, Ge, In, IsNull, IsNotNull, Asc, Desc
+// String columns additionally support: Like, ILike, Contains, StartsWith, EndsWith
 
 // --- Int32Column operations ---
 
@@ -14,6 +14,10 @@ func (c Int32Column) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c Int32Column) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c Int32Column) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -72,6 +76,10 @@ func (c NullInt32Column) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullInt32Column) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullInt32Column) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -130,6 +138,10 @@ func (c Int64Column) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c Int64Column) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c Int64Column) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -188,6 +200,10 @@ func (c NullInt64Column) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullInt64Column) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullInt64Column) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -246,6 +262,10 @@ func (c Float64Column) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c Float64Column) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c Float64Column) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -304,6 +324,10 @@ func (c NullFloat64Column) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullFloat64Column) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullFloat64Column) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -362,6 +386,10 @@ func (c DecimalColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c DecimalColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c DecimalColumn) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -420,6 +448,10 @@ func (c NullDecimalColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullDecimalColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullDecimalColumn) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -478,6 +510,10 @@ func (c BoolColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c BoolColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c BoolColumn) IsNull() Expr {
 	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
 }
@@ -504,6 +540,10 @@ func (c NullBoolColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullBoolColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullBoolColumn) IsNull() Expr {
 	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
 }
@@ -530,6 +570,10 @@ func (c StringColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c StringColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c StringColumn) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -583,6 +627,38 @@ func (c StringColumn) ILike(pattern any) Expr {
 	}
 }
 
+// Contains matches values containing s as a literal substring. Unlike Like,
+// any % or _ in s is escaped in SQL, so user input cannot inject wildcards.
+func (c StringColumn) Contains(s any) Expr {
+	return escapedLike("LIKE_CONTAINS", c, s)
+}
+
+// StartsWith matches values beginning with s, escaping wildcards like Contains.
+func (c StringColumn) StartsWith(s any) Expr {
+	return escapedLike("LIKE_PREFIX", c, s)
+}
+
+// EndsWith matches values ending with s, escaping wildcards like Contains.
+func (c StringColumn) EndsWith(s any) Expr {
+	return escapedLike("LIKE_SUFFIX", c, s)
+}
+
+// IContains is Contains ignoring case. ASCII letters fold on every
+// database; whether other letters do depends on the database.
+func (c StringColumn) IContains(s any) Expr {
+	return escapedLike("ILIKE_CONTAINS", c, s)
+}
+
+// IStartsWith is StartsWith ignoring case, folding like IContains.
+func (c StringColumn) IStartsWith(s any) Expr {
+	return escapedLike("ILIKE_PREFIX", c, s)
+}
+
+// IEndsWith is EndsWith ignoring case, folding like IContains.
+func (c StringColumn) IEndsWith(s any) Expr {
+	return escapedLike("ILIKE_SUFFIX", c, s)
+}
+
 // --- NullStringColumn operations ---
 
 func (c NullStringColumn) Eq(other any) Expr {
@@ -593,6 +669,10 @@ func (c NullStringColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullStringColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullStringColumn) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -644,6 +724,37 @@ func (c NullStringColumn) ILike(pattern any) Expr {
 	}
 }
 
+func (c NullStringColumn) Contains(s any) Expr {
+	return escapedLike("LIKE_CONTAINS", c, s)
+}
+
+func (c NullStringColumn) StartsWith(s any) Expr {
+	return escapedLike("LIKE_PREFIX", c, s)
+}
+
+func (c NullStringColumn) EndsWith(s any) Expr {
+	return escapedLike("LIKE_SUFFIX", c, s)
+}
+
+func (c NullStringColumn) IContains(s any) Expr {
+	return escapedLike("ILIKE_CONTAINS", c, s)
+}
+
+func (c NullStringColumn) IStartsWith(s any) Expr {
+	return escapedLike("ILIKE_PREFIX", c, s)
+}
+
+func (c NullStringColumn) IEndsWith(s any) Expr {
+	return escapedLike("ILIKE_SUFFIX", c, s)
+}
+
+// escapedLike builds the FuncExpr behind Contains, StartsWith and EndsWith.
+// The compiler escapes the search string in SQL, so it works the same
+// whether s is a literal or a parameter bound at run time.
+func escapedLike(name string, col Column, s any) Expr {
+	return FuncExpr{Name: name, Args: []Expr{ColumnExpr{col}, toExpr(s)}}
+}
+
 // --- TimeColumn operations ---
 
 func (c TimeColumn) Eq(other any) Expr {
@@ -654,6 +765,10 @@ func (c TimeColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c TimeColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c TimeColumn) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -696,6 +811,10 @@ func (c NullTimeColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullTimeColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullTimeColumn) Lt(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
 }
@@ -728,6 +847,98 @@ func (c NullTimeColumn) Desc() OrderByExpr {
 	return OrderByExpr{Expr: ColumnExpr{c}, Desc: true}
 }
 
+// --- TimestamptzColumn operations ---
+
+func (c TimestamptzColumn) Eq(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpEq, Right: toExpr(other)}
+}
+
+func (c TimestamptzColumn) Ne(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
+}
+
+func (c TimestamptzColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
+func (c TimestamptzColumn) Lt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
+}
+
+func (c TimestamptzColumn) Le(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLe, Right: toExpr(other)}
+}
+
+func (c TimestamptzColumn) Gt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGt, Right: toExpr(other)}
+}
+
+func (c TimestamptzColumn) Ge(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGe, Right: toExpr(other)}
+}
+
+func (c TimestamptzColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c TimestamptzColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+func (c TimestamptzColumn) Asc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: false}
+}
+
+func (c TimestamptzColumn) Desc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: true}
+}
+
+// --- NullTimestamptzColumn operations ---
+
+func (c NullTimestamptzColumn) Eq(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpEq, Right: toExpr(other)}
+}
+
+func (c NullTimestamptzColumn) Ne(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
+}
+
+func (c NullTimestamptzColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
+func (c NullTimestamptzColumn) Lt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
+}
+
+func (c NullTimestamptzColumn) Le(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLe, Right: toExpr(other)}
+}
+
+func (c NullTimestamptzColumn) Gt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGt, Right: toExpr(other)}
+}
+
+func (c NullTimestamptzColumn) Ge(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGe, Right: toExpr(other)}
+}
+
+func (c NullTimestamptzColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c NullTimestamptzColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+func (c NullTimestamptzColumn) Asc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: false}
+}
+
+func (c NullTimestamptzColumn) Desc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: true}
+}
+
 // --- BytesColumn operations ---
 
 func (c BytesColumn) Eq(other any) Expr {
@@ -738,6 +949,10 @@ func (c BytesColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c BytesColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c BytesColumn) IsNull() Expr {
 	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
 }
@@ -756,6 +971,10 @@ func (c JSONColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c JSONColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c JSONColumn) IsNull() Expr {
 	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
 }
@@ -764,6 +983,19 @@ func (c JSONColumn) IsNotNull() Expr {
 	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
 }
 
+// Extract addresses the value at path, such as "$.address.city" or
+// "$.tags[0]", read as text.
+func (c JSONColumn) Extract(path string) JSONPath {
+	return JSONPath{col: c, path: path}
+}
+
+// Contains matches documents containing value, JSON text given as a
+// parameter or string: {"a":1,"b":2} contains {"a":1}, and [1,2,3]
+// contains [3,1] and 2.
+func (c JSONColumn) Contains(value any) Expr {
+	return jsonContains(c, value)
+}
+
 // --- NullJSONColumn operations ---
 
 func (c NullJSONColumn) Eq(other any) Expr {
@@ -774,6 +1006,10 @@ func (c NullJSONColumn) Ne(other any) Expr {
 	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
 }
 
+func (c NullJSONColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
 func (c NullJSONColumn) IsNull() Expr {
 	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
 }
@@ -781,3 +1017,279 @@ func (c NullJSONColumn) IsNull() Expr {
 func (c NullJSONColumn) IsNotNull() Expr {
 	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
 }
+
+// Extract addresses the value at path, read as text. It is NULL when the
+// column or the value is.
+func (c NullJSONColumn) Extract(path string) JSONPath {
+	return JSONPath{col: c, path: path}
+}
+
+// Contains matches documents containing value. NULL documents never match.
+func (c NullJSONColumn) Contains(value any) Expr {
+	return jsonContains(c, value)
+}
+
+// --- JSONPath operations ---
+
+// JSONPath is a value inside a JSON column, built by Extract. It reads as
+// text: strings unquoted, numbers and booleans in their JSON spelling, and
+// JSON null or a missing path as NULL.
+type JSONPath struct {
+	col  Column
+	path string
+}
+
+// Expr returns the extracted text, for use in SELECT or ORDER BY.
+func (p JSONPath) Expr() Expr {
+	return FuncExpr{
+		Name: "JSON_EXTRACT_TEXT",
+		Args: []Expr{ColumnExpr{p.col}, LiteralExpr{Value: p.path}},
+	}
+}
+
+func (p JSONPath) Eq(other any) Expr {
+	return BinaryExpr{Left: p.Expr(), Op: OpEq, Right: toExpr(other)}
+}
+
+func (p JSONPath) Ne(other any) Expr {
+	return BinaryExpr{Left: p.Expr(), Op: OpNe, Right: toExpr(other)}
+}
+
+func (p JSONPath) Like(pattern any) Expr {
+	return BinaryExpr{Left: p.Expr(), Op: OpLike, Right: toExpr(pattern)}
+}
+
+func (p JSONPath) In(values ...any) Expr {
+	exprs := make([]Expr, len(values))
+	for i, v := range values {
+		exprs[i] = toExpr(v)
+	}
+	return BinaryExpr{Left: p.Expr(), Op: OpIn, Right: ListExpr{Values: exprs}}
+}
+
+func (p JSONPath) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: p.Expr()}
+}
+
+func (p JSONPath) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: p.Expr()}
+}
+
+func (p JSONPath) Asc() OrderByExpr {
+	return OrderByExpr{Expr: p.Expr(), Desc: false}
+}
+
+func (p JSONPath) Desc() OrderByExpr {
+	return OrderByExpr{Expr: p.Expr(), Desc: true}
+}
+
+// --- PointColumn operations ---
+
+func (c PointColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c PointColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+// WithinRadius matches points no more than meters away from (lat, lng),
+// measured along the earth's surface (translated per database).
+func (c PointColumn) WithinRadius(lat, lng, meters any) Expr {
+	return withinRadius(c, lat, lng, meters)
+}
+
+// DistanceFrom returns the distance in meters from (lat, lng), for use in
+// SELECT or ORDER BY.
+func (c PointColumn) DistanceFrom(lat, lng any) Expr {
+	return distanceFrom(c, lat, lng)
+}
+
+// --- NullPointColumn operations ---
+
+func (c NullPointColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c NullPointColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+// WithinRadius matches points no more than meters away from (lat, lng).
+// NULL points never match.
+func (c NullPointColumn) WithinRadius(lat, lng, meters any) Expr {
+	return withinRadius(c, lat, lng, meters)
+}
+
+// DistanceFrom returns the distance in meters from (lat, lng), or NULL.
+func (c NullPointColumn) DistanceFrom(lat, lng any) Expr {
+	return distanceFrom(c, lat, lng)
+}
+
+// --- PolygonColumn operations ---
+
+func (c PolygonColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c PolygonColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+// Contains matches polygons covering the point (lat, lng), boundary
+// included (translated per database).
+func (c PolygonColumn) Contains(lat, lng any) Expr {
+	return geoContains(c, lat, lng)
+}
+
+// --- NullPolygonColumn operations ---
+
+func (c NullPolygonColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c NullPolygonColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+// Contains matches polygons covering the point (lat, lng). NULL polygons
+// never match.
+func (c NullPolygonColumn) Contains(lat, lng any) Expr {
+	return geoContains(c, lat, lng)
+}
+
+// --- CustomColumn operations ---
+
+func (c CustomColumn) Eq(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpEq, Right: toExpr(other)}
+}
+
+func (c CustomColumn) Ne(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
+}
+
+func (c CustomColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
+func (c CustomColumn) Lt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
+}
+
+func (c CustomColumn) Le(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLe, Right: toExpr(other)}
+}
+
+func (c CustomColumn) Gt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGt, Right: toExpr(other)}
+}
+
+func (c CustomColumn) Ge(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGe, Right: toExpr(other)}
+}
+
+func (c CustomColumn) In(values ...any) Expr {
+	exprs := make([]Expr, len(values))
+	for i, v := range values {
+		exprs[i] = toExpr(v)
+	}
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIn, Right: ListExpr{Values: exprs}}
+}
+
+func (c CustomColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c CustomColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+func (c CustomColumn) Asc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: false}
+}
+
+func (c CustomColumn) Desc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: true}
+}
+
+// --- NullCustomColumn operations ---
+
+func (c NullCustomColumn) Eq(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpEq, Right: toExpr(other)}
+}
+
+func (c NullCustomColumn) Ne(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpNe, Right: toExpr(other)}
+}
+
+func (c NullCustomColumn) IsNotDistinctFrom(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIsNotDistinctFrom, Right: toExpr(other)}
+}
+
+func (c NullCustomColumn) Lt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLt, Right: toExpr(other)}
+}
+
+func (c NullCustomColumn) Le(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpLe, Right: toExpr(other)}
+}
+
+func (c NullCustomColumn) Gt(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGt, Right: toExpr(other)}
+}
+
+func (c NullCustomColumn) Ge(other any) Expr {
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpGe, Right: toExpr(other)}
+}
+
+func (c NullCustomColumn) In(values ...any) Expr {
+	exprs := make([]Expr, len(values))
+	for i, v := range values {
+		exprs[i] = toExpr(v)
+	}
+	return BinaryExpr{Left: ColumnExpr{c}, Op: OpIn, Right: ListExpr{Values: exprs}}
+}
+
+func (c NullCustomColumn) IsNull() Expr {
+	return UnaryExpr{Op: OpIsNull, Expr: ColumnExpr{c}}
+}
+
+func (c NullCustomColumn) IsNotNull() Expr {
+	return UnaryExpr{Op: OpNotNull, Expr: ColumnExpr{c}}
+}
+
+func (c NullCustomColumn) Asc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: false}
+}
+
+func (c NullCustomColumn) Desc() OrderByExpr {
+	return OrderByExpr{Expr: ColumnExpr{c}, Desc: true}
+}
+
+func withinRadius(col Column, lat, lng, meters any) Expr {
+	return FuncExpr{
+		Name: "WITHIN_RADIUS",
+		Args: []Expr{ColumnExpr{col}, toExpr(lat), toExpr(lng), toExpr(meters)},
+	}
+}
+
+func distanceFrom(col Column, lat, lng any) Expr {
+	return FuncExpr{
+		Name: "GEO_DISTANCE",
+		Args: []Expr{ColumnExpr{col}, toExpr(lat), toExpr(lng)},
+	}
+}
+
+func geoContains(col Column, lat, lng any) Expr {
+	return FuncExpr{
+		Name: "GEO_CONTAINS",
+		Args: []Expr{ColumnExpr{col}, toExpr(lat), toExpr(lng)},
+	}
+}
+
+func jsonContains(col Column, value any) Expr {
+	return FuncExpr{
+		Name: "JSON_CONTAINS",
+		Args: []Expr{ColumnExpr{col}, toExpr(value)},
+	}
+}
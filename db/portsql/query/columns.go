@@ -114,6 +114,15 @@ func (c NullFloat64Column) WithTable(tableName string) NullFloat64Column {
 
 // --- Decimal Columns (for decimal type - stored as string for precision) ---
 
+// DecimalTypedColumn is implemented by DECIMAL/NUMERIC columns. Their Go
+// type is a string like the text columns'; the runner generator uses it to
+// normalize SUM and AVG of decimal columns, which drivers return as text,
+// []byte or float64 depending on the dialect.
+type DecimalTypedColumn interface {
+	Column
+	IsDecimal() bool
+}
+
 // DecimalColumn represents a non-nullable decimal column.
 // Decimals are represented as strings in Go to preserve precision.
 type DecimalColumn struct {
@@ -125,6 +134,7 @@ func (c DecimalColumn) TableName() string  { return c.Table }
 func (c DecimalColumn) ColumnName() string { return c.Name }
 func (c DecimalColumn) IsNullable() bool   { return false }
 func (c DecimalColumn) GoType() string     { return "string" }
+func (c DecimalColumn) IsDecimal() bool    { return true }
 
 // WithTable returns a copy of this column with a different table name (for aliases).
 func (c DecimalColumn) WithTable(tableName string) DecimalColumn {
@@ -141,12 +151,19 @@ func (c NullDecimalColumn) TableName() string  { return c.Table }
 func (c NullDecimalColumn) ColumnName() string { return c.Name }
 func (c NullDecimalColumn) IsNullable() bool   { return true }
 func (c NullDecimalColumn) GoType() string     { return "*string" }
+func (c NullDecimalColumn) IsDecimal() bool    { return true }
 
 // WithTable returns a copy of this column with a different table name (for aliases).
 func (c NullDecimalColumn) WithTable(tableName string) NullDecimalColumn {
 	return NullDecimalColumn{Table: tableName, Name: c.Name}
 }
 
+// IsDecimalColumn reports whether col is a DECIMAL/NUMERIC column.
+func IsDecimalColumn(col Column) bool {
+	d, ok := col.(DecimalTypedColumn)
+	return ok && d.IsDecimal()
+}
+
 // --- Bool Columns (for boolean type) ---
 
 // BoolColumn represents a non-nullable boolean column.
@@ -249,6 +266,56 @@ func (c NullTimeColumn) WithTable(tableName string) NullTimeColumn {
 	return NullTimeColumn{Table: tableName, Name: c.Name}
 }
 
+// --- Timestamptz Columns (for timestamptz type) ---
+
+// UTCColumn is implemented by columns whose values are normalized to UTC.
+// The runner generator uses it to convert parameters and results so the
+// stored wall-clock time is UTC on every dialect.
+type UTCColumn interface {
+	Column
+	IsUTC() bool
+}
+
+// TimestamptzColumn represents a non-nullable timestamptz column.
+type TimestamptzColumn struct {
+	Table string
+	Name  string
+}
+
+func (c TimestamptzColumn) TableName() string  { return c.Table }
+func (c TimestamptzColumn) ColumnName() string { return c.Name }
+func (c TimestamptzColumn) IsNullable() bool   { return false }
+func (c TimestamptzColumn) GoType() string     { return "time.Time" }
+func (c TimestamptzColumn) IsUTC() bool        { return true }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c TimestamptzColumn) WithTable(tableName string) TimestamptzColumn {
+	return TimestamptzColumn{Table: tableName, Name: c.Name}
+}
+
+// NullTimestamptzColumn represents a nullable timestamptz column.
+type NullTimestamptzColumn struct {
+	Table string
+	Name  string
+}
+
+func (c NullTimestamptzColumn) TableName() string  { return c.Table }
+func (c NullTimestamptzColumn) ColumnName() string { return c.Name }
+func (c NullTimestamptzColumn) IsNullable() bool   { return true }
+func (c NullTimestamptzColumn) GoType() string     { return "*time.Time" }
+func (c NullTimestamptzColumn) IsUTC() bool        { return true }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c NullTimestamptzColumn) WithTable(tableName string) NullTimestamptzColumn {
+	return NullTimestamptzColumn{Table: tableName, Name: c.Name}
+}
+
+// IsUTCColumn reports whether col holds UTC-normalized timestamps.
+func IsUTCColumn(col Column) bool {
+	u, ok := col.(UTCColumn)
+	return ok && u.IsUTC()
+}
+
 // --- Bytes Column (for binary type) ---
 
 // BytesColumn represents a binary column.
@@ -302,6 +369,153 @@ func (c NullJSONColumn) WithTable(tableName string) NullJSONColumn {
 	return NullJSONColumn{Table: tableName, Name: c.Name}
 }
 
+// --- Point Columns (for point type) ---
+
+// SpatialColumn is implemented by columns holding geographic points or
+// polygons. The compiler uses it to convert between the database's native
+// representation and the WKT text used in Go.
+type SpatialColumn interface {
+	Column
+	IsSpatial() bool
+}
+
+// PointColumn represents a non-nullable point column. Values are WKT
+// strings, e.g. "POINT(-122.4194 37.7749)"; see PointWKT.
+type PointColumn struct {
+	Table string
+	Name  string
+}
+
+func (c PointColumn) TableName() string  { return c.Table }
+func (c PointColumn) ColumnName() string { return c.Name }
+func (c PointColumn) IsNullable() bool   { return false }
+func (c PointColumn) GoType() string     { return "string" }
+func (c PointColumn) IsSpatial() bool    { return true }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c PointColumn) WithTable(tableName string) PointColumn {
+	return PointColumn{Table: tableName, Name: c.Name}
+}
+
+// NullPointColumn represents a nullable point column.
+type NullPointColumn struct {
+	Table string
+	Name  string
+}
+
+func (c NullPointColumn) TableName() string  { return c.Table }
+func (c NullPointColumn) ColumnName() string { return c.Name }
+func (c NullPointColumn) IsNullable() bool   { return true }
+func (c NullPointColumn) GoType() string     { return "*string" }
+func (c NullPointColumn) IsSpatial() bool    { return true }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c NullPointColumn) WithTable(tableName string) NullPointColumn {
+	return NullPointColumn{Table: tableName, Name: c.Name}
+}
+
+// --- Polygon Columns (for polygon type) ---
+
+// PolygonColumn represents a non-nullable polygon column. Values are
+// single-ring WKT strings, e.g. "POLYGON((-122.5 37.7, -122.3 37.7, -122.3 37.9, -122.5 37.7))".
+type PolygonColumn struct {
+	Table string
+	Name  string
+}
+
+func (c PolygonColumn) TableName() string  { return c.Table }
+func (c PolygonColumn) ColumnName() string { return c.Name }
+func (c PolygonColumn) IsNullable() bool   { return false }
+func (c PolygonColumn) GoType() string     { return "string" }
+func (c PolygonColumn) IsSpatial() bool    { return true }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c PolygonColumn) WithTable(tableName string) PolygonColumn {
+	return PolygonColumn{Table: tableName, Name: c.Name}
+}
+
+// NullPolygonColumn represents a nullable polygon column.
+type NullPolygonColumn struct {
+	Table string
+	Name  string
+}
+
+func (c NullPolygonColumn) TableName() string  { return c.Table }
+func (c NullPolygonColumn) ColumnName() string { return c.Name }
+func (c NullPolygonColumn) IsNullable() bool   { return true }
+func (c NullPolygonColumn) GoType() string     { return "*string" }
+func (c NullPolygonColumn) IsSpatial() bool    { return true }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c NullPolygonColumn) WithTable(tableName string) NullPolygonColumn {
+	return NullPolygonColumn{Table: tableName, Name: c.Name}
+}
+
+// IsSpatialColumn reports whether col holds geographic points or polygons.
+func IsSpatialColumn(col Column) bool {
+	s, ok := col.(SpatialColumn)
+	return ok && s.IsSpatial()
+}
+
+// --- Custom Columns (for types registered with ddl.RegisterType) ---
+
+// CustomTypedColumn is implemented by columns of a registered custom type.
+// The runner generator uses the type name to find the type's scan and value
+// conversions.
+type CustomTypedColumn interface {
+	Column
+	CustomType() string
+}
+
+// CustomColumn represents a non-nullable column of a custom type. Go is the
+// Go type of its values, e.g. "decimal.Decimal".
+type CustomColumn struct {
+	Table string
+	Name  string
+	Type  string
+	Go    string
+}
+
+func (c CustomColumn) TableName() string  { return c.Table }
+func (c CustomColumn) ColumnName() string { return c.Name }
+func (c CustomColumn) IsNullable() bool   { return false }
+func (c CustomColumn) GoType() string     { return c.Go }
+func (c CustomColumn) CustomType() string { return c.Type }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c CustomColumn) WithTable(tableName string) CustomColumn {
+	c.Table = tableName
+	return c
+}
+
+// NullCustomColumn represents a nullable column of a custom type.
+type NullCustomColumn struct {
+	Table string
+	Name  string
+	Type  string
+	Go    string
+}
+
+func (c NullCustomColumn) TableName() string  { return c.Table }
+func (c NullCustomColumn) ColumnName() string { return c.Name }
+func (c NullCustomColumn) IsNullable() bool   { return true }
+func (c NullCustomColumn) GoType() string     { return "*" + c.Go }
+func (c NullCustomColumn) CustomType() string { return c.Type }
+
+// WithTable returns a copy of this column with a different table name (for aliases).
+func (c NullCustomColumn) WithTable(tableName string) NullCustomColumn {
+	c.Table = tableName
+	return c
+}
+
+// CustomTypeOf returns the custom type name of col, or "" for built-in types.
+func CustomTypeOf(col Column) string {
+	if c, ok := col.(CustomTypedColumn); ok {
+		return c.CustomType()
+	}
+	return ""
+}
+
 // Compile-time verification that all column types implement Column interface
 var (
 	_ Column = Int32Column{}
@@ -318,7 +532,24 @@ var (
 	_ Column = NullStringColumn{}
 	_ Column = TimeColumn{}
 	_ Column = NullTimeColumn{}
+	_ Column = TimestamptzColumn{}
+	_ Column = NullTimestamptzColumn{}
 	_ Column = BytesColumn{}
 	_ Column = JSONColumn{}
 	_ Column = NullJSONColumn{}
+	_ Column = PointColumn{}
+	_ Column = NullPointColumn{}
+	_ Column = PolygonColumn{}
+	_ Column = NullPolygonColumn{}
+
+	_ SpatialColumn = PointColumn{}
+	_ SpatialColumn = NullPointColumn{}
+	_ SpatialColumn = PolygonColumn{}
+	_ SpatialColumn = NullPolygonColumn{}
+
+	_ UTCColumn = TimestamptzColumn{}
+	_ UTCColumn = NullTimestamptzColumn{}
+
+	_ CustomTypedColumn = CustomColumn{}
+	_ CustomTypedColumn = NullCustomColumn{}
 )
@@ -2,6 +2,7 @@ package compile
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/shipq/shipq/db/portsql/query"
@@ -11,6 +12,10 @@ import (
 type Compiler struct {
 	dialect Dialect
 	state   *CompilerState
+
+	// pointValue is set while writing a value stored into a point column,
+	// so parameters and string literals are converted from WKT.
+	pointValue bool
 }
 
 // NewCompiler creates a new compiler for the given dialect.
@@ -50,7 +55,16 @@ func (c *Compiler) Compile(ast *query.AST) (sql string, paramOrder []string, err
 // This allows nested compilation (subqueries, CTEs, set operations) to share
 // state with the parent compilation, ensuring correct parameter numbering.
 func (c *Compiler) compileInto(ast *query.AST, b *strings.Builder) error {
-	// Handle CTEs (WITH clause) first
+	// Leading optimizer hints (pg_hint_plan only reads the first comment)
+	if ast.Kind == query.SelectQuery && !c.dialect.OptimizerHintAfterSelect() {
+		c.writeOptimizerHints(b, ast.Hints)
+	}
+
+	if err := c.validateTableRefs(ast); err != nil {
+		return err
+	}
+
+	// Handle CTEs (WITH clause)
 	if len(ast.CTEs) > 0 {
 		if err := c.writeCTEs(b, ast.CTEs); err != nil {
 			return err
@@ -86,6 +100,75 @@ func (c *Compiler) compileInto(ast *query.AST, b *strings.Builder) error {
 	return nil
 }
 
+// validateTableRefs checks the schema-qualified tables ("schema.table") a
+// query reads or writes. Postgres resolves the qualifier as a schema and
+// MySQL as a database; SQLite has neither, so they are rejected there
+// rather than compiled into a quoted identifier that names no table.
+func (c *Compiler) validateTableRefs(ast *query.AST) error {
+	names := []string{ast.FromTable.Name}
+	for _, join := range ast.Joins {
+		names = append(names, join.Table.Name)
+	}
+	for _, name := range names {
+		if err := c.validateTableName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) validateTableName(name string) error {
+	if !strings.Contains(name, ".") {
+		return nil
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid table name %q: expected schema.table", name)
+	}
+	for _, part := range parts {
+		if err := ValidateIdentifier(part); err != nil {
+			return fmt.Errorf("invalid table name %q: %w", name, err)
+		}
+	}
+	if !c.dialect.SupportsQualifiedTables() {
+		return fmt.Errorf("schema-qualified table %q is unsupported on %s", name, c.dialect.Name())
+	}
+	return nil
+}
+
+// ValidateNamespaces checks that the qualifier of every schema-qualified
+// table ast reads or writes, including in CTEs, set operations and
+// INSERT ... SELECT, is one of namespaces: the schemas declared in
+// schema.json with plan.AddNamespace. `shipq db compile` calls it so a
+// mistyped qualifier fails the compile rather than the query.
+func ValidateNamespaces(ast *query.AST, namespaces []string) error {
+	if ast == nil {
+		return nil
+	}
+	names := []string{ast.FromTable.Name}
+	for _, join := range ast.Joins {
+		names = append(names, join.Table.Name)
+	}
+	for _, name := range names {
+		if schema, _, ok := strings.Cut(name, "."); ok && !slices.Contains(namespaces, schema) {
+			return fmt.Errorf("table %q is in namespace %q, which no migration declares with plan.AddNamespace", name, schema)
+		}
+	}
+	subqueries := []*query.AST{ast.InsertSource}
+	for _, cte := range ast.CTEs {
+		subqueries = append(subqueries, cte.Query)
+	}
+	if ast.SetOp != nil {
+		subqueries = append(subqueries, ast.SetOp.Left, ast.SetOp.Right)
+	}
+	for _, sub := range subqueries {
+		if err := ValidateNamespaces(sub, namespaces); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // =============================================================================
 // SELECT Compilation
 // =============================================================================
@@ -95,9 +178,20 @@ func (c *Compiler) compileSelect(ast *query.AST) (string, error) {
 
 	// SELECT clause
 	b.WriteString("SELECT ")
+	if c.dialect.OptimizerHintAfterSelect() {
+		c.writeOptimizerHints(&b, ast.Hints)
+	}
 	if ast.Distinct {
 		b.WriteString("DISTINCT ")
 	}
+	top := c.dialect.OffsetFetch() && ast.Limit != nil && ast.Offset == nil
+	if top {
+		b.WriteString("TOP (")
+		if err := c.writeExpr(&b, ast.Limit); err != nil {
+			return "", err
+		}
+		b.WriteString(") ")
+	}
 	if len(ast.SelectCols) == 0 {
 		b.WriteString("*")
 	} else {
@@ -105,7 +199,12 @@ func (c *Compiler) compileSelect(ast *query.AST) (string, error) {
 			if i > 0 {
 				b.WriteString(", ")
 			}
-			if err := c.writeExpr(&b, col.Expr); err != nil {
+			if ce, ok := col.Expr.(query.ColumnExpr); ok && query.IsSpatialColumn(ce.Column) {
+				c.dialect.WritePointText(&b, func() { c.writeColumn(&b, ce.Column) })
+				if col.Alias == "" {
+					col.Alias = ce.Column.ColumnName()
+				}
+			} else if err := c.writeExpr(&b, col.Expr); err != nil {
 				return "", err
 			}
 			if col.Alias != "" {
@@ -120,7 +219,7 @@ func (c *Compiler) compileSelect(ast *query.AST) (string, error) {
 
 	// FROM clause
 	b.WriteString(" FROM ")
-	c.writeIdentifier(&b, ast.FromTable.Name)
+	c.writeTableName(&b, ast.FromTable.Name)
 	if ast.FromTable.Alias != "" {
 		if err := ValidateIdentifier(ast.FromTable.Alias); err != nil {
 			return "", fmt.Errorf("invalid table alias: %w", err)
@@ -128,13 +227,29 @@ func (c *Compiler) compileSelect(ast *query.AST) (string, error) {
 		b.WriteString(" AS ")
 		c.writeIdentifier(&b, ast.FromTable.Alias)
 	}
+	indexHinted := false
+	for _, hint := range ast.Hints {
+		if hint.Kind == query.HintOptimizer || hint.Dialect.String() != c.dialect.Name() {
+			continue
+		}
+		if err := c.dialect.WriteIndexHint(&b, hint); err != nil {
+			return "", err
+		}
+		indexHinted = true
+	}
+	if ast.ForUpdate && c.dialect.RowLockTableHint() {
+		if indexHinted {
+			return "", fmt.Errorf("FOR UPDATE cannot be combined with an index hint on %s", c.dialect.Name())
+		}
+		b.WriteString(" WITH (UPDLOCK, ROWLOCK)")
+	}
 
 	// JOIN clauses
 	for _, join := range ast.Joins {
 		b.WriteString(" ")
 		b.WriteString(string(join.Type))
 		b.WriteString(" JOIN ")
-		c.writeIdentifier(&b, join.Table.Name)
+		c.writeTableName(&b, join.Table.Name)
 		if join.Table.Alias != "" {
 			if err := ValidateIdentifier(join.Table.Alias); err != nil {
 				return "", fmt.Errorf("invalid join table alias: %w", err)
@@ -191,20 +306,16 @@ func (c *Compiler) compileSelect(ast *query.AST) (string, error) {
 		}
 	}
 
-	// LIMIT clause
-	if ast.Limit != nil {
-		b.WriteString(" LIMIT ")
-		if err := c.writeExpr(&b, ast.Limit); err != nil {
+	// LIMIT and OFFSET clauses
+	if !top {
+		if err := c.writePagination(&b, ast); err != nil {
 			return "", err
 		}
 	}
 
-	// OFFSET clause
-	if ast.Offset != nil {
-		b.WriteString(" OFFSET ")
-		if err := c.writeExpr(&b, ast.Offset); err != nil {
-			return "", err
-		}
+	// FOR UPDATE clause
+	if ast.ForUpdate && c.dialect.SupportsRowLocks() && !c.dialect.RowLockTableHint() {
+		b.WriteString(" FOR UPDATE")
 	}
 
 	return b.String(), nil
@@ -218,7 +329,7 @@ func (c *Compiler) compileInsert(ast *query.AST) (string, error) {
 	var b strings.Builder
 
 	b.WriteString("INSERT INTO ")
-	c.writeIdentifier(&b, ast.FromTable.Name)
+	c.writeTableName(&b, ast.FromTable.Name)
 
 	// Column list
 	if len(ast.InsertCols) > 0 {
@@ -232,6 +343,13 @@ func (c *Compiler) compileInsert(ast *query.AST) (string, error) {
 		b.WriteString(")")
 	}
 
+	// OUTPUT clause (SQL Server returns inserted columns ahead of VALUES)
+	returning := len(ast.Returning) > 0 && c.dialect.SupportsReturning()
+	if returning && c.dialect.ReturningAsOutput() {
+		b.WriteString(" OUTPUT ")
+		c.writeReturning(&b, ast.Returning, "INSERTED.")
+	}
+
 	if ast.InsertSource != nil {
 		// INSERT ... SELECT
 		b.WriteString(" ")
@@ -251,7 +369,7 @@ func (c *Compiler) compileInsert(ast *query.AST) (string, error) {
 				if ci > 0 {
 					b.WriteString(", ")
 				}
-				if err := c.writeExpr(&b, val); err != nil {
+				if err := c.writeValue(&b, ast.InsertCols[ci], val); err != nil {
 					return "", err
 				}
 			}
@@ -259,19 +377,125 @@ func (c *Compiler) compileInsert(ast *query.AST) (string, error) {
 		}
 	}
 
+	if ast.OnConflict != nil {
+		if !c.dialect.SupportsUpsert() {
+			return "", fmt.Errorf("upserts (ON CONFLICT) are unsupported on %s", c.dialect.Name())
+		}
+		if err := c.writeOnConflict(&b, ast); err != nil {
+			return "", err
+		}
+	}
+
 	// RETURNING clause (Postgres and SQLite support this, MySQL doesn't)
 	// Note: MySQL codegen handles RETURNING differently by using result.LastInsertId()
-	if len(ast.Returning) > 0 && c.dialect.SupportsReturning() {
+	if returning && !c.dialect.ReturningAsOutput() {
 		b.WriteString(" RETURNING ")
-		for i, col := range ast.Returning {
+		c.writeReturning(&b, ast.Returning, "")
+	}
+
+	return b.String(), nil
+}
+
+// writeReturning writes the columns of a RETURNING or OUTPUT clause, each
+// qualified by prefix (INSERTED. for OUTPUT). Point columns are read as WKT
+// under their own name.
+func (c *Compiler) writeReturning(b *strings.Builder, cols []query.Column, prefix string) {
+	for i, col := range cols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		name := c.dialect.QuoteIdentifier(col.ColumnName())
+		if query.IsSpatialColumn(col) {
+			var text strings.Builder
+			c.dialect.WritePointText(&text, func() { text.WriteString(prefix + name) })
+			if text.String() != prefix+name {
+				b.WriteString(text.String())
+				b.WriteString(" AS ")
+				b.WriteString(name)
+				continue
+			}
+		}
+		b.WriteString(prefix + name)
+	}
+}
+
+// writeOnConflict writes the conflict clause of an upsert.
+func (c *Compiler) writeOnConflict(b *strings.Builder, ast *query.AST) error {
+	oc := ast.OnConflict
+	if c.dialect.UpsertOnDuplicateKey() {
+		b.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if oc.DoNothing {
+			// No DO NOTHING: assigning a column to itself keeps the row as is.
+			col := ast.InsertCols[0]
+			if len(oc.Columns) > 0 {
+				col = oc.Columns[0]
+			}
+			c.writeIdentifier(b, col.ColumnName())
+			b.WriteString(" = ")
+			c.writeIdentifier(b, col.ColumnName())
+			return nil
+		}
+		for i, set := range oc.Updates {
 			if i > 0 {
 				b.WriteString(", ")
 			}
-			c.writeIdentifier(&b, col.ColumnName())
+			c.writeIdentifier(b, set.Column.ColumnName())
+			b.WriteString(" = ")
+			if oc.Where == nil {
+				if err := c.writeValue(b, set.Column, set.Value); err != nil {
+					return err
+				}
+				continue
+			}
+			// No conditional upsert: keep the old value unless Where holds.
+			b.WriteString("IF(")
+			if err := c.writeExpr(b, oc.Where); err != nil {
+				return err
+			}
+			b.WriteString(", ")
+			if err := c.writeValue(b, set.Column, set.Value); err != nil {
+				return err
+			}
+			b.WriteString(", ")
+			c.writeIdentifier(b, set.Column.ColumnName())
+			b.WriteString(")")
 		}
+		return nil
 	}
 
-	return b.String(), nil
+	b.WriteString(" ON CONFLICT")
+	if len(oc.Columns) > 0 {
+		b.WriteString(" (")
+		for i, col := range oc.Columns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			c.writeIdentifier(b, col.ColumnName())
+		}
+		b.WriteString(")")
+	}
+	if oc.DoNothing {
+		b.WriteString(" DO NOTHING")
+		return nil
+	}
+	b.WriteString(" DO UPDATE SET ")
+	for i, set := range oc.Updates {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		c.writeIdentifier(b, set.Column.ColumnName())
+		b.WriteString(" = ")
+		if err := c.writeValue(b, set.Column, set.Value); err != nil {
+			return err
+		}
+	}
+	if oc.Where != nil {
+		b.WriteString(" WHERE ")
+		if err := c.writeExpr(b, oc.Where); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // =============================================================================
@@ -282,7 +506,7 @@ func (c *Compiler) compileUpdate(ast *query.AST) (string, error) {
 	var b strings.Builder
 
 	b.WriteString("UPDATE ")
-	c.writeIdentifier(&b, ast.FromTable.Name)
+	c.writeTableName(&b, ast.FromTable.Name)
 
 	// SET clause
 	b.WriteString(" SET ")
@@ -292,7 +516,7 @@ func (c *Compiler) compileUpdate(ast *query.AST) (string, error) {
 		}
 		c.writeIdentifier(&b, set.Column.ColumnName())
 		b.WriteString(" = ")
-		if err := c.writeExpr(&b, set.Value); err != nil {
+		if err := c.writeValue(&b, set.Column, set.Value); err != nil {
 			return "", err
 		}
 	}
@@ -316,7 +540,7 @@ func (c *Compiler) compileDelete(ast *query.AST) (string, error) {
 	var b strings.Builder
 
 	b.WriteString("DELETE FROM ")
-	c.writeIdentifier(&b, ast.FromTable.Name)
+	c.writeTableName(&b, ast.FromTable.Name)
 
 	// WHERE clause
 	if ast.Where != nil {
@@ -339,11 +563,17 @@ func (c *Compiler) writeExpr(b *strings.Builder, expr query.Expr) error {
 		c.writeColumn(b, e.Column)
 
 	case query.ParamExpr:
+		if c.pointValue {
+			return c.writePointValue(b, e)
+		}
 		c.state.ParamCount++
 		c.state.Params = append(c.state.Params, e.Name)
 		b.WriteString(c.dialect.Placeholder(c.state.ParamCount))
 
 	case query.LiteralExpr:
+		if _, isString := e.Value.(string); isString && c.pointValue {
+			return c.writePointValue(b, e)
+		}
 		if err := c.writeLiteral(b, e.Value); err != nil {
 			return err
 		}
@@ -386,7 +616,11 @@ func (c *Compiler) writeExpr(b *strings.Builder, expr query.Expr) error {
 			if err := c.writeExpr(b, e.Left); err != nil {
 				return err
 			}
-			fmt.Fprintf(b, " %s ", e.Op)
+			if e.Op == query.OpIsNotDistinctFrom {
+				fmt.Fprintf(b, " %s ", c.dialect.NullSafeEqualOp())
+			} else {
+				fmt.Fprintf(b, " %s ", e.Op)
+			}
 			if err := c.writeExpr(b, e.Right); err != nil {
 				return err
 			}
@@ -450,18 +684,21 @@ func (c *Compiler) writeExpr(b *strings.Builder, expr query.Expr) error {
 		// Write subquery wrapped in parentheses
 		// Use compileInto to share state with parent, ensuring correct param numbering
 		b.WriteString("(")
-		if err := c.compileInto(e.Query, b); err != nil {
+		if err := c.compileNested(e.Query, b); err != nil {
 			return err
 		}
 		b.WriteString(")")
 
+	case query.ExcludedExpr:
+		c.dialect.WriteExcluded(b, c.dialect.QuoteIdentifier(e.Column.ColumnName()))
+
 	case query.ExistsExpr:
 		if e.Negated {
 			b.WriteString("NOT ")
 		}
 		b.WriteString("EXISTS (")
 		// Use compileInto to share state with parent, ensuring correct param numbering
-		if err := c.compileInto(e.Subquery, b); err != nil {
+		if err := c.compileNested(e.Subquery, b); err != nil {
 			return err
 		}
 		b.WriteString(")")
@@ -473,12 +710,71 @@ func (c *Compiler) writeExpr(b *strings.Builder, expr query.Expr) error {
 	return nil
 }
 
+// writeValue writes a value being stored into col.
+func (c *Compiler) writeValue(b *strings.Builder, col query.Column, val query.Expr) error {
+	if !query.IsSpatialColumn(col) {
+		return c.writeExpr(b, val)
+	}
+	c.pointValue = true
+	defer func() { c.pointValue = false }()
+	return c.writeExpr(b, val)
+}
+
+// writePointValue writes a parameter or literal holding WKT text, converted
+// for storage in a point column.
+func (c *Compiler) writePointValue(b *strings.Builder, val query.Expr) error {
+	c.pointValue = false
+	defer func() { c.pointValue = true }()
+	return c.dialect.WritePointValue(b, func() error { return c.writeExpr(b, val) })
+}
+
+// compileNested compiles a subquery inside an expression. Values in the
+// subquery are not the enclosing point value, so the flag is cleared.
+func (c *Compiler) compileNested(ast *query.AST, b *strings.Builder) error {
+	saved := c.pointValue
+	c.pointValue = false
+	defer func() { c.pointValue = saved }()
+	return c.compileInto(ast, b)
+}
+
+// writeOptimizerHints writes the optimizer hints targeting the compiler's
+// dialect as a single /*+ ... */ comment followed by a space. Hints for
+// other dialects are skipped.
+func (c *Compiler) writeOptimizerHints(b *strings.Builder, hints []query.Hint) {
+	var texts []string
+	for _, hint := range hints {
+		if hint.Kind == query.HintOptimizer && hint.Dialect.String() == c.dialect.Name() {
+			texts = append(texts, hint.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return
+	}
+	b.WriteString("/*+ ")
+	b.WriteString(strings.Join(texts, " "))
+	b.WriteString(" */ ")
+}
+
 func (c *Compiler) writeIdentifier(b *strings.Builder, name string) {
 	b.WriteString(c.dialect.QuoteIdentifier(name))
 }
 
+// writeTableName writes a table reference. A schema-qualified name such as
+// "billing.invoices" is quoted part by part; validateTableRefs has already
+// checked that the dialect supports it.
+func (c *Compiler) writeTableName(b *strings.Builder, name string) {
+	schema, table, ok := strings.Cut(name, ".")
+	if !ok {
+		c.writeIdentifier(b, name)
+		return
+	}
+	c.writeIdentifier(b, schema)
+	b.WriteString(".")
+	c.writeIdentifier(b, table)
+}
+
 func (c *Compiler) writeColumn(b *strings.Builder, col query.Column) {
-	c.writeIdentifier(b, col.TableName())
+	c.writeTableName(b, col.TableName())
 	b.WriteString(".")
 	c.writeIdentifier(b, col.ColumnName())
 }
@@ -531,6 +827,17 @@ func (c *Compiler) writeFunc(b *strings.Builder, f query.FuncExpr) error {
 		return c.dialect.WriteILIKE(b, f.Args, func(e query.Expr) error {
 			return c.writeExpr(b, e)
 		})
+	case "LIKE_CONTAINS", "LIKE_PREFIX", "LIKE_SUFFIX", "ILIKE_CONTAINS", "ILIKE_PREFIX", "ILIKE_SUFFIX":
+		leading := !strings.HasSuffix(f.Name, "_PREFIX")
+		trailing := !strings.HasSuffix(f.Name, "_SUFFIX")
+		fold := strings.HasPrefix(f.Name, "ILIKE_")
+		return c.dialect.WriteEscapedLike(b, f.Args, leading, trailing, fold, func(e query.Expr) error {
+			return c.writeExpr(b, e)
+		})
+	case "WITHIN_RADIUS", "GEO_DISTANCE", "GEO_CONTAINS":
+		return c.writeGeoFunc(b, f)
+	case "JSON_EXTRACT_TEXT", "JSON_CONTAINS":
+		return c.writeJSONFunc(b, f)
 	default:
 		b.WriteString(f.Name)
 		b.WriteString("(")
@@ -547,6 +854,57 @@ func (c *Compiler) writeFunc(b *strings.Builder, f query.FuncExpr) error {
 	return nil
 }
 
+// writeGeoFunc writes WITHIN_RADIUS(col, lat, lng, meters) and
+// GEO_DISTANCE(col, lat, lng), built by the point column methods, and
+// GEO_CONTAINS(col, lat, lng), built by the polygon column methods.
+func (c *Compiler) writeGeoFunc(b *strings.Builder, f query.FuncExpr) error {
+	want := 3
+	if f.Name == "WITHIN_RADIUS" {
+		want = 4
+	}
+	if len(f.Args) != want {
+		return fmt.Errorf("%s requires exactly %d arguments", f.Name, want)
+	}
+	ce, ok := f.Args[0].(query.ColumnExpr)
+	if !ok || !query.IsSpatialColumn(ce.Column) {
+		return fmt.Errorf("%s requires a spatial column as its first argument", f.Name)
+	}
+	writeExpr := func(e query.Expr) error { return c.writeExpr(b, e) }
+	if f.Name == "GEO_CONTAINS" {
+		return c.dialect.WriteGeoContains(b, ce.Column, f.Args[1], f.Args[2], writeExpr)
+	}
+	if f.Name == "WITHIN_RADIUS" {
+		return c.dialect.WriteWithinRadius(b, ce.Column, f.Args[1], f.Args[2], f.Args[3], writeExpr)
+	}
+	return c.dialect.WriteGeoDistance(b, ce.Column, f.Args[1], f.Args[2], writeExpr)
+}
+
+// writeJSONFunc writes JSON_EXTRACT_TEXT(col, path) and
+// JSON_CONTAINS(col, value), built by the JSON column methods.
+func (c *Compiler) writeJSONFunc(b *strings.Builder, f query.FuncExpr) error {
+	if len(f.Args) != 2 {
+		return fmt.Errorf("%s requires exactly 2 arguments", f.Name)
+	}
+	ce, ok := f.Args[0].(query.ColumnExpr)
+	if !ok {
+		return fmt.Errorf("%s requires a JSON column as its first argument", f.Name)
+	}
+	writeExpr := func(e query.Expr) error { return c.writeExpr(b, e) }
+	if f.Name == "JSON_CONTAINS" {
+		return c.dialect.WriteJSONContains(b, ce.Column, f.Args[1], writeExpr)
+	}
+	lit, ok := f.Args[1].(query.LiteralExpr)
+	path, isString := lit.Value.(string)
+	if !ok || !isString {
+		return fmt.Errorf("%s requires a literal path as its second argument", f.Name)
+	}
+	steps, err := ParseJSONPath(path)
+	if err != nil {
+		return err
+	}
+	return c.dialect.WriteJSONExtract(b, ce.Column, steps, writeExpr)
+}
+
 func (c *Compiler) writeJSONAgg(b *strings.Builder, j query.JSONAggExpr) error {
 	return c.dialect.WriteJSONAgg(b, j.Columns, j.Fields,
 		func(col query.Column) { c.writeColumn(b, col) },
@@ -566,6 +924,9 @@ func (c *Compiler) writeOrderByExpr(b *strings.Builder, expr query.Expr) error {
 
 func (c *Compiler) writeCTEs(b *strings.Builder, ctes []query.CTE) error {
 	b.WriteString("WITH ")
+	if c.dialect.RecursiveKeyword() && slices.ContainsFunc(ctes, func(cte query.CTE) bool { return cte.Recursive }) {
+		b.WriteString("RECURSIVE ")
+	}
 	for i, cte := range ctes {
 		if i > 0 {
 			b.WriteString(", ")
@@ -584,7 +945,11 @@ func (c *Compiler) writeCTEs(b *strings.Builder, ctes []query.CTE) error {
 		}
 		b.WriteString(" AS (")
 		// Use compileInto to share state with parent, ensuring correct param numbering
-		if err := c.compileInto(cte.Query, b); err != nil {
+		compile := c.compileInto
+		if cte.Recursive {
+			compile = c.compileRecursiveCTEInto
+		}
+		if err := compile(cte.Query, b); err != nil {
 			return err
 		}
 		b.WriteString(")")
@@ -593,6 +958,19 @@ func (c *Compiler) writeCTEs(b *strings.Builder, ctes []query.CTE) error {
 	return nil
 }
 
+// compileRecursiveCTEInto compiles the body of a recursive CTE, a UNION of
+// its base and recursive queries. The two are never parenthesized: a bare
+// UNION is the form every dialect recognizes as recursion.
+func (c *Compiler) compileRecursiveCTEInto(ast *query.AST, b *strings.Builder) error {
+	if err := c.compileInto(ast.SetOp.Left, b); err != nil {
+		return err
+	}
+	b.WriteString(" ")
+	b.WriteString(string(ast.SetOp.Op))
+	b.WriteString(" ")
+	return c.compileInto(ast.SetOp.Right, b)
+}
+
 // =============================================================================
 // Set Operation Compilation (UNION, INTERSECT, EXCEPT)
 // =============================================================================
@@ -643,21 +1021,52 @@ func (c *Compiler) compileSetOpInto(ast *query.AST, b *strings.Builder) error {
 		}
 	}
 
-	// LIMIT on combined result
-	if ast.Limit != nil {
-		b.WriteString(" LIMIT ")
-		if err := c.writeExpr(b, ast.Limit); err != nil {
-			return err
+	// LIMIT and OFFSET on combined result
+	return c.writePagination(b, ast)
+}
+
+// writePagination writes the LIMIT and OFFSET clauses that follow ORDER BY.
+// OffsetFetch dialects write OFFSET x ROWS FETCH NEXT n ROWS ONLY instead,
+// which is only valid after an ORDER BY; ORDER BY (SELECT NULL) leaves the
+// row order unspecified, as LIMIT does elsewhere.
+func (c *Compiler) writePagination(b *strings.Builder, ast *query.AST) error {
+	if !c.dialect.OffsetFetch() {
+		if ast.Limit != nil {
+			b.WriteString(" LIMIT ")
+			if err := c.writeExpr(b, ast.Limit); err != nil {
+				return err
+			}
 		}
+		if ast.Offset != nil {
+			b.WriteString(" OFFSET ")
+			if err := c.writeExpr(b, ast.Offset); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	// OFFSET on combined result
+	if ast.Limit == nil && ast.Offset == nil {
+		return nil
+	}
+	if len(ast.OrderBy) == 0 {
+		b.WriteString(" ORDER BY (SELECT NULL)")
+	}
+	b.WriteString(" OFFSET ")
 	if ast.Offset != nil {
-		b.WriteString(" OFFSET ")
 		if err := c.writeExpr(b, ast.Offset); err != nil {
 			return err
 		}
+	} else {
+		b.WriteString("0")
+	}
+	b.WriteString(" ROWS")
+	if ast.Limit != nil {
+		b.WriteString(" FETCH NEXT ")
+		if err := c.writeExpr(b, ast.Limit); err != nil {
+			return err
+		}
+		b.WriteString(" ROWS ONLY")
 	}
-
 	return nil
 }
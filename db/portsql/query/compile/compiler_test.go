@@ -1,6 +1,7 @@
 package compile
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -45,6 +46,9 @@ func runDialectTests(t *testing.T, dialect Dialect) {
 	t.Run("Aggregates", func(t *testing.T) {
 		testAggregates(t, dialect)
 	})
+	t.Run("GroupByHaving", func(t *testing.T) {
+		testGroupByHaving(t, dialect)
+	})
 	t.Run("Subquery", func(t *testing.T) {
 		testSubquery(t, dialect)
 	})
@@ -259,6 +263,56 @@ func testAggregates(t *testing.T, dialect Dialect) {
 	}
 }
 
+type usersTable struct{}
+
+func (usersTable) TableName() string { return "users" }
+
+// testGroupByHaving checks that HAVING is written between GROUP BY and
+// ORDER BY, and that its parameters are numbered after WHERE's and before
+// LIMIT's.
+func testGroupByHaving(t *testing.T, dialect Dialect) {
+	country := query.StringColumn{Table: "users", Name: "country"}
+	active := query.BoolColumn{Table: "users", Name: "active"}
+
+	ast := query.From(usersTable{}).
+		Select(country).
+		SelectCountAs("total").
+		Where(active.Eq(query.Param[bool]("active"))).
+		GroupBy(country).
+		Having(query.Or(
+			query.Count().Ge(query.Param[int64]("minUsers")),
+			query.CountDistinct(country).Eq(query.Param[int64]("minUsers")),
+		)).
+		OrderBy(country.Asc()).
+		Limit(query.Param[int]("limit")).
+		Build()
+
+	compiler := NewCompiler(dialect)
+	sql, params, err := compiler.Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := []string{"active", "minUsers", "minUsers", "limit"}
+	if strings.Join(params, ",") != strings.Join(want, ",") {
+		t.Errorf("params = %v, want %v", params, want)
+	}
+
+	having := strings.Index(sql, " HAVING ")
+	if having < 0 || having < strings.Index(sql, " GROUP BY ") || having > strings.Index(sql, " ORDER BY ") {
+		t.Fatalf("HAVING not between GROUP BY and ORDER BY: %s", sql)
+	}
+	clause := sql[having:strings.Index(sql, " ORDER BY ")]
+	if !strings.Contains(clause, "COUNT(*) >= ") || !strings.Contains(clause, "COUNT(DISTINCT ") {
+		t.Errorf("unexpected HAVING clause: %s", clause)
+	}
+	if dialect == Postgres {
+		if !strings.Contains(clause, "$2") || !strings.Contains(clause, "$3") || !strings.HasSuffix(sql, "LIMIT $4") {
+			t.Errorf("Postgres placeholders not numbered in SQL order: %s", sql)
+		}
+	}
+}
+
 func testSubquery(t *testing.T, dialect Dialect) {
 	outerCol := query.Int64Column{Table: "users", Name: "id"}
 	innerCol := query.Int64Column{Table: "orders", Name: "user_id"}
@@ -466,6 +520,72 @@ func TestDialect_NowFunc(t *testing.T) {
 	}
 }
 
+func TestIsNotDistinctFrom(t *testing.T) {
+	parent := query.NullInt64Column{Table: "comments", Name: "parent_id"}
+	ast := &query.AST{
+		Kind:      query.SelectQuery,
+		FromTable: query.TableRef{Name: "comments"},
+		Where:     parent.IsNotDistinctFrom(query.Param[*int64]("parent_id")),
+	}
+
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{Postgres, `SELECT * FROM "comments" WHERE ("comments"."parent_id" IS NOT DISTINCT FROM $1)`},
+		{MySQL, "SELECT * FROM `comments` WHERE (`comments`.`parent_id` <=> ?)"},
+		{SQLite, `SELECT * FROM "comments" WHERE ("comments"."parent_id" IS ?)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			sql, params, err := NewCompiler(tt.dialect).Compile(ast)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("expected SQL:\n%s\ngot:\n%s", tt.expected, sql)
+			}
+			if len(params) != 1 || params[0] != "parent_id" {
+				t.Errorf("params = %v, want [parent_id]", params)
+			}
+		})
+	}
+}
+
+func TestSelectForUpdate(t *testing.T) {
+	email := query.StringColumn{Table: "users", Name: "email"}
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: email}}},
+		Where:      email.Eq(query.Param[string]("email")),
+		ForUpdate:  true,
+	}
+
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{Postgres, `SELECT "users"."email" FROM "users" WHERE ("users"."email" = $1) FOR UPDATE`},
+		{MySQL, "SELECT `users`.`email` FROM `users` WHERE (`users`.`email` = ?) FOR UPDATE"},
+		// SQLite serializes writers instead of locking rows.
+		{SQLite, `SELECT "users"."email" FROM "users" WHERE ("users"."email" = ?)`},
+		// SQL Server locks through a table hint.
+		{MSSQL, "SELECT [users].[email] FROM [users] WITH (UPDLOCK, ROWLOCK) WHERE ([users].[email] = @p1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			sql, _, err := NewCompiler(tt.dialect).Compile(ast)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("expected SQL:\n%s\ngot:\n%s", tt.expected, sql)
+			}
+		})
+	}
+}
+
 func TestDialect_WrapSetOpQueries(t *testing.T) {
 	tests := []struct {
 		dialect  Dialect
@@ -474,6 +594,7 @@ func TestDialect_WrapSetOpQueries(t *testing.T) {
 		{Postgres, true},
 		{MySQL, true},
 		{SQLite, false},
+		{MSSQL, true},
 	}
 
 	for _, tt := range tests {
@@ -585,3 +706,264 @@ func TestJSONAgg_EmptyColumns_ReturnsError(t *testing.T) {
 		})
 	}
 }
+
+func TestCompile_OptimizerHints(t *testing.T) {
+	build := func() *query.AST {
+		return &query.AST{
+			Kind:      query.SelectQuery,
+			FromTable: query.TableRef{Name: "posts"},
+			Distinct:  true,
+			Hints: []query.Hint{
+				{Dialect: query.Postgres, Kind: query.HintOptimizer, Text: "IndexScan(posts posts_created_at_idx)"},
+				{Dialect: query.MySQL, Kind: query.HintOptimizer, Text: "MAX_EXECUTION_TIME(1000)"},
+				{Dialect: query.MySQL, Kind: query.HintOptimizer, Text: "NO_ICP(posts)"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		want    string
+	}{
+		{"Postgres", Postgres, `/*+ IndexScan(posts posts_created_at_idx) */ SELECT DISTINCT * FROM "posts"`},
+		{"MySQL", MySQL, "SELECT /*+ MAX_EXECUTION_TIME(1000) NO_ICP(posts) */ DISTINCT * FROM `posts`"},
+		{"SQLite", SQLite, `SELECT DISTINCT * FROM "posts"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := NewCompiler(tt.dialect).Compile(build())
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if sql != tt.want {
+				t.Errorf("expected SQL:\n%s\ngot:\n%s", tt.want, sql)
+			}
+		})
+	}
+}
+
+func TestCompile_IndexHints(t *testing.T) {
+	build := func() *query.AST {
+		return &query.AST{
+			Kind:      query.SelectQuery,
+			FromTable: query.TableRef{Name: "posts", Alias: "p"},
+			Hints: []query.Hint{
+				{Dialect: query.MySQL, Kind: query.HintForceIndex, Indexes: []string{"posts_created_at_idx", "posts_org_idx"}},
+				{Dialect: query.MySQL, Kind: query.HintIgnoreIndex, Indexes: []string{"posts_title_idx"}},
+				{Dialect: query.SQLite, Kind: query.HintUseIndex, Indexes: []string{"posts_created_at_idx"}},
+				{Dialect: query.MSSQL, Kind: query.HintForceIndex, Indexes: []string{"posts_created_at_idx", "posts_org_idx"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		want    string
+	}{
+		{"Postgres", Postgres, `SELECT * FROM "posts" AS "p"`},
+		{"MySQL", MySQL, "SELECT * FROM `posts` AS `p` FORCE INDEX (`posts_created_at_idx`, `posts_org_idx`) IGNORE INDEX (`posts_title_idx`)"},
+		{"SQLite", SQLite, `SELECT * FROM "posts" AS "p" INDEXED BY "posts_created_at_idx"`},
+		{"MSSQL", MSSQL, "SELECT * FROM [posts] AS [p] WITH (INDEX([posts_created_at_idx], [posts_org_idx]))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := NewCompiler(tt.dialect).Compile(build())
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if sql != tt.want {
+				t.Errorf("expected SQL:\n%s\ngot:\n%s", tt.want, sql)
+			}
+		})
+	}
+}
+
+func TestCompile_IndexHintUnsupportedByDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		hint    query.Hint
+	}{
+		{"PostgresIndexHint", Postgres, query.Hint{Dialect: query.Postgres, Kind: query.HintUseIndex, Indexes: []string{"idx"}}},
+		{"SQLiteIgnoreIndex", SQLite, query.Hint{Dialect: query.SQLite, Kind: query.HintIgnoreIndex, Indexes: []string{"idx"}}},
+		{"SQLiteMultipleIndexes", SQLite, query.Hint{Dialect: query.SQLite, Kind: query.HintUseIndex, Indexes: []string{"a", "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast := &query.AST{
+				Kind:      query.SelectQuery,
+				FromTable: query.TableRef{Name: "posts"},
+				Hints:     []query.Hint{tt.hint},
+			}
+			if _, _, err := NewCompiler(tt.dialect).Compile(ast); err == nil {
+				t.Error("expected error for unsupported index hint")
+			}
+		})
+	}
+}
+
+func TestCompile_SchemaQualifiedJoin(t *testing.T) {
+	invoices := query.ExternalTable{Schema: "billing", Name: "invoices"}
+	userID := query.Int64Column{Table: "users", Name: "id"}
+	invoiceUser := query.Int64Column{Table: "billing.invoices", Name: "user_id"}
+	total := query.Float64Column{Table: "billing.invoices", Name: "total"}
+
+	ast := query.From(usersTable{}).
+		Join(invoices).On(invoiceUser.Eq(userID)).
+		Select(userID, total).
+		Build()
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{Postgres, `SELECT "users"."id", "billing"."invoices"."total" FROM "users" INNER JOIN "billing"."invoices" ON ("billing"."invoices"."user_id" = "users"."id")`},
+		{MySQL, "SELECT `users`.`id`, `billing`.`invoices`.`total` FROM `users` INNER JOIN `billing`.`invoices` ON (`billing`.`invoices`.`user_id` = `users`.`id`)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			sql, _, err := NewCompiler(tt.dialect).Compile(ast)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if sql != tt.want {
+				t.Errorf("expected SQL:\n%s\ngot:\n%s", tt.want, sql)
+			}
+		})
+	}
+
+	t.Run("sqlite", func(t *testing.T) {
+		_, _, err := NewCompiler(SQLite).Compile(ast)
+		if err == nil || !strings.Contains(err.Error(), `schema-qualified table "billing.invoices" is unsupported on sqlite`) {
+			t.Errorf("expected an unsupported-on-sqlite error, got %v", err)
+		}
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, name := range []string{"a.b.c", "billing.", "bil-ling.invoices"} {
+			bad := query.Delete(query.CTERef(name)).Build()
+			if _, _, err := NewCompiler(Postgres).Compile(bad); err == nil {
+				t.Errorf("expected an error for table %q", name)
+			}
+		}
+	})
+
+	t.Run("namespaces", func(t *testing.T) {
+		if err := ValidateNamespaces(ast, []string{"billing"}); err != nil {
+			t.Errorf("declared namespace: %v", err)
+		}
+		union := query.From(usersTable{}).Select(userID).
+			Union(query.From(invoices).Select(invoiceUser)).
+			Build()
+		for _, a := range []*query.AST{ast, union} {
+			err := ValidateNamespaces(a, []string{"audit"})
+			if err == nil || !strings.Contains(err.Error(), `table "billing.invoices" is in namespace "billing"`) {
+				t.Errorf("expected an undeclared-namespace error, got %v", err)
+			}
+		}
+	})
+}
+
+type categoriesTable struct{}
+
+func (categoriesTable) TableName() string { return "categories" }
+
+// categoryTree selects a category and all its descendants.
+func categoryTree() *query.AST {
+	id := query.Int64Column{Table: "categories", Name: "id"}
+	parentID := query.NullInt64Column{Table: "categories", Name: "parent_id"}
+	treeID := query.Int64Column{Table: "tree", Name: "id"}
+
+	root := query.From(categoriesTable{}).
+		Select(id, parentID).
+		Where(id.Eq(query.Param[int64]("rootID")))
+	children := query.From(categoriesTable{}).
+		Select(id, parentID).
+		Join(query.CTERef("tree")).On(parentID.Eq(treeID))
+
+	return query.WithRecursive("tree", root, children).
+		Select(query.CTERef("tree")).
+		Select(treeID).
+		Limit(query.Param[int]("limit")).
+		Build()
+}
+
+func TestCompile_RecursiveCTE(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{Postgres, `WITH RECURSIVE "tree" AS (SELECT "categories"."id", "categories"."parent_id" FROM "categories" WHERE ("categories"."id" = $1) UNION ALL SELECT "categories"."id", "categories"."parent_id" FROM "categories" INNER JOIN "tree" ON ("categories"."parent_id" = "tree"."id")) SELECT "tree"."id" FROM "tree" LIMIT $2`},
+		{SQLite, `WITH RECURSIVE "tree" AS (SELECT "categories"."id", "categories"."parent_id" FROM "categories" WHERE ("categories"."id" = ?) UNION ALL SELECT "categories"."id", "categories"."parent_id" FROM "categories" INNER JOIN "tree" ON ("categories"."parent_id" = "tree"."id")) SELECT "tree"."id" FROM "tree" LIMIT ?`},
+		{MSSQL, `WITH [tree] AS (SELECT [categories].[id], [categories].[parent_id] FROM [categories] WHERE ([categories].[id] = @p1) UNION ALL SELECT [categories].[id], [categories].[parent_id] FROM [categories] INNER JOIN [tree] ON ([categories].[parent_id] = [tree].[id])) SELECT TOP (@p2) [tree].[id] FROM [tree]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			sql, params, err := NewCompiler(tt.dialect).Compile(categoryTree())
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("expected SQL:\n%s\ngot:\n%s", tt.expected, sql)
+			}
+			if strings.Join(params, ",") != "rootID,limit" {
+				t.Errorf("params = %v, want [rootID limit]", params)
+			}
+		})
+	}
+}
+
+func TestCompile_RecursiveCTE_MySQL(t *testing.T) {
+	sql, _, err := NewCompiler(MySQL).Compile(categoryTree())
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !strings.HasPrefix(sql, "WITH RECURSIVE `tree` AS (SELECT ") || !strings.Contains(sql, " UNION ALL SELECT ") {
+		t.Errorf("unexpected SQL: %s", sql)
+	}
+}
+
+func TestValidateAST_RecursiveCTE(t *testing.T) {
+	ast := categoryTree()
+	ast.CTEs[0].Query.SetOp.Op = query.SetOpUnion
+	if err := ValidateAST(ast); err == nil || !strings.Contains(err.Error(), "must be a UNION ALL") {
+		t.Errorf("expected UNION to be rejected, got %v", err)
+	}
+
+	ast = categoryTree()
+	ast.CTEs[0].Query.Limit = query.LiteralExpr{Value: 10}
+	if err := ValidateAST(ast); err == nil || !strings.Contains(err.Error(), "cannot have ORDER BY, LIMIT or OFFSET") {
+		t.Errorf("expected LIMIT to be rejected, got %v", err)
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	steps, err := ParseJSONPath("$.user.tags[12].name_2")
+	if err != nil {
+		t.Fatalf("ParseJSONPath() error = %v", err)
+	}
+	want := []JSONPathStep{{Key: "user", Index: -1}, {Key: "tags", Index: -1}, {Index: 12}, {Key: "name_2", Index: -1}}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("ParseJSONPath() = %v, want %v", steps, want)
+	}
+	if steps, err := ParseJSONPath("$"); err != nil || len(steps) != 0 {
+		t.Errorf("ParseJSONPath($) = %v, %v", steps, err)
+	}
+
+	for _, path := range []string{"", "user", "$.", "$..a", "$.a-b", "$.it's", "$[", "$[x]", "$[-1]", "$[+1]", "$a"} {
+		if _, err := ParseJSONPath(path); err == nil {
+			t.Errorf("ParseJSONPath(%q) should fail", path)
+		}
+	}
+
+	ast := query.From(eventsTable{}).
+		Select(query.Int64Column{Table: "events", Name: "id"}).
+		Where(query.JSONColumn{Table: "events", Name: "payload"}.Extract("$.it's").Eq("x")).
+		Build()
+	if _, _, err := NewCompiler(Postgres).Compile(ast); err == nil {
+		t.Error("expected Compile to reject an invalid JSON path")
+	}
+}
@@ -2,13 +2,15 @@ package compile
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/db/portsql/query"
 )
 
 // Dialect defines the SQL dialect-specific behavior for compilation.
-// Each dialect (Postgres, MySQL, SQLite) implements this interface
+// Each dialect (Postgres, MySQL, SQLite, SQL Server) implements this interface
 // to customize identifier quoting, placeholders, literals, and special functions.
 type Dialect interface {
 	// Name returns the dialect name for debugging/logging.
@@ -18,7 +20,7 @@ type Dialect interface {
 	QuoteIdentifier(name string) string
 
 	// Placeholder returns the parameter placeholder for the given index (1-based).
-	// Postgres uses $1, $2, etc. MySQL and SQLite use ?.
+	// Postgres uses $1, $2, etc. MySQL and SQLite use ?. SQL Server uses @p1, @p2.
 	Placeholder(index int) string
 
 	// BoolLiteral returns the SQL literal for a boolean value.
@@ -38,11 +40,30 @@ type Dialect interface {
 	// MySQL does not (it uses LAST_INSERT_ID() instead).
 	SupportsReturning() bool
 
+	// ReturningAsOutput returns true if returned columns are written as an
+	// OUTPUT INSERTED.col clause ahead of VALUES (SQL Server) rather than a
+	// trailing RETURNING.
+	ReturningAsOutput() bool
+
+	// OffsetFetch returns true if pagination is written as TOP (n), or as
+	// OFFSET x ROWS FETCH NEXT n ROWS ONLY after an ORDER BY (SQL Server),
+	// instead of LIMIT and OFFSET.
+	OffsetFetch() bool
+
 	// WriteILIKE writes a case-insensitive LIKE expression.
 	// Postgres has native ILIKE, others need LOWER() LIKE LOWER().
 	// The writeExpr callback should be used to write the arguments.
 	WriteILIKE(b *strings.Builder, args []query.Expr, writeExpr func(query.Expr) error) error
 
+	// WriteEscapedLike writes col LIKE <pattern> ESCAPE '!' for Contains,
+	// StartsWith and EndsWith, escaping wildcards in args[1] in SQL so the
+	// search string matches literally. leading and trailing put a % before
+	// and after it. fold makes the match case-insensitive (IContains and
+	// friends): ILIKE on Postgres, LOWER() on both sides elsewhere.
+	// Otherwise the match compares code points like the binary column
+	// collations, which on SQLite takes GLOB since its LIKE ignores case.
+	WriteEscapedLike(b *strings.Builder, args []query.Expr, leading, trailing, fold bool, writeExpr func(query.Expr) error) error
+
 	// WriteJSONAgg writes a JSON aggregation expression.
 	// Each dialect has different JSON functions.
 	// When fields is non-empty it takes precedence over cols, allowing richer
@@ -57,6 +78,80 @@ type Dialect interface {
 	// COLLATE=utf8mb4_bin for MySQL tables), so no per-query annotation is needed.
 	// The writeExpr callback should be used to write the expression.
 	WriteOrderByExpr(b *strings.Builder, expr query.Expr, writeExpr func(query.Expr) error) error
+
+	// OptimizerHintAfterSelect returns true if /*+ ... */ hint comments must
+	// directly follow the SELECT keyword (MySQL). Otherwise they lead the
+	// statement, which is where pg_hint_plan looks for them.
+	OptimizerHintAfterSelect() bool
+
+	// RecursiveKeyword returns true if a WITH clause holding a recursive CTE
+	// is written WITH RECURSIVE. SQL Server infers recursion and rejects the
+	// keyword.
+	RecursiveKeyword() bool
+
+	// NullSafeEqualOp returns the operator comparing two values as equal
+	// when both are NULL (OpIsNotDistinctFrom).
+	NullSafeEqualOp() string
+
+	// SupportsRowLocks returns true if SELECT ... FOR UPDATE is available.
+	// SQLite locks the whole database on write, so it has no row locks.
+	SupportsRowLocks() bool
+
+	// RowLockTableHint returns true if row locks are taken with a
+	// WITH (UPDLOCK, ROWLOCK) hint on the FROM table (SQL Server) rather
+	// than a trailing FOR UPDATE.
+	RowLockTableHint() bool
+
+	// SupportsQualifiedTables returns true if a table can be named as
+	// schema.table: a Postgres schema or a MySQL database on the same
+	// server. SQLite has neither.
+	SupportsQualifiedTables() bool
+
+	// SupportsUpsert returns true if INSERT can resolve conflicts in place.
+	// SQL Server only has MERGE, which the compiler does not generate.
+	SupportsUpsert() bool
+
+	// UpsertOnDuplicateKey returns true if upserts are written as
+	// ON DUPLICATE KEY UPDATE (MySQL) rather than ON CONFLICT.
+	UpsertOnDuplicateKey() bool
+
+	// WriteExcluded writes the value a conflicting INSERT proposed for
+	// the (quoted) column: EXCLUDED.col, or VALUES(col) on MySQL.
+	WriteExcluded(b *strings.Builder, column string)
+
+	// WriteIndexHint writes an index hint that follows the FROM table
+	// reference, or returns an error if the dialect cannot express it.
+	WriteIndexHint(b *strings.Builder, hint query.Hint) error
+
+	// WritePointText writes a point or polygon column read as WKT text,
+	// e.g. "POINT(lng lat)". The writeColumn callback writes the column
+	// reference.
+	WritePointText(b *strings.Builder, writeColumn func())
+
+	// WritePointValue writes a WKT value being stored into a point or
+	// polygon column.
+	// The writeValue callback writes the parameter or literal.
+	WritePointValue(b *strings.Builder, writeValue func() error) error
+
+	// WriteWithinRadius writes a predicate matching points of col no more
+	// than meters from (lat, lng) along the earth's surface.
+	WriteWithinRadius(b *strings.Builder, col query.Column, lat, lng, meters query.Expr, writeExpr func(query.Expr) error) error
+
+	// WriteGeoDistance writes the distance in meters between col and
+	// (lat, lng).
+	WriteGeoDistance(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error
+
+	// WriteGeoContains writes a predicate matching polygons of col that
+	// contain the point (lat, lng).
+	WriteGeoContains(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error
+
+	// WriteJSONExtract writes the value at path in the JSON column col as
+	// text, or NULL for JSON null and missing paths.
+	WriteJSONExtract(b *strings.Builder, col query.Column, path []JSONPathStep, writeExpr func(query.Expr) error) error
+
+	// WriteJSONContains writes a predicate matching documents in col that
+	// contain the JSON text value.
+	WriteJSONContains(b *strings.Builder, col query.Column, value query.Expr, writeExpr func(query.Expr) error) error
 }
 
 // CompilerState holds the mutable state during compilation.
@@ -93,6 +188,178 @@ func writeILIKEWithLower(b *strings.Builder, args []query.Expr, writeExpr func(q
 	return nil
 }
 
+// likeEscapeChar is the LIKE escape character used by WriteEscapedLike.
+// Backslash would need doubling inside MySQL string literals.
+const likeEscapeChar = "!"
+
+// likeWildcards are the characters LIKE treats specially on every dialect.
+// SQL Server adds [ for character ranges.
+const likeWildcards = "%_"
+
+// writeEscapedLike is the shared body of WriteEscapedLike. It escapes the
+// escape character first, then each of wildcards, and wraps the result in %
+// as needed. op is LIKE or ILIKE, lower wraps both sides in LOWER(), and
+// concat selects CONCAT() over the standard || operator.
+func writeEscapedLike(b *strings.Builder, args []query.Expr, leading, trailing bool, op string, lower, concat bool, wildcards string, writeExpr func(query.Expr) error) error {
+	if len(args) != 2 {
+		return fmt.Errorf("escaped LIKE requires exactly 2 arguments")
+	}
+	if lower {
+		b.WriteString("LOWER(")
+	}
+	if err := writeExpr(args[0]); err != nil {
+		return err
+	}
+	if lower {
+		b.WriteString(")")
+	}
+	b.WriteString(" " + op + " ")
+	sep, open, closing := " || ", "(", ")"
+	if concat {
+		sep, open = ", ", "CONCAT("
+	}
+	switch {
+	case lower && concat:
+		open, closing = "LOWER(CONCAT(", "))"
+	case lower:
+		open = "LOWER("
+	}
+	b.WriteString(open)
+	if leading {
+		b.WriteString("'%'" + sep)
+	}
+	b.WriteString(strings.Repeat("REPLACE(", len(wildcards)+1))
+	if err := writeExpr(args[1]); err != nil {
+		return err
+	}
+	e := likeEscapeChar
+	fmt.Fprintf(b, ", '%s', '%s%s')", e, e, e)
+	for _, w := range wildcards {
+		fmt.Fprintf(b, ", '%c', '%s%c')", w, e, w)
+	}
+	if trailing {
+		b.WriteString(sep + "'%'")
+	}
+	b.WriteString(closing + " ESCAPE '" + e + "'")
+	return nil
+}
+
+// writeEscapedGlob is the case-sensitive WriteEscapedLike for SQLite, whose
+// LIKE ignores ASCII case. GLOB has no ESCAPE clause, so each of [, * and ?
+// is matched through a one-character class. The ? is written as char(63)
+// to keep the SQL text free of stray placeholder characters.
+func writeEscapedGlob(b *strings.Builder, args []query.Expr, leading, trailing bool, writeExpr func(query.Expr) error) error {
+	if len(args) != 2 {
+		return fmt.Errorf("escaped GLOB requires exactly 2 arguments")
+	}
+	if err := writeExpr(args[0]); err != nil {
+		return err
+	}
+	b.WriteString(" GLOB (")
+	if leading {
+		b.WriteString("'*' || ")
+	}
+	b.WriteString("REPLACE(REPLACE(REPLACE(")
+	if err := writeExpr(args[1]); err != nil {
+		return err
+	}
+	b.WriteString(", '[', '[[]'), '*', '[*]'), char(63), '[' || char(63) || ']')")
+	if trailing {
+		b.WriteString(" || '*'")
+	}
+	b.WriteString(")")
+	return nil
+}
+
+// writeTemplate writes tmpl, replacing each {name} with args[name] written
+// through writeExpr. Spatial SQL repeats its arguments, and writing each
+// occurrence keeps positional placeholders in step with the parameters.
+func writeTemplate(b *strings.Builder, tmpl string, args map[string]query.Expr, writeExpr func(query.Expr) error) error {
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			b.WriteString(tmpl)
+			return nil
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return fmt.Errorf("unterminated placeholder in %q", tmpl)
+		}
+		b.WriteString(tmpl[:start])
+		arg, ok := args[tmpl[start+1:start+end]]
+		if !ok {
+			return fmt.Errorf("unknown placeholder %q", tmpl[start:start+end+1])
+		}
+		if err := writeExpr(arg); err != nil {
+			return err
+		}
+		tmpl = tmpl[start+end+1:]
+	}
+}
+
+// JSONPathStep is one step of a JSON path: an object key, or an array
+// index when Index is not negative.
+type JSONPathStep struct {
+	Key   string
+	Index int
+}
+
+// ParseJSONPath parses a path such as "$.address.city" or "$.tags[0]".
+// Keys are limited to letters, digits and underscores so the path can be
+// spelled safely in each database's syntax.
+func ParseJSONPath(path string) ([]JSONPathStep, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("JSON path %q must start with $", path)
+	}
+	var steps []JSONPathStep
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			n := 1
+			for n < len(rest) && isJSONKeyChar(rest[n]) {
+				n++
+			}
+			if n == 1 {
+				return nil, fmt.Errorf("JSON path %q has an invalid key at %q", path, rest)
+			}
+			steps = append(steps, JSONPathStep{Key: rest[1:n], Index: -1})
+			rest = rest[n:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			index, err := strconv.Atoi(rest[1:max(end, 1)])
+			if end < 0 || err != nil || index < 0 || rest[1] == '+' {
+				return nil, fmt.Errorf("JSON path %q has an invalid array index at %q", path, rest)
+			}
+			steps = append(steps, JSONPathStep{Index: index})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSON path %q has an unexpected %q", path, rest[:1])
+		}
+	}
+	return steps, nil
+}
+
+func isJSONKeyChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// standardJSONPath spells steps in the SQL/JSON path syntax MySQL, SQLite
+// and SQL Server share, as a quoted string literal.
+func standardJSONPath(steps []JSONPathStep) string {
+	var b strings.Builder
+	b.WriteString("'$")
+	for _, s := range steps {
+		if s.Index >= 0 {
+			fmt.Fprintf(&b, "[%d]", s.Index)
+		} else {
+			b.WriteString("." + s.Key)
+		}
+	}
+	b.WriteString("'")
+	return b.String()
+}
+
 // =============================================================================
 // Postgres Dialect
 // =============================================================================
@@ -131,6 +398,14 @@ func (d *PostgresDialect) SupportsReturning() bool {
 	return true
 }
 
+func (d *PostgresDialect) ReturningAsOutput() bool {
+	return false
+}
+
+func (d *PostgresDialect) OffsetFetch() bool {
+	return false
+}
+
 func (d *PostgresDialect) WriteILIKE(b *strings.Builder, args []query.Expr, writeExpr func(query.Expr) error) error {
 	// Postgres has native ILIKE
 	if len(args) != 2 {
@@ -143,6 +418,14 @@ func (d *PostgresDialect) WriteILIKE(b *strings.Builder, args []query.Expr, writ
 	return writeExpr(args[1])
 }
 
+func (d *PostgresDialect) WriteEscapedLike(b *strings.Builder, args []query.Expr, leading, trailing, fold bool, writeExpr func(query.Expr) error) error {
+	op := "LIKE"
+	if fold {
+		op = "ILIKE"
+	}
+	return writeEscapedLike(b, args, leading, trailing, op, false, false, likeWildcards, writeExpr)
+}
+
 func (d *PostgresDialect) WriteJSONAgg(b *strings.Builder, cols []query.Column, fields []query.JSONAggField, writeColumn func(query.Column), writeExpr func(query.Expr) error) error {
 	if len(fields) > 0 {
 		return d.writeJSONAggFields(b, fields, writeColumn, writeExpr)
@@ -202,6 +485,112 @@ func (d *PostgresDialect) WriteOrderByExpr(b *strings.Builder, expr query.Expr,
 	return writeExpr(expr)
 }
 
+func (d *PostgresDialect) OptimizerHintAfterSelect() bool {
+	return false
+}
+
+func (d *PostgresDialect) RecursiveKeyword() bool {
+	return true
+}
+
+func (d *PostgresDialect) NullSafeEqualOp() string {
+	return "IS NOT DISTINCT FROM"
+}
+
+func (d *PostgresDialect) SupportsRowLocks() bool {
+	return true
+}
+
+func (d *PostgresDialect) RowLockTableHint() bool {
+	return false
+}
+
+func (d *PostgresDialect) SupportsQualifiedTables() bool {
+	return true
+}
+
+func (d *PostgresDialect) SupportsUpsert() bool {
+	return true
+}
+
+func (d *PostgresDialect) UpsertOnDuplicateKey() bool {
+	return false
+}
+
+func (d *PostgresDialect) WriteExcluded(b *strings.Builder, column string) {
+	b.WriteString("EXCLUDED.")
+	b.WriteString(column)
+}
+
+func (d *PostgresDialect) WriteIndexHint(b *strings.Builder, hint query.Hint) error {
+	return fmt.Errorf("postgres does not support index hints; use OptimizerHint with a pg_hint_plan directive instead")
+}
+
+func (d *PostgresDialect) WritePointText(b *strings.Builder, writeColumn func()) {
+	b.WriteString("ST_AsText(")
+	writeColumn()
+	b.WriteString(")")
+}
+
+func (d *PostgresDialect) WritePointValue(b *strings.Builder, writeValue func() error) error {
+	b.WriteString("ST_GeogFromText(")
+	if err := writeValue(); err != nil {
+		return err
+	}
+	b.WriteString(")")
+	return nil
+}
+
+// postgresGeogPoint builds a geography point; ST_MakePoint takes longitude
+// first.
+const postgresGeogPoint = "ST_SetSRID(ST_MakePoint({lng}, {lat}), 4326)::geography"
+
+func (d *PostgresDialect) WriteWithinRadius(b *strings.Builder, col query.Column, lat, lng, meters query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng, "meters": meters}
+	return writeTemplate(b, "ST_DWithin({col}, "+postgresGeogPoint+", {meters})", args, writeExpr)
+}
+
+func (d *PostgresDialect) WriteGeoDistance(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng}
+	return writeTemplate(b, "ST_Distance({col}, "+postgresGeogPoint+")", args, writeExpr)
+}
+
+// WriteGeoContains uses ST_Intersects, which for a polygon and a point
+// includes the boundary and can use the GiST index.
+func (d *PostgresDialect) WriteGeoContains(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng}
+	return writeTemplate(b, "ST_Intersects({col}, "+postgresGeogPoint+")", args, writeExpr)
+}
+
+// WriteJSONExtract uses ->> for a single step and #>> for longer paths.
+func (d *PostgresDialect) WriteJSONExtract(b *strings.Builder, col query.Column, path []JSONPathStep, writeExpr func(query.Expr) error) error {
+	b.WriteString("(")
+	if err := writeExpr(query.ColumnExpr{Column: col}); err != nil {
+		return err
+	}
+	switch {
+	case len(path) == 1 && path[0].Index >= 0:
+		fmt.Fprintf(b, " ->> %d)", path[0].Index)
+	case len(path) == 1:
+		b.WriteString(" ->> '" + path[0].Key + "')")
+	default:
+		parts := make([]string, len(path))
+		for i, s := range path {
+			parts[i] = s.Key
+			if s.Index >= 0 {
+				parts[i] = strconv.Itoa(s.Index)
+			}
+		}
+		b.WriteString(" #>> '{" + strings.Join(parts, ",") + "}')")
+	}
+	return nil
+}
+
+func (d *PostgresDialect) WriteJSONContains(b *strings.Builder, col query.Column, value query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "value": value}
+	return writeTemplate(b, "({col} @> CAST({value} AS jsonb))", args, writeExpr)
+}
+
 // =============================================================================
 // MySQL Dialect
 // =============================================================================
@@ -240,11 +629,24 @@ func (d *MySQLDialect) SupportsReturning() bool {
 	return false // MySQL uses LAST_INSERT_ID() instead
 }
 
+func (d *MySQLDialect) ReturningAsOutput() bool {
+	return false
+}
+
+func (d *MySQLDialect) OffsetFetch() bool {
+	return false
+}
+
 func (d *MySQLDialect) WriteILIKE(b *strings.Builder, args []query.Expr, writeExpr func(query.Expr) error) error {
 	// MySQL doesn't have native ILIKE, use LOWER() LIKE LOWER()
 	return writeILIKEWithLower(b, args, writeExpr)
 }
 
+func (d *MySQLDialect) WriteEscapedLike(b *strings.Builder, args []query.Expr, leading, trailing, fold bool, writeExpr func(query.Expr) error) error {
+	// || is logical OR in MySQL unless PIPES_AS_CONCAT is set
+	return writeEscapedLike(b, args, leading, trailing, "LIKE", fold, true, likeWildcards, writeExpr)
+}
+
 func (d *MySQLDialect) WriteJSONAgg(b *strings.Builder, cols []query.Column, fields []query.JSONAggField, writeColumn func(query.Column), writeExpr func(query.Expr) error) error {
 	if len(fields) > 0 {
 		return d.writeJSONAggFields(b, fields, writeColumn, writeExpr)
@@ -314,6 +716,116 @@ func (d *MySQLDialect) WriteOrderByExpr(b *strings.Builder, expr query.Expr, wri
 	return writeExpr(expr)
 }
 
+func (d *MySQLDialect) OptimizerHintAfterSelect() bool {
+	return true
+}
+
+func (d *MySQLDialect) RecursiveKeyword() bool {
+	return true
+}
+
+func (d *MySQLDialect) NullSafeEqualOp() string {
+	return "<=>"
+}
+
+func (d *MySQLDialect) SupportsRowLocks() bool {
+	return true
+}
+
+func (d *MySQLDialect) RowLockTableHint() bool {
+	return false
+}
+
+func (d *MySQLDialect) SupportsQualifiedTables() bool {
+	return true
+}
+
+func (d *MySQLDialect) SupportsUpsert() bool {
+	return true
+}
+
+func (d *MySQLDialect) UpsertOnDuplicateKey() bool {
+	return true
+}
+
+// WriteExcluded uses VALUES(col), which MySQL 8.0.20+ deprecates in favour
+// of a row alias but which, unlike the alias, also works on MariaDB.
+func (d *MySQLDialect) WriteExcluded(b *strings.Builder, column string) {
+	b.WriteString("VALUES(")
+	b.WriteString(column)
+	b.WriteString(")")
+}
+
+func (d *MySQLDialect) WriteIndexHint(b *strings.Builder, hint query.Hint) error {
+	switch hint.Kind {
+	case query.HintUseIndex:
+		b.WriteString(" USE INDEX (")
+	case query.HintForceIndex:
+		b.WriteString(" FORCE INDEX (")
+	case query.HintIgnoreIndex:
+		b.WriteString(" IGNORE INDEX (")
+	default:
+		return fmt.Errorf("unsupported index hint kind %q", hint.Kind)
+	}
+	for i, idx := range hint.Indexes {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(d.QuoteIdentifier(idx))
+	}
+	b.WriteString(")")
+	return nil
+}
+
+// MySQL reads and writes SRID 4326 WKT in latitude-longitude order unless
+// told otherwise; point columns hold longitude first like the other dialects.
+const mysqlAxisOrder = "'axis-order=long-lat'"
+
+func (d *MySQLDialect) WritePointText(b *strings.Builder, writeColumn func()) {
+	b.WriteString("ST_AsText(")
+	writeColumn()
+	b.WriteString(", " + mysqlAxisOrder + ")")
+}
+
+func (d *MySQLDialect) WritePointValue(b *strings.Builder, writeValue func() error) error {
+	b.WriteString("ST_GeomFromText(")
+	if err := writeValue(); err != nil {
+		return err
+	}
+	b.WriteString(", 4326, " + mysqlAxisOrder + ")")
+	return nil
+}
+
+const mysqlGeoDistance = "ST_Distance_Sphere({col}, ST_GeomFromText(CONCAT('POINT(', {lng}, ' ', {lat}, ')'), 4326, " + mysqlAxisOrder + "))"
+
+func (d *MySQLDialect) WriteWithinRadius(b *strings.Builder, col query.Column, lat, lng, meters query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng, "meters": meters}
+	return writeTemplate(b, "("+mysqlGeoDistance+" <= {meters})", args, writeExpr)
+}
+
+func (d *MySQLDialect) WriteGeoDistance(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng}
+	return writeTemplate(b, mysqlGeoDistance, args, writeExpr)
+}
+
+func (d *MySQLDialect) WriteGeoContains(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng}
+	return writeTemplate(b, "ST_Intersects({col}, ST_GeomFromText(CONCAT('POINT(', {lng}, ' ', {lat}, ')'), 4326, "+mysqlAxisOrder+"))", args, writeExpr)
+}
+
+// WriteJSONExtract unquotes the extracted value, turning JSON null into
+// NULL rather than the string "null" JSON_UNQUOTE gives.
+func (d *MySQLDialect) WriteJSONExtract(b *strings.Builder, col query.Column, path []JSONPathStep, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}}
+	extract := "JSON_EXTRACT({col}, " + standardJSONPath(path) + ")"
+	return writeTemplate(b, "(CASE JSON_TYPE("+extract+") WHEN 'NULL' THEN NULL ELSE JSON_UNQUOTE("+extract+") END)", args, writeExpr)
+}
+
+func (d *MySQLDialect) WriteJSONContains(b *strings.Builder, col query.Column, value query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "value": value}
+	return writeTemplate(b, "JSON_CONTAINS({col}, {value})", args, writeExpr)
+}
+
 // =============================================================================
 // SQLite Dialect
 // =============================================================================
@@ -352,11 +864,26 @@ func (d *SQLiteDialect) SupportsReturning() bool {
 	return true // SQLite 3.35+ supports RETURNING
 }
 
+func (d *SQLiteDialect) ReturningAsOutput() bool {
+	return false
+}
+
+func (d *SQLiteDialect) OffsetFetch() bool {
+	return false
+}
+
 func (d *SQLiteDialect) WriteILIKE(b *strings.Builder, args []query.Expr, writeExpr func(query.Expr) error) error {
 	// SQLite doesn't have native ILIKE, use LOWER() LIKE LOWER()
 	return writeILIKEWithLower(b, args, writeExpr)
 }
 
+func (d *SQLiteDialect) WriteEscapedLike(b *strings.Builder, args []query.Expr, leading, trailing, fold bool, writeExpr func(query.Expr) error) error {
+	if !fold {
+		return writeEscapedGlob(b, args, leading, trailing, writeExpr)
+	}
+	return writeEscapedLike(b, args, leading, trailing, "LIKE", true, false, likeWildcards, writeExpr)
+}
+
 func (d *SQLiteDialect) WriteJSONAgg(b *strings.Builder, cols []query.Column, fields []query.JSONAggField, writeColumn func(query.Column), writeExpr func(query.Expr) error) error {
 	if len(fields) > 0 {
 		return d.writeJSONAggFields(b, fields, writeColumn, writeExpr)
@@ -425,6 +952,363 @@ func (d *SQLiteDialect) WriteOrderByExpr(b *strings.Builder, expr query.Expr, wr
 	return writeExpr(expr)
 }
 
+func (d *SQLiteDialect) OptimizerHintAfterSelect() bool {
+	return false
+}
+
+func (d *SQLiteDialect) RecursiveKeyword() bool {
+	return true
+}
+
+func (d *SQLiteDialect) NullSafeEqualOp() string {
+	return "IS"
+}
+
+func (d *SQLiteDialect) SupportsRowLocks() bool {
+	return false
+}
+
+func (d *SQLiteDialect) RowLockTableHint() bool {
+	return false
+}
+
+func (d *SQLiteDialect) SupportsQualifiedTables() bool {
+	return false
+}
+
+func (d *SQLiteDialect) SupportsUpsert() bool {
+	return true
+}
+
+func (d *SQLiteDialect) UpsertOnDuplicateKey() bool {
+	return false
+}
+
+func (d *SQLiteDialect) WriteExcluded(b *strings.Builder, column string) {
+	b.WriteString("excluded.")
+	b.WriteString(column)
+}
+
+func (d *SQLiteDialect) WriteIndexHint(b *strings.Builder, hint query.Hint) error {
+	switch hint.Kind {
+	case query.HintUseIndex, query.HintForceIndex:
+		if len(hint.Indexes) != 1 {
+			return fmt.Errorf("sqlite INDEXED BY takes exactly one index, got %d", len(hint.Indexes))
+		}
+		b.WriteString(" INDEXED BY ")
+		b.WriteString(d.QuoteIdentifier(hint.Indexes[0]))
+		return nil
+	default:
+		return fmt.Errorf("sqlite does not support %s hints", hint.Kind)
+	}
+}
+
+// SQLite stores points as WKT text, so reads and writes pass through.
+func (d *SQLiteDialect) WritePointText(b *strings.Builder, writeColumn func()) {
+	writeColumn()
+}
+
+func (d *SQLiteDialect) WritePointValue(b *strings.Builder, writeValue func() error) error {
+	return writeValue()
+}
+
+// sqliteGeoDistance returns the haversine distance in meters between the
+// point stored in colSQL and ({lat}, {lng}), using the mean earth radius.
+func sqliteGeoDistance(colSQL string) string {
+	lat, lng := ddl.SQLitePointLat(colSQL), ddl.SQLitePointLng(colSQL)
+	return "(2 * 6371008.8 * asin(sqrt(power(sin(radians(" + lat + " - {lat}) / 2), 2) + " +
+		"cos(radians({lat})) * cos(radians(" + lat + ")) * power(sin(radians(" + lng + " - {lng}) / 2), 2))))"
+}
+
+func (d *SQLiteDialect) sqliteColumnSQL(col query.Column) string {
+	return d.QuoteIdentifier(col.TableName()) + "." + d.QuoteIdentifier(col.ColumnName())
+}
+
+// WriteWithinRadius narrows candidates with a bounding-box search of the
+// column's R*Tree before checking the exact distance. The R*Tree is named
+// after the column's table, so the column must not be referenced through a
+// table alias.
+func (d *SQLiteDialect) WriteWithinRadius(b *strings.Builder, col query.Column, lat, lng, meters query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"lat": lat, "lng": lng, "meters": meters}
+	dLat := "{meters} / 111320.0"
+	dLng := "{meters} / (111320.0 * cos(radians({lat})))"
+	tmpl := "(" + d.QuoteIdentifier(col.TableName()) + ".rowid IN (SELECT id FROM " +
+		d.QuoteIdentifier(ddl.RTreeTableName(col.TableName(), col.ColumnName())) + " WHERE " +
+		"max_lat >= {lat} - " + dLat + " AND min_lat <= {lat} + " + dLat + " AND " +
+		"max_lng >= {lng} - " + dLng + " AND min_lng <= {lng} + " + dLng + ") AND " +
+		sqliteGeoDistance(d.sqliteColumnSQL(col)) + " <= {meters})"
+	return writeTemplate(b, tmpl, args, writeExpr)
+}
+
+func (d *SQLiteDialect) WriteGeoDistance(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"lat": lat, "lng": lng}
+	return writeTemplate(b, sqliteGeoDistance(d.sqliteColumnSQL(col)), args, writeExpr)
+}
+
+// WriteGeoContains narrows candidates to the polygons whose bounding box,
+// kept in the column's R*Tree, holds the point, then counts the edges of
+// the ring that a ray cast east from the point crosses: an odd count means
+// the point is inside. Like WriteWithinRadius, the column must not be
+// referenced through a table alias.
+func (d *SQLiteDialect) WriteGeoContains(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"lat": lat, "lng": lng}
+	vertices := ddl.SQLitePolygonVertices(d.sqliteColumnSQL(col))
+	lng0, lat0 := "json_extract(v0.value, '$[0]')", "json_extract(v0.value, '$[1]')"
+	lng1, lat1 := "json_extract(v1.value, '$[0]')", "json_extract(v1.value, '$[1]')"
+	tmpl := "(" + d.QuoteIdentifier(col.TableName()) + ".rowid IN (SELECT id FROM " +
+		d.QuoteIdentifier(ddl.RTreeTableName(col.TableName(), col.ColumnName())) + " WHERE " +
+		"min_lng <= {lng} AND max_lng >= {lng} AND min_lat <= {lat} AND max_lat >= {lat}) AND " +
+		"(SELECT count(*) % 2 FROM json_each(" + vertices + ") AS v0 JOIN json_each(" + vertices + ") AS v1 ON v1.key = v0.key + 1 " +
+		"WHERE (" + lat0 + " > {lat}) <> (" + lat1 + " > {lat}) AND " +
+		"{lng} < " + lng0 + " + (" + lng1 + " - " + lng0 + ") * ({lat} - " + lat0 + ") / (" + lat1 + " - " + lat0 + " + 0.0)) = 1)"
+	return writeTemplate(b, tmpl, args, writeExpr)
+}
+
+// WriteJSONExtract casts the extracted value to text, spelling booleans as
+// true and false like the other databases rather than as 1 and 0.
+func (d *SQLiteDialect) WriteJSONExtract(b *strings.Builder, col query.Column, path []JSONPathStep, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}}
+	p := standardJSONPath(path)
+	tmpl := "(CASE json_type({col}, " + p + ") WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' " +
+		"ELSE CAST(json_extract({col}, " + p + ") AS TEXT) END)"
+	return writeTemplate(b, tmpl, args, writeExpr)
+}
+
+// WriteJSONContains emulates Postgres' @> with json_each: every member of
+// value must appear in the document, under the same key for objects. Arrays
+// nested one level down, such as {"tags":["a"]}, are matched by element;
+// deeper values are compared whole.
+func (d *SQLiteDialect) WriteJSONContains(b *strings.Builder, col query.Column, value query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "value": value}
+	tmpl := "(CASE json_type({value}) WHEN 'object' THEN json_type({col}) = 'object' " +
+		"WHEN 'array' THEN json_type({col}) = 'array' ELSE 1 END " +
+		"AND NOT EXISTS (SELECT 1 FROM json_each({value}) AS needle WHERE NOT EXISTS (" +
+		"SELECT 1 FROM json_each({col}) AS hay WHERE (hay.key IS needle.key OR json_type({col}) = 'array') " +
+		"AND CASE WHEN needle.type = 'array' AND hay.type = 'array' THEN NOT EXISTS (" +
+		"SELECT 1 FROM json_each(needle.value) AS needle2 WHERE NOT EXISTS (" +
+		"SELECT 1 FROM json_each(hay.value) AS hay2 WHERE " + sqliteJSONEqual("hay2", "needle2") + ")) " +
+		"ELSE " + sqliteJSONEqual("hay", "needle") + " END)))"
+	return writeTemplate(b, tmpl, args, writeExpr)
+}
+
+// sqliteJSONEqual compares two json_each rows by value and type, treating
+// integers and reals alike so 2 matches 2.0.
+func sqliteJSONEqual(a, b string) string {
+	return "(" + a + ".value IS " + b + ".value AND (" + a + ".type = " + b + ".type OR " +
+		a + ".type IN ('integer', 'real') AND " + b + ".type IN ('integer', 'real')))"
+}
+
+// =============================================================================
+// SQL Server Dialect
+// =============================================================================
+
+// MSSQLDialect implements Dialect for Microsoft SQL Server and Azure SQL.
+// JSON aggregation uses JSON_ARRAYAGG and JSON_OBJECT, and the null-safe
+// comparison IS NOT DISTINCT FROM, both available on Azure SQL Database
+// and SQL Server 2022 and later.
+type MSSQLDialect struct{}
+
+func (d *MSSQLDialect) Name() string { return "mssql" }
+
+func (d *MSSQLDialect) QuoteIdentifier(name string) string {
+	// Escape embedded closing brackets by doubling them
+	escaped := strings.ReplaceAll(name, "]", "]]")
+	return "[" + escaped + "]"
+}
+
+func (d *MSSQLDialect) Placeholder(index int) string {
+	return fmt.Sprintf("@p%d", index)
+}
+
+func (d *MSSQLDialect) BoolLiteral(val bool) string {
+	if val {
+		return "1"
+	}
+	return "0"
+}
+
+// NowFunc returns the current UTC time; DATETIME2 columns carry no zone.
+func (d *MSSQLDialect) NowFunc() string {
+	return "SYSUTCDATETIME()"
+}
+
+func (d *MSSQLDialect) WrapSetOpQueries() bool {
+	return true
+}
+
+func (d *MSSQLDialect) SupportsReturning() bool {
+	return true
+}
+
+func (d *MSSQLDialect) ReturningAsOutput() bool {
+	return true
+}
+
+func (d *MSSQLDialect) OffsetFetch() bool {
+	return true
+}
+
+func (d *MSSQLDialect) WriteILIKE(b *strings.Builder, args []query.Expr, writeExpr func(query.Expr) error) error {
+	// The default collation is case-insensitive, but a column may use a
+	// case-sensitive one, so compare lowercased values like MySQL and SQLite.
+	return writeILIKEWithLower(b, args, writeExpr)
+}
+
+func (d *MSSQLDialect) WriteEscapedLike(b *strings.Builder, args []query.Expr, leading, trailing, fold bool, writeExpr func(query.Expr) error) error {
+	// [ opens a character range in T-SQL LIKE patterns
+	return writeEscapedLike(b, args, leading, trailing, "LIKE", fold, true, likeWildcards+"[", writeExpr)
+}
+
+func (d *MSSQLDialect) WriteJSONAgg(b *strings.Builder, cols []query.Column, fields []query.JSONAggField, writeColumn func(query.Column), writeExpr func(query.Expr) error) error {
+	if len(fields) == 0 {
+		if len(cols) == 0 {
+			return fmt.Errorf("JSON aggregation requires at least one column")
+		}
+		for _, col := range cols {
+			fields = append(fields, query.JSONAggField{Key: col.ColumnName(), Column: col})
+		}
+	}
+	b.WriteString("COALESCE(JSON_ARRAYAGG(CASE WHEN ")
+	if first := fields[0]; first.Column != nil {
+		writeColumn(first.Column)
+	} else if first.Expr != nil {
+		if err := writeExpr(first.Expr); err != nil {
+			return err
+		}
+	}
+	b.WriteString(" IS NOT NULL THEN JSON_OBJECT(")
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(b, "'%s': ", f.Key)
+		if f.Column != nil {
+			if isTimeColumn(f.Column) {
+				// Style 126 is ISO 8601 (yyyy-mm-ddThh:mi:ss.fffffff)
+				b.WriteString("CONCAT(CONVERT(VARCHAR(27), ")
+				writeColumn(f.Column)
+				b.WriteString(", 126), 'Z')")
+			} else {
+				writeColumn(f.Column)
+			}
+		} else if f.Expr != nil {
+			if err := writeExpr(f.Expr); err != nil {
+				return err
+			}
+		}
+	}
+	b.WriteString(") END), '[]')")
+	return nil
+}
+
+func (d *MSSQLDialect) WriteOrderByExpr(b *strings.Builder, expr query.Expr, writeExpr func(query.Expr) error) error {
+	// Collation is set at the column level (COLLATE Latin1_General_BIN2)
+	// during table creation, so no per-query annotation is needed.
+	return writeExpr(expr)
+}
+
+func (d *MSSQLDialect) OptimizerHintAfterSelect() bool {
+	return false
+}
+
+func (d *MSSQLDialect) RecursiveKeyword() bool {
+	return false
+}
+
+func (d *MSSQLDialect) NullSafeEqualOp() string {
+	return "IS NOT DISTINCT FROM"
+}
+
+func (d *MSSQLDialect) SupportsRowLocks() bool {
+	return true
+}
+
+func (d *MSSQLDialect) RowLockTableHint() bool {
+	return true
+}
+
+func (d *MSSQLDialect) SupportsQualifiedTables() bool {
+	return true
+}
+
+func (d *MSSQLDialect) SupportsUpsert() bool {
+	return false
+}
+
+func (d *MSSQLDialect) UpsertOnDuplicateKey() bool {
+	return false
+}
+
+// WriteExcluded is unreachable: SQL Server upserts are rejected before any
+// conflict clause is written.
+func (d *MSSQLDialect) WriteExcluded(b *strings.Builder, column string) {
+	b.WriteString(column)
+}
+
+func (d *MSSQLDialect) WriteIndexHint(b *strings.Builder, hint query.Hint) error {
+	switch hint.Kind {
+	case query.HintUseIndex, query.HintForceIndex:
+		b.WriteString(" WITH (INDEX(")
+		for i, idx := range hint.Indexes {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(d.QuoteIdentifier(idx))
+		}
+		b.WriteString("))")
+		return nil
+	default:
+		return fmt.Errorf("mssql does not support %s hints", hint.Kind)
+	}
+}
+
+// SQL Server reads and writes geography WKT with longitude first, like the
+// other dialects.
+func (d *MSSQLDialect) WritePointText(b *strings.Builder, writeColumn func()) {
+	writeColumn()
+	b.WriteString(".STAsText()")
+}
+
+func (d *MSSQLDialect) WritePointValue(b *strings.Builder, writeValue func() error) error {
+	b.WriteString("geography::STGeomFromText(")
+	if err := writeValue(); err != nil {
+		return err
+	}
+	b.WriteString(", 4326)")
+	return nil
+}
+
+// mssqlGeoDistance measures from the column; geography::Point takes
+// latitude first.
+const mssqlGeoDistance = "{col}.STDistance(geography::Point({lat}, {lng}, 4326))"
+
+func (d *MSSQLDialect) WriteWithinRadius(b *strings.Builder, col query.Column, lat, lng, meters query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng, "meters": meters}
+	return writeTemplate(b, "("+mssqlGeoDistance+" <= {meters})", args, writeExpr)
+}
+
+func (d *MSSQLDialect) WriteGeoDistance(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng}
+	return writeTemplate(b, mssqlGeoDistance, args, writeExpr)
+}
+
+// WriteGeoContains requires polygons stored with their ring
+// counter-clockwise, as query.NormalizePolygonWKT writes them: SQL Server
+// takes the other orientation to mean the rest of the globe.
+func (d *MSSQLDialect) WriteGeoContains(b *strings.Builder, col query.Column, lat, lng query.Expr, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}, "lat": lat, "lng": lng}
+	return writeTemplate(b, "({col}.STIntersects(geography::Point({lat}, {lng}, 4326)) = 1)", args, writeExpr)
+}
+
+func (d *MSSQLDialect) WriteJSONExtract(b *strings.Builder, col query.Column, path []JSONPathStep, writeExpr func(query.Expr) error) error {
+	args := map[string]query.Expr{"col": query.ColumnExpr{Column: col}}
+	return writeTemplate(b, "JSON_VALUE({col}, "+standardJSONPath(path)+")", args, writeExpr)
+}
+
+func (d *MSSQLDialect) WriteJSONContains(b *strings.Builder, col query.Column, value query.Expr, writeExpr func(query.Expr) error) error {
+	return fmt.Errorf("JSON containment is not supported on SQL Server")
+}
+
 // =============================================================================
 // Dialect Singletons
 // =============================================================================
@@ -438,4 +1322,7 @@ var (
 
 	// SQLite is the singleton SQLite dialect.
 	SQLite Dialect = &SQLiteDialect{}
+
+	// MSSQL is the singleton SQL Server dialect.
+	MSSQL Dialect = &MSSQLDialect{}
 )
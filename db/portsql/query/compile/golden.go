@@ -0,0 +1,185 @@
+package compile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// GoldenUpdateEnv is the environment variable that makes CheckGoldenSQL
+// rewrite the golden files instead of comparing against them.
+const GoldenUpdateEnv = "SHIPQ_UPDATE_GOLDEN"
+
+// GoldenTB is the part of testing.TB used by CheckGoldenSQL.
+type GoldenTB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Logf(format string, args ...any)
+}
+
+// GoldenOptions configures CheckGoldenSQL.
+type GoldenOptions struct {
+	// Dir holds one subdirectory of golden files per dialect. Default
+	// "testdata/sql".
+	Dir string
+	// Dialects to compile for ("postgres", "mysql", "sqlite", "mssql").
+	// Default postgres, mysql and sqlite.
+	Dialects []string
+	// Update rewrites the golden files, and removes those of queries that no
+	// longer exist. It is also enabled by SHIPQ_UPDATE_GOLDEN=1.
+	Update bool
+}
+
+// CheckGoldenSQL compiles every registered query for each dialect and
+// compares the SQL and parameter order with <Dir>/<dialect>/<Query>.sql,
+// failing with a line diff on mismatch. Import the querydefs packages (for
+// their registrations) in the test that calls it:
+//
+//	import _ "myapp/querydefs/posts"
+//
+//	func TestQuerySQL(t *testing.T) {
+//		compile.CheckGoldenSQL(t, compile.GoldenOptions{})
+//	}
+//
+// Run the test with SHIPQ_UPDATE_GOLDEN=1 to accept changes, then commit
+// the files so SQL changes show up in review.
+func CheckGoldenSQL(t GoldenTB, opts GoldenOptions) {
+	t.Helper()
+	if opts.Dir == "" {
+		opts.Dir = filepath.Join("testdata", "sql")
+	}
+	if len(opts.Dialects) == 0 {
+		opts.Dialects = []string{"postgres", "mysql", "sqlite"}
+	}
+	update := opts.Update || os.Getenv(GoldenUpdateEnv) == "1"
+
+	queries := query.GetRegisteredQueries()
+	if len(queries) == 0 {
+		t.Fatalf("no queries registered; import the querydefs packages in this test")
+	}
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, dialectName := range opts.Dialects {
+		dialect, ok := goldenDialects[dialectName]
+		if !ok {
+			t.Fatalf("unknown dialect %q", dialectName)
+		}
+		dir := filepath.Join(opts.Dir, dialectName)
+		if update {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("create %s: %v", dir, err)
+			}
+		}
+
+		want := make(map[string]bool, len(names))
+		for _, name := range names {
+			path := filepath.Join(dir, name+".sql")
+			want[path] = true
+
+			sql, params, err := NewCompiler(dialect).Compile(queries[name].AST)
+			if err != nil {
+				t.Errorf("%s (%s): %v", name, dialectName, err)
+				continue
+			}
+			got := goldenSQLFile(sql, params)
+
+			if update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatalf("write %s: %v", path, err)
+				}
+				continue
+			}
+			existing, err := os.ReadFile(path)
+			if err != nil {
+				t.Errorf("%s: missing golden file (run with %s=1 to create it)", path, GoldenUpdateEnv)
+				continue
+			}
+			if string(existing) != got {
+				t.Errorf("%s: SQL changed (run with %s=1 to accept)\n%s", path, GoldenUpdateEnv, lineDiff(string(existing), got))
+			}
+		}
+
+		files, _ := filepath.Glob(filepath.Join(dir, "*.sql"))
+		for _, path := range files {
+			if want[path] {
+				continue
+			}
+			if update {
+				if err := os.Remove(path); err != nil {
+					t.Fatalf("remove %s: %v", path, err)
+				}
+				t.Logf("removed stale golden file %s", path)
+				continue
+			}
+			t.Errorf("%s: golden file of a query that is no longer registered (run with %s=1 to remove it)", path, GoldenUpdateEnv)
+		}
+	}
+	if update {
+		t.Logf("updated golden SQL in %s", opts.Dir)
+	}
+}
+
+var goldenDialects = map[string]Dialect{
+	"postgres": Postgres,
+	"mysql":    MySQL,
+	"sqlite":   SQLite,
+	"mssql":    MSSQL,
+}
+
+// goldenSQLFile renders a compiled query as a golden file: a comment with
+// the parameter order, then the SQL.
+func goldenSQLFile(sql string, params []string) string {
+	return "-- params: " + strings.Join(params, ", ") + "\n" + sql + "\n"
+}
+
+// lineDiff renders the lines of want and got that differ, prefixed with "-"
+// and "+", around their longest common subsequence.
+func lineDiff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&out, "  %s\n", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+ %s\n", b[j])
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,99 @@
+package compile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// recordingTB collects the failures reported by CheckGoldenSQL.
+type recordingTB struct {
+	errors []string
+	fatal  string
+}
+
+func (r *recordingTB) Helper()                   {}
+func (r *recordingTB) Logf(string, ...any)       {}
+func (r *recordingTB) Errorf(f string, a ...any) { r.errors = append(r.errors, fmt.Sprintf(f, a...)) }
+func (r *recordingTB) Fatalf(f string, a ...any) { r.fatal = fmt.Sprintf(f, a...) }
+
+func TestCheckGoldenSQL(t *testing.T) {
+	query.ClearRegistry()
+	defer query.ClearRegistry()
+
+	idCol := query.Int64Column{Table: "authors", Name: "id"}
+	query.MustDefineOne("GetAuthor", &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "authors"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: query.StringColumn{Table: "authors", Name: "name"}}}},
+		Where:      query.BinaryExpr{Left: query.ColumnExpr{Column: idCol}, Op: query.OpEq, Right: query.ParamExpr{Name: "id", GoType: "int64"}},
+	})
+
+	dir := t.TempDir()
+	opts := GoldenOptions{Dir: dir, Dialects: []string{"postgres", "sqlite"}}
+
+	// Nothing committed yet.
+	tb := &recordingTB{}
+	CheckGoldenSQL(tb, opts)
+	if len(tb.errors) != 2 || !strings.Contains(tb.errors[0], "missing golden file") {
+		t.Fatalf("errors = %q", tb.errors)
+	}
+
+	update := opts
+	update.Update = true
+	tb = &recordingTB{}
+	CheckGoldenSQL(tb, update)
+	if len(tb.errors) > 0 || tb.fatal != "" {
+		t.Fatalf("update failed: %q %q", tb.errors, tb.fatal)
+	}
+	pgPath := filepath.Join(dir, "postgres", "GetAuthor.sql")
+	pg, err := os.ReadFile(pgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-- params: id\nSELECT \"authors\".\"name\" FROM \"authors\" WHERE (\"authors\".\"id\" = $1)\n"; string(pg) != want {
+		t.Errorf("postgres golden file = %q, want %q", pg, want)
+	}
+
+	tb = &recordingTB{}
+	CheckGoldenSQL(tb, opts)
+	if len(tb.errors) > 0 {
+		t.Fatalf("unchanged SQL should pass, got %q", tb.errors)
+	}
+
+	// A changed query fails with a diff.
+	if err := os.WriteFile(pgPath, []byte("-- params: id\nSELECT 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tb = &recordingTB{}
+	CheckGoldenSQL(tb, opts)
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "- SELECT 1\n+ SELECT \"authors\"") || !strings.Contains(tb.errors[0], "  -- params: id") {
+		t.Errorf("errors = %q", tb.errors)
+	}
+
+	// Golden files of removed queries are reported, and removed on update.
+	stale := filepath.Join(dir, "sqlite", "DeletedQuery.sql")
+	if err := os.WriteFile(stale, []byte("SELECT 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tb = &recordingTB{}
+	CheckGoldenSQL(tb, GoldenOptions{Dir: dir, Dialects: []string{"sqlite"}})
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "no longer registered") {
+		t.Errorf("errors = %q", tb.errors)
+	}
+	CheckGoldenSQL(&recordingTB{}, update)
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale golden file should be removed on update")
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc\n", "a\nx\nc\n")
+	if want := "  a\n- b\n+ x\n  c\n"; got != want {
+		t.Errorf("lineDiff = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,311 @@
+package compile
+
+import (
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+func TestMSSQL_SelectWithWhere(t *testing.T) {
+	idCol := query.Int64Column{Table: "authors", Name: "id"}
+	nameCol := query.StringColumn{Table: "authors", Name: "name"}
+
+	ast := &query.AST{
+		Kind:      query.SelectQuery,
+		FromTable: query.TableRef{Name: "authors"},
+		SelectCols: []query.SelectExpr{
+			{Expr: query.ColumnExpr{Column: idCol}},
+			{Expr: query.ColumnExpr{Column: nameCol}},
+		},
+		Where: query.And(
+			idCol.Eq(query.Param[int64]("id")),
+			nameCol.Eq(query.Param[string]("name")),
+		),
+	}
+
+	sql, params, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	// SQL Server uses brackets and numbered @p placeholders
+	expected := "SELECT [authors].[id], [authors].[name] FROM [authors] WHERE (([authors].[id] = @p1) AND ([authors].[name] = @p2))"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(params) != 2 || params[0] != "id" || params[1] != "name" {
+		t.Errorf("expected params [id name], got %v", params)
+	}
+}
+
+func TestMSSQL_QuoteIdentifier(t *testing.T) {
+	if got := MSSQL.QuoteIdentifier("odd]name"); got != "[odd]]name]" {
+		t.Errorf("QuoteIdentifier = %q, want %q", got, "[odd]]name]")
+	}
+}
+
+func TestMSSQL_Pagination(t *testing.T) {
+	idCol := query.Int64Column{Table: "authors", Name: "id"}
+	build := func() *query.AST {
+		return &query.AST{
+			Kind:       query.SelectQuery,
+			FromTable:  query.TableRef{Name: "authors"},
+			Distinct:   true,
+			SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: idCol}}},
+			Where:      idCol.Gt(query.Param[int64]("after")),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(*query.AST)
+		expected string
+		params   []string
+	}{
+		{
+			name: "limit only uses TOP",
+			edit: func(ast *query.AST) {
+				ast.Limit = query.ParamExpr{Name: "limit", GoType: "int"}
+				ast.OrderBy = []query.OrderByExpr{{Expr: query.ColumnExpr{Column: idCol}}}
+			},
+			expected: "SELECT DISTINCT TOP (@p1) [authors].[id] FROM [authors] WHERE ([authors].[id] > @p2) ORDER BY [authors].[id]",
+			params:   []string{"limit", "after"},
+		},
+		{
+			name: "limit and offset use OFFSET FETCH",
+			edit: func(ast *query.AST) {
+				ast.Limit = query.ParamExpr{Name: "limit", GoType: "int"}
+				ast.Offset = query.ParamExpr{Name: "offset", GoType: "int"}
+				ast.OrderBy = []query.OrderByExpr{{Expr: query.ColumnExpr{Column: idCol}, Desc: true}}
+			},
+			expected: "SELECT DISTINCT [authors].[id] FROM [authors] WHERE ([authors].[id] > @p1) ORDER BY [authors].[id] DESC OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY",
+			params:   []string{"after", "offset", "limit"},
+		},
+		{
+			name: "offset without ORDER BY",
+			edit: func(ast *query.AST) {
+				ast.Offset = query.LiteralExpr{Value: 20}
+			},
+			expected: "SELECT DISTINCT [authors].[id] FROM [authors] WHERE ([authors].[id] > @p1) ORDER BY (SELECT NULL) OFFSET 20 ROWS",
+			params:   []string{"after"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast := build()
+			tt.edit(ast)
+			sql, params, err := NewCompiler(MSSQL).Compile(ast)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("expected SQL:\n%s\ngot:\n%s", tt.expected, sql)
+			}
+			if len(params) != len(tt.params) {
+				t.Fatalf("params = %v, want %v", params, tt.params)
+			}
+			for i := range params {
+				if params[i] != tt.params[i] {
+					t.Errorf("params = %v, want %v", params, tt.params)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestMSSQL_UnionLimit(t *testing.T) {
+	side := func(table string) *query.AST {
+		return &query.AST{
+			Kind:      query.SelectQuery,
+			FromTable: query.TableRef{Name: table},
+			SelectCols: []query.SelectExpr{
+				{Expr: query.ColumnExpr{Column: query.StringColumn{Table: table, Name: "email"}}},
+			},
+		}
+	}
+	ast := &query.AST{
+		Kind:  query.SelectQuery,
+		SetOp: &query.SetOperation{Left: side("active_users"), Op: query.SetOpUnion, Right: side("archived_users")},
+		Limit: query.LiteralExpr{Value: 10},
+	}
+
+	sql, _, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "(SELECT [active_users].[email] FROM [active_users]) UNION (SELECT [archived_users].[email] FROM [archived_users]) " +
+		"ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestMSSQL_InsertOutput(t *testing.T) {
+	id := query.Int64Column{Table: "authors", Name: "id"}
+	name := query.StringColumn{Table: "authors", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.InsertQuery,
+		FromTable:  query.TableRef{Name: "authors"},
+		InsertCols: []query.Column{name},
+		InsertRows: [][]query.Expr{{query.ParamExpr{Name: "name", GoType: "string"}}},
+		Returning:  []query.Column{id},
+	}
+
+	sql, params, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "INSERT INTO [authors] ([name]) OUTPUT INSERTED.[id] VALUES (@p1)"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(params) != 1 || params[0] != "name" {
+		t.Errorf("expected params [name], got %v", params)
+	}
+}
+
+func TestMSSQL_UpsertUnsupported(t *testing.T) {
+	email := query.StringColumn{Table: "users", Name: "email"}
+	ast := &query.AST{
+		Kind:       query.InsertQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		InsertCols: []query.Column{email},
+		InsertRows: [][]query.Expr{{query.ParamExpr{Name: "email", GoType: "string"}}},
+		OnConflict: &query.OnConflict{Columns: []query.Column{email}, DoNothing: true},
+	}
+	if _, _, err := NewCompiler(MSSQL).Compile(ast); err == nil {
+		t.Error("expected error for upsert on mssql")
+	}
+}
+
+func TestMSSQL_ForUpdateWithIndexHint(t *testing.T) {
+	ast := &query.AST{
+		Kind:      query.SelectQuery,
+		FromTable: query.TableRef{Name: "posts"},
+		Hints:     []query.Hint{{Dialect: query.MSSQL, Kind: query.HintUseIndex, Indexes: []string{"posts_org_idx"}}},
+		ForUpdate: true,
+	}
+	if _, _, err := NewCompiler(MSSQL).Compile(ast); err == nil {
+		t.Error("expected error: a second WITH table hint is invalid")
+	}
+}
+
+func TestMSSQL_Contains(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.Contains(query.Param[string]("q")),
+	}
+
+	sql, _, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	// [ opens a character range, so it is escaped along with % and _
+	want := "[users].[name] LIKE CONCAT('%', REPLACE(REPLACE(REPLACE(REPLACE(@p1, '!', '!!'), '%', '!%'), '_', '!_'), '[', '![')" +
+		", '%') ESCAPE '!'"
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+}
+
+func TestMSSQL_JSONAgg(t *testing.T) {
+	id := query.Int64Column{Table: "books", Name: "id"}
+	published := query.TimeColumn{Table: "books", Name: "published_at"}
+
+	ast := &query.AST{
+		Kind:      query.SelectQuery,
+		FromTable: query.TableRef{Name: "books"},
+		SelectCols: []query.SelectExpr{
+			{Expr: query.JSONAggExpr{Columns: []query.Column{id, published}}, Alias: "books"},
+		},
+	}
+
+	sql, _, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "SELECT COALESCE(JSON_ARRAYAGG(CASE WHEN [books].[id] IS NOT NULL THEN JSON_OBJECT('id': [books].[id], " +
+		"'published_at': CONCAT(CONVERT(VARCHAR(27), [books].[published_at], 126), 'Z')) END), '[]') AS [books] FROM [books]"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestMSSQL_PointColumn(t *testing.T) {
+	loc := query.PointColumn{Table: "stores", Name: "location"}
+
+	ast := query.From(storesTable{}).
+		Select(loc).
+		Where(loc.WithinRadius(37.77, -122.42, 5000)).
+		Build()
+
+	sql, _, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "SELECT [stores].[location].STAsText() AS [location] FROM [stores] " +
+		"WHERE ([stores].[location].STDistance(geography::Point(37.77, -122.42, 4326)) <= 5000)"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+
+	insert := query.InsertInto(storesTable{}).
+		Columns(loc).
+		Values(query.Literal("POINT(1 2)")).
+		Returning(loc).
+		Build()
+	sql, _, err = NewCompiler(MSSQL).Compile(insert)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected = "INSERT INTO [stores] ([location]) OUTPUT INSERTED.[location].STAsText() AS [location] " +
+		"VALUES (geography::STGeomFromText('POINT(1 2)', 4326))"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestMSSQL_PolygonColumn(t *testing.T) {
+	zone := query.PolygonColumn{Table: "stores", Name: "zone"}
+
+	ast := query.From(storesTable{}).
+		Select(zone).
+		Where(zone.Contains(37.77, -122.42)).
+		Build()
+
+	sql, _, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "SELECT [stores].[zone].STAsText() AS [zone] FROM [stores] " +
+		"WHERE ([stores].[zone].STIntersects(geography::Point(37.77, -122.42, 4326)) = 1)"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestMSSQL_JSONColumn(t *testing.T) {
+	payload := query.JSONColumn{Table: "events", Name: "payload"}
+
+	ast := query.From(eventsTable{}).Select(payload).Where(payload.Extract("$.kind").Eq("signup")).Build()
+	sql, _, err := NewCompiler(MSSQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "SELECT [events].[payload] FROM [events] WHERE (JSON_VALUE([events].[payload], '$.kind') = 'signup')"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+
+	ast = query.From(eventsTable{}).Select(payload).Where(payload.Contains(`{"kind":"signup"}`)).Build()
+	if _, _, err := NewCompiler(MSSQL).Compile(ast); err == nil {
+		t.Error("expected an error for JSON containment on SQL Server")
+	}
+}
@@ -1466,3 +1466,212 @@ func TestMySQL_InsertSelect_NoReturning(t *testing.T) {
 		t.Errorf("MySQL SQL should NOT contain RETURNING: %s", sql)
 	}
 }
+
+func TestMySQL_PointColumn(t *testing.T) {
+	loc := query.PointColumn{Table: "stores", Name: "location"}
+
+	ast := query.From(storesTable{}).
+		Select(loc).
+		Where(loc.WithinRadius(37.77, -122.42, 5000)).
+		Build()
+
+	sql, _, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "SELECT ST_AsText(`stores`.`location`, 'axis-order=long-lat') AS `location` FROM `stores` " +
+		"WHERE (ST_Distance_Sphere(`stores`.`location`, ST_GeomFromText(CONCAT('POINT(', -122.42, ' ', 37.77, ')'), 4326, 'axis-order=long-lat')) <= 5000)"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+
+	insert := query.InsertInto(storesTable{}).
+		Columns(loc).
+		Values(query.Literal("POINT(1 2)")).
+		Build()
+	sql, _, err = NewCompiler(MySQL).Compile(insert)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected = "INSERT INTO `stores` (`location`) VALUES (ST_GeomFromText('POINT(1 2)', 4326, 'axis-order=long-lat'))"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestMySQL_PolygonColumn(t *testing.T) {
+	zone := query.NullPolygonColumn{Table: "stores", Name: "zone"}
+
+	ast := query.From(storesTable{}).
+		Select(zone).
+		Where(zone.Contains(37.77, -122.42)).
+		Build()
+
+	sql, _, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "SELECT ST_AsText(`stores`.`zone`, 'axis-order=long-lat') AS `zone` FROM `stores` " +
+		"WHERE ST_Intersects(`stores`.`zone`, ST_GeomFromText(CONCAT('POINT(', -122.42, ' ', 37.77, ')'), 4326, 'axis-order=long-lat'))"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestMySQL_JSONColumn(t *testing.T) {
+	payload := query.NullJSONColumn{Table: "events", Name: "payload"}
+
+	ast := query.From(eventsTable{}).
+		Select(payload).
+		Where(query.And(payload.Extract("$.tags[0]").Eq("go"), payload.Contains(`{"kind":"signup"}`))).
+		Build()
+
+	sql, _, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "SELECT `events`.`payload` FROM `events` WHERE (((CASE JSON_TYPE(JSON_EXTRACT(`events`.`payload`, '$.tags[0]')) " +
+		"WHEN 'NULL' THEN NULL ELSE JSON_UNQUOTE(JSON_EXTRACT(`events`.`payload`, '$.tags[0]')) END) = 'go') " +
+		"AND JSON_CONTAINS(`events`.`payload`, '{\"kind\":\"signup\"}'))"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
+
+func TestMySQL_StartsWith(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.StartsWith(query.Param[string]("prefix")),
+	}
+
+	sql, params, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := "`users`.`name` LIKE CONCAT(REPLACE(REPLACE(REPLACE(?, '!', '!!'), '%', '!%'), '_', '!_'), '%') ESCAPE '!'"
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+	if len(params) != 1 || params[0] != "prefix" {
+		t.Errorf("params = %v, want [prefix]", params)
+	}
+}
+
+func TestMySQL_EndsWith(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.EndsWith(query.Param[string]("suffix")),
+	}
+
+	sql, params, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := "`users`.`name` LIKE CONCAT('%', REPLACE(REPLACE(REPLACE(?, '!', '!!'), '%', '!%'), '_', '!_')) ESCAPE '!'"
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+	if len(params) != 1 || params[0] != "suffix" {
+		t.Errorf("params = %v, want [suffix]", params)
+	}
+}
+
+func TestMySQL_IStartsWith(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.IStartsWith(query.Param[string]("prefix")),
+	}
+
+	sql, _, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := "LOWER(`users`.`name`) LIKE LOWER(CONCAT(REPLACE(REPLACE(REPLACE(?, '!', '!!'), '%', '!%'), '_', '!_'), '%')) ESCAPE '!'"
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+}
+
+func TestMySQL_Contains(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.Contains(query.Param[string]("q")),
+	}
+
+	sql, _, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	if !containsStr(sql, "`users`.`name` LIKE CONCAT('%', REPLACE(") || !containsStr(sql, "'%') ESCAPE '!'") {
+		t.Errorf("SQL should wrap the escaped search string in %%: %s", sql)
+	}
+}
+
+func TestMySQL_Upsert(t *testing.T) {
+	email := query.StringColumn{Table: "users", Name: "email"}
+	name := query.StringColumn{Table: "users", Name: "name"}
+	orgID := query.Int64Column{Table: "users", Name: "org_id"}
+
+	ast := &query.AST{
+		Kind:       query.InsertQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		InsertCols: []query.Column{email, name, orgID},
+		InsertRows: [][]query.Expr{{
+			query.ParamExpr{Name: "email", GoType: "string"},
+			query.ParamExpr{Name: "name", GoType: "string"},
+			query.ParamExpr{Name: "org_id", GoType: "int64"},
+		}},
+		OnConflict: &query.OnConflict{
+			Columns: []query.Column{email},
+			Updates: query.SetExcluded(name),
+		},
+	}
+
+	sql, _, err := NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := "INSERT INTO `users` (`email`, `name`, `org_id`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+
+	// No conditional upsert: each assignment keeps the old value unless Where holds.
+	ast.OnConflict.Where = orgID.Eq(query.Excluded(orgID))
+	sql, _, err = NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !containsStr(sql, "ON DUPLICATE KEY UPDATE `name` = IF((`users`.`org_id` = VALUES(`org_id`)), VALUES(`name`), `name`)") {
+		t.Errorf("expected IF-guarded assignment, got:\n%s", sql)
+	}
+
+	ast.OnConflict = &query.OnConflict{Columns: []query.Column{email}, DoNothing: true}
+	sql, _, err = NewCompiler(MySQL).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !containsStr(sql, "ON DUPLICATE KEY UPDATE `email` = `email`") {
+		t.Errorf("expected no-op assignment for DO NOTHING, got:\n%s", sql)
+	}
+}
@@ -1,6 +1,9 @@
 package compile
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/shipq/shipq/db/portsql/query"
@@ -2198,3 +2201,315 @@ func TestPostgres_InsertSelect_CTE_ParamOrder(t *testing.T) {
 		t.Errorf("CTE param should be $1, not $2: %s", sql)
 	}
 }
+
+// storesTable is the table used by the point column tests.
+type storesTable struct{}
+
+func (storesTable) TableName() string { return "stores" }
+
+func TestPostgres_PointColumn(t *testing.T) {
+	id := query.Int64Column{Table: "stores", Name: "id"}
+	loc := query.PointColumn{Table: "stores", Name: "location"}
+	lat, lng := query.Param[float64]("lat"), query.Param[float64]("lng")
+
+	t.Run("select, filter and order by distance", func(t *testing.T) {
+		ast := query.From(storesTable{}).
+			Select(id, loc).
+			Where(loc.WithinRadius(lat, lng, query.Param[float64]("meters"))).
+			OrderBy(query.OrderByExpr{Expr: loc.DistanceFrom(lat, lng)}).
+			Build()
+
+		sql, params, err := NewCompiler(Postgres).Compile(ast)
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		expected := `SELECT "stores"."id", ST_AsText("stores"."location") AS "location" FROM "stores" ` +
+			`WHERE ST_DWithin("stores"."location", ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography, $3) ` +
+			`ORDER BY ST_Distance("stores"."location", ST_SetSRID(ST_MakePoint($4, $5), 4326)::geography)`
+		if sql != expected {
+			t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+		}
+		wantParams := []string{"lng", "lat", "meters", "lng", "lat"}
+		if strings.Join(params, ",") != strings.Join(wantParams, ",") {
+			t.Errorf("expected params %v, got %v", wantParams, params)
+		}
+	})
+
+	t.Run("insert converts WKT", func(t *testing.T) {
+		ast := query.InsertInto(storesTable{}).
+			Columns(id, loc).
+			Values(query.Param[int64]("id"), query.Param[string]("location")).
+			Returning(loc).
+			Build()
+
+		sql, _, err := NewCompiler(Postgres).Compile(ast)
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		expected := `INSERT INTO "stores" ("id", "location") VALUES ($1, ST_GeogFromText($2)) RETURNING ST_AsText("location") AS "location"`
+		if sql != expected {
+			t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+		}
+	})
+
+	t.Run("update converts only the new value", func(t *testing.T) {
+		nullLoc := query.NullPointColumn{Table: "stores", Name: "hub"}
+		ast := query.Update(storesTable{}).
+			Set(nullLoc, query.Coalesce(query.Param[*string]("hub"), query.ColumnExpr{Column: nullLoc})).
+			Where(id.Eq(query.Param[int64]("id"))).
+			Build()
+
+		sql, _, err := NewCompiler(Postgres).Compile(ast)
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		expected := `UPDATE "stores" SET "hub" = COALESCE(ST_GeogFromText($1), "stores"."hub") WHERE ("stores"."id" = $2)`
+		if sql != expected {
+			t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+		}
+	})
+}
+
+func TestPostgres_PolygonColumn(t *testing.T) {
+	zone := query.PolygonColumn{Table: "stores", Name: "zone"}
+
+	ast := query.From(storesTable{}).
+		Select(zone).
+		Where(zone.Contains(query.Param[float64]("lat"), query.Param[float64]("lng"))).
+		Build()
+
+	sql, params, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := `SELECT ST_AsText("stores"."zone") AS "zone" FROM "stores" ` +
+		`WHERE ST_Intersects("stores"."zone", ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography)`
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+	if strings.Join(params, ",") != "lng,lat" {
+		t.Errorf("expected params [lng lat], got %v", params)
+	}
+}
+
+// eventsTable is the table used by the JSON column tests.
+type eventsTable struct{}
+
+func (eventsTable) TableName() string { return "events" }
+
+func TestPostgres_JSONColumn(t *testing.T) {
+	id := query.Int64Column{Table: "events", Name: "id"}
+	payload := query.JSONColumn{Table: "events", Name: "payload"}
+
+	ast := query.From(eventsTable{}).
+		Select(id).
+		SelectExprAs(payload.Extract("$.user.plan").Expr(), "plan").
+		Where(query.And(
+			payload.Extract("$.kind").Eq(query.Param[string]("kind")),
+			payload.Contains(query.Param[string]("filter")),
+		)).
+		OrderBy(payload.Extract("$.tags[0]").Desc()).
+		Build()
+
+	sql, params, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := `SELECT "events"."id", ("events"."payload" #>> '{user,plan}') AS "plan" FROM "events" ` +
+		`WHERE ((("events"."payload" ->> 'kind') = $1) AND ("events"."payload" @> CAST($2 AS jsonb))) ` +
+		`ORDER BY ("events"."payload" #>> '{tags,0}') DESC`
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+	if strings.Join(params, ",") != "kind,filter" {
+		t.Errorf("expected params [kind filter], got %v", params)
+	}
+
+	ast = query.From(eventsTable{}).Select(id).Where(payload.Extract("$[2]").IsNotNull()).Build()
+	sql, _, err = NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !strings.HasSuffix(sql, `WHERE ("events"."payload" ->> 2) IS NOT NULL`) {
+		t.Errorf("unexpected SQL:\n%s", sql)
+	}
+}
+
+func TestPostgres_StartsWith(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.StartsWith(query.Param[string]("prefix")),
+	}
+
+	sql, params, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := `"users"."name" LIKE (REPLACE(REPLACE(REPLACE($1, '!', '!!'), '%', '!%'), '_', '!_') || '%') ESCAPE '!'`
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+	if len(params) != 1 || params[0] != "prefix" {
+		t.Errorf("params = %v, want [prefix]", params)
+	}
+}
+
+func TestPostgres_EndsWith(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.EndsWith(query.Param[string]("suffix")),
+	}
+
+	sql, params, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := `"users"."name" LIKE ('%' || REPLACE(REPLACE(REPLACE($1, '!', '!!'), '%', '!%'), '_', '!_')) ESCAPE '!'`
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+	if len(params) != 1 || params[0] != "suffix" {
+		t.Errorf("params = %v, want [suffix]", params)
+	}
+}
+
+func TestPostgres_Contains(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.Contains(query.Param[string]("q")),
+	}
+
+	sql, _, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	if !containsStr(sql, `"users"."name" LIKE ('%' || REPLACE(`) || !containsStr(sql, "'%') ESCAPE '!'") {
+		t.Errorf("SQL should wrap the escaped search string in %%: %s", sql)
+	}
+}
+
+func TestPostgres_IStartsWith(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.IStartsWith(query.Param[string]("prefix")),
+	}
+
+	sql, _, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := `"users"."name" ILIKE (REPLACE(REPLACE(REPLACE($1, '!', '!!'), '%', '!%'), '_', '!_') || '%') ESCAPE '!'`
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+}
+
+func TestPostgres_BulkInsert_SubqueryParamNumbering(t *testing.T) {
+	// Params inside each row's subquery are numbered in order with the
+	// row's other params, continuing across rows.
+	title := query.StringColumn{Table: "posts", Name: "title"}
+	categoryID := query.Int64Column{Table: "posts", Name: "category_id"}
+	row := func(i int) []query.Expr {
+		return []query.Expr{
+			query.ParamExpr{Name: fmt.Sprintf("title_%d", i), GoType: "string"},
+			query.SubqueryExpr{Query: &query.AST{
+				Kind:       query.SelectQuery,
+				FromTable:  query.TableRef{Name: "categories"},
+				SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: query.Int64Column{Table: "categories", Name: "id"}}}},
+				Where: query.BinaryExpr{
+					Left:  query.ColumnExpr{Column: query.StringColumn{Table: "categories", Name: "public_id"}},
+					Op:    query.OpEq,
+					Right: query.ParamExpr{Name: fmt.Sprintf("category_%d", i), GoType: "string"},
+				},
+			}},
+		}
+	}
+
+	ast := &query.AST{
+		Kind:       query.InsertQuery,
+		FromTable:  query.TableRef{Name: "posts"},
+		InsertCols: []query.Column{title, categoryID},
+		InsertRows: [][]query.Expr{row(0), row(1)},
+	}
+
+	sql, params, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	expected := `INSERT INTO "posts" ("title", "category_id") VALUES ` +
+		`($1, (SELECT "categories"."id" FROM "categories" WHERE ("categories"."public_id" = $2))), ` +
+		`($3, (SELECT "categories"."id" FROM "categories" WHERE ("categories"."public_id" = $4)))`
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+	wantParams := []string{"title_0", "category_0", "title_1", "category_1"}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("expected params %v, got %v", wantParams, params)
+	}
+}
+
+func TestPostgres_Upsert(t *testing.T) {
+	email := query.StringColumn{Table: "users", Name: "email"}
+	name := query.StringColumn{Table: "users", Name: "name"}
+	orgID := query.Int64Column{Table: "users", Name: "org_id"}
+
+	ast := &query.AST{
+		Kind:       query.InsertQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		InsertCols: []query.Column{email, name, orgID},
+		InsertRows: [][]query.Expr{{
+			query.ParamExpr{Name: "email", GoType: "string"},
+			query.ParamExpr{Name: "name", GoType: "string"},
+			query.ParamExpr{Name: "org_id", GoType: "int64"},
+		}},
+		OnConflict: &query.OnConflict{
+			Columns: []query.Column{email},
+			Updates: append(query.SetExcluded(name),
+				query.Assign(orgID, query.ParamExpr{Name: "new_org_id", GoType: "int64"})),
+			Where: orgID.Eq(query.Excluded(orgID)),
+		},
+	}
+
+	sql, params, err := NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	expected := `INSERT INTO "users" ("email", "name", "org_id") VALUES ($1, $2, $3) ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name", "org_id" = $4 WHERE ("users"."org_id" = EXCLUDED."org_id")`
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+	if len(params) != 4 || params[3] != "new_org_id" {
+		t.Errorf("expected new_org_id as 4th param, got %v", params)
+	}
+
+	ast.OnConflict = &query.OnConflict{Columns: []query.Column{email}, DoNothing: true}
+	sql, _, err = NewCompiler(Postgres).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !strings.HasSuffix(sql, `ON CONFLICT ("email") DO NOTHING`) {
+		t.Errorf("expected DO NOTHING clause, got:\n%s", sql)
+	}
+}
@@ -5,6 +5,7 @@ package compile
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -214,6 +215,65 @@ func TestSQLiteIntegration_InsertWithReturning(t *testing.T) {
 	}
 }
 
+func TestSQLiteIntegration_Upsert(t *testing.T) {
+	db := connectSQLite(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS test_upserts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			org_id INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		t.Fatalf("failed to create test table: %v", err)
+	}
+
+	email := query.StringColumn{Table: "test_upserts", Name: "email"}
+	name := query.StringColumn{Table: "test_upserts", Name: "name"}
+	orgID := query.Int64Column{Table: "test_upserts", Name: "org_id"}
+
+	ast := query.InsertInto(mockTable{name: "test_upserts"}).
+		Columns(email, name, orgID).
+		Values(query.Param[string]("email"), query.Param[string]("name"), query.Param[int64]("org_id")).
+		OnConflict(email).
+		DoUpdateWhere(orgID.Eq(query.Excluded(orgID)), query.SetExcluded(name)...).
+		Build()
+
+	sql, _, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	t.Logf("Compiled SQL: %s", sql)
+
+	for _, row := range []struct {
+		name  string
+		orgID int64
+	}{
+		{"Alice", 1},
+		{"Alice Smith", 1}, // same org: updates the name
+		{"Mallory", 2},     // other org: leaves the row alone
+	} {
+		if _, err := db.Exec(sql, "alice@example.com", row.name, row.orgID); err != nil {
+			t.Fatalf("upsert %q failed: %v", row.name, err)
+		}
+	}
+
+	var count int
+	var gotName string
+	if err := db.QueryRow("SELECT COUNT(*), MAX(name) FROM test_upserts").Scan(&count, &gotName); err != nil {
+		t.Fatalf("failed to read back: %v", err)
+	}
+	if count != 1 || gotName != "Alice Smith" {
+		t.Errorf("expected one row named %q, got %d rows, name %q", "Alice Smith", count, gotName)
+	}
+}
+
 func TestSQLiteIntegration_InsertWithDatetimeNow(t *testing.T) {
 	db := connectSQLite(t)
 	if db == nil {
@@ -724,6 +784,89 @@ func TestSQLiteIntegration_ILike(t *testing.T) {
 	}
 }
 
+func TestSQLiteIntegration_ContainsEscapesWildcards(t *testing.T) {
+	db := connectSQLite(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS test_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		t.Fatalf("failed to create test table: %v", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO test_codes (code) VALUES ('50% off'), ('500 off'), ('a_b'), ('axb'), ('wow!'), ('50%!'), ('Sale'), ('a*b?[c]')`)
+	if err != nil {
+		t.Fatalf("failed to insert test data: %v", err)
+	}
+
+	codeCol := query.StringColumn{Table: "test_codes", Name: "code"}
+	matches := func(expr query.Expr, arg string) []string {
+		t.Helper()
+		ast := query.From(mockTable{name: "test_codes"}).
+			Select(codeCol).
+			Where(expr).
+			OrderBy(codeCol.Asc()).
+			Build()
+		sql, _, err := NewCompiler(SQLite).Compile(ast)
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		rows, err := db.Query(sql, arg)
+		if err != nil {
+			t.Fatalf("failed to query %s: %v", sql, err)
+		}
+		defer rows.Close()
+		var codes []string
+		for rows.Next() {
+			var code string
+			if err := rows.Scan(&code); err != nil {
+				t.Fatalf("failed to scan: %v", err)
+			}
+			codes = append(codes, code)
+		}
+		return codes
+	}
+
+	param := query.Param[string]("q")
+	tests := []struct {
+		name string
+		expr query.Expr
+		arg  string
+		want string
+	}{
+		{"percent is literal", codeCol.Contains(param), "0%", "[50% off 50%!]"},
+		{"underscore is literal", codeCol.Contains(param), "_", "[a_b]"},
+		{"escape char is literal", codeCol.Contains(param), "!", "[50%! wow!]"},
+		{"prefix", codeCol.StartsWith(param), "50%", "[50% off 50%!]"},
+		{"prefix is anchored", codeCol.StartsWith(param), "off", "[]"},
+		{"suffix", codeCol.EndsWith(param), "%!", "[50%!]"},
+		{"suffix underscore is literal", codeCol.EndsWith(param), "_b", "[a_b]"},
+		{"suffix is anchored", codeCol.EndsWith(param), "50", "[]"},
+		{"case-sensitive", codeCol.Contains(param), "sale", "[]"},
+		{"star is literal", codeCol.Contains(param), "*", "[a*b?[c]]"},
+		{"question mark is literal", codeCol.StartsWith(param), "a*b?", "[a*b?[c]]"},
+		{"bracket is literal", codeCol.EndsWith(param), "[c]", "[a*b?[c]]"},
+		{"ignoring case", codeCol.IContains(param), "AL", "[Sale]"},
+		{"prefix ignoring case", codeCol.IStartsWith(param), "sA", "[Sale]"},
+		{"suffix ignoring case", codeCol.IEndsWith(param), "LE", "[Sale]"},
+		{"ignoring case escapes wildcards", codeCol.IContains(param), "_", "[a_b]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(matches(tt.expr, tt.arg)); got != tt.want {
+				t.Errorf("matches(%q) = %s, want %s", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSQLiteIntegration_ComplexQuery(t *testing.T) {
 	db := connectSQLite(t)
 	if db == nil {
@@ -1103,3 +1246,278 @@ func TestSQLiteIntegration_CTE(t *testing.T) {
 		t.Errorf("expected total = 300.00, got %.2f", total)
 	}
 }
+
+func TestSQLiteIntegration_RecursiveCTE(t *testing.T) {
+	db := connectSQLite(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	_, err := db.Exec(`
+		CREATE TABLE categories (id INTEGER PRIMARY KEY, parent_id INTEGER);
+		INSERT INTO categories (id, parent_id) VALUES (1, NULL), (2, 1), (3, 2), (4, 3), (5, NULL), (6, 5);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create test table: %v", err)
+	}
+
+	sqlStr, _, err := NewCompiler(SQLite).Compile(categoryTree())
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	rows, err := db.Query(sqlStr, 2, 10)
+	if err != nil {
+		t.Fatalf("query failed: %v\nSQL: %s", err, sqlStr)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if fmt.Sprint(ids) != "[2 3 4]" {
+		t.Errorf("expected category 2 and its descendants [2 3 4], got %v", ids)
+	}
+}
+
+func TestSQLiteIntegration_PointWithinRadius(t *testing.T) {
+	db := connectSQLite(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`CREATE TABLE stores (id INTEGER PRIMARY KEY, name TEXT NOT NULL, location TEXT NOT NULL)`,
+		`CREATE VIRTUAL TABLE stores_location_rtree USING rtree(id, min_lng, max_lng, min_lat, max_lat)`,
+		`INSERT INTO stores (id, name, location) VALUES
+			(1, 'San Francisco', 'POINT(-122.4194 37.7749)'),
+			(2, 'Oakland', 'POINT(-122.2712 37.8044)'),
+			(3, 'Los Angeles', 'POINT(-118.2437 34.0522)')`,
+		`INSERT INTO stores_location_rtree VALUES
+			(1, -122.4194, -122.4194, 37.7749, 37.7749),
+			(2, -122.2712, -122.2712, 37.8044, 37.8044),
+			(3, -118.2437, -118.2437, 34.0522, 34.0522)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed: %v\nSQL: %s", err, stmt)
+		}
+	}
+
+	name := query.StringColumn{Table: "stores", Name: "name"}
+	loc := query.PointColumn{Table: "stores", Name: "location"}
+	lat, lng := query.Param[float64]("lat"), query.Param[float64]("lng")
+
+	ast := query.From(mockTable{name: "stores"}).
+		Select(name).
+		SelectExprAs(loc.DistanceFrom(lat, lng), "distance").
+		Where(loc.WithinRadius(lat, lng, query.Param[float64]("meters"))).
+		OrderBy(query.OrderByExpr{Expr: loc.DistanceFrom(lat, lng)}).
+		Build()
+
+	sqlStr, params, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	values := map[string]any{"lat": 37.7749, "lng": -122.4194, "meters": 20000.0}
+	args := make([]any, len(params))
+	for i, p := range params {
+		args[i] = values[p]
+	}
+
+	rows, err := db.Query(sqlStr, args...)
+	if err != nil {
+		t.Fatalf("query failed: %v\nSQL: %s", err, sqlStr)
+	}
+	defer rows.Close()
+
+	var names []string
+	var distances []float64
+	for rows.Next() {
+		var n string
+		var d float64
+		if err := rows.Scan(&n, &d); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		names = append(names, n)
+		distances = append(distances, d)
+	}
+
+	if len(names) != 2 || names[0] != "San Francisco" || names[1] != "Oakland" {
+		t.Fatalf("expected [San Francisco Oakland], got %v", names)
+	}
+	if distances[0] > 1 || distances[1] < 13000 || distances[1] > 14000 {
+		t.Errorf("unexpected distances: %v", distances)
+	}
+}
+
+func TestSQLiteIntegration_PolygonContains(t *testing.T) {
+	db := connectSQLite(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`CREATE TABLE zones (id INTEGER PRIMARY KEY, name TEXT NOT NULL, area TEXT NOT NULL)`,
+		`CREATE VIRTUAL TABLE zones_area_rtree USING rtree(id, min_lng, max_lng, min_lat, max_lat)`,
+		// An L shape: its bounding box holds (37.85, -122.35) but it does not
+		`INSERT INTO zones (id, name, area) VALUES
+			(1, 'Square', 'POLYGON((-122.5 37.7, -122.3 37.7, -122.3 37.9, -122.5 37.9, -122.5 37.7))'),
+			(2, 'L', 'POLYGON((-122.5 37.7, -122.3 37.7, -122.3 37.8, -122.4 37.8, -122.4 37.9, -122.5 37.9, -122.5 37.7))'),
+			(3, 'Far', 'POLYGON((2 48, 3 48, 3 49, 2 48))')`,
+		`INSERT INTO zones_area_rtree VALUES
+			(1, -122.5, -122.3, 37.7, 37.9),
+			(2, -122.5, -122.3, 37.7, 37.9),
+			(3, 2, 3, 48, 49)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed: %v\nSQL: %s", err, stmt)
+		}
+	}
+
+	name := query.StringColumn{Table: "zones", Name: "name"}
+	area := query.PolygonColumn{Table: "zones", Name: "area"}
+	ast := query.From(mockTable{name: "zones"}).
+		Select(name).
+		Where(area.Contains(query.Param[float64]("lat"), query.Param[float64]("lng"))).
+		OrderBy(name.Desc()).
+		Build()
+
+	sqlStr, params, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	contains := func(lat, lng float64) []string {
+		t.Helper()
+		values := map[string]any{"lat": lat, "lng": lng}
+		args := make([]any, len(params))
+		for i, p := range params {
+			args[i] = values[p]
+		}
+		rows, err := db.Query(sqlStr, args...)
+		if err != nil {
+			t.Fatalf("query failed: %v\nSQL: %s", err, sqlStr)
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var n string
+			if err := rows.Scan(&n); err != nil {
+				t.Fatalf("scan failed: %v", err)
+			}
+			names = append(names, n)
+		}
+		return names
+	}
+
+	if got := contains(37.75, -122.45); fmt.Sprint(got) != "[Square L]" {
+		t.Errorf("inside both: got %v", got)
+	}
+	if got := contains(37.85, -122.35); fmt.Sprint(got) != "[Square]" {
+		t.Errorf("inside the square only: got %v", got)
+	}
+	if got := contains(40, -100); len(got) != 0 {
+		t.Errorf("outside all: got %v", got)
+	}
+}
+
+func TestSQLiteIntegration_JSONExtractAndContains(t *testing.T) {
+	db := connectSQLite(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`CREATE TABLE events (id INTEGER PRIMARY KEY, payload TEXT)`,
+		`INSERT INTO events (id, payload) VALUES
+			(1, '{"kind":"signup","user":{"plan":"pro"},"tags":["a","b"],"n":2,"ok":true}'),
+			(2, '{"kind":"login","user":{"plan":"free"},"tags":["b"],"n":2.5,"ok":false}'),
+			(3, '{"kind":null,"tags":[]}'),
+			(4, '[1,2,3]'),
+			(5, NULL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed: %v\nSQL: %s", err, stmt)
+		}
+	}
+
+	id := query.Int64Column{Table: "events", Name: "id"}
+	payload := query.NullJSONColumn{Table: "events", Name: "payload"}
+
+	ids := func(t *testing.T, where query.Expr, value any) []int64 {
+		t.Helper()
+		ast := query.From(mockTable{name: "events"}).Select(id).Where(where).OrderBy(id.Asc()).Build()
+		sqlStr, params, err := NewCompiler(SQLite).Compile(ast)
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		args := make([]any, len(params))
+		for i := range params {
+			args[i] = value
+		}
+		rows, err := db.Query(sqlStr, args...)
+		if err != nil {
+			t.Fatalf("query failed: %v\nSQL: %s", err, sqlStr)
+		}
+		defer rows.Close()
+		var got []int64
+		for rows.Next() {
+			var n int64
+			if err := rows.Scan(&n); err != nil {
+				t.Fatalf("scan failed: %v", err)
+			}
+			got = append(got, n)
+		}
+		return got
+	}
+
+	extractTests := []struct {
+		where query.Expr
+		want  string
+	}{
+		{payload.Extract("$.kind").Eq("signup"), "[1]"},
+		{payload.Extract("$.user.plan").In("pro", "free"), "[1 2]"},
+		{payload.Extract("$.tags[0]").Eq("b"), "[2]"},
+		{payload.Extract("$.n").Eq("2.5"), "[2]"},
+		{payload.Extract("$.ok").Eq("true"), "[1]"},
+		{payload.Extract("$.kind").IsNull(), "[3 4 5]"},
+		{payload.Extract("$[1]").Eq("2"), "[4]"},
+	}
+	for _, tt := range extractTests {
+		if got := fmt.Sprint(ids(t, tt.where, nil)); got != tt.want {
+			t.Errorf("%+v: got ids %s, want %s", tt.where, got, tt.want)
+		}
+	}
+
+	containsTests := []struct {
+		value string
+		want  string
+	}{
+		{`{"kind":"signup"}`, "[1]"},
+		{`{"tags":["b"]}`, "[1 2]"},
+		{`{"tags":["a","c"]}`, "[]"},
+		{`{"n":2}`, "[1]"},
+		{`{"n":2.0}`, "[1]"},
+		{`{"ok":1}`, "[]"},
+		{`{"kind":null}`, "[3]"},
+		{`{}`, "[1 2 3]"},
+		{`[3,1]`, "[4]"},
+		{`2`, "[4]"},
+		{`"signup"`, "[]"},
+	}
+	for _, tt := range containsTests {
+		if got := fmt.Sprint(ids(t, payload.Contains(query.Param[string]("value")), tt.value)); got != tt.want {
+			t.Errorf("Contains(%s): got ids %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
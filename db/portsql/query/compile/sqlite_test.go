@@ -1,6 +1,7 @@
 package compile
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/shipq/shipq/db/portsql/query"
@@ -1413,3 +1414,197 @@ func TestSQLite_JSONAgg_NoTimeColumns_NoStrftime(t *testing.T) {
 		t.Errorf("SQL should NOT contain strftime when no time columns: %s", sql)
 	}
 }
+
+func TestSQLite_PointColumn(t *testing.T) {
+	id := query.Int64Column{Table: "stores", Name: "id"}
+	loc := query.PointColumn{Table: "stores", Name: "location"}
+
+	t.Run("select and within radius", func(t *testing.T) {
+		ast := query.From(storesTable{}).
+			Select(id, loc).
+			Where(loc.WithinRadius(query.Param[float64]("lat"), query.Param[float64]("lng"), query.Param[float64]("meters"))).
+			Build()
+
+		sql, params, err := NewCompiler(SQLite).Compile(ast)
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		if !strings.HasPrefix(sql, `SELECT "stores"."id", "stores"."location" AS "location" FROM "stores" WHERE ("stores".rowid IN (SELECT id FROM "stores_location_rtree" WHERE max_lat >= ? - ? / 111320.0`) {
+			t.Errorf("unexpected SQL:\n%s", sql)
+		}
+		if !strings.Contains(sql, "6371008.8 * asin(") {
+			t.Errorf("expected haversine distance check, got:\n%s", sql)
+		}
+		if strings.Count(sql, "?") != len(params) {
+			t.Errorf("%d placeholders but %d params", strings.Count(sql, "?"), len(params))
+		}
+		if params[0] != "lat" || params[1] != "meters" {
+			t.Errorf("unexpected param order: %v", params)
+		}
+	})
+
+	t.Run("insert stores text unchanged", func(t *testing.T) {
+		ast := query.InsertInto(storesTable{}).
+			Columns(loc).
+			Values(query.Param[string]("location")).
+			Returning(loc).
+			Build()
+
+		sql, _, err := NewCompiler(SQLite).Compile(ast)
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+		expected := `INSERT INTO "stores" ("location") VALUES (?) RETURNING "location"`
+		if sql != expected {
+			t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+		}
+	})
+}
+
+func TestSQLite_PolygonColumn(t *testing.T) {
+	zone := query.PolygonColumn{Table: "stores", Name: "zone"}
+
+	ast := query.From(storesTable{}).
+		Select(zone).
+		Where(zone.Contains(query.Param[float64]("lat"), query.Param[float64]("lng"))).
+		Build()
+
+	sql, params, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	prefix := `SELECT "stores"."zone" AS "zone" FROM "stores" WHERE ("stores".rowid IN (SELECT id FROM "stores_zone_rtree" ` +
+		`WHERE min_lng <= ? AND max_lng >= ? AND min_lat <= ? AND max_lat >= ?) AND (SELECT count(*) % 2 FROM json_each(`
+	if !strings.HasPrefix(sql, prefix) {
+		t.Errorf("expected SQL to start with:\n%s\ngot:\n%s", prefix, sql)
+	}
+	if strings.Count(sql, "?") != len(params) {
+		t.Errorf("%d placeholders but %d params", strings.Count(sql, "?"), len(params))
+	}
+	if strings.Join(params[:4], ",") != "lng,lng,lat,lat" {
+		t.Errorf("unexpected param order: %v", params)
+	}
+}
+
+func TestSQLite_JSONColumn(t *testing.T) {
+	id := query.Int64Column{Table: "events", Name: "id"}
+	payload := query.JSONColumn{Table: "events", Name: "payload"}
+
+	ast := query.From(eventsTable{}).
+		Select(id).
+		Where(query.And(
+			payload.Extract("$.user.plan").Eq(query.Param[string]("plan")),
+			payload.Contains(query.Param[string]("filter")),
+		)).
+		Build()
+
+	sql, params, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !strings.HasPrefix(sql, `SELECT "events"."id" FROM "events" WHERE (((CASE json_type("events"."payload", '$.user.plan') `+
+		`WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(json_extract("events"."payload", '$.user.plan') AS TEXT) END) = ?) AND `) {
+		t.Errorf("unexpected SQL:\n%s", sql)
+	}
+	if !strings.Contains(sql, `FROM json_each(?) AS needle`) {
+		t.Errorf("expected json_each containment check, got:\n%s", sql)
+	}
+	if strings.Count(sql, "?") != len(params) || strings.Join(params, ",") != "plan,filter,filter" {
+		t.Errorf("%d placeholders, params %v", strings.Count(sql, "?"), params)
+	}
+}
+
+func TestSQLite_StartsWith(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.StartsWith(query.Param[string]("prefix")),
+	}
+
+	sql, params, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	// SQLite's LIKE ignores ASCII case, so the case-sensitive match is a GLOB
+	want := `"users"."name" GLOB (REPLACE(REPLACE(REPLACE(?, '[', '[[]'), '*', '[*]'), char(63), '[' || char(63) || ']') || '*')`
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+	if len(params) != 1 || params[0] != "prefix" {
+		t.Errorf("params = %v, want [prefix]", params)
+	}
+}
+
+func TestSQLite_Contains(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.Contains(query.Param[string]("q")),
+	}
+
+	sql, _, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	if !containsStr(sql, `"users"."name" GLOB ('*' || REPLACE(`) || !containsStr(sql, "|| '*')") {
+		t.Errorf("SQL should wrap the escaped search string in *: %s", sql)
+	}
+}
+
+func TestSQLite_IContains(t *testing.T) {
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.SelectQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		SelectCols: []query.SelectExpr{{Expr: query.ColumnExpr{Column: name}}},
+		Where:      name.IContains(query.Param[string]("q")),
+	}
+
+	sql, _, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	want := `LOWER("users"."name") LIKE LOWER('%' || REPLACE(REPLACE(REPLACE(?, '!', '!!'), '%', '!%'), '_', '!_') || '%') ESCAPE '!'`
+	if !containsStr(sql, want) {
+		t.Errorf("SQL should contain %s: %s", want, sql)
+	}
+}
+
+func TestSQLite_Upsert(t *testing.T) {
+	email := query.StringColumn{Table: "users", Name: "email"}
+	name := query.StringColumn{Table: "users", Name: "name"}
+
+	ast := &query.AST{
+		Kind:       query.InsertQuery,
+		FromTable:  query.TableRef{Name: "users"},
+		InsertCols: []query.Column{email, name},
+		InsertRows: [][]query.Expr{{
+			query.ParamExpr{Name: "email", GoType: "string"},
+			query.ParamExpr{Name: "name", GoType: "string"},
+		}},
+		OnConflict: &query.OnConflict{
+			Columns: []query.Column{email},
+			Updates: query.SetExcluded(name),
+		},
+		Returning: []query.Column{email},
+	}
+
+	sql, _, err := NewCompiler(SQLite).Compile(ast)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	expected := `INSERT INTO "users" ("email", "name") VALUES (?, ?) ON CONFLICT ("email") DO UPDATE SET "name" = excluded."name" RETURNING "email"`
+	if sql != expected {
+		t.Errorf("expected SQL:\n%s\ngot:\n%s", expected, sql)
+	}
+}
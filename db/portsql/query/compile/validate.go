@@ -3,6 +3,7 @@ package compile
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/shipq/shipq/db/portsql/query"
 )
@@ -111,6 +112,13 @@ func ValidateAST(ast *query.AST) error {
 		}
 	}
 
+	// Validate optimizer hints
+	for i, hint := range ast.Hints {
+		if err := validateHint(ast, hint); err != nil {
+			return fmt.Errorf("hint %d: %w", i, err)
+		}
+	}
+
 	// Validate CTEs
 	for i, cte := range ast.CTEs {
 		if err := ValidateIdentifier(cte.Name); err != nil {
@@ -122,6 +130,11 @@ func ValidateAST(ast *query.AST) error {
 				return fmt.Errorf("CTE %q column %d: %w", cte.Name, j, err)
 			}
 		}
+		if cte.Recursive {
+			if err := validateRecursiveCTE(cte); err != nil {
+				return err
+			}
+		}
 		// Recursively validate CTE query
 		if cte.Query != nil {
 			if err := ValidateAST(cte.Query); err != nil {
@@ -232,6 +245,54 @@ func validateSelect(ast *query.AST) error {
 	return nil
 }
 
+// validateRecursiveCTE checks the shape WithRecursive builds: a UNION ALL of
+// a base and a recursive query with no ordering or pagination of its own,
+// which SQL Server and MySQL reject in a recursive CTE.
+func validateRecursiveCTE(cte query.CTE) error {
+	q := cte.Query
+	if q == nil || q.SetOp == nil || q.SetOp.Op != query.SetOpUnionAll {
+		return fmt.Errorf("recursive CTE %q must be a UNION ALL of a base and a recursive query", cte.Name)
+	}
+	if len(q.OrderBy) > 0 || q.Limit != nil || q.Offset != nil {
+		return fmt.Errorf("recursive CTE %q cannot have ORDER BY, LIMIT or OFFSET", cte.Name)
+	}
+	return nil
+}
+
+// validateHint checks a single optimizer hint. Hint text is emitted verbatim
+// inside a comment, so it must not be able to close that comment. Hints are
+// validated regardless of their target dialect so that a bad hint fails on
+// every database, not only the one it targets.
+func validateHint(ast *query.AST, hint query.Hint) error {
+	if ast.Kind != query.SelectQuery {
+		return fmt.Errorf("optimizer hints are only supported on SELECT queries")
+	}
+	if hint.Dialect.String() == "unknown" {
+		return fmt.Errorf("unknown dialect %d", hint.Dialect)
+	}
+	switch hint.Kind {
+	case query.HintOptimizer:
+		if strings.TrimSpace(hint.Text) == "" {
+			return fmt.Errorf("optimizer hint cannot be empty")
+		}
+		if strings.Contains(hint.Text, "*/") || strings.Contains(hint.Text, "/*") {
+			return fmt.Errorf("optimizer hint %q cannot contain comment delimiters", hint.Text)
+		}
+	case query.HintUseIndex, query.HintForceIndex, query.HintIgnoreIndex:
+		if len(hint.Indexes) == 0 {
+			return fmt.Errorf("%s hint requires at least one index", hint.Kind)
+		}
+		for _, idx := range hint.Indexes {
+			if err := ValidateIdentifier(idx); err != nil {
+				return fmt.Errorf("%s hint: %w", hint.Kind, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown hint kind %q", hint.Kind)
+	}
+	return nil
+}
+
 func validateInsert(ast *query.AST) error {
 	hasRows := len(ast.InsertRows) > 0
 	hasSource := ast.InsertSource != nil
@@ -284,9 +345,42 @@ func validateInsert(ast *query.AST) error {
 		}
 	}
 
+	if ast.OnConflict != nil {
+		if err := validateOnConflict(ast); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// validateOnConflict validates the conflict clause of an upsert.
+func validateOnConflict(ast *query.AST) error {
+	oc := ast.OnConflict
+	if oc.DoNothing {
+		if len(oc.Updates) > 0 {
+			return fmt.Errorf("ON CONFLICT cannot both DO NOTHING and DO UPDATE")
+		}
+		// MySQL spells DO NOTHING as a no-op assignment of one of these.
+		if len(oc.Columns) == 0 && len(ast.InsertCols) == 0 {
+			return fmt.Errorf("ON CONFLICT DO NOTHING requires conflict columns or an INSERT column list")
+		}
+		return nil
+	}
+	if len(oc.Columns) == 0 {
+		return fmt.Errorf("ON CONFLICT DO UPDATE requires conflict columns")
+	}
+	if len(oc.Updates) == 0 {
+		return fmt.Errorf("ON CONFLICT DO UPDATE requires at least one assignment")
+	}
+	for i, set := range oc.Updates {
+		if err := validateExpr(set.Value, fmt.Sprintf("ON CONFLICT assignment %d", i)); err != nil {
+			return err
+		}
+	}
+	return validateExpr(oc.Where, "ON CONFLICT WHERE")
+}
+
 func validateUpdate(ast *query.AST) error {
 	// UPDATE must have at least one SET clause
 	if len(ast.SetClauses) == 0 {
@@ -743,3 +743,81 @@ func TestValidateInsert_SelectSource_ColumnValuesConsistency(t *testing.T) {
 		t.Errorf("expected no error, got: %v", err)
 	}
 }
+
+func TestValidateHints(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    query.QueryKind
+		hint    query.Hint
+		wantErr string
+	}{
+		{"valid optimizer hint", query.SelectQuery, query.Hint{Dialect: query.MySQL, Kind: query.HintOptimizer, Text: "BKA(posts)"}, ""},
+		{"comment terminator", query.SelectQuery, query.Hint{Dialect: query.Postgres, Kind: query.HintOptimizer, Text: "SeqScan(posts) */ DROP TABLE posts; /*"}, "comment delimiters"},
+		{"empty hint", query.SelectQuery, query.Hint{Dialect: query.Postgres, Kind: query.HintOptimizer, Text: " "}, "cannot be empty"},
+		{"no indexes", query.SelectQuery, query.Hint{Dialect: query.MySQL, Kind: query.HintUseIndex}, "at least one index"},
+		{"bad index name", query.SelectQuery, query.Hint{Dialect: query.MySQL, Kind: query.HintUseIndex, Indexes: []string{"idx; --"}}, "use_index hint"},
+		{"non-select", query.DeleteQuery, query.Hint{Dialect: query.MySQL, Kind: query.HintOptimizer, Text: "BKA(posts)"}, "only supported on SELECT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast := &query.AST{
+				Kind:      tt.kind,
+				FromTable: query.TableRef{Name: "posts"},
+				Hints:     []query.Hint{tt.hint},
+			}
+			err := ValidateAST(ast)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateInsert_OnConflict(t *testing.T) {
+	email := query.StringColumn{Table: "users", Name: "email"}
+	name := query.StringColumn{Table: "users", Name: "name"}
+	upsert := func(oc *query.OnConflict) *query.AST {
+		return &query.AST{
+			Kind:       query.InsertQuery,
+			FromTable:  query.TableRef{Name: "users"},
+			InsertCols: []query.Column{email, name},
+			InsertRows: [][]query.Expr{{
+				query.ParamExpr{Name: "email", GoType: "string"},
+				query.ParamExpr{Name: "name", GoType: "string"},
+			}},
+			OnConflict: oc,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		oc      *query.OnConflict
+		wantErr string
+	}{
+		{"do update", &query.OnConflict{Columns: []query.Column{email}, Updates: query.SetExcluded(name)}, ""},
+		{"do nothing without target", &query.OnConflict{DoNothing: true}, ""},
+		{"do update without target", &query.OnConflict{Updates: query.SetExcluded(name)}, "requires conflict columns"},
+		{"do update without assignments", &query.OnConflict{Columns: []query.Column{email}}, "at least one assignment"},
+		{"both", &query.OnConflict{Columns: []query.Column{email}, DoNothing: true, Updates: query.SetExcluded(name)}, "cannot both"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAST(upsert(tt.oc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -60,6 +60,7 @@ func WalkExpr(expr query.Expr, visit ExprVisitor) {
 		// - ColumnExpr
 		// - ParamExpr
 		// - LiteralExpr
+		// - ExcludedExpr
 	}
 }
 
@@ -109,6 +110,14 @@ func WalkAST(ast *query.AST, visit ExprVisitor) {
 		WalkAST(ast.InsertSource, visit)
 	}
 
+	// Walk upsert assignments (after the values, as compiled)
+	if ast.OnConflict != nil {
+		for _, set := range ast.OnConflict.Updates {
+			WalkExpr(set.Value, visit)
+		}
+		WalkExpr(ast.OnConflict.Where, visit)
+	}
+
 	// Walk SET clauses
 	for _, set := range ast.SetClauses {
 		WalkExpr(set.Value, visit)
@@ -52,6 +52,10 @@ const (
 	OpIn   BinaryOp = "IN"
 	OpAdd  BinaryOp = "+"
 	OpSub  BinaryOp = "-"
+
+	// OpIsNotDistinctFrom is NULL-safe equality: true when both sides are
+	// equal or both are NULL. Each dialect writes its own operator.
+	OpIsNotDistinctFrom BinaryOp = "IS NOT DISTINCT FROM"
 )
 
 // UnaryExpr represents a unary operation (op expr).
@@ -153,6 +157,15 @@ type ExistsExpr struct {
 
 func (ExistsExpr) exprNode() {}
 
+// ExcludedExpr is the value a conflicting INSERT proposed for a column,
+// usable in the assignments of an upsert: EXCLUDED.col on Postgres and
+// SQLite, VALUES(col) on MySQL.
+type ExcludedExpr struct {
+	Column Column
+}
+
+func (ExcludedExpr) exprNode() {}
+
 // Compile-time verification that all expression types implement Expr
 var (
 	_ Expr = ColumnExpr{}
@@ -166,4 +179,5 @@ var (
 	_ Expr = AggregateExpr{}
 	_ Expr = SubqueryExpr{}
 	_ Expr = ExistsExpr{}
+	_ Expr = ExcludedExpr{}
 )
@@ -736,3 +736,70 @@ func TestSub_WithLiteralOperand(t *testing.T) {
 		t.Errorf("expected literal value 1, got %v", right.Value)
 	}
 }
+
+func TestStringColumn_EscapedLike(t *testing.T) {
+	col := StringColumn{Table: "users", Name: "name"}
+	nullCol := NullStringColumn{Table: "users", Name: "bio"}
+
+	tests := []struct {
+		expr Expr
+		want string
+	}{
+		{col.Contains(Param[string]("q")), "LIKE_CONTAINS"},
+		{col.StartsWith(Param[string]("q")), "LIKE_PREFIX"},
+		{nullCol.Contains(Param[string]("q")), "LIKE_CONTAINS"},
+		{nullCol.StartsWith(Param[string]("q")), "LIKE_PREFIX"},
+		{col.EndsWith(Param[string]("q")), "LIKE_SUFFIX"},
+		{nullCol.EndsWith(Param[string]("q")), "LIKE_SUFFIX"},
+		{col.IContains(Param[string]("q")), "ILIKE_CONTAINS"},
+		{col.IStartsWith(Param[string]("q")), "ILIKE_PREFIX"},
+		{nullCol.IEndsWith(Param[string]("q")), "ILIKE_SUFFIX"},
+	}
+	for _, tt := range tests {
+		funcExpr, ok := tt.expr.(FuncExpr)
+		if !ok {
+			t.Fatalf("expected FuncExpr, got %T", tt.expr)
+		}
+		if funcExpr.Name != tt.want {
+			t.Errorf("expected Name = %q, got %q", tt.want, funcExpr.Name)
+		}
+		if len(funcExpr.Args) != 2 {
+			t.Fatalf("expected 2 args, got %d", len(funcExpr.Args))
+		}
+		if _, ok := funcExpr.Args[1].(ParamExpr); !ok {
+			t.Errorf("expected second arg to be ParamExpr, got %T", funcExpr.Args[1])
+		}
+	}
+}
+
+func TestJSONColumn_ExtractAndContains(t *testing.T) {
+	col := JSONColumn{Table: "events", Name: "payload"}
+
+	bin, ok := col.Extract("$.user.plan").Eq("pro").(BinaryExpr)
+	if !ok {
+		t.Fatal("expected BinaryExpr")
+	}
+	fn, ok := bin.Left.(FuncExpr)
+	if !ok || fn.Name != "JSON_EXTRACT_TEXT" || len(fn.Args) != 2 {
+		t.Fatalf("expected JSON_EXTRACT_TEXT(col, path), got %+v", bin.Left)
+	}
+	if path := fn.Args[1].(LiteralExpr).Value; path != "$.user.plan" {
+		t.Errorf("expected path $.user.plan, got %v", path)
+	}
+	if lit := bin.Right.(LiteralExpr).Value; lit != "pro" {
+		t.Errorf("expected right side 'pro', got %v", lit)
+	}
+
+	order := NullJSONColumn{Table: "events", Name: "payload"}.Extract("$.n").Desc()
+	if !order.Desc || order.Expr.(FuncExpr).Name != "JSON_EXTRACT_TEXT" {
+		t.Errorf("unexpected order by %+v", order)
+	}
+
+	contains, ok := col.Contains(Param[string]("filter")).(FuncExpr)
+	if !ok || contains.Name != "JSON_CONTAINS" || len(contains.Args) != 2 {
+		t.Fatalf("expected JSON_CONTAINS(col, value), got %+v", contains)
+	}
+	if p := contains.Args[1].(ParamExpr); p.Name != "filter" {
+		t.Errorf("expected param filter, got %+v", p)
+	}
+}
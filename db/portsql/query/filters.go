@@ -0,0 +1,89 @@
+package query
+
+import "fmt"
+
+// Filter is an optional condition of a paginated query. The generated
+// method has a pointer field for each filter param in its params type and
+// ANDs the condition into the query's WHERE clause only when the field is
+// set, so one query serves every combination of filters.
+type Filter struct {
+	Column Column
+	Op     BinaryOp
+	Param  string
+}
+
+// FilterEq filters on col = param.
+func FilterEq(col Column, param string) Filter {
+	return Filter{Column: col, Op: OpEq, Param: param}
+}
+
+// FilterGe filters on col >= param.
+func FilterGe(col Column, param string) Filter {
+	return Filter{Column: col, Op: OpGe, Param: param}
+}
+
+// FilterLt filters on col < param.
+func FilterLt(col Column, param string) Filter {
+	return Filter{Column: col, Op: OpLt, Param: param}
+}
+
+// filterOps are the operators a Filter may use.
+var filterOps = map[BinaryOp]bool{
+	OpEq: true, OpNe: true, OpLt: true, OpLe: true, OpGt: true, OpGe: true,
+}
+
+// MustDefineFilters adds optional filters to the paginated query name,
+// which must already be registered:
+//
+//	func init() {
+//	    query.MustDefinePaginated("ListPosts", ...)
+//	    query.MustDefineFilters("ListPosts",
+//	        query.FilterEq(schema.Posts.Status(), "status"),
+//	        query.FilterGe(schema.Posts.CreatedAt(), "created_after"),
+//	    )
+//	}
+//
+// The generated ListPosts takes params.Status (*string) and
+// params.CreatedAfter (*time.Time) and filters on those that are non-nil.
+//
+// MustDefineFilters panics if the query is not a registered paginated
+// query, or a filter has no column or param, an unsupported operator, or
+// the param of another filter. A param that is also a param of the query
+// itself is reported when the runner is generated.
+func MustDefineFilters(name string, filters ...Filter) {
+	if err := defineFilters(name, filters); err != nil {
+		panic(err.Error())
+	}
+}
+
+func defineFilters(name string, filters []Filter) error {
+	v, ok := registry.Load(name)
+	if !ok {
+		return fmt.Errorf("filters for unknown query %q", name)
+	}
+	rq := v.(RegisteredQuery)
+	if rq.ReturnType != ReturnPaginated {
+		return fmt.Errorf("filters for query %q: only paginated queries can be filtered", name)
+	}
+
+	used := make(map[string]bool)
+	for _, f := range rq.Filters {
+		used[f.Param] = true
+	}
+	for _, f := range filters {
+		if f.Column == nil || f.Param == "" {
+			return fmt.Errorf("filters for query %q: a filter needs a column and a param", name)
+		}
+		if !filterOps[f.Op] {
+			return fmt.Errorf("filters for query %q: unsupported operator %q", name, f.Op)
+		}
+		if used[f.Param] {
+			return fmt.Errorf("filters for query %q: param %q is already used", name, f.Param)
+		}
+		used[f.Param] = true
+	}
+
+	rq.Filters = append(rq.Filters, filters...)
+	registry.Store(name, rq)
+	return nil
+}
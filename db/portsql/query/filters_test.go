@@ -0,0 +1,81 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMustDefineFilters(t *testing.T) {
+	ClearRegistry()
+
+	posts := mockTable{name: "posts"}
+	status := StringColumn{Table: "posts", Name: "status"}
+	createdAt := TimeColumn{Table: "posts", Name: "created_at"}
+	MustDefinePaginated("ListPosts", From(posts).Select(status).Build(), createdAt.Desc())
+	MustDefineFilters("ListPosts",
+		FilterEq(status, "status"),
+		FilterGe(createdAt, "created_after"),
+	)
+	MustDefineFilters("ListPosts", FilterLt(createdAt, "created_before"))
+
+	filters := GetRegisteredQueries()["ListPosts"].Filters
+	if len(filters) != 3 {
+		t.Fatalf("expected 3 filters, got %d", len(filters))
+	}
+	if filters[1].Op != OpGe || filters[1].Param != "created_after" {
+		t.Errorf("unexpected filter %+v", filters[1])
+	}
+
+	data, err := SerializeQueries()
+	if err != nil {
+		t.Fatalf("SerializeQueries failed: %v", err)
+	}
+	var serialized []SerializedQuery
+	if err := json.Unmarshal(data, &serialized); err != nil {
+		t.Fatalf("failed to decode serialized queries: %v", err)
+	}
+	want := []SerializedFilter{
+		{Column: SerializedColumn{Table: "posts", Name: "status", GoType: "string"}, Op: "=", Param: "status"},
+		{Column: SerializedColumn{Table: "posts", Name: "created_at", GoType: "time.Time"}, Op: ">=", Param: "created_after"},
+		{Column: SerializedColumn{Table: "posts", Name: "created_at", GoType: "time.Time"}, Op: "<", Param: "created_before"},
+	}
+	if len(serialized) != 1 || !reflect.DeepEqual(serialized[0].Filters, want) {
+		t.Errorf("serialized queries = %s, want filters %+v", data, want)
+	}
+}
+
+func TestMustDefineFilters_Errors(t *testing.T) {
+	ClearRegistry()
+
+	posts := mockTable{name: "posts"}
+	status := StringColumn{Table: "posts", Name: "status"}
+	createdAt := TimeColumn{Table: "posts", Name: "created_at"}
+	MustDefinePaginated("ListPosts", From(posts).Select(status).Build(), createdAt.Desc())
+	MustDefineMany("AllPosts", From(posts).Select(status).Build())
+
+	tests := []struct {
+		name    string
+		query   string
+		filters []Filter
+		wantErr string
+	}{
+		{"unknown query", "ListComments", []Filter{FilterEq(status, "status")}, "unknown query"},
+		{"not paginated", "AllPosts", []Filter{FilterEq(status, "status")}, "only paginated"},
+		{"no param", "ListPosts", []Filter{FilterEq(status, "")}, "needs a column and a param"},
+		{"bad operator", "ListPosts", []Filter{{Column: status, Op: OpLike, Param: "q"}}, "unsupported operator"},
+		{"duplicate param", "ListPosts", []Filter{FilterEq(status, "s"), FilterGe(createdAt, "s")}, "already used"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := defineFilters(tt.query, tt.filters)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+	if n := len(GetRegisteredQueries()["ListPosts"].Filters); n != 0 {
+		t.Errorf("failed definitions should not add filters, got %d", n)
+	}
+}
@@ -2,6 +2,9 @@ package query
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,6 +39,127 @@ func Coalesce(args ...Expr) FuncExpr {
 	return FuncExpr{Name: "COALESCE", Args: args}
 }
 
+// Assign returns the assignment col = value, for the DoUpdate clause of an
+// upsert.
+func Assign(col Column, value Expr) SetClause {
+	return SetClause{Column: col, Value: value}
+}
+
+// Excluded returns the value a conflicting INSERT proposed for col.
+func Excluded(col Column) ExcludedExpr {
+	return ExcludedExpr{Column: col}
+}
+
+// SetExcluded returns assignments overwriting each of cols with the value
+// the conflicting INSERT proposed, the usual upsert update:
+//
+//	OnConflict(schema.Users.Email()).DoUpdate(query.SetExcluded(schema.Users.Name())...)
+func SetExcluded(cols ...Column) []SetClause {
+	sets := make([]SetClause, len(cols))
+	for i, col := range cols {
+		sets[i] = Assign(col, Excluded(col))
+	}
+	return sets
+}
+
+// PointWKT formats a latitude/longitude pair as the WKT text point columns
+// hold. WKT puts longitude first.
+func PointWKT(lat, lng float64) string {
+	return "POINT(" + strconv.FormatFloat(lng, 'f', -1, 64) + " " + strconv.FormatFloat(lat, 'f', -1, 64) + ")"
+}
+
+// ParsePointWKT parses a WKT "POINT(lng lat)" value, as read from a point
+// column, and validates the coordinate ranges.
+func ParsePointWKT(wkt string) (lat, lng float64, err error) {
+	s := strings.TrimSpace(wkt)
+	upper := strings.ToUpper(s)
+	if !strings.HasPrefix(upper, "POINT") || !strings.HasSuffix(s, ")") {
+		return 0, 0, fmt.Errorf("invalid point %q: want POINT(lng lat)", wkt)
+	}
+	body := strings.TrimSpace(s[len("POINT") : len(s)-1])
+	if !strings.HasPrefix(body, "(") {
+		return 0, 0, fmt.Errorf("invalid point %q: want POINT(lng lat)", wkt)
+	}
+	fields := strings.Fields(body[1:])
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("invalid point %q: want POINT(lng lat)", wkt)
+	}
+	if lng, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid point %q: bad longitude", wkt)
+	}
+	if lat, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid point %q: bad latitude", wkt)
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, fmt.Errorf("invalid point %q: coordinates out of range", wkt)
+	}
+	return lat, lng, nil
+}
+
+// NormalizePolygonWKT parses a single-ring WKT "POLYGON((lng lat, ...))"
+// value, validates it, and returns it in the canonical form polygon columns
+// hold: no extra spaces, and the ring counter-clockwise, the orientation SQL
+// Server reads as the area inside it. The ring must be closed and have at
+// least three distinct corners; polygons with holes are not supported.
+func NormalizePolygonWKT(wkt string) (string, error) {
+	s := strings.TrimSpace(wkt)
+	upper := strings.ToUpper(s)
+	if !strings.HasPrefix(upper, "POLYGON") || !strings.HasSuffix(s, ")") {
+		return "", fmt.Errorf("invalid polygon %q: want POLYGON((lng lat, ...))", wkt)
+	}
+	body := strings.TrimSpace(s[len("POLYGON") : len(s)-1])
+	if !strings.HasPrefix(body, "(") {
+		return "", fmt.Errorf("invalid polygon %q: want POLYGON((lng lat, ...))", wkt)
+	}
+	body = strings.TrimSpace(body[1:])
+	if !strings.HasPrefix(body, "(") || !strings.HasSuffix(body, ")") || strings.ContainsAny(body[1:len(body)-1], "()") {
+		return "", fmt.Errorf("invalid polygon %q: want a single ring", wkt)
+	}
+
+	var ring [][2]float64
+	for _, vertex := range strings.Split(body[1:len(body)-1], ",") {
+		fields := strings.Fields(vertex)
+		if len(fields) != 2 {
+			return "", fmt.Errorf("invalid polygon %q: want lng lat pairs", wkt)
+		}
+		lng, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid polygon %q: bad longitude", wkt)
+		}
+		lat, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid polygon %q: bad latitude", wkt)
+		}
+		if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			return "", fmt.Errorf("invalid polygon %q: coordinates out of range", wkt)
+		}
+		ring = append(ring, [2]float64{lng, lat})
+	}
+	if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+		return "", fmt.Errorf("invalid polygon %q: the ring must be closed and have at least 3 corners", wkt)
+	}
+
+	// Shoelace formula: a negative signed area means clockwise
+	var area float64
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	if area == 0 {
+		return "", fmt.Errorf("invalid polygon %q: the ring encloses no area", wkt)
+	}
+	if area < 0 {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+
+	vertices := make([]string, len(ring))
+	for i, v := range ring {
+		vertices[i] = strconv.FormatFloat(v[0], 'f', -1, 64) + " " + strconv.FormatFloat(v[1], 'f', -1, 64)
+	}
+	return "POLYGON((" + strings.Join(vertices, ", ") + "))", nil
+}
+
 // And combines expressions with AND.
 // Returns nil if no expressions are provided.
 // Returns the single expression if only one is provided.
@@ -235,3 +235,53 @@ func TestCoalesce_InSetClause(t *testing.T) {
 		t.Errorf("expected 2 args in COALESCE, got %d", len(funcExpr.Args))
 	}
 }
+
+func TestPointWKT(t *testing.T) {
+	wkt := PointWKT(37.7749, -122.4194)
+	if wkt != "POINT(-122.4194 37.7749)" {
+		t.Errorf("PointWKT = %q", wkt)
+	}
+
+	lat, lng, err := ParsePointWKT(" point (-122.4194  37.7749) ")
+	if err != nil {
+		t.Fatalf("ParsePointWKT failed: %v", err)
+	}
+	if lat != 37.7749 || lng != -122.4194 {
+		t.Errorf("ParsePointWKT = (%v, %v)", lat, lng)
+	}
+
+	for _, bad := range []string{"", "POINT(1)", "POINT(1 2 3)", "LINESTRING(1 2, 3 4)", "POINT(a b)", "POINT(10 95)", "POINT(181 0)"} {
+		if _, _, err := ParsePointWKT(bad); err == nil {
+			t.Errorf("ParsePointWKT(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestNormalizePolygonWKT(t *testing.T) {
+	ccw := "POLYGON((0 0, 1 0, 1 1, 0 0))"
+	for _, in := range []string{ccw, " polygon ( (0 0,1 0 , 1  1,0 0) ) ", "POLYGON((0 0, 1 1, 1 0, 0 0))"} {
+		got, err := NormalizePolygonWKT(in)
+		if err != nil {
+			t.Fatalf("NormalizePolygonWKT(%q) failed: %v", in, err)
+		}
+		if got != ccw {
+			t.Errorf("NormalizePolygonWKT(%q) = %q, want %q", in, got, ccw)
+		}
+	}
+
+	for _, bad := range []string{
+		"",
+		"POINT(1 2)",
+		"POLYGON(0 0, 1 0, 1 1, 0 0)",
+		"POLYGON((0 0, 1 0, 1 1))",
+		"POLYGON((0 0, 1 0, 0 0))",
+		"POLYGON((0 0, 1 1, 2 2, 0 0))",
+		"POLYGON((0 0, 4 0, 4 4, 0 0), (1 1, 2 1, 2 2, 1 1))",
+		"POLYGON((0 0, 1 0, 1 95, 0 0))",
+		"POLYGON((0 0, 1 0, a 1, 0 0))",
+	} {
+		if _, err := NormalizePolygonWKT(bad); err == nil {
+			t.Errorf("NormalizePolygonWKT(%q) succeeded, want error", bad)
+		}
+	}
+}
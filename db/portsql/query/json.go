@@ -22,6 +22,7 @@ type ASTJson struct {
 	OrderBy    []OrderByExprJson `json:"order_by,omitempty"`
 	Limit      *ExprJson         `json:"limit,omitempty"`
 	Offset     *ExprJson         `json:"offset,omitempty"`
+	ForUpdate  bool              `json:"for_update,omitempty"`
 
 	InsertCols []ColumnJson  `json:"insert_cols,omitempty"`
 	InsertRows [][]*ExprJson `json:"insert_rows,omitempty"`
@@ -56,8 +57,9 @@ type OrderByExprJson struct {
 
 // SetClauseJson is the JSON-serializable form of SetClause.
 type SetClauseJson struct {
-	Column *ColumnJson `json:"column"`
-	Value  *ExprJson   `json:"value"`
+	Column   *ColumnJson `json:"column"`
+	Value    *ExprJson   `json:"value"`
+	Optional bool        `json:"optional,omitempty"`
 }
 
 // SetOperationJson is the JSON-serializable form of SetOperation.
@@ -148,6 +150,7 @@ func (ast *AST) ToJSON() (*ASTJson, error) {
 	j := &ASTJson{
 		Kind:      ast.Kind,
 		Distinct:  ast.Distinct,
+		ForUpdate: ast.ForUpdate,
 		FromTable: ast.FromTable,
 		Params:    ast.Params,
 	}
@@ -261,8 +264,9 @@ func (ast *AST) ToJSON() (*ASTJson, error) {
 			return nil, err
 		}
 		j.SetClauses = append(j.SetClauses, SetClauseJson{
-			Column: &colJson,
-			Value:  valJson,
+			Column:   &colJson,
+			Value:    valJson,
+			Optional: set.Optional,
 		})
 	}
 
@@ -483,6 +487,7 @@ func (j *ASTJson) FromJSON() (*AST, error) {
 	ast := &AST{
 		Kind:      j.Kind,
 		Distinct:  j.Distinct,
+		ForUpdate: j.ForUpdate,
 		FromTable: j.FromTable,
 		Params:    j.Params,
 	}
@@ -595,8 +600,9 @@ func (j *ASTJson) FromJSON() (*AST, error) {
 			return nil, err
 		}
 		ast.SetClauses = append(ast.SetClauses, SetClause{
-			Column: set.Column.ToColumn(),
-			Value:  val,
+			Column:   set.Column.ToColumn(),
+			Value:    val,
+			Optional: set.Optional,
 		})
 	}
 
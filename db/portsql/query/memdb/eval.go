@@ -0,0 +1,460 @@
+package memdb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// scope binds the rows a expression is evaluated against: one row per
+// table in the FROM clause (nil for the unmatched side of a LEFT JOIN),
+// the enclosing query's scope for correlated subqueries, and, for a
+// grouped query, the rows of the group aggregates run over.
+type scope struct {
+	names  []string       // table aliases or names, in FROM order
+	rows   map[string]Row // row of each table
+	parent *scope
+	group  []*scope
+}
+
+func (s *scope) bind(name string, row Row) *scope {
+	rows := make(map[string]Row, len(s.rows)+1)
+	for k, v := range s.rows {
+		rows[k] = v
+	}
+	rows[name] = row
+	return &scope{names: append(s.names[:len(s.names):len(s.names)], name), rows: rows, parent: s.parent}
+}
+
+// column looks a column up in the scope, then in the enclosing scopes.
+func (s *scope) column(table, name string) (any, error) {
+	for sc := s; sc != nil; sc = sc.parent {
+		if table != "" {
+			if row, ok := sc.rows[table]; ok {
+				return row[name], nil
+			}
+			continue
+		}
+		for _, t := range sc.names {
+			if row := sc.rows[t]; row != nil {
+				if v, ok := row[name]; ok {
+					return v, nil
+				}
+			}
+		}
+	}
+	if table != "" {
+		return nil, fmt.Errorf("memdb: unknown table %q for column %q", table, name)
+	}
+	return nil, nil
+}
+
+// evaluator evaluates the expressions of one query.
+type evaluator struct {
+	db   *DB
+	args map[string]any
+}
+
+func (ev *evaluator) eval(e query.Expr, s *scope) (any, error) {
+	switch x := e.(type) {
+	case nil:
+		return nil, nil
+	case query.ColumnExpr:
+		return s.column(x.Column.TableName(), x.Column.ColumnName())
+	case query.ParamExpr:
+		v, ok := ev.args[x.Name]
+		if !ok {
+			return nil, fmt.Errorf("memdb: missing value for param %q", x.Name)
+		}
+		return normalize(v), nil
+	case query.LiteralExpr:
+		return normalize(x.Value), nil
+	case query.BinaryExpr:
+		return ev.evalBinary(x, s)
+	case query.UnaryExpr:
+		v, err := ev.eval(x.Expr, s)
+		if err != nil {
+			return nil, err
+		}
+		switch x.Op {
+		case query.OpIsNull:
+			return v == nil, nil
+		case query.OpNotNull:
+			return v != nil, nil
+		case query.OpNot:
+			if v == nil {
+				return nil, nil
+			}
+			return !truthy(v), nil
+		}
+		return nil, unsupported("unary operator %s", x.Op)
+	case query.FuncExpr:
+		return ev.evalFunc(x, s)
+	case query.AggregateExpr:
+		return ev.evalAggregate(x, s)
+	case query.SubqueryExpr:
+		rows, err := ev.db.selectRows(x.Query, ev.args, s, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 || len(rows[0]) == 0 {
+			return nil, nil
+		}
+		if len(rows) > 1 {
+			return nil, fmt.Errorf("memdb: scalar subquery returned %d rows", len(rows))
+		}
+		return rows[0][0], nil
+	case query.ExistsExpr:
+		rows, err := ev.db.selectRows(x.Subquery, ev.args, s, nil)
+		if err != nil {
+			return nil, err
+		}
+		return (len(rows) > 0) != x.Negated, nil
+	case query.ExcludedExpr:
+		return s.column(excludedTable, x.Column.ColumnName())
+	}
+	return nil, unsupported("expression %T", e)
+}
+
+// excludedTable binds the proposed row while an upsert's updates run.
+const excludedTable = "\x00excluded"
+
+func (ev *evaluator) evalBinary(x query.BinaryExpr, s *scope) (any, error) {
+	switch x.Op {
+	case query.OpAnd, query.OpOr:
+		l, err := ev.eval(x.Left, s)
+		if err != nil {
+			return nil, err
+		}
+		if x.Op == query.OpAnd && l != nil && !truthy(l) {
+			return false, nil
+		}
+		if x.Op == query.OpOr && truthy(l) {
+			return true, nil
+		}
+		r, err := ev.eval(x.Right, s)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case x.Op == query.OpAnd && r != nil && !truthy(r):
+			return false, nil
+		case x.Op == query.OpOr && truthy(r):
+			return true, nil
+		case l == nil || r == nil:
+			return nil, nil
+		}
+		return x.Op == query.OpAnd, nil
+	case query.OpIn:
+		return ev.evalIn(x, s)
+	}
+
+	l, err := ev.eval(x.Left, s)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ev.eval(x.Right, s)
+	if err != nil {
+		return nil, err
+	}
+	if x.Op == query.OpIsNotDistinctFrom {
+		if l == nil || r == nil {
+			return l == nil && r == nil, nil
+		}
+		c, err := compare(l, r)
+		return c == 0, err
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+
+	switch x.Op {
+	case query.OpEq, query.OpNe, query.OpLt, query.OpLe, query.OpGt, query.OpGe:
+		c, err := compare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch x.Op {
+		case query.OpEq:
+			return c == 0, nil
+		case query.OpNe:
+			return c != 0, nil
+		case query.OpLt:
+			return c < 0, nil
+		case query.OpLe:
+			return c <= 0, nil
+		case query.OpGt:
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case query.OpLike:
+		return like(l, r, false)
+	case query.OpAdd, query.OpSub:
+		return arith(l, r, x.Op)
+	}
+	return nil, unsupported("binary operator %s", x.Op)
+}
+
+// evalIn evaluates x IN (list) and x IN (subquery).
+func (ev *evaluator) evalIn(x query.BinaryExpr, s *scope) (any, error) {
+	l, err := ev.eval(x.Left, s)
+	if err != nil {
+		return nil, err
+	}
+	var values []any
+	switch r := x.Right.(type) {
+	case query.ListExpr:
+		for _, e := range r.Values {
+			v, err := ev.eval(e, s)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+	case query.SubqueryExpr:
+		rows, err := ev.db.selectRows(r.Query, ev.args, s, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			values = append(values, row[0])
+		}
+	default:
+		// A param bound to a slice, e.g. In(query.Param[[]int64]("ids")).
+		v, err := ev.eval(r, s)
+		if err != nil {
+			return nil, err
+		}
+		values = spread(ev.args, r, v)
+	}
+	if l == nil {
+		return nil, nil
+	}
+	sawNull := false
+	for _, v := range values {
+		if v == nil {
+			sawNull = true
+			continue
+		}
+		if c, err := compare(l, v); err == nil && c == 0 {
+			return true, nil
+		}
+	}
+	if sawNull {
+		return nil, nil
+	}
+	return false, nil
+}
+
+// spread returns the elements of a slice param, or v alone.
+func spread(args map[string]any, e query.Expr, v any) []any {
+	p, ok := e.(query.ParamExpr)
+	if !ok {
+		return []any{v}
+	}
+	raw := args[p.Name]
+	rv := reflectSlice(raw)
+	if rv == nil {
+		return []any{v}
+	}
+	return rv
+}
+
+func (ev *evaluator) evalFunc(f query.FuncExpr, s *scope) (any, error) {
+	args := make([]any, len(f.Args))
+	for i, a := range f.Args {
+		v, err := ev.eval(a, s)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch f.Name {
+	case "NOW":
+		return ev.db.Now(), nil
+	case "COALESCE":
+		for _, v := range args {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	case "LOWER", "UPPER":
+		if len(args) != 1 || args[0] == nil {
+			return nil, nil
+		}
+		str, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("memdb: %s of %T", f.Name, args[0])
+		}
+		if f.Name == "LOWER" {
+			return strings.ToLower(str), nil
+		}
+		return strings.ToUpper(str), nil
+	case "ILIKE":
+		if len(args) != 2 || args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		return like(args[0], args[1], true)
+	case "LIKE_CONTAINS", "LIKE_PREFIX", "LIKE_SUFFIX", "ILIKE_CONTAINS", "ILIKE_PREFIX", "ILIKE_SUFFIX":
+		if len(args) != 2 || args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		// Byte comparison, like the binary column collations; the ILIKE_
+		// forms fold ASCII letters only, the folding every dialect shares.
+		str, sub := fmt.Sprint(args[0]), fmt.Sprint(args[1])
+		if strings.HasPrefix(f.Name, "ILIKE_") {
+			str, sub = asciiLower(str), asciiLower(sub)
+		}
+		switch {
+		case strings.HasSuffix(f.Name, "_PREFIX"):
+			return strings.HasPrefix(str, sub), nil
+		case strings.HasSuffix(f.Name, "_SUFFIX"):
+			return strings.HasSuffix(str, sub), nil
+		}
+		return strings.Contains(str, sub), nil
+	}
+	return nil, unsupported("function %s", f.Name)
+}
+
+func (ev *evaluator) evalAggregate(a query.AggregateExpr, s *scope) (any, error) {
+	if s.group == nil {
+		return nil, fmt.Errorf("memdb: aggregate %s outside a grouped query", a.Func)
+	}
+	var values []any
+	seen := map[string]bool{}
+	for _, member := range s.group {
+		if a.Arg == nil {
+			values = append(values, int64(1))
+			continue
+		}
+		v, err := ev.eval(a.Arg, member)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		if a.Distinct {
+			key := fmt.Sprintf("%T:%v", v, v)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		values = append(values, v)
+	}
+
+	switch a.Func {
+	case query.AggCount:
+		return int64(len(values)), nil
+	case query.AggMin, query.AggMax:
+		var best any
+		for _, v := range values {
+			if best == nil {
+				best = v
+				continue
+			}
+			c, err := compare(v, best)
+			if err != nil {
+				return nil, err
+			}
+			if (a.Func == query.AggMin && c < 0) || (a.Func == query.AggMax && c > 0) {
+				best = v
+			}
+		}
+		return best, nil
+	case query.AggSum, query.AggAvg:
+		if len(values) == 0 {
+			return nil, nil
+		}
+		var sum any = int64(0)
+		for _, v := range values {
+			next, err := arith(sum, v, query.OpAdd)
+			if err != nil {
+				return nil, err
+			}
+			sum = next
+		}
+		if a.Func == query.AggSum {
+			return sum, nil
+		}
+		f, _ := toFloat(sum)
+		return f / float64(len(values)), nil
+	}
+	return nil, unsupported("aggregate %s", a.Func)
+}
+
+// like matches value against a LIKE pattern, where % matches any run of
+// characters and _ any one character.
+func like(value, pattern any, fold bool) (any, error) {
+	str, ok := value.(string)
+	pat, ok2 := pattern.(string)
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("memdb: LIKE of %T and %T", value, pattern)
+	}
+	var re strings.Builder
+	if fold {
+		re.WriteString("(?i)")
+	}
+	re.WriteString("(?s)^")
+	for _, r := range pat {
+		switch r {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String()).MatchString(str), nil
+}
+
+// asciiLower lowercases the ASCII letters of s and leaves other runes alone.
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// arith adds or subtracts two numbers.
+func arith(l, r any, op query.BinaryOp) (any, error) {
+	if li, ok := l.(int64); ok {
+		if ri, ok := r.(int64); ok {
+			if op == query.OpSub {
+				return li - ri, nil
+			}
+			return li + ri, nil
+		}
+	}
+	lf, ok := toFloat(l)
+	rf, ok2 := toFloat(r)
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("memdb: %s of %T and %T", op, l, r)
+	}
+	if op == query.OpSub {
+		return lf - rf, nil
+	}
+	return lf + rf, nil
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func unsupported(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUnsupported, fmt.Sprintf(format, args...))
+}
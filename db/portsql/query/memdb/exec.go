@@ -0,0 +1,783 @@
+package memdb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+// Result is the outcome of DB.Exec. It implements database/sql.Result.
+type Result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+// LastInsertId returns the auto-increment value of the last inserted row.
+func (r Result) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+
+// RowsAffected returns the number of rows inserted, updated or deleted.
+func (r Result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// Page is one page of a keyset-paginated SELECT, as the generated runner
+// fetches it: the query's rows ordered by the cursor columns after its own
+// ORDER BY, starting after the cursor.
+type Page struct {
+	// Cursor lists the cursor columns; each is descending unless its
+	// Ascending is set.
+	Cursor []query.SerializedColumn
+	// After holds the cursor column values of the last row of the
+	// previous page, in Cursor order. Nil fetches the first page.
+	After []any
+	// Limit caps the number of rows; 0 means no cap.
+	Limit int
+}
+
+// Query runs a SELECT, or an INSERT, UPDATE or DELETE with RETURNING, and
+// returns its rows. Each row holds the values of the select list (or the
+// RETURNING columns) in order.
+func (db *DB) Query(ast *query.AST, args map[string]any) ([][]any, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if ast.Kind == query.SelectQuery {
+		return db.selectRows(ast, args, nil, nil)
+	}
+	rows, _, err := db.write(ast, args)
+	return rows, err
+}
+
+// QueryPage runs a SELECT for one page of results.
+func (db *DB) QueryPage(ast *query.AST, args map[string]any, page Page) ([][]any, error) {
+	if ast.Kind != query.SelectQuery {
+		return nil, fmt.Errorf("memdb: QueryPage needs a SELECT, got %s", ast.Kind)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.selectRows(ast, args, nil, &page)
+}
+
+// Exec runs an INSERT, UPDATE or DELETE and reports its effect.
+func (db *DB) Exec(ast *query.AST, args map[string]any) (Result, error) {
+	if ast.Kind == query.SelectQuery {
+		return Result{}, fmt.Errorf("memdb: Exec needs an INSERT, UPDATE or DELETE, got a SELECT")
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	_, res, err := db.write(ast, args)
+	return res, err
+}
+
+// ExecBatch runs ast once per element of rows, all under one lock, the
+// way a bulk insert of those rows runs as one statement. It stops at the
+// first error; the rows already written stay written.
+func (db *DB) ExecBatch(ast *query.AST, rows []map[string]any) (Result, error) {
+	if ast.Kind == query.SelectQuery {
+		return Result{}, fmt.Errorf("memdb: ExecBatch needs an INSERT, UPDATE or DELETE, got a SELECT")
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var total Result
+	for _, args := range rows {
+		_, res, err := db.write(ast, args)
+		if err != nil {
+			return total, err
+		}
+		total.rowsAffected += res.rowsAffected
+		if res.lastInsertID != 0 {
+			total.lastInsertID = res.lastInsertID
+		}
+	}
+	return total, nil
+}
+
+// And returns a copy of ast with conds ANDed into its WHERE clause.
+func And(ast *query.AST, conds ...query.Expr) *query.AST {
+	c := *ast
+	for _, cond := range conds {
+		if c.Where == nil {
+			c.Where = cond
+		} else {
+			c.Where = query.BinaryExpr{Left: c.Where, Op: query.OpAnd, Right: cond}
+		}
+	}
+	return &c
+}
+
+// Filter returns the condition of an optional filter of a paginated query.
+func Filter(f query.SerializedFilter) query.Expr {
+	return query.BinaryExpr{
+		Left: query.ColumnExpr{Column: query.SimpleColumn{
+			Table_: f.Column.Table, Name_: f.Column.Name, GoType_: f.Column.GoType,
+		}},
+		Op:    query.BinaryOp(f.Op),
+		Right: query.ParamExpr{Name: f.Param, GoType: f.Column.GoType},
+	}
+}
+
+func (db *DB) write(ast *query.AST, args map[string]any) ([][]any, Result, error) {
+	if len(ast.CTEs) > 0 {
+		return nil, Result{}, unsupported("WITH in %s", ast.Kind)
+	}
+	switch ast.Kind {
+	case query.InsertQuery:
+		return db.insert(ast, args)
+	case query.UpdateQuery:
+		return db.update(ast, args)
+	case query.DeleteQuery:
+		return db.delete(ast, args)
+	}
+	return nil, Result{}, unsupported("query kind %q", ast.Kind)
+}
+
+// ---------- SELECT ----------
+
+// selectRows evaluates a SELECT in the enclosing scope parent (nil at the
+// top level), fetching one page of it when page is set.
+func (db *DB) selectRows(ast *query.AST, args map[string]any, parent *scope, page *Page) ([][]any, error) {
+	if ast.SetOp != nil {
+		return nil, unsupported("set operation %s", ast.SetOp.Op)
+	}
+	if len(ast.CTEs) > 0 {
+		return nil, unsupported("WITH")
+	}
+	if len(ast.SelectCols) == 0 {
+		return nil, unsupported("SELECT without a select list")
+	}
+	ev := &evaluator{db: db, args: args}
+
+	scopes, err := db.source(ast, ev, parent)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err = filterScopes(scopes, func(s *scope) (bool, error) {
+		if ast.Where == nil {
+			return true, nil
+		}
+		v, err := ev.eval(ast.Where, s)
+		return truthy(v), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if page != nil && page.After != nil {
+		scopes, err = filterScopes(scopes, func(s *scope) (bool, error) {
+			return afterCursor(s, page)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ast.GroupBy) > 0 || ast.Having != nil || selectHasAggregate(ast.SelectCols) {
+		if scopes, err = group(ast, ev, scopes, parent); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([][]any, len(scopes))
+	for i, s := range scopes {
+		row := make([]any, len(ast.SelectCols))
+		for j, col := range ast.SelectCols {
+			if _, ok := col.Expr.(query.JSONAggExpr); ok {
+				return nil, unsupported("JSON aggregate")
+			}
+			if row[j], err = ev.eval(col.Expr, s); err != nil {
+				return nil, err
+			}
+		}
+		rows[i] = row
+	}
+
+	order := ast.OrderBy
+	if page != nil {
+		for _, col := range page.Cursor {
+			order = append(order[:len(order):len(order)], query.OrderByExpr{
+				Expr: query.ColumnExpr{Column: query.SimpleColumn{Table_: col.Table, Name_: col.Name}},
+				Desc: !col.Ascending,
+			})
+		}
+	}
+	if err := sortRows(ast, ev, order, scopes, rows); err != nil {
+		return nil, err
+	}
+
+	if ast.Distinct {
+		rows = distinct(rows)
+	}
+
+	offset, err := ev.intValue(ast.Offset)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := ev.intValue(ast.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if page != nil && page.Limit > 0 && (limit < 0 || int64(page.Limit) < limit) {
+		limit = int64(page.Limit)
+	}
+	if offset > 0 {
+		rows = rows[min(int(offset), len(rows)):]
+	}
+	if limit >= 0 && int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// source returns a scope for each row of the FROM clause and its joins.
+func (db *DB) source(ast *query.AST, ev *evaluator, parent *scope) ([]*scope, error) {
+	root := &scope{rows: map[string]Row{}, parent: parent}
+	if ast.FromTable.Name == "" {
+		return []*scope{root}, nil
+	}
+	rows, err := db.tableRows(ast.FromTable.Name)
+	if err != nil {
+		return nil, err
+	}
+	name := tableRefName(ast.FromTable)
+	scopes := make([]*scope, len(rows))
+	for i, r := range rows {
+		scopes[i] = root.bind(name, r)
+	}
+
+	for _, join := range ast.Joins {
+		if join.Type != query.InnerJoin && join.Type != query.LeftJoin {
+			return nil, unsupported("%s JOIN", join.Type)
+		}
+		joinRows, err := db.tableRows(join.Table.Name)
+		if err != nil {
+			return nil, err
+		}
+		joinName := tableRefName(join.Table)
+		var next []*scope
+		for _, s := range scopes {
+			matched := false
+			for _, r := range joinRows {
+				candidate := s.bind(joinName, r)
+				v, err := ev.eval(join.Condition, candidate)
+				if err != nil {
+					return nil, err
+				}
+				if join.Condition == nil || truthy(v) {
+					next = append(next, candidate)
+					matched = true
+				}
+			}
+			if !matched && join.Type == query.LeftJoin {
+				next = append(next, s.bind(joinName, nil))
+			}
+		}
+		scopes = next
+	}
+	return scopes, nil
+}
+
+func (db *DB) tableRows(name string) ([]Row, error) {
+	t, ok := db.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("memdb: no such table %q", name)
+	}
+	return t.rows, nil
+}
+
+func tableRefName(ref query.TableRef) string {
+	if ref.Alias != "" {
+		return ref.Alias
+	}
+	return ref.Name
+}
+
+func filterScopes(scopes []*scope, keep func(*scope) (bool, error)) ([]*scope, error) {
+	var out []*scope
+	for _, s := range scopes {
+		ok, err := keep(s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// afterCursor reports whether the row of s comes after the page cursor in
+// the order of the cursor columns.
+func afterCursor(s *scope, page *Page) (bool, error) {
+	if len(page.After) != len(page.Cursor) {
+		return false, fmt.Errorf("memdb: cursor has %d values for %d columns", len(page.After), len(page.Cursor))
+	}
+	for i, col := range page.Cursor {
+		v, err := s.column(col.Table, col.Name)
+		if err != nil {
+			return false, err
+		}
+		after := normalize(page.After[i])
+		if v == nil || after == nil {
+			return false, nil
+		}
+		c, err := compare(v, after)
+		if err != nil {
+			return false, err
+		}
+		if c == 0 {
+			continue
+		}
+		return (c > 0) == col.Ascending, nil
+	}
+	return false, nil
+}
+
+// group collapses scopes into one scope per group. A query with aggregates
+// but no GROUP BY is one group, even over no rows.
+func group(ast *query.AST, ev *evaluator, scopes []*scope, parent *scope) ([]*scope, error) {
+	var groups []*scope
+	index := map[string]*scope{}
+	for _, s := range scopes {
+		var key string
+		for _, col := range ast.GroupBy {
+			v, err := s.column(col.TableName(), col.ColumnName())
+			if err != nil {
+				return nil, err
+			}
+			key += fmt.Sprintf("%T:%v\x00", v, v)
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &scope{names: s.names, rows: s.rows, parent: s.parent, group: []*scope{}}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.group = append(g.group, s)
+	}
+	if len(groups) == 0 && len(ast.GroupBy) == 0 {
+		groups = []*scope{{rows: map[string]Row{}, parent: parent, group: []*scope{}}}
+	}
+	if ast.Having == nil {
+		return groups, nil
+	}
+	return filterScopes(groups, func(g *scope) (bool, error) {
+		v, err := ev.eval(ast.Having, g)
+		return truthy(v), err
+	})
+}
+
+func selectHasAggregate(cols []query.SelectExpr) bool {
+	for _, col := range cols {
+		if hasAggregate(col.Expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAggregate reports whether e contains an aggregate outside subqueries.
+func hasAggregate(e query.Expr) bool {
+	switch x := e.(type) {
+	case query.AggregateExpr:
+		return true
+	case query.BinaryExpr:
+		return hasAggregate(x.Left) || hasAggregate(x.Right)
+	case query.UnaryExpr:
+		return hasAggregate(x.Expr)
+	case query.FuncExpr:
+		for _, a := range x.Args {
+			if hasAggregate(a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortRows orders scopes and their projected rows together. An ORDER BY
+// column without a table may name a select list alias. NULLs sort first.
+func sortRows(ast *query.AST, ev *evaluator, order []query.OrderByExpr, scopes []*scope, rows [][]any) error {
+	if len(order) == 0 {
+		return nil
+	}
+	aliases := map[string]int{}
+	for i, col := range ast.SelectCols {
+		if col.Alias != "" {
+			aliases[col.Alias] = i
+		}
+	}
+	keys := make([][]any, len(scopes))
+	for i, s := range scopes {
+		keys[i] = make([]any, len(order))
+		for j, o := range order {
+			if c, ok := o.Expr.(query.ColumnExpr); ok && c.Column.TableName() == "" {
+				if at, ok := aliases[c.Column.ColumnName()]; ok {
+					keys[i][j] = rows[i][at]
+					continue
+				}
+			}
+			v, err := ev.eval(o.Expr, s)
+			if err != nil {
+				return err
+			}
+			keys[i][j] = v
+		}
+	}
+
+	idx := make([]int, len(rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	var sortErr error
+	sort.SliceStable(idx, func(a, b int) bool {
+		for j, o := range order {
+			x, y := keys[idx[a]][j], keys[idx[b]][j]
+			var c int
+			switch {
+			case x == nil && y == nil:
+				continue
+			case x == nil:
+				c = -1
+			case y == nil:
+				c = 1
+			default:
+				var err error
+				if c, err = compare(x, y); err != nil {
+					sortErr = err
+					return false
+				}
+			}
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != o.Desc
+		}
+		return false
+	})
+	if sortErr != nil {
+		return sortErr
+	}
+
+	sortedScopes := make([]*scope, len(scopes))
+	sortedRows := make([][]any, len(rows))
+	for i, at := range idx {
+		sortedScopes[i], sortedRows[i] = scopes[at], rows[at]
+	}
+	copy(scopes, sortedScopes)
+	copy(rows, sortedRows)
+	return nil
+}
+
+func distinct(rows [][]any) [][]any {
+	seen := map[string]bool{}
+	var out [][]any
+	for _, row := range rows {
+		key := fmt.Sprintf("%#v", row)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, row)
+	}
+	return out
+}
+
+// intValue evaluates a LIMIT or OFFSET; nil is -1.
+func (ev *evaluator) intValue(e query.Expr) (int64, error) {
+	if e == nil {
+		return -1, nil
+	}
+	v, err := ev.eval(e, &scope{rows: map[string]Row{}})
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("memdb: LIMIT/OFFSET of %T", v)
+	}
+	return n, nil
+}
+
+// ---------- INSERT ----------
+
+func (db *DB) insert(ast *query.AST, args map[string]any) ([][]any, Result, error) {
+	ev := &evaluator{db: db, args: args}
+	name := ast.FromTable.Name
+	empty := &scope{rows: map[string]Row{}}
+
+	var values [][]any
+	switch {
+	case ast.InsertSource != nil:
+		rows, err := db.selectRows(ast.InsertSource, args, nil, nil)
+		if err != nil {
+			return nil, Result{}, err
+		}
+		values = rows
+	default:
+		for _, exprs := range ast.InsertRows {
+			row := make([]any, len(exprs))
+			for i, e := range exprs {
+				v, err := ev.eval(e, empty)
+				if err != nil {
+					return nil, Result{}, err
+				}
+				row[i] = v
+			}
+			values = append(values, row)
+		}
+	}
+
+	var (
+		res      Result
+		returned [][]any
+	)
+	for _, vals := range values {
+		if len(vals) != len(ast.InsertCols) {
+			return nil, Result{}, fmt.Errorf("memdb: INSERT of %d values into %d columns", len(vals), len(ast.InsertCols))
+		}
+		row := db.newRow(name)
+		for i, col := range ast.InsertCols {
+			row[col.ColumnName()] = vals[i]
+		}
+
+		if ast.OnConflict != nil {
+			existing, err := db.conflicting(name, ast.OnConflict, row)
+			if err != nil {
+				return nil, Result{}, err
+			}
+			if existing != nil {
+				changed, err := db.upsert(ev, name, ast.OnConflict, existing, row)
+				if err != nil {
+					return nil, Result{}, err
+				}
+				if !changed {
+					continue
+				}
+				res.rowsAffected++
+				if len(ast.Returning) > 0 {
+					returned = append(returned, returning(ast.Returning, existing))
+				}
+				continue
+			}
+		}
+
+		if id := db.store(name, row); id != 0 {
+			res.lastInsertID = id
+		}
+		res.rowsAffected++
+		if len(ast.Returning) > 0 {
+			returned = append(returned, returning(ast.Returning, row))
+		}
+	}
+	return returned, res, nil
+}
+
+// conflicting returns the stored row of table whose conflict target
+// columns equal those of row, or nil.
+func (db *DB) conflicting(table string, oc *query.OnConflict, row Row) (Row, error) {
+	if len(oc.Columns) == 0 {
+		return nil, unsupported("ON CONFLICT without a conflict target")
+	}
+	for _, existing := range db.table(table).rows {
+		match := true
+		for _, col := range oc.Columns {
+			a, b := existing[col.ColumnName()], row[col.ColumnName()]
+			if a == nil || b == nil {
+				match = false
+				break
+			}
+			if c, err := compare(a, b); err != nil || c != 0 {
+				match = false
+				break
+			}
+		}
+		if match {
+			return existing, nil
+		}
+	}
+	return nil, nil
+}
+
+// upsert applies the DO UPDATE clause of oc to existing, with proposed as
+// the excluded row, and reports whether it changed the row.
+func (db *DB) upsert(ev *evaluator, table string, oc *query.OnConflict, existing, proposed Row) (bool, error) {
+	if oc.DoNothing || len(oc.Updates) == 0 {
+		return false, nil
+	}
+	s := (&scope{rows: map[string]Row{}}).bind(table, existing).bind(excludedTable, proposed)
+	if oc.Where != nil {
+		v, err := ev.eval(oc.Where, s)
+		if err != nil {
+			return false, err
+		}
+		if !truthy(v) {
+			return false, nil
+		}
+	}
+	return true, applySets(ev, oc.Updates, existing, s)
+}
+
+// ---------- UPDATE and DELETE ----------
+
+func (db *DB) update(ast *query.AST, args map[string]any) ([][]any, Result, error) {
+	if len(ast.Joins) > 0 {
+		return nil, Result{}, unsupported("UPDATE with joins")
+	}
+	ev := &evaluator{db: db, args: args}
+	matches, err := db.matching(ast, ev)
+	if err != nil {
+		return nil, Result{}, err
+	}
+	var returned [][]any
+	for _, m := range matches {
+		if err := applySets(ev, ast.SetClauses, m.row, m.scope); err != nil {
+			return nil, Result{}, err
+		}
+		if len(ast.Returning) > 0 {
+			returned = append(returned, returning(ast.Returning, m.row))
+		}
+	}
+	return returned, Result{rowsAffected: int64(len(matches))}, nil
+}
+
+func (db *DB) delete(ast *query.AST, args map[string]any) ([][]any, Result, error) {
+	if len(ast.Joins) > 0 {
+		return nil, Result{}, unsupported("DELETE with joins")
+	}
+	ev := &evaluator{db: db, args: args}
+	matches, err := db.matching(ast, ev)
+	if err != nil {
+		return nil, Result{}, err
+	}
+	deleted := make(map[int]bool, len(matches))
+	var returned [][]any
+	for _, m := range matches {
+		deleted[m.index] = true
+		if len(ast.Returning) > 0 {
+			returned = append(returned, returning(ast.Returning, m.row))
+		}
+	}
+	t := db.table(ast.FromTable.Name)
+	kept := make([]Row, 0, len(t.rows)-len(matches))
+	for i, r := range t.rows {
+		if !deleted[i] {
+			kept = append(kept, r)
+		}
+	}
+	t.rows = kept
+	return returned, Result{rowsAffected: int64(len(matches))}, nil
+}
+
+// match is a stored row a statement's WHERE selects.
+type match struct {
+	index int
+	row   Row
+	scope *scope
+}
+
+// matching returns the rows of the statement's table its WHERE selects.
+func (db *DB) matching(ast *query.AST, ev *evaluator) ([]match, error) {
+	rows, err := db.tableRows(ast.FromTable.Name)
+	if err != nil {
+		return nil, err
+	}
+	name := tableRefName(ast.FromTable)
+	root := &scope{rows: map[string]Row{}}
+	var matches []match
+	for i, r := range rows {
+		s := root.bind(name, r)
+		if ast.Where != nil {
+			v, err := ev.eval(ast.Where, s)
+			if err != nil {
+				return nil, err
+			}
+			if !truthy(v) {
+				continue
+			}
+		}
+		matches = append(matches, match{index: i, row: r, scope: s})
+	}
+	return matches, nil
+}
+
+// applySets evaluates every SET clause against the row as it was, then
+// writes them. An optional clause whose params are not all in the args is
+// skipped.
+func applySets(ev *evaluator, sets []query.SetClause, row Row, s *scope) error {
+	values := make(map[string]any, len(sets))
+	for _, set := range sets {
+		if set.Optional && !ev.hasParams(set.Value) {
+			continue
+		}
+		v, err := ev.eval(set.Value, s)
+		if err != nil {
+			return err
+		}
+		values[set.Column.ColumnName()] = v
+	}
+	for col, v := range values {
+		row[col] = v
+	}
+	return nil
+}
+
+// hasParams reports whether every param e references has a value.
+func (ev *evaluator) hasParams(e query.Expr) bool {
+	ok := true
+	walkParams(e, func(name string) {
+		if _, has := ev.args[name]; !has {
+			ok = false
+		}
+	})
+	return ok
+}
+
+// walkParams calls fn with the name of every param in e, including those
+// of subqueries.
+func walkParams(e query.Expr, fn func(string)) {
+	switch x := e.(type) {
+	case query.ParamExpr:
+		fn(x.Name)
+	case query.BinaryExpr:
+		walkParams(x.Left, fn)
+		walkParams(x.Right, fn)
+	case query.UnaryExpr:
+		walkParams(x.Expr, fn)
+	case query.FuncExpr:
+		for _, a := range x.Args {
+			walkParams(a, fn)
+		}
+	case query.ListExpr:
+		for _, v := range x.Values {
+			walkParams(v, fn)
+		}
+	case query.AggregateExpr:
+		walkParams(x.Arg, fn)
+	case query.SubqueryExpr:
+		walkASTParams(x.Query, fn)
+	case query.ExistsExpr:
+		walkASTParams(x.Subquery, fn)
+	}
+}
+
+func walkASTParams(ast *query.AST, fn func(string)) {
+	if ast == nil {
+		return
+	}
+	for _, col := range ast.SelectCols {
+		walkParams(col.Expr, fn)
+	}
+	for _, j := range ast.Joins {
+		walkParams(j.Condition, fn)
+	}
+	walkParams(ast.Where, fn)
+	walkParams(ast.Having, fn)
+	walkParams(ast.Limit, fn)
+	walkParams(ast.Offset, fn)
+}
+
+// returning projects the RETURNING columns of a written row.
+func returning(cols []query.Column, row Row) []any {
+	out := make([]any, len(cols))
+	for i, col := range cols {
+		out[i] = row[col.ColumnName()]
+	}
+	return out
+}
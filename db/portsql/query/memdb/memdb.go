@@ -0,0 +1,220 @@
+// Package memdb evaluates PortSQL query ASTs against in-memory tables.
+//
+// It backs the fake query runner generated in shipq/queries/fake, so
+// handler unit tests can run their CRUD queries without a database. It
+// understands the subset of the DSL the generated CRUD queries and most
+// hand-written ones use:
+//
+//   - SELECT from a table with INNER and LEFT joins, WHERE, GROUP BY,
+//     HAVING, ORDER BY, LIMIT and OFFSET, DISTINCT, COUNT/SUM/AVG/MIN/MAX,
+//     scalar and EXISTS subqueries
+//   - INSERT of VALUES rows or a SELECT, with ON CONFLICT and RETURNING
+//   - UPDATE, including optional SET clauses, and DELETE, with RETURNING
+//   - comparisons, AND/OR/NOT, IS [NOT] NULL, IN, LIKE, ILIKE, Contains,
+//     StartsWith, COALESCE and NOW()
+//
+// Anything else (set operations, CTEs, JSON aggregates, spatial and JSON
+// functions) returns an error wrapping ErrUnsupported. Constraints are not
+// enforced: a duplicate unique key or a dangling reference is stored as is.
+//
+//	db := memdb.New(memdb.Table{Name: "posts", Columns: []memdb.Column{
+//	    {Name: "id", AutoIncrement: true},
+//	    {Name: "created_at", DefaultNow: true},
+//	}})
+//	res, err := db.Exec(insertAST, map[string]any{"title": "Hello"})
+//	rows, err := db.Query(selectAST, map[string]any{"publicId": "abc"})
+package memdb
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnsupported is wrapped by the errors of queries memdb cannot evaluate.
+var ErrUnsupported = errors.New("memdb: unsupported query")
+
+// Row is a stored row, keyed by column name. Values are normalized: ints
+// are int64, floats float64 and pointers are dereferenced; nil is NULL.
+type Row map[string]any
+
+// Table declares the columns of a table that get a value when an INSERT
+// leaves them out. Tables need not be declared; an undeclared table is
+// created empty on first use and has no defaults.
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Column is a column of a declared Table.
+type Column struct {
+	Name string
+	// AutoIncrement numbers the column 1, 2, ... when an INSERT leaves it
+	// out or sets it to NULL. Its last value is the LastInsertId.
+	AutoIncrement bool
+	// DefaultNow sets the column to DB.Now() when an INSERT leaves it out.
+	DefaultNow bool
+	// Default is the value of the column when an INSERT leaves it out.
+	Default any
+}
+
+// DB is a set of in-memory tables. It is safe for concurrent use; each
+// query runs under one lock, so it sees and leaves a consistent state.
+type DB struct {
+	mu     sync.Mutex
+	defs   map[string]Table
+	tables map[string]*table
+
+	// Now returns the value of NOW() and of DefaultNow columns. It
+	// defaults to time.Now in UTC; tests can pin it.
+	Now func() time.Time
+}
+
+type table struct {
+	rows   []Row
+	lastID int64
+}
+
+// New returns an empty DB with the given tables declared.
+func New(tables ...Table) *DB {
+	db := &DB{
+		defs:   make(map[string]Table, len(tables)),
+		tables: make(map[string]*table, len(tables)),
+		Now:    func() time.Time { return time.Now().UTC() },
+	}
+	for _, t := range tables {
+		db.defs[t.Name] = t
+		db.tables[t.Name] = &table{}
+	}
+	return db
+}
+
+// table returns the rows of name, creating the table if needed.
+func (db *DB) table(name string) *table {
+	t, ok := db.tables[name]
+	if !ok {
+		t = &table{}
+		db.tables[name] = t
+	}
+	return t
+}
+
+// Insert adds row to table as is, applying the table's defaults to the
+// columns it leaves out, and returns the stored row. It is meant for
+// seeding test data.
+func (db *DB) Insert(table string, row Row) Row {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	stored := db.newRow(table)
+	for col, v := range row {
+		stored[col] = normalize(v)
+	}
+	db.store(table, stored)
+	return cloneRow(stored)
+}
+
+// Rows returns a copy of the rows of table, in insertion order.
+func (db *DB) Rows(table string) []Row {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.tables[table]
+	if !ok {
+		return nil
+	}
+	rows := make([]Row, len(t.rows))
+	for i, r := range t.rows {
+		rows[i] = cloneRow(r)
+	}
+	return rows
+}
+
+// Tables returns the names of the tables, sorted.
+func (db *DB) Tables() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	names := make([]string, 0, len(db.tables))
+	for name := range db.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot is the state of a DB at one point, restored by DB.Restore.
+type Snapshot struct {
+	tables map[string]*table
+}
+
+// Snapshot captures the current state of every table.
+func (db *DB) Snapshot() *Snapshot {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return &Snapshot{tables: cloneTables(db.tables)}
+}
+
+// Restore puts the tables back as they were when s was taken, the way a
+// rolled back transaction leaves them.
+func (db *DB) Restore(s *Snapshot) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tables = cloneTables(s.tables)
+}
+
+// newRow returns a row holding the defaults of table, with AutoIncrement
+// columns left NULL until store numbers them.
+func (db *DB) newRow(table string) Row {
+	row := Row{}
+	for _, col := range db.defs[table].Columns {
+		switch {
+		case col.AutoIncrement:
+			row[col.Name] = nil
+		case col.DefaultNow:
+			row[col.Name] = db.Now()
+		default:
+			row[col.Name] = normalize(col.Default)
+		}
+	}
+	return row
+}
+
+// store numbers the AutoIncrement columns of row that are NULL, appends it
+// to table and returns the row's auto-increment value (0 if it has none).
+func (db *DB) store(table string, row Row) int64 {
+	t := db.table(table)
+	var id int64
+	for _, col := range db.defs[table].Columns {
+		if !col.AutoIncrement {
+			continue
+		}
+		if v, ok := row[col.Name].(int64); ok {
+			t.lastID = max(t.lastID, v)
+		} else {
+			t.lastID++
+			row[col.Name] = t.lastID
+		}
+		id = row[col.Name].(int64)
+	}
+	t.rows = append(t.rows, row)
+	return id
+}
+
+func cloneRow(r Row) Row {
+	c := make(Row, len(r))
+	for k, v := range r {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneTables(tables map[string]*table) map[string]*table {
+	c := make(map[string]*table, len(tables))
+	for name, t := range tables {
+		rows := make([]Row, len(t.rows))
+		for i, r := range t.rows {
+			rows[i] = cloneRow(r)
+		}
+		c[name] = &table{rows: rows, lastID: t.lastID}
+	}
+	return c
+}
@@ -0,0 +1,353 @@
+package memdb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/db/portsql/query"
+)
+
+type tableName string
+
+func (t tableName) TableName() string { return string(t) }
+
+var (
+	posts     = tableName("posts")
+	authors   = tableName("authors")
+	id        = query.Int64Column{Table: "posts", Name: "id"}
+	publicID  = query.StringColumn{Table: "posts", Name: "public_id"}
+	title     = query.StringColumn{Table: "posts", Name: "title"}
+	status    = query.StringColumn{Table: "posts", Name: "status"}
+	authorID  = query.NullInt64Column{Table: "posts", Name: "author_id"}
+	createdAt = query.TimeColumn{Table: "posts", Name: "created_at"}
+	deletedAt = query.NullTimeColumn{Table: "posts", Name: "deleted_at"}
+	authorPK  = query.Int64Column{Table: "authors", Name: "id"}
+	name      = query.StringColumn{Table: "authors", Name: "name"}
+)
+
+var t0 = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestDB() *DB {
+	db := New(
+		Table{Name: "posts", Columns: []Column{
+			{Name: "id", AutoIncrement: true},
+			{Name: "status", Default: "draft"},
+			{Name: "created_at", DefaultNow: true},
+			{Name: "deleted_at"},
+		}},
+		Table{Name: "authors", Columns: []Column{{Name: "id", AutoIncrement: true}}},
+	)
+	db.Now = func() time.Time { return t0 }
+	return db
+}
+
+func TestCRUD(t *testing.T) {
+	db := newTestDB()
+
+	create := query.InsertInto(posts).
+		Columns(publicID, title).
+		Values(query.Param[string]("publicId"), query.Param[string]("title")).
+		Returning(id, publicID).
+		Build()
+	rows, err := db.Query(create, map[string]any{"publicId": "p1", "title": "Hello"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if want := [][]any{{int64(1), "p1"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("create returned %v, want %v", rows, want)
+	}
+	res, err := db.Exec(create, map[string]any{"publicId": "p2", "title": "World"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if id, _ := res.LastInsertId(); id != 2 {
+		t.Errorf("LastInsertId() = %d, want 2", id)
+	}
+
+	get := query.From(posts).
+		Select(title, status, createdAt).
+		Where(query.And(publicID.Eq(query.Param[string]("publicId")), deletedAt.IsNull())).
+		Build()
+	rows, err = db.Query(get, map[string]any{"publicId": "p1"})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if want := [][]any{{"Hello", "draft", t0}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("get = %v, want %v", rows, want)
+	}
+
+	update := query.Update(posts).
+		SetOptional(title, query.Param[string]("title")).
+		SetOptional(status, query.Param[string]("status")).
+		Where(publicID.Eq(query.Param[string]("publicId"))).
+		Build()
+	if _, err := db.Exec(update, map[string]any{"publicId": "p1", "status": "published"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	rows, _ = db.Query(get, map[string]any{"publicId": "p1"})
+	if rows[0][0] != "Hello" || rows[0][1] != "published" {
+		t.Errorf("after a partial update, row = %v", rows[0])
+	}
+
+	softDelete := query.Update(posts).
+		Set(deletedAt, query.Now()).
+		Where(publicID.Eq(query.Param[string]("publicId"))).
+		Build()
+	if res, _ := db.Exec(softDelete, map[string]any{"publicId": "p1"}); affected(res) != 1 {
+		t.Errorf("soft delete affected %d rows, want 1", affected(res))
+	}
+	if rows, _ := db.Query(get, map[string]any{"publicId": "p1"}); len(rows) != 0 {
+		t.Errorf("soft-deleted row still found: %v", rows)
+	}
+
+	restore := query.Update(posts).
+		Set(deletedAt, query.Literal[any](nil)).
+		Where(query.And(publicID.Eq(query.Param[string]("publicId")), deletedAt.IsNotNull())).
+		Build()
+	if res, _ := db.Exec(restore, map[string]any{"publicId": "p1"}); affected(res) != 1 {
+		t.Errorf("restore affected %d rows, want 1", affected(res))
+	}
+	if res, _ := db.Exec(restore, map[string]any{"publicId": "p1"}); affected(res) != 0 {
+		t.Errorf("second restore affected %d rows, want 0", affected(res))
+	}
+
+	del := query.Delete(posts).Where(publicID.Eq(query.Param[string]("publicId"))).Build()
+	if res, _ := db.Exec(del, map[string]any{"publicId": "p1"}); affected(res) != 1 {
+		t.Errorf("delete affected %d rows, want 1", affected(res))
+	}
+	if got := db.Rows("posts"); len(got) != 1 || got[0]["public_id"] != "p2" {
+		t.Errorf("rows after delete = %v", got)
+	}
+}
+
+func affected(r Result) int64 {
+	n, _ := r.RowsAffected()
+	return n
+}
+
+func TestJoinsAndAggregates(t *testing.T) {
+	db := newTestDB()
+	ada := db.Insert("authors", Row{"name": "Ada"})
+	db.Insert("authors", Row{"name": "Grace"})
+	db.Insert("posts", Row{"public_id": "a", "title": "A", "author_id": ada["id"]})
+	db.Insert("posts", Row{"public_id": "b", "title": "B", "author_id": ada["id"]})
+	db.Insert("posts", Row{"public_id": "c", "title": "C"})
+
+	joined := query.From(posts).
+		LeftJoin(authors).On(authorID.Eq(authorPK)).
+		Select(title).
+		SelectAs(name, "author_name").
+		OrderBy(title.Asc()).
+		Build()
+	rows, err := db.Query(joined, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]any{{"A", "Ada"}, {"B", "Ada"}, {"C", nil}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("left join = %v, want %v", rows, want)
+	}
+
+	counts := query.From(authors).
+		LeftJoin(posts).On(authorID.Eq(authorPK)).
+		Select(name).
+		SelectExprAs(query.CountCol(publicID), "posts").
+		GroupBy(name).
+		OrderBy(name.Asc()).
+		Build()
+	rows, err = db.Query(counts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = [][]any{{"Ada", int64(2)}, {"Grace", int64(0)}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("grouped count = %v, want %v", rows, want)
+	}
+
+	total := query.From(posts).SelectExprAs(query.Count(), "n").Where(title.Eq("nope")).Build()
+	rows, err = db.Query(total, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, [][]any{{int64(0)}}) {
+		t.Errorf("count over no rows = %v", rows)
+	}
+}
+
+func TestQueryPage(t *testing.T) {
+	db := newTestDB()
+	for i, pid := range []string{"a", "b", "c", "d", "e"} {
+		// b and c share a timestamp, so public_id breaks the tie.
+		at := t0.Add(time.Duration(i-min(i/2, 1)) * time.Minute)
+		db.Insert("posts", Row{"public_id": pid, "created_at": at})
+	}
+	list := query.From(posts).Select(publicID, createdAt).Build()
+	cursor := []query.SerializedColumn{
+		{Table: "posts", Name: "created_at"},
+		{Table: "posts", Name: "public_id"},
+	}
+
+	var got []string
+	var after []any
+	for {
+		rows, err := db.QueryPage(list, nil, Page{Cursor: cursor, After: after, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range rows {
+			got = append(got, r[0].(string))
+		}
+		if len(rows) < 2 {
+			break
+		}
+		last := rows[len(rows)-1]
+		// Cursors carry strings, as the generated runner encodes them.
+		after = []any{last[1].(time.Time).Format(time.RFC3339Nano), last[0]}
+	}
+	if want := []string{"e", "d", "c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	db := newTestDB()
+	upsert := query.InsertInto(posts).
+		Columns(publicID, title).
+		Values(query.Param[string]("publicId"), query.Param[string]("title")).
+		OnConflict(publicID).
+		DoUpdate(query.SetExcluded(title)...).
+		Build()
+	for _, title := range []string{"first", "second"} {
+		if _, err := db.Exec(upsert, map[string]any{"publicId": "p", "title": title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows := db.Rows("posts")
+	if len(rows) != 1 || rows[0]["title"] != "second" {
+		t.Errorf("rows after upserts = %v", rows)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	db := newTestDB()
+	db.Insert("posts", Row{"public_id": "kept"})
+	snap := db.Snapshot()
+	db.Insert("posts", Row{"public_id": "rolled back"})
+	db.Restore(snap)
+	if rows := db.Rows("posts"); len(rows) != 1 || rows[0]["public_id"] != "kept" {
+		t.Errorf("rows after restore = %v", rows)
+	}
+	if row := db.Insert("posts", Row{}); row["id"] != int64(2) {
+		t.Errorf("id after restore = %v, want 2", row["id"])
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	db := newTestDB()
+	union := query.From(posts).Select(title).Build()
+	union.SetOp = &query.SetOperation{Left: union, Op: query.SetOpUnion, Right: union}
+	if _, err := db.Query(union, nil); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("UNION error = %v, want ErrUnsupported", err)
+	}
+	if _, err := db.Query(query.From(tableName("missing")).Select(title).Build(), nil); err == nil {
+		t.Error("expected an error for a missing table")
+	}
+}
+
+func TestAssign(t *testing.T) {
+	type status string
+	var (
+		s   status
+		n   int32
+		p   *string
+		ts  time.Time
+		raw []byte
+	)
+	for _, tt := range []struct {
+		dst any
+		v   any
+	}{
+		{&s, "open"},
+		{&n, int64(7)},
+		{&p, "x"},
+		{&ts, "2026-01-02T03:04:05Z"},
+		{&raw, "{}"},
+	} {
+		if err := Assign(tt.dst, tt.v); err != nil {
+			t.Errorf("Assign(%T, %v) error = %v", tt.dst, tt.v, err)
+		}
+	}
+	if s != "open" || n != 7 || p == nil || *p != "x" || !ts.Equal(t0.Truncate(time.Hour).Add(4*time.Minute+5*time.Second)) || string(raw) != "{}" {
+		t.Errorf("assigned %v %v %v %v %q", s, n, p, ts, raw)
+	}
+	if err := Assign(&p, nil); err != nil || p != nil {
+		t.Errorf("Assign(nil) left %v, %v", p, err)
+	}
+	if err := Assign(&n, "seven"); err == nil {
+		t.Error("expected an error assigning a non-numeric string to an int")
+	}
+}
+
+func TestExecBatch(t *testing.T) {
+	db := newTestDB()
+	insert := query.InsertInto(posts).
+		Columns(publicID).
+		Values(query.Param[string]("publicId")).
+		Build()
+	res, err := db.ExecBatch(insert, []map[string]any{{"publicId": "a"}, {"publicId": "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected(res) != 2 {
+		t.Errorf("ExecBatch affected %d rows, want 2", affected(res))
+	}
+	if id, _ := res.LastInsertId(); id != 2 {
+		t.Errorf("LastInsertId() = %d, want 2", id)
+	}
+
+	var (
+		got string
+		n   int64
+	)
+	if err := Scan([]any{"a", int64(1)}, &got, &n); err != nil || got != "a" || n != 1 {
+		t.Errorf("Scan = %q, %d, %v", got, n, err)
+	}
+	if err := Scan([]any{"a"}, &got, &n); err == nil {
+		t.Error("expected an error scanning one value into two destinations")
+	}
+}
+
+func TestStringSearch(t *testing.T) {
+	db := newTestDB()
+	for i, s := range []string{"Hello Wörld", "50% off", "hello"} {
+		db.Insert("posts", Row{"public_id": string(rune('a' + i)), "title": s})
+	}
+	q := query.Param[string]("q")
+	for _, tt := range []struct {
+		expr query.Expr
+		arg  string
+		want []string
+	}{
+		{title.Contains(q), "Hello", []string{"a"}},
+		{title.Contains(q), "%", []string{"b"}},
+		{title.IContains(q), "HELLO", []string{"a", "c"}},
+		{title.IStartsWith(q), "hello w", []string{"a"}},
+		{title.IEndsWith(q), "LO", []string{"c"}},
+		// Only ASCII letters fold, as on every dialect
+		{title.IEndsWith(q), "WÖRLD", nil},
+	} {
+		rows, err := db.Query(query.From(posts).Select(publicID).Where(tt.expr).OrderBy(publicID.Asc()).Build(), map[string]any{"q": tt.arg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, row := range rows {
+			got = append(got, row[0].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %q matched %v, want %v", tt.expr.(query.FuncExpr).Name, tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package memdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// normalize converts a Go value to the form rows store: pointers are
+// dereferenced (nil is NULL), integers become int64, floats float64,
+// named string and bool types their underlying type, and byte slices
+// (including json.RawMessage) are copied. Other values are kept as is.
+func normalize(v any) any {
+	if v == nil {
+		return nil
+	}
+	switch x := v.(type) {
+	case string, int64, float64, bool, time.Time:
+		return x
+	case []byte:
+		return bytes.Clone(x)
+	case json.RawMessage:
+		return bytes.Clone(x)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return bytes.Clone(rv.Bytes())
+		}
+	}
+	return v
+}
+
+// timeLayouts are the text forms a string is parsed in when it is compared
+// with a time, such as a pagination cursor.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compare orders two non-NULL normalized values, returning -1, 0 or 1. A
+// string compared with a time or a number is parsed first, the way a
+// database coerces a text parameter.
+func compare(a, b any) (int, error) {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return cmpOrdered(x, y), nil
+		case float64:
+			return cmpOrdered(float64(x), y), nil
+		case bool:
+			return cmpOrdered(x, boolInt(y)), nil
+		case string:
+			if f, err := strconv.ParseFloat(y, 64); err == nil {
+				return cmpOrdered(float64(x), f), nil
+			}
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return cmpOrdered(x, float64(y)), nil
+		case float64:
+			return cmpOrdered(x, y), nil
+		case string:
+			if f, err := strconv.ParseFloat(y, 64); err == nil {
+				return cmpOrdered(x, f), nil
+			}
+		}
+	case string:
+		switch y := b.(type) {
+		case string:
+			return strings.Compare(x, y), nil
+		case []byte:
+			return strings.Compare(x, string(y)), nil
+		case int64, float64, time.Time:
+			c, err := compare(b, a)
+			return -c, err
+		}
+	case bool:
+		switch y := b.(type) {
+		case bool:
+			return cmpOrdered(boolInt(x), boolInt(y)), nil
+		case int64:
+			return cmpOrdered(boolInt(x), y), nil
+		}
+	case time.Time:
+		switch y := b.(type) {
+		case time.Time:
+			return x.Compare(y), nil
+		case string:
+			if t, ok := parseTime(y); ok {
+				return x.Compare(t), nil
+			}
+		}
+	case []byte:
+		switch y := b.(type) {
+		case []byte:
+			return bytes.Compare(x, y), nil
+		case string:
+			return bytes.Compare(x, []byte(y)), nil
+		}
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("memdb: cannot compare %T with %T", a, b)
+}
+
+func cmpOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// truthy reports whether a condition value holds: NULL and false do not.
+func truthy(v any) bool {
+	switch x := v.(type) {
+	case bool:
+		return x
+	case int64:
+		return x != 0
+	case float64:
+		return x != 0
+	}
+	return false
+}
+
+// Assign stores the value v of a result column into dst, a pointer to the
+// result field, converting it to the field's type: NULL zeroes the field,
+// a pointer field gets a new value, and ints, floats, strings and times are
+// converted between each other's named types.
+func Assign(dst any, v any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("memdb: Assign needs a non-nil pointer, got %T", dst)
+	}
+	return assignValue(rv.Elem(), v)
+}
+
+// Scan assigns the values of row to dest in order, the way sql.Rows.Scan
+// does, using Assign for each.
+func Scan(row []any, dest ...any) error {
+	if len(row) != len(dest) {
+		return fmt.Errorf("memdb: Scan of %d values into %d destinations", len(row), len(dest))
+	}
+	for i, d := range dest {
+		if err := Assign(d, row[i]); err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func assignValue(field reflect.Value, v any) error {
+	v = normalize(v)
+	if v == nil {
+		field.SetZero()
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := assignValue(elem.Elem(), v); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	src := reflect.ValueOf(v)
+	if src.Type().AssignableTo(field.Type()) {
+		field.Set(src)
+		return nil
+	}
+	switch x := v.(type) {
+	case int64:
+		switch field.Kind() {
+		case reflect.Bool:
+			field.SetBool(x != 0)
+			return nil
+		case reflect.String:
+			field.SetString(strconv.FormatInt(x, 10))
+			return nil
+		}
+	case float64:
+		if field.Kind() == reflect.String {
+			field.SetString(strconv.FormatFloat(x, 'f', -1, 64))
+			return nil
+		}
+	case string:
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(x, 10, 64)
+			if err != nil {
+				return fmt.Errorf("memdb: cannot assign %q to %s", x, field.Type())
+			}
+			field.SetInt(n)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(x, 64)
+			if err != nil {
+				return fmt.Errorf("memdb: cannot assign %q to %s", x, field.Type())
+			}
+			field.SetFloat(f)
+			return nil
+		}
+		if field.Type() == reflect.TypeOf(time.Time{}) {
+			t, ok := parseTime(x)
+			if !ok {
+				return fmt.Errorf("memdb: cannot assign %q to time.Time", x)
+			}
+			field.Set(reflect.ValueOf(t))
+			return nil
+		}
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8 {
+			field.SetBytes([]byte(x))
+			return nil
+		}
+	case []byte:
+		if field.Kind() == reflect.String {
+			field.SetString(string(x))
+			return nil
+		}
+	}
+	if src.Type().ConvertibleTo(field.Type()) {
+		field.Set(src.Convert(field.Type()))
+		return nil
+	}
+	return fmt.Errorf("memdb: cannot assign %T to %s", v, field.Type())
+}
+
+// reflectSlice returns the normalized elements of a slice value other than
+// []byte, or nil when v is not one.
+func reflectSlice(v any) []any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = normalize(rv.Index(i).Interface())
+	}
+	return out
+}
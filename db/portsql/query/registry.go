@@ -2,7 +2,10 @@ package query
 
 import (
 	"errors"
+	"runtime"
+	"strings"
 	"sync"
+	"time"
 )
 
 // QueryReturnType specifies how a query returns results.
@@ -22,6 +25,10 @@ const (
 	// The runner generates cursor types, encode/decode helpers, and a method
 	// that handles LIMIT+1, cursor WHERE injection, and NextCursor computation.
 	ReturnPaginated QueryReturnType = "paginated"
+	// ReturnApplied indicates a conditional UPDATE or DELETE whose generated
+	// method reports whether it changed any row (bool) instead of returning
+	// sql.Result, e.g. a check-and-set state transition.
+	ReturnApplied QueryReturnType = "applied"
 )
 
 // RegisteredQuery holds a query AST and its return type.
@@ -34,12 +41,94 @@ type RegisteredQuery struct {
 	// the second is the tiebreaker (e.g., id).
 	// Each OrderByExpr carries both the column and the sort direction (Desc bool).
 	CursorColumns []OrderByExpr
+	// Filters are the optional conditions added with MustDefineFilters.
+	// Only set when ReturnType is ReturnPaginated.
+	Filters []Filter
+	// Sorts are the alternate orders added with MustDefineSorts.
+	// Only set when ReturnType is ReturnPaginated.
+	Sorts []Sort
+	// RefreshSchedule is the cron-style worker schedule of a materialized
+	// view ("" = refresh on demand only). Only set when ReturnType is
+	// ReturnMaterializedView.
+	RefreshSchedule string
+	// RowParams names the query whose params type the generated bulk
+	// method takes per row ("" = the bulk query's own). Only set when
+	// ReturnType is ReturnBulkExec.
+	RowParams string
+	// Iter makes the runner also generate an iterator variant of the
+	// query ({Name}Iter). Only set when ReturnType is ReturnMany.
+	Iter bool
+	// Timeout overrides the [db] query_timeout of the generated method
+	// (0 = use the default). Set with MustDefineTimeout.
+	Timeout time.Duration
+	// Package is the import path of the package that registered the query,
+	// which lets `shipq db compile --only` recompile a single package.
+	Package string
 }
 
 // registry stores all queries registered via MustDefineOne/MustDefineMany/MustDefineExec.
 // Uses a sync.Map for thread-safety during init() execution across packages.
 var registry sync.Map
 
+// register stores rq under name, recording the package that defined it.
+// It reports false if a query with the same name is already registered.
+func register(name string, rq RegisteredQuery) bool {
+	rq.Package = definingPackage()
+	_, loaded := registry.LoadOrStore(name, rq)
+	return !loaded
+}
+
+// definingPackage returns the import path of the package whose code is
+// registering a query. Queries are registered from init(), so the innermost
+// init function on the stack identifies the package; failing that, the first
+// caller outside this package is used.
+func definingPackage() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var funcs []string
+	for {
+		frame, more := frames.Next()
+		funcs = append(funcs, frame.Function)
+		if !more {
+			break
+		}
+	}
+	if len(funcs) == 0 {
+		return ""
+	}
+	return packageOfCallers(funcs, funcPackage(funcs[0]))
+}
+
+// packageOfCallers picks the defining package from a stack of fully
+// qualified function names, innermost first; self is this package's path.
+func packageOfCallers(funcs []string, self string) string {
+	for _, fn := range funcs {
+		pkg := funcPackage(fn)
+		rest := strings.TrimPrefix(fn, pkg+".")
+		if rest == "init" || strings.HasPrefix(rest, "init.") {
+			return pkg
+		}
+	}
+	for _, fn := range funcs {
+		if pkg := funcPackage(fn); pkg != self && pkg != "runtime" {
+			return pkg
+		}
+	}
+	return ""
+}
+
+// funcPackage returns the import path part of a fully qualified function
+// name such as "myapp/querydefs/posts.init.0".
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return fn
+	}
+	return fn[:slash+1+dot]
+}
+
 // mustDefineQuery is the internal registration function that panics on errors.
 // This is intentional - query registration happens at init() time, and errors
 // should cause immediate, obvious failures rather than silent runtime issues.
@@ -54,7 +143,7 @@ func mustDefineQuery(name string, ast *AST, returnType QueryReturnType) *AST {
 		AST:        ast,
 		ReturnType: returnType,
 	}
-	if _, loaded := registry.LoadOrStore(name, rq); loaded {
+	if !register(name, rq) {
 		panic("duplicate query name: " + name)
 	}
 	return ast
@@ -107,9 +196,31 @@ func MustDefineOne(name string, ast *AST) *AST {
 //	    )
 //	}
 //
-// The generated method will return ([]Result, error).
-func MustDefineMany(name string, ast *AST) *AST {
-	return mustDefineQuery(name, ast, ReturnMany)
+// The generated method will return ([]Result, error). Pass Iter() to also
+// generate an iterator over the rows.
+func MustDefineMany(name string, ast *AST, opts ...ManyOption) *AST {
+	ast, err := TryDefineMany(name, ast, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return ast
+}
+
+// ManyOption configures a MustDefineMany registration.
+type ManyOption func(*RegisteredQuery)
+
+// Iter makes the runner also generate a {Name}Iter method returning an
+// iter.Seq2 over the rows, which are scanned one at a time as the caller
+// ranges over them instead of being loaded into a slice:
+//
+//	query.MustDefineMany("ListUsers", ..., query.Iter())
+//
+//	seq, err := runner.ListUsersIter(ctx, params)
+//	for user, err := range seq { ... }
+func Iter() ManyOption {
+	return func(rq *RegisteredQuery) {
+		rq.Iter = true
+	}
 }
 
 // MustDefineExec registers a query that executes without returning rows.
@@ -138,6 +249,30 @@ func MustDefineExec(name string, ast *AST) *AST {
 	return mustDefineQuery(name, ast, ReturnExec)
 }
 
+// MustDefineApplied registers a conditional UPDATE or DELETE whose outcome
+// is whether it changed any row. Use it for check-and-set updates, where the
+// WHERE clause carries the expected current value and losing a race is an
+// ordinary result rather than an error.
+//
+// MustDefineApplied panics under the same conditions as MustDefineExec.
+//
+//	func init() {
+//	    query.MustDefineApplied("UpdateUserStatusIf",
+//	        query.Update(schema.Users).
+//	            Set(schema.Users.Status(), query.Param[string]("to")).
+//	            Where(query.And(
+//	                schema.Users.PublicId().Eq(query.Param[string]("publicId")),
+//	                schema.Users.Status().Eq(query.Param[string]("from")),
+//	            )).
+//	            Build(),
+//	    )
+//	}
+//
+// The generated method will return (bool, error).
+func MustDefineApplied(name string, ast *AST) *AST {
+	return mustDefineQuery(name, ast, ReturnApplied)
+}
+
 // MustDefinePaginated registers a query with cursor-based pagination.
 // The runner generates:
 //   - Two SQL variants (base and with-cursor) at compile time
@@ -185,7 +320,7 @@ func MustDefinePaginated(name string, ast *AST, cursorCols ...OrderByExpr) *AST
 		ReturnType:    ReturnPaginated,
 		CursorColumns: cursorCols,
 	}
-	if _, loaded := registry.LoadOrStore(name, rq); loaded {
+	if !register(name, rq) {
 		panic("duplicate query name: " + name)
 	}
 	return ast
@@ -228,15 +363,52 @@ func MustDefinePaginatedDesc(name string, ast *AST, cursorCols ...Column) *AST {
 //
 // The generated method will accept a []BulkInsertAuthorsParams slice and
 // build the multi-row INSERT at runtime.
-func MustDefineBulkExec(name string, ast *AST) *AST {
-	return mustDefineQuery(name, ast, ReturnBulkExec)
+func MustDefineBulkExec(name string, ast *AST, opts ...BulkOption) *AST {
+	ast, err := TryDefineBulkExec(name, ast, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return ast
 }
 
 // TryDefineBulkExec registers a bulk insert query.
 // Unlike MustDefineBulkExec, this returns an error instead of panicking.
 // Use this in tools or tests where you want to handle registration errors gracefully.
-func TryDefineBulkExec(name string, ast *AST) (*AST, error) {
-	return tryDefineQuery(name, ast, ReturnBulkExec)
+func TryDefineBulkExec(name string, ast *AST, opts ...BulkOption) (*AST, error) {
+	if name == "" {
+		return nil, errors.New("query name cannot be empty")
+	}
+	if ast == nil {
+		return nil, errors.New("query AST cannot be nil")
+	}
+	rq := RegisteredQuery{
+		AST:        ast,
+		ReturnType: ReturnBulkExec,
+	}
+	for _, opt := range opts {
+		opt(&rq)
+	}
+	if !register(name, rq) {
+		return nil, errors.New("duplicate query name: " + name)
+	}
+	return ast, nil
+}
+
+// BulkOption configures a bulk insert registration.
+type BulkOption func(*RegisteredQuery)
+
+// RowParams makes the generated bulk method take a slice of the params
+// type of the named query instead of declaring its own, e.g. so a batch
+// create accepts the same []CreateUserParams as single creates:
+//
+//	query.MustDefineBulkExec("CreateUsersBatch", ..., query.RowParams("CreateUser"))
+//
+// `shipq db compile` checks that the named query has every param of the
+// template row, with the same types.
+func RowParams(queryName string) BulkOption {
+	return func(rq *RegisteredQuery) {
+		rq.RowParams = queryName
+	}
 }
 
 // =============================================================================
@@ -246,17 +418,20 @@ func TryDefineBulkExec(name string, ast *AST) (*AST, error) {
 // tryDefineQuery is the internal non-panicking registration function.
 // Returns an error instead of panicking on invalid input.
 func tryDefineQuery(name string, ast *AST, returnType QueryReturnType) (*AST, error) {
+	return tryRegisterQuery(name, ast, RegisteredQuery{ReturnType: returnType})
+}
+
+// tryRegisterQuery registers rq, whose ReturnType and options are already
+// set, with ast under name.
+func tryRegisterQuery(name string, ast *AST, rq RegisteredQuery) (*AST, error) {
 	if name == "" {
 		return nil, errors.New("query name cannot be empty")
 	}
 	if ast == nil {
 		return nil, errors.New("query AST cannot be nil")
 	}
-	rq := RegisteredQuery{
-		AST:        ast,
-		ReturnType: returnType,
-	}
-	if _, loaded := registry.LoadOrStore(name, rq); loaded {
+	rq.AST = ast
+	if !register(name, rq) {
 		return nil, errors.New("duplicate query name: " + name)
 	}
 	return ast, nil
@@ -272,8 +447,12 @@ func TryDefineOne(name string, ast *AST) (*AST, error) {
 // TryDefineMany registers a query that returns 0 to N rows.
 // Unlike MustDefineMany, this returns an error instead of panicking.
 // Use this in tools or tests where you want to handle registration errors gracefully.
-func TryDefineMany(name string, ast *AST) (*AST, error) {
-	return tryDefineQuery(name, ast, ReturnMany)
+func TryDefineMany(name string, ast *AST, opts ...ManyOption) (*AST, error) {
+	rq := RegisteredQuery{ReturnType: ReturnMany}
+	for _, opt := range opts {
+		opt(&rq)
+	}
+	return tryRegisterQuery(name, ast, rq)
 }
 
 // TryDefineExec registers a query that executes without returning rows.
@@ -283,6 +462,13 @@ func TryDefineExec(name string, ast *AST) (*AST, error) {
 	return tryDefineQuery(name, ast, ReturnExec)
 }
 
+// TryDefineApplied registers a conditional UPDATE or DELETE reporting
+// whether it changed any row.
+// Unlike MustDefineApplied, this returns an error instead of panicking.
+func TryDefineApplied(name string, ast *AST) (*AST, error) {
+	return tryDefineQuery(name, ast, ReturnApplied)
+}
+
 // TryDefinePaginated registers a cursor-paginated query.
 // Unlike MustDefinePaginated, this returns an error instead of panicking.
 func TryDefinePaginated(name string, ast *AST, cursorCols ...OrderByExpr) (*AST, error) {
@@ -300,7 +486,7 @@ func TryDefinePaginated(name string, ast *AST, cursorCols ...OrderByExpr) (*AST,
 		ReturnType:    ReturnPaginated,
 		CursorColumns: cursorCols,
 	}
-	if _, loaded := registry.LoadOrStore(name, rq); loaded {
+	if !register(name, rq) {
 		return nil, errors.New("duplicate query name: " + name)
 	}
 	return ast, nil
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -491,6 +492,30 @@ func TestTryDefineMany_Success(t *testing.T) {
 	}
 }
 
+func TestMustDefineMany_Iter(t *testing.T) {
+	ClearRegistry()
+
+	authors := mockTable{name: "authors"}
+	MustDefineMany("ListAuthorsIter", From(authors).Build(), Iter())
+	MustDefineMany("ListAuthorsPlain", From(authors).Build())
+
+	queries := GetRegisteredQueries()
+	if !queries["ListAuthorsIter"].Iter {
+		t.Error("Iter() should mark the query for an iterator variant")
+	}
+	if queries["ListAuthorsPlain"].Iter {
+		t.Error("queries without Iter() should not get an iterator variant")
+	}
+
+	data, err := SerializeQueries()
+	if err != nil {
+		t.Fatalf("SerializeQueries failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"iter": true`) {
+		t.Errorf("serialized queries should carry iter, got %s", data)
+	}
+}
+
 func TestTryDefineExec_Success(t *testing.T) {
 	ClearRegistry()
 
@@ -514,6 +539,26 @@ func TestTryDefineExec_Success(t *testing.T) {
 	}
 }
 
+func TestDefineApplied(t *testing.T) {
+	ClearRegistry()
+
+	authors := mockTable{name: "authors"}
+	MustDefineApplied("UpdateAuthorNameIf", Update(authors).Build())
+	if _, err := TryDefineApplied("DeleteAuthorIf", Delete(authors).Build()); err != nil {
+		t.Fatalf("TryDefineApplied returned error: %v", err)
+	}
+	if _, err := TryDefineApplied("DeleteAuthorIf", Delete(authors).Build()); err == nil {
+		t.Error("TryDefineApplied accepted a duplicate name")
+	}
+
+	queries := GetRegisteredQueries()
+	for _, name := range []string{"UpdateAuthorNameIf", "DeleteAuthorIf"} {
+		if got := queries[name].ReturnType; got != ReturnApplied {
+			t.Errorf("%s: expected ReturnApplied, got %v", name, got)
+		}
+	}
+}
+
 // =============================================================================
 // MustDefineBulkExec / TryDefineBulkExec tests
 // =============================================================================
@@ -694,3 +739,62 @@ func TestMustDefineBulkExec_CrossTypeNameConflict(t *testing.T) {
 		InsertInto(authors).Columns(nameCol).AddRow(Param[string]("name")).Build(),
 	) // Should panic even though different define type
 }
+
+func TestFuncPackage(t *testing.T) {
+	for fn, want := range map[string]string{
+		"myapp/querydefs/posts.init.0":                         "myapp/querydefs/posts",
+		"myapp/querydefs/posts.init":                           "myapp/querydefs/posts",
+		"github.com/acme/app/querydefs.ListThings.func1":       "github.com/acme/app/querydefs",
+		"myapp/shipq/lib/db/portsql/query.mustDefineQuery":     "myapp/shipq/lib/db/portsql/query",
+		"runtime.doInit1":                                      "runtime",
+		"github.com/acme/app/querydefs/v2.(*builder).Build":    "github.com/acme/app/querydefs/v2",
+		"github.com/acme/app/internal/helpers.DefineList[...]": "github.com/acme/app/internal/helpers",
+	} {
+		if got := funcPackage(fn); got != want {
+			t.Errorf("funcPackage(%q) = %q, want %q", fn, got, want)
+		}
+	}
+}
+
+func TestPackageOfCallers(t *testing.T) {
+	self := "myapp/shipq/lib/db/portsql/query"
+
+	// A helper package called from a querydefs init: the init wins.
+	got := packageOfCallers([]string{
+		self + ".definingPackage",
+		self + ".register",
+		self + ".mustDefineQuery",
+		"myapp/querydefs/helpers.DefineList",
+		"myapp/querydefs/posts.init.0",
+		"runtime.doInit1",
+	}, self)
+	if got != "myapp/querydefs/posts" {
+		t.Errorf("packageOfCallers = %q, want myapp/querydefs/posts", got)
+	}
+
+	// Outside init, the first caller outside the query package is used.
+	got = packageOfCallers([]string{
+		self + ".definingPackage",
+		self + ".tryDefineQuery",
+		"myapp/tools.Register",
+		"main.main",
+	}, self)
+	if got != "myapp/tools" {
+		t.Errorf("packageOfCallers = %q, want myapp/tools", got)
+	}
+}
+
+func TestRegister_RecordsPackage(t *testing.T) {
+	ClearRegistry()
+	defer ClearRegistry()
+
+	authors := mockTable{name: "authors"}
+	nameCol := StringColumn{Table: "authors", Name: "name"}
+	MustDefineOne("GetAuthor", From(authors).Select(nameCol).Build())
+
+	// Registered from a test function, so the testing package is the first
+	// caller outside this package.
+	if got := GetRegisteredQueries()["GetAuthor"].Package; got != "testing" {
+		t.Errorf("Package = %q, want testing", got)
+	}
+}
@@ -0,0 +1,242 @@
+package rowdiff
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options controls how two results are matched.
+type Options struct {
+	// Key lists the columns identifying a row. Rows with the same key are
+	// compared column by column and reported as changed; without a key,
+	// rows only match when every column is equal.
+	Key []string
+	// Ordered compares rows position by position, for queries whose
+	// ORDER BY makes the order part of the result. It takes precedence
+	// over Key.
+	Ordered bool
+	// Ignore lists columns left out of the comparison, such as generated
+	// ids or timestamps that legitimately differ.
+	Ignore []string
+	// FloatTolerance is the largest difference at which two numbers are
+	// still equal (0 = exact).
+	FloatTolerance float64
+	// LeftName and RightName label the two sides in reports
+	// (default "left" and "right").
+	LeftName, RightName string
+}
+
+// Diff is the structured difference between two results.
+type Diff struct {
+	LeftName, RightName string
+	// OnlyLeftColumns and OnlyRightColumns list columns returned by one
+	// side only. They are not compared.
+	OnlyLeftColumns, OnlyRightColumns []string
+	// OnlyLeft and OnlyRight hold rows without a match on the other side.
+	OnlyLeft, OnlyRight []Row
+	// Changed holds rows matched by key or position whose columns differ.
+	Changed []RowChange
+}
+
+// RowChange describes a matched row whose columns differ.
+type RowChange struct {
+	// Row identifies the row: its key ("id=3") or position ("row 3").
+	Row     string
+	Columns []ColumnChange
+}
+
+// ColumnChange is a column whose value differs between the two sides.
+type ColumnChange struct {
+	Column      string
+	Left, Right any
+}
+
+// Empty reports whether the results were equal.
+func (d *Diff) Empty() bool {
+	return len(d.OnlyLeftColumns) == 0 && len(d.OnlyRightColumns) == 0 &&
+		len(d.OnlyLeft) == 0 && len(d.OnlyRight) == 0 && len(d.Changed) == 0
+}
+
+// Compare diffs two results.
+func Compare(left, right *Result, opts Options) *Diff {
+	d := &Diff{LeftName: opts.LeftName, RightName: opts.RightName}
+	if d.LeftName == "" {
+		d.LeftName = "left"
+	}
+	if d.RightName == "" {
+		d.RightName = "right"
+	}
+
+	var columns []string
+	for _, col := range left.Columns {
+		if slices.Contains(opts.Ignore, col) {
+			continue
+		}
+		if slices.Contains(right.Columns, col) {
+			columns = append(columns, col)
+		} else {
+			d.OnlyLeftColumns = append(d.OnlyLeftColumns, col)
+		}
+	}
+	for _, col := range right.Columns {
+		if !slices.Contains(opts.Ignore, col) && !slices.Contains(left.Columns, col) {
+			d.OnlyRightColumns = append(d.OnlyRightColumns, col)
+		}
+	}
+
+	switch {
+	case opts.Ordered:
+		compareOrdered(d, left.Rows, right.Rows, columns, opts)
+	case len(opts.Key) > 0:
+		compareKeyed(d, left.Rows, right.Rows, columns, opts)
+	default:
+		compareUnordered(d, left.Rows, right.Rows, columns, opts)
+	}
+	return d
+}
+
+// compareOrdered matches rows by position.
+func compareOrdered(d *Diff, left, right []Row, columns []string, opts Options) {
+	n := min(len(left), len(right))
+	for i := 0; i < n; i++ {
+		if changes := columnChanges(left[i], right[i], columns, opts); len(changes) > 0 {
+			d.Changed = append(d.Changed, RowChange{Row: "row " + strconv.Itoa(i+1), Columns: changes})
+		}
+	}
+	d.OnlyLeft = append(d.OnlyLeft, left[n:]...)
+	d.OnlyRight = append(d.OnlyRight, right[n:]...)
+}
+
+// compareKeyed matches rows by their key columns.
+func compareKeyed(d *Diff, left, right []Row, columns []string, opts Options) {
+	matched := make([]bool, len(right))
+	for _, l := range left {
+		j := findRow(l, right, matched, opts.Key, opts)
+		if j < 0 {
+			d.OnlyLeft = append(d.OnlyLeft, l)
+			continue
+		}
+		matched[j] = true
+		if changes := columnChanges(l, right[j], columns, opts); len(changes) > 0 {
+			d.Changed = append(d.Changed, RowChange{Row: formatKey(l, opts.Key), Columns: changes})
+		}
+	}
+	d.OnlyRight = append(d.OnlyRight, unmatched(right, matched)...)
+}
+
+// compareUnordered matches rows that are equal in every column, as
+// multisets.
+func compareUnordered(d *Diff, left, right []Row, columns []string, opts Options) {
+	matched := make([]bool, len(right))
+	for _, l := range left {
+		j := findRow(l, right, matched, columns, opts)
+		if j < 0 {
+			d.OnlyLeft = append(d.OnlyLeft, l)
+			continue
+		}
+		matched[j] = true
+	}
+	d.OnlyRight = append(d.OnlyRight, unmatched(right, matched)...)
+}
+
+// findRow returns the index of the first unmatched row of rows equal to
+// row in columns, or -1.
+func findRow(row Row, rows []Row, matched []bool, columns []string, opts Options) int {
+	for j, candidate := range rows {
+		if !matched[j] && len(columnChanges(row, candidate, columns, opts)) == 0 {
+			return j
+		}
+	}
+	return -1
+}
+
+// unmatched returns the rows not marked as matched.
+func unmatched(rows []Row, matched []bool) []Row {
+	var out []Row
+	for j, row := range rows {
+		if !matched[j] {
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// columnChanges returns the columns whose values differ between l and r.
+func columnChanges(l, r Row, columns []string, opts Options) []ColumnChange {
+	var changes []ColumnChange
+	for _, col := range columns {
+		if !valuesEqual(l[col], r[col], opts.FloatTolerance) {
+			changes = append(changes, ColumnChange{Column: col, Left: l[col], Right: r[col]})
+		}
+	}
+	return changes
+}
+
+// formatKey renders the key columns of row, e.g. "id=3".
+func formatKey(row Row, key []string) string {
+	parts := make([]string, len(key))
+	for i, col := range key {
+		parts[i] = col + "=" + formatValue(row[col])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatRow renders a row with its columns sorted.
+func formatRow(row Row) string {
+	cols := make([]string, 0, len(row))
+	for col := range row {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		parts[i] = col + ": " + formatValue(row[col])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// formatValue renders a normalized value.
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// String renders the diff as a report suitable for test failures.
+func (d *Diff) String() string {
+	if d.Empty() {
+		return "no differences"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "rows differ between %s and %s:", d.LeftName, d.RightName)
+	if len(d.OnlyLeftColumns) > 0 {
+		fmt.Fprintf(&b, "\n  columns only in %s: %s", d.LeftName, strings.Join(d.OnlyLeftColumns, ", "))
+	}
+	if len(d.OnlyRightColumns) > 0 {
+		fmt.Fprintf(&b, "\n  columns only in %s: %s", d.RightName, strings.Join(d.OnlyRightColumns, ", "))
+	}
+	for _, row := range d.OnlyLeft {
+		fmt.Fprintf(&b, "\n  only in %s: %s", d.LeftName, formatRow(row))
+	}
+	for _, row := range d.OnlyRight {
+		fmt.Fprintf(&b, "\n  only in %s: %s", d.RightName, formatRow(row))
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(&b, "\n  changed %s:", change.Row)
+		for _, c := range change.Columns {
+			fmt.Fprintf(&b, "\n    %s: %s %s, %s %s", c.Column, d.LeftName, formatValue(c.Left), d.RightName, formatValue(c.Right))
+		}
+	}
+	return b.String()
+}
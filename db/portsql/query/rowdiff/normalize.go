@@ -0,0 +1,163 @@
+package rowdiff
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the text forms drivers return timestamps in when they do
+// not scan them into time.Time (SQLite always, MySQL without parseTime).
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Normalize converts a scanned value to a driver-independent form: []byte
+// becomes string, every integer type and bool become int64, float32 becomes
+// float64 and times are converted to UTC.
+func Normalize(v any) any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return string(x)
+	case bool:
+		if x {
+			return int64(1)
+		}
+		return int64(0)
+	case int:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	case uint:
+		return normalizeUint(uint64(x))
+	case uint8:
+		return int64(x)
+	case uint16:
+		return int64(x)
+	case uint32:
+		return int64(x)
+	case uint64:
+		return normalizeUint(x)
+	case float32:
+		return float64(x)
+	case time.Time:
+		return x.UTC()
+	default:
+		return v
+	}
+}
+
+// normalizeUint returns u as an int64, or as a float64 if it overflows.
+func normalizeUint(u uint64) any {
+	if u > math.MaxInt64 {
+		return float64(u)
+	}
+	return int64(u)
+}
+
+// valuesEqual reports whether two normalized values represent the same
+// data. Values of different kinds are coerced where dialects disagree: a
+// number equals a string holding the same number (decimals), a time equals
+// a string holding the same instant, and two JSON documents are equal when
+// they decode to the same value regardless of key order and whitespace.
+func valuesEqual(a, b any, tolerance float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if fa, ok := asFloat(a); ok {
+		if fb, ok := asFloat(b); ok {
+			if ia, ok := a.(int64); ok {
+				if ib, ok := b.(int64); ok {
+					return ia == ib
+				}
+			}
+			return math.Abs(fa-fb) <= tolerance
+		}
+		if s, ok := b.(string); ok {
+			fb, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			return err == nil && math.Abs(fa-fb) <= tolerance
+		}
+		return false
+	}
+	if _, ok := asFloat(b); ok {
+		return valuesEqual(b, a, tolerance)
+	}
+
+	if ta, ok := asTime(a); ok {
+		tb, ok := asTime(b)
+		return ok && ta.Equal(tb)
+	}
+	if _, ok := b.(time.Time); ok {
+		return valuesEqual(b, a, tolerance)
+	}
+
+	sa, aok := a.(string)
+	sb, bok := b.(string)
+	if aok && bok {
+		if sa == sb {
+			return true
+		}
+		if ja, ok := asJSON(sa); ok {
+			if jb, ok := asJSON(sb); ok {
+				return reflect.DeepEqual(ja, jb)
+			}
+		}
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// asFloat returns a normalized numeric value as a float64.
+func asFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// asTime returns v as a time if it is one, or a string holding one.
+func asTime(v any) (time.Time, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, true
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, x); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// asJSON decodes s if it holds a JSON object or array.
+func asJSON(s string) (any, bool) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,146 @@
+// Package rowdiff runs compiled PortSQL queries and diffs their row sets.
+//
+// It brings the cross-database checks PortSQL runs on itself to user
+// projects: execute the same query on two dialects, or on one database
+// before and after a migration, and compare the results. Values are
+// normalized first, so driver differences (MySQL returning []byte, SQLite
+// returning booleans as integers and timestamps as text, key order in JSON
+// documents) do not show up as differences.
+//
+//	diff, err := rowdiff.CompareQuery(ctx, ast, params,
+//	    rowdiff.Target{Name: "postgres", DB: pg, Dialect: "postgres"},
+//	    rowdiff.Target{Name: "sqlite", DB: lite, Dialect: "sqlite"},
+//	    rowdiff.Options{Key: []string{"id"}})
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	if !diff.Empty() {
+//	    t.Error(diff)
+//	}
+package rowdiff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
+)
+
+// Row is one result row, keyed by column name, with normalized values.
+type Row map[string]any
+
+// Result is a normalized row set.
+type Result struct {
+	Columns []string
+	Rows    []Row
+}
+
+// Target is a database a query runs against.
+type Target struct {
+	// Name labels the target in diff reports, e.g. "postgres" or "before".
+	Name string
+	DB   *sql.DB
+	// Dialect is "postgres", "mysql" or "sqlite".
+	Dialect string
+}
+
+// dialectFor returns the compiler dialect for a dialect name.
+func dialectFor(name string) (compile.Dialect, error) {
+	switch name {
+	case "postgres":
+		return compile.Postgres, nil
+	case "mysql":
+		return compile.MySQL, nil
+	case "sqlite":
+		return compile.SQLite, nil
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", name)
+	}
+}
+
+// Run compiles ast for the target's dialect, executes it with the named
+// params and returns the normalized rows.
+func Run(ctx context.Context, target Target, ast *query.AST, params map[string]any) (*Result, error) {
+	dialect, err := dialectFor(target.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, paramOrder, err := compile.NewCompiler(dialect).Compile(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile query for %s: %w", target.Dialect, err)
+	}
+	args := make([]any, len(paramOrder))
+	for i, name := range paramOrder {
+		v, ok := params[name]
+		if !ok {
+			return nil, fmt.Errorf("missing value for parameter %q", name)
+		}
+		args[i] = v
+	}
+	return Query(ctx, target.DB, sqlStr, args...)
+}
+
+// Query executes raw SQL and returns the normalized rows. Use it for
+// snapshots that are not PortSQL queries, such as a whole table before a
+// migration.
+func Query(ctx context.Context, db *sql.DB, sqlStr string, args ...any) (*Result, error) {
+	rows, err := db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	result := &Result{Columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		row := make(Row, len(columns))
+		for i, col := range columns {
+			row[col] = Normalize(values[i])
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return result, nil
+}
+
+// CompareQuery runs ast on both targets and diffs the results.
+func CompareQuery(ctx context.Context, ast *query.AST, params map[string]any, left, right Target, opts Options) (*Diff, error) {
+	l, err := Run(ctx, left, ast, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", targetName(left), err)
+	}
+	r, err := Run(ctx, right, ast, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", targetName(right), err)
+	}
+	if opts.LeftName == "" {
+		opts.LeftName = targetName(left)
+	}
+	if opts.RightName == "" {
+		opts.RightName = targetName(right)
+	}
+	return Compare(l, r, opts), nil
+}
+
+// targetName returns the label of a target, defaulting to its dialect.
+func targetName(t Target) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Dialect
+}
@@ -0,0 +1,161 @@
+package rowdiff
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	_ "modernc.org/sqlite"
+)
+
+type testTable struct{ name string }
+
+func (t testTable) TableName() string { return t.name }
+
+func TestValuesEqual_CoercesDialectDifferences(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{"mysql bytes vs string", Normalize([]byte("hello")), "hello", true},
+		{"postgres bool vs sqlite int", Normalize(true), Normalize(int64(1)), true},
+		{"int widths", Normalize(int32(7)), Normalize(uint8(7)), true},
+		{"decimal text vs float", "12.50", 12.5, true},
+		{"integer vs float", int64(3), 3.0, true},
+		{"different numbers", int64(3), int64(4), false},
+		{"time vs sqlite text", ts, "2026-03-01 12:30:00", true},
+		{"time vs rfc3339", Normalize(ts.In(time.FixedZone("x", 3600))), "2026-03-01T12:30:00Z", true},
+		{"json key order", `{"a":1,"b":[1,2]}`, `{"b": [1, 2], "a": 1}`, true},
+		{"json values differ", `{"a":1}`, `{"a":2}`, false},
+		{"null vs null", nil, nil, true},
+		{"null vs empty string", nil, "", false},
+		{"strings differ", "a", "b", false},
+	} {
+		if got := valuesEqual(tt.a, tt.b, 0); got != tt.want {
+			t.Errorf("%s: valuesEqual(%#v, %#v) = %v, want %v", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+	if !valuesEqual(0.1+0.2, 0.3, 1e-9) {
+		t.Error("FloatTolerance should absorb rounding")
+	}
+}
+
+func TestCompare_Keyed(t *testing.T) {
+	left := &Result{
+		Columns: []string{"id", "name", "created_at"},
+		Rows: []Row{
+			{"id": int64(1), "name": "a", "created_at": "x"},
+			{"id": int64(2), "name": "b", "created_at": "x"},
+			{"id": int64(3), "name": "c", "created_at": "x"},
+		},
+	}
+	right := &Result{
+		Columns: []string{"id", "name", "created_at", "extra"},
+		Rows: []Row{
+			{"id": 2.0, "name": "B", "created_at": "y"},
+			{"id": int64(1), "name": "a", "created_at": "y"},
+			{"id": int64(4), "name": "d", "created_at": "y"},
+		},
+	}
+
+	d := Compare(left, right, Options{Key: []string{"id"}, Ignore: []string{"created_at"}, LeftName: "before", RightName: "after"})
+	if d.Empty() {
+		t.Fatal("expected differences")
+	}
+	if len(d.OnlyRightColumns) != 1 || d.OnlyRightColumns[0] != "extra" {
+		t.Errorf("OnlyRightColumns = %v, want [extra]", d.OnlyRightColumns)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Row != "id=2" || d.Changed[0].Columns[0].Column != "name" {
+		t.Errorf("Changed = %+v, want name of id=2", d.Changed)
+	}
+	if len(d.OnlyLeft) != 1 || d.OnlyLeft[0]["id"] != int64(3) {
+		t.Errorf("OnlyLeft = %v, want id 3", d.OnlyLeft)
+	}
+	if len(d.OnlyRight) != 1 || d.OnlyRight[0]["id"] != int64(4) {
+		t.Errorf("OnlyRight = %v, want id 4", d.OnlyRight)
+	}
+
+	want := `rows differ between before and after:
+  columns only in after: extra
+  only in before: {created_at: "x", id: 3, name: "c"}
+  only in after: {created_at: "y", id: 4, name: "d"}
+  changed id=2:
+    name: before "b", after "B"`
+	if got := d.String(); got != want {
+		t.Errorf("String() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCompare_UnorderedAndOrdered(t *testing.T) {
+	left := &Result{Columns: []string{"n"}, Rows: []Row{{"n": int64(1)}, {"n": int64(2)}, {"n": int64(2)}}}
+	right := &Result{Columns: []string{"n"}, Rows: []Row{{"n": int64(2)}, {"n": int64(1)}, {"n": int64(2)}}}
+
+	if d := Compare(left, right, Options{}); !d.Empty() {
+		t.Errorf("same multiset should not differ:\n%s", d)
+	}
+	d := Compare(left, right, Options{Ordered: true})
+	if len(d.Changed) != 2 || d.Changed[0].Row != "row 1" {
+		t.Errorf("ordered compare should report rows 1 and 2, got %+v", d.Changed)
+	}
+
+	right.Rows = right.Rows[:2]
+	d = Compare(left, right, Options{})
+	if len(d.OnlyLeft) != 1 || len(d.OnlyRight) != 0 {
+		t.Errorf("a missing duplicate should be reported once, got %s", d)
+	}
+}
+
+func openSQLite(t *testing.T, names ...string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`CREATE TABLE "authors" ("id" INTEGER PRIMARY KEY, "name" TEXT, "active" INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range names {
+		if _, err := db.Exec(`INSERT INTO "authors" ("id", "name", "active") VALUES (?, ?, 1)`, i+1, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestCompareQuery(t *testing.T) {
+	ctx := context.Background()
+	authors := testTable{name: "authors"}
+	id := query.Int64Column{Table: "authors", Name: "id"}
+	name := query.StringColumn{Table: "authors", Name: "name"}
+	active := query.BoolColumn{Table: "authors", Name: "active"}
+	ast := query.From(authors).
+		Select(id, name).
+		Where(active.Eq(query.Param[bool]("active"))).
+		OrderBy(id.Asc()).
+		Build()
+
+	before := Target{Name: "before", DB: openSQLite(t, "Ada", "Grace"), Dialect: "sqlite"}
+	after := Target{Name: "after", DB: openSQLite(t, "Ada", "Grace Hopper"), Dialect: "sqlite"}
+
+	d, err := CompareQuery(ctx, ast, map[string]any{"active": true}, before, after, Options{Key: []string{"id"}})
+	if err != nil {
+		t.Fatalf("CompareQuery failed: %v", err)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Row != "id=2" || d.Changed[0].Columns[0].Right != "Grace Hopper" {
+		t.Errorf("expected the renamed author, got:\n%s", d)
+	}
+
+	if _, err := CompareQuery(ctx, ast, nil, before, after, Options{}); err == nil || !strings.Contains(err.Error(), `missing value for parameter "active"`) {
+		t.Errorf("expected a missing parameter error, got %v", err)
+	}
+	if _, err := Run(ctx, Target{DB: before.DB, Dialect: "oracle"}, ast, nil); err == nil {
+		t.Error("expected an unsupported dialect error")
+	}
+}
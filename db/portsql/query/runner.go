@@ -12,6 +12,7 @@ const (
 	Postgres Dialect = iota
 	MySQL
 	SQLite
+	MSSQL
 )
 
 // String returns the dialect name.
@@ -23,11 +24,30 @@ func (d Dialect) String() string {
 		return "mysql"
 	case SQLite:
 		return "sqlite"
+	case MSSQL:
+		return "mssql"
 	default:
 		return "unknown"
 	}
 }
 
+// dialectFromName is the inverse of Dialect.String. Unknown names map to -1,
+// which matches no dialect.
+func dialectFromName(name string) Dialect {
+	switch name {
+	case "postgres":
+		return Postgres
+	case "mysql":
+		return MySQL
+	case "sqlite":
+		return SQLite
+	case "mssql":
+		return MSSQL
+	default:
+		return -1
+	}
+}
+
 // Querier is the interface for executing queries.
 // Both *sql.DB and *sql.Tx implement this interface.
 type Querier interface {
@@ -13,6 +13,21 @@ type SerializedQuery struct {
 	// CursorColumns is set when ReturnType is "paginated".
 	// Specifies the columns used for cursor ordering and comparison.
 	CursorColumns []SerializedColumn `json:"cursor_columns,omitempty"`
+	// Filters is set for paginated queries with optional filters.
+	Filters []SerializedFilter `json:"filters,omitempty"`
+	// Sorts is set for paginated queries with alternate sort orders.
+	Sorts []SerializedSort `json:"sorts,omitempty"`
+	// RefreshSchedule is set for materialized views with a worker schedule.
+	RefreshSchedule string `json:"refresh_schedule,omitempty"`
+	// RowParams is set for bulk inserts that reuse another query's params type.
+	RowParams string `json:"row_params,omitempty"`
+	// Iter is set for ReturnMany queries registered with Iter().
+	Iter bool `json:"iter,omitempty"`
+	// TimeoutMs is set for queries given a timeout with MustDefineTimeout.
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+	// Package is the import path of the querydefs package that defined
+	// the query.
+	Package string `json:"package,omitempty"`
 }
 
 // SerializedAST is the JSON-serializable representation of a query AST.
@@ -28,12 +43,14 @@ type SerializedAST struct {
 	OrderBy    []SerializedOrderBy    `json:"order_by,omitempty"`
 	Limit      *SerializedExpr        `json:"limit,omitempty"`
 	Offset     *SerializedExpr        `json:"offset,omitempty"`
+	ForUpdate  bool                   `json:"for_update,omitempty"`
 
 	// INSERT specific
-	InsertCols   []SerializedColumn `json:"insert_cols,omitempty"`
-	InsertRows   [][]SerializedExpr `json:"insert_rows,omitempty"`
-	InsertSource *SerializedAST     `json:"insert_source,omitempty"`
-	Returning    []SerializedColumn `json:"returning,omitempty"`
+	InsertCols   []SerializedColumn    `json:"insert_cols,omitempty"`
+	InsertRows   [][]SerializedExpr    `json:"insert_rows,omitempty"`
+	InsertSource *SerializedAST        `json:"insert_source,omitempty"`
+	OnConflict   *SerializedOnConflict `json:"on_conflict,omitempty"`
+	Returning    []SerializedColumn    `json:"returning,omitempty"`
 
 	// UPDATE specific
 	SetClauses []SerializedSetClause `json:"set_clauses,omitempty"`
@@ -41,6 +58,9 @@ type SerializedAST struct {
 	// CTEs
 	CTEs []SerializedCTE `json:"ctes,omitempty"`
 
+	// Optimizer hints
+	Hints []SerializedHint `json:"hints,omitempty"`
+
 	// Set operations
 	SetOp *SerializedSetOp `json:"set_op,omitempty"`
 
@@ -75,15 +95,33 @@ type SerializedOrderBy struct {
 
 // SerializedSetClause represents column = value in UPDATE.
 type SerializedSetClause struct {
-	Column SerializedColumn `json:"column"`
-	Value  SerializedExpr   `json:"value"`
+	Column   SerializedColumn `json:"column"`
+	Value    SerializedExpr   `json:"value"`
+	Optional bool             `json:"optional,omitempty"`
+}
+
+// SerializedOnConflict represents the conflict clause of an upsert.
+type SerializedOnConflict struct {
+	Columns   []SerializedColumn    `json:"columns,omitempty"`
+	DoNothing bool                  `json:"do_nothing,omitempty"`
+	Updates   []SerializedSetClause `json:"updates,omitempty"`
+	Where     *SerializedExpr       `json:"where,omitempty"`
 }
 
 // SerializedCTE represents a Common Table Expression.
 type SerializedCTE struct {
-	Name    string         `json:"name"`
-	Columns []string       `json:"columns,omitempty"`
-	Query   *SerializedAST `json:"query"`
+	Name      string         `json:"name"`
+	Columns   []string       `json:"columns,omitempty"`
+	Query     *SerializedAST `json:"query"`
+	Recursive bool           `json:"recursive,omitempty"`
+}
+
+// SerializedHint represents a dialect-specific optimizer hint.
+type SerializedHint struct {
+	Dialect string   `json:"dialect"` // "postgres", "mysql", "sqlite"
+	Kind    string   `json:"kind"`
+	Text    string   `json:"text,omitempty"`
+	Indexes []string `json:"indexes,omitempty"`
 }
 
 // SerializedSetOp represents a set operation (UNION, INTERSECT, EXCEPT).
@@ -102,7 +140,7 @@ type SerializedParamInfo struct {
 // SerializedExpr represents any expression in JSON form.
 // Uses a tagged union pattern for type discrimination.
 type SerializedExpr struct {
-	Type string `json:"type"` // "column", "param", "literal", "binary", "unary", "func", "list", "aggregate", "json_agg", "subquery", "exists"
+	Type string `json:"type"` // "column", "param", "literal", "binary", "unary", "func", "list", "aggregate", "json_agg", "subquery", "exists", "excluded"
 
 	// Fields used depending on Type:
 	Column    *SerializedColumn  `json:"column,omitempty"`
@@ -124,6 +162,24 @@ type SerializedColumn struct {
 	Name      string `json:"name"`
 	GoType    string `json:"go_type,omitempty"`
 	Ascending bool   `json:"ascending,omitempty"` // false (zero value) = descending, preserving backward compat
+	Spatial   bool   `json:"spatial,omitempty"`   // point column; see SpatialColumn
+	UTC       bool   `json:"utc,omitempty"`       // timestamptz column; see UTCColumn
+	Decimal   bool   `json:"decimal,omitempty"`   // decimal column; see DecimalTypedColumn
+	Custom    string `json:"custom,omitempty"`    // custom type name; see CustomTypedColumn
+}
+
+// SerializedFilter represents an optional condition of a paginated query.
+type SerializedFilter struct {
+	Column SerializedColumn `json:"column"`
+	Op     string           `json:"op"`
+	Param  string           `json:"param"`
+}
+
+// SerializedSort represents an alternate order of a paginated query. Its
+// columns carry their direction like CursorColumns.
+type SerializedSort struct {
+	Name    string             `json:"name"`
+	Columns []SerializedColumn `json:"columns"`
 }
 
 // SerializedParam represents a named parameter.
@@ -195,7 +251,8 @@ func SerializeAST(ast *AST) *SerializedAST {
 			Name:  ast.FromTable.Name,
 			Alias: ast.FromTable.Alias,
 		},
-		Distinct: ast.Distinct,
+		Distinct:  ast.Distinct,
+		ForUpdate: ast.ForUpdate,
 	}
 
 	// Select columns
@@ -289,6 +346,10 @@ func SerializeAST(ast *AST) *SerializedAST {
 		s.InsertSource = SerializeAST(ast.InsertSource)
 	}
 
+	if ast.OnConflict != nil {
+		s.OnConflict = serializeOnConflict(ast.OnConflict)
+	}
+
 	if len(ast.Returning) > 0 {
 		s.Returning = make([]SerializedColumn, len(ast.Returning))
 		for i, col := range ast.Returning {
@@ -301,8 +362,9 @@ func SerializeAST(ast *AST) *SerializedAST {
 		s.SetClauses = make([]SerializedSetClause, len(ast.SetClauses))
 		for i, sc := range ast.SetClauses {
 			s.SetClauses[i] = SerializedSetClause{
-				Column: serializeColumn(sc.Column),
-				Value:  SerializeExpr(sc.Value),
+				Column:   serializeColumn(sc.Column),
+				Value:    SerializeExpr(sc.Value),
+				Optional: sc.Optional,
 			}
 		}
 	}
@@ -312,9 +374,23 @@ func SerializeAST(ast *AST) *SerializedAST {
 		s.CTEs = make([]SerializedCTE, len(ast.CTEs))
 		for i, cte := range ast.CTEs {
 			s.CTEs[i] = SerializedCTE{
-				Name:    cte.Name,
-				Columns: cte.Columns,
-				Query:   SerializeAST(cte.Query),
+				Name:      cte.Name,
+				Columns:   cte.Columns,
+				Query:     SerializeAST(cte.Query),
+				Recursive: cte.Recursive,
+			}
+		}
+	}
+
+	// Optimizer hints
+	if len(ast.Hints) > 0 {
+		s.Hints = make([]SerializedHint, len(ast.Hints))
+		for i, h := range ast.Hints {
+			s.Hints[i] = SerializedHint{
+				Dialect: h.Dialect.String(),
+				Kind:    string(h.Kind),
+				Text:    h.Text,
+				Indexes: h.Indexes,
 			}
 		}
 	}
@@ -476,6 +552,13 @@ func SerializeExpr(expr Expr) SerializedExpr {
 			},
 		}
 
+	case ExcludedExpr:
+		col := serializeColumn(e.Column)
+		return SerializedExpr{
+			Type:   "excluded",
+			Column: &col,
+		}
+
 	default:
 		// Unknown expression type - serialize as literal with type info
 		return SerializedExpr{
@@ -485,12 +568,53 @@ func SerializeExpr(expr Expr) SerializedExpr {
 	}
 }
 
+// serializeOnConflict converts the conflict clause of an upsert.
+func serializeOnConflict(oc *OnConflict) *SerializedOnConflict {
+	s := &SerializedOnConflict{DoNothing: oc.DoNothing}
+	for _, col := range oc.Columns {
+		s.Columns = append(s.Columns, serializeColumn(col))
+	}
+	for _, sc := range oc.Updates {
+		s.Updates = append(s.Updates, SerializedSetClause{
+			Column: serializeColumn(sc.Column),
+			Value:  SerializeExpr(sc.Value),
+		})
+	}
+	if oc.Where != nil {
+		expr := SerializeExpr(oc.Where)
+		s.Where = &expr
+	}
+	return s
+}
+
+// deserializeOnConflict converts a SerializedOnConflict back to an OnConflict.
+func deserializeOnConflict(s *SerializedOnConflict) *OnConflict {
+	oc := &OnConflict{DoNothing: s.DoNothing}
+	for _, col := range s.Columns {
+		oc.Columns = append(oc.Columns, deserializeColumn(col))
+	}
+	for _, sc := range s.Updates {
+		oc.Updates = append(oc.Updates, SetClause{
+			Column: deserializeColumn(sc.Column),
+			Value:  DeserializeExpr(sc.Value),
+		})
+	}
+	if s.Where != nil {
+		oc.Where = DeserializeExpr(*s.Where)
+	}
+	return oc
+}
+
 // serializeColumn converts a Column interface to SerializedColumn.
 func serializeColumn(col Column) SerializedColumn {
 	return SerializedColumn{
-		Table:  col.TableName(),
-		Name:   col.ColumnName(),
-		GoType: col.GoType(),
+		Table:   col.TableName(),
+		Name:    col.ColumnName(),
+		GoType:  col.GoType(),
+		Spatial: IsSpatialColumn(col),
+		UTC:     IsUTCColumn(col),
+		Decimal: IsDecimalColumn(col),
+		Custom:  CustomTypeOf(col),
 	}
 }
 
@@ -509,21 +633,30 @@ func SerializeQueries() ([]byte, error) {
 	for _, name := range names {
 		rq := queries[name]
 		sq := SerializedQuery{
-			Name:       name,
-			ReturnType: rq.ReturnType,
-			AST:        SerializeAST(rq.AST),
+			Name:            name,
+			ReturnType:      rq.ReturnType,
+			AST:             SerializeAST(rq.AST),
+			RefreshSchedule: rq.RefreshSchedule,
+			RowParams:       rq.RowParams,
+			Iter:            rq.Iter,
+			TimeoutMs:       rq.Timeout.Milliseconds(),
+			Package:         rq.Package,
 		}
 		if len(rq.CursorColumns) > 0 {
-			sq.CursorColumns = make([]SerializedColumn, len(rq.CursorColumns))
-			for i, ob := range rq.CursorColumns {
-				colExpr, ok := ob.Expr.(ColumnExpr)
-				if !ok {
-					continue
-				}
-				sc := serializeColumn(colExpr.Column)
-				sc.Ascending = !ob.Desc
-				sq.CursorColumns[i] = sc
-			}
+			sq.CursorColumns = serializeCursorColumns(rq.CursorColumns)
+		}
+		for _, f := range rq.Filters {
+			sq.Filters = append(sq.Filters, SerializedFilter{
+				Column: serializeColumn(f.Column),
+				Op:     string(f.Op),
+				Param:  f.Param,
+			})
+		}
+		for _, s := range rq.Sorts {
+			sq.Sorts = append(sq.Sorts, SerializedSort{
+				Name:    s.Name,
+				Columns: serializeCursorColumns(s.Columns),
+			})
 		}
 		result = append(result, sq)
 	}
@@ -531,6 +664,22 @@ func SerializeQueries() ([]byte, error) {
 	return json.MarshalIndent(result, "", "  ")
 }
 
+// serializeCursorColumns serializes the columns of an ORDER BY used for
+// cursor pagination, recording each one's direction in Ascending.
+func serializeCursorColumns(cols []OrderByExpr) []SerializedColumn {
+	serialized := make([]SerializedColumn, len(cols))
+	for i, ob := range cols {
+		colExpr, ok := ob.Expr.(ColumnExpr)
+		if !ok {
+			continue
+		}
+		sc := serializeColumn(colExpr.Column)
+		sc.Ascending = !ob.Desc
+		serialized[i] = sc
+	}
+	return serialized
+}
+
 // =============================================================================
 // Deserialization Functions (for round-trip testing)
 // =============================================================================
@@ -544,8 +693,9 @@ func DeserializeAST(s *SerializedAST) *AST {
 	}
 
 	ast := &AST{
-		Kind:     QueryKind(s.Kind),
-		Distinct: s.Distinct,
+		Kind:      QueryKind(s.Kind),
+		Distinct:  s.Distinct,
+		ForUpdate: s.ForUpdate,
 		FromTable: TableRef{
 			Name:  s.FromTable.Name,
 			Alias: s.FromTable.Alias,
@@ -639,6 +789,10 @@ func DeserializeAST(s *SerializedAST) *AST {
 		ast.InsertSource = DeserializeAST(s.InsertSource)
 	}
 
+	if s.OnConflict != nil {
+		ast.OnConflict = deserializeOnConflict(s.OnConflict)
+	}
+
 	if len(s.Returning) > 0 {
 		ast.Returning = make([]Column, len(s.Returning))
 		for i, col := range s.Returning {
@@ -651,8 +805,9 @@ func DeserializeAST(s *SerializedAST) *AST {
 		ast.SetClauses = make([]SetClause, len(s.SetClauses))
 		for i, sc := range s.SetClauses {
 			ast.SetClauses[i] = SetClause{
-				Column: deserializeColumn(sc.Column),
-				Value:  DeserializeExpr(sc.Value),
+				Column:   deserializeColumn(sc.Column),
+				Value:    DeserializeExpr(sc.Value),
+				Optional: sc.Optional,
 			}
 		}
 	}
@@ -662,9 +817,23 @@ func DeserializeAST(s *SerializedAST) *AST {
 		ast.CTEs = make([]CTE, len(s.CTEs))
 		for i, cte := range s.CTEs {
 			ast.CTEs[i] = CTE{
-				Name:    cte.Name,
-				Columns: cte.Columns,
-				Query:   DeserializeAST(cte.Query),
+				Name:      cte.Name,
+				Columns:   cte.Columns,
+				Query:     DeserializeAST(cte.Query),
+				Recursive: cte.Recursive,
+			}
+		}
+	}
+
+	// Optimizer hints
+	if len(s.Hints) > 0 {
+		ast.Hints = make([]Hint, len(s.Hints))
+		for i, h := range s.Hints {
+			ast.Hints[i] = Hint{
+				Dialect: dialectFromName(h.Dialect),
+				Kind:    HintKind(h.Kind),
+				Text:    h.Text,
+				Indexes: h.Indexes,
 			}
 		}
 	}
@@ -809,6 +978,12 @@ func DeserializeExpr(s SerializedExpr) Expr {
 			Negated:  s.Exists.Negated,
 		}
 
+	case "excluded":
+		if s.Column == nil {
+			return nil
+		}
+		return ExcludedExpr{Column: deserializeColumn(*s.Column)}
+
 	default:
 		// Unknown type - return as literal
 		return LiteralExpr{Value: s.Literal}
@@ -818,15 +993,23 @@ func DeserializeExpr(s SerializedExpr) Expr {
 // SimpleColumn is a generic column used for deserialization.
 // It preserves the column metadata without needing the specific typed column.
 type SimpleColumn struct {
-	Table_  string
-	Name_   string
-	GoType_ string
+	Table_   string
+	Name_    string
+	GoType_  string
+	Spatial_ bool
+	UTC_     bool
+	Decimal_ bool
+	Custom_  string
 }
 
 func (c SimpleColumn) TableName() string  { return c.Table_ }
 func (c SimpleColumn) ColumnName() string { return c.Name_ }
 func (c SimpleColumn) IsNullable() bool   { return len(c.GoType_) > 0 && c.GoType_[0] == '*' }
 func (c SimpleColumn) GoType() string     { return c.GoType_ }
+func (c SimpleColumn) IsSpatial() bool    { return c.Spatial_ }
+func (c SimpleColumn) IsUTC() bool        { return c.UTC_ }
+func (c SimpleColumn) IsDecimal() bool    { return c.Decimal_ }
+func (c SimpleColumn) CustomType() string { return c.Custom_ }
 
 // Verify SimpleColumn implements Column
 var _ Column = SimpleColumn{}
@@ -834,8 +1017,12 @@ var _ Column = SimpleColumn{}
 // deserializeColumn converts a SerializedColumn to a Column interface.
 func deserializeColumn(s SerializedColumn) Column {
 	return SimpleColumn{
-		Table_:  s.Table,
-		Name_:   s.Name,
-		GoType_: s.GoType,
+		Table_:   s.Table,
+		Name_:    s.Name,
+		GoType_:  s.GoType,
+		Spatial_: s.Spatial,
+		UTC_:     s.UTC,
+		Decimal_: s.Decimal,
+		Custom_:  s.Custom,
 	}
 }
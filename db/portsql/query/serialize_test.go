@@ -990,6 +990,35 @@ func TestSerialize_InsertSelect_WithCTE_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestSerialize_RecursiveCTE_RoundTrip(t *testing.T) {
+	nodes := mockTable{name: "nodes"}
+	id := Int64Column{Table: "nodes", Name: "id"}
+	parentID := Int64Column{Table: "nodes", Name: "parent_id"}
+
+	ast := WithRecursive("tree",
+		From(nodes).Select(id).Where(id.Eq(Param[int64]("rootID"))),
+		From(nodes).Select(id).Join(CTERef("tree")).On(parentID.Eq(Int64Column{Table: "tree", Name: "id"})),
+	).Select(CTERef("tree")).Select(Int64Column{Table: "tree", Name: "id"}).Build()
+
+	jsonData, err := json.Marshal(SerializeAST(ast))
+	if err != nil {
+		t.Fatalf("failed to marshal to JSON: %v", err)
+	}
+	var fromJSON SerializedAST
+	if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+		t.Fatalf("failed to unmarshal from JSON: %v", err)
+	}
+
+	deserialized := DeserializeAST(&fromJSON)
+	if len(deserialized.CTEs) != 1 || !deserialized.CTEs[0].Recursive {
+		t.Fatalf("expected one recursive CTE, got %+v", deserialized.CTEs)
+	}
+	setOp := deserialized.CTEs[0].Query.SetOp
+	if setOp == nil || setOp.Op != SetOpUnionAll || setOp.Right.Joins[0].Table.Name != "tree" {
+		t.Errorf("expected base UNION ALL recursive query, got %+v", setOp)
+	}
+}
+
 func TestSerialize_InsertSelect_JSON(t *testing.T) {
 	ast := &AST{
 		Kind:       InsertQuery,
@@ -1141,6 +1170,51 @@ func TestSerialize_InsertSelect_WithReturning_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestSerialize_Upsert_RoundTrip(t *testing.T) {
+	email := StringColumn{Table: "users", Name: "email"}
+	name := StringColumn{Table: "users", Name: "name"}
+	orgID := Int64Column{Table: "users", Name: "org_id"}
+	ast := InsertInto(mockTable{name: "users"}).
+		Columns(email, name, orgID).
+		Values(Param[string]("email"), Param[string]("name"), Param[int64]("orgId")).
+		OnConflict(email).
+		DoUpdateWhere(orgID.Eq(Excluded(orgID)), SetExcluded(name)...).
+		Build()
+
+	jsonData, err := json.Marshal(SerializeAST(ast))
+	if err != nil {
+		t.Fatalf("failed to marshal to JSON: %v", err)
+	}
+	var fromJSON SerializedAST
+	if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+		t.Fatalf("failed to unmarshal from JSON: %v", err)
+	}
+
+	oc := DeserializeAST(&fromJSON).OnConflict
+	if oc == nil {
+		t.Fatal("expected OnConflict to be non-nil")
+	}
+	if len(oc.Columns) != 1 || oc.Columns[0].ColumnName() != "email" {
+		t.Errorf("expected conflict column email, got %v", oc.Columns)
+	}
+	if len(oc.Updates) != 1 || oc.Updates[0].Column.ColumnName() != "name" {
+		t.Fatalf("expected one assignment to name, got %v", oc.Updates)
+	}
+	if ex, ok := oc.Updates[0].Value.(ExcludedExpr); !ok || ex.Column.ColumnName() != "name" {
+		t.Errorf("expected ExcludedExpr for name, got %#v", oc.Updates[0].Value)
+	}
+	where, ok := oc.Where.(BinaryExpr)
+	if !ok {
+		t.Fatalf("expected BinaryExpr WHERE, got %T", oc.Where)
+	}
+	if _, ok := where.Right.(ExcludedExpr); !ok {
+		t.Errorf("expected ExcludedExpr on the right of WHERE, got %T", where.Right)
+	}
+	if oc.DoNothing {
+		t.Error("expected DoNothing to be false")
+	}
+}
+
 func TestSerializeExpr_JSONAggWithFields(t *testing.T) {
 	chapterTitle := StringColumn{Table: "chapters", Name: "title"}
 
@@ -1214,3 +1288,53 @@ func TestSerializeExpr_JSONAggWithFields(t *testing.T) {
 		t.Error("expected subquery Query to be non-nil")
 	}
 }
+
+func TestSerialize_PointColumn_KeepsSpatial(t *testing.T) {
+	loc := PointColumn{Table: "stores", Name: "location"}
+	original := &AST{
+		Kind:       SelectQuery,
+		FromTable:  TableRef{Name: "stores"},
+		SelectCols: []SelectExpr{{Expr: ColumnExpr{Column: loc}}},
+		Where:      loc.WithinRadius(ParamExpr{Name: "lat", GoType: "float64"}, ParamExpr{Name: "lng", GoType: "float64"}, 500),
+	}
+
+	restored := DeserializeAST(SerializeAST(original))
+
+	col := restored.SelectCols[0].Expr.(ColumnExpr).Column
+	if !IsSpatialColumn(col) {
+		t.Error("select column lost its spatial flag")
+	}
+	if col.GoType() != "string" {
+		t.Errorf("GoType() = %q, want %q", col.GoType(), "string")
+	}
+	fn := restored.Where.(FuncExpr)
+	if fn.Name != "WITHIN_RADIUS" || !IsSpatialColumn(fn.Args[0].(ColumnExpr).Column) {
+		t.Errorf("WithinRadius did not round-trip: %+v", fn)
+	}
+	if IsSpatialColumn(SimpleColumn{Table_: "stores", Name_: "name", GoType_: "string"}) {
+		t.Error("plain column reported as spatial")
+	}
+}
+
+func TestSerialize_TimestamptzColumn_KeepsUTC(t *testing.T) {
+	at := TimestamptzColumn{Table: "events", Name: "occurred_at"}
+	original := &AST{
+		Kind:       SelectQuery,
+		FromTable:  TableRef{Name: "events"},
+		SelectCols: []SelectExpr{{Expr: ColumnExpr{Column: at}}},
+		Where:      at.Gt(ParamExpr{Name: "since", GoType: "time.Time"}),
+	}
+
+	restored := DeserializeAST(SerializeAST(original))
+
+	col := restored.SelectCols[0].Expr.(ColumnExpr).Column
+	if !IsUTCColumn(col) {
+		t.Error("select column lost its UTC flag")
+	}
+	if col.GoType() != "time.Time" {
+		t.Errorf("GoType() = %q, want %q", col.GoType(), "time.Time")
+	}
+	if IsUTCColumn(TimeColumn{Table: "events", Name: "created_at"}) {
+		t.Error("datetime column reported as UTC")
+	}
+}
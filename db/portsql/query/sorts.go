@@ -0,0 +1,76 @@
+package query
+
+import "fmt"
+
+// Sort is an alternate order of a paginated query, selected by name at
+// runtime. The generated method has a Sort field in its params type: ""
+// keeps the cursor columns of MustDefinePaginated, while the name of a Sort
+// orders and pages by its columns instead.
+type Sort struct {
+	Name    string
+	Columns []OrderByExpr
+}
+
+// SortBy returns the sort name ordering by cols. As with the cursor columns
+// of MustDefinePaginated, the last column must be unique (e.g. public_id)
+// so that every row has its own cursor.
+func SortBy(name string, cols ...OrderByExpr) Sort {
+	return Sort{Name: name, Columns: cols}
+}
+
+// MustDefineSorts adds alternate sort orders to the paginated query name,
+// which must already be registered:
+//
+//	func init() {
+//	    query.MustDefinePaginated("ListPosts", ...)
+//	    query.MustDefineSorts("ListPosts",
+//	        query.SortBy("title", schema.Posts.Title().Asc(), schema.Posts.PublicId().Asc()),
+//	        query.SortBy("updated_at", schema.Posts.UpdatedAt().Desc(), schema.Posts.PublicId().Desc()),
+//	    )
+//	}
+//
+// The generated ListPosts orders by title when params.Sort is "title", and
+// its cursors remember the sort they were issued for.
+//
+// MustDefineSorts panics if the query is not a registered paginated query,
+// or a sort has no name, no columns, a column that is not a plain column,
+// or the name of another sort.
+func MustDefineSorts(name string, sorts ...Sort) {
+	if err := defineSorts(name, sorts); err != nil {
+		panic(err.Error())
+	}
+}
+
+func defineSorts(name string, sorts []Sort) error {
+	v, ok := registry.Load(name)
+	if !ok {
+		return fmt.Errorf("sorts for unknown query %q", name)
+	}
+	rq := v.(RegisteredQuery)
+	if rq.ReturnType != ReturnPaginated {
+		return fmt.Errorf("sorts for query %q: only paginated queries can be sorted", name)
+	}
+
+	used := make(map[string]bool)
+	for _, s := range rq.Sorts {
+		used[s.Name] = true
+	}
+	for _, s := range sorts {
+		if s.Name == "" || len(s.Columns) == 0 {
+			return fmt.Errorf("sorts for query %q: a sort needs a name and columns", name)
+		}
+		for _, ob := range s.Columns {
+			if _, ok := ob.Expr.(ColumnExpr); !ok {
+				return fmt.Errorf("sorts for query %q: sort %q orders by an expression, not a column", name, s.Name)
+			}
+		}
+		if used[s.Name] {
+			return fmt.Errorf("sorts for query %q: sort %q is already defined", name, s.Name)
+		}
+		used[s.Name] = true
+	}
+
+	rq.Sorts = append(rq.Sorts, sorts...)
+	registry.Store(name, rq)
+	return nil
+}
@@ -0,0 +1,76 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMustDefineSorts(t *testing.T) {
+	ClearRegistry()
+
+	posts := mockTable{name: "posts"}
+	title := StringColumn{Table: "posts", Name: "title"}
+	publicID := StringColumn{Table: "posts", Name: "public_id"}
+	createdAt := TimeColumn{Table: "posts", Name: "created_at"}
+	MustDefinePaginated("ListPosts", From(posts).Select(title).Build(), createdAt.Desc(), publicID.Desc())
+	MustDefineSorts("ListPosts", SortBy("title", title.Asc(), publicID.Asc()))
+
+	sorts := GetRegisteredQueries()["ListPosts"].Sorts
+	if len(sorts) != 1 || sorts[0].Name != "title" || len(sorts[0].Columns) != 2 {
+		t.Fatalf("unexpected sorts %+v", sorts)
+	}
+
+	data, err := SerializeQueries()
+	if err != nil {
+		t.Fatalf("SerializeQueries failed: %v", err)
+	}
+	var serialized []SerializedQuery
+	if err := json.Unmarshal(data, &serialized); err != nil {
+		t.Fatalf("failed to decode serialized queries: %v", err)
+	}
+	want := []SerializedSort{{Name: "title", Columns: []SerializedColumn{
+		{Table: "posts", Name: "title", GoType: "string", Ascending: true},
+		{Table: "posts", Name: "public_id", GoType: "string", Ascending: true},
+	}}}
+	if len(serialized) != 1 || !reflect.DeepEqual(serialized[0].Sorts, want) {
+		t.Errorf("serialized queries = %s, want sorts %+v", data, want)
+	}
+}
+
+func TestMustDefineSorts_Errors(t *testing.T) {
+	ClearRegistry()
+
+	posts := mockTable{name: "posts"}
+	title := StringColumn{Table: "posts", Name: "title"}
+	createdAt := TimeColumn{Table: "posts", Name: "created_at"}
+	MustDefinePaginated("ListPosts", From(posts).Select(title).Build(), createdAt.Desc())
+	MustDefineMany("AllPosts", From(posts).Select(title).Build())
+	MustDefineSorts("ListPosts", SortBy("title", title.Asc()))
+
+	tests := []struct {
+		name    string
+		query   string
+		sorts   []Sort
+		wantErr string
+	}{
+		{"unknown query", "ListComments", []Sort{SortBy("title", title.Asc())}, "unknown query"},
+		{"not paginated", "AllPosts", []Sort{SortBy("title", title.Asc())}, "only paginated"},
+		{"no name", "ListPosts", []Sort{SortBy("", title.Asc())}, "needs a name and columns"},
+		{"no columns", "ListPosts", []Sort{SortBy("newest")}, "needs a name and columns"},
+		{"expression", "ListPosts", []Sort{SortBy("lower", OrderByExpr{Expr: ParamExpr{Name: "x", GoType: "string"}})}, "not a column"},
+		{"duplicate", "ListPosts", []Sort{SortBy("title", title.Desc())}, "already defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := defineSorts(tt.query, tt.sorts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+	if n := len(GetRegisteredQueries()["ListPosts"].Sorts); n != 1 {
+		t.Errorf("failed definitions should not add sorts, got %d", n)
+	}
+}
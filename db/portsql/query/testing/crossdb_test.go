@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/shipq/shipq/db/portsql/query"
 	"github.com/shipq/shipq/proptest"
 )
@@ -23,6 +24,7 @@ func uniqueID(base string) string {
 }
 
 func TestCrossDB_SimpleSelect(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -105,6 +107,7 @@ func TestCrossDB_SimpleSelect(t *testing.T) {
 }
 
 func TestCrossDB_EdgeCaseStrings(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -205,6 +208,7 @@ func TestCrossDB_EdgeCaseStrings(t *testing.T) {
 }
 
 func TestCrossDB_BooleanValues(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -281,6 +285,7 @@ func TestCrossDB_BooleanValues(t *testing.T) {
 }
 
 func TestCrossDB_NullHandling(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -372,6 +377,7 @@ func TestCrossDB_NullHandling(t *testing.T) {
 }
 
 func TestCrossDB_JSONAggregation(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -514,6 +520,7 @@ func filterNullBooks(books []map[string]any) []map[string]any {
 // =============================================================================
 
 func TestCrossDB_JSONAggregation_TypedStruct(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -638,6 +645,7 @@ func TestCrossDB_JSONAggregation_TypedStruct(t *testing.T) {
 }
 
 func TestCrossDB_JSONAggregation_EmptyResult(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -729,6 +737,7 @@ func TestCrossDB_JSONAggregation_EmptyResult(t *testing.T) {
 }
 
 func TestCrossDB_JSONAggregation_NullableColumns(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -879,6 +888,7 @@ func TestCrossDB_JSONAggregation_NullableColumns(t *testing.T) {
 // =============================================================================
 
 func TestCrossDB_NestedJSONAggregation(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1047,6 +1057,7 @@ func TestCrossDB_NestedJSONAggregation(t *testing.T) {
 }
 
 func TestCrossDB_NestedJSONAggregation_EmptyInner(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1165,6 +1176,7 @@ func TestCrossDB_NestedJSONAggregation_EmptyInner(t *testing.T) {
 }
 
 func TestCrossDB_NestedJSONAggregation_EmptyOuter(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1269,6 +1281,7 @@ func TestCrossDB_NestedJSONAggregation_EmptyOuter(t *testing.T) {
 }
 
 func TestCrossDB_SoftDeleteFiltering(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1378,6 +1391,7 @@ func TestCrossDB_SoftDeleteFiltering(t *testing.T) {
 }
 
 func TestCrossDB_OrderByConsistency(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1483,6 +1497,7 @@ func TestCrossDB_OrderByConsistency(t *testing.T) {
 }
 
 func TestCrossDB_LimitOffset(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1588,6 +1603,7 @@ func TestCrossDB_LimitOffset(t *testing.T) {
 }
 
 func TestCrossDB_InClause(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1706,6 +1722,7 @@ func TestCrossDB_InClause(t *testing.T) {
 // =============================================================================
 
 func TestCrossDB_CountAggregate(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1777,6 +1794,7 @@ func TestCrossDB_CountAggregate(t *testing.T) {
 }
 
 func TestCrossDB_SelectDistinct(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1871,6 +1889,7 @@ func TestCrossDB_SelectDistinct(t *testing.T) {
 }
 
 func TestCrossDB_CountDistinctAggregate(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -1946,6 +1965,7 @@ func TestCrossDB_CountDistinctAggregate(t *testing.T) {
 }
 
 func TestCrossDB_CTE(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -2032,6 +2052,7 @@ func TestCrossDB_CTE(t *testing.T) {
 }
 
 func TestCrossDB_CTEWithJoin(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -2122,3 +2143,290 @@ func TestCrossDB_CTEWithJoin(t *testing.T) {
 		return true
 	})
 }
+
+// =============================================================================
+// JSON Column Operators
+// =============================================================================
+
+// randomMetadata returns a JSON object drawn from a small domain of keys
+// and values, so generated filters often match.
+func randomMetadata(g *proptest.Generator) map[string]any {
+	m := make(map[string]any)
+	if g.Bool() {
+		m["kind"] = []any{"a", "b", nil}[g.Intn(3)]
+	}
+	if g.Bool() {
+		m["n"] = g.IntRange(0, 2)
+	}
+	if g.Bool() {
+		m["ok"] = g.Bool()
+	}
+	if g.Bool() {
+		tags := []any{}
+		for _, tag := range []string{"x", "y", "z"} {
+			if g.Bool() {
+				tags = append(tags, tag)
+			}
+		}
+		m["tags"] = tags
+	}
+	return m
+}
+
+// extractText models JSONPath's text reading of a metadata value; ok is
+// false when the result is NULL.
+func extractText(m map[string]any, key string, index int) (string, bool) {
+	v := m[key]
+	if index >= 0 {
+		tags, _ := v.([]any)
+		if index >= len(tags) {
+			return "", false
+		}
+		v = tags[index]
+	}
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case int:
+		return fmt.Sprint(v), true
+	case bool:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// containsModel models JSONColumn.Contains for flat objects whose arrays
+// hold only scalars.
+func containsModel(doc, needle map[string]any) bool {
+	for key, want := range needle {
+		got, ok := doc[key]
+		if !ok {
+			return false
+		}
+		wantTags, isArray := want.([]any)
+		if !isArray {
+			if got != want {
+				return false
+			}
+			continue
+		}
+		gotTags, ok := got.([]any)
+		if !ok {
+			return false
+		}
+		for _, tag := range wantTags {
+			found := false
+			for _, g := range gotTags {
+				found = found || g == tag
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// insertAuthorsWithMetadata inserts n authors with random metadata and
+// returns their public IDs and documents.
+func insertAuthorsWithMetadata(t *testing.T, dbs *TestDBs, g *proptest.Generator, n int) ([]string, []map[string]any) {
+	baseID := trialCounter.Add(1)
+	publicIDs := make([]string, n)
+	docs := make([]map[string]any, n)
+	for i := range n {
+		publicIDs[i] = fmt.Sprintf("author_%d_%02d", baseID, i)
+		dbs.InsertAuthor(t, publicIDs[i], fmt.Sprintf("Author %02d", i), fmt.Sprintf("author%d_%d@test.com", baseID, i), nil, true)
+		docs[i] = randomMetadata(g)
+		data, err := json.Marshal(docs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		dbs.SetAuthorMetadata(t, publicIDs[i], string(data))
+	}
+	return publicIDs, docs
+}
+
+// idRows is the part of pgx.Rows and *sql.Rows used to read public IDs.
+type idRows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+}
+
+func scanIDs(rows idRows) ([]string, error) {
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// queryPublicIDsEach runs ast, which selects only public_id, on every
+// database with params bound by name.
+func queryPublicIDsEach(dbs *TestDBs, ast *query.AST, values map[string]any) (map[Dialect][]string, error) {
+	results := make(map[Dialect][]string)
+	for _, dialect := range AllDialects() {
+		sqlStr, params, err := CompileFor(ast, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("compile for %s: %w", dialect, err)
+		}
+		args := make([]any, len(params))
+		for i, p := range params {
+			args[i] = values[p]
+		}
+
+		var ids []string
+		switch dialect {
+		case DialectPostgres:
+			var rows pgx.Rows
+			if rows, err = dbs.Postgres.Query(context.Background(), sqlStr, args...); err == nil {
+				ids, err = scanIDs(rows)
+				rows.Close()
+			}
+		case DialectMySQL, DialectSQLite:
+			db := dbs.MySQL
+			if dialect == DialectSQLite {
+				db = dbs.SQLite
+			}
+			var rows *sql.Rows
+			if rows, err = db.Query(sqlStr, args...); err == nil {
+				ids, err = scanIDs(rows)
+				rows.Close()
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query on %s: %w\nSQL: %s", dialect, err, sqlStr)
+		}
+		results[dialect] = ids
+	}
+	return results, nil
+}
+
+// matchesEverywhere checks every database found exactly want.
+func matchesEverywhere(t *testing.T, results map[Dialect][]string, want []string) bool {
+	t.Helper()
+	for _, dialect := range AllDialects() {
+		if fmt.Sprint(results[dialect]) != fmt.Sprint(want) {
+			t.Logf("%s found %v, want %v", dialect, results[dialect], want)
+			return false
+		}
+	}
+	return true
+}
+
+func TestCrossDB_JSONExtract(t *testing.T) {
+	t.Parallel()
+	dbs, cleanup := SetupTestDBs(t)
+	if dbs == nil {
+		return
+	}
+	defer cleanup()
+
+	publicIDCol := query.StringColumn{Table: "test_authors", Name: "public_id"}
+	metadata := query.NullJSONColumn{Table: "test_authors", Name: "metadata"}
+
+	proptest.Check(t, "JSON extraction matches the same rows across databases", proptest.Config{NumTrials: 50, Verbose: true}, func(g *proptest.Generator) bool {
+		dbs.ClearAllData(t)
+		publicIDs, docs := insertAuthorsWithMetadata(t, dbs, g, 6)
+
+		key, index := []string{"kind", "n", "ok", "tags"}[g.Intn(4)], -1
+		path := "$." + key
+		if key == "tags" {
+			index = g.Intn(2)
+			path = fmt.Sprintf("$.tags[%d]", index)
+		}
+		value := []string{"a", "b", "0", "1", "true", "false", "x", "y"}[g.Intn(8)]
+
+		ast := query.From(MockTable("test_authors")).
+			Select(publicIDCol).
+			Where(metadata.Extract(path).Eq(query.Param[string]("value"))).
+			OrderBy(publicIDCol.Asc()).
+			Build()
+		results, err := queryPublicIDsEach(dbs, ast, map[string]any{"value": value})
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		want := []string{}
+		for i, doc := range docs {
+			if got, ok := extractText(doc, key, index); ok && got == value {
+				want = append(want, publicIDs[i])
+			}
+		}
+		if !matchesEverywhere(t, results, want) {
+			t.Logf("path %s = %q", path, value)
+			return false
+		}
+
+		ast = query.From(MockTable("test_authors")).
+			Select(publicIDCol).
+			Where(metadata.Extract(path).IsNull()).
+			OrderBy(publicIDCol.Asc()).
+			Build()
+		results, err = queryPublicIDsEach(dbs, ast, nil)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		want = []string{}
+		for i, doc := range docs {
+			if _, ok := extractText(doc, key, index); !ok {
+				want = append(want, publicIDs[i])
+			}
+		}
+		if !matchesEverywhere(t, results, want) {
+			t.Logf("path %s IS NULL", path)
+			return false
+		}
+		return true
+	})
+}
+
+func TestCrossDB_JSONContains(t *testing.T) {
+	t.Parallel()
+	dbs, cleanup := SetupTestDBs(t)
+	if dbs == nil {
+		return
+	}
+	defer cleanup()
+
+	publicIDCol := query.StringColumn{Table: "test_authors", Name: "public_id"}
+	metadata := query.NullJSONColumn{Table: "test_authors", Name: "metadata"}
+
+	proptest.Check(t, "JSON containment matches the same rows across databases", proptest.Config{NumTrials: 50, Verbose: true}, func(g *proptest.Generator) bool {
+		dbs.ClearAllData(t)
+		publicIDs, docs := insertAuthorsWithMetadata(t, dbs, g, 6)
+
+		needle := randomMetadata(g)
+		data, err := json.Marshal(needle)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ast := query.From(MockTable("test_authors")).
+			Select(publicIDCol).
+			Where(metadata.Contains(query.Param[string]("needle"))).
+			OrderBy(publicIDCol.Asc()).
+			Build()
+		results, err := queryPublicIDsEach(dbs, ast, map[string]any{"needle": string(data)})
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		want := []string{}
+		for i, doc := range docs {
+			if containsModel(doc, needle) {
+				want = append(want, publicIDs[i])
+			}
+		}
+		if !matchesEverywhere(t, results, want) {
+			t.Logf("contains %s", data)
+			return false
+		}
+		return true
+	})
+}
@@ -34,6 +34,7 @@ func parseMySQLTimestamp(s string) (time.Time, error) {
 
 // TestInsert_SetsTimestamps verifies that INSERT sets created_at and updated_at.
 func TestInsert_SetsTimestamps(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -118,6 +119,7 @@ func TestInsert_SetsTimestamps(t *testing.T) {
 
 // TestUpdate_OnlyChangesUpdatedAt verifies that UPDATE changes updated_at but not created_at.
 func TestUpdate_OnlyChangesUpdatedAt(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -206,6 +208,7 @@ func TestUpdate_OnlyChangesUpdatedAt(t *testing.T) {
 
 // TestSoftDelete_SetsDeletedAt verifies that soft delete sets deleted_at to NOW().
 func TestSoftDelete_SetsDeletedAt(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -274,6 +277,7 @@ func TestSoftDelete_SetsDeletedAt(t *testing.T) {
 // TestSoftDelete_ExcludesFromRegularQueries verifies that soft-deleted records
 // are excluded from queries with "deleted_at IS NULL".
 func TestSoftDelete_ExcludesFromRegularQueries(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -372,6 +376,7 @@ func TestNanoidGeneration(t *testing.T) {
 // TestCrossDatabase_TimestampConsistency verifies that all three databases
 // set timestamps (regardless of timezone differences).
 func TestCrossDatabase_TimestampConsistency(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -441,6 +446,7 @@ func TestCrossDatabase_TimestampConsistency(t *testing.T) {
 // TestInsertAndFetch_WithGeneratedPublicID simulates the full CRUD flow with
 // generated public_id.
 func TestInsertAndFetch_WithGeneratedPublicID(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -497,6 +503,7 @@ func TestInsertAndFetch_WithGeneratedPublicID(t *testing.T) {
 // TestUpdateExcludesSoftDeleted verifies that UPDATE with deleted_at IS NULL
 // does not affect soft-deleted records.
 func TestUpdateExcludesSoftDeleted(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -540,6 +547,7 @@ func TestUpdateExcludesSoftDeleted(t *testing.T) {
 // TestSoftDeleteIdempotent verifies that soft delete can be called multiple times
 // without error (idempotent operation).
 func TestSoftDeleteIdempotent(t *testing.T) {
+	t.Parallel()
 	dbs, cleanup := SetupTestDBs(t)
 	if dbs == nil {
 		return
@@ -8,10 +8,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jackc/pgx/v5"
 	"github.com/shipq/shipq/db/portsql/query"
 	"github.com/shipq/shipq/db/portsql/query/compile"
@@ -55,51 +57,78 @@ func AllDialects() []Dialect {
 
 // SetupTestDBs creates test databases with identical schemas.
 // Returns nil for any database that is unavailable, allowing tests to skip.
+//
+// Every call gets its own Postgres schema, MySQL database and SQLite
+// in-memory database, so tests that use it can run with t.Parallel.
+// The cleanup function drops them again.
 func SetupTestDBs(t *testing.T) (*TestDBs, func()) {
 	t.Helper()
 
-	pgConn := setupPostgres(t)
-	myDB := setupMySQL(t)
+	name := testSchemaName(t)
+	pgConn := setupPostgres(t, name)
+	myDB := setupMySQL(t, name)
 	sqDB := setupSQLite(t)
 
-	// All databases must be available for cross-db tests
-	if pgConn == nil || myDB == nil || sqDB == nil {
+	dbs := &TestDBs{
+		Postgres: pgConn,
+		MySQL:    myDB,
+		SQLite:   sqDB,
+	}
+	cleanup := func() {
 		if pgConn != nil {
+			pgConn.Exec(context.Background(), "DROP SCHEMA IF EXISTS "+name+" CASCADE")
 			pgConn.Close(context.Background())
 		}
 		if myDB != nil {
+			myDB.Exec("DROP DATABASE IF EXISTS " + name)
 			myDB.Close()
 		}
 		if sqDB != nil {
 			sqDB.Close()
 		}
-		t.Skip("Not all databases available for cross-database testing")
-		return nil, func() {}
 	}
 
-	dbs := &TestDBs{
-		Postgres: pgConn,
-		MySQL:    myDB,
-		SQLite:   sqDB,
+	// All databases must be available for cross-db tests
+	if pgConn == nil || myDB == nil || sqDB == nil {
+		cleanup()
+		t.Skip("Not all databases available for cross-database testing")
+		return nil, func() {}
 	}
 
 	// Create identical schemas on all databases
 	createTestSchema(t, dbs)
 
-	cleanup := func() {
-		pgConn.Close(context.Background())
-		myDB.Close()
-		sqDB.Close()
-	}
-
 	return dbs, cleanup
 }
 
-// setupPostgres connects to Postgres.
+// schemaCounter keeps schema names unique across the tests of one process.
+var schemaCounter atomic.Int64
+
+// testSchemaName returns a Postgres schema and MySQL database name that no
+// other running test uses: the test name plus the process ID and a counter,
+// so concurrent `go test` runs against the same servers don't collide either.
+// It fits within both databases' identifier limits (63 and 64 bytes).
+func testSchemaName(t *testing.T) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimPrefix(t.Name(), "Test")) {
+		if b.Len() == 32 {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return fmt.Sprintf("portsql_%s_%d_%d", b.String(), os.Getpid(), schemaCounter.Add(1))
+}
+
+// setupPostgres connects to Postgres and switches the connection to a new
+// schema with the given name.
 //
 // Checks POSTGRES_TEST_URL first (for CI / custom setups), then falls back
 // to the local unix socket used by the nix-shell dev environment.
-func setupPostgres(t *testing.T) *pgx.Conn {
+func setupPostgres(t *testing.T, schema string) *pgx.Conn {
 	t.Helper()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -116,14 +145,27 @@ func setupPostgres(t *testing.T) *pgx.Conn {
 		return nil
 	}
 
+	// Unqualified table names in the compiled queries resolve to the
+	// test's own schema through search_path.
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		conn.Close(context.Background())
+		t.Fatalf("postgres create schema failed: %v", err)
+	}
+	if _, err := conn.Exec(ctx, "SET search_path TO "+schema); err != nil {
+		conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		conn.Close(context.Background())
+		t.Fatalf("postgres set search_path failed: %v", err)
+	}
+
 	return conn
 }
 
-// setupMySQL connects to MySQL.
+// setupMySQL connects to MySQL, creates a database with the given name and
+// returns a connection pool that uses it.
 //
 // Checks MYSQL_TEST_URL first (for CI / custom setups), then falls back
 // to the local unix socket used by the nix-shell dev environment.
-func setupMySQL(t *testing.T) *sql.DB {
+func setupMySQL(t *testing.T, database string) *sql.DB {
 	t.Helper()
 
 	dsn := os.Getenv("MYSQL_TEST_URL")
@@ -147,27 +189,42 @@ func setupMySQL(t *testing.T) *sql.DB {
 			return nil
 		}
 
-		// First connect without database to create it if needed
-		dsnNoDb := "root@unix(" + socketPath + ")/?multiStatements=true"
-		tempDb, err := sql.Open("mysql", dsnNoDb)
-		if err != nil {
-			t.Logf("MySQL unavailable: %v", err)
-			return nil
-		}
-		tempDb.Exec("CREATE DATABASE IF NOT EXISTS test")
-		tempDb.Close()
+		dsn = "root@unix(" + socketPath + ")/?multiStatements=true"
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Logf("MySQL unavailable: %v", err)
+		return nil
+	}
 
-		dsn = "root@unix(" + socketPath + ")/test?multiStatements=true"
+	// Connect without a database first to create the test's own
+	cfg.DBName = ""
+	admin, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Logf("MySQL unavailable: %v", err)
+		return nil
+	}
+	defer admin.Close()
+	if err := admin.Ping(); err != nil {
+		t.Logf("MySQL unavailable: %v", err)
+		return nil
+	}
+	if _, err := admin.Exec("CREATE DATABASE " + database); err != nil {
+		t.Fatalf("mysql create database failed: %v", err)
 	}
 
-	db, err := sql.Open("mysql", dsn)
+	cfg.DBName = database
+	db, err := sql.Open("mysql", cfg.FormatDSN())
 	if err != nil {
+		admin.Exec("DROP DATABASE " + database)
 		t.Logf("MySQL unavailable: %v", err)
 		return nil
 	}
 
 	if err := db.Ping(); err != nil {
 		db.Close()
+		admin.Exec("DROP DATABASE " + database)
 		t.Logf("MySQL unavailable: %v", err)
 		return nil
 	}
@@ -175,7 +232,9 @@ func setupMySQL(t *testing.T) *sql.DB {
 	return db
 }
 
-// setupSQLite creates an in-memory SQLite database
+// setupSQLite creates an in-memory SQLite database. The pool is limited to
+// one connection because every connection to :memory: opens a new, empty
+// database.
 func setupSQLite(t *testing.T) *sql.DB {
 	t.Helper()
 
@@ -184,6 +243,7 @@ func setupSQLite(t *testing.T) *sql.DB {
 		t.Logf("SQLite unavailable: %v", err)
 		return nil
 	}
+	db.SetMaxOpenConns(1)
 
 	if err := db.Ping(); err != nil {
 		db.Close()
@@ -194,30 +254,13 @@ func setupSQLite(t *testing.T) *sql.DB {
 	return db
 }
 
-// createTestSchema creates identical schemas on all databases
+// createTestSchema creates identical tables on all databases, in the
+// schemas SetupTestDBs made for the test
 func createTestSchema(t *testing.T, dbs *TestDBs) {
 	t.Helper()
 
 	ctx := context.Background()
 
-	// Drop existing tables first (child tables before parents)
-	// Postgres
-	dbs.Postgres.Exec(ctx, "DROP TABLE IF EXISTS test_chapters CASCADE")
-	dbs.Postgres.Exec(ctx, "DROP TABLE IF EXISTS test_books CASCADE")
-	dbs.Postgres.Exec(ctx, "DROP TABLE IF EXISTS test_authors CASCADE")
-
-	// MySQL
-	dbs.MySQL.Exec("SET FOREIGN_KEY_CHECKS = 0")
-	dbs.MySQL.Exec("DROP TABLE IF EXISTS test_chapters")
-	dbs.MySQL.Exec("DROP TABLE IF EXISTS test_books")
-	dbs.MySQL.Exec("DROP TABLE IF EXISTS test_authors")
-	dbs.MySQL.Exec("SET FOREIGN_KEY_CHECKS = 1")
-
-	// SQLite
-	dbs.SQLite.Exec("DROP TABLE IF EXISTS test_chapters")
-	dbs.SQLite.Exec("DROP TABLE IF EXISTS test_books")
-	dbs.SQLite.Exec("DROP TABLE IF EXISTS test_authors")
-
 	// Create authors table
 	pgAuthorsSQL := `
 		CREATE TABLE test_authors (
@@ -229,7 +272,8 @@ func createTestSchema(t *testing.T, dbs *TestDBs) {
 			active BOOLEAN NOT NULL DEFAULT TRUE,
 			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
-			deleted_at TIMESTAMP
+			deleted_at TIMESTAMP,
+			metadata JSONB
 		)
 	`
 
@@ -243,7 +287,8 @@ func createTestSchema(t *testing.T, dbs *TestDBs) {
 			active TINYINT(1) NOT NULL DEFAULT 1,
 			created_at DATETIME NOT NULL DEFAULT NOW(),
 			updated_at DATETIME NOT NULL DEFAULT NOW(),
-			deleted_at DATETIME
+			deleted_at DATETIME,
+			metadata JSON
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin
 	`
 
@@ -257,7 +302,8 @@ func createTestSchema(t *testing.T, dbs *TestDBs) {
 			active INTEGER NOT NULL DEFAULT 1,
 			created_at TEXT NOT NULL DEFAULT (datetime('now')),
 			updated_at TEXT NOT NULL DEFAULT (datetime('now')),
-			deleted_at TEXT
+			deleted_at TEXT,
+			metadata TEXT
 		)
 	`
 
@@ -145,7 +145,9 @@ func (dbs *TestDBs) InsertChapter(t *testing.T, publicID string, bookPublicID st
 	}
 }
 
-// ClearAllData removes all test data from all databases
+// ClearAllData removes all test data from all databases. Tests call it
+// between proptest trials; other tests never see the rows, since each
+// SetupTestDBs call has its own schemas.
 func (dbs *TestDBs) ClearAllData(t *testing.T) {
 	t.Helper()
 
@@ -195,3 +197,35 @@ func (dbs *TestDBs) SoftDeleteAuthor(t *testing.T, publicID string) {
 		t.Fatalf("sqlite soft delete failed: %v", err)
 	}
 }
+
+// SetAuthorMetadata stores a JSON document in an author's metadata column
+// in all databases.
+func (dbs *TestDBs) SetAuthorMetadata(t *testing.T, publicID, metadata string) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	// Postgres
+	if _, err := dbs.Postgres.Exec(ctx,
+		"UPDATE test_authors SET metadata = CAST($1 AS jsonb) WHERE public_id = $2",
+		metadata, publicID,
+	); err != nil {
+		t.Fatalf("postgres set metadata failed: %v", err)
+	}
+
+	// MySQL
+	if _, err := dbs.MySQL.Exec(
+		"UPDATE test_authors SET metadata = ? WHERE public_id = ?",
+		metadata, publicID,
+	); err != nil {
+		t.Fatalf("mysql set metadata failed: %v", err)
+	}
+
+	// SQLite
+	if _, err := dbs.SQLite.Exec(
+		"UPDATE test_authors SET metadata = ? WHERE public_id = ?",
+		metadata, publicID,
+	); err != nil {
+		t.Fatalf("sqlite set metadata failed: %v", err)
+	}
+}
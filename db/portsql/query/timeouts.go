@@ -0,0 +1,45 @@
+package query
+
+import (
+	"fmt"
+	"time"
+)
+
+// MustDefineTimeout bounds the query name, which must already be registered,
+// by d instead of the [db] query_timeout default of shipq.ini:
+//
+//	func init() {
+//	    query.MustDefineMany("SearchPosts", ...)
+//	    query.MustDefineTimeout("SearchPosts", 30*time.Second)
+//	}
+//
+// The generated method runs the query under context.WithTimeout and returns
+// a *queries.TimeoutError (errors.Is(err, queries.ErrTimeout)) when the
+// deadline passes. The Each and Iter variants of a ReturnMany query read
+// rows at the caller's pace, so only the caller's context bounds them.
+//
+// MustDefineTimeout panics if the query is not registered, is a
+// materialized view, or d is not a positive whole number of milliseconds.
+func MustDefineTimeout(name string, d time.Duration) {
+	if err := defineTimeout(name, d); err != nil {
+		panic(err.Error())
+	}
+}
+
+func defineTimeout(name string, d time.Duration) error {
+	v, ok := registry.Load(name)
+	if !ok {
+		return fmt.Errorf("timeout for unknown query %q", name)
+	}
+	rq := v.(RegisteredQuery)
+	if rq.ReturnType == ReturnMaterializedView {
+		return fmt.Errorf("timeout for query %q: materialized view refreshes have no timeout", name)
+	}
+	if d <= 0 || d%time.Millisecond != 0 {
+		return fmt.Errorf("timeout for query %q: %s is not a positive number of milliseconds", name, d)
+	}
+
+	rq.Timeout = d
+	registry.Store(name, rq)
+	return nil
+}
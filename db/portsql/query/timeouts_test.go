@@ -0,0 +1,59 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMustDefineTimeout(t *testing.T) {
+	ClearRegistry()
+
+	posts := mockTable{name: "posts"}
+	title := StringColumn{Table: "posts", Name: "title"}
+	MustDefineMany("SearchPosts", From(posts).Select(title).Build())
+	MustDefineTimeout("SearchPosts", 30*time.Second)
+
+	if got := GetRegisteredQueries()["SearchPosts"].Timeout; got != 30*time.Second {
+		t.Fatalf("Timeout = %s, want 30s", got)
+	}
+
+	data, err := SerializeQueries()
+	if err != nil {
+		t.Fatalf("SerializeQueries failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"timeout_ms": 30000`) {
+		t.Errorf("serialized queries should contain the timeout, got %s", data)
+	}
+}
+
+func TestMustDefineTimeout_Errors(t *testing.T) {
+	ClearRegistry()
+
+	posts := mockTable{name: "posts"}
+	title := StringColumn{Table: "posts", Name: "title"}
+	MustDefineMany("SearchPosts", From(posts).Select(title).Build())
+
+	tests := []struct {
+		name    string
+		query   string
+		timeout time.Duration
+		wantErr string
+	}{
+		{"unknown query", "ListComments", time.Second, "unknown query"},
+		{"zero", "SearchPosts", 0, "not a positive number of milliseconds"},
+		{"negative", "SearchPosts", -time.Second, "not a positive number of milliseconds"},
+		{"sub-millisecond", "SearchPosts", 1500 * time.Microsecond, "not a positive number of milliseconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := defineTimeout(tt.query, tt.timeout)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+	if got := GetRegisteredQueries()["SearchPosts"].Timeout; got != 0 {
+		t.Errorf("failed definitions should not set a timeout, got %s", got)
+	}
+}
@@ -0,0 +1,101 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReturnMaterializedView marks a registered SELECT as a materialized view
+// rather than a query. The runner generates a read method
+// (List<View>) and a refresh method (Refresh<View>) instead of a single
+// method named after the registration.
+const ReturnMaterializedView QueryReturnType = "materialized_view"
+
+// ViewOption configures a materialized view registration.
+type ViewOption func(*RegisteredQuery)
+
+// RefreshSchedule makes the worker refresh the view on a cron-style
+// schedule: a 5-field cron expression ("*/15 * * * *") or one of
+// "@hourly", "@daily", "@weekly" and "@every <duration>". The schedule is
+// validated when the worker starts.
+func RefreshSchedule(spec string) ViewOption {
+	return func(rq *RegisteredQuery) {
+		rq.RefreshSchedule = spec
+	}
+}
+
+// MustDefineMaterializedView registers a denormalized, read-only view of
+// ast under name, which is also the view's table name in the database
+// (e.g. "daily_order_totals").
+//
+// On Postgres the view is a CREATE MATERIALIZED VIEW; on MySQL and SQLite it
+// is a plain table that each refresh repopulates inside a transaction. The
+// view is created on first refresh, so there is no migration to write.
+//
+// MustDefineMaterializedView panics if:
+//   - name is not a lower snake_case identifier
+//   - ast is nil or not a SELECT
+//   - a query or view with the same name is already registered
+//
+// Views cannot take parameters; `shipq db compile` rejects any that do.
+//
+//	func init() {
+//	    query.MustDefineMaterializedView("daily_order_totals",
+//	        query.From(schema.Orders).
+//	            Select(schema.Orders.Day()).
+//	            SelectExprAs(query.Sum(schema.Orders.Total()), "total").
+//	            GroupBy(schema.Orders.Day()).
+//	            Build(),
+//	        query.RefreshSchedule("@hourly"),
+//	    )
+//	}
+//
+// The generated runner has ListDailyOrderTotals and RefreshDailyOrderTotals.
+func MustDefineMaterializedView(name string, ast *AST, opts ...ViewOption) *AST {
+	ast, err := TryDefineMaterializedView(name, ast, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return ast
+}
+
+// TryDefineMaterializedView registers a materialized view.
+// Unlike MustDefineMaterializedView, this returns an error instead of panicking.
+func TryDefineMaterializedView(name string, ast *AST, opts ...ViewOption) (*AST, error) {
+	if name == "" {
+		return nil, errors.New("view name cannot be empty")
+	}
+	if !isSnakeIdentifier(name) {
+		return nil, fmt.Errorf("view name %q must be a lower snake_case identifier", name)
+	}
+	if ast == nil {
+		return nil, errors.New("view AST cannot be nil")
+	}
+	if ast.Kind != SelectQuery {
+		return nil, fmt.Errorf("view %s must be a SELECT query", name)
+	}
+	rq := RegisteredQuery{
+		AST:        ast,
+		ReturnType: ReturnMaterializedView,
+	}
+	for _, opt := range opts {
+		opt(&rq)
+	}
+	if !register(name, rq) {
+		return nil, errors.New("duplicate query name: " + name)
+	}
+	return ast, nil
+}
+
+// isSnakeIdentifier reports whether s is a lower snake_case identifier.
+func isSnakeIdentifier(s string) bool {
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMustDefineMaterializedView(t *testing.T) {
+	ClearRegistry()
+
+	orders := mockTable{name: "orders"}
+	customer := StringColumn{Table: "orders", Name: "customer"}
+
+	MustDefineMaterializedView("customer_totals",
+		From(orders).Select(customer).GroupBy(customer).Build(),
+		RefreshSchedule("@hourly"),
+	)
+
+	rq, ok := GetRegisteredQueries()["customer_totals"]
+	if !ok {
+		t.Fatal("view was not registered")
+	}
+	if rq.ReturnType != ReturnMaterializedView || rq.RefreshSchedule != "@hourly" {
+		t.Errorf("unexpected registration %+v", rq)
+	}
+
+	data, err := SerializeQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"refresh_schedule": "@hourly"`) {
+		t.Errorf("refresh schedule not serialized:\n%s", data)
+	}
+}
+
+func TestTryDefineMaterializedView_Errors(t *testing.T) {
+	ClearRegistry()
+
+	orders := mockTable{name: "orders"}
+	customer := StringColumn{Table: "orders", Name: "customer"}
+	sel := From(orders).Select(customer).Build()
+
+	for _, tc := range []struct {
+		name string
+		ast  *AST
+		want string
+	}{
+		{"CustomerTotals", sel, "snake_case"},
+		{"9lives", sel, "snake_case"},
+		{"customer_totals", nil, "cannot be nil"},
+		{"customer_totals", Delete(orders).Build(), "must be a SELECT"},
+	} {
+		if _, err := TryDefineMaterializedView(tc.name, tc.ast); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("TryDefineMaterializedView(%q) error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+
+	if _, err := TryDefineMaterializedView("customer_totals", sel); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TryDefineMaterializedView("customer_totals", sel); err == nil {
+		t.Error("expected duplicate name error")
+	}
+}
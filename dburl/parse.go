@@ -12,15 +12,21 @@ const (
 	DialectPostgres = "postgres"
 	DialectMySQL    = "mysql"
 	DialectSQLite   = "sqlite"
+	DialectMSSQL    = "mssql"
 )
 
+// SQLiteMemoryURL is the URL of a shared-cache in-memory SQLite database.
+// Every connection in the process sees the same database, which disappears
+// once its last connection closes.
+const SQLiteMemoryURL = "sqlite://:memory:?cache=shared"
+
 var (
 	ErrUnknownDialect = errors.New("unknown database dialect")
 	ErrInvalidURL     = errors.New("invalid database URL")
 )
 
-// InferDialectFromDBUrl returns the dialect ("postgres", "mysql", or "sqlite")
-// based on the URL scheme.
+// InferDialectFromDBUrl returns the dialect ("postgres", "mysql", "sqlite", or
+// "mssql") based on the URL scheme.
 func InferDialectFromDBUrl(dbURL string) (string, error) {
 	u, err := url.Parse(dbURL)
 	if err != nil {
@@ -35,6 +41,8 @@ func InferDialectFromDBUrl(dbURL string) (string, error) {
 		return DialectMySQL, nil
 	case "sqlite", "sqlite3":
 		return DialectSQLite, nil
+	case "sqlserver", "mssql":
+		return DialectMSSQL, nil
 	default:
 		return "", fmt.Errorf("%w: %s", ErrUnknownDialect, scheme)
 	}
@@ -61,6 +69,19 @@ func IsLocalhost(dbURL string) bool {
 	return host == "localhost" || host == "127.0.0.1" || host == "::1"
 }
 
+// IsSQLiteMemory reports whether dbURL names an in-memory SQLite database,
+// e.g. "sqlite://:memory:?cache=shared" or "sqlite::memory:".
+func IsSQLiteMemory(dbURL string) bool {
+	lower := strings.ToLower(dbURL)
+	for _, prefix := range []string{"sqlite3://", "sqlite://", "sqlite3:", "sqlite:"} {
+		if strings.HasPrefix(lower, prefix) {
+			rest := strings.TrimPrefix(lower[len(prefix):], "file:")
+			return strings.HasPrefix(rest, ":memory:")
+		}
+	}
+	return false
+}
+
 // BuildPostgresURL constructs a PostgreSQL connection URL.
 // Format: postgres://user@host:port/dbname
 func BuildPostgresURL(dbname, user, host string, port int) string {
@@ -73,6 +94,13 @@ func BuildMySQLURL(dbname, user, host string, port int) string {
 	return fmt.Sprintf("mysql://%s@%s:%d/%s", user, host, port, dbname)
 }
 
+// BuildMSSQLURL constructs a SQL Server connection URL in the form the
+// go-mssqldb driver expects, with the database as a query parameter.
+// Format: sqlserver://user@host:port?database=dbname
+func BuildMSSQLURL(dbname, user, host string, port int) string {
+	return fmt.Sprintf("sqlserver://%s@%s:%d?database=%s", user, host, port, url.QueryEscape(dbname))
+}
+
 // BuildSQLiteURL constructs a SQLite connection URL.
 // Format: sqlite:///path/to/file.db
 func BuildSQLiteURL(filepath string) string {
@@ -85,13 +113,24 @@ func BuildSQLiteURL(filepath string) string {
 }
 
 // ParseDatabaseName extracts the database name from a URL.
-// Returns an empty string if no database name is present.
+// Returns an empty string if no database name is present, and ":memory:"
+// for an in-memory SQLite database.
 func ParseDatabaseName(dbURL string) string {
+	if IsSQLiteMemory(dbURL) {
+		return ":memory:"
+	}
+
 	u, err := url.Parse(dbURL)
 	if err != nil {
 		return ""
 	}
 
+	// SQL Server URLs carry the database in ?database=; the path names an
+	// instance.
+	if isMSSQLScheme(u.Scheme) {
+		return u.Query().Get("database")
+	}
+
 	// Remove leading slash from path
 	path := strings.TrimPrefix(u.Path, "/")
 	return path
@@ -104,14 +143,33 @@ func WithDatabaseName(dbURL, dbname string) (string, error) {
 		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
 	}
 
+	if isMSSQLScheme(u.Scheme) {
+		q := u.Query()
+		q.Set("database", dbname)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+
 	u.Path = "/" + dbname
 	return u.String(), nil
 }
 
+// isMSSQLScheme reports whether scheme selects the SQL Server dialect.
+func isMSSQLScheme(scheme string) bool {
+	scheme = strings.ToLower(scheme)
+	return scheme == "sqlserver" || scheme == "mssql"
+}
+
 // TestDatabaseURL returns the test database URL for a given dev URL.
 // Convention: test database is named {dev_db}_test
 // For SQLite: foo.db -> foo_test.db
+// An in-memory SQLite URL is returned unchanged, since every process gets
+// its own database.
 func TestDatabaseURL(devURL string) (string, error) {
+	if IsSQLiteMemory(devURL) {
+		return devURL, nil
+	}
+
 	devDBName := ParseDatabaseName(devURL)
 	if devDBName == "" {
 		return "", fmt.Errorf("could not parse database name from URL")
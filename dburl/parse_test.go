@@ -37,6 +37,16 @@ func TestInferDialectFromDBUrl(t *testing.T) {
 			url:  "sqlite3:///path/to/db.sqlite",
 			want: DialectSQLite,
 		},
+		{
+			name: "sqlserver URL",
+			url:  "sqlserver://sa@localhost:1433?database=mydb",
+			want: DialectMSSQL,
+		},
+		{
+			name: "mssql URL",
+			url:  "mssql://sa@localhost:1433?database=mydb",
+			want: DialectMSSQL,
+		},
 		{
 			name:    "unknown scheme",
 			url:     "mongodb://localhost/db",
@@ -213,6 +223,14 @@ func TestBuildMySQLURL(t *testing.T) {
 	}
 }
 
+func TestBuildMSSQLURL(t *testing.T) {
+	got := BuildMSSQLURL("mydb", "sa", "localhost", 1433)
+	want := "sqlserver://sa@localhost:1433?database=mydb"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func TestBuildSQLiteURL(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -282,6 +300,16 @@ func TestParseDatabaseName(t *testing.T) {
 			url:  "sqlite:///path/to/db.sqlite",
 			want: "path/to/db.sqlite",
 		},
+		{
+			name: "sqlite in-memory URL",
+			url:  SQLiteMemoryURL,
+			want: ":memory:",
+		},
+		{
+			name: "sqlserver URL",
+			url:  "sqlserver://sa@myserver.database.windows.net:1433?database=mydb",
+			want: "mydb",
+		},
 	}
 
 	for _, tt := range tests {
@@ -321,6 +349,16 @@ func TestTestDatabaseURL(t *testing.T) {
 			devURL: "sqlite:///path/to/myapp",
 			want:   "sqlite:///path/to/myapp_test",
 		},
+		{
+			name:   "sqlite in-memory URL is unchanged",
+			devURL: "sqlite://:memory:?cache=shared",
+			want:   "sqlite://:memory:?cache=shared",
+		},
+		{
+			name:   "sqlserver URL",
+			devURL: "sqlserver://sa@localhost:1433?database=myapp",
+			want:   "sqlserver://sa@localhost:1433?database=myapp_test",
+		},
 		{
 			name:    "empty database name",
 			devURL:  "postgres://user@localhost:5432/",
@@ -352,6 +390,38 @@ func TestTestDatabaseURL(t *testing.T) {
 	}
 }
 
+func TestIsSQLiteMemory(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"sqlite://:memory:?cache=shared", true},
+		{"sqlite::memory:", true},
+		{"sqlite3://:memory:", true},
+		{"sqlite:file::memory:?cache=shared", true},
+		{"SQLITE://:MEMORY:", true},
+		{"sqlite:///path/to/db.sqlite", false},
+		{"sqlite:./memory.db", false},
+		{"postgres://localhost:5432/:memory:", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := IsSQLiteMemory(tt.url); got != tt.want {
+				t.Errorf("IsSQLiteMemory(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+
+	dialect, err := InferDialectFromDBUrl(SQLiteMemoryURL)
+	if err != nil || dialect != DialectSQLite {
+		t.Errorf("InferDialectFromDBUrl(%q) = %q, %v", SQLiteMemoryURL, dialect, err)
+	}
+	if !IsLocalhost(SQLiteMemoryURL) {
+		t.Errorf("IsLocalhost(%q) = false", SQLiteMemoryURL)
+	}
+}
+
 func TestWithDatabaseName(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -378,6 +448,12 @@ func TestWithDatabaseName(t *testing.T) {
 			dbname: "newdb",
 			want:   "postgres://user@localhost:5432/newdb",
 		},
+		{
+			name:   "sqlserver URL keeps other parameters",
+			url:    "sqlserver://sa@localhost:1433?database=olddb&encrypt=true",
+			dbname: "newdb",
+			want:   "sqlserver://sa@localhost:1433?database=newdb&encrypt=true",
+		},
 		{
 			name:    "invalid URL",
 			url:     "://invalid",
@@ -28,12 +28,21 @@ var NanoidFS embed.FS
 //go:embed httputil/*.go
 var HttputilFS embed.FS
 
+//go:embed validate/*.go
+var ValidateFS embed.FS
+
 //go:embed filestorage/*.go
 var FilestorageFS embed.FS
 
 //go:embed channel/*.go
 var ChannelFS embed.FS
 
+//go:embed grpcbridge/*.go
+var GrpcbridgeFS embed.FS
+
+//go:embed telemetry/*.go
+var TelemetryFS embed.FS
+
 //go:embed llm/*.go
 var LlmFS embed.FS
 
@@ -63,6 +72,12 @@ var DdlFS embed.FS
 //go:embed db/portsql/ref/*.go
 var RefFS embed.FS
 
+//go:embed db/portsql/query/rowdiff/*.go
+var QueryRowdiffFS embed.FS
+
+//go:embed db/portsql/query/memdb/*.go
+var QueryMemdbFS embed.FS
+
 //go:embed proptest/*.go
 var ProptestFS embed.FS
 
@@ -71,6 +86,9 @@ var ProptestFS embed.FS
 //go:embed dag/*.go
 var DagFS embed.FS
 
+//go:embed openapidiff/*.go
+var OpenapidiffFS embed.FS
+
 // Category D: static assets (JS, CSS) for development tooling
 
 //go:embed assets/*
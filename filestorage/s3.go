@@ -5,6 +5,7 @@ package filestorage
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"time"
 
@@ -189,6 +190,23 @@ func (s *S3Client) GenerateDownloadURL(key, filename string) (string, error) {
 	return result.URL, nil
 }
 
+// OpenObject returns the contents of an object, read as the caller consumes
+// them, so that large files can be processed without buffering them. The
+// caller must close the returned reader.
+func (s *S3Client) OpenObject(key string) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+
+	result, err := s.client.GetObject(context.Background(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return result.Body, nil
+}
+
 // DeleteObject deletes an object from S3.
 func (s *S3Client) DeleteObject(key string) error {
 	input := &s3.DeleteObjectInput{
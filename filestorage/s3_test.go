@@ -1,6 +1,9 @@
 package filestorage
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -85,6 +88,44 @@ func TestNewS3Client_GenerateDownloadURL(t *testing.T) {
 	}
 }
 
+func TestS3Client_OpenObject(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/test-bucket/imports/rows.csv" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "title\nhello\n")
+	}))
+	defer srv.Close()
+
+	client, err := NewS3Client(S3Config{
+		Bucket:    "test-bucket",
+		Endpoint:  srv.URL,
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := client.OpenObject("imports/rows.csv")
+	if err != nil {
+		t.Fatalf("OpenObject failed: %v", err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("reading object: %v", err)
+	}
+	if string(data) != "title\nhello\n" {
+		t.Errorf("object = %q", data)
+	}
+
+	if _, err := client.OpenObject("missing.csv"); err == nil {
+		t.Error("expected error for a missing object")
+	}
+}
+
 func TestIsValidVisibility(t *testing.T) {
 	tests := []struct {
 		input string
@@ -0,0 +1,215 @@
+package grpcbridge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Messages are converted to and from the handlers' JSON by hand rather
+// than with protojson, which writes 64-bit integers as strings that
+// encoding/json will not decode into an int64. Fields are keyed by their
+// json_name, which the generator sets to the struct's JSON key. Well-known
+// types (Timestamp, Value) are delegated to protojson, whose encodings of
+// them already match encoding/json's.
+
+// isWellKnown reports whether md is a google.protobuf well-known type.
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Package() == "google.protobuf"
+}
+
+// messageToJSON returns m as a JSON object. Fields without presence are
+// always included, so a required string left empty reaches the handler's
+// validation as "".
+func messageToJSON(m protoreflect.Message) map[string]any {
+	obj := make(map[string]any)
+	fields := m.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if (fd.HasPresence() || fd.IsList()) && !m.Has(fd) {
+			continue
+		}
+		v := m.Get(fd)
+		if fd.IsList() {
+			list := v.List()
+			items := make([]any, list.Len())
+			for j := range list.Len() {
+				items[j] = valueToJSON(fd, list.Get(j))
+			}
+			obj[fd.JSONName()] = items
+			continue
+		}
+		obj[fd.JSONName()] = valueToJSON(fd, v)
+	}
+	return obj
+}
+
+// valueToJSON returns a single value of fd in the form encoding/json
+// marshals as the handler expects.
+func valueToJSON(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if isWellKnown(fd.Message()) {
+			raw, err := protojson.Marshal(v.Message().Interface())
+			if err != nil {
+				return nil
+			}
+			return json.RawMessage(raw)
+		}
+		return messageToJSON(v.Message())
+	case protoreflect.BytesKind:
+		return v.Bytes()
+	case protoreflect.EnumKind:
+		return int32(v.Enum())
+	default:
+		return v.Interface()
+	}
+}
+
+// textValue formats a JSON value for a path parameter or query string.
+func textValue(v any) string {
+	if raw, ok := v.(json.RawMessage); ok {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s
+		}
+		return string(raw)
+	}
+	return fmt.Sprint(v)
+}
+
+// unmarshalJSON sets the fields of m from the JSON object data. Keys with
+// no field of that JSON name are ignored, as are nulls.
+func unmarshalJSON(data []byte, m protoreflect.Message) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON response: %w", err)
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("response is not a JSON object")
+	}
+	return jsonToMessage(obj, m)
+}
+
+// jsonToMessage sets the fields of m from obj.
+func jsonToMessage(obj map[string]any, m protoreflect.Message) error {
+	fields := m.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		v, ok := obj[fd.JSONName()]
+		if !ok || v == nil {
+			continue
+		}
+		if fd.IsList() {
+			items, ok := v.([]any)
+			if !ok {
+				return fmt.Errorf("%s: expected an array", fd.JSONName())
+			}
+			list := m.Mutable(fd).List()
+			for _, item := range items {
+				var elem protoreflect.Value
+				if fd.Message() != nil {
+					elem = list.NewElement()
+				}
+				elem, err := jsonToValue(fd, item, elem)
+				if err != nil {
+					return fmt.Errorf("%s: %w", fd.JSONName(), err)
+				}
+				list.Append(elem)
+			}
+			continue
+		}
+		if fd.Message() != nil {
+			if _, err := jsonToValue(fd, v, m.Mutable(fd)); err != nil {
+				return fmt.Errorf("%s: %w", fd.JSONName(), err)
+			}
+			continue
+		}
+		val, err := jsonToValue(fd, v, protoreflect.Value{})
+		if err != nil {
+			return fmt.Errorf("%s: %w", fd.JSONName(), err)
+		}
+		m.Set(fd, val)
+	}
+	return nil
+}
+
+// jsonToValue converts the JSON value v to a value of fd. For message
+// fields it fills msg, a mutable message of fd's type, and returns it.
+func jsonToValue(fd protoreflect.FieldDescriptor, v any, msg protoreflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if isWellKnown(fd.Message()) {
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return msg, err
+			}
+			return msg, protojson.Unmarshal(raw, msg.Message().Interface())
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return msg, fmt.Errorf("expected an object")
+		}
+		return msg, jsonToMessage(obj, msg.Message())
+	case protoreflect.StringKind:
+		s, ok := v.(string)
+		if !ok {
+			return msg, fmt.Errorf("expected a string")
+		}
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		b, ok := v.(bool)
+		if !ok {
+			return msg, fmt.Errorf("expected a boolean")
+		}
+		return protoreflect.ValueOfBool(b), nil
+	case protoreflect.BytesKind:
+		s, ok := v.(string)
+		if !ok {
+			return msg, fmt.Errorf("expected a base64 string")
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return msg, err
+		}
+		return protoreflect.ValueOfBytes(b), nil
+	}
+
+	n, ok := v.(json.Number)
+	if !ok {
+		return msg, fmt.Errorf("expected a number")
+	}
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(n.String(), 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(n.String(), 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(n.String(), 10, 32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(n.String(), 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(n.String(), 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := n.Float64()
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.EnumKind:
+		i, err := strconv.ParseInt(n.String(), 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), err
+	}
+	return msg, fmt.Errorf("unsupported field kind %s", strings.ToLower(fd.Kind().String()))
+}
@@ -0,0 +1,343 @@
+// Package grpcbridge serves the generated API over gRPC. Every RPC is turned
+// into an in-process HTTP request to the API's handler, so gRPC calls run
+// the same handler functions, middleware, auth checks and validation as
+// HTTP calls and the two transports cannot drift apart.
+// This package is embedded into user projects via the shipq embed system,
+// landing at {modulePath}/shipq/lib/grpcbridge.
+package grpcbridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// Well-known types the generated descriptor imports.
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Method binds one RPC to the HTTP route that serves it.
+type Method struct {
+	// Service is the fully-qualified service name, e.g. "api.PostsService".
+	Service string
+	// Name is the RPC name, e.g. "GetPost".
+	Name string
+	// HTTPMethod is the route's method, e.g. "GET".
+	HTTPMethod string
+	// Path is the route with {param} placeholders, including any strip
+	// prefix, e.g. "/api/posts/{id}". Each placeholder is filled from the
+	// request field whose JSON name matches it.
+	Path string
+	// Query lists the JSON names of the request fields sent in the query
+	// string. Every other field goes in the JSON body.
+	Query []string
+}
+
+// NewServer returns a gRPC server exposing the services of the serialized
+// FileDescriptorProto desc, each RPC served by h as described by methods.
+func NewServer(h http.Handler, desc []byte, methods []Method, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	s := grpc.NewServer(opts...)
+	if err := Register(s, h, desc, methods); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Register registers the services of the serialized FileDescriptorProto
+// desc on s. It fails when an RPC of desc has no entry in methods.
+func Register(s grpc.ServiceRegistrar, h http.Handler, desc []byte, methods []Method) error {
+	var fdp descriptorpb.FileDescriptorProto
+	if err := proto.Unmarshal(desc, &fdp); err != nil {
+		return fmt.Errorf("grpcbridge: invalid file descriptor: %w", err)
+	}
+	fd, err := protodesc.NewFile(&fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		return fmt.Errorf("grpcbridge: invalid file descriptor: %w", err)
+	}
+
+	routes := make(map[string]Method, len(methods))
+	for _, m := range methods {
+		routes[m.Service+"/"+m.Name] = m
+	}
+
+	services := fd.Services()
+	for i := range services.Len() {
+		sd := services.Get(i)
+		gsd := grpc.ServiceDesc{
+			ServiceName: string(sd.FullName()),
+			HandlerType: (*any)(nil),
+			Metadata:    fd.Path(),
+		}
+		rpcs := sd.Methods()
+		for j := range rpcs.Len() {
+			md := rpcs.Get(j)
+			route, ok := routes[string(sd.FullName())+"/"+string(md.Name())]
+			if !ok {
+				return fmt.Errorf("grpcbridge: no route for %s/%s", sd.FullName(), md.Name())
+			}
+			gsd.Methods = append(gsd.Methods, grpc.MethodDesc{
+				MethodName: string(md.Name()),
+				Handler:    unaryHandler(h, route, md),
+			})
+		}
+		s.RegisterService(&gsd, struct{}{})
+	}
+	return nil
+}
+
+// unaryHandler returns the gRPC handler of the RPC md, which calls h.
+func unaryHandler(h http.Handler, route Method, md protoreflect.MethodDescriptor) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := dynamicpb.NewMessage(md.Input())
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		call := func(ctx context.Context, req any) (any, error) {
+			return serve(ctx, h, route, req.(proto.Message), md.Output())
+		}
+		if interceptor == nil {
+			return call(ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + route.Service + "/" + route.Name,
+		}
+		return interceptor(ctx, in, info, call)
+	}
+}
+
+// serve sends in to h as an HTTP request for route and converts the
+// response into a message of type out.
+func serve(ctx context.Context, h http.Handler, route Method, in proto.Message, out protoreflect.MessageDescriptor) (proto.Message, error) {
+	req, err := newRequest(ctx, route, in)
+	if err != nil {
+		return nil, err
+	}
+	rec := &recorder{header: make(http.Header), status: http.StatusOK}
+	h.ServeHTTP(rec, req)
+
+	if md := responseMetadata(rec.header); md.Len() > 0 {
+		grpc.SetHeader(ctx, md)
+	}
+	if rec.status >= 400 {
+		return nil, errorStatus(ctx, rec.status, rec.body.Bytes())
+	}
+
+	msg := dynamicpb.NewMessage(out)
+	if rec.body.Len() == 0 {
+		return msg, nil
+	}
+	if ct, _, _ := mime.ParseMediaType(rec.header.Get("Content-Type")); ct != "application/json" {
+		return nil, status.Errorf(codes.Internal, "%s %s answered %q, not JSON", route.HTTPMethod, route.Path, ct)
+	}
+	if err := unmarshalJSON(rec.body.Bytes(), msg); err != nil {
+		return nil, status.Errorf(codes.Internal, "%s %s: %v", route.HTTPMethod, route.Path, err)
+	}
+	return msg, nil
+}
+
+// newRequest builds the HTTP request for an RPC: path parameters and query
+// fields are taken out of in and the rest is sent as the JSON body. The
+// incoming metadata becomes request headers, so cookies and Authorization
+// reach the handler.
+func newRequest(ctx context.Context, route Method, in proto.Message) (*http.Request, error) {
+	fields := messageToJSON(in.ProtoReflect())
+
+	path := route.Path
+	for name, value := range fields {
+		placeholder := "{" + name + "}"
+		if strings.Contains(path, placeholder) {
+			path = strings.ReplaceAll(path, placeholder, url.PathEscape(textValue(value)))
+			delete(fields, name)
+		}
+	}
+	if i := strings.Index(path, "{"); i >= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "missing path parameter %s", path[i:])
+	}
+
+	query := url.Values{}
+	for _, name := range route.Query {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		delete(fields, name)
+		// Unset query fields, including scalars left at their zero value,
+		// are left out of the query string.
+		if fd := in.ProtoReflect().Descriptor().Fields().ByJSONName(name); fd == nil || !in.ProtoReflect().Has(fd) {
+			continue
+		}
+		if list, ok := value.([]any); ok {
+			for _, v := range list {
+				query.Add(name, textValue(v))
+			}
+			continue
+		}
+		query.Set(name, textValue(value))
+	}
+	target := path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body []byte
+	if hasBody(route.HTTPMethod) {
+		var err error
+		if body, err = json.Marshal(fields); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "encode request: %v", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, route.HTTPMethod, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "build request: %v", err)
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			if forwardedKey(key) {
+				for _, v := range values {
+					req.Header.Add(key, v)
+				}
+			}
+		}
+		if authority := md.Get(":authority"); len(authority) > 0 {
+			req.Host = authority[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		req.RemoteAddr = p.Addr.String()
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// hasBody reports whether requests with method carry a JSON body.
+func hasBody(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	default:
+		return false
+	}
+}
+
+// forwardedKey reports whether the incoming metadata key is passed to the
+// handler as a header. Pseudo-headers, gRPC's own headers and binary
+// metadata are not.
+func forwardedKey(key string) bool {
+	switch {
+	case strings.HasPrefix(key, ":"), strings.HasPrefix(key, "grpc-"), strings.HasSuffix(key, "-bin"):
+		return false
+	case key == "content-type", key == "te", key == "content-length":
+		return false
+	default:
+		return true
+	}
+}
+
+// responseMetadata returns the response headers sent back as gRPC header
+// metadata, such as the Set-Cookie of a login.
+func responseMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		switch key {
+		case "Content-Type", "Content-Length":
+			continue
+		}
+		md.Append(strings.ToLower(key), values...)
+	}
+	return md
+}
+
+// errorStatus converts an HTTP error response into a gRPC status. The
+// message comes from the {"error": ...} body the handlers write; the
+// request fields it names are sent in the "error-fields" trailer.
+func errorStatus(ctx context.Context, code int, body []byte) error {
+	var resp struct {
+		Error  string   `json:"error"`
+		Fields []string `json:"fields"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Error == "" {
+		resp.Error = http.StatusText(code)
+	}
+	if len(resp.Fields) > 0 {
+		grpc.SetTrailer(ctx, metadata.Pairs("error-fields", strings.Join(resp.Fields, ",")))
+	}
+	return status.Error(Code(code), resp.Error)
+}
+
+// Code returns the gRPC code for an HTTP error status.
+func Code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound, http.StatusGone:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if httpStatus >= 500 {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// recorder is the http.ResponseWriter handlers write an RPC's response to.
+type recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
+
+// Flush is a no-op; the response is returned once the handler is done.
+func (r *recorder) Flush() {}
@@ -0,0 +1,261 @@
+package grpcbridge
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func field(name, jsonName string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(jsonName),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+// postsFile describes a posts service with a GET by path parameter, a
+// POST with a body and a GET with query fields.
+func postsFile() *descriptorpb.FileDescriptorProto {
+	msg := func(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+	}
+	rpc := func(name, in, out string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{Name: proto.String(name), InputType: proto.String(in), OutputType: proto.String(out)}
+	}
+	tags := field("tags", "tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	tags.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("api.proto"),
+		Package:    proto.String("api"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/struct.proto", "google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			msg("GetPostRequest", field("id", "id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, "")),
+			msg("CreatePostRequest",
+				field("title", "title", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("metadata", "metadata", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Value"),
+			),
+			msg("ListPostsRequest",
+				field("limit", "limit", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				field("author", "author", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			),
+			msg("Post",
+				field("id", "id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("title", "title", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("created_at", "createdAt", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+				tags,
+			),
+			msg("ListPostsResponse", &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String("items"),
+				JsonName: proto.String("items"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".api.Post"),
+			}),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("PostsService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				rpc("GetPost", ".api.GetPostRequest", ".api.Post"),
+				rpc("CreatePost", ".api.CreatePostRequest", ".api.Post"),
+				rpc("ListPosts", ".api.ListPostsRequest", ".api.ListPostsResponse"),
+			},
+		}},
+	}
+}
+
+var postsMethods = []Method{
+	{Service: "api.PostsService", Name: "GetPost", HTTPMethod: "GET", Path: "/api/posts/{id}"},
+	{Service: "api.PostsService", Name: "CreatePost", HTTPMethod: "POST", Path: "/api/posts"},
+	{Service: "api.PostsService", Name: "ListPosts", HTTPMethod: "GET", Path: "/api/posts", Query: []string{"limit", "author"}},
+}
+
+func postsMux(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("GET /api/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+			return
+		}
+		if r.PathValue("id") != "9007199254740993" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "post not found"})
+			return
+		}
+		w.Header().Set("X-Request-Id", "req-1")
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id": int64(9007199254740993), "title": "Hello", "createdAt": "2024-05-01T12:00:00Z", "tags": []string{"a", "b"},
+		})
+	})
+	mux.HandleFunc("POST /api/posts", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body["title"] == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "title is required", "fields": []string{"title"}})
+			return
+		}
+		if meta, _ := body["metadata"].(map[string]any); meta["pinned"] != true {
+			t.Errorf("metadata not passed as JSON: %v", body)
+		}
+		writeJSON(w, http.StatusCreated, map[string]any{"id": 1, "title": body["title"], "unknown": true})
+	})
+	mux.HandleFunc("GET /api/posts", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.RawQuery; got != "limit=2" {
+			t.Errorf("query = %q, want only the set field", got)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": []map[string]any{{"id": 1}, {"id": 2, "createdAt": nil}}})
+	})
+	return mux
+}
+
+// dial starts the bridge on an in-memory listener and returns a client
+// connection and the file descriptor the client builds messages from.
+func dial(t *testing.T) (*grpc.ClientConn, protoreflect.FileDescriptor) {
+	t.Helper()
+	fdp := postsFile()
+	desc, err := proto.Marshal(fdp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer(postsMux(t), desc, postsMethods)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, fd
+}
+
+func newMessage(fd protoreflect.FileDescriptor, name string, jsonText string) *dynamicpb.Message {
+	m := dynamicpb.NewMessage(fd.Messages().ByName(protoreflect.Name(name)))
+	if jsonText != "" {
+		if err := unmarshalJSON([]byte(jsonText), m); err != nil {
+			panic(err)
+		}
+	}
+	return m
+}
+
+func TestBridge_PathParamsHeadersAndResponse(t *testing.T) {
+	conn, fd := dial(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+
+	in := newMessage(fd, "GetPostRequest", `{"id": 9007199254740993}`)
+	out := newMessage(fd, "Post", "")
+	var header metadata.MD
+	if err := conn.Invoke(ctx, "/api.PostsService/GetPost", in, out, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetPost error = %v", err)
+	}
+
+	got, err := json.Marshal(messageToJSON(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"createdAt":"2024-05-01T12:00:00Z","id":9007199254740993,"tags":["a","b"],"title":"Hello"}`
+	if string(got) != want {
+		t.Errorf("response = %s, want %s", got, want)
+	}
+	if v := header.Get("x-request-id"); len(v) != 1 || v[0] != "req-1" {
+		t.Errorf("header metadata = %v", header)
+	}
+}
+
+func TestBridge_ErrorCodes(t *testing.T) {
+	conn, fd := dial(t)
+
+	err := conn.Invoke(context.Background(), "/api.PostsService/GetPost", newMessage(fd, "GetPostRequest", `{"id": 1}`), newMessage(fd, "Post", ""))
+	if s := status.Convert(err); s.Code() != codes.Unauthenticated || s.Message() != "authentication required" {
+		t.Errorf("unauthenticated call: %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	err = conn.Invoke(ctx, "/api.PostsService/GetPost", newMessage(fd, "GetPostRequest", `{"id": 1}`), newMessage(fd, "Post", ""))
+	if s := status.Convert(err); s.Code() != codes.NotFound || s.Message() != "post not found" {
+		t.Errorf("missing post: %v", err)
+	}
+
+	var trailer metadata.MD
+	err = conn.Invoke(context.Background(), "/api.PostsService/CreatePost", newMessage(fd, "CreatePostRequest", `{"metadata": {"pinned": true}}`), newMessage(fd, "Post", ""), grpc.Trailer(&trailer))
+	if s := status.Convert(err); s.Code() != codes.InvalidArgument {
+		t.Errorf("invalid create: %v", err)
+	}
+	if v := trailer.Get("error-fields"); len(v) != 1 || v[0] != "title" {
+		t.Errorf("trailer = %v", trailer)
+	}
+}
+
+func TestBridge_BodyAndQuery(t *testing.T) {
+	conn, fd := dial(t)
+
+	out := newMessage(fd, "Post", "")
+	in := newMessage(fd, "CreatePostRequest", `{"title": "New", "metadata": {"pinned": true}}`)
+	if err := conn.Invoke(context.Background(), "/api.PostsService/CreatePost", in, out); err != nil {
+		t.Fatalf("CreatePost error = %v", err)
+	}
+	if got := messageToJSON(out)["title"]; got != "New" {
+		t.Errorf("title = %v", got)
+	}
+
+	list := newMessage(fd, "ListPostsResponse", "")
+	if err := conn.Invoke(context.Background(), "/api.PostsService/ListPosts", newMessage(fd, "ListPostsRequest", `{"limit": 2}`), list); err != nil {
+		t.Fatalf("ListPosts error = %v", err)
+	}
+	items := messageToJSON(list)["items"].([]any)
+	if len(items) != 2 || items[1].(map[string]any)["id"] != int64(2) {
+		t.Errorf("items = %v", items)
+	}
+}
+
+func TestRegister_MissingRoute(t *testing.T) {
+	desc, err := proto.Marshal(postsFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Register(grpc.NewServer(), http.NotFoundHandler(), desc, postsMethods[:2])
+	if err == nil || err.Error() != "grpcbridge: no route for api.PostsService/ListPosts" {
+		t.Errorf("Register() error = %v", err)
+	}
+}
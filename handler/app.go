@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // App is a registration shim that captures handler metadata.
@@ -48,6 +49,44 @@ func (rb *RouteBuilder) OptionalAuth() *RouteBuilder {
 	return rb
 }
 
+// Deprecated marks this route as deprecated. The generated server adds a
+// "Deprecation: true" header to every response and the OpenAPI operation is
+// flagged with deprecated: true.
+func (rb *RouteBuilder) Deprecated() *RouteBuilder {
+	rb.app.registry.Handlers[rb.index].Deprecated = true
+	return rb
+}
+
+// Sunset marks this route as deprecated and records the date (YYYY-MM-DD)
+// after which it may be removed. The date is sent in a Sunset header.
+// Panics if date is not a valid YYYY-MM-DD date.
+func (rb *RouteBuilder) Sunset(date string) *RouteBuilder {
+	if _, err := time.Parse(SunsetDateLayout, date); err != nil {
+		panic(fmt.Sprintf("sunset date %q for route %q must be formatted as YYYY-MM-DD",
+			date, rb.app.registry.Handlers[rb.index].Path))
+	}
+	rb.app.registry.Handlers[rb.index].Deprecated = true
+	rb.app.registry.Handlers[rb.index].Sunset = date
+	return rb
+}
+
+// featureNamePattern matches the flag names accepted by Feature.
+var featureNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]*$`)
+
+// Feature gates this route behind the named feature flag. The generated
+// server asks the flag evaluator (httputil.FeatureFlags) on every request
+// and answers 404 while the flag is off, so the route can ship dark and be
+// switched on without a redeploy. Panics if name is empty or contains
+// characters other than letters, digits, '_', '.', ':' and '-'.
+func (rb *RouteBuilder) Feature(name string) *RouteBuilder {
+	if !featureNamePattern.MatchString(name) {
+		panic(fmt.Sprintf("feature flag name %q for route %q must be non-empty and contain only letters, digits, '_', '.', ':' and '-'",
+			name, rb.app.registry.Handlers[rb.index].Path))
+	}
+	rb.app.registry.Handlers[rb.index].Feature = name
+	return rb
+}
+
 // Get registers a GET handler.
 func (a *App) Get(path string, handler any) *RouteBuilder {
 	a.register(GET, path, handler)
@@ -117,6 +156,9 @@ func (a *App) register(method HTTPMethod, path string, handler any) {
 		reqType = reqType.Elem()
 	}
 	info.Request = extractStructInfo(reqType)
+	if rb, ok := reflect.New(reqType).Interface().(interface{ RawBodyTypes() []string }); ok {
+		info.RawBodyTypes = rb.RawBodyTypes()
+	}
 
 	// Extract response type (first return value)
 	respType := handlerType.Out(0)
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -217,6 +218,97 @@ func TestMultipleRegistrations(t *testing.T) {
 	}
 }
 
+func TestRouteDeprecation(t *testing.T) {
+	app := NewApp()
+	app.Get("/users", ListUsers)
+	app.Get("/users/:id", GetUser).Deprecated()
+	app.Delete("/users/:id", DeleteUser).Auth().Sunset("2027-01-31")
+
+	hs := app.registry.Handlers
+	if hs[0].Deprecated || hs[0].Sunset != "" {
+		t.Errorf("handler 0: expected not deprecated, got Deprecated=%v Sunset=%q", hs[0].Deprecated, hs[0].Sunset)
+	}
+	if !hs[1].Deprecated || hs[1].Sunset != "" {
+		t.Errorf("handler 1: expected deprecated without sunset, got Deprecated=%v Sunset=%q", hs[1].Deprecated, hs[1].Sunset)
+	}
+	if !hs[2].Deprecated || hs[2].Sunset != "2027-01-31" {
+		t.Errorf("handler 2: expected deprecated with sunset, got Deprecated=%v Sunset=%q", hs[2].Deprecated, hs[2].Sunset)
+	}
+	if !hs[2].RequireAuth {
+		t.Error("handler 2: Sunset should not clear RequireAuth")
+	}
+}
+
+func TestRouteSunset_PanicsOnInvalidDate(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expected panic for invalid sunset date")
+		}
+		if !strings.Contains(r.(string), "YYYY-MM-DD") {
+			t.Errorf("panic message should mention the expected format, got: %v", r)
+		}
+	}()
+
+	app := NewApp()
+	app.Get("/users/:id", GetUser).Sunset("January 31, 2027")
+}
+
+func TestRouteFeature(t *testing.T) {
+	app := NewApp()
+	app.Get("/users", ListUsers)
+	app.Post("/users", CreateUser).Feature("user-signup").Auth()
+
+	hs := app.registry.Handlers
+	if hs[0].Feature != "" {
+		t.Errorf("handler 0: expected no feature, got %q", hs[0].Feature)
+	}
+	if hs[1].Feature != "user-signup" || !hs[1].RequireAuth {
+		t.Errorf("handler 1: expected feature user-signup with auth, got Feature=%q RequireAuth=%v", hs[1].Feature, hs[1].RequireAuth)
+	}
+}
+
+func TestRouteFeature_PanicsOnInvalidName(t *testing.T) {
+	for _, name := range []string{"", "new checkout", "-flag"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for feature name %q", name)
+				}
+			}()
+			NewApp().Get("/users", ListUsers).Feature(name)
+		}()
+	}
+}
+
+type ImportUsersRequest struct {
+	DryRun bool `query:"dry_run"`
+
+	body io.Reader
+}
+
+func (*ImportUsersRequest) RawBodyTypes() []string { return []string{"text/csv"} }
+
+func (r *ImportUsersRequest) SetRawBody(_ string, body io.Reader) { r.body = body }
+
+func ImportUsers(ctx context.Context, req *ImportUsersRequest) (*ListUsersResponse, error) {
+	return nil, nil
+}
+
+func TestRawBodyTypes(t *testing.T) {
+	app := NewApp()
+	app.Post("/users", CreateUser)
+	app.Post("/users/import", ImportUsers)
+
+	hs := app.registry.Handlers
+	if hs[0].RawBodyTypes != nil {
+		t.Errorf("handler 0: expected no raw body types, got %v", hs[0].RawBodyTypes)
+	}
+	if len(hs[1].RawBodyTypes) != 1 || hs[1].RawBodyTypes[0] != "text/csv" {
+		t.Errorf("handler 1: expected raw body type text/csv, got %v", hs[1].RawBodyTypes)
+	}
+}
+
 func TestExtractPathParams(t *testing.T) {
 	tests := []struct {
 		path     string
@@ -60,11 +60,25 @@ type HandlerInfo struct {
 	RequireAuth  bool // true if handler requires authentication
 	OptionalAuth bool // true if handler should attempt auth but not require it
 
+	// Deprecation
+	Deprecated bool   // true if .Deprecated() or .Sunset() is chained
+	Sunset     string // removal date as YYYY-MM-DD, empty if none announced
+
+	// Feature gating
+	Feature string // feature flag that must be enabled to serve the route, empty if ungated
+
+	// RawBodyTypes lists the media types the request reads raw instead of
+	// having them decoded (see httputil.RawBodyReader), e.g. "text/csv".
+	RawBodyTypes []string
+
 	// Request/Response types - full struct definitions
 	Request  *StructInfo // nil for handlers with no request body (some GETs)
 	Response *StructInfo // nil for handlers that return no body
 }
 
+// SunsetDateLayout is the time layout for HandlerInfo.Sunset.
+const SunsetDateLayout = "2006-01-02"
+
 // Registry holds all registered handlers.
 type Registry struct {
 	Handlers []HandlerInfo
@@ -8,6 +8,7 @@ type Error struct {
 	code    int
 	message string
 	cause   error
+	fields  []string
 }
 
 // Error returns the error message.
@@ -27,6 +28,17 @@ func (e *Error) Message() string { return e.message }
 // Unwrap returns the underlying cause for errors.As/errors.Is support.
 func (e *Error) Unwrap() error { return e.cause }
 
+// Fields returns the request fields the error is about, if any.
+func (e *Error) Fields() []string { return e.fields }
+
+// WithFields records the request fields the error is about (e.g. the
+// columns of a violated unique index), which are written to the response
+// alongside the message. It returns e.
+func (e *Error) WithFields(fields ...string) *Error {
+	e.fields = fields
+	return e
+}
+
 // New creates a new HTTP error with the given code and message.
 func New(code int, message string) *Error {
 	return &Error{code: code, message: message}
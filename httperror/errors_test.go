@@ -226,6 +226,19 @@ func TestConflictf(t *testing.T) {
 	}
 }
 
+func TestWithFields(t *testing.T) {
+	err := Conflict("email already exists").WithFields("email")
+	if err.Code() != 409 {
+		t.Errorf("Code() = %d, want 409", err.Code())
+	}
+	if got := err.Fields(); len(got) != 1 || got[0] != "email" {
+		t.Errorf("Fields() = %v, want [email]", got)
+	}
+	if got := New(400, "bad").Fields(); got != nil {
+		t.Errorf("Fields() = %v, want nil", got)
+	}
+}
+
 func TestUnprocessableEntity(t *testing.T) {
 	err := UnprocessableEntity("validation failed")
 	if err.Code() != 422 {
@@ -127,6 +127,19 @@ func (cw *CookieWriter) Flush() {
 	}
 }
 
+// FlushError applies pending cookies and flushes the underlying writer.
+// http.ResponseController prefers it over Flush, which only applies
+// cookies, so streamed responses reach the client incrementally.
+func (cw *CookieWriter) FlushError() error {
+	cw.Flush()
+	return http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *CookieWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
 // WriteHeader applies pending cookies and then writes the status code.
 func (cw *CookieWriter) WriteHeader(code int) {
 	cw.Flush()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -321,3 +322,21 @@ func (f *fakeResponseWriter) Write(b []byte) (int, error) {
 	f.body = append(f.body, b...)
 	return len(b), nil
 }
+
+func TestCookieWriter_ResponseControllerFlushesUnderlyingWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ops := &[]CookieOp{
+		{Cookie: &http.Cookie{Name: "session", Value: "abc123"}},
+	}
+
+	cw := NewCookieWriter(rec, ops)
+	if err := http.NewResponseController(cw).Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if !rec.Flushed {
+		t.Error("expected the underlying writer to be flushed")
+	}
+	if rec.Header().Get("Set-Cookie") == "" {
+		t.Error("expected Set-Cookie header before the flush")
+	}
+}
@@ -0,0 +1,53 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/shipq/shipq/db/portsql/migrate"
+)
+
+// Meta describes the build a server was generated from. The generated
+// api package fills in everything except AppliedMigrationHead, which
+// MetaHandler reads from the database on each request.
+type Meta struct {
+	ServiceVersion string `json:"service_version"`
+	ShipqVersion   string `json:"shipq_version"`
+	// SchemaHash is the SHA-256 of shipq/db/migrate/schema.json at compile
+	// time, or "" when the project has no migrations.
+	SchemaHash string `json:"schema_hash"`
+	// MigrationHead is the newest migration the binary was compiled against.
+	MigrationHead string `json:"migration_head"`
+	// Migrations lists every migration the binary was compiled against, in
+	// plan order. MetaHandler checks them the way /healthz does.
+	Migrations []string `json:"-"`
+	// AppliedMigrationHead is the newest migration recorded in the
+	// database. It is "" when the tracking table is missing or empty.
+	AppliedMigrationHead string   `json:"applied_migration_head"`
+	SchemaInSync         bool     `json:"schema_in_sync"`
+	APIVersions          []string `json:"api_versions"`
+}
+
+// MetaHandler serves meta as JSON, adding the applied migration head read
+// through q. The schema is in sync when the database's head is the binary's
+// and migrate.PendingMigrationNames, which /healthz also uses, finds none of
+// meta.Migrations missing. Database errors are not fatal: deploy tooling
+// gets an empty applied head and schema_in_sync=false rather than a 500.
+func MetaHandler(q Querier, meta Meta) http.Handler {
+	if meta.APIVersions == nil {
+		meta.APIVersions = []string{}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := meta
+		resp.AppliedMigrationHead, _ = migrate.AppliedMigrationHead(r.Context(), q)
+		resp.SchemaInSync = resp.AppliedMigrationHead == resp.MigrationHead
+		if len(meta.Migrations) > 0 {
+			pending, err := migrate.PendingMigrationNames(r.Context(), q, meta.Migrations)
+			resp.SchemaInSync = resp.SchemaInSync && err == nil && len(pending) == 0
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
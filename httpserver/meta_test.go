@@ -0,0 +1,70 @@
+package httpserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMetaHandler(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	meta := Meta{
+		ServiceVersion: "v1.2.3",
+		ShipqVersion:   "v0.9.0",
+		SchemaHash:     "abc",
+		MigrationHead:  "20260102000000_add_posts",
+	}
+	get := func() Meta {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		MetaHandler(db, meta).ServeHTTP(rec, httptest.NewRequest("GET", "/__meta", nil))
+		if rec.Code != 200 {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		var got Meta
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+		}
+		return got
+	}
+
+	// No tracking table yet.
+	got := get()
+	if got.AppliedMigrationHead != "" || got.SchemaInSync {
+		t.Errorf("expected empty applied head without tracking table, got %+v", got)
+	}
+	if got.APIVersions == nil || got.ServiceVersion != "v1.2.3" {
+		t.Errorf("unexpected meta %+v", got)
+	}
+
+	if _, err := db.Exec(`CREATE TABLE _portsql_migrations (name TEXT PRIMARY KEY, version TEXT NOT NULL, applied_at TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO _portsql_migrations VALUES
+		('20260101000000_create_users', '20260101000000', 'x'),
+		('20260102000000_add_posts', '20260102000000', 'x')`); err != nil {
+		t.Fatal(err)
+	}
+
+	got = get()
+	if got.AppliedMigrationHead != "20260102000000_add_posts" || !got.SchemaInSync {
+		t.Errorf("expected schema in sync at add_posts, got %+v", got)
+	}
+
+	// The heads match, but a migration merged before the head never ran;
+	// /healthz reports it pending, so /__meta must not claim sync.
+	meta.Migrations = []string{"20260101000000_create_users", "20260101120000_add_tags", "20260102000000_add_posts"}
+	got = get()
+	if got.SchemaInSync {
+		t.Errorf("expected schema out of sync with add_tags pending, got %+v", got)
+	}
+}
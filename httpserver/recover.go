@@ -0,0 +1,157 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/shipq/shipq/logging"
+)
+
+// PanicReport describes a panic recovered while serving a request.
+type PanicReport struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the goroutine's stack trace at the point of recovery.
+	Stack []byte
+	// Request is the request being served. Its body may be partly read.
+	Request *http.Request
+	// RequestID is the ID assigned by the logging middleware, or "" when
+	// the handler is not wrapped by it.
+	RequestID string
+}
+
+// Err returns Value as an error, wrapping non-error values, for reporters
+// whose API takes an error (e.g. sentry.CaptureException).
+func (p PanicReport) Err() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", p.Value)
+}
+
+// PanicReporter forwards recovered panics to an error tracker such as
+// Sentry. ReportPanic is called after the 500 response has been written,
+// on the request's goroutine, so it should not block for long.
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, report PanicReport)
+}
+
+// PanicReporterFunc adapts a function to the PanicReporter interface.
+type PanicReporterFunc func(ctx context.Context, report PanicReport)
+
+// ReportPanic calls f(ctx, report).
+func (f PanicReporterFunc) ReportPanic(ctx context.Context, report PanicReport) {
+	f(ctx, report)
+}
+
+// problem is an RFC 9457 problem details body.
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Recover wraps next so that a panic in a handler is logged with its stack
+// trace, answered with a 500 application/problem+json response and passed
+// to reporter, which may be nil. The server keeps running. When the handler
+// has already started its response, the status and body cannot be replaced,
+// so the connection is closed instead.
+//
+// Panics with http.ErrAbortHandler are not recovered: they are how a
+// handler asks net/http to abort the response.
+func Recover(logger *slog.Logger, reporter PanicReporter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoverWriter{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			requestID, _ := logging.RequestIDFromContext(r.Context())
+			report := PanicReport{
+				Value:     v,
+				Stack:     debug.Stack(),
+				Request:   r,
+				RequestID: requestID,
+			}
+			logger.Error("panic recovered",
+				"request_id", report.RequestID,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(v),
+				"stack", string(report.Stack),
+			)
+
+			if rw.wroteHeader {
+				reportPanic(logger, reporter, r.Context(), report)
+				panic(http.ErrAbortHandler)
+			}
+
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(problem{
+				Type:      "about:blank",
+				Title:     http.StatusText(http.StatusInternalServerError),
+				Status:    http.StatusInternalServerError,
+				Instance:  r.URL.Path,
+				RequestID: report.RequestID,
+			})
+			reportPanic(logger, reporter, r.Context(), report)
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// reportPanic calls reporter, logging rather than propagating a panic from
+// the reporter itself.
+func reportPanic(logger *slog.Logger, reporter PanicReporter, ctx context.Context, report PanicReport) {
+	if reporter == nil {
+		return
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			logger.Error("panic reporter failed", "request_id", report.RequestID, "panic", fmt.Sprint(v))
+		}
+	}()
+	reporter.ReportPanic(ctx, report)
+}
+
+// recoverWriter records whether the response has been started.
+type recoverWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoverWriter) WriteHeader(code int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoverWriter) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(p)
+}
+
+// Flush forwards to the underlying writer so streamed responses still
+// reach the client incrementally.
+func (rw *recoverWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		rw.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *recoverWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
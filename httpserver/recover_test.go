@@ -0,0 +1,128 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/logging"
+)
+
+func TestRecover_WritesProblemAndReports(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	var got PanicReport
+	reporter := PanicReporterFunc(func(ctx context.Context, report PanicReport) {
+		got = report
+	})
+	h := Recover(logger, reporter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest("GET", "/posts/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), logging.RequestIDKey, "req-1"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var body problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	want := problem{Type: "about:blank", Title: "Internal Server Error", Status: 500, Instance: "/posts/1", RequestID: "req-1"}
+	if body != want {
+		t.Errorf("body = %+v, want %+v", body, want)
+	}
+	if strings.Contains(rec.Body.String(), "boom") {
+		t.Error("the panic value must not leak into the response")
+	}
+
+	if got.Value != "boom" || got.RequestID != "req-1" || got.Request != req {
+		t.Errorf("report = %+v", got)
+	}
+	if !bytes.Contains(got.Stack, []byte("recover_test.go")) {
+		t.Errorf("stack should include the panicking frame:\n%s", got.Stack)
+	}
+	if got.Err().Error() != "panic: boom" {
+		t.Errorf("Err() = %v", got.Err())
+	}
+	if !strings.Contains(logs.String(), `"msg":"panic recovered"`) {
+		t.Errorf("expected a panic log line, got %s", logs.String())
+	}
+}
+
+func TestRecover_ErrorValue(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	report := PanicReport{Value: sentinel}
+	if !errors.Is(report.Err(), sentinel) {
+		t.Errorf("Err() = %v, want the panicked error", report.Err())
+	}
+}
+
+func TestRecover_NilReporterAndReporterPanic(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	for name, reporter := range map[string]PanicReporter{
+		"nil": nil,
+		"panicking": PanicReporterFunc(func(context.Context, PanicReport) {
+			panic("reporter broke")
+		}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Recover(logger, reporter, panicking).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500", rec.Code)
+			}
+		})
+	}
+}
+
+func TestRecover_ResponseStarted(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	reported := false
+	h := Recover(logger, PanicReporterFunc(func(context.Context, PanicReport) { reported = true }),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			panic("boom")
+		}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", v)
+		}
+		if !reported {
+			t.Error("the panic should be reported before aborting")
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestRecover_PassesThrough(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	h := Recover(logger, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.(http.Flusher).Flush()
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	if rec.Code != http.StatusCreated || !rec.Flushed {
+		t.Errorf("status = %d, flushed = %v", rec.Code, rec.Flushed)
+	}
+}
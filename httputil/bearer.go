@@ -0,0 +1,51 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// bearerTokenContextKey is the context key for the token of a request's
+// Authorization: Bearer header.
+type bearerTokenContextKey struct{}
+
+// WithBearerAuth wraps h so that the token of an Authorization: Bearer
+// header reaches the auth check through BearerTokenFromContext. The auth
+// check validates it; requests without the header fall back to the session
+// cookie.
+func WithBearerAuth(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := BearerToken(r); ok {
+			r = r.WithContext(context.WithValue(r.Context(), bearerTokenContextKey{}, token))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// BearerToken returns the token of r's Authorization header when it uses
+// the Bearer scheme (case-insensitively, per RFC 6750). Returns ("", false)
+// for a missing header, another scheme or an empty token.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// BearerTokenFromContext returns the bearer token WithBearerAuth found on
+// the request. Returns ("", false) if the request sent none.
+func BearerTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(bearerTokenContextKey{}).(string)
+	return token, ok
+}
+
+// AddBearerAuth sets req's Authorization header to the bearer token, if
+// any. It is the bearer counterpart of AddAuth, for tests.
+func AddBearerAuth(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
@@ -0,0 +1,58 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerToken(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                   "",
+		"Bearer abc.def":     "abc.def",
+		"bearer  abc.def ":   "abc.def",
+		"Bearer ":            "",
+		"Basic dXNlcjpwdw==": "",
+		"Bearerabc":          "",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		got, ok := BearerToken(req)
+		if got != want || ok != (want != "") {
+			t.Errorf("BearerToken(%q) = %q, %t; want %q", header, got, ok, want)
+		}
+	}
+}
+
+func TestWithBearerAuth(t *testing.T) {
+	checkAuth := func(ctx context.Context) (int64, int64, error) {
+		if token, _ := BearerTokenFromContext(ctx); token == "valid" {
+			return 42, 1, nil
+		}
+		return 0, 0, errors.New("no valid session")
+	}
+	handler := WithBearerAuth(WrapAuthHandler(
+		&mockQuerier{},
+		func(ctx context.Context) context.Context { return ctx },
+		checkAuth,
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) },
+	))
+
+	for token, status := range map[string]int{
+		"valid":   http.StatusOK,
+		"expired": http.StatusUnauthorized,
+		"":        http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		AddBearerAuth(req, token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("token %q: status %d, want %d", token, w.Code, status)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package httputil
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// CBORCodec encodes bodies as CBOR (application/cbor, RFC 8949). Like
+// MsgpackCodec it mirrors the JSON encoding of each value. When decoding,
+// byte strings are accepted for []byte fields and tag 1 epoch times for
+// time.Time fields; indefinite-length items are supported.
+type CBORCodec struct{}
+
+// ContentType implements Codec.
+func (CBORCodec) ContentType() string { return "application/cbor" }
+
+// Marshal implements Codec.
+func (CBORCodec) Marshal(v any) ([]byte, error) {
+	tree, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendCBOR(nil, tree)
+}
+
+// Unmarshal implements Codec.
+func (CBORCodec) Unmarshal(data []byte, v any) error {
+	d := &cborDecoder{data: data}
+	tree, err := d.value(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("cbor: trailing data")
+	}
+	return fromGeneric(tree, v)
+}
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// cborBreak terminates an indefinite-length item.
+const cborBreak = 0xff
+
+// appendCBORHead writes the initial byte of an item of the given major type
+// followed by its argument in the shortest form.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	m := major << 5
+	switch {
+	case n < 24:
+		return append(b, m|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, m|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, m|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, m|27), n)
+}
+
+func appendCBOR(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xf6), nil
+	case bool:
+		if v {
+			return append(b, 0xf5), nil
+		}
+		return append(b, 0xf4), nil
+	case json.Number:
+		n, err := numberValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return appendCBOR(b, n)
+	case int64:
+		if v < 0 {
+			return appendCBORHead(b, cborNegInt, uint64(^v)), nil
+		}
+		return appendCBORHead(b, cborUint, uint64(v)), nil
+	case uint64:
+		return appendCBORHead(b, cborUint, v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(v)), nil
+	case string:
+		b = appendCBORHead(b, cborText, uint64(len(v)))
+		return append(b, v...), nil
+	case []any:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		var err error
+		for _, item := range v {
+			if b, err = appendCBOR(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var err error
+		for _, k := range keys {
+			if b, err = appendCBOR(b, k); err != nil {
+				return nil, err
+			}
+			if b, err = appendCBOR(b, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("cbor: cannot encode %T", v)
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+var errCBORShort = errors.New("cbor: unexpected end of data")
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errCBORShort
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// head reads an item's initial byte and argument. indefinite is set for
+// additional information 31.
+func (d *cborDecoder) head() (major, info byte, arg uint64, indefinite bool, err error) {
+	b, err := d.take(1)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, info = b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info <= 27:
+		raw, err := d.take(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		for _, c := range raw {
+			arg = arg<<8 | uint64(c)
+		}
+		return major, info, arg, false, nil
+	case info == 31 && major != cborUint && major != cborNegInt && major != cborTag:
+		return major, info, 0, true, nil
+	}
+	return 0, 0, 0, false, fmt.Errorf("cbor: invalid additional information %d", info)
+}
+
+// atBreak consumes a break byte if one is next.
+func (d *cborDecoder) atBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == cborBreak {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > maxCodecDepth {
+		return nil, errors.New("cbor: nesting too deep")
+	}
+	major, info, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		return arg, nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, errors.New("cbor: negative integer overflows int64")
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		b, err := d.chunks(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborBytes {
+			// []byte fields decode from base64 strings in JSON.
+			return base64.StdEncoding.EncodeToString(b), nil
+		}
+		return string(b), nil
+	case cborArray:
+		return d.array(arg, indefinite, depth)
+	case cborMap:
+		return d.mapping(arg, indefinite, depth)
+	case cborTag:
+		inner, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTagged(arg, inner)
+	}
+
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfToFloat64(uint16(arg)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case 27:
+		return math.Float64frombits(arg), nil
+	}
+	return nil, fmt.Errorf("cbor: unsupported simple value %d", arg)
+}
+
+// chunks reads a byte or text string, joining the chunks of an
+// indefinite-length one.
+func (d *cborDecoder) chunks(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		return d.take(n)
+	}
+	var out []byte
+	for !d.atBreak() {
+		m, _, n, ind, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || ind {
+			return nil, errors.New("cbor: invalid chunk in indefinite-length string")
+		}
+		b, err := d.take(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+func (d *cborDecoder) array(n uint64, indefinite bool, depth int) (any, error) {
+	if indefinite {
+		out := []any{}
+		for !d.atBreak() {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	// Every element takes at least one byte.
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errCBORShort
+	}
+	out := make([]any, n)
+	for i := range out {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (d *cborDecoder) mapping(n uint64, indefinite bool, depth int) (any, error) {
+	if !indefinite && n > uint64(len(d.data)-d.pos)/2 {
+		return nil, errCBORShort
+	}
+	out := make(map[string]any)
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite && d.atBreak() {
+			break
+		}
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("cbor: map key must be a text string, got %T", k)
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// cborTagged interprets a tagged item. Epoch times (tag 1) become RFC 3339
+// strings; every other tag, including tag 0 date strings, yields its content.
+func cborTagged(tag uint64, inner any) (any, error) {
+	if tag != 1 {
+		return inner, nil
+	}
+	var t time.Time
+	switch v := inner.(type) {
+	case uint64:
+		t = time.Unix(int64(v), 0)
+	case int64:
+		t = time.Unix(v, 0)
+	case float64:
+		sec, frac := math.Modf(v)
+		t = time.Unix(int64(sec), int64(frac*1e9))
+	default:
+		return nil, fmt.Errorf("cbor: invalid epoch time %T", inner)
+	}
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision float.
+func halfToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
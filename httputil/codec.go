@@ -0,0 +1,214 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shipq/shipq/httperror"
+)
+
+// Codec encodes and decodes request and response bodies for one media type.
+// Generated handlers always speak JSON; registered codecs are used when the
+// client asks for them with Accept or sends a matching Content-Type.
+type Codec interface {
+	// ContentType is the media type the codec handles, e.g. "application/msgpack".
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   []Codec
+)
+
+// RegisterCodec makes c available to WriteResponse and DecodeBody. A codec
+// registered for a media type that already has one replaces it. The
+// generated server registers the codecs listed in [server] serializers;
+// projects can register their own from an init function.
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	for i, existing := range codecs {
+		if existing.ContentType() == c.ContentType() {
+			codecs[i] = c
+			return
+		}
+	}
+	codecs = append(codecs, c)
+}
+
+// lookupCodec returns the registered codec for mediaType, or nil.
+func lookupCodec(mediaType string) Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for _, c := range codecs {
+		if c.ContentType() == mediaType {
+			return c
+		}
+	}
+	return nil
+}
+
+// negotiateCodec picks the response codec for an Accept header. It returns
+// nil when JSON should be used: no header, no registered codec listed, or
+// JSON (or a wildcard) preferred at least as strongly as any codec.
+func negotiateCodec(accept string) Codec {
+	if accept == "" {
+		return nil
+	}
+	var best Codec
+	bestQ, jsonQ := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = parsed
+			}
+		}
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+			continue
+		}
+		if c := lookupCodec(mediaType); c != nil && q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+	if best == nil || jsonQ >= bestQ {
+		return nil
+	}
+	return best
+}
+
+// WriteResponse writes v with the codec the request's Accept header asks
+// for, falling back to JSON. A JSONStreamer is always streamed as JSON.
+func WriteResponse(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Add("Vary", "Accept")
+	c := negotiateCodec(r.Header.Get("Accept"))
+	if _, ok := v.(JSONStreamer); ok || c == nil {
+		WriteJSON(w, status, v)
+		return
+	}
+	data, err := c.Marshal(v)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", c.ContentType())
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// RawBodyReader is implemented by request types that read the request body
+// themselves instead of having it decoded, typically a file upload that is
+// processed row by row. The generated server binds such requests with
+// DecodeBody, which hands over bodies of the listed media types unread.
+// The body stays open until the handler returns.
+type RawBodyReader interface {
+	// RawBodyTypes lists the media types read raw, e.g. "text/csv".
+	// Bodies of other types are decoded as usual.
+	RawBodyTypes() []string
+	// SetRawBody passes the request body and its media type.
+	SetRawBody(mediaType string, body io.Reader)
+}
+
+// DecodeBody decodes the request body into v according to its Content-Type.
+// Bodies without a Content-Type, or with a JSON one, are decoded as JSON.
+// If v is a RawBodyReader accepting the media type, the body is passed to
+// it instead. An unregistered media type yields a 415 error; a malformed
+// body a 400.
+func DecodeBody(r *http.Request, v any) error {
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		parsed, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return httperror.BadRequest("invalid Content-Type header")
+		}
+		mediaType = parsed
+	}
+
+	if rb, ok := v.(RawBodyReader); ok && slices.Contains(rb.RawBodyTypes(), mediaType) {
+		rb.SetRawBody(mediaType, r.Body)
+		return nil
+	}
+
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+			return httperror.BadRequest("invalid JSON body")
+		}
+		return nil
+	}
+
+	c := lookupCodec(mediaType)
+	if c == nil {
+		return httperror.Newf(http.StatusUnsupportedMediaType, "unsupported Content-Type %q", mediaType)
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return httperror.BadRequest("failed to read request body")
+	}
+	if err := c.Unmarshal(data, v); err != nil {
+		return httperror.BadRequestf("invalid %s body", mediaType)
+	}
+	return nil
+}
+
+// maxCodecDepth bounds the nesting of decoded arrays and maps so a hostile
+// body cannot exhaust the stack.
+const maxCodecDepth = 1000
+
+// toGeneric converts v to the nil, bool, json.Number, string, []any and
+// map[string]any values its JSON encoding describes. The binary codecs
+// encode that tree so that field names, omitempty and custom marshalers
+// match the JSON API exactly.
+func toGeneric(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// fromGeneric stores a decoded value tree in v through its JSON form.
+func fromGeneric(tree any, v any) error {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numberValue converts a JSON number to int64, to uint64 when it is too
+// large for int64, or to float64.
+func numberValue(n json.Number) (any, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u, nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", n)
+	}
+	return f, nil
+}
@@ -0,0 +1,323 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/httperror"
+)
+
+type codecTestItem struct {
+	ID      string   `json:"id"`
+	Count   int      `json:"count"`
+	Offset  int64    `json:"offset"`
+	Big     uint64   `json:"big"`
+	Ratio   float64  `json:"ratio"`
+	Active  bool     `json:"active"`
+	Note    *string  `json:"note"`
+	Skipped string   `json:"skipped,omitempty"`
+	Tags    []string `json:"tags"`
+	Data    []byte   `json:"data"`
+	At      time.Time
+	Child   *codecTestItem `json:"child,omitempty"`
+}
+
+func codecTestValue() codecTestItem {
+	note := "hello"
+	return codecTestItem{
+		ID:     "abc",
+		Count:  300,
+		Offset: -70000,
+		Big:    math.MaxUint64,
+		Ratio:  1.5,
+		Active: true,
+		Note:   &note,
+		Tags:   []string{"a", string(make([]byte, 40))},
+		Data:   []byte{0, 1, 2, 255},
+		At:     time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC),
+		Child:  &codecTestItem{ID: "child", Offset: -5, Tags: []string{}},
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	for _, c := range []Codec{MsgpackCodec{}, CBORCodec{}} {
+		t.Run(c.ContentType(), func(t *testing.T) {
+			in := codecTestValue()
+			data, err := c.Marshal(in)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var out codecTestItem
+			if err := c.Unmarshal(data, &out); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Errorf("round trip mismatch:\n got %+v\nwant %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestCodecs_KnownEncodings(t *testing.T) {
+	value := map[string]any{"a": 1, "b": []any{-1, "x"}, "c": nil}
+	tests := []struct {
+		codec Codec
+		want  string
+	}{
+		// {"a": 1, "b": [-1, "x"], "c": nil}
+		{MsgpackCodec{}, "83a16101a16292ffa178a163c0"},
+		{CBORCodec{}, "a36161016162822061786163f6"},
+	}
+	for _, tt := range tests {
+		got, err := tt.codec.Marshal(value)
+		if err != nil {
+			t.Fatalf("%s Marshal: %v", tt.codec.ContentType(), err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("%s: got %x, want %s", tt.codec.ContentType(), got, tt.want)
+		}
+	}
+}
+
+func TestCBOR_DecodesRFC8949Forms(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want any
+	}{
+		{"f93c00", 1.0},  // half-precision float
+		{"f9c400", -4.0}, // negative half
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"3903e7", int64(-1000)},
+		{"5f42010243030405ff", "AQIDBAU="}, // indefinite byte string
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"bf61610161629f0203ffff", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"c11a514b67b0", "2013-03-21T20:04:00Z"}, // tag 1 epoch time
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.hex)
+		var got any
+		if err := (CBORCodec{}).Unmarshal(data, &got); err != nil {
+			t.Errorf("%s: %v", tt.hex, err)
+			continue
+		}
+		// Unmarshal goes through JSON, so integers come back as float64.
+		want := tt.want
+		if wantJSON, err := toGenericJSON(want); err == nil {
+			want = wantJSON
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %#v, want %#v", tt.hex, got, want)
+		}
+	}
+}
+
+func TestMsgpack_DecodesTimestampAndBinary(t *testing.T) {
+	// {"at": timestamp 32 (2013-03-21T20:04:00Z), "data": bin8 [1 2]}
+	data, _ := hex.DecodeString("82a26174d6ff514b67b0a464617461c4020102")
+	var out struct {
+		At   time.Time `json:"at"`
+		Data []byte    `json:"data"`
+	}
+	if err := (MsgpackCodec{}).Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !out.At.Equal(time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)) {
+		t.Errorf("At = %v", out.At)
+	}
+	if !bytes.Equal(out.Data, []byte{1, 2}) {
+		t.Errorf("Data = %v", out.Data)
+	}
+}
+
+func TestCodecs_RejectMalformedInput(t *testing.T) {
+	tests := []struct {
+		codec Codec
+		hex   string
+	}{
+		{MsgpackCodec{}, "a5616263"},        // truncated string
+		{MsgpackCodec{}, "dd7fffffff"},      // huge array length
+		{MsgpackCodec{}, "8101a0"},          // non-string key
+		{MsgpackCodec{}, "c0c0"},            // trailing data
+		{MsgpackCodec{}, "c1"},              // reserved byte
+		{CBORCodec{}, "9b00000000ffffffff"}, // huge array length
+		{CBORCodec{}, "a10101"},             // non-string key
+		{CBORCodec{}, "5f6161ff"},           // text chunk in byte string
+		{CBORCodec{}, "1c"},                 // reserved additional information
+		{CBORCodec{}, "f6f6"},               // trailing data
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.hex)
+		var v any
+		if err := tt.codec.Unmarshal(data, &v); err == nil {
+			t.Errorf("%s %s: expected error", tt.codec.ContentType(), tt.hex)
+		}
+	}
+
+	deep := bytes.Repeat([]byte{0x91}, maxCodecDepth+2)
+	var v any
+	if err := (MsgpackCodec{}).Unmarshal(append(deep, 0xc0), &v); err == nil {
+		t.Error("expected deeply nested msgpack to be rejected")
+	}
+}
+
+func TestNegotiateCodec(t *testing.T) {
+	RegisterCodec(MsgpackCodec{})
+	RegisterCodec(CBORCodec{})
+
+	tests := []struct {
+		accept string
+		want   string // "" means JSON
+	}{
+		{"", ""},
+		{"application/json", ""},
+		{"*/*", ""},
+		{"application/msgpack", "application/msgpack"},
+		{"application/cbor", "application/cbor"},
+		{"application/json;q=0.5, application/cbor", "application/cbor"},
+		{"application/msgpack;q=0.5, application/json", ""},
+		{"application/msgpack, application/json", ""},
+		{"application/msgpack;q=0.8, application/cbor;q=0.9", "application/cbor"},
+		{"application/x-protobuf", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if c := negotiateCodec(tt.accept); c != nil {
+			got = c.ContentType()
+		}
+		if got != tt.want {
+			t.Errorf("negotiateCodec(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestWriteResponse_Negotiates(t *testing.T) {
+	RegisterCodec(MsgpackCodec{})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+	WriteResponse(w, r, http.StatusCreated, map[string]string{"id": "x"})
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/msgpack" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if w.Header().Get("Vary") != "Accept" {
+		t.Errorf("expected Vary: Accept, got %q", w.Header().Get("Vary"))
+	}
+	if got := hex.EncodeToString(w.Body.Bytes()); got != "81a26964a178" {
+		t.Errorf("body = %s", got)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	w = httptest.NewRecorder()
+	WriteResponse(w, r, http.StatusOK, map[string]string{"id": "x"})
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("default Content-Type = %q, want application/json", ct)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	RegisterCodec(CBORCodec{})
+
+	type req struct {
+		Name string `json:"name"`
+	}
+	cborBody, _ := (CBORCodec{}).Marshal(req{Name: "cbor"})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+		wantStatus  int
+	}{
+		{"no content type", "", []byte(`{"name":"plain"}`), "plain", 0},
+		{"json with charset", "application/json; charset=utf-8", []byte(`{"name":"json"}`), "json", 0},
+		{"registered codec", "application/cbor", cborBody, "cbor", 0},
+		{"malformed json", "application/json", []byte(`{`), "", http.StatusBadRequest},
+		{"malformed cbor", "application/cbor", []byte{0xbf}, "", http.StatusBadRequest},
+		{"unknown type", "application/xml", []byte(`<x/>`), "", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			var got req
+			err := DecodeBody(r, &got)
+			if tt.wantStatus != 0 {
+				var httpErr *httperror.Error
+				if !errors.As(err, &httpErr) || httpErr.Code() != tt.wantStatus {
+					t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeBody: %v", err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("Name = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+type rawBodyTestRequest struct {
+	Name string `json:"name"`
+
+	mediaType string
+	body      io.Reader
+}
+
+func (*rawBodyTestRequest) RawBodyTypes() []string { return []string{"text/csv"} }
+
+func (r *rawBodyTestRequest) SetRawBody(mediaType string, body io.Reader) {
+	r.mediaType, r.body = mediaType, body
+}
+
+func TestDecodeBody_RawBodyReader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name\nada\n"))
+	r.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	var got rawBodyTestRequest
+	if err := DecodeBody(r, &got); err != nil {
+		t.Fatalf("DecodeBody: %v", err)
+	}
+	if got.mediaType != "text/csv" || got.body == nil {
+		t.Fatalf("got media type %q, body %v", got.mediaType, got.body)
+	}
+	if data, _ := io.ReadAll(got.body); string(data) != "name\nada\n" {
+		t.Errorf("body = %q", data)
+	}
+
+	// Other media types are still decoded.
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"json"}`))
+	got = rawBodyTestRequest{}
+	if err := DecodeBody(r, &got); err != nil {
+		t.Fatalf("DecodeBody: %v", err)
+	}
+	if got.Name != "json" || got.body != nil {
+		t.Errorf("got name %q, body %v", got.Name, got.body)
+	}
+}
+
+// toGenericJSON returns v as encoding/json would decode it into an any.
+func toGenericJSON(v any) (any, error) {
+	var out any
+	err := fromGeneric(v, &out)
+	return out, err
+}
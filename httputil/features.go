@@ -0,0 +1,95 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/shipq/shipq/httperror"
+)
+
+// FeatureFlags evaluates feature flags for a request. Routes registered with
+// .Feature(name) are served only while Enabled reports true for their flag.
+// An error means the flag's state is unknown (e.g. the flag store is
+// unreachable) and the route answers 503 rather than guessing.
+type FeatureFlags interface {
+	Enabled(ctx context.Context, name string) (bool, error)
+}
+
+// FeatureFlagsFunc adapts a function to the FeatureFlags interface.
+type FeatureFlagsFunc func(ctx context.Context, name string) (bool, error)
+
+// Enabled calls f(ctx, name).
+func (f FeatureFlagsFunc) Enabled(ctx context.Context, name string) (bool, error) {
+	return f(ctx, name)
+}
+
+// StaticFeatureFlags is a fixed set of flags; flags missing from the map are
+// off.
+type StaticFeatureFlags map[string]bool
+
+// Enabled reports whether name is set to true.
+func (s StaticFeatureFlags) Enabled(_ context.Context, name string) (bool, error) {
+	return s[name], nil
+}
+
+var (
+	featureFlagsMu sync.RWMutex
+	featureFlags   FeatureFlags
+)
+
+// SetFeatureFlags sets the evaluator used by feature-gated routes whose
+// request context carries none (see WithFeatureFlags). Call it before
+// serving, typically from main or an init function. Passing nil removes it.
+func SetFeatureFlags(f FeatureFlags) {
+	featureFlagsMu.Lock()
+	defer featureFlagsMu.Unlock()
+	featureFlags = f
+}
+
+// featureFlagsContextKey is the context key for a request's FeatureFlags.
+type featureFlagsContextKey struct{}
+
+// WithFeatureFlags returns a new context whose feature-gated routes are
+// evaluated by f instead of the evaluator set with SetFeatureFlags. Gates
+// run before the generated handler wrappers, so set it from middleware
+// around the mux (e.g. to evaluate flags per tenant or in tests).
+func WithFeatureFlags(ctx context.Context, f FeatureFlags) context.Context {
+	return context.WithValue(ctx, featureFlagsContextKey{}, f)
+}
+
+// FeatureFlagsFromContext returns the evaluator for ctx: the one set with
+// WithFeatureFlags, else the one set with SetFeatureFlags.
+// Returns (nil, false) if neither is set.
+func FeatureFlagsFromContext(ctx context.Context) (FeatureFlags, bool) {
+	if f, ok := ctx.Value(featureFlagsContextKey{}).(FeatureFlags); ok && f != nil {
+		return f, true
+	}
+	featureFlagsMu.RLock()
+	defer featureFlagsMu.RUnlock()
+	return featureFlags, featureFlags != nil
+}
+
+// WithFeature wraps h so that it is served only while the feature flag name
+// is enabled. A disabled flag answers 404, as if the route didn't exist, and
+// so does a route with no evaluator configured: gated routes stay dark until
+// flags are wired up. An evaluator error answers 503.
+func WithFeature(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flags, ok := FeatureFlagsFromContext(r.Context())
+		if !ok {
+			WriteError(w, httperror.NotFound("not found"))
+			return
+		}
+		enabled, err := flags.Enabled(r.Context(), name)
+		if err != nil {
+			WriteError(w, httperror.Wrap(http.StatusServiceUnavailable, "feature temporarily unavailable", err))
+			return
+		}
+		if !enabled {
+			WriteError(w, httperror.NotFound("not found"))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,45 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithFeature(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+	})
+	gated := WithFeature("new-checkout", inner)
+	serve := func(ctx context.Context) int {
+		w := httptest.NewRecorder()
+		gated.ServeHTTP(w, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+		return w.Code
+	}
+
+	SetFeatureFlags(nil)
+	if code := serve(context.Background()); code != http.StatusNotFound {
+		t.Errorf("no evaluator: status = %d, want 404", code)
+	}
+
+	SetFeatureFlags(StaticFeatureFlags{"new-checkout": true})
+	defer SetFeatureFlags(nil)
+	if code := serve(context.Background()); code != http.StatusOK {
+		t.Errorf("enabled flag: status = %d, want 200", code)
+	}
+
+	// The context's evaluator takes precedence over the global one.
+	ctx := WithFeatureFlags(context.Background(), StaticFeatureFlags{})
+	if code := serve(ctx); code != http.StatusNotFound {
+		t.Errorf("disabled flag: status = %d, want 404", code)
+	}
+
+	failing := FeatureFlagsFunc(func(context.Context, string) (bool, error) {
+		return false, errors.New("flag store unreachable")
+	})
+	if code := serve(WithFeatureFlags(context.Background(), failing)); code != http.StatusServiceUnavailable {
+		t.Errorf("evaluator error: status = %d, want 503", code)
+	}
+}
@@ -0,0 +1,75 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// HealthzPath is where cmd/server mounts HealthzHandler.
+const HealthzPath = "/healthz"
+
+// healthzTimeout bounds each check made by HealthzHandler.
+const healthzTimeout = 2 * time.Second
+
+// Pinger is the part of *sql.DB that HealthzHandler uses.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthReport is the body of a HealthzHandler response.
+type HealthReport struct {
+	// Status is "ok", or "unavailable" when a check failed.
+	Status string `json:"status"`
+	// Database is "ok" or the error of pinging the database.
+	Database string `json:"database"`
+	// Migrations is omitted when the server was built without a migration plan.
+	Migrations *MigrationHealth `json:"migrations,omitempty"`
+}
+
+// MigrationHealth reports the migrations the database has not applied.
+type MigrationHealth struct {
+	Pending []string `json:"pending"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// HealthzHandler answers GET and HEAD with a HealthReport: 200 when the
+// database answers a ping and has no pending migrations, 503 otherwise, so
+// it can serve as a load balancer's readiness check. pending lists the
+// unapplied migrations; nil skips that check.
+func HealthzHandler(db Pinger, pending func(ctx context.Context) ([]string, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			WriteJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), healthzTimeout)
+		defer cancel()
+
+		report := HealthReport{Status: "ok", Database: "ok"}
+		if err := db.PingContext(ctx); err != nil {
+			report.Status = "unavailable"
+			report.Database = err.Error()
+		} else if pending != nil {
+			names, err := pending(ctx)
+			if names == nil {
+				names = []string{}
+			}
+			report.Migrations = &MigrationHealth{Pending: names}
+			if err != nil {
+				report.Migrations.Error = err.Error()
+			}
+			if err != nil || len(names) > 0 {
+				report.Status = "unavailable"
+			}
+		}
+
+		status := http.StatusOK
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		WriteJSON(w, status, report)
+	})
+}
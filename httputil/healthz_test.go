@@ -0,0 +1,87 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type fakePinger struct{ err error }
+
+func (p fakePinger) PingContext(context.Context) error { return p.err }
+
+func TestHealthzHandler(t *testing.T) {
+	none := func(context.Context) ([]string, error) { return nil, nil }
+	tests := []struct {
+		name       string
+		db         Pinger
+		pending    func(context.Context) ([]string, error)
+		wantStatus int
+		want       HealthReport
+	}{
+		{
+			name:       "no migration plan",
+			db:         fakePinger{},
+			wantStatus: http.StatusOK,
+			want:       HealthReport{Status: "ok", Database: "ok"},
+		},
+		{
+			name:       "up to date",
+			db:         fakePinger{},
+			pending:    none,
+			wantStatus: http.StatusOK,
+			want:       HealthReport{Status: "ok", Database: "ok", Migrations: &MigrationHealth{Pending: []string{}}},
+		},
+		{
+			name: "pending migrations",
+			db:   fakePinger{},
+			pending: func(context.Context) ([]string, error) {
+				return []string{"20260111160000_create_posts"}, nil
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       HealthReport{Status: "unavailable", Database: "ok", Migrations: &MigrationHealth{Pending: []string{"20260111160000_create_posts"}}},
+		},
+		{
+			name: "migration status unreadable",
+			db:   fakePinger{},
+			pending: func(context.Context) ([]string, error) {
+				return nil, errors.New("no such table: _portsql_migrations")
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       HealthReport{Status: "unavailable", Database: "ok", Migrations: &MigrationHealth{Pending: []string{}, Error: "no such table: _portsql_migrations"}},
+		},
+		{
+			name:       "database down",
+			db:         fakePinger{err: errors.New("connection refused")},
+			pending:    none,
+			wantStatus: http.StatusServiceUnavailable,
+			want:       HealthReport{Status: "unavailable", Database: "connection refused"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			HealthzHandler(tt.db, tt.pending).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthzPath, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var got HealthReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("report = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	HealthzHandler(fakePinger{}, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, HealthzPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
@@ -13,19 +13,29 @@ import (
 	"github.com/shipq/shipq/httpserver"
 )
 
-// WriteJSON writes a JSON response with the given status code.
+// WriteJSON writes a JSON response with the given status code. A v that
+// implements JSONStreamer is streamed rather than encoded in one piece.
 func WriteJSON(w http.ResponseWriter, status int, v any) {
+	if sv, ok := v.(JSONStreamer); ok {
+		writeStream(w, status, sv)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(v)
 }
 
 // WriteError writes an error response. If the error is an *httperror.Error,
-// the corresponding HTTP status code and message are used. Otherwise, a generic
+// the corresponding HTTP status code and message are used, along with a
+// "fields" list when the error names request fields. Otherwise, a generic
 // 500 Internal Server Error is returned.
 func WriteError(w http.ResponseWriter, err error) {
 	var httpErr *httperror.Error
 	if errors.As(err, &httpErr) {
+		if fields := httpErr.Fields(); len(fields) > 0 {
+			WriteJSON(w, httpErr.Code(), map[string]any{"error": httpErr.Message(), "fields": fields})
+			return
+		}
 		WriteJSON(w, httpErr.Code(), map[string]string{"error": httpErr.Message()})
 		return
 	}
@@ -49,6 +59,19 @@ func WrapHandler(q httpserver.Querier, injectCtx func(ctx context.Context) conte
 	})
 }
 
+// WithDeprecation wraps h so that every response carries a "Deprecation: true"
+// header and, when sunset is non-empty, a "Sunset" header (RFC 8594). sunset
+// must already be formatted as an HTTP-date.
+func WithDeprecation(sunset string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if sunset != "" {
+			w.Header().Set("Sunset", sunset)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 // sessionContextKey is the context key for storing the authenticated account's internal ID.
 type sessionContextKey struct{}
 
@@ -68,6 +91,18 @@ func SessionAccountIDFromContext(ctx context.Context) (int64, bool) {
 	return id, ok
 }
 
+// MustSessionAccountIDFromContext is like SessionAccountIDFromContext but
+// panics when no account ID is present. Use it in handlers that are only
+// reachable through WrapAuthHandler, where a missing ID means the route was
+// registered without .Auth().
+func MustSessionAccountIDFromContext(ctx context.Context) int64 {
+	id, ok := SessionAccountIDFromContext(ctx)
+	if !ok {
+		panic("httputil.MustSessionAccountIDFromContext: no session account ID in context -- is the route registered with .Auth() (WrapAuthHandler)?")
+	}
+	return id
+}
+
 // orgContextKey is the context key for storing the authenticated user's organization ID.
 type orgContextKey struct{}
 
@@ -87,18 +122,69 @@ func OrganizationIDFromContext(ctx context.Context) (int64, bool) {
 	return id, ok
 }
 
+// MustOrganizationIDFromContext is like OrganizationIDFromContext but panics
+// when no organization ID is present.
+func MustOrganizationIDFromContext(ctx context.Context) int64 {
+	id, ok := OrganizationIDFromContext(ctx)
+	if !ok {
+		panic("httputil.MustOrganizationIDFromContext: no organization ID in context -- is the route registered with .Auth() (WrapAuthHandler)?")
+	}
+	return id
+}
+
+// rolesContextKey is the context key for storing the caller's role names.
+type rolesContextKey struct{}
+
+// GlobalOwnerRole is the role name that bypasses every role check.
+const GlobalOwnerRole = "GLOBAL_OWNER"
+
+// WithRoles returns a new context carrying the caller's role names. Generated
+// handlers with column-level roles load them on demand when absent, so
+// middleware only needs to set them to avoid the extra query.
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesContextKey{}, roles)
+}
+
+// RolesFromContext extracts the caller's role names from the context.
+// Returns (nil, false) if no roles were injected.
+func RolesFromContext(ctx context.Context) ([]string, bool) {
+	roles, ok := ctx.Value(rolesContextKey{}).([]string)
+	return roles, ok
+}
+
+// RolesAllow reports whether roles grants access to something restricted to
+// allowed. An empty allowed list is unrestricted; GlobalOwnerRole is always
+// granted.
+func RolesAllow(roles []string, allowed ...string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, r := range roles {
+		if r == GlobalOwnerRole {
+			return true
+		}
+		for _, a := range allowed {
+			if r == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // WrapAuthHandler is like WrapHandler but also enforces authentication.
 // The checkAuth function should verify the current session and return the
 // authenticated account's internal ID and organization ID, or an error if
 // not authenticated. Both values are injected into the request context so
 // that downstream handlers can access them via SessionAccountIDFromContext
 // and OrganizationIDFromContext.
-// Returns 401 Unauthorized if checkAuth returns an error.
+// Returns 401 Unauthorized if checkAuth returns an error, or 403 Forbidden
+// if that error is a ForbiddenError.
 func WrapAuthHandler(q httpserver.Querier, injectCtx func(ctx context.Context) context.Context, checkAuth func(ctx context.Context) (accountID int64, orgID int64, err error), h http.HandlerFunc) http.Handler {
 	return WrapHandler(q, injectCtx, func(w http.ResponseWriter, r *http.Request) {
 		accountID, orgID, err := checkAuth(r.Context())
 		if err != nil {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			writeAuthError(w, err)
 			return
 		}
 		ctx := WithSessionAccountID(r.Context(), accountID)
@@ -112,7 +198,8 @@ func WrapAuthHandler(q httpserver.Querier, injectCtx func(ctx context.Context) c
 // If checkAuth succeeds, the account and org IDs are injected into context.
 // If checkAuth returns an error for which isNoSession returns true, the request
 // proceeds without authentication (context will have no account ID).
-// If checkAuth returns any other error (DB failure, etc.), a 500 is returned.
+// A ForbiddenError is answered with a 403, and any other error (DB failure,
+// etc.) with a 500.
 func WrapOptionalAuthHandler(
 	q httpserver.Querier,
 	injectCtx func(ctx context.Context) context.Context,
@@ -128,6 +215,11 @@ func WrapOptionalAuthHandler(
 				h(w, r)
 				return
 			}
+			var forbidden *ForbiddenError
+			if errors.As(err, &forbidden) {
+				WriteJSON(w, http.StatusForbidden, map[string]string{"error": forbidden.Message})
+				return
+			}
 			// Real error (DB failure, etc.)
 			WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
 			return
@@ -150,12 +242,50 @@ func (e *ForbiddenError) Error() string { return e.Message }
 // Forbidden returns a new ForbiddenError with the given message.
 func Forbidden(msg string) error { return &ForbiddenError{Message: msg} }
 
+// writeAuthError answers a request whose auth check failed: 403 when the
+// check returned a ForbiddenError (an authenticated caller asking for an
+// organization it does not belong to), 401 otherwise.
+func writeAuthError(w http.ResponseWriter, err error) {
+	var forbidden *ForbiddenError
+	if errors.As(err, &forbidden) {
+		WriteJSON(w, http.StatusForbidden, map[string]string{"error": forbidden.Message})
+		return
+	}
+	WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+}
+
+// requestedOrgContextKey is the context key for the organization public ID a
+// request asks to act in.
+type requestedOrgContextKey struct{}
+
+// WithScopeHeader wraps h so that the organization public ID sent in the
+// named request header reaches the auth check through
+// RequestedOrganizationFromContext. The auth check decides whether the
+// account may act in that organization; requests without the header act in
+// the account's default one.
+func WithScopeHeader(header string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get(header); v != "" {
+			r = r.WithContext(context.WithValue(r.Context(), requestedOrgContextKey{}, v))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// RequestedOrganizationFromContext returns the organization public ID the
+// request asked to act in through WithScopeHeader. Returns ("", false) if
+// the request did not send the header.
+func RequestedOrganizationFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(requestedOrgContextKey{}).(string)
+	return v, ok
+}
+
 // WrapRBACHandler is like WrapAuthHandler but also enforces RBAC (role-based
 // access control) after authentication. The checkRBAC callback always receives
 // orgID from the auth check; in the unscoped case the generated closure ignores it.
 //
 // Decision flow:
-//  1. checkAuth fails -> 401 Unauthorized
+//  1. checkAuth fails -> 401 Unauthorized (403 for a ForbiddenError)
 //  2. checkRBAC returns ForbiddenError -> 403 Forbidden
 //  3. checkRBAC returns other error -> 500 Internal Server Error
 //  4. Both pass -> handler is invoked
@@ -171,7 +301,7 @@ func WrapRBACHandler(
 	return WrapHandler(q, injectCtx, func(w http.ResponseWriter, r *http.Request) {
 		accountID, orgID, err := checkAuth(r.Context())
 		if err != nil {
-			WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			writeAuthError(w, err)
 			return
 		}
 		ctx := WithSessionAccountID(r.Context(), accountID)
@@ -7,7 +7,10 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/shipq/shipq/httperror"
 )
 
 func TestWriteJSON(t *testing.T) {
@@ -51,6 +54,25 @@ func TestWriteError_HTTPError(t *testing.T) {
 	}
 }
 
+func TestWriteError_Fields(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteError(w, httperror.Conflict("org_id, slug already exists").WithFields("org_id", "slug"))
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", w.Code)
+	}
+	var body struct {
+		Error  string   `json:"error"`
+		Fields []string `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	if body.Error != "org_id, slug already exists" || strings.Join(body.Fields, ",") != "org_id,slug" {
+		t.Errorf("unexpected body %+v", body)
+	}
+}
+
 func TestWriteError_GenericError(t *testing.T) {
 	w := httptest.NewRecorder()
 	WriteError(w, errors.New("something broke"))
@@ -160,6 +182,27 @@ func TestWrapAuthHandler_Unauthenticated(t *testing.T) {
 	}
 }
 
+func TestWithDeprecation(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+	})
+
+	w := httptest.NewRecorder()
+	WithDeprecation("Sun, 31 Jan 2027 00:00:00 GMT", inner).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if got := w.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("expected Deprecation: true, got %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Sun, 31 Jan 2027 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	WithDeprecation("", inner).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if _, ok := w.Header()["Sunset"]; ok {
+		t.Error("Sunset header should be omitted when no sunset date is set")
+	}
+}
+
 func TestSessionAccountIDFromContext_NotSet(t *testing.T) {
 	ctx := context.Background()
 	id, ok := SessionAccountIDFromContext(ctx)
@@ -204,6 +247,78 @@ func TestOrganizationIDFromContext_Set(t *testing.T) {
 	}
 }
 
+func TestMustSessionAccountIDFromContext(t *testing.T) {
+	ctx := WithSessionAccountID(context.Background(), 7)
+	if got := MustSessionAccountIDFromContext(ctx); got != 7 {
+		t.Errorf("expected 7, got %d", got)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expected panic when no session account ID is in context")
+		}
+		if msg, _ := r.(string); !strings.Contains(msg, "WrapAuthHandler") {
+			t.Errorf("panic message should name the missing middleware, got %v", r)
+		}
+	}()
+	MustSessionAccountIDFromContext(context.Background())
+}
+
+func TestMustOrganizationIDFromContext(t *testing.T) {
+	ctx := WithOrganizationID(context.Background(), 8)
+	if got := MustOrganizationIDFromContext(ctx); got != 8 {
+		t.Errorf("expected 8, got %d", got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic when no organization ID is in context")
+		}
+	}()
+	MustOrganizationIDFromContext(context.Background())
+}
+
+func TestRolesFromContext(t *testing.T) {
+	if _, ok := RolesFromContext(context.Background()); ok {
+		t.Error("expected ok=false when no roles are in context")
+	}
+
+	ctx := WithRoles(context.Background(), []string{})
+	roles, ok := RolesFromContext(ctx)
+	if !ok || len(roles) != 0 {
+		t.Errorf("expected (empty, true) for an explicitly empty role list, got (%v, %v)", roles, ok)
+	}
+
+	ctx = WithRoles(context.Background(), []string{"hr", "viewer"})
+	roles, ok = RolesFromContext(ctx)
+	if !ok || len(roles) != 2 || roles[0] != "hr" {
+		t.Errorf("unexpected roles %v, ok=%v", roles, ok)
+	}
+}
+
+func TestRolesAllow(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []string
+		allowed []string
+		want    bool
+	}{
+		{"unrestricted", nil, nil, true},
+		{"no roles", nil, []string{"hr"}, false},
+		{"matching role", []string{"viewer", "hr"}, []string{"admin", "hr"}, true},
+		{"non-matching role", []string{"viewer"}, []string{"admin", "hr"}, false},
+		{"global owner", []string{GlobalOwnerRole}, []string{"hr"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RolesAllow(tt.roles, tt.allowed...); got != tt.want {
+				t.Errorf("RolesAllow(%v, %v) = %v, want %v", tt.roles, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBothContextValues_Independent(t *testing.T) {
 	ctx := context.Background()
 	ctx = WithSessionAccountID(ctx, 100)
@@ -306,6 +421,52 @@ func TestWrapRBACHandler_Forbidden(t *testing.T) {
 	}
 }
 
+func TestWrapRBACHandler_ScopeHeader(t *testing.T) {
+	// The auth check sees the requested organization and refuses one the
+	// account does not belong to with a 403.
+	checkAuth := func(ctx context.Context) (int64, int64, error) {
+		switch org, _ := RequestedOrganizationFromContext(ctx); org {
+		case "":
+			return 42, 1, nil
+		case "org_member":
+			return 42, 7, nil
+		default:
+			return 0, 0, Forbidden("not a member of organization " + org)
+		}
+	}
+	var gotOrg int64
+	handler := WithScopeHeader("X-Organization-ID", WrapRBACHandler(
+		&mockQuerier{},
+		func(ctx context.Context) context.Context { return ctx },
+		checkAuth,
+		func(ctx context.Context, accountID, orgID int64, routePath, method string) error { return nil },
+		"/test",
+		"GET",
+		func(w http.ResponseWriter, r *http.Request) { gotOrg, _ = OrganizationIDFromContext(r.Context()) },
+	))
+
+	for _, tc := range []struct {
+		header  string
+		status  int
+		wantOrg int64
+	}{
+		{"", http.StatusOK, 1},
+		{"org_member", http.StatusOK, 7},
+		{"org_other", http.StatusForbidden, 0},
+	} {
+		gotOrg = 0
+		req := httptest.NewRequest("GET", "/test", nil)
+		if tc.header != "" {
+			req.Header.Set("X-Organization-ID", tc.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tc.status || gotOrg != tc.wantOrg {
+			t.Errorf("header %q: status %d, org %d; want %d, %d", tc.header, w.Code, gotOrg, tc.status, tc.wantOrg)
+		}
+	}
+}
+
 func TestWrapRBACHandler_InternalError(t *testing.T) {
 	called := false
 	handler := WrapRBACHandler(
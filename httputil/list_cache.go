@@ -0,0 +1,146 @@
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listCacheSweepSize is the number of entries above which storing a result
+// first drops every expired entry.
+const listCacheSweepSize = 1024
+
+// ListCacheStats are the counters of one list endpoint's micro-cache.
+type ListCacheStats struct {
+	// Hits is the number of requests answered from a cached result.
+	Hits int64 `json:"hits"`
+	// Coalesced is the number of requests that waited for an identical
+	// request already querying the database and shared its result.
+	Coalesced int64 `json:"coalesced"`
+	// Misses is the number of requests that queried the database.
+	Misses int64 `json:"misses"`
+}
+
+// ListCache is a short-TTL micro-cache for a generated List handler. It
+// answers identical requests (same parameters and scope) arriving within
+// the TTL with one database query, which sheds load when many clients ask
+// for the same first page at once. Errors are shared with the requests
+// waiting on a call but never cached.
+type ListCache[T any] struct {
+	endpoint string
+	ttl      time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]listCacheEntry[T]
+	inflight map[string]*listCacheCall[T]
+	stats    ListCacheStats
+}
+
+type listCacheEntry[T any] struct {
+	value   *T
+	expires time.Time
+}
+
+type listCacheCall[T any] struct {
+	done  chan struct{}
+	value *T
+	err   error
+}
+
+var (
+	listCachesMu sync.Mutex
+	listCaches   = map[string]func() ListCacheStats{}
+)
+
+// NewListCache creates the micro-cache of endpoint (e.g. "GET /posts"),
+// keeping results for ttl. Its counters are reported by ListCacheSnapshot.
+func NewListCache[T any](endpoint string, ttl time.Duration) *ListCache[T] {
+	c := &ListCache[T]{
+		endpoint: endpoint,
+		ttl:      ttl,
+		entries:  make(map[string]listCacheEntry[T]),
+		inflight: make(map[string]*listCacheCall[T]),
+	}
+	listCachesMu.Lock()
+	listCaches[endpoint] = c.Stats
+	listCachesMu.Unlock()
+	return c
+}
+
+// ListCacheKey builds a cache key from the values a list response depends
+// on: its parameters and the caller's scope.
+func ListCacheKey(parts ...any) string {
+	strs := make([]string, len(parts))
+	for i, p := range parts {
+		strs[i] = fmt.Sprint(p)
+	}
+	return strings.Join(strs, "\x00")
+}
+
+// Do returns the cached result for key, waits for an identical call in
+// flight, or calls load and caches its result for the TTL.
+func (c *ListCache[T]) Do(ctx context.Context, key string, load func() (*T, error)) (*T, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if time.Now().Before(e.expires) {
+			c.stats.Hits++
+			c.mu.Unlock()
+			return e.value, nil
+		}
+		delete(c.entries, key)
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.stats.Coalesced++
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.value, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &listCacheCall[T]{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	call.value, call.err = load()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		now := time.Now()
+		if len(c.entries) >= listCacheSweepSize {
+			for k, e := range c.entries {
+				if !now.Before(e.expires) {
+					delete(c.entries, k)
+				}
+			}
+		}
+		c.entries[key] = listCacheEntry[T]{value: call.value, expires: now.Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.value, call.err
+}
+
+// Stats returns the cache's counters.
+func (c *ListCache[T]) Stats() ListCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// ListCacheSnapshot returns the counters of every list micro-cache, keyed
+// by endpoint.
+func ListCacheSnapshot() map[string]ListCacheStats {
+	listCachesMu.Lock()
+	defer listCachesMu.Unlock()
+	out := make(map[string]ListCacheStats, len(listCaches))
+	for endpoint, stats := range listCaches {
+		out[endpoint] = stats()
+	}
+	return out
+}
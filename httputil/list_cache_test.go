@@ -0,0 +1,88 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type cachedPage struct{ Items []string }
+
+func TestListCache_HitsAndExpiry(t *testing.T) {
+	cache := NewListCache[cachedPage]("GET /cache-hits", 50*time.Millisecond)
+	ctx := context.Background()
+	var loads int
+	load := func() (*cachedPage, error) {
+		loads++
+		return &cachedPage{Items: []string{"a"}}, nil
+	}
+
+	key := ListCacheKey(int64(7), 20, "")
+	first, _ := cache.Do(ctx, key, load)
+	second, _ := cache.Do(ctx, key, load)
+	if loads != 1 || first != second {
+		t.Fatalf("expected the second request to be a hit, loads = %d", loads)
+	}
+	if _, err := cache.Do(ctx, ListCacheKey(int64(8), 20, ""), load); err != nil || loads != 2 {
+		t.Errorf("a different scope must not share the entry, loads = %d", loads)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	cache.Do(ctx, key, load)
+	if loads != 3 {
+		t.Errorf("expired entry should reload, loads = %d", loads)
+	}
+
+	stats := ListCacheSnapshot()["GET /cache-hits"]
+	if stats.Hits != 1 || stats.Misses != 3 || stats.Coalesced != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestListCache_CoalescesConcurrentRequests(t *testing.T) {
+	cache := NewListCache[cachedPage]("GET /cache-coalesce", time.Second)
+	release := make(chan struct{})
+	var loads atomic.Int32
+	load := func() (*cachedPage, error) {
+		loads.Add(1)
+		<-release
+		return &cachedPage{}, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Do(context.Background(), "page-1", load)
+		}()
+	}
+	for cache.Stats().Misses+cache.Stats().Coalesced < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := loads.Load(); got != 1 {
+		t.Errorf("loads = %d, want 1", got)
+	}
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Coalesced != n-1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestListCache_ErrorsAreNotCached(t *testing.T) {
+	cache := NewListCache[cachedPage]("GET /cache-errors", time.Second)
+	boom := errors.New("boom")
+	if _, err := cache.Do(context.Background(), "k", func() (*cachedPage, error) { return nil, boom }); err != boom {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	page, err := cache.Do(context.Background(), "k", func() (*cachedPage, error) { return &cachedPage{}, nil })
+	if err != nil || page == nil {
+		t.Errorf("expected a fresh load after an error, got %v, %v", page, err)
+	}
+}
@@ -0,0 +1,36 @@
+package httputil
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// AllowedMethods returns the value of the Allow header for a path served by
+// methods: the methods themselves, HEAD when GET is among them (ServeMux
+// routes HEAD requests to GET patterns, and the server drops the body), and
+// OPTIONS, sorted and comma-separated.
+func AllowedMethods(methods ...string) string {
+	allow := []string{http.MethodOptions}
+	for _, m := range methods {
+		m = strings.ToUpper(m)
+		allow = append(allow, m)
+		if m == http.MethodGet {
+			allow = append(allow, http.MethodHead)
+		}
+	}
+	slices.Sort(allow)
+	return strings.Join(slices.Compact(allow), ", ")
+}
+
+// Options returns a handler answering OPTIONS requests for a path served by
+// methods with 204 No Content and an Allow header listing them (see
+// AllowedMethods). CORS middleware wrapping the mux sees preflight requests
+// first; this answers the rest, e.g. gateway health checks.
+func Options(methods ...string) http.Handler {
+	allow := AllowedMethods(methods...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
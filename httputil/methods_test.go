@@ -0,0 +1,77 @@
+package httputil
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowedMethods(t *testing.T) {
+	tests := []struct {
+		methods []string
+		want    string
+	}{
+		{[]string{"GET"}, "GET, HEAD, OPTIONS"},
+		{[]string{"POST", "GET"}, "GET, HEAD, OPTIONS, POST"},
+		{[]string{"DELETE", "PATCH", "PATCH"}, "DELETE, OPTIONS, PATCH"},
+		{nil, "OPTIONS"},
+	}
+	for _, tt := range tests {
+		if got := AllowedMethods(tt.methods...); got != tt.want {
+			t.Errorf("AllowedMethods(%v) = %q, want %q", tt.methods, got, tt.want)
+		}
+	}
+}
+
+func TestOptionsAndHead(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /posts/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+	}))
+	mux.Handle("DELETE /posts/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.Handle("OPTIONS /posts/{id}", Options("GET", "DELETE"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodOptions, srv.URL+"/posts/1", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("OPTIONS status = %d, want 204", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Allow"); got != "DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("Allow = %q", got)
+	}
+
+	get, err := http.Get(srv.URL + "/posts/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	getBody, _ := io.ReadAll(get.Body)
+	get.Body.Close()
+
+	head, err := http.Head(srv.URL + "/posts/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	headBody, _ := io.ReadAll(head.Body)
+	head.Body.Close()
+	if head.StatusCode != http.StatusOK {
+		t.Errorf("HEAD status = %d, want 200", head.StatusCode)
+	}
+	if len(headBody) != 0 {
+		t.Errorf("HEAD returned a body: %q", headBody)
+	}
+	if head.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("HEAD Content-Type = %q", head.Header.Get("Content-Type"))
+	}
+	if head.ContentLength != int64(len(getBody)) {
+		t.Errorf("HEAD Content-Length = %d, want %d", head.ContentLength, len(getBody))
+	}
+}
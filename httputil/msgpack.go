@@ -0,0 +1,333 @@
+package httputil
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MsgpackCodec encodes bodies as MessagePack (application/msgpack). Values
+// are shaped exactly like their JSON encoding: structs become maps keyed by
+// their json names and times become RFC 3339 strings. When decoding, binary
+// values are accepted for []byte fields and timestamp extensions for
+// time.Time fields.
+type MsgpackCodec struct{}
+
+// ContentType implements Codec.
+func (MsgpackCodec) ContentType() string { return "application/msgpack" }
+
+// Marshal implements Codec.
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	tree, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendMsgpack(nil, tree)
+}
+
+// Unmarshal implements Codec.
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	d := &msgpackDecoder{data: data}
+	tree, err := d.value(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: trailing data")
+	}
+	return fromGeneric(tree, v)
+}
+
+func appendMsgpack(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		n, err := numberValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return appendMsgpack(b, n)
+	case int64:
+		switch {
+		case v >= 0:
+			return appendMsgpack(b, uint64(v))
+		case v >= -32:
+			return append(b, byte(v)), nil
+		case v >= math.MinInt8:
+			return append(b, 0xd0, byte(v)), nil
+		case v >= math.MinInt16:
+			return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v)), nil
+		case v >= math.MinInt32:
+			return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v)), nil
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v)), nil
+	case uint64:
+		switch {
+		case v <= 0x7f:
+			return append(b, byte(v)), nil
+		case v <= math.MaxUint8:
+			return append(b, 0xcc, byte(v)), nil
+		case v <= math.MaxUint16:
+			return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v)), nil
+		case v <= math.MaxUint32:
+			return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v)), nil
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v)), nil
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			b = append(b, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			b = append(b, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+		}
+		return append(b, v...), nil
+	case []any:
+		b = appendMsgpackLen(b, len(v), 0x90, 0xdc, 0xdd)
+		var err error
+		for _, item := range v {
+			if b, err = appendMsgpack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		b = appendMsgpackLen(b, len(v), 0x80, 0xde, 0xdf)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var err error
+		for _, k := range keys {
+			if b, err = appendMsgpack(b, k); err != nil {
+				return nil, err
+			}
+			if b, err = appendMsgpack(b, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("msgpack: cannot encode %T", v)
+}
+
+// appendMsgpackLen writes an array or map header: the fix form for fewer
+// than 16 elements, otherwise the 16- or 32-bit form.
+func appendMsgpackLen(b []byte, n int, fix, b16, b32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, b16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, b32), uint32(n))
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+func (d *msgpackDecoder) take(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.take(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (d *msgpackDecoder) value(depth int) (any, error) {
+	if depth > maxCodecDepth {
+		return nil, errors.New("msgpack: nesting too deep")
+	}
+	head, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+	c := head[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c&0x0f), depth)
+	case c&0xf0 == 0x80:
+		return d.mapping(int(c&0x0f), depth)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.uint(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, nil
+	case 0xca:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.take(int(n))
+		if err != nil {
+			return nil, err
+		}
+		// []byte fields decode from base64 strings in JSON.
+		return base64.StdEncoding.EncodeToString(b), nil
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapping(int(n), depth)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: invalid type byte 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n int) (any, error) {
+	b, err := d.take(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n, depth int) (any, error) {
+	// Every element takes at least one byte.
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	out := make([]any, n)
+	for i := range out {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (d *msgpackDecoder) mapping(n, depth int) (any, error) {
+	if n > (len(d.data)-d.pos)/2 {
+		return nil, errMsgpackShort
+	}
+	out := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key must be a string, got %T", k)
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// ext decodes an extension value of n data bytes. Only the timestamp
+// extension (type -1) is supported; it becomes an RFC 3339 string.
+func (d *msgpackDecoder) ext(n int) (any, error) {
+	typ, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.take(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ[0]) != -1 {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %d", int8(typ[0]))
+	}
+	var t time.Time
+	switch n {
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(b)), 0)
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		t = time.Unix(int64(v&0x3ffffffff), int64(v>>34))
+	case 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b)))
+	default:
+		return nil, fmt.Errorf("msgpack: invalid timestamp length %d", n)
+	}
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
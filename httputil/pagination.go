@@ -0,0 +1,109 @@
+package httputil
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// DeepScanDepth is the page depth at which a client paginating a list
+// endpoint is treated as iterating the whole table. Reaching it logs a
+// warning once per scan; 0 disables the warning.
+var DeepScanDepth = 100
+
+// PaginationLogger receives deep-scan and cursor-decode warnings. When nil,
+// slog.Default() is used.
+var PaginationLogger *slog.Logger
+
+// PaginationStats are the cursor pagination counters of one list endpoint.
+type PaginationStats struct {
+	// Pages is the number of pages served.
+	Pages int64 `json:"pages"`
+	// FollowUpPages is the number of pages requested with a cursor.
+	FollowUpPages int64 `json:"follow_up_pages"`
+	// DeepScans counts clients that reached DeepScanDepth.
+	DeepScans int64 `json:"deep_scans"`
+	// MaxDepth is the deepest page served.
+	MaxDepth int64 `json:"max_depth"`
+	// CursorErrors counts cursors that failed to decode, most often
+	// because they were issued before a schema change. The request is
+	// served from the first page instead.
+	CursorErrors int64 `json:"cursor_errors"`
+}
+
+var (
+	paginationMu    sync.Mutex
+	paginationStats = map[string]*PaginationStats{}
+)
+
+// statsFor returns the counters for endpoint. paginationMu must be held.
+func statsFor(endpoint string) *PaginationStats {
+	s, ok := paginationStats[endpoint]
+	if !ok {
+		s = &PaginationStats{}
+		paginationStats[endpoint] = s
+	}
+	return s
+}
+
+func paginationLogger() *slog.Logger {
+	if PaginationLogger != nil {
+		return PaginationLogger
+	}
+	return slog.Default()
+}
+
+// RecordListPage records that endpoint served the page at depth (1 for a
+// request without a cursor). Generated list handlers call it for every
+// page.
+func RecordListPage(ctx context.Context, endpoint string, depth int) {
+	paginationMu.Lock()
+	s := statsFor(endpoint)
+	s.Pages++
+	if depth > 1 {
+		s.FollowUpPages++
+	}
+	if int64(depth) > s.MaxDepth {
+		s.MaxDepth = int64(depth)
+	}
+	deepScan := DeepScanDepth > 0 && depth == DeepScanDepth
+	if deepScan {
+		s.DeepScans++
+	}
+	paginationMu.Unlock()
+
+	if deepScan {
+		paginationLogger().WarnContext(ctx, "deep pagination scan",
+			"endpoint", endpoint, "depth", depth)
+	}
+}
+
+// RecordCursorError records that endpoint received a cursor it could not
+// decode.
+func RecordCursorError(ctx context.Context, endpoint string) {
+	paginationMu.Lock()
+	statsFor(endpoint).CursorErrors++
+	paginationMu.Unlock()
+
+	paginationLogger().WarnContext(ctx, "invalid pagination cursor",
+		"endpoint", endpoint)
+}
+
+// PaginationSnapshot returns a copy of the counters of every list endpoint
+// that has served a page, keyed by endpoint.
+func PaginationSnapshot() map[string]PaginationStats {
+	paginationMu.Lock()
+	defer paginationMu.Unlock()
+	out := make(map[string]PaginationStats, len(paginationStats))
+	for endpoint, s := range paginationStats {
+		out[endpoint] = *s
+	}
+	return out
+}
+
+// ResetPaginationStats clears all pagination counters.
+func ResetPaginationStats() {
+	paginationMu.Lock()
+	defer paginationMu.Unlock()
+	paginationStats = map[string]*PaginationStats{}
+}
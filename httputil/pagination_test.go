@@ -0,0 +1,82 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func capturePaginationLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	PaginationLogger = slog.New(slog.NewTextHandler(&buf, nil))
+	ResetPaginationStats()
+	t.Cleanup(func() {
+		PaginationLogger = nil
+		ResetPaginationStats()
+	})
+	return &buf
+}
+
+func TestRecordListPage_CountsDepthAndDeepScans(t *testing.T) {
+	logs := capturePaginationLog(t)
+	oldDepth := DeepScanDepth
+	DeepScanDepth = 3
+	defer func() { DeepScanDepth = oldDepth }()
+
+	ctx := context.Background()
+	for depth := 1; depth <= 4; depth++ {
+		RecordListPage(ctx, "list posts", depth)
+	}
+	RecordListPage(ctx, "list authors", 1)
+
+	stats := PaginationSnapshot()
+	posts := stats["list posts"]
+	if posts.Pages != 4 || posts.FollowUpPages != 3 || posts.MaxDepth != 4 || posts.DeepScans != 1 {
+		t.Errorf("list posts stats = %+v", posts)
+	}
+	if authors := stats["list authors"]; authors.Pages != 1 || authors.FollowUpPages != 0 || authors.DeepScans != 0 {
+		t.Errorf("list authors stats = %+v", authors)
+	}
+
+	out := logs.String()
+	if strings.Count(out, "deep pagination scan") != 1 || !strings.Contains(out, "endpoint=\"list posts\"") || !strings.Contains(out, "depth=3") {
+		t.Errorf("expected one deep-scan warning for list posts at depth 3, got:\n%s", out)
+	}
+}
+
+func TestRecordListPage_DeepScanDisabled(t *testing.T) {
+	logs := capturePaginationLog(t)
+	oldDepth := DeepScanDepth
+	DeepScanDepth = 0
+	defer func() { DeepScanDepth = oldDepth }()
+
+	RecordListPage(context.Background(), "list posts", 1000)
+	if got := PaginationSnapshot()["list posts"].DeepScans; got != 0 {
+		t.Errorf("DeepScans = %d, want 0", got)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no warnings, got:\n%s", logs)
+	}
+}
+
+func TestRecordCursorError(t *testing.T) {
+	logs := capturePaginationLog(t)
+
+	RecordCursorError(context.Background(), "list posts")
+	RecordCursorError(context.Background(), "list posts")
+
+	if got := PaginationSnapshot()["list posts"].CursorErrors; got != 2 {
+		t.Errorf("CursorErrors = %d, want 2", got)
+	}
+	if !strings.Contains(logs.String(), "invalid pagination cursor") {
+		t.Errorf("expected a cursor warning, got:\n%s", logs)
+	}
+
+	ResetPaginationStats()
+	if len(PaginationSnapshot()) != 0 {
+		t.Error("ResetPaginationStats should clear all endpoints")
+	}
+}
@@ -0,0 +1,188 @@
+package httputil
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// JSONStreamer is implemented by response values that are too large to
+// marshal in one go, typically a record embedding thousands of child rows.
+// WriteJSON and WriteResponse call StreamJSON instead of encoding the value,
+// and always answer with JSON. The value's struct fields still describe the
+// response shape for OpenAPI and the TypeScript client.
+type JSONStreamer interface {
+	StreamJSON(s *JSONStream) error
+}
+
+// streamFlushSize is how much output a JSONStream buffers before sending it
+// to the client.
+const streamFlushSize = 32 << 10
+
+// JSONStream writes a JSON response incrementally. Output is buffered and
+// flushed to the client every streamFlushSize bytes, so memory stays bounded
+// however many items are written. Writes block while the client is not
+// reading, which throttles the producer (and the database rows feeding it)
+// to the client's pace; once the client goes away every further write fails
+// and the producer is stopped with that error. A client that stays connected
+// but stops reading is given StreamWriteTimeout per flush.
+type JSONStream struct {
+	buf *bufio.Writer
+}
+
+// StreamWriteTimeout bounds each flush of a streamed response. Without it a
+// client that keeps the connection open but never reads would hold the
+// handler, and the database rows it is scanning, indefinitely. Zero
+// disables the deadline.
+var StreamWriteTimeout = 30 * time.Second
+
+// streamWriter sends the status line on the first write and flushes each
+// chunk bufio hands it, so a full buffer reaches the client straight away.
+// Flushing and deadlines go through an http.ResponseController, which
+// unwraps middleware writers down to the connection.
+type streamWriter struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	status      int
+	wroteHeader bool
+	deadline    bool
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.w.Header().Set("Content-Type", "application/json")
+		sw.w.WriteHeader(sw.status)
+		sw.wroteHeader = true
+	}
+	if StreamWriteTimeout > 0 {
+		// Writers that cannot set deadlines (e.g. test recorders) just block.
+		if sw.rc.SetWriteDeadline(time.Now().Add(StreamWriteTimeout)) == nil {
+			sw.deadline = true
+		}
+	}
+	n, err := sw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if err := sw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
+
+// clearDeadline removes the write deadline, so it does not carry over to the
+// next request on a keep-alive connection.
+func (sw *streamWriter) clearDeadline() {
+	if sw.deadline {
+		sw.rc.SetWriteDeadline(time.Time{})
+	}
+}
+
+// writeStream streams v as the response body. Nothing is sent until the
+// first buffer fills, so a StreamJSON that fails early still produces a
+// proper error response; a failure after that leaves the body truncated,
+// which clients see as invalid JSON.
+func writeStream(w http.ResponseWriter, status int, v JSONStreamer) {
+	out := &streamWriter{w: w, rc: http.NewResponseController(w), status: status}
+	defer out.clearDeadline()
+	s := &JSONStream{buf: bufio.NewWriterSize(out, streamFlushSize)}
+	if err := v.StreamJSON(s); err != nil {
+		if !out.wroteHeader {
+			WriteError(w, err)
+		}
+		return
+	}
+	s.buf.Flush()
+}
+
+// Raw writes pre-encoded JSON, such as punctuation between values.
+func (s *JSONStream) Raw(data string) error {
+	_, err := s.buf.WriteString(data)
+	return err
+}
+
+// Value writes v encoded as JSON.
+func (s *JSONStream) Value(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.buf.Write(data)
+	return err
+}
+
+// Array writes a JSON array of the values items yields. items stops at the
+// first error yield returns.
+func (s *JSONStream) Array(items func(yield func(any) error) error) error {
+	if err := s.Raw("["); err != nil {
+		return err
+	}
+	first := true
+	err := items(func(v any) error {
+		if !first {
+			if err := s.Raw(","); err != nil {
+				return err
+			}
+		}
+		first = false
+		return s.Value(v)
+	})
+	if err != nil {
+		return err
+	}
+	return s.Raw("]")
+}
+
+// Object writes head, which must encode as a JSON object, with one more
+// member named field holding the array streamed from items. head must not
+// itself contain field; tag the field `json:",omitempty"` and leave it nil.
+//
+//	func (r *GetPostResponse) StreamJSON(s *httputil.JSONStream) error {
+//		return s.Object(*r, "comments", r.comments)
+//	}
+func (s *JSONStream) Object(head any, field string, items func(yield func(any) error) error) error {
+	return s.ObjectFields(head, StreamedField{Name: field, Items: items})
+}
+
+// StreamedField is a member of a streamed object whose array value is
+// read from Items while the response is written.
+type StreamedField struct {
+	Name  string
+	Items func(yield func(any) error) error
+}
+
+// ObjectFields is Object with any number of streamed members, written in
+// order after the members of head.
+func (s *JSONStream) ObjectFields(head any, fields ...StreamedField) error {
+	data, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+	if len(data) < 2 || data[0] != '{' || data[len(data)-1] != '}' {
+		return errors.New("httputil: streamed object head must encode as a JSON object")
+	}
+	if _, err := s.buf.Write(data[:len(data)-1]); err != nil {
+		return err
+	}
+	sep := len(data) > 2
+	for _, f := range fields {
+		if sep {
+			if err := s.Raw(","); err != nil {
+				return err
+			}
+		}
+		sep = true
+		if err := s.Value(f.Name); err != nil {
+			return err
+		}
+		if err := s.Raw(":"); err != nil {
+			return err
+		}
+		if err := s.Array(f.Items); err != nil {
+			return err
+		}
+	}
+	return s.Raw("}")
+}
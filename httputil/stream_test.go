@@ -0,0 +1,242 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/httperror"
+)
+
+type streamTestComment struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+type streamTestPost struct {
+	ID       string              `json:"id"`
+	Title    string              `json:"title"`
+	Comments []streamTestComment `json:"comments,omitempty"`
+
+	count int
+	err   error
+}
+
+func (p *streamTestPost) StreamJSON(s *JSONStream) error {
+	return s.Object(*p, "comments", func(yield func(any) error) error {
+		for i := 0; i < p.count; i++ {
+			if err := yield(streamTestComment{ID: i, Body: strings.Repeat("x", 100)}); err != nil {
+				return err
+			}
+		}
+		return p.err
+	})
+}
+
+func TestWriteJSON_Streamer(t *testing.T) {
+	for _, count := range []int{0, 1, 3, 2000} {
+		rec := httptest.NewRecorder()
+		WriteJSON(rec, http.StatusOK, &streamTestPost{ID: "p1", Title: "Hi", count: count})
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("count %d: status = %d", count, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("count %d: Content-Type = %q", count, ct)
+		}
+		var got struct {
+			ID       string              `json:"id"`
+			Title    string              `json:"title"`
+			Comments []streamTestComment `json:"comments"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("count %d: invalid JSON: %v", count, err)
+		}
+		if got.ID != "p1" || got.Title != "Hi" || len(got.Comments) != count {
+			t.Errorf("count %d: got id=%q title=%q comments=%d", count, got.ID, got.Title, len(got.Comments))
+		}
+		if count > 0 && got.Comments[count-1].ID != count-1 {
+			t.Errorf("count %d: last comment id = %d", count, got.Comments[count-1].ID)
+		}
+	}
+}
+
+func TestWriteJSON_StreamerFlushes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteJSON(rec, http.StatusOK, &streamTestPost{ID: "p1", count: 2000})
+	if !rec.Flushed {
+		t.Error("expected a large stream to be flushed to the client")
+	}
+}
+
+func TestWriteJSON_StreamerEarlyError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteJSON(rec, http.StatusOK, &streamTestPost{ID: "p1", count: 2, err: httperror.NotFound("post not found")})
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "post not found") {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
+
+func TestWriteJSON_StreamerLateError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteJSON(rec, http.StatusOK, &streamTestPost{ID: "p1", count: 2000, err: errors.New("boom")})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 once streaming has started", rec.Code)
+	}
+	if json.Valid(rec.Body.Bytes()) {
+		t.Error("expected a truncated body after a mid-stream failure")
+	}
+}
+
+func TestWriteResponse_StreamerIgnoresCodec(t *testing.T) {
+	RegisterCodec(MsgpackCodec{})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/msgpack")
+	rec := httptest.NewRecorder()
+	WriteResponse(rec, r, http.StatusOK, &streamTestPost{ID: "p1", count: 1})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("invalid JSON: %s", rec.Body.String())
+	}
+}
+
+func TestJSONStream_ObjectRequiresObjectHead(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteJSON(rec, http.StatusOK, streamFunc(func(s *JSONStream) error {
+		return s.Object([]int{1}, "items", func(func(any) error) error { return nil })
+	}))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+}
+
+func TestJSONStream_ObjectFields(t *testing.T) {
+	items := func(values ...string) func(func(any) error) error {
+		return func(yield func(any) error) error {
+			for _, v := range values {
+				if err := yield(v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	tests := []struct {
+		name   string
+		head   any
+		fields []StreamedField
+		want   string
+	}{
+		{"no fields", struct{}{}, nil, `{}`},
+		{"empty head", struct{}{}, []StreamedField{{"tags", items("a")}}, `{"tags":["a"]}`},
+		{
+			"several fields",
+			struct {
+				ID string `json:"id"`
+			}{"p1"},
+			[]StreamedField{{"tags", items("a", "b")}, {"labels", items()}},
+			`{"id":"p1","tags":["a","b"],"labels":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteJSON(rec, http.StatusOK, streamFunc(func(s *JSONStream) error {
+				return s.ObjectFields(tt.head, tt.fields...)
+			}))
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type streamFunc func(s *JSONStream) error
+
+func (f streamFunc) StreamJSON(s *JSONStream) error { return f(s) }
+
+// endlessStreamServer streams an unbounded array and reports on stopped
+// how many items it wrote once the producer gives up.
+func endlessStreamServer(t *testing.T) (*httptest.Server, <-chan int) {
+	t.Helper()
+	stopped := make(chan int, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 0
+		WriteJSON(w, http.StatusOK, streamFunc(func(s *JSONStream) error {
+			err := s.Array(func(yield func(any) error) error {
+				for ; ; n++ {
+					if err := yield(strings.Repeat("x", 1000)); err != nil {
+						return err
+					}
+				}
+			})
+			stopped <- n
+			return err
+		}))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, stopped
+}
+
+func TestWriteJSON_StreamerStopsWhenClientDisconnects(t *testing.T) {
+	srv, stopped := endlessStreamServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(resp.Body, make([]byte, 64<<10)); err != nil {
+		t.Fatalf("reading the start of the stream: %v", err)
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("producer kept streaming after the client disconnected")
+	}
+}
+
+func TestWriteJSON_StreamerTimesOutStalledClient(t *testing.T) {
+	old := StreamWriteTimeout
+	StreamWriteTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { StreamWriteTimeout = old })
+
+	srv, stopped := endlessStreamServer(t)
+
+	// Connect and send a request, then never read the response.
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("producer blocked on a client that stopped reading")
+	}
+}
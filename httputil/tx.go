@@ -0,0 +1,66 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+)
+
+// TxBeginner starts a transaction for a request. It returns the context the
+// handler runs with, typically one carrying a transaction-scoped query
+// runner, and the functions that commit and roll the transaction back.
+type TxBeginner func(ctx context.Context) (txCtx context.Context, commit, rollback func() error, err error)
+
+// WithTx wraps h so that it runs in a transaction started by begin. The
+// response is buffered: a status below 400 commits the transaction before
+// anything reaches the client, so a failed commit still answers 500. A
+// status of 400 or above, or a panic, rolls it back. Headers set by h are
+// written as usual.
+func WithTx(begin TxBeginner, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, commit, rollback, err := begin(r.Context())
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		done := false
+		defer func() {
+			if !done {
+				rollback()
+			}
+		}()
+
+		tw := &txWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(tw, r.WithContext(ctx))
+
+		done = true
+		if tw.status >= 400 {
+			rollback()
+		} else if err := commit(); err != nil {
+			WriteError(w, err)
+			return
+		}
+		w.WriteHeader(tw.status)
+		w.Write(tw.body.Bytes())
+	})
+}
+
+// txWriter holds a response back until WithTx has finished the transaction.
+type txWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (tw *txWriter) WriteHeader(status int) {
+	if !tw.wroteHeader {
+		tw.status = status
+		tw.wroteHeader = true
+	}
+}
+
+func (tw *txWriter) Write(p []byte) (int, error) {
+	tw.wroteHeader = true
+	return tw.body.Write(p)
+}
@@ -0,0 +1,114 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shipq/shipq/httperror"
+)
+
+// fakeTx records how WithTx finished its transaction.
+type fakeTx struct {
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (f *fakeTx) begin(ctx context.Context) (context.Context, func() error, func() error, error) {
+	commit := func() error {
+		f.committed = f.commitErr == nil
+		return f.commitErr
+	}
+	rollback := func() error {
+		f.rolledBack = true
+		return nil
+	}
+	return context.WithValue(ctx, fakeTx{}, f), commit, rollback, nil
+}
+
+func TestWithTx(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		commitErr    error
+		wantStatus   int
+		wantCommit   bool
+		wantRollback bool
+	}{
+		{
+			name: "success commits",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Context().Value(fakeTx{}) == nil {
+					t.Error("handler should run with the transaction's context")
+				}
+				WriteJSON(w, http.StatusCreated, map[string]string{"id": "1"})
+			},
+			wantStatus: http.StatusCreated,
+			wantCommit: true,
+		},
+		{
+			name: "handler error rolls back",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				WriteError(w, httperror.New(http.StatusConflict, "duplicate"))
+			},
+			wantStatus:   http.StatusConflict,
+			wantRollback: true,
+		},
+		{
+			name: "failed commit answers 500",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				WriteJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+			},
+			commitErr:  errors.New("serialization failure"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{commitErr: tt.commitErr}
+			w := httptest.NewRecorder()
+			WithTx(tx.begin, tt.handler).ServeHTTP(w, httptest.NewRequest("POST", "/posts", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if tx.committed != tt.wantCommit || tx.rolledBack != tt.wantRollback {
+				t.Errorf("committed = %v, rolled back = %v; want %v, %v", tx.committed, tx.rolledBack, tt.wantCommit, tt.wantRollback)
+			}
+		})
+	}
+}
+
+func TestWithTx_PanicRollsBack(t *testing.T) {
+	tx := &fakeTx{}
+	h := WithTx(tx.begin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the panic should propagate to the recovery middleware")
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/posts/1", nil))
+	}()
+	if !tx.rolledBack || tx.committed {
+		t.Errorf("committed = %v, rolled back = %v; want a rollback", tx.committed, tx.rolledBack)
+	}
+}
+
+func TestWithTx_BeginError(t *testing.T) {
+	begin := func(ctx context.Context) (context.Context, func() error, func() error, error) {
+		return nil, nil, nil, errors.New("too many connections")
+	}
+	called := false
+	w := httptest.NewRecorder()
+	WithTx(begin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })).
+		ServeHTTP(w, httptest.NewRequest("POST", "/posts", nil))
+	if called || w.Code != http.StatusInternalServerError {
+		t.Errorf("handler called = %v, status = %d; want the request refused with 500", called, w.Code)
+	}
+}
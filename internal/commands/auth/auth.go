@@ -149,6 +149,7 @@ func AuthCmd() {
 		Dialect:         cfg.Dialect,
 		TestDatabaseURL: cfg.TestDatabaseURL(),
 		ScopeColumn:     cfg.ScopeColumn,
+		BearerAuth:      authgen.BearerAuthFromIni(ini),
 	}
 
 	handlerFiles, err := authgen.GenerateAuthHandlerFiles(authCfg)
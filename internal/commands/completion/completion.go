@@ -0,0 +1,257 @@
+// Package completion implements "shipq completion", which prints shell
+// completion scripts, and the hidden "shipq __complete" command the scripts
+// call to compute candidates.
+package completion
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/cli"
+	migrateloader "github.com/shipq/shipq/codegen/migrate"
+	dbcmd "github.com/shipq/shipq/internal/commands/db"
+	resourcecmd "github.com/shipq/shipq/internal/commands/resource"
+	startcmd "github.com/shipq/shipq/internal/commands/start"
+	"github.com/shipq/shipq/project"
+)
+
+// Shells lists the shells "shipq completion" has scripts for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Commands lists the top-level commands offered for completion.
+var Commands = []string{
+	"status", "nix", "docker", "health", "init", "auth", "signup", "email",
+	"seed", "start", "kill-port", "kill-defaults", "db", "migrate", "files",
+	"workers", "outbox", "quotas", "resource", "handler", "routes", "schema", "smoke",
+	"test", "llm", "completion",
+}
+
+// subcommands maps each command to the words accepted as its first argument.
+var subcommands = map[string][]string{
+	"auth":       {"google", "github"},
+	"db":         {"setup", "set", "compile", "reset", "refresh", "explain", "seed", "import", "console", "promote", "start", "stop"},
+	"seed":       {"new"},
+	"migrate":    {"new", "up", "reset", "resolve", "status"},
+	"handler":    {"generate", "compile"},
+	"workers":    {"compile"},
+	"schema":     {"changelog", "labels", "import", "graph"},
+	"test":       {"concurrency"},
+	"llm":        {"compile"},
+	"start":      startcmd.ValidServices(),
+	"completion": Shells,
+}
+
+// flags maps a command path ("db seed", "resource") to its flags.
+var flags = map[string][]string{
+	"init":             {"--lite", "--sqlite", "--postgres", "--mysql"},
+	"db compile":       {"--only", "--cpuprofile", "--memprofile", "--verbose"},
+	"db refresh":       {"--recreate"},
+	"db explain":       {"--analyze", "--param"},
+	"db start":         {"--docker"},
+	"db seed":          {"--env", "--generate", "--seed", "--exclude-label"},
+	"db import":        {"--dry-run"},
+	"seed":             {"--env"},
+	"seed new":         {"--env"},
+	"migrate resolve":  {"--dry-run"},
+	"migrate status":   {"--json"},
+	"handler compile":  {"--cpuprofile", "--memprofile", "--verbose"},
+	"handler generate": {"--action", "--bulk"},
+	"resource":         {"--public", "--exclude-label", "--jobs"},
+	"routes":           {"--deprecated"},
+	"schema changelog": {"--json"},
+	"schema import":    {"--write"},
+	"schema graph":     {"--migrations", "--format", "--svg"},
+	"schema labels":    {"--json"},
+	"start server":     {"--no-watch"},
+	"start worker":     {"--no-watch"},
+	"start postgres":   {"--docker"},
+	"start mysql":      {"--docker"},
+}
+
+// schemaNames returns the names completed from schema.json.
+type schemaNames func() (tables, labels []string)
+
+// CompletionCmd implements "shipq completion <bash|zsh|fish>".
+func CompletionCmd(args []string) {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+	case "bash":
+		fmt.Print(bashScript)
+	case "zsh":
+		fmt.Print(zshScript)
+	case "fish":
+		fmt.Print(fishScript)
+	default:
+		cli.Fatal(fmt.Sprintf("unknown shell %q (supported: %s)", args[0], strings.Join(Shells, ", ")))
+	}
+}
+
+const usage = `Usage: shipq completion <bash|zsh|fish>
+
+Print a shell completion script. Besides commands and flags, it completes
+table names for 'handler generate', 'resource' and 'db compile --only',
+and @labels for 'resource', from shipq/db/migrate/schema.json.
+
+Install:
+  bash  echo 'source <(shipq completion bash)' >> ~/.bashrc
+  zsh   shipq completion zsh > "${fpath[1]}/_shipq"
+  fish  shipq completion fish > ~/.config/fish/completions/shipq.fish
+`
+
+// CompleteCmd implements the hidden "shipq __complete <words...>" command.
+// words are the command line after "shipq", ending with the word being
+// completed (possibly empty). Candidates are printed one per line; errors
+// print nothing so a broken project never disturbs the shell.
+func CompleteCmd(words []string) {
+	for _, c := range Complete(words, projectSchemaNames) {
+		fmt.Println(c)
+	}
+}
+
+// projectSchemaNames reads the table names and labels of the current
+// project's schema.json. Outside a project, or before the first migration,
+// it returns nothing.
+func projectSchemaNames() (tables, labels []string) {
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		return nil, nil
+	}
+	plan, err := migrateloader.LoadMigrationPlan(roots.ShipqRoot)
+	if err != nil {
+		return nil, nil
+	}
+	seen := make(map[string]bool)
+	for name, t := range plan.Schema.Tables {
+		tables = append(tables, name)
+		for _, l := range t.Labels {
+			if !seen[l] {
+				seen[l] = true
+				labels = append(labels, l)
+			}
+		}
+	}
+	sort.Strings(tables)
+	sort.Strings(labels)
+	return tables, labels
+}
+
+// Complete returns the candidates for the last of words, the command line
+// after "shipq", filtered by the prefix already typed.
+func Complete(words []string, names schemaNames) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	prev, cur := words[:len(words)-1], words[len(words)-1]
+	return withPrefix(candidates(prev, cur, names), cur)
+}
+
+// candidates returns every word that may follow prev.
+func candidates(prev []string, cur string, names schemaNames) []string {
+	if len(prev) == 0 {
+		return Commands
+	}
+	cmd := prev[0]
+	path := cmd
+	if len(prev) > 1 {
+		path = cmd + " " + prev[1]
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		if f, ok := flags[path]; ok {
+			return f
+		}
+		return flags[cmd]
+	}
+
+	switch last := prev[len(prev)-1]; {
+	case last == "--only" && path == "db compile":
+		tables, _ := names()
+		return tables
+	case strings.HasPrefix(last, "-"):
+		// The value of some other flag: nothing to suggest.
+		return nil
+	}
+
+	switch {
+	case len(prev) == 1 && cmd == "resource":
+		tables, labels := names()
+		out := append([]string{}, tables...)
+		for _, l := range labels {
+			out = append(out, "@"+l)
+		}
+		return out
+	case len(prev) == 2 && cmd == "resource":
+		return resourcecmd.ValidOperations
+	case len(prev) == 1:
+		return subcommands[cmd]
+	case len(prev) == 2 && path == "handler generate":
+		tables, _ := names()
+		return tables
+	case len(prev) == 2 && (path == "db start" || path == "db stop"):
+		return []string{"postgres", "mysql"}
+	case len(prev) == 2 && (path == "db set" || path == "db promote"):
+		return dbcmd.ValidDialects
+	}
+	return nil
+}
+
+// withPrefix returns the words of list that start with prefix.
+func withPrefix(list []string, prefix string) []string {
+	var out []string
+	for _, w := range list {
+		if strings.HasPrefix(w, prefix) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+const bashScript = `# bash completion for shipq
+# Install: echo 'source <(shipq completion bash)' >> ~/.bashrc
+
+_shipq() {
+    local IFS=$'\n'
+    COMPREPLY=($(shipq __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+
+complete -o default -F _shipq shipq
+`
+
+const zshScript = `#compdef shipq
+# zsh completion for shipq
+# Install: shipq completion zsh > "${fpath[1]}/_shipq"
+
+_shipq() {
+    local -a candidates
+    candidates=(${(f)"$(shipq __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    if (( ${#candidates} )); then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+
+if [ "$funcstack[1]" = "_shipq" ]; then
+    _shipq "$@"
+else
+    compdef _shipq shipq
+fi
+`
+
+const fishScript = `# fish completion for shipq
+# Install: shipq completion fish > ~/.config/fish/completions/shipq.fish
+
+function __shipq_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    shipq __complete $tokens[2..-1] 2>/dev/null
+end
+
+complete -c shipq -f -a '(__shipq_complete)'
+`
@@ -0,0 +1,34 @@
+package completion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	names := func() ([]string, []string) {
+		return []string{"accounts", "posts", "users"}, []string{"billing"}
+	}
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"mi"}, []string{"migrate"}},
+		{[]string{"db", "p"}, []string{"promote"}},
+		{[]string{"db", "promote", ""}, []string{"sqlite", "postgres", "mysql"}},
+		{[]string{"handler", "generate", "u"}, []string{"users"}},
+		{[]string{"handler", "generate", "users", ""}, nil},
+		{[]string{"resource", ""}, []string{"accounts", "posts", "users", "@billing"}},
+		{[]string{"resource", "@"}, []string{"@billing"}},
+		{[]string{"resource", "posts", "get"}, []string{"get_one"}},
+		{[]string{"resource", "posts", "all", "--j"}, []string{"--jobs"}},
+		{[]string{"db", "compile", "--only", "p"}, []string{"posts"}},
+		{[]string{"db", "seed", "--generate", ""}, nil},
+		{[]string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+	}
+	for _, tt := range tests {
+		if got := Complete(tt.words, names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
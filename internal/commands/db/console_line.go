@@ -0,0 +1,207 @@
+package db
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// consoleHistoryFile is where "shipq db console" keeps its input history,
+// relative to the shipq root. .shipq/ is gitignored.
+var consoleHistoryFile = filepath.Join(".shipq", "console_history")
+
+// consoleHistoryMax is how many lines of history are kept.
+const consoleHistoryMax = 500
+
+// errInterrupted is returned by a lineReader when the user presses Ctrl-C;
+// the console drops the statement being typed.
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads console input a line at a time.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plainLineReader reads lines without editing, for piped input and
+// terminals the console cannot put in raw mode.
+type plainLineReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r *plainLineReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// consoleHistory is the console's input history, oldest first, backed by
+// a file that each entry is appended to.
+type consoleHistory struct {
+	path  string
+	lines []string
+}
+
+// loadConsoleHistory reads the history file at path. A missing file is an
+// empty history.
+func loadConsoleHistory(path string) (*consoleHistory, error) {
+	h := &consoleHistory{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if len(h.lines) > consoleHistoryMax {
+		h.lines = h.lines[len(h.lines)-consoleHistoryMax:]
+	}
+	return h, nil
+}
+
+// Add records line, skipping blanks and immediate repeats, and appends it
+// to the history file.
+func (h *consoleHistory) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return nil
+	}
+	h.lines = append(h.lines, line)
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, line)
+	return err
+}
+
+// editLine reads one line from in with readline-style editing, echoing to
+// out. in must already be in raw mode. Supported keys: arrows (left/right
+// move, up/down walk history), Home/End and Ctrl-A/Ctrl-E, Backspace,
+// Ctrl-U (delete to start), Ctrl-C (errInterrupted) and Ctrl-D on an
+// empty line (io.EOF).
+func editLine(in *bufio.Reader, out io.Writer, prompt string, history []string) (string, error) {
+	var (
+		buf  []rune
+		pos  int
+		hist = len(history) // index into history; len(history) is the new line
+		// draft keeps the unfinished new line while browsing history.
+		draft []rune
+	)
+	redraw := func() {
+		fmt.Fprintf(out, "\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(out, "\x1b[%dD", back)
+		}
+	}
+	recall := func(i int) {
+		if i < 0 || i > len(history) || i == hist {
+			return
+		}
+		if hist == len(history) {
+			draft = buf
+		}
+		hist = i
+		if i == len(history) {
+			buf = draft
+		} else {
+			buf = []rune(history[i])
+		}
+		pos = len(buf)
+		redraw()
+	}
+
+	fmt.Fprint(out, prompt)
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(out, "\r\n")
+			return string(buf), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				fmt.Fprint(out, "\r\n")
+				return "", io.EOF
+			}
+		case 1: // Ctrl-A
+			pos = 0
+			redraw()
+		case 5: // Ctrl-E
+			pos = len(buf)
+			redraw()
+		case 21: // Ctrl-U
+			buf = append([]rune(nil), buf[pos:]...)
+			pos = 0
+			redraw()
+		case 8, 127: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1:pos-1], buf[pos:]...)
+				pos--
+				redraw()
+			}
+		case 27: // escape sequence: ESC [ <final> or ESC O <final>
+			if b, _ := in.ReadByte(); b != '[' && b != 'O' {
+				continue
+			}
+			final, _ := in.ReadByte()
+			for final >= '0' && final <= '9' || final == ';' {
+				final, _ = in.ReadByte()
+			}
+			switch final {
+			case 'A':
+				recall(hist - 1)
+			case 'B':
+				recall(hist + 1)
+			case 'C':
+				if pos < len(buf) {
+					pos++
+					redraw()
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+					redraw()
+				}
+			case 'H':
+				pos = 0
+				redraw()
+			case 'F':
+				pos = len(buf)
+				redraw()
+			}
+		default:
+			if !unicode.IsPrint(r) && r != '\t' {
+				continue
+			}
+			buf = append(buf[:pos:pos], append([]rune{r}, buf[pos:]...)...)
+			pos++
+			redraw()
+		}
+	}
+}
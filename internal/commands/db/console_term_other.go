@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package db
+
+import (
+	"io"
+	"os"
+)
+
+// newTermLineReader reports ok=false: line editing needs a Unix terminal,
+// so the console reads plain lines here.
+func newTermLineReader(in *os.File, out io.Writer, history *consoleHistory) (lineReader, bool) {
+	return nil, false
+}
@@ -0,0 +1,51 @@
+//go:build linux || darwin
+
+package db
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// termLineReader reads lines from a terminal with editLine, switching the
+// terminal to raw mode only while a line is being read so query output is
+// written in the normal mode.
+type termLineReader struct {
+	fd      int
+	in      *bufio.Reader
+	out     io.Writer
+	history *consoleHistory
+}
+
+// newTermLineReader returns a line-editing reader for in when it is a
+// terminal, or ok=false otherwise.
+func newTermLineReader(in *os.File, out io.Writer, history *consoleHistory) (lineReader, bool) {
+	fd := int(in.Fd())
+	if _, err := unix.IoctlGetTermios(fd, ioctlGetTermios); err != nil {
+		return nil, false
+	}
+	return &termLineReader{fd: fd, in: bufio.NewReader(in), out: out, history: history}, true
+}
+
+func (r *termLineReader) ReadLine(prompt string) (string, error) {
+	saved, err := unix.IoctlGetTermios(r.fd, ioctlGetTermios)
+	if err != nil {
+		return "", err
+	}
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(r.fd, ioctlSetTermios, &raw); err != nil {
+		return "", err
+	}
+	defer unix.IoctlSetTermios(r.fd, ioctlSetTermios, saved)
+
+	return editLine(r.in, r.out, prompt, r.history.lines)
+}
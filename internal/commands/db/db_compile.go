@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,19 +13,77 @@ import (
 	"github.com/shipq/shipq/codegen/dbpkg"
 	"github.com/shipq/shipq/codegen/discovery"
 	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/codegen/querycompile"
 	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
 	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
 	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/db/portsql/query/compile"
 	"github.com/shipq/shipq/inifile"
 	shipqdag "github.com/shipq/shipq/internal/dag"
+	"github.com/shipq/shipq/internal/profiling"
 	"github.com/shipq/shipq/project"
 )
 
 // DBCompileCmd implements the "shipq db compile" command.
 // It generates type-safe query runner code from user-defined queries.
 func DBCompileCmd() {
+	compileQueries("")
+}
+
+// DBCompileArgsCmd implements "shipq db compile [--only <table|query>]".
+// With --only, just the querydefs package of the named table or query is
+// recompiled; the queries of every other package come from the previous
+// compile, so the shared types stay complete. The profiling flags
+// (--cpuprofile, --memprofile, --verbose) are accepted too.
+func DBCompileArgsCmd(args []string) {
+	opts, args, err := profiling.ParseFlags(args)
+	if err != nil {
+		cli.FatalErr("invalid arguments for 'shipq db compile'", err)
+	}
+	only, err := ParseCompileArgs(args)
+	if err != nil {
+		cli.FatalErr("invalid arguments for 'shipq db compile'", err)
+	}
+	stopProfiling, err := profiling.Start(opts, os.Stdout)
+	if err != nil {
+		cli.FatalErr("failed to start profiling", err)
+	}
+	compileQueries(only)
+	if err := stopProfiling(); err != nil {
+		cli.FatalErr("failed to finish profiling", err)
+	}
+}
+
+// ParseCompileArgs parses the flags of "shipq db compile" and returns the
+// value of --only ("" when absent).
+func ParseCompileArgs(args []string) (string, error) {
+	only := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--only":
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+				return "", fmt.Errorf("--only requires a table or query name")
+			}
+			i++
+			only = args[i]
+		case strings.HasPrefix(arg, "--only="):
+			only = strings.TrimPrefix(arg, "--only=")
+			if only == "" {
+				return "", fmt.Errorf("--only requires a table or query name")
+			}
+		default:
+			return "", fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	return only, nil
+}
+
+// compileQueries runs the query compilation. When only names a table or a
+// query, just its querydefs package is recompiled.
+func compileQueries(only string) {
 	// Find project roots (supports monorepo setup)
 	roots, err := project.FindProjectRoots()
 	if err != nil {
@@ -59,6 +118,38 @@ func DBCompileCmd() {
 		plan = nil
 	}
 
+	// 2.1. Resolve --only to the querydefs package to recompile. A partial
+	// compile needs the queries cached by a previous compile; without them
+	// everything is compiled.
+	var cachedQueries []query.SerializedQuery
+	onlyTable, onlyPkg := "", ""
+	if only != "" {
+		cached, ok, err := querycompile.ReadQueryCache(roots.ShipqRoot)
+		if err != nil {
+			cli.FatalErr("failed to load the previous compile", err)
+		}
+		isTable := false
+		if plan != nil {
+			_, isTable = plan.Schema.Tables[only]
+		}
+		if isTable {
+			onlyTable = only
+		} else if !ok {
+			cli.Fatal(fmt.Sprintf("%q is not a table, and no previous compile is available to look up queries\n  Run 'shipq db compile' first", only))
+		} else if pkg, found := querycompile.FindQueryPackage(cached, only); found {
+			onlyPkg = pkg
+		} else {
+			cli.Fatal(fmt.Sprintf("no table or query named %q", only))
+		}
+		if ok {
+			cachedQueries = cached
+		} else {
+			cli.Warn("No previous compile to reuse; compiling all queries.")
+			only, onlyTable = "", ""
+		}
+	}
+	selected := func(tableName string) bool { return only == "" || tableName == onlyTable }
+
 	// 2.5. Apply scope filtering based on actual table schemas
 	tableOpts := cfg.GetTableOpts()
 	if plan != nil && cfg.CRUDConfig != nil {
@@ -80,11 +171,21 @@ func DBCompileCmd() {
 	// 2.6. Generate CRUD querydefs for ALL schema tables before discovery.
 	// This ensures every table has query builder DSL definitions that get
 	// compiled through the same pipeline as user-defined queries.
+	stopCodegen := phase.Start(phase.Codegen)
 	if plan != nil {
 		for tableName, table := range plan.Schema.Tables {
-			scopeColumn := ""
+			if !selected(tableName) {
+				continue
+			}
+			scopeColumn, nearColumn := "", ""
+			var maxRowsPerScope int
+			var stateColumns, sorts []string
+			var filters, noSoftDelete bool
 			if opts, ok := tableOpts[tableName]; ok {
-				scopeColumn = opts.ScopeColumn
+				scopeColumn, nearColumn = opts.ScopeColumn, opts.NearColumn
+				maxRowsPerScope = opts.MaxRowsPerScope
+				stateColumns, sorts = opts.StateColumns, opts.Sorts
+				filters, noSoftDelete = opts.Filters, opts.NoSoftDelete
 			}
 			querydefsDir := filepath.Join(roots.ShipqRoot, "querydefs", tableName)
 			qPath := filepath.Join(querydefsDir, "queries.go")
@@ -106,6 +207,13 @@ func DBCompileCmd() {
 				ScopeColumn: scopeColumn,
 				Schema:      plan.Schema.Tables,
 				ExposeEmail: exposeEmail,
+				NearColumn:  nearColumn,
+
+				MaxRowsPerScope: maxRowsPerScope,
+				StateColumns:    stateColumns,
+				Filters:         filters,
+				Sorts:           sorts,
+				NoSoftDelete:    noSoftDelete,
 			}
 			code, err := crudquerydefs.GenerateCRUDQueryDefs(qdCfg)
 			if err != nil {
@@ -117,10 +225,47 @@ func DBCompileCmd() {
 		}
 	}
 
+	// 2.65. Generate the purge query for each table with a RetainFor policy,
+	// and drop it again from tables whose policy was removed.
+	if plan != nil {
+		for tableName, table := range plan.Schema.Tables {
+			if !selected(tableName) {
+				continue
+			}
+			querydefsDir := filepath.Join(roots.ShipqRoot, "querydefs", tableName)
+			rPath := filepath.Join(querydefsDir, crudquerydefs.RetentionFileName)
+			if table.RetentionSeconds <= 0 {
+				if _, err := codegen.RemoveGeneratedFile(rPath); err != nil {
+					cli.Warn("Failed to remove stale " + rPath + ": " + err.Error())
+				}
+				continue
+			}
+			if err := codegen.EnsureDir(querydefsDir); err != nil {
+				cli.FatalErr("failed to create querydefs directory", err)
+			}
+			code, err := crudquerydefs.GenerateRetentionQueryDef(crudquerydefs.Config{
+				ModulePath: cfg.ModulePath,
+				TableName:  tableName,
+				Table:      table,
+			})
+			if err != nil {
+				cli.FatalErr("failed to generate retention querydefs for "+tableName, err)
+			}
+			if _, err := codegen.WriteGeneratedFile(rPath, code); err != nil {
+				cli.FatalErr("failed to write retention querydefs for "+tableName, err)
+			}
+		}
+	}
+
+	stopCodegen()
+
 	// 2.7. Warn about tables lacking cursor pagination support
 	if plan != nil {
 		cursorWarnings := portsqlcodegen.CheckAllTablesCursorSupport(plan)
 		for _, w := range cursorWarnings {
+			if !selected(w.TableName) {
+				continue
+			}
 			cli.Warnf("Table %q lacks %s - using offset pagination (cursor pagination requires both)",
 				w.TableName, joinMissing(w.MissingColumns))
 		}
@@ -133,12 +278,22 @@ func DBCompileCmd() {
 	if err != nil {
 		cli.FatalErr("failed to read module path", err)
 	}
+	stopDiscovery := phase.Start(phase.Discovery)
 	pkgs, err := discovery.DiscoverQuerydefsPackages(roots.GoModRoot, roots.ShipqRoot, rawModulePath)
 	if err != nil {
 		cli.FatalErr("failed to discover querydefs packages", err)
 	}
 
-	if len(pkgs) == 0 {
+	if onlyTable != "" {
+		onlyPkg = tableQuerydefsPackage(pkgs, onlyTable)
+		if onlyPkg == "" {
+			cli.Fatal(fmt.Sprintf("no querydefs package found for table %q", onlyTable))
+		}
+	}
+	if onlyPkg != "" {
+		pkgs = []string{onlyPkg}
+		cli.Infof("Recompiling %s", onlyPkg)
+	} else if len(pkgs) == 0 {
 		cli.Warn("No querydefs packages found. Only CRUD operations will be generated.")
 	} else {
 		cli.Infof("Found %d querydefs package(s)", len(pkgs))
@@ -164,6 +319,23 @@ func DBCompileCmd() {
 		userQueries = queries
 		cli.Infof("Found %d query(ies)", len(userQueries))
 	}
+	stopDiscovery()
+	if onlyPkg != "" {
+		merged, err := querycompile.MergeQueries(cachedQueries, userQueries, onlyPkg)
+		if err != nil {
+			cli.FatalErr("failed to merge with the previous compile", err)
+		}
+		userQueries = merged
+	}
+
+	// 5.1. Schema-qualified tables must be in a namespace schema.json declares
+	if plan != nil {
+		for _, q := range userQueries {
+			if err := compile.ValidateNamespaces(query.DeserializeAST(q.AST), plan.Schema.Namespaces); err != nil {
+				cli.FatalErr("invalid query "+q.Name, err)
+			}
+		}
+	}
 
 	// 6. Create output directories (in shipq root)
 	queriesDir := filepath.Join(roots.ShipqRoot, "shipq", "queries")
@@ -178,11 +350,19 @@ func DBCompileCmd() {
 
 	// 7. Generate and write types.go
 	runnerCfg := queryrunner.UnifiedRunnerConfig{
-		ModulePath:  cfg.ModulePath,
-		Dialect:     cfg.Dialect,
-		UserQueries: userQueries,
+		ModulePath:        cfg.ModulePath,
+		Dialect:           cfg.Dialect,
+		UserQueries:       userQueries,
+		MaxRows:           cfg.MaxRows,
+		PrepareStatements: cfg.PrepareStatements,
+		Telemetry:         cfg.Telemetry,
+		QueryTimeout:      cfg.QueryTimeout,
+	}
+	if plan != nil {
+		runnerCfg.Schema = plan.Schema.Tables
 	}
 
+	stopSQLGen := phase.Start(phase.SQLGen)
 	typesCode, err := queryrunner.GenerateSharedTypes(runnerCfg)
 	if err != nil {
 		cli.FatalErr("failed to generate types.go", err)
@@ -203,6 +383,8 @@ func DBCompileCmd() {
 		cli.FatalErr("failed to generate runner.go", err)
 	}
 
+	stopSQLGen()
+
 	runnerPath := filepath.Join(dialectDir, "runner.go")
 	written, err = codegen.WriteFileIfChanged(runnerPath, runnerCode)
 	if err != nil {
@@ -212,7 +394,73 @@ func DBCompileCmd() {
 		cli.Infof("  Generated shipq/queries/%s/runner.go", cfg.Dialect)
 	}
 
-	// 9. Clean up compile artifacts
+	// 8b. Generate the in-memory fake runner used by handler unit tests
+	fakeCode, err := queryrunner.GenerateFakeRunner(runnerCfg)
+	if err != nil {
+		cli.FatalErr("failed to generate fake runner.go", err)
+	}
+	fakePath := filepath.Join(roots.ShipqRoot, queryrunner.FakeRunnerPath)
+	if err := codegen.EnsureDir(filepath.Dir(fakePath)); err != nil {
+		cli.FatalErr("failed to create fake directory", err)
+	}
+	written, err = codegen.WriteFileIfChanged(fakePath, fakeCode)
+	if err != nil {
+		cli.FatalErr("failed to write fake runner.go", err)
+	}
+	if written {
+		cli.Info("  Generated " + queryrunner.FakeRunnerPath)
+	}
+
+	// 9. Write the materialized view manifest read by `shipq db refresh`
+	// and the worker scheduler
+	viewManifest, err := queryrunner.GenerateViewManifest(runnerCfg)
+	if err != nil {
+		cli.FatalErr("failed to generate views.json", err)
+	}
+	viewsPath := filepath.Join(roots.ShipqRoot, queryrunner.ViewManifestPath)
+	if viewManifest == nil {
+		if err := os.Remove(viewsPath); err != nil && !os.IsNotExist(err) {
+			cli.Warn("Failed to remove stale views.json: " + err.Error())
+		}
+	} else {
+		written, err = codegen.WriteFileIfChanged(viewsPath, viewManifest)
+		if err != nil {
+			cli.FatalErr("failed to write views.json", err)
+		}
+		if written {
+			cli.Info("  Generated shipq/queries/views.json")
+		}
+	}
+
+	// 9.5. Write the retention manifest, the one place listing every table's
+	// RetainFor policy, which the worker scheduler also reads
+	if plan != nil {
+		retentionManifest, err := queryrunner.GenerateRetentionManifest(plan.Schema.Tables)
+		if err != nil {
+			cli.FatalErr("failed to generate retention.json", err)
+		}
+		retentionPath := filepath.Join(roots.ShipqRoot, queryrunner.RetentionManifestPath)
+		if retentionManifest == nil {
+			if err := os.Remove(retentionPath); err != nil && !os.IsNotExist(err) {
+				cli.Warn("Failed to remove stale retention.json: " + err.Error())
+			}
+		} else {
+			written, err = codegen.WriteFileIfChanged(retentionPath, retentionManifest)
+			if err != nil {
+				cli.FatalErr("failed to write retention.json", err)
+			}
+			if written {
+				cli.Info("  Generated shipq/queries/retention.json")
+			}
+		}
+	}
+
+	// 9.6. Cache the compiled queries for later `--only` compiles
+	if err := querycompile.WriteQueryCache(roots.ShipqRoot, userQueries); err != nil {
+		cli.Warn("Failed to cache compiled queries: " + err.Error())
+	}
+
+	// 10. Clean up compile artifacts
 	if err := querycompile.CleanCompileArtifacts(roots.ShipqRoot); err != nil {
 		cli.Warn("Failed to clean compile artifacts: " + err.Error())
 	}
@@ -229,6 +477,17 @@ func DBCompileCmd() {
 	cli.Infof("  CRUD tables: %d", tableCount)
 }
 
+// tableQuerydefsPackage returns the import path of the querydefs package of
+// tableName among the discovered packages ("" if there is none).
+func tableQuerydefsPackage(pkgs []string, tableName string) string {
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg, "/querydefs/"+tableName) {
+			return pkg
+		}
+	}
+	return ""
+}
+
 // joinMissing joins missing column names with " and ".
 func joinMissing(columns []string) string {
 	if len(columns) == 0 {
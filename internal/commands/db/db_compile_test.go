@@ -0,0 +1,44 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCompileArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{nil, "", ""},
+		{[]string{"--only", "posts"}, "posts", ""},
+		{[]string{"--only=GetPostBySlug"}, "GetPostBySlug", ""},
+		{[]string{"--only"}, "", "requires a table or query name"},
+		{[]string{"--only", "--verbose"}, "", "requires a table or query name"},
+		{[]string{"--only="}, "", "requires a table or query name"},
+		{[]string{"posts"}, "", "unexpected argument"},
+	}
+	for _, tt := range tests {
+		got, err := ParseCompileArgs(tt.args)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCompileArgs(%v) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCompileArgs(%v) = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestTableQuerydefsPackage(t *testing.T) {
+	pkgs := []string{"myapp/querydefs", "myapp/querydefs/posts", "myapp/querydefs/post_tags"}
+	if got := tableQuerydefsPackage(pkgs, "posts"); got != "myapp/querydefs/posts" {
+		t.Errorf("tableQuerydefsPackage(posts) = %q", got)
+	}
+	if got := tableQuerydefsPackage(pkgs, "tags"); got != "" {
+		t.Errorf("tableQuerydefsPackage(tags) = %q, want none", got)
+	}
+}
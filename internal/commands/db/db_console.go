@@ -0,0 +1,453 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen/querycompile"
+	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/project"
+)
+
+// DBConsoleUsage prints the usage of "shipq db console".
+func DBConsoleUsage() {
+	fmt.Print(`Usage: shipq db console
+
+Open an interactive SQL console on the dev database (db.database_url).
+
+SQL statements end with ';' and may span several lines. They run as typed,
+outside any transaction, so writes are kept.
+
+Commands:
+` + consoleCommands)
+}
+
+const consoleCommands = `  \d [table]       List tables, or describe a table's columns and indexes
+  \dt              List tables
+  \queries [text]  List the compiled querydefs, optionally those containing text
+  \run <query>     Run a querydef, prompting for each parameter
+  \history         Show the input history
+  \?               Show this help
+  \q               Quit
+`
+
+// DBConsoleCmd implements "shipq db console".
+func DBConsoleCmd(args []string) {
+	if len(args) > 0 {
+		cli.Fatal(fmt.Sprintf("unexpected argument for 'shipq db console': %s", args[0]))
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+	ini, err := inifile.ParseFile(filepath.Join(roots.ShipqRoot, project.ShipqIniFile))
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+	databaseURL := ini.Get("db", "database_url")
+	if databaseURL == "" {
+		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+	if dburl.IsSQLiteMemory(databaseURL) {
+		cli.Fatal("the dev database is in-memory, so there is nothing to connect to")
+	}
+	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
+	if err != nil {
+		cli.FatalErr("failed to determine database dialect", err)
+	}
+
+	// The console works without compiled queries; \run then says so.
+	queries, _, err := querycompile.ReadQueryCache(roots.ShipqRoot)
+	if err != nil {
+		cli.Warnf("failed to load compiled queries: %v", err)
+	}
+
+	history, err := loadConsoleHistory(filepath.Join(roots.ShipqRoot, consoleHistoryFile))
+	if err != nil {
+		cli.Warnf("failed to load console history: %v", err)
+		history = &consoleHistory{}
+	}
+
+	db, err := openDatabase(databaseURL, dialect)
+	if err != nil {
+		cli.FatalErr("failed to connect to database", err)
+	}
+	defer db.Close()
+
+	lines, ok := newTermLineReader(os.Stdin, os.Stdout, history)
+	if !ok {
+		lines = &plainLineReader{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+	}
+
+	fmt.Printf("Connected to the %s dev database. \\? for help, \\q to quit.\n", dialect)
+	console := &Console{DB: db, Dialect: dialect, Queries: queries, Out: os.Stdout, lines: lines, history: history}
+	if err := console.Run(context.Background()); err != nil {
+		cli.FatalErr("console", err)
+	}
+}
+
+// Console is the "shipq db console" REPL.
+type Console struct {
+	DB      *sql.DB
+	Dialect string
+	// Queries are the compiled querydefs \run can execute.
+	Queries []query.SerializedQuery
+	Out     io.Writer
+
+	lines   lineReader
+	history *consoleHistory
+}
+
+// Run reads and executes input until \q or end of input. Statement and
+// command errors are printed and the console carries on; only input errors
+// end it.
+func (c *Console) Run(ctx context.Context) error {
+	var stmt strings.Builder
+	for {
+		prompt := "shipq> "
+		if stmt.Len() > 0 {
+			prompt = "   ... "
+		}
+		line, err := c.lines.ReadLine(prompt)
+		if errors.Is(err, errInterrupted) {
+			stmt.Reset()
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if stmt.Len() == 0 {
+			if trimmed == "" {
+				continue
+			}
+			if strings.HasPrefix(trimmed, `\`) {
+				c.addHistory(trimmed)
+				if quit := c.command(ctx, trimmed); quit {
+					return nil
+				}
+				continue
+			}
+		} else {
+			stmt.WriteByte('\n')
+		}
+		stmt.WriteString(line)
+		if !strings.HasSuffix(trimmed, ";") {
+			continue
+		}
+
+		text := strings.TrimSuffix(strings.TrimSpace(stmt.String()), ";")
+		stmt.Reset()
+		c.addHistory(strings.ReplaceAll(text, "\n", " ") + ";")
+		if err := c.execute(ctx, text, nil, returnsRows(text)); err != nil {
+			fmt.Fprintf(c.Out, "error: %v\n", err)
+		}
+	}
+}
+
+func (c *Console) addHistory(line string) {
+	if c.history == nil {
+		return
+	}
+	if err := c.history.Add(line); err != nil {
+		fmt.Fprintf(c.Out, "warning: failed to save history: %v\n", err)
+	}
+}
+
+// command runs a backslash command and reports whether it was \q.
+func (c *Console) command(ctx context.Context, line string) (quit bool) {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	var err error
+	switch name {
+	case `\q`, `\quit`:
+		return true
+	case `\?`, `\help`:
+		fmt.Fprint(c.Out, consoleCommands)
+	case `\dt`:
+		err = c.listTables(ctx)
+	case `\d`:
+		if arg == "" {
+			err = c.listTables(ctx)
+		} else {
+			err = c.describe(ctx, arg)
+		}
+	case `\queries`:
+		c.listQueries(arg)
+	case `\run`:
+		err = c.runQuery(ctx, arg)
+	case `\history`:
+		if c.history != nil {
+			for i, h := range c.history.lines {
+				fmt.Fprintf(c.Out, "%5d  %s\n", i+1, h)
+			}
+		}
+	default:
+		fmt.Fprintf(c.Out, "unknown command %s; \\? lists the commands\n", name)
+	}
+	if err != nil && !errors.Is(err, errInterrupted) {
+		fmt.Fprintf(c.Out, "error: %v\n", err)
+	}
+	return false
+}
+
+func (c *Console) listTables(ctx context.Context) error {
+	tables, err := ListTables(ctx, c.DB, c.Dialect)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		fmt.Fprintln(c.Out, "no tables")
+		return nil
+	}
+	for _, t := range tables {
+		fmt.Fprintln(c.Out, t)
+	}
+	return nil
+}
+
+func (c *Console) describe(ctx context.Context, table string) error {
+	info, err := DescribeTable(ctx, c.DB, c.Dialect, table)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "Table %s\n", info.Name)
+	tw := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Column\tType\tNull\tDefault\tKey")
+	for _, col := range info.Columns {
+		null, key := "NOT NULL", ""
+		if col.Nullable {
+			null = "NULL"
+		}
+		if col.PrimaryKey {
+			key = "PK"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", col.Name, col.Type, null, col.Default, key)
+	}
+	tw.Flush()
+
+	if len(info.Indexes) > 0 {
+		fmt.Fprintln(c.Out, "Indexes:")
+		for _, idx := range info.Indexes {
+			unique := ""
+			if idx.Unique {
+				unique = " UNIQUE"
+			}
+			fmt.Fprintf(c.Out, "  %s%s (%s)\n", idx.Name, unique, strings.Join(idx.Columns, ", "))
+		}
+	}
+	return nil
+}
+
+func (c *Console) listQueries(filter string) {
+	if len(c.Queries) == 0 {
+		fmt.Fprintln(c.Out, "no compiled queries; run 'shipq db compile' first")
+		return
+	}
+	tw := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+	for _, q := range c.Queries {
+		if filter == "" || strings.Contains(strings.ToLower(q.Name), strings.ToLower(filter)) {
+			fmt.Fprintf(tw, "%s\t%s\n", q.Name, q.ReturnType)
+		}
+	}
+	tw.Flush()
+}
+
+// runQuery runs the querydef name, prompting for its parameters.
+func (c *Console) runQuery(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf(`\run needs a query name; \queries lists them`)
+	}
+	var sq *query.SerializedQuery
+	for i := range c.Queries {
+		if c.Queries[i].Name == name {
+			sq = &c.Queries[i]
+			break
+		}
+	}
+	if sq == nil {
+		if len(c.Queries) == 0 {
+			return fmt.Errorf("no compiled queries; run 'shipq db compile' first")
+		}
+		return fmt.Errorf("unknown query %q; \\queries lists them", name)
+	}
+	compiled, err := queryrunner.CompileForExplain(*sq, c.Dialect)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]any, len(compiled.ParamTypes))
+	for _, param := range compiled.ParamOrder {
+		if _, done := values[param]; done {
+			continue
+		}
+		v, err := c.promptParam(param, compiled.ParamTypes[param])
+		if err != nil {
+			return err
+		}
+		values[param] = v
+	}
+	args := make([]any, len(compiled.ParamOrder))
+	for i, param := range compiled.ParamOrder {
+		args[i] = values[param]
+	}
+
+	fmt.Fprintf(c.Out, "-- %s\n", compiled.SQL)
+	withRows := sq.ReturnType != query.ReturnExec && sq.ReturnType != query.ReturnApplied
+	return c.execute(ctx, compiled.SQL, args, withRows)
+}
+
+// promptParam reads a value of goType for param until one parses. An empty
+// answer is NULL for optional (pointer) parameters and the explain page
+// size for a paginated query's limit.
+func (c *Console) promptParam(param, goType string) (any, error) {
+	base, optional := strings.CutPrefix(goType, "*")
+	for {
+		raw, err := c.lines.ReadLine(fmt.Sprintf("%s (%s): ", param, goType))
+		if err != nil {
+			return nil, err
+		}
+		raw = strings.TrimSpace(raw)
+		switch {
+		case raw == "" && param == "__limit":
+			return explainLimit, nil
+		case raw == "" && optional:
+			return nil, nil
+		case raw == "" && base != "string":
+			fmt.Fprintln(c.Out, "a value is required")
+			continue
+		}
+		v, err := sampleValue(base, raw, true, time.Now())
+		if err != nil {
+			fmt.Fprintf(c.Out, "invalid %s: %v\n", goType, err)
+			continue
+		}
+		return v, nil
+	}
+}
+
+// execute runs a statement and prints its rows, or the number of rows it
+// affected when withRows is false.
+func (c *Console) execute(ctx context.Context, text string, args []any, withRows bool) error {
+	if !withRows {
+		res, err := c.DB.ExecContext(ctx, text, args...)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			fmt.Fprintln(c.Out, "OK")
+			return nil
+		}
+		fmt.Fprintf(c.Out, "OK, %d %s affected\n", n, plural(n, "row"))
+		return nil
+	}
+
+	rows, err := c.DB.QueryContext(ctx, text, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(cols) == 0 {
+		fmt.Fprintln(c.Out, "OK")
+		return rows.Err()
+	}
+
+	tw := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	dashes := make([]string, len(cols))
+	for i, col := range cols {
+		dashes[i] = strings.Repeat("-", len(col))
+	}
+	fmt.Fprintln(tw, strings.Join(dashes, "\t"))
+
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = formatCell(v)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	tw.Flush()
+	fmt.Fprintf(c.Out, "(%d %s)\n", n, plural(n, "row"))
+	return nil
+}
+
+// formatCell formats a scanned value for the result table.
+func formatCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return strings.NewReplacer("\t", " ", "\n", " ").Replace(string(v))
+	case string:
+		return strings.NewReplacer("\t", " ", "\n", " ").Replace(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// returnsRows reports whether a statement typed at the console produces a
+// result set, judging by its first keyword or a RETURNING clause.
+func returnsRows(text string) bool {
+	fields := strings.Fields(strings.ToUpper(text))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "SELECT", "WITH", "SHOW", "PRAGMA", "EXPLAIN", "VALUES", "TABLE", "DESCRIBE", "DESC":
+		return true
+	}
+	for _, f := range fields {
+		if f == "RETURNING" {
+			return true
+		}
+	}
+	return false
+}
+
+func plural(n int64, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
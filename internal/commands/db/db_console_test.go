@@ -0,0 +1,203 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+)
+
+type consolePostsTable struct{}
+
+func (consolePostsTable) TableName() string { return "posts" }
+
+// openConsoleTestDB returns an in-memory SQLite database with a posts table.
+func openConsoleTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range []string{
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT NOT NULL, body TEXT, status TEXT NOT NULL DEFAULT 'draft')`,
+		`CREATE UNIQUE INDEX posts_title_idx ON posts (title)`,
+		`CREATE INDEX posts_status_body_idx ON posts (status, body)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestDescribeTable_SQLite(t *testing.T) {
+	db := openConsoleTestDB(t)
+	ctx := context.Background()
+
+	tables, err := ListTables(ctx, db, dburl.DialectSQLite)
+	if err != nil {
+		t.Fatalf("ListTables() error = %v", err)
+	}
+	if !reflect.DeepEqual(tables, []string{"posts"}) {
+		t.Errorf("ListTables() = %v, want [posts]", tables)
+	}
+
+	info, err := DescribeTable(ctx, db, dburl.DialectSQLite, "posts")
+	if err != nil {
+		t.Fatalf("DescribeTable() error = %v", err)
+	}
+	wantCols := []ColumnInfo{
+		{Name: "id", Type: "INTEGER", Nullable: true, PrimaryKey: true},
+		{Name: "title", Type: "TEXT"},
+		{Name: "body", Type: "TEXT", Nullable: true},
+		{Name: "status", Type: "TEXT", Default: "'draft'"},
+	}
+	if !reflect.DeepEqual(info.Columns, wantCols) {
+		t.Errorf("columns = %+v\nwant %+v", info.Columns, wantCols)
+	}
+	wantIdx := []IndexInfo{
+		{Name: "posts_status_body_idx", Columns: []string{"status", "body"}},
+		{Name: "posts_title_idx", Columns: []string{"title"}, Unique: true},
+	}
+	if !reflect.DeepEqual(info.Indexes, wantIdx) {
+		t.Errorf("indexes = %+v\nwant %+v", info.Indexes, wantIdx)
+	}
+
+	if _, err := DescribeTable(ctx, db, dburl.DialectSQLite, "missing"); err == nil {
+		t.Error("expected an error for a missing table")
+	}
+}
+
+func TestConsole_Run(t *testing.T) {
+	db := openConsoleTestDB(t)
+	title := query.StringColumn{Table: "posts", Name: "title"}
+	status := query.StringColumn{Table: "posts", Name: "status"}
+	getPost := query.SerializedQuery{
+		Name:       "GetPostByTitle",
+		ReturnType: query.ReturnOne,
+		AST: query.SerializeAST(query.From(consolePostsTable{}).
+			Select(title, status).
+			Where(title.Eq(query.Param[string]("title"))).
+			Build()),
+	}
+
+	input := strings.Join([]string{
+		`INSERT INTO posts (title) VALUES ('hello');`,
+		`SELECT title,`,
+		`  status FROM posts;`,
+		`SELECT nope FROM posts;`,
+		`\d posts`,
+		`\queries post`,
+		`\run GetPostByTitle`,
+		`hello`,
+		`\q`,
+		`SELECT 'not reached';`,
+	}, "\n")
+	var out bytes.Buffer
+	historyPath := filepath.Join(t.TempDir(), "history")
+	console := &Console{
+		DB:      db,
+		Dialect: dburl.DialectSQLite,
+		Queries: []query.SerializedQuery{getPost},
+		Out:     &out,
+		lines:   &plainLineReader{in: bufio.NewReader(strings.NewReader(input)), out: &out},
+		history: &consoleHistory{path: historyPath},
+	}
+	if err := console.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got := out.String()
+	// The transcript echoes each prompt; the multi-line SELECT continues at
+	// the "..." prompt and \q stops before the last statement.
+	want := "shipq> OK, 1 row affected\n" +
+		"shipq>    ... title  status\n" +
+		"-----  ------\n" +
+		"hello  draft\n" +
+		"(1 row)\n" +
+		"shipq> error: SQL logic error: no such column: nope (1)\n" +
+		"shipq> Table posts\n" +
+		"Column  Type     Null      Default  Key\n" +
+		"id      INTEGER  NULL               PK\n" +
+		"title   TEXT     NOT NULL           \n" +
+		"body    TEXT     NULL               \n" +
+		"status  TEXT     NOT NULL  'draft'  \n" +
+		"Indexes:\n" +
+		"  posts_status_body_idx (status, body)\n" +
+		"  posts_title_idx UNIQUE (title)\n" +
+		"shipq> GetPostByTitle  one\n" +
+		"shipq> title (string): -- SELECT \"posts\".\"title\", \"posts\".\"status\" FROM \"posts\" WHERE (\"posts\".\"title\" = ?)\n" +
+		"title  status\n" +
+		"-----  ------\n" +
+		"hello  draft\n" +
+		"(1 row)\n" +
+		"shipq> "
+	if got != want {
+		t.Errorf("output =\n%s\nwant:\n%s", got, want)
+	}
+
+	saved, err := os.ReadFile(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), "SELECT title,   status FROM posts;\n") || !strings.Contains(string(saved), "\\run GetPostByTitle\n") {
+		t.Errorf("history file = %q", saved)
+	}
+}
+
+func TestEditLine(t *testing.T) {
+	history := []string{"SELECT 1;", "SELECT 2;"}
+	tests := []struct {
+		name, keys, want string
+		err              error
+	}{
+		{"typing", "abc\r", "abc", nil},
+		{"left arrow inserts mid-line", "ab\x1b[Dc\r", "acb", nil},
+		{"backspace", "abx\x7fc\r", "abc", nil},
+		{"ctrl-a and ctrl-u", "abc\x01x\x05y\x15z\r", "z", nil},
+		{"history up", "\x1b[A\r", "SELECT 2;", nil},
+		{"history up twice then down", "\x1b[A\x1b[A\x1b[B\r", "SELECT 2;", nil},
+		{"history down restores draft", "dra\x1b[A\x1b[Bft\r", "draft", nil},
+		{"ctrl-c", "abc\x03", "", errInterrupted},
+		{"ctrl-d on empty line", "\x04", "", io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := editLine(bufio.NewReader(strings.NewReader(tt.keys)), io.Discard, "> ", history)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("editLine() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("editLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReturnsRows(t *testing.T) {
+	for text, want := range map[string]bool{
+		"select 1":                              true,
+		"WITH x AS (SELECT 1) SELECT * FROM x":  true,
+		"INSERT INTO t VALUES (1)":              false,
+		"insert into t values (1) returning id": true,
+		"UPDATE t SET a = 1":                    false,
+		"PRAGMA table_info(t)":                  true,
+		"  \n":                                  false,
+	} {
+		if got := returnsRows(text); got != want {
+			t.Errorf("returnsRows(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen/querycompile"
+	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/project"
+)
+
+// ExplainArgs are the arguments of "shipq db explain".
+type ExplainArgs struct {
+	Query   string
+	Analyze bool
+	// Params holds the --param name=value overrides of sample values.
+	Params map[string]string
+}
+
+// ParseExplainArgs parses "<query-name> [--analyze] [--param name=value]...".
+func ParseExplainArgs(args []string) (ExplainArgs, error) {
+	parsed := ExplainArgs{Params: make(map[string]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--analyze":
+			parsed.Analyze = true
+		case arg == "--param" || strings.HasPrefix(arg, "--param="):
+			value, ok := strings.CutPrefix(arg, "--param=")
+			if !ok {
+				if i+1 >= len(args) {
+					return ExplainArgs{}, fmt.Errorf("--param requires name=value")
+				}
+				i++
+				value = args[i]
+			}
+			name, v, ok := strings.Cut(value, "=")
+			if !ok || name == "" {
+				return ExplainArgs{}, fmt.Errorf("--param %q must be name=value", value)
+			}
+			parsed.Params[name] = v
+		case strings.HasPrefix(arg, "-"):
+			return ExplainArgs{}, fmt.Errorf("unknown flag: %s", arg)
+		case parsed.Query == "":
+			parsed.Query = arg
+		default:
+			return ExplainArgs{}, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	if parsed.Query == "" {
+		return ExplainArgs{}, fmt.Errorf("a query name is required")
+	}
+	return parsed, nil
+}
+
+// DBExplainUsage prints the usage of "shipq db explain".
+func DBExplainUsage() {
+	fmt.Print(`Usage: shipq db explain <query-name> [--analyze] [--param name=value]...
+
+Compile a query from the last 'shipq db compile' for the project's dialect,
+run EXPLAIN on it against the dev database, and print the plan.
+
+Parameters get sample values (1, "sample", true, the current time, NULL for
+optional ones); --param overrides one, e.g. --param publicId=abc123.
+Paginated queries are explained as their first page, LIMIT 20.
+
+Options:
+  --analyze        Run EXPLAIN ANALYZE, which executes the query (Postgres,
+                   MySQL). It runs in a transaction that is rolled back.
+  --param n=v      Use v for the parameter n
+`)
+}
+
+// DBExplainCmd implements "shipq db explain <query-name> [--analyze] [--param name=value]...".
+func DBExplainCmd(args []string) {
+	parsed, err := ParseExplainArgs(args)
+	if err != nil {
+		cli.FatalErr("invalid arguments for 'shipq db explain'", err)
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	queries, ok, err := querycompile.ReadQueryCache(roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to load compiled queries", err)
+	}
+	if !ok {
+		cli.Fatal("no compiled queries found\n  Run 'shipq db compile' first")
+	}
+	idx := -1
+	for i, q := range queries {
+		if q.Name == parsed.Query {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		cli.Fatal(fmt.Sprintf("unknown query %q\n  Query names are those passed to query.MustDefine*, e.g. GetPostByPublicID", parsed.Query))
+	}
+
+	ini, err := inifile.ParseFile(filepath.Join(roots.ShipqRoot, project.ShipqIniFile))
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+	databaseURL := ini.Get("db", "database_url")
+	if databaseURL == "" {
+		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+	if dburl.IsSQLiteMemory(databaseURL) {
+		cli.Fatal("the dev database is in-memory, so it has no tables to explain against")
+	}
+	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
+	if err != nil {
+		cli.FatalErr("failed to determine database dialect", err)
+	}
+
+	compiled, err := queryrunner.CompileForExplain(queries[idx], dialect)
+	if err != nil {
+		cli.FatalErr("cannot explain "+parsed.Query, err)
+	}
+	values, err := SampleParams(compiled.ParamTypes, parsed.Params, time.Now())
+	if err != nil {
+		cli.FatalErr("cannot explain "+parsed.Query, err)
+	}
+	queryArgs := make([]any, len(compiled.ParamOrder))
+	for i, name := range compiled.ParamOrder {
+		queryArgs[i] = values[name]
+	}
+
+	db, err := openDatabase(databaseURL, dialect)
+	if err != nil {
+		cli.FatalErr("failed to connect to database", err)
+	}
+	defer db.Close()
+
+	plan, err := Explain(context.Background(), db, dialect, compiled.SQL, queryArgs, parsed.Analyze)
+	if err != nil {
+		cli.FatalErr("failed to explain "+parsed.Query, err)
+	}
+
+	fmt.Printf("-- %s (%s)\n%s\n", parsed.Query, dialect, compiled.SQL)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("-- %s = %s\n", name, formatSample(values[name]))
+	}
+	fmt.Println()
+	for _, line := range plan {
+		fmt.Println(line)
+	}
+}
+
+// explainLimit is the LIMIT a paginated query is explained with.
+const explainLimit = 20
+
+// SampleParams returns a value for each parameter of types: the --param
+// override when one is given, otherwise a sample of the parameter's Go
+// type. Optional (pointer) parameters default to NULL.
+func SampleParams(types, overrides map[string]string, now time.Time) (map[string]any, error) {
+	for name := range overrides {
+		if _, ok := types[name]; !ok {
+			return nil, fmt.Errorf("the query has no parameter %q", name)
+		}
+	}
+
+	values := make(map[string]any, len(types))
+	for name, goType := range types {
+		raw, override := overrides[name]
+		if name == "__limit" && !override {
+			values[name] = explainLimit
+			continue
+		}
+		base, optional := strings.CutPrefix(goType, "*")
+		if optional && !override {
+			values[name] = nil
+			continue
+		}
+		v, err := sampleValue(base, raw, override, now)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s (%s): %w", name, goType, err)
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// sampleValue returns raw converted to goType, or a sample of goType when
+// there is no override.
+func sampleValue(goType, raw string, override bool, now time.Time) (any, error) {
+	switch goType {
+	case "string":
+		if override {
+			return raw, nil
+		}
+		return "sample", nil
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		if !override {
+			return int64(1), nil
+		}
+		return strconv.ParseInt(raw, 10, 64)
+	case "float32", "float64":
+		if !override {
+			return float64(1), nil
+		}
+		return strconv.ParseFloat(raw, 64)
+	case "bool":
+		if !override {
+			return true, nil
+		}
+		return strconv.ParseBool(raw)
+	case "time.Time":
+		if !override {
+			return now.UTC(), nil
+		}
+		return time.Parse(time.RFC3339, raw)
+	case "json.RawMessage":
+		if !override {
+			return "{}", nil
+		}
+		return raw, nil
+	case "[]byte":
+		return []byte(raw), nil
+	}
+	if override {
+		return raw, nil
+	}
+	return nil, fmt.Errorf("no sample value for this type; pass --param <name>=<value>")
+}
+
+// formatSample formats a parameter value for the explain header.
+func formatSample(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		return strconv.Quote(string(v))
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Explain runs EXPLAIN (or EXPLAIN ANALYZE) on query and returns the plan,
+// one line per entry. It runs in a transaction that is rolled back, since
+// EXPLAIN ANALYZE executes the statement, writes included.
+func Explain(ctx context.Context, db *sql.DB, dialect, query string, args []any, analyze bool) ([]string, error) {
+	var prefix string
+	switch dialect {
+	case dburl.DialectPostgres:
+		prefix = "EXPLAIN "
+		if analyze {
+			prefix = "EXPLAIN ANALYZE "
+		}
+	case dburl.DialectMySQL:
+		prefix = "EXPLAIN FORMAT=TREE "
+		if analyze {
+			prefix = "EXPLAIN ANALYZE "
+		}
+	case dburl.DialectSQLite:
+		if analyze {
+			return nil, fmt.Errorf("SQLite has no EXPLAIN ANALYZE; drop --analyze")
+		}
+		prefix = "EXPLAIN QUERY PLAN "
+	default:
+		return nil, fmt.Errorf("explain is not supported for %s", dialect)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, prefix+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if dialect == dburl.DialectSQLite {
+		return sqlitePlan(rows)
+	}
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, strings.Split(strings.TrimRight(line, "\n"), "\n")...)
+	}
+	return lines, rows.Err()
+}
+
+// sqlitePlan reads the (id, parent, notused, detail) rows of EXPLAIN QUERY
+// PLAN and indents each detail under its parent, as the sqlite3 shell does.
+func sqlitePlan(rows *sql.Rows) ([]string, error) {
+	depth := map[int]int{0: -1}
+	var lines []string
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			return nil, err
+		}
+		depth[id] = depth[parent] + 1
+		lines = append(lines, strings.Repeat("  ", depth[id])+detail)
+	}
+	return lines, rows.Err()
+}
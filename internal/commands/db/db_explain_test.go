@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/dburl"
+)
+
+func TestParseExplainArgs(t *testing.T) {
+	got, err := ParseExplainArgs([]string{"ListPosts", "--analyze", "--param", "authorId=7", "--param=title=a=b"})
+	if err != nil {
+		t.Fatalf("ParseExplainArgs() error = %v", err)
+	}
+	want := ExplainArgs{Query: "ListPosts", Analyze: true, Params: map[string]string{"authorId": "7", "title": "a=b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseExplainArgs() = %+v, want %+v", got, want)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"--analyze"},
+		{"A", "B"},
+		{"A", "--param"},
+		{"A", "--param", "noequals"},
+		{"A", "--verbose"},
+	} {
+		if _, err := ParseExplainArgs(args); err == nil {
+			t.Errorf("ParseExplainArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestSampleParams(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	types := map[string]string{
+		"publicId": "string",
+		"authorId": "int64",
+		"score":    "float64",
+		"active":   "bool",
+		"since":    "time.Time",
+		"title":    "*string",
+		"meta":     "json.RawMessage",
+		"__limit":  "int",
+	}
+	got, err := SampleParams(types, map[string]string{"authorId": "7", "title": "Hello"}, now)
+	if err != nil {
+		t.Fatalf("SampleParams() error = %v", err)
+	}
+	want := map[string]any{
+		"publicId": "sample",
+		"authorId": int64(7),
+		"score":    float64(1),
+		"active":   true,
+		"since":    now,
+		"title":    "Hello",
+		"meta":     "{}",
+		"__limit":  explainLimit,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SampleParams() = %#v, want %#v", got, want)
+	}
+
+	if got, _ := SampleParams(map[string]string{"title": "*string"}, nil, now); got["title"] != nil {
+		t.Errorf("optional parameter without override = %v, want NULL", got["title"])
+	}
+	if _, err := SampleParams(types, map[string]string{"missing": "1"}, now); err == nil {
+		t.Error("expected an error for an unknown parameter")
+	}
+	if _, err := SampleParams(types, map[string]string{"authorId": "x"}, now); err == nil {
+		t.Error("expected an error for a malformed integer")
+	}
+	if _, err := SampleParams(map[string]string{"loc": "geo.Point"}, nil, now); err == nil || !strings.Contains(err.Error(), "--param") {
+		t.Errorf("expected a hint to pass --param, got %v", err)
+	}
+}
+
+func TestExplain_SQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, author_id INTEGER, title TEXT);
+		CREATE INDEX idx_posts_author_id ON posts (author_id)`); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	plan, err := Explain(ctx, db, dburl.DialectSQLite, `SELECT title FROM posts WHERE author_id = ? ORDER BY id LIMIT ?`, []any{int64(1), 20}, false)
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if len(plan) == 0 || !strings.Contains(strings.Join(plan, "\n"), "idx_posts_author_id") {
+		t.Errorf("plan should use the author index:\n%s", strings.Join(plan, "\n"))
+	}
+
+	if _, err := Explain(ctx, db, dburl.DialectSQLite, `DELETE FROM posts`, nil, true); err == nil {
+		t.Error("expected an error for --analyze on SQLite")
+	}
+}
@@ -0,0 +1,654 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/db/portsql/query"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/dbops"
+	"github.com/shipq/shipq/nanoid"
+	"github.com/shipq/shipq/project"
+)
+
+// importProgressRows is how often, in rows, "shipq db import" reports progress.
+const importProgressRows = 10000
+
+// maxImportErrors is how many invalid rows "shipq db import" lists before
+// it stops reading.
+const maxImportErrors = 50
+
+// ImportArgs are the arguments of "shipq db import".
+type ImportArgs struct {
+	Table  string
+	File   string
+	DryRun bool
+}
+
+// ParseImportArgs parses "<table> <file.csv> [--dry-run]".
+func ParseImportArgs(args []string) (ImportArgs, error) {
+	var parsed ImportArgs
+	for _, arg := range args {
+		switch {
+		case arg == "--dry-run":
+			parsed.DryRun = true
+		case strings.HasPrefix(arg, "-"):
+			return ImportArgs{}, fmt.Errorf("unknown flag: %s", arg)
+		case parsed.Table == "":
+			parsed.Table = arg
+		case parsed.File == "":
+			parsed.File = arg
+		default:
+			return ImportArgs{}, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	if parsed.File == "" {
+		return ImportArgs{}, fmt.Errorf("a table and a CSV file are required")
+	}
+	return parsed, nil
+}
+
+// DBImportUsage prints the usage of "shipq db import".
+func DBImportUsage() {
+	fmt.Print(`Usage: shipq db import <table> <file.csv> [--dry-run]
+
+Load the rows of a CSV file into a table of the dev database. The first line
+names the columns; their order does not matter. Columns are checked against
+shipq/db/migrate/schema.json and every row is validated before anything is
+written, so a file with bad rows loads nothing.
+
+Values use the formats of the generated import endpoints: RFC 3339
+timestamps, true/false booleans, base64 binary, WKT POINT(lng lat) points.
+An empty field is NULL in a nullable column and "" in a text column.
+Reference columns take the referenced row's id. public_id, created_at and
+updated_at are filled in when the file leaves them out or empty.
+
+Postgres loads the file with COPY; MySQL and SQLite with multi-row INSERTs
+in one transaction.
+
+Options:
+  --dry-run    Validate the file and report the row count, writing nothing
+`)
+}
+
+// DBImportCmd implements "shipq db import <table> <file.csv> [--dry-run]".
+func DBImportCmd(args []string) {
+	parsed, err := ParseImportArgs(args)
+	if err != nil {
+		cli.FatalErr("invalid arguments for 'shipq db import'", err)
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+	schemaData, err := os.ReadFile(filepath.Join(roots.ShipqRoot, "shipq", "db", "migrate", "schema.json"))
+	if err != nil {
+		cli.FatalErr("failed to read schema.json (run 'shipq migrate up' first)", err)
+	}
+	plan, err := migrate.PlanFromJSON(schemaData)
+	if err != nil {
+		cli.FatalErr("failed to parse schema.json", err)
+	}
+	table, ok := plan.Schema.Tables[parsed.Table]
+	if !ok {
+		cli.Fatal(fmt.Sprintf("unknown table %q", parsed.Table))
+	}
+
+	ini, err := inifile.ParseFile(filepath.Join(roots.ShipqRoot, project.ShipqIniFile))
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+	databaseURL := ini.Get("db", "database_url")
+	if databaseURL == "" {
+		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+	if dburl.IsSQLiteMemory(databaseURL) {
+		cli.Fatal("the dev database is in-memory, so there is nothing to import into")
+	}
+	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
+	if err != nil {
+		cli.FatalErr("failed to determine database dialect", err)
+	}
+
+	open := func() *os.File {
+		f, err := os.Open(parsed.File)
+		if err != nil {
+			cli.FatalErr("failed to open "+parsed.File, err)
+		}
+		return f
+	}
+
+	f := open()
+	total, rowErrs, err := ValidateCSV(f, table, dialect)
+	f.Close()
+	if err != nil {
+		cli.FatalErr("cannot import "+parsed.File, err)
+	}
+	if len(rowErrs) > 0 {
+		for _, e := range rowErrs {
+			cli.Warnf("  %s", e.Error())
+		}
+		if len(rowErrs) == maxImportErrors {
+			cli.Warnf("  (stopped after %d invalid rows)", maxImportErrors)
+		}
+		cli.Fatal(fmt.Sprintf("%s has invalid rows; nothing was imported", parsed.File))
+	}
+	if parsed.DryRun {
+		cli.Successf("%d row(s) of %s are valid for %s (dry run, nothing written)", total, parsed.File, table.Name)
+		return
+	}
+
+	db, err := openDatabase(databaseURL, dialect)
+	if err != nil {
+		cli.FatalErr("failed to connect to database", err)
+	}
+	defer db.Close()
+
+	f = open()
+	defer f.Close()
+	cli.Infof("Importing %d row(s) into %s", total, table.Name)
+	next := importProgressRows
+	n, err := ImportCSV(context.Background(), db, dialect, table, f, func(done int) {
+		if done >= next && done < total {
+			cli.Infof("  %d/%d rows", done, total)
+			next = done - done%importProgressRows + importProgressRows
+		}
+	})
+	if err != nil {
+		cli.FatalErr("failed to import "+parsed.File, err)
+	}
+	cli.Successf("Imported %d row(s) into %s", n, table.Name)
+}
+
+// ImportRowError is a CSV row that cannot be imported.
+type ImportRowError struct {
+	Line   int
+	Column string // "" when the row as a whole is malformed
+	Err    string
+}
+
+func (e *ImportRowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: %s %s", e.Line, e.Column, e.Err)
+}
+
+// ValidateCSV reads the CSV in r as rows of table and returns the number of
+// data rows and the invalid ones, at most maxImportErrors of them. The error
+// is set when the file can't be imported at all, e.g. its header names an
+// unknown column.
+func ValidateCSV(r io.Reader, table ddl.Table, dialect string) (int, []ImportRowError, error) {
+	cr, err := newCSVRows(r, table, dialect, time.Now())
+	if err != nil {
+		return 0, nil, err
+	}
+	total := 0
+	var rowErrs []ImportRowError
+	for {
+		_, err := cr.next()
+		if errors.Is(err, io.EOF) {
+			return total, rowErrs, nil
+		}
+		total++
+		var rowErr *ImportRowError
+		if !errors.As(err, &rowErr) {
+			if err != nil {
+				return total, rowErrs, err
+			}
+			continue
+		}
+		rowErrs = append(rowErrs, *rowErr)
+		if len(rowErrs) == maxImportErrors {
+			return total, rowErrs, nil
+		}
+	}
+}
+
+// ImportCSV loads the CSV in r into table and returns the number of rows
+// written. Postgres runs a single COPY; MySQL and SQLite run multi-row
+// INSERTs in one transaction. Either way an invalid row or a failed write
+// loads nothing. progress, when set, is called with the rows sent so far.
+func ImportCSV(ctx context.Context, db *sql.DB, dialect string, table ddl.Table, r io.Reader, progress func(int)) (int, error) {
+	cr, err := newCSVRows(r, table, dialect, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if progress == nil {
+		progress = func(int) {}
+	}
+	if dialect == dburl.DialectPostgres {
+		return copyCSVRows(ctx, db, cr, progress)
+	}
+	return insertCSVRows(ctx, db, cr, progress)
+}
+
+// importColumn is a column the import writes.
+type importColumn struct {
+	ddl.ColumnDefinition
+	// field is the column's position in the CSV records, or -1 when the
+	// file leaves it out and the import fills it in.
+	field int
+}
+
+// csvRows reads the data rows of a CSV file as values of the columns of a
+// table.
+type csvRows struct {
+	r       *csv.Reader
+	table   ddl.Table
+	dialect string
+	cols    []importColumn
+	now     time.Time
+}
+
+// newCSVRows reads the header of r and matches it to the columns of table.
+// Every NOT NULL column without a default must be named, apart from
+// public_id, created_at and updated_at, which are filled in.
+func newCSVRows(r io.Reader, table ddl.Table, dialect string, now time.Time) (*csvRows, error) {
+	cr := &csvRows{r: csv.NewReader(r), table: table, dialect: dialect, now: now.UTC()}
+	cr.r.ReuseRecord = true
+	header, err := cr.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("the file is empty; the first line must name the columns")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	fields := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if _, dup := fields[name]; dup {
+			return nil, fmt.Errorf("CSV header names column %q twice", name)
+		}
+		fields[name] = i
+	}
+	for name := range fields {
+		if !slices.ContainsFunc(table.Columns, func(c ddl.ColumnDefinition) bool { return c.Name == name }) {
+			return nil, fmt.Errorf("table %s has no column %q", table.Name, name)
+		}
+	}
+
+	for _, col := range table.Columns {
+		i, named := fields[col.Name]
+		switch {
+		case named && col.PrimaryKey && (col.Type == ddl.BigintType || col.Type == ddl.IntegerType):
+			return nil, fmt.Errorf("column %q is generated by the database; leave it out of the file", col.Name)
+		case named && col.Custom != nil:
+			return nil, fmt.Errorf("column %q has custom type %s, which the import can't convert; load it with a seed file", col.Name, col.Type)
+		case named:
+			cr.cols = append(cr.cols, importColumn{ColumnDefinition: col, field: i})
+		case col.Nullable || col.Default != nil || (col.PrimaryKey && (col.Type == ddl.BigintType || col.Type == ddl.IntegerType)):
+			// Left to the database.
+		case filledColumn(col):
+			cr.cols = append(cr.cols, importColumn{ColumnDefinition: col, field: -1})
+		default:
+			return nil, fmt.Errorf("CSV header is missing column %q, which is NOT NULL", col.Name)
+		}
+	}
+	return cr, nil
+}
+
+// filledColumn reports whether the import fills col in when the file leaves
+// it out or empty: a NOT NULL public_id, created_at or updated_at. Left out,
+// a column with a default gets that instead.
+func filledColumn(col ddl.ColumnDefinition) bool {
+	if col.Nullable {
+		return false
+	}
+	switch col.Name {
+	case "public_id":
+		return true
+	case "created_at", "updated_at":
+		return ddl.IsTimeType(col.Type)
+	}
+	return false
+}
+
+// columnNames returns the names of the columns the import writes.
+func (cr *csvRows) columnNames() []string {
+	names := make([]string, len(cr.cols))
+	for i, col := range cr.cols {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// next returns the values of the next data row, io.EOF after the last one,
+// or an *ImportRowError for a row that can't be imported. Reading may go
+// on after a row error.
+func (cr *csvRows) next() ([]any, error) {
+	record, err := cr.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &ImportRowError{Line: parseErr.StartLine, Err: parseErr.Err.Error()}
+		}
+		return nil, err
+	}
+	line, _ := cr.r.FieldPos(0)
+
+	values := make([]any, len(cr.cols))
+	for i, col := range cr.cols {
+		field, named := "", col.field >= 0 && col.field < len(record)
+		if named {
+			field = record[col.field]
+		}
+		v, err := cr.value(col.ColumnDefinition, field)
+		if err != nil {
+			return nil, &ImportRowError{Line: line, Column: col.Name, Err: err.Error()}
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// value converts the CSV field of col to the value written to the
+// database; nil is NULL.
+func (cr *csvRows) value(col ddl.ColumnDefinition, field string) (any, error) {
+	if field == "" {
+		switch {
+		case col.Name == "public_id" && filledColumn(col):
+			return nanoid.New(), nil
+		case filledColumn(col):
+			return cr.timeValue(cr.now), nil
+		case col.Nullable:
+			return nil, nil
+		case col.References == "" && (col.Type == ddl.StringType || col.Type == ddl.TextType):
+			// Non-nullable text columns accept empty strings, like the
+			// generated import endpoints.
+			return "", nil
+		}
+		return nil, errors.New("is required")
+	}
+
+	if col.References != "" {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be the id of a %s row", col.References)
+		}
+		return id, nil
+	}
+
+	switch col.Type {
+	case ddl.IntegerType:
+		n, err := strconv.ParseInt(field, 10, 32)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return n, nil
+	case ddl.BigintType:
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return n, nil
+	case ddl.FloatType:
+		x, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return x, nil
+	case ddl.DecimalType:
+		if _, err := strconv.ParseFloat(field, 64); err != nil {
+			return nil, errors.New("must be a decimal number")
+		}
+		return field, nil
+	case ddl.BooleanType:
+		b, err := strconv.ParseBool(field)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return b, nil
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
+		t, err := time.Parse(time.RFC3339, field)
+		if err != nil {
+			return nil, errors.New("must be an RFC 3339 timestamp")
+		}
+		return cr.timeValue(t), nil
+	case ddl.BinaryType:
+		b, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return nil, errors.New("must be base64-encoded")
+		}
+		return b, nil
+	case ddl.JSONType:
+		if !json.Valid([]byte(field)) {
+			return nil, errors.New("must be valid JSON")
+		}
+		return field, nil
+	case ddl.EnumType:
+		if !slices.Contains(col.EnumValues, field) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(col.EnumValues, ", "))
+		}
+		return field, nil
+	case ddl.PointType:
+		var lng, lat float64
+		if n, _ := fmt.Sscanf(strings.ToUpper(strings.TrimSpace(field)), "POINT(%g %g)", &lng, &lat); n != 2 || lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+			return nil, errors.New("must be a WKT POINT(lng lat)")
+		}
+		return fmt.Sprintf("POINT(%s %s)", strconv.FormatFloat(lng, 'f', -1, 64), strconv.FormatFloat(lat, 'f', -1, 64)), nil
+	case ddl.PolygonType:
+		polygon, err := query.NormalizePolygonWKT(field)
+		if err != nil {
+			return nil, errors.New("must be a single-ring WKT POLYGON((lng lat, ...))")
+		}
+		return polygon, nil
+	case ddl.StringType:
+		if col.Length != nil && len([]rune(field)) > *col.Length {
+			return nil, fmt.Errorf("is longer than %d characters", *col.Length)
+		}
+		return field, nil
+	}
+	return field, nil
+}
+
+// timeValue returns t in UTC, as text on SQLite, which stores datetimes
+// that way.
+func (cr *csvRows) timeValue(t time.Time) any {
+	t = t.UTC()
+	if cr.dialect == dburl.DialectSQLite {
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return t
+}
+
+// insertCSVRows writes the rows of cr with multi-row INSERTs in a single
+// transaction.
+func insertCSVRows(ctx context.Context, db *sql.DB, cr *csvRows, progress func(int)) (int, error) {
+	// Stay well below the bound parameter limits (SQLite 32766, MySQL 65535).
+	perBatch := max(1, min(500, 30000/len(cr.cols)))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	done := 0
+	var args []any
+	flush := func(rows int) error {
+		if rows == 0 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, importInsertSQL(cr.dialect, cr.table.Name, cr.cols, rows), args...); err != nil {
+			return fmt.Errorf("insert into %s: %w", cr.table.Name, err)
+		}
+		done += rows
+		args = args[:0]
+		progress(done)
+		return nil
+	}
+
+	pending := 0
+	for {
+		values, err := cr.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, values...)
+		if pending++; pending == perBatch {
+			if err := flush(pending); err != nil {
+				return 0, err
+			}
+			pending = 0
+		}
+	}
+	if err := flush(pending); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return done, nil
+}
+
+// importInsertSQL builds the INSERT of rows rows of cols into table.
+func importInsertSQL(dialect, table string, cols []importColumn, rows int) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + dbops.QuoteIdentifier(table, dialect) + " (")
+	for i, col := range cols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(dbops.QuoteIdentifier(col.Name, dialect))
+	}
+	b.WriteString(") VALUES ")
+	n := 0
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for i, col := range cols {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			n++
+			param := "?"
+			if dialect == dburl.DialectPostgres {
+				param = "$" + strconv.Itoa(n)
+			}
+			if ddl.IsSpatialType(col.Type) && dialect == dburl.DialectMySQL {
+				param = "ST_GeomFromText(" + param + ", 4326, 'axis-order=long-lat')"
+			}
+			b.WriteString(param)
+		}
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// copyCSVRows writes the rows of cr with a single COPY ... FROM STDIN,
+// which the server parses as CSV: it converts the text of each value to the
+// column's type, so jsonb, numeric and geography columns take the same text
+// the import validated.
+func copyCSVRows(ctx context.Context, db *sql.DB, cr *csvRows, progress func(int)) (int, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	quoted := make([]string, len(cr.cols))
+	for i, name := range cr.columnNames() {
+		quoted[i] = dbops.QuoteIdentifier(name, dburl.DialectPostgres)
+	}
+	stmt := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv)", dbops.QuoteIdentifier(cr.table.Name, dburl.DialectPostgres), strings.Join(quoted, ", "))
+
+	pr, pw := io.Pipe()
+	done := 0
+	go func() {
+		var line strings.Builder
+		for {
+			values, err := cr.next()
+			if errors.Is(err, io.EOF) {
+				pw.Close()
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			line.Reset()
+			for i, v := range values {
+				if i > 0 {
+					line.WriteByte(',')
+				}
+				line.WriteString(copyField(v))
+			}
+			line.WriteByte('\n')
+			if _, err := io.WriteString(pw, line.String()); err != nil {
+				return
+			}
+			if done++; done%importProgressRows == 0 {
+				progress(done)
+			}
+		}
+	}()
+
+	var copied int64
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("COPY needs the pgx driver, got %T", driverConn)
+		}
+		tag, err := c.Conn().PgConn().CopyFrom(ctx, pr, stmt)
+		copied = tag.RowsAffected()
+		return err
+	})
+	// Unblocks the reader goroutine if COPY failed before reading it all.
+	pr.Close()
+	if err != nil {
+		return 0, fmt.Errorf("copy into %s: %w", cr.table.Name, err)
+	}
+	return int(copied), nil
+}
+
+// copyField formats v as a field of COPY's CSV format, in which an
+// unquoted empty field is NULL and a quoted one the empty string.
+func copyField(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return `"` + strings.ReplaceAll(x, `"`, `""`) + `"`
+	case []byte:
+		return `\x` + hex.EncodeToString(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dburl"
+)
+
+func importTestPlan(t *testing.T) *migrate.MigrationPlan {
+	t.Helper()
+	plan := migrate.NewPlan()
+	if _, err := plan.AddTable("authors", func(tb *ddl.TableBuilder) error {
+		tb.String("name")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	authors, err := plan.Table("authors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.AddTable("books", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("author_id").References(authors)
+		tb.VarChar("title", 12)
+		tb.Decimal("price", 6, 2)
+		tb.Bool("published")
+		tb.Enum("format", "paper", "ebook")
+		tb.Datetime("released_at").Nullable()
+		tb.JSON("meta").Nullable()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestParseImportArgs(t *testing.T) {
+	got, err := ParseImportArgs([]string{"books", "books.csv", "--dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ImportArgs{Table: "books", File: "books.csv", DryRun: true}); got != want {
+		t.Errorf("ParseImportArgs = %+v, want %+v", got, want)
+	}
+	for _, args := range [][]string{nil, {"books"}, {"books", "a.csv", "b.csv"}, {"books", "a.csv", "--force"}} {
+		if _, err := ParseImportArgs(args); err == nil {
+			t.Errorf("ParseImportArgs(%v) should fail", args)
+		}
+	}
+}
+
+func TestValidateCSV(t *testing.T) {
+	books := importTestPlan(t).Schema.Tables["books"]
+
+	csv := `title,author_id,price,published,format,released_at,meta
+Dune,1,9.99,true,paper,2024-05-01T10:00:00Z,"{""a"": 1}"
+,2,10,false,ebook,,
+A very long title,1,9.99,true,paper,,
+Emma,x,cheap,maybe,audio,yesterday,{
+"unterminated
+`
+	total, rowErrs, err := ValidateCSV(strings.NewReader(csv), books, dburl.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 {
+		t.Errorf("total = %d, want 5", total)
+	}
+	var got []string
+	for _, e := range rowErrs {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		"line 4: title is longer than 12 characters",
+		"line 5: author_id must be the id of a authors row",
+		`line 6: extraneous or missing " in quoted-field`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("row errors = %q, want %q", got, want)
+	}
+
+	for header, wantErr := range map[string]string{
+		"": "the file is empty",
+		"title,author_id,price,published,format,isbn":  `no column "isbn"`,
+		"id,title,author_id,price,published,format":    "generated by the database",
+		"title,price,published,format":                 `missing column "author_id"`,
+		"title,title,author_id,price,published,format": "twice",
+	} {
+		if _, _, err := ValidateCSV(strings.NewReader(header), books, dburl.DialectSQLite); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("header %q: err = %v, want %q", header, err, wantErr)
+		}
+	}
+}
+
+func TestCSVRows_Values(t *testing.T) {
+	books := importTestPlan(t).Schema.Tables["books"]
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cr, err := newCSVRows(strings.NewReader("format,title,author_id,price,published,released_at,created_at\nebook,,7,12.50,1,2024-05-01T12:00:00+02:00,\n"), books, dburl.DialectPostgres, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := cr.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]any)
+	for i, name := range cr.columnNames() {
+		got[name] = values[i]
+	}
+	if id, _ := got["public_id"].(string); id == "" {
+		t.Errorf("public_id = %v, want a generated id", got["public_id"])
+	}
+	delete(got, "public_id")
+	want := map[string]any{
+		"author_id":   int64(7),
+		"title":       "",
+		"price":       "12.50",
+		"published":   true,
+		"format":      "ebook",
+		"released_at": time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		"created_at":  now, // named but empty; left out, updated_at gets its default
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+}
+
+func TestCSVRows_Polygon(t *testing.T) {
+	tb := ddl.MakeEmptyTable("zones")
+	tb.Polygon("area")
+	zones := *tb.Build()
+
+	cr, err := newCSVRows(strings.NewReader("area\n\"polygon((0 0, 0 1, 1 1, 0 0))\"\n\"POLYGON((0 0, 1 0, 1 1))\"\n"), zones, dburl.DialectSQLite, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := cr.next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "POLYGON((0 0, 1 1, 0 1, 0 0))"; !reflect.DeepEqual(values, []any{want}) {
+		t.Errorf("values = %v, want the counter-clockwise ring %s", values, want)
+	}
+	if _, err := cr.next(); err == nil || !strings.Contains(err.Error(), "area must be a single-ring WKT POLYGON") {
+		t.Errorf("unclosed ring: err = %v", err)
+	}
+}
+
+func TestImportCSV_SQLite(t *testing.T) {
+	plan := importTestPlan(t)
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "import.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, m := range plan.Migrations {
+		if _, err := db.Exec(m.Instructions.Sqlite); err != nil {
+			t.Fatalf("migration %s: %v", m.Name, err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO authors (public_id, name, created_at, updated_at) VALUES ('a1', 'Frank', '2024-01-01 00:00:00', '2024-01-01 00:00:00')`); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	b.WriteString("title,author_id,price,published,format\n")
+	for i := 0; i < 1200; i++ {
+		b.WriteString("Dune,1,9.99,true,paper\n")
+	}
+	var calls []int
+	n, err := ImportCSV(context.Background(), db, dburl.DialectSQLite, plan.Schema.Tables["books"], strings.NewReader(b.String()), func(done int) {
+		calls = append(calls, done)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1200 {
+		t.Errorf("imported %d rows, want 1200", n)
+	}
+	if want := []int{500, 1000, 1200}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("progress = %v, want %v", calls, want)
+	}
+	var count, publicIDs int
+	if err := db.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT public_id) FROM books WHERE created_at IS NOT NULL`).Scan(&count, &publicIDs); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1200 || publicIDs != 1200 {
+		t.Errorf("%d rows with %d public ids, want 1200 of each", count, publicIDs)
+	}
+
+	// A row the database rejects rolls back the whole import.
+	bad := "public_id,title,author_id,price,published,format\nx1,Emma,1,5,false,ebook\nx1,Emma,1,5,false,ebook\n"
+	if _, err := ImportCSV(context.Background(), db, dburl.DialectSQLite, plan.Schema.Tables["books"], strings.NewReader(bad), nil); err == nil {
+		t.Fatal("duplicate public_id should fail the import")
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM books`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1200 {
+		t.Errorf("failed import left %d rows, want 1200", count)
+	}
+}
+
+func TestImportInsertSQL(t *testing.T) {
+	cols := []importColumn{
+		{ColumnDefinition: ddl.ColumnDefinition{Name: "name", Type: ddl.StringType}},
+		{ColumnDefinition: ddl.ColumnDefinition{Name: "location", Type: ddl.PointType}},
+	}
+	if got, want := importInsertSQL(dburl.DialectMySQL, "places", cols, 2), "INSERT INTO `places` (`name`, `location`) VALUES (?, ST_GeomFromText(?, 4326, 'axis-order=long-lat')), (?, ST_GeomFromText(?, 4326, 'axis-order=long-lat'))"; got != want {
+		t.Errorf("mysql:\n got %s\nwant %s", got, want)
+	}
+	if got, want := importInsertSQL(dburl.DialectSQLite, "places", cols[:1], 3), `INSERT INTO "places" ("name") VALUES (?), (?), (?)`; got != want {
+		t.Errorf("sqlite:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestCopyField(t *testing.T) {
+	for _, tt := range []struct {
+		value any
+		want  string
+	}{
+		{nil, ``},
+		{"", `""`},
+		{`say "hi", then go`, `"say ""hi"", then go"`},
+		{[]byte{0xde, 0xad}, `\xdead`},
+		{time.Date(2024, 5, 1, 10, 0, 0, 500, time.UTC), `2024-05-01T10:00:00.0000005Z`},
+		{true, `true`},
+		{int64(-3), `-3`},
+		{1.5, `1.5`},
+	} {
+		if got := copyField(tt.value); got != tt.want {
+			t.Errorf("copyField(%#v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen/dbpkg"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	shipqdag "github.com/shipq/shipq/internal/dag"
+	"github.com/shipq/shipq/project"
+	"github.com/shipq/shipq/registry"
+)
+
+// DBPromoteCmd implements "shipq db promote <postgres|mysql>".
+// It moves a SQLite (typically lite) project to a server database: it points
+// shipq.ini at the default localhost URL for dialect, leaves lite mode, and
+// regenerates the dialect-specific code (shipq/db, the query runner and the
+// server wiring). Creating the new database and migrating it is left to
+// `shipq db setup` and `shipq migrate up`; no data is copied.
+func DBPromoteCmd(dialect string) {
+	if dialect != dburl.DialectPostgres && dialect != dburl.DialectMySQL {
+		cli.Fatal(fmt.Sprintf("cannot promote to %q\n  Valid dialects: postgres, mysql", dialect))
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	shipqIniPath := filepath.Join(roots.ShipqRoot, project.ShipqIniFile)
+	iniFile, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+
+	if current := iniFile.Get("db", "database_url"); current != "" {
+		currentDialect, err := dburl.InferDialectFromDBUrl(current)
+		if err != nil {
+			cli.FatalErr("failed to determine the current database dialect", err)
+		}
+		if currentDialect != dburl.DialectSQLite {
+			cli.Fatal(fmt.Sprintf("this project already uses %s; only SQLite projects can be promoted", currentDialect))
+		}
+	}
+
+	// 1. Rewrite the config
+	projectName := project.GetProjectName(roots.ShipqRoot)
+	dbURL := DefaultDatabaseURL(dialect, projectName, roots.ShipqRoot)
+	iniFile.Set("db", "database_url", dbURL)
+	if iniFile.Section("db").HasKey("lite") {
+		iniFile.Set("db", "lite", "false")
+	}
+	if err := iniFile.WriteFile(shipqIniPath); err != nil {
+		cli.FatalErr("failed to write shipq.ini", err)
+	}
+	cli.Successf("Promoted shipq.ini to %s", dialect)
+	cli.Infof("  database_url = %s", dbURL)
+
+	// 2. Regenerate the dialect-specific code
+	cli.Info("Regenerating shipq/db package...")
+	if err := dbpkg.EnsureDBPackage(roots.ShipqRoot); err != nil {
+		cli.FatalErr("failed to generate db package", err)
+	}
+
+	sqliteRunnerDir := filepath.Join(roots.ShipqRoot, "shipq", "queries", dburl.DialectSQLite)
+	if err := os.RemoveAll(sqliteRunnerDir); err != nil {
+		cli.Warn("Failed to remove the SQLite query runner: " + err.Error())
+	}
+
+	satisfied := shipqdag.SatisfiedFunc(roots.ShipqRoot)
+	if satisfied(shipqdag.CmdMigrateUp) {
+		fmt.Println("")
+		fmt.Println("Compiling queries...")
+		DBCompileCmd()
+	}
+
+	fmt.Println("")
+	fmt.Println("Compiling handler registry...")
+	if err := registry.Run(roots.ShipqRoot, roots.GoModRoot); err != nil {
+		cli.FatalErr("failed to compile registry", err)
+	}
+
+	cli.Successf("Project promoted to %s", dialect)
+	fmt.Println("")
+	fmt.Println("Next steps:")
+	fmt.Printf("  shipq start %s    # in a separate terminal\n", dialect)
+	fmt.Println("  shipq db setup")
+	fmt.Println("  shipq migrate up")
+	fmt.Println("  go mod tidy")
+	fmt.Println("")
+	fmt.Println("Data in the SQLite file is not copied to the new database.")
+}
+
+// DBPromoteUsage prints help text for `shipq db promote` to stderr.
+func DBPromoteUsage() {
+	fmt.Fprintln(os.Stderr, "shipq db promote - Move a SQLite (lite) project to a server database")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Usage: shipq db promote <postgres|mysql>")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Points shipq.ini at the default localhost URL for the dialect, leaves lite")
+	fmt.Fprintln(os.Stderr, "mode and regenerates the dialect-specific code. Run 'shipq db setup' and")
+	fmt.Fprintln(os.Stderr, "'shipq migrate up' afterwards; data is not copied.")
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/dbops"
+	"github.com/shipq/shipq/project"
+)
+
+// DBRefreshCmd implements "shipq db refresh [<view>...] [--recreate]".
+// It refreshes the named materialized views (all of them when none are
+// named) against the database in shipq.ini, creating any that do not exist
+// yet. --recreate drops each view first, which is needed after its query
+// changes.
+func DBRefreshCmd(args []string) {
+	recreate := false
+	var names []string
+	for _, arg := range args {
+		switch {
+		case arg == "--recreate":
+			recreate = true
+		case len(arg) > 0 && arg[0] == '-':
+			cli.Fatal(fmt.Sprintf("unknown flag for 'shipq db refresh': %s", arg))
+		default:
+			names = append(names, arg)
+		}
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	views, err := queryrunner.ReadViewManifest(roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to load materialized views", err)
+	}
+	if len(views) == 0 {
+		cli.Fatal("no materialized views found\n  Define one with query.MustDefineMaterializedView and run 'shipq db compile'")
+	}
+	selected, err := SelectViews(views, names)
+	if err != nil {
+		cli.FatalErr("cannot refresh", err)
+	}
+
+	ini, err := inifile.ParseFile(filepath.Join(roots.ShipqRoot, project.ShipqIniFile))
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+	databaseURL := ini.Get("db", "database_url")
+	if databaseURL == "" {
+		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
+	if err != nil {
+		cli.FatalErr("failed to determine database dialect", err)
+	}
+
+	db, err := openDatabase(databaseURL, dialect)
+	if err != nil {
+		cli.FatalErr("failed to connect to database", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, view := range selected {
+		if err := RefreshView(ctx, db, view, recreate); err != nil {
+			cli.FatalErr("failed to refresh "+view.Name, err)
+		}
+		cli.Successf("Refreshed %s", view.Name)
+	}
+}
+
+// SelectViews returns the views named in names, in the order given, or all
+// views when names is empty.
+func SelectViews(views []queryrunner.ViewManifestEntry, names []string) ([]queryrunner.ViewManifestEntry, error) {
+	if len(names) == 0 {
+		return views, nil
+	}
+	byName := make(map[string]queryrunner.ViewManifestEntry, len(views))
+	for _, v := range views {
+		byName[v.Name] = v
+	}
+	selected := make([]queryrunner.ViewManifestEntry, 0, len(names))
+	for _, name := range names {
+		v, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown materialized view %q", name)
+		}
+		selected = append(selected, v)
+	}
+	return selected, nil
+}
+
+// RefreshView creates view if needed and recomputes its rows. The refresh
+// statements run in one transaction so readers never see a half-filled
+// table-backed view.
+func RefreshView(ctx context.Context, db *sql.DB, view queryrunner.ViewManifestEntry, recreate bool) error {
+	if recreate {
+		if _, err := db.ExecContext(ctx, view.Drop); err != nil {
+			return fmt.Errorf("drop: %w", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, view.Create); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range view.Refresh {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// openDatabase opens and pings a database connection.
+func openDatabase(dbURL, dialect string) (*sql.DB, error) {
+	var dsn, driverName string
+	switch dialect {
+	case dburl.DialectPostgres:
+		dsn, driverName = dbURL, "pgx"
+	case dburl.DialectMySQL:
+		var err error
+		if dsn, err = dbops.MySQLURLToDSN(dbURL); err != nil {
+			return nil, err
+		}
+		driverName = "mysql"
+	case dburl.DialectSQLite:
+		dsn, driverName = dbops.SQLiteURLToPath(dbURL), "sqlite"
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
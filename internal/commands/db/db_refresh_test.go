@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
+)
+
+var testViews = []queryrunner.ViewManifestEntry{
+	{
+		Name:   "a_totals",
+		Create: `CREATE TABLE IF NOT EXISTS "a_totals" AS SELECT COUNT(*) AS n FROM items`,
+		Refresh: []string{
+			`DELETE FROM "a_totals"`,
+			`INSERT INTO "a_totals" SELECT COUNT(*) AS n FROM items`,
+		},
+		Drop: `DROP TABLE IF EXISTS "a_totals"`,
+	},
+	{Name: "b_totals"},
+}
+
+func TestSelectViews(t *testing.T) {
+	all, err := SelectViews(testViews, nil)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("SelectViews(nil) = %v, %v; want all views", all, err)
+	}
+
+	picked, err := SelectViews(testViews, []string{"b_totals", "a_totals"})
+	if err != nil {
+		t.Fatalf("SelectViews() error = %v", err)
+	}
+	if picked[0].Name != "b_totals" || picked[1].Name != "a_totals" {
+		t.Errorf("expected views in requested order, got %v", picked)
+	}
+
+	if _, err := SelectViews(testViews, []string{"missing"}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected unknown view error, got %v", err)
+	}
+}
+
+func TestRefreshView_SQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`CREATE TABLE items (id INTEGER); INSERT INTO items VALUES (1), (2)`); err != nil {
+		t.Fatal(err)
+	}
+	count := func() int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(`SELECT n FROM "a_totals"`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	ctx := context.Background()
+	if err := RefreshView(ctx, db, testViews[0], false); err != nil {
+		t.Fatalf("RefreshView() error = %v", err)
+	}
+	if got := count(); got != 2 {
+		t.Errorf("n = %d, want 2", got)
+	}
+
+	if _, err := db.Exec(`INSERT INTO items VALUES (3)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := RefreshView(ctx, db, testViews[0], true); err != nil {
+		t.Fatalf("RefreshView(recreate) error = %v", err)
+	}
+	if got := count(); got != 3 {
+		t.Errorf("n = %d after recreate, want 3", got)
+	}
+}
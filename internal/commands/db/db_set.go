@@ -33,6 +33,10 @@ func DBSetCmd(dialect string) {
 		cli.FatalErr("failed to parse shipq.ini", err)
 	}
 
+	if IsLiteMode(iniFile) && dialect != "sqlite" {
+		cli.Fatal(fmt.Sprintf("this project is in lite mode (SQLite only)\n  Run 'shipq db promote %s' to move it to %s", dialect, dialect))
+	}
+
 	iniFile.Set("db", "database_url", dbURL)
 
 	if err := iniFile.WriteFile(shipqIniPath); err != nil {
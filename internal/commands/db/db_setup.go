@@ -84,7 +84,11 @@ func DBSetupCmd() {
 	databaseURL := os.Getenv("DATABASE_URL")
 	var dialect string
 
-	if databaseURL == "" {
+	if IsLiteProject(roots.ShipqRoot) {
+		// Lite projects always run on the embedded SQLite file, whatever
+		// servers happen to be installed
+		databaseURL, dialect = DefaultDatabaseURL(dburl.DialectSQLite, projectName, roots.ShipqRoot), dburl.DialectSQLite
+	} else if databaseURL == "" {
 		databaseURL, dialect = inferDatabaseURL(roots.ShipqRoot, projectName)
 	} else {
 		// Infer dialect from provided URL
@@ -135,6 +139,20 @@ func DBSetupCmd() {
 	cli.Infof("  Updated shipq.ini with database_url")
 }
 
+// SetupServerDatabases creates the project's database and test database on
+// the postgres or mysql server at databaseURL, as "shipq db setup" does, and
+// returns the connection URL of the project's database.
+func SetupServerDatabases(dialect, databaseURL, projectName string) (string, error) {
+	switch dialect {
+	case dburl.DialectPostgres:
+		return setupPostgres(databaseURL, projectName)
+	case dburl.DialectMySQL:
+		return setupMySQL(databaseURL, projectName)
+	default:
+		return "", fmt.Errorf("unsupported database dialect: %s", dialect)
+	}
+}
+
 // setupPostgres creates PostgreSQL databases and returns the connection URL.
 func setupPostgres(databaseURL, projectName string) (string, error) {
 	// Open maintenance connection to postgres database
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/shipq/shipq/dburl"
+)
+
+// TableInfo describes a table as the live database reports it.
+type TableInfo struct {
+	Name    string
+	Columns []ColumnInfo
+	Indexes []IndexInfo
+}
+
+// ColumnInfo is one column of a TableInfo, in table order.
+type ColumnInfo struct {
+	Name     string
+	Type     string
+	Nullable bool
+	// Default is the column's default expression, empty when it has none.
+	Default    string
+	PrimaryKey bool
+}
+
+// IndexInfo is one secondary index of a TableInfo. The primary key is
+// reported on its columns instead.
+type IndexInfo struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// ListTables returns the names of the user tables in the connected
+// database (the current schema on Postgres), sorted.
+func ListTables(ctx context.Context, db *sql.DB, dialect string) ([]string, error) {
+	var q string
+	switch dialect {
+	case dburl.DialectPostgres:
+		q = `SELECT table_name FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`
+	case dburl.DialectMySQL:
+		q = `SELECT table_name FROM information_schema.tables
+WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name`
+	case dburl.DialectSQLite:
+		q = `SELECT name FROM sqlite_master
+WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+
+	rows, err := db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// DescribeTable returns the columns and secondary indexes of table. It
+// returns an error when the table does not exist.
+func DescribeTable(ctx context.Context, db *sql.DB, dialect, table string) (*TableInfo, error) {
+	var (
+		info = &TableInfo{Name: table}
+		err  error
+	)
+	switch dialect {
+	case dburl.DialectPostgres:
+		info.Columns, err = postgresColumns(ctx, db, table)
+		if err == nil {
+			info.Indexes, err = postgresIndexes(ctx, db, table)
+		}
+	case dburl.DialectMySQL:
+		info.Columns, err = mysqlColumns(ctx, db, table)
+		if err == nil {
+			info.Indexes, err = mysqlIndexes(ctx, db, table)
+		}
+	case dburl.DialectSQLite:
+		info.Columns, err = sqliteColumns(ctx, db, table)
+		if err == nil {
+			info.Indexes, err = sqliteIndexes(ctx, db, table)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(info.Columns) == 0 {
+		return nil, fmt.Errorf("table %q not found", table)
+	}
+	return info, nil
+}
+
+func postgresColumns(ctx context.Context, db *sql.DB, table string) ([]ColumnInfo, error) {
+	return scanColumns(ctx, db, `SELECT c.column_name, c.data_type, c.is_nullable = 'YES', COALESCE(c.column_default, ''),
+  EXISTS (
+    SELECT 1 FROM information_schema.table_constraints tc
+    JOIN information_schema.key_column_usage kcu
+      ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+    WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema
+      AND tc.table_name = c.table_name AND kcu.column_name = c.column_name
+  )
+FROM information_schema.columns c
+WHERE c.table_schema = current_schema() AND c.table_name = $1
+ORDER BY c.ordinal_position`, table)
+}
+
+func postgresIndexes(ctx context.Context, db *sql.DB, table string) ([]IndexInfo, error) {
+	return scanIndexColumns(ctx, db, `SELECT i.relname, ix.indisunique, a.attname
+FROM pg_class t
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_index ix ON ix.indrelid = t.oid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = current_schema() AND t.relname = $1 AND NOT ix.indisprimary
+ORDER BY i.relname, k.ord`, table)
+}
+
+func mysqlColumns(ctx context.Context, db *sql.DB, table string) ([]ColumnInfo, error) {
+	return scanColumns(ctx, db, `SELECT column_name, column_type, is_nullable = 'YES', COALESCE(column_default, ''), column_key = 'PRI'
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY ordinal_position`, table)
+}
+
+func mysqlIndexes(ctx context.Context, db *sql.DB, table string) ([]IndexInfo, error) {
+	return scanIndexColumns(ctx, db, `SELECT index_name, non_unique = 0, column_name
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND table_name = ? AND index_name <> 'PRIMARY'
+ORDER BY index_name, seq_in_index`, table)
+}
+
+func sqliteColumns(ctx context.Context, db *sql.DB, table string) ([]ColumnInfo, error) {
+	return scanColumns(ctx, db, `SELECT name, type, "notnull" = 0, COALESCE(dflt_value, ''), pk > 0
+FROM pragma_table_info(?) ORDER BY cid`, table)
+}
+
+func sqliteIndexes(ctx context.Context, db *sql.DB, table string) ([]IndexInfo, error) {
+	// pragma_index_list is read in full before pragma_index_info, so the
+	// two never hold a connection each (an in-memory database has one).
+	rows, err := db.QueryContext(ctx, `SELECT name, "unique" FROM pragma_index_list(?)
+WHERE origin <> 'pk' ORDER BY name`, table)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []IndexInfo
+	for rows.Next() {
+		var idx IndexInfo
+		if err := rows.Scan(&idx.Name, &idx.Unique); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		indexes = append(indexes, idx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range indexes {
+		cols, err := stringList(ctx, db, `SELECT name FROM pragma_index_info(?) ORDER BY seqno`, indexes[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i].Columns = cols
+	}
+	return indexes, nil
+}
+
+// scanColumns reads (name, type, nullable, default, primary key) rows.
+func scanColumns(ctx context.Context, db *sql.DB, q string, args ...any) ([]ColumnInfo, error) {
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default, &c.PrimaryKey); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+// scanIndexColumns reads (index, unique, column) rows ordered by index and
+// column position, and groups them into indexes.
+func scanIndexColumns(ctx context.Context, db *sql.DB, q string, args ...any) ([]IndexInfo, error) {
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var indexes []IndexInfo
+	for rows.Next() {
+		var name, column string
+		var unique bool
+		if err := rows.Scan(&name, &unique, &column); err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n == 0 || indexes[n-1].Name != name {
+			indexes = append(indexes, IndexInfo{Name: name, Unique: unique})
+		}
+		last := &indexes[len(indexes)-1]
+		last.Columns = append(last.Columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+func stringList(ctx context.Context, db *sql.DB, q string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/project"
+)
+
+// IsLiteMode reports whether shipq.ini has [db] lite = true, as written by
+// `shipq init --lite`. A lite project runs the whole toolchain on its
+// embedded SQLite file: starting a database server is a no-op, `db setup`
+// always creates the SQLite files, and `db promote` moves the project to
+// Postgres or MySQL.
+func IsLiteMode(ini *inifile.File) bool {
+	return strings.EqualFold(ini.Get("db", "lite"), "true")
+}
+
+// IsLiteProject reads shipq.ini in shipqRoot and reports whether the
+// project is in lite mode. A missing or unreadable shipq.ini is not lite.
+func IsLiteProject(shipqRoot string) bool {
+	ini, err := inifile.ParseFile(filepath.Join(shipqRoot, project.ShipqIniFile))
+	if err != nil {
+		return false
+	}
+	return IsLiteMode(ini)
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/inifile"
+)
+
+func TestIsLiteMode(t *testing.T) {
+	for content, want := range map[string]bool{
+		"[db]\nlite = true\n":                       true,
+		"[db]\nlite = TRUE\n":                       true,
+		"[db]\nlite = false\n":                      false,
+		"[db]\ndatabase_url = sqlite:///tmp/x.db\n": false,
+	} {
+		ini, err := inifile.Parse(strings.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := IsLiteMode(ini); got != want {
+			t.Errorf("IsLiteMode(%q) = %v, want %v", content, got, want)
+		}
+	}
+}
+
+func TestIsLiteProject(t *testing.T) {
+	dir := t.TempDir()
+	if IsLiteProject(dir) {
+		t.Error("a directory without shipq.ini is not a lite project")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shipq.ini"), []byte("[db]\nlite = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !IsLiteProject(dir) {
+		t.Error("expected a lite project")
+	}
+}
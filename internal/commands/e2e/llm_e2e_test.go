@@ -365,8 +365,8 @@ import (
 // they are NOT registered in FromServer() and are NOT sent through
 // the typed channel.
 func HandleChatRequest(ctx context.Context, req *ChatRequest) error {
-    tc := TypedChannelFromContext(ctx)
-    client := llm.ClientFromContext(ctx)
+    tc := MustTypedChannelFromContext(ctx)
+    client := llm.MustClientFromContext(ctx)
 
     resp, err := client.Chat(ctx, req.Message)
     if err != nil {
@@ -400,8 +400,8 @@ import (
 // llm.Client from the channel context (transport) and DB context (persistence)
 // and stores it in context for the handler to retrieve.
 func Setup(ctx context.Context) context.Context {
-    ch := channel.FromContext(ctx)
-    db := channel.DBFromContext(ctx)
+    ch := channel.MustFromContext(ctx)
+    db := channel.MustDBFromContext(ctx)
 
     app := llm.NewApp()
     weather.Register(app)
@@ -210,6 +210,7 @@ func EmailCmd() {
 		OAuthProviders:  oauthProviders,
 		SignupEnabled:   signupEnabled,
 		EmailEnabled:    true,
+		BearerAuth:      authgen.BearerAuthFromIni(ini),
 	}
 
 	// ---------------------------------------------------------------
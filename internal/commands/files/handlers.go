@@ -3,14 +3,15 @@ package files
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+
+	"github.com/shipq/shipq/codegen/phase"
 )
 
 const generatedFileHeader = "// Code generated by shipq. DO NOT EDIT.\n"
 
 // formatSource formats Go source code, returning the original if formatting fails.
 func formatSource(src []byte) []byte {
-	formatted, err := format.Source(src)
+	formatted, err := phase.FormatSource(src)
 	if err != nil {
 		return src
 	}
@@ -110,7 +111,7 @@ func UploadURL(ctx context.Context, req *UploadURLRequest) (*UploadURLResponse,
 	fileKey := nanoid.New()
 	publicID := nanoid.New()
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	s3Client, err := getS3Client()
 	if err != nil {
@@ -245,7 +246,7 @@ func Complete(ctx context.Context, req *CompleteRequest) (*CompleteResponse, err
 		return nil, httperror.New(400, "file id is required")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	file, err := runner.FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{
 		PublicId: req.Id,
@@ -336,7 +337,7 @@ func Download(ctx context.Context, req *DownloadRequest) (*DownloadResponse, err
 		return nil, httperror.New(400, "file id is required")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	file, err := runner.FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{
 		PublicId: req.Id,
@@ -454,7 +455,7 @@ func ListFiles(ctx context.Context, req *ListFilesRequest) (*ListFilesResponse,
 		return nil, httperror.New(401, "unauthorized")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	// Validate and set defaults
 	limit := req.Limit
@@ -544,7 +545,7 @@ func DeleteFile(ctx context.Context, req *DeleteFileRequest) (*DeleteFileRespons
 		return nil, httperror.New(400, "file id is required")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	file, err := runner.FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{
 		PublicId: req.Id,
@@ -626,7 +627,7 @@ func UpdateVisibility(ctx context.Context, req *UpdateVisibilityRequest) (*Updat
 		return nil, httperror.New(400, "visibility must be one of: private, organization, public, anonymous")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	file, err := runner.FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{
 		PublicId: req.Id,
@@ -715,7 +716,7 @@ func GrantAccess(ctx context.Context, req *GrantAccessRequest) (*GrantAccessResp
 		return nil, httperror.New(400, "role must be 'viewer' or 'manager'")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	file, err := runner.FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{
 		PublicId: req.Id,
@@ -784,7 +785,7 @@ func RevokeAccess(ctx context.Context, req *RevokeAccessRequest) (*RevokeAccessR
 		return nil, httperror.New(400, "file id and account_id are required")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	file, err := runner.FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{
 		PublicId: req.Id,
@@ -855,7 +856,7 @@ func ListAccess(ctx context.Context, req *ListAccessRequest) (*ListAccessRespons
 		return nil, httperror.New(400, "file id is required")
 	}
 
-	runner := queries.RunnerFromContext(ctx)
+	runner := queries.MustRunnerFromContext(ctx)
 
 	file, err := runner.FilesFindByPublicID(ctx, queries.FilesFindByPublicIDParams{
 		PublicId: req.Id,
@@ -3,13 +3,43 @@ package handler
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/commands/shared"
 	shipqdag "github.com/shipq/shipq/internal/dag"
+	"github.com/shipq/shipq/internal/profiling"
 	"github.com/shipq/shipq/project"
 	"github.com/shipq/shipq/registry"
 )
 
-func HandlerCompileCmd() {
+// HandlerCompileCmd implements "shipq handler compile [--grpc]
+// [--cpuprofile <file>] [--memprofile <file>] [--verbose]".
+//
+// --grpc turns on [server] grpc in shipq.ini, so this and every later
+// compile also generate api/api.proto and the gRPC server adapter, then
+// runs go mod tidy to pick up the gRPC dependencies.
+func HandlerCompileCmd(args []string) {
+	opts, rest, err := profiling.ParseFlags(args)
+	enableGRPC := false
+	for _, arg := range rest {
+		if arg == "--grpc" {
+			enableGRPC = true
+		} else if err == nil {
+			err = fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid arguments for 'shipq handler compile': %v\n", err)
+		os.Exit(1)
+	}
+	stopProfiling, err := profiling.Start(opts, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Find project roots (supports monorepo setup)
 	roots, err := project.FindProjectRoots()
 	if err != nil {
@@ -22,8 +52,46 @@ func HandlerCompileCmd() {
 		os.Exit(1)
 	}
 
+	if enableGRPC {
+		if err := enableGRPCServer(roots.ShipqRoot); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if err := registry.Run(roots.ShipqRoot, roots.GoModRoot); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+
+	if enableGRPC {
+		if err := shared.GoModTidy(roots.GoModRoot); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := stopProfiling(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// enableGRPCServer sets [server] grpc = true in shipq.ini unless it is
+// already set.
+func enableGRPCServer(shipqRoot string) error {
+	shipqIniPath := filepath.Join(shipqRoot, project.ShipqIniFile)
+	ini, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse shipq.ini: %w", err)
+	}
+	if strings.ToLower(ini.Get("server", "grpc")) == "true" {
+		return nil
+	}
+	ini.Set("server", "grpc", "true")
+	if err := ini.WriteFile(shipqIniPath); err != nil {
+		return fmt.Errorf("failed to write shipq.ini: %w", err)
+	}
+	fmt.Println("Enabled [server] grpc in shipq.ini")
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/shipq/shipq/codegen"
 	"github.com/shipq/shipq/codegen/crud"
@@ -12,6 +13,7 @@ import (
 	dbcodegen "github.com/shipq/shipq/db/portsql/codegen"
 	"github.com/shipq/shipq/db/portsql/migrate"
 	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/commands/db"
 	"github.com/shipq/shipq/internal/commands/shared"
 	shipqdag "github.com/shipq/shipq/internal/dag"
 	"github.com/shipq/shipq/project"
@@ -22,15 +24,48 @@ func HandlerGenerateCmd(args []string) {
 	if len(args) == 0 {
 		fmt.Fprintln(os.Stderr, "error: 'shipq handler generate' requires a table name")
 		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "Usage: shipq handler generate <table_name>")
+		fmt.Fprintln(os.Stderr, "Usage: shipq handler generate <table_name> [--bulk] [--restore] [--action <verb>]")
 		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, "Examples:")
 		fmt.Fprintln(os.Stderr, "  shipq handler generate posts")
 		fmt.Fprintln(os.Stderr, "  shipq handler generate users")
+		fmt.Fprintln(os.Stderr, "  shipq handler generate posts --bulk")
+		fmt.Fprintln(os.Stderr, "  shipq handler generate posts --restore")
+		fmt.Fprintln(os.Stderr, "  shipq handler generate posts --action publish")
 		os.Exit(1)
 	}
 
 	tableName := args[0]
+	action := ""
+	bulk := false
+	restore := false
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--bulk":
+			bulk = true
+		case args[i] == "--restore":
+			restore = true
+		case args[i] == "--action" && i+1 < len(args):
+			i++
+			action = args[i]
+		case strings.HasPrefix(args[i], "--action="):
+			action = strings.TrimPrefix(args[i], "--action=")
+		default:
+			fmt.Fprintf(os.Stderr, "error: unexpected argument %q\n", args[i])
+			fmt.Fprintln(os.Stderr, "Usage: shipq handler generate <table_name> [--bulk] [--restore] [--action <verb>]")
+			os.Exit(1)
+		}
+	}
+	if (bulk || restore) && action != "" {
+		fmt.Fprintln(os.Stderr, "error: --bulk and --restore cannot be combined with --action")
+		os.Exit(1)
+	}
+	if action != "" {
+		if err := handlergen.ValidateAction(action); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Find project roots (supports monorepo setup)
 	roots, err := project.FindProjectRoots()
@@ -93,17 +128,38 @@ func HandlerGenerateCmd(args []string) {
 		}
 	}
 
-	// Get scope column for this table
-	scopeColumn := ""
-	if opts, ok := crudCfg.TableOpts[tableName]; ok {
-		scopeColumn = opts.ScopeColumn
-	}
+	// Get scope column and deprecation settings for this table
+	tableOpts := crudCfg.TableOpts[tableName]
+	scopeColumn := tableOpts.ScopeColumn
+	nearColumn := tableOpts.NearColumn
 
-	// Read expose_email setting from shipq.ini
+	// Read expose_email and protect_by_default settings from shipq.ini
 	exposeEmail := false
+	requireAuth := false
 	shipqIniPath := filepath.Join(roots.ShipqRoot, project.ShipqIniFile)
 	if ini, iniErr := inifile.ParseFile(shipqIniPath); iniErr == nil {
 		exposeEmail = shared.IsExposeEmailEnabled(ini)
+		requireAuth = strings.ToLower(ini.Get("auth", "protect_by_default")) == "true"
+	}
+
+	if action != "" {
+		cfg := handlergen.HandlerGenConfig{
+			ModulePath:  modulePath,
+			TableName:   tableName,
+			Table:       table,
+			Schema:      plan.Schema.Tables,
+			ScopeColumn: scopeColumn,
+			RequireAuth: requireAuth,
+			ExposeEmail: exposeEmail,
+			PathSegment: tableOpts.PathSegment,
+			JSONCase:    tableOpts.JSONCase,
+			JSONNames:   tableOpts.JSONNames,
+		}
+		if err := generateAction(roots, cfg, action); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// Generate CRUD querydefs (DSL code the user can inspect and customise)
@@ -120,6 +176,13 @@ func HandlerGenerateCmd(args []string) {
 		ScopeColumn: scopeColumn,
 		Schema:      plan.Schema.Tables,
 		ExposeEmail: exposeEmail,
+		NearColumn:  nearColumn,
+
+		MaxRowsPerScope: tableOpts.MaxRowsPerScope,
+		StateColumns:    tableOpts.StateColumns,
+		Filters:         tableOpts.Filters,
+		Sorts:           tableOpts.Sorts,
+		NoSoftDelete:    tableOpts.NoSoftDelete,
 	}
 	querydefsBytes, err := crudquerydefs.GenerateCRUDQueryDefs(querydefsCfg)
 	if err != nil {
@@ -142,6 +205,25 @@ func HandlerGenerateCmd(args []string) {
 		Schema:      plan.Schema.Tables,
 		ScopeColumn: scopeColumn,
 		ExposeEmail: exposeEmail,
+		Deprecated:  tableOpts.Deprecated,
+		Sunset:      tableOpts.Sunset,
+		Defaults:    tableOpts.Defaults,
+		ListCacheMs: tableOpts.ListCacheMs,
+		PathSegment: tableOpts.PathSegment,
+		Outbox:      tableOpts.Outbox,
+
+		MaxRowsPerScope: tableOpts.MaxRowsPerScope,
+		QuotaStatus:     tableOpts.QuotaStatus,
+
+		JSONCase:  tableOpts.JSONCase,
+		JSONNames: tableOpts.JSONNames,
+
+		Bulk:         bulk || tableOpts.Bulk,
+		Hooks:        tableOpts.Hooks,
+		Filters:      tableOpts.Filters,
+		Sorts:        tableOpts.Sorts,
+		NoSoftDelete: tableOpts.NoSoftDelete,
+		Restore:      restore || tableOpts.Restore,
 	}
 
 	files, err := handlergen.GenerateHandlerFiles(cfg)
@@ -183,3 +265,94 @@ func HandlerGenerateCmd(args []string) {
 		// Don't exit - handler generation succeeded
 	}
 }
+
+// generateAction scaffolds a custom action: a querydef for the
+// <Action><Resource>ByPublicID query, a handler stub for
+// POST /<table>/:id/<action>, and its registration. The querydef and
+// handler are user-owned and never overwritten once they exist.
+func generateAction(roots *project.ProjectRoots, cfg handlergen.HandlerGenConfig, action string) error {
+	querydefsDir := filepath.Join(roots.ShipqRoot, "querydefs", cfg.TableName)
+	apiDir := filepath.Join(roots.ShipqRoot, "api", cfg.TableName)
+	for _, dir := range []string{querydefsDir, apiDir} {
+		if err := codegen.EnsureDir(dir); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	querydefBytes, err := crudquerydefs.GenerateActionQueryDef(crudquerydefs.Config{
+		ModulePath:  cfg.ModulePath,
+		TableName:   cfg.TableName,
+		Table:       cfg.Table,
+		ScopeColumn: cfg.ScopeColumn,
+	}, action)
+	if err != nil {
+		return err
+	}
+	if err := writeIfAbsent(filepath.Join(querydefsDir, action+".go"), querydefBytes); err != nil {
+		return err
+	}
+
+	// Compile queries so the runner has the new method before the handler
+	// that calls it is compiled into the registry.
+	fmt.Println("")
+	fmt.Println("Recompiling queries...")
+	db.DBCompileCmd()
+
+	handlerBytes, err := handlergen.GenerateActionHandler(cfg, action)
+	if err != nil {
+		return err
+	}
+	if err := writeIfAbsent(filepath.Join(apiDir, action+".go"), handlerBytes); err != nil {
+		return err
+	}
+
+	// The stub maps database errors with classifyDBError from helpers.go.
+	helpersPath := filepath.Join(apiDir, "helpers.go")
+	if _, err := os.Stat(helpersPath); os.IsNotExist(err) {
+		helpersBytes, err := handlergen.GenerateHelpersFile(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to generate helpers.go: %w", err)
+		}
+		if err := os.WriteFile(helpersPath, helpersBytes, 0644); err != nil {
+			return fmt.Errorf("failed to write helpers.go: %w", err)
+		}
+		fmt.Printf("Generated: %s\n", helpersPath)
+	}
+
+	registerPath := filepath.Join(apiDir, "register.go")
+	reg := handlergen.RegistrationForAction(cfg.TableName, cfg.PathSegment, action, cfg.RequireAuth)
+	registerBytes, err := handlergen.AddRouteToRegister(registerPath, cfg.ModulePath, cfg.TableName, cfg.PathSegment, reg)
+	if err != nil {
+		return fmt.Errorf("failed to generate register.go: %w", err)
+	}
+	if changed, err := codegen.WriteFileIfChanged(registerPath, registerBytes); err != nil {
+		return fmt.Errorf("failed to write register.go: %w", err)
+	} else if changed {
+		fmt.Printf("Generated: %s\n", registerPath)
+	}
+
+	fmt.Println("")
+	fmt.Println("Compiling handler registry...")
+	if err := registry.Run(roots.ShipqRoot, roots.GoModRoot); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to compile registry: %v\n", err)
+	}
+
+	fmt.Println("")
+	fmt.Printf("Action %q added: POST %s\n", action, reg.Path)
+	fmt.Printf("  Edit querydefs/%s/%s.go to define what it changes, then run 'shipq db compile'.\n", cfg.TableName, action)
+	return nil
+}
+
+// writeIfAbsent writes a scaffolded file unless one already exists, so
+// re-running the command never clobbers the user's edits.
+func writeIfAbsent(path string, content []byte) error {
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("Exists:    %s (left unchanged)\n", path)
+		return nil
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("Generated: %s\n", path)
+	return nil
+}
@@ -24,6 +24,8 @@ import (
 //	--postgres   Use PostgreSQL as the database dialect
 //	--mysql      Use MySQL as the database dialect
 //	--sqlite     Use SQLite as the database dialect (default)
+//	--lite       Run the whole toolchain on an embedded SQLite file with no
+//	             external services; promote later with `shipq db promote`
 func InitCmd() {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -40,6 +42,10 @@ func InitCmd() {
 
 	// Parse dialect flag from os.Args
 	dialect := parseDialectFlag()
+	lite := parseLiteFlag()
+	if lite && dialect != "sqlite" {
+		cli.Fatal("--lite runs on SQLite and cannot be combined with --postgres or --mysql")
+	}
 
 	// Check if a go.mod exists anywhere up the directory tree (monorepo support)
 	goModRoot, err := project.FindGoModRootFrom(cwd)
@@ -61,6 +67,11 @@ func InitCmd() {
 		if err := createShipqIni(cwd, projectName, dialect); err != nil {
 			cli.FatalErr("failed to create shipq.ini", err)
 		}
+		if lite {
+			if err := enableLiteMode(cwd); err != nil {
+				cli.FatalErr("failed to enable lite mode", err)
+			}
+		}
 		createdShipqIni = true
 	}
 
@@ -133,7 +144,24 @@ func InitCmd() {
 	}
 
 	// Print next-steps guidance
-	if didSomething {
+	if didSomething && lite {
+		fmt.Println("")
+		fmt.Println("Lite mode: everything runs on an embedded SQLite file, no servers needed.")
+		fmt.Println("")
+		fmt.Println("Next steps:")
+		fmt.Println("")
+		fmt.Println("  1. Create the database file:")
+		fmt.Println("")
+		fmt.Println("       shipq db setup")
+		fmt.Println("")
+		fmt.Println("  2. Compile the server and run it:")
+		fmt.Println("")
+		fmt.Println("       shipq handler compile")
+		fmt.Println("       go mod tidy")
+		fmt.Println("       go run ./cmd/server")
+		fmt.Println("")
+		fmt.Println("  Outgrown SQLite? Run 'shipq db promote postgres' (or mysql).")
+	} else if didSomething {
 		fmt.Println("")
 		fmt.Println("Next steps:")
 		fmt.Println("")
@@ -160,7 +188,7 @@ func InitCmd() {
 	}
 }
 
-// parseDialectFlag inspects os.Args for --postgres, --mysql, or --sqlite.
+// parseDialectFlag inspects os.Args for --postgres, --mysql, --sqlite or --lite.
 // Defaults to "sqlite" when no flag is provided.
 func parseDialectFlag() string {
 	dialect := "sqlite"
@@ -170,13 +198,36 @@ func parseDialectFlag() string {
 			dialect = "postgres"
 		case "--mysql":
 			dialect = "mysql"
-		case "--sqlite":
+		case "--sqlite", "--lite":
 			dialect = "sqlite"
 		}
 	}
 	return dialect
 }
 
+// parseLiteFlag reports whether os.Args contains --lite. Lite projects use
+// SQLite, so --lite also selects the sqlite dialect in parseDialectFlag.
+func parseLiteFlag() bool {
+	for _, arg := range os.Args[2:] {
+		if arg == "--lite" {
+			return true
+		}
+	}
+	return false
+}
+
+// enableLiteMode marks the project in dir as a lite project by setting
+// [db] lite = true in its shipq.ini.
+func enableLiteMode(dir string) error {
+	shipqIniPath := filepath.Join(dir, project.ShipqIniFile)
+	f, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		return err
+	}
+	f.Set("db", "lite", "true")
+	return f.WriteFile(shipqIniPath)
+}
+
 // defaultDatabaseURL builds a default database URL for the given dialect.
 func defaultDatabaseURL(dialect, projectName, dir string) string {
 	switch dialect {
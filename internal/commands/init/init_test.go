@@ -517,6 +517,7 @@ func TestParseDialectFlag(t *testing.T) {
 		{"--postgres flag", []string{"shipq", "init", "--postgres"}, "postgres"},
 		{"--mysql flag", []string{"shipq", "init", "--mysql"}, "mysql"},
 		{"last flag wins", []string{"shipq", "init", "--postgres", "--mysql"}, "mysql"},
+		{"--lite flag", []string{"shipq", "init", "--lite"}, "sqlite"},
 	}
 
 	for _, tt := range tests {
@@ -534,6 +535,41 @@ func TestParseDialectFlag(t *testing.T) {
 	}
 }
 
+func TestParseLiteFlag(t *testing.T) {
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+
+	os.Args = []string{"shipq", "init", "--lite"}
+	if !parseLiteFlag() {
+		t.Error("parseLiteFlag() = false with --lite")
+	}
+	os.Args = []string{"shipq", "init", "--sqlite"}
+	if parseLiteFlag() {
+		t.Error("parseLiteFlag() = true without --lite")
+	}
+}
+
+func TestEnableLiteMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := createShipqIni(tmpDir, "myproject", "sqlite"); err != nil {
+		t.Fatalf("createShipqIni failed: %v", err)
+	}
+	if err := enableLiteMode(tmpDir); err != nil {
+		t.Fatalf("enableLiteMode failed: %v", err)
+	}
+
+	ini, err := inifile.ParseFile(filepath.Join(tmpDir, project.ShipqIniFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ini.Get("db", "lite"); got != "true" {
+		t.Errorf("[db] lite = %q, want true", got)
+	}
+	if !strings.HasPrefix(ini.Get("db", "database_url"), "sqlite://") {
+		t.Errorf("lite project should keep its SQLite URL, got %q", ini.Get("db", "database_url"))
+	}
+}
+
 func TestInitInEmptyDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -3,10 +3,11 @@ package generator
 import (
 	"bytes"
 	"fmt"
-	"go/format"
+	"strconv"
 	"strings"
 
 	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/codegen/phase"
 	"github.com/shipq/shipq/internal/commands/migrate/parser"
 )
 
@@ -88,6 +89,9 @@ func GenerateMigration(cfg MigrationConfig) ([]byte, error) {
 			buf.WriteString(fmt.Sprintf("\t\t// Auto-added: global scope from [db] scope = %s\n", cfg.ScopeColumn))
 		}
 		colCode := generateColumnCode(col)
+		if col.Nullable {
+			colCode += ".Nullable()"
+		}
 		buf.WriteString(fmt.Sprintf("\t\t%s\n", colCode))
 	}
 
@@ -97,7 +101,7 @@ func GenerateMigration(cfg MigrationConfig) ([]byte, error) {
 	buf.WriteString("}\n")
 
 	// Format the generated code
-	formatted, err := format.Source(buf.Bytes())
+	formatted, err := phase.FormatSource(buf.Bytes())
 	if err != nil {
 		// Return unformatted code with error info for debugging
 		return buf.Bytes(), fmt.Errorf("failed to format generated code: %w", err)
@@ -131,6 +135,24 @@ func generateColumnCode(col parser.ColumnSpec) string {
 		return fmt.Sprintf("tb.Bigint(%q).References(%s)", col.Name, refVarName)
 	}
 
+	// Decimal takes precision and scale in addition to the name
+	if col.Type == "decimal" {
+		precision, scale := col.Precision, col.Scale
+		if precision == 0 {
+			precision, scale = parser.DefaultDecimalPrecision, parser.DefaultDecimalScale
+		}
+		return fmt.Sprintf("tb.Decimal(%q, %d, %d)", col.Name, precision, scale)
+	}
+
+	// Enum lists its values after the name
+	if col.Type == "enum" {
+		args := []string{strconv.Quote(col.Name)}
+		for _, v := range col.Values {
+			args = append(args, strconv.Quote(v))
+		}
+		return fmt.Sprintf("tb.Enum(%s)", strings.Join(args, ", "))
+	}
+
 	// Map column types to TableBuilder methods
 	methodName := columnTypeToMethod(col.Type)
 	return fmt.Sprintf("tb.%s(%q)", methodName, col.Name)
@@ -157,10 +179,18 @@ func columnTypeToMethod(colType string) string {
 		return "Datetime"
 	case "timestamp":
 		return "Timestamp"
+	case "timestamptz":
+		return "Timestamptz"
 	case "binary":
 		return "Binary"
 	case "json":
 		return "JSON"
+	case "point":
+		return "Point"
+	case "polygon":
+		return "Polygon"
+	case "enum":
+		return "Enum"
 	default:
 		// Capitalize first letter as fallback
 		return strings.Title(colType)
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/shipq/shipq/codegen/gentest/gofile"
 	"github.com/shipq/shipq/internal/commands/migrate/parser"
 )
 
@@ -396,6 +397,7 @@ func TestGenerateMigration_AllColumnTypes(t *testing.T) {
 			{Name: "col_timestamp", Type: "timestamp"},
 			{Name: "col_binary", Type: "binary"},
 			{Name: "col_json", Type: "json"},
+			{Name: "col_enum", Type: "enum", Values: []string{"draft", "live"}},
 		},
 	}
 
@@ -414,11 +416,12 @@ func TestGenerateMigration_AllColumnTypes(t *testing.T) {
 		"col_bigint":    `tb.Bigint("col_bigint")`,
 		"col_bool":      `tb.Bool("col_bool")`,
 		"col_float":     `tb.Float("col_float")`,
-		"col_decimal":   `tb.Decimal("col_decimal")`,
+		"col_decimal":   `tb.Decimal("col_decimal", 10, 2)`,
 		"col_datetime":  `tb.Datetime("col_datetime")`,
 		"col_timestamp": `tb.Timestamp("col_timestamp")`,
 		"col_binary":    `tb.Binary("col_binary")`,
 		"col_json":      `tb.JSON("col_json")`,
+		"col_enum":      `tb.Enum("col_enum", "draft", "live")`,
 	}
 
 	for colName, expected := range expectedMappings {
@@ -504,8 +507,11 @@ func TestColumnTypeToMethod(t *testing.T) {
 		{"decimal", "Decimal"},
 		{"datetime", "Datetime"},
 		{"timestamp", "Timestamp"},
+		{"timestamptz", "Timestamptz"},
 		{"binary", "Binary"},
 		{"json", "JSON"},
+		{"point", "Point"},
+		{"polygon", "Polygon"},
 	}
 
 	for _, tt := range tests {
@@ -578,3 +584,32 @@ func TestCollectReferencedTables(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateMigration_NullableColumns(t *testing.T) {
+	cfg := MigrationConfig{
+		PackageName:   "migrations",
+		MigrationName: "pets",
+		Timestamp:     "20240115120000",
+		Columns: []parser.ColumnSpec{
+			{Name: "name", Type: "string"},
+			{Name: "nickname", Type: "string", Nullable: true},
+			{Name: "owner_id", Type: "references", References: "owners", Nullable: true},
+		},
+		ModulePath: "github.com/example/myproject",
+	}
+
+	code, err := GenerateMigration(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := gofile.Parse(t, "migration.go", code)
+	f.AssertStmts("",
+		`tb.String("name")`,
+		`tb.String("nickname").Nullable()`,
+		`tb.Bigint("owner_id").References(ownersRef).Nullable()`,
+	)
+	if f.HasStmt("", `tb.String("name").Nullable()`) {
+		t.Errorf("name should not be nullable:\n%s", code)
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -10,27 +11,46 @@ import (
 type ColumnSpec struct {
 	Name       string
 	Type       string
-	References string // empty if not a reference
+	References string   // empty if not a reference
+	Precision  int      // decimal only: total digits
+	Scale      int      // decimal only: digits after the decimal point
+	Values     []string // enum only: the allowed values
+	Nullable   bool     // emit .Nullable(); not settable from the CLI syntax
 }
 
+// Default precision and scale for "name:decimal" specs. Two fractional
+// digits suits the common case of monetary values.
+const (
+	DefaultDecimalPrecision = 10
+	DefaultDecimalScale     = 2
+)
+
+// maxDecimalPrecision is the largest precision accepted by every supported
+// dialect (MySQL caps DECIMAL at 65 digits).
+const maxDecimalPrecision = 65
+
 // validColumnTypes is the set of supported column types.
 var validColumnTypes = map[string]bool{
-	"string":    true,
-	"text":      true,
-	"int":       true,
-	"bigint":    true,
-	"bool":      true,
-	"float":     true,
-	"decimal":   true,
-	"datetime":  true,
-	"timestamp": true,
-	"binary":    true,
-	"json":      true,
+	"string":      true,
+	"text":        true,
+	"int":         true,
+	"bigint":      true,
+	"bool":        true,
+	"float":       true,
+	"decimal":     true,
+	"datetime":    true,
+	"timestamp":   true,
+	"timestamptz": true,
+	"binary":      true,
+	"json":        true,
+	"point":       true,
+	"polygon":     true,
+	"enum":        true,
 }
 
 // ValidColumnTypesList returns a sorted list of valid column types for error messages.
 func ValidColumnTypesList() string {
-	return "string, text, int, bigint, bool, float, decimal, datetime, timestamp, binary, json"
+	return "string, text, int, bigint, bool, float, decimal, datetime, timestamp, timestamptz, binary, json, point, polygon, enum"
 }
 
 // ParseColumnSpec parses a column spec like "name:string" or "user_id:references:users".
@@ -74,6 +94,16 @@ func ParseColumnSpec(spec string) (*ColumnSpec, error) {
 		return nil, fmt.Errorf("unknown column type %q in spec %q, valid types are: %s", colType, spec, ValidColumnTypesList())
 	}
 
+	// Handle decimal type with optional precision and scale
+	if colType == "decimal" {
+		return parseDecimalSpec(spec, name, parts[2:])
+	}
+
+	// Handle enum type, which lists its values
+	if colType == "enum" {
+		return parseEnumSpec(spec, name, parts[2:])
+	}
+
 	// Check for extra parts
 	if len(parts) > 2 {
 		return nil, fmt.Errorf("invalid column spec %q: too many parts (expected 'name:type' or 'name:references:table')", spec)
@@ -85,6 +115,53 @@ func ParseColumnSpec(spec string) (*ColumnSpec, error) {
 	}, nil
 }
 
+// parseDecimalSpec handles "name:decimal" and "name:decimal:precision:scale".
+func parseDecimalSpec(spec, name string, extra []string) (*ColumnSpec, error) {
+	col := &ColumnSpec{
+		Name:      name,
+		Type:      "decimal",
+		Precision: DefaultDecimalPrecision,
+		Scale:     DefaultDecimalScale,
+	}
+	if len(extra) == 0 {
+		return col, nil
+	}
+	if len(extra) != 2 {
+		return nil, fmt.Errorf("invalid column spec %q: decimal takes both precision and scale (format: 'name:decimal:precision:scale')", spec)
+	}
+
+	precision, err := strconv.Atoi(extra[0])
+	if err != nil || precision < 1 || precision > maxDecimalPrecision {
+		return nil, fmt.Errorf("invalid column spec %q: decimal precision must be between 1 and %d", spec, maxDecimalPrecision)
+	}
+	scale, err := strconv.Atoi(extra[1])
+	if err != nil || scale < 0 || scale > precision {
+		return nil, fmt.Errorf("invalid column spec %q: decimal scale must be between 0 and the precision (%d)", spec, precision)
+	}
+
+	col.Precision = precision
+	col.Scale = scale
+	return col, nil
+}
+
+// parseEnumSpec handles "name:enum:value1,value2,...".
+func parseEnumSpec(spec, name string, extra []string) (*ColumnSpec, error) {
+	if len(extra) != 1 || extra[0] == "" {
+		return nil, fmt.Errorf("invalid column spec %q: enum requires its values (format: 'name:enum:value1,value2')", spec)
+	}
+	values := strings.Split(extra[0], ",")
+	for _, v := range values {
+		if v == "" {
+			return nil, fmt.Errorf("invalid column spec %q: enum values cannot be empty", spec)
+		}
+	}
+	return &ColumnSpec{
+		Name:   name,
+		Type:   "enum",
+		Values: values,
+	}, nil
+}
+
 // ParseColumnSpecs parses multiple column specs from command line args.
 func ParseColumnSpecs(args []string) ([]ColumnSpec, error) {
 	specs := make([]ColumnSpec, 0, len(args))
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/shipq/shipq/proptest"
@@ -25,6 +26,8 @@ func TestParseColumnSpec_ValidSimpleTypes(t *testing.T) {
 		{"updated_at:timestamp", "updated_at", "timestamp"},
 		{"data:binary", "data", "binary"},
 		{"metadata:json", "metadata", "json"},
+		{"location:point", "location", "point"},
+		{"zone:polygon", "zone", "polygon"},
 		{"_private:string", "_private", "string"},
 		{"col123:int", "col123", "int"},
 		{"my_col_name:text", "my_col_name", "text"},
@@ -49,6 +52,67 @@ func TestParseColumnSpec_ValidSimpleTypes(t *testing.T) {
 	}
 }
 
+func TestParseColumnSpec_DecimalPrecisionScale(t *testing.T) {
+	tests := []struct {
+		spec          string
+		wantPrecision int
+		wantScale     int
+	}{
+		{"amount:decimal", DefaultDecimalPrecision, DefaultDecimalScale},
+		{"amount:decimal:12:4", 12, 4},
+		{"amount:decimal:65:0", 65, 0},
+		{"amount:decimal:5:5", 5, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseColumnSpec(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if spec.Precision != tt.wantPrecision || spec.Scale != tt.wantScale {
+				t.Errorf("Precision, Scale = %d, %d, want %d, %d", spec.Precision, spec.Scale, tt.wantPrecision, tt.wantScale)
+			}
+		})
+	}
+}
+
+func TestParseColumnSpec_InvalidDecimal(t *testing.T) {
+	specs := []string{
+		"amount:decimal:12",
+		"amount:decimal:12:2:1",
+		"amount:decimal:0:0",
+		"amount:decimal:66:2",
+		"amount:decimal:10:11",
+		"amount:decimal:ten:2",
+		"amount:decimal:10:-1",
+	}
+
+	for _, s := range specs {
+		t.Run(s, func(t *testing.T) {
+			if _, err := ParseColumnSpec(s); err == nil {
+				t.Errorf("expected error for %q", s)
+			}
+		})
+	}
+}
+
+func TestParseColumnSpec_Enum(t *testing.T) {
+	spec, err := ParseColumnSpec("status:enum:pending,active,archived")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Type != "enum" || strings.Join(spec.Values, ",") != "pending,active,archived" {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+
+	for _, s := range []string{"status:enum", "status:enum:", "status:enum:a,,b", "status:enum:a:b"} {
+		if _, err := ParseColumnSpec(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
 func TestParseColumnSpec_ValidReferences(t *testing.T) {
 	tests := []struct {
 		spec           string
@@ -185,7 +249,7 @@ func TestValidateMigrationName(t *testing.T) {
 // Property-based tests
 
 func TestParseColumnSpec_Roundtrip(t *testing.T) {
-	validTypes := []string{"string", "text", "int", "bigint", "bool", "float", "decimal", "datetime", "timestamp", "binary", "json"}
+	validTypes := []string{"string", "text", "int", "bigint", "bool", "float", "decimal", "datetime", "timestamp", "timestamptz", "binary", "json", "point", "polygon"}
 
 	proptest.QuickCheck(t, "roundtrip for simple types", func(g *proptest.Generator) bool {
 		colName := g.IdentifierLower(20)
@@ -251,7 +315,7 @@ func TestParseColumnSpec_AcceptsReservedWords(t *testing.T) {
 }
 
 func TestParseColumnSpecs_Idempotent(t *testing.T) {
-	validTypes := []string{"string", "text", "int", "bigint", "bool", "float", "decimal", "datetime", "timestamp", "binary", "json"}
+	validTypes := []string{"string", "text", "int", "bigint", "bool", "float", "decimal", "datetime", "timestamp", "timestamptz", "binary", "json", "point", "polygon"}
 
 	proptest.QuickCheck(t, "parsing is deterministic", func(g *proptest.Generator) bool {
 		// Generate a valid spec
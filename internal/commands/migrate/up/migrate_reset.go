@@ -229,6 +229,18 @@ func generateQueryRunnerForReset(shipqRoot, modulePath string, plan *migrate.Mig
 		return fmt.Errorf("failed to write runner.go: %w", err)
 	}
 
+	// A fake runner left by db compile would no longer match types.go.
+	fakePath := filepath.Join(shipqRoot, queryrunner.FakeRunnerPath)
+	if _, err := os.Stat(fakePath); err == nil {
+		fakeCode, err := queryrunner.GenerateFakeRunner(runnerCfg)
+		if err != nil {
+			return fmt.Errorf("failed to generate fake runner.go: %w", err)
+		}
+		if _, err := codegen.WriteFileIfChanged(fakePath, fakeCode); err != nil {
+			return fmt.Errorf("failed to write fake runner.go: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,96 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/shipq/shipq/cli"
+	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/project"
+)
+
+// MigrateResolveCmd implements the "shipq migrate resolve [--dry-run]" command.
+// It renumbers pending migrations that conflict with the dev database's applied
+// history (older than the applied head, or sharing a timestamp) so they run
+// last, in their existing relative order. References to the old names inside
+// the migrations directory are rewritten to match.
+func MigrateResolveCmd(args []string) {
+	dryRun := false
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run":
+			dryRun = true
+		default:
+			cli.Fatal(fmt.Sprintf("unknown argument: %s\n  Usage: shipq migrate resolve [--dry-run]", arg))
+		}
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	shipqIniPath := filepath.Join(roots.ShipqRoot, project.ShipqIniFile)
+	ini, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+
+	databaseURL := ini.Get("db", "database_url")
+	if databaseURL == "" {
+		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+
+	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
+	if err != nil {
+		cli.FatalErr("failed to determine database dialect", err)
+	}
+
+	migrationsPath := getMigrationsPath(ini, roots.ShipqRoot)
+	migrations, err := codegenMigrate.DiscoverMigrations(migrationsPath)
+	if err != nil {
+		cli.FatalErr("failed to discover migrations", err)
+	}
+
+	devDB, err := openDatabase(databaseURL, dialect)
+	if err != nil {
+		cli.FatalErr("failed to connect to dev database", err)
+	}
+	defer devDB.Close()
+
+	ctx := context.Background()
+	if err := migrate.EnsureTrackingTable(ctx, devDB, dialect); err != nil {
+		cli.FatalErr("failed to create tracking table", err)
+	}
+	applied, err := migrate.GetAppliedMigrations(ctx, devDB)
+	if err != nil {
+		cli.FatalErr("failed to read applied migrations", err)
+	}
+
+	conflicts := codegenMigrate.DetectConflicts(migrations, applied)
+	if conflicts.Empty() {
+		cli.Success("No migration conflicts found")
+		return
+	}
+
+	renames := codegenMigrate.PlanRenumber(migrationsPath, conflicts.Conflicting(migrations))
+	for _, r := range renames {
+		cli.Infof("  %s -> %s", filepath.Base(r.From.Path), filepath.Base(r.To.Path))
+	}
+
+	if dryRun {
+		cli.Info("Dry run: no files were changed")
+		return
+	}
+
+	if err := codegenMigrate.ApplyRenumber(migrationsPath, renames); err != nil {
+		cli.FatalErr("failed to renumber migrations", err)
+	}
+
+	cli.Successf("Renumbered %d migration(s)", len(renames))
+	cli.Info("Review the moved migrations, then run 'shipq migrate up'.")
+}
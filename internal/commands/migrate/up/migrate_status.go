@@ -0,0 +1,156 @@
+package up
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shipq/shipq/cli"
+	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/project"
+)
+
+// MigrationStatusReport is the JSON form of `shipq migrate status --json`.
+type MigrationStatusReport struct {
+	Migrations []codegenMigrate.MigrationStatus `json:"migrations"`
+	Applied    int                              `json:"applied"`
+	Pending    int                              `json:"pending"`
+	Missing    int                              `json:"missing"`
+}
+
+// MigrateStatusCmd implements the "shipq migrate status [--json]" command.
+// It compares the dev database's tracking table with the migrations
+// directory and lists each migration as applied, pending, or missing (applied
+// but without a file). It only reads the database.
+func MigrateStatusCmd(args []string) {
+	asJSON := false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "-h", "--help":
+			fmt.Print(statusUsage)
+			os.Exit(0)
+		default:
+			cli.Fatal(fmt.Sprintf("unknown argument: %s\n  Usage: shipq migrate status [--json]", arg))
+		}
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	shipqIniPath := filepath.Join(roots.ShipqRoot, project.ShipqIniFile)
+	ini, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+
+	databaseURL := ini.Get("db", "database_url")
+	if databaseURL == "" {
+		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+	if dburl.IsSQLiteMemory(databaseURL) {
+		cli.Fatal("the dev database is in-memory, so it has no migration history\n  Migrations are applied when a test binary opens it")
+	}
+
+	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
+	if err != nil {
+		cli.FatalErr("failed to determine database dialect", err)
+	}
+
+	migrationsPath := getMigrationsPath(ini, roots.ShipqRoot)
+	migrations, err := codegenMigrate.DiscoverMigrations(migrationsPath)
+	if err != nil {
+		cli.FatalErr("failed to discover migrations", err)
+	}
+
+	devDB, err := openDatabase(databaseURL, dialect)
+	if err != nil {
+		cli.FatalErr("failed to connect to dev database", err)
+	}
+	defer devDB.Close()
+
+	// A database that was never migrated has no tracking table; everything
+	// is pending. The table isn't created here so the command stays read-only.
+	ctx := context.Background()
+	tables, err := migrate.GetAllTables(ctx, devDB, dialect)
+	if err != nil {
+		cli.FatalErr("failed to list tables", err)
+	}
+	applied := make(map[string]time.Time)
+	if slices.Contains(tables, "_portsql_migrations") {
+		records, err := migrate.GetAppliedMigrationRecords(ctx, devDB)
+		if err != nil {
+			cli.FatalErr("failed to read applied migrations", err)
+		}
+		for _, r := range records {
+			applied[r.Name] = r.AppliedAt
+		}
+	}
+
+	statuses := codegenMigrate.MigrationStatuses(migrations, applied)
+	if asJSON {
+		counts := codegenMigrate.CountStates(statuses)
+		out, err := json.MarshalIndent(MigrationStatusReport{
+			Migrations: statuses,
+			Applied:    counts[codegenMigrate.MigrationApplied],
+			Pending:    counts[codegenMigrate.MigrationPending],
+			Missing:    counts[codegenMigrate.MigrationMissing],
+		}, "", "  ")
+		if err != nil {
+			cli.FatalErr("failed to encode migration status", err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	fmt.Print(FormatMigrationStatus(statuses))
+}
+
+const statusUsage = `Usage: shipq migrate status [--json]
+
+List the migrations of the migrations directory and the dev database's
+_portsql_migrations table:
+
+  applied   recorded in the database
+  pending   not applied yet; 'shipq migrate up' applies it
+  missing   applied, but its file is gone from the migrations directory
+
+Options:
+  --json   Print a machine-readable report
+`
+
+// FormatMigrationStatus renders statuses as an aligned table followed by a
+// count of each state.
+func FormatMigrationStatus(statuses []codegenMigrate.MigrationStatus) string {
+	if len(statuses) == 0 {
+		return "No migrations found.\n"
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tMIGRATION\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.UTC().Format("2006-01-02 15:04:05 UTC")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.State, s.Name, appliedAt)
+	}
+	tw.Flush()
+
+	counts := codegenMigrate.CountStates(statuses)
+	fmt.Fprintf(&buf, "\n%d applied, %d pending, %d missing\n",
+		counts[codegenMigrate.MigrationApplied], counts[codegenMigrate.MigrationPending], counts[codegenMigrate.MigrationMissing])
+	return buf.String()
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/shipq/shipq/codegen/dbpkg"
 	"github.com/shipq/shipq/codegen/embed"
 	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/config"
 	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
 	"github.com/shipq/shipq/db/portsql/migrate"
 	"github.com/shipq/shipq/dburl"
@@ -90,10 +91,15 @@ func MigrateUpCmd() {
 	cli.Info("Embedding shipq library packages...")
 	filesEnabled := shared.IsFeatureEnabled(ini, "files")
 	workersEnabled := shared.IsFeatureEnabled(ini, "workers")
+	telemetryEnabled, err := config.ParseTelemetry(ini)
+	if err != nil {
+		cli.FatalErr("invalid shipq.ini", err)
+	}
 	if err := embed.EmbedAllPackages(roots.ShipqRoot, importPrefix, embed.EmbedOptions{
-		FilesEnabled:   filesEnabled,
-		WorkersEnabled: workersEnabled,
-		DBDialect:      dialect,
+		FilesEnabled:     filesEnabled,
+		WorkersEnabled:   workersEnabled,
+		TelemetryEnabled: telemetryEnabled,
+		DBDialect:        dialect,
 	}); err != nil {
 		cli.FatalErr("failed to embed library packages", err)
 	}
@@ -136,6 +142,14 @@ func MigrateUpCmd() {
 		cli.FatalErr("failed to parse migration plan", err)
 	}
 
+	// An in-memory database vanishes when this command exits; the generated
+	// test helpers migrate it when a test binary opens it.
+	if dburl.IsSQLiteMemory(databaseURL) {
+		cli.Info("In-memory database: skipping migrations (applied when tests open it)")
+		generateSchemaAndCompile(roots.ShipqRoot, importPrefix, plan)
+		return
+	}
+
 	cli.Info("Running migrations against dev database...")
 	devDB, err := openDatabase(databaseURL, dialect)
 	if err != nil {
@@ -143,6 +157,11 @@ func MigrateUpCmd() {
 	}
 	defer devDB.Close()
 
+	// Warn before applying anything that merged branches left pending
+	// migrations behind the applied head
+	checkMigrationConflicts(context.Background(), devDB, migrations)
+	reportPartialMigrations(context.Background(), devDB, dialect)
+
 	if err := migrate.Run(context.Background(), devDB, plan, dialect); err != nil {
 		cli.FatalErr("failed to migrate dev database", err)
 	}
@@ -165,14 +184,21 @@ func MigrateUpCmd() {
 	}
 	defer testDB.Close()
 
+	reportPartialMigrations(context.Background(), testDB, dialect)
 	if err := migrate.Run(context.Background(), testDB, plan, dialect); err != nil {
 		cli.FatalErr("failed to migrate test database", err)
 	}
 	cli.Success("Test database migrated")
 
+	generateSchemaAndCompile(roots.ShipqRoot, importPrefix, plan)
+}
+
+// generateSchemaAndCompile runs the last steps of migrate up: it generates
+// the schema package and compiles queries against the new plan.
+func generateSchemaAndCompile(shipqRoot, importPrefix string, plan *migrate.MigrationPlan) {
 	// Step 10: Generate schema package (in shipq root)
 	cli.Info("Generating shipq/db/schema package...")
-	if err := generateSchemaPackage(roots.ShipqRoot, importPrefix, plan); err != nil {
+	if err := generateSchemaPackage(shipqRoot, importPrefix, plan); err != nil {
 		cli.FatalErr("failed to generate schema package", err)
 	}
 	cli.Success("Generated shipq/db/schema/schema.go")
@@ -221,6 +247,10 @@ func getMigrationsPath(ini *inifile.File, projectRoot string) string {
 // Convention: test database is named {dev_db}_test
 // For SQLite: foo.db -> foo_test.db
 func buildTestDatabaseURL(devURL, dialect string) (string, error) {
+	if dburl.IsSQLiteMemory(devURL) {
+		return devURL, nil
+	}
+
 	devDBName := dburl.ParseDatabaseName(devURL)
 	if devDBName == "" {
 		return "", fmt.Errorf("could not parse database name from URL")
@@ -309,3 +339,52 @@ func checkOrphanedMigrations(ctx context.Context, db *sql.DB, plan *migrate.Migr
 		cli.Warn("these entries from the _portsql_migrations table if they are no longer needed.")
 	}
 }
+
+// checkMigrationConflicts warns about pending migrations that sort before the
+// latest applied one or share a timestamp with another migration. These
+// usually come from merging branches that each added migrations: they will
+// be applied after migrations written later, so the database can end up in
+// a different state than schema.json describes.
+func checkMigrationConflicts(ctx context.Context, db *sql.DB, migrations []codegenMigrate.MigrationFile) {
+	applied, err := migrate.GetAppliedMigrations(ctx, db)
+	if err != nil {
+		return // No tracking table yet, so nothing has been applied
+	}
+
+	conflicts := codegenMigrate.DetectConflicts(migrations, applied)
+	if conflicts.Empty() {
+		return
+	}
+
+	if len(conflicts.OutOfOrder) > 0 {
+		cli.Warnf("WARNING: %d pending migration(s) are older than the latest applied migration (%s):", len(conflicts.OutOfOrder), conflicts.AppliedHead)
+		for _, m := range conflicts.OutOfOrder {
+			cli.Warnf("  - %s", m.MigrationName())
+		}
+		cli.Warn("They will run after newer migrations, not in file order. This usually means two")
+		cli.Warn("branches added migrations in parallel.")
+	}
+	if len(conflicts.Collisions) > 0 {
+		cli.Warn("WARNING: pending migration(s) share a timestamp with another migration:")
+		for _, m := range conflicts.Collisions {
+			cli.Warnf("  - %s", m.MigrationName())
+		}
+	}
+	cli.Warn("Run 'shipq migrate resolve' to renumber them after the applied head.")
+}
+
+// reportPartialMigrations tells the user which migrations a previous failed
+// run left partly applied (MySQL only); migrate.Run resumes them from the
+// failed statement.
+func reportPartialMigrations(ctx context.Context, db *sql.DB, dialect string) {
+	partial, err := migrate.GetPartialMigrations(ctx, db, dialect)
+	if err != nil {
+		return
+	}
+	for _, p := range partial {
+		cli.Infof("Resuming %s after %d applied statement(s)", p.Name, p.Completed)
+		if p.LastError != "" {
+			cli.Infof("  previous error: %s", p.LastError)
+		}
+	}
+}
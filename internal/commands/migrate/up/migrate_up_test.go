@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shipq/shipq/codegen/dbpkg"
 	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
@@ -418,3 +419,26 @@ func Migrate_20260115120200_comments(plan *migrate.MigrationPlan) error {
 		}
 	}
 }
+
+func TestFormatMigrationStatus(t *testing.T) {
+	at := time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC)
+	got := FormatMigrationStatus([]codegenMigrate.MigrationStatus{
+		{Name: "20260101000000_users", State: codegenMigrate.MigrationApplied, AppliedAt: &at},
+		{Name: "20260102000000_comments", State: codegenMigrate.MigrationMissing, AppliedAt: &at},
+		{Name: "20260103000000_posts", State: codegenMigrate.MigrationPending},
+	})
+	want := `STATUS   MIGRATION                APPLIED AT
+applied  20260101000000_users     2026-01-01 09:30:00 UTC
+missing  20260102000000_comments  2026-01-01 09:30:00 UTC
+pending  20260103000000_posts     -
+
+1 applied, 1 pending, 1 missing
+`
+	if got != want {
+		t.Errorf("FormatMigrationStatus() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := FormatMigrationStatus(nil); got != "No migrations found.\n" {
+		t.Errorf("FormatMigrationStatus(nil) = %q", got)
+	}
+}
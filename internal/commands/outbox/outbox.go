@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/channelgen"
+	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/commands/migrate/up"
+	"github.com/shipq/shipq/internal/commands/shared"
+	"github.com/shipq/shipq/internal/commands/workers"
+	shipqdag "github.com/shipq/shipq/internal/dag"
+	"github.com/shipq/shipq/project"
+)
+
+// outboxMigrationSuffixes is used to detect an existing outbox_events migration.
+var outboxMigrationSuffixes = []string{
+	"_outbox_events.go",
+}
+
+// OutboxCmd handles "shipq outbox" — adds the transactional outbox: an
+// outbox_events table that the generated create, update and delete
+// handlers of opted-in tables record events in, in the transaction that
+// writes the row, and a relay in the worker that publishes them to a
+// pluggable sink.
+//
+// Prerequisites:
+//   - [workers] must exist in shipq.ini (run `shipq workers` first)
+//
+// This command:
+//  1. Adds an [outbox] section to shipq.ini with the relay's defaults
+//  2. Generates the outbox_events migration and runs migrate up
+//  3. Creates outbox/sink.go (user-owned; never overwritten)
+//  4. Runs `shipq workers compile`, which generates the outbox querydefs,
+//     compiles queries and regenerates the worker with the relay
+func OutboxCmd() {
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("not in a shipq project", err)
+	}
+
+	// DAG prerequisite check
+	if !shipqdag.CheckPrerequisites(shipqdag.CmdOutbox, roots.ShipqRoot) {
+		os.Exit(1)
+	}
+
+	moduleInfo, err := codegen.GetModuleInfo(roots.GoModRoot, roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to determine Go module info", err)
+	}
+	modulePath := moduleInfo.FullImportPath("")
+
+	shipqIniPath := filepath.Join(roots.ShipqRoot, project.ShipqIniFile)
+	ini, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+
+	migrationsDir := ini.Get("db", "migrations")
+	if migrationsDir == "" {
+		migrationsDir = "migrations"
+	}
+	migrationsPath := filepath.Join(roots.ShipqRoot, migrationsDir)
+
+	fmt.Println("Setting up the event outbox...")
+
+	// ── Step 1: Update shipq.ini with [outbox] section ───────────────
+
+	fmt.Println("")
+	fmt.Println("Updating shipq.ini with outbox config...")
+
+	if ini.Section("outbox") == nil {
+		ini.Set("outbox", "poll_interval", workers.DefaultOutboxPollInterval.String())
+		ini.Set("outbox", "batch_size", fmt.Sprint(workers.DefaultOutboxBatchSize))
+		ini.Set("outbox", "max_attempts", fmt.Sprint(workers.DefaultOutboxMaxAttempts))
+		if err := ini.WriteFile(shipqIniPath); err != nil {
+			cli.FatalErr("failed to write shipq.ini", err)
+		}
+		fmt.Println("  Added [outbox] section with relay defaults")
+	} else {
+		fmt.Println("  [outbox] section already exists, skipping...")
+	}
+
+	// ── Step 2: Generate outbox_events migration ─────────────────────
+
+	fmt.Println("")
+	fmt.Println("Checking outbox_events migration...")
+
+	if shared.MigrationsExist(migrationsPath, outboxMigrationSuffixes, false) {
+		fmt.Println("  outbox_events migration already exists, skipping")
+	} else {
+		if err := os.MkdirAll(migrationsPath, 0755); err != nil {
+			cli.FatalErr("failed to create migrations directory", err)
+		}
+		timestamp := codegenMigrate.NextMigrationBaseTime(migrationsPath).Format("20060102150405")
+		filePath := filepath.Join(migrationsPath, fmt.Sprintf("%s_outbox_events.go", timestamp))
+		if err := os.WriteFile(filePath, channelgen.GenerateOutboxMigration(timestamp, modulePath), 0644); err != nil {
+			cli.FatalErr("failed to write migration", err)
+		}
+		relPath, _ := filepath.Rel(roots.ShipqRoot, filePath)
+		fmt.Printf("  Created: %s\n", relPath)
+	}
+
+	fmt.Println("")
+	fmt.Println("Running migrations...")
+	up.MigrateUpCmd()
+
+	// ── Step 3: Create the user-owned sink ───────────────────────────
+
+	fmt.Println("")
+	fmt.Println("Checking outbox sink...")
+
+	sinkPath := filepath.Join(roots.ShipqRoot, "outbox", "sink.go")
+	if _, err := os.Stat(sinkPath); err == nil {
+		fmt.Println("  outbox/sink.go already exists, skipping")
+	} else {
+		if err := codegen.EnsureDir(filepath.Dir(sinkPath)); err != nil {
+			cli.FatalErr("failed to create outbox directory", err)
+		}
+		if err := os.WriteFile(sinkPath, channelgen.GenerateOutboxSink(modulePath), 0644); err != nil {
+			cli.FatalErr("failed to write outbox/sink.go", err)
+		}
+		fmt.Println("  Created: outbox/sink.go")
+	}
+
+	// ── Step 4: Querydefs, queries and the worker's relay ────────────
+
+	fmt.Println("")
+	workers.WorkersCompileCmd()
+
+	fmt.Println("")
+	cli.Success("Event outbox added successfully!")
+	fmt.Println("")
+	fmt.Println("Next steps:")
+	fmt.Println("  1. Opt tables in, e.g. for posts:")
+	fmt.Println("       [crud.posts]")
+	fmt.Println("       outbox = true")
+	fmt.Println("     then regenerate their handlers: shipq resource posts all")
+	fmt.Println("  2. Point outbox/sink.go at your broker (events are logged until then)")
+	fmt.Println("  3. shipq start worker")
+}
@@ -0,0 +1,136 @@
+package quotas
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen"
+	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/codegen/quotagen"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/commands/db"
+	"github.com/shipq/shipq/internal/commands/migrate/up"
+	"github.com/shipq/shipq/internal/commands/shared"
+	shipqdag "github.com/shipq/shipq/internal/dag"
+	"github.com/shipq/shipq/project"
+)
+
+// quotaMigrationSuffixes is used to detect an existing scope_quotas migration.
+var quotaMigrationSuffixes = []string{
+	"_scope_quotas.go",
+}
+
+// QuotasCmd handles "shipq quotas" — adds per-scope row quotas: tables
+// with max_rows_per_scope in their [crud.<table>] section get a create
+// handler that counts the caller's live rows and refuses the create once
+// the quota is reached. The scope_quotas table overrides a table's limit
+// for one scope at runtime.
+//
+// Prerequisites:
+//   - shipq db setup must have been run
+//
+// This command:
+//  1. Adds a [quotas] section to shipq.ini with the error status
+//  2. Generates the scope_quotas migration and runs migrate up
+//  3. Generates querydefs/scope_quotas/queries.go and compiles queries
+func QuotasCmd() {
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("not in a shipq project", err)
+	}
+
+	// DAG prerequisite check
+	if !shipqdag.CheckPrerequisites(shipqdag.CmdQuotas, roots.ShipqRoot) {
+		os.Exit(1)
+	}
+
+	moduleInfo, err := codegen.GetModuleInfo(roots.GoModRoot, roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to determine Go module info", err)
+	}
+	modulePath := moduleInfo.FullImportPath("")
+
+	shipqIniPath := filepath.Join(roots.ShipqRoot, project.ShipqIniFile)
+	ini, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+
+	migrationsDir := ini.Get("db", "migrations")
+	if migrationsDir == "" {
+		migrationsDir = "migrations"
+	}
+	migrationsPath := filepath.Join(roots.ShipqRoot, migrationsDir)
+
+	fmt.Println("Setting up per-scope quotas...")
+
+	// ── Step 1: Update shipq.ini with [quotas] section ───────────────
+
+	fmt.Println("")
+	fmt.Println("Updating shipq.ini with quotas config...")
+
+	if ini.Section("quotas") == nil {
+		ini.Set("quotas", "status", fmt.Sprint(quotagen.DefaultStatus))
+		if err := ini.WriteFile(shipqIniPath); err != nil {
+			cli.FatalErr("failed to write shipq.ini", err)
+		}
+		fmt.Println("  Added [quotas] section")
+	} else {
+		fmt.Println("  [quotas] section already exists, skipping...")
+	}
+
+	// ── Step 2: Generate scope_quotas migration ──────────────────────
+
+	fmt.Println("")
+	fmt.Println("Checking scope_quotas migration...")
+
+	if shared.MigrationsExist(migrationsPath, quotaMigrationSuffixes, false) {
+		fmt.Println("  scope_quotas migration already exists, skipping")
+	} else {
+		if err := os.MkdirAll(migrationsPath, 0755); err != nil {
+			cli.FatalErr("failed to create migrations directory", err)
+		}
+		timestamp := codegenMigrate.NextMigrationBaseTime(migrationsPath).Format("20060102150405")
+		filePath := filepath.Join(migrationsPath, fmt.Sprintf("%s_scope_quotas.go", timestamp))
+		if err := os.WriteFile(filePath, quotagen.GenerateQuotaMigration(timestamp, modulePath), 0644); err != nil {
+			cli.FatalErr("failed to write migration", err)
+		}
+		relPath, _ := filepath.Rel(roots.ShipqRoot, filePath)
+		fmt.Printf("  Created: %s\n", relPath)
+	}
+
+	fmt.Println("")
+	fmt.Println("Running migrations...")
+	up.MigrateUpCmd()
+
+	// ── Step 3: Querydefs and queries ────────────────────────────────
+
+	// migrate up compiled CRUD querydefs for scope_quotas; replace them
+	// with the quota queries.
+	fmt.Println("")
+	dir := filepath.Join(roots.ShipqRoot, "querydefs", quotagen.QuotaTable)
+	if err := codegen.EnsureDir(dir); err != nil {
+		cli.FatalErr("failed to create querydefs directory", err)
+	}
+	if _, err := codegen.WriteGeneratedFile(filepath.Join(dir, "queries.go"), quotagen.GenerateQuotaQuerydefs(modulePath)); err != nil {
+		cli.FatalErr("failed to write quota querydefs", err)
+	}
+	fmt.Println("  Generated querydefs/scope_quotas/queries.go")
+
+	fmt.Println("")
+	fmt.Println("Compiling queries...")
+	db.DBCompileCmd()
+
+	fmt.Println("")
+	cli.Success("Per-scope quotas added successfully!")
+	fmt.Println("")
+	fmt.Println("Next steps:")
+	fmt.Println("  1. Limit a scoped table, e.g. for posts:")
+	fmt.Println("       [crud.posts]")
+	fmt.Println("       max_rows_per_scope = 1000")
+	fmt.Println("     then regenerate its handlers: shipq resource posts all")
+	fmt.Println("  2. Raise or lower one organization's limit at runtime with")
+	fmt.Println("     InsertScopeQuota / UpdateScopeQuota (resource = \"posts\")")
+}
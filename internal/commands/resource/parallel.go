@@ -0,0 +1,71 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// tableRun is the outcome of one table's generation step.
+type tableRun struct {
+	out      bytes.Buffer
+	duration time.Duration
+	err      error
+}
+
+// forEachTable runs fn for every table on up to jobs goroutines. Each
+// table's output is buffered and written to w as one block as soon as the
+// table finishes, followed by a progress line with its duration, so the
+// output of tables generated in parallel never interleaves. Every table
+// writes only its own files, so what is generated doesn't depend on the
+// order the tables finish in. The error of the first failing table, in
+// table order, is returned once all tables are done.
+func forEachTable(w io.Writer, tables []string, jobs int, fn func(table string, out io.Writer) error) error {
+	jobs = min(max(jobs, 1), len(tables))
+	runs := make([]tableRun, len(tables))
+
+	work := make(chan int)
+	finished := make(chan int)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				start := time.Now()
+				runs[i].err = fn(tables[i], &runs[i].out)
+				runs[i].duration = time.Since(start)
+				finished <- i
+			}
+		}()
+	}
+	go func() {
+		for i := range tables {
+			work <- i
+		}
+		close(work)
+		wg.Wait()
+		close(finished)
+	}()
+
+	done := 0
+	for i := range finished {
+		done++
+		run := &runs[i]
+		w.Write(run.out.Bytes())
+		status := run.duration.Round(time.Millisecond).String()
+		if run.err != nil {
+			status = "failed"
+		}
+		fmt.Fprintf(w, "  [%d/%d] %s (%s)\n", done, len(tables), tables[i], status)
+	}
+
+	for i := range runs {
+		if runs[i].err != nil {
+			return fmt.Errorf("%s: %w", tables[i], runs[i].err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package resource
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachTable(t *testing.T) {
+	tables := []string{"a", "b", "c", "d", "e", "f"}
+	var running, peak atomic.Int32
+	var buf bytes.Buffer
+	err := forEachTable(&buf, tables, 3, func(table string, out io.Writer) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		fmt.Fprintf(out, "begin %s\n", table)
+		time.Sleep(5 * time.Millisecond)
+		fmt.Fprintf(out, "end %s\n", table)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("%d tables ran at once, want at most 3", p)
+	}
+
+	// Each table's block is written whole, followed by its progress line.
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3*len(tables) {
+		t.Fatalf("output:\n%s", buf.String())
+	}
+	for i := 0; i < len(lines); i += 3 {
+		table := strings.TrimPrefix(lines[i], "begin ")
+		if lines[i+1] != "end "+table || !strings.HasPrefix(lines[i+2], fmt.Sprintf("  [%d/6] %s (", i/3+1, table)) {
+			t.Errorf("interleaved output:\n%s", buf.String())
+			break
+		}
+	}
+}
+
+func TestForEachTable_FirstErrorInTableOrder(t *testing.T) {
+	var buf bytes.Buffer
+	err := forEachTable(&buf, []string{"a", "b", "c"}, 3, func(table string, out io.Writer) error {
+		switch table {
+		case "b":
+			time.Sleep(10 * time.Millisecond)
+			return errors.New("boom b")
+		case "c":
+			return errors.New("boom c")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "b: boom b" {
+		t.Errorf("err = %v, want the error of b", err)
+	}
+	if !strings.Contains(buf.String(), "c (failed)") {
+		t.Errorf("progress should mark failed tables:\n%s", buf.String())
+	}
+}
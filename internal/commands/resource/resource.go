@@ -2,8 +2,12 @@ package resource
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/shipq/shipq/codegen"
@@ -11,6 +15,8 @@ import (
 	"github.com/shipq/shipq/codegen/crudquerydefs"
 	"github.com/shipq/shipq/codegen/handlergen"
 	"github.com/shipq/shipq/codegen/resourcegen"
+	portsqlcodegen "github.com/shipq/shipq/db/portsql/codegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
 	"github.com/shipq/shipq/db/portsql/migrate"
 	"github.com/shipq/shipq/dburl"
 	"github.com/shipq/shipq/inifile"
@@ -23,24 +29,53 @@ import (
 )
 
 // ValidOperations lists the accepted operation names for `shipq resource <table> <op>`.
-var ValidOperations = []string{"create", "get_one", "list", "update", "delete", "all"}
+var ValidOperations = []string{"create", "get_one", "list", "update", "delete", "import", "near", "all"}
 
-// ResourceCmd handles `shipq resource <table> <operation>`.
-func ResourceCmd(tableName, operation string, extraArgs []string) {
+// ResourceCmd handles `shipq resource <table|@label> <operation>`.
+func ResourceCmd(target, operation string, extraArgs []string) {
 	isPublic := false
-	for _, arg := range extraArgs {
-		if arg == "--public" {
+	var excludeLabels []string
+	jobs := runtime.NumCPU()
+	for i := 0; i < len(extraArgs); i++ {
+		arg := extraArgs[i]
+		switch {
+		case arg == "--public":
 			isPublic = true
+		case arg == "--jobs" || strings.HasPrefix(arg, "--jobs="):
+			value, ok := strings.CutPrefix(arg, "--jobs=")
+			if !ok {
+				if i+1 >= len(extraArgs) {
+					fmt.Fprintln(os.Stderr, "error: --jobs requires a number")
+					os.Exit(1)
+				}
+				i++
+				value = extraArgs[i]
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "error: --jobs must be a positive number, got %q\n", value)
+				os.Exit(1)
+			}
+			jobs = n
+		case arg == "--exclude-label":
+			if i+1 >= len(extraArgs) {
+				fmt.Fprintln(os.Stderr, "error: --exclude-label requires a label")
+				os.Exit(1)
+			}
+			i++
+			excludeLabels = append(excludeLabels, extraArgs[i])
+		case strings.HasPrefix(arg, "--exclude-label="):
+			excludeLabels = append(excludeLabels, strings.TrimPrefix(arg, "--exclude-label="))
 		}
 	}
 
-	if err := generateResource(tableName, operation, isPublic); err != nil {
+	if err := generateResource(target, operation, isPublic, excludeLabels, jobs); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func generateResource(tableName, operation string, isPublic bool) error {
+func generateResource(target, operation string, isPublic bool, excludeLabels []string, jobs int) error {
 	// Find project roots
 	roots, err := project.FindProjectRoots()
 	if err != nil {
@@ -123,14 +158,9 @@ func generateResource(tableName, operation string, isPublic bool) error {
 		return fmt.Errorf("failed to parse schema.json: %w", err)
 	}
 
-	table, ok := plan.Schema.Tables[tableName]
-	if !ok {
-		return fmt.Errorf("table %q not found in schema.json.\nAvailable tables: %s",
-			tableName, strings.Join(handlergen.SortedTableNames(plan.Schema.Tables), ", "))
-	}
-
-	if table.IsJunctionTable {
-		return fmt.Errorf("table %q is a junction table and doesn't need handlers", tableName)
+	tableNames, err := resolveResourceTables(plan.Schema.Tables, target, excludeLabels)
+	if err != nil {
+		return err
 	}
 
 	// Load CRUD config for scope settings
@@ -138,28 +168,137 @@ func generateResource(tableName, operation string, isPublic bool) error {
 	for name := range plan.Schema.Tables {
 		allTableNames = append(allTableNames, name)
 	}
-
-	scopeColumn := ""
 	crudCfg, crudErr := crud.LoadCRUDConfigWithTables(roots.ShipqRoot, allTableNames, plan.Schema.Tables)
-	if crudErr == nil {
-		if opts, ok := crudCfg.TableOpts[tableName]; ok {
-			scopeColumn = opts.ScopeColumn
+	if crudErr != nil {
+		crudCfg = nil
+	}
+
+	env := resourceEnv{
+		roots:           roots,
+		modulePath:      modulePath,
+		plan:            plan,
+		crudCfg:         crudCfg,
+		requireAuth:     requireAuth,
+		exposeEmail:     exposeEmail,
+		dialect:         dialect,
+		testDatabaseURL: testDatabaseURL,
+	}
+
+	// Generate CRUD querydefs (DSL code the user can inspect and customise).
+	// Tables are independent, so they are generated on up to jobs workers.
+	fmt.Println("")
+	fmt.Printf("Generating querydefs for %d table(s)...\n", len(tableNames))
+	if err := forEachTable(os.Stdout, tableNames, jobs, env.writeQuerydefs); err != nil {
+		return err
+	}
+
+	// Recompile queries now that CRUD querydefs are in place
+	fmt.Println("")
+	fmt.Println("Recompiling queries...")
+	db.DBCompileCmd()
+
+	// Determine operations to generate
+	var ops []handlergen.Operation
+	if operation == "all" {
+		ops = handlergen.AllOperations()
+	} else {
+		op := handlergen.Operation(operation)
+		ops = []handlergen.Operation{op}
+	}
+
+	err = forEachTable(os.Stdout, tableNames, jobs, func(tableName string, out io.Writer) error {
+		return env.writeHandlers(tableName, operation, ops, out)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Compile the registry
+	fmt.Println("")
+	fmt.Println("Compiling handler registry...")
+	if err := registry.Run(roots.ShipqRoot, roots.GoModRoot); err != nil {
+		return fmt.Errorf("failed to compile registry: %w", err)
+	}
+
+	fmt.Println("")
+	fmt.Printf("Done! Generated %s for %s.\n", operation, strings.Join(tableNames, ", "))
+
+	return nil
+}
+
+// resourceEnv holds the project settings shared by every table a
+// `shipq resource` run generates.
+type resourceEnv struct {
+	roots           *project.ProjectRoots
+	modulePath      string
+	plan            *migrate.MigrationPlan
+	crudCfg         *crud.CRUDConfig
+	requireAuth     bool
+	exposeEmail     bool
+	dialect         string
+	testDatabaseURL string
+}
+
+// tableOpts returns the [crud.<table>] options for tableName.
+func (env resourceEnv) tableOpts(tableName string) portsqlcodegen.CRUDOptions {
+	if env.crudCfg == nil {
+		return portsqlcodegen.CRUDOptions{}
+	}
+	return env.crudCfg.TableOpts[tableName]
+}
+
+// resolveResourceTables returns the tables a `shipq resource` run targets.
+// A target of the form @<label> selects every non-junction table carrying
+// the label, minus those carrying one of excludeLabels; anything else names
+// a single table.
+func resolveResourceTables(tables map[string]ddl.Table, target string, excludeLabels []string) ([]string, error) {
+	if label, ok := strings.CutPrefix(target, "@"); ok {
+		var names []string
+		for _, name := range migrate.SelectTables(tables, []string{label}, excludeLabels) {
+			if !tables[name].IsJunctionTable {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no tables carry label %q.\nRun 'shipq schema labels' to list labels.", label)
 		}
+		return names, nil
+	}
+
+	table, ok := tables[target]
+	if !ok {
+		return nil, fmt.Errorf("table %q not found in schema.json.\nAvailable tables: %s",
+			target, strings.Join(handlergen.SortedTableNames(tables), ", "))
 	}
+	if table.IsJunctionTable {
+		return nil, fmt.Errorf("table %q is a junction table and doesn't need handlers", target)
+	}
+	return []string{target}, nil
+}
 
-	// Generate CRUD querydefs (DSL code the user can inspect and customise)
-	querydefsDir := filepath.Join(roots.ShipqRoot, "querydefs", tableName)
+// writeQuerydefs generates querydefs/<table>/queries.go, reporting the
+// files it writes to out.
+func (env resourceEnv) writeQuerydefs(tableName string, out io.Writer) error {
+	opts := env.tableOpts(tableName)
+	querydefsDir := filepath.Join(env.roots.ShipqRoot, "querydefs", tableName)
 	if err := codegen.EnsureDir(querydefsDir); err != nil {
 		return fmt.Errorf("failed to create querydefs directory: %w", err)
 	}
 
 	querydefsCfg := crudquerydefs.Config{
-		ModulePath:  modulePath,
+		ModulePath:  env.modulePath,
 		TableName:   tableName,
-		Table:       table,
-		ScopeColumn: scopeColumn,
-		Schema:      plan.Schema.Tables,
-		ExposeEmail: exposeEmail,
+		Table:       env.plan.Schema.Tables[tableName],
+		ScopeColumn: opts.ScopeColumn,
+		Schema:      env.plan.Schema.Tables,
+		ExposeEmail: env.exposeEmail,
+		NearColumn:  opts.NearColumn,
+
+		MaxRowsPerScope: opts.MaxRowsPerScope,
+		StateColumns:    opts.StateColumns,
+		Filters:         opts.Filters,
+		Sorts:           opts.Sorts,
+		NoSoftDelete:    opts.NoSoftDelete,
 	}
 	querydefsBytes, err := crudquerydefs.GenerateCRUDQueryDefs(querydefsCfg)
 	if err != nil {
@@ -171,22 +310,24 @@ func generateResource(tableName, operation string, isPublic bool) error {
 		return fmt.Errorf("failed to write querydefs: %w", err)
 	}
 	if querydefsChanged {
-		fmt.Printf("  Generated querydefs/%s/queries.go\n", tableName)
+		fmt.Fprintf(out, "  Generated querydefs/%s/queries.go\n", tableName)
 	}
+	return nil
+}
 
-	// Recompile queries now that CRUD querydefs are in place
-	fmt.Println("")
-	fmt.Println("Recompiling queries...")
-	db.DBCompileCmd()
-
-	// Determine operations to generate
-	var ops []handlergen.Operation
-	if operation == "all" {
-		ops = handlergen.AllOperations()
-	} else {
-		op := handlergen.Operation(operation)
-		ops = []handlergen.Operation{op}
-	}
+// writeHandlers generates the handlers, register.go, fixture and tests of
+// ops under api/<table>, reporting the files it writes to out.
+func (env resourceEnv) writeHandlers(tableName, operation string, ops []handlergen.Operation, out io.Writer) error {
+	roots, modulePath, plan := env.roots, env.modulePath, env.plan
+	requireAuth, exposeEmail := env.requireAuth, env.exposeEmail
+	dialect, testDatabaseURL := env.dialect, env.testDatabaseURL
+	table := plan.Schema.Tables[tableName]
+	opts := env.tableOpts(tableName)
+	scopeColumn := opts.ScopeColumn
+	deprecated, sunset := opts.Deprecated, opts.Sunset
+	importMaxRows := opts.ImportMaxRows
+	nearColumn := opts.NearColumn
+	defaults := opts.Defaults
 
 	cfg := handlergen.HandlerGenConfig{
 		ModulePath:  modulePath,
@@ -196,6 +337,35 @@ func generateResource(tableName, operation string, isPublic bool) error {
 		ScopeColumn: scopeColumn,
 		RequireAuth: requireAuth,
 		ExposeEmail: exposeEmail,
+		Deprecated:  deprecated,
+		Sunset:      sunset,
+
+		ImportMaxRows: importMaxRows,
+		NearColumn:    nearColumn,
+		Defaults:      defaults,
+		ListCacheMs:   opts.ListCacheMs,
+		PathSegment:   opts.PathSegment,
+		Outbox:        opts.Outbox,
+		Hooks:         opts.Hooks,
+		Filters:       opts.Filters,
+		Sorts:         opts.Sorts,
+		NoSoftDelete:  opts.NoSoftDelete,
+		Restore:       opts.Restore,
+
+		MaxRowsPerScope: opts.MaxRowsPerScope,
+		QuotaStatus:     opts.QuotaStatus,
+
+		JSONCase:  opts.JSONCase,
+		JSONNames: opts.JSONNames,
+	}
+
+	// [crud.<table>] bulk = true adds the bulk create endpoint alongside create
+	if opts.Bulk && slices.Contains(ops, handlergen.OpCreate) {
+		ops = append(slices.Clone(ops), handlergen.OpBulkCreate)
+	}
+	// [crud.<table>] restore = true adds the restore endpoint alongside delete
+	if opts.Restore && slices.Contains(ops, handlergen.OpDelete) {
+		ops = append(slices.Clone(ops), handlergen.OpRestore)
 	}
 
 	// Create api/<table> directory
@@ -205,8 +375,8 @@ func generateResource(tableName, operation string, isPublic bool) error {
 	}
 
 	// Generate handler files for each operation
-	fmt.Println("")
-	fmt.Printf("Generating %s handlers for %s...\n", operation, tableName)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Generating %s handlers for %s...\n", operation, tableName)
 	relations := handlergen.AnalyzeRelationships(table, plan.Schema.Tables)
 
 	for _, op := range ops {
@@ -222,10 +392,30 @@ func generateResource(tableName, operation string, isPublic bool) error {
 			return fmt.Errorf("failed to write %s: %w", filePath, err)
 		}
 		if changed {
-			fmt.Printf("  Generated %s\n", filename)
+			fmt.Fprintf(out, "  Generated %s\n", filename)
 		}
 	}
 
+	// [crud.<table>] import_job = true adds the worker channel that imports
+	// uploaded files in the background
+	if opts.ImportJob && slices.Contains(ops, handlergen.OpImport) {
+		if err := env.writeImportJob(cfg, out); err != nil {
+			return err
+		}
+	}
+
+	// Generate helpers.go with classifyDBError, which every generated
+	// handler uses to map database errors to HTTP errors.
+	handlerHelpersBytes, err := handlergen.GenerateHelpersFile(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to generate helpers.go: %w", err)
+	}
+	if changed, err := codegen.WriteFileIfChanged(filepath.Join(apiDir, "helpers.go"), handlerHelpersBytes); err != nil {
+		return fmt.Errorf("failed to write helpers.go: %w", err)
+	} else if changed {
+		fmt.Fprintln(out, "  Generated helpers.go")
+	}
+
 	// Generate types.go for shared type declarations (e.g. AuthorEmbed)
 	// when the table has author_account_id, so the struct is defined once
 	// instead of being redeclared in every handler file.
@@ -240,13 +430,13 @@ func generateResource(tableName, operation string, isPublic bool) error {
 			return fmt.Errorf("failed to write types.go: %w", err)
 		}
 		if changed {
-			fmt.Println("  Generated types.go")
+			fmt.Fprintln(out, "  Generated types.go")
 		}
 	}
 
 	// Generate/update register.go
 	registerPath := filepath.Join(apiDir, "register.go")
-	registerBytes, err := handlergen.GenerateIncrementalRegister(registerPath, modulePath, tableName, ops, requireAuth)
+	registerBytes, err := handlergen.GenerateIncrementalRegister(registerPath, modulePath, tableName, opts.PathSegment, ops, requireAuth, deprecated, sunset)
 	if err != nil {
 		return fmt.Errorf("failed to generate register.go: %w", err)
 	}
@@ -255,7 +445,7 @@ func generateResource(tableName, operation string, isPublic bool) error {
 		return fmt.Errorf("failed to write register.go: %w", err)
 	}
 	if changed {
-		fmt.Println("  Generated register.go")
+		fmt.Fprintln(out, "  Generated register.go")
 	}
 
 	// Write .shipq-no-regen marker
@@ -268,7 +458,7 @@ func generateResource(tableName, operation string, isPublic bool) error {
 	}
 
 	// Generate fixture package
-	fmt.Println("  Generating fixture...")
+	fmt.Fprintln(out, "  Generating fixture...")
 	fixtureDir := filepath.Join(apiDir, "fixture")
 	if err := codegen.EnsureDir(fixtureDir); err != nil {
 		return fmt.Errorf("failed to create fixture directory: %w", err)
@@ -292,7 +482,7 @@ func generateResource(tableName, operation string, isPublic bool) error {
 	}
 
 	// Generate per-operation test files
-	fmt.Println("  Generating tests...")
+	fmt.Fprintln(out, "  Generating tests...")
 	testDir := filepath.Join(roots.ShipqRoot, "api", tableName, "spec")
 	if err := codegen.EnsureDir(testDir); err != nil {
 		return fmt.Errorf("failed to create test directory: %w", err)
@@ -307,6 +497,7 @@ func generateResource(tableName, operation string, isPublic bool) error {
 		Dialect:         dialect,
 		TestDatabaseURL: testDatabaseURL,
 		ScopeColumn:     scopeColumn,
+		Defaults:        defaults,
 	}
 
 	// Generate shared helpers file (parseDatabaseURL, isLocalhostURL)
@@ -329,6 +520,7 @@ func generateResource(tableName, operation string, isPublic bool) error {
 		Dialect:         dialect,
 		TestDatabaseURL: testDatabaseURL,
 		ScopeColumn:     scopeColumn,
+		Defaults:        defaults,
 	}
 
 	for _, op := range ops {
@@ -342,19 +534,34 @@ func generateResource(tableName, operation string, isPublic bool) error {
 		if _, err := codegen.WriteFileIfChanged(testFilePath, testBytes); err != nil {
 			return fmt.Errorf("failed to write %s: %w", testFilePath, err)
 		}
-		fmt.Printf("  Generated %s\n", testFilename)
+		fmt.Fprintf(out, "  Generated %s\n", testFilename)
 	}
 
-	// Compile the registry
-	fmt.Println("")
-	fmt.Println("Compiling handler registry...")
-	if err := registry.Run(roots.ShipqRoot, roots.GoModRoot); err != nil {
-		return fmt.Errorf("failed to compile registry: %w", err)
-	}
-
-	fmt.Println("")
-	fmt.Printf("Done! Generated %s for %s.\n", operation, tableName)
+	return nil
+}
 
+// writeImportJob generates channels/<table>_import/register.go, the worker
+// channel of the import job mode, reporting the file it writes to out.
+func (env resourceEnv) writeImportJob(cfg handlergen.HandlerGenConfig, out io.Writer) error {
+	if env.dialect == "" {
+		return fmt.Errorf("import_job requires [db] database_url in shipq.ini to pick the worker's query runner")
+	}
+	name := handlergen.ImportJobChannel(cfg.TableName)
+	jobBytes, err := handlergen.GenerateImportJob(cfg, env.dialect)
+	if err != nil {
+		return fmt.Errorf("failed to generate import job: %w", err)
+	}
+	channelDir := filepath.Join(env.roots.ShipqRoot, "channels", name)
+	if err := codegen.EnsureDir(channelDir); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", channelDir, err)
+	}
+	changed, err := codegen.WriteFileIfChanged(filepath.Join(channelDir, "register.go"), jobBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write import job: %w", err)
+	}
+	if changed {
+		fmt.Fprintf(out, "  Generated channels/%s/register.go (run 'shipq workers compile' to register it)\n", name)
+	}
 	return nil
 }
 
@@ -363,15 +570,21 @@ func generateSingleHandler(cfg handlergen.HandlerGenConfig, op handlergen.Operat
 	case handlergen.OpCreate:
 		return handlergen.GenerateCreateHandler(cfg, relations)
 	case handlergen.OpGetOne:
-		// Pass nil relations: the query runner does not yet support
-		// WithRelations, so we cannot embed relation data in get-one.
-		return handlergen.GenerateGetOneHandler(cfg, nil)
+		return handlergen.GenerateGetOneHandler(cfg, relations)
 	case handlergen.OpList:
 		return handlergen.GenerateListHandler(cfg, relations)
 	case handlergen.OpUpdate:
 		return handlergen.GenerateUpdateHandler(cfg, relations)
 	case handlergen.OpDelete:
 		return handlergen.GenerateSoftDeleteHandler(cfg, relations)
+	case handlergen.OpImport:
+		return handlergen.GenerateImportHandler(cfg, relations)
+	case handlergen.OpNear:
+		return handlergen.GenerateNearHandler(cfg, relations)
+	case handlergen.OpBulkCreate:
+		return handlergen.GenerateBulkCreateHandler(cfg, relations)
+	case handlergen.OpRestore:
+		return handlergen.GenerateRestoreHandler(cfg, relations)
 	default:
 		return nil, fmt.Errorf("unknown operation: %s", op)
 	}
@@ -389,6 +602,14 @@ func generateSingleTest(cfg resourcegen.PerOpTestGenConfig, op handlergen.Operat
 		return resourcegen.GenerateUpdateTest(cfg)
 	case handlergen.OpDelete:
 		return resourcegen.GenerateSoftDeleteTest(cfg)
+	case handlergen.OpImport:
+		return resourcegen.GenerateImportTest(cfg)
+	case handlergen.OpNear:
+		return resourcegen.GenerateNearTest(cfg)
+	case handlergen.OpBulkCreate:
+		return resourcegen.GenerateBulkCreateTest(cfg)
+	case handlergen.OpRestore:
+		return resourcegen.GenerateRestoreTest(cfg)
 	default:
 		return nil, fmt.Errorf("unknown operation: %s", op)
 	}
@@ -0,0 +1,46 @@
+package resource
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestResolveResourceTables(t *testing.T) {
+	tables := map[string]ddl.Table{
+		"invoices":       {Name: "invoices", Labels: []string{"billing"}},
+		"ledger":         {Name: "ledger", Labels: []string{"billing", "internal"}},
+		"invoice_credit": {Name: "invoice_credit", Labels: []string{"billing"}, IsJunctionTable: true},
+		"posts":          {Name: "posts"},
+	}
+
+	for _, tt := range []struct {
+		target  string
+		exclude []string
+		want    string
+		wantErr string
+	}{
+		{target: "posts", want: "posts"},
+		{target: "@billing", want: "invoices,ledger"},
+		{target: "@billing", exclude: []string{"internal"}, want: "invoices"},
+		{target: "@internal", exclude: []string{"internal"}, wantErr: `no tables carry label "internal"`},
+		{target: "missing", wantErr: "Available tables: invoice_credit, invoices, ledger, posts"},
+		{target: "invoice_credit", wantErr: "junction table"},
+	} {
+		got, err := resolveResourceTables(tables, tt.target, tt.exclude)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.target, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.target, err)
+			continue
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%s (exclude %v) = %v, want %s", tt.target, tt.exclude, got, tt.want)
+		}
+	}
+}
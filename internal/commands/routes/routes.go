@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/project"
+	"github.com/shipq/shipq/registry"
+)
+
+// RoutesCmd implements the "shipq routes" command.
+// It lists the routes recorded by the last `shipq handler compile`. With
+// --deprecated only deprecated routes are shown, together with their sunset
+// date, so the removal schedule can be reviewed before it is announced to
+// clients.
+func RoutesCmd(args []string) {
+	deprecatedOnly := false
+	for _, arg := range args {
+		switch arg {
+		case "--deprecated":
+			deprecatedOnly = true
+		default:
+			cli.Fatal(fmt.Sprintf("unknown flag for 'shipq routes': %s", arg))
+		}
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	handlers, err := registry.ReadRoutesManifest(roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to load routes", err)
+	}
+
+	fmt.Fprint(os.Stdout, FormatRoutes(handlers, deprecatedOnly, time.Now()))
+}
+
+// FormatRoutes renders handlers as an aligned table. When deprecatedOnly is
+// true, non-deprecated routes are skipped and a SUNSET column shows the
+// removal date and how many days remain relative to now.
+func FormatRoutes(handlers []codegen.SerializedHandlerInfo, deprecatedOnly bool, now time.Time) string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	if deprecatedOnly {
+		fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER\tSUNSET")
+	} else {
+		fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER\tAUTH\tDEPRECATED\tFEATURE")
+	}
+
+	count := 0
+	for _, h := range handlers {
+		if deprecatedOnly && !h.Deprecated {
+			continue
+		}
+		count++
+		handlerName := h.FuncName
+		if h.PackagePath != "" {
+			handlerName = path.Base(h.PackagePath) + "." + h.FuncName
+		}
+		if deprecatedOnly {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", h.Method, h.Path, handlerName, describeSunset(h.Sunset, now))
+			continue
+		}
+		auth := "-"
+		if h.RequireAuth {
+			auth = "required"
+		} else if h.OptionalAuth {
+			auth = "optional"
+		}
+		deprecated := "-"
+		if h.Deprecated {
+			deprecated = "yes"
+			if h.Sunset != "" {
+				deprecated = "sunset " + h.Sunset
+			}
+		}
+		feature := "-"
+		if h.Feature != "" {
+			feature = h.Feature
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", h.Method, h.Path, handlerName, auth, deprecated, feature)
+	}
+	tw.Flush()
+
+	if deprecatedOnly && count == 0 {
+		return "No deprecated routes.\n"
+	}
+	return buf.String()
+}
+
+// describeSunset formats a YYYY-MM-DD sunset date with the number of days
+// remaining (or elapsed) relative to now.
+func describeSunset(sunset string, now time.Time) string {
+	if sunset == "" {
+		return "(not scheduled)"
+	}
+	t, err := time.Parse("2006-01-02", sunset)
+	if err != nil {
+		return sunset
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(t.Sub(today).Hours() / 24)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%s (in %d days)", sunset, days)
+	case days == 0:
+		return sunset + " (today)"
+	default:
+		return fmt.Sprintf("%s (passed %d days ago)", sunset, -days)
+	}
+}
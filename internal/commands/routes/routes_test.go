@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+var testHandlers = []codegen.SerializedHandlerInfo{
+	{Method: "GET", Path: "/posts", FuncName: "ListPosts", PackagePath: "myapp/api/posts", Feature: "post-feed"},
+	{Method: "GET", Path: "/posts/:id", FuncName: "GetPost", PackagePath: "myapp/api/posts", OptionalAuth: true, Deprecated: true},
+	{Method: "DELETE", Path: "/posts/:id", FuncName: "SoftDeletePost", PackagePath: "myapp/api/posts", RequireAuth: true, Deprecated: true, Sunset: "2027-01-31"},
+}
+
+func TestFormatRoutes_All(t *testing.T) {
+	out := FormatRoutes(testHandlers, false, time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	want := "METHOD  PATH        HANDLER               AUTH      DEPRECATED         FEATURE\n" +
+		"GET     /posts      posts.ListPosts       -         -                  post-feed\n" +
+		"GET     /posts/:id  posts.GetPost         optional  yes                -\n" +
+		"DELETE  /posts/:id  posts.SoftDeletePost  required  sunset 2027-01-31  -\n"
+	if out != want {
+		t.Errorf("FormatRoutes() =\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestFormatRoutes_DeprecatedOnly(t *testing.T) {
+	out := FormatRoutes(testHandlers, true, time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	if strings.Contains(out, "ListPosts") {
+		t.Errorf("non-deprecated route should be skipped:\n%s", out)
+	}
+	if !strings.Contains(out, "(not scheduled)") {
+		t.Errorf("deprecated route without sunset should say so:\n%s", out)
+	}
+	if !strings.Contains(out, "2027-01-31 (in 30 days)") {
+		t.Errorf("expected days remaining until sunset:\n%s", out)
+	}
+}
+
+func TestFormatRoutes_NoDeprecatedRoutes(t *testing.T) {
+	out := FormatRoutes(testHandlers[:1], true, time.Now())
+	if out != "No deprecated routes.\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestDescribeSunset_Passed(t *testing.T) {
+	got := describeSunset("2027-01-31", time.Date(2027, 2, 2, 0, 0, 0, 0, time.UTC))
+	if got != "2027-01-31 (passed 2 days ago)" {
+		t.Errorf("describeSunset() = %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/project"
+)
+
+// schemaJSONPath is where `shipq migrate up` records the migration plan,
+// relative to the shipq root.
+var schemaJSONPath = filepath.Join("shipq", "db", "migrate", "schema.json")
+
+// ChangelogCmd implements "shipq schema changelog <from> [<to>] [--json]".
+// Each side is either a path to a schema.json snapshot or a git ref, in
+// which case the project's schema.json at that ref is used. When <to> is
+// omitted the working tree's schema.json is compared against <from>.
+//
+// The changelog is printed as Markdown for release notes, or with --json
+// as a migrate.SchemaDiff for tooling.
+func ChangelogCmd(args []string) {
+	asJSON := false
+	var refs []string
+	for _, arg := range args {
+		switch {
+		case arg == "--json":
+			asJSON = true
+		case arg == "-h" || arg == "--help":
+			fmt.Print(changelogUsage)
+			os.Exit(0)
+		case strings.HasPrefix(arg, "-"):
+			cli.Fatal(fmt.Sprintf("unknown flag for 'shipq schema changelog': %s", arg))
+		default:
+			refs = append(refs, arg)
+		}
+	}
+	if len(refs) == 0 || len(refs) > 2 {
+		fmt.Fprint(os.Stderr, changelogUsage)
+		os.Exit(1)
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	from, err := loadPlan(roots.ShipqRoot, refs[0])
+	if err != nil {
+		cli.FatalErr("failed to load "+refs[0], err)
+	}
+	var to *migrate.MigrationPlan
+	if len(refs) == 2 {
+		to, err = loadPlan(roots.ShipqRoot, refs[1])
+	} else {
+		to, err = loadPlanFile(filepath.Join(roots.ShipqRoot, schemaJSONPath))
+	}
+	if err != nil {
+		cli.FatalErr("failed to load schema", err)
+	}
+
+	diff := migrate.DiffPlans(from, to)
+	if asJSON {
+		out, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			cli.FatalErr("failed to encode changelog", err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	fmt.Print(diff.Markdown())
+}
+
+const changelogUsage = `Usage: shipq schema changelog <from> [<to>] [--json]
+
+Print the schema changes between two releases.
+
+<from> and <to> are paths to schema.json snapshots or git refs (tags,
+branches, commits). <to> defaults to the working tree's schema.json.
+
+Options:
+  --json   Print a machine-readable diff instead of Markdown
+
+Examples:
+  shipq schema changelog v1.2.0
+  shipq schema changelog v1.2.0 v1.3.0 > CHANGELOG-schema.md
+  shipq schema changelog old/schema.json new/schema.json --json
+`
+
+// loadPlan loads a migration plan from ref. An existing file is read as a
+// schema.json snapshot; anything else is resolved as a git ref in the
+// repository containing shipqRoot. A ref that predates schema.json yields
+// an empty plan, so the first release's changelog lists every table.
+func loadPlan(shipqRoot, ref string) (*migrate.MigrationPlan, error) {
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return loadPlanFile(ref)
+	}
+
+	if _, err := git(shipqRoot, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("%q is neither a file nor a git ref", ref)
+	}
+	// "./" makes the path relative to shipqRoot rather than the repo root.
+	object := ref + ":./" + filepath.ToSlash(schemaJSONPath)
+	if _, err := git(shipqRoot, "cat-file", "-e", object); err != nil {
+		return &migrate.MigrationPlan{}, nil
+	}
+	data, err := git(shipqRoot, "show", object)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := migrate.PlanFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema.json at %s: %w", ref, err)
+	}
+	return plan, nil
+}
+
+func loadPlanFile(path string) (*migrate.MigrationPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := migrate.PlanFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return plan, nil
+}
+
+// git runs a git subcommand in dir and returns its stdout.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// initRepo creates a git repository whose first commit has no schema.json
+// (tag v0), second adds a users table (tag v1) and third adds posts (v2).
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(json string) {
+		t.Helper()
+		path := filepath.Join(dir, schemaJSONPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(json), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "shipq.ini"), []byte("[db]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "-A")
+	run("commit", "-qm", "init")
+	run("tag", "v0")
+
+	write(`{"schema":{"tables":{"users":{"name":"users","columns":[{"name":"id","type":"bigint"}]}}},"migrations":[{"name":"create_users"}]}`)
+	run("add", "-A")
+	run("commit", "-qm", "users")
+	run("tag", "v1")
+
+	write(`{"schema":{"tables":{"users":{"name":"users","columns":[{"name":"id","type":"bigint"}]},"posts":{"name":"posts","columns":[{"name":"id","type":"bigint"}]}}},"migrations":[{"name":"create_users"},{"name":"create_posts"}]}`)
+	run("add", "-A")
+	run("commit", "-qm", "posts")
+	run("tag", "v2")
+	return dir
+}
+
+func TestLoadPlan_GitRefs(t *testing.T) {
+	dir := initRepo(t)
+
+	v1, err := loadPlan(dir, "v1")
+	if err != nil {
+		t.Fatalf("loadPlan(v1) error = %v", err)
+	}
+	if len(v1.Schema.Tables) != 1 {
+		t.Errorf("v1 tables = %v", v1.Schema.Tables)
+	}
+
+	v2, err := loadPlan(dir, "v2")
+	if err != nil {
+		t.Fatalf("loadPlan(v2) error = %v", err)
+	}
+	if _, ok := v2.Schema.Tables["posts"]; !ok {
+		t.Errorf("v2 should have posts, got %v", v2.Schema.Tables)
+	}
+}
+
+func TestLoadPlan_RefBeforeSchemaIsEmpty(t *testing.T) {
+	dir := initRepo(t)
+
+	plan, err := loadPlan(dir, "v0")
+	if err != nil {
+		t.Fatalf("loadPlan(v0) error = %v", err)
+	}
+	if len(plan.Schema.Tables) != 0 || len(plan.Migrations) != 0 {
+		t.Errorf("expected empty plan, got %+v", plan)
+	}
+}
+
+func TestLoadPlan_File(t *testing.T) {
+	dir := initRepo(t)
+
+	plan, err := loadPlan(dir, filepath.Join(dir, schemaJSONPath))
+	if err != nil {
+		t.Fatalf("loadPlan(file) error = %v", err)
+	}
+	if len(plan.Schema.Tables) != 2 {
+		t.Errorf("tables = %v", plan.Schema.Tables)
+	}
+}
+
+func TestLoadPlan_UnknownRef(t *testing.T) {
+	dir := initRepo(t)
+
+	if _, err := loadPlan(dir, "no-such-tag"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
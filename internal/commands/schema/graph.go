@@ -0,0 +1,291 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/project"
+)
+
+// Graph is a directed dependency graph of tables or migrations: each edge
+// points from a node to a node it depends on.
+type Graph struct {
+	Name  string // "tables" or "migrations"
+	Nodes []string
+	Edges []GraphEdge
+	// Problems lists dependency data that can't be satisfied, such as a
+	// migration depending on a later one.
+	Problems []string
+}
+
+// GraphEdge is one dependency of a Graph.
+type GraphEdge struct {
+	From  string
+	To    string
+	Label string
+}
+
+// GraphCmd implements "shipq schema graph [--migrations] [--format dot|mermaid]
+// [--svg <file>]". It prints the foreign key dependencies between the tables
+// of the working tree's schema.json or, with --migrations, the ordering
+// constraints between migrations. Problems in the dependency data are
+// reported on stderr and make the command exit 1.
+func GraphCmd(args []string) {
+	format := "mermaid"
+	migrations := false
+	svgPath := ""
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--migrations":
+			migrations = true
+		case arg == "--format" && i+1 < len(args):
+			i++
+			format = args[i]
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case arg == "--svg" && i+1 < len(args):
+			i++
+			svgPath = args[i]
+		case strings.HasPrefix(arg, "--svg="):
+			svgPath = strings.TrimPrefix(arg, "--svg=")
+		case arg == "-h" || arg == "--help":
+			fmt.Print(graphUsage)
+			os.Exit(0)
+		default:
+			cli.Fatal(fmt.Sprintf("unknown argument for 'shipq schema graph': %s", arg))
+		}
+	}
+	if format != "dot" && format != "mermaid" {
+		cli.Fatal(fmt.Sprintf("unknown graph format %q (supported: dot, mermaid)", format))
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+	plan, err := loadPlanFile(filepath.Join(roots.ShipqRoot, schemaJSONPath))
+	if err != nil {
+		cli.FatalErr("failed to load schema", err)
+	}
+
+	graph := buildTableGraph(plan)
+	if migrations {
+		graph = buildMigrationGraph(plan)
+	}
+
+	if svgPath != "" {
+		if err := renderSVG(graph.DOT(), svgPath); err != nil {
+			cli.FatalErr("failed to render SVG", err)
+		}
+		cli.Successf("Wrote %s", svgPath)
+	} else if format == "dot" {
+		fmt.Print(graph.DOT())
+	} else {
+		fmt.Print(graph.Mermaid())
+	}
+
+	for _, problem := range graph.Problems {
+		cli.Warn(problem)
+	}
+	if len(graph.Problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+const graphUsage = `Usage: shipq schema graph [--migrations] [--format dot|mermaid] [--svg <file>]
+
+Print the foreign key dependencies between the tables of schema.json: an
+edge points from a table to each table it references.
+
+Options:
+  --migrations        Graph the migrations instead: an edge points from a
+                      migration to the earlier migration that created a
+                      table it alters or references
+  --format <format>   mermaid (default) or dot
+  --svg <file>        Render the graph to an SVG file with Graphviz (dot)
+
+Dependencies that can't be satisfied, such as a migration depending on a
+later one or a foreign key to a table that doesn't exist, are reported on
+stderr and make the command exit 1.
+`
+
+// buildTableGraph returns the foreign key graph of plan's tables. A
+// reference to a table outside the schema is a problem; a table may
+// reference itself.
+func buildTableGraph(plan *migrate.MigrationPlan) Graph {
+	g := Graph{Name: "tables"}
+	for name := range plan.Schema.Tables {
+		g.Nodes = append(g.Nodes, name)
+	}
+	sort.Strings(g.Nodes)
+	for _, name := range g.Nodes {
+		for _, col := range plan.Schema.Tables[name].Columns {
+			parent := referencedTable(col.References, col.ForeignKey)
+			if parent == "" {
+				continue
+			}
+			if _, ok := plan.Schema.Tables[parent]; !ok {
+				g.Problems = append(g.Problems, fmt.Sprintf("%s.%s references unknown table %q", name, col.Name, parent))
+				continue
+			}
+			g.Edges = append(g.Edges, GraphEdge{From: name, To: parent, Label: col.Name})
+		}
+	}
+	return g
+}
+
+// referencedTable returns the table named by a References or ForeignKey
+// ("table" or "table.column") value, or "".
+func referencedTable(references, foreignKey string) string {
+	ref := references
+	if ref == "" {
+		ref = foreignKey
+	}
+	table, _, _ := strings.Cut(ref, ".")
+	return table
+}
+
+// Statements of a migration's SQL that name the table they create or change.
+var (
+	createTableSQL = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?["` + "`" + `\[]?(\w+)`)
+	touchTableSQL  = regexp.MustCompile(`(?i)(?:ALTER\s+TABLE|DROP\s+TABLE(?:\s+IF\s+EXISTS)?|INDEX\s+(?:IF\s+NOT\s+EXISTS\s+)?["` + "`" + `\[]?\w+["` + "`" + `\]]?\s+ON)\s+["` + "`" + `\[]?(\w+)`)
+)
+
+// buildMigrationGraph returns the ordering constraints between plan's
+// migrations, read from their SQL: a migration depends on the migration
+// that created each table it alters, indexes or drops, and, for each
+// foreign key column it adds, on the one that created the referenced
+// table. A migration that depends on a later one, or changes a table no
+// migration created, is a problem.
+func buildMigrationGraph(plan *migrate.MigrationPlan) Graph {
+	g := Graph{Name: "migrations"}
+	creator := make(map[string]int) // table → index of the migration creating it
+	for i, m := range plan.Migrations {
+		g.Nodes = append(g.Nodes, m.Name)
+		for _, match := range createTableSQL.FindAllStringSubmatch(migrationSQL(m), -1) {
+			if _, ok := creator[match[1]]; !ok {
+				creator[match[1]] = i
+			}
+		}
+	}
+
+	seen := make(map[GraphEdge]bool)
+	depend := func(i int, table, reason string) {
+		c, ok := creator[table]
+		if !ok {
+			if _, inSchema := plan.Schema.Tables[table]; inSchema {
+				g.Problems = append(g.Problems, fmt.Sprintf("%s %s table %q, which no migration creates", plan.Migrations[i].Name, reason, table))
+			}
+			return
+		}
+		if c == i {
+			return
+		}
+		edge := GraphEdge{From: plan.Migrations[i].Name, To: plan.Migrations[c].Name, Label: table}
+		if seen[edge] {
+			return
+		}
+		seen[edge] = true
+		g.Edges = append(g.Edges, edge)
+		if c > i {
+			g.Problems = append(g.Problems, fmt.Sprintf("%s %s table %q before %s creates it", edge.From, reason, table, edge.To))
+		}
+	}
+
+	// A foreign key column is added by the first statement creating or
+	// altering its table that names it.
+	added := make(map[string]bool) // "table.column"
+	for i, m := range plan.Migrations {
+		for _, stmt := range strings.Split(migrationSQL(m), ";") {
+			table := ""
+			if match := createTableSQL.FindStringSubmatch(stmt); match != nil {
+				table = match[1]
+			}
+			if match := touchTableSQL.FindStringSubmatch(stmt); match != nil {
+				table = match[1]
+				depend(i, table, "changes")
+			}
+			for _, col := range plan.Schema.Tables[table].Columns {
+				parent := referencedTable(col.References, col.ForeignKey)
+				if parent == "" || parent == table || added[table+"."+col.Name] || !containsWord(stmt, col.Name) {
+					continue
+				}
+				added[table+"."+col.Name] = true
+				depend(i, parent, "references")
+			}
+		}
+	}
+	return g
+}
+
+// containsWord reports whether word appears in s delimited by non-word
+// characters, as a column name in a statement.
+func containsWord(s, word string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(word) + `\b`).MatchString(s)
+}
+
+// migrationSQL returns the SQL of m the graph is read from: its Postgres
+// instructions, or those of the first other dialect it has.
+func migrationSQL(m migrate.Migration) string {
+	for _, sql := range []string{m.Instructions.Postgres, m.Instructions.Sqlite, m.Instructions.MySQL, m.Instructions.MSSQL} {
+		if sql != "" {
+			return sql
+		}
+	}
+	return ""
+}
+
+// DOT renders the graph in Graphviz's DOT language.
+func (g Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", g.Name)
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %q;\n", n)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.From, e.To, e.Label)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Node IDs are the
+// node's position, since migration names start with a digit.
+func (g Graph) Mermaid() string {
+	id := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		id[n] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id[n], n)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", id[e.From], e.Label, id[e.To])
+	}
+	return b.String()
+}
+
+// renderSVG renders dot to an SVG file at path with Graphviz.
+func renderSVG(dot, path string) error {
+	if _, err := exec.LookPath("dot"); err != nil {
+		return fmt.Errorf("--svg needs the dot command of Graphviz: %w", err)
+	}
+	cmd := exec.Command("dot", "-Tsvg", "-o", path)
+	cmd.Stdin = strings.NewReader(dot)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("dot: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+)
+
+// graphPlan creates users, then posts referencing users, then adds a
+// column to users.
+func graphPlan(t *testing.T) *migrate.MigrationPlan {
+	t.Helper()
+	plan := &migrate.MigrationPlan{}
+	plan.SetCurrentMigration("20260101000000_create_users")
+	if _, err := plan.AddTable("users", func(tb *ddl.TableBuilder) error {
+		tb.String("email")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	users, _ := plan.Table("users")
+	plan.SetCurrentMigration("20260102000000_create_posts")
+	if _, err := plan.AddTable("posts", func(tb *ddl.TableBuilder) error {
+		tb.String("title")
+		tb.Bigint("author_id").References(users)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	plan.SetCurrentMigration("20260103000000_add_users_name")
+	if err := plan.UpdateTable("users", func(ab *ddl.AlterTableBuilder) error {
+		ab.String("name")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestBuildTableGraph(t *testing.T) {
+	g := buildTableGraph(graphPlan(t))
+
+	want := "flowchart LR\n" +
+		"  n0[\"posts\"]\n" +
+		"  n1[\"users\"]\n" +
+		"  n0 -->|author_id| n1\n"
+	if got := g.Mermaid(); got != want {
+		t.Errorf("Mermaid() = %q, want %q", got, want)
+	}
+	if dot := g.DOT(); !strings.Contains(dot, `"posts" -> "users" [label="author_id"];`) {
+		t.Errorf("DOT() missing the posts -> users edge:\n%s", dot)
+	}
+	if len(g.Problems) > 0 {
+		t.Errorf("unexpected problems: %v", g.Problems)
+	}
+
+	plan := graphPlan(t)
+	posts := plan.Schema.Tables["posts"]
+	posts.Columns = append(posts.Columns, ddl.ColumnDefinition{Name: "tag_id", Type: ddl.BigintType, References: "tags"})
+	plan.Schema.Tables["posts"] = posts
+	if g := buildTableGraph(plan); len(g.Problems) != 1 || !strings.Contains(g.Problems[0], `unknown table "tags"`) {
+		t.Errorf("Problems = %v, want the reference to tags", g.Problems)
+	}
+}
+
+func TestBuildMigrationGraph(t *testing.T) {
+	plan := graphPlan(t)
+	g := buildMigrationGraph(plan)
+
+	want := []GraphEdge{
+		{From: "20260102000000_create_posts", To: "20260101000000_create_users", Label: "users"},
+		{From: "20260103000000_add_users_name", To: "20260101000000_create_users", Label: "users"},
+	}
+	if len(g.Edges) != len(want) {
+		t.Fatalf("Edges = %v, want %v", g.Edges, want)
+	}
+	for i := range want {
+		if g.Edges[i] != want[i] {
+			t.Errorf("Edges[%d] = %v, want %v", i, g.Edges[i], want[i])
+		}
+	}
+	if len(g.Problems) > 0 {
+		t.Errorf("unexpected problems: %v", g.Problems)
+	}
+
+	// Running posts before users breaks the dependency.
+	plan.Migrations[0], plan.Migrations[1] = plan.Migrations[1], plan.Migrations[0]
+	g = buildMigrationGraph(plan)
+	if len(g.Problems) != 1 || !strings.Contains(g.Problems[0], `20260102000000_create_posts references table "users" before 20260101000000_create_users creates it`) {
+		t.Errorf("Problems = %v, want the out-of-order reference", g.Problems)
+	}
+}
@@ -0,0 +1,796 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen/crud"
+	"github.com/shipq/shipq/dbstrings"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/commands/migrate/generator"
+	"github.com/shipq/shipq/internal/commands/migrate/parser"
+	"github.com/shipq/shipq/internal/commands/shared"
+	shipqdag "github.com/shipq/shipq/internal/dag"
+)
+
+// ImportCmd implements "shipq schema import <openapi.json> [--write]". It
+// reads the OpenAPI document of an existing service and proposes one
+// migration per REST resource it finds, plus the `shipq resource` and
+// `shipq handler generate --action` commands that scaffold its endpoints.
+// Constructs with no shipq equivalent are reported rather than guessed at.
+// Without --write nothing is written.
+func ImportCmd(args []string) {
+	write := false
+	var specPath string
+	for _, arg := range args {
+		switch {
+		case arg == "--write":
+			write = true
+		case arg == "-h" || arg == "--help":
+			fmt.Print(importUsage)
+			os.Exit(0)
+		case strings.HasPrefix(arg, "-"):
+			cli.Fatal(fmt.Sprintf("unknown flag for 'shipq schema import': %s", arg))
+		case specPath == "":
+			specPath = arg
+		default:
+			cli.Fatal(fmt.Sprintf("unexpected argument for 'shipq schema import': %s", arg))
+		}
+	}
+	if specPath == "" {
+		cli.Fatal("usage: shipq schema import <openapi.json> [--write]")
+	}
+
+	cfg, err := shared.LoadProjectConfig()
+	if err != nil {
+		cli.FatalErr("not in a shipq project", err)
+	}
+
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		cli.FatalErr("failed to read OpenAPI document", err)
+	}
+
+	// Tables that already exist are left alone, but can still be referenced.
+	existing := map[string]bool{}
+	if plan, err := loadPlanFile(filepath.Join(cfg.ShipqRoot, schemaJSONPath)); err == nil {
+		for name := range plan.Schema.Tables {
+			existing[name] = true
+		}
+	}
+
+	proposal, err := ProposeImport(data, existing, cfg.ScopeColumn)
+	if err != nil {
+		cli.FatalErr("failed to import OpenAPI document", err)
+	}
+	fmt.Print(proposal.Text())
+
+	if len(proposal.Tables) == 0 {
+		return
+	}
+	if !write {
+		fmt.Printf("\nDry run: re-run with --write to create %d migration(s).\n", len(proposal.Tables))
+		return
+	}
+
+	if !shipqdag.CheckPrerequisites(shipqdag.CmdMigrateNew, cfg.ShipqRoot) {
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(cfg.MigrationsPath, 0755); err != nil {
+		cli.FatalErr("failed to create migrations directory", err)
+	}
+
+	scopeTable := ""
+	if cfg.ScopeColumn != "" {
+		scopeTable = crud.InferScopeTable(cfg.ScopeColumn)
+		if ini, err := inifile.ParseFile(filepath.Join(cfg.ShipqRoot, "shipq.ini")); err == nil {
+			if t := ini.Get("db", "scope_table"); t != "" {
+				scopeTable = t
+			}
+		}
+	}
+
+	fmt.Println("")
+	for _, table := range proposal.Tables {
+		// Each call sees the previous file, so timestamps stay in
+		// dependency order.
+		timestamp := generator.GenerateTimestamp(cfg.MigrationsPath)
+		code, err := generator.GenerateMigration(generator.MigrationConfig{
+			PackageName:   "migrations",
+			MigrationName: table.Name,
+			Timestamp:     timestamp,
+			Columns:       table.Columns,
+			ScopeColumn:   cfg.ScopeColumn,
+			ScopeTable:    scopeTable,
+			IsGlobal:      table.Name == scopeTable,
+			ModulePath:    cfg.ModulePath,
+		})
+		if err != nil {
+			cli.FatalErr(fmt.Sprintf("failed to generate migration for %s", table.Name), err)
+		}
+		filePath := filepath.Join(cfg.MigrationsPath, generator.GenerateMigrationFileName(timestamp, table.Name))
+		if err := os.WriteFile(filePath, code, 0644); err != nil {
+			cli.FatalErr("failed to write migration file", err)
+		}
+		relPath, err := filepath.Rel(cfg.ShipqRoot, filePath)
+		if err != nil {
+			relPath = filePath
+		}
+		cli.Successf("Created migration: %s", relPath)
+	}
+
+	fmt.Println("\nNext steps:")
+	fmt.Println("  shipq migrate up")
+	for _, cmd := range proposal.ScaffoldCommands() {
+		fmt.Printf("  %s\n", cmd)
+	}
+}
+
+const importUsage = `Usage: shipq schema import <openapi.json> [--write]
+
+Propose shipq migrations and handler scaffolds for the REST resources of
+an existing service's OpenAPI 3 document (JSON).
+
+Each collection path (/pets, /pets/{id}) becomes a table whose columns
+come from the resource's JSON schema. Constructs with no shipq
+equivalent are listed under "Needs attention" instead of being guessed.
+
+Options:
+  --write   Write the proposed migrations (default: print the proposal only)
+`
+
+// ImportedTable is one table proposed from an OpenAPI resource.
+type ImportedTable struct {
+	Name    string
+	Columns []parser.ColumnSpec
+	// Ops are the `shipq resource` operations the service exposes for
+	// this resource, in canonical order.
+	Ops []string
+	// Actions are custom POST /<table>/{id}/<verb> endpoints.
+	Actions []string
+}
+
+// ImportFinding flags part of the document that was skipped or imported
+// lossily.
+type ImportFinding struct {
+	Where   string // a path, "<table>.<column>" or a schema name
+	Message string
+}
+
+// ImportProposal is the result of mapping an OpenAPI document onto shipq
+// conventions. Tables are in dependency order: a table comes after every
+// table it references.
+type ImportProposal struct {
+	Tables   []ImportedTable
+	Findings []ImportFinding
+}
+
+// crudOpOrder is the order ops are listed in, matching `shipq resource`.
+var crudOpOrder = []string{"create", "get_one", "list", "update", "delete"}
+
+// shipqManagedColumns are added to every table by shipq itself.
+var shipqManagedColumns = map[string]bool{
+	"id": true, "public_id": true, "created_at": true, "updated_at": true, "deleted_at": true,
+}
+
+// versionSegment matches path prefixes like v1 or v2beta.
+var versionSegment = regexp.MustCompile(`^v[0-9]+[a-z0-9]*$`)
+
+// identifier matches names usable as tables and columns.
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// ProposeImport maps an OpenAPI 3 document onto tables. existing holds the
+// tables already in the schema: they are not proposed again but may be
+// referenced. scopeColumn, if set, is left out of the columns because the
+// migration generator injects it.
+func ProposeImport(data []byte, existing map[string]bool, scopeColumn string) (*ImportProposal, error) {
+	var doc openAPIDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		if t := bytes.TrimSpace(data); len(t) > 0 && t[0] != '{' {
+			return nil, fmt.Errorf("only JSON documents are supported; convert YAML first: %w", err)
+		}
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q (want 3.x)", doc.OpenAPI)
+	}
+
+	imp := &importer{doc: &doc, proposal: &ImportProposal{}}
+	resources := imp.collectResources()
+
+	// Resolve every table name before mapping columns, so references
+	// between imported resources are recognised whatever their order.
+	known := map[string]bool{}
+	for name := range existing {
+		known[name] = true
+	}
+	var names []string
+	for name, res := range resources {
+		if existing[name] {
+			imp.flag(name, "table already exists; skipped")
+			continue
+		}
+		if len(res.schemas) == 0 {
+			imp.flag(res.path, "no JSON schema found for the resource; skipped")
+			continue
+		}
+		known[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tables []ImportedTable
+	for _, name := range names {
+		res := resources[name]
+		table := ImportedTable{Name: name, Actions: res.actions}
+		for _, op := range crudOpOrder {
+			if res.ops[op] {
+				table.Ops = append(table.Ops, op)
+			}
+		}
+		table.Columns = imp.mapColumns(name, res.schemas, known, scopeColumn)
+		tables = append(tables, table)
+	}
+
+	imp.proposal.Tables = imp.orderByDependency(tables)
+	sort.SliceStable(imp.proposal.Findings, func(i, j int) bool {
+		return imp.proposal.Findings[i].Where < imp.proposal.Findings[j].Where
+	})
+	return imp.proposal, nil
+}
+
+// Text renders the proposal for the terminal.
+func (p *ImportProposal) Text() string {
+	var b strings.Builder
+	if len(p.Tables) == 0 {
+		b.WriteString("No importable resources found.\n")
+	}
+	for _, t := range p.Tables {
+		fmt.Fprintf(&b, "%s", t.Name)
+		if len(t.Ops) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(t.Ops, ", "))
+		}
+		b.WriteString("\n")
+		for _, c := range t.Columns {
+			typ := c.Type
+			if c.Type == "references" {
+				typ = "references " + c.References
+			}
+			if c.Nullable {
+				typ += ", nullable"
+			}
+			fmt.Fprintf(&b, "  %-24s %s\n", c.Name, typ)
+		}
+		for _, a := range t.Actions {
+			fmt.Fprintf(&b, "  action: POST /%s/{id}/%s\n", t.Name, a)
+		}
+	}
+	if len(p.Findings) > 0 {
+		b.WriteString("\nNeeds attention:\n")
+		for _, f := range p.Findings {
+			fmt.Fprintf(&b, "  %s: %s\n", f.Where, f.Message)
+		}
+	}
+	return b.String()
+}
+
+// ScaffoldCommands returns the shipq commands that generate handlers for
+// the proposed tables.
+func (p *ImportProposal) ScaffoldCommands() []string {
+	var cmds []string
+	for _, t := range p.Tables {
+		if len(t.Ops) == len(crudOpOrder) {
+			cmds = append(cmds, fmt.Sprintf("shipq resource %s all", t.Name))
+		} else {
+			for _, op := range t.Ops {
+				cmds = append(cmds, fmt.Sprintf("shipq resource %s %s", t.Name, op))
+			}
+		}
+		for _, a := range t.Actions {
+			cmds = append(cmds, fmt.Sprintf("shipq handler generate %s --action %s", t.Name, a))
+		}
+	}
+	return cmds
+}
+
+// importedResource gathers everything the document says about one
+// collection.
+type importedResource struct {
+	path    string // first path seen, for findings
+	ops     map[string]bool
+	actions []string
+	schemas []*oaSchema
+}
+
+type importer struct {
+	doc      *openAPIDoc
+	proposal *ImportProposal
+}
+
+func (imp *importer) flag(where, format string, args ...any) {
+	imp.proposal.Findings = append(imp.proposal.Findings, ImportFinding{Where: where, Message: fmt.Sprintf(format, args...)})
+}
+
+// collectResources groups the document's paths by collection.
+func (imp *importer) collectResources() map[string]*importedResource {
+	resources := map[string]*importedResource{}
+	get := func(name string) *importedResource {
+		if resources[name] == nil {
+			resources[name] = &importedResource{ops: map[string]bool{}}
+		}
+		return resources[name]
+	}
+
+	paths := make([]string, 0, len(imp.doc.Paths))
+	for p := range imp.doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		segs := resourceSegments(path)
+		isParam := func(i int) bool { return strings.HasPrefix(segs[i], "{") }
+
+		var (
+			name  string
+			kind  string // "collection", "item" or "action"
+			extra string
+		)
+		switch {
+		case len(segs) == 1 && !isParam(0):
+			name, kind = segs[0], "collection"
+		case len(segs) == 2 && !isParam(0) && isParam(1):
+			name, kind = segs[0], "item"
+		case len(segs) == 3 && !isParam(0) && isParam(1) && !isParam(2):
+			name, kind, extra = segs[0], "action", segs[2]
+		default:
+			imp.flag(path, "nested or non-REST path; not imported")
+			continue
+		}
+		name = tableNameFor(name)
+		if !identifier.MatchString(name) {
+			imp.flag(path, "cannot derive a table name")
+			continue
+		}
+
+		res := get(name)
+		if res.path == "" {
+			res.path = path
+		}
+		for _, method := range sortedKeys(imp.doc.Paths[path]) {
+			op := imp.doc.Paths[path][method]
+			where := strings.ToUpper(method) + " " + path
+			switch method {
+			case "parameters", "summary", "description", "servers":
+				continue
+			}
+			if kind == "action" {
+				if method != "post" {
+					imp.flag(where, "only POST custom actions are supported")
+					continue
+				}
+				res.actions = append(res.actions, dbstrings.ToSnakeCase(strings.ReplaceAll(extra, "-", "_")))
+				continue
+			}
+
+			var crudOp string
+			switch {
+			case kind == "collection" && method == "get":
+				crudOp = "list"
+				if s := imp.listItemSchema(op.responseSchema()); s != nil {
+					res.schemas = append(res.schemas, s)
+				}
+			case kind == "collection" && method == "post":
+				crudOp = "create"
+				res.schemas = appendSchema(res.schemas, op.requestSchema(), op.responseSchema())
+			case kind == "item" && method == "get":
+				crudOp = "get_one"
+				res.schemas = appendSchema(res.schemas, op.responseSchema())
+			case kind == "item" && (method == "patch" || method == "put"):
+				crudOp = "update"
+				res.schemas = appendSchema(res.schemas, op.requestSchema())
+			case kind == "item" && method == "delete":
+				crudOp = "delete"
+			default:
+				imp.flag(where, "no matching shipq CRUD operation")
+				continue
+			}
+			res.ops[crudOp] = true
+		}
+	}
+	return resources
+}
+
+// resourceSegments splits path and drops API prefixes such as /api/v1.
+func resourceSegments(path string) []string {
+	var segs []string
+	for _, s := range strings.Split(strings.Trim(path, "/"), "/") {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	for len(segs) > 1 && (segs[0] == "api" || versionSegment.MatchString(segs[0])) {
+		segs = segs[1:]
+	}
+	return segs
+}
+
+// tableNameFor turns a path segment such as "petOwners" or "pet-owners"
+// into a snake_case table name. Collections are plural already, so the
+// segment is not re-inflected.
+func tableNameFor(segment string) string {
+	return dbstrings.ToSnakeCase(strings.ReplaceAll(segment, "-", "_"))
+}
+
+func appendSchema(list []*oaSchema, schemas ...*oaSchema) []*oaSchema {
+	for _, s := range schemas {
+		if s != nil {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// listItemSchema finds the element schema of a list response: either a
+// bare array or an envelope object with a single array property.
+func (imp *importer) listItemSchema(s *oaSchema) *oaSchema {
+	s = imp.resolve(s)
+	if s == nil {
+		return nil
+	}
+	if s.Type.is("array") {
+		return s.Items
+	}
+	var found *oaSchema
+	for _, p := range s.Properties {
+		if ps := imp.resolve(p.Schema); ps != nil && ps.Type.is("array") {
+			if found != nil {
+				return nil
+			}
+			found = ps.Items
+		}
+	}
+	return found
+}
+
+// resolve follows $ref pointers into components/schemas.
+func (imp *importer) resolve(s *oaSchema) *oaSchema {
+	for i := 0; s != nil && s.Ref != "" && i < 32; i++ {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok {
+			return nil
+		}
+		s = imp.doc.Components.Schemas[name]
+	}
+	return s
+}
+
+// mapColumns merges the properties of every schema seen for a resource
+// into column specs, in first-seen order.
+func (imp *importer) mapColumns(table string, schemas []*oaSchema, known map[string]bool, scopeColumn string) []parser.ColumnSpec {
+	var cols []parser.ColumnSpec
+	index := map[string]int{}
+	required := map[string]bool{}
+
+	var visit func(s *oaSchema, depth int)
+	visit = func(s *oaSchema, depth int) {
+		s = imp.resolve(s)
+		if s == nil || depth > 8 {
+			return
+		}
+		for _, sub := range s.AllOf {
+			visit(sub, depth+1)
+		}
+		if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+			imp.flag(table, "oneOf/anyOf resource schema; only shared properties imported")
+		}
+		for _, r := range s.Required {
+			required[dbstrings.ToSnakeCase(r)] = true
+		}
+		for _, p := range s.Properties {
+			name := dbstrings.ToSnakeCase(strings.ReplaceAll(p.Name, "-", "_"))
+			if shipqManagedColumns[name] || name == scopeColumn {
+				continue
+			}
+			if _, seen := index[name]; seen {
+				continue
+			}
+			where := table + "." + name
+			if !identifier.MatchString(name) {
+				imp.flag(where, "property name is not a valid column name; skipped")
+				continue
+			}
+			col, ok := imp.mapProperty(where, name, p.Schema, known)
+			if !ok {
+				continue
+			}
+			index[name] = len(cols)
+			cols = append(cols, col)
+		}
+	}
+	for _, s := range schemas {
+		visit(s, 0)
+	}
+
+	for i := range cols {
+		if !required[cols[i].Name] {
+			cols[i].Nullable = true
+		}
+	}
+	return cols
+}
+
+// mapProperty maps one property schema onto a column type.
+func (imp *importer) mapProperty(where, name string, raw *oaSchema, known map[string]bool) (parser.ColumnSpec, bool) {
+	col := parser.ColumnSpec{Name: name}
+	s := imp.resolve(raw)
+	if s == nil {
+		imp.flag(where, "unresolvable $ref; skipped")
+		return col, false
+	}
+	if s.Nullable || s.Type.nullable {
+		col.Nullable = true
+	}
+
+	// owner_id -> owners, whether the service exposes ids as numbers or
+	// strings: shipq stores the reference and exposes the public id.
+	if base, ok := strings.CutSuffix(name, "_id"); ok && base != "" {
+		if target := dbstrings.ToPlural(base); known[target] {
+			col.Type, col.References = "references", target
+			return col, true
+		}
+	}
+
+	switch {
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+		col.Type = "json"
+		imp.flag(where, "oneOf/anyOf stored as json")
+	case raw != nil && raw.Ref != "" && s.Type.is("object"):
+		col.Type = "json"
+		imp.flag(where, "embedded %s object stored as json", strings.TrimPrefix(raw.Ref, "#/components/schemas/"))
+	case s.Type.is("string"):
+		col.Type = "string"
+		switch s.Format {
+		case "date-time":
+			col.Type = "timestamptz"
+		case "date":
+			col.Type = "datetime"
+			imp.flag(where, "date stored as datetime")
+		case "byte", "binary":
+			col.Type = "binary"
+		default:
+			if s.MaxLength != nil && *s.MaxLength > 255 {
+				col.Type = "text"
+			}
+		}
+		if len(s.Enum) > 0 {
+			imp.flag(where, "enum values are not enforced by the column")
+		}
+	case s.Type.is("integer"):
+		col.Type = "bigint"
+		if s.Format == "int32" {
+			col.Type = "int"
+		}
+	case s.Type.is("number"):
+		col.Type = "float"
+		if s.Format == "decimal" {
+			col.Type = "decimal"
+			imp.flag(where, "decimal uses the default precision (%d, %d)", parser.DefaultDecimalPrecision, parser.DefaultDecimalScale)
+		}
+	case s.Type.is("boolean"):
+		col.Type = "bool"
+	case s.Type.is("array"):
+		col.Type = "json"
+		if items := s.Items; items != nil && items.Ref != "" {
+			imp.flag(where, "array of %s stored as json; model a one-to-many relationship instead if it is a resource", strings.TrimPrefix(items.Ref, "#/components/schemas/"))
+		}
+	case s.Type.is("object"):
+		col.Type = "json"
+	default:
+		col.Type = "json"
+		imp.flag(where, "untyped property stored as json")
+	}
+	return col, true
+}
+
+// orderByDependency sorts tables so each comes after the tables it
+// references. A reference that closes a cycle is demoted to a plain
+// bigint and reported.
+func (imp *importer) orderByDependency(tables []ImportedTable) []ImportedTable {
+	byName := map[string]int{}
+	for i, t := range tables {
+		byName[t.Name] = i
+	}
+	state := map[string]int{} // 0 unvisited, 1 visiting, 2 done
+	var ordered []ImportedTable
+
+	var visit func(i int)
+	visit = func(i int) {
+		t := &tables[i]
+		state[t.Name] = 1
+		for c := range t.Columns {
+			col := &t.Columns[c]
+			if col.Type != "references" {
+				continue
+			}
+			if col.References == t.Name {
+				imp.flag(t.Name+"."+col.Name, "self-reference stored as bigint; add the foreign key in a later migration")
+				col.Type, col.References = "bigint", ""
+				continue
+			}
+			j, imported := byName[col.References]
+			if !imported {
+				continue
+			}
+			switch state[col.References] {
+			case 0:
+				visit(j)
+			case 1:
+				imp.flag(t.Name+"."+col.Name, "reference cycle with %s; stored as bigint, add the foreign key in a later migration", col.References)
+				col.Type, col.References = "bigint", ""
+			}
+		}
+		state[t.Name] = 2
+		ordered = append(ordered, *t)
+	}
+	for i := range tables {
+		if state[tables[i].Name] == 0 {
+			visit(i)
+		}
+	}
+	return ordered
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// The subset of OpenAPI 3 the importer reads.
+
+type openAPIDoc struct {
+	OpenAPI    string                            `json:"openapi"`
+	Paths      map[string]map[string]oaOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]*oaSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type oaMediaTypes map[string]struct {
+	Schema *oaSchema `json:"schema"`
+}
+
+// json returns the application/json schema, if any.
+func (m oaMediaTypes) json() *oaSchema {
+	for ct, media := range m {
+		if ct == "application/json" || strings.HasSuffix(ct, "+json") {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+type oaOperation struct {
+	RequestBody *struct {
+		Content oaMediaTypes `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content oaMediaTypes `json:"content"`
+	} `json:"responses"`
+}
+
+// UnmarshalJSON tolerates non-operation path item fields (parameters,
+// summary, ...), which share the map with the operations.
+func (o *oaOperation) UnmarshalJSON(data []byte) error {
+	type plain oaOperation
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil
+	}
+	*o = oaOperation(p)
+	return nil
+}
+
+func (o oaOperation) requestSchema() *oaSchema {
+	if o.RequestBody == nil {
+		return nil
+	}
+	return o.RequestBody.Content.json()
+}
+
+// responseSchema returns the JSON schema of the first 2xx response.
+func (o oaOperation) responseSchema() *oaSchema {
+	for _, code := range sortedKeys(o.Responses) {
+		if strings.HasPrefix(code, "2") {
+			if s := o.Responses[code].Content.json(); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+type oaSchema struct {
+	Ref        string       `json:"$ref"`
+	Type       oaType       `json:"type"`
+	Format     string       `json:"format"`
+	Nullable   bool         `json:"nullable"`
+	Properties oaProperties `json:"properties"`
+	Required   []string     `json:"required"`
+	Items      *oaSchema    `json:"items"`
+	Enum       []any        `json:"enum"`
+	MaxLength  *int         `json:"maxLength"`
+	AllOf      []*oaSchema  `json:"allOf"`
+	OneOf      []*oaSchema  `json:"oneOf"`
+	AnyOf      []*oaSchema  `json:"anyOf"`
+}
+
+// oaType is a schema type: a string in 3.0, or a list that may include
+// "null" in 3.1.
+type oaType struct {
+	names    []string
+	nullable bool
+}
+
+func (t *oaType) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		t.names = []string{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	for _, n := range many {
+		if n == "null" {
+			t.nullable = true
+		} else {
+			t.names = append(t.names, n)
+		}
+	}
+	return nil
+}
+
+func (t oaType) is(name string) bool {
+	return len(t.names) == 1 && t.names[0] == name
+}
+
+// oaProperties keeps properties in document order, so columns come out
+// in the order the service's authors wrote them.
+type oaProperties []oaProperty
+
+type oaProperty struct {
+	Name   string
+	Schema *oaSchema
+}
+
+func (p *oaProperties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("properties must be an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var s oaSchema
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		*p = append(*p, oaProperty{Name: tok.(string), Schema: &s})
+	}
+	return nil
+}
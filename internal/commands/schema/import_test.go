@@ -0,0 +1,231 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/internal/commands/migrate/parser"
+)
+
+const petstoreDoc = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/api/v1/pets": {
+      "get": {"responses": {"200": {"content": {"application/json": {"schema": {
+        "type": "object",
+        "properties": {"items": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}, "next": {"type": "string"}}
+      }}}}}},
+      "post": {
+        "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewPet"}}}},
+        "responses": {"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+      }
+    },
+    "/api/v1/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "required": true}],
+      "get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}},
+      "delete": {"responses": {"204": {}}}
+    },
+    "/api/v1/pets/{petId}/adopt": {"post": {"responses": {"200": {}}}},
+    "/api/v1/owners": {
+      "get": {"responses": {"200": {"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Owner"}}}}}}},
+      "post": {"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Owner"}}}}, "responses": {"201": {}}}
+    },
+    "/api/v1/owners/{id}": {
+      "get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Owner"}}}}}},
+      "patch": {"responses": {"200": {}}},
+      "put": {"responses": {"200": {}}},
+      "delete": {"responses": {"204": {}}}
+    },
+    "/api/v1/owners/{id}/pets/{petId}": {"get": {"responses": {"200": {}}}}
+  },
+  "components": {"schemas": {
+    "NewPet": {
+      "type": "object",
+      "required": ["name", "ownerId"],
+      "properties": {
+        "name": {"type": "string"},
+        "ownerId": {"type": "string"},
+        "species": {"type": "string", "enum": ["cat", "dog"]},
+        "bio": {"type": "string", "maxLength": 2000}
+      }
+    },
+    "Pet": {
+      "allOf": [
+        {"$ref": "#/components/schemas/NewPet"},
+        {"type": "object", "required": ["id"], "properties": {
+          "id": {"type": "string"},
+          "createdAt": {"type": "string", "format": "date-time"},
+          "weightKg": {"type": "number"},
+          "tags": {"type": "array", "items": {"type": "string"}},
+          "vaccinatedOn": {"type": "string", "format": "date"}
+        }}
+      ]
+    },
+    "Owner": {
+      "type": "object",
+      "required": ["email"],
+      "properties": {
+        "id": {"type": "integer"},
+        "email": {"type": "string"},
+        "age": {"type": "integer", "format": "int32"},
+        "address": {"$ref": "#/components/schemas/Address"},
+        "verified": {"type": ["boolean", "null"]},
+        "organizationId": {"type": "integer"}
+      }
+    },
+    "Address": {"type": "object", "properties": {"city": {"type": "string"}}}
+  }}
+}`
+
+func TestProposeImport(t *testing.T) {
+	p, err := ProposeImport([]byte(petstoreDoc), nil, "organization_id")
+	if err != nil {
+		t.Fatalf("ProposeImport: %v", err)
+	}
+
+	if len(p.Tables) != 2 {
+		t.Fatalf("got %d tables, want 2: %+v", len(p.Tables), p.Tables)
+	}
+	owners, pets := p.Tables[0], p.Tables[1]
+	if owners.Name != "owners" || pets.Name != "pets" {
+		t.Fatalf("tables = %s, %s; want owners before pets", owners.Name, pets.Name)
+	}
+
+	wantOwners := []parser.ColumnSpec{
+		{Name: "email", Type: "string"},
+		{Name: "age", Type: "int", Nullable: true},
+		{Name: "address", Type: "json", Nullable: true},
+		{Name: "verified", Type: "bool", Nullable: true},
+	}
+	if !reflect.DeepEqual(owners.Columns, wantOwners) {
+		t.Errorf("owners columns = %+v, want %+v", owners.Columns, wantOwners)
+	}
+	if want := []string{"create", "get_one", "list", "update", "delete"}; !reflect.DeepEqual(owners.Ops, want) {
+		t.Errorf("owners ops = %v, want %v", owners.Ops, want)
+	}
+
+	wantPets := []parser.ColumnSpec{
+		{Name: "name", Type: "string"},
+		{Name: "owner_id", Type: "references", References: "owners"},
+		{Name: "species", Type: "string", Nullable: true},
+		{Name: "bio", Type: "text", Nullable: true},
+		{Name: "weight_kg", Type: "float", Nullable: true},
+		{Name: "tags", Type: "json", Nullable: true},
+		{Name: "vaccinated_on", Type: "datetime", Nullable: true},
+	}
+	if !reflect.DeepEqual(pets.Columns, wantPets) {
+		t.Errorf("pets columns = %+v, want %+v", pets.Columns, wantPets)
+	}
+	if want := []string{"create", "get_one", "list", "delete"}; !reflect.DeepEqual(pets.Ops, want) {
+		t.Errorf("pets ops = %v, want %v", pets.Ops, want)
+	}
+	if want := []string{"adopt"}; !reflect.DeepEqual(pets.Actions, want) {
+		t.Errorf("pets actions = %v, want %v", pets.Actions, want)
+	}
+
+	want := "owners (create, get_one, list, update, delete)\n" +
+		"  email                    string\n" +
+		"  age                      int, nullable\n" +
+		"  address                  json, nullable\n" +
+		"  verified                 bool, nullable\n" +
+		"pets (create, get_one, list, delete)\n" +
+		"  name                     string\n" +
+		"  owner_id                 references owners\n" +
+		"  species                  string, nullable\n" +
+		"  bio                      text, nullable\n" +
+		"  weight_kg                float, nullable\n" +
+		"  tags                     json, nullable\n" +
+		"  vaccinated_on            datetime, nullable\n" +
+		"  action: POST /pets/{id}/adopt\n" +
+		"\n" +
+		"Needs attention:\n" +
+		"  /api/v1/owners/{id}/pets/{petId}: nested or non-REST path; not imported\n" +
+		"  owners.address: embedded Address object stored as json\n" +
+		"  pets.species: enum values are not enforced by the column\n" +
+		"  pets.vaccinated_on: date stored as datetime\n"
+	if text := p.Text(); text != want {
+		t.Errorf("Text() =\n%s\nwant:\n%s", text, want)
+	}
+
+	wantCmds := []string{
+		"shipq resource owners all",
+		"shipq resource pets create",
+		"shipq resource pets get_one",
+		"shipq resource pets list",
+		"shipq resource pets delete",
+		"shipq handler generate pets --action adopt",
+	}
+	if got := p.ScaffoldCommands(); !reflect.DeepEqual(got, wantCmds) {
+		t.Errorf("ScaffoldCommands() = %v, want %v", got, wantCmds)
+	}
+}
+
+func TestProposeImport_ExistingAndCycles(t *testing.T) {
+	doc := `{
+	  "openapi": "3.1.0",
+	  "paths": {
+	    "/users": {"post": {"requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"name": {"type": "string"}}}}}}}},
+	    "/teams": {"post": {"requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"lead_id": {"type": "integer"}, "team_id": {"type": "integer"}}}}}}}},
+	    "/leads": {"post": {"requestBody": {"content": {"application/json": {"schema": {"type": "object", "properties": {"team_id": {"type": "integer"}, "user_id": {"type": "integer"}}}}}}}},
+	    "/health": {"get": {}}
+	  }
+	}`
+	p, err := ProposeImport([]byte(doc), map[string]bool{"users": true}, "")
+	if err != nil {
+		t.Fatalf("ProposeImport: %v", err)
+	}
+
+	var names []string
+	for _, table := range p.Tables {
+		names = append(names, table.Name)
+	}
+	if want := []string{"teams", "leads"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("tables = %v, want %v", names, want)
+	}
+	// leads -> teams is kept; teams -> leads closes the cycle.
+	if got := p.Tables[0].Columns[0]; got.Type != "bigint" {
+		t.Errorf("teams.lead_id = %+v, want a plain bigint", got)
+	}
+	if got := p.Tables[0].Columns[1]; got.Type != "bigint" {
+		t.Errorf("teams.team_id = %+v, want a plain bigint", got)
+	}
+	if got := p.Tables[1].Columns[1]; got.References != "users" {
+		t.Errorf("leads.user_id = %+v, want a reference to the existing users table", got)
+	}
+
+	want := "teams (create)\n" +
+		"  lead_id                  bigint, nullable\n" +
+		"  team_id                  bigint, nullable\n" +
+		"leads (create)\n" +
+		"  team_id                  references teams, nullable\n" +
+		"  user_id                  references users, nullable\n" +
+		"\n" +
+		"Needs attention:\n" +
+		"  /health: no JSON schema found for the resource; skipped\n" +
+		"  teams.lead_id: reference cycle with leads; stored as bigint, add the foreign key in a later migration\n" +
+		"  teams.team_id: self-reference stored as bigint; add the foreign key in a later migration\n" +
+		"  users: table already exists; skipped\n"
+	if text := p.Text(); text != want {
+		t.Errorf("Text() =\n%s\nwant:\n%s", text, want)
+	}
+}
+
+func TestProposeImport_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"yaml", "openapi: 3.0.0\n", "convert YAML first"},
+		{"swagger", `{"swagger": "2.0"}`, "unsupported OpenAPI version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ProposeImport([]byte(tt.doc), nil, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/project"
+)
+
+// LabelManifest is the JSON form of `shipq schema labels --json`: the
+// tables of each label, and the tables without any.
+type LabelManifest struct {
+	Labels    map[string][]string `json:"labels"`
+	Unlabeled []string            `json:"unlabeled"`
+}
+
+// LabelsCmd implements "shipq schema labels [--json]". It lists the tables
+// carrying each label in the working tree's schema.json.
+func LabelsCmd(args []string) {
+	asJSON := false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "-h", "--help":
+			fmt.Print(labelsUsage)
+			os.Exit(0)
+		default:
+			cli.Fatal(fmt.Sprintf("unknown argument for 'shipq schema labels': %s", arg))
+		}
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+	plan, err := loadPlanFile(filepath.Join(roots.ShipqRoot, schemaJSONPath))
+	if err != nil {
+		cli.FatalErr("failed to load schema", err)
+	}
+
+	manifest := buildLabelManifest(plan)
+	if asJSON {
+		out, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			cli.FatalErr("failed to encode labels", err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	fmt.Print(manifest.Text())
+}
+
+const labelsUsage = `Usage: shipq schema labels [--json]
+
+List the tables carrying each label, as set with tb.Label(...) or
+plan.LabelTable(...) in migrations.
+
+Options:
+  --json   Print a machine-readable manifest
+`
+
+// buildLabelManifest groups the tables of plan by label.
+func buildLabelManifest(plan *migrate.MigrationPlan) LabelManifest {
+	manifest := LabelManifest{
+		Labels:    migrate.TablesByLabel(plan.Schema.Tables),
+		Unlabeled: []string{},
+	}
+	for name, table := range plan.Schema.Tables {
+		if len(table.Labels) == 0 {
+			manifest.Unlabeled = append(manifest.Unlabeled, name)
+		}
+	}
+	sort.Strings(manifest.Unlabeled)
+	return manifest
+}
+
+// Text renders the manifest as one line per label.
+func (m LabelManifest) Text() string {
+	labels := make([]string, 0, len(m.Labels))
+	for label := range m.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var b strings.Builder
+	if len(labels) == 0 {
+		b.WriteString("No labeled tables.\n")
+	}
+	for _, label := range labels {
+		fmt.Fprintf(&b, "%s: %s\n", label, strings.Join(m.Labels[label], ", "))
+	}
+	if len(m.Unlabeled) > 0 {
+		fmt.Fprintf(&b, "(unlabeled): %s\n", strings.Join(m.Unlabeled, ", "))
+	}
+	return b.String()
+}
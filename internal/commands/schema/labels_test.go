@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+)
+
+func TestBuildLabelManifest(t *testing.T) {
+	plan := &migrate.MigrationPlan{Schema: migrate.Schema{Tables: map[string]ddl.Table{
+		"invoices": {Name: "invoices", Labels: []string{"billing"}},
+		"ledger":   {Name: "ledger", Labels: []string{"billing", "internal"}},
+		"users":    {Name: "users"},
+		"posts":    {Name: "posts"},
+	}}}
+
+	manifest := buildLabelManifest(plan)
+	want := "billing: invoices, ledger\ninternal: ledger\n(unlabeled): posts, users\n"
+	if got := manifest.Text(); got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+
+	empty := buildLabelManifest(&migrate.MigrationPlan{})
+	if got := empty.Text(); got != "No labeled tables.\n" {
+		t.Errorf("Text() = %q for an empty schema", got)
+	}
+}
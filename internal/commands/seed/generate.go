@@ -0,0 +1,666 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/dag"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/dbops"
+	"github.com/shipq/shipq/nanoid"
+	"github.com/shipq/shipq/project"
+	"github.com/shipq/shipq/proptest"
+)
+
+// maxRowAttempts is how many times a row is regenerated when it repeats the
+// values of a unique index before it is skipped.
+const maxRowAttempts = 10
+
+// GenerateOptions configures "shipq db seed --generate".
+type GenerateOptions struct {
+	// Rows is the number of rows inserted into each table.
+	Rows int
+	// Seed seeds the random values; zero picks one from the clock. The same
+	// seed reproduces the same data set (apart from public ids) on an empty
+	// database.
+	Seed int64
+	// ExcludeLabels skips the tables carrying any of these labels.
+	ExcludeLabels []string
+	// Env is "test" to seed the test database instead of the dev one. For
+	// seed files it also selects the seed set; see SeedEnvs.
+	Env string
+}
+
+// TableSeedResult reports what was generated for one table.
+type TableSeedResult struct {
+	Table    string
+	Inserted int
+	// Skipped counts rows dropped because they would repeat the values of
+	// a unique index.
+	Skipped int
+	// Reason is set when the whole table was skipped.
+	Reason string
+}
+
+// DBSeedCmd implements "shipq db seed [--env E] [--generate N [--seed S]
+// [--exclude-label L]...]". Without --generate it runs the seed files like
+// "shipq seed".
+func DBSeedCmd(args []string) {
+	opts, generate, err := parseDBSeedArgs(args)
+	if err != nil {
+		cli.Fatal(err.Error())
+	}
+	if !generate {
+		runSeedFiles(opts.Env)
+		return
+	}
+	GenerateCmd(opts)
+}
+
+const dbSeedUsage = `Usage: shipq db seed [--env dev|test] [--generate N] [--seed S] [--exclude-label L]...
+
+Without --generate, runs the Seed_* functions in seeds/ (same as 'shipq seed').
+
+With --generate, inserts N rows of realistic random data into every table in
+schema.json, parents before the tables referencing them. Nullability,
+unique indexes and string lengths are respected; foreign keys point at
+existing rows.
+
+Options:
+  --env E             dev (default) or test: the database to seed, and for
+                      seed files the seed set to run
+  --generate N        Rows to insert per table
+  --seed S            Random seed, to reproduce a data set
+  --exclude-label L   Skip tables labeled L (repeatable)
+`
+
+func parseDBSeedArgs(args []string) (GenerateOptions, bool, error) {
+	var opts GenerateOptions
+	generate := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "-h", "--help":
+			fmt.Print(dbSeedUsage)
+			os.Exit(0)
+		case "--generate", "--seed", "--exclude-label", "--env":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, false, fmt.Errorf("%s requires a value", name)
+				}
+				i++
+				value = args[i]
+			}
+			switch name {
+			case "--generate":
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 {
+					return opts, false, fmt.Errorf("--generate must be a positive number of rows, got %q", value)
+				}
+				opts.Rows = n
+				generate = true
+			case "--seed":
+				s, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return opts, false, fmt.Errorf("--seed must be an integer, got %q", value)
+				}
+				opts.Seed = s
+			case "--exclude-label":
+				opts.ExcludeLabels = append(opts.ExcludeLabels, value)
+			case "--env":
+				if err := validateSeedEnv(value); err != nil {
+					return opts, false, err
+				}
+				opts.Env = value
+			}
+		default:
+			return opts, false, fmt.Errorf("unknown argument for 'shipq db seed': %s", arg)
+		}
+	}
+	if !generate && (opts.Seed != 0 || len(opts.ExcludeLabels) > 0) {
+		return opts, false, fmt.Errorf("--seed and --exclude-label require --generate")
+	}
+	return opts, generate, nil
+}
+
+// GenerateCmd inserts generated rows into every table of the project's
+// schema.json.
+func GenerateCmd(opts GenerateOptions) {
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	ini, err := inifile.ParseFile(filepath.Join(roots.ShipqRoot, project.ShipqIniFile))
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+	databaseURL, err := seedDatabaseURL(ini, opts.Env)
+	if err != nil {
+		cli.Fatal(err.Error())
+	}
+	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
+	if err != nil {
+		cli.FatalErr("failed to determine database dialect", err)
+	}
+
+	schemaData, err := os.ReadFile(filepath.Join(roots.ShipqRoot, "shipq", "db", "migrate", "schema.json"))
+	if err != nil {
+		cli.FatalErr("failed to read schema.json (run 'shipq migrate up' first)", err)
+	}
+	plan, err := migrate.PlanFromJSON(schemaData)
+	if err != nil {
+		cli.FatalErr("failed to parse schema.json", err)
+	}
+
+	db, err := openDatabase(databaseURL, dialect)
+	if err != nil {
+		cli.FatalErr("failed to open database", err)
+	}
+	defer db.Close()
+
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	cli.Infof("Generating %d row(s) per table (seed %d)", opts.Rows, opts.Seed)
+
+	results, err := GenerateSeedData(context.Background(), db, dialect, plan.Schema.Tables, opts)
+	for _, r := range results {
+		switch {
+		case r.Reason != "":
+			cli.Warnf("  %s: skipped (%s)", r.Table, r.Reason)
+		case r.Skipped > 0:
+			cli.Infof("  %s: %d row(s), %d skipped as duplicates", r.Table, r.Inserted, r.Skipped)
+		default:
+			cli.Infof("  %s: %d row(s)", r.Table, r.Inserted)
+		}
+	}
+	if err != nil {
+		cli.FatalErr("failed to generate seed data", err)
+	}
+	cli.Success("Seed data generated!")
+}
+
+// GenerateSeedData inserts opts.Rows generated rows into each table of
+// tables not excluded by label, ordered so that referenced tables are filled
+// first. It returns a result per table processed so far, also on error.
+func GenerateSeedData(ctx context.Context, db *sql.DB, dialect string, tables map[string]ddl.Table, opts GenerateOptions) ([]TableSeedResult, error) {
+	order, err := seedOrder(tables, migrate.SelectTables(tables, nil, opts.ExcludeLabels))
+	if err != nil {
+		return nil, err
+	}
+
+	gen := newRowGenerator(proptest.New(opts.Seed), dialect, func(table string) ([]int64, error) {
+		return loadIDs(ctx, db, dialect, table)
+	})
+
+	var results []TableSeedResult
+	for _, name := range order {
+		table := tables[name]
+		result := TableSeedResult{Table: name}
+		if reason := unsupportedReason(table); reason != "" {
+			result.Reason = reason
+			results = append(results, result)
+			continue
+		}
+
+		for seq := 1; seq <= opts.Rows; seq++ {
+			cols, vals, err := gen.row(table, seq)
+			var skip *skipTableError
+			if errors.As(err, &skip) {
+				result.Reason = skip.reason
+				break
+			}
+			if err != nil {
+				results = append(results, result)
+				return results, err
+			}
+			if cols == nil {
+				result.Skipped++
+				continue
+			}
+			if _, err := db.ExecContext(ctx, insertSQL(dialect, table, cols), vals...); err != nil {
+				if isUniqueViolation(err) {
+					result.Skipped++
+					continue
+				}
+				results = append(results, result)
+				return results, fmt.Errorf("insert into %s: %w", name, err)
+			}
+			result.Inserted++
+		}
+		// Tables referencing this one must see the new rows.
+		gen.forgetIDs(name)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// seedOrder sorts names so that every table follows the tables it
+// references. References to tables outside names, and to the table itself,
+// don't constrain the order.
+func seedOrder(tables map[string]ddl.Table, names []string) ([]string, error) {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+	nodes := make([]dag.Node[string], 0, len(names))
+	for _, name := range names {
+		node := dag.Node[string]{ID: name}
+		for _, col := range tables[name].Columns {
+			parent := referencedTable(col)
+			if parent != "" && parent != name && selected[parent] && !slices.Contains(node.HardDeps, parent) {
+				node.HardDeps = append(node.HardDeps, parent)
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	graph, err := dag.New(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot order tables for seeding: %w", err)
+	}
+	return graph.TopologicalOrder(), nil
+}
+
+// referencedTable returns the table a column points at, or "".
+func referencedTable(col ddl.ColumnDefinition) string {
+	ref := col.References
+	if ref == "" {
+		ref = col.ForeignKey
+	}
+	table, _, _ := strings.Cut(ref, ".")
+	return table
+}
+
+// unsupportedReason explains why rows cannot be generated for table, or
+// returns "" when they can.
+func unsupportedReason(table ddl.Table) string {
+	for _, col := range table.Columns {
+		if col.Custom != nil && !col.Nullable && col.Default == nil {
+			return fmt.Sprintf("column %s has custom type %s; write a seed file for it", col.Name, col.Type)
+		}
+	}
+	return ""
+}
+
+// skipTableError reports that no rows can be generated for a table, e.g.
+// because a NOT NULL reference has no rows to point at.
+type skipTableError struct {
+	reason string
+}
+
+func (e *skipTableError) Error() string { return e.reason }
+
+// rowGenerator produces column values for generated rows.
+type rowGenerator struct {
+	g       *proptest.Generator
+	dialect string
+	// loadIDs returns the ids of a table's existing rows.
+	loadIDs func(table string) ([]int64, error)
+	ids     map[string][]int64
+	// seen holds the values already used for each unique index.
+	seen map[string]map[string]bool
+	// uniqueBase keeps unique values of this run apart from earlier runs.
+	uniqueBase int64
+}
+
+func newRowGenerator(g *proptest.Generator, dialect string, loadIDs func(string) ([]int64, error)) *rowGenerator {
+	return &rowGenerator{
+		g:          g,
+		dialect:    dialect,
+		loadIDs:    loadIDs,
+		ids:        make(map[string][]int64),
+		seen:       make(map[string]map[string]bool),
+		uniqueBase: g.Int64Range(100_000, 999_999),
+	}
+}
+
+func (rg *rowGenerator) forgetIDs(table string) {
+	delete(rg.ids, table)
+}
+
+// row generates the columns and values of the seq-th row of table. It
+// returns nil columns when every attempt repeated a unique index's values.
+func (rg *rowGenerator) row(table ddl.Table, seq int) ([]string, []any, error) {
+	uniqueCols := make(map[string]bool)
+	for _, idx := range table.Indexes {
+		if idx.Unique {
+			for _, c := range idx.Columns {
+				uniqueCols[c] = true
+			}
+		}
+	}
+
+	for attempt := 0; attempt < maxRowAttempts; attempt++ {
+		var cols []string
+		var vals []any
+		values := make(map[string]any)
+		for _, col := range table.Columns {
+			if skipColumn(col) {
+				continue
+			}
+			v, err := rg.value(table.Name, col, seq, uniqueCols[col.Name])
+			if err != nil {
+				return nil, nil, err
+			}
+			cols = append(cols, col.Name)
+			vals = append(vals, v)
+			values[col.Name] = v
+		}
+		if rg.claimUnique(table, values) {
+			return cols, vals, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// claimUnique records the row's values for every unique index of table,
+// reporting false (and recording nothing) if any index already holds them.
+func (rg *rowGenerator) claimUnique(table ddl.Table, values map[string]any) bool {
+	keys := make(map[string]string)
+	for _, idx := range table.Indexes {
+		if !idx.Unique {
+			continue
+		}
+		parts := make([]string, len(idx.Columns))
+		for i, c := range idx.Columns {
+			v, ok := values[c]
+			if !ok || v == nil {
+				// Generated by the database, or NULL (never a duplicate).
+				parts = nil
+				break
+			}
+			parts[i] = fmt.Sprint(v)
+		}
+		if parts == nil {
+			continue
+		}
+		key := strings.Join(parts, "\x00")
+		if rg.seen[idx.Name][key] {
+			return false
+		}
+		keys[idx.Name] = key
+	}
+	for name, key := range keys {
+		if rg.seen[name] == nil {
+			rg.seen[name] = make(map[string]bool)
+		}
+		rg.seen[name][key] = true
+	}
+	return true
+}
+
+// skipColumn reports whether the database fills col: auto-increment keys,
+// columns with defaults, and the soft-delete marker.
+func skipColumn(col ddl.ColumnDefinition) bool {
+	if col.PrimaryKey && (col.Type == ddl.BigintType || col.Type == ddl.IntegerType) {
+		return true
+	}
+	return col.Default != nil || col.Name == "deleted_at"
+}
+
+// value generates a value for col of the seq-th row.
+func (rg *rowGenerator) value(tableName string, col ddl.ColumnDefinition, seq int, unique bool) (any, error) {
+	g := rg.g
+	if parent := referencedTable(col); parent != "" {
+		ids, err := rg.parentIDs(parent)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 || (col.Nullable && g.BoolWithProb(0.1)) {
+			if col.Nullable {
+				return nil, nil
+			}
+			if parent == tableName {
+				return nil, &skipTableError{fmt.Sprintf("%s references its own table and is NOT NULL", col.Name)}
+			}
+			return nil, &skipTableError{fmt.Sprintf("%s references %s, which has no rows", col.Name, parent)}
+		}
+		return ids[g.Intn(len(ids))], nil
+	}
+	if col.Name == "public_id" {
+		return nanoid.New(), nil
+	}
+	if col.Custom != nil || (col.Nullable && !unique && g.BoolWithProb(0.1)) {
+		return nil, nil
+	}
+
+	switch col.Type {
+	case ddl.IntegerType, ddl.BigintType:
+		if unique {
+			return rg.uniqueBase*1000 + int64(seq), nil
+		}
+		return int64(g.IntRange(0, 1000)), nil
+	case ddl.FloatType:
+		return math.Round(g.Float64Range(0, 1000)*100) / 100, nil
+	case ddl.DecimalType:
+		return decimalValue(g, col), nil
+	case ddl.BooleanType:
+		return g.Bool(), nil
+	case ddl.StringType, ddl.TextType:
+		s := textValue(g, col)
+		if unique {
+			s = withUniqueSuffix(s, fmt.Sprintf("%d%d", rg.uniqueBase, seq), col)
+		}
+		return s, nil
+	case ddl.DatetimeType, ddl.TimestampType, ddl.TimestamptzType:
+		t := time.Now().UTC().Add(-time.Duration(g.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+		if rg.dialect == dburl.DialectSQLite {
+			return t.Format("2006-01-02 15:04:05"), nil
+		}
+		return t, nil
+	case ddl.BinaryType:
+		return g.BytesN(8, 32), nil
+	case ddl.JSONType:
+		b, _ := json.Marshal(map[string]any{"note": words(g, 2, 4), "count": g.IntRange(0, 100)})
+		return string(b), nil
+	case ddl.EnumType:
+		return col.EnumValues[g.Intn(len(col.EnumValues))], nil
+	case ddl.PointType:
+		lng := math.Round(g.Float64Range(-180, 180)*1e4) / 1e4
+		lat := math.Round(g.Float64Range(-85, 85)*1e4) / 1e4
+		return "POINT(" + strconv.FormatFloat(lng, 'f', -1, 64) + " " + strconv.FormatFloat(lat, 'f', -1, 64) + ")", nil
+	case ddl.PolygonType:
+		// A counter-clockwise triangle of about a kilometre
+		lng := math.Round(g.Float64Range(-179, 179)*1e4) / 1e4
+		lat := math.Round(g.Float64Range(-84, 84)*1e4) / 1e4
+		corner := func(dLng, dLat float64) string {
+			return strconv.FormatFloat(math.Round((lng+dLng)*1e4)/1e4, 'f', -1, 64) + " " + strconv.FormatFloat(math.Round((lat+dLat)*1e4)/1e4, 'f', -1, 64)
+		}
+		return "POLYGON((" + corner(0, 0) + ", " + corner(0.01, 0) + ", " + corner(0.01, 0.01) + ", " + corner(0, 0) + "))", nil
+	}
+	return nil, fmt.Errorf("%s.%s: cannot generate values of type %s", tableName, col.Name, col.Type)
+}
+
+func (rg *rowGenerator) parentIDs(table string) ([]int64, error) {
+	if ids, ok := rg.ids[table]; ok {
+		return ids, nil
+	}
+	ids, err := rg.loadIDs(table)
+	if err != nil {
+		return nil, fmt.Errorf("load ids of %s: %w", table, err)
+	}
+	rg.ids[table] = ids
+	return ids, nil
+}
+
+var (
+	seedFirstNames = []string{"Ada", "Grace", "Alan", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Frances", "Edsger", "Radia", "Guido"}
+	seedLastNames  = []string{"Lovelace", "Hopper", "Turing", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson", "Allen", "Dijkstra", "Perlman", "Rossum"}
+	seedWords      = []string{"amber", "bright", "cedar", "delta", "ember", "forest", "granite", "harbor", "island", "jade", "kite", "lumen", "meadow", "north", "orbit", "pine", "quartz", "river", "summit", "tide", "umber", "valley", "willow", "zenith"}
+)
+
+// textValue generates a string for col, guessing its meaning from its name
+// (email, first_name, url, ...) and keeping within its length.
+func textValue(g *proptest.Generator, col ddl.ColumnDefinition) string {
+	first := seedFirstNames[g.Intn(len(seedFirstNames))]
+	last := seedLastNames[g.Intn(len(seedLastNames))]
+	name := col.Name
+
+	var s string
+	switch {
+	case strings.Contains(name, "email"):
+		s = strings.ToLower(first+"."+last) + "@example.com"
+	case name == "first_name":
+		s = first
+	case name == "last_name":
+		s = last
+	case strings.HasSuffix(name, "url") || name == "website":
+		s = "https://example.com/" + strings.ReplaceAll(words(g, 1, 2), " ", "-")
+	case strings.Contains(name, "phone"):
+		s = "+1555" + g.StringFromN(proptest.CharsetDigits, 7, 7)
+	case name == "slug" || name == "username" || name == "handle":
+		s = strings.ReplaceAll(words(g, 1, 2), " ", "-")
+	case name == "name" || name == "title" || strings.HasSuffix(name, "_name"):
+		s = capitalize(words(g, 1, 3))
+	case col.Type == ddl.TextType || name == "description" || name == "body" || name == "content" || name == "bio" || name == "notes":
+		s = capitalize(words(g, 6, 14)) + "."
+	default:
+		s = words(g, 1, 3)
+	}
+	if col.Length != nil && len(s) > *col.Length {
+		s = s[:*col.Length]
+	}
+	return s
+}
+
+// withUniqueSuffix makes s unique by appending tag, shortening s to keep
+// within col's length. Emails get the tag before the "@".
+func withUniqueSuffix(s, tag string, col ddl.ColumnDefinition) string {
+	local, domain, isEmail := strings.Cut(s, "@")
+	if !isEmail {
+		local, domain = s, ""
+	} else {
+		domain = "@" + domain
+	}
+	suffix := "-" + tag
+	if isEmail {
+		suffix = "+" + tag
+	}
+	if col.Length != nil {
+		if room := *col.Length - len(suffix) - len(domain); len(local) > room {
+			local = local[:max(room, 0)]
+		}
+	}
+	return local + suffix + domain
+}
+
+func words(g *proptest.Generator, min, max int) string {
+	n := g.IntRange(min, max)
+	out := make([]string, n)
+	for i := range out {
+		out[i] = seedWords[g.Intn(len(seedWords))]
+	}
+	return strings.Join(out, " ")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// decimalValue generates a decimal string fitting col's precision and scale.
+func decimalValue(g *proptest.Generator, col ddl.ColumnDefinition) string {
+	precision, scale := 10, 2
+	if col.Precision != nil {
+		precision = *col.Precision
+	}
+	if col.Scale != nil {
+		scale = *col.Scale
+	}
+	intDigits := min(precision-scale, 6)
+	whole := int64(0)
+	if intDigits > 0 {
+		whole = g.Int63n(int64(math.Pow10(intDigits)))
+	}
+	if scale <= 0 {
+		return strconv.FormatInt(whole, 10)
+	}
+	frac := g.Int63n(int64(math.Pow10(min(scale, 9))))
+	return fmt.Sprintf("%d.%0*d", whole, min(scale, 9), frac)
+}
+
+// insertSQL builds the INSERT of cols into table for dialect.
+func insertSQL(dialect string, table ddl.Table, cols []string) string {
+	types := make(map[string]string, len(table.Columns))
+	for _, col := range table.Columns {
+		types[col.Name] = col.Type
+	}
+
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + dbops.QuoteIdentifier(table.Name, dialect) + " (")
+	for i, c := range cols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(dbops.QuoteIdentifier(c, dialect))
+	}
+	b.WriteString(") VALUES (")
+	for i, c := range cols {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		param := "?"
+		if dialect == dburl.DialectPostgres {
+			param = "$" + strconv.Itoa(i+1)
+		}
+		if ddl.IsSpatialType(types[c]) {
+			switch dialect {
+			case dburl.DialectPostgres:
+				param = "ST_GeogFromText(" + param + ")"
+			case dburl.DialectMySQL:
+				param = "ST_GeomFromText(" + param + ", 4326, 'axis-order=long-lat')"
+			}
+		}
+		b.WriteString(param)
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// loadIDs returns the ids of the rows of table.
+func loadIDs(ctx context.Context, db *sql.DB, dialect, table string) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+dbops.QuoteIdentifier("id", dialect)+" FROM "+dbops.QuoteIdentifier(table, dialect))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation on
+// SQLite, PostgreSQL or MySQL.
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique constraint failed") ||
+		strings.Contains(msg, "23505") ||
+		strings.Contains(msg, "duplicate entry")
+}
@@ -0,0 +1,194 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/proptest"
+)
+
+func seedTestPlan(t *testing.T) *migrate.MigrationPlan {
+	t.Helper()
+	plan := migrate.NewPlan()
+	if _, err := plan.AddTable("authors", func(tb *ddl.TableBuilder) error {
+		tb.String("email").Unique()
+		tb.String("first_name")
+		tb.VarChar("handle", 12).Unique()
+		tb.Text("bio").Nullable()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	authors, err := plan.Table("authors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.AddTable("books", func(tb *ddl.TableBuilder) error {
+		tb.Bigint("author_id").References(authors)
+		tb.String("title")
+		tb.Decimal("price", 6, 2)
+		tb.Bool("published")
+		tb.Datetime("released_at").Nullable()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	books, err := plan.Table("books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.AddTable("favorites", func(tb *ddl.TableBuilder) error {
+		author := tb.Bigint("author_id").References(authors)
+		book := tb.Bigint("book_id").References(books)
+		tb.AddUniqueIndex(author.Col(), book.Col())
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestSeedOrder(t *testing.T) {
+	plan := seedTestPlan(t)
+	order, err := seedOrder(plan.Schema.Tables, []string{"favorites", "books", "authors"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"authors", "books", "favorites"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	// Unselected parents don't constrain the order.
+	order, err = seedOrder(plan.Schema.Tables, []string{"favorites", "books"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"books", "favorites"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestRowGenerator_RespectsColumns(t *testing.T) {
+	plan := seedTestPlan(t)
+	authors := plan.Schema.Tables["authors"]
+	gen := newRowGenerator(proptest.New(1), dburl.DialectSQLite, func(string) ([]int64, error) { return nil, nil })
+
+	emails := make(map[any]bool)
+	for seq := 1; seq <= 50; seq++ {
+		cols, vals, err := gen.row(authors, seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := make(map[string]any)
+		for i, c := range cols {
+			row[c] = vals[i]
+		}
+		for _, skipped := range []string{"id", "created_at", "updated_at", "deleted_at"} {
+			if _, ok := row[skipped]; ok {
+				t.Fatalf("column %s should be left to the database", skipped)
+			}
+		}
+		email, _ := row["email"].(string)
+		if !strings.HasSuffix(email, "@example.com") || emails[email] {
+			t.Errorf("email %q is not a fresh example.com address", email)
+		}
+		emails[email] = true
+		if handle, _ := row["handle"].(string); handle == "" || len(handle) > 12 {
+			t.Errorf("handle %q does not fit VARCHAR(12)", handle)
+		}
+		if row["first_name"] == nil {
+			t.Error("first_name is NOT NULL")
+		}
+	}
+}
+
+func TestRowGenerator_MissingParentSkipsTable(t *testing.T) {
+	plan := seedTestPlan(t)
+	gen := newRowGenerator(proptest.New(1), dburl.DialectSQLite, func(string) ([]int64, error) { return nil, nil })
+	_, _, err := gen.row(plan.Schema.Tables["books"], 1)
+	if _, ok := err.(*skipTableError); !ok {
+		t.Fatalf("err = %v, want a skipTableError", err)
+	}
+}
+
+func TestGenerateSeedData_SQLite(t *testing.T) {
+	plan := seedTestPlan(t)
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "seed.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, m := range plan.Migrations {
+		if _, err := db.Exec(m.Instructions.Sqlite); err != nil {
+			t.Fatalf("migration %s: %v", m.Name, err)
+		}
+	}
+
+	ctx := context.Background()
+	opts := GenerateOptions{Rows: 5, Seed: 42}
+	results, err := GenerateSeedData(ctx, db, dburl.DialectSQLite, plan.Schema.Tables, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Reason != "" || r.Inserted+r.Skipped != opts.Rows || r.Inserted == 0 {
+			t.Errorf("unexpected result %+v", r)
+		}
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + r.Table).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != r.Inserted {
+			t.Errorf("%s: %d rows, reported %d", r.Table, count, r.Inserted)
+		}
+	}
+
+	var orphans int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM books WHERE author_id NOT IN (SELECT id FROM authors)`).Scan(&orphans); err != nil {
+		t.Fatal(err)
+	}
+	if orphans != 0 {
+		t.Errorf("%d books reference missing authors", orphans)
+	}
+
+	// A second run adds rows without colliding with the first.
+	if _, err := GenerateSeedData(ctx, db, dburl.DialectSQLite, plan.Schema.Tables, GenerateOptions{Rows: 5, Seed: 43}); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	var authors int
+	if err := db.QueryRow("SELECT COUNT(*) FROM authors").Scan(&authors); err != nil {
+		t.Fatal(err)
+	}
+	if authors != 10 {
+		t.Errorf("authors = %d after two runs, want 10", authors)
+	}
+}
+
+func TestParseDBSeedArgs(t *testing.T) {
+	opts, generate, err := parseDBSeedArgs([]string{"--generate", "20", "--seed=7", "--exclude-label", "billing"})
+	if err != nil || !generate {
+		t.Fatalf("generate = %v, err = %v", generate, err)
+	}
+	if want := (GenerateOptions{Rows: 20, Seed: 7, ExcludeLabels: []string{"billing"}}); !reflect.DeepEqual(opts, want) {
+		t.Errorf("opts = %+v, want %+v", opts, want)
+	}
+
+	if _, generate, err := parseDBSeedArgs(nil); err != nil || generate {
+		t.Errorf("no args should run the seed files, got generate = %v, err = %v", generate, err)
+	}
+	if opts, generate, err := parseDBSeedArgs([]string{"--env", "test"}); err != nil || generate || opts.Env != "test" {
+		t.Errorf("--env test: opts = %+v, generate = %v, err = %v", opts, generate, err)
+	}
+	for _, args := range [][]string{{"--generate", "0"}, {"--generate"}, {"--seed", "1"}, {"--bogus"}, {"--env", "prod"}} {
+		if _, _, err := parseDBSeedArgs(args); err == nil {
+			t.Errorf("parseDBSeedArgs(%v) should fail", args)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -15,8 +17,10 @@ import (
 
 	"github.com/shipq/shipq/cli"
 	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/seedgen"
 	"github.com/shipq/shipq/dburl"
 	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/internal/commands/shared"
 	shipqdag "github.com/shipq/shipq/internal/dag"
 	"github.com/shipq/shipq/internal/dbops"
 	"github.com/shipq/shipq/project"
@@ -29,8 +33,114 @@ type SeedFile struct {
 	FuncName string // Full function name (e.g., "Seed_dev")
 }
 
-// SeedCmd handles "shipq seed" - discovers and runs seed files.
-func SeedCmd() {
+// SeedEnvs are the seed sets --env selects. A seed named Seed_<env> or
+// Seed_<env>_* belongs to that environment; every other seed is shared and
+// runs in all of them.
+var SeedEnvs = []string{"dev", "test"}
+
+// Env returns the environment the seed belongs to, or "" for a shared seed.
+func (s SeedFile) Env() string {
+	for _, env := range SeedEnvs {
+		if s.Name == env || strings.HasPrefix(s.Name, env+"_") {
+			return env
+		}
+	}
+	return ""
+}
+
+// SelectSeeds returns the seeds that run for env: its own and the shared
+// ones, keeping their order.
+func SelectSeeds(seeds []SeedFile, env string) []SeedFile {
+	var selected []SeedFile
+	for _, s := range seeds {
+		if e := s.Env(); e == "" || e == env {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
+
+// SeedCmd handles "shipq seed [--env dev|test]" and "shipq seed new".
+func SeedCmd(args []string) {
+	if len(args) > 0 && args[0] == "new" {
+		NewSeedCmd(args[1:])
+		return
+	}
+	env, err := parseSeedArgs(args)
+	if err != nil {
+		cli.Fatal(err.Error())
+	}
+	runSeedFiles(env)
+}
+
+const seedUsage = `Usage: shipq seed [--env dev|test]
+       shipq seed new <name> [--env dev|test]
+
+Runs the Seed_* functions in seeds/, in name order. Seeds named
+Seed_dev_* or Seed_test_* only run for that --env; the others always run.
+
+  --env dev    Seed the dev database with the dev set (default)
+  --env test   Seed the test database with the test set
+
+'shipq seed new <name>' writes seeds/<name>_seed.go, an empty seed that
+inserts rows through the generated query runner. With --env the seed is
+named Seed_<env>_<name>.
+`
+
+func parseSeedArgs(args []string) (string, error) {
+	env := ""
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "-h", "--help":
+			fmt.Print(seedUsage)
+			os.Exit(0)
+		case "--env":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return "", fmt.Errorf("--env requires a value")
+				}
+				i++
+				value = args[i]
+			}
+			if err := validateSeedEnv(value); err != nil {
+				return "", err
+			}
+			env = value
+		default:
+			return "", fmt.Errorf("unknown argument for 'shipq seed': %s", args[i])
+		}
+	}
+	return env, nil
+}
+
+func validateSeedEnv(env string) error {
+	if !slices.Contains(SeedEnvs, env) {
+		return fmt.Errorf("--env must be one of %s, got %q", strings.Join(SeedEnvs, ", "), env)
+	}
+	return nil
+}
+
+// seedDatabaseURL returns the database seeded for env: [db] database_url for
+// dev (or no env), the test database derived from it for test.
+func seedDatabaseURL(ini *inifile.File, env string) (string, error) {
+	databaseURL := ini.Get("db", "database_url")
+	if databaseURL == "" {
+		return "", fmt.Errorf("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+	if env == "test" {
+		return dburl.TestDatabaseURL(databaseURL)
+	}
+	return databaseURL, nil
+}
+
+// runSeedFiles discovers and runs the seed files selected by env ("" means
+// dev).
+func runSeedFiles(env string) {
+	if env == "" {
+		env = "dev"
+	}
+
 	// Step 1: Find project roots
 	roots, err := project.FindProjectRoots()
 	if err != nil {
@@ -57,9 +167,9 @@ func SeedCmd() {
 		cli.FatalErr("failed to parse shipq.ini", err)
 	}
 
-	databaseURL := ini.Get("db", "database_url")
-	if databaseURL == "" {
-		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	databaseURL, err := seedDatabaseURL(ini, env)
+	if err != nil {
+		cli.Fatal(err.Error())
 	}
 
 	dialect, err := dburl.InferDialectFromDBUrl(databaseURL)
@@ -76,11 +186,17 @@ func SeedCmd() {
 
 	if len(seeds) == 0 {
 		cli.Info("No seed files found in seeds/")
-		cli.Info("Run 'shipq auth' to generate auth seed files")
+		cli.Info("Run 'shipq auth' to generate auth seed files, or 'shipq seed new <name>'")
 		return
 	}
 
-	cli.Infof("Found %d seed file(s)", len(seeds))
+	seeds = SelectSeeds(seeds, env)
+	if len(seeds) == 0 {
+		cli.Infof("No seeds for --env %s", env)
+		return
+	}
+
+	cli.Infof("Found %d seed(s) for %s", len(seeds), env)
 
 	// Step 4: Build and run the seed runner
 	cli.Info("Running seeds...")
@@ -328,3 +444,72 @@ func openDatabase(dbURL, dialect string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// seedNamePattern matches the names accepted by "shipq seed new".
+var seedNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// NewSeedCmd handles "shipq seed new <name> [--env dev|test]": it writes an
+// empty seed file to seeds/ for the user to fill in.
+func NewSeedCmd(args []string) {
+	var name string
+	var rest []string
+	for _, arg := range args {
+		if name == "" && !strings.HasPrefix(arg, "-") {
+			name = arg
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	env, err := parseSeedArgs(rest)
+	if err != nil {
+		cli.Fatal(err.Error())
+	}
+	if name == "" {
+		cli.Fatal("usage: shipq seed new <name> [--env dev|test]")
+	}
+	if !seedNamePattern.MatchString(name) {
+		cli.Fatal(fmt.Sprintf("invalid seed name %q: use lowercase letters, digits and underscores", name))
+	}
+	if env != "" {
+		name = env + "_" + name
+	}
+
+	cfg, err := shared.LoadProjectConfig()
+	if err != nil {
+		cli.FatalErr("not in a shipq project", err)
+	}
+	if cfg.Dialect == "" {
+		cli.Fatal("db.database_url not configured in shipq.ini\n  Run 'shipq db setup' first")
+	}
+
+	seedsPath := filepath.Join(cfg.ShipqRoot, "seeds")
+	existing, err := DiscoverSeeds(seedsPath)
+	if err != nil {
+		cli.FatalErr("failed to discover seeds", err)
+	}
+	for _, s := range existing {
+		if s.Name == name {
+			cli.Fatal(fmt.Sprintf("%s already exists in %s", s.FuncName, s.Path))
+		}
+	}
+
+	code, err := seedgen.GenerateSeedScaffold(seedgen.SeedGenConfig{
+		ModulePath: cfg.ModulePath,
+		Dialect:    cfg.Dialect,
+	}, name)
+	if err != nil {
+		cli.FatalErr("failed to generate seed", err)
+	}
+
+	filePath := filepath.Join(seedsPath, name+"_seed.go")
+	if _, err := os.Stat(filePath); err == nil {
+		cli.Fatal(fmt.Sprintf("%s already exists", filePath))
+	}
+	if err := os.MkdirAll(seedsPath, 0755); err != nil {
+		cli.FatalErr("failed to create seeds directory", err)
+	}
+	if err := os.WriteFile(filePath, code, 0644); err != nil {
+		cli.FatalErr("failed to write seed file", err)
+	}
+	cli.Successf("Created seeds/%s_seed.go (Seed_%s)", name, name)
+}
@@ -0,0 +1,79 @@
+package seed
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/gentest/gofile"
+	"github.com/shipq/shipq/codegen/seedgen"
+)
+
+func TestSelectSeeds(t *testing.T) {
+	seeds := []SeedFile{
+		{Name: "dev"},
+		{Name: "dev_auth"},
+		{Name: "developers"},
+		{Name: "roles"},
+		{Name: "test_fixtures"},
+	}
+	names := func(seeds []SeedFile) []string {
+		var out []string
+		for _, s := range seeds {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+
+	if got, want := names(SelectSeeds(seeds, "dev")), []string{"dev", "dev_auth", "developers", "roles"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dev seeds = %v, want %v", got, want)
+	}
+	if got, want := names(SelectSeeds(seeds, "test")), []string{"developers", "roles", "test_fixtures"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("test seeds = %v, want %v", got, want)
+	}
+}
+
+func TestParseSeedArgs(t *testing.T) {
+	for args, want := range map[string]string{"": "", "--env test": "test", "--env=dev": "dev"} {
+		got, err := parseSeedArgs(strings.Fields(args))
+		if err != nil || got != want {
+			t.Errorf("parseSeedArgs(%q) = %q, %v; want %q", args, got, err, want)
+		}
+	}
+	for _, args := range [][]string{{"--env"}, {"--env", "prod"}, {"--bogus"}} {
+		if _, err := parseSeedArgs(args); err == nil {
+			t.Errorf("parseSeedArgs(%v) should fail", args)
+		}
+	}
+}
+
+func TestGenerateSeedScaffold_IsDiscovered(t *testing.T) {
+	code, err := seedgen.GenerateSeedScaffold(seedgen.SeedGenConfig{ModulePath: "example.com/app", Dialect: "postgres"}, "test_products")
+	if err != nil {
+		t.Fatalf("GenerateSeedScaffold: %v", err)
+	}
+	f := gofile.Parse(t, "test_products_seed.go", code)
+	if !f.HasImport("example.com/app/shipq/queries/postgres") {
+		t.Error("missing the postgres query runner import")
+	}
+	f.AssertSignature("Seed_test_products", "func Seed_test_products(db *sql.DB) error")
+	f.AssertSignature("seedTestProducts", "func seedTestProducts(ctx context.Context, runner queries.Runner) error")
+	if f.Calls("Seed_test_products", "seedTestProducts") != 1 {
+		t.Error("Seed_test_products should call seedTestProducts once")
+	}
+	f.AssertExprs("Seed_test_products", "seedTestProducts(context.Background(), dbrunner.NewQueryRunner(tx))")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "test_products_seed.go"), code, 0644); err != nil {
+		t.Fatal(err)
+	}
+	seeds, err := DiscoverSeeds(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seeds) != 1 || seeds[0].FuncName != "Seed_test_products" || seeds[0].Env() != "test" {
+		t.Errorf("discovered %+v, want Seed_test_products in the test set", seeds)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	configpkg "github.com/shipq/shipq/codegen/httpserver/config"
+	"github.com/shipq/shipq/config"
 	"github.com/shipq/shipq/inifile"
 	"github.com/shipq/shipq/registry"
 )
@@ -100,18 +101,23 @@ func buildConfigOptions(ini *inifile.File, cfg *ProjectConfig) registry.ConfigEa
 	oauthGitHub := IsOAuthGitHubEnabled(ini)
 
 	dd := BuildDevDefaults(ini, cfg.DatabaseURL)
+	// Invalid pool settings are reported by registry.Run, which regenerates
+	// the config package with them.
+	pool, _ := config.ParseDatabasePoolConfig(ini)
 
 	return registry.ConfigEarlyOptions{
-		ShipqRoot:      cfg.ShipqRoot,
-		GoModRoot:      cfg.GoModRoot,
-		Dialect:        cfg.Dialect,
-		FilesEnabled:   IsFeatureEnabled(ini, "files"),
-		WorkersEnabled: IsFeatureEnabled(ini, "workers"),
-		OAuthGoogle:    oauthGoogle,
-		OAuthGitHub:    oauthGitHub,
-		EmailEnabled:   IsFeatureEnabled(ini, "email"),
-		DevDefaults:    dd,
-		CustomEnvVars:  registry.ParseCustomEnvVars(ini),
+		ShipqRoot:       cfg.ShipqRoot,
+		GoModRoot:       cfg.GoModRoot,
+		Dialect:         cfg.Dialect,
+		FilesEnabled:    IsFeatureEnabled(ini, "files"),
+		WorkersEnabled:  IsFeatureEnabled(ini, "workers"),
+		OAuthGoogle:     oauthGoogle,
+		OAuthGitHub:     oauthGitHub,
+		EmailEnabled:    IsFeatureEnabled(ini, "email"),
+		DevDefaults:     dd,
+		CustomEnvVars:   registry.ParseCustomEnvVars(ini),
+		DatabaseTLSEnvs: config.ParseDatabaseTLSEnvs(ini),
+		DatabasePool:    pool,
 	}
 }
 
@@ -83,6 +83,7 @@ func SignupCmd() {
 		EmailEnabled:    emailEnabled,
 		OAuthProviders:  oauthProviders,
 		SignupEnabled:   true,
+		BearerAuth:      authgen.BearerAuthFromIni(ini),
 	}
 
 	fmt.Println("Generating signup handler...")
@@ -0,0 +1,61 @@
+package smoke
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/openapigen"
+	"github.com/shipq/shipq/project"
+	"github.com/shipq/shipq/registry"
+)
+
+// SmokeCmd implements the "shipq smoke" command.
+// It generates cmd/smoke/main.go from the routes and OpenAPI document
+// recorded by the last `shipq handler compile`. The resulting program checks
+// every GET endpoint of a running server and exits non-zero on failures, so
+// it can run as a post-deploy smoke check.
+func SmokeCmd(args []string) {
+	if len(args) > 0 {
+		cli.Fatal(fmt.Sprintf("unknown argument for 'shipq smoke': %s", args[0]))
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	handlers, err := registry.ReadRoutesManifest(roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to load routes", err)
+	}
+	specJSON, err := registry.ReadOpenAPIManifest(roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to load OpenAPI document", err)
+	}
+
+	code, err := openapigen.GenerateSmokeMain(openapigen.SmokeGenConfig{
+		Handlers: handlers,
+		SpecJSON: specJSON,
+	})
+	if err != nil {
+		cli.FatalErr("failed to generate smoke program", err)
+	}
+
+	smokeDir := filepath.Join(roots.ShipqRoot, "cmd", "smoke")
+	if err := codegen.EnsureDir(smokeDir); err != nil {
+		cli.FatalErr("failed to create cmd/smoke directory", err)
+	}
+	outputPath := filepath.Join(smokeDir, "main.go")
+	if _, err := codegen.WriteFileIfChanged(outputPath, code); err != nil {
+		cli.FatalErr("failed to write smoke program", err)
+	}
+
+	cli.Successf("Generated %s", outputPath)
+	fmt.Println("")
+	fmt.Println("Run it against a seeded server:")
+	fmt.Println("  go run ./cmd/smoke -base-url http://localhost:8080")
+	fmt.Println("")
+	fmt.Println("Pass -cookie \"session=...\" to include routes that require auth.")
+}
@@ -3,6 +3,12 @@ package start
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/inifile"
+	dbcmd "github.com/shipq/shipq/internal/commands/db"
+	"github.com/shipq/shipq/project"
 )
 
 // validServices is the authoritative list of services that "shipq start" supports.
@@ -34,6 +40,12 @@ Services:
 Options (server and worker only):
   --no-watch  Disable hot reload and use plain 'go run' instead
 
+Options (postgres and mysql only):
+  --docker    Run the server in an ephemeral Docker container instead of
+              local binaries, create the project's databases and write
+              database_url to shipq.ini. Returns once the server is ready;
+              stop it with 'shipq db stop'.
+
 Each service runs in the foreground. Open a separate terminal tab for each
 one you need, or use a process manager such as overmind / goreman.
 
@@ -53,10 +65,25 @@ func hasFlag(args []string, flag string) bool {
 // StartCmd dispatches "shipq start <service>" to the correct starter function.
 // args carries any remaining CLI arguments after the service name (e.g. ["--no-watch"]).
 func StartCmd(service string, args []string) {
+	switch service {
+	case "postgres", "mysql", "sqlite":
+		if startLiteDatabase() {
+			return
+		}
+	}
+
 	switch service {
 	case "postgres":
+		if hasFlag(args, "--docker") {
+			StartDocker(service)
+			return
+		}
 		StartPostgres()
 	case "mysql":
+		if hasFlag(args, "--docker") {
+			StartDocker(service)
+			return
+		}
 		StartMySQL()
 	case "sqlite":
 		StartSQLite()
@@ -89,3 +116,23 @@ func ValidServices() []string {
 	copy(out, validServices)
 	return out
 }
+
+// startLiteDatabase handles "shipq start <database>" in a lite project,
+// where the database is the embedded SQLite file and there is nothing to
+// start. It reports false when the project is not in lite mode.
+func startLiteDatabase() bool {
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		return false
+	}
+	ini, err := inifile.ParseFile(filepath.Join(roots.ShipqRoot, project.ShipqIniFile))
+	if err != nil || !dbcmd.IsLiteMode(ini) {
+		return false
+	}
+	cli.Info("Lite mode: the database is an embedded SQLite file, so there is no server to start.")
+	if url := ini.Get("db", "database_url"); url != "" {
+		cli.Infof("  database_url = %s", url)
+	}
+	cli.Info("Run 'shipq db promote <postgres|mysql>' to move to a server database.")
+	return true
+}
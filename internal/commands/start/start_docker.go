@@ -0,0 +1,251 @@
+package start
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/inifile"
+	dbcmd "github.com/shipq/shipq/internal/commands/db"
+	"github.com/shipq/shipq/project"
+)
+
+// dockerDatabase describes the container "shipq start <database> --docker"
+// runs for one dialect.
+type dockerDatabase struct {
+	Dialect  string
+	Image    string
+	BasePort int    // the host port is BasePort plus an offset derived from the project
+	Port     int    // port the server listens on inside the container
+	User     string // superuser the connection URL authenticates as
+	Env      []string
+	Ready    []string // command run in the container that succeeds once the server accepts TCP connections
+}
+
+// Credentials of the Docker databases. The containers only listen on
+// localhost and hold development data, so the password is fixed.
+const dockerPassword = "shipq"
+
+// dockerDatabases are the databases "shipq start --docker" supports. The
+// readiness checks connect over TCP because both images first run their
+// initialisation scripts on a server that only listens on a Unix socket.
+var dockerDatabases = map[string]dockerDatabase{
+	"postgres": {
+		Dialect:  "postgres",
+		Image:    "postgres:17",
+		BasePort: 15432,
+		Port:     5432,
+		User:     "shipq",
+		Env:      []string{"POSTGRES_USER=shipq", "POSTGRES_PASSWORD=" + dockerPassword},
+		Ready:    []string{"pg_isready", "-h", "127.0.0.1", "-U", "shipq"},
+	},
+	"mysql": {
+		Dialect:  "mysql",
+		Image:    "mysql:8.4",
+		BasePort: 13306,
+		Port:     3306,
+		User:     "root",
+		Env:      []string{"MYSQL_ROOT_PASSWORD=" + dockerPassword},
+		Ready:    []string{"mysqladmin", "ping", "-h", "127.0.0.1", "-uroot", "-p" + dockerPassword, "--silent"},
+	},
+}
+
+// dockerReadyTimeout bounds how long StartDocker waits for a container's
+// server to accept connections, including the image's first-run setup.
+const dockerReadyTimeout = 90 * time.Second
+
+// containerNameInvalid matches the characters a container name may not
+// contain.
+var containerNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// dockerContainerName returns the name of the project's container for the
+// service, e.g. "shipq-myapp-postgres".
+func dockerContainerName(projectName, service string) string {
+	name := containerNameInvalid.ReplaceAllString(strings.ToLower(projectName), "-")
+	return "shipq-" + strings.Trim(name, "-.") + "-" + service
+}
+
+// dockerHostPort returns the host port the project's container publishes.
+// It is derived from the project root so the same project always gets the
+// same port while two projects rarely collide.
+func dockerHostPort(d dockerDatabase, shipqRoot string) int {
+	h := fnv.New32a()
+	h.Write([]byte(shipqRoot))
+	return d.BasePort + int(h.Sum32()%1000)
+}
+
+// dockerServerURL returns the URL of the container's server, connecting
+// to the maintenance database.
+func dockerServerURL(d dockerDatabase, hostPort int) string {
+	if d.Dialect == "postgres" {
+		return fmt.Sprintf("postgres://%s:%s@localhost:%d/postgres", d.User, dockerPassword, hostPort)
+	}
+	return fmt.Sprintf("mysql://%s:%s@localhost:%d/", d.User, dockerPassword, hostPort)
+}
+
+// dockerRunArgs returns the arguments of the "docker run" starting the
+// container. It is removed when it stops, so its data is ephemeral.
+func dockerRunArgs(d dockerDatabase, name string, hostPort int) []string {
+	args := []string{"run", "--detach", "--rm", "--name", name,
+		"--publish", fmt.Sprintf("127.0.0.1:%d:%d", hostPort, d.Port)}
+	for _, env := range d.Env {
+		args = append(args, "--env", env)
+	}
+	return append(args, d.Image)
+}
+
+// StartDocker implements "shipq start <postgres|mysql> --docker". It runs
+// the database in an ephemeral container named after the project, waits
+// until the server accepts connections, creates the project's databases
+// and points shipq.ini's database_url at them. It returns once the
+// container is ready; "shipq db stop" removes it.
+func StartDocker(service string) {
+	d, ok := dockerDatabases[service]
+	if !ok {
+		cli.Fatal(fmt.Sprintf("--docker supports postgres and mysql, not %s", service))
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		cli.FatalErr("--docker needs the docker command", err)
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("not in a shipq project", err)
+	}
+	projectName := project.GetProjectName(roots.ShipqRoot)
+	name := dockerContainerName(projectName, service)
+	hostPort := dockerHostPort(d, roots.ShipqRoot)
+
+	if dockerContainerRunning(name) {
+		cli.Infof("Container %s is already running", name)
+	} else {
+		cli.Infof("Starting %s in container %s...", d.Image, name)
+		if out, err := runDocker(dockerRunArgs(d, name, hostPort)...); err != nil {
+			cli.FatalErr("failed to start container", fmt.Errorf("%w: %s", err, out))
+		}
+	}
+
+	cli.Info("Waiting for the database to accept connections...")
+	if err := waitForDocker(name, d.Ready, dockerReadyTimeout); err != nil {
+		cli.FatalErr("database did not become ready", err)
+	}
+
+	databaseURL, err := dbcmd.SetupServerDatabases(d.Dialect, dockerServerURL(d, hostPort), projectName)
+	if err != nil {
+		cli.FatalErr("failed to set up database", err)
+	}
+
+	shipqIniPath := filepath.Join(roots.ShipqRoot, project.ShipqIniFile)
+	ini, err := inifile.ParseFile(shipqIniPath)
+	if err != nil {
+		cli.FatalErr("failed to parse shipq.ini", err)
+	}
+	ini.Set("db", "database_url", databaseURL)
+	if err := ini.WriteFile(shipqIniPath); err != nil {
+		cli.FatalErr("failed to write shipq.ini", err)
+	}
+
+	cli.Successf("%s is running in container %s", service, name)
+	cli.Infof("  database_url = %s", databaseURL)
+	cli.Info("The container's data is discarded when it stops. Stop it with 'shipq db stop'.")
+}
+
+// StopDocker implements "shipq db stop [postgres|mysql]". It stops the
+// project's database containers started with --docker, or only the named
+// one.
+func StopDocker(args []string) {
+	services := []string{"postgres", "mysql"}
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "--help", "help":
+			fmt.Print(stopUsage)
+			os.Exit(0)
+		case "postgres", "mysql":
+			services = args[:1]
+		default:
+			cli.Fatal(fmt.Sprintf("unknown database %q (supported: postgres, mysql)", args[0]))
+		}
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		cli.FatalErr("'shipq db stop' needs the docker command", err)
+	}
+
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("not in a shipq project", err)
+	}
+	projectName := project.GetProjectName(roots.ShipqRoot)
+
+	stopped := 0
+	for _, service := range services {
+		name := dockerContainerName(projectName, service)
+		if !dockerContainerRunning(name) {
+			continue
+		}
+		if out, err := runDocker("stop", name); err != nil {
+			cli.FatalErr("failed to stop container "+name, fmt.Errorf("%w: %s", err, out))
+		}
+		cli.Successf("Stopped %s", name)
+		stopped++
+	}
+	if stopped == 0 {
+		cli.Info("No database container of this project is running")
+	}
+}
+
+const stopUsage = `Usage: shipq db stop [postgres|mysql]
+
+Stop and remove the project's database containers started with
+'shipq start <postgres|mysql> --docker', or only the named one. Their data
+is discarded; database_url in shipq.ini is left unchanged.
+`
+
+// dockerContainerRunning reports whether a container named name is running.
+func dockerContainerRunning(name string) bool {
+	out, err := runDocker("inspect", "--format", "{{.State.Running}}", name)
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// waitForDocker runs check in container name until it succeeds, with
+// exponential backoff and a timeout.
+func waitForDocker(name string, check []string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	interval := 250 * time.Millisecond
+	maxInterval := 2 * time.Second
+
+	var out string
+	var err error
+	for time.Now().Before(deadline) {
+		out, err = runDocker(append([]string{"exec", name}, check...)...)
+		if err == nil {
+			return nil
+		}
+		if !dockerContainerRunning(name) {
+			return fmt.Errorf("container %s exited; see 'docker logs %s'", name, name)
+		}
+		time.Sleep(interval)
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+	return fmt.Errorf("timed out after %s: %v: %s", timeout, err, strings.TrimSpace(out))
+}
+
+// runDocker runs the docker command with args and returns its combined
+// output.
+func runDocker(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
@@ -0,0 +1,160 @@
+// Package test implements "shipq test" subcommands.
+package test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/crud"
+	"github.com/shipq/shipq/codegen/resourcegen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
+	"github.com/shipq/shipq/dburl"
+	"github.com/shipq/shipq/inifile"
+	"github.com/shipq/shipq/project"
+)
+
+// ConcurrencyCmd implements "shipq test concurrency [go test flags]".
+// It writes api/<table>/spec/zz_generated_concurrency_test.go for every
+// resource generated by `shipq resource`, then runs those tests with -race.
+// Extra arguments are passed through to `go test`.
+func ConcurrencyCmd(args []string) {
+	roots, err := project.FindProjectRoots()
+	if err != nil {
+		cli.FatalErr("failed to find project", err)
+	}
+
+	moduleInfo, err := codegen.GetModuleInfo(roots.GoModRoot, roots.ShipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to read module info", err)
+	}
+	modulePath := moduleInfo.FullImportPath("")
+
+	dialect := ""
+	if ini, err := inifile.ParseFile(filepath.Join(roots.ShipqRoot, project.ShipqIniFile)); err == nil {
+		if u := ini.Get("db", "database_url"); u != "" {
+			dialect, _ = dburl.InferDialectFromDBUrl(u)
+		}
+	}
+	if dialect == "" {
+		cli.Fatal("no [db] database_url in shipq.ini; run 'shipq db setup' first")
+	}
+
+	schemaData, err := os.ReadFile(filepath.Join(roots.ShipqRoot, "shipq", "db", "migrate", "schema.json"))
+	if err != nil {
+		cli.FatalErr("failed to read schema.json (run 'shipq migrate up' first)", err)
+	}
+	plan, err := migrate.PlanFromJSON(schemaData)
+	if err != nil {
+		cli.FatalErr("failed to parse schema.json", err)
+	}
+
+	tables := concurrencyTables(roots.ShipqRoot, plan.Schema.Tables)
+	if len(tables) == 0 {
+		cli.Fatal("no resources to test; generate one with 'shipq resource <table> all'")
+	}
+
+	allTableNames := make([]string, 0, len(plan.Schema.Tables))
+	for name := range plan.Schema.Tables {
+		allTableNames = append(allTableNames, name)
+	}
+	crudCfg, crudErr := crud.LoadCRUDConfigWithTables(roots.ShipqRoot, allTableNames, plan.Schema.Tables)
+
+	var pkgs []string
+	for _, tableName := range tables {
+		scopeColumn := ""
+		if crudErr == nil {
+			if opts, ok := crudCfg.TableOpts[tableName]; ok {
+				scopeColumn = opts.ScopeColumn
+			}
+		}
+
+		code, err := resourcegen.GenerateConcurrencyTest(resourcegen.ConcurrencyTestGenConfig{
+			ModulePath:  modulePath,
+			TableName:   tableName,
+			Table:       plan.Schema.Tables[tableName],
+			Dialect:     dialect,
+			ScopeColumn: scopeColumn,
+		})
+		if err != nil {
+			cli.FatalErr("failed to generate concurrency test for "+tableName, err)
+		}
+		outputPath := filepath.Join(roots.ShipqRoot, "api", tableName, "spec", resourcegen.ConcurrencyTestFile)
+		written, err := codegen.WriteFileIfChanged(outputPath, code)
+		if err != nil {
+			cli.FatalErr("failed to write "+outputPath, err)
+		}
+		if written {
+			fmt.Printf("  Generated api/%s/spec/%s\n", tableName, resourcegen.ConcurrencyTestFile)
+		}
+		pkgs = append(pkgs, "./api/"+tableName+"/spec")
+	}
+
+	fmt.Println("")
+	fmt.Println("Running concurrency tests with -race...")
+	cmd := exec.Command("go", goTestArgs(pkgs, args)...)
+	cmd.Dir = roots.ShipqRoot
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		cli.FatalErr("failed to run go test", err)
+	}
+}
+
+// concurrencyTables returns, sorted, the tables that have the generated
+// fixture and spec helpers the concurrency test builds on, and a public_id
+// column for the runner methods to address rows by.
+func concurrencyTables(shipqRoot string, tables map[string]ddl.Table) []string {
+	var names []string
+	for name, table := range tables {
+		if table.IsJunctionTable || !hasColumn(table, "public_id") {
+			continue
+		}
+		apiDir := filepath.Join(shipqRoot, "api", name)
+		if !fileExists(filepath.Join(apiDir, "fixture", "fixture.go")) ||
+			!fileExists(filepath.Join(apiDir, "spec", "helpers_test.go")) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// goTestArgs builds the `go test` arguments for the concurrency packages.
+// extra comes last so callers can override -count or add -v.
+func goTestArgs(pkgs, extra []string) []string {
+	args := []string{
+		"test",
+		"-race",
+		"-tags", resourcegen.ConcurrencyBuildTag,
+		"-run", "^TestConcurrency_",
+		"-count=1",
+	}
+	args = append(args, extra...)
+	return append(args, pkgs...)
+}
+
+func hasColumn(table ddl.Table, name string) bool {
+	for _, col := range table.Columns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
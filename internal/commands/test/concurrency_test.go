@@ -0,0 +1,54 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shipq/shipq/db/portsql/ddl"
+)
+
+func TestConcurrencyTables(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{
+		"api/books/fixture/fixture.go",
+		"api/books/spec/helpers_test.go",
+		"api/authors/fixture/fixture.go",
+		"api/authors/spec/helpers_test.go",
+		"api/tags/fixture/fixture.go", // no spec helpers
+		"api/counters/fixture/fixture.go",
+		"api/counters/spec/helpers_test.go",
+	} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withPublicID := ddl.Table{Columns: []ddl.ColumnDefinition{{Name: "id"}, {Name: "public_id"}}}
+	tables := map[string]ddl.Table{
+		"books":    withPublicID,
+		"authors":  withPublicID,
+		"tags":     withPublicID,
+		"counters": {Columns: []ddl.ColumnDefinition{{Name: "id"}}},
+		"comments": withPublicID, // never generated
+	}
+
+	got := concurrencyTables(root, tables)
+	want := []string{"authors", "books"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("concurrencyTables() = %v, want %v", got, want)
+	}
+}
+
+func TestGoTestArgs(t *testing.T) {
+	got := goTestArgs([]string{"./api/books/spec"}, []string{"-v"})
+	want := []string{"test", "-race", "-tags", "concurrency", "-run", "^TestConcurrency_", "-count=1", "-v", "./api/books/spec"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("goTestArgs() = %v, want %v", got, want)
+	}
+}
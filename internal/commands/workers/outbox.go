@@ -0,0 +1,89 @@
+package workers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/shipq/shipq/cli"
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/channelgen"
+	"github.com/shipq/shipq/inifile"
+)
+
+// Defaults for the [outbox] settings, matching the channel library's
+// OutboxRelay defaults.
+const (
+	DefaultOutboxPollInterval = time.Second
+	DefaultOutboxBatchSize    = 100
+	DefaultOutboxMaxAttempts  = 10
+)
+
+// OutboxRelayConfig reads the [outbox] section of shipq.ini. It returns nil
+// when the section is absent, i.e. `shipq outbox` hasn't been run.
+func OutboxRelayConfig(ini *inifile.File) (*channelgen.OutboxRelay, error) {
+	section := ini.Section("outbox")
+	if section == nil {
+		return nil, nil
+	}
+	relay := &channelgen.OutboxRelay{
+		PollIntervalMs: DefaultOutboxPollInterval.Milliseconds(),
+		BatchSize:      DefaultOutboxBatchSize,
+		MaxAttempts:    DefaultOutboxMaxAttempts,
+	}
+	if v := section.Get("poll_interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 100*time.Millisecond {
+			return nil, fmt.Errorf("[outbox] poll_interval = %q must be a duration of at least 100ms, e.g. 1s", v)
+		}
+		relay.PollIntervalMs = d.Milliseconds()
+	}
+	for _, opt := range []struct {
+		key string
+		dst *int
+	}{
+		{"batch_size", &relay.BatchSize},
+		{"max_attempts", &relay.MaxAttempts},
+	} {
+		v := section.Get(opt.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("[outbox] %s = %q must be a positive integer", opt.key, v)
+		}
+		*opt.dst = n
+	}
+	return relay, nil
+}
+
+// outboxRelay returns the worker's outbox relay settings, exiting on an
+// invalid [outbox] section.
+func outboxRelay(ini *inifile.File) *channelgen.OutboxRelay {
+	relay, err := OutboxRelayConfig(ini)
+	if err != nil {
+		cli.FatalErr("invalid outbox config", err)
+	}
+	return relay
+}
+
+// writeOutboxQuerydefs regenerates querydefs/outbox_events/queries.go when
+// the outbox is enabled, ahead of the db compile that builds the runner
+// methods the write handlers and the relay call.
+func writeOutboxQuerydefs(ini *inifile.File, shipqRoot, importPrefix string) {
+	if ini.Section("outbox") == nil {
+		return
+	}
+	dir := filepath.Join(shipqRoot, "querydefs", channelgen.OutboxTable)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		cli.FatalErr("failed to create querydefs directory", err)
+	}
+	code := channelgen.GenerateOutboxQuerydefs(importPrefix)
+	if _, err := codegen.WriteGeneratedFile(filepath.Join(dir, "queries.go"), code); err != nil {
+		cli.FatalErr("failed to write outbox querydefs", err)
+	}
+	fmt.Println("  Generated querydefs/outbox_events/queries.go")
+}
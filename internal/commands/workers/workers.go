@@ -16,6 +16,7 @@ import (
 	"github.com/shipq/shipq/codegen/embed"
 	"github.com/shipq/shipq/codegen/llmgen"
 	codegenMigrate "github.com/shipq/shipq/codegen/migrate"
+	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
 	"github.com/shipq/shipq/dburl"
 	"github.com/shipq/shipq/inifile"
 	"github.com/shipq/shipq/internal/commands/db"
@@ -255,6 +256,7 @@ func WorkersCmd() {
 		cli.FatalErr("failed to write querydefs", err)
 	}
 	fmt.Println("  Generated querydefs/job_results/queries.go")
+	writeOutboxQuerydefs(ini, roots.ShipqRoot, importPrefix)
 
 	fmt.Println("")
 	fmt.Println("Compiling queries...")
@@ -326,6 +328,9 @@ func WorkersCmd() {
 		CentrifugoHMACSecret: centrifugoHMACSecret,
 		CentrifugoWSURL:      centrifugoWSURL,
 		AutoMigrate:          autoMigrate,
+		ScheduledViews:       scheduledViews(roots.ShipqRoot),
+		RetentionPurges:      retentionPurges(roots.ShipqRoot),
+		Outbox:               outboxRelay(ini),
 	}
 
 	if err := channelgen.WriteWorkerMain(workerCfg, roots.ShipqRoot); err != nil {
@@ -501,6 +506,46 @@ func extractRedisAddr(redisURL string) string {
 	return addr
 }
 
+// scheduledViews returns the materialized views in the manifest written by
+// db compile that declare a refresh schedule.
+func scheduledViews(shipqRoot string) []channelgen.ScheduledView {
+	views, err := queryrunner.ReadViewManifest(shipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to load materialized views", err)
+	}
+	var scheduled []channelgen.ScheduledView
+	for _, v := range views {
+		if v.RefreshSchedule == "" {
+			continue
+		}
+		scheduled = append(scheduled, channelgen.ScheduledView{
+			Name:          v.Name,
+			RefreshMethod: v.RefreshMethod,
+			Schedule:      v.RefreshSchedule,
+		})
+	}
+	return scheduled
+}
+
+// retentionPurges returns the tables in the retention manifest written by
+// db compile, each purged on the manifest's schedule.
+func retentionPurges(shipqRoot string) []channelgen.RetentionPurge {
+	entries, err := queryrunner.ReadRetentionManifest(shipqRoot)
+	if err != nil {
+		cli.FatalErr("failed to load retention policies", err)
+	}
+	var purges []channelgen.RetentionPurge
+	for _, e := range entries {
+		purges = append(purges, channelgen.RetentionPurge{
+			Table:            e.Table,
+			PurgeMethod:      e.PurgeMethod,
+			RetentionSeconds: e.RetentionSeconds,
+			Schedule:         e.Schedule,
+		})
+	}
+	return purges
+}
+
 // generateExampleChannel generates a simple echo channel as documentation/scaffolding.
 func generateExampleChannel(modulePath string) string {
 	return fmt.Sprintf(`package example
@@ -539,7 +584,7 @@ import "context"
 // HandleEchoRequest is the handler for the echo channel.
 // It receives the dispatch message and sends back an echo response.
 func HandleEchoRequest(ctx context.Context, req *EchoRequest) error {
-	tc := TypedChannelFromContext(ctx)
+	tc := MustTypedChannelFromContext(ctx)
 	return tc.SendEchoResponse(ctx, &EchoResponse{
 		Echo: "Echo: " + req.Message,
 	})
@@ -101,6 +101,7 @@ func WorkersCompileCmd() {
 		cli.FatalErr("failed to write querydefs", err)
 	}
 	fmt.Println("  Generated querydefs/job_results/queries.go")
+	writeOutboxQuerydefs(ini, roots.ShipqRoot, importPrefix)
 
 	// ── Step 2: Compile queries ──────────────────────────────────────
 
@@ -160,6 +161,9 @@ func WorkersCompileCmd() {
 		CentrifugoHMACSecret: centrifugoHMACSecret,
 		CentrifugoWSURL:      centrifugoWSURL,
 		AutoMigrate:          autoMigrate,
+		ScheduledViews:       scheduledViews(roots.ShipqRoot),
+		RetentionPurges:      retentionPurges(roots.ShipqRoot),
+		Outbox:               outboxRelay(ini),
 	}
 
 	if err := channelgen.WriteWorkerMain(workerCfg, roots.ShipqRoot); err != nil {
@@ -20,6 +20,8 @@ const (
 	CmdFiles          CommandID = "files"
 	CmdWorkers        CommandID = "workers"
 	CmdWorkersCompile CommandID = "workers_compile"
+	CmdOutbox         CommandID = "outbox"
+	CmdQuotas         CommandID = "quotas"
 	CmdHealth         CommandID = "health"
 	CmdResource       CommandID = "resource"
 	CmdHandlerGen     CommandID = "handler_generate"
@@ -46,6 +48,8 @@ var commandNames = map[CommandID]string{
 	CmdFiles:          "files",
 	CmdWorkers:        "workers",
 	CmdWorkersCompile: "workers compile",
+	CmdOutbox:         "outbox",
+	CmdQuotas:         "quotas",
 	CmdHealth:         "health",
 	CmdResource:       "resource",
 	CmdHandlerGen:     "handler generate",
@@ -132,6 +136,16 @@ func Graph() *dag.Graph[CommandID] {
 			Description: "Recompile channel codegen",
 			HardDeps:    []CommandID{CmdWorkers},
 		},
+		{
+			ID:          CmdOutbox,
+			Description: "Add the transactional event outbox",
+			HardDeps:    []CommandID{CmdWorkers},
+		},
+		{
+			ID:          CmdQuotas,
+			Description: "Add per-scope row quotas",
+			HardDeps:    []CommandID{CmdDBSetup},
+		},
 		{
 			ID:          CmdEmail,
 			Description: "Add email verification + password reset",
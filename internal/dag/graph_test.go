@@ -434,6 +434,8 @@ func TestGraphContainsAllExpectedCommands(t *testing.T) {
 		shipqdag.CmdFiles,
 		shipqdag.CmdWorkers,
 		shipqdag.CmdWorkersCompile,
+		shipqdag.CmdOutbox,
+		shipqdag.CmdQuotas,
 		shipqdag.CmdResource,
 		shipqdag.CmdHandlerGen,
 		shipqdag.CmdHealth,
@@ -32,6 +32,10 @@ func SatisfiedFunc(shipqRoot string) func(CommandID) bool {
 			return workersSatisfied(shipqRoot)
 		case CmdEmail:
 			return emailSatisfied(shipqRoot)
+		case CmdOutbox:
+			return outboxSatisfied(shipqRoot)
+		case CmdQuotas:
+			return quotasSatisfied(shipqRoot)
 		case CmdFiles:
 			return filesSatisfied(shipqRoot)
 		case CmdLLMCompile:
@@ -91,6 +95,22 @@ func emailSatisfied(shipqRoot string) bool {
 	return ini.Section("email") != nil
 }
 
+func outboxSatisfied(shipqRoot string) bool {
+	ini, err := inifile.ParseFile(filepath.Join(shipqRoot, "shipq.ini"))
+	if err != nil {
+		return false
+	}
+	return ini.Section("outbox") != nil
+}
+
+func quotasSatisfied(shipqRoot string) bool {
+	ini, err := inifile.ParseFile(filepath.Join(shipqRoot, "shipq.ini"))
+	if err != nil {
+		return false
+	}
+	return ini.Section("quotas") != nil
+}
+
 func filesSatisfied(shipqRoot string) bool {
 	ini, err := inifile.ParseFile(filepath.Join(shipqRoot, "shipq.ini"))
 	if err != nil {
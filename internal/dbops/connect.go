@@ -127,13 +127,17 @@ func removeMySQLDBName(dsn string) string {
 	return dsn[:slashIdx+1]
 }
 
-// SQLiteURLToPath extracts the file path from a SQLite URL.
+// SQLiteURLToPath extracts the file path from a SQLite URL. An in-memory
+// database becomes a "file::memory:" URI so that SQLite honours cache=shared.
 func SQLiteURLToPath(sqliteURL string) string {
-	if len(sqliteURL) > 9 && sqliteURL[:9] == "sqlite://" {
-		return sqliteURL[9:]
+	path := sqliteURL
+	if len(path) > 9 && path[:9] == "sqlite://" {
+		path = path[9:]
+	} else if len(path) > 7 && path[:7] == "sqlite:" {
+		path = path[7:]
 	}
-	if len(sqliteURL) > 7 && sqliteURL[:7] == "sqlite:" {
-		return sqliteURL[7:]
+	if strings.HasPrefix(path, ":memory:") {
+		return "file:" + path
 	}
-	return sqliteURL
+	return path
 }
@@ -1,9 +1,14 @@
 package dbops_test
 
 import (
+	"context"
+	"database/sql"
 	"strings"
 	"testing"
 
+	_ "modernc.org/sqlite"
+
+	"github.com/shipq/shipq/dburl"
 	"github.com/shipq/shipq/internal/dbops"
 )
 
@@ -103,3 +108,46 @@ func TestMySQLURLToDSN(t *testing.T) {
 		}
 	})
 }
+
+func TestSQLiteURLToPath(t *testing.T) {
+	tests := map[string]string{
+		"sqlite:///path/to/db.sqlite":       "/path/to/db.sqlite",
+		"sqlite:.shipq/data/app.db":         ".shipq/data/app.db",
+		"sqlite://:memory:?cache=shared":    "file::memory:?cache=shared",
+		"sqlite::memory:":                   "file::memory:",
+		"sqlite:file::memory:?cache=shared": "file::memory:?cache=shared",
+	}
+	for url, want := range tests {
+		if got := dbops.SQLiteURLToPath(url); got != want {
+			t.Errorf("SQLiteURLToPath(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestSQLiteURLToPath_SharedMemoryAcrossConnections(t *testing.T) {
+	db, err := sql.Open("sqlite", dbops.SQLiteURLToPath(dburl.SQLiteMemoryURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	first, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	if _, err := first.ExecContext(ctx, "CREATE TABLE shared_memory_check (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	var n int
+	if err := second.QueryRowContext(ctx, "SELECT COUNT(*) FROM shared_memory_check").Scan(&n); err != nil {
+		t.Fatalf("second connection does not see the shared in-memory schema: %v", err)
+	}
+}
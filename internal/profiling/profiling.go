@@ -0,0 +1,127 @@
+// Package profiling implements the --cpuprofile, --memprofile and --verbose
+// flags of the compile commands, which capture pprof profiles and print a
+// per-phase timing summary so that slow compiles can be diagnosed.
+package profiling
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/shipq/shipq/codegen/phase"
+)
+
+// Options holds the profiling flags of a command.
+type Options struct {
+	CPUProfile string // file to write a CPU profile to, "" for none
+	MemProfile string // file to write a heap profile to, "" for none
+	Verbose    bool   // print the phase timing summary
+}
+
+// Usage describes the profiling flags for command help output.
+const Usage = `  --cpuprofile <file>  Write a CPU profile (go tool pprof) to <file>
+  --memprofile <file>  Write a heap profile (go tool pprof) to <file>
+  --verbose            Print how long each phase took`
+
+// ParseFlags removes the profiling flags from args and returns them along
+// with the remaining arguments, in order. Both "--flag value" and
+// "--flag=value" are accepted.
+func ParseFlags(args []string) (Options, []string, error) {
+	var opts Options
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		var target *string
+		switch name {
+		case "--cpuprofile":
+			target = &opts.CPUProfile
+		case "--memprofile":
+			target = &opts.MemProfile
+		case "--verbose":
+			if hasValue {
+				return Options{}, nil, fmt.Errorf("%s does not take a value", name)
+			}
+			opts.Verbose = true
+			continue
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+				return Options{}, nil, fmt.Errorf("%s requires a file name", name)
+			}
+			i++
+			value = args[i]
+		}
+		if value == "" {
+			return Options{}, nil, fmt.Errorf("%s requires a file name", name)
+		}
+		*target = value
+	}
+	return opts, rest, nil
+}
+
+// Start begins profiling as configured by opts: it starts the CPU profile
+// and turns on phase timing. The returned stop function writes the heap
+// profile, ends the CPU profile and, with Verbose, prints the phase summary
+// to w. Commands call it once they finish successfully; a command that
+// exits on an error leaves the CPU profile incomplete.
+func Start(opts Options, w io.Writer) (stop func() error, err error) {
+	begin := time.Now()
+	if opts.Verbose {
+		phase.Enable()
+	}
+
+	var cpuFile *os.File
+	if opts.CPUProfile != "" {
+		cpuFile, err = os.Create(opts.CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("failed to write CPU profile: %w", err)
+			}
+			fmt.Fprintf(w, "CPU profile written to %s\n", opts.CPUProfile)
+		}
+		if opts.MemProfile != "" {
+			if err := writeHeapProfile(opts.MemProfile); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Heap profile written to %s\n", opts.MemProfile)
+		}
+		if opts.Verbose {
+			phase.WriteSummary(w, time.Since(begin))
+		}
+		return nil
+	}, nil
+}
+
+// writeHeapProfile writes a heap profile of the live objects after a
+// garbage collection to path.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return f.Close()
+}
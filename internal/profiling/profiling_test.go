@@ -0,0 +1,73 @@
+package profiling
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/phase"
+)
+
+func TestParseFlags(t *testing.T) {
+	opts, rest, err := ParseFlags([]string{"--only", "posts", "--cpuprofile", "cpu.out", "--memprofile=mem.out", "--verbose"})
+	if err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	want := Options{CPUProfile: "cpu.out", MemProfile: "mem.out", Verbose: true}
+	if opts != want {
+		t.Errorf("ParseFlags() opts = %+v, want %+v", opts, want)
+	}
+	if !reflect.DeepEqual(rest, []string{"--only", "posts"}) {
+		t.Errorf("ParseFlags() rest = %v, want the other flags untouched", rest)
+	}
+}
+
+func TestParseFlags_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{"--cpuprofile"},
+		{"--cpuprofile", "--verbose"},
+		{"--memprofile="},
+		{"--verbose=true"},
+	} {
+		if _, _, err := ParseFlags(args); err == nil {
+			t.Errorf("ParseFlags(%v) should fail", args)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{
+		CPUProfile: filepath.Join(dir, "cpu.out"),
+		MemProfile: filepath.Join(dir, "mem.out"),
+		Verbose:    true,
+	}
+	var out bytes.Buffer
+	stop, err := Start(opts, &out)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	phase.Start(phase.Discovery)()
+	if err := stop(); err != nil {
+		t.Fatalf("stop() error = %v", err)
+	}
+
+	for _, path := range []string{opts.CPUProfile, opts.MemProfile} {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("expected a non-empty profile at %s (err = %v)", path, err)
+		}
+	}
+	// The profile paths come first, then the phase summary with the one
+	// phase that ran.
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 4 ||
+		lines[0] != "CPU profile written to "+opts.CPUProfile ||
+		lines[1] != "Heap profile written to "+opts.MemProfile ||
+		lines[2] != "Phase timings:" ||
+		strings.Fields(lines[3])[0] != phase.Discovery {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
@@ -27,24 +27,38 @@ func WithNamedClient(ctx context.Context, name string, c *Client) context.Contex
 }
 
 // ClientFromContext retrieves the default LLM client from the context.
-// Panics if no default client is present — this indicates the handler is not
-// running inside an LLM-enabled channel, or Setup was not called correctly.
-func ClientFromContext(ctx context.Context) *Client {
+// Returns (nil, false) if no default client is present.
+func ClientFromContext(ctx context.Context) (*Client, bool) {
 	return NamedClientFromContext(ctx, "")
 }
 
+// MustClientFromContext is like ClientFromContext but panics when no default
+// client is present — this indicates the handler is not running inside an
+// LLM-enabled channel, or Setup was not called correctly.
+func MustClientFromContext(ctx context.Context) *Client {
+	c, ok := ClientFromContext(ctx)
+	if !ok {
+		panic("llm.MustClientFromContext: no default Client in context — did your Setup call llm.WithClient?")
+	}
+	return c
+}
+
 // NamedClientFromContext retrieves a named LLM client from the context.
 // Use this when your Setup registered multiple clients for different
 // providers or models.
-// Panics if the named client is not present.
-func NamedClientFromContext(ctx context.Context, name string) *Client {
-	m := getClientMap(ctx)
+// Returns (nil, false) if the named client is not present.
+func NamedClientFromContext(ctx context.Context, name string) (*Client, bool) {
+	m, _ := ctx.Value(clientMapKey{}).(map[string]*Client)
 	c, ok := m[name]
-	if !ok || c == nil {
-		if name == "" {
-			panic("llm.ClientFromContext: no default Client in context — did your Setup call llm.WithClient?")
-		}
-		panic("llm.NamedClientFromContext: no Client named " + name + " in context — did your Setup call llm.WithNamedClient?")
+	return c, ok && c != nil
+}
+
+// MustNamedClientFromContext is like NamedClientFromContext but panics when
+// the named client is not present.
+func MustNamedClientFromContext(ctx context.Context, name string) *Client {
+	c, ok := NamedClientFromContext(ctx, name)
+	if !ok {
+		panic("llm.MustNamedClientFromContext: no Client named " + name + " in context — did your Setup call llm.WithNamedClient?")
 	}
 	return c
 }
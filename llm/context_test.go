@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClientFromContext(t *testing.T) {
+	ctx := context.Background()
+	if c, ok := ClientFromContext(ctx); ok || c != nil {
+		t.Fatalf("ClientFromContext on bare context = (%v, %v), want (nil, false)", c, ok)
+	}
+
+	def := NewClient(newMockProvider())
+	summary := NewClient(newMockProvider())
+	ctx = WithClient(ctx, def)
+	ctx = WithNamedClient(ctx, "summary", summary)
+
+	if c, ok := ClientFromContext(ctx); !ok || c != def {
+		t.Errorf("ClientFromContext = (%v, %v), want the default client", c, ok)
+	}
+	if c, ok := NamedClientFromContext(ctx, "summary"); !ok || c != summary {
+		t.Errorf("NamedClientFromContext(summary) = (%v, %v), want the summary client", c, ok)
+	}
+	if c, ok := NamedClientFromContext(ctx, "missing"); ok || c != nil {
+		t.Errorf("NamedClientFromContext(missing) = (%v, %v), want (nil, false)", c, ok)
+	}
+	if MustClientFromContext(ctx) != def {
+		t.Error("MustClientFromContext returned the wrong client")
+	}
+}
+
+func TestMustNamedClientFromContext_PanicsWhenMissing(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for a missing named client")
+		}
+	}()
+	MustNamedClientFromContext(WithClient(context.Background(), NewClient(newMockProvider())), "summary")
+}
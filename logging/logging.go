@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"log/slog"
+	"math/rand"
 	"net/http"
 	"os"
 	"slices"
@@ -20,12 +21,21 @@ const (
 	RequestIDKey contextKey = "request_id"
 )
 
-// RequestIDFromContext returns the request ID stored in the context, if any.
-func RequestIDFromContext(ctx context.Context) string {
-	if id, ok := ctx.Value(RequestIDKey).(string); ok {
-		return id
+// RequestIDFromContext returns the request ID stored in the context.
+// Returns ("", false) if the request did not pass through Decorate.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(RequestIDKey).(string)
+	return id, ok
+}
+
+// MustRequestIDFromContext is like RequestIDFromContext but panics when no
+// request ID is present.
+func MustRequestIDFromContext(ctx context.Context) string {
+	id, ok := RequestIDFromContext(ctx)
+	if !ok {
+		panic("logging.MustRequestIDFromContext: no request ID in context -- is this handler wrapped by logging.Decorate?")
 	}
-	return ""
+	return id
 }
 
 // PrettyJSONHandler is a custom handler that pretty prints JSON in development
@@ -71,9 +81,12 @@ var ProdLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 var DevLogger = slog.New(newPrettyJSONHandler())
 
 // statusRecorder wraps http.ResponseWriter to capture the status code.
+// When body is non-nil, the first maxCapturedBody bytes of the response are
+// copied into it for slow-request capture.
 type statusRecorder struct {
 	http.ResponseWriter
 	statusCode int
+	body       *cappedBuffer
 }
 
 func (sr *statusRecorder) WriteHeader(code int) {
@@ -81,9 +94,63 @@ func (sr *statusRecorder) WriteHeader(code int) {
 	sr.ResponseWriter.WriteHeader(code)
 }
 
+func (sr *statusRecorder) Write(p []byte) (int, error) {
+	if sr.body != nil {
+		sr.body.Write(p)
+	}
+	return sr.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// streamed responses can still flush and set write deadlines.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// Options controls sampling and slow-request capture in DecorateWithOptions.
+// The zero value logs every request and captures nothing.
+type Options struct {
+	// SampleRate is the fraction (0, 1] of successful requests (status < 400)
+	// that are logged. Errors are always logged. Zero or values >= 1 log
+	// every request.
+	SampleRate float64
+
+	// SlowThreshold marks requests that take at least this long as slow.
+	// Slow requests are always logged and, when SlowRequests is set, their
+	// request and response bodies are recorded. Zero disables capture.
+	SlowThreshold time.Duration
+
+	// SlowRequests receives captured slow requests. May be nil.
+	SlowRequests *SlowRequestBuffer
+}
+
+// randFloat is the sampling source; tests replace it for determinism.
+var randFloat = rand.Float64
+
+// sampled reports whether a successful request should be logged.
+func (o Options) sampled() bool {
+	if o.SampleRate <= 0 || o.SampleRate >= 1 {
+		return true
+	}
+	return randFloat() < o.SampleRate
+}
+
+// captureEnabled reports whether request/response bodies should be buffered.
+func (o Options) captureEnabled() bool {
+	return o.SlowThreshold > 0 && o.SlowRequests != nil
+}
+
 // Decorate wraps an HTTP handler and adds tasteful JSON logging to all requests.
 // It ignores requests to the paths in the ignoreList.
 func Decorate(ignoreList []string, logger *slog.Logger, next http.Handler) http.Handler {
+	return DecorateWithOptions(ignoreList, logger, Options{}, next)
+}
+
+// DecorateWithOptions is Decorate with sampling and slow-request capture.
+// The sampling decision is made when the request starts, so a sampled
+// request logs both request_started and request_completed. Requests that
+// were not sampled still log request_completed if they fail or are slow.
+func DecorateWithOptions(ignoreList []string, logger *slog.Logger, opts Options, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if slices.Contains(ignoreList, r.URL.Path) {
 			next.ServeHTTP(w, r)
@@ -92,6 +159,7 @@ func Decorate(ignoreList []string, logger *slog.Logger, next http.Handler) http.
 
 		requestID := nanoid.New()
 		startTime := time.Now()
+		sampled := opts.sampled()
 
 		// Store request ID on context so handlers can include it in logs.
 		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
@@ -103,18 +171,50 @@ func Decorate(ignoreList []string, logger *slog.Logger, next http.Handler) http.
 			userID = &id
 		}
 
-		logger.Info("request_started",
-			"request_id", requestID,
-			"path", r.URL.Path,
-			"method", r.Method,
-			"user_id", userID,
-			"timestamp", startTime,
-		)
+		if sampled {
+			logger.Info("request_started",
+				"request_id", requestID,
+				"path", r.URL.Path,
+				"method", r.Method,
+				"user_id", userID,
+				"timestamp", startTime,
+			)
+		}
 
 		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		var reqBody *cappedBuffer
+		if opts.captureEnabled() {
+			reqBody = &cappedBuffer{}
+			recorder.body = &cappedBuffer{}
+			if r.Body != nil {
+				r.Body = teeReadCloser{Reader: io.TeeReader(r.Body, reqBody), Closer: r.Body}
+			}
+		}
 		next.ServeHTTP(recorder, r)
 
 		endTime := time.Now()
+		duration := endTime.Sub(startTime)
+		slow := opts.SlowThreshold > 0 && duration >= opts.SlowThreshold
+
+		if slow && opts.SlowRequests != nil {
+			opts.SlowRequests.Add(SlowRequest{
+				RequestID:    requestID,
+				Method:       r.Method,
+				Path:         r.URL.Path,
+				Query:        r.URL.RawQuery,
+				StatusCode:   recorder.statusCode,
+				DurationMs:   float64(duration.Nanoseconds()) / 1e6,
+				StartedAt:    startTime,
+				RequestBody:  reqBody.String(),
+				ResponseBody: recorder.body.String(),
+				Truncated:    reqBody.truncated || recorder.body.truncated,
+			})
+		}
+
+		if !sampled && !slow && recorder.statusCode < http.StatusBadRequest {
+			return
+		}
+
 		logger.Info("request_completed",
 			"request_id", requestID,
 			"path", r.URL.Path,
@@ -122,7 +222,8 @@ func Decorate(ignoreList []string, logger *slog.Logger, next http.Handler) http.
 			"user_id", userID,
 			"status_code", recorder.statusCode,
 			"timestamp", endTime,
-			"duration_ms", float64(endTime.Sub(startTime).Nanoseconds())/1e6,
+			"duration_ms", float64(duration.Nanoseconds())/1e6,
+			"slow", slow,
 		)
 	})
 }
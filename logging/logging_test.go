@@ -264,3 +264,27 @@ func TestDurationLogging(t *testing.T) {
 		t.Errorf("Expected duration >= 100ms, got %v", duration)
 	}
 }
+
+func TestRequestIDFromContext(t *testing.T) {
+	if id, ok := RequestIDFromContext(context.Background()); ok || id != "" {
+		t.Errorf("RequestIDFromContext on bare context = (%q, %v), want (\"\", false)", id, ok)
+	}
+
+	var got string
+	var gotOK bool
+	handler := Decorate(nil, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, gotOK = RequestIDFromContext(r.Context())
+		_ = MustRequestIDFromContext(r.Context())
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+	if !gotOK || got == "" {
+		t.Errorf("RequestIDFromContext under Decorate = (%q, %v), want a request ID", got, gotOK)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustRequestIDFromContext to panic on bare context")
+		}
+	}()
+	MustRequestIDFromContext(context.Background())
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxCapturedBody is the number of request and response body bytes kept
+// per slow request. Anything beyond this is dropped and Truncated is set.
+const maxCapturedBody = 64 * 1024
+
+// defaultSlowBufferSize is used when NewSlowRequestBuffer gets a size <= 0.
+const defaultSlowBufferSize = 100
+
+// SlowRequest is a captured request that exceeded Options.SlowThreshold.
+type SlowRequest struct {
+	RequestID    string    `json:"request_id"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Query        string    `json:"query,omitempty"`
+	StatusCode   int       `json:"status_code"`
+	DurationMs   float64   `json:"duration_ms"`
+	StartedAt    time.Time `json:"started_at"`
+	RequestBody  string    `json:"request_body,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Truncated    bool      `json:"truncated,omitempty"`
+}
+
+// SlowRequestBuffer is a fixed-size ring buffer of the most recent slow
+// requests. It is safe for concurrent use and implements http.Handler,
+// serving its contents (newest first) as JSON.
+type SlowRequestBuffer struct {
+	mu      sync.Mutex
+	entries []SlowRequest
+	next    int
+	full    bool
+}
+
+// NewSlowRequestBuffer creates a buffer that retains the last size requests.
+func NewSlowRequestBuffer(size int) *SlowRequestBuffer {
+	if size <= 0 {
+		size = defaultSlowBufferSize
+	}
+	return &SlowRequestBuffer{entries: make([]SlowRequest, size)}
+}
+
+// Add records a slow request, evicting the oldest one when full.
+func (b *SlowRequestBuffer) Add(req SlowRequest) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = req
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Entries returns a copy of the buffered requests, newest first.
+func (b *SlowRequestBuffer) Entries() []SlowRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.next
+	if b.full {
+		n = len(b.entries)
+	}
+	out := make([]SlowRequest, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, b.entries[(b.next-i+len(b.entries))%len(b.entries)])
+	}
+	return out
+}
+
+// ServeHTTP writes the buffered requests as a JSON array.
+func (b *SlowRequestBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(b.Entries())
+}
+
+// cappedBuffer keeps at most maxCapturedBody bytes and remembers whether
+// anything was dropped. Writes never fail so it can sit behind a TeeReader.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := maxCapturedBody - c.buf.Len(); room < len(p) {
+		c.truncated = true
+		if room > 0 {
+			c.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	c.buf.Write(p)
+	return len(p), nil
+}
+
+func (c *cappedBuffer) String() string {
+	if c == nil {
+		return ""
+	}
+	return c.buf.String()
+}
+
+// teeReadCloser pairs a TeeReader with the original body's Close.
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
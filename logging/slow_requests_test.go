@@ -0,0 +1,178 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withRandFloat pins the sampling source for the duration of a test.
+func withRandFloat(t *testing.T, v float64) {
+	t.Helper()
+	orig := randFloat
+	randFloat = func() float64 { return v }
+	t.Cleanup(func() { randFloat = orig })
+}
+
+func TestDecorateWithOptions_Sampling(t *testing.T) {
+	tests := []struct {
+		name          string
+		roll          float64
+		status        int
+		wantStarted   bool
+		wantCompleted bool
+	}{
+		{"sampled success", 0.001, http.StatusOK, true, true},
+		{"unsampled success", 0.5, http.StatusOK, false, false},
+		{"unsampled client error", 0.5, http.StatusNotFound, false, true},
+		{"unsampled server error", 0.5, http.StatusInternalServerError, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRandFloat(t, tt.roll)
+
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			decorated := DecorateWithOptions(nil, logger, Options{SampleRate: 0.01}, handler)
+			decorated.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+			output := buf.String()
+			if got := strings.Contains(output, "request_started"); got != tt.wantStarted {
+				t.Errorf("request_started logged = %v, want %v", got, tt.wantStarted)
+			}
+			if got := strings.Contains(output, "request_completed"); got != tt.wantCompleted {
+				t.Errorf("request_completed logged = %v, want %v", got, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestDecorateWithOptions_ZeroValueLogsEverything(t *testing.T) {
+	withRandFloat(t, 0.999)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	decorated := DecorateWithOptions(nil, logger, Options{}, handler)
+	decorated.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	if !strings.Contains(buf.String(), "request_completed") {
+		t.Error("zero-value Options should log every request")
+	}
+}
+
+func TestDecorateWithOptions_CapturesSlowRequests(t *testing.T) {
+	withRandFloat(t, 0.5)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	slowRequests := NewSlowRequestBuffer(10)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	})
+	decorated := DecorateWithOptions(nil, logger, Options{
+		SampleRate:    0.01,
+		SlowThreshold: 10 * time.Millisecond,
+		SlowRequests:  slowRequests,
+	}, handler)
+
+	decorated.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/fast", strings.NewReader(`1`)))
+	rr := httptest.NewRecorder()
+	decorated.ServeHTTP(rr, httptest.NewRequest("POST", "/slow?x=1", strings.NewReader(`2`)))
+
+	// The handler must still see its own response unchanged.
+	if rr.Body.String() != `{"echo":2}` {
+		t.Errorf("response body = %q", rr.Body.String())
+	}
+
+	entries := slowRequests.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 slow request, got %d", len(entries))
+	}
+	got := entries[0]
+	if got.Path != "/slow" || got.Query != "x=1" || got.Method != "POST" {
+		t.Errorf("unexpected request line: %+v", got)
+	}
+	if got.StatusCode != http.StatusCreated {
+		t.Errorf("StatusCode = %d, want %d", got.StatusCode, http.StatusCreated)
+	}
+	if got.RequestBody != `2` || got.ResponseBody != `{"echo":2}` {
+		t.Errorf("bodies not captured: request=%q response=%q", got.RequestBody, got.ResponseBody)
+	}
+
+	// Slow requests are logged even when not sampled; the fast one is not.
+	output := buf.String()
+	if strings.Contains(output, `"path":"/fast"`) {
+		t.Error("unsampled fast request should not be logged")
+	}
+	if !strings.Contains(output, `"slow":true`) {
+		t.Error("slow request should be logged with slow=true")
+	}
+}
+
+func TestSlowRequestBuffer_RingOrder(t *testing.T) {
+	b := NewSlowRequestBuffer(3)
+	for i := 1; i <= 5; i++ {
+		b.Add(SlowRequest{RequestID: fmt.Sprint(i)})
+	}
+
+	entries := b.Entries()
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.RequestID)
+	}
+	if strings.Join(ids, ",") != "5,4,3" {
+		t.Errorf("Entries() = %v, want newest-first [5 4 3]", ids)
+	}
+}
+
+func TestSlowRequestBuffer_ServeHTTP(t *testing.T) {
+	b := NewSlowRequestBuffer(0)
+	b.Add(SlowRequest{RequestID: "abc", Path: "/x"})
+
+	rr := httptest.NewRecorder()
+	b.ServeHTTP(rr, httptest.NewRequest("GET", "/__debug/slow-requests", nil))
+
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var got []SlowRequest
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(got) != 1 || got[0].RequestID != "abc" {
+		t.Errorf("unexpected entries: %+v", got)
+	}
+}
+
+func TestCappedBuffer_Truncates(t *testing.T) {
+	var c cappedBuffer
+	c.Write(bytes.Repeat([]byte("a"), maxCapturedBody-1))
+	n, err := c.Write([]byte("bcd"))
+	if n != 3 || err != nil {
+		t.Errorf("Write() = %d, %v; want 3, nil", n, err)
+	}
+	if !c.truncated {
+		t.Error("expected truncated to be set")
+	}
+	if len(c.String()) != maxCapturedBody || !strings.HasSuffix(c.String(), "ab") {
+		t.Errorf("unexpected buffer contents (len %d)", len(c.String()))
+	}
+}
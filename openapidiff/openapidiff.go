@@ -0,0 +1,390 @@
+// Package openapidiff compares two OpenAPI 3 documents and reports the
+// changes that would break clients written against the older one: removed
+// paths, operations and response fields, changed types, and request
+// parameters or fields that became required. Additions are reported too,
+// as non-breaking changes.
+package openapidiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change kinds.
+const (
+	PathRemoved        = "path_removed"
+	OperationRemoved   = "operation_removed"
+	OperationIDChanged = "operation_id_changed"
+	ResponseRemoved    = "response_removed"
+	RequestBodyRemoved = "request_body_removed"
+	FieldRemoved       = "field_removed"
+	ParameterRemoved   = "parameter_removed"
+	TypeChanged        = "type_changed"
+	BecameNullable     = "became_nullable"
+	BecameOptional     = "became_optional"
+	BecameRequired     = "became_required"
+	RequiredAdded      = "required_added"
+	PathAdded          = "path_added"
+	OperationAdded     = "operation_added"
+	RequestBodyAdded   = "request_body_added"
+	FieldAdded         = "field_added"
+	ParameterAdded     = "parameter_added"
+)
+
+// Change is one difference between the two documents. Location names the
+// part of the operation that changed, e.g. "query.limit",
+// "request.title" or "response.200.items[].id".
+type Change struct {
+	Kind     string `json:"kind"`
+	Breaking bool   `json:"breaking"`
+	Path     string `json:"path"`
+	Method   string `json:"method,omitempty"`
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (c Change) String() string {
+	where := c.Path
+	if c.Method != "" {
+		where = strings.ToUpper(c.Method) + " " + where
+	}
+	if c.Location != "" {
+		where += " " + c.Location
+	}
+	return where + ": " + c.Message
+}
+
+// Report is the machine-readable result of Compare.
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// Breaking returns the changes that break existing clients.
+func (r Report) Breaking() []Change {
+	var out []Change
+	for _, c := range r.Changes {
+		if c.Breaking {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// JSON renders the report as indented JSON.
+func (r Report) JSON() ([]byte, error) {
+	if r.Changes == nil {
+		r.Changes = []Change{}
+	}
+	return json.MarshalIndent(r, "", "  ")
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Compare reports the changes from the OpenAPI document oldSpec to newSpec.
+// Both must be JSON. Local $refs are resolved; schemas are compared
+// structurally by type, format, nullability, properties and required.
+func Compare(oldSpec, newSpec []byte) (Report, error) {
+	var oldDoc, newDoc map[string]any
+	if err := json.Unmarshal(oldSpec, &oldDoc); err != nil {
+		return Report{}, fmt.Errorf("parse old document: %w", err)
+	}
+	if err := json.Unmarshal(newSpec, &newDoc); err != nil {
+		return Report{}, fmt.Errorf("parse new document: %w", err)
+	}
+
+	d := &differ{old: oldDoc, new: newDoc}
+	oldPaths, newPaths := object(oldDoc["paths"]), object(newDoc["paths"])
+	for _, path := range sortedKeys(oldPaths) {
+		newItem, ok := newPaths[path]
+		if !ok {
+			d.add(Change{Kind: PathRemoved, Breaking: true, Path: path, Message: "path removed"})
+			continue
+		}
+		d.comparePathItem(path, object(oldPaths[path]), object(newItem))
+	}
+	for _, path := range sortedKeys(newPaths) {
+		if _, ok := oldPaths[path]; !ok {
+			d.add(Change{Kind: PathAdded, Path: path, Message: "path added"})
+		}
+	}
+	return Report{Changes: d.changes}, nil
+}
+
+type differ struct {
+	old, new map[string]any
+	changes  []Change
+
+	// path and method of the operation being compared
+	path, method string
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+// change records a change in the current operation.
+func (d *differ) change(kind string, breaking bool, location, format string, args ...any) {
+	d.add(Change{
+		Kind:     kind,
+		Breaking: breaking,
+		Path:     d.path,
+		Method:   d.method,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) comparePathItem(path string, oldItem, newItem map[string]any) {
+	for _, method := range methods {
+		oldOp, inOld := oldItem[method]
+		newOp, inNew := newItem[method]
+		d.path, d.method = path, method
+		switch {
+		case inOld && !inNew:
+			d.change(OperationRemoved, true, "", "operation removed")
+		case !inOld && inNew:
+			d.change(OperationAdded, false, "", "operation added")
+		case inOld && inNew:
+			d.compareOperation(oldItem, newItem, object(oldOp), object(newOp))
+		}
+	}
+}
+
+func (d *differ) compareOperation(oldItem, newItem, oldOp, newOp map[string]any) {
+	if oldID, newID := oldOp["operationId"], newOp["operationId"]; oldID != nil && newID != nil && oldID != newID {
+		// Generated clients name their functions after the operationId.
+		d.change(OperationIDChanged, true, "", "operationId changed from %v to %v", oldID, newID)
+	}
+
+	d.compareParameters(d.parameters(oldItem, oldOp, d.old), d.parameters(newItem, newOp, d.new))
+
+	oldBody := d.bodySchema(object(oldOp["requestBody"]), d.old)
+	newBody := d.bodySchema(object(newOp["requestBody"]), d.new)
+	switch {
+	case oldBody != nil && newBody != nil:
+		d.compareSchema("request", oldBody, newBody, true)
+	case oldBody == nil && newBody != nil:
+		d.change(RequestBodyAdded, truthy(d.resolve(object(newOp["requestBody"]), d.new)["required"]), "request", "request body added")
+	case oldBody != nil && newBody == nil:
+		d.change(RequestBodyRemoved, true, "request", "request body removed")
+	}
+
+	oldResponses, newResponses := object(oldOp["responses"]), object(newOp["responses"])
+	for _, code := range sortedKeys(oldResponses) {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		location := "response." + code
+		newResp, ok := newResponses[code]
+		if !ok {
+			d.change(ResponseRemoved, true, location, "%s response removed", code)
+			continue
+		}
+		oldSchema := d.bodySchema(d.resolve(object(oldResponses[code]), d.old), d.old)
+		newSchema := d.bodySchema(d.resolve(object(newResp), d.new), d.new)
+		switch {
+		case oldSchema != nil && newSchema == nil:
+			d.change(ResponseRemoved, true, location, "%s response body removed", code)
+		case oldSchema != nil:
+			d.compareSchema(location, oldSchema, newSchema, false)
+		}
+	}
+}
+
+// parameters returns the operation's parameters, including those declared
+// on its path item, keyed by "in.name".
+func (d *differ) parameters(item, op, doc map[string]any) map[string]map[string]any {
+	params := make(map[string]map[string]any)
+	for _, owner := range []map[string]any{item, op} {
+		list, _ := owner["parameters"].([]any)
+		for _, p := range list {
+			param := d.resolve(object(p), doc)
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			params[in+"."+name] = param
+		}
+	}
+	return params
+}
+
+func (d *differ) compareParameters(oldParams, newParams map[string]map[string]any) {
+	for _, key := range sortedKeys(oldParams) {
+		oldParam := oldParams[key]
+		newParam, ok := newParams[key]
+		if !ok {
+			d.change(ParameterRemoved, true, key, "parameter removed")
+			continue
+		}
+		if !truthy(oldParam["required"]) && truthy(newParam["required"]) {
+			d.change(BecameRequired, true, key, "parameter became required")
+		}
+		d.compareSchema(key, d.resolve(object(oldParam["schema"]), d.old), d.resolve(object(newParam["schema"]), d.new), true)
+	}
+	for _, key := range sortedKeys(newParams) {
+		if _, ok := oldParams[key]; ok {
+			continue
+		}
+		if truthy(newParams[key]["required"]) {
+			d.change(RequiredAdded, true, key, "required parameter added")
+		} else {
+			d.change(ParameterAdded, false, key, "optional parameter added")
+		}
+	}
+}
+
+// compareSchema compares the schemas at location. request is true for
+// values the client sends, where new required fields break clients; for
+// responses, removed fields and new nulls do.
+func (d *differ) compareSchema(location string, oldSchema, newSchema map[string]any, request bool) {
+	oldSchema, newSchema = d.resolve(oldSchema, d.old), d.resolve(newSchema, d.new)
+
+	oldType, oldNull := schemaType(oldSchema)
+	newType, newNull := schemaType(newSchema)
+	if oldType != newType && oldType != "" && newType != "" {
+		d.change(TypeChanged, true, location, "type changed from %s to %s", oldType, newType)
+		return
+	}
+	if !request && !oldNull && newNull {
+		d.change(BecameNullable, true, location, "may now be null")
+	}
+
+	if oldType == "array" {
+		d.compareSchema(location+"[]", object(oldSchema["items"]), object(newSchema["items"]), request)
+		return
+	}
+
+	oldProps, newProps := object(oldSchema["properties"]), object(newSchema["properties"])
+	oldRequired, newRequired := stringSet(oldSchema["required"]), stringSet(newSchema["required"])
+	for _, name := range sortedKeys(oldProps) {
+		field := join(location, name)
+		newProp, ok := newProps[name]
+		if !ok {
+			// A client may still send a removed request field; it is
+			// ignored, which silently drops data.
+			d.change(FieldRemoved, true, field, "field removed")
+			continue
+		}
+		switch {
+		case request && !oldRequired[name] && newRequired[name]:
+			d.change(BecameRequired, true, field, "field became required")
+		case !request && oldRequired[name] && !newRequired[name]:
+			d.change(BecameOptional, true, field, "field is no longer always present")
+		}
+		d.compareSchema(field, object(oldProps[name]), object(newProp), request)
+	}
+	for _, name := range sortedKeys(newProps) {
+		if _, ok := oldProps[name]; ok {
+			continue
+		}
+		field := join(location, name)
+		if request && newRequired[name] {
+			d.change(RequiredAdded, true, field, "required field added")
+		} else {
+			d.change(FieldAdded, false, field, "field added")
+		}
+	}
+}
+
+// bodySchema returns the JSON schema of a request body or response, or nil
+// when it has none.
+func (d *differ) bodySchema(body, doc map[string]any) map[string]any {
+	body = d.resolve(body, doc)
+	content := object(body["content"])
+	media, ok := content["application/json"]
+	if !ok {
+		for _, key := range sortedKeys(content) {
+			media = content[key]
+			break
+		}
+	}
+	schema := object(object(media)["schema"])
+	if schema == nil {
+		return nil
+	}
+	return d.resolve(schema, doc)
+}
+
+// resolve follows local $refs ("#/components/schemas/Pet").
+func (d *differ) resolve(v, doc map[string]any) map[string]any {
+	for i := 0; v != nil && i < 32; i++ {
+		ref, ok := v["$ref"].(string)
+		if !ok {
+			return v
+		}
+		target, ok := strings.CutPrefix(ref, "#/")
+		if !ok {
+			return v
+		}
+		var cur any = doc
+		for _, part := range strings.Split(target, "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			cur = object(cur)[part]
+		}
+		v = object(cur)
+	}
+	return v
+}
+
+// schemaType returns the non-null type of a schema, and whether it admits
+// null (OpenAPI 3.0 nullable or a 3.1 type array containing "null").
+func schemaType(schema map[string]any) (typ string, nullable bool) {
+	nullable = truthy(schema["nullable"])
+	switch t := schema["type"].(type) {
+	case string:
+		typ = t
+	case []any:
+		var types []string
+		for _, v := range t {
+			if s, _ := v.(string); s == "null" {
+				nullable = true
+			} else if s != "" {
+				types = append(types, s)
+			}
+		}
+		sort.Strings(types)
+		typ = strings.Join(types, "|")
+	}
+	if format, _ := schema["format"].(string); format != "" && typ != "" {
+		typ += "(" + format + ")"
+	}
+	return typ, nullable
+}
+
+func join(location, name string) string {
+	if location == "" {
+		return name
+	}
+	return location + "." + name
+}
+
+func object(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func truthy(v any) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func stringSet(v any) map[string]bool {
+	set := make(map[string]bool)
+	list, _ := v.([]any)
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
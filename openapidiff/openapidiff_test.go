@@ -0,0 +1,191 @@
+package openapidiff_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/openapidiff"
+)
+
+const baseline = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "ListPets",
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+        "responses": {"200": {"content": {"application/json": {"schema": {
+          "type": "object",
+          "required": ["items"],
+          "properties": {"items": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}
+        }}}}}
+      },
+      "post": {
+        "operationId": "CreatePet",
+        "requestBody": {"content": {"application/json": {"schema": {
+          "type": "object",
+          "required": ["name"],
+          "properties": {"name": {"type": "string"}, "species": {"type": "string"}}
+        }}}},
+        "responses": {"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+      }
+    },
+    "/pets/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {"operationId": "GetPet", "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}},
+      "delete": {"operationId": "DeletePet", "responses": {"204": {}}}
+    },
+    "/owners": {"get": {"operationId": "ListOwners", "responses": {"200": {}}}}
+  },
+  "components": {"schemas": {
+    "Pet": {
+      "type": "object",
+      "required": ["id", "name"],
+      "properties": {
+        "id": {"type": "string"},
+        "name": {"type": "string"},
+        "age": {"type": "integer", "format": "int64"},
+        "species": {"type": "string"}
+      }
+    }
+  }}
+}`
+
+func compare(t *testing.T, newSpec string) openapidiff.Report {
+	t.Helper()
+	report, err := openapidiff.Compare([]byte(baseline), []byte(newSpec))
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	return report
+}
+
+func TestCompare_Identical(t *testing.T) {
+	report := compare(t, baseline)
+	if len(report.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", report.Changes)
+	}
+}
+
+func TestCompare_AdditionsAreCompatible(t *testing.T) {
+	newSpec := strings.NewReplacer(
+		`"species": {"type": "string"}
+      }`, `"species": {"type": "string"},
+        "color": {"type": "string"}
+      }`,
+		`"/owners": {`, `"/owners/{id}": {"get": {"responses": {"200": {}}}}, "/owners": {`,
+		`"schema": {"type": "integer"}}]`, `"schema": {"type": "integer"}}, {"name": "cursor", "in": "query", "schema": {"type": "string"}}]`,
+		`"species": {"type": "string"}}`, `"species": {"type": "string"}, "color": {"type": "string"}}`,
+	).Replace(baseline)
+
+	report := compare(t, newSpec)
+	if breaking := report.Breaking(); len(breaking) != 0 {
+		t.Fatalf("expected no breaking changes, got %v", breaking)
+	}
+
+	kinds := make(map[string]int)
+	for _, c := range report.Changes {
+		kinds[c.Kind]++
+	}
+	if kinds[openapidiff.PathAdded] != 1 || kinds[openapidiff.ParameterAdded] != 1 {
+		t.Errorf("expected one added path and parameter, got %v", report.Changes)
+	}
+	// Pet gains color in three responses, and the create request gains it too.
+	if kinds[openapidiff.FieldAdded] != 4 {
+		t.Errorf("expected 4 added fields, got %v", report.Changes)
+	}
+}
+
+func TestCompare_BreakingChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		kind     string
+		location string
+	}{
+		{"path removed", `"/owners": {"get": {"operationId": "ListOwners", "responses": {"200": {}}}}`, `"/users": {"get": {"responses": {"200": {}}}}`, openapidiff.PathRemoved, ""},
+		{"operation removed", `"delete": {"operationId": "DeletePet", "responses": {"204": {}}}`, `"put": {"responses": {"200": {}}}`, openapidiff.OperationRemoved, ""},
+		{"operationId changed", `"operationId": "GetPet"`, `"operationId": "FetchPet"`, openapidiff.OperationIDChanged, ""},
+		{"response field removed", `"age": {"type": "integer", "format": "int64"},`, ``, openapidiff.FieldRemoved, "response.200.items[].age"},
+		{"type changed", `"age": {"type": "integer", "format": "int64"}`, `"age": {"type": "string"}`, openapidiff.TypeChanged, "response.200.items[].age"},
+		{"format changed", `"age": {"type": "integer", "format": "int64"}`, `"age": {"type": "integer", "format": "int32"}`, openapidiff.TypeChanged, "response.200.items[].age"},
+		{"became nullable", `"id": {"type": "string"}`, `"id": {"type": "string", "nullable": true}`, openapidiff.BecameNullable, "response.200.items[].id"},
+		{"no longer always present", `"required": ["id", "name"]`, `"required": ["id"]`, openapidiff.BecameOptional, "response.200.items[].name"},
+		{"request field became required", `"required": ["name"],`, `"required": ["name", "species"],`, openapidiff.BecameRequired, "request.species"},
+		{"required request field added",
+			`"required": ["name"],` + "\n" + `          "properties": {"name": {"type": "string"}, "species": {"type": "string"}}`,
+			`"required": ["name", "owner"], "properties": {"name": {"type": "string"}, "species": {"type": "string"}, "owner": {"type": "string"}}`,
+			openapidiff.RequiredAdded, "request.owner"},
+		{"parameter removed", `"parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],`, ``, openapidiff.ParameterRemoved, "query.limit"},
+		{"parameter became required", `{"name": "limit", "in": "query",`, `{"name": "limit", "in": "query", "required": true,`, openapidiff.BecameRequired, "query.limit"},
+		{"path parameter type changed", `{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}`, `{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}`, openapidiff.TypeChanged, "path.id"},
+		{"success response removed", `"201": {`, `"202": {`, openapidiff.ResponseRemoved, "response.201"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(baseline, tt.old) {
+				t.Fatalf("baseline does not contain %q", tt.old)
+			}
+			report := compare(t, strings.Replace(baseline, tt.old, tt.new, 1))
+			for _, c := range report.Breaking() {
+				if c.Kind == tt.kind && (tt.location == "" || c.Location == tt.location) {
+					return
+				}
+			}
+			t.Errorf("expected breaking %s at %q, got %v", tt.kind, tt.location, report.Changes)
+		})
+	}
+}
+
+func TestCompare_OpenAPI31Nullable(t *testing.T) {
+	newSpec := strings.Replace(baseline, `"id": {"type": "string"}`, `"id": {"type": ["string", "null"]}`, 1)
+	report := compare(t, newSpec)
+	breaking := report.Breaking()
+	if len(breaking) == 0 || breaking[0].Kind != openapidiff.BecameNullable {
+		t.Errorf("expected became_nullable, got %v", report.Changes)
+	}
+	for _, c := range breaking {
+		if c.Kind == openapidiff.TypeChanged {
+			t.Errorf("a type array with null should not count as a type change: %v", c)
+		}
+	}
+}
+
+func TestCompare_InvalidJSON(t *testing.T) {
+	if _, err := openapidiff.Compare([]byte("{"), []byte(baseline)); err == nil {
+		t.Error("expected an error for invalid old document")
+	}
+	if _, err := openapidiff.Compare([]byte(baseline), []byte("not json")); err == nil {
+		t.Error("expected an error for invalid new document")
+	}
+}
+
+func TestReport_JSON(t *testing.T) {
+	report := compare(t, strings.Replace(baseline, `"operationId": "GetPet"`, `"operationId": "FetchPet"`, 1))
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var decoded struct {
+		Changes []map[string]any `json:"changes"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, data)
+	}
+	if len(decoded.Changes) != 1 {
+		t.Fatalf("expected 1 change, got %s", data)
+	}
+	got := decoded.Changes[0]
+	if got["kind"] != "operation_id_changed" || got["breaking"] != true || got["path"] != "/pets/{id}" || got["method"] != "get" {
+		t.Errorf("unexpected change: %v", got)
+	}
+
+	empty, err := openapidiff.Report{}.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	if !strings.Contains(string(empty), `"changes": []`) {
+		t.Errorf("empty report should encode an empty list, got %s", empty)
+	}
+}
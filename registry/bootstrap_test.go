@@ -15,7 +15,7 @@ func TestBootstrapQueryPackages_SQLite(t *testing.T) {
 	tmpDir := t.TempDir()
 	modulePath := "com.test-bootstrap-sqlite"
 
-	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite"); err != nil {
+	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite", false); err != nil {
 		t.Fatalf("bootstrapQueryPackages failed: %v", err)
 	}
 
@@ -86,7 +86,7 @@ func TestBootstrapQueryPackages_Postgres(t *testing.T) {
 	tmpDir := t.TempDir()
 	modulePath := "com.test-bootstrap-postgres"
 
-	if err := bootstrapQueryPackages(tmpDir, modulePath, "postgres"); err != nil {
+	if err := bootstrapQueryPackages(tmpDir, modulePath, "postgres", false); err != nil {
 		t.Fatalf("bootstrapQueryPackages failed: %v", err)
 	}
 
@@ -106,7 +106,7 @@ func TestBootstrapQueryPackages_MySQL(t *testing.T) {
 	tmpDir := t.TempDir()
 	modulePath := "com.test-bootstrap-mysql"
 
-	if err := bootstrapQueryPackages(tmpDir, modulePath, "mysql"); err != nil {
+	if err := bootstrapQueryPackages(tmpDir, modulePath, "mysql", false); err != nil {
 		t.Fatalf("bootstrapQueryPackages failed: %v", err)
 	}
 
@@ -127,7 +127,7 @@ func TestBootstrapQueryPackages_Idempotent(t *testing.T) {
 	modulePath := "com.test-bootstrap-idempotent"
 
 	// Call twice — should not error on second call
-	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite"); err != nil {
+	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite", false); err != nil {
 		t.Fatalf("first bootstrapQueryPackages failed: %v", err)
 	}
 
@@ -138,7 +138,7 @@ func TestBootstrapQueryPackages_Idempotent(t *testing.T) {
 		t.Fatalf("types.go not found after first call: %v", err)
 	}
 
-	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite"); err != nil {
+	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite", false); err != nil {
 		t.Fatalf("second bootstrapQueryPackages failed: %v", err)
 	}
 
@@ -157,7 +157,7 @@ func TestBootstrapQueryPackages_ModulePathInTypes(t *testing.T) {
 	tmpDir := t.TempDir()
 	modulePath := "github.com/company/myapp"
 
-	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite"); err != nil {
+	if err := bootstrapQueryPackages(tmpDir, modulePath, "sqlite", false); err != nil {
 		t.Fatalf("bootstrapQueryPackages failed: %v", err)
 	}
 
@@ -192,7 +192,7 @@ func TestBootstrapPackages_CreatesLibPackages(t *testing.T) {
 		t.Fatalf("failed to write go.mod: %v", err)
 	}
 
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, false); err != nil {
 		t.Fatalf("bootstrapPackages failed: %v", err)
 	}
 
@@ -204,6 +204,7 @@ func TestBootstrapPackages_CreatesLibPackages(t *testing.T) {
 		filepath.Join("shipq", "lib", "httperror"),
 		filepath.Join("shipq", "lib", "logging"),
 		filepath.Join("shipq", "lib", "crypto"),
+		filepath.Join("shipq", "lib", "validate"),
 		filepath.Join("shipq", "lib", "nanoid"),
 	}
 
@@ -257,7 +258,7 @@ func TestBootstrapPackages_CreatesDBPackage(t *testing.T) {
 		t.Fatalf("failed to write go.mod: %v", err)
 	}
 
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, false); err != nil {
 		t.Fatalf("bootstrapPackages failed: %v", err)
 	}
 
@@ -293,7 +294,7 @@ func TestBootstrapPackages_CreatesQueryStubs(t *testing.T) {
 		t.Fatalf("failed to write go.mod: %v", err)
 	}
 
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, false); err != nil {
 		t.Fatalf("bootstrapPackages failed: %v", err)
 	}
 
@@ -336,7 +337,7 @@ func TestBootstrapPackages_SkipsDBIfAlreadyExists(t *testing.T) {
 		t.Fatalf("failed to write custom db.go: %v", err)
 	}
 
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, false); err != nil {
 		t.Fatalf("bootstrapPackages failed: %v", err)
 	}
 
@@ -376,7 +377,7 @@ func TestBootstrapPackages_SkipsQueryStubsIfAlreadyExist(t *testing.T) {
 		t.Fatalf("failed to write custom types.go: %v", err)
 	}
 
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, false); err != nil {
 		t.Fatalf("bootstrapPackages failed: %v", err)
 	}
 
@@ -409,7 +410,7 @@ func TestBootstrapPackages_EmptyDialect(t *testing.T) {
 	// With empty dialect, query stubs should be skipped (no error)
 	// EmbedAllPackages defaults empty dialect to "sqlite" internally, so lib
 	// packages will still be created.
-	if err := bootstrapPackages(tmpDir, modulePath, "", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "", false, false, false, false); err != nil {
 		t.Fatalf("bootstrapPackages with empty dialect should not error: %v", err)
 	}
 
@@ -437,12 +438,12 @@ func TestBootstrapPackages_Idempotent(t *testing.T) {
 	}
 
 	// First call
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, false); err != nil {
 		t.Fatalf("first bootstrapPackages failed: %v", err)
 	}
 
 	// Second call should succeed without errors
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, false); err != nil {
 		t.Fatalf("second bootstrapPackages failed: %v", err)
 	}
 
@@ -477,7 +478,7 @@ func TestBootstrapPackages_WithFilesEnabled(t *testing.T) {
 		t.Fatalf("failed to write go.mod: %v", err)
 	}
 
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", true, false); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", true, false, false, false); err != nil {
 		t.Fatalf("bootstrapPackages with files enabled failed: %v", err)
 	}
 
@@ -504,7 +505,7 @@ func TestBootstrapPackages_WithWorkersEnabled(t *testing.T) {
 		t.Fatalf("failed to write go.mod: %v", err)
 	}
 
-	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, true); err != nil {
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, true, false, false); err != nil {
 		t.Fatalf("bootstrapPackages with workers enabled failed: %v", err)
 	}
 
@@ -515,6 +516,39 @@ func TestBootstrapPackages_WithWorkersEnabled(t *testing.T) {
 	}
 }
 
+func TestBootstrapPackages_WithTelemetryEnabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	modulePath := "com.test-bootstrap-telemetry"
+
+	// Create a minimal shipq.ini
+	shipqIniContent := "[db]\ndatabase_url = sqlite://" + filepath.Join(tmpDir, ".shipq", "data", "test.db") + "\n\n[server]\ntelemetry = otel\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "shipq.ini"), []byte(shipqIniContent), 0644); err != nil {
+		t.Fatalf("failed to write shipq.ini: %v", err)
+	}
+
+	// Create go.mod
+	goModContent := "module " + modulePath + "\n\ngo 1.21\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(goModContent), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	if err := bootstrapPackages(tmpDir, modulePath, "sqlite", false, false, false, true); err != nil {
+		t.Fatalf("bootstrapPackages with telemetry enabled failed: %v", err)
+	}
+
+	// telemetry lib should exist, and the stub runner should offer Traced
+	if _, err := os.Stat(filepath.Join(tmpDir, "shipq", "lib", "telemetry", "telemetry.go")); os.IsNotExist(err) {
+		t.Error("shipq/lib/telemetry/ should exist when telemetry is enabled")
+	}
+	types, err := os.ReadFile(filepath.Join(tmpDir, "shipq", "queries", "types.go"))
+	if err != nil {
+		t.Fatalf("failed to read types.go: %v", err)
+	}
+	if !strings.Contains(string(types), "func Traced(r Runner) Runner") {
+		t.Error("stub types.go should define Traced when telemetry is enabled")
+	}
+}
+
 // ── Verify embed.EmbedAllPackages creates assets ─────────────────────────────
 
 func TestBootstrapPackages_CreatesAssets(t *testing.T) {
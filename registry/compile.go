@@ -6,6 +6,7 @@ import (
 
 	"github.com/shipq/shipq/codegen"
 	configpkg "github.com/shipq/shipq/codegen/httpserver/config"
+	"github.com/shipq/shipq/config"
 )
 
 // CompileConfig holds all configuration needed for registry compilation.
@@ -33,6 +34,11 @@ type CompileConfig struct {
 	// ScopeColumn is the global scope column from [db] scope in shipq.ini.
 	// When set (e.g., "organization_id"), RBAC queries filter by organization.
 	ScopeColumn string
+	// ScopeHeader is [db] scope_header in shipq.ini: the request header in
+	// which authenticated requests name the organization (by public ID) they
+	// act in. The account must belong to it. Empty means requests always act
+	// in the account's default organization.
+	ScopeHeader string
 	// GenerateResourceTests enables generation of CRUD tests for full resources.
 	// A "full resource" is a package that implements all 5 CRUD operations.
 	GenerateResourceTests bool
@@ -70,6 +76,14 @@ type CompileConfig struct {
 	// section of shipq.ini. Each entry specifies an env var name and whether
 	// it is required (fatal if missing in production) or optional (warn only).
 	CustomEnvVars []configpkg.CustomEnvVar
+	// DatabaseTLSEnvs lists the GO_ENV values in which the generated
+	// CheckDatabaseTLS requires a verified TLS DATABASE_URL. Parsed from
+	// [db] require_tls in shipq.ini; nil means the default (production).
+	DatabaseTLSEnvs []string
+	// DatabasePool holds the [db] max_open_conns, max_idle_conns and
+	// conn_max_lifetime settings baked into the generated
+	// config.ConfigureDatabasePool.
+	DatabasePool config.DatabasePoolConfig
 	// Channels holds the serialized channel metadata from the channel compiler.
 	// Only populated when WorkersEnabled is true.
 	Channels []codegen.SerializedChannelInfo
@@ -77,6 +91,43 @@ type CompileConfig struct {
 	// For example, "/api" means a request to "/api/posts" is routed as "/posts".
 	// Parsed from [server] strip_prefix in shipq.ini.
 	StripPrefix string
+	// Serializers lists the extra body formats ("msgpack", "cbor") the
+	// generated handlers negotiate. Parsed from [server] serializers.
+	Serializers []string
+	// AccessLog holds the [logging] section of shipq.ini (sampling and
+	// slow-request capture). Nil when the section is absent.
+	AccessLog *config.LoggingConfig
+	// NoRecover is true when [server] recover_panics = false in shipq.ini.
+	// The generated mux then lets handler panics reach net/http instead of
+	// answering them with a 500 and calling api.PanicReporter.
+	NoRecover bool
+	// TxPerRequest is true when [server] tx_per_request = true in shipq.ini.
+	// Generated POST, PUT, PATCH and DELETE routes then run their handler
+	// in a transaction that commits on success and rolls back on an error
+	// response or a panic.
+	TxPerRequest bool
+	// GRPC is true when [server] grpc = true in shipq.ini (set by
+	// `shipq handler compile --grpc`). The handlers are then also served
+	// over gRPC: api/api.proto describes them and cmd/server starts a gRPC
+	// server on GRPC_PORT that calls the same handlers through NewMux.
+	GRPC bool
+	// Telemetry is true when [server] telemetry = otel in shipq.ini. NewMux
+	// then runs each request in an OpenTelemetry span and cmd/server wraps
+	// its query runner in queries.Traced, so each query gets a child span.
+	Telemetry bool
+	// BearerAuth is true when [server] auth = bearer in shipq.ini.
+	// Authenticated routes then pass the token of an Authorization: Bearer
+	// header to the auth package, which validates it like the session
+	// cookie.
+	BearerAuth bool
+	// OpenAPI holds the [openapi] and [openapi.servers] sections of
+	// shipq.ini: per-environment server URLs and the security schemes
+	// applied to authenticated operations, and the released baseline the
+	// compatibility test compares against. Nil means cookie auth only.
+	OpenAPI *config.OpenAPIConfig
+	// Naming holds the [naming] section of shipq.ini, which shapes the
+	// OpenAPI operationIds. Nil names operations after their handler.
+	Naming *config.NamingConfig
 	// TSFrameworks lists which framework integrations to generate.
 	// Valid entries are "react" and "svelte". Parsed from the comma-separated
 	// [typescript] framework value in shipq.ini. Defaults to ["react"].
@@ -128,11 +179,13 @@ func HasFramework(frameworks []string, fw string) bool {
 //
 //   - generateOpenAPI() ✓
 //   - generateHTTPServer() ✓
+//   - generateGRPC() ✓
 //   - generateHTTPMain() ✓
 //   - generateHTTPTestClient() ✓
 //   - generateHTTPTestHarness() ✓
 //   - generateResourceTests() ✓
 //   - generateOpenAPITest() ✓
+//   - generateOpenAPICompatTest() ✓
 //   - generateTypeScriptHTTPClient() ✓
 //   - generateTypeScriptReactHooks() ✓
 //   - generateTypeScriptSvelteHooks() ✓
@@ -152,6 +205,12 @@ func CompileRegistry(cfg CompileConfig) error {
 		}
 	}
 
+	// Record the registry for commands that inspect routes without
+	// rebuilding the compile program (e.g. `shipq routes`).
+	if err := writeRoutesManifest(cfg); err != nil {
+		return err
+	}
+
 	// Always generate config package first — it is imported by the HTTP
 	// server, main.go, and test files regardless of whether auth is enabled.
 	if err := generateConfig(cfg); err != nil {
@@ -164,6 +223,9 @@ func CompileRegistry(cfg CompileConfig) error {
 	if err != nil {
 		return err
 	}
+	if err := writeOpenAPIManifest(cfg.ShipqRoot, oaData.SpecJSON); err != nil {
+		return err
+	}
 
 	// Generate admin panel HTML
 	adminHTML := generateAdminPanel(cfg)
@@ -172,6 +234,12 @@ func CompileRegistry(cfg CompileConfig) error {
 		return err
 	}
 
+	if cfg.GRPC {
+		if err := generateGRPC(cfg); err != nil {
+			return err
+		}
+	}
+
 	if err := generateHTTPMain(cfg); err != nil {
 		return err
 	}
@@ -190,6 +258,11 @@ func CompileRegistry(cfg CompileConfig) error {
 		return err
 	}
 
+	// Generate the backward-compatibility test against [openapi] baseline
+	if err := generateOpenAPICompatTest(cfg); err != nil {
+		return err
+	}
+
 	// Generate resource tests if enabled
 	if cfg.GenerateResourceTests {
 		if err := generateResourceTests(cfg); err != nil {
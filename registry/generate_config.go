@@ -6,6 +6,7 @@ import (
 
 	"github.com/shipq/shipq/codegen"
 	configpkg "github.com/shipq/shipq/codegen/httpserver/config"
+	"github.com/shipq/shipq/config"
 )
 
 // GenerateConfigEarly generates the config package before handler compilation.
@@ -33,16 +34,18 @@ func GenerateConfigEarlyWithOptions(shipqRoot, goModRoot, dialect string, filesE
 
 // ConfigEarlyOptions holds all options for early config generation.
 type ConfigEarlyOptions struct {
-	ShipqRoot      string
-	GoModRoot      string
-	Dialect        string
-	FilesEnabled   bool
-	WorkersEnabled bool
-	OAuthGoogle    bool
-	OAuthGitHub    bool
-	EmailEnabled   bool
-	DevDefaults    configpkg.DevDefaults
-	CustomEnvVars  []configpkg.CustomEnvVar
+	ShipqRoot       string
+	GoModRoot       string
+	Dialect         string
+	FilesEnabled    bool
+	WorkersEnabled  bool
+	OAuthGoogle     bool
+	OAuthGitHub     bool
+	EmailEnabled    bool
+	DevDefaults     configpkg.DevDefaults
+	CustomEnvVars   []configpkg.CustomEnvVar
+	DatabaseTLSEnvs []string
+	DatabasePool    config.DatabasePoolConfig
 }
 
 // GenerateConfigEarlyWithFullOptions generates the config package with full
@@ -54,17 +57,19 @@ func GenerateConfigEarlyWithFullOptions(opts ConfigEarlyOptions) error {
 	}
 
 	cfg := CompileConfig{
-		ShipqRoot:      opts.ShipqRoot,
-		GoModRoot:      opts.GoModRoot,
-		ModulePath:     moduleInfo.FullImportPath(""),
-		DBDialect:      opts.Dialect,
-		FilesEnabled:   opts.FilesEnabled,
-		WorkersEnabled: opts.WorkersEnabled,
-		OAuthGoogle:    opts.OAuthGoogle,
-		OAuthGitHub:    opts.OAuthGitHub,
-		EmailEnabled:   opts.EmailEnabled,
-		DevDefaults:    opts.DevDefaults,
-		CustomEnvVars:  opts.CustomEnvVars,
+		ShipqRoot:       opts.ShipqRoot,
+		GoModRoot:       opts.GoModRoot,
+		ModulePath:      moduleInfo.FullImportPath(""),
+		DBDialect:       opts.Dialect,
+		FilesEnabled:    opts.FilesEnabled,
+		WorkersEnabled:  opts.WorkersEnabled,
+		OAuthGoogle:     opts.OAuthGoogle,
+		OAuthGitHub:     opts.OAuthGitHub,
+		EmailEnabled:    opts.EmailEnabled,
+		DevDefaults:     opts.DevDefaults,
+		CustomEnvVars:   opts.CustomEnvVars,
+		DatabaseTLSEnvs: opts.DatabaseTLSEnvs,
+		DatabasePool:    opts.DatabasePool,
 	}
 
 	return generateConfig(cfg)
@@ -73,15 +78,18 @@ func GenerateConfigEarlyWithFullOptions(opts ConfigEarlyOptions) error {
 // generateConfig generates the config package files (config.go and config_test.go).
 func generateConfig(cfg CompileConfig) error {
 	configCfg := configpkg.ConfigGenConfig{
-		ModulePath:     cfg.ModulePath,
-		Dialect:        cfg.DBDialect,
-		FilesEnabled:   cfg.FilesEnabled,
-		WorkersEnabled: cfg.WorkersEnabled,
-		OAuthGoogle:    cfg.OAuthGoogle,
-		OAuthGitHub:    cfg.OAuthGitHub,
-		EmailEnabled:   cfg.EmailEnabled,
-		DevDefaults:    cfg.DevDefaults,
-		CustomEnvVars:  cfg.CustomEnvVars,
+		ModulePath:      cfg.ModulePath,
+		Dialect:         cfg.DBDialect,
+		FilesEnabled:    cfg.FilesEnabled,
+		WorkersEnabled:  cfg.WorkersEnabled,
+		OAuthGoogle:     cfg.OAuthGoogle,
+		OAuthGitHub:     cfg.OAuthGitHub,
+		EmailEnabled:    cfg.EmailEnabled,
+		DevDefaults:     cfg.DevDefaults,
+		CustomEnvVars:   cfg.CustomEnvVars,
+		DatabaseTLSEnvs: cfg.DatabaseTLSEnvs,
+		GRPCEnabled:     cfg.GRPC,
+		DatabasePool:    cfg.DatabasePool,
 	}
 
 	// Generate config.go
@@ -0,0 +1,38 @@
+package registry
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/shipq/shipq/codegen"
+	"github.com/shipq/shipq/codegen/grpcgen"
+)
+
+// generateGRPC generates the .proto file describing the handlers and the
+// NewGRPCServer adapter that serves it ([server] grpc = true).
+func generateGRPC(cfg CompileConfig) error {
+	files, err := grpcgen.GenerateGRPC(grpcgen.GRPCGenConfig{
+		ModulePath:  cfg.ModulePath,
+		OutputPkg:   cfg.OutputPkg,
+		Handlers:    cfg.Handlers,
+		StripPrefix: cfg.StripPrefix,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate gRPC service: %w", err)
+	}
+
+	for _, f := range files {
+		outputPath := filepath.Join(cfg.ShipqRoot, f.RelPath)
+		if err := codegen.EnsureDir(filepath.Dir(outputPath)); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", f.RelPath, err)
+		}
+		written, err := codegen.WriteFileIfChanged(outputPath, f.Content)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.RelPath, err)
+		}
+		if cfg.Verbose && written {
+			fmt.Printf("Generated %s\n", outputPath)
+		}
+	}
+	return nil
+}
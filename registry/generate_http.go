@@ -1,7 +1,12 @@
 package registry
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/shipq/shipq/codegen"
@@ -13,6 +18,11 @@ import (
 // OpenAPI documentation routes in the generated server.
 // adminHTML is optional; when non-empty it enables admin panel routes.
 func generateHTTPServer(cfg CompileConfig, openAPISpec, openAPIDocsHTML, adminHTML string) error {
+	schemaHash, migrations, err := readSchemaMeta(cfg.ShipqRoot)
+	if err != nil {
+		return err
+	}
+
 	// Generate HTTP server
 	httpCfg := server.HTTPServerGenConfig{
 		ModulePath:      cfg.ModulePath,
@@ -22,9 +32,19 @@ func generateHTTPServer(cfg CompileConfig, openAPISpec, openAPIDocsHTML, adminHT
 		OpenAPIDocsHTML: openAPIDocsHTML,
 		AdminHTML:       adminHTML,
 		ScopeColumn:     cfg.ScopeColumn,
+		ScopeHeader:     cfg.ScopeHeader,
 		HasChannels:     cfg.WorkersEnabled && len(cfg.Channels) > 0,
 		HasOAuth:        cfg.OAuthGoogle || cfg.OAuthGitHub,
 		StripPrefix:     cfg.StripPrefix,
+		Serializers:     cfg.Serializers,
+		AccessLog:       cfg.AccessLog,
+		ShipqVersion:    codegen.ShipqVersion(),
+		SchemaHash:      schemaHash,
+		Migrations:      migrations,
+		NoRecover:       cfg.NoRecover,
+		TxPerRequest:    cfg.TxPerRequest,
+		Telemetry:       cfg.Telemetry,
+		BearerAuth:      cfg.BearerAuth,
 	}
 
 	files, err := server.GenerateHTTPServer(httpCfg)
@@ -51,5 +71,82 @@ func generateHTTPServer(cfg CompileConfig, openAPISpec, openAPIDocsHTML, adminHT
 		}
 	}
 
+	if !cfg.NoRecover {
+		if err := writePanicReporterHook(cfg); err != nil {
+			return err
+		}
+	}
+	if cfg.Telemetry {
+		if err := writeUserHook(cfg, server.TelemetryHookFile, server.GenerateTelemetryHook(cfg.OutputPkg)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkBearerAuthPackage returns an error when [server] auth = bearer but
+// api/auth was generated before it was set: its GetCurrentSession reads the
+// session cookie alone and would reject every bearer token. A project
+// without an auth package passes.
+func checkBearerAuthPackage(shipqRoot string) error {
+	helpers, err := os.ReadFile(filepath.Join(shipqRoot, "api", "auth", "helpers.go"))
+	if err != nil {
+		return nil
+	}
+	if !bytes.Contains(helpers, []byte("httputil.BearerTokenFromContext")) {
+		return fmt.Errorf("[server] auth = bearer: api/auth was generated without bearer token support; run `shipq auth` (and `shipq signup` or `shipq email` if you used them) to regenerate it")
+	}
+	return nil
+}
+
+// writePanicReporterHook creates the user-owned file where the generated
+// PanicReporter hook is set, unless it already exists.
+func writePanicReporterHook(cfg CompileConfig) error {
+	return writeUserHook(cfg, server.PanicReporterHookFile, server.GeneratePanicReporterHook(cfg.OutputPkg))
+}
+
+// writeUserHook creates the user-owned file name in the api package with
+// content, unless it already exists.
+func writeUserHook(cfg CompileConfig, name string, content []byte) error {
+	hookPath := filepath.Join(cfg.ShipqRoot, cfg.OutputPkg, name)
+	if _, err := os.Stat(hookPath); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(hookPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", hookPath, err)
+	}
+	if cfg.Verbose {
+		fmt.Printf("Generated %s\n", hookPath)
+	}
 	return nil
 }
+
+// readSchemaMeta returns the SHA-256 of shipq/db/migrate/schema.json and the
+// names of its migrations, oldest first, as reported by GET /__meta. Both
+// are empty when the project has no schema.json yet.
+func readSchemaMeta(shipqRoot string) (hash string, migrations []string, err error) {
+	schemaPath := filepath.Join(shipqRoot, "shipq", "db", "migrate", "schema.json")
+	data, err := os.ReadFile(schemaPath)
+	if os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read %s: %w", schemaPath, err)
+	}
+
+	var plan struct {
+		Migrations []struct {
+			Name string `json:"name"`
+		} `json:"migrations"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s: %w", schemaPath, err)
+	}
+	for _, m := range plan.Migrations {
+		migrations = append(migrations, m.Name)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), migrations, nil
+}
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen/httpserver/server"
+	"github.com/shipq/shipq/codegen/openapigen"
+	"github.com/shipq/shipq/config"
+)
+
+func TestReadSchemaMeta(t *testing.T) {
+	root := t.TempDir()
+
+	hash, migrations, err := readSchemaMeta(root)
+	if err != nil || hash != "" || migrations != nil {
+		t.Fatalf("readSchemaMeta() without schema.json = %q, %q, %v; want empty", hash, migrations, err)
+	}
+
+	dir := filepath.Join(root, "shipq", "db", "migrate")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"schema":{},"migrations":[{"name":"20260101000000_create_users"},{"name":"20260102000000_add_posts"}]}`)
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, migrations, err = readSchemaMeta(root)
+	if err != nil {
+		t.Fatalf("readSchemaMeta() error = %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("hash = %q, want sha256 of schema.json", hash)
+	}
+	if want := []string{"20260101000000_create_users", "20260102000000_add_posts"}; !slices.Equal(migrations, want) {
+		t.Errorf("migrations = %q, want %q", migrations, want)
+	}
+}
+
+func TestWritePanicReporterHook(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CompileConfig{ShipqRoot: root, OutputPkg: "api"}
+	hookPath := filepath.Join(root, "api", "panic_reporter.go")
+
+	if err := writePanicReporterHook(cfg); err != nil {
+		t.Fatalf("writePanicReporterHook() error = %v", err)
+	}
+	data, err := os.ReadFile(hookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "package api\n") || !strings.Contains(string(data), "PanicReporter = ") {
+		t.Errorf("unexpected hook file:\n%s", data)
+	}
+
+	// The file belongs to the user once created.
+	edited := []byte("package api\n\n// mine\n")
+	if err := os.WriteFile(hookPath, edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePanicReporterHook(cfg); err != nil {
+		t.Fatalf("writePanicReporterHook() error = %v", err)
+	}
+	if data, _ := os.ReadFile(hookPath); string(data) != string(edited) {
+		t.Errorf("an existing hook file must not be overwritten, got:\n%s", data)
+	}
+}
+
+func TestWriteUserHook_Telemetry(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CompileConfig{ShipqRoot: root, OutputPkg: "api", Telemetry: true}
+	if err := writeUserHook(cfg, server.TelemetryHookFile, server.GenerateTelemetryHook(cfg.OutputPkg)); err != nil {
+		t.Fatalf("writeUserHook() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "api", "telemetry.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "package api\n") || !strings.Contains(string(data), "otel.SetTracerProvider(") {
+		t.Errorf("unexpected hook file:\n%s", data)
+	}
+}
+
+func TestCheckBearerAuthPackage(t *testing.T) {
+	root := t.TempDir()
+	if err := checkBearerAuthPackage(root); err != nil {
+		t.Fatalf("a project without api/auth should pass, got %v", err)
+	}
+
+	authDir := filepath.Join(root, "api", "auth")
+	if err := os.MkdirAll(authDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	helpersPath := filepath.Join(authDir, "helpers.go")
+	if err := os.WriteFile(helpersPath, []byte("package auth\n\n// reads the session cookie\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkBearerAuthPackage(root); err == nil || !strings.Contains(err.Error(), "shipq auth") {
+		t.Errorf("a cookie-only auth package should ask for shipq auth, got %v", err)
+	}
+
+	if err := os.WriteFile(helpersPath, []byte("package auth\n\n// token, ok := httputil.BearerTokenFromContext(ctx)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkBearerAuthPackage(root); err != nil {
+		t.Errorf("a bearer auth package should pass, got %v", err)
+	}
+}
+
+func TestGenerateOpenAPICompatTest(t *testing.T) {
+	root := t.TempDir()
+	cfg := CompileConfig{
+		ShipqRoot:  root,
+		ModulePath: "example.com/app",
+		OutputDir:  "api",
+		OutputPkg:  "api",
+		OpenAPI:    &config.OpenAPIConfig{Baseline: filepath.Join("openapi", "released.json")},
+	}
+	testPath := filepath.Join(root, "api", "zz_generated_openapi_compat_test.go")
+
+	if err := generateOpenAPICompatTest(cfg); err != nil {
+		t.Fatalf("generateOpenAPICompatTest() error = %v", err)
+	}
+	data, err := os.ReadFile(testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `const openAPIBaseline = "../openapi/released.json"`) {
+		t.Errorf("baseline path should be relative to the package:\n%s", data)
+	}
+
+	// Dropping the baseline removes the generated test.
+	cfg.OpenAPI.Baseline = ""
+	if err := generateOpenAPICompatTest(cfg); err != nil {
+		t.Fatalf("generateOpenAPICompatTest() error = %v", err)
+	}
+	if _, err := os.Stat(testPath); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, stat err = %v", testPath, err)
+	}
+}
+
+func TestDocsOptions(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "shipq", "db", "migrate")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"schema":{"tables":{"patients":{"name":"patients","columns":[` +
+		`{"name":"ssn","type":"string","sensitive":true},{"name":"name","type":"string"}]}}}}`)
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := docsOptions(CompileConfig{ShipqRoot: root})
+	if err != nil || opts.MaskFields != nil || opts.ReadOnly {
+		t.Fatalf("without [openapi] got %+v, %v; want no masking", opts, err)
+	}
+
+	cfg := CompileConfig{ShipqRoot: root, OpenAPI: &config.OpenAPIConfig{
+		DocsMask:       true,
+		DocsMaskFields: []string{"phone"},
+		DocsReadOnly:   true,
+	}}
+	opts, err = docsOptions(cfg)
+	if err != nil {
+		t.Fatalf("docsOptions() error = %v", err)
+	}
+	if !opts.ReadOnly {
+		t.Error("ReadOnly should follow docs_read_only")
+	}
+	// Token fields first, then docs_mask_fields, then the sensitive columns.
+	want := append(slices.Clone(openapigen.DocsTokenFields), "phone", "ssn")
+	if !slices.Equal(opts.MaskFields, want) {
+		t.Errorf("MaskFields = %v, want %v", opts.MaskFields, want)
+	}
+}
@@ -2,12 +2,20 @@ package registry
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/shipq/shipq/codegen"
 	"github.com/shipq/shipq/codegen/httpserver/server"
 )
 
+// hasSchemaJSON reports whether `shipq migrate up` has written the
+// migration plan the generated migrate package embeds.
+func hasSchemaJSON(shipqRoot string) bool {
+	_, err := os.Stat(filepath.Join(shipqRoot, "shipq", "db", "migrate", "schema.json"))
+	return err == nil
+}
+
 // generateHTTPMain generates the main.go entrypoint file for the HTTP server.
 func generateHTTPMain(cfg CompileConfig) error {
 	// Determine if any channel requires auth (i.e., is not public)
@@ -27,6 +35,11 @@ func generateHTTPMain(cfg CompileConfig) error {
 		HasAuth:     cfg.HasAuth && channelsNeedAuth,
 		AutoMigrate: cfg.AutoMigrate,
 		StripPrefix: cfg.StripPrefix,
+		AccessLog:   cfg.AccessLog != nil,
+		MigrationUI: hasSchemaJSON(cfg.ShipqRoot),
+		NoRecover:   cfg.NoRecover,
+		GRPC:        cfg.GRPC,
+		Telemetry:   cfg.Telemetry,
 	}
 
 	mainCode, err := server.GenerateHTTPMain(mainCfg)
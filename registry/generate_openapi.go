@@ -1,9 +1,15 @@
 package registry
 
 import (
+	"fmt"
+	"os"
 	"path"
+	"path/filepath"
+	"slices"
 
 	"github.com/shipq/shipq/codegen/openapigen"
+	"github.com/shipq/shipq/db/portsql/ddl"
+	"github.com/shipq/shipq/db/portsql/migrate"
 )
 
 // openAPIData holds the generated OpenAPI spec and docs HTML for passing
@@ -19,11 +25,19 @@ type openAPIData struct {
 func generateOpenAPI(cfg CompileConfig) (openAPIData, error) {
 	title := path.Base(cfg.ModulePath)
 
+	customTypes, err := readCustomTypes(cfg.ShipqRoot)
+	if err != nil {
+		return openAPIData{}, err
+	}
+
 	specCfg := openapigen.OpenAPIGenConfig{
 		ModulePath:  cfg.ModulePath,
 		Handlers:    cfg.Handlers,
 		Title:       title,
 		StripPrefix: cfg.StripPrefix,
+		OpenAPI:     cfg.OpenAPI,
+		CustomTypes: customTypes,
+		Naming:      cfg.Naming,
 	}
 
 	specJSON, err := openapigen.GenerateOpenAPISpec(specCfg)
@@ -31,10 +45,68 @@ func generateOpenAPI(cfg CompileConfig) (openAPIData, error) {
 		return openAPIData{}, err
 	}
 
-	docsHTML := openapigen.GenerateDocsHTML(title+" - API Documentation", cfg.StripPrefix)
+	docsOpts, err := docsOptions(cfg)
+	if err != nil {
+		return openAPIData{}, err
+	}
+	docsHTML := openapigen.GenerateDocsHTML(title+" - API Documentation", cfg.StripPrefix, docsOpts)
 
 	return openAPIData{
 		SpecJSON: string(specJSON),
 		DocsHTML: docsHTML,
 	}, nil
 }
+
+// docsOptions returns the docs page options set in [openapi]. With
+// docs_mask, the page masks auth tokens, docs_mask_fields and every column
+// marked Sensitive in shipq/db/migrate/schema.json.
+func docsOptions(cfg CompileConfig) (openapigen.DocsOptions, error) {
+	if cfg.OpenAPI == nil {
+		return openapigen.DocsOptions{}, nil
+	}
+	opts := openapigen.DocsOptions{ReadOnly: cfg.OpenAPI.DocsReadOnly}
+	if !cfg.OpenAPI.DocsMask {
+		return opts, nil
+	}
+	opts.MaskFields = append(slices.Clone(openapigen.DocsTokenFields), cfg.OpenAPI.DocsMaskFields...)
+
+	schemaPath := filepath.Join(cfg.ShipqRoot, "shipq", "db", "migrate", "schema.json")
+	data, err := os.ReadFile(schemaPath)
+	if os.IsNotExist(err) {
+		return opts, nil
+	}
+	if err != nil {
+		return opts, fmt.Errorf("failed to read %s: %w", schemaPath, err)
+	}
+	plan, err := migrate.PlanFromJSON(data)
+	if err != nil {
+		return opts, fmt.Errorf("failed to parse %s: %w", schemaPath, err)
+	}
+	for _, table := range plan.Schema.Tables {
+		for _, col := range table.Columns {
+			if col.Sensitive {
+				opts.MaskFields = append(opts.MaskFields, col.Name)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// readCustomTypes returns the custom column types used by the schema in
+// shipq/db/migrate/schema.json, or nil when the project has no schema.json
+// yet.
+func readCustomTypes(shipqRoot string) (map[string]ddl.CustomType, error) {
+	schemaPath := filepath.Join(shipqRoot, "shipq", "db", "migrate", "schema.json")
+	data, err := os.ReadFile(schemaPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", schemaPath, err)
+	}
+	plan, err := migrate.PlanFromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", schemaPath, err)
+	}
+	return ddl.CustomTypes(plan.Schema.Tables), nil
+}
@@ -52,3 +52,47 @@ func generateOpenAPITest(cfg CompileConfig) error {
 
 	return nil
 }
+
+// generateOpenAPICompatTest writes the OpenAPI backward-compatibility test
+// when [openapi] baseline is set, and removes a previously generated one
+// when it is not.
+func generateOpenAPICompatTest(cfg CompileConfig) error {
+	outputDir := filepath.Join(cfg.ShipqRoot, cfg.OutputDir)
+	testOutputPath := filepath.Join(outputDir, "zz_generated_openapi_compat_test.go")
+
+	if cfg.OpenAPI == nil || cfg.OpenAPI.Baseline == "" {
+		if _, err := codegen.RemoveGeneratedFile(testOutputPath); err != nil {
+			return fmt.Errorf("failed to remove OpenAPI compat test: %w", err)
+		}
+		return nil
+	}
+
+	baselinePath, err := filepath.Rel(outputDir, filepath.Join(cfg.ShipqRoot, cfg.OpenAPI.Baseline))
+	if err != nil {
+		return fmt.Errorf("failed to resolve OpenAPI baseline: %w", err)
+	}
+
+	testCode, err := openapigen.GenerateOpenAPICompatTest(openapigen.OpenAPICompatTestGenConfig{
+		ModulePath:   cfg.ModulePath,
+		OutputPkg:    cfg.OutputPkg,
+		BaselinePath: filepath.ToSlash(baselinePath),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate OpenAPI compat test: %w", err)
+	}
+
+	if err := codegen.EnsureDir(outputDir); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	written, err := codegen.WriteFileIfChanged(testOutputPath, testCode)
+	if err != nil {
+		return fmt.Errorf("failed to write OpenAPI compat test: %w", err)
+	}
+
+	if cfg.Verbose && written {
+		fmt.Printf("Generated %s\n", testOutputPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+// RoutesManifestFile is the path, relative to the shipq root, of the route
+// manifest written by every handler compile. It lets commands such as
+// `shipq routes` inspect the registry without rebuilding the compile program.
+const RoutesManifestFile = ".shipq/routes.json"
+
+// OpenAPIManifestFile is the path, relative to the shipq root, of the OpenAPI
+// document written by every handler compile. `shipq smoke` embeds it so the
+// smoke binary validates responses against the same spec the server serves.
+const OpenAPIManifestFile = ".shipq/openapi.json"
+
+// writeRoutesManifest records the compiled handler registry in
+// RoutesManifestFile.
+func writeRoutesManifest(cfg CompileConfig) error {
+	handlers := cfg.Handlers
+	if handlers == nil {
+		handlers = []codegen.SerializedHandlerInfo{}
+	}
+	data, err := json.MarshalIndent(handlers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal routes manifest: %w", err)
+	}
+
+	manifestPath := filepath.Join(cfg.ShipqRoot, RoutesManifestFile)
+	if err := codegen.EnsureDir(filepath.Dir(manifestPath)); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(manifestPath), err)
+	}
+	if _, err := codegen.WriteFileIfChanged(manifestPath, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write routes manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadRoutesManifest loads the handler registry recorded by the last
+// `shipq handler compile` in shipqRoot.
+func ReadRoutesManifest(shipqRoot string) ([]codegen.SerializedHandlerInfo, error) {
+	manifestPath := filepath.Join(shipqRoot, RoutesManifestFile)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found; run 'shipq handler compile' first", RoutesManifestFile)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", RoutesManifestFile, err)
+	}
+
+	var handlers []codegen.SerializedHandlerInfo
+	if err := json.Unmarshal(data, &handlers); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RoutesManifestFile, err)
+	}
+	return handlers, nil
+}
+
+// writeOpenAPIManifest records the compiled OpenAPI document in
+// OpenAPIManifestFile.
+func writeOpenAPIManifest(shipqRoot, specJSON string) error {
+	manifestPath := filepath.Join(shipqRoot, OpenAPIManifestFile)
+	if err := codegen.EnsureDir(filepath.Dir(manifestPath)); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(manifestPath), err)
+	}
+	if _, err := codegen.WriteFileIfChanged(manifestPath, []byte(specJSON+"\n")); err != nil {
+		return fmt.Errorf("failed to write OpenAPI manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadOpenAPIManifest loads the OpenAPI document recorded by the last
+// `shipq handler compile` in shipqRoot.
+func ReadOpenAPIManifest(shipqRoot string) (string, error) {
+	manifestPath := filepath.Join(shipqRoot, OpenAPIManifestFile)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s not found; run 'shipq handler compile' first", OpenAPIManifestFile)
+		}
+		return "", fmt.Errorf("failed to read %s: %w", OpenAPIManifestFile, err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
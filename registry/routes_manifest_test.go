@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipq/shipq/codegen"
+)
+
+func TestRoutesManifest_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+	cfg := CompileConfig{
+		ShipqRoot: root,
+		Handlers: []codegen.SerializedHandlerInfo{
+			{Method: "GET", Path: "/posts", FuncName: "ListPosts", PackagePath: "myapp/api/posts"},
+			{Method: "DELETE", Path: "/posts/:id", FuncName: "SoftDeletePost", PackagePath: "myapp/api/posts", Deprecated: true, Sunset: "2027-01-31"},
+		},
+	}
+
+	if err := writeRoutesManifest(cfg); err != nil {
+		t.Fatalf("writeRoutesManifest() error = %v", err)
+	}
+
+	got, err := ReadRoutesManifest(root)
+	if err != nil {
+		t.Fatalf("ReadRoutesManifest() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 handlers, got %d", len(got))
+	}
+	if got[0].Deprecated {
+		t.Error("ListPosts should not be deprecated")
+	}
+	if !got[1].Deprecated || got[1].Sunset != "2027-01-31" {
+		t.Errorf("SoftDeletePost deprecation not preserved: %+v", got[1])
+	}
+}
+
+func TestReadRoutesManifest_Missing(t *testing.T) {
+	_, err := ReadRoutesManifest(t.TempDir())
+	if err == nil {
+		t.Fatal("expected error when manifest is missing")
+	}
+	if !strings.Contains(err.Error(), "shipq handler compile") {
+		t.Errorf("error should tell the user to run handler compile, got: %v", err)
+	}
+}
+
+func TestOpenAPIManifest_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+	spec := `{"openapi": "3.1.0"}`
+
+	if err := writeOpenAPIManifest(root, spec); err != nil {
+		t.Fatalf("writeOpenAPIManifest() error = %v", err)
+	}
+	got, err := ReadOpenAPIManifest(root)
+	if err != nil {
+		t.Fatalf("ReadOpenAPIManifest() error = %v", err)
+	}
+	if got != spec {
+		t.Errorf("ReadOpenAPIManifest() = %q, want %q", got, spec)
+	}
+
+	if _, err := ReadOpenAPIManifest(t.TempDir()); err == nil || !strings.Contains(err.Error(), "shipq handler compile") {
+		t.Errorf("expected missing-manifest error mentioning handler compile, got %v", err)
+	}
+}
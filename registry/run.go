@@ -1,8 +1,10 @@
 package registry
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -13,6 +15,8 @@ import (
 	"github.com/shipq/shipq/codegen/embed"
 	"github.com/shipq/shipq/codegen/handlercompile"
 	configpkg "github.com/shipq/shipq/codegen/httpserver/config"
+	"github.com/shipq/shipq/codegen/phase"
+	"github.com/shipq/shipq/config"
 	"github.com/shipq/shipq/db/portsql/codegen/queryrunner"
 	"github.com/shipq/shipq/dburl"
 	"github.com/shipq/shipq/inifile"
@@ -54,6 +58,7 @@ func Run(shipqRoot, goModRoot string) error {
 
 	// Read feature flags from shipq.ini
 	scopeColumn := ""
+	scopeHeader := ""
 	filesEnabled := false
 	workersEnabled := false
 	hasAuth := false
@@ -61,12 +66,28 @@ func Run(shipqRoot, goModRoot string) error {
 	oauthGitHub := false
 	var devDefaults configpkg.DevDefaults
 	var customEnvVars []configpkg.CustomEnvVar
+	var databaseTLSEnvs []string
+	var databasePool config.DatabasePoolConfig
 	tsFrameworks := []string{"react"}
 	tsHTTPOutput := ""
 	tsChannelOutput := ""
 	stripPrefix := ""
+	var serializers []string
+	strictHandlers := false
+	noRecover := false
+	txPerRequest := false
+	grpcEnabled := false
+	telemetryEnabled := false
+	bearerAuth := false
+	var accessLog *config.LoggingConfig
+	var openAPI *config.OpenAPIConfig
+	var naming *config.NamingConfig
 	if ini, err := inifile.ParseFile(shipqIniPath); err == nil {
 		scopeColumn = ini.Get("db", "scope")
+		scopeHeader = strings.TrimSpace(ini.Get("db", "scope_header"))
+		if scopeHeader != "" && scopeColumn == "" {
+			return fmt.Errorf("[db] scope_header = %s requires [db] scope", scopeHeader)
+		}
 		if ini.Section("files") != nil {
 			filesEnabled = true
 		}
@@ -91,6 +112,11 @@ func Run(shipqRoot, goModRoot string) error {
 		}
 
 		customEnvVars = ParseCustomEnvVars(ini)
+		databaseTLSEnvs = config.ParseDatabaseTLSEnvs(ini)
+		databasePool, err = config.ParseDatabasePoolConfig(ini)
+		if err != nil {
+			return err
+		}
 
 		tsFrameworks = ParseFrameworks(ini.Get("typescript", "framework"))
 		if o := ini.Get("typescript", "http_output"); o != "" {
@@ -103,6 +129,41 @@ func Run(shipqRoot, goModRoot string) error {
 		if sp := ini.Get("server", "strip_prefix"); sp != "" {
 			stripPrefix = strings.TrimRight(strings.TrimSpace(sp), "/")
 		}
+		serializers, err = config.ParseSerializers(ini)
+		if err != nil {
+			return err
+		}
+		strictHandlers = strings.ToLower(ini.Get("server", "strict_handlers")) == "true"
+		noRecover = strings.ToLower(ini.Get("server", "recover_panics")) == "false"
+		txPerRequest = strings.ToLower(ini.Get("server", "tx_per_request")) == "true"
+		grpcEnabled = strings.ToLower(ini.Get("server", "grpc")) == "true"
+		telemetryEnabled, err = config.ParseTelemetry(ini)
+		if err != nil {
+			return err
+		}
+		authScheme, err := config.ParseAuthScheme(ini)
+		if err != nil {
+			return err
+		}
+		bearerAuth = authScheme == "bearer"
+		if bearerAuth {
+			if err := checkBearerAuthPackage(shipqRoot); err != nil {
+				return err
+			}
+		}
+
+		accessLog, err = config.ParseLoggingConfig(ini)
+		if err != nil {
+			return err
+		}
+		openAPI, err = config.ParseOpenAPIConfig(ini)
+		if err != nil {
+			return err
+		}
+		naming, err = config.ParseNamingConfig(ini)
+		if err != nil {
+			return err
+		}
 	}
 
 	// ── Bootstrap: ensure all imported packages exist ────────────────
@@ -110,11 +171,12 @@ func Run(shipqRoot, goModRoot string) error {
 	// generated server code imports shipq/lib/httpserver, shipq/queries,
 	// config, etc. We must ensure these packages exist on disk BEFORE
 	// building the compile program or generating server code.
-	if err := bootstrapPackages(shipqRoot, importPrefix, dialect, filesEnabled, workersEnabled); err != nil {
+	if err := bootstrapPackages(shipqRoot, importPrefix, dialect, filesEnabled, workersEnabled, grpcEnabled, telemetryEnabled); err != nil {
 		return fmt.Errorf("failed to bootstrap packages: %w", err)
 	}
 
 	// ── Discover and compile handlers ────────────────────────────────
+	stopDiscovery := phase.Start(phase.Discovery)
 	apiPkgs, err := discovery.DiscoverAPIPackages(goModRoot, shipqRoot, moduleInfo.ModulePath)
 	if err != nil {
 		return fmt.Errorf("failed to discover API packages: %w", err)
@@ -132,10 +194,17 @@ func Run(shipqRoot, goModRoot string) error {
 	}
 
 	handlers, err := handlercompile.BuildAndRunHandlerCompileProgram(goModRoot, cfg)
+	stopDiscovery()
 	if err != nil {
 		return fmt.Errorf("failed to compile handlers: %w", err)
 	}
 
+	if strictHandlers {
+		if err := checkAllHandlersRegistered(goModRoot, shipqRoot, moduleInfo.ModulePath, handlers); err != nil {
+			return err
+		}
+	}
+
 	// ── Read remaining config from shipq.ini ─────────────────────────
 	// Scope configuration (depends on handlers being known)
 	tableScopes := make(map[string]string)
@@ -174,7 +243,9 @@ func Run(shipqRoot, goModRoot string) error {
 	// Compile channel registry if workers are enabled
 	var channels []codegen.SerializedChannelInfo
 	if workersEnabled {
+		stopDiscovery := phase.Start(phase.Discovery)
 		compiledChannels, compErr := channelcompile.BuildAndRunChannelCompileProgram(goModRoot, shipqRoot, moduleInfo)
+		stopDiscovery()
 		if compErr != nil {
 			return fmt.Errorf("failed to compile channels: %w", compErr)
 		}
@@ -191,6 +262,7 @@ func Run(shipqRoot, goModRoot string) error {
 		DatabaseURL:     databaseURL,
 		TableScopes:     tableScopes,
 		ScopeColumn:     scopeColumn,
+		ScopeHeader:     scopeHeader,
 		AutoMigrate:     autoMigrate,
 		FilesEnabled:    filesEnabled,
 		WorkersEnabled:  workersEnabled,
@@ -200,12 +272,24 @@ func Run(shipqRoot, goModRoot string) error {
 		Channels:        channels,
 		DevDefaults:     devDefaults,
 		CustomEnvVars:   customEnvVars,
+		DatabaseTLSEnvs: databaseTLSEnvs,
+		DatabasePool:    databasePool,
 		StripPrefix:     stripPrefix,
+		Serializers:     serializers,
+		AccessLog:       accessLog,
+		NoRecover:       noRecover,
+		TxPerRequest:    txPerRequest,
+		GRPC:            grpcEnabled,
+		Telemetry:       telemetryEnabled,
+		BearerAuth:      bearerAuth,
+		OpenAPI:         openAPI,
+		Naming:          naming,
 		TSFrameworks:    tsFrameworks,
 		TSHTTPOutput:    tsHTTPOutput,
 		TSChannelOutput: tsChannelOutput,
 	}
 
+	defer phase.Start(phase.Codegen)()
 	return CompileRegistry(compileCfg)
 }
 
@@ -218,14 +302,16 @@ func Run(shipqRoot, goModRoot string) error {
 //  1. Embedded library packages (shipq/lib/*) — via embed.EmbedAllPackages
 //  2. Database helper package (shipq/db/db.go) — via dbpkg.EnsureDBPackage
 //  3. Query runner stubs (shipq/queries/) — minimal Runner interface + QueryRunner
-func bootstrapPackages(shipqRoot, importPrefix, dialect string, filesEnabled, workersEnabled bool) error {
+func bootstrapPackages(shipqRoot, importPrefix, dialect string, filesEnabled, workersEnabled, grpcEnabled, telemetryEnabled bool) error {
 	// 1. Embed library packages (handler, httpserver, httputil, logging, etc.)
 	// The handler compile program imports shipq/lib/handler, and the generated
 	// HTTP server code imports shipq/lib/httpserver, shipq/lib/logging, etc.
 	embedOpts := embed.EmbedOptions{
-		FilesEnabled:   filesEnabled,
-		WorkersEnabled: workersEnabled,
-		DBDialect:      dialect,
+		FilesEnabled:     filesEnabled,
+		WorkersEnabled:   workersEnabled,
+		GRPCEnabled:      grpcEnabled,
+		TelemetryEnabled: telemetryEnabled,
+		DBDialect:        dialect,
 	}
 	if err := embed.EmbedAllPackages(shipqRoot, importPrefix, embedOpts); err != nil {
 		return fmt.Errorf("failed to embed library packages: %w", err)
@@ -250,7 +336,7 @@ func bootstrapPackages(shipqRoot, importPrefix, dialect string, filesEnabled, wo
 	if dialect != "" {
 		typesPath := filepath.Join(shipqRoot, "shipq", "queries", "types.go")
 		if _, err := os.Stat(typesPath); os.IsNotExist(err) {
-			if err := bootstrapQueryPackages(shipqRoot, importPrefix, dialect); err != nil {
+			if err := bootstrapQueryPackages(shipqRoot, importPrefix, dialect, telemetryEnabled); err != nil {
 				return err
 			}
 		}
@@ -267,11 +353,12 @@ func bootstrapPackages(shipqRoot, importPrefix, dialect string, filesEnabled, wo
 //
 // When `db compile` runs later (after `migrate up`), it regenerates these
 // files with real query methods, fully overwriting the stubs.
-func bootstrapQueryPackages(shipqRoot, importPrefix, dialect string) error {
+func bootstrapQueryPackages(shipqRoot, importPrefix, dialect string, telemetry bool) error {
 	runnerCfg := queryrunner.UnifiedRunnerConfig{
 		ModulePath:  importPrefix,
 		Dialect:     dialect,
 		UserQueries: nil, // no queries yet
+		Telemetry:   telemetry,
 	}
 
 	// Generate types.go (Runner interface, TxRunner, context helpers)
@@ -339,6 +426,7 @@ func devDefaultsFromIni(ini *inifile.File, filesEnabled, workersEnabled bool) co
 	d := configpkg.DevDefaults{
 		DatabaseURL:  ini.Get("db", "database_url"),
 		Port:         "8080",
+		GRPCPort:     "9090",
 		CookieSecret: ini.Get("auth", "cookie_secret"),
 	}
 
@@ -362,3 +450,28 @@ func devDefaultsFromIni(ini *inifile.File, filesEnabled, workersEnabled bool) co
 
 	return d
 }
+
+// checkAllHandlersRegistered implements [server] strict_handlers: it fails
+// when an exported handler-shaped function under api/ is missing from the
+// compiled handler manifest, listing every such function.
+func checkAllHandlersRegistered(goModRoot, shipqRoot, modulePath string, handlers []codegen.SerializedHandlerInfo) error {
+	registered := make(map[string]bool, len(handlers))
+	for _, h := range handlers {
+		registered[h.PackagePath+"."+h.FuncName] = true
+	}
+	missing, err := discovery.FindUnregisteredHandlers(goModRoot, shipqRoot, modulePath, registered)
+	if err != nil {
+		return fmt.Errorf("strict handler discovery failed: %w", err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "strict_handlers: %d handler function(s) under api/ are not registered:\n", len(missing))
+	for _, m := range missing {
+		fmt.Fprintf(&b, "  %s: %s.%s\n", m.Pos, path.Base(m.Package), m.Func)
+	}
+	fmt.Fprintf(&b, "route them in the package's Register function, or mark intentional helpers with %s", discovery.NoRouteDirective)
+	return errors.New(b.String())
+}
@@ -0,0 +1,143 @@
+// Package telemetry traces the generated API with OpenTelemetry. Handler
+// starts a span for every HTTP request and StartQuery one for every query
+// runner call, so a request's span holds the spans of the queries it ran.
+// Spans go to the global TracerProvider and propagate through the global
+// TextMapPropagator; the application installs both (otel.SetTracerProvider,
+// otel.SetTextMapPropagator) with the exporter of its choice. Until it does,
+// the spans are no-ops.
+// This package is embedded into user projects via the shipq embed system,
+// landing at {modulePath}/shipq/lib/telemetry.
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of the spans.
+const TracerName = "github.com/shipq/shipq/telemetry"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Handler wraps next so that each request runs in a server span, continuing
+// the trace of the request's traceparent header when it has one. The span
+// is named after the method and the matched route pattern, e.g.
+// "GET /posts/{id}", once next has served the request; it records the
+// route and the response status, and is marked failed for a 5xx. next
+// should be the *http.ServeMux (possibly behind middleware that passes the
+// request on as is), since the mux sets the request's Pattern.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(sw, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Method + " " + routeOf(r.Pattern))
+			span.SetAttributes(attribute.String("http.route", routeOf(r.Pattern)))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+		if sw.status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+	})
+}
+
+// routeOf returns the path of a ServeMux pattern such as
+// "GET example.com/posts/{id}".
+func routeOf(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		return pattern[i:]
+	}
+	return pattern
+}
+
+// statusWriter records the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Query is the span of one query runner call.
+type Query struct {
+	span trace.Span
+}
+
+// StartQuery starts the span of a call to the runner method name against a
+// dialect database ("postgres", "mysql", "sqlite" or "mssql"). The returned
+// context carries the span; pass it to the call and End the Query after.
+func StartQuery(ctx context.Context, name, dialect string) (context.Context, Query) {
+	ctx, span := tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", dbSystem(dialect)),
+			attribute.String("db.operation.name", name),
+		),
+	)
+	return ctx, Query{span: span}
+}
+
+// End ends the span, recording the rows the call returned or changed and
+// its error. rows < 0 means the call has no row count.
+func (q Query) End(rows int, err error) {
+	if rows >= 0 {
+		q.span.SetAttributes(attribute.Int("db.response.returned_rows", rows))
+	}
+	if err != nil {
+		q.span.RecordError(err)
+		q.span.SetStatus(codes.Error, err.Error())
+	}
+	q.span.End()
+}
+
+// dbSystem returns the OpenTelemetry db.system.name of a shipq dialect.
+func dbSystem(dialect string) string {
+	switch dialect {
+	case "postgres":
+		return "postgresql"
+	case "mssql":
+		return "microsoft.sql_server"
+	case "mysql", "sqlite":
+		return dialect
+	default:
+		return "other_sql"
+	}
+}
@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a TracerProvider recording ended spans for the
+// duration of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+// attr returns the value of the attribute key of span.
+func attr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestHandler(t *testing.T) {
+	rec := recordSpans(t)
+
+	var queryParent trace.SpanContext
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ctx, q := StartQuery(r.Context(), "GetPost", "postgres")
+		queryParent = trace.SpanContextFromContext(ctx)
+		q.End(1, nil)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /posts", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	h := Handler(mux)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/abc", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/posts", nil))
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	query, get, post := spans[0], spans[1], spans[2]
+
+	if get.Name() != "GET /posts/{id}" || get.SpanKind() != trace.SpanKindServer {
+		t.Errorf("request span = %q (%v), want GET /posts/{id} (server)", get.Name(), get.SpanKind())
+	}
+	if got := attr(get, "http.route").AsString(); got != "/posts/{id}" {
+		t.Errorf("http.route = %q", got)
+	}
+	if got := attr(get, "http.response.status_code").AsInt64(); got != 404 {
+		t.Errorf("status code = %d, want 404", got)
+	}
+	if get.Status().Code == codes.Error {
+		t.Error("a 404 should not mark the span failed")
+	}
+	if query.Parent().SpanID() != get.SpanContext().SpanID() || !queryParent.IsValid() {
+		t.Error("query span should be a child of the request span")
+	}
+
+	if got := attr(post, "http.response.status_code").AsInt64(); got != 500 {
+		t.Errorf("status code = %d, want 500", got)
+	}
+	if post.Status().Code != codes.Error {
+		t.Error("a 500 should mark the span failed")
+	}
+}
+
+func TestHandler_ContinuesTrace(t *testing.T) {
+	rec := recordSpans(t)
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+	span := rec.Ended()[0]
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the traceparent's", got)
+	}
+	if span.Name() != http.MethodGet {
+		t.Errorf("unmatched request span = %q, want the bare method", span.Name())
+	}
+}
+
+func TestStartQuery(t *testing.T) {
+	rec := recordSpans(t)
+
+	_, q := StartQuery(context.Background(), "ListPosts", "mssql")
+	q.End(3, nil)
+	_, q = StartQuery(context.Background(), "RefreshStats", "sqlite")
+	q.End(-1, errors.New("no such table"))
+
+	spans := rec.Ended()
+	list, refresh := spans[0], spans[1]
+	if list.Name() != "ListPosts" || list.SpanKind() != trace.SpanKindClient {
+		t.Errorf("query span = %q (%v)", list.Name(), list.SpanKind())
+	}
+	for key, want := range map[string]any{
+		"db.system.name":            "microsoft.sql_server",
+		"db.operation.name":         "ListPosts",
+		"db.response.returned_rows": int64(3),
+	} {
+		if got := attr(list, key).AsInterface(); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+
+	if attr(refresh, "db.response.returned_rows").Type() != attribute.INVALID {
+		t.Error("a negative row count should not be recorded")
+	}
+	if refresh.Status().Code != codes.Error || len(refresh.Events()) != 1 {
+		t.Errorf("a failed query should record its error, got status %v and %d events", refresh.Status(), len(refresh.Events()))
+	}
+}
@@ -0,0 +1,152 @@
+// Package validate provides the request validation used by generated CRUD
+// handlers. This package is embedded into user projects via the shipq embed
+// system, landing at {modulePath}/shipq/lib/validate.
+//
+// An Errors value collects every failed check of a request so that the
+// client learns about all invalid fields at once:
+//
+//	var v validate.Errors
+//	v.Required("title", req.Title)
+//	v.MaxLength("title", req.Title, 200)
+//	v.Email("contact_email", req.ContactEmail)
+//	if err := v.Err(); err != nil {
+//		return nil, err
+//	}
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shipq/shipq/httperror"
+)
+
+// Errors collects field validation failures. The zero value is ready to use.
+type Errors struct {
+	fields   []string
+	messages []string
+}
+
+// Add records that field failed validation with message, e.g.
+// Add("title", "must not be empty").
+func (e *Errors) Add(field, message string) {
+	e.fields = append(e.fields, field)
+	e.messages = append(e.messages, field+" "+message)
+}
+
+// Fields returns the fields that failed validation, in the order they were
+// checked. A field that failed several checks is listed once.
+func (e *Errors) Fields() []string {
+	var fields []string
+	seen := make(map[string]bool, len(e.fields))
+	for _, f := range e.fields {
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Err returns nil when every check passed. Otherwise it returns a 422
+// *httperror.Error whose message lists every failure and whose fields name
+// the invalid request fields, which httputil.WriteError renders as
+// {"error": "...", "fields": [...]}.
+func (e *Errors) Err() error {
+	if len(e.messages) == 0 {
+		return nil
+	}
+	return httperror.UnprocessableEntity(strings.Join(e.messages, "; ")).WithFields(e.Fields()...)
+}
+
+// Required checks that value is not empty.
+func (e *Errors) Required(field, value string) {
+	if value == "" {
+		e.Add(field, "is required")
+	}
+}
+
+// MaxLength checks that value has at most max characters. Lengths are
+// counted in characters, as VARCHAR(n) columns count them, not in bytes.
+func (e *Errors) MaxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		e.Add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+// Email checks that value is a bare email address such as
+// "ada@example.com". An empty value passes; use Required to reject it.
+func (e *Errors) Email(field, value string) {
+	if value == "" {
+		return
+	}
+	if !IsEmail(value) {
+		e.Add(field, "must be a valid email address")
+	}
+}
+
+// IsEmail reports whether s is a bare email address, without a display name
+// or angle brackets.
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && strings.Contains(s[strings.LastIndex(s, "@")+1:], ".")
+}
+
+// OneOf checks that value is one of values, as enum columns require. An
+// empty value passes; use Required to reject it.
+func (e *Errors) OneOf(field, value string, values ...string) {
+	if value == "" || slices.Contains(values, value) {
+		return
+	}
+	e.Add(field, "must be one of "+strings.Join(values, ", "))
+}
+
+// Range checks that min <= value <= max.
+func (e *Errors) Range(field string, value, min, max float64) {
+	if value < min || value > max {
+		e.Add(field, fmt.Sprintf("must be between %v and %v", min, max))
+	}
+}
+
+// Decimal checks that value is a plain decimal number (an optional sign,
+// digits and an optional fractional part, no exponent) that fits a
+// DECIMAL(precision, scale) column, i.e. has at most precision-scale digits
+// before the decimal point. A precision of 0 only checks the format. Extra
+// fractional digits are rounded by the database and are accepted. An empty
+// value passes; use Required to reject it.
+func (e *Errors) Decimal(field, value string, precision, scale int) {
+	if value == "" {
+		return
+	}
+	intDigits, ok := decimalIntDigits(value)
+	if !ok {
+		e.Add(field, "must be a decimal number")
+		return
+	}
+	if max := precision - scale; precision > 0 && intDigits > max {
+		e.Add(field, fmt.Sprintf("must have at most %d digits before the decimal point", max))
+	}
+}
+
+// decimalIntDigits returns the number of significant digits before the
+// decimal point of s, or ok=false when s is not a plain decimal number.
+func decimalIntDigits(s string) (digits int, ok bool) {
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" && (!hasPoint || fracPart == "") {
+		return 0, false
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, false
+			}
+		}
+	}
+	return len(strings.TrimLeft(intPart, "0")), true
+}
@@ -0,0 +1,136 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shipq/shipq/httperror"
+)
+
+func TestErrors_NoFailures(t *testing.T) {
+	var v Errors
+	v.Required("title", "Hello")
+	v.MaxLength("title", "Hello", 5)
+	v.Email("email", "ada@example.com")
+	v.Email("backup_email", "")
+	v.Range("rating", 3, 1, 5)
+	v.Decimal("price", "123.45", 5, 2)
+	if err := v.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+}
+
+func TestErrors_Err(t *testing.T) {
+	var v Errors
+	v.Required("title", "")
+	v.MaxLength("title", "toolong", 3)
+	v.Email("email", "not-an-email")
+
+	err := v.Err()
+	var httpErr *httperror.Error
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Err() = %v, want *httperror.Error", err)
+	}
+	if httpErr.Code() != 422 {
+		t.Errorf("Code() = %d, want 422", httpErr.Code())
+	}
+	wantMsg := "title is required; title must be at most 3 characters; email must be a valid email address"
+	if httpErr.Message() != wantMsg {
+		t.Errorf("Message() = %q, want %q", httpErr.Message(), wantMsg)
+	}
+	if want := []string{"title", "email"}; !reflect.DeepEqual(httpErr.Fields(), want) {
+		t.Errorf("Fields() = %v, want %v", httpErr.Fields(), want)
+	}
+}
+
+func TestMaxLength_CountsCharacters(t *testing.T) {
+	var v Errors
+	v.MaxLength("name", "héllo", 5)
+	if err := v.Err(); err != nil {
+		t.Errorf("5 characters should fit max 5: %v", err)
+	}
+	v.MaxLength("name", "héllo!", 5)
+	if err := v.Err(); err == nil {
+		t.Error("6 characters should not fit max 5")
+	}
+}
+
+func TestIsEmail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"ada@example.com", true},
+		{"ada.lovelace+tag@mail.example.org", true},
+		{"ada", false},
+		{"ada@", false},
+		{"ada@localhost", false},
+		{"Ada <ada@example.com>", false},
+		{" ada@example.com", false},
+	}
+	for _, tt := range tests {
+		if got := IsEmail(tt.in); got != tt.want {
+			t.Errorf("IsEmail(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	var v Errors
+	v.Range("rating", 0, 1, 5)
+	v.Range("rating", 6, 1, 5)
+	if got := len(v.messages); got != 2 {
+		t.Errorf("got %d failures, want 2", got)
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	var v Errors
+	v.OneOf("status", "active", "pending", "active")
+	v.OneOf("status", "", "pending", "active")
+	if err := v.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	v.OneOf("status", "deleted", "pending", "active")
+	if err := v.Err(); err == nil || err.Error() != "status must be one of pending, active" {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		ok   bool
+		desc string
+	}{
+		{"123.45", true, "fits"},
+		{"-999.99", true, "negative fits"},
+		{"+1", true, "explicit sign"},
+		{"0001.5", true, "leading zeros are not significant"},
+		{".5", true, "no integer part"},
+		{"5.", true, "no fractional part"},
+		{"1.23456", true, "extra fractional digits are rounded"},
+		{"1000", false, "too many integer digits"},
+		{"1e3", false, "exponent"},
+		{"abc", false, "not a number"},
+		{".", false, "bare point"},
+		{"-", false, "bare sign"},
+		{"--1", false, "double sign"},
+		{"1.2.3", false, "two points"},
+	}
+	for _, tt := range tests {
+		var v Errors
+		v.Decimal("price", tt.in, 5, 2)
+		if got := v.Err() == nil; got != tt.ok {
+			t.Errorf("%s: Decimal(%q, 5, 2) ok = %v, want %v", tt.desc, tt.in, got, tt.ok)
+		}
+	}
+
+	var v Errors
+	v.Decimal("price", "12345678901234567890", 0, 0)
+	v.Decimal("price", "1e3", 0, 0)
+	if got := len(v.messages); got != 1 {
+		t.Errorf("precision 0 should only check the format, got %d failures", got)
+	}
+}